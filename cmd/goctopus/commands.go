@@ -0,0 +1,474 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// Capacity of the channel used to receive live book events
+const bookFeedChannelCapacity = 10
+
+// Maximum number of trades returned by one GetTradesHistory request
+const tradesHistoryPageSize = 50
+
+/*************************************************************************************************/
+/* COMMANDS                                                                                      */
+/*************************************************************************************************/
+
+// Display ticker information for the provided pairs.
+func (c *cli) ticker(ctx context.Context, args []string) error {
+	fs := c.newFlagSet("ticker", "ticker PAIR [PAIR...]")
+	pairs, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pairs) == 0 {
+		return newUsageError("at least one pair is required")
+	}
+	restPairs := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		restPairs = append(restPairs, restPair(pair))
+	}
+	resp, _, err := c.client.GetTickerInformation(ctx, &market.GetTickerInformationRequestOptions{Pairs: restPairs})
+	if err != nil {
+		return fmt.Errorf("get ticker information failed: %w", err)
+	}
+	if err := checkAPIErrors("get ticker information", &resp.KrakenSpotRESTResponse); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(resp.Result))
+	for name := range resp.Result {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PAIR\tBID\tASK\tLAST\tVOLUME 24H\tLOW 24H\tHIGH 24H")
+	for _, name := range names {
+		info := resp.Result[name]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			name, at(info.Bid, 0), at(info.Ask, 0), at(info.Close, 0), at(info.Volume, 1), at(info.Low, 1), at(info.High, 1))
+	}
+	return w.Flush()
+}
+
+// Display the order book of a pair. With -follow, the top levels of the book are streamed with
+// the websocket API until the context is cancelled.
+func (c *cli) book(ctx context.Context, args []string) error {
+	fs := c.newFlagSet("book", "book PAIR [-depth N] [-follow]")
+	depth := fs.Int("depth", 10, "Number of levels to display. Must be 10, 25, 100, 500 or 1000 with -follow")
+	follow := fs.Bool("follow", false, "Stream the top levels of the book with the websocket API")
+	pairs, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pairs) != 1 {
+		return newUsageError("exactly one pair is required")
+	}
+	if *depth < 1 {
+		return newUsageError("depth must be strictly positive")
+	}
+	if *follow {
+		return c.followBook(ctx, pairs[0], *depth)
+	}
+	resp, _, err := c.client.GetOrderBook(
+		ctx,
+		market.GetOrderBookRequestParameters{Pair: restPair(pairs[0])},
+		&market.GetOrderBookRequestOptions{Count: *depth})
+	if err != nil {
+		return fmt.Errorf("get order book failed: %w", err)
+	}
+	if err := checkAPIErrors("get order book", &resp.KrakenSpotRESTResponse); err != nil {
+		return err
+	}
+	if resp.Result == nil {
+		return fmt.Errorf("get order book failed: empty result")
+	}
+	asks := make([][2]string, 0, len(resp.Result.Asks))
+	for _, entry := range resp.Result.Asks {
+		asks = append(asks, [2]string{entry.Price, entry.Volume})
+	}
+	bids := make([][2]string, 0, len(resp.Result.Bids))
+	for _, entry := range resp.Result.Bids {
+		bids = append(bids, [2]string{entry.Price, entry.Volume})
+	}
+	return printBook(c.out, resp.Result.PairId, asks, bids)
+}
+
+// Stream the top levels of the book of a pair until the context is cancelled.
+func (c *cli) followBook(ctx context.Context, pair string, depth int) error {
+	switch messages.DepthEnum(depth) {
+	case messages.D10, messages.D25, messages.D100, messages.D500, messages.D1000:
+	default:
+		return newUsageError(fmt.Sprintf("depth must be 10, 25, 100, 500 or 1000 with -follow. Got %d", depth))
+	}
+	rcv := make(chan event.Event, bookFeedChannelCapacity)
+	stop, err := c.newBookFeed(ctx, pair, messages.DepthEnum(depth), rcv)
+	if err != nil {
+		return fmt.Errorf("follow book failed: %w", err)
+	}
+	defer func() {
+		// Drain events published while the feed stops
+		done := make(chan struct{})
+		go func() {
+			for {
+				select {
+				case <-rcv:
+				case <-done:
+					return
+				}
+			}
+		}()
+		stop()
+		close(done)
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-rcv:
+			if !ok {
+				return nil
+			}
+			switch e.Type() {
+			case string(events.BookTop):
+				top := new(websocket.BookTop)
+				if err := e.DataAs(top); err != nil {
+					return fmt.Errorf("follow book failed: %w", err)
+				}
+				asks := make([][2]string, 0, len(top.Asks))
+				for _, entry := range top.Asks {
+					asks = append(asks, [2]string{entry.Price.String(), entry.Volume.String()})
+				}
+				bids := make([][2]string, 0, len(top.Bids))
+				for _, entry := range top.Bids {
+					bids = append(bids, [2]string{entry.Price.String(), entry.Volume.String()})
+				}
+				fmt.Fprintf(c.out, "--- %s ---\n", time.Now().Format(time.RFC3339))
+				if err := printBook(c.out, top.Pair, asks, bids); err != nil {
+					return err
+				}
+			case string(events.ConnectionInterrupted):
+				fmt.Fprintln(c.errOut, "connection interrupted, waiting for reconnection...")
+			}
+		}
+	}
+}
+
+// Add an order. The order is only validated unless -validate=false is used.
+func (c *cli) addOrder(ctx context.Context, args []string) error {
+	fs := c.newFlagSet("add-order", "add-order -pair PAIR -side buy|sell -volume VOLUME [-type TYPE] [-price PRICE] [-validate=false]")
+	pair := fs.String("pair", "", "Pair (ex: XBT/USD)")
+	side := fs.String("side", "", "Order side: buy or sell")
+	orderType := fs.String("type", string(trading.Limit), "Order type (market, limit, stop-loss, ...)")
+	volume := fs.String("volume", "", "Order volume in base currency")
+	price := fs.String("price", "", "Order price. Required for limit orders")
+	validate := fs.Bool("validate", true, "Only validate the order. Use -validate=false to place the order")
+	extra, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(extra) > 0 {
+		return newUsageError(fmt.Sprintf("unexpected arguments: %v", extra))
+	}
+	if *pair == "" || *volume == "" {
+		return newUsageError("pair and volume are required")
+	}
+	if *side != string(trading.Buy) && *side != string(trading.Sell) {
+		return newUsageError(fmt.Sprintf("side must be buy or sell. Got %q", *side))
+	}
+	if *orderType == string(trading.Limit) && *price == "" {
+		return newUsageError("price is required for limit orders")
+	}
+	if err := c.requireCredentials(); err != nil {
+		return err
+	}
+	resp, _, err := c.client.AddOrder(
+		ctx,
+		c.nonceGenerator.GenerateNonce(),
+		trading.AddOrderRequestParameters{
+			Pair: restPair(*pair),
+			Order: trading.Order{
+				OrderType: *orderType,
+				Type:      *side,
+				Volume:    *volume,
+				Price:     *price,
+			},
+		},
+		&trading.AddOrderRequestOptions{Validate: *validate},
+		c.secopts)
+	if err != nil {
+		return fmt.Errorf("add order failed: %w", err)
+	}
+	if err := checkAPIErrors("add order", &resp.KrakenSpotRESTResponse); err != nil {
+		return err
+	}
+	if resp.Result == nil {
+		return fmt.Errorf("add order failed: empty result")
+	}
+	if *validate {
+		fmt.Fprintf(c.out, "order validated (not placed): %s\n", resp.Result.Description.Order)
+		return nil
+	}
+	fmt.Fprintf(c.out, "order placed: %s\n", resp.Result.Description.Order)
+	for _, txid := range resp.Result.TransactionIDs {
+		fmt.Fprintf(c.out, "txid: %s\n", txid)
+	}
+	return nil
+}
+
+// Display account balances.
+func (c *cli) balances(ctx context.Context, args []string) error {
+	fs := c.newFlagSet("balances", "balances")
+	extra, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(extra) > 0 {
+		return newUsageError(fmt.Sprintf("unexpected arguments: %v", extra))
+	}
+	if err := c.requireCredentials(); err != nil {
+		return err
+	}
+	resp, _, err := c.client.GetAccountBalance(ctx, c.nonceGenerator.GenerateNonce(), c.secopts)
+	if err != nil {
+		return fmt.Errorf("get account balance failed: %w", err)
+	}
+	if err := checkAPIErrors("get account balance", &resp.KrakenSpotRESTResponse); err != nil {
+		return err
+	}
+	assets := make([]string, 0, len(resp.Result))
+	for asset := range resp.Result {
+		assets = append(assets, asset)
+	}
+	sort.Strings(assets)
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ASSET\tBALANCE")
+	for _, asset := range assets {
+		fmt.Fprintf(w, "%s\t%s\n", asset, resp.Result[asset].String())
+	}
+	return w.Flush()
+}
+
+// Export data. Only trades history export is supported.
+func (c *cli) export(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "trades" {
+		return newUsageError("usage: goctopus export trades [-start START] [-end END] [-output FILE]")
+	}
+	fs := c.newFlagSet("export trades", "export trades [-start START] [-end END] [-output FILE]")
+	start := fs.String("start", "", "Start unix timestamp or trade ID (exclusive)")
+	end := fs.String("end", "", "End unix timestamp or trade ID (inclusive)")
+	output := fs.String("output", "", "Output file. Standard output is used if empty")
+	extra, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if len(extra) > 0 {
+		return newUsageError(fmt.Sprintf("unexpected arguments: %v", extra))
+	}
+	if err := c.requireCredentials(); err != nil {
+		return err
+	}
+	// Fetch all pages
+	trades := map[string]*account.TradeInfo{}
+	for offset := int64(0); ; {
+		resp, _, err := c.client.GetTradesHistory(
+			ctx,
+			c.nonceGenerator.GenerateNonce(),
+			&account.GetTradesHistoryRequestOptions{Start: *start, End: *end, Offset: offset},
+			c.secopts)
+		if err != nil {
+			return fmt.Errorf("get trades history failed: %w", err)
+		}
+		if err := checkAPIErrors("get trades history", &resp.KrakenSpotRESTResponse); err != nil {
+			return err
+		}
+		if resp.Result == nil || len(resp.Result.Trades) == 0 {
+			break
+		}
+		for id, trade := range resp.Result.Trades {
+			trades[id] = trade
+		}
+		offset += int64(len(resp.Result.Trades))
+		if offset >= int64(resp.Result.Count) || len(resp.Result.Trades) < tradesHistoryPageSize {
+			break
+		}
+	}
+	// Write CSV
+	out := c.out
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("export trades failed: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := writeTradesCSV(out, trades); err != nil {
+		return fmt.Errorf("export trades failed: %w", err)
+	}
+	if *output != "" {
+		fmt.Fprintf(c.errOut, "%d trades exported to %s\n", len(trades), *output)
+	}
+	return nil
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Error used when command line arguments are invalid.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string { return e.msg }
+
+// Create a new usage error.
+func newUsageError(msg string) error {
+	return &usageError{msg: msg}
+}
+
+// Return true if the error is a usage error.
+func isUsageError(err error) bool {
+	uerr := new(usageError)
+	return errors.As(err, &uerr)
+}
+
+// Create a new flag set for a command.
+func (c *cli) newFlagSet(name string, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.errOut)
+	fs.Usage = func() {
+		fmt.Fprintf(c.errOut, "Usage: goctopus %s\n", synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// Return an error if API credentials have not been provided.
+func (c *cli) requireCredentials() error {
+	if !c.hasCredentials {
+		return fmt.Errorf("API credentials are required: set %s and %s", envAPIKey, envAPISecret)
+	}
+	return nil
+}
+
+// # Description
+//
+// Parse flags and positional arguments. Unlike flag.FlagSet.Parse, flags can be provided after
+// positional arguments (ex: book XBT/USD -depth 25).
+//
+// # Return
+//
+// The positional arguments or an error if flags could not be parsed. flag.ErrHelp is returned if
+// help has been requested.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	positionals := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return nil, err
+			}
+			return nil, newUsageError(err.Error())
+		}
+		if fs.NArg() == 0 {
+			return positionals, nil
+		}
+		positionals = append(positionals, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// Convert a pair from the websocket format (XBT/USD) to the format used by the REST API (XBTUSD).
+func restPair(pair string) string {
+	return strings.ReplaceAll(pair, "/", "")
+}
+
+// Return the value at the provided index or an empty string if the index is out of range.
+func at(values []string, index int) string {
+	if index < len(values) {
+		return values[index]
+	}
+	return ""
+}
+
+// Return an error if the API response contains errors.
+func checkAPIErrors(operation string, resp *common.KrakenSpotRESTResponse) error {
+	if len(resp.Error) > 0 {
+		return fmt.Errorf("%s failed: %s", operation, strings.Join(resp.Error, ", "))
+	}
+	return nil
+}
+
+// Print a book: asks by descending price followed by bids by descending price. Asks and bids must
+// be provided from the best level to the worst one as (price, volume) couples.
+func printBook(out io.Writer, pair string, asks [][2]string, bids [][2]string) error {
+	fmt.Fprintln(out, pair)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "SIDE\tPRICE\tVOLUME\t")
+	for i := len(asks) - 1; i >= 0; i-- {
+		fmt.Fprintf(w, "ask\t%s\t%s\t\n", asks[i][0], asks[i][1])
+	}
+	for _, bid := range bids {
+		fmt.Fprintf(w, "bid\t%s\t%s\t\n", bid[0], bid[1])
+	}
+	return w.Flush()
+}
+
+// Write trades as CSV, ordered by time.
+func writeTradesCSV(out io.Writer, trades map[string]*account.TradeInfo) error {
+	ids := make([]string, 0, len(trades))
+	for id := range trades {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		ti, _ := trades[ids[i]].Timestamp.Float64()
+		tj, _ := trades[ids[j]].Timestamp.Float64()
+		if ti == tj {
+			return ids[i] < ids[j]
+		}
+		return ti < tj
+	})
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"txid", "ordertxid", "pair", "time", "type", "ordertype", "price", "cost", "fee", "vol"}); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		trade := trades[id]
+		record := []string{
+			id,
+			trade.OrderTransactionId,
+			trade.Pair,
+			trade.Timestamp.String(),
+			trade.Type,
+			trade.OrderType,
+			trade.Price.String(),
+			trade.Cost.String(),
+			trade.Fee.String(),
+			trade.Volume.String(),
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
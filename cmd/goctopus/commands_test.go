@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the cli commands
+type CommandsUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestCommandsUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(CommandsUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the ticker command.
+func (suite *CommandsUnitTestSuite) TestTicker() {
+	client := rest.NewMockKrakenSpotRESTClient()
+	client.On("GetTickerInformation", mock.Anything, &market.GetTickerInformationRequestOptions{Pairs: []string{"XBTUSD"}}).
+		Return(rest.NewMockGetTickerInformationResponse(map[string]*market.AssetTickerInfo{
+			"XXBTZUSD": {
+				Ask:    []string{"30300.10000", "1", "1.000"},
+				Bid:    []string{"30300.00000", "1", "1.000"},
+				Close:  []string{"30303.20000", "0.00067643"},
+				Volume: []string{"4083.67001100", "4412.73601799"},
+				Low:    []string{"29200.00000", "29200.00000"},
+				High:   []string{"30700.00000", "30700.00000"},
+			},
+		}), nil, nil)
+	c, out, _ := newTestCLI(client, false)
+	require.Equal(suite.T(), 0, c.run(context.Background(), []string{"ticker", "XBT/USD"}))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(suite.T(), lines, 2)
+	require.Equal(suite.T(), []string{"XXBTZUSD", "30300.00000", "30300.10000", "30303.20000", "4412.73601799", "29200.00000", "30700.00000"}, strings.Fields(lines[1]))
+}
+
+// Test the ticker command when the API returns an error.
+func (suite *CommandsUnitTestSuite) TestTickerAPIError() {
+	client := rest.NewMockKrakenSpotRESTClient()
+	client.On("GetTickerInformation", mock.Anything, mock.Anything).
+		Return(&market.GetTickerInformationResponse{
+			KrakenSpotRESTResponse: *rest.NewMockKrakenSpotRESTErrorResponse("EQuery:Unknown asset pair"),
+		}, nil, nil)
+	c, _, errOut := newTestCLI(client, false)
+	require.Equal(suite.T(), 1, c.run(context.Background(), []string{"ticker", "FOO/BAR"}))
+	require.Contains(suite.T(), errOut.String(), "EQuery:Unknown asset pair")
+}
+
+// Test the book command with the REST API.
+func (suite *CommandsUnitTestSuite) TestBook() {
+	client := rest.NewMockKrakenSpotRESTClient()
+	client.On("GetOrderBook", mock.Anything, market.GetOrderBookRequestParameters{Pair: "XBTUSD"}, &market.GetOrderBookRequestOptions{Count: 2}).
+		Return(&market.GetOrderBookResponse{
+			KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+			Result: &market.OrderBook{
+				PairId: "XXBTZUSD",
+				Asks:   []market.OrderBookEntry{{Price: "101", Volume: "1"}, {Price: "102", Volume: "2"}},
+				Bids:   []market.OrderBookEntry{{Price: "100", Volume: "3"}, {Price: "99", Volume: "4"}},
+			},
+		}, nil, nil)
+	c, out, _ := newTestCLI(client, false)
+	// Flags can be provided after the pair
+	require.Equal(suite.T(), 0, c.run(context.Background(), []string{"book", "XBT/USD", "-depth", "2"}))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(suite.T(), lines, 6)
+	require.Equal(suite.T(), []string{"ask", "102", "2"}, strings.Fields(lines[2]))
+	require.Equal(suite.T(), []string{"ask", "101", "1"}, strings.Fields(lines[3]))
+	require.Equal(suite.T(), []string{"bid", "100", "3"}, strings.Fields(lines[4]))
+	require.Equal(suite.T(), []string{"bid", "99", "4"}, strings.Fields(lines[5]))
+	// Invalid inputs
+	require.Equal(suite.T(), 2, c.run(context.Background(), []string{"book"}))
+	require.Equal(suite.T(), 2, c.run(context.Background(), []string{"book", "XBT/USD", "-depth", "0"}))
+	require.Equal(suite.T(), 2, c.run(context.Background(), []string{"book", "XBT/USD", "-depth", "42", "-follow"}))
+}
+
+// Test the book command with -follow.
+//
+// Test will ensure:
+//   - The live feed is started with the provided pair and depth.
+//   - book_top events are printed.
+//   - The command exits and stops the feed when the feed channel is closed.
+func (suite *CommandsUnitTestSuite) TestBookFollow() {
+	c, out, errOut := newTestCLI(rest.NewMockKrakenSpotRESTClient(), false)
+	stopped := false
+	c.newBookFeed = func(ctx context.Context, pair string, depth messages.DepthEnum, rcv chan event.Event) (func(), error) {
+		require.Equal(suite.T(), "XBT/USD", pair)
+		require.Equal(suite.T(), messages.D25, depth)
+		interrupted := event.New()
+		interrupted.SetType(string(events.ConnectionInterrupted))
+		top := event.New()
+		top.SetType(string(events.BookTop))
+		require.NoError(suite.T(), top.SetData("application/json", &websocket.BookTop{
+			Pair: "XBT/USD",
+			Asks: []messages.BookMessageEntry{{Price: json.Number("101"), Volume: json.Number("1")}},
+			Bids: []messages.BookMessageEntry{{Price: json.Number("100"), Volume: json.Number("2")}},
+		}))
+		rcv <- top
+		rcv <- interrupted
+		close(rcv)
+		return func() { stopped = true }, nil
+	}
+	require.Equal(suite.T(), 0, c.run(context.Background(), []string{"book", "XBT/USD", "-depth", "25", "-follow"}))
+	require.True(suite.T(), stopped)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(suite.T(), lines, 5)
+	require.Equal(suite.T(), "XBT/USD", lines[1])
+	require.Equal(suite.T(), []string{"ask", "101", "1"}, strings.Fields(lines[3]))
+	require.Equal(suite.T(), []string{"bid", "100", "2"}, strings.Fields(lines[4]))
+	require.Contains(suite.T(), errOut.String(), "connection interrupted")
+	// Feed error
+	c.newBookFeed = func(ctx context.Context, pair string, depth messages.DepthEnum, rcv chan event.Event) (func(), error) {
+		return nil, fmt.Errorf("connection refused")
+	}
+	require.Equal(suite.T(), 1, c.run(context.Background(), []string{"book", "XBT/USD", "-follow"}))
+}
+
+// Test the add-order command.
+//
+// Test will ensure:
+//   - Orders are only validated by default.
+//   - Orders are placed with -validate=false and transaction IDs are printed.
+//   - Invalid inputs are rejected before any request is sent.
+func (suite *CommandsUnitTestSuite) TestAddOrder() {
+	client := rest.NewMockKrakenSpotRESTClient()
+	params := trading.AddOrderRequestParameters{
+		Pair: "XBTUSD",
+		Order: trading.Order{
+			OrderType: string(trading.Limit),
+			Type:      string(trading.Buy),
+			Volume:    "0.01",
+			Price:     "25000",
+		},
+	}
+	result := &trading.AddOrderResponse{
+		KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+		Result: &trading.AddOrderResult{
+			Description:    trading.OrderDescription{Order: "buy 0.01000000 XBTUSD @ limit 25000.0"},
+			TransactionIDs: []string{"OUF4EM-FRGI2-MQMWZD"},
+		},
+	}
+	client.On("AddOrder", mock.Anything, mock.Anything, params, &trading.AddOrderRequestOptions{Validate: true}, mock.Anything).Return(result, nil, nil)
+	client.On("AddOrder", mock.Anything, mock.Anything, params, &trading.AddOrderRequestOptions{Validate: false}, mock.Anything).Return(result, nil, nil)
+	c, out, _ := newTestCLI(client, true)
+	args := []string{"add-order", "-pair", "XBT/USD", "-side", "buy", "-volume", "0.01", "-price", "25000"}
+	require.Equal(suite.T(), 0, c.run(context.Background(), args))
+	require.Contains(suite.T(), out.String(), "order validated (not placed)")
+	out.Reset()
+	require.Equal(suite.T(), 0, c.run(context.Background(), append(args, "-validate=false")))
+	require.Contains(suite.T(), out.String(), "order placed")
+	require.Contains(suite.T(), out.String(), "OUF4EM-FRGI2-MQMWZD")
+	client.AssertNumberOfCalls(suite.T(), "AddOrder", 2)
+	// Invalid inputs
+	require.Equal(suite.T(), 2, c.run(context.Background(), []string{"add-order", "-pair", "XBT/USD", "-side", "hold", "-volume", "1", "-price", "1"}))
+	require.Equal(suite.T(), 2, c.run(context.Background(), []string{"add-order", "-pair", "XBT/USD", "-side", "buy", "-volume", "1"}))
+	require.Equal(suite.T(), 2, c.run(context.Background(), []string{"add-order", "-side", "buy", "-volume", "1", "-price", "1"}))
+	client.AssertNumberOfCalls(suite.T(), "AddOrder", 2)
+}
+
+// Test the balances command.
+func (suite *CommandsUnitTestSuite) TestBalances() {
+	client := rest.NewMockKrakenSpotRESTClient()
+	client.On("GetAccountBalance", mock.Anything, mock.Anything, mock.Anything).
+		Return(rest.NewMockGetAccountBalanceResponse(map[string]string{"ZUSD": "100.0000", "XXBT": "1.2500"}), nil, nil)
+	c, out, _ := newTestCLI(client, true)
+	require.Equal(suite.T(), 0, c.run(context.Background(), []string{"balances"}))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(suite.T(), lines, 3)
+	require.Equal(suite.T(), []string{"XXBT", "1.2500"}, strings.Fields(lines[1]))
+	require.Equal(suite.T(), []string{"ZUSD", "100.0000"}, strings.Fields(lines[2]))
+}
+
+// Test the export trades command.
+//
+// Test will ensure:
+//   - All pages of the trades history are fetched.
+//   - Trades are written as CSV ordered by time.
+//   - Trades can be written to a file.
+func (suite *CommandsUnitTestSuite) TestExportTrades() {
+	client := rest.NewMockKrakenSpotRESTClient()
+	firstPage := map[string]*account.TradeInfo{}
+	for i := 0; i < tradesHistoryPageSize; i++ {
+		firstPage[fmt.Sprintf("T%03d", i)] = newTestTrade(1700000100 - int64(i))
+	}
+	client.On("GetTradesHistory", mock.Anything, mock.Anything, &account.GetTradesHistoryRequestOptions{Start: "1700000000", Offset: 0}, mock.Anything).
+		Return(newTestTradesHistoryResponse(firstPage, 51), nil, nil)
+	client.On("GetTradesHistory", mock.Anything, mock.Anything, &account.GetTradesHistoryRequestOptions{Start: "1700000000", Offset: 50}, mock.Anything).
+		Return(newTestTradesHistoryResponse(map[string]*account.TradeInfo{"T999": newTestTrade(1700000001)}, 51), nil, nil)
+	c, out, _ := newTestCLI(client, true)
+	require.Equal(suite.T(), 0, c.run(context.Background(), []string{"export", "trades", "-start", "1700000000"}))
+	client.AssertNumberOfCalls(suite.T(), "GetTradesHistory", 2)
+	records, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), records, 52)
+	require.Equal(suite.T(), "txid", records[0][0])
+	require.Equal(suite.T(), "T999", records[1][0])
+	require.Equal(suite.T(), "T049", records[2][0])
+	require.Equal(suite.T(), "T000", records[51][0])
+	// Export to file
+	path := filepath.Join(suite.T().TempDir(), "trades.csv")
+	out.Reset()
+	require.Equal(suite.T(), 0, c.run(context.Background(), []string{"export", "trades", "-start", "1700000000", "-output", path}))
+	require.Empty(suite.T(), out.String())
+	content, err := os.ReadFile(path)
+	require.NoError(suite.T(), err)
+	records, err = csv.NewReader(strings.NewReader(string(content))).ReadAll()
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), records, 52)
+	// Invalid inputs
+	require.Equal(suite.T(), 2, c.run(context.Background(), []string{"export"}))
+	require.Equal(suite.T(), 2, c.run(context.Background(), []string{"export", "ledgers"}))
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Create a trade executed at the provided time.
+func newTestTrade(timestamp int64) *account.TradeInfo {
+	return &account.TradeInfo{
+		OrderTransactionId: "OQCLML-BW3P3-BUCMWZ",
+		Pair:               "XXBTZUSD",
+		Timestamp:          json.Number(fmt.Sprintf("%d.0", timestamp)),
+		Type:               "buy",
+		OrderType:          "limit",
+		Price:              json.Number("30010.00000"),
+		Cost:               json.Number("600.20000"),
+		Fee:                json.Number("0.00000"),
+		Volume:             json.Number("0.02000000"),
+	}
+}
+
+// Create a GetTradesHistoryResponse with the provided trades.
+func newTestTradesHistoryResponse(trades map[string]*account.TradeInfo, count int) *account.GetTradesHistoryResponse {
+	return &account.GetTradesHistoryResponse{
+		KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+		Result:                 &account.GetTradesHistoryResult{Trades: trades, Count: count},
+	}
+}
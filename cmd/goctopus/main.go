@@ -0,0 +1,225 @@
+// Command goctopus is a command line tool to quickly interact with the Kraken spot exchange. It is
+// built on top of the purple-goctopus SDK and doubles as living documentation of the SDK.
+//
+// Usage:
+//
+//	goctopus ticker XBT/USD [ETH/USD...]
+//	goctopus book XBT/USD [-depth 25] [-follow]
+//	goctopus add-order -pair XBT/USD -side buy -type limit -volume 0.01 -price 25000 [-validate=false]
+//	goctopus balances
+//	goctopus export trades [-start 1700000000] [-end 1710000000] [-output trades.csv]
+//
+// Private commands (add-order, balances, export) require API credentials which are read from the
+// KRAKEN_API_KEY, KRAKEN_API_SECRET and KRAKEN_API_OTP (optional password second factor)
+// environment variables.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+/*************************************************************************************************/
+/* CONSTANTS                                                                                     */
+/*************************************************************************************************/
+
+// Environment variables used to get API credentials
+const (
+	// API key
+	envAPIKey = "KRAKEN_API_KEY"
+	// Base64 encoded API secret
+	envAPISecret = "KRAKEN_API_SECRET"
+	// Optional password second factor
+	envAPIOTP = "KRAKEN_API_OTP"
+)
+
+// Usage message
+const usage = `goctopus - quick interaction with the Kraken spot exchange
+
+Usage:
+
+  goctopus <command> [arguments]
+
+Commands:
+
+  ticker PAIR [PAIR...]     Display ticker information for the pairs
+  book PAIR                 Display the order book of a pair (-depth, -follow)
+  add-order                 Add an order (-pair, -side, -type, -volume, -price, -validate)
+  balances                  Display account balances
+  export trades             Export trades history as CSV (-start, -end, -output)
+
+Pairs use the websocket format (ex: XBT/USD).
+
+Private commands (add-order, balances, export) read API credentials from the KRAKEN_API_KEY,
+KRAKEN_API_SECRET and KRAKEN_API_OTP (optional password second factor) environment variables.
+
+Use "goctopus <command> -h" for help about a command.
+`
+
+/*************************************************************************************************/
+/* CLI                                                                                           */
+/*************************************************************************************************/
+
+// Function used to start a live feed of the top levels of the book of a pair. The feed publishes
+// book_top events on the provided channel and runs until the returned stop function is called.
+type bookFeedFactory func(ctx context.Context, pair string, depth messages.DepthEnum, rcv chan event.Event) (stop func(), err error)
+
+// Command line tool
+type cli struct {
+	// REST client used to interact with the API
+	client rest.KrakenSpotRESTClientIface
+	// Nonce generator used to sign private requests
+	nonceGenerator noncegen.NonceGenerator
+	// Security options used for private requests
+	secopts *common.SecurityOptions
+	// True if API credentials have been provided
+	hasCredentials bool
+	// Factory used to start live book feeds
+	newBookFeed bookFeedFactory
+	// Writer used for command output
+	out io.Writer
+	// Writer used for errors and usage messages
+	errOut io.Writer
+}
+
+// # Description
+//
+// Create a new cli. The REST client is authorized with the API credentials read from the
+// environment if they are set.
+//
+// # Inputs
+//
+//   - getenv: Function used to read environment variables.
+//   - out: Writer used for command output.
+//   - errOut: Writer used for errors and usage messages.
+//
+// # Return
+//
+// The new cli or an error if the API credentials are invalid.
+func newCLI(getenv func(string) string, out io.Writer, errOut io.Writer) (*cli, error) {
+	var authorizer rest.KrakenSpotRESTClientAuthorizerIface
+	key, secret := getenv(envAPIKey), getenv(envAPISecret)
+	if key != "" && secret != "" {
+		var err error
+		authorizer, err = rest.NewKrakenSpotRESTClientAuthorizer(key, secret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API credentials: %w", err)
+		}
+	}
+	var secopts *common.SecurityOptions
+	if otp := getenv(envAPIOTP); otp != "" {
+		secopts = &common.SecurityOptions{SecondFactor: otp}
+	}
+	return &cli{
+		client:         rest.NewKrakenSpotRESTClient(authorizer, nil),
+		nonceGenerator: noncegen.NewHFNonceGenerator(),
+		secopts:        secopts,
+		hasCredentials: authorizer != nil,
+		newBookFeed:    newWebsocketBookFeed,
+		out:            out,
+		errOut:         errOut,
+	}, nil
+}
+
+// # Description
+//
+// Run the command described by the provided arguments.
+//
+// # Inputs
+//
+//   - ctx: Context used for coordination purpose. Long running commands stop when it is cancelled.
+//   - args: Command line arguments without the program name.
+//
+// # Return
+//
+// The exit code: 0 on success, 1 if the command failed and 2 in case of usage error.
+func (c *cli) run(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(c.errOut, usage)
+		return 2
+	}
+	var err error
+	switch args[0] {
+	case "ticker":
+		err = c.ticker(ctx, args[1:])
+	case "book":
+		err = c.book(ctx, args[1:])
+	case "add-order":
+		err = c.addOrder(ctx, args[1:])
+	case "balances":
+		err = c.balances(ctx, args[1:])
+	case "export":
+		err = c.export(ctx, args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(c.out, usage)
+		return 0
+	default:
+		fmt.Fprintf(c.errOut, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+	switch {
+	case err == nil:
+		return 0
+	case err == flag.ErrHelp:
+		return 0
+	case isUsageError(err):
+		fmt.Fprintf(c.errOut, "error: %s\n", err.Error())
+		return 2
+	default:
+		fmt.Fprintf(c.errOut, "error: %s\n", err.Error())
+		return 1
+	}
+}
+
+/*************************************************************************************************/
+/* MAIN                                                                                          */
+/*************************************************************************************************/
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	c, err := newCLI(os.Getenv, os.Stdout, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		stop()
+		os.Exit(1)
+	}
+	code := c.run(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+/*************************************************************************************************/
+/* WEBSOCKET BOOK FEED                                                                           */
+/*************************************************************************************************/
+
+// Start a live feed of the top levels of the book of a pair with a public websocket client.
+func newWebsocketBookFeed(ctx context.Context, pair string, depth messages.DepthEnum, rcv chan event.Event) (func(), error) {
+	engine, client, err := websocket.NewDefaultEngineWithPublicWebsocketClient(nil, nil, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create websocket client: %w", err)
+	}
+	publicClient, ok := client.(*websocket.KrakenSpotPublicWebsocketClient)
+	if !ok {
+		return nil, fmt.Errorf("unexpected websocket client type %T", client)
+	}
+	if err := engine.Start(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect to websocket server: %w", err)
+	}
+	if err := publicClient.SubscribeBookTop(ctx, []string{pair}, depth, int(depth), 0, rcv); err != nil {
+		_ = engine.Stop(context.Background())
+		return nil, err
+	}
+	return func() { _ = engine.Stop(context.Background()) }, nil
+}
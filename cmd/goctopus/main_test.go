@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the cli
+type CLIUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestCLIUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(CLIUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the cli factory reads API credentials from the environment.
+func (suite *CLIUnitTestSuite) TestNewCLI() {
+	// No credentials
+	c, err := newCLI(func(string) string { return "" }, new(bytes.Buffer), new(bytes.Buffer))
+	require.NoError(suite.T(), err)
+	require.False(suite.T(), c.hasCredentials)
+	require.Nil(suite.T(), c.secopts)
+	// Valid credentials
+	env := map[string]string{
+		envAPIKey:    "key",
+		envAPISecret: "c2VjcmV0",
+		envAPIOTP:    "42",
+	}
+	c, err = newCLI(func(key string) string { return env[key] }, new(bytes.Buffer), new(bytes.Buffer))
+	require.NoError(suite.T(), err)
+	require.True(suite.T(), c.hasCredentials)
+	require.Equal(suite.T(), "42", c.secopts.SecondFactor)
+	// Invalid secret
+	env[envAPISecret] = "not base64!"
+	_, err = newCLI(func(key string) string { return env[key] }, new(bytes.Buffer), new(bytes.Buffer))
+	require.Error(suite.T(), err)
+}
+
+// Test command dispatch and exit codes.
+//
+// Test will ensure:
+//   - Usage is displayed and exit code is 2 when no command or an unknown command is provided.
+//   - Usage is displayed and exit code is 0 when help is requested.
+//   - Exit code is 1 when a private command is used without credentials.
+func (suite *CLIUnitTestSuite) TestRun() {
+	c, out, errOut := newTestCLI(rest.NewMockKrakenSpotRESTClient(), false)
+	require.Equal(suite.T(), 2, c.run(context.Background(), nil))
+	require.Contains(suite.T(), errOut.String(), "Commands:")
+	errOut.Reset()
+	require.Equal(suite.T(), 2, c.run(context.Background(), []string{"unknown"}))
+	require.Contains(suite.T(), errOut.String(), `unknown command "unknown"`)
+	require.Equal(suite.T(), 0, c.run(context.Background(), []string{"help"}))
+	require.Contains(suite.T(), out.String(), "Commands:")
+	require.Equal(suite.T(), 0, c.run(context.Background(), []string{"book", "-h"}))
+	errOut.Reset()
+	require.Equal(suite.T(), 1, c.run(context.Background(), []string{"balances"}))
+	require.Contains(suite.T(), errOut.String(), envAPIKey)
+	require.Equal(suite.T(), 2, c.run(context.Background(), []string{"ticker"}))
+	require.Equal(suite.T(), 2, c.run(context.Background(), []string{"book", "XBT/USD", "-unknown"}))
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Create a cli which uses the provided REST client and buffers as outputs.
+func newTestCLI(client rest.KrakenSpotRESTClientIface, hasCredentials bool) (*cli, *bytes.Buffer, *bytes.Buffer) {
+	out, errOut := new(bytes.Buffer), new(bytes.Buffer)
+	return &cli{
+		client:         client,
+		nonceGenerator: noncegen.NewHFNonceGenerator(),
+		secopts:        nil,
+		hasCredentials: hasCredentials,
+		newBookFeed:    newWebsocketBookFeed,
+		out:            out,
+		errOut:         errOut,
+	}, out, errOut
+}
@@ -0,0 +1,30 @@
+// This package provides a Clock interface used by the SDK clients for their time-based logic
+// (token expiry, resubscribe backoff, dead man's switch, ...) and three implementations: one which
+// uses the system clock, a fake clock which can be advanced manually in tests and a simulation
+// clock which runs at a controllable speed to replay recorded data.
+package clock
+
+import "time"
+
+// Interface which defines the time functions used by the SDK clients. Providing a custom Clock
+// to a client makes its time-based logic deterministic in tests.
+type Clock interface {
+	// Get the current time.
+	Now() time.Time
+	// Pause the current goroutine for at least the provided duration.
+	Sleep(d time.Duration)
+	// Create a new Timer which sends the current time on its channel after at least the provided
+	// duration.
+	NewTimer(d time.Duration) Timer
+}
+
+// Interface for the timers created by a Clock. Timer mirrors time.Timer.
+type Timer interface {
+	// Get the channel on which the current time is sent when the timer fires.
+	C() <-chan time.Time
+	// Prevent the timer from firing. Returns false if the timer has already fired or has been
+	// stopped.
+	Stop() bool
+	// Change the timer to fire after the provided duration. Returns true if the timer was active.
+	Reset(d time.Duration) bool
+}
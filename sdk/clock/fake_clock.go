@@ -0,0 +1,161 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// A Clock for tests whose time only moves when Advance or Set is called. Timers fire and
+// sleeping goroutines wake up once the clock has been moved past their deadline.
+//
+// The fake clock is safe for concurrent use.
+type FakeClock struct {
+	// Mutex used to protect the clock state
+	mu sync.Mutex
+	// Condition used to signal timer changes to BlockUntil
+	cond *sync.Cond
+	// Current time
+	now time.Time
+	// Active timers
+	timers map[*fakeTimer]struct{}
+}
+
+// Factory which returns a new FakeClock set to the provided time.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{
+		now:    now,
+		timers: map[*fakeTimer]struct{}{},
+	}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Get the current time of the fake clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Block until the fake clock has been moved forward by at least the provided duration.
+func (c *FakeClock) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	<-c.NewTimer(d).C()
+}
+
+// Create a new Timer which fires once the fake clock has been moved forward by at least the
+// provided duration. The timer fires immediately if the duration is not strictly positive.
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scheduleLocked(t, d)
+	return t
+}
+
+// Move the fake clock forward by the provided duration and fire the timers whose deadline has
+// been reached, in deadline order.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(c.now.Add(d))
+}
+
+// Set the time of the fake clock and fire the timers whose deadline has been reached, in deadline
+// order.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(now)
+}
+
+// Get the number of active timers (including sleeping goroutines).
+func (c *FakeClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// Block until at least n timers are active. Use it to wait for a goroutine to start sleeping or
+// waiting on a timer before advancing the clock.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// Set the time and fire expired timers. Mutex must be held.
+func (c *FakeClock) setLocked(now time.Time) {
+	c.now = now
+	expired := []*fakeTimer{}
+	for t := range c.timers {
+		if !t.deadline.After(now) {
+			expired = append(expired, t)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].deadline.Before(expired[j].deadline) })
+	for _, t := range expired {
+		c.fireLocked(t)
+	}
+}
+
+// Schedule the timer to fire after the provided duration. Mutex must be held.
+func (c *FakeClock) scheduleLocked(t *fakeTimer, d time.Duration) {
+	t.deadline = c.now.Add(d)
+	if d <= 0 {
+		c.fireLocked(t)
+		return
+	}
+	c.timers[t] = struct{}{}
+	c.cond.Broadcast()
+}
+
+// Fire the timer and remove it from the active timers. Mutex must be held.
+func (c *FakeClock) fireLocked(t *fakeTimer) {
+	delete(c.timers, t)
+	// Like time.Timer, drop the value if the previous one has not been received yet
+	select {
+	case t.c <- c.now:
+	default:
+	}
+	c.cond.Broadcast()
+}
+
+// Timer created by a FakeClock.
+type fakeTimer struct {
+	// Clock which owns the timer
+	clock *FakeClock
+	// Channel on which the time is sent when the timer fires
+	c chan time.Time
+	// Time at which the timer fires. Protected by the clock mutex.
+	deadline time.Time
+}
+
+// Get the channel on which the time is sent when the timer fires.
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+// Prevent the timer from firing.
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	_, active := t.clock.timers[t]
+	delete(t.clock.timers, t)
+	t.clock.cond.Broadcast()
+	return active
+}
+
+// Change the timer to fire after the provided duration.
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	_, active := t.clock.timers[t]
+	t.clock.scheduleLocked(t, d)
+	return active
+}
@@ -0,0 +1,75 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test FakeClock compliance with Clock interface
+func TestFakeClockInterfaceCompliance(t *testing.T) {
+	var instance interface{} = NewFakeClock(time.Now())
+	_, ok := instance.(Clock)
+	require.True(t, ok)
+}
+
+// Test FakeClock timers.
+//
+// Test will ensure:
+//   - Time only moves when the clock is advanced or set.
+//   - Timers fire once their deadline has been reached.
+//   - Stopped timers do not fire and reset timers fire at their new deadline.
+//   - Timers with a non-positive duration fire immediately.
+func TestFakeClockTimers(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+	require.Equal(t, start, c.Now())
+	t1 := c.NewTimer(time.Minute)
+	t2 := c.NewTimer(2 * time.Minute)
+	t3 := c.NewTimer(3 * time.Minute)
+	require.Equal(t, 3, c.Timers())
+	// Nothing fires before the deadline
+	c.Advance(59 * time.Second)
+	require.Len(t, t1.C(), 0)
+	// First timer fires with the clock time
+	c.Advance(time.Second)
+	require.Equal(t, start.Add(time.Minute), <-t1.C())
+	require.False(t, t1.Stop())
+	// Stopped timer does not fire
+	require.True(t, t2.Stop())
+	// Reset timer fires at its new deadline
+	require.True(t, t3.Reset(10*time.Minute))
+	c.Set(start.Add(5 * time.Minute))
+	require.Len(t, t2.C(), 0)
+	require.Len(t, t3.C(), 0)
+	c.Advance(6 * time.Minute)
+	require.Equal(t, start.Add(11*time.Minute), <-t3.C())
+	require.Equal(t, 0, c.Timers())
+	// Non-positive duration fires immediately
+	require.Equal(t, start.Add(11*time.Minute), <-c.NewTimer(0).C())
+}
+
+// Test FakeClock Sleep and BlockUntil.
+func TestFakeClockSleep(t *testing.T) {
+	c := NewFakeClock(time.Now())
+	done := make(chan struct{})
+	go func() {
+		c.Sleep(time.Hour)
+		close(done)
+	}()
+	// Wait for the goroutine to sleep
+	c.BlockUntil(1)
+	c.Advance(30 * time.Minute)
+	select {
+	case <-done:
+		require.FailNow(t, "sleep returned too early")
+	default:
+	}
+	c.Advance(30 * time.Minute)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.FailNow(t, "sleep did not return")
+	}
+}
@@ -0,0 +1,245 @@
+package clock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Replay speeds commonly used with a SimulationClock.
+const (
+	// Simulated time flows at the same pace as the wall clock.
+	RealTime float64 = 1
+	// Simulated time flows 10 times faster than the wall clock.
+	Speed10x float64 = 10
+	// Simulated time flows 100 times faster than the wall clock.
+	Speed100x float64 = 100
+)
+
+// A Clock for replays whose time flows from a start time at a controllable speed (x1, x10, x100,
+// ...) and can jump to any time. Timers and sleeping goroutines follow the simulated time: a one
+// minute timer fires after 600 milliseconds at x100.
+//
+// To replay recorded messages, wait for the timestamp of each message with WaitUntil before
+// delivering it: the components which use the clock (candles, indicators, health checks, ...) then
+// observe the same times relative to the messages as in live mode, whatever the replay speed.
+// The replay package of the websocket client (Cf. sdk/spot/websocket/replay) does it for the
+// events recorded from the channels of the client.
+//
+// The simulation clock is safe for concurrent use.
+type SimulationClock struct {
+	// Mutex used to protect the clock state
+	mu sync.Mutex
+	// Simulated time when the speed or the time was last changed
+	base time.Time
+	// Wall time when the speed or the time was last changed
+	anchor time.Time
+	// Number of simulated seconds per wall second. Zero when paused.
+	speed float64
+	// Active timers
+	timers map[*simulationTimer]struct{}
+}
+
+// Factory which returns a new SimulationClock set to the provided time and running in real time.
+func NewSimulationClock(start time.Time) *SimulationClock {
+	return &SimulationClock{
+		base:   start,
+		anchor: time.Now(),
+		speed:  RealTime,
+		timers: map[*simulationTimer]struct{}{},
+	}
+}
+
+// Get the simulated time.
+func (c *SimulationClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.nowLocked()
+}
+
+// Block until the simulated time has moved forward by at least the provided duration.
+func (c *SimulationClock) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	<-c.NewTimer(d).C()
+}
+
+// Create a new Timer which fires once the simulated time has moved forward by at least the
+// provided duration. The timer fires immediately if the duration is not strictly positive.
+func (c *SimulationClock) NewTimer(d time.Duration) Timer {
+	t := &simulationTimer{clock: c, c: make(chan time.Time, 1)}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t.deadline = c.nowLocked().Add(d)
+	c.timers[t] = struct{}{}
+	c.scheduleLocked(t)
+	return t
+}
+
+// Get the number of simulated seconds per wall second. Zero when the clock is paused.
+func (c *SimulationClock) Speed() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.speed
+}
+
+// # Description
+//
+// Change the replay speed. Active timers are rescheduled so they fire at their simulated deadline.
+//
+// # Inputs
+//
+//   - speed: Number of simulated seconds per wall second (ex: RealTime, Speed10x, Speed100x). Zero
+//     pauses the clock: timers do not fire until the speed is changed or the clock jumps.
+//
+// # Return
+//
+// An error if the speed is negative. In that case, the speed is not changed.
+func (c *SimulationClock) SetSpeed(speed float64) error {
+	if speed < 0 {
+		return fmt.Errorf("simulation clock speed cannot be negative: %v", speed)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.base = c.nowLocked()
+	c.anchor = time.Now()
+	c.speed = speed
+	c.rescheduleLocked()
+	return nil
+}
+
+// Set the simulated time, for example to skip a quiet period of a replay. The timers whose
+// deadline has been reached fire, in deadline order. Timers keep their simulated deadline when the
+// clock jumps backward. The speed is not changed.
+func (c *SimulationClock) JumpTo(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.base = now
+	c.anchor = time.Now()
+	c.rescheduleLocked()
+}
+
+// # Description
+//
+// Block until the simulated time reaches the provided time, for example the timestamp of the next
+// recorded message to replay. Returns immediately if the time has already been reached.
+//
+// # Inputs
+//
+//   - ctx: Context used for coordination purpose.
+//   - t: Simulated time to wait for.
+//
+// # Return
+//
+// Nil once the time has been reached or the context error if the context is done first.
+func (c *SimulationClock) WaitUntil(ctx context.Context, t time.Time) error {
+	c.mu.Lock()
+	if !t.After(c.nowLocked()) {
+		c.mu.Unlock()
+		return nil
+	}
+	timer := &simulationTimer{clock: c, c: make(chan time.Time, 1), deadline: t}
+	c.timers[timer] = struct{}{}
+	c.scheduleLocked(timer)
+	c.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// Get the simulated time. Mutex must be held.
+func (c *SimulationClock) nowLocked() time.Time {
+	return c.base.Add(time.Duration(float64(time.Since(c.anchor)) * c.speed))
+}
+
+// Fire the active timer if its deadline has been reached or program a wall timer which fires at
+// its deadline at the current speed. Mutex must be held.
+func (c *SimulationClock) scheduleLocked(t *simulationTimer) {
+	if t.wall != nil {
+		t.wall.Stop()
+		t.wall = nil
+	}
+	now := c.nowLocked()
+	if !t.deadline.After(now) {
+		delete(c.timers, t)
+		// Like time.Timer, drop the value if the previous one has not been received yet
+		select {
+		case t.c <- now:
+		default:
+		}
+		return
+	}
+	if c.speed == 0 {
+		// Paused
+		return
+	}
+	t.wall = time.AfterFunc(time.Duration(float64(t.deadline.Sub(now))/c.speed), func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if _, active := c.timers[t]; active {
+			// Reschedule if the wall timer has fired a bit early because of rounding
+			c.scheduleLocked(t)
+		}
+	})
+}
+
+// Reschedule all active timers, in deadline order. Mutex must be held.
+func (c *SimulationClock) rescheduleLocked() {
+	timers := make([]*simulationTimer, 0, len(c.timers))
+	for t := range c.timers {
+		timers = append(timers, t)
+	}
+	sort.Slice(timers, func(i, j int) bool { return timers[i].deadline.Before(timers[j].deadline) })
+	for _, t := range timers {
+		c.scheduleLocked(t)
+	}
+}
+
+// Timer created by a SimulationClock.
+type simulationTimer struct {
+	// Clock which owns the timer
+	clock *SimulationClock
+	// Channel on which the time is sent when the timer fires
+	c chan time.Time
+	// Simulated time at which the timer fires. Protected by the clock mutex.
+	deadline time.Time
+	// Wall timer which fires at the deadline at the current speed. Nil when the clock is paused.
+	// Protected by the clock mutex.
+	wall *time.Timer
+}
+
+// Get the channel on which the time is sent when the timer fires.
+func (t *simulationTimer) C() <-chan time.Time {
+	return t.c
+}
+
+// Prevent the timer from firing.
+func (t *simulationTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	_, active := t.clock.timers[t]
+	delete(t.clock.timers, t)
+	if t.wall != nil {
+		t.wall.Stop()
+		t.wall = nil
+	}
+	return active
+}
+
+// Change the timer to fire once the simulated time has moved forward by the provided duration.
+func (t *simulationTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	_, active := t.clock.timers[t]
+	t.deadline = t.clock.nowLocked().Add(d)
+	t.clock.timers[t] = struct{}{}
+	t.clock.scheduleLocked(t)
+	return active
+}
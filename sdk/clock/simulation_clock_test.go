@@ -0,0 +1,110 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test SimulationClock compliance with Clock interface
+func TestSimulationClockInterfaceCompliance(t *testing.T) {
+	var instance interface{} = NewSimulationClock(time.Now())
+	_, ok := instance.(Clock)
+	require.True(t, ok)
+}
+
+// Test SimulationClock speed.
+//
+// Test will ensure:
+//   - The clock starts at the provided time and runs in real time.
+//   - Simulated time flows faster when the speed is increased.
+//   - Simulated time does not flow when the clock is paused.
+//   - Negative speeds are rejected.
+func TestSimulationClockSpeed(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewSimulationClock(start)
+	require.Equal(t, RealTime, c.Speed())
+	require.WithinDuration(t, start, c.Now(), 50*time.Millisecond)
+	// x100: more than 1 simulated second after 10 wall milliseconds
+	require.NoError(t, c.SetSpeed(Speed100x))
+	before := c.Now()
+	time.Sleep(10 * time.Millisecond)
+	require.GreaterOrEqual(t, c.Now().Sub(before), time.Second)
+	// Paused
+	require.NoError(t, c.SetSpeed(0))
+	paused := c.Now()
+	time.Sleep(5 * time.Millisecond)
+	require.Equal(t, paused, c.Now())
+	require.Error(t, c.SetSpeed(-1))
+	require.Zero(t, c.Speed())
+}
+
+// Test SimulationClock timers.
+//
+// Test will ensure:
+//   - Timers fire after their simulated duration at the current speed.
+//   - Timers do not fire while the clock is paused and are rescheduled when the speed changes.
+//   - Timers whose deadline is reached by a jump fire immediately, in deadline order.
+//   - Stopped timers do not fire and timers with a non-positive duration fire immediately.
+func TestSimulationClockTimers(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewSimulationClock(start)
+	require.NoError(t, c.SetSpeed(1000))
+	// One simulated minute at x1000 takes 60 wall milliseconds
+	wall := time.Now()
+	fired := <-c.NewTimer(time.Minute).C()
+	require.False(t, fired.Before(start.Add(time.Minute)))
+	require.Less(t, time.Since(wall), 5*time.Second)
+	// Paused timers
+	require.NoError(t, c.SetSpeed(0))
+	paused := c.NewTimer(time.Millisecond)
+	stopped := c.NewTimer(time.Hour)
+	require.True(t, stopped.Stop())
+	time.Sleep(10 * time.Millisecond)
+	require.Len(t, paused.C(), 0)
+	require.NoError(t, c.SetSpeed(Speed100x))
+	<-paused.C()
+	// Jumps
+	require.NoError(t, c.SetSpeed(0))
+	now := c.Now()
+	t1 := c.NewTimer(time.Hour)
+	t2 := c.NewTimer(2 * time.Hour)
+	t3 := c.NewTimer(3 * time.Hour)
+	c.JumpTo(now.Add(2 * time.Hour))
+	require.Equal(t, now.Add(2*time.Hour), <-t1.C())
+	require.Equal(t, now.Add(2*time.Hour), <-t2.C())
+	require.Len(t, t3.C(), 0)
+	require.True(t, t3.Reset(time.Minute))
+	c.JumpTo(now.Add(2*time.Hour + time.Minute))
+	<-t3.C()
+	require.Len(t, stopped.C(), 0)
+	require.Equal(t, c.Now(), <-c.NewTimer(0).C())
+}
+
+// Test SimulationClock WaitUntil and Sleep.
+//
+// Test will ensure:
+//   - WaitUntil returns immediately when the time has already been reached.
+//   - WaitUntil returns once the simulated time reaches the provided time.
+//   - WaitUntil returns the context error when the context is done first.
+//   - Sleep follows the simulated time.
+func TestSimulationClockWaitUntil(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewSimulationClock(start)
+	require.NoError(t, c.WaitUntil(context.Background(), start.Add(-time.Hour)))
+	require.NoError(t, c.SetSpeed(0))
+	done := make(chan error, 1)
+	go func() { done <- c.WaitUntil(context.Background(), start.Add(time.Hour)) }()
+	require.Never(t, func() bool { return len(done) > 0 }, 20*time.Millisecond, time.Millisecond)
+	c.JumpTo(start.Add(time.Hour))
+	require.NoError(t, <-done)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, c.WaitUntil(ctx, start.Add(2*time.Hour)), context.DeadlineExceeded)
+	require.NoError(t, c.SetSpeed(3600))
+	before := c.Now()
+	c.Sleep(time.Minute)
+	require.GreaterOrEqual(t, c.Now().Sub(before), time.Minute)
+}
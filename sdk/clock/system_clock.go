@@ -0,0 +1,46 @@
+package clock
+
+import "time"
+
+// Clock which uses the system clock (time package).
+type SystemClock struct{}
+
+// Factory which returns a new SystemClock.
+func NewSystemClock() *SystemClock {
+	return new(SystemClock)
+}
+
+// Get the current time with time.Now.
+func (c *SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Pause the current goroutine with time.Sleep.
+func (c *SystemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// Create a new Timer backed by a time.Timer.
+func (c *SystemClock) NewTimer(d time.Duration) Timer {
+	return &systemTimer{timer: time.NewTimer(d)}
+}
+
+// Timer backed by a time.Timer.
+type systemTimer struct {
+	timer *time.Timer
+}
+
+// Get the channel of the time.Timer.
+func (t *systemTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+// Stop the time.Timer.
+func (t *systemTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+// Reset the time.Timer.
+func (t *systemTimer) Reset(d time.Duration) bool {
+	return t.timer.Reset(d)
+}
@@ -0,0 +1,34 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test SystemClock compliance with Clock interface
+func TestSystemClockInterfaceCompliance(t *testing.T) {
+	var instance interface{} = NewSystemClock()
+	_, ok := instance.(Clock)
+	require.True(t, ok)
+}
+
+// Test SystemClock Now, Sleep and NewTimer
+func TestSystemClock(t *testing.T) {
+	c := NewSystemClock()
+	start := c.Now()
+	c.Sleep(time.Millisecond)
+	require.GreaterOrEqual(t, time.Since(start), time.Millisecond)
+	// Timer fires
+	timer := c.NewTimer(time.Millisecond)
+	select {
+	case <-timer.C():
+	case <-time.After(time.Second):
+		require.FailNow(t, "timer has not fired")
+	}
+	require.False(t, timer.Stop())
+	// Stopped timer does not fire
+	require.False(t, timer.Reset(time.Hour))
+	require.True(t, timer.Stop())
+}
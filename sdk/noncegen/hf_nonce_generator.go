@@ -5,6 +5,10 @@ import (
 	"time"
 )
 
+// Amount of nanoseconds the baseline of a HFNonceGenerator is moved past the current time and
+// the last generated nonce when it is resynced.
+const HFNonceResyncStep = int64(time.Second)
+
 // A thread-safe nonce generator with no collision risk when used at high frequency. The nonce
 // generator generate nonce from two numbers that are added:
 //   - base: The UNIX nanosec timestamp of the moment when the generator has been created. This
@@ -47,3 +51,14 @@ func (g *HFNonceGenerator) GenerateNonce() int64 {
 	g.inc = g.inc + 1
 	return nonce
 }
+
+// Move the baseline of the nonce generator forward: the next nonce will be greater than the last
+// generated nonce and than the current UNIX nanosec timestamp by HFNonceResyncStep.
+func (g *HFNonceGenerator) Resync() {
+	// Lock mutex and defer Unlock
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	// Set base past the next nonce and the current time and reset the counter
+	g.base = max(g.base+g.inc, time.Now().UnixNano()) + HFNonceResyncStep
+	g.inc = 0
+}
@@ -30,3 +30,22 @@ func TestHFNonceGenerator(t *testing.T) {
 	require.Equal(t, int64(0), nonce-gen.base)
 	require.Equal(t, int64(1), gen.inc)
 }
+
+// Test HFNonceGenerator Resync
+func TestHFNonceGeneratorResync(t *testing.T) {
+	var instance interface{} = NewHFNonceGenerator()
+	_, ok := instance.(ResyncableNonceGenerator)
+	require.True(t, ok)
+	// Create a HFNonceGenerator with a baseline in the future
+	gen := NewHFNonceGenerator()
+	gen.base = time.Now().Add(time.Hour).UnixNano()
+	last := gen.GenerateNonce()
+	// Resync: next nonce must be greater than the last nonce by the resync step
+	gen.Resync()
+	require.Equal(t, last+1+HFNonceResyncStep, gen.GenerateNonce())
+	// Resync a generator with a baseline in the past: next nonce must be past the current time
+	gen.base = 0
+	now := time.Now().UnixNano()
+	gen.Resync()
+	require.GreaterOrEqual(t, gen.GenerateNonce(), now+HFNonceResyncStep)
+}
@@ -7,3 +7,12 @@ type NonceGenerator interface {
 	// Generate a new nonce.
 	GenerateNonce() int64
 }
+
+// Interface for nonce generators whose baseline can be moved forward. This is used to recover
+// from "EAPI:Invalid nonce" errors: after a resync, generated nonces must be greater than all
+// nonces previously generated and than the current time expressed in the generator unit.
+type ResyncableNonceGenerator interface {
+	NonceGenerator
+	// Move the baseline of the nonce generator forward.
+	Resync()
+}
@@ -0,0 +1,353 @@
+// Package accounts provides a manager which owns the clients of multiple Kraken accounts (one
+// REST client and one optional private websocket client per API key), routes calls to a given
+// account and runs aggregated operations across all accounts while isolating failures per account.
+package accounts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+)
+
+/*************************************************************************************************/
+/* MODEL                                                                                         */
+/*************************************************************************************************/
+
+// Clients of a Kraken account.
+type Account struct {
+	// REST client authenticated with the API key of the account. Must not be nil.
+	REST rest.KrakenSpotRESTClientIface
+	// Optional private websocket client authenticated with the API key of the account. When set,
+	// it is preferred over the REST client for order management operations.
+	Websocket websocket.KrakenSpotPrivateWebsocketClientInterface
+	// Nonce generator used to sign the REST requests of the account. Each API key must have its
+	// own nonce generator. If nil, a noncegen.HFNonceGenerator is used.
+	NonceGenerator noncegen.NonceGenerator
+	// Optional security options used for the REST requests of the account. Can be nil if 2FA is
+	// not used.
+	SecurityOptions *common.SecurityOptions
+}
+
+// Error returned when an account is not managed by the Manager.
+type UnknownAccountError struct {
+	// Name of the account
+	Account string
+}
+
+// Format the error message
+func (e *UnknownAccountError) Error() string {
+	return fmt.Sprintf("unknown account %s", e.Account)
+}
+
+// Error returned when an operation failed for an account.
+type AccountError struct {
+	// Name of the account
+	Account string
+	// Root error
+	Root error
+}
+
+// Format the error message
+func (e *AccountError) Error() string {
+	return fmt.Sprintf("operation failed for account %s: %s", e.Account, e.Root.Error())
+}
+
+// Unwrap the root error
+func (e *AccountError) Unwrap() error {
+	return e.Root
+}
+
+// Balances of all managed accounts.
+type CombinedBalances struct {
+	// Sum of the balances of the accounts which could be queried, by asset.
+	Totals map[string]float64
+	// Balances of each account which could be queried, by account name then by asset.
+	Accounts map[string]map[string]float64
+	// Errors of the accounts which could not be queried, by account name.
+	Errors map[string]error
+}
+
+// Result of a cancel-all operation across accounts.
+type CancelAllResult struct {
+	// Number of orders cancelled, by account name, for the accounts where the operation succeeded.
+	Counts map[string]int
+	// Errors of the accounts where the operation failed, by account name.
+	Errors map[string]error
+}
+
+/*************************************************************************************************/
+/* MANAGER                                                                                       */
+/*************************************************************************************************/
+
+// # Description
+//
+// Manager which owns the clients of multiple Kraken accounts identified by name.
+//
+// Aggregated operations are run concurrently for all accounts. A failure (error or panic) of an
+// account does not prevent the operation from completing for the other accounts: it is reported
+// in the result as an AccountError.
+//
+// Manager is safe for concurrent use.
+type Manager struct {
+	// Mutex used to protect the accounts
+	mu sync.RWMutex
+	// Accounts by name
+	accounts map[string]*Account
+}
+
+// Factory which returns a new Manager without any account.
+func NewManager() *Manager {
+	return &Manager{accounts: map[string]*Account{}}
+}
+
+// # Description
+//
+// Add an account to the manager.
+//
+// # Inputs
+//
+//   - name: Name of the account. Must not be empty.
+//   - account: Clients of the account. REST client must not be nil.
+//
+// # Return
+//
+// An error if the name is empty, if an account with the same name is already managed or if the
+// REST client is nil.
+func (m *Manager) Add(name string, account *Account) error {
+	if name == "" {
+		return fmt.Errorf("account name must not be empty")
+	}
+	if account == nil || account.REST == nil {
+		return fmt.Errorf("REST client of account %s must not be nil", name)
+	}
+	if account.NonceGenerator == nil {
+		account.NonceGenerator = noncegen.NewHFNonceGenerator()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.accounts[name]; ok {
+		return fmt.Errorf("account %s already exists", name)
+	}
+	m.accounts[name] = account
+	return nil
+}
+
+// # Description
+//
+// Remove an account from the manager. Clients of the account are not stopped.
+//
+// # Return
+//
+// The removed account or an UnknownAccountError if the account is not managed.
+func (m *Manager) Remove(name string) (*Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	account, ok := m.accounts[name]
+	if !ok {
+		return nil, &UnknownAccountError{Account: name}
+	}
+	delete(m.accounts, name)
+	return account, nil
+}
+
+// # Description
+//
+// Get the clients of an account.
+//
+// # Return
+//
+// The account or an UnknownAccountError if the account is not managed.
+func (m *Manager) Get(name string) (*Account, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	account, ok := m.accounts[name]
+	if !ok {
+		return nil, &UnknownAccountError{Account: name}
+	}
+	return account, nil
+}
+
+// Get the names of the managed accounts, sorted.
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.accounts))
+	for name := range m.accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// # Description
+//
+// Run a function with the clients of an account.
+//
+// # Inputs
+//
+//   - ctx: Context provided to the function.
+//   - name: Name of the account.
+//   - fn: Function to run.
+//
+// # Return
+//
+// An UnknownAccountError if the account is not managed. Otherwise, the error returned by the
+// function wrapped in an AccountError. Panics in the function are recovered and returned as errors.
+func (m *Manager) Do(ctx context.Context, name string, fn func(ctx context.Context, account *Account) error) error {
+	account, err := m.Get(name)
+	if err != nil {
+		return err
+	}
+	return run(ctx, name, account, fn)
+}
+
+// # Description
+//
+// Run a function concurrently with the clients of each managed account.
+//
+// # Inputs
+//
+//   - ctx: Context provided to the function.
+//   - fn: Function to run for each account.
+//
+// # Return
+//
+// The errors of the accounts where the function failed, by account name. Errors are AccountError.
+// Panics in the function are recovered and returned as errors. An empty map is returned if the
+// function succeeded for all accounts.
+func (m *Manager) ForEach(ctx context.Context, fn func(ctx context.Context, name string, account *Account) error) map[string]error {
+	m.mu.RLock()
+	accounts := make(map[string]*Account, len(m.accounts))
+	for name, account := range m.accounts {
+		accounts[name] = account
+	}
+	m.mu.RUnlock()
+	errs := map[string]error{}
+	errsMu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for name, account := range accounts {
+		wg.Add(1)
+		go func(name string, account *Account) {
+			defer wg.Done()
+			err := run(ctx, name, account, func(ctx context.Context, account *Account) error {
+				return fn(ctx, name, account)
+			})
+			if err != nil {
+				errsMu.Lock()
+				errs[name] = err
+				errsMu.Unlock()
+			}
+		}(name, account)
+	}
+	wg.Wait()
+	return errs
+}
+
+// # Description
+//
+// Get the balances of all managed accounts with the REST API and sum them by asset.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//
+// # Return
+//
+// The balances of each account, their sum by asset and the errors of the accounts which could
+// not be queried.
+func (m *Manager) GetCombinedBalances(ctx context.Context) *CombinedBalances {
+	result := &CombinedBalances{
+		Totals:   map[string]float64{},
+		Accounts: map[string]map[string]float64{},
+	}
+	resultMu := sync.Mutex{}
+	result.Errors = m.ForEach(ctx, func(ctx context.Context, name string, account *Account) error {
+		resp, _, err := account.REST.GetAccountBalance(ctx, account.NonceGenerator.GenerateNonce(), account.SecurityOptions)
+		if err != nil {
+			return fmt.Errorf("get account balance failed: %w", err)
+		}
+		if len(resp.Error) > 0 {
+			return fmt.Errorf("get account balance failed: %v", resp.Error)
+		}
+		balances := make(map[string]float64, len(resp.Result))
+		for asset, balance := range resp.Result {
+			value, err := strconv.ParseFloat(balance.String(), 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse balance of asset %s: %w", asset, err)
+			}
+			balances[asset] = value
+		}
+		resultMu.Lock()
+		defer resultMu.Unlock()
+		result.Accounts[name] = balances
+		for asset, value := range balances {
+			result.Totals[asset] += value
+		}
+		return nil
+	})
+	return result
+}
+
+// # Description
+//
+// Cancel all open orders of all managed accounts. The private websocket client of an account is
+// used when set, the REST client otherwise.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//
+// # Return
+//
+// The number of cancelled orders for each account and the errors of the accounts where the
+// operation failed.
+func (m *Manager) CancelAllOrders(ctx context.Context) *CancelAllResult {
+	result := &CancelAllResult{Counts: map[string]int{}}
+	resultMu := sync.Mutex{}
+	result.Errors = m.ForEach(ctx, func(ctx context.Context, name string, account *Account) error {
+		count := 0
+		if account.Websocket != nil {
+			resp, err := account.Websocket.CancellAllOrders(ctx)
+			if err != nil {
+				return fmt.Errorf("cancel all orders failed: %w", err)
+			}
+			count = resp.Count
+		} else {
+			resp, _, err := account.REST.CancelAllOrders(ctx, account.NonceGenerator.GenerateNonce(), account.SecurityOptions)
+			if err != nil {
+				return fmt.Errorf("cancel all orders failed: %w", err)
+			}
+			if len(resp.Error) > 0 {
+				return fmt.Errorf("cancel all orders failed: %v", resp.Error)
+			}
+			if resp.Result != nil {
+				count = resp.Result.Count
+			}
+		}
+		resultMu.Lock()
+		defer resultMu.Unlock()
+		result.Counts[name] = count
+		return nil
+	})
+	return result
+}
+
+// Run a function with the clients of an account. Errors and panics are returned as AccountError.
+func run(ctx context.Context, name string, account *Account, fn func(ctx context.Context, account *Account) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &AccountError{Account: name, Root: fmt.Errorf("panic: %v", r)}
+		}
+	}()
+	if err := fn(ctx, account); err != nil {
+		return &AccountError{Account: name, Root: err}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package accounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/papertrading"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for Manager
+type ManagerUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestManagerUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(ManagerUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test accounts management and routing.
+//
+// Test will ensure:
+//   - Invalid and duplicated accounts are rejected.
+//   - A nonce generator is set when none is provided.
+//   - Get, Do and Remove return an UnknownAccountError for unknown accounts.
+//   - Do wraps errors and recovered panics in an AccountError.
+func (suite *ManagerUnitTestSuite) TestRouting() {
+	m := NewManager()
+	require.Error(suite.T(), m.Add("", &Account{REST: rest.NewMockKrakenSpotRESTClient()}))
+	require.Error(suite.T(), m.Add("main", &Account{}))
+	account := &Account{REST: rest.NewMockKrakenSpotRESTClient()}
+	require.NoError(suite.T(), m.Add("main", account))
+	require.NotNil(suite.T(), account.NonceGenerator)
+	require.Error(suite.T(), m.Add("main", &Account{REST: rest.NewMockKrakenSpotRESTClient()}))
+	require.NoError(suite.T(), m.Add("sub", &Account{REST: rest.NewMockKrakenSpotRESTClient()}))
+	require.Equal(suite.T(), []string{"main", "sub"}, m.Names())
+	// Get
+	got, err := m.Get("main")
+	require.NoError(suite.T(), err)
+	require.Same(suite.T(), account, got)
+	_, err = m.Get("unknown")
+	require.ErrorAs(suite.T(), err, new(*UnknownAccountError))
+	// Do
+	require.NoError(suite.T(), m.Do(context.Background(), "main", func(ctx context.Context, a *Account) error {
+		require.Same(suite.T(), account, a)
+		return nil
+	}))
+	root := errors.New("failure")
+	err = m.Do(context.Background(), "main", func(ctx context.Context, a *Account) error { return root })
+	aerr := new(AccountError)
+	require.ErrorAs(suite.T(), err, &aerr)
+	require.Equal(suite.T(), "main", aerr.Account)
+	require.ErrorIs(suite.T(), err, root)
+	err = m.Do(context.Background(), "main", func(ctx context.Context, a *Account) error { panic("boom") })
+	require.ErrorAs(suite.T(), err, &aerr)
+	err = m.Do(context.Background(), "unknown", func(ctx context.Context, a *Account) error { return nil })
+	require.ErrorAs(suite.T(), err, new(*UnknownAccountError))
+	// Remove
+	removed, err := m.Remove("sub")
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), removed)
+	_, err = m.Remove("sub")
+	require.ErrorAs(suite.T(), err, new(*UnknownAccountError))
+	require.Equal(suite.T(), []string{"main"}, m.Names())
+}
+
+// Test combined balances.
+//
+// Test will ensure:
+//   - Balances of each account are returned and summed by asset.
+//   - Failures of an account (error, API error) are reported without impacting other accounts.
+func (suite *ManagerUnitTestSuite) TestGetCombinedBalances() {
+	m := NewManager()
+	main := rest.NewMockKrakenSpotRESTClient()
+	main.On("GetAccountBalance", mock.Anything, mock.Anything, mock.Anything).
+		Return(rest.NewMockGetAccountBalanceResponse(map[string]string{"ZUSD": "100.5", "XXBT": "1"}), nil, nil)
+	sub := rest.NewMockKrakenSpotRESTClient()
+	sub.On("GetAccountBalance", mock.Anything, mock.Anything, mock.Anything).
+		Return(rest.NewMockGetAccountBalanceResponse(map[string]string{"ZUSD": "50"}), nil, nil)
+	apierr := rest.NewMockKrakenSpotRESTClient()
+	apierr.On("GetAccountBalance", mock.Anything, mock.Anything, mock.Anything).
+		Return(&account.GetAccountBalanceResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{"EAPI:Invalid key"}}}, nil, nil)
+	failed := rest.NewMockKrakenSpotRESTClient()
+	failed.On("GetAccountBalance", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, nil, fmt.Errorf("connection refused"))
+	require.NoError(suite.T(), m.Add("main", &Account{REST: main}))
+	require.NoError(suite.T(), m.Add("sub", &Account{REST: sub}))
+	require.NoError(suite.T(), m.Add("apierr", &Account{REST: apierr}))
+	require.NoError(suite.T(), m.Add("failed", &Account{REST: failed}))
+	balances := m.GetCombinedBalances(context.Background())
+	require.Equal(suite.T(), map[string]float64{"ZUSD": 150.5, "XXBT": 1}, balances.Totals)
+	require.Equal(suite.T(), map[string]float64{"ZUSD": 50}, balances.Accounts["sub"])
+	require.Len(suite.T(), balances.Accounts, 2)
+	require.Len(suite.T(), balances.Errors, 2)
+	require.ErrorAs(suite.T(), balances.Errors["apierr"], new(*AccountError))
+	require.ErrorAs(suite.T(), balances.Errors["failed"], new(*AccountError))
+}
+
+// Test cancel-all across accounts.
+//
+// Test will ensure:
+//   - The websocket client is used when set, the REST client otherwise.
+//   - Cancelled orders are counted per account.
+//   - Failures of an account are reported without impacting other accounts.
+func (suite *ManagerUnitTestSuite) TestCancelAllOrders() {
+	m := NewManager()
+	paper := papertrading.NewKrakenSpotPaperTradingClient(nil, 0, nil)
+	_, err := paper.AddOrder(context.Background(), websocket.AddOrderRequestParameters{
+		OrderType: "limit", Type: "buy", Pair: "XBT/USD", Price: "1", Volume: "1",
+	})
+	require.NoError(suite.T(), err)
+	wsrest := rest.NewMockKrakenSpotRESTClient()
+	restonly := rest.NewMockKrakenSpotRESTClient()
+	restonly.On("CancelAllOrders", mock.Anything, mock.Anything, mock.Anything).
+		Return(&trading.CancelAllOrdersResponse{Result: &trading.CancelAllOrdersResult{Count: 3}}, nil, nil)
+	failed := rest.NewMockKrakenSpotRESTClient()
+	failed.On("CancelAllOrders", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, nil, fmt.Errorf("connection refused"))
+	require.NoError(suite.T(), m.Add("ws", &Account{REST: wsrest, Websocket: paper}))
+	require.NoError(suite.T(), m.Add("rest", &Account{REST: restonly}))
+	require.NoError(suite.T(), m.Add("failed", &Account{REST: failed}))
+	result := m.CancelAllOrders(context.Background())
+	require.Equal(suite.T(), map[string]int{"ws": 1, "rest": 3}, result.Counts)
+	require.Len(suite.T(), result.Errors, 1)
+	require.ErrorAs(suite.T(), result.Errors["failed"], new(*AccountError))
+	wsrest.AssertNotCalled(suite.T(), "CancelAllOrders", mock.Anything, mock.Anything, mock.Anything)
+}
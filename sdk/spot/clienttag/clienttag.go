@@ -0,0 +1,115 @@
+// Package clienttag provides the helpers used by the REST and websocket clients to apply a client
+// tag consistently: in the User-Agent header of REST requests and of the websocket handshake, and
+// as an attribute of every OpenTelemetry span. Client tags allow fleet operators to distinguish
+// bots which share the same API keys.
+package clienttag
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// Default value for the User-Agent header
+	DefaultUserAgent = "Lake42-Goctopus"
+	// Key of the span attribute which contains the client tag
+	AttributeKey = attribute.Key("goctopus.client_tag")
+)
+
+// # Description
+//
+// Build the value of the User-Agent header from a user agent and a client tag. The tag is added
+// as a comment after the user agent: "<agent> (<tag>)".
+//
+// # Inputs
+//
+//   - agent: User agent. If empty, DefaultUserAgent is used.
+//   - tag: Client tag. If empty, the user agent is returned as is.
+//
+// # Return
+//
+// The value to use for the User-Agent header.
+func UserAgent(agent string, tag string) string {
+	if agent == "" {
+		agent = DefaultUserAgent
+	}
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return agent
+	}
+	return agent + " (" + tag + ")"
+}
+
+// # Description
+//
+// Wrap a tracer provider so every span started by its tracers has the client tag attribute
+// (Cf. AttributeKey).
+//
+// # Inputs
+//
+//   - tp: Tracer provider to wrap. Must not be nil.
+//   - tag: Client tag. If empty, the tracer provider is returned as is.
+//
+// # Return
+//
+// The wrapped tracer provider.
+func TracerProvider(tp trace.TracerProvider, tag string) trace.TracerProvider {
+	if tag == "" {
+		return tp
+	}
+	if tagged, ok := tp.(*taggedTracerProvider); ok {
+		// Replace the tag instead of stacking wrappers
+		tp = tagged.TracerProvider
+	}
+	return &taggedTracerProvider{TracerProvider: tp, tag: tag}
+}
+
+// # Description
+//
+// Wrap a tracer so every span it starts has the client tag attribute (Cf. AttributeKey).
+//
+// # Inputs
+//
+//   - tracer: Tracer to wrap. Must not be nil.
+//   - tag: Client tag. If empty, the tag set by a previous call (if any) is removed.
+//
+// # Return
+//
+// The wrapped tracer.
+func Tracer(tracer trace.Tracer, tag string) trace.Tracer {
+	if tagged, ok := tracer.(*taggedTracer); ok {
+		// Replace the tag instead of stacking wrappers
+		tracer = tagged.Tracer
+	}
+	if tag == "" {
+		return tracer
+	}
+	return &taggedTracer{Tracer: tracer, attr: AttributeKey.String(tag)}
+}
+
+// Tracer provider whose tracers add the client tag attribute to every span.
+type taggedTracerProvider struct {
+	trace.TracerProvider
+	// Client tag
+	tag string
+}
+
+// Get a tracer which adds the client tag attribute to every span.
+func (tp *taggedTracerProvider) Tracer(name string, options ...trace.TracerOption) trace.Tracer {
+	return Tracer(tp.TracerProvider.Tracer(name, options...), tp.tag)
+}
+
+// Tracer which adds the client tag attribute to every span.
+type taggedTracer struct {
+	trace.Tracer
+	// Client tag attribute
+	attr attribute.KeyValue
+}
+
+// Start a span with the client tag attribute.
+func (t *taggedTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return t.Tracer.Start(ctx, spanName, append(opts, trace.WithAttributes(t.attr))...)
+}
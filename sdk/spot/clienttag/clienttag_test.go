@@ -0,0 +1,92 @@
+package clienttag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for client tag helpers
+type ClientTagUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestClientTagUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(ClientTagUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test UserAgent.
+func (suite *ClientTagUnitTestSuite) TestUserAgent() {
+	require.Equal(suite.T(), DefaultUserAgent, UserAgent("", ""))
+	require.Equal(suite.T(), "my-bot/1.0", UserAgent("my-bot/1.0", " "))
+	require.Equal(suite.T(), DefaultUserAgent+" (bot-a)", UserAgent("", "bot-a"))
+	require.Equal(suite.T(), "my-bot/1.0 (bot-a)", UserAgent("my-bot/1.0", "bot-a"))
+}
+
+// Test tagged tracer providers and tracers.
+//
+// Test will ensure:
+//   - Spans started by tagged tracers have the client tag attribute along with their own attributes.
+//   - Tagging an already tagged tracer or tracer provider replaces the tag.
+//   - An empty tag leaves the tracer provider untouched and removes the tag from a tracer.
+func (suite *ClientTagUnitTestSuite) TestTracer() {
+	rec := &attributesRecorder{}
+	var tp trace.TracerProvider = &recordingTracerProvider{TracerProvider: noop.NewTracerProvider(), rec: rec}
+	require.Same(suite.T(), tp, TracerProvider(tp, ""))
+	tagged := TracerProvider(TracerProvider(tp, "bot-a"), "bot-b")
+	tagged.Tracer("test").Start(context.Background(), "span", trace.WithAttributes(attribute.String("k", "v")))
+	require.Equal(suite.T(), []attribute.KeyValue{attribute.String("k", "v"), AttributeKey.String("bot-b")}, rec.attributes)
+	// Remove tag from tracer
+	tracer := Tracer(tagged.Tracer("test"), "")
+	tracer.Start(context.Background(), "span")
+	require.Empty(suite.T(), rec.attributes)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Records the attributes of the last started span.
+type attributesRecorder struct {
+	attributes []attribute.KeyValue
+}
+
+// Tracer provider whose tracers record the attributes of started spans.
+type recordingTracerProvider struct {
+	trace.TracerProvider
+	// Recorder
+	rec *attributesRecorder
+}
+
+// Get a recording tracer.
+func (tp *recordingTracerProvider) Tracer(name string, options ...trace.TracerOption) trace.Tracer {
+	return &recordingTracer{Tracer: tp.TracerProvider.Tracer(name, options...), rec: tp.rec}
+}
+
+// Tracer which records the attributes of started spans.
+type recordingTracer struct {
+	trace.Tracer
+	// Recorder
+	rec *attributesRecorder
+}
+
+// Record span attributes and start a span.
+func (t *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	cfg := trace.NewSpanStartConfig(opts...)
+	t.rec.attributes = cfg.Attributes()
+	return t.Tracer.Start(ctx, name, opts...)
+}
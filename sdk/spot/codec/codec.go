@@ -0,0 +1,64 @@
+// Package codec provides the JSON codec abstraction used by the REST and websocket clients to
+// encode requests and decode responses.
+//
+// By default, the clients use encoding/json. Users who process a high volume of responses can
+// provide their own codec implementation built on a faster JSON library (jsoniter, sonic,
+// easyjson generated decoders, ...).
+//
+// Note that SDK types which implement json.Unmarshaler (ex: websocket data messages which are
+// JSON arrays) rely on encoding/json internally: a custom codec brings no gain on them. It only
+// speeds up plain structs such as REST responses and websocket request responses. Run the
+// package benchmarks to compare codecs on your payloads.
+package codec
+
+import (
+	"encoding/json"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+)
+
+// Interface for a JSON codec.
+//
+// Implementations must be safe for concurrent use and must honor the json.Marshaler and
+// json.Unmarshaler interfaces implemented by the SDK types.
+type JSONCodec interface {
+	// Marshal the provided value as JSON.
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal the provided JSON data into the provided value.
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSON codec which uses encoding/json.
+type StandardJSONCodec struct{}
+
+// Marshal the provided value as JSON with encoding/json.
+func (StandardJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal the provided JSON data into the provided value with encoding/json.
+func (StandardJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// # Description
+//
+// Unmarshal the data of an event published by the websocket client into the provided value
+// using the provided codec. This is the helper to use to parse messages received on the book,
+// trade, ... channels with a custom codec.
+//
+// # Inputs
+//
+//   - codec: Codec to use. If nil, StandardJSONCodec is used.
+//   - e: Event which contains the JSON data to unmarshal.
+//   - v: Target value.
+//
+// # Return
+//
+// An error if the event data cannot be unmarshalled.
+func UnmarshalEventData(codec JSONCodec, e event.Event, v interface{}) error {
+	if codec == nil {
+		codec = StandardJSONCodec{}
+	}
+	return codec.Unmarshal(e.Data(), v)
+}
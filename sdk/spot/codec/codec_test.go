@@ -0,0 +1,167 @@
+package codec
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for JSON codecs
+type JSONCodecUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestJSONCodecUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(JSONCodecUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test StandardJSONCodec and a jsoniter based codec produce the same results on REST and
+// websocket payloads.
+func (suite *JSONCodecUnitTestSuite) TestCodecs() {
+	for _, codec := range []JSONCodec{StandardJSONCodec{}, newJsoniterCodec()} {
+		// REST response
+		resp := new(account.GetTradesHistoryResponse)
+		require.NoError(suite.T(), codec.Unmarshal([]byte(tradesHistoryPayload(3)), resp))
+		require.Empty(suite.T(), resp.Error)
+		require.Equal(suite.T(), 3, resp.Result.Count)
+		require.Equal(suite.T(), "30000.1", resp.Result.Trades["T0"].Price.String())
+		require.Equal(suite.T(), "XXBTZUSD", resp.Result.Trades["T2"].Pair)
+		// Websocket message with custom unmarshaller
+		trade := new(messages.Trade)
+		require.NoError(suite.T(), codec.Unmarshal([]byte(tradePayload), trade))
+		require.Equal(suite.T(), "XBT/USD", trade.Pair)
+		require.Len(suite.T(), trade.Data, 2)
+		require.Equal(suite.T(), "5541.20000", trade.Data[0].Price.String())
+		// Round trip with custom marshaller
+		payload, err := codec.Marshal(trade)
+		require.NoError(suite.T(), err)
+		decoded := new(messages.Trade)
+		require.NoError(suite.T(), codec.Unmarshal(payload, decoded))
+		require.Equal(suite.T(), trade, decoded)
+		// Invalid payload
+		require.Error(suite.T(), codec.Unmarshal([]byte("{"), resp))
+	}
+}
+
+// Test UnmarshalEventData with a provided codec and with the default codec.
+func (suite *JSONCodecUnitTestSuite) TestUnmarshalEventData() {
+	e := event.New()
+	require.NoError(suite.T(), e.SetData("application/json", []byte(tradePayload)))
+	trade := new(messages.Trade)
+	require.NoError(suite.T(), UnmarshalEventData(nil, e, trade))
+	require.Equal(suite.T(), "XBT/USD", trade.Pair)
+	trade = new(messages.Trade)
+	require.NoError(suite.T(), UnmarshalEventData(newJsoniterCodec(), e, trade))
+	require.Equal(suite.T(), "XBT/USD", trade.Pair)
+}
+
+/*************************************************************************************************/
+/* BENCHMARKS                                                                                    */
+/*************************************************************************************************/
+
+// Benchmark parsing a REST trades history response with 50 trades with encoding/json.
+func BenchmarkStandardCodecTradesHistory(b *testing.B) {
+	benchmarkTradesHistory(b, StandardJSONCodec{})
+}
+
+// Benchmark parsing a REST trades history response with 50 trades with jsoniter.
+func BenchmarkJsoniterCodecTradesHistory(b *testing.B) {
+	benchmarkTradesHistory(b, newJsoniterCodec())
+}
+
+// Benchmark parsing an ownTrades message with 50 trades with encoding/json.
+func BenchmarkStandardCodecOwnTrades(b *testing.B) {
+	benchmarkOwnTrades(b, StandardJSONCodec{})
+}
+
+// Benchmark parsing an ownTrades message with 50 trades with jsoniter.
+func BenchmarkJsoniterCodecOwnTrades(b *testing.B) {
+	benchmarkOwnTrades(b, newJsoniterCodec())
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Trade message payload
+const tradePayload = `[0,[["5541.20000","0.15850568","1534614057.321597","s","l",""],["6060.00000","0.02455000","1534614057.324998","b","l",""]],"trade","XBT/USD"]`
+
+// JSON codec which uses jsoniter configured to be compatible with encoding/json.
+type jsoniterCodec struct {
+	api jsoniter.API
+}
+
+// Build a new jsoniter codec.
+func newJsoniterCodec() *jsoniterCodec {
+	return &jsoniterCodec{api: jsoniter.ConfigCompatibleWithStandardLibrary}
+}
+
+// Marshal with jsoniter.
+func (c *jsoniterCodec) Marshal(v interface{}) ([]byte, error) {
+	return c.api.Marshal(v)
+}
+
+// Unmarshal with jsoniter.
+func (c *jsoniterCodec) Unmarshal(data []byte, v interface{}) error {
+	return c.api.Unmarshal(data, v)
+}
+
+// Build a trades history response payload with n trades.
+func tradesHistoryPayload(n int) string {
+	trades := make([]string, n)
+	for i := 0; i < n; i++ {
+		trades[i] = fmt.Sprintf(`"T%d":{"ordertxid":"OQCLML-BW3P3-BUCMWZ","postxid":"TKH2SE-M7IF5-CFI7LT","pair":"XXBTZUSD","time":1688667796.8802,"type":"buy","ordertype":"limit","price":"30000.%d","cost":"300.00000","fee":"0.78000","vol":"0.01000000","margin":"0.00000","misc":"","trade_id":%d,"maker":true}`, i, i+1, i)
+	}
+	return fmt.Sprintf(`{"error":[],"result":{"trades":{%s},"count":%d}}`, strings.Join(trades, ","), n)
+}
+
+// Build an ownTrades message payload with n trades.
+func ownTradesPayload(n int) string {
+	trades := make([]string, n)
+	for i := 0; i < n; i++ {
+		trades[i] = fmt.Sprintf(`{"T%d":{"cost":"1000000.00000","fee":"1600.00000","margin":"0.00000","ordertxid":"TDLH43-DVQXD-2KHVYY","ordertype":"limit","pair":"XBT/EUR","postxid":"OGTT3Y-C6I3P-XRI6HX","price":"100000.00000","time":"1560516023.070651","type":"sell","vol":"1000000000.00000000"}}`, i)
+	}
+	return fmt.Sprintf(`[[%s],"ownTrades",{"sequence":2948}]`, strings.Join(trades, ","))
+}
+
+// Benchmark parsing a trades history response with the provided codec.
+func benchmarkTradesHistory(b *testing.B, codec JSONCodec) {
+	payload := []byte(tradesHistoryPayload(50))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp := new(account.GetTradesHistoryResponse)
+		if err := codec.Unmarshal(payload, resp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Benchmark parsing an ownTrades message with the provided codec.
+func benchmarkOwnTrades(b *testing.B, codec JSONCodec) {
+	payload := []byte(ownTradesPayload(50))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		msg := new(messages.OwnTrades)
+		if err := codec.Unmarshal(payload, msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// Package dryrun provides REST and websocket client wrappers which force validate-only mode on
+// all order placement and edition requests so staging deployments can never place real orders.
+package dryrun
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+/*************************************************************************************************/
+/* MODE                                                                                          */
+/*************************************************************************************************/
+
+// Type of the events published when a request which would have placed or edited a real order is
+// forced to validate-only mode.
+const ValidateOverriddenEventType = "validate_overridden"
+
+// Source of the events published by the dry-run clients.
+const DryRunEventSource = "goctopus.sdk.spot.dryrun"
+
+// Data of a validate_overridden event.
+type ValidateOverridden struct {
+	// Name of the overridden method (ex: AddOrder)
+	Method string `json:"method"`
+	// Pair of the order
+	Pair string `json:"pair,omitempty"`
+	// ID of the edited order. Empty for new orders.
+	OrderId string `json:"orderid,omitempty"`
+}
+
+// # Description
+//
+// Global validate-only switch shared by the dry-run REST and websocket clients.
+//
+// While the mode is enabled, all AddOrder, AddOrderBatch and EditOrder requests made with the
+// dry-run clients are sent with validate=true regardless of their parameters: Kraken validates
+// the requests but does not place or edit any order. Requests which did not set validate=true are
+// sent anyway and a validate_overridden warning event (Cf. ValidateOverridden) is published.
+// AmendOrder requests, which cannot be validated only, are rejected.
+//
+// The mode is safe for concurrent use.
+type Mode struct {
+	// Whether validate-only mode is enabled
+	enabled atomic.Bool
+	// Mutex used to serialize event publication
+	pubMu sync.Mutex
+	// Optional channel used to publish warning events
+	pub chan event.Event
+}
+
+// # Description
+//
+// Build a new validate-only mode switch.
+//
+// # Inputs
+//
+//   - enabled: Initial state of the switch.
+//   - pub: Optional channel used to publish validate_overridden events. Blocking writes are used.
+//     Can be nil.
+//
+// # Return
+//
+// The new validate-only mode switch.
+func NewMode(enabled bool, pub chan event.Event) *Mode {
+	m := &Mode{pub: pub}
+	m.enabled.Store(enabled)
+	return m
+}
+
+// Enable validate-only mode.
+func (m *Mode) Enable() {
+	m.enabled.Store(true)
+}
+
+// Disable validate-only mode: requests are forwarded as is.
+func (m *Mode) Disable() {
+	m.enabled.Store(false)
+}
+
+// Tell whether validate-only mode is enabled.
+func (m *Mode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// # Description
+//
+// Tell whether a request must be forced to validate-only mode and publish a warning event if the
+// request did not set validate=true itself.
+//
+// # Inputs
+//
+//   - validate: Value of the validate flag of the request.
+//   - warning: Data of the warning event.
+//
+// # Return
+//
+// True if the request must be sent with validate=true.
+func (m *Mode) force(validate bool, warning ValidateOverridden) bool {
+	if !m.Enabled() {
+		return false
+	}
+	if !validate {
+		m.publish(warning)
+	}
+	return true
+}
+
+// Publish a validate_overridden event if a channel has been provided.
+func (m *Mode) publish(warning ValidateOverridden) {
+	if m.pub == nil {
+		return
+	}
+	e := event.New()
+	e.SetType(ValidateOverriddenEventType)
+	e.SetSource(DryRunEventSource)
+	e.SetSubject(warning.Method)
+	e.SetData("application/json", warning)
+	m.pubMu.Lock()
+	defer m.pubMu.Unlock()
+	m.pub <- e
+}
+
+/*************************************************************************************************/
+/* WEBSOCKET                                                                                     */
+/*************************************************************************************************/
+
+// Private websocket client which forces validate-only mode on AddOrder and EditOrder requests
+// while the Mode is enabled. All other methods are forwarded to the wrapped client.
+type WebsocketClient struct {
+	// Wrapped client
+	websocket.KrakenSpotPrivateWebsocketClientInterface
+	// Validate-only switch
+	mode *Mode
+}
+
+// # Description
+//
+// Wrap a private websocket client so AddOrder and EditOrder requests are sent with validate=true
+// while the provided mode is enabled.
+//
+// # Inputs
+//
+//   - client: Private websocket client to wrap.
+//   - mode: Validate-only switch. Can be shared with other dry-run clients.
+//
+// # Return
+//
+// The dry-run client.
+func NewWebsocketClient(client websocket.KrakenSpotPrivateWebsocketClientInterface, mode *Mode) *WebsocketClient {
+	return &WebsocketClient{
+		KrakenSpotPrivateWebsocketClientInterface: client,
+		mode: mode,
+	}
+}
+
+// Send the order with validate=true if validate-only mode is enabled. Cf.
+// KrakenSpotPrivateWebsocketClientInterface.AddOrder.
+func (client *WebsocketClient) AddOrder(ctx context.Context, params websocket.AddOrderRequestParameters) (*messages.AddOrderResponse, error) {
+	if client.mode.force(params.Validate, ValidateOverridden{Method: "AddOrder", Pair: params.Pair}) {
+		params.Validate = true
+	}
+	return client.KrakenSpotPrivateWebsocketClientInterface.AddOrder(ctx, params)
+}
+
+// Send the order edition with validate=true if validate-only mode is enabled. Cf.
+// KrakenSpotPrivateWebsocketClientInterface.EditOrder.
+func (client *WebsocketClient) EditOrder(ctx context.Context, params websocket.EditOrderRequestParameters) (*messages.EditOrderResponse, error) {
+	if client.mode.force(params.Validate, ValidateOverridden{Method: "EditOrder", Pair: params.Pair, OrderId: params.Id}) {
+		params.Validate = true
+	}
+	return client.KrakenSpotPrivateWebsocketClientInterface.EditOrder(ctx, params)
+}
+
+/*************************************************************************************************/
+/* REST                                                                                          */
+/*************************************************************************************************/
+
+// REST client which forces validate-only mode on AddOrder, AddOrderBatch and EditOrder requests
+// and which rejects AmendOrder requests while the Mode is enabled. All other methods are forwarded
+// to the wrapped client.
+type RESTClient struct {
+	// Wrapped client
+	rest.KrakenSpotRESTClientIface
+	// Validate-only switch
+	mode *Mode
+}
+
+// # Description
+//
+// Wrap a REST client so AddOrder, AddOrderBatch and EditOrder requests are sent with
+// validate=true and AmendOrder requests are rejected while the provided mode is enabled.
+//
+// # Inputs
+//
+//   - client: REST client to wrap.
+//   - mode: Validate-only switch. Can be shared with other dry-run clients.
+//
+// # Return
+//
+// The dry-run client.
+func NewRESTClient(client rest.KrakenSpotRESTClientIface, mode *Mode) *RESTClient {
+	return &RESTClient{
+		KrakenSpotRESTClientIface: client,
+		mode:                      mode,
+	}
+}
+
+// Send the order with validate=true if validate-only mode is enabled. The provided options are
+// not modified. Cf. KrakenSpotRESTClientIface.AddOrder.
+func (client *RESTClient) AddOrder(ctx context.Context, nonce int64, params trading.AddOrderRequestParameters, opts *trading.AddOrderRequestOptions, secopts *common.SecurityOptions) (*trading.AddOrderResponse, *http.Response, error) {
+	if client.mode.force(opts != nil && opts.Validate, ValidateOverridden{Method: "AddOrder", Pair: params.Pair}) {
+		forced := trading.AddOrderRequestOptions{}
+		if opts != nil {
+			forced = *opts
+		}
+		forced.Validate = true
+		opts = &forced
+	}
+	return client.KrakenSpotRESTClientIface.AddOrder(ctx, nonce, params, opts, secopts)
+}
+
+// Send the batch with validate=true if validate-only mode is enabled. The provided options are
+// not modified. Cf. KrakenSpotRESTClientIface.AddOrderBatch.
+func (client *RESTClient) AddOrderBatch(ctx context.Context, nonce int64, params trading.AddOrderBatchRequestParameters, opts *trading.AddOrderBatchRequestOptions, secopts *common.SecurityOptions) (*trading.AddOrderBatchResponse, *http.Response, error) {
+	if client.mode.force(opts != nil && opts.Validate, ValidateOverridden{Method: "AddOrderBatch", Pair: params.Pair}) {
+		forced := trading.AddOrderBatchRequestOptions{}
+		if opts != nil {
+			forced = *opts
+		}
+		forced.Validate = true
+		opts = &forced
+	}
+	return client.KrakenSpotRESTClientIface.AddOrderBatch(ctx, nonce, params, opts, secopts)
+}
+
+// Send the order edition with validate=true if validate-only mode is enabled. The provided
+// options are not modified. Cf. KrakenSpotRESTClientIface.EditOrder.
+func (client *RESTClient) EditOrder(ctx context.Context, nonce int64, params trading.EditOrderRequestParameters, opts *trading.EditOrderRequestOptions, secopts *common.SecurityOptions) (*trading.EditOrderResponse, *http.Response, error) {
+	if client.mode.force(opts != nil && opts.Validate, ValidateOverridden{Method: "EditOrder", Pair: params.Pair, OrderId: params.Id}) {
+		forced := trading.EditOrderRequestOptions{}
+		if opts != nil {
+			forced = *opts
+		}
+		forced.Validate = true
+		opts = &forced
+	}
+	return client.KrakenSpotRESTClientIface.EditOrder(ctx, nonce, params, opts, secopts)
+}
+
+// Reject the amendment if validate-only mode is enabled: AmendOrder has no validate flag, so the
+// request is not sent to Kraken. Cf. KrakenSpotRESTClientIface.AmendOrder.
+func (client *RESTClient) AmendOrder(ctx context.Context, nonce int64, params trading.AmendOrderRequestParameters, opts *trading.AmendOrderRequestOptions, secopts *common.SecurityOptions) (*trading.AmendOrderResponse, *http.Response, error) {
+	if client.mode.Enabled() {
+		return nil, nil, fmt.Errorf("amend order failed: AmendOrder cannot be validated only and is rejected while validate-only mode is enabled")
+	}
+	return client.KrakenSpotRESTClientIface.AmendOrder(ctx, nonce, params, opts, secopts)
+}
@@ -0,0 +1,143 @@
+package dryrun
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/papertrading"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for dry-run clients
+type DryRunUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestDryRunUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(DryRunUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the dry-run websocket client.
+//
+// Test will ensure:
+//   - Orders are sent with validate=true while the mode is enabled and are not placed.
+//   - A warning event is published when a request does not set validate=true.
+//   - No warning event is published when a request already sets validate=true.
+//   - Requests are forwarded as is once the mode is disabled.
+func (suite *DryRunUnitTestSuite) TestWebsocketClient() {
+	pub := make(chan event.Event, 10)
+	mode := NewMode(true, pub)
+	paper := &editRecorder{KrakenSpotPrivateWebsocketClientInterface: papertrading.NewKrakenSpotPaperTradingClient(nil, 0, nil)}
+	client := NewWebsocketClient(paper, mode)
+	require.Implements(suite.T(), (*websocket.KrakenSpotPrivateWebsocketClientInterface)(nil), client)
+	params := websocket.AddOrderRequestParameters{OrderType: "limit", Type: "buy", Pair: "XBT/USD", Price: "100", Volume: "1"}
+	resp, err := client.AddOrder(context.Background(), params)
+	require.NoError(suite.T(), err)
+	require.Empty(suite.T(), resp.TxId)
+	e := <-pub
+	require.Equal(suite.T(), ValidateOverriddenEventType, e.Type())
+	require.Equal(suite.T(), DryRunEventSource, e.Source())
+	warning := new(ValidateOverridden)
+	require.NoError(suite.T(), e.DataAs(warning))
+	require.Equal(suite.T(), ValidateOverridden{Method: "AddOrder", Pair: "XBT/USD"}, *warning)
+	// Validate already set
+	params.Validate = true
+	_, err = client.AddOrder(context.Background(), params)
+	require.NoError(suite.T(), err)
+	require.Empty(suite.T(), pub)
+	// Edit order
+	_, err = client.EditOrder(context.Background(), websocket.EditOrderRequestParameters{Id: "O1", Pair: "XBT/USD", Price: "101"})
+	require.NoError(suite.T(), err)
+	require.True(suite.T(), paper.validate)
+	require.NoError(suite.T(), (<-pub).DataAs(warning))
+	require.Equal(suite.T(), ValidateOverridden{Method: "EditOrder", Pair: "XBT/USD", OrderId: "O1"}, *warning)
+	// Disabled
+	mode.Disable()
+	require.False(suite.T(), mode.Enabled())
+	params.Validate = false
+	resp, err = client.AddOrder(context.Background(), params)
+	require.NoError(suite.T(), err)
+	require.NotEmpty(suite.T(), resp.TxId)
+	require.Empty(suite.T(), pub)
+}
+
+// Test the dry-run REST client.
+//
+// Test will ensure:
+//   - AddOrder, AddOrderBatch and EditOrder are sent with validate=true while the mode is enabled.
+//   - The options provided by the caller are not modified.
+//   - AmendOrder is rejected and not sent while the mode is enabled.
+//   - A nil publication channel is supported.
+func (suite *DryRunUnitTestSuite) TestRESTClient() {
+	mockClient := rest.NewMockKrakenSpotRESTClient()
+	mockClient.On("AddOrder", mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(func(opts *trading.AddOrderRequestOptions) bool {
+		return opts != nil && opts.Validate
+	}), mock.Anything).Return(&trading.AddOrderResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}}}, nil, nil)
+	mockClient.On("AddOrderBatch", mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(func(opts *trading.AddOrderBatchRequestOptions) bool {
+		return opts != nil && opts.Validate
+	}), mock.Anything).Return(&trading.AddOrderBatchResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}}}, nil, nil)
+	mockClient.On("EditOrder", mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(func(opts *trading.EditOrderRequestOptions) bool {
+		return opts != nil && opts.Validate && opts.Price == "101"
+	}), mock.Anything).Return(&trading.EditOrderResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}}}, nil, nil)
+	client := NewRESTClient(mockClient, NewMode(true, nil))
+	require.Implements(suite.T(), (*rest.KrakenSpotRESTClientIface)(nil), client)
+	_, _, err := client.AddOrder(context.Background(), 1, trading.AddOrderRequestParameters{
+		Pair:  "XXBTZUSD",
+		Order: trading.Order{OrderType: "market", Type: "buy", Volume: "1"},
+	}, nil, nil)
+	require.NoError(suite.T(), err)
+	batchOpts := &trading.AddOrderBatchRequestOptions{}
+	_, _, err = client.AddOrderBatch(context.Background(), 2, trading.AddOrderBatchRequestParameters{Pair: "XXBTZUSD"}, batchOpts, nil)
+	require.NoError(suite.T(), err)
+	require.False(suite.T(), batchOpts.Validate)
+	editOpts := &trading.EditOrderRequestOptions{Price: "101"}
+	_, _, err = client.EditOrder(context.Background(), 3, trading.EditOrderRequestParameters{Id: "O1", Pair: "XXBTZUSD"}, editOpts, nil)
+	require.NoError(suite.T(), err)
+	require.False(suite.T(), editOpts.Validate)
+	mockClient.AssertNumberOfCalls(suite.T(), "AddOrder", 1)
+	mockClient.AssertNumberOfCalls(suite.T(), "AddOrderBatch", 1)
+	mockClient.AssertNumberOfCalls(suite.T(), "EditOrder", 1)
+	// AmendOrder
+	mockClient.On("AmendOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&trading.AmendOrderResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}}}, nil, nil)
+	amend := trading.AmendOrderRequestParameters{TxId: "O1"}
+	_, _, err = client.AmendOrder(context.Background(), 4, amend, &trading.AmendOrderRequestOptions{LimitPrice: "101"}, nil)
+	require.Error(suite.T(), err)
+	mockClient.AssertNotCalled(suite.T(), "AmendOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	client.mode.Disable()
+	_, _, err = client.AmendOrder(context.Background(), 5, amend, &trading.AmendOrderRequestOptions{LimitPrice: "101"}, nil)
+	require.NoError(suite.T(), err)
+	mockClient.AssertNumberOfCalls(suite.T(), "AmendOrder", 1)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Private client which records the validate flag of EditOrder requests.
+type editRecorder struct {
+	websocket.KrakenSpotPrivateWebsocketClientInterface
+	validate bool
+}
+
+func (c *editRecorder) EditOrder(ctx context.Context, params websocket.EditOrderRequestParameters) (*messages.EditOrderResponse, error) {
+	c.validate = params.Validate
+	return &messages.EditOrderResponse{}, nil
+}
@@ -0,0 +1,360 @@
+// Package export converts the trades history (GetTradesHistory) and the ledger entries
+// (GetLedgersInfo) returned by the REST API into common accounting formats: Beancount,
+// ledger-cli and tax software CSV files (TurboTax, generic).
+//
+// Data are first converted into Transaction, a format independent representation where assets
+// have been renamed and values converted into a fiat currency, then written with one of the
+// Write* functions.
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+)
+
+// Enum for transaction types
+type TransactionTypeEnum string
+
+// Values for TransactionTypeEnum
+const (
+	// An asset has been exchanged for another one.
+	TransactionTrade TransactionTypeEnum = "trade"
+	// An asset has been deposited on the account.
+	TransactionDeposit TransactionTypeEnum = "deposit"
+	// An asset has been withdrawn from the account.
+	TransactionWithdrawal TransactionTypeEnum = "withdrawal"
+	// An asset has been received as income (staking rewards, dividends, ...).
+	TransactionIncome TransactionTypeEnum = "income"
+	// An asset has been moved between wallets or accounts (transfer, staking, ...).
+	TransactionTransfer TransactionTypeEnum = "transfer"
+	// An asset has been spent or received for another reason (adjustment, rollover, ...).
+	TransactionOther TransactionTypeEnum = "other"
+)
+
+// Quantity of an asset.
+type Amount struct {
+	// Asset name, after renaming.
+	Asset string
+	// Quantity. Always positive.
+	Quantity float64
+}
+
+// Format independent transaction.
+type Transaction struct {
+	// Transaction ID: trade ID or ledger reference ID.
+	Id string
+	// Time of the transaction.
+	Time time.Time
+	// Type of the transaction.
+	Type TransactionTypeEnum
+	// Description (ex: buy XXBTZUSD, ledger entry type and subtype, ...).
+	Description string
+	// Amount which has left the account. Nil if none.
+	Sent *Amount
+	// Amount which has entered the account. Nil if none.
+	Received *Amount
+	// Fee paid for the transaction. Nil if none.
+	Fee *Amount
+	// Value of the transaction in ValueCurrency.
+	Value float64
+	// Fiat currency of Value (Cf. Config.FiatCurrency). Empty if the value of the transaction
+	// could not be computed.
+	ValueCurrency string
+}
+
+// Base and quote assets of a pair, as named by Kraken (ex: XXBT and ZUSD for XXBTZUSD).
+type PairAssets struct {
+	// Base asset
+	Base string
+	// Quote asset
+	Quote string
+}
+
+// # Description
+//
+// Build the pairs configuration from the tradable asset pairs returned by GetTradableAssetPairs.
+//
+// # Inputs
+//
+//   - pairs: Result of GetTradableAssetPairs.
+//
+// # Return
+//
+// Base and quote assets by pair name.
+func PairsFromAssetPairs(pairs map[string]market.AssetPairInfo) map[string]PairAssets {
+	result := make(map[string]PairAssets, len(pairs))
+	for name, info := range pairs {
+		result[name] = PairAssets{Base: info.Base, Quote: info.Quote}
+	}
+	return result
+}
+
+// Source of historical prices used to compute the fiat value of transactions.
+type PriceSource interface {
+	// # Description
+	//
+	// Get the price of an asset in fiat currency at the provided time.
+	//
+	// # Inputs
+	//
+	//	- asset: Asset name, after renaming.
+	//	- at: Time of the transaction.
+	//
+	// # Return
+	//
+	// The price and true if a price is known for the asset at that time, false otherwise.
+	Price(asset string, at time.Time) (float64, bool)
+}
+
+// Export configuration.
+type Config struct {
+	// Asset renaming (ex: XXBT -> BTC, ZUSD -> USD). Assets which are not in the map keep their
+	// Kraken name.
+	AssetNames map[string]string
+	// Base and quote assets by pair name, used to split trades pairs. Cf. PairsFromAssetPairs.
+	Pairs map[string]PairAssets
+	// Fiat currency used to value transactions, after renaming (ex: USD). Values are not
+	// computed if empty.
+	FiatCurrency string
+	// Optional source of historical prices used to value transactions which do not involve the
+	// fiat currency. Can be nil.
+	Prices PriceSource
+}
+
+// Rename an asset.
+func (cfg *Config) rename(asset string) string {
+	if name, ok := cfg.AssetNames[asset]; ok {
+		return name
+	}
+	return asset
+}
+
+// Build an amount from a raw quantity. Nil is returned for zero quantities.
+func (cfg *Config) amount(asset string, quantity float64) *Amount {
+	if quantity == 0 {
+		return nil
+	}
+	if quantity < 0 {
+		quantity = -quantity
+	}
+	return &Amount{Asset: cfg.rename(asset), Quantity: quantity}
+}
+
+// Compute the fiat value of the transaction from the fiat leg or from the price source.
+func (cfg *Config) value(tx *Transaction) {
+	if cfg.FiatCurrency == "" {
+		return
+	}
+	for _, a := range []*Amount{tx.Received, tx.Sent} {
+		if a != nil && a.Asset == cfg.FiatCurrency {
+			tx.Value, tx.ValueCurrency = a.Quantity, cfg.FiatCurrency
+			return
+		}
+	}
+	if cfg.Prices == nil {
+		return
+	}
+	for _, a := range []*Amount{tx.Received, tx.Sent} {
+		if a == nil {
+			continue
+		}
+		if price, ok := cfg.Prices.Price(a.Asset, tx.Time); ok {
+			tx.Value, tx.ValueCurrency = a.Quantity*price, cfg.FiatCurrency
+			return
+		}
+	}
+}
+
+// # Description
+//
+// Convert trades returned by GetTradesHistory or QueryTradesInfo into transactions.
+//
+// # Inputs
+//
+//   - trades: Trades by trade ID.
+//   - cfg: Export configuration. Pairs must contain the pairs of all trades.
+//
+// # Return
+//
+// The transactions sorted by time or an error if a trade could not be converted.
+func FromTrades(trades map[string]*account.TradeInfo, cfg Config) ([]Transaction, error) {
+	txs := make([]Transaction, 0, len(trades))
+	for id, trade := range trades {
+		if trade == nil {
+			continue
+		}
+		pair, ok := cfg.Pairs[trade.Pair]
+		if !ok {
+			return nil, fmt.Errorf("trade %s: unknown pair %s", id, trade.Pair)
+		}
+		at, err := parseTimestamp(trade.Timestamp.String())
+		if err != nil {
+			return nil, fmt.Errorf("trade %s: %w", id, err)
+		}
+		volume, err := trade.Volume.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("trade %s: failed to parse volume: %w", id, err)
+		}
+		cost, err := trade.Cost.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("trade %s: failed to parse cost: %w", id, err)
+		}
+		fee, err := trade.Fee.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("trade %s: failed to parse fee: %w", id, err)
+		}
+		tx := Transaction{
+			Id:          id,
+			Time:        at,
+			Type:        TransactionTrade,
+			Description: fmt.Sprintf("%s %s", trade.Type, trade.Pair),
+			Fee:         cfg.amount(pair.Quote, fee),
+		}
+		switch trade.Type {
+		case "buy":
+			tx.Sent, tx.Received = cfg.amount(pair.Quote, cost), cfg.amount(pair.Base, volume)
+		case "sell":
+			tx.Sent, tx.Received = cfg.amount(pair.Base, volume), cfg.amount(pair.Quote, cost)
+		default:
+			return nil, fmt.Errorf("trade %s: unknown trade type %s", id, trade.Type)
+		}
+		cfg.value(&tx)
+		txs = append(txs, tx)
+	}
+	sortTransactions(txs)
+	return txs, nil
+}
+
+// # Description
+//
+// Convert ledger entries returned by GetLedgersInfo or QueryLedgers into transactions. Entries
+// which share the same reference ID (ex: both legs of a trade) are merged in a single
+// transaction.
+//
+// # Inputs
+//
+//   - entries: Ledger entries by ledger ID.
+//   - cfg: Export configuration.
+//
+// # Return
+//
+// The transactions sorted by time or an error if an entry could not be converted.
+func FromLedgers(entries map[string]*account.LedgerEntry, cfg Config) ([]Transaction, error) {
+	// Group entries by reference ID
+	groups := map[string][]*account.LedgerEntry{}
+	for id, entry := range entries {
+		if entry == nil {
+			continue
+		}
+		refid := entry.ReferenceId
+		if refid == "" {
+			refid = id
+		}
+		groups[refid] = append(groups[refid], entry)
+	}
+	txs := make([]Transaction, 0, len(groups))
+	for refid, group := range groups {
+		tx := Transaction{Id: refid}
+		descriptions := []string{}
+		for _, entry := range group {
+			at, err := parseTimestamp(entry.Timestamp.String())
+			if err != nil {
+				return nil, fmt.Errorf("ledger %s: %w", refid, err)
+			}
+			if tx.Time.IsZero() || at.Before(tx.Time) {
+				tx.Time = at
+			}
+			amount, err := entry.Amount.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("ledger %s: failed to parse amount: %w", refid, err)
+			}
+			fee, err := entry.Fee.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("ledger %s: failed to parse fee: %w", refid, err)
+			}
+			if amount < 0 {
+				tx.Sent = cfg.amount(entry.Asset, amount)
+			} else if amount > 0 {
+				tx.Received = cfg.amount(entry.Asset, amount)
+			}
+			if fee != 0 {
+				tx.Fee = cfg.amount(entry.Asset, fee)
+			}
+			description := entry.Type
+			if entry.SubType != "" {
+				description = description + " " + entry.SubType
+			}
+			descriptions = append(descriptions, description)
+			tx.Type = mergeTypes(tx.Type, ledgerType(entry))
+		}
+		sort.Strings(descriptions)
+		tx.Description = strings.Join(dedup(descriptions), ", ")
+		cfg.value(&tx)
+		txs = append(txs, tx)
+	}
+	sortTransactions(txs)
+	return txs, nil
+}
+
+// Map a ledger entry type to a transaction type.
+func ledgerType(entry *account.LedgerEntry) TransactionTypeEnum {
+	switch entry.GetType() {
+	case account.EntryTypeTrade, account.EntryTypeSpend, account.EntryTypeReceive, account.EntryTypeConverion, account.EntryTypeSale:
+		return TransactionTrade
+	case account.EntryTypeDeposit:
+		return TransactionDeposit
+	case account.EntryTypeWithdrawal:
+		return TransactionWithdrawal
+	case account.EntryTypeReward, account.EntryTypeDividend, account.EntryTypeCredit:
+		return TransactionIncome
+	case account.EntryTypeTransfer, account.EntryTypeStaking, account.EntryTypeCustodyTransfer:
+		return TransactionTransfer
+	default:
+		return TransactionOther
+	}
+}
+
+// Merge the types of entries which share a reference ID: a trade wins over other types.
+func mergeTypes(current TransactionTypeEnum, next TransactionTypeEnum) TransactionTypeEnum {
+	if current == "" || next == TransactionTrade {
+		return next
+	}
+	return current
+}
+
+// Parse a unix timestamp with optional decimals.
+func parseTimestamp(raw string) (time.Time, error) {
+	ts, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse timestamp %q: %w", raw, err)
+	}
+	sec := int64(ts)
+	return time.Unix(sec, int64((ts-float64(sec))*1e9)).UTC(), nil
+}
+
+// Sort transactions by time, then by ID.
+func sortTransactions(txs []Transaction) {
+	sort.Slice(txs, func(i, j int) bool {
+		if txs[i].Time.Equal(txs[j].Time) {
+			return txs[i].Id < txs[j].Id
+		}
+		return txs[i].Time.Before(txs[j].Time)
+	})
+}
+
+// Remove consecutive duplicates from a sorted slice.
+func dedup(values []string) []string {
+	result := values[:0]
+	for i, v := range values {
+		if i == 0 || v != values[i-1] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,146 @@
+package export
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the conversion of trades and ledger entries into transactions.
+type ExportUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestExportUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(ExportUnitTestSuite))
+}
+
+// Configuration used by tests.
+func testConfig() Config {
+	return Config{
+		AssetNames:   map[string]string{"XXBT": "BTC", "ZUSD": "USD", "XETH": "ETH"},
+		Pairs:        map[string]PairAssets{"XXBTZUSD": {Base: "XXBT", Quote: "ZUSD"}, "XETHXXBT": {Base: "XETH", Quote: "XXBT"}},
+		FiatCurrency: "USD",
+	}
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test FromTrades.
+//
+// Test will ensure:
+//   - Buy and sell trades are split into sent and received amounts with renamed assets.
+//   - Fees are expressed in the quote asset.
+//   - Values are taken from the fiat leg or from the price source.
+//   - Transactions are sorted by time.
+//   - An error is returned for unknown pairs.
+func (suite *ExportUnitTestSuite) TestFromTrades() {
+	cfg := testConfig()
+	prices := NewOHLCPriceSource()
+	require.NoError(suite.T(), prices.Add("BTC", market.M60, []market.OHLC{{Timestamp: 1688662800, Close: "30000"}}))
+	cfg.Prices = prices
+	trades := map[string]*account.TradeInfo{
+		"T2": {Pair: "XXBTZUSD", Timestamp: json.Number("1688662000.5"), Type: "sell", Price: "30000", Cost: "15000", Fee: "24", Volume: "0.5"},
+		"T1": {Pair: "XXBTZUSD", Timestamp: json.Number("1688661000"), Type: "buy", Price: "29000", Cost: "29000", Fee: "46.4", Volume: "1"},
+		"T3": {Pair: "XETHXXBT", Timestamp: json.Number("1688663000"), Type: "buy", Price: "0.06", Cost: "0.06", Fee: "0.0001", Volume: "1"},
+	}
+	txs, err := FromTrades(trades, cfg)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), txs, 3)
+	require.Equal(suite.T(), "T1", txs[0].Id)
+	require.Equal(suite.T(), TransactionTrade, txs[0].Type)
+	require.Equal(suite.T(), "buy XXBTZUSD", txs[0].Description)
+	require.Equal(suite.T(), &Amount{Asset: "USD", Quantity: 29000}, txs[0].Sent)
+	require.Equal(suite.T(), &Amount{Asset: "BTC", Quantity: 1}, txs[0].Received)
+	require.Equal(suite.T(), &Amount{Asset: "USD", Quantity: 46.4}, txs[0].Fee)
+	require.Equal(suite.T(), "USD", txs[0].ValueCurrency)
+	require.InDelta(suite.T(), 29000, txs[0].Value, 1e-9)
+	require.Equal(suite.T(), "T2", txs[1].Id)
+	require.Equal(suite.T(), time.Unix(1688662000, 500000000).UTC(), txs[1].Time)
+	require.Equal(suite.T(), &Amount{Asset: "BTC", Quantity: 0.5}, txs[1].Sent)
+	require.Equal(suite.T(), &Amount{Asset: "USD", Quantity: 15000}, txs[1].Received)
+	// ETH has no price: value computed from the BTC leg
+	require.Equal(suite.T(), "T3", txs[2].Id)
+	require.Equal(suite.T(), "USD", txs[2].ValueCurrency)
+	require.InDelta(suite.T(), 1800, txs[2].Value, 1e-9)
+	// Unknown pair
+	_, err = FromTrades(map[string]*account.TradeInfo{"T4": {Pair: "XLTCZUSD", Timestamp: "1", Type: "buy", Cost: "1", Fee: "0", Volume: "1"}}, cfg)
+	require.Error(suite.T(), err)
+}
+
+// Test FromLedgers.
+//
+// Test will ensure:
+//   - Entries which share a reference ID are merged in a single trade transaction.
+//   - Deposits, withdrawals and staking rewards are converted with the right type.
+//   - Transactions which cannot be valued have no value currency.
+func (suite *ExportUnitTestSuite) TestFromLedgers() {
+	entries := map[string]*account.LedgerEntry{
+		"L1": {ReferenceId: "D1", Timestamp: "1688660000", Type: "deposit", Asset: "ZUSD", Amount: "50000", Fee: "0", Balance: "50000"},
+		"L2": {ReferenceId: "T1", Timestamp: "1688661000", Type: "trade", Asset: "ZUSD", Amount: "-29000", Fee: "46.4", Balance: "20953.6"},
+		"L3": {ReferenceId: "T1", Timestamp: "1688661000", Type: "trade", Asset: "XXBT", Amount: "1", Fee: "0", Balance: "1"},
+		"L4": {ReferenceId: "R1", Timestamp: "1688662000", Type: "staking", SubType: "reward", Asset: "DOT.S", Amount: "0.1", Fee: "0", Balance: "0.1"},
+		"L5": {ReferenceId: "W1", Timestamp: "1688663000", Type: "withdrawal", Asset: "XXBT", Amount: "-0.5", Fee: "0.0001", Balance: "0.4999"},
+		"L6": {ReferenceId: "I1", Timestamp: "1688664000", Type: "reward", Asset: "DOT.S", Amount: "0.2", Fee: "0", Balance: "0.3"},
+	}
+	txs, err := FromLedgers(entries, testConfig())
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), txs, 5)
+	require.Equal(suite.T(), TransactionDeposit, txs[0].Type)
+	require.Equal(suite.T(), &Amount{Asset: "USD", Quantity: 50000}, txs[0].Received)
+	require.Nil(suite.T(), txs[0].Sent)
+	require.Equal(suite.T(), "T1", txs[1].Id)
+	require.Equal(suite.T(), TransactionTrade, txs[1].Type)
+	require.Equal(suite.T(), "trade", txs[1].Description)
+	require.Equal(suite.T(), &Amount{Asset: "USD", Quantity: 29000}, txs[1].Sent)
+	require.Equal(suite.T(), &Amount{Asset: "BTC", Quantity: 1}, txs[1].Received)
+	require.Equal(suite.T(), &Amount{Asset: "USD", Quantity: 46.4}, txs[1].Fee)
+	require.InDelta(suite.T(), 29000, txs[1].Value, 1e-9)
+	require.Equal(suite.T(), TransactionTransfer, txs[2].Type)
+	require.Equal(suite.T(), "staking reward", txs[2].Description)
+	require.Equal(suite.T(), TransactionWithdrawal, txs[3].Type)
+	require.Equal(suite.T(), &Amount{Asset: "BTC", Quantity: 0.5}, txs[3].Sent)
+	require.Equal(suite.T(), &Amount{Asset: "BTC", Quantity: 0.0001}, txs[3].Fee)
+	require.Empty(suite.T(), txs[3].ValueCurrency)
+	require.Equal(suite.T(), TransactionIncome, txs[4].Type)
+}
+
+// Test OHLCPriceSource.
+//
+// Test will ensure:
+//   - The close price of the indicator which covers the provided time is returned.
+//   - No price is returned before the first indicator or after the last one.
+//   - Data with a different interval are rejected.
+func (suite *ExportUnitTestSuite) TestOHLCPriceSource() {
+	prices := NewOHLCPriceSource()
+	require.NoError(suite.T(), prices.Add("BTC", market.M60, []market.OHLC{
+		{Timestamp: 7200, Close: "102"},
+		{Timestamp: 3600, Close: "101"},
+	}))
+	_, ok := prices.Price("BTC", time.Unix(3599, 0))
+	require.False(suite.T(), ok)
+	price, ok := prices.Price("BTC", time.Unix(3600, 0))
+	require.True(suite.T(), ok)
+	require.Equal(suite.T(), 101.0, price)
+	price, ok = prices.Price("BTC", time.Unix(10799, 0))
+	require.True(suite.T(), ok)
+	require.Equal(suite.T(), 102.0, price)
+	_, ok = prices.Price("BTC", time.Unix(10800, 0))
+	require.False(suite.T(), ok)
+	_, ok = prices.Price("ETH", time.Unix(3600, 0))
+	require.False(suite.T(), ok)
+	require.Error(suite.T(), prices.Add("BTC", market.M1440, []market.OHLC{{Timestamp: 86400, Close: "1"}}))
+	require.Error(suite.T(), prices.Add("ETH", market.M60, []market.OHLC{{Timestamp: 3600, Close: "abc"}}))
+}
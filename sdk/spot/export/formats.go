@@ -0,0 +1,336 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/*************************************************************************************************/
+/* PLAIN TEXT ACCOUNTING                                                                         */
+/*************************************************************************************************/
+
+// Accounts used by the plain text accounting formats (Beancount, ledger-cli).
+type Accounts struct {
+	// Parent account of the Kraken balances. One sub-account is used by asset
+	// (ex: Assets:Kraken:BTC).
+	Assets string
+	// Account used to book fees.
+	Fees string
+	// Parent account of income (staking rewards, ...). One sub-account is used by asset.
+	Income string
+	// Counterpart account of deposits, withdrawals and transfers.
+	External string
+}
+
+// Default accounts used by the plain text accounting formats.
+var DefaultAccounts = Accounts{
+	Assets:   "Assets:Kraken",
+	Fees:     "Expenses:Fees:Kraken",
+	Income:   "Income:Kraken",
+	External: "Equity:External:Kraken",
+}
+
+// A posting of a plain text accounting transaction.
+type posting struct {
+	// Account
+	account string
+	// Signed quantity
+	quantity float64
+	// Asset
+	asset string
+	// Optional total price
+	price *Amount
+}
+
+// Build the postings of a transaction.
+func (accounts Accounts) postings(tx Transaction) []posting {
+	postings := []posting{}
+	if tx.Received != nil {
+		p := posting{account: subAccount(accounts.Assets, tx.Received.Asset), quantity: tx.Received.Quantity, asset: tx.Received.Asset}
+		if tx.Type == TransactionTrade && tx.Sent != nil {
+			p.price = tx.Sent
+		}
+		postings = append(postings, p)
+	}
+	if tx.Sent != nil {
+		postings = append(postings, posting{account: subAccount(accounts.Assets, tx.Sent.Asset), quantity: -tx.Sent.Quantity, asset: tx.Sent.Asset})
+	}
+	// Counterpart of single leg transactions
+	switch {
+	case tx.Received != nil && tx.Sent == nil:
+		account := accounts.External
+		if tx.Type == TransactionIncome {
+			account = subAccount(accounts.Income, tx.Received.Asset)
+		}
+		postings = append(postings, posting{account: account, quantity: -tx.Received.Quantity, asset: tx.Received.Asset})
+	case tx.Sent != nil && tx.Received == nil:
+		postings = append(postings, posting{account: accounts.External, quantity: tx.Sent.Quantity, asset: tx.Sent.Asset})
+	}
+	if tx.Fee != nil {
+		postings = append(postings,
+			posting{account: accounts.Fees, quantity: tx.Fee.Quantity, asset: tx.Fee.Asset},
+			posting{account: subAccount(accounts.Assets, tx.Fee.Asset), quantity: -tx.Fee.Quantity, asset: tx.Fee.Asset})
+	}
+	return postings
+}
+
+// # Description
+//
+// Write transactions in the Beancount format. The transaction ID and value are written as
+// metadata. Trades are balanced with a total price annotation (@@) on the received asset.
+//
+// # Inputs
+//
+//   - w: Writer to use.
+//   - txs: Transactions to write.
+//   - accounts: Accounts to use. Cf. DefaultAccounts.
+//
+// # Return
+//
+// An error if data could not be written.
+func WriteBeancount(w io.Writer, txs []Transaction, accounts Accounts) error {
+	for _, tx := range txs {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s * \"Kraken\" %s\n", tx.Time.Format("2006-01-02"), strconv.Quote(tx.Description))
+		fmt.Fprintf(&b, "  id: %s\n", strconv.Quote(tx.Id))
+		if tx.ValueCurrency != "" {
+			fmt.Fprintf(&b, "  value: %s %s\n", formatValue(tx.Value), beancountCommodity(tx.ValueCurrency))
+		}
+		for _, p := range accounts.postings(tx) {
+			fmt.Fprintf(&b, "  %s  %s %s", p.account, formatQuantity(p.quantity), beancountCommodity(p.asset))
+			if p.price != nil {
+				fmt.Fprintf(&b, " @@ %s %s", formatQuantity(p.price.Quantity), beancountCommodity(p.price.Asset))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return fmt.Errorf("failed to write transaction %s: %w", tx.Id, err)
+		}
+	}
+	return nil
+}
+
+// # Description
+//
+// Write transactions in the ledger-cli format. The transaction ID and value are written as
+// metadata. Trades are balanced with a total price annotation (@@) on the received asset.
+//
+// # Inputs
+//
+//   - w: Writer to use.
+//   - txs: Transactions to write.
+//   - accounts: Accounts to use. Cf. DefaultAccounts.
+//
+// # Return
+//
+// An error if data could not be written.
+func WriteLedger(w io.Writer, txs []Transaction, accounts Accounts) error {
+	for _, tx := range txs {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s * Kraken - %s\n", tx.Time.Format("2006/01/02"), tx.Description)
+		fmt.Fprintf(&b, "    ; id: %s\n", tx.Id)
+		if tx.ValueCurrency != "" {
+			fmt.Fprintf(&b, "    ; value: %s %s\n", formatValue(tx.Value), tx.ValueCurrency)
+		}
+		for _, p := range accounts.postings(tx) {
+			fmt.Fprintf(&b, "    %s  %s %s", p.account, formatQuantity(p.quantity), ledgerCommodity(p.asset))
+			if p.price != nil {
+				fmt.Fprintf(&b, " @@ %s %s", formatQuantity(p.price.Quantity), ledgerCommodity(p.price.Asset))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return fmt.Errorf("failed to write transaction %s: %w", tx.Id, err)
+		}
+	}
+	return nil
+}
+
+// Build a sub-account from a parent account and an asset name.
+func subAccount(parent string, asset string) string {
+	component := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '-'
+	}, asset)
+	if component == "" {
+		return parent
+	}
+	runes := []rune(component)
+	runes[0] = unicode.ToUpper(runes[0])
+	return parent + ":" + string(runes)
+}
+
+// Format an asset name as a Beancount commodity: uppercase letters, digits and ' . _ - only.
+func beancountCommodity(asset string) string {
+	return strings.Map(func(r rune) rune {
+		r = unicode.ToUpper(r)
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || strings.ContainsRune("'._-", r) {
+			return r
+		}
+		return '-'
+	}, asset)
+}
+
+// Format an asset name as a ledger-cli commodity: commodities which contain other characters
+// than letters must be quoted.
+func ledgerCommodity(asset string) string {
+	for _, r := range asset {
+		if !unicode.IsLetter(r) {
+			return strconv.Quote(asset)
+		}
+	}
+	return asset
+}
+
+/*************************************************************************************************/
+/* CSV                                                                                           */
+/*************************************************************************************************/
+
+// Header of the TurboTax universal crypto CSV format.
+var turboTaxCSVHeader = []string{
+	"Date", "Type", "Sent Asset", "Sent Amount", "Received Asset", "Received Amount",
+	"Fee Asset", "Fee Amount", "Market Value Currency", "Market Value", "Description",
+	"Transaction Hash", "Transaction ID",
+}
+
+// Header of the generic crypto tax CSV format (used by Koinly and other tax software).
+var genericCSVHeader = []string{
+	"Date", "Sent Amount", "Sent Currency", "Received Amount", "Received Currency",
+	"Fee Amount", "Fee Currency", "Net Worth Amount", "Net Worth Currency", "Label",
+	"Description", "TxHash",
+}
+
+// # Description
+//
+// Write transactions in the TurboTax universal crypto CSV format. Trades from fiat are written as
+// Buy, trades to fiat as Sale and other trades as Convert.
+//
+// # Inputs
+//
+//   - w: Writer to use.
+//   - txs: Transactions to write.
+//
+// # Return
+//
+// An error if data could not be written.
+func WriteTurboTaxCSV(w io.Writer, txs []Transaction) error {
+	return writeCSV(w, turboTaxCSVHeader, txs, func(tx Transaction) []string {
+		row := []string{tx.Time.Format("2006-01-02 15:04:05"), turboTaxType(tx)}
+		row = append(row, assetAndQuantity(tx.Sent)...)
+		row = append(row, assetAndQuantity(tx.Received)...)
+		row = append(row, assetAndQuantity(tx.Fee)...)
+		value := ""
+		if tx.ValueCurrency != "" {
+			value = formatValue(tx.Value)
+		}
+		return append(row, tx.ValueCurrency, value, tx.Description, "", tx.Id)
+	})
+}
+
+// # Description
+//
+// Write transactions in the generic crypto tax CSV format (Koinly universal format). Income
+// transactions are labeled as reward.
+//
+// # Inputs
+//
+//   - w: Writer to use.
+//   - txs: Transactions to write.
+//
+// # Return
+//
+// An error if data could not be written.
+func WriteGenericCSV(w io.Writer, txs []Transaction) error {
+	return writeCSV(w, genericCSVHeader, txs, func(tx Transaction) []string {
+		row := []string{tx.Time.Format("2006-01-02 15:04:05 UTC")}
+		row = append(row, quantityAndAsset(tx.Sent)...)
+		row = append(row, quantityAndAsset(tx.Received)...)
+		row = append(row, quantityAndAsset(tx.Fee)...)
+		value := ""
+		if tx.ValueCurrency != "" {
+			value = formatValue(tx.Value)
+		}
+		label := ""
+		if tx.Type == TransactionIncome {
+			label = "reward"
+		}
+		return append(row, value, tx.ValueCurrency, label, tx.Description, tx.Id)
+	})
+}
+
+// Write a CSV file with the provided header and one row by transaction.
+func writeCSV(w io.Writer, header []string, txs []Transaction, row func(Transaction) []string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, tx := range txs {
+		if err := writer.Write(row(tx)); err != nil {
+			return fmt.Errorf("failed to write transaction %s: %w", tx.Id, err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Get the TurboTax transaction type.
+func turboTaxType(tx Transaction) string {
+	switch tx.Type {
+	case TransactionTrade:
+		switch {
+		case tx.ValueCurrency != "" && tx.Sent != nil && tx.Sent.Asset == tx.ValueCurrency:
+			return "Buy"
+		case tx.ValueCurrency != "" && tx.Received != nil && tx.Received.Asset == tx.ValueCurrency:
+			return "Sale"
+		default:
+			return "Convert"
+		}
+	case TransactionDeposit:
+		return "Deposit"
+	case TransactionWithdrawal:
+		return "Withdrawal"
+	case TransactionIncome:
+		return "Income"
+	case TransactionTransfer:
+		return "Transfer"
+	default:
+		if tx.Received != nil {
+			return "Deposit"
+		}
+		return "Withdrawal"
+	}
+}
+
+// Format an amount as asset and quantity columns. Empty columns are returned for nil amounts.
+func assetAndQuantity(a *Amount) []string {
+	if a == nil {
+		return []string{"", ""}
+	}
+	return []string{a.Asset, formatQuantity(a.Quantity)}
+}
+
+// Format an amount as quantity and asset columns. Empty columns are returned for nil amounts.
+func quantityAndAsset(a *Amount) []string {
+	if a == nil {
+		return []string{"", ""}
+	}
+	return []string{formatQuantity(a.Quantity), a.Asset}
+}
+
+// Format a quantity without exponent and with the minimal number of decimals.
+func formatQuantity(quantity float64) string {
+	return strconv.FormatFloat(quantity, 'f', -1, 64)
+}
+
+// Format a fiat value with 2 decimals.
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
@@ -0,0 +1,127 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the export formats.
+type FormatsUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestFormatsUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(FormatsUnitTestSuite))
+}
+
+// Transactions used by tests.
+func testTransactions() []Transaction {
+	return []Transaction{
+		{
+			Id:            "T1",
+			Time:          time.Date(2023, 7, 6, 16, 30, 0, 0, time.UTC),
+			Type:          TransactionTrade,
+			Description:   "buy XXBTZUSD",
+			Sent:          &Amount{Asset: "USD", Quantity: 29000},
+			Received:      &Amount{Asset: "BTC", Quantity: 1},
+			Fee:           &Amount{Asset: "USD", Quantity: 46.4},
+			Value:         29000,
+			ValueCurrency: "USD",
+		},
+		{
+			Id:          "R1",
+			Time:        time.Date(2023, 7, 7, 0, 0, 0, 0, time.UTC),
+			Type:        TransactionIncome,
+			Description: "reward",
+			Received:    &Amount{Asset: "DOT.S", Quantity: 0.1},
+		},
+	}
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test WriteBeancount.
+//
+// Test will ensure:
+//   - Trades are balanced with a total price annotation and fees are booked.
+//   - Income is booked against the income account of the asset.
+//   - Asset names are sanitized in accounts.
+func (suite *FormatsUnitTestSuite) TestWriteBeancount() {
+	var b strings.Builder
+	require.NoError(suite.T(), WriteBeancount(&b, testTransactions(), DefaultAccounts))
+	expected := `2023-07-06 * "Kraken" "buy XXBTZUSD"
+  id: "T1"
+  value: 29000.00 USD
+  Assets:Kraken:BTC  1 BTC @@ 29000 USD
+  Assets:Kraken:USD  -29000 USD
+  Expenses:Fees:Kraken  46.4 USD
+  Assets:Kraken:USD  -46.4 USD
+
+2023-07-07 * "Kraken" "reward"
+  id: "R1"
+  Assets:Kraken:DOT-S  0.1 DOT.S
+  Income:Kraken:DOT-S  -0.1 DOT.S
+
+`
+	require.Equal(suite.T(), expected, b.String())
+}
+
+// Test WriteLedger.
+//
+// Test will ensure:
+//   - Transactions are written in the ledger-cli format.
+//   - Commodities which contain other characters than letters are quoted.
+func (suite *FormatsUnitTestSuite) TestWriteLedger() {
+	var b strings.Builder
+	require.NoError(suite.T(), WriteLedger(&b, testTransactions(), DefaultAccounts))
+	expected := `2023/07/06 * Kraken - buy XXBTZUSD
+    ; id: T1
+    ; value: 29000.00 USD
+    Assets:Kraken:BTC  1 BTC @@ 29000 USD
+    Assets:Kraken:USD  -29000 USD
+    Expenses:Fees:Kraken  46.4 USD
+    Assets:Kraken:USD  -46.4 USD
+
+2023/07/07 * Kraken - reward
+    ; id: R1
+    Assets:Kraken:DOT-S  0.1 "DOT.S"
+    Income:Kraken:DOT-S  -0.1 "DOT.S"
+
+`
+	require.Equal(suite.T(), expected, b.String())
+}
+
+// Test WriteTurboTaxCSV and WriteGenericCSV.
+//
+// Test will ensure:
+//   - A header is written.
+//   - Trades from fiat are written as Buy and income as Income (TurboTax).
+//   - Income is labeled as reward (generic).
+//   - Unknown values are written as empty columns.
+func (suite *FormatsUnitTestSuite) TestWriteCSV() {
+	var b strings.Builder
+	require.NoError(suite.T(), WriteTurboTaxCSV(&b, testTransactions()))
+	expected := `Date,Type,Sent Asset,Sent Amount,Received Asset,Received Amount,Fee Asset,Fee Amount,Market Value Currency,Market Value,Description,Transaction Hash,Transaction ID
+2023-07-06 16:30:00,Buy,USD,29000,BTC,1,USD,46.4,USD,29000.00,buy XXBTZUSD,,T1
+2023-07-07 00:00:00,Income,,,DOT.S,0.1,,,,,reward,,R1
+`
+	require.Equal(suite.T(), expected, b.String())
+	b.Reset()
+	require.NoError(suite.T(), WriteGenericCSV(&b, testTransactions()))
+	expected = `Date,Sent Amount,Sent Currency,Received Amount,Received Currency,Fee Amount,Fee Currency,Net Worth Amount,Net Worth Currency,Label,Description,TxHash
+2023-07-06 16:30:00 UTC,29000,USD,1,BTC,46.4,USD,29000.00,USD,,buy XXBTZUSD,T1
+2023-07-07 00:00:00 UTC,,,0.1,DOT.S,,,,,reward,reward,R1
+`
+	require.Equal(suite.T(), expected, b.String())
+}
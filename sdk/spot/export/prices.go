@@ -0,0 +1,90 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+)
+
+// A close price valid during a period.
+type pricePoint struct {
+	// Start of the period (unix seconds)
+	start int64
+	// Close price
+	price float64
+}
+
+// # Description
+//
+// PriceSource which uses historical OHLC data (Cf. GetOHLCData) of asset/fiat pairs: the price of
+// an asset at a given time is the close price of the OHLC indicator which covers that time.
+//
+// OHLCPriceSource is not safe for concurrent updates: add all data before exporting.
+type OHLCPriceSource struct {
+	// Prices sorted by start time, by asset
+	prices map[string][]pricePoint
+	// Duration covered by each indicator, by asset
+	intervals map[string]int64
+}
+
+// Build an empty OHLCPriceSource.
+func NewOHLCPriceSource() *OHLCPriceSource {
+	return &OHLCPriceSource{
+		prices:    map[string][]pricePoint{},
+		intervals: map[string]int64{},
+	}
+}
+
+// # Description
+//
+// Add the OHLC data of an asset/fiat pair to the source.
+//
+// # Inputs
+//
+//   - asset: Asset name, after renaming (Cf. Config.AssetNames).
+//   - interval: Interval of the OHLC data. Data for the same asset must use the same interval.
+//   - data: OHLC data returned by GetOHLCData for the asset/fiat pair.
+//
+// # Return
+//
+// An error if a close price could not be parsed or if the interval does not match previously
+// added data.
+func (s *OHLCPriceSource) Add(asset string, interval market.OHLCIntervalEnum, data []market.OHLC) error {
+	seconds := int64(interval) * 60
+	if current, ok := s.intervals[asset]; ok && current != seconds {
+		return fmt.Errorf("OHLC data for %s already use a %d minutes interval", asset, current/60)
+	}
+	points := s.prices[asset]
+	for _, ohlc := range data {
+		price, err := strconv.ParseFloat(ohlc.Close, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse close price %q for %s: %w", ohlc.Close, asset, err)
+		}
+		points = append(points, pricePoint{start: ohlc.Timestamp, price: price})
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].start < points[j].start })
+	s.prices[asset] = points
+	s.intervals[asset] = seconds
+	return nil
+}
+
+// # Description
+//
+// Get the close price of the OHLC indicator which covers the provided time.
+//
+// # Return
+//
+// The price and true if an indicator covers the provided time, false otherwise.
+func (s *OHLCPriceSource) Price(asset string, at time.Time) (float64, bool) {
+	points := s.prices[asset]
+	ts := at.Unix()
+	// Index of the first indicator which starts after the provided time
+	i := sort.Search(len(points), func(i int) bool { return points[i].start > ts })
+	if i == 0 || ts >= points[i-1].start+s.intervals[asset] {
+		return 0, false
+	}
+	return points[i-1].price, true
+}
@@ -0,0 +1,161 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for fixtures
+type FixturesUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestFixturesUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(FixturesUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test websocket fixtures are valid messages.
+//
+// Test will ensure each fixture has the expected message type and can be parsed into the
+// corresponding message struct.
+func (suite *FixturesUnitTestSuite) TestWebsocketFixtures() {
+	cases := []struct {
+		payload string
+		mType   string
+		target  interface{}
+	}{
+		{WebsocketHeartbeat, "heartbeat", new(messages.Heartbeat)},
+		{WebsocketPong, "pong", new(messages.Pong)},
+		{WebsocketSystemStatusOnline, "systemStatus", new(messages.SystemStatus)},
+		{WebsocketSystemStatusMaintenance, "systemStatus", new(messages.SystemStatus)},
+		{WebsocketSubscriptionStatusTickerSubscribed, "subscriptionStatus", new(messages.SubscriptionStatus)},
+		{WebsocketSubscriptionStatusBookSubscribed, "subscriptionStatus", new(messages.SubscriptionStatus)},
+		{WebsocketSubscriptionStatusOHLCUnsubscribed, "subscriptionStatus", new(messages.SubscriptionStatus)},
+		{WebsocketSubscriptionStatusError, "subscriptionStatus", new(messages.SubscriptionStatus)},
+		{WebsocketError, "error", new(messages.ErrorMessage)},
+		{WebsocketTicker, "ticker", new(messages.Ticker)},
+		{WebsocketOHLC, "ohlc-5", new(messages.OHLC)},
+		{WebsocketTrade, "trade", new(messages.Trade)},
+		{WebsocketSpread, "spread", new(messages.Spread)},
+		{WebsocketBookSnapshot, "book-10", new(messages.BookSnapshot)},
+		{WebsocketBookSnapshotTruncated, "book-10", new(messages.BookSnapshot)},
+		{WebsocketBookUpdate, "book-10", new(messages.BookUpdate)},
+		{WebsocketBookUpdateAsksOnly, "book-10", new(messages.BookUpdate)},
+		{WebsocketBookUpdateRepublish, "book-25", new(messages.BookUpdate)},
+		{WebsocketOpenOrders, "openOrders", new(messages.OpenOrders)},
+		{WebsocketOpenOrdersStatusChange, "openOrders", new(messages.OpenOrders)},
+		{WebsocketOwnTrades, "ownTrades", new(messages.OwnTrades)},
+		{WebsocketAddOrderStatus, "addOrderStatus", new(messages.AddOrderResponse)},
+		{WebsocketAddOrderStatusError, "addOrderStatus", new(messages.AddOrderResponse)},
+		{WebsocketEditOrderStatus, "editOrderStatus", new(messages.EditOrderResponse)},
+		{WebsocketCancelOrderStatus, "cancelOrderStatus", new(messages.CancelOrderResponse)},
+		{WebsocketCancelOrderStatusError, "cancelOrderStatus", new(messages.CancelOrderResponse)},
+		{WebsocketCancelAllStatus, "cancelAllStatus", new(messages.CancelAllOrdersResponse)},
+		{WebsocketCancelAllOrdersAfterStatus, "cancelAllOrdersAfterStatus", new(messages.CancelAllOrdersAfterXResponse)},
+	}
+	for _, tc := range cases {
+		mType, _, err := messages.SniffMessageType([]byte(tc.payload))
+		require.NoError(suite.T(), err, tc.payload)
+		require.Equal(suite.T(), tc.mType, mType, tc.payload)
+		require.NoError(suite.T(), json.Unmarshal([]byte(tc.payload), tc.target), tc.payload)
+	}
+}
+
+// Test REST fixtures are valid responses.
+//
+// Test will ensure each fixture can be parsed into the corresponding response struct and that
+// error fixtures have errors.
+func (suite *FixturesUnitTestSuite) TestRESTFixtures() {
+	cases := []struct {
+		payload string
+		target  interface{}
+	}{
+		{RESTServerTime, new(market.GetServerTimeResponse)},
+		{RESTSystemStatus, new(market.GetSystemStatusResponse)},
+		{RESTAssetInfo, new(market.GetAssetInfoResponse)},
+		{RESTTradableAssetPairs, new(market.GetTradableAssetPairsResponse)},
+		{RESTTickerInformation, new(market.GetTickerInformationResponse)},
+		{RESTOHLCData, new(market.GetOHLCDataResponse)},
+		{RESTOrderBook, new(market.GetOrderBookResponse)},
+		{RESTOrderBookTruncated, new(market.GetOrderBookResponse)},
+		{RESTRecentTrades, new(market.GetRecentTradesResponse)},
+		{RESTRecentSpreads, new(market.GetRecentSpreadsResponse)},
+		{RESTAccountBalance, new(account.GetAccountBalanceResponse)},
+		{RESTAddOrder, new(trading.AddOrderResponse)},
+		{RESTWebsocketToken, new(websocket.GetWebsocketTokenResponse)},
+		{RESTWarning, new(market.GetServerTimeResponse)},
+	}
+	for _, tc := range cases {
+		require.NoError(suite.T(), json.Unmarshal([]byte(tc.payload), tc.target), tc.payload)
+	}
+	for _, payload := range []string{RESTErrorInvalidNonce, RESTErrorRateLimitExceeded, RESTErrorUnknownAssetPair, RESTErrorMultiple} {
+		resp := new(common.KrakenSpotRESTResponse)
+		require.NoError(suite.T(), json.Unmarshal([]byte(payload), resp))
+		require.NotEmpty(suite.T(), resp.Error)
+	}
+}
+
+// Test book builders.
+//
+// Test will ensure:
+//   - Book snapshots are built with empty sides as empty arrays.
+//   - Book updates are built with asks and bids in distinct objects and the checksum in the last one.
+func (suite *FixturesUnitTestSuite) TestBookBuilders() {
+	asks := []BookLevel{{Price: "101.0", Volume: "1.0", Timestamp: "1534614248.123678"}}
+	bids := []BookLevel{{Price: "99.0", Volume: "2.0", Timestamp: "1534614248.123678"}}
+	snapshot := new(messages.BookSnapshot)
+	require.NoError(suite.T(), json.Unmarshal([]byte(BookSnapshot("XBT/USD", 25, asks, nil)), snapshot))
+	require.Equal(suite.T(), "book-25", snapshot.Name)
+	require.Equal(suite.T(), "XBT/USD", snapshot.Pair)
+	require.Len(suite.T(), snapshot.Data.Asks, 1)
+	require.Empty(suite.T(), snapshot.Data.Bids)
+	// Both sides
+	payload := BookUpdate("XBT/USD", 10, asks, bids, "42")
+	require.Equal(suite.T(), `[1234,{"a":[["101.0","1.0","1534614248.123678"]]},{"b":[["99.0","2.0","1534614248.123678"]],"c":"42"},"book-10","XBT/USD"]`, payload)
+	update := new(messages.BookUpdate)
+	require.NoError(suite.T(), json.Unmarshal([]byte(payload), update))
+	require.Len(suite.T(), update.Data.Asks, 1)
+	require.Len(suite.T(), update.Data.Bids, 1)
+	require.Equal(suite.T(), "42", update.Data.Checksum)
+	// One side
+	update = new(messages.BookUpdate)
+	require.NoError(suite.T(), json.Unmarshal([]byte(BookUpdate("XBT/USD", 10, nil, bids, "43")), update))
+	require.Empty(suite.T(), update.Data.Asks)
+	require.Equal(suite.T(), "43", update.Data.Checksum)
+}
+
+// Test message and response builders.
+func (suite *FixturesUnitTestSuite) TestBuilders() {
+	status := new(messages.SubscriptionStatus)
+	require.NoError(suite.T(), json.Unmarshal([]byte(SubscriptionStatus("book", "book-10", "XBT/USD", "error", 7)), status))
+	require.Equal(suite.T(), "book-10", status.ChannelName)
+	require.Equal(suite.T(), "error", status.Status)
+	require.NotEmpty(suite.T(), status.Err)
+	errmsg := new(messages.ErrorMessage)
+	require.NoError(suite.T(), json.Unmarshal([]byte(ErrorMessage(7, "Unsupported event")), errmsg))
+	require.Equal(suite.T(), "Unsupported event", errmsg.Err)
+	// REST
+	require.Equal(suite.T(), `{"error":["EAPI:Invalid nonce"]}`, RESTResponse("", "EAPI:Invalid nonce"))
+	resp := new(market.GetServerTimeResponse)
+	require.NoError(suite.T(), json.Unmarshal([]byte(RESTResponse(`{"unixtime":42,"rfc1123":""}`)), resp))
+	require.Empty(suite.T(), resp.Error)
+	require.Equal(suite.T(), int64(42), resp.Result.Unixtime)
+}
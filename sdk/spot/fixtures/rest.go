@@ -0,0 +1,84 @@
+package fixtures
+
+/*************************************************************************************************/
+/* REST: MARKET DATA                                                                             */
+/*************************************************************************************************/
+
+const (
+	// GetServerTime response
+	RESTServerTime = `{"error":[],"result":{"unixtime":1688669448,"rfc1123":"Thu, 06 Jul 23 18:50:48 +0000"}}`
+	// GetSystemStatus response
+	RESTSystemStatus = `{"error":[],"result":{"status":"online","timestamp":"2023-07-06T18:52:00Z"}}`
+	// GetAssetInfo response for XXBT and ZUSD
+	RESTAssetInfo = `{"error":[],"result":{"XXBT":{"aclass":"currency","altname":"XBT","decimals":10,"display_decimals":5,"collateral_value":1,"status":"enabled"},"ZUSD":{"aclass":"currency","altname":"USD","decimals":4,"display_decimals":2,"collateral_value":1,"status":"enabled"}}}`
+	// GetTradableAssetPairs response for XXBTZUSD
+	RESTTradableAssetPairs = `{"error":[],"result":{"XXBTZUSD":{"altname":"XBTUSD","wsname":"XBT/USD","aclass_base":"currency","base":"XXBT","aclass_quote":"currency","quote":"ZUSD","lot":"unit","cost_decimals":5,"pair_decimals":1,"lot_decimals":8,"lot_multiplier":1,"leverage_buy":[2,3,4,5],"leverage_sell":[2,3,4,5],"fees":[[0,0.26],[50000,0.24]],"fees_maker":[[0,0.16],[50000,0.14]],"fee_volume_currency":"ZUSD","margin_call":80,"margin_stop":40,"ordermin":"0.0001","costmin":"0.5","tick_size":"0.1","status":"online"}}}`
+	// GetTickerInformation response for XXBTZUSD
+	RESTTickerInformation = `{"error":[],"result":{"XXBTZUSD":{"a":["30300.10000","1","1.000"],"b":["30300.00000","1","1.000"],"c":["30303.20000","0.00067643"],"v":["4083.67001100","4412.73601799"],"p":["30706.77771","30689.13205"],"t":[34619,38907],"l":["29868.30000","29868.30000"],"h":["31631.00000","31631.00000"],"o":"30502.80000"}}}`
+	// GetOHLCData response for XXBTZUSD with two candles
+	RESTOHLCData = `{"error":[],"result":{"XXBTZUSD":[[1688671200,"30306.1","30306.2","30305.7","30305.7","30306.1","3.39243896",23],[1688671260,"30304.5","30304.5","30300.0","30300.0","30300.0","4.42996871",18]],"last":1688672160}}`
+	// GetOrderBook response for XXBTZUSD with 3 levels on each side
+	RESTOrderBook = `{"error":[],"result":{"XXBTZUSD":{"asks":[["30384.10000","2.059",1688671659],["30387.90000","1.500",1688671380],["30393.70000","9.871",1688671261]],"bids":[["30297.00000","1.115",1688671636],["30296.70000","2.002",1688671674],["30289.80000","5.001",1688671673]]}}}`
+	// GetOrderBook response for XXBTZUSD with an empty bid side (truncated book)
+	RESTOrderBookTruncated = `{"error":[],"result":{"XXBTZUSD":{"asks":[["30384.10000","2.059",1688671659]],"bids":[]}}}`
+	// GetRecentTrades response for XXBTZUSD with two trades
+	RESTRecentTrades = `{"error":[],"result":{"XXBTZUSD":[["30243.40000","0.34507674",1688669597.8277369,"b","m","",61044952],["30243.30000","0.00376960",1688669598.2804112,"s","l","",61044953]],"last":"1688671969993150842"}}`
+	// GetRecentSpreads response for XXBTZUSD with two spreads
+	RESTRecentSpreads = `{"error":[],"result":{"XXBTZUSD":[[1688671834,"30292.10000","30297.50000"],[1688671834,"30292.10000","30296.70000"]],"last":1688672106}}`
+)
+
+/*************************************************************************************************/
+/* REST: ACCOUNT, TRADING & WEBSOCKET TOKEN                                                      */
+/*************************************************************************************************/
+
+const (
+	// GetAccountBalance response
+	RESTAccountBalance = `{"error":[],"result":{"ZUSD":"171288.6158","ZEUR":"504861.8946","XXBT":"1011.1908877900","XETH":"818.5500000000"}}`
+	// AddOrder response for a limit order
+	RESTAddOrder = `{"error":[],"result":{"descr":{"order":"buy 1.25000000 XBTUSD @ limit 27500.0"},"txid":["OU22CG-KLAF2-FWUDD7"]}}`
+	// GetWebSocketsToken response
+	RESTWebsocketToken = `{"error":[],"result":{"token":"1Dwc4lzSwNWOAwkMdqhssNNFhs1ed606d1WcF3XfEMw","expires":900}}`
+)
+
+/*************************************************************************************************/
+/* REST: ERRORS                                                                                  */
+/*************************************************************************************************/
+
+const (
+	// Error response for an invalid nonce
+	RESTErrorInvalidNonce = `{"error":["EAPI:Invalid nonce"]}`
+	// Error response for an exceeded rate limit
+	RESTErrorRateLimitExceeded = `{"error":["EAPI:Rate limit exceeded"]}`
+	// Error response for an unknown pair
+	RESTErrorUnknownAssetPair = `{"error":["EQuery:Unknown asset pair"]}`
+	// Error response with several errors and an empty result
+	RESTErrorMultiple = `{"error":["EGeneral:Invalid arguments","EGeneral:Invalid arguments:volume"],"result":{}}`
+	// Response with a warning in the error array along with a result
+	RESTWarning = `{"error":["WGeneral:Deprecated call"],"result":{"unixtime":1688669448,"rfc1123":"Thu, 06 Jul 23 18:50:48 +0000"}}`
+)
+
+/*************************************************************************************************/
+/* REST: BUILDERS                                                                                */
+/*************************************************************************************************/
+
+// # Description
+//
+// Build a REST response.
+//
+// # Inputs
+//
+//   - result: JSON encoded result. If empty, the response has no result.
+//   - errors: Errors (or warnings) of the response. Can be empty.
+//
+// # Return
+//
+// The REST response.
+func RESTResponse(result string, errors ...string) string {
+	if errors == nil {
+		errors = []string{}
+	}
+	if result == "" {
+		return marshal(map[string]interface{}{"error": errors})
+	}
+	return `{"error":` + marshal(errors) + `,"result":` + result + `}`
+}
@@ -0,0 +1,233 @@
+// Package fixtures provides realistic canned payloads of Kraken spot websocket messages and REST
+// responses, as constants and builders, so the SDK tests and downstream tests share one canonical
+// corpus.
+//
+// Constants are compact JSON payloads as sent by Kraken. Edge cases (error responses, error
+// arrays, one-sided and truncated books, republish updates, ...) have their own constants.
+// Builders can be used to forge payloads with custom data.
+//
+// The package has no dependency on the SDK: it can be imported by tests of any package.
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+/*************************************************************************************************/
+/* WEBSOCKET: GENERAL MESSAGES                                                                   */
+/*************************************************************************************************/
+
+const (
+	// heartbeat message
+	WebsocketHeartbeat = `{"event":"heartbeat"}`
+	// pong message in response to a ping with reqid 42
+	WebsocketPong = `{"event":"pong","reqid":42}`
+	// systemStatus message sent when the connection is opened
+	WebsocketSystemStatusOnline = `{"connectionID":8628615390848610000,"event":"systemStatus","status":"online","version":"1.9.0"}`
+	// systemStatus message sent when the exchange enters maintenance
+	WebsocketSystemStatusMaintenance = `{"connectionID":8628615390848610000,"event":"systemStatus","status":"maintenance","version":"1.9.0"}`
+	// subscriptionStatus message for a successful ticker subscription
+	WebsocketSubscriptionStatusTickerSubscribed = `{"channelName":"ticker","event":"subscriptionStatus","pair":"XBT/USD","reqid":42,"status":"subscribed","subscription":{"name":"ticker"}}`
+	// subscriptionStatus message for a successful book subscription with depth 10
+	WebsocketSubscriptionStatusBookSubscribed = `{"channelName":"book-10","event":"subscriptionStatus","pair":"XBT/USD","reqid":42,"status":"subscribed","subscription":{"depth":10,"name":"book"}}`
+	// subscriptionStatus message for a successful ohlc unsubscription with interval 5
+	WebsocketSubscriptionStatusOHLCUnsubscribed = `{"channelName":"ohlc-5","event":"subscriptionStatus","pair":"XBT/USD","reqid":42,"status":"unsubscribed","subscription":{"interval":5,"name":"ohlc"}}`
+	// subscriptionStatus message for a failed subscription (unknown pair)
+	WebsocketSubscriptionStatusError = `{"errorMessage":"Currency pair not in ISO 4217-A3 format XBTUSD","event":"subscriptionStatus","pair":"XBTUSD","reqid":42,"status":"error","subscription":{"name":"ticker"}}`
+	// error message sent in response to an invalid request
+	WebsocketError = `{"errorMessage":"Exceeded msg rate","event":"error","reqid":42,"status":"error"}`
+)
+
+/*************************************************************************************************/
+/* WEBSOCKET: PUBLIC MARKET DATA                                                                 */
+/*************************************************************************************************/
+
+const (
+	// ticker message for XBT/USD
+	WebsocketTicker = `[340,{"a":["5525.40000",1,"1.000"],"b":["5525.10000",1,"1.000"],"c":["5525.10000","0.00398963"],"v":["2634.11501494","3591.17907851"],"p":["5631.44067","5653.78939"],"t":[11493,16267],"l":["5505.00000","5505.00000"],"h":["5783.00000","5783.00000"],"o":["5760.70000","5763.40000"]},"ticker","XBT/USD"]`
+	// ohlc message for XBT/USD with a 5 minutes interval
+	WebsocketOHLC = `[42,["1542057314.748456","1542057360.435743","3586.70000","3586.70000","3586.60000","3586.60000","3586.68894","0.03373000",2],"ohlc-5","XBT/USD"]`
+	// trade message for XBT/USD with two trades
+	WebsocketTrade = `[0,[["5541.20000","0.15850568","1534614057.321597","s","l",""],["6060.00000","0.02455000","1534614057.324998","b","l",""]],"trade","XBT/USD"]`
+	// spread message for XBT/USD
+	WebsocketSpread = `[0,["5698.40000","5700.00000","1542057299.545897","1.01234567","0.98765432"],"spread","XBT/USD"]`
+	// book snapshot message for XBT/USD with depth 10 and 3 levels on each side
+	WebsocketBookSnapshot = `[0,{"as":[["5541.30000","2.50700000","1534614248.123678"],["5541.80000","0.33000000","1534614098.345543"],["5542.70000","0.64700000","1534614244.654432"]],"bs":[["5541.20000","1.52900000","1534614248.765567"],["5539.90000","0.30000000","1534614241.769870"],["5539.50000","5.00000000","1534613831.243486"]]},"book-10","XBT/USD"]`
+	// book snapshot message for XBT/USD with depth 10 and an empty bid side (truncated book)
+	WebsocketBookSnapshotTruncated = `[0,{"as":[["5541.30000","2.50700000","1534614248.123678"]],"bs":[]},"book-10","XBT/USD"]`
+	// book update message for XBT/USD with updates on both sides: asks and bids are sent in two
+	// distinct objects and the checksum is in the last one
+	WebsocketBookUpdate = `[1234,{"a":[["5541.30000","2.50700000","1534614248.456738"],["5542.50000","0.40100000","1534614248.456738"]]},{"b":[["5541.30000","0.00000000","1534614335.345903"]],"c":"974942666"},"book-10","XBT/USD"]`
+	// book update message for XBT/USD with updates on the ask side only
+	WebsocketBookUpdateAsksOnly = `[1234,{"a":[["5541.30000","2.50700000","1534614248.456738"]],"c":"974942666"},"book-10","XBT/USD"]`
+	// book update message for XBT/USD with republished levels (update type "r")
+	WebsocketBookUpdateRepublish = `[1234,{"a":[["5541.30000","2.50700000","1534614248.456738","r"],["5542.50000","0.40100000","1534614248.456738","r"]],"c":"974942666"},"book-25","XBT/USD"]`
+)
+
+/*************************************************************************************************/
+/* WEBSOCKET: PRIVATE DATA                                                                       */
+/*************************************************************************************************/
+
+const (
+	// openOrders message with a new limit order
+	WebsocketOpenOrders = `[[{"OGTT3Y-C6I3P-XRI6HX":{"refid":"OKIVMP-5GVZN-Z2D2UA","userref":0,"status":"open","opentm":"1560516023.070651","starttm":"0.000000","expiretm":"0.000000","descr":{"pair":"XBT/EUR","type":"sell","ordertype":"limit","price":"34.50000","price2":"0.00000","leverage":"0:1","order":"sell 10.00345345 XBT/EUR @ limit 34.50000 with 0:1 leverage"},"vol":"10.00345345","vol_exec":"0.00000000","cost":"0.00000","fee":"0.00000","avg_price":"34.50000","stopprice":"0.000000","limitprice":"34.50000","oflags":"fcib"}}],"openOrders",{"sequence":234}]`
+	// openOrders message with a status change of an existing order
+	WebsocketOpenOrdersStatusChange = `[[{"OGTT3Y-C6I3P-XRI6HX":{"status":"closed"}}],"openOrders",{"sequence":235}]`
+	// ownTrades message with one trade
+	WebsocketOwnTrades = `[[{"TDLH43-DVQXD-2KHVYY":{"ordertxid":"OGTT3Y-C6I3P-XRI6HX","postxid":"TKH2SE-M7IF5-CFI7LT","pair":"XBT/EUR","time":"1560516023.070651","type":"sell","ordertype":"limit","price":"100000.00000","cost":"1000000.00000","fee":"1600.00000","vol":"10.00000000","margin":"0.00000"}}],"ownTrades",{"sequence":2948}]`
+	// addOrderStatus message for a successful order
+	WebsocketAddOrderStatus = `{"descr":"buy 0.01770000 XBTUSD @ limit 4000","event":"addOrderStatus","reqid":42,"status":"ok","txid":"ONPNXH-KMKMU-F4MR5V"}`
+	// addOrderStatus message for a rejected order
+	WebsocketAddOrderStatusError = `{"errorMessage":"EOrder:Order minimum not met","event":"addOrderStatus","reqid":42,"status":"error"}`
+	// editOrderStatus message for a successful edit
+	WebsocketEditOrderStatus = `{"descr":"order edited price = 9000.00000000","event":"editOrderStatus","originaltxid":"O65KZW-J4AW3-VFS74A","reqid":42,"status":"ok","txid":"OTI672-HJFAO-XOIPPK"}`
+	// cancelOrderStatus message for a successful cancel
+	WebsocketCancelOrderStatus = `{"event":"cancelOrderStatus","reqid":42,"status":"ok"}`
+	// cancelOrderStatus message for a failed cancel (unknown order)
+	WebsocketCancelOrderStatusError = `{"errorMessage":"EOrder:Unknown order","event":"cancelOrderStatus","reqid":42,"status":"error"}`
+	// cancelAllStatus message
+	WebsocketCancelAllStatus = `{"count":2,"event":"cancelAllStatus","reqid":42,"status":"ok"}`
+	// cancelAllOrdersAfterStatus message
+	WebsocketCancelAllOrdersAfterStatus = `{"currentTime":"2020-12-21T09:37:09Z","event":"cancelAllOrdersAfterStatus","reqid":42,"status":"ok","triggerTime":"2020-12-21T09:38:09Z"}`
+)
+
+/*************************************************************************************************/
+/* WEBSOCKET: BUILDERS                                                                           */
+/*************************************************************************************************/
+
+// A book level used to build book messages.
+type BookLevel struct {
+	// Price level
+	Price string
+	// Volume at the price level. "0.00000000" to remove the level in updates.
+	Volume string
+	// Timestamp of the last update of the level
+	Timestamp string
+}
+
+// Format the level as a JSON array like Kraken.
+func (level BookLevel) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]string{level.Price, level.Volume, level.Timestamp})
+}
+
+// # Description
+//
+// Build a book snapshot message.
+//
+// # Inputs
+//
+//   - pair: Pair of the book (ex: XBT/USD).
+//   - depth: Depth of the book subscription.
+//   - asks: Ask levels in ascending price order. Can be empty.
+//   - bids: Bid levels in descending price order. Can be empty.
+//
+// # Return
+//
+// The book snapshot message.
+func BookSnapshot(pair string, depth int, asks []BookLevel, bids []BookLevel) string {
+	return marshal([]interface{}{
+		0,
+		map[string][]BookLevel{"as": nonNilLevels(asks), "bs": nonNilLevels(bids)},
+		fmt.Sprintf("book-%d", depth),
+		pair,
+	})
+}
+
+// # Description
+//
+// Build a book update message. Like Kraken, asks and bids are sent in two distinct objects when
+// both sides are updated and the checksum is added to the last object.
+//
+// # Inputs
+//
+//   - pair: Pair of the book (ex: XBT/USD).
+//   - depth: Depth of the book subscription.
+//   - asks: Updated ask levels. Can be empty.
+//   - bids: Updated bid levels. Can be empty.
+//   - checksum: Checksum of the book after the update.
+//
+// # Return
+//
+// The book update message.
+func BookUpdate(pair string, depth int, asks []BookLevel, bids []BookLevel, checksum string) string {
+	msg := []interface{}{1234}
+	if len(asks) > 0 {
+		msg = append(msg, map[string]interface{}{"a": asks})
+	}
+	if len(bids) > 0 || len(asks) == 0 {
+		msg = append(msg, map[string]interface{}{"b": nonNilLevels(bids)})
+	}
+	msg[len(msg)-1].(map[string]interface{})["c"] = checksum
+	return marshal(append(msg, fmt.Sprintf("book-%d", depth), pair))
+}
+
+// # Description
+//
+// Build a subscriptionStatus message.
+//
+// # Inputs
+//
+//   - name: Name of the channel (ticker, ohlc, trade, spread, book, ...).
+//   - channelName: Channel name with its suffix if any (ticker, ohlc-5, book-10, ...).
+//   - pair: Pair of the subscription. Can be empty for private channels.
+//   - status: Status of the subscription (subscribed, unsubscribed, error).
+//   - reqid: Request ID.
+//
+// # Return
+//
+// The subscriptionStatus message. The errorMessage field is set when status is error.
+func SubscriptionStatus(name string, channelName string, pair string, status string, reqid int64) string {
+	msg := map[string]interface{}{
+		"channelName":  channelName,
+		"event":        "subscriptionStatus",
+		"reqid":        reqid,
+		"status":       status,
+		"subscription": map[string]interface{}{"name": name},
+	}
+	if pair != "" {
+		msg["pair"] = pair
+	}
+	if status == "error" {
+		msg["errorMessage"] = "Subscription failed"
+	}
+	return marshal(msg)
+}
+
+// # Description
+//
+// Build an error message.
+//
+// # Inputs
+//
+//   - reqid: Request ID.
+//   - errorMessage: Error message.
+//
+// # Return
+//
+// The error message.
+func ErrorMessage(reqid int64, errorMessage string) string {
+	return marshal(map[string]interface{}{
+		"errorMessage": errorMessage,
+		"event":        "error",
+		"reqid":        reqid,
+		"status":       "error",
+	})
+}
+
+// Replace nil levels by an empty slice so they are formatted as an empty array.
+func nonNilLevels(levels []BookLevel) []BookLevel {
+	if levels == nil {
+		return []BookLevel{}
+	}
+	return levels
+}
+
+// Marshal a payload built by the package. Panics on error as payloads are always valid.
+func marshal(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,238 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+)
+
+/*************************************************************************************************/
+/* REST                                                                                          */
+/*************************************************************************************************/
+
+// Checker which checks the REST API is reachable with GetSystemStatus.
+type RESTChecker struct {
+	// Name of the check
+	name string
+	// REST client used to send requests
+	client rest.KrakenSpotRESTClientIface
+	// Accepted system statuses. Any status is accepted if empty.
+	accepted []market.SystemStatus
+}
+
+// # Description
+//
+// Build a Checker which checks the REST API is reachable: the check fails if GetSystemStatus
+// fails, if the API returns errors or if the system status is not one of the accepted statuses.
+//
+// # Inputs
+//
+//   - name: Name of the check.
+//   - client: REST client used to send requests. Must not be nil.
+//   - accepted: Accepted system statuses (ex: market.Online for a readiness probe of a trading
+//     service). If empty, any status is accepted: only reachability is checked.
+//
+// # Return
+//
+// The RESTChecker.
+func NewRESTChecker(name string, client rest.KrakenSpotRESTClientIface, accepted []market.SystemStatus) *RESTChecker {
+	return &RESTChecker{name: name, client: client, accepted: accepted}
+}
+
+// Get the name of the check.
+func (c *RESTChecker) Name() string {
+	return c.name
+}
+
+// Check the REST API is reachable and the system status is accepted.
+func (c *RESTChecker) Check(ctx context.Context) error {
+	resp, _, err := c.client.GetSystemStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("get system status failed: %w", err)
+	}
+	if len(resp.Error) > 0 || resp.Result == nil {
+		return fmt.Errorf("get system status failed: %v", resp.Error)
+	}
+	if len(c.accepted) == 0 {
+		return nil
+	}
+	for _, status := range c.accepted {
+		if resp.Result.Status == string(status) {
+			return nil
+		}
+	}
+	return fmt.Errorf("system status is %s: expected one of %v", resp.Result.Status, c.accepted)
+}
+
+/*************************************************************************************************/
+/* WEBSOCKET CONNECTION                                                                          */
+/*************************************************************************************************/
+
+// Source of the state of a websocket connection, like the public and private websocket clients.
+type ConnectionStateProvider interface {
+	// Get a snapshot of the state of the websocket connection.
+	GetConnectionState() websocket.ConnectionState
+}
+
+// Checker which checks a websocket client is connected.
+type WebsocketConnectionChecker struct {
+	// Name of the check
+	name string
+	// Client which provides the connection state
+	client ConnectionStateProvider
+}
+
+// # Description
+//
+// Build a Checker which fails while the websocket client is not connected to the server.
+//
+// # Inputs
+//
+//   - name: Name of the check.
+//   - client: Websocket client. Must not be nil.
+//
+// # Return
+//
+// The WebsocketConnectionChecker.
+func NewWebsocketConnectionChecker(name string, client ConnectionStateProvider) *WebsocketConnectionChecker {
+	return &WebsocketConnectionChecker{name: name, client: client}
+}
+
+// Get the name of the check.
+func (c *WebsocketConnectionChecker) Name() string {
+	return c.name
+}
+
+// Check the websocket client is connected.
+func (c *WebsocketConnectionChecker) Check(ctx context.Context) error {
+	if !c.client.GetConnectionState().Connected {
+		return fmt.Errorf("websocket client is not connected")
+	}
+	return nil
+}
+
+/*************************************************************************************************/
+/* SUBSCRIPTION FRESHNESS                                                                        */
+/*************************************************************************************************/
+
+// Source of the events published by a websocket client, like the public and private websocket
+// clients (Cf. websocket.EventBus).
+type TopicSubscriber interface {
+	// Subscribe to the events whose topic matches the pattern.
+	SubscribeTopic(pattern string, capacity int) (*websocket.TopicSubscription, error)
+}
+
+// Checker which checks events are regularly received for a subscription. Use Close to release
+// the underlying topic subscription.
+type FreshnessChecker struct {
+	// Name of the check
+	name string
+	// Topic pattern of the events
+	pattern string
+	// Maximum age of the last event
+	maxAge time.Duration
+	// Topic subscription used to receive the events
+	sub *websocket.TopicSubscription
+	// Mutex used to protect clock and last
+	mu sync.Mutex
+	// Clock used to get the current time
+	clock clock.Clock
+	// Time at which the last event has been received or at which the checker has been created
+	last time.Time
+	// True once an event has been received
+	received bool
+}
+
+// # Description
+//
+// Build a Checker which fails when no event has been published by the websocket client for
+// the provided topic pattern (ex: market.book.*.XBT/USD, private.>) since more than maxAge. The
+// checker subscribes to the events of the client (Cf. SubscribeTopic) and does not consume the
+// events of the channels provided on subscribe.
+//
+// The check succeeds during maxAge after the checker has been created even if no event has been
+// received yet.
+//
+// # Inputs
+//
+//   - name: Name of the check.
+//   - client: Websocket client. Must not be nil.
+//   - pattern: Topic pattern of the events (Cf. websocket.MatchTopic).
+//   - maxAge: Maximum age of the last event. Must be strictly positive.
+//
+// # Return
+//
+// The FreshnessChecker or an error if maxAge is not strictly positive or if the pattern is invalid.
+func NewFreshnessChecker(name string, client TopicSubscriber, pattern string, maxAge time.Duration) (*FreshnessChecker, error) {
+	if maxAge <= 0 {
+		return nil, fmt.Errorf("max age must be strictly positive. Got %s", maxAge)
+	}
+	sub, err := client.SubscribeTopic(pattern, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", pattern, err)
+	}
+	c := &FreshnessChecker{
+		name:    name,
+		pattern: pattern,
+		maxAge:  maxAge,
+		sub:     sub,
+		clock:   clock.NewSystemClock(),
+	}
+	c.last = c.clock.Now()
+	go c.run()
+	return c, nil
+}
+
+// Set the clock used to get the current time. This can be used to provide a clock.FakeClock in
+// tests. If nil, the system clock is used.
+func (c *FreshnessChecker) SetClock(clk clock.Clock) {
+	if clk == nil {
+		clk = clock.NewSystemClock()
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock = clk
+	if !c.received {
+		c.last = clk.Now()
+	}
+}
+
+// Get the name of the check.
+func (c *FreshnessChecker) Name() string {
+	return c.name
+}
+
+// Check an event has been received for the topic pattern since less than the maximum age.
+func (c *FreshnessChecker) Check(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	age := c.clock.Now().Sub(c.last)
+	if age <= c.maxAge {
+		return nil
+	}
+	if !c.received {
+		return fmt.Errorf("no event received for %s since %s", c.pattern, age)
+	}
+	return fmt.Errorf("last event for %s received %s ago", c.pattern, age)
+}
+
+// Release the topic subscription. The check fails once the maximum age has elapsed.
+func (c *FreshnessChecker) Close() {
+	c.sub.Unsubscribe()
+}
+
+// Record the time at which events are received until the topic subscription is released.
+func (c *FreshnessChecker) run() {
+	for range c.sub.C {
+		c.mu.Lock()
+		c.last = c.clock.Now()
+		c.received = true
+		c.mu.Unlock()
+	}
+}
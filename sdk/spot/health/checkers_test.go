@@ -0,0 +1,131 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// Websocket clients provide the connection state and the events
+var _ ConnectionStateProvider = (*websocket.KrakenSpotPublicWebsocketClient)(nil)
+var _ TopicSubscriber = (*websocket.KrakenSpotPublicWebsocketClient)(nil)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the checkers
+type CheckersUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestCheckersUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(CheckersUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test RESTChecker.
+//
+// Test will ensure:
+//   - The check succeeds when the system status is returned and no status is required.
+//   - The check fails when the system status is not one of the accepted statuses.
+//   - The check fails when the request fails or when the API returns errors.
+func (suite *CheckersUnitTestSuite) TestRESTChecker() {
+	client := rest.NewMockKrakenSpotRESTClient()
+	client.On("GetSystemStatus", mock.Anything).Return(&market.GetSystemStatusResponse{Result: &market.GetSystemStatusResult{Status: string(market.Maintenance)}}, nil, nil).Twice()
+	require.NoError(suite.T(), NewRESTChecker("rest", client, nil).Check(context.Background()))
+	checker := NewRESTChecker("rest", client, []market.SystemStatus{market.Online, market.PostOnly})
+	require.Equal(suite.T(), "rest", checker.Name())
+	require.EqualError(suite.T(), checker.Check(context.Background()), "system status is maintenance: expected one of [online post_only]")
+	client.On("GetSystemStatus", mock.Anything).Return(nil, nil, fmt.Errorf("connection refused")).Once()
+	require.ErrorContains(suite.T(), checker.Check(context.Background()), "connection refused")
+	client.On("GetSystemStatus", mock.Anything).Return(&market.GetSystemStatusResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{"EService:Unavailable"}}}, nil, nil).Once()
+	require.ErrorContains(suite.T(), checker.Check(context.Background()), "EService:Unavailable")
+	client.AssertExpectations(suite.T())
+}
+
+// Test WebsocketConnectionChecker.
+//
+// Test will ensure:
+//   - The check succeeds when the client is connected and fails otherwise.
+func (suite *CheckersUnitTestSuite) TestWebsocketConnectionChecker() {
+	state := &fakeConnectionState{}
+	checker := NewWebsocketConnectionChecker("websocket", state)
+	require.Equal(suite.T(), "websocket", checker.Name())
+	require.EqualError(suite.T(), checker.Check(context.Background()), "websocket client is not connected")
+	state.connected = true
+	require.NoError(suite.T(), checker.Check(context.Background()))
+}
+
+// Test FreshnessChecker.
+//
+// Test will ensure:
+//   - The check succeeds during the maximum age after the checker has been created.
+//   - The check fails once no event has been received for longer than the maximum age.
+//   - The check succeeds again once an event matching the pattern is received.
+//   - Invalid maximum ages and patterns are rejected.
+func (suite *CheckersUnitTestSuite) TestFreshnessChecker() {
+	bus := &fakeTopicSubscriber{bus: websocket.NewEventBus()}
+	checker, err := NewFreshnessChecker("book", bus, "market.book.*.XBT/USD", time.Minute)
+	require.NoError(suite.T(), err)
+	defer checker.Close()
+	clk := clock.NewFakeClock(time.Now())
+	checker.SetClock(clk)
+	require.Equal(suite.T(), "book", checker.Name())
+	require.NoError(suite.T(), checker.Check(context.Background()))
+	clk.Advance(2 * time.Minute)
+	require.ErrorContains(suite.T(), checker.Check(context.Background()), "no event received for market.book.*.XBT/USD")
+	// Events for other topics are ignored
+	bus.bus.Publish(websocket.BookTopic(messages.D10, "ETH/USD"), event.New())
+	bus.bus.Publish(websocket.BookTopic(messages.D10, "XBT/USD"), event.New())
+	require.Eventually(suite.T(), func() bool {
+		return checker.Check(context.Background()) == nil
+	}, time.Second, time.Millisecond)
+	clk.Advance(2 * time.Minute)
+	require.ErrorContains(suite.T(), checker.Check(context.Background()), "last event for market.book.*.XBT/USD received 2m0s ago")
+	// Invalid inputs
+	_, err = NewFreshnessChecker("book", bus, "market.book.*.XBT/USD", 0)
+	require.Error(suite.T(), err)
+	_, err = NewFreshnessChecker("book", bus, "market..book", time.Minute)
+	require.Error(suite.T(), err)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Connection state provider with a configurable state.
+type fakeConnectionState struct {
+	connected bool
+}
+
+// Get the configured connection state.
+func (f *fakeConnectionState) GetConnectionState() websocket.ConnectionState {
+	return websocket.ConnectionState{Connected: f.connected}
+}
+
+// Topic subscriber backed by an event bus.
+type fakeTopicSubscriber struct {
+	bus *websocket.EventBus
+}
+
+// Subscribe to the event bus.
+func (f *fakeTopicSubscriber) SubscribeTopic(pattern string, capacity int) (*websocket.TopicSubscription, error) {
+	return f.bus.Subscribe(pattern, capacity)
+}
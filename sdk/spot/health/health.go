@@ -0,0 +1,199 @@
+// Package health provides the Checkers used to report the health of the SDK clients (REST
+// reachability, websocket connection, subscription freshness, websocket token validity) and an
+// http.Handler which runs them and reports their results as JSON, for services which run the SDK
+// and expose readiness and liveness probes.
+//
+// Checkers only implement Check(ctx) error and can also be plugged into third party health
+// frameworks. Use a Handler per probe, for example:
+//
+//	http.Handle("/livez", health.NewHandler(0, health.NewWebsocketConnectionChecker("websocket", client)))
+//	http.Handle("/readyz", health.NewHandler(5*time.Second, health.NewRESTChecker("rest", restClient, nil), freshness))
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/*************************************************************************************************/
+/* MODEL                                                                                         */
+/*************************************************************************************************/
+
+// Default maximum duration of the checks run by a Handler.
+const DefaultCheckTimeout = 10 * time.Second
+
+// Enum for health statuses.
+type StatusEnum string
+
+// Values for StatusEnum
+const (
+	// The checked component is healthy
+	StatusUp StatusEnum = "up"
+	// The checked component is not healthy
+	StatusDown StatusEnum = "down"
+)
+
+// Check of the health of a component.
+type Checker interface {
+	// Name of the check, used in reports.
+	Name() string
+	// Check the health of the component. Return nil if the component is healthy or an error which
+	// describes why it is not.
+	Check(ctx context.Context) error
+}
+
+// Result of a single check.
+type CheckResult struct {
+	// Name of the check
+	Name string `json:"name"`
+	// Status of the checked component
+	Status StatusEnum `json:"status"`
+	// Error returned by the check. Empty if the component is healthy.
+	Error string `json:"error,omitempty"`
+	// Duration of the check in milliseconds
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// Results of the checks run by a Handler.
+type Report struct {
+	// Overall status: up only if all checks are up
+	Status StatusEnum `json:"status"`
+	// Results of the checks, in registration order
+	Checks []CheckResult `json:"checks"`
+}
+
+// Checker built from a function.
+type checkerFunc struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// Get the name of the check.
+func (c *checkerFunc) Name() string {
+	return c.name
+}
+
+// Call the check function.
+func (c *checkerFunc) Check(ctx context.Context) error {
+	return c.check(ctx)
+}
+
+// # Description
+//
+// Build a Checker from a function. This can be used to report the health of application
+// components along with the SDK clients.
+//
+// # Inputs
+//
+//   - name: Name of the check.
+//   - check: Function which returns nil if the component is healthy. Must not be nil.
+//
+// # Return
+//
+// The Checker.
+func NewChecker(name string, check func(ctx context.Context) error) Checker {
+	return &checkerFunc{name: name, check: check}
+}
+
+/*************************************************************************************************/
+/* HANDLER                                                                                       */
+/*************************************************************************************************/
+
+// http.Handler which runs checks and writes their report as JSON. The response status is 200 when
+// all checks are up and 503 otherwise.
+type Handler struct {
+	// Checks run by the handler
+	checkers []Checker
+	// Maximum duration of the checks
+	timeout time.Duration
+}
+
+// # Description
+//
+// Build a Handler which runs the provided checks.
+//
+// # Inputs
+//
+//   - timeout: Maximum duration of the checks. DefaultCheckTimeout is used if timeout is not strictly positive.
+//   - checkers: Checks run by the handler. The report is up if no check is provided.
+//
+// # Return
+//
+// The Handler.
+func NewHandler(timeout time.Duration, checkers ...Checker) *Handler {
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	return &Handler{checkers: checkers, timeout: timeout}
+}
+
+// # Description
+//
+// Run all checks concurrently and build their report. Checks which have not completed before the
+// handler timeout are reported as down.
+//
+// # Inputs
+//
+//   - ctx: Context used for coordination purpose. Checks are interrupted when the context is done.
+//
+// # Return
+//
+// The report of the checks.
+func (h *Handler) Run(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	report := Report{Status: StatusUp, Checks: make([]CheckResult, len(h.checkers))}
+	wg := sync.WaitGroup{}
+	for i, checker := range h.checkers {
+		wg.Add(1)
+		go func(i int, checker Checker) {
+			defer wg.Done()
+			report.Checks[i] = runCheck(ctx, checker)
+		}(i, checker)
+	}
+	wg.Wait()
+	for _, result := range report.Checks {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// Run the checks and write their report as JSON.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := h.Run(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status == StatusUp {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// Run a check and return its result. The check is reported as down if it has not completed
+// before the context is done.
+func runCheck(ctx context.Context, checker Checker) CheckResult {
+	start := time.Now()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- checker.Check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	result := CheckResult{Name: checker.Name(), Status: StatusUp, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
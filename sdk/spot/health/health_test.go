@@ -0,0 +1,86 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for Handler
+type HandlerUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestHandlerUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(HandlerUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the handler reports healthy checks.
+//
+// Test will ensure:
+//   - The response status is 200 and the report is up when all checks are up.
+//   - The report is written as JSON with the results of the checks in registration order.
+func (suite *HandlerUnitTestSuite) TestHealthy() {
+	handler := NewHandler(0,
+		NewChecker("first", func(ctx context.Context) error { return nil }),
+		NewChecker("second", func(ctx context.Context) error { return nil }))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(suite.T(), http.StatusOK, rec.Code)
+	require.Equal(suite.T(), "application/json", rec.Header().Get("Content-Type"))
+	report := new(Report)
+	require.NoError(suite.T(), json.Unmarshal(rec.Body.Bytes(), report))
+	require.Equal(suite.T(), StatusUp, report.Status)
+	require.Len(suite.T(), report.Checks, 2)
+	require.Equal(suite.T(), "first", report.Checks[0].Name)
+	require.Equal(suite.T(), "second", report.Checks[1].Name)
+	require.Equal(suite.T(), StatusUp, report.Checks[1].Status)
+	require.Empty(suite.T(), report.Checks[1].Error)
+}
+
+// Test the handler reports failed checks.
+//
+// Test will ensure:
+//   - The response status is 503 and the report is down when a check fails.
+//   - The error of the failed check is reported.
+//   - A check which does not complete before the timeout is reported as down.
+func (suite *HandlerUnitTestSuite) TestUnhealthy() {
+	block := make(chan struct{})
+	defer close(block)
+	handler := NewHandler(10*time.Millisecond,
+		NewChecker("ok", func(ctx context.Context) error { return nil }),
+		NewChecker("ko", func(ctx context.Context) error { return fmt.Errorf("boom") }),
+		NewChecker("stuck", func(ctx context.Context) error {
+			<-block
+			return nil
+		}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(suite.T(), http.StatusServiceUnavailable, rec.Code)
+	report := new(Report)
+	require.NoError(suite.T(), json.Unmarshal(rec.Body.Bytes(), report))
+	require.Equal(suite.T(), StatusDown, report.Status)
+	require.Equal(suite.T(), CheckResult{Name: "ok", Status: StatusUp, DurationMs: report.Checks[0].DurationMs}, report.Checks[0])
+	require.Equal(suite.T(), StatusDown, report.Checks[1].Status)
+	require.Equal(suite.T(), "boom", report.Checks[1].Error)
+	require.Equal(suite.T(), StatusDown, report.Checks[2].Status)
+	require.Equal(suite.T(), context.DeadlineExceeded.Error(), report.Checks[2].Error)
+	// No checks
+	require.Equal(suite.T(), StatusUp, NewHandler(0).Run(context.Background()).Status)
+}
@@ -0,0 +1,78 @@
+//go:build !goctopus_publiconly
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+)
+
+// Source of the state of a websocket token, like the private websocket client.
+type TokenStateProvider interface {
+	// Get a snapshot of the state of the websocket token.
+	GetTokenState() websocket.TokenState
+}
+
+// Checker which checks a private websocket client has a valid websocket token.
+type TokenChecker struct {
+	// Name of the check
+	name string
+	// Client which provides the token state
+	client TokenStateProvider
+	// Minimum remaining validity of the token
+	minValidity time.Duration
+	// Clock used to get the current time
+	clock clock.Clock
+}
+
+// # Description
+//
+// Build a Checker which fails when the private websocket client has no valid websocket token or
+// when its token expires soon. The token is fetched lazily by the client: use this checker with
+// the background token refresher (Cf. StartTokenRefresher).
+//
+// # Inputs
+//
+//   - name: Name of the check.
+//   - client: Private websocket client. Must not be nil.
+//   - minValidity: Minimum remaining validity of the token. Zero only checks the token has not expired.
+//
+// # Return
+//
+// The TokenChecker.
+func NewTokenChecker(name string, client TokenStateProvider, minValidity time.Duration) *TokenChecker {
+	return &TokenChecker{name: name, client: client, minValidity: minValidity, clock: clock.NewSystemClock()}
+}
+
+// Set the clock used to get the current time. This can be used to provide a clock.FakeClock in
+// tests. If nil, the system clock is used.
+func (c *TokenChecker) SetClock(clk clock.Clock) {
+	if clk == nil {
+		clk = clock.NewSystemClock()
+	}
+	c.clock = clk
+}
+
+// Get the name of the check.
+func (c *TokenChecker) Name() string {
+	return c.name
+}
+
+// Check the websocket token is valid for at least the minimum validity.
+func (c *TokenChecker) Check(ctx context.Context) error {
+	state := c.client.GetTokenState()
+	if !state.HasValidToken {
+		if state.LastError != nil {
+			return fmt.Errorf("no valid websocket token: %w", state.LastError)
+		}
+		return fmt.Errorf("no valid websocket token")
+	}
+	if remaining := state.ExpiresAt.Sub(c.clock.Now()); remaining < c.minValidity {
+		return fmt.Errorf("websocket token expires in %s", remaining)
+	}
+	return nil
+}
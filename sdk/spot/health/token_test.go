@@ -0,0 +1,71 @@
+//go:build !goctopus_publiconly
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// The private websocket client provides the token state
+var _ TokenStateProvider = (*websocket.KrakenSpotPrivateWebsocketClient)(nil)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for TokenChecker
+type TokenCheckerUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestTokenCheckerUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(TokenCheckerUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test TokenChecker.
+//
+// Test will ensure:
+//   - The check fails when there is no valid token and reports the last refresh error.
+//   - The check fails when the token expires before the minimum validity.
+//   - The check succeeds when the token is valid for longer than the minimum validity.
+func (suite *TokenCheckerUnitTestSuite) TestTokenChecker() {
+	now := time.Now()
+	state := &fakeTokenState{}
+	checker := NewTokenChecker("token", state, time.Minute)
+	checker.SetClock(clock.NewFakeClock(now))
+	require.Equal(suite.T(), "token", checker.Name())
+	require.EqualError(suite.T(), checker.Check(context.Background()), "no valid websocket token")
+	state.state.LastError = fmt.Errorf("invalid key")
+	require.EqualError(suite.T(), checker.Check(context.Background()), "no valid websocket token: invalid key")
+	state.state = websocket.TokenState{HasValidToken: true, ExpiresAt: now.Add(30 * time.Second)}
+	require.EqualError(suite.T(), checker.Check(context.Background()), "websocket token expires in 30s")
+	state.state.ExpiresAt = now.Add(10 * time.Minute)
+	require.NoError(suite.T(), checker.Check(context.Background()))
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Token state provider with a configurable state.
+type fakeTokenState struct {
+	state websocket.TokenState
+}
+
+// Get the configured token state.
+func (f *fakeTokenState) GetTokenState() websocket.TokenState {
+	return f.state
+}
@@ -0,0 +1,411 @@
+// Package lifecycle provides a Coordinator which performs a process-wide graceful shutdown of the
+// websocket engines, background refreshers and in-flight REST requests of an application.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+)
+
+/*************************************************************************************************/
+/* MODEL                                                                                         */
+/*************************************************************************************************/
+
+// Default maximum duration of the shutdown started by Run.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// Error returned by Begin once the shutdown has started.
+var ErrShuttingDown = errors.New("shutdown in progress")
+
+// Enum for the shutdown phases, in execution order.
+type ShutdownPhaseEnum string
+
+// Values for ShutdownPhaseEnum
+const (
+	// Open orders are cancelled or the dead man's switch is armed with the registered private
+	// websocket clients.
+	PhaseCancelOnDisconnect ShutdownPhaseEnum = "cancel_on_disconnect"
+	// In-flight REST requests are waited.
+	PhaseRESTRequests ShutdownPhaseEnum = "rest_requests"
+	// Background refreshers are stopped.
+	PhaseRefreshers ShutdownPhaseEnum = "refreshers"
+	// Websocket engines are stopped.
+	PhaseEngines ShutdownPhaseEnum = "engines"
+	// Events published in the registered channels are handed to their handlers.
+	PhaseDrain ShutdownPhaseEnum = "drain"
+)
+
+// Enum for the cancel-on-disconnect behaviors applied by the Coordinator to a private websocket
+// client before its engine is stopped.
+type CancelOnDisconnectEnum string
+
+// Values for CancelOnDisconnectEnum
+const (
+	// Cancel all open orders with cancelAll.
+	CancelAllOnShutdown CancelOnDisconnectEnum = "cancel_all"
+	// Arm the dead man's switch with cancelAllOrdersAfter: orders are cancelled by Kraken once the
+	// timeout expires, unless another process takes over and resets the timer.
+	DeadMansSwitchOnShutdown CancelOnDisconnectEnum = "dead_mans_switch"
+)
+
+// Error which occured during a shutdown phase.
+type ShutdownError struct {
+	// Phase during which the error occured
+	Phase ShutdownPhaseEnum
+	// Name of the component which failed
+	Component string
+	// Root error
+	Root error
+}
+
+// Format the error message
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("shutdown of %s failed during phase %s: %s", e.Component, e.Phase, e.Root.Error())
+}
+
+// Unwrap the root error
+func (e *ShutdownError) Unwrap() error {
+	return e.Root
+}
+
+// Component which can be stopped, like a *wscengine.WebsocketEngine.
+type Stopper interface {
+	// Stop the component. The context bounds the duration of the stop.
+	Stop(ctx context.Context) error
+}
+
+// A registered websocket engine.
+type engine struct {
+	name    string
+	stopper Stopper
+}
+
+// A registered background refresher.
+type refresher struct {
+	name   string
+	cancel context.CancelFunc
+}
+
+// A registered private websocket client with its cancel-on-disconnect behavior.
+type cancelOnDisconnect struct {
+	name    string
+	client  websocket.KrakenSpotPrivateWebsocketClientInterface
+	mode    CancelOnDisconnectEnum
+	timeout time.Duration
+}
+
+// A registered channel to drain.
+type drain struct {
+	name    string
+	ch      <-chan event.Event
+	handler func(event.Event)
+}
+
+/*************************************************************************************************/
+/* COORDINATOR                                                                                   */
+/*************************************************************************************************/
+
+// # Description
+//
+// Coordinator which performs an ordered shutdown of the registered components:
+//
+//  1. Cancel-on-disconnect: open orders are cancelled or the dead man's switch is armed with the
+//     registered private websocket clients, while their connections are still up.
+//  2. REST requests: new requests are refused with ErrShuttingDown and in-flight requests are waited.
+//  3. Refreshers: background refreshers (token refresher, latency monitor, ...) are stopped.
+//  4. Engines: websocket engines are stopped in reverse registration order.
+//  5. Drain: events published in the registered channels (ex: openOrders) are handed to their
+//     handlers. Draining starts with the shutdown, concurrently with the other phases, so events
+//     published while orders are cancelled or engines are stopped (ex: connection_interrupted) do
+//     not block them. Once the engines are stopped, events left in the channels are drained.
+//
+// Components are registered before the shutdown starts. Shutdown runs only once: further calls
+// wait for and return the result of the first shutdown.
+//
+// Coordinator is safe for concurrent use.
+type Coordinator struct {
+	// Mutex used to protect the coordinator state
+	mu sync.Mutex
+	// True once the shutdown has started
+	shuttingDown bool
+	// In-flight REST requests
+	inflight sync.WaitGroup
+	// Registered components
+	engines            []engine
+	refreshers         []refresher
+	cancelOnDisconnect []cancelOnDisconnect
+	drains             []drain
+	// Closed once the shutdown is complete
+	done chan struct{}
+	// Result of the shutdown
+	err error
+}
+
+// Factory which returns a new Coordinator without any registered component.
+func NewCoordinator() *Coordinator {
+	return &Coordinator{done: make(chan struct{})}
+}
+
+// # Description
+//
+// Register a websocket engine (or any other Stopper) which is stopped during the engines phase.
+// Engines are stopped in reverse registration order.
+//
+// # Inputs
+//
+//   - name: Name of the engine, used in errors.
+//   - stopper: Engine to stop, like a *wscengine.WebsocketEngine.
+func (c *Coordinator) RegisterEngine(name string, stopper Stopper) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.engines = append(c.engines, engine{name: name, stopper: stopper})
+}
+
+// # Description
+//
+// Start a background refresher which is stopped during the refreshers phase. The refresher is
+// started with a context which is cancelled during the shutdown, like the one expected by
+// StartTokenRefresher or StartLatencyMonitor.
+//
+// # Inputs
+//
+//   - name: Name of the refresher.
+//   - start: Function which starts the refresher with the provided context.
+//
+// # Return
+//
+// ErrShuttingDown if the shutdown has started or the error returned by start.
+func (c *Coordinator) StartRefresher(name string, start func(ctx context.Context) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.shuttingDown {
+		return ErrShuttingDown
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := start(ctx); err != nil {
+		cancel()
+		return err
+	}
+	c.refreshers = append(c.refreshers, refresher{name: name, cancel: cancel})
+	return nil
+}
+
+// # Description
+//
+// Register a private websocket client whose open orders must be handled before its engine is
+// stopped.
+//
+// # Inputs
+//
+//   - name: Name of the client, used in errors.
+//   - client: Private websocket client.
+//   - mode: Cancel-on-disconnect behavior.
+//   - timeout: Timeout of the dead man's switch. Rounded up to the second. Ignored for CancelAllOnShutdown.
+func (c *Coordinator) RegisterCancelOnDisconnect(name string, client websocket.KrakenSpotPrivateWebsocketClientInterface, mode CancelOnDisconnectEnum, timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cancelOnDisconnect = append(c.cancelOnDisconnect, cancelOnDisconnect{name: name, client: client, mode: mode, timeout: timeout})
+}
+
+// # Description
+//
+// Register a channel, like an openOrders channel, whose events are handed to the provided handler
+// during the whole shutdown.
+//
+// # Inputs
+//
+//   - name: Name of the channel.
+//   - ch: Channel to drain. Draining starts when the shutdown starts and stops once the channel is
+//     closed, or once the engines are stopped and the channel is empty.
+//   - handler: Function called for each event. The handler is called from a dedicated goroutine,
+//     concurrently with the other shutdown phases.
+func (c *Coordinator) RegisterDrain(name string, ch <-chan event.Event, handler func(event.Event)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.drains = append(c.drains, drain{name: name, ch: ch, handler: handler})
+}
+
+// # Description
+//
+// Declare the start of a REST request. The shutdown waits for the request before stopping the
+// refreshers and the engines.
+//
+// # Return
+//
+// A function which must be called once the request is complete, or ErrShuttingDown if the
+// shutdown has started: the request must not be sent in that case.
+func (c *Coordinator) Begin() (func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.shuttingDown {
+		return nil, ErrShuttingDown
+	}
+	c.inflight.Add(1)
+	once := sync.Once{}
+	return func() { once.Do(c.inflight.Done) }, nil
+}
+
+// Tell whether the shutdown has started.
+func (c *Coordinator) ShuttingDown() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.shuttingDown
+}
+
+// Channel closed once the shutdown is complete.
+func (c *Coordinator) Done() <-chan struct{} {
+	return c.done
+}
+
+// # Description
+//
+// Wait until the provided context is done or until the process receives one of the provided
+// signals, then perform the shutdown.
+//
+// # Inputs
+//
+//   - ctx: Context which triggers the shutdown once done.
+//   - timeout: Maximum duration of the shutdown. A zero or negative value means DefaultShutdownTimeout.
+//   - signals: Signals which trigger the shutdown. SIGTERM and SIGINT if none are provided.
+//
+// # Return
+//
+// The result of the shutdown.
+func (c *Coordinator) Run(ctx context.Context, timeout time.Duration, signals ...os.Signal) error {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGTERM, os.Interrupt}
+	}
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	sigctx, stop := signal.NotifyContext(ctx, signals...)
+	defer stop()
+	<-sigctx.Done()
+	shutdownctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return c.Shutdown(shutdownctx)
+}
+
+// # Description
+//
+// Perform the ordered shutdown of the registered components. An error in a phase does not
+// prevent the next phases from being performed.
+//
+// # Inputs
+//
+//   - ctx: Context which bounds the duration of the shutdown. Once done, remaining REST requests
+//     are not waited anymore and remaining phases are performed with the done context.
+//
+// # Return
+//
+// Nil if all components have been shut down, otherwise the ShutdownError of each failure joined
+// together.
+func (c *Coordinator) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	if c.shuttingDown {
+		c.mu.Unlock()
+		select {
+		case <-c.done:
+			return c.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	c.shuttingDown = true
+	cods, refreshers, engines, drains := c.cancelOnDisconnect, c.refreshers, c.engines, c.drains
+	c.mu.Unlock()
+	errs := []error{}
+	// Drain, for the whole shutdown so that events published by the other phases do not block them
+	enginesStopped := make(chan struct{})
+	drained := sync.WaitGroup{}
+	for _, d := range drains {
+		drained.Add(1)
+		go func(d drain) {
+			defer drained.Done()
+			drainChannel(d, enginesStopped)
+		}(d)
+	}
+	// Cancel-on-disconnect
+	for _, cod := range cods {
+		var err error
+		switch cod.mode {
+		case DeadMansSwitchOnShutdown:
+			seconds := int((cod.timeout + time.Second - 1) / time.Second)
+			_, err = cod.client.CancellAllOrdersAfterX(ctx, websocket.CancelAllOrdersAfterXRequestParameters{Timeout: seconds})
+		default:
+			_, err = cod.client.CancellAllOrders(ctx)
+		}
+		if err != nil {
+			errs = append(errs, &ShutdownError{Phase: PhaseCancelOnDisconnect, Component: cod.name, Root: err})
+		}
+	}
+	// In-flight REST requests
+	waited := make(chan struct{})
+	go func() {
+		c.inflight.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+	case <-ctx.Done():
+		errs = append(errs, &ShutdownError{Phase: PhaseRESTRequests, Component: "rest", Root: ctx.Err()})
+	}
+	// Refreshers
+	for _, r := range refreshers {
+		r.cancel()
+	}
+	// Engines, in reverse registration order
+	for i := len(engines) - 1; i >= 0; i-- {
+		if err := engines[i].stopper.Stop(ctx); err != nil {
+			errs = append(errs, &ShutdownError{Phase: PhaseEngines, Component: engines[i].name, Root: err})
+		}
+	}
+	// Drain the events left once the engines are stopped
+	close(enginesStopped)
+	drained.Wait()
+	c.err = errors.Join(errs...)
+	close(c.done)
+	return c.err
+}
+
+// Hand the events of a channel to its handler until the channel is closed, or until stopped is
+// closed and the channel is empty.
+func drainChannel(d drain, stopped <-chan struct{}) {
+	for {
+		select {
+		case e, ok := <-d.ch:
+			if !ok {
+				return
+			}
+			d.handle(e)
+		case <-stopped:
+			for {
+				select {
+				case e, ok := <-d.ch:
+					if !ok {
+						return
+					}
+					d.handle(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Hand an event to the handler, if any.
+func (d drain) handle(e event.Event) {
+	if d.handler != nil {
+		d.handler(e)
+	}
+}
@@ -0,0 +1,213 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/gowse/wscengine"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/papertrading"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for Coordinator
+type CoordinatorUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestCoordinatorUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(CoordinatorUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test an ordered shutdown.
+//
+// Test will ensure:
+//   - Open orders are cancelled before the engines are stopped.
+//   - The dead man's switch is armed with the timeout rounded up to the second.
+//   - In-flight REST requests are waited and new requests are refused.
+//   - Refreshers are stopped before the engines.
+//   - Engines are stopped in reverse registration order.
+//   - Events published in registered channels during the shutdown are drained.
+//   - Further calls to Shutdown return the result of the first shutdown.
+func (suite *CoordinatorUnitTestSuite) TestShutdown() {
+	c := NewCoordinator()
+	steps := &recorder{}
+	// Cancel-on-disconnect
+	paper := papertrading.NewKrakenSpotPaperTradingClient(nil, 0, nil)
+	openOrders := make(chan event.Event, 10)
+	require.NoError(suite.T(), paper.SubscribeOpenOrders(context.Background(), false, openOrders))
+	_, err := paper.AddOrder(context.Background(), websocket.AddOrderRequestParameters{
+		OrderType: "limit", Type: "buy", Pair: "XBT/USD", Price: "1", Volume: "1",
+	})
+	require.NoError(suite.T(), err)
+	c.RegisterCancelOnDisconnect("paper", paper, CancelAllOnShutdown, 0)
+	dms := &dmsClient{KrakenSpotPrivateWebsocketClientInterface: paper, steps: steps}
+	c.RegisterCancelOnDisconnect("dms", dms, DeadMansSwitchOnShutdown, 1500*time.Millisecond)
+	// Refresher
+	var refresher context.Context
+	require.NoError(suite.T(), c.StartRefresher("token", func(ctx context.Context) error {
+		refresher = ctx
+		return nil
+	}))
+	require.Error(suite.T(), c.StartRefresher("failed", func(ctx context.Context) error { return errors.New("failure") }))
+	// Engines
+	c.RegisterEngine("public", &stopper{name: "public", steps: steps})
+	c.RegisterEngine("private", &stopper{name: "private", steps: steps, refresher: refresher})
+	// Drain
+	drained := 0
+	c.RegisterDrain("openOrders", openOrders, func(e event.Event) { drained++ })
+	// In-flight request
+	end, err := c.Begin()
+	require.NoError(suite.T(), err)
+	result := make(chan error, 1)
+	go func() { result <- c.Shutdown(context.Background()) }()
+	require.Eventually(suite.T(), c.ShuttingDown, time.Second, time.Millisecond)
+	_, err = c.Begin()
+	require.ErrorIs(suite.T(), err, ErrShuttingDown)
+	require.Never(suite.T(), func() bool { return len(result) > 0 }, 50*time.Millisecond, time.Millisecond)
+	require.Equal(suite.T(), []string{"dms"}, steps.get())
+	end()
+	end()
+	require.NoError(suite.T(), <-result)
+	require.Equal(suite.T(), []string{"dms", "refresher", "private", "public"}, steps.get())
+	require.Equal(suite.T(), 2, dms.timeout)
+	// Snapshot, new order and cancelled order
+	require.Equal(suite.T(), 3, drained)
+	require.Empty(suite.T(), openOrders)
+	// Shutdown only once
+	require.NoError(suite.T(), c.Shutdown(context.Background()))
+	<-c.Done()
+}
+
+// Test draining a channel in which events are published while orders are cancelled.
+//
+// Test will ensure:
+//   - Shutdown does not hang when cancel-all publishes in a full drained channel.
+//   - Published events are handed to the handler.
+func (suite *CoordinatorUnitTestSuite) TestShutdownDrainDuringCancel() {
+	c := NewCoordinator()
+	paper := papertrading.NewKrakenSpotPaperTradingClient(nil, 0, nil)
+	// Channel is full once the snapshot and the new order are published
+	openOrders := make(chan event.Event, 2)
+	require.NoError(suite.T(), paper.SubscribeOpenOrders(context.Background(), false, openOrders))
+	drained := 0
+	c.RegisterDrain("openOrders", openOrders, func(e event.Event) { drained++ })
+	_, err := paper.AddOrder(context.Background(), websocket.AddOrderRequestParameters{
+		OrderType: "limit", Type: "buy", Pair: "XBT/USD", Price: "1", Volume: "1",
+	})
+	require.NoError(suite.T(), err)
+	c.RegisterCancelOnDisconnect("paper", paper, CancelAllOnShutdown, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(suite.T(), c.Shutdown(ctx))
+	require.Equal(suite.T(), 3, drained)
+}
+
+// Test shutdown failures.
+//
+// Test will ensure:
+//   - A failure does not prevent the next phases from being performed.
+//   - Failures are returned as ShutdownError.
+//   - The shutdown does not wait for in-flight requests once the context is done.
+func (suite *CoordinatorUnitTestSuite) TestShutdownErrors() {
+	c := NewCoordinator()
+	steps := &recorder{}
+	root := errors.New("failure")
+	c.RegisterEngine("public", &stopper{name: "public", steps: steps})
+	c.RegisterEngine("private", &stopper{name: "private", steps: steps, err: root})
+	_, err := c.Begin()
+	require.NoError(suite.T(), err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = c.Shutdown(ctx)
+	require.ErrorIs(suite.T(), err, root)
+	require.ErrorIs(suite.T(), err, context.DeadlineExceeded)
+	serr := new(ShutdownError)
+	require.ErrorAs(suite.T(), err, &serr)
+	require.Equal(suite.T(), []string{"private", "public"}, steps.get())
+}
+
+// Test Run triggers the shutdown once the context is done.
+//
+// Test will ensure:
+//   - Websocket engines can be registered.
+//   - Run returns the result of the shutdown once the context is cancelled.
+func (suite *CoordinatorUnitTestSuite) TestRun() {
+	require.Implements(suite.T(), (*Stopper)(nil), new(wscengine.WebsocketEngine))
+	c := NewCoordinator()
+	steps := &recorder{}
+	c.RegisterEngine("public", &stopper{name: "public", steps: steps})
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- c.Run(ctx, 0) }()
+	require.Never(suite.T(), c.ShuttingDown, 20*time.Millisecond, time.Millisecond)
+	cancel()
+	require.NoError(suite.T(), <-result)
+	require.Equal(suite.T(), []string{"public"}, steps.get())
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Records the shutdown steps in order.
+type recorder struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (r *recorder) add(step string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.steps = append(r.steps, step)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.steps...)
+}
+
+// Stopper which records its stop and whether the refresher context was cancelled before.
+type stopper struct {
+	name      string
+	steps     *recorder
+	err       error
+	refresher context.Context
+}
+
+func (s *stopper) Stop(ctx context.Context) error {
+	if s.refresher != nil && s.refresher.Err() != nil {
+		s.steps.add("refresher")
+	}
+	s.steps.add(s.name)
+	return s.err
+}
+
+// Private client which records the dead man's switch timeout.
+type dmsClient struct {
+	websocket.KrakenSpotPrivateWebsocketClientInterface
+	steps   *recorder
+	timeout int
+}
+
+func (c *dmsClient) CancellAllOrdersAfterX(ctx context.Context, params websocket.CancelAllOrdersAfterXRequestParameters) (*messages.CancelAllOrdersAfterXResponse, error) {
+	c.steps.add("dms")
+	c.timeout = params.Timeout
+	return &messages.CancelAllOrdersAfterXResponse{}, nil
+}
@@ -0,0 +1,466 @@
+// Package metadata provides a background service which periodically refreshes asset and pair
+// metadata from the REST API and notifies subscribers of listings, delistings and changes so
+// long-running bots learn about new pairs without restart.
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+)
+
+// Default interval between two refreshes.
+const DefaultRefreshInterval = time.Hour
+
+// Source of the events published by the metadata refresher.
+const MetadataRefresherEventSource = "goctopus.sdk.spot.metadata"
+
+// Enum for the types of the events published by the metadata refresher.
+type MetadataEventTypeEnum string
+
+// Values for MetadataEventTypeEnum
+const (
+	// A pair has been listed. Data: PairChange.
+	PairListed MetadataEventTypeEnum = "pair_listed"
+	// A pair has been delisted. Data: PairChange.
+	PairDelisted MetadataEventTypeEnum = "pair_delisted"
+	// The tick size, status, order minimums or decimals of a pair have changed. Data: PairChange.
+	PairChanged MetadataEventTypeEnum = "pair_changed"
+	// An asset has been listed. Data: AssetChange.
+	AssetListed MetadataEventTypeEnum = "asset_listed"
+	// An asset has been delisted. Data: AssetChange.
+	AssetDelisted MetadataEventTypeEnum = "asset_delisted"
+	// The status or decimals of an asset have changed. Data: AssetChange.
+	AssetChanged MetadataEventTypeEnum = "asset_changed"
+	// A refresh has failed. Data: RefreshFailed.
+	MetadataRefreshFailed MetadataEventTypeEnum = "metadata_refresh_failed"
+)
+
+// Data of pair_listed, pair_delisted and pair_changed events.
+type PairChange struct {
+	// Pair name (ex: XXBTZUSD)
+	Pair string `json:"pair"`
+	// Pair info before the change. Nil for listings.
+	Previous *market.AssetPairInfo `json:"previous,omitempty"`
+	// Pair info after the change. Nil for delistings.
+	Current *market.AssetPairInfo `json:"current,omitempty"`
+	// JSON names of the changed fields (ex: tick_size). Only set for pair_changed events.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Data of asset_listed, asset_delisted and asset_changed events.
+type AssetChange struct {
+	// Asset name (ex: XXBT)
+	Asset string `json:"asset"`
+	// Asset info before the change. Nil for listings.
+	Previous *market.AssetInfo `json:"previous,omitempty"`
+	// Asset info after the change. Nil for delistings.
+	Current *market.AssetInfo `json:"current,omitempty"`
+	// JSON names of the changed fields (ex: status). Only set for asset_changed events.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Data of metadata_refresh_failed events.
+type RefreshFailed struct {
+	// Error message
+	Error string `json:"error"`
+}
+
+// Statistics of a metadata refresher.
+type RefresherStats struct {
+	// Number of successful refreshes
+	Refreshes int64
+	// Time of the last successful refresh. Zero if no refresh has succeeded yet.
+	LastRefresh time.Time
+	// Error returned by the last refresh attempt. Nil if it succeeded.
+	LastError error
+}
+
+// # Description
+//
+// Background service which periodically refreshes GetAssetInfo and GetTradableAssetPairs and
+// detects listings, delistings and changes of the tick size, status, order minimums or decimals
+// of pairs and assets.
+//
+// The first refresh builds the initial view of the metadata and does not publish any event. Each
+// later refresh publishes a typed event (Cf. MetadataEventTypeEnum) for each detected listing,
+// delisting or change. Events are published pairs first then assets, ordered by name.
+//
+// The refresher is safe for concurrent use.
+type Refresher struct {
+	// REST client used to fetch metadata
+	client rest.KrakenSpotRESTClientIface
+	// Interval between two refreshes
+	interval time.Duration
+	// Optional channel used to publish events
+	pub chan event.Event
+	// Logger used to publish debug/verbose logs
+	logger *log.Logger
+	// Clock used to schedule refreshes
+	clock clock.Clock
+	// Mutex used to protect refresher state
+	mu sync.Mutex
+	// Mutex used to serialize refreshes
+	refreshMu sync.Mutex
+	// Current pairs by name. Nil until the first successful refresh.
+	pairs map[string]*market.AssetPairInfo
+	// Current assets by name. Nil until the first successful refresh.
+	assets map[string]*market.AssetInfo
+	// Statistics
+	stats RefresherStats
+	// Cancel function of the refresh goroutine. Nil if not started.
+	stop context.CancelFunc
+	// Channel closed when the refresh goroutine exits
+	done chan struct{}
+}
+
+// # Description
+//
+// Build a new metadata refresher.
+//
+// # Inputs
+//
+//   - client: REST client used to fetch metadata.
+//   - interval: Interval between two refreshes. DefaultRefreshInterval is used if interval is not
+//     strictly positive.
+//   - pub: Optional channel used to publish events. Blocking writes are used. Can be nil.
+//   - logger: Optional logger used to publish debug/verbose logs. Can be nil.
+//
+// # Return
+//
+// The new metadata refresher or an error if no client is provided.
+func NewRefresher(client rest.KrakenSpotRESTClientIface, interval time.Duration, pub chan event.Event, logger *log.Logger) (*Refresher, error) {
+	if client == nil {
+		return nil, fmt.Errorf("a REST client must be provided")
+	}
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	if logger == nil {
+		logger = log.New(io.Discard, "", log.Flags())
+	}
+	return &Refresher{
+		client:   client,
+		interval: interval,
+		pub:      pub,
+		logger:   logger,
+		clock:    clock.NewSystemClock(),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Set the clock used to schedule refreshes. This can be used to provide a clock.FakeClock in
+// tests. Must be called before Start. If nil, the system clock is used.
+func (r *Refresher) SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.NewSystemClock()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clock = c
+}
+
+// # Description
+//
+// Start refreshing metadata in background. The first refresh is made immediately, then metadata
+// are refreshed at each interval until Stop is called or the provided context is cancelled.
+// Failed refreshes are logged, published as metadata_refresh_failed events and retried at the
+// next interval.
+//
+// # Return
+//
+// An error if the refresher has already been started or has been stopped.
+func (r *Refresher) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop != nil {
+		return fmt.Errorf("metadata refresher has already been started or has been stopped")
+	}
+	ctx, r.stop = context.WithCancel(ctx)
+	go r.run(ctx, r.clock)
+	return nil
+}
+
+// Stop refreshing metadata. Use Done to wait for the refresh goroutine to exit.
+func (r *Refresher) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop != nil {
+		r.stop()
+		return
+	}
+	// Refresher has not been started: prevent any start and close done channel
+	r.stop = func() {}
+	close(r.done)
+}
+
+// Get a channel which is closed once the refresher has been stopped.
+func (r *Refresher) Done() <-chan struct{} {
+	return r.done
+}
+
+// Get the refresher statistics.
+func (r *Refresher) Stats() RefresherStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+// Get a copy of the current pairs by name. Nil until the first successful refresh.
+func (r *Refresher) Pairs() map[string]market.AssetPairInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pairs == nil {
+		return nil
+	}
+	pairs := make(map[string]market.AssetPairInfo, len(r.pairs))
+	for name, info := range r.pairs {
+		pairs[name] = *info
+	}
+	return pairs
+}
+
+// Get the mapping between the names of the current pairs (Cf. market.PairAliases). Nil until the
+// first successful refresh.
+func (r *Refresher) PairAliases() *market.PairAliases {
+	pairs := r.Pairs()
+	if pairs == nil {
+		return nil
+	}
+	return market.NewPairAliases(pairs)
+}
+
+// Get a copy of the current assets by name. Nil until the first successful refresh.
+func (r *Refresher) Assets() map[string]market.AssetInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.assets == nil {
+		return nil
+	}
+	assets := make(map[string]market.AssetInfo, len(r.assets))
+	for name, info := range r.assets {
+		assets[name] = *info
+	}
+	return assets
+}
+
+// # Description
+//
+// Refresh metadata now, publish an event for each detected listing, delisting or change and
+// return the published events. No event is published by the first successful refresh.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//
+// # Return
+//
+// The events published by the refresh or an error if metadata could not be fetched. In case of
+// error, a metadata_refresh_failed event is published and the current view is left unchanged.
+func (r *Refresher) Refresh(ctx context.Context) ([]event.Event, error) {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+	pairs, assets, err := r.fetch(ctx)
+	if err != nil {
+		err = fmt.Errorf("metadata refresh failed: %w", err)
+		r.logger.Println(err.Error())
+		r.mu.Lock()
+		r.stats.LastError = err
+		r.mu.Unlock()
+		r.publish([]event.Event{r.newEvent(MetadataRefreshFailed, "", RefreshFailed{Error: err.Error()})})
+		return nil, err
+	}
+	r.mu.Lock()
+	events := []event.Event{}
+	if r.pairs != nil {
+		events = append(events, r.diffPairs(r.pairs, pairs)...)
+		events = append(events, r.diffAssets(r.assets, assets)...)
+	}
+	r.pairs = pairs
+	r.assets = assets
+	r.stats.Refreshes++
+	r.stats.LastRefresh = r.clock.Now()
+	r.stats.LastError = nil
+	r.mu.Unlock()
+	r.publish(events)
+	return events, nil
+}
+
+// Refresh metadata at each interval until the context is cancelled.
+func (r *Refresher) run(ctx context.Context, clk clock.Clock) {
+	defer close(r.done)
+	for {
+		r.Refresh(ctx)
+		timer := clk.NewTimer(r.interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+	}
+}
+
+// Fetch pairs and assets.
+func (r *Refresher) fetch(ctx context.Context) (map[string]*market.AssetPairInfo, map[string]*market.AssetInfo, error) {
+	presp, _, err := r.client.GetTradableAssetPairs(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get tradable asset pairs: %w", err)
+	}
+	if len(presp.Error) > 0 {
+		return nil, nil, fmt.Errorf("failed to get tradable asset pairs: %v", presp.Error)
+	}
+	aresp, _, err := r.client.GetAssetInfo(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get asset info: %w", err)
+	}
+	if len(aresp.Error) > 0 {
+		return nil, nil, fmt.Errorf("failed to get asset info: %v", aresp.Error)
+	}
+	pairs := make(map[string]*market.AssetPairInfo, len(presp.Result))
+	for name, info := range presp.Result {
+		if info != nil {
+			pairs[name] = info
+		}
+	}
+	assets := make(map[string]*market.AssetInfo, len(aresp.Result))
+	for name, info := range aresp.Result {
+		if info != nil {
+			assets[name] = info
+		}
+	}
+	return pairs, assets, nil
+}
+
+// Build the events for the listed, delisted and changed pairs, ordered by name.
+func (r *Refresher) diffPairs(previous map[string]*market.AssetPairInfo, current map[string]*market.AssetPairInfo) []event.Event {
+	events := []event.Event{}
+	for _, name := range sortedKeys(previous, current) {
+		prev, cur := previous[name], current[name]
+		switch {
+		case prev == nil:
+			events = append(events, r.newEvent(PairListed, name, PairChange{Pair: name, Current: cur}))
+		case cur == nil:
+			events = append(events, r.newEvent(PairDelisted, name, PairChange{Pair: name, Previous: prev}))
+		default:
+			if fields := changedPairFields(prev, cur); len(fields) > 0 {
+				events = append(events, r.newEvent(PairChanged, name, PairChange{Pair: name, Previous: prev, Current: cur, Fields: fields}))
+			}
+		}
+	}
+	return events
+}
+
+// Build the events for the listed, delisted and changed assets, ordered by name.
+func (r *Refresher) diffAssets(previous map[string]*market.AssetInfo, current map[string]*market.AssetInfo) []event.Event {
+	events := []event.Event{}
+	for _, name := range sortedKeys(previous, current) {
+		prev, cur := previous[name], current[name]
+		switch {
+		case prev == nil:
+			events = append(events, r.newEvent(AssetListed, name, AssetChange{Asset: name, Current: cur}))
+		case cur == nil:
+			events = append(events, r.newEvent(AssetDelisted, name, AssetChange{Asset: name, Previous: prev}))
+		default:
+			if fields := changedAssetFields(prev, cur); len(fields) > 0 {
+				events = append(events, r.newEvent(AssetChanged, name, AssetChange{Asset: name, Previous: prev, Current: cur, Fields: fields}))
+			}
+		}
+	}
+	return events
+}
+
+// Get the JSON names of the trading related fields which differ between two pair infos.
+func changedPairFields(prev *market.AssetPairInfo, cur *market.AssetPairInfo) []string {
+	fields := []string{}
+	if prev.TickSize != cur.TickSize {
+		fields = append(fields, "tick_size")
+	}
+	if prev.Status != cur.Status {
+		fields = append(fields, "status")
+	}
+	if prev.OrderMin != cur.OrderMin {
+		fields = append(fields, "ordermin")
+	}
+	if prev.CostMin != cur.CostMin {
+		fields = append(fields, "costmin")
+	}
+	if prev.PairDecimals != cur.PairDecimals {
+		fields = append(fields, "pair_decimals")
+	}
+	if prev.LotDecimals != cur.LotDecimals {
+		fields = append(fields, "lot_decimals")
+	}
+	if prev.CostDecimals != cur.CostDecimals {
+		fields = append(fields, "cost_decimals")
+	}
+	if prev.WebsocketName != cur.WebsocketName {
+		fields = append(fields, "wsname")
+	}
+	return fields
+}
+
+// Get the JSON names of the fields which differ between two asset infos.
+func changedAssetFields(prev *market.AssetInfo, cur *market.AssetInfo) []string {
+	fields := []string{}
+	if prev.Status != cur.Status {
+		fields = append(fields, "status")
+	}
+	if prev.Decimals != cur.Decimals {
+		fields = append(fields, "decimals")
+	}
+	if prev.DisplayDecimals != cur.DisplayDecimals {
+		fields = append(fields, "display_decimals")
+	}
+	if prev.Altname != cur.Altname {
+		fields = append(fields, "altname")
+	}
+	return fields
+}
+
+// Get the sorted union of the keys of two maps.
+func sortedKeys[T any](a map[string]T, b map[string]T) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Build an event with the provided type, subject and data.
+func (r *Refresher) newEvent(eventType MetadataEventTypeEnum, subject string, data interface{}) event.Event {
+	e := event.New()
+	e.SetType(string(eventType))
+	e.SetSource(MetadataRefresherEventSource)
+	e.SetTime(r.clock.Now())
+	if subject != "" {
+		e.SetSubject(subject)
+	}
+	e.SetData("application/json", data)
+	return e
+}
+
+// Publish events if a channel has been provided.
+func (r *Refresher) publish(events []event.Event) {
+	if r.pub == nil {
+		return
+	}
+	for _, e := range events {
+		r.pub <- e
+	}
+}
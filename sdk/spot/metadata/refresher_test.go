@@ -0,0 +1,159 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the metadata refresher
+type RefresherUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestRefresherUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(RefresherUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test change detection.
+//
+// Test will ensure:
+//   - The first refresh does not publish any event.
+//   - Listings, delistings and changes of pairs and assets are published, pairs first, by name.
+//   - Changes of fields which are not watched are ignored.
+//   - The pair aliases are built from the current pairs.
+//   - A failed refresh publishes a metadata_refresh_failed event and keeps the current view.
+func (suite *RefresherUnitTestSuite) TestRefresh() {
+	client := rest.NewMockKrakenSpotRESTClient()
+	suite.mockPairs(client, map[string]*market.AssetPairInfo{
+		"XXBTZUSD": {WebsocketName: "XBT/USD", TickSize: "0.1", Status: market.PairOnline},
+		"XETHZUSD": {WebsocketName: "ETH/USD", TickSize: "0.01", Status: market.PairOnline},
+	})
+	suite.mockAssets(client, map[string]*market.AssetInfo{"XXBT": {Status: "enabled"}, "ZUSD": {Status: "enabled"}})
+	suite.mockPairs(client, map[string]*market.AssetPairInfo{
+		"XXBTZUSD": {WebsocketName: "XBT/USD", TickSize: "1", Status: market.PairOnline, Fees: [][]float64{{0, 0.26}}},
+		"SOLUSD":   {WebsocketName: "SOL/USD", TickSize: "0.01", Status: market.PairPostOnly},
+	})
+	suite.mockAssets(client, map[string]*market.AssetInfo{"XXBT": {Status: "deposit_only"}, "ZUSD": {Status: "enabled"}, "SOL": {Status: "enabled"}})
+	client.On("GetTradableAssetPairs", mock.Anything, mock.Anything).Return(nil, nil, errors.New("boom")).Once()
+	pub := make(chan event.Event, 10)
+	refresher, err := NewRefresher(client, 0, pub, nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), DefaultRefreshInterval, refresher.interval)
+	require.Nil(suite.T(), refresher.Pairs())
+	require.Nil(suite.T(), refresher.PairAliases())
+	// Initial refresh
+	events, err := refresher.Refresh(context.Background())
+	require.NoError(suite.T(), err)
+	require.Empty(suite.T(), events)
+	require.Len(suite.T(), refresher.Pairs(), 2)
+	require.True(suite.T(), refresher.PairAliases().Same("XBT/USD", "XXBTZUSD"))
+	// Changes
+	events, err = refresher.Refresh(context.Background())
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), events, 5)
+	require.Len(suite.T(), pub, 5)
+	expected := []struct {
+		eventType MetadataEventTypeEnum
+		subject   string
+	}{{PairListed, "SOLUSD"}, {PairDelisted, "XETHZUSD"}, {PairChanged, "XXBTZUSD"}, {AssetListed, "SOL"}, {AssetChanged, "XXBT"}}
+	for i, exp := range expected {
+		e := <-pub
+		require.Equal(suite.T(), string(exp.eventType), e.Type(), i)
+		require.Equal(suite.T(), exp.subject, e.Subject(), i)
+		require.Equal(suite.T(), MetadataRefresherEventSource, e.Source())
+	}
+	change := new(PairChange)
+	require.NoError(suite.T(), events[2].DataAs(change))
+	require.Equal(suite.T(), []string{"tick_size"}, change.Fields)
+	require.Equal(suite.T(), "0.1", change.Previous.TickSize)
+	require.Equal(suite.T(), "1", change.Current.TickSize)
+	achange := new(AssetChange)
+	require.NoError(suite.T(), events[4].DataAs(achange))
+	require.Equal(suite.T(), []string{"status"}, achange.Fields)
+	// Failure
+	_, err = refresher.Refresh(context.Background())
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), string(MetadataRefreshFailed), (<-pub).Type())
+	require.Len(suite.T(), refresher.Pairs(), 2)
+	require.Len(suite.T(), refresher.Assets(), 3)
+	stats := refresher.Stats()
+	require.Equal(suite.T(), int64(2), stats.Refreshes)
+	require.Error(suite.T(), stats.LastError)
+}
+
+// Test the background refreshes.
+//
+// Test will ensure:
+//   - Metadata are refreshed immediately on start, then at each interval.
+//   - API errors are reported as failed refreshes.
+//   - The refresher cannot be started twice and stops on Stop.
+//   - A refresher stopped before being started cannot be started.
+func (suite *RefresherUnitTestSuite) TestStartStop() {
+	_, err := NewRefresher(nil, time.Minute, nil, nil)
+	require.Error(suite.T(), err)
+	client := rest.NewMockKrakenSpotRESTClient()
+	suite.mockPairs(client, map[string]*market.AssetPairInfo{})
+	suite.mockAssets(client, map[string]*market.AssetInfo{})
+	client.On("GetTradableAssetPairs", mock.Anything, mock.Anything).Return(&market.GetTradableAssetPairsResponse{
+		KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{"EService:Unavailable"}},
+	}, nil, nil)
+	fake := clock.NewFakeClock(time.Now())
+	refresher, err := NewRefresher(client, time.Minute, nil, nil)
+	require.NoError(suite.T(), err)
+	refresher.SetClock(fake)
+	require.NoError(suite.T(), refresher.Start(context.Background()))
+	require.Error(suite.T(), refresher.Start(context.Background()))
+	fake.BlockUntil(1)
+	require.Equal(suite.T(), int64(1), refresher.Stats().Refreshes)
+	fake.Advance(time.Minute)
+	require.Eventually(suite.T(), func() bool { return refresher.Stats().LastError != nil }, time.Second, time.Millisecond)
+	require.ErrorContains(suite.T(), refresher.Stats().LastError, "EService:Unavailable")
+	refresher.Stop()
+	<-refresher.Done()
+	// Stopped before start
+	refresher, err = NewRefresher(client, time.Minute, nil, nil)
+	require.NoError(suite.T(), err)
+	refresher.Stop()
+	<-refresher.Done()
+	require.Error(suite.T(), refresher.Start(context.Background()))
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Make the mocked client return the provided pairs once.
+func (suite *RefresherUnitTestSuite) mockPairs(client *rest.MockKrakenSpotRESTClient, pairs map[string]*market.AssetPairInfo) {
+	client.On("GetTradableAssetPairs", mock.Anything, mock.Anything).Return(&market.GetTradableAssetPairsResponse{
+		KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+		Result:                 pairs,
+	}, nil, nil).Once()
+}
+
+// Make the mocked client return the provided assets once.
+func (suite *RefresherUnitTestSuite) mockAssets(client *rest.MockKrakenSpotRESTClient, assets map[string]*market.AssetInfo) {
+	client.On("GetAssetInfo", mock.Anything, mock.Anything).Return(&market.GetAssetInfoResponse{
+		KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+		Result:                 assets,
+	}, nil, nil).Once()
+}
@@ -0,0 +1,314 @@
+// Package pnl provides a tracker which computes per-pair positions, average entry prices,
+// realized and unrealized P&L from executed trades (websocket ownTrades and/or REST trades
+// history) and live prices (websocket ticker).
+package pnl
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// Type of the events published by the tracker each time a position is updated.
+const PnLUpdateEventType = "pnl_update"
+
+// Source of the events published by the tracker.
+const PnLTrackerEventSource = "goctopus.sdk.spot.pnl"
+
+// Position quantities whose absolute value is below this threshold are considered as zero. This
+// absorbs the float residuals left when a position is closed by trades with decimal volumes.
+const quantityEpsilon = 1e-9
+
+// Position and P&L for a pair.
+type Position struct {
+	// Pair
+	Pair string `json:"pair"`
+	// Signed position quantity in base currency: positive for a long position, negative for a
+	// short position.
+	Quantity float64 `json:"quantity"`
+	// Average entry price of the current position. Zero if there is no position.
+	AverageEntryPrice float64 `json:"average_entry_price"`
+	// Realized P&L, in quote currency, excluding fees.
+	RealizedPnL float64 `json:"realized_pnl"`
+	// Total fees paid, in quote currency.
+	Fees float64 `json:"fees"`
+	// Last known price. Zero if no price has been received yet.
+	LastPrice float64 `json:"last_price"`
+	// Unrealized P&L of the current position, in quote currency, valued at the last known price.
+	// Zero if no price has been received yet.
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+}
+
+// Get the net P&L: realized + unrealized P&L minus fees.
+func (p Position) NetPnL() float64 {
+	return p.RealizedPnL + p.UnrealizedPnL - p.Fees
+}
+
+// Data of a pnl_update event.
+type PnLUpdate struct {
+	// ID of the trade which has updated the position. Empty if the update comes from a price
+	// update.
+	TradeId string `json:"trade_id,omitempty"`
+	// Updated position
+	Position Position `json:"position"`
+}
+
+// # Description
+//
+// Tracker which computes positions and P&L per pair from executed trades and live prices.
+//
+// Positions are computed using the average cost method: buying adds to a long position (or
+// reduces a short position), selling adds to a short position (or reduces a long position).
+// Reducing a position realizes P&L against the average entry price.
+//
+// Pairs are used as provided by the sources: the websocket API (ex: XBT/USD) and the REST API
+// (ex: XXBTZUSD) do not use the same pair names. Use the same source (or the same pair names)
+// for trades and prices.
+//
+// Trades are identified by their ID: a trade which has already been processed (ex: trades
+// replayed by an ownTrades snapshot) is ignored.
+type PnLTracker struct {
+	// Mutex used to protect tracker state
+	mu sync.Mutex
+	// Mutex used to serialize event publication
+	pubMu sync.Mutex
+	// Positions by pair
+	positions map[string]*Position
+	// IDs of processed trades
+	seenTrades map[string]bool
+	// Optional channel used to publish pnl_update events
+	pub chan event.Event
+}
+
+// A trade to process.
+type trade struct {
+	id     string
+	pair   string
+	side   string
+	price  float64
+	volume float64
+	fee    float64
+	time   float64
+}
+
+// # Description
+//
+// Build a new PnLTracker.
+//
+// # Inputs
+//
+//   - pub: Optional channel used to publish pnl_update events (Cf. PnLUpdate) each time a
+//     position is updated. Blocking writes are used. Can be nil.
+//
+// # Return
+//
+// A new PnLTracker
+func NewPnLTracker(pub chan event.Event) *PnLTracker {
+	return &PnLTracker{
+		positions:  map[string]*Position{},
+		seenTrades: map[string]bool{},
+		pub:        pub,
+	}
+}
+
+// Process an ownTrades message from the websocket API.
+func (t *PnLTracker) ProcessOwnTrades(msg messages.OwnTrades) error {
+	trades := []trade{}
+	for _, data := range msg.Data {
+		for id, ot := range data {
+			tr, err := parseTrade(id, ot.Pair, ot.Type, ot.Price, ot.Volume, ot.Fee, ot.Timestamp)
+			if err != nil {
+				return err
+			}
+			trades = append(trades, tr)
+		}
+	}
+	t.applyTrades(trades)
+	return nil
+}
+
+// Process the trades history returned by the REST API. Trades are processed by ascending time.
+func (t *PnLTracker) ProcessTradesHistory(result *account.GetTradesHistoryResult) error {
+	if result == nil {
+		return nil
+	}
+	trades := []trade{}
+	for id, ti := range result.Trades {
+		tr, err := parseTrade(id, ti.Pair, ti.Type, ti.Price.String(), ti.Volume.String(), ti.Fee.String(), ti.Timestamp.String())
+		if err != nil {
+			return err
+		}
+		trades = append(trades, tr)
+	}
+	t.applyTrades(trades)
+	return nil
+}
+
+// Process a ticker message from the websocket API: the last trade price is used to value the
+// position of the pair.
+func (t *PnLTracker) ProcessTicker(msg messages.Ticker) error {
+	if len(msg.Data.Close) == 0 {
+		return fmt.Errorf("ticker for %s has no last trade price", msg.Pair)
+	}
+	price, err := msg.Data.GetLastTradePrice().Float64()
+	if err != nil {
+		return fmt.Errorf("failed to parse ticker last trade price: %w", err)
+	}
+	t.SetPrice(msg.Pair, price)
+	return nil
+}
+
+// Set the price used to value the position of the pair.
+func (t *PnLTracker) SetPrice(pair string, price float64) {
+	t.pubMu.Lock()
+	defer t.pubMu.Unlock()
+	t.mu.Lock()
+	position := t.getOrCreatePosition(pair)
+	position.LastPrice = price
+	position.UnrealizedPnL = position.Quantity * (price - position.AverageEntryPrice)
+	update := PnLUpdate{Position: *position}
+	t.mu.Unlock()
+	t.publish(update)
+}
+
+// Get the position for the pair. False is returned if there is no position for the pair.
+func (t *PnLTracker) GetPosition(pair string) (Position, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	position, ok := t.positions[pair]
+	if !ok {
+		return Position{}, false
+	}
+	return *position, true
+}
+
+// Get positions for all pairs.
+func (t *PnLTracker) GetPositions() map[string]Position {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	positions := make(map[string]Position, len(t.positions))
+	for pair, position := range t.positions {
+		positions[pair] = *position
+	}
+	return positions
+}
+
+// Apply the trades by ascending time and publish updates.
+func (t *PnLTracker) applyTrades(trades []trade) {
+	sort.SliceStable(trades, func(i, j int) bool { return trades[i].time < trades[j].time })
+	t.pubMu.Lock()
+	defer t.pubMu.Unlock()
+	t.mu.Lock()
+	updates := []PnLUpdate{}
+	for _, tr := range trades {
+		if t.seenTrades[tr.id] {
+			continue
+		}
+		t.seenTrades[tr.id] = true
+		position := t.getOrCreatePosition(tr.pair)
+		applyTrade(position, tr)
+		updates = append(updates, PnLUpdate{TradeId: tr.id, Position: *position})
+	}
+	t.mu.Unlock()
+	for _, update := range updates {
+		t.publish(update)
+	}
+}
+
+// Get the position for the pair, create it if needed. Mutex must be held.
+func (t *PnLTracker) getOrCreatePosition(pair string) *Position {
+	position, ok := t.positions[pair]
+	if !ok {
+		position = &Position{Pair: pair}
+		t.positions[pair] = position
+	}
+	return position
+}
+
+// Publish the update as a pnl_update event if a channel has been provided - use blocking write.
+func (t *PnLTracker) publish(update PnLUpdate) {
+	if t.pub == nil {
+		return
+	}
+	e := event.New()
+	e.SetType(PnLUpdateEventType)
+	e.SetSource(PnLTrackerEventSource)
+	e.SetSubject(update.Position.Pair)
+	e.SetData("application/json", update)
+	t.pub <- e
+}
+
+// Apply the trade to the position using the average cost method.
+func applyTrade(position *Position, tr trade) {
+	signed := tr.volume
+	if tr.side == string(messages.Sell) {
+		signed = -tr.volume
+	}
+	position.Fees += tr.fee
+	switch {
+	case abs(position.Quantity) < quantityEpsilon || (position.Quantity > 0) == (signed > 0):
+		// Open or increase position
+		total := abs(position.Quantity) + tr.volume
+		position.AverageEntryPrice = (abs(position.Quantity)*position.AverageEntryPrice + tr.volume*tr.price) / total
+		position.Quantity += signed
+	default:
+		// Reduce, close or reverse position
+		closed := min(abs(position.Quantity), tr.volume)
+		if position.Quantity > 0 {
+			position.RealizedPnL += closed * (tr.price - position.AverageEntryPrice)
+		} else {
+			position.RealizedPnL += closed * (position.AverageEntryPrice - tr.price)
+		}
+		reversed := tr.volume-abs(position.Quantity) >= quantityEpsilon
+		position.Quantity += signed
+		if reversed {
+			position.AverageEntryPrice = tr.price
+		} else if abs(position.Quantity) < quantityEpsilon {
+			// Closed: drop float residuals so the position is flat
+			position.Quantity = 0
+			position.AverageEntryPrice = 0
+		}
+	}
+	if position.LastPrice != 0 {
+		position.UnrealizedPnL = position.Quantity * (position.LastPrice - position.AverageEntryPrice)
+	}
+}
+
+// Parse the trade data.
+func parseTrade(id string, pair string, side string, price string, volume string, fee string, timestamp string) (trade, error) {
+	tr := trade{id: id, pair: pair, side: side}
+	var err error
+	if side != string(messages.Buy) && side != string(messages.Sell) {
+		return tr, fmt.Errorf("invalid side for trade %s: %q", id, side)
+	}
+	if tr.price, err = strconv.ParseFloat(price, 64); err != nil {
+		return tr, fmt.Errorf("failed to parse price of trade %s: %w", id, err)
+	}
+	if tr.volume, err = strconv.ParseFloat(volume, 64); err != nil {
+		return tr, fmt.Errorf("failed to parse volume of trade %s: %w", id, err)
+	}
+	if fee != "" {
+		if tr.fee, err = strconv.ParseFloat(fee, 64); err != nil {
+			return tr, fmt.Errorf("failed to parse fee of trade %s: %w", id, err)
+		}
+	}
+	if timestamp != "" {
+		if tr.time, err = strconv.ParseFloat(timestamp, 64); err != nil {
+			return tr, fmt.Errorf("failed to parse time of trade %s: %w", id, err)
+		}
+	}
+	return tr, nil
+}
+
+// Absolute value of a float.
+func abs(value float64) float64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
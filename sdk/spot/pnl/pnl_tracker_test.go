@@ -0,0 +1,171 @@
+package pnl
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for PnLTracker
+type PnLTrackerUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestPnLTrackerUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(PnLTrackerUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test positions and P&L computed from ownTrades and ticker messages.
+//
+// Test will ensure:
+//   - Buying increases the position and re-averages the entry price.
+//   - Selling realizes P&L against the average entry price.
+//   - Reversing the position sets the average entry price to the trade price.
+//   - Duplicated trades are ignored.
+//   - Unrealized P&L is computed from ticker prices.
+//   - pnl_update events are published.
+func (suite *PnLTrackerUnitTestSuite) TestOwnTradesAndTicker() {
+	pub := make(chan event.Event, 10)
+	tracker := NewPnLTracker(pub)
+	msg := messages.OwnTrades{
+		ChannelName: string(messages.ChannelOwnTrades),
+		Data: []map[string]messages.OwnTradeData{
+			{"T1": {Pair: "XBT/USD", Type: "buy", Price: "100", Volume: "1", Fee: "0.1", Timestamp: "1"}},
+			{"T2": {Pair: "XBT/USD", Type: "buy", Price: "200", Volume: "1", Fee: "0.2", Timestamp: "2"}},
+		},
+	}
+	require.NoError(suite.T(), tracker.ProcessOwnTrades(msg))
+	// Duplicated trades (ex: snapshot) are ignored
+	require.NoError(suite.T(), tracker.ProcessOwnTrades(msg))
+	position, ok := tracker.GetPosition("XBT/USD")
+	require.True(suite.T(), ok)
+	require.InDelta(suite.T(), 2, position.Quantity, 1e-9)
+	require.InDelta(suite.T(), 150, position.AverageEntryPrice, 1e-9)
+	require.InDelta(suite.T(), 0.3, position.Fees, 1e-9)
+	require.Len(suite.T(), pub, 2)
+	// Ticker -> unrealized P&L
+	require.NoError(suite.T(), tracker.ProcessTicker(messages.Ticker{
+		Pair: "XBT/USD",
+		Data: messages.TickerData{Close: []json.Number{"160", "0.1"}},
+	}))
+	position, _ = tracker.GetPosition("XBT/USD")
+	require.InDelta(suite.T(), 160, position.LastPrice, 1e-9)
+	require.InDelta(suite.T(), 20, position.UnrealizedPnL, 1e-9)
+	// Sell 3 -> realize 2 * (170 - 150) and reverse to a short position of 1 @ 170
+	require.NoError(suite.T(), tracker.ProcessOwnTrades(messages.OwnTrades{
+		Data: []map[string]messages.OwnTradeData{
+			{"T3": {Pair: "XBT/USD", Type: "sell", Price: "170", Volume: "3", Fee: "0.5", Timestamp: "3"}},
+		},
+	}))
+	position, _ = tracker.GetPosition("XBT/USD")
+	require.InDelta(suite.T(), -1, position.Quantity, 1e-9)
+	require.InDelta(suite.T(), 170, position.AverageEntryPrice, 1e-9)
+	require.InDelta(suite.T(), 40, position.RealizedPnL, 1e-9)
+	require.InDelta(suite.T(), 10, position.UnrealizedPnL, 1e-9)
+	require.InDelta(suite.T(), 49.2, position.NetPnL(), 1e-9)
+	// Buy back -> position is flat
+	require.NoError(suite.T(), tracker.ProcessOwnTrades(messages.OwnTrades{
+		Data: []map[string]messages.OwnTradeData{
+			{"T4": {Pair: "XBT/USD", Type: "buy", Price: "180", Volume: "1", Timestamp: "4"}},
+		},
+	}))
+	position, _ = tracker.GetPosition("XBT/USD")
+	require.Zero(suite.T(), position.Quantity)
+	require.Zero(suite.T(), position.AverageEntryPrice)
+	require.Zero(suite.T(), position.UnrealizedPnL)
+	require.InDelta(suite.T(), 30, position.RealizedPnL, 1e-9)
+	// Check published events
+	require.Len(suite.T(), pub, 5)
+	for i := 0; i < 4; i++ {
+		<-pub
+	}
+	e := <-pub
+	require.Equal(suite.T(), PnLUpdateEventType, e.Type())
+	update := new(PnLUpdate)
+	require.NoError(suite.T(), json.Unmarshal(e.Data(), update))
+	require.Equal(suite.T(), "T4", update.TradeId)
+	require.Equal(suite.T(), position, update.Position)
+}
+
+// Test positions computed from the REST trades history.
+//
+// Test will ensure:
+//   - Trades are processed by ascending time.
+//   - Positions are tracked by pair.
+func (suite *PnLTrackerUnitTestSuite) TestTradesHistory() {
+	tracker := NewPnLTracker(nil)
+	require.NoError(suite.T(), tracker.ProcessTradesHistory(nil))
+	require.NoError(suite.T(), tracker.ProcessTradesHistory(&account.GetTradesHistoryResult{
+		Trades: map[string]*account.TradeInfo{
+			"T2": {Pair: "XXBTZUSD", Type: "sell", Price: "120", Volume: "1", Fee: "0", Timestamp: "2"},
+			"T1": {Pair: "XXBTZUSD", Type: "buy", Price: "100", Volume: "2", Fee: "0", Timestamp: "1"},
+			"T3": {Pair: "XETHZUSD", Type: "sell", Price: "10", Volume: "5", Fee: "0.1", Timestamp: "3"},
+		},
+		Count: 3,
+	}))
+	positions := tracker.GetPositions()
+	require.Len(suite.T(), positions, 2)
+	require.InDelta(suite.T(), 1, positions["XXBTZUSD"].Quantity, 1e-9)
+	require.InDelta(suite.T(), 100, positions["XXBTZUSD"].AverageEntryPrice, 1e-9)
+	require.InDelta(suite.T(), 20, positions["XXBTZUSD"].RealizedPnL, 1e-9)
+	require.InDelta(suite.T(), -5, positions["XETHZUSD"].Quantity, 1e-9)
+	require.InDelta(suite.T(), 10, positions["XETHZUSD"].AverageEntryPrice, 1e-9)
+	_, ok := tracker.GetPosition("XBT/USD")
+	require.False(suite.T(), ok)
+}
+
+// Test a position closed by trades with decimal volumes.
+//
+// Test will ensure:
+//   - Float residuals are dropped: the position is flat once closed.
+//   - The next trade opens a new position at the trade price.
+func (suite *PnLTrackerUnitTestSuite) TestClosePositionWithDecimalVolumes() {
+	tracker := NewPnLTracker(nil)
+	require.NoError(suite.T(), tracker.ProcessOwnTrades(messages.OwnTrades{
+		Data: []map[string]messages.OwnTradeData{
+			{"T1": {Pair: "XBT/USD", Type: "buy", Price: "100", Volume: "0.1"}},
+			{"T2": {Pair: "XBT/USD", Type: "buy", Price: "100", Volume: "0.2"}},
+			{"T3": {Pair: "XBT/USD", Type: "sell", Price: "110", Volume: "0.3"}},
+		},
+	}))
+	position, ok := tracker.GetPosition("XBT/USD")
+	require.True(suite.T(), ok)
+	require.Zero(suite.T(), position.Quantity)
+	require.Zero(suite.T(), position.AverageEntryPrice)
+	require.InDelta(suite.T(), 3, position.RealizedPnL, 1e-9)
+	require.NoError(suite.T(), tracker.ProcessOwnTrades(messages.OwnTrades{
+		Data: []map[string]messages.OwnTradeData{{"T4": {Pair: "XBT/USD", Type: "sell", Price: "200", Volume: "1"}}},
+	}))
+	position, _ = tracker.GetPosition("XBT/USD")
+	require.InDelta(suite.T(), -1, position.Quantity, 1e-9)
+	require.Equal(suite.T(), 200.0, position.AverageEntryPrice)
+}
+
+// Test invalid trades are rejected.
+func (suite *PnLTrackerUnitTestSuite) TestInvalidTrades() {
+	tracker := NewPnLTracker(nil)
+	require.Error(suite.T(), tracker.ProcessOwnTrades(messages.OwnTrades{
+		Data: []map[string]messages.OwnTradeData{{"T1": {Pair: "XBT/USD", Type: "hold", Price: "1", Volume: "1"}}},
+	}))
+	require.Error(suite.T(), tracker.ProcessOwnTrades(messages.OwnTrades{
+		Data: []map[string]messages.OwnTradeData{{"T1": {Pair: "XBT/USD", Type: "buy", Price: "abc", Volume: "1"}}},
+	}))
+	require.Error(suite.T(), tracker.ProcessOwnTrades(messages.OwnTrades{
+		Data: []map[string]messages.OwnTradeData{{"T1": {Pair: "XBT/USD", Type: "buy", Price: "1", Volume: ""}}},
+	}))
+	require.Empty(suite.T(), tracker.GetPositions())
+}
@@ -0,0 +1,170 @@
+package precision
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// Options of the precision-checked clients.
+type Options struct {
+	// If true, order prices and volumes are rounded to valid increments before the order size is
+	// validated. Otherwise, only the order size is validated.
+	AutoRound bool
+}
+
+/*************************************************************************************************/
+/* WEBSOCKET                                                                                     */
+/*************************************************************************************************/
+
+// Private websocket client which rounds orders and validates their size with a Registry before
+// they are sent to Kraken. All other methods are forwarded to the wrapped client.
+type WebsocketClient struct {
+	// Wrapped client
+	websocket.KrakenSpotPrivateWebsocketClientInterface
+	// Registry which provides the pair rules
+	registry *Registry
+	// Options
+	opts Options
+}
+
+// # Description
+//
+// Wrap a private websocket client so orders sent with AddOrder are rounded (if enabled) and
+// validated with the rules of the provided registry.
+//
+// # Inputs
+//
+//   - client: Private websocket client to wrap.
+//   - registry: Registry which provides the pair rules.
+//   - opts: Options. Can be nil to only validate order sizes.
+//
+// # Return
+//
+// The precision-checked client.
+func NewWebsocketClient(client websocket.KrakenSpotPrivateWebsocketClientInterface, registry *Registry, opts *Options) *WebsocketClient {
+	if opts == nil {
+		opts = &Options{}
+	}
+	return &WebsocketClient{
+		KrakenSpotPrivateWebsocketClientInterface: client,
+		registry: registry,
+		opts:     *opts,
+	}
+}
+
+// # Description
+//
+// Round the order (if enabled), validate its size and send it with the wrapped client.
+// Cf. KrakenSpotPrivateWebsocketClientInterface.AddOrder.
+//
+// # Return
+//
+// A PrecisionError if the pair is unknown, a value is invalid or the order is below the pair
+// minimums. The order is not sent in that case. Otherwise, the response and error returned by the
+// wrapped client.
+func (client *WebsocketClient) AddOrder(ctx context.Context, params websocket.AddOrderRequestParameters) (*messages.AddOrderResponse, error) {
+	volume, price, price2, err := client.registry.prepare(params.Pair, params.Volume, params.Price, params.Price2, client.opts.AutoRound)
+	if err != nil {
+		return nil, err
+	}
+	params.Volume, params.Price, params.Price2 = volume, price, price2
+	return client.KrakenSpotPrivateWebsocketClientInterface.AddOrder(ctx, params)
+}
+
+/*************************************************************************************************/
+/* REST                                                                                          */
+/*************************************************************************************************/
+
+// REST client which rounds orders and validates their size with a Registry before they are sent
+// to Kraken. All other methods are forwarded to the wrapped client.
+type RESTClient struct {
+	// Wrapped client
+	rest.KrakenSpotRESTClientIface
+	// Registry which provides the pair rules
+	registry *Registry
+	// Options
+	opts Options
+}
+
+// # Description
+//
+// Wrap a REST client so orders sent with AddOrder and AddOrderBatch are rounded (if enabled) and
+// validated with the rules of the provided registry.
+//
+// # Inputs
+//
+//   - client: REST client to wrap.
+//   - registry: Registry which provides the pair rules.
+//   - opts: Options. Can be nil to only validate order sizes.
+//
+// # Return
+//
+// The precision-checked client.
+func NewRESTClient(client rest.KrakenSpotRESTClientIface, registry *Registry, opts *Options) *RESTClient {
+	if opts == nil {
+		opts = &Options{}
+	}
+	return &RESTClient{
+		KrakenSpotRESTClientIface: client,
+		registry:                  registry,
+		opts:                      *opts,
+	}
+}
+
+// # Description
+//
+// Round the order (if enabled), validate its size and send it with the wrapped client.
+// Cf. KrakenSpotRESTClientIface.AddOrder.
+//
+// # Return
+//
+// A PrecisionError if the pair is unknown, a value is invalid or the order is below the pair
+// minimums. The order is not sent in that case. Otherwise, the values returned by the wrapped
+// client.
+func (client *RESTClient) AddOrder(ctx context.Context, nonce int64, params trading.AddOrderRequestParameters, opts *trading.AddOrderRequestOptions, secopts *common.SecurityOptions) (*trading.AddOrderResponse, *http.Response, error) {
+	order, err := client.prepare(params.Pair, params.Order)
+	if err != nil {
+		return nil, nil, err
+	}
+	params.Order = order
+	return client.KrakenSpotRESTClientIface.AddOrder(ctx, nonce, params, opts, secopts)
+}
+
+// # Description
+//
+// Round each order of the batch (if enabled), validate their size and send the batch with the
+// wrapped client. The orders of the provided parameters are not modified.
+// Cf. KrakenSpotRESTClientIface.AddOrderBatch.
+//
+// # Return
+//
+// A PrecisionError for the first invalid order. The batch is not sent in that case. Otherwise,
+// the values returned by the wrapped client.
+func (client *RESTClient) AddOrderBatch(ctx context.Context, nonce int64, params trading.AddOrderBatchRequestParameters, opts *trading.AddOrderBatchRequestOptions, secopts *common.SecurityOptions) (*trading.AddOrderBatchResponse, *http.Response, error) {
+	orders := make([]trading.Order, len(params.Orders))
+	for i, order := range params.Orders {
+		prepared, err := client.prepare(params.Pair, order)
+		if err != nil {
+			return nil, nil, err
+		}
+		orders[i] = prepared
+	}
+	params.Orders = orders
+	return client.KrakenSpotRESTClientIface.AddOrderBatch(ctx, nonce, params, opts, secopts)
+}
+
+// Round (if enabled) and validate a REST order.
+func (client *RESTClient) prepare(pair string, order trading.Order) (trading.Order, error) {
+	volume, price, price2, err := client.registry.prepare(pair, order.Volume, order.Price, order.Price2, client.opts.AutoRound)
+	if err != nil {
+		return order, err
+	}
+	order.Volume, order.Price, order.Price2 = volume, price, price2
+	return order, nil
+}
@@ -0,0 +1,134 @@
+package precision
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for precision-checked clients
+type ClientsUnitTestSuite struct {
+	suite.Suite
+	// Registry used by the clients
+	registry *Registry
+}
+
+// Run unit test suite
+func TestClientsUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(ClientsUnitTestSuite))
+}
+
+// Build the registry before each test.
+func (suite *ClientsUnitTestSuite) BeforeTest(suiteName, testName string) {
+	suite.registry = NewRegistry()
+	rules := PairRules{PairDecimals: 1, LotDecimals: 4, OrderMin: "0.001", CostMin: "0.5"}
+	rules.Pair = "XBT/USD"
+	suite.registry.SetPair("XBT/USD", rules)
+	rules.Pair = "XXBTZUSD"
+	suite.registry.SetPair("XXBTZUSD", rules)
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the precision-checked websocket client.
+//
+// Test will ensure:
+//   - Orders are sent unchanged when auto-rounding is disabled.
+//   - Prices and volumes are rounded when auto-rounding is enabled.
+//   - Orders below the minimums or for unknown pairs are rejected and not sent.
+func (suite *ClientsUnitTestSuite) TestWebsocketClient() {
+	recorder := &recordingWebsocketClient{}
+	client := NewWebsocketClient(recorder, suite.registry, nil)
+	require.Implements(suite.T(), (*websocket.KrakenSpotPrivateWebsocketClientInterface)(nil), client)
+	params := websocket.AddOrderRequestParameters{OrderType: "stop-loss-limit", Type: "buy", Pair: "XBT/USD", Price: "100.04", Price2: "+1.555", Volume: "0.123456"}
+	_, err := client.AddOrder(context.Background(), params)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), params, recorder.orders[0])
+	client = NewWebsocketClient(recorder, suite.registry, &Options{AutoRound: true})
+	_, err = client.AddOrder(context.Background(), params)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "100.0", recorder.orders[1].Price)
+	require.Equal(suite.T(), "+1.555", recorder.orders[1].Price2)
+	require.Equal(suite.T(), "0.1234", recorder.orders[1].Volume)
+	params.Volume = "0.0019"
+	perr := new(PrecisionError)
+	_, err = client.AddOrder(context.Background(), params)
+	require.ErrorAs(suite.T(), err, &perr)
+	require.Equal(suite.T(), CheckCostMin, perr.Check)
+	params.Pair = "ETH/USD"
+	_, err = client.AddOrder(context.Background(), params)
+	require.ErrorAs(suite.T(), err, &perr)
+	require.Equal(suite.T(), CheckUnknownPair, perr.Check)
+	require.Len(suite.T(), recorder.orders, 2)
+}
+
+// Test the precision-checked REST client.
+//
+// Test will ensure:
+//   - Orders and batches are rounded when auto-rounding is enabled.
+//   - The orders of the provided batch parameters are not modified.
+//   - Batches with an order below the minimums are rejected and not sent.
+func (suite *ClientsUnitTestSuite) TestRESTClient() {
+	mockClient := rest.NewMockKrakenSpotRESTClient()
+	mockClient.On("AddOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&trading.AddOrderResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}}}, nil, nil)
+	mockClient.On("AddOrderBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&trading.AddOrderBatchResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}}}, nil, nil)
+	client := NewRESTClient(mockClient, suite.registry, &Options{AutoRound: true})
+	require.Implements(suite.T(), (*rest.KrakenSpotRESTClientIface)(nil), client)
+	_, _, err := client.AddOrder(context.Background(), 0, trading.AddOrderRequestParameters{
+		Pair:  "XXBTZUSD",
+		Order: trading.Order{OrderType: "limit", Type: "sell", Price: "250.55", Volume: "1.00005"},
+	}, nil, nil)
+	require.NoError(suite.T(), err)
+	sent := mockClient.Calls[0].Arguments.Get(2).(trading.AddOrderRequestParameters)
+	require.Equal(suite.T(), "250.6", sent.Order.Price)
+	require.Equal(suite.T(), "1.0000", sent.Order.Volume)
+	batch := trading.AddOrderBatchRequestParameters{
+		Pair:   "XXBTZUSD",
+		Orders: []trading.Order{{OrderType: "limit", Type: "buy", Price: "99.99", Volume: "0.01"}, {OrderType: "market", Type: "buy", Volume: "0.0015"}},
+	}
+	_, _, err = client.AddOrderBatch(context.Background(), 0, batch, nil, nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "99.99", batch.Orders[0].Price)
+	sentBatch := mockClient.Calls[1].Arguments.Get(2).(trading.AddOrderBatchRequestParameters)
+	require.Equal(suite.T(), "100.0", sentBatch.Orders[0].Price)
+	require.Equal(suite.T(), "0.0015", sentBatch.Orders[1].Volume)
+	batch.Orders[1].Volume = "0.0009"
+	perr := new(PrecisionError)
+	_, _, err = client.AddOrderBatch(context.Background(), 0, batch, nil, nil)
+	require.ErrorAs(suite.T(), err, &perr)
+	require.Equal(suite.T(), CheckOrderMin, perr.Check)
+	mockClient.AssertNumberOfCalls(suite.T(), "AddOrderBatch", 1)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Private websocket client which records the orders sent with AddOrder.
+type recordingWebsocketClient struct {
+	websocket.KrakenSpotPrivateWebsocketClientInterface
+	// Orders sent with AddOrder
+	orders []websocket.AddOrderRequestParameters
+}
+
+// Record the order.
+func (client *recordingWebsocketClient) AddOrder(ctx context.Context, params websocket.AddOrderRequestParameters) (*messages.AddOrderResponse, error) {
+	client.orders = append(client.orders, params)
+	return &messages.AddOrderResponse{}, nil
+}
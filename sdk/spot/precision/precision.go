@@ -0,0 +1,374 @@
+// Package precision provides helpers which round order prices and volumes to the increments
+// allowed for a pair (tick size, pair_decimals, lot_decimals) and validate order sizes against
+// the pair minimums (ordermin, costmin), as well as websocket and REST client wrappers which apply
+// them to orders before they are sent to Kraken.
+package precision
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+)
+
+// Enum for the checks performed by the precision helpers.
+type PrecisionCheckEnum string
+
+// Values for PrecisionCheckEnum
+const (
+	// The order volume is below the pair minimum order volume (ordermin).
+	CheckOrderMin PrecisionCheckEnum = "ordermin"
+	// The order cost (volume * price) is below the pair minimum order cost (costmin).
+	CheckCostMin PrecisionCheckEnum = "costmin"
+	// No rules are known for the pair.
+	CheckUnknownPair PrecisionCheckEnum = "unknown_pair"
+	// The price or the volume is not a valid positive decimal number.
+	CheckInvalidValue PrecisionCheckEnum = "invalid_value"
+)
+
+// Error returned when an order price or volume cannot be used for a pair.
+type PrecisionError struct {
+	// Failed check
+	Check PrecisionCheckEnum
+	// Pair of the rejected order
+	Pair string
+	// Rejected value (volume, cost or price depending on the check)
+	Value string
+	// Limit which has been violated. Empty if the check has no limit.
+	Limit string
+	// Human readable reason
+	Reason string
+}
+
+// Format the error message
+func (e *PrecisionError) Error() string {
+	return fmt.Sprintf("order for %s rejected by precision check %s: %s", e.Pair, e.Check, e.Reason)
+}
+
+// Precision rules of a pair, usually built from the pair metadata (Cf. RulesFromAssetPairInfo).
+type PairRules struct {
+	// Name of the pair. Used in errors.
+	Pair string
+	// Number of decimals for prices. Used when TickSize is empty.
+	PairDecimals int
+	// Number of decimals for volumes.
+	LotDecimals int
+	// Minimum price increment. Empty if the pair has no tick size.
+	TickSize string
+	// Minimum order volume, in base currency. Empty disables the check.
+	OrderMin string
+	// Minimum order cost (volume * price), in quote currency. Empty disables the check.
+	CostMin string
+}
+
+// Build the precision rules of a pair from its metadata.
+func RulesFromAssetPairInfo(pair string, info market.AssetPairInfo) PairRules {
+	return PairRules{
+		Pair:         pair,
+		PairDecimals: info.PairDecimals,
+		LotDecimals:  info.LotDecimals,
+		TickSize:     info.TickSize,
+		OrderMin:     info.OrderMin,
+		CostMin:      info.CostMin,
+	}
+}
+
+// # Description
+//
+// Round the price to the nearest multiple of the pair tick size or, if the pair has no tick size,
+// to the number of decimals of the pair. Ties are rounded away from zero.
+//
+// Empty prices and relative prices (prefixed with +, - or #, or suffixed with %) are returned
+// unchanged as they are resolved by Kraken.
+//
+// # Inputs
+//
+//   - price: Price to round.
+//
+// # Return
+//
+// The rounded price or a PrecisionError if the price is not a valid positive decimal number.
+func (r PairRules) RoundPrice(price string) (string, error) {
+	if price == "" || isRelativePrice(price) {
+		return price, nil
+	}
+	value, err := r.parse(price, "price")
+	if err != nil {
+		return "", err
+	}
+	increment, decimals := r.priceIncrement()
+	return roundToIncrement(value, increment, false).FloatString(decimals), nil
+}
+
+// # Description
+//
+// Round the volume down to the number of lot decimals of the pair. The volume is rounded down so
+// the order never exceeds the requested quantity.
+//
+// # Inputs
+//
+//   - volume: Volume to round.
+//
+// # Return
+//
+// The rounded volume or a PrecisionError if the volume is not a valid positive decimal number.
+func (r PairRules) RoundVolume(volume string) (string, error) {
+	value, err := r.parse(volume, "volume")
+	if err != nil {
+		return "", err
+	}
+	increment := pow10(-r.LotDecimals)
+	return roundToIncrement(value, increment, true).FloatString(max(r.LotDecimals, 0)), nil
+}
+
+// # Description
+//
+// Validate the order size against the pair minimums: the volume must not be lower than ordermin
+// and the cost (volume * price) must not be lower than costmin. The cost is only checked when an
+// absolute price is provided. A zero volume (used to close margin positions) is not checked.
+//
+// # Inputs
+//
+//   - volume: Order volume, in base currency.
+//   - price: Order price. Can be empty or relative, in which case the cost is not checked.
+//
+// # Return
+//
+// Nil if the order size is valid. Otherwise, a PrecisionError which details the violated minimum.
+func (r PairRules) ValidateOrderSize(volume string, price string) error {
+	vol, err := r.parse(volume, "volume")
+	if err != nil {
+		return err
+	}
+	if vol.Sign() == 0 {
+		return nil
+	}
+	if r.OrderMin != "" {
+		min, err := r.parse(r.OrderMin, "ordermin")
+		if err != nil {
+			return err
+		}
+		if vol.Cmp(min) < 0 {
+			return &PrecisionError{
+				Check:  CheckOrderMin,
+				Pair:   r.Pair,
+				Value:  volume,
+				Limit:  r.OrderMin,
+				Reason: fmt.Sprintf("volume %s is below the minimum order volume %s", volume, r.OrderMin),
+			}
+		}
+	}
+	if r.CostMin != "" && price != "" && !isRelativePrice(price) {
+		min, err := r.parse(r.CostMin, "costmin")
+		if err != nil {
+			return err
+		}
+		p, err := r.parse(price, "price")
+		if err != nil {
+			return err
+		}
+		cost := new(big.Rat).Mul(vol, p)
+		if cost.Cmp(min) < 0 {
+			value := strings.TrimRight(strings.TrimRight(cost.FloatString(max(r.PairDecimals, 0)+max(r.LotDecimals, 0)), "0"), ".")
+			return &PrecisionError{
+				Check:  CheckCostMin,
+				Pair:   r.Pair,
+				Value:  value,
+				Limit:  r.CostMin,
+				Reason: fmt.Sprintf("cost %s (volume %s * price %s) is below the minimum order cost %s", value, volume, price, r.CostMin),
+			}
+		}
+	}
+	return nil
+}
+
+// Get the price increment and the number of decimals used to format prices.
+func (r PairRules) priceIncrement() (*big.Rat, int) {
+	decimals := max(r.PairDecimals, 0)
+	if r.TickSize != "" {
+		if tick, ok := new(big.Rat).SetString(r.TickSize); ok && tick.Sign() > 0 {
+			if idx := strings.Index(r.TickSize, "."); idx >= 0 {
+				decimals = max(decimals, len(strings.TrimRight(r.TickSize[idx+1:], "0")))
+			}
+			return tick, decimals
+		}
+	}
+	return pow10(-r.PairDecimals), decimals
+}
+
+// Parse a positive decimal number. Name is used in the error message.
+func (r PairRules) parse(value string, name string) (*big.Rat, error) {
+	parsed, ok := new(big.Rat).SetString(value)
+	if !ok || strings.Contains(value, "/") || parsed.Sign() < 0 {
+		return nil, &PrecisionError{
+			Check:  CheckInvalidValue,
+			Pair:   r.Pair,
+			Value:  value,
+			Reason: fmt.Sprintf("%s %q is not a valid positive decimal number", name, value),
+		}
+	}
+	return parsed, nil
+}
+
+// Round a positive value to a multiple of increment. Value is rounded down if down is true or to
+// the nearest multiple otherwise, ties being rounded up.
+func roundToIncrement(value *big.Rat, increment *big.Rat, down bool) *big.Rat {
+	quotient := new(big.Rat).Quo(value, increment)
+	steps := new(big.Int).Quo(quotient.Num(), quotient.Denom())
+	if !down {
+		remainder := new(big.Rat).Sub(quotient, new(big.Rat).SetInt(steps))
+		if remainder.Cmp(big.NewRat(1, 2)) >= 0 {
+			steps.Add(steps, big.NewInt(1))
+		}
+	}
+	return new(big.Rat).Mul(new(big.Rat).SetInt(steps), increment)
+}
+
+// Get 10^exp as a rational number.
+func pow10(exp int) *big.Rat {
+	p := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(exp))), nil)
+	if exp < 0 {
+		return new(big.Rat).SetFrac(big.NewInt(1), p)
+	}
+	return new(big.Rat).SetInt(p)
+}
+
+// Get the absolute value of an integer.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Check whether the price is relative to the last traded price.
+func isRelativePrice(price string) bool {
+	return strings.HasPrefix(price, "+") || strings.HasPrefix(price, "-") || strings.HasPrefix(price, "#") || strings.HasSuffix(price, "%")
+}
+
+// # Description
+//
+// Registry of the precision rules of the pairs. Pairs must be named like in the orders: the
+// websocket API (ex: XBT/USD) and the REST API (ex: XXBTZUSD or XBTUSD) do not use the same pair
+// names. LoadAssetPairs registers the rules of each pair under all its names.
+//
+// The registry is safe for concurrent use.
+type Registry struct {
+	// Mutex used to protect rules
+	mu sync.RWMutex
+	// Rules by pair name
+	rules map[string]PairRules
+}
+
+// Build a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{rules: map[string]PairRules{}}
+}
+
+// Set the rules of a pair.
+func (reg *Registry) SetPair(pair string, rules PairRules) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.rules[pair] = rules
+}
+
+// # Description
+//
+// Register the rules of the provided pairs, as returned by GetTradableAssetPairs or by
+// metadata.Refresher.Pairs. The rules of each pair are registered under its REST name, its
+// alternative name and its websocket name.
+//
+// # Inputs
+//
+//   - pairs: Pair metadata by REST pair name.
+func (reg *Registry) LoadAssetPairs(pairs map[string]market.AssetPairInfo) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for name, info := range pairs {
+		for _, alias := range []string{name, info.AlternativeName, info.WebsocketName} {
+			if alias != "" {
+				reg.rules[alias] = RulesFromAssetPairInfo(alias, info)
+			}
+		}
+	}
+}
+
+// # Description
+//
+// Get the rules of a pair.
+//
+// # Return
+//
+// The rules of the pair or a PrecisionError if no rules are known for the pair.
+func (reg *Registry) Pair(pair string) (PairRules, error) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	rules, ok := reg.rules[pair]
+	if !ok {
+		return PairRules{}, &PrecisionError{
+			Check:  CheckUnknownPair,
+			Pair:   pair,
+			Reason: "no precision rules are known for the pair",
+		}
+	}
+	return rules, nil
+}
+
+// Round the price with the rules of the pair. Cf. PairRules.RoundPrice.
+func (reg *Registry) RoundPrice(pair string, price string) (string, error) {
+	rules, err := reg.Pair(pair)
+	if err != nil {
+		return "", err
+	}
+	return rules.RoundPrice(price)
+}
+
+// Round the volume with the rules of the pair. Cf. PairRules.RoundVolume.
+func (reg *Registry) RoundVolume(pair string, volume string) (string, error) {
+	rules, err := reg.Pair(pair)
+	if err != nil {
+		return "", err
+	}
+	return rules.RoundVolume(volume)
+}
+
+// Validate the order size with the rules of the pair. Cf. PairRules.ValidateOrderSize.
+func (reg *Registry) ValidateOrderSize(pair string, volume string, price string) error {
+	rules, err := reg.Pair(pair)
+	if err != nil {
+		return err
+	}
+	return rules.ValidateOrderSize(volume, price)
+}
+
+// # Description
+//
+// Prepare an order for the pair: the prices and the volume are rounded if autoRound is true, then
+// the order size is validated.
+//
+// # Return
+//
+// The (rounded) volume, price and price2 or a PrecisionError.
+func (reg *Registry) prepare(pair string, volume string, price string, price2 string, autoRound bool) (string, string, string, error) {
+	rules, err := reg.Pair(pair)
+	if err != nil {
+		return "", "", "", err
+	}
+	if autoRound {
+		if volume, err = rules.RoundVolume(volume); err != nil {
+			return "", "", "", err
+		}
+		if price, err = rules.RoundPrice(price); err != nil {
+			return "", "", "", err
+		}
+		if price2, err = rules.RoundPrice(price2); err != nil {
+			return "", "", "", err
+		}
+	}
+	if err := rules.ValidateOrderSize(volume, price); err != nil {
+		return "", "", "", err
+	}
+	return volume, price, price2, nil
+}
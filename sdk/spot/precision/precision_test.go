@@ -0,0 +1,132 @@
+package precision
+
+import (
+	"testing"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for precision helpers
+type PrecisionUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestPrecisionUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(PrecisionUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test RoundPrice.
+//
+// Test will ensure:
+//   - Prices are rounded to the nearest tick or to the pair decimals when there is no tick size.
+//   - Empty and relative prices are returned unchanged.
+//   - Invalid prices are rejected with a PrecisionError.
+func (suite *PrecisionUnitTestSuite) TestRoundPrice() {
+	rules := PairRules{Pair: "XBT/USD", PairDecimals: 1}
+	price, err := rules.RoundPrice("27123.45")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "27123.5", price)
+	price, err = rules.RoundPrice("27123.44")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "27123.4", price)
+	rules.TickSize = "0.25"
+	price, err = rules.RoundPrice("100.13")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "100.25", price)
+	price, err = rules.RoundPrice("100.12")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "100.00", price)
+	for _, unchanged := range []string{"", "+5", "-1.123", "#2", "2.5%"} {
+		price, err = rules.RoundPrice(unchanged)
+		require.NoError(suite.T(), err)
+		require.Equal(suite.T(), unchanged, price)
+	}
+	for _, invalid := range []string{"abc", "1/2"} {
+		_, err = rules.RoundPrice(invalid)
+		perr := new(PrecisionError)
+		require.ErrorAs(suite.T(), err, &perr)
+		require.Equal(suite.T(), CheckInvalidValue, perr.Check)
+	}
+}
+
+// Test RoundVolume.
+//
+// Test will ensure:
+//   - Volumes are rounded down to the lot decimals.
+//   - Invalid volumes are rejected with a PrecisionError.
+func (suite *PrecisionUnitTestSuite) TestRoundVolume() {
+	rules := PairRules{Pair: "XBT/USD", LotDecimals: 8}
+	volume, err := rules.RoundVolume("0.123456789")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "0.12345678", volume)
+	rules.LotDecimals = 0
+	volume, err = rules.RoundVolume("12.9")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "12", volume)
+	_, err = rules.RoundVolume("-1")
+	require.ErrorAs(suite.T(), err, new(*PrecisionError))
+}
+
+// Test ValidateOrderSize.
+//
+// Test will ensure:
+//   - Orders below ordermin or costmin are rejected with a detailed PrecisionError.
+//   - The cost is not checked without an absolute price.
+//   - A zero volume is accepted.
+func (suite *PrecisionUnitTestSuite) TestValidateOrderSize() {
+	rules := PairRules{Pair: "XBT/USD", PairDecimals: 1, LotDecimals: 8, OrderMin: "0.0001", CostMin: "0.5"}
+	require.NoError(suite.T(), rules.ValidateOrderSize("0.001", "1000"))
+	require.NoError(suite.T(), rules.ValidateOrderSize("0.0001", ""))
+	require.NoError(suite.T(), rules.ValidateOrderSize("0.0001", "+10"))
+	require.NoError(suite.T(), rules.ValidateOrderSize("0", ""))
+	perr := new(PrecisionError)
+	require.ErrorAs(suite.T(), rules.ValidateOrderSize("0.00001", "100000"), &perr)
+	require.Equal(suite.T(), PrecisionError{Check: CheckOrderMin, Pair: "XBT/USD", Value: "0.00001", Limit: "0.0001", Reason: "volume 0.00001 is below the minimum order volume 0.0001"}, *perr)
+	require.ErrorAs(suite.T(), rules.ValidateOrderSize("0.0002", "1000"), &perr)
+	require.Equal(suite.T(), CheckCostMin, perr.Check)
+	require.Equal(suite.T(), "0.2", perr.Value)
+	require.Equal(suite.T(), "0.5", perr.Limit)
+	require.ErrorAs(suite.T(), rules.ValidateOrderSize("abc", ""), &perr)
+	require.Equal(suite.T(), CheckInvalidValue, perr.Check)
+}
+
+// Test Registry.
+//
+// Test will ensure:
+//   - Pair rules are registered under the REST, alternative and websocket names.
+//   - Unknown pairs are rejected with a PrecisionError.
+func (suite *PrecisionUnitTestSuite) TestRegistry() {
+	registry := NewRegistry()
+	registry.LoadAssetPairs(map[string]market.AssetPairInfo{
+		"XXBTZUSD": {AlternativeName: "XBTUSD", WebsocketName: "XBT/USD", PairDecimals: 1, LotDecimals: 8, OrderMin: "0.0001", CostMin: "0.5", TickSize: "0.1"},
+	})
+	for _, name := range []string{"XXBTZUSD", "XBTUSD", "XBT/USD"} {
+		rules, err := registry.Pair(name)
+		require.NoError(suite.T(), err)
+		require.Equal(suite.T(), name, rules.Pair)
+		require.Equal(suite.T(), "0.1", rules.TickSize)
+	}
+	price, err := registry.RoundPrice("XBT/USD", "100.04")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "100.0", price)
+	volume, err := registry.RoundVolume("XBTUSD", "1.000000009")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "1.00000000", volume)
+	require.ErrorAs(suite.T(), registry.ValidateOrderSize("XXBTZUSD", "0.00001", ""), new(*PrecisionError))
+	registry.SetPair("ETH/USD", PairRules{Pair: "ETH/USD", LotDecimals: 2})
+	require.NoError(suite.T(), registry.ValidateOrderSize("ETH/USD", "0.01", "1"))
+	perr := new(PrecisionError)
+	require.ErrorAs(suite.T(), registry.ValidateOrderSize("DOGE/USD", "1", ""), &perr)
+	require.Equal(suite.T(), CheckUnknownPair, perr.Check)
+}
@@ -0,0 +1,318 @@
+// Package reconcile provides a component which cross-checks the fills reported by the exchange
+// (websocket ownTrades, REST trades history) and the account ledger against locally recorded
+// orders and reports mismatches as typed findings, for example to feed audit pipelines.
+package reconcile
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// Default tolerance used to compare volumes and fees.
+const DefaultTolerance = 1e-8
+
+// Enum for the types of findings reported by the reconciler.
+type FindingTypeEnum string
+
+// Values for FindingTypeEnum
+const (
+	// The exchange reports more executed volume for an order than recorded locally: some fills
+	// are missing in the local records.
+	FindingMissingFill FindingTypeEnum = "missing_fill"
+	// The local records contain more executed volume for an order than reported by the exchange.
+	FindingUnconfirmedFill FindingTypeEnum = "unconfirmed_fill"
+	// Fees recorded locally for an order do not match the fees of its trades, or fees of a trade
+	// do not match the fees of its ledger entries.
+	FindingFeeDiscrepancy FindingTypeEnum = "fee_discrepancy"
+	// The exchange reports trades for an order which is not recorded locally.
+	FindingUnknownOrder FindingTypeEnum = "unknown_order"
+	// A trade ledger entry does not reference any known trade.
+	FindingUnknownLedgerEntry FindingTypeEnum = "unknown_ledger_entry"
+)
+
+// An order recorded locally, as seen by the local system.
+type LocalOrder struct {
+	// Order transaction ID assigned by the exchange
+	OrderId string `json:"order_id"`
+	// Pair
+	Pair string `json:"pair"`
+	// Executed volume in base currency
+	ExecutedVolume float64 `json:"executed_volume"`
+	// Fees paid, in quote currency
+	Fee float64 `json:"fee"`
+}
+
+// A mismatch found by the reconciler.
+type Finding struct {
+	// Type of finding
+	Type FindingTypeEnum `json:"type"`
+	// Order transaction ID. Empty for findings about ledger entries without known trade.
+	OrderId string `json:"order_id,omitempty"`
+	// Trade ID, if the finding is about a single trade.
+	TradeId string `json:"trade_id,omitempty"`
+	// Ledger entry ID, if the finding is about a single ledger entry.
+	LedgerId string `json:"ledger_id,omitempty"`
+	// Expected value: local value for orders, trade value for ledger entries.
+	Expected float64 `json:"expected"`
+	// Actual value: value reported by the exchange or by the ledger.
+	Actual float64 `json:"actual"`
+	// Human readable description
+	Description string `json:"description"`
+}
+
+// A fill reported by the exchange.
+type fill struct {
+	orderId string
+	volume  float64
+	fee     float64
+}
+
+// A trade ledger entry.
+type ledgerEntry struct {
+	tradeId string
+	fee     float64
+}
+
+// # Description
+//
+// Reconciler which cross-checks fills reported by the exchange and ledger entries against
+// locally recorded orders.
+//
+// Fills and ledger entries are identified by their ID: data which has already been processed
+// (ex: trades replayed by an ownTrades snapshot) is ignored. Local orders are identified by their
+// order transaction ID: recording an order again replaces the previous record.
+//
+// Reconciler is safe for concurrent use.
+type Reconciler struct {
+	// Mutex used to protect reconciler state
+	mu sync.Mutex
+	// Tolerance used to compare volumes and fees
+	tolerance float64
+	// Local orders by order ID
+	orders map[string]LocalOrder
+	// Fills by trade ID
+	fills map[string]fill
+	// Trade ledger entries by ledger ID
+	ledger map[string]ledgerEntry
+}
+
+// # Description
+//
+// Build a new Reconciler.
+//
+// # Inputs
+//
+//   - tolerance: Tolerance used to compare volumes and fees. A zero or negative value defaults
+//     to DefaultTolerance.
+//
+// # Return
+//
+// A new Reconciler
+func NewReconciler(tolerance float64) *Reconciler {
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	return &Reconciler{
+		tolerance: tolerance,
+		orders:    map[string]LocalOrder{},
+		fills:     map[string]fill{},
+		ledger:    map[string]ledgerEntry{},
+	}
+}
+
+// Record a local order. Recording an order with the same order ID replaces the previous record.
+func (r *Reconciler) RecordOrder(order LocalOrder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.orders[order.OrderId] = order
+}
+
+// Process an ownTrades message from the websocket API.
+func (r *Reconciler) ProcessOwnTrades(msg messages.OwnTrades) error {
+	fills := map[string]fill{}
+	for _, data := range msg.Data {
+		for id, ot := range data {
+			f, err := parseFill(id, ot.OrderTransactionId, ot.Volume, ot.Fee)
+			if err != nil {
+				return err
+			}
+			fills[id] = f
+		}
+	}
+	r.addFills(fills)
+	return nil
+}
+
+// Process trades returned by the REST API (GetTradesHistory or QueryTradesInfo results).
+func (r *Reconciler) ProcessTrades(trades map[string]*account.TradeInfo) error {
+	fills := map[string]fill{}
+	for id, ti := range trades {
+		f, err := parseFill(id, ti.OrderTransactionId, ti.Volume.String(), ti.Fee.String())
+		if err != nil {
+			return err
+		}
+		fills[id] = f
+	}
+	r.addFills(fills)
+	return nil
+}
+
+// Process ledger entries returned by the REST API (GetLedgersInfo or QueryLedgers results).
+// Only trade ledger entries are reconciled, other entries are ignored.
+func (r *Reconciler) ProcessLedgerEntries(entries map[string]*account.LedgerEntry) error {
+	parsed := map[string]ledgerEntry{}
+	for id, entry := range entries {
+		if entry.GetType() != account.EntryTypeTrade {
+			continue
+		}
+		fee, err := parseOptionalFloat(entry.Fee.String())
+		if err != nil {
+			return fmt.Errorf("failed to parse fee of ledger entry %s: %w", id, err)
+		}
+		parsed[id] = ledgerEntry{tradeId: entry.ReferenceId, fee: fee}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, entry := range parsed {
+		r.ledger[id] = entry
+	}
+	return nil
+}
+
+// # Description
+//
+// Cross-check all processed data and report mismatches:
+//
+//   - Executed volumes and fees of local orders are compared with the trades of the orders.
+//   - Trades of orders which are not recorded locally are reported as unknown orders.
+//   - Trade ledger entries are matched with trades by reference ID: entries without known trade
+//     are reported and fees of the entries are compared with the fee of their trade.
+//
+// Local orders without any trade and with no executed volume are not reported.
+//
+// # Return
+//
+// Findings sorted by type, order ID, trade ID and ledger ID. Empty if no mismatch has been found.
+func (r *Reconciler) Reconcile() []Finding {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	findings := []Finding{}
+	// Aggregate fills by order
+	executed := map[string]*LocalOrder{}
+	for _, f := range r.fills {
+		agg, ok := executed[f.orderId]
+		if !ok {
+			agg = &LocalOrder{OrderId: f.orderId}
+			executed[f.orderId] = agg
+		}
+		agg.ExecutedVolume += f.volume
+		agg.Fee += f.fee
+	}
+	// Compare local orders with fills
+	for id, order := range r.orders {
+		agg, ok := executed[id]
+		if !ok {
+			agg = &LocalOrder{OrderId: id}
+		}
+		switch {
+		case agg.ExecutedVolume-order.ExecutedVolume > r.tolerance:
+			findings = append(findings, Finding{
+				Type: FindingMissingFill, OrderId: id, Expected: order.ExecutedVolume, Actual: agg.ExecutedVolume,
+				Description: fmt.Sprintf("exchange reports %g executed for order %s but %g is recorded locally", agg.ExecutedVolume, id, order.ExecutedVolume),
+			})
+		case order.ExecutedVolume-agg.ExecutedVolume > r.tolerance:
+			findings = append(findings, Finding{
+				Type: FindingUnconfirmedFill, OrderId: id, Expected: order.ExecutedVolume, Actual: agg.ExecutedVolume,
+				Description: fmt.Sprintf("%g is recorded locally as executed for order %s but exchange reports %g", order.ExecutedVolume, id, agg.ExecutedVolume),
+			})
+		}
+		if math.Abs(agg.Fee-order.Fee) > r.tolerance {
+			findings = append(findings, Finding{
+				Type: FindingFeeDiscrepancy, OrderId: id, Expected: order.Fee, Actual: agg.Fee,
+				Description: fmt.Sprintf("fees of order %s are %g locally but %g on exchange", id, order.Fee, agg.Fee),
+			})
+		}
+	}
+	// Report trades of unknown orders
+	for id, f := range r.fills {
+		if _, ok := r.orders[f.orderId]; !ok {
+			findings = append(findings, Finding{
+				Type: FindingUnknownOrder, OrderId: f.orderId, TradeId: id, Actual: f.volume,
+				Description: fmt.Sprintf("trade %s is for order %s which is not recorded locally", id, f.orderId),
+			})
+		}
+	}
+	// Match ledger entries with trades
+	ledgerFees := map[string]float64{}
+	for id, entry := range r.ledger {
+		if _, ok := r.fills[entry.tradeId]; !ok {
+			findings = append(findings, Finding{
+				Type: FindingUnknownLedgerEntry, TradeId: entry.tradeId, LedgerId: id, Actual: entry.fee,
+				Description: fmt.Sprintf("ledger entry %s references unknown trade %s", id, entry.tradeId),
+			})
+			continue
+		}
+		ledgerFees[entry.tradeId] += entry.fee
+	}
+	for tradeId, fee := range ledgerFees {
+		f := r.fills[tradeId]
+		if math.Abs(f.fee-fee) > r.tolerance {
+			findings = append(findings, Finding{
+				Type: FindingFeeDiscrepancy, OrderId: f.orderId, TradeId: tradeId, Expected: f.fee, Actual: fee,
+				Description: fmt.Sprintf("fee of trade %s is %g but its ledger entries have %g fees", tradeId, f.fee, fee),
+			})
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.OrderId != b.OrderId {
+			return a.OrderId < b.OrderId
+		}
+		if a.TradeId != b.TradeId {
+			return a.TradeId < b.TradeId
+		}
+		return a.LedgerId < b.LedgerId
+	})
+	return findings
+}
+
+// Record the fills which have not been processed yet.
+func (r *Reconciler) addFills(fills map[string]fill) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, f := range fills {
+		if _, seen := r.fills[id]; !seen {
+			r.fills[id] = f
+		}
+	}
+}
+
+// Parse the fill data.
+func parseFill(id string, orderId string, volume string, fee string) (fill, error) {
+	f := fill{orderId: orderId}
+	var err error
+	if f.volume, err = strconv.ParseFloat(volume, 64); err != nil {
+		return f, fmt.Errorf("failed to parse volume of trade %s: %w", id, err)
+	}
+	if f.fee, err = parseOptionalFloat(fee); err != nil {
+		return f, fmt.Errorf("failed to parse fee of trade %s: %w", id, err)
+	}
+	return f, nil
+}
+
+// Parse a float which can be empty. Empty values are mapped to zero.
+func parseOptionalFloat(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
@@ -0,0 +1,126 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for Reconciler
+type ReconcilerUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestReconcilerUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(ReconcilerUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test reconciliation when local orders, trades and ledger entries match.
+//
+// Test will ensure:
+//   - No finding is reported.
+//   - Trades replayed by an ownTrades snapshot and by the REST API are counted once.
+//   - Non trade ledger entries are ignored.
+func (suite *ReconcilerUnitTestSuite) TestNoFinding() {
+	r := NewReconciler(0)
+	r.RecordOrder(LocalOrder{OrderId: "O1", Pair: "XBT/USD", ExecutedVolume: 0.3, Fee: 1.5})
+	r.RecordOrder(LocalOrder{OrderId: "O2", Pair: "XBT/USD"})
+	require.NoError(suite.T(), r.ProcessOwnTrades(ownTrades(map[string]messages.OwnTradeData{
+		"T1": {OrderTransactionId: "O1", Volume: "0.1", Fee: "0.5"},
+		"T2": {OrderTransactionId: "O1", Volume: "0.2", Fee: "1.0"},
+	})))
+	require.NoError(suite.T(), r.ProcessTrades(map[string]*account.TradeInfo{
+		"T2": {OrderTransactionId: "O1", Volume: "0.2", Fee: "1.0"},
+	}))
+	require.NoError(suite.T(), r.ProcessLedgerEntries(map[string]*account.LedgerEntry{
+		"L1": {ReferenceId: "T1", Type: string(account.EntryTypeTrade), Fee: "0.5"},
+		"L2": {ReferenceId: "T1", Type: string(account.EntryTypeTrade), Fee: "0"},
+		"L3": {ReferenceId: "T2", Type: string(account.EntryTypeTrade), Fee: "1.0"},
+		"L4": {ReferenceId: "D1", Type: string(account.EntryTypeDeposit), Fee: "0"},
+	}))
+	require.Empty(suite.T(), r.Reconcile())
+}
+
+// Test reconciliation reports all types of findings.
+//
+// Test will ensure:
+//   - Missing fills, unconfirmed fills, fee discrepancies, unknown orders and unknown ledger
+//     entries are reported with the expected and actual values.
+//   - Findings are sorted.
+func (suite *ReconcilerUnitTestSuite) TestFindings() {
+	r := NewReconciler(DefaultTolerance)
+	r.RecordOrder(LocalOrder{OrderId: "O1", ExecutedVolume: 0.1, Fee: 0.5})
+	r.RecordOrder(LocalOrder{OrderId: "O2", ExecutedVolume: 1, Fee: 2})
+	require.NoError(suite.T(), r.ProcessOwnTrades(ownTrades(map[string]messages.OwnTradeData{
+		"T1": {OrderTransactionId: "O1", Volume: "0.1", Fee: "0.5"},
+		"T2": {OrderTransactionId: "O1", Volume: "0.2", Fee: "1"},
+		"T3": {OrderTransactionId: "O2", Volume: "0.5", Fee: "2"},
+		"T4": {OrderTransactionId: "O3", Volume: "0.7", Fee: "0"},
+	})))
+	require.NoError(suite.T(), r.ProcessLedgerEntries(map[string]*account.LedgerEntry{
+		"L1": {ReferenceId: "T1", Type: string(account.EntryTypeTrade), Fee: "0.6"},
+		"L2": {ReferenceId: "T9", Type: string(account.EntryTypeTrade), Fee: "0.1"},
+	}))
+	findings := r.Reconcile()
+	require.Len(suite.T(), findings, 6)
+	expected := []Finding{
+		{Type: FindingFeeDiscrepancy, OrderId: "O1", Expected: 0.5, Actual: 1.5},
+		{Type: FindingFeeDiscrepancy, OrderId: "O1", TradeId: "T1", Expected: 0.5, Actual: 0.6},
+		{Type: FindingMissingFill, OrderId: "O1", Expected: 0.1, Actual: 0.30000000000000004},
+		{Type: FindingUnconfirmedFill, OrderId: "O2", Expected: 1, Actual: 0.5},
+		{Type: FindingUnknownLedgerEntry, TradeId: "T9", LedgerId: "L2", Actual: 0.1},
+		{Type: FindingUnknownOrder, OrderId: "O3", TradeId: "T4", Actual: 0.7},
+	}
+	for i, finding := range findings {
+		require.NotEmpty(suite.T(), finding.Description)
+		require.Equal(suite.T(), expected[i].Type, finding.Type)
+		require.Equal(suite.T(), expected[i].OrderId, finding.OrderId)
+		require.Equal(suite.T(), expected[i].TradeId, finding.TradeId)
+		require.Equal(suite.T(), expected[i].LedgerId, finding.LedgerId)
+		require.InDelta(suite.T(), expected[i].Expected, finding.Expected, DefaultTolerance)
+		require.InDelta(suite.T(), expected[i].Actual, finding.Actual, DefaultTolerance)
+	}
+}
+
+// Test invalid data is rejected.
+//
+// Test will ensure:
+//   - Invalid volumes and fees are reported as errors.
+func (suite *ReconcilerUnitTestSuite) TestInvalidData() {
+	r := NewReconciler(0)
+	require.Error(suite.T(), r.ProcessOwnTrades(ownTrades(map[string]messages.OwnTradeData{
+		"T1": {OrderTransactionId: "O1", Volume: "abc"},
+	})))
+	require.Error(suite.T(), r.ProcessTrades(map[string]*account.TradeInfo{
+		"T1": {OrderTransactionId: "O1", Volume: "1", Fee: json.Number("abc")},
+	}))
+	require.Error(suite.T(), r.ProcessLedgerEntries(map[string]*account.LedgerEntry{
+		"L1": {ReferenceId: "T1", Type: string(account.EntryTypeTrade), Fee: json.Number("abc")},
+	}))
+	require.Empty(suite.T(), r.Reconcile())
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build an ownTrades message with the provided trades.
+func ownTrades(trades map[string]messages.OwnTradeData) messages.OwnTrades {
+	return messages.OwnTrades{
+		ChannelName: string(messages.ChannelOwnTrades),
+		Data:        []map[string]messages.OwnTradeData{trades},
+	}
+}
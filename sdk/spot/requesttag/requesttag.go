@@ -0,0 +1,174 @@
+// Package requesttag provides the helpers used to attach user-defined tags to the context of a
+// request (ex: strategy name, signal ID, decision ID). Tags set with WithTag are propagated by
+// the REST and websocket clients as attributes of their OpenTelemetry spans, as metadata of
+// pending websocket requests and, optionally, as the userref of orders. Tags allow users to
+// correlate strategy decisions with requests, orders and fills end to end.
+package requesttag
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Prefix of the keys of the span attributes which contain the tags: a tag with key "strategy" is
+// recorded with the span attribute "goctopus.tag.strategy".
+const AttributePrefix = "goctopus.tag."
+
+// Key used to store tags in a context.
+type tagsContextKey struct{}
+
+// # Description
+//
+// Get a copy of the provided context which carries the provided tag along with the tags of the
+// parent context. A tag which has the same key as a tag of the parent context replaces it.
+//
+// # Inputs
+//
+//   - ctx: Parent context. Must not be nil.
+//   - key: Tag key.
+//   - value: Tag value.
+//
+// # Return
+//
+// A copy of the parent context with the tag.
+func WithTag(ctx context.Context, key string, value string) context.Context {
+	parent, _ := ctx.Value(tagsContextKey{}).(map[string]string)
+	// Copy parent tags so they are not modified
+	tags := make(map[string]string, len(parent)+1)
+	for k, v := range parent {
+		tags[k] = v
+	}
+	tags[key] = value
+	return context.WithValue(ctx, tagsContextKey{}, tags)
+}
+
+// # Description
+//
+// Get the tags carried by the provided context.
+//
+// # Inputs
+//
+//   - ctx: Context. Must not be nil.
+//
+// # Return
+//
+// A copy of the tags carried by the context. Nil if the context does not carry any tag.
+func Tags(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(tagsContextKey{}).(map[string]string)
+	if len(tags) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(tags))
+	for k, v := range tags {
+		copied[k] = v
+	}
+	return copied
+}
+
+// # Description
+//
+// Get the value of a tag carried by the provided context.
+//
+// # Inputs
+//
+//   - ctx: Context. Must not be nil.
+//   - key: Tag key.
+//
+// # Return
+//
+// The value of the tag and true if the context carries the tag. An empty string and false otherwise.
+func Get(ctx context.Context, key string) (string, bool) {
+	tags, _ := ctx.Value(tagsContextKey{}).(map[string]string)
+	value, ok := tags[key]
+	return value, ok
+}
+
+// # Description
+//
+// Get the span attributes which record the tags carried by the provided context (Cf.
+// AttributePrefix). Attributes are sorted by key.
+//
+// # Inputs
+//
+//   - ctx: Context. Must not be nil.
+//
+// # Return
+//
+// The span attributes. Nil if the context does not carry any tag.
+func Attributes(ctx context.Context) []attribute.KeyValue {
+	tags, _ := ctx.Value(tagsContextKey{}).(map[string]string)
+	if len(tags) == 0 {
+		return nil
+	}
+	attrs := make([]attribute.KeyValue, 0, len(tags))
+	for k, v := range tags {
+		attrs = append(attrs, attribute.String(AttributePrefix+k, v))
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+	return attrs
+}
+
+// # Description
+//
+// Get the order user reference (userref) carried by the tag with the provided key. The value of
+// the tag must be a 32 bits signed integer, which is the type of the userref field of orders.
+//
+// # Inputs
+//
+//   - ctx: Context. Must not be nil.
+//   - key: Key of the tag which carries the user reference. If empty, no user reference is found.
+//
+// # Return
+//
+// The user reference and true if the context carries a tag with the provided key and an integer
+// value. 0 and false otherwise.
+func UserReference(ctx context.Context, key string) (int64, bool) {
+	if key == "" {
+		return 0, false
+	}
+	value, ok := Get(ctx, key)
+	if !ok {
+		return 0, false
+	}
+	userref, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return userref, true
+}
+
+// # Description
+//
+// Wrap a tracer so every span it starts has the attributes which record the tags carried by the
+// context used to start the span (Cf. Attributes).
+//
+// # Inputs
+//
+//   - tracer: Tracer to wrap. Must not be nil.
+//
+// # Return
+//
+// The wrapped tracer. The tracer is returned as is if it is already wrapped.
+func Tracer(tracer trace.Tracer) trace.Tracer {
+	if _, ok := tracer.(*taggedTracer); ok {
+		return tracer
+	}
+	return &taggedTracer{Tracer: tracer}
+}
+
+// Tracer which adds the tags carried by the context to every span.
+type taggedTracer struct {
+	trace.Tracer
+}
+
+// Start a span with the attributes which record the tags carried by the context.
+func (t *taggedTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if attrs := Attributes(ctx); attrs != nil {
+		opts = append(opts, trace.WithAttributes(attrs...))
+	}
+	return t.Tracer.Start(ctx, spanName, opts...)
+}
@@ -0,0 +1,107 @@
+package requesttag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for request tag helpers
+type RequestTagUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestRequestTagUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(RequestTagUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test WithTag, Tags, Get and Attributes.
+//
+// Test will ensure:
+//   - A context without tags has no tags and no attributes.
+//   - Tags are inherited from the parent context and replaced when the key is reused.
+//   - Parent contexts and returned maps are not modified.
+//   - Attributes are prefixed and sorted by key.
+func (suite *RequestTagUnitTestSuite) TestWithTag() {
+	require.Nil(suite.T(), Tags(context.Background()))
+	require.Nil(suite.T(), Attributes(context.Background()))
+	parent := WithTag(WithTag(context.Background(), "strategy", "mm"), "signal", "s-1")
+	child := WithTag(parent, "signal", "s-2")
+	require.Equal(suite.T(), map[string]string{"strategy": "mm", "signal": "s-1"}, Tags(parent))
+	require.Equal(suite.T(), map[string]string{"strategy": "mm", "signal": "s-2"}, Tags(child))
+	tags := Tags(child)
+	tags["strategy"] = "other"
+	value, ok := Get(child, "strategy")
+	require.True(suite.T(), ok)
+	require.Equal(suite.T(), "mm", value)
+	_, ok = Get(child, "unknown")
+	require.False(suite.T(), ok)
+	require.Equal(suite.T(), []attribute.KeyValue{
+		attribute.String("goctopus.tag.signal", "s-2"),
+		attribute.String("goctopus.tag.strategy", "mm"),
+	}, Attributes(child))
+}
+
+// Test UserReference.
+//
+// Test will ensure:
+//   - The user reference is read from the tag with the provided key.
+//   - Missing tags, empty keys and values which are not 32 bits integers are ignored.
+func (suite *RequestTagUnitTestSuite) TestUserReference() {
+	ctx := WithTag(WithTag(WithTag(context.Background(), "decision", "42"), "strategy", "mm"), "big", "4294967296")
+	userref, ok := UserReference(ctx, "decision")
+	require.True(suite.T(), ok)
+	require.Equal(suite.T(), int64(42), userref)
+	for _, key := range []string{"", "strategy", "big", "unknown"} {
+		_, ok = UserReference(ctx, key)
+		require.False(suite.T(), ok, key)
+	}
+}
+
+// Test Tracer.
+//
+// Test will ensure:
+//   - Spans have the tags of the context along with their own attributes.
+//   - Spans started with a context without tags only have their own attributes.
+//   - Wrapping an already wrapped tracer does not stack wrappers.
+func (suite *RequestTagUnitTestSuite) TestTracer() {
+	rec := &recordingTracer{Tracer: noop.NewTracerProvider().Tracer("test")}
+	tracer := Tracer(rec)
+	require.Same(suite.T(), tracer, Tracer(tracer))
+	tracer.Start(WithTag(context.Background(), "strategy", "mm"), "span", trace.WithAttributes(attribute.String("k", "v")))
+	require.Equal(suite.T(), []attribute.KeyValue{attribute.String("k", "v"), attribute.String("goctopus.tag.strategy", "mm")}, rec.attributes)
+	tracer.Start(context.Background(), "span", trace.WithAttributes(attribute.String("k", "v")))
+	require.Equal(suite.T(), []attribute.KeyValue{attribute.String("k", "v")}, rec.attributes)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Tracer which records the attributes of the last started span.
+type recordingTracer struct {
+	trace.Tracer
+	// Attributes of the last started span
+	attributes []attribute.KeyValue
+}
+
+// Record span attributes and start a span.
+func (t *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	cfg := trace.NewSpanStartConfig(opts...)
+	t.attributes = cfg.Attributes()
+	return t.Tracer.Start(ctx, name, opts...)
+}
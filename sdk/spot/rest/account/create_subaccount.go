@@ -0,0 +1,18 @@
+package account
+
+import "github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+
+// CreateSubaccount request parameters
+type CreateSubaccountRequestParameters struct {
+	// Username for the subaccount
+	Username string `json:"username"`
+	// Email address for the subaccount
+	Email string `json:"email"`
+}
+
+// CreateSubaccount response
+type CreateSubaccountResponse struct {
+	common.KrakenSpotRESTResponse
+	// True if the subaccount has been created
+	Result bool `json:"result"`
+}
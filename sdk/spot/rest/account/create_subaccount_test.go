@@ -0,0 +1,49 @@
+package account
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for CreateSubaccount DTO.
+//
+// The test suite ensures all DTO can be marshalled/unmarshalled to/from JSON payloads used by the
+// Kraken Spot REST API.
+type CreateSubaccountTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestCreateSubaccountTestSuite(t *testing.T) {
+	suite.Run(t, new(CreateSubaccountTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the JSON unmarshaller of CreateSubaccountResponse.
+//
+// The test will ensure:
+//   - A valid JSON response from the API can be unmarshalled into the corresponding CreateSubaccountResponse struct.
+func (suite *CreateSubaccountTestSuite) TestCreateSubaccountResponseUnmarshalJSON() {
+	// Test settings, expectations, ...
+	payload := `{
+		"error": [],
+		"result": true
+	}`
+	// Unmarshal payload into struct
+	response := new(CreateSubaccountResponse)
+	err := json.Unmarshal([]byte(payload), response)
+	require.NoError(suite.T(), err)
+	// Check data
+	require.Empty(suite.T(), response.Error)
+	require.True(suite.T(), response.Result)
+}
@@ -44,6 +44,22 @@ type GetClosedOrdersRequestOptions struct {
 	//
 	// Defaults to false.
 	ConsolidateTaker bool `json:"consolidate_taker"`
+	// Restrict results to given client order id.
+	//
+	// An empty string means no filtering based on a client order id.
+	ClientOrderId string `json:"cl_ord_id,omitempty"`
+	// Whether or not to skip the computation of the count of orders matching criteria. Improves
+	// performance for accounts with many orders: the count of the results is zero in that case.
+	//
+	// Defaults to false.
+	WithoutCount bool `json:"without_count,omitempty"`
+	// Filters applied by the client to the closed orders returned by the API, for the criteria the
+	// API cannot filter on (pair, user reference range, order type, status, ...). Only the orders
+	// which match all filters are kept (Cf. MatchOrderInfo). Filters do not change the count of the
+	// results, which is computed by the API, nor the pagination.
+	//
+	// An empty list means no filtering.
+	Filters []OrderInfoFilter `json:"-"`
 }
 
 // GetClosedOrders results.
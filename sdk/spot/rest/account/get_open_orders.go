@@ -12,6 +12,16 @@ type GetOpenOrdersRequestOptions struct {
 	//
 	// A nil value means no restrictions.
 	UserReference *int64
+	// Restrict results to given client order id.
+	//
+	// An empty string means no restrictions.
+	ClientOrderId string
+	// Filters applied by the client to the open orders returned by the API, for the criteria the
+	// API cannot filter on (pair, user reference range, order type, ...). Only the orders which
+	// match all filters are kept (Cf. MatchOrderInfo).
+	//
+	// An empty list means no filtering.
+	Filters []OrderInfoFilter
 }
 
 // GetOpenOrders result
@@ -0,0 +1,82 @@
+package account
+
+import (
+	"encoding/json"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+)
+
+// Enum for the types of order amends
+type AmendTypeEnum string
+
+// Values for AmendTypeEnum
+const (
+	// Values of the order when it has been placed
+	AmendOriginal AmendTypeEnum = "original"
+	// Amend requested by the user
+	AmendUser AmendTypeEnum = "user"
+	// Amend made by the engine (ex: price or quantity restated after a corporate action)
+	AmendRestated AmendTypeEnum = "restated"
+)
+
+// Enum for the rebase multiplier used to express the quantities and prices of amends on rebased
+// assets (xstocks).
+type RebaseMultiplierEnum string
+
+// Values for RebaseMultiplierEnum
+const (
+	RebaseMultiplierRebased RebaseMultiplierEnum = "rebased"
+	RebaseMultiplierBase    RebaseMultiplierEnum = "base"
+)
+
+// GetOrderAmends request parameters.
+type GetOrderAmendsRequestParameters struct {
+	// Kraken order ID
+	OrderId string `json:"order_id"`
+}
+
+// GetOrderAmends request options.
+type GetOrderAmendsRequestOptions struct {
+	// Multiplier used to express quantities and prices for rebased assets. Cf. RebaseMultiplierEnum.
+	//
+	// An empty string triggers the default behavior (rebased).
+	RebaseMultiplier string `json:"rebase_multiplier,omitempty"`
+}
+
+// A single amend of an order.
+type OrderAmend struct {
+	// Amend ID
+	AmendId string `json:"amend_id"`
+	// Type of amend. Cf. AmendTypeEnum
+	AmendType string `json:"amend_type"`
+	// Order quantity in terms of the base asset
+	OrderQuantity json.Number `json:"order_qty,omitempty"`
+	// Visible quantity of iceberg orders
+	DisplayQuantity json.Number `json:"display_qty,omitempty"`
+	// Remaining quantity to fill
+	RemainingQuantity json.Number `json:"remaining_qty,omitempty"`
+	// Limit price
+	LimitPrice json.Number `json:"limit_price,omitempty"`
+	// Trigger price of triggered orders
+	TriggerPrice json.Number `json:"trigger_price,omitempty"`
+	// Reason of the amend
+	Reason string `json:"reason,omitempty"`
+	// True if the order is post-only
+	PostOnly bool `json:"post_only"`
+	// Unix timestamp (milliseconds) of the amend
+	Timestamp int64 `json:"timestamp"`
+}
+
+// GetOrderAmends result.
+type GetOrderAmendsResult struct {
+	// Amends of the order, including the original order values, in chronological order
+	Amends []OrderAmend `json:"amends"`
+	// Number of amends
+	Count int `json:"count"`
+}
+
+// GetOrderAmends response.
+type GetOrderAmendsResponse struct {
+	common.KrakenSpotRESTResponse
+	Result *GetOrderAmendsResult `json:"result,omitempty"`
+}
@@ -0,0 +1,78 @@
+package account
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for GetOrderAmends DTO.
+//
+// The test suite ensures all DTO can be marshalled/unmarshalled to/from JSON payloads used by the
+// Kraken Spot REST API.
+type GetOrderAmendsTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestGetOrderAmendsTestSuite(t *testing.T) {
+	suite.Run(t, new(GetOrderAmendsTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the JSON unmarshaller of GetOrderAmendsResponse.
+//
+// The test will ensure:
+//   - A valid JSON response from the API can be unmarshalled into the corresponding GetOrderAmendsResponse struct.
+func (suite *GetOrderAmendsTestSuite) TestGetOrderAmendsUnmarshalJSON() {
+	// Test settings, expectations, ...
+	payload := `{
+		"error": [],
+		"result": {
+		  "amends": [
+			{
+			  "amend_id": "TN2WNY-VWLQA-SFEXBY",
+			  "amend_type": "original",
+			  "order_qty": "1.00000000",
+			  "remaining_qty": "1.00000000",
+			  "limit_price": "30000.0",
+			  "post_only": false,
+			  "timestamp": 1724922426345
+			},
+			{
+			  "amend_id": "TCTA2Z-OXJLW-YM42RT",
+			  "amend_type": "user",
+			  "order_qty": "1.00000000",
+			  "remaining_qty": "1.00000000",
+			  "limit_price": "30500.0",
+			  "post_only": true,
+			  "timestamp": 1724922537432
+			}
+		  ],
+		  "count": 2
+		}
+	}`
+	// Unmarshal payload into struct
+	response := new(GetOrderAmendsResponse)
+	err := json.Unmarshal([]byte(payload), response)
+	require.NoError(suite.T(), err)
+	// Check data
+	require.Empty(suite.T(), response.Error)
+	require.NotNil(suite.T(), response.Result)
+	require.Equal(suite.T(), 2, response.Result.Count)
+	require.Len(suite.T(), response.Result.Amends, 2)
+	require.Equal(suite.T(), string(AmendOriginal), response.Result.Amends[0].AmendType)
+	require.Equal(suite.T(), "TCTA2Z-OXJLW-YM42RT", response.Result.Amends[1].AmendId)
+	require.Equal(suite.T(), json.Number("30500.0"), response.Result.Amends[1].LimitPrice)
+	require.True(suite.T(), response.Result.Amends[1].PostOnly)
+	require.Equal(suite.T(), int64(1724922537432), response.Result.Amends[1].Timestamp)
+}
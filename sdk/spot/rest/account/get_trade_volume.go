@@ -2,6 +2,9 @@ package account
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
 
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
 )
@@ -45,3 +48,136 @@ type GetTradeVolumeResponse struct {
 	common.KrakenSpotRESTResponse
 	Result *GetTradeVolumeResult `json:"result,omitempty"`
 }
+
+/*************************************************************************************************/
+/* FEE TIERS                                                                                     */
+/*************************************************************************************************/
+
+// Typed view of the fee tier information of a pair.
+type FeeTier struct {
+	// Current fee in percent
+	Fee float64
+	// Minimum fee in percent. Zero for fixed fees.
+	MinimumFee float64
+	// Maximum fee in percent. Zero for fixed fees.
+	MaximumFee float64
+	// Volume level of the current tier. Zero for fixed fees.
+	TierVolume float64
+	// True if there is a next tier: false for fixed fees or at the lowest fee tier.
+	HasNextTier bool
+	// Fee of the next tier in percent. Zero if there is no next tier.
+	NextFee float64
+	// Volume level of the next tier. Zero if there is no next tier.
+	NextTierVolume float64
+}
+
+// The next fee tier of a pair and the volume needed to reach it.
+type NextFeeTier struct {
+	// Fee of the next tier in percent
+	Fee float64
+	// Volume level of the next tier
+	Volume float64
+	// Additional volume needed to reach the next tier, in the volume currency. Zero if the
+	// current volume already reaches the next tier volume level.
+	VolumeNeeded float64
+}
+
+// # Description
+//
+// Parse the fee tier information into a FeeTier. Empty optional values are mapped to zero.
+//
+// # Return
+//
+// The typed fee tier information or an error if one of the values cannot be parsed.
+func (info *FeeTierInfo) Tier() (FeeTier, error) {
+	var err error
+	tier := FeeTier{HasNextTier: info.NextFee != "" && info.NextTierVolume != ""}
+	if tier.Fee, err = parseDecimal("fee", info.Fee); err != nil {
+		return FeeTier{}, err
+	}
+	if tier.MinimumFee, err = parseDecimal("minimum fee", info.MinimumFee); err != nil {
+		return FeeTier{}, err
+	}
+	if tier.MaximumFee, err = parseDecimal("maximum fee", info.MaximumFee); err != nil {
+		return FeeTier{}, err
+	}
+	if tier.TierVolume, err = parseDecimal("tier volume", info.TierVolume); err != nil {
+		return FeeTier{}, err
+	}
+	if tier.NextFee, err = parseDecimal("next fee", info.NextFee); err != nil {
+		return FeeTier{}, err
+	}
+	if tier.NextTierVolume, err = parseDecimal("next tier volume", info.NextTierVolume); err != nil {
+		return FeeTier{}, err
+	}
+	return tier, nil
+}
+
+// # Description
+//
+// Get the current fee tier of a pair.
+//
+// # Inputs
+//
+//   - pair: Asset pair as used in the GetTradeVolume request.
+//   - maker: If true, the maker fee tier is returned. Pairs which are not subject to maker/taker
+//     fees have no maker fee tier: their fee tier is returned instead.
+//
+// # Return
+//
+// The current fee tier of the pair or an error if the pair has no fee information or if the fee
+// information cannot be parsed.
+func (result *GetTradeVolumeResult) GetCurrentFeeTier(pair string, maker bool) (FeeTier, error) {
+	info := result.Fees[pair]
+	if maker && result.FeesMaker[pair] != nil {
+		info = result.FeesMaker[pair]
+	}
+	if info == nil {
+		return FeeTier{}, fmt.Errorf("no fee information for pair %s", pair)
+	}
+	return info.Tier()
+}
+
+// # Description
+//
+// Get the next fee tier of a pair and the additional volume needed to reach it.
+//
+// # Inputs
+//
+//   - pair: Asset pair as used in the GetTradeVolume request.
+//   - maker: If true, the next maker fee tier is returned. Cf. GetCurrentFeeTier.
+//
+// # Return
+//
+// The next fee tier of the pair, nil if the pair has fixed fees or is at the lowest fee tier.
+// An error is returned if the pair has no fee information or if values cannot be parsed.
+func (result *GetTradeVolumeResult) GetNextFeeTier(pair string, maker bool) (*NextFeeTier, error) {
+	tier, err := result.GetCurrentFeeTier(pair, maker)
+	if err != nil {
+		return nil, err
+	}
+	if !tier.HasNextTier {
+		return nil, nil
+	}
+	volume, err := parseDecimal("volume", result.Volume)
+	if err != nil {
+		return nil, err
+	}
+	return &NextFeeTier{
+		Fee:          tier.NextFee,
+		Volume:       tier.NextTierVolume,
+		VolumeNeeded: math.Max(0, tier.NextTierVolume-volume),
+	}, nil
+}
+
+// Parse a decimal value received from the server as a float64. Empty values are mapped to zero.
+func parseDecimal(name string, value json.Number) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(value.String(), 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s %q: %w", name, value.String(), err)
+	}
+	return f, nil
+}
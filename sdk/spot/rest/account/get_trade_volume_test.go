@@ -99,3 +99,54 @@ func (suite *GetTradeVolumeTestSuite) TestGetTradeVolumeUnmarshalJSON() {
 	require.Equal(suite.T(), expectedFeesMakerBTCNextVolume, response.Result.FeesMaker[expectedTargetPair].NextTierVolume.String())
 	require.Equal(suite.T(), expectedFeesMakerBTCTierVolume, response.Result.FeesMaker[expectedTargetPair].TierVolume.String())
 }
+
+// Test the fee tier helpers of GetTradeVolumeResult.
+//
+// The test will ensure:
+//   - Current fee tiers are parsed into typed values for takers and makers.
+//   - Maker fee tier falls back to the fee tier for pairs without maker fees.
+//   - The next fee tier and the volume needed to reach it are computed.
+//   - No next fee tier is returned at the lowest fee tier.
+//   - An error is returned for unknown pairs and invalid values.
+func (suite *GetTradeVolumeTestSuite) TestFeeTiers() {
+	payload := `{
+		"currency": "ZUSD",
+		"volume": "30000.5000",
+		"fees": {
+			"XXBTZUSD": {"fee": "0.2200", "minfee": "0.1000", "maxfee": "0.2600", "nextfee": "0.2000", "nextvolume": "100000.0000", "tiervolume": "50000.0000"},
+			"USDTZUSD": {"fee": "0.2000", "minfee": "0.2000", "maxfee": "0.2000", "nextfee": null, "nextvolume": null, "tiervolume": null},
+			"XETHZUSD": {"fee": "0.1000", "minfee": "0.1000", "maxfee": "0.2600", "nextfee": null, "nextvolume": null, "tiervolume": "10000000.0000"}
+		},
+		"fees_maker": {
+			"XXBTZUSD": {"fee": "0.1200", "minfee": "0.0000", "maxfee": "0.1600", "nextfee": "0.1000", "nextvolume": "10000.0000", "tiervolume": "0.0000"}
+		}
+	}`
+	result := new(GetTradeVolumeResult)
+	require.NoError(suite.T(), json.Unmarshal([]byte(payload), result))
+	result.Fees["BAD"] = &FeeTierInfo{Fee: "abc"}
+	// Current fee tiers
+	tier, err := result.GetCurrentFeeTier("XXBTZUSD", false)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), FeeTier{Fee: 0.22, MinimumFee: 0.1, MaximumFee: 0.26, TierVolume: 50000, HasNextTier: true, NextFee: 0.2, NextTierVolume: 100000}, tier)
+	tier, err = result.GetCurrentFeeTier("XXBTZUSD", true)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 0.12, tier.Fee)
+	tier, err = result.GetCurrentFeeTier("USDTZUSD", true)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), FeeTier{Fee: 0.2, MinimumFee: 0.2, MaximumFee: 0.2}, tier)
+	// Next fee tiers
+	next, err := result.GetNextFeeTier("XXBTZUSD", false)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), &NextFeeTier{Fee: 0.2, Volume: 100000, VolumeNeeded: 69999.5}, next)
+	next, err = result.GetNextFeeTier("XXBTZUSD", true)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), &NextFeeTier{Fee: 0.1, Volume: 10000, VolumeNeeded: 0}, next)
+	next, err = result.GetNextFeeTier("XETHZUSD", false)
+	require.NoError(suite.T(), err)
+	require.Nil(suite.T(), next)
+	// Errors
+	_, err = result.GetCurrentFeeTier("UNKNOWN", false)
+	require.Error(suite.T(), err)
+	_, err = result.GetNextFeeTier("BAD", false)
+	require.Error(suite.T(), err)
+}
@@ -42,6 +42,10 @@ type GetTradesHistoryRequestOptions struct {
 	//
 	// Defaults to false.
 	ConsolidateTaker bool `json:"consolidate_taker"`
+	// Whether or not to include the IDs of the ledger entries related to each trade.
+	//
+	// Defaults to false.
+	Ledgers bool `json:"ledgers,omitempty"`
 }
 
 // GetTradesHistory results.
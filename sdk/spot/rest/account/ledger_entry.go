@@ -1,6 +1,9 @@
 package account
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strings"
+)
 
 // Enum for ledger entry types
 type LedgerEntryTypeEnum string
@@ -28,6 +31,34 @@ const (
 	EntryTypeNftCreatorFee   LedgerEntryTypeEnum = "nftcreatorfee"
 	EntryTypeNftRebate       LedgerEntryTypeEnum = "nftrebate"
 	EntryTypeCustodyTransfer LedgerEntryTypeEnum = "custodytransfer"
+	EntryTypeEarn            LedgerEntryTypeEnum = "earn"
+)
+
+// Enum for ledger entry subtypes
+type LedgerEntrySubTypeEnum string
+
+// Values for LedgerEntrySubTypeEnum
+const (
+	SubTypeNone            LedgerEntrySubTypeEnum = ""
+	SubTypeSpotFromFutures LedgerEntrySubTypeEnum = "spotfromfutures"
+	SubTypeSpotToFutures   LedgerEntrySubTypeEnum = "spottofutures"
+	SubTypeStakingFromSpot LedgerEntrySubTypeEnum = "stakingfromspot"
+	SubTypeSpotToStaking   LedgerEntrySubTypeEnum = "spottostaking"
+	SubTypeStakingToSpot   LedgerEntrySubTypeEnum = "stakingtospot"
+	SubTypeSpotFromStaking LedgerEntrySubTypeEnum = "spotfromstaking"
+	SubTypeAllocation      LedgerEntrySubTypeEnum = "allocation"
+	SubTypeDeallocation    LedgerEntrySubTypeEnum = "deallocation"
+	SubTypeAutoAllocation  LedgerEntrySubTypeEnum = "autoallocation"
+	SubTypeMigration       LedgerEntrySubTypeEnum = "migration"
+	SubTypeReward          LedgerEntrySubTypeEnum = "reward"
+)
+
+// Enum for asset classes
+type AssetClassEnum string
+
+// Values for AssetClassEnum
+const (
+	AssetClassCurrency AssetClassEnum = "currency"
 )
 
 // LedgerEntry contains ledger entry data.
@@ -36,11 +67,12 @@ type LedgerEntry struct {
 	ReferenceId string `json:"refid"`
 	// Unix timestamp of ledger
 	Timestamp json.Number `json:"time"`
-	// Type of ledger entry. Cf LedgerEntryTypeEnum for values.
+	// Type of ledger entry. Cf LedgerEntryTypeEnum for values and GetType for the typed value.
 	Type string `json:"type"`
-	// Additional info relating to the ledger entry type, where applicable
+	// Additional info relating to the ledger entry type, where applicable. Cf LedgerEntrySubTypeEnum
+	// for values and GetSubType for the typed value.
 	SubType string `json:"subtype,omitempty"`
-	// Asset class
+	// Asset class. Cf AssetClassEnum for values and GetAssetClass for the typed value.
 	AssetClass string `json:"aclass"`
 	// Asset
 	Asset string `json:"asset"`
@@ -51,3 +83,194 @@ type LedgerEntry struct {
 	// Resulting balance
 	Balance json.Number `json:"balance"`
 }
+
+// Get the type of the ledger entry. Cf ParseLedgerEntryType.
+func (entry *LedgerEntry) GetType() LedgerEntryTypeEnum {
+	return ParseLedgerEntryType(entry.Type)
+}
+
+// Get the subtype of the ledger entry. Cf ParseLedgerEntrySubType.
+func (entry *LedgerEntry) GetSubType() LedgerEntrySubTypeEnum {
+	return ParseLedgerEntrySubType(entry.SubType)
+}
+
+// Get the asset class of the ledger entry. Cf ParseAssetClass.
+func (entry *LedgerEntry) GetAssetClass() AssetClassEnum {
+	return ParseAssetClass(entry.AssetClass)
+}
+
+/*****************************************************************************/
+/* PARSING                                                                   */
+/*****************************************************************************/
+
+// # Description
+//
+// Parse a raw ledger entry type. Parsing is case insensitive and tolerant of values added to the
+// API after this version of the SDK: an unknown type is returned as is (Cf. IsKnown) so it can
+// still be compared, logged or stored without being lost.
+//
+// # Inputs
+//
+//   - raw: Raw ledger entry type, as returned by the API.
+//
+// # Return
+//
+// The matching LedgerEntryTypeEnum value or the unknown raw value.
+func ParseLedgerEntryType(raw string) LedgerEntryTypeEnum {
+	if known := LedgerEntryTypeEnum(strings.ToLower(strings.TrimSpace(raw))); known.IsKnown() {
+		return known
+	}
+	return LedgerEntryTypeEnum(raw)
+}
+
+// # Description
+//
+// Parse a raw ledger entry subtype. Parsing is case insensitive and tolerant of values added to
+// the API after this version of the SDK: an unknown subtype is returned as is (Cf. IsKnown).
+//
+// # Inputs
+//
+//   - raw: Raw ledger entry subtype, as returned by the API. Can be empty.
+//
+// # Return
+//
+// The matching LedgerEntrySubTypeEnum value or the unknown raw value.
+func ParseLedgerEntrySubType(raw string) LedgerEntrySubTypeEnum {
+	if known := LedgerEntrySubTypeEnum(strings.ToLower(strings.TrimSpace(raw))); known.IsKnown() {
+		return known
+	}
+	return LedgerEntrySubTypeEnum(raw)
+}
+
+// # Description
+//
+// Parse a raw asset class. Parsing is case insensitive and tolerant of values added to the API
+// after this version of the SDK: an unknown asset class is returned as is (Cf. IsKnown).
+//
+// # Inputs
+//
+//   - raw: Raw asset class, as returned by the API.
+//
+// # Return
+//
+// The matching AssetClassEnum value or the unknown raw value.
+func ParseAssetClass(raw string) AssetClassEnum {
+	if known := AssetClassEnum(strings.ToLower(strings.TrimSpace(raw))); known.IsKnown() {
+		return known
+	}
+	return AssetClassEnum(raw)
+}
+
+// Return true if the ledger entry type is one of the values known by the SDK.
+func (t LedgerEntryTypeEnum) IsKnown() bool {
+	switch t {
+	case EntryTypeNone, EntryTypeTrade, EntryTypeDeposit, EntryTypeWithdrawal, EntryTypeTransfer,
+		EntryTypeMargin, EntryTypeAdjustment, EntryTypeRollover, EntryTypeSpend, EntryTypeReceive,
+		EntryTypeSettled, EntryTypeCredit, EntryTypeStaking, EntryTypeReward, EntryTypeDividend,
+		EntryTypeSale, EntryTypeConverion, EntryTypeNftTrade, EntryTypeNftCreatorFee,
+		EntryTypeNftRebate, EntryTypeCustodyTransfer, EntryTypeEarn:
+		return true
+	default:
+		return false
+	}
+}
+
+// Return true if the ledger entry subtype is one of the values known by the SDK.
+func (t LedgerEntrySubTypeEnum) IsKnown() bool {
+	switch t {
+	case SubTypeNone, SubTypeSpotFromFutures, SubTypeSpotToFutures, SubTypeStakingFromSpot,
+		SubTypeSpotToStaking, SubTypeStakingToSpot, SubTypeSpotFromStaking, SubTypeAllocation,
+		SubTypeDeallocation, SubTypeAutoAllocation, SubTypeMigration, SubTypeReward:
+		return true
+	default:
+		return false
+	}
+}
+
+// Return true if the asset class is one of the values known by the SDK.
+func (c AssetClassEnum) IsKnown() bool {
+	return c == AssetClassCurrency
+}
+
+/*****************************************************************************/
+/* FILTERS                                                                   */
+/*****************************************************************************/
+
+// Predicate used to select ledger entries, for example in the options of the history streams.
+// A filter must return false for nil entries.
+type LedgerEntryFilter func(entry *LedgerEntry) bool
+
+// Build a filter which selects the ledger entries with one of the provided types.
+func OnlyLedgerEntryTypes(types ...LedgerEntryTypeEnum) LedgerEntryFilter {
+	return func(entry *LedgerEntry) bool {
+		if entry == nil {
+			return false
+		}
+		t := entry.GetType()
+		for _, candidate := range types {
+			if t == candidate {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Build a filter which selects the trade ledger entries.
+func OnlyTrades() LedgerEntryFilter {
+	return OnlyLedgerEntryTypes(EntryTypeTrade)
+}
+
+// Build a filter which selects the staking rewards: staking and reward entries and earn entries
+// with the reward subtype.
+func OnlyStakingRewards() LedgerEntryFilter {
+	return func(entry *LedgerEntry) bool {
+		if entry == nil {
+			return false
+		}
+		switch entry.GetType() {
+		case EntryTypeStaking, EntryTypeReward:
+			return true
+		case EntryTypeEarn:
+			return entry.GetSubType() == SubTypeReward
+		default:
+			return false
+		}
+	}
+}
+
+// Build a filter which selects the transfers: transfer and custody transfer entries and earn
+// entries which move funds from or to an earn strategy (allocation, deallocation, ...).
+func OnlyTransfers() LedgerEntryFilter {
+	return func(entry *LedgerEntry) bool {
+		if entry == nil {
+			return false
+		}
+		switch entry.GetType() {
+		case EntryTypeTransfer, EntryTypeCustodyTransfer:
+			return true
+		case EntryTypeEarn:
+			switch entry.GetSubType() {
+			case SubTypeAllocation, SubTypeDeallocation, SubTypeAutoAllocation, SubTypeMigration:
+				return true
+			}
+			return false
+		default:
+			return false
+		}
+	}
+}
+
+// Return true if the ledger entry is selected by all the provided filters. Any non-nil entry is
+// selected when no filter is provided.
+func MatchLedgerEntry(entry *LedgerEntry, filters ...LedgerEntryFilter) bool {
+	if entry == nil {
+		return false
+	}
+	for _, filter := range filters {
+		if !filter(entry) {
+			return false
+		}
+	}
+	return true
+}
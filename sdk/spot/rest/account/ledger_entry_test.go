@@ -0,0 +1,85 @@
+package account
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for LedgerEntry enums and filters.
+type LedgerEntryTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestLedgerEntryTestSuite(t *testing.T) {
+	suite.Run(t, new(LedgerEntryTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the parsing of ledger entry types, subtypes and asset classes.
+//
+// The test will ensure:
+//   - Known values are parsed case insensitively.
+//   - Unknown values are returned as is and are reported as unknown.
+func (suite *LedgerEntryTestSuite) TestParse() {
+	entry := &LedgerEntry{Type: "Staking", SubType: "spotToStaking", AssetClass: "currency"}
+	require.Equal(suite.T(), EntryTypeStaking, entry.GetType())
+	require.Equal(suite.T(), SubTypeSpotToStaking, entry.GetSubType())
+	require.Equal(suite.T(), AssetClassCurrency, entry.GetAssetClass())
+	require.True(suite.T(), entry.GetType().IsKnown())
+	require.True(suite.T(), (&LedgerEntry{}).GetSubType().IsKnown())
+	// Unknown values
+	entry = &LedgerEntry{Type: "Airdrop", SubType: "newsubtype", AssetClass: "tokenized_asset"}
+	require.Equal(suite.T(), LedgerEntryTypeEnum("Airdrop"), entry.GetType())
+	require.False(suite.T(), entry.GetType().IsKnown())
+	require.Equal(suite.T(), LedgerEntrySubTypeEnum("newsubtype"), entry.GetSubType())
+	require.False(suite.T(), entry.GetSubType().IsKnown())
+	require.Equal(suite.T(), AssetClassEnum("tokenized_asset"), entry.GetAssetClass())
+	require.False(suite.T(), entry.GetAssetClass().IsKnown())
+}
+
+// Test the ledger entry filters.
+//
+// The test will ensure:
+//   - OnlyTrades only selects trade entries.
+//   - OnlyStakingRewards selects staking and reward entries and earn rewards.
+//   - OnlyTransfers selects transfers and earn allocations but not earn rewards.
+//   - MatchLedgerEntry requires all filters to match and never selects nil entries.
+func (suite *LedgerEntryTestSuite) TestFilters() {
+	trade := &LedgerEntry{Type: "trade"}
+	staking := &LedgerEntry{Type: "staking"}
+	earnReward := &LedgerEntry{Type: "earn", SubType: "reward"}
+	earnAllocation := &LedgerEntry{Type: "earn", SubType: "allocation"}
+	transfer := &LedgerEntry{Type: "transfer", SubType: "spotfromfutures"}
+	unknown := &LedgerEntry{Type: "airdrop"}
+	cases := []struct {
+		filter   LedgerEntryFilter
+		selected []*LedgerEntry
+		skipped  []*LedgerEntry
+	}{
+		{OnlyTrades(), []*LedgerEntry{trade}, []*LedgerEntry{staking, earnReward, transfer, unknown, nil}},
+		{OnlyStakingRewards(), []*LedgerEntry{staking, earnReward}, []*LedgerEntry{trade, earnAllocation, transfer, unknown, nil}},
+		{OnlyTransfers(), []*LedgerEntry{transfer, earnAllocation}, []*LedgerEntry{trade, staking, earnReward, unknown, nil}},
+	}
+	for _, c := range cases {
+		for _, entry := range c.selected {
+			require.True(suite.T(), c.filter(entry), entry)
+		}
+		for _, entry := range c.skipped {
+			require.False(suite.T(), c.filter(entry), entry)
+		}
+	}
+	require.True(suite.T(), MatchLedgerEntry(unknown))
+	require.False(suite.T(), MatchLedgerEntry(nil))
+	require.True(suite.T(), MatchLedgerEntry(trade, OnlyTrades(), OnlyLedgerEntryTypes(EntryTypeTrade, EntryTypeSpend)))
+	require.False(suite.T(), MatchLedgerEntry(trade, OnlyTrades(), OnlyTransfers()))
+}
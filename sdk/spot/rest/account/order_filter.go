@@ -0,0 +1,178 @@
+package account
+
+import (
+	"strings"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+)
+
+// Get the order status. Unknown values are returned as is (Cf. OrderStatusEnum.IsKnown).
+func (order *OrderInfo) GetStatus() OrderStatusEnum {
+	if known := OrderStatusEnum(strings.ToLower(strings.TrimSpace(order.Status))); known.IsKnown() {
+		return known
+	}
+	return OrderStatusEnum(order.Status)
+}
+
+// Get the order direction. Unknown values are returned as is (Cf. SideEnum.IsKnown).
+func (order *OrderInfo) GetSide() SideEnum {
+	if known := SideEnum(strings.ToLower(strings.TrimSpace(order.Description.Type))); known.IsKnown() {
+		return known
+	}
+	return SideEnum(order.Description.Type)
+}
+
+// Get the order type. Unknown values are returned as is (Cf. OrderTypeEnum.IsKnown).
+func (order *OrderInfo) GetOrderType() OrderTypeEnum {
+	if known := OrderTypeEnum(strings.ToLower(strings.TrimSpace(order.Description.OrderType))); known.IsKnown() {
+		return known
+	}
+	return OrderTypeEnum(order.Description.OrderType)
+}
+
+// Get the user reference of the order. Returns false if the order has no user reference or if it
+// is not an integer.
+func (order *OrderInfo) GetUserReference() (int64, bool) {
+	if order.UserReferenceId == "" {
+		return 0, false
+	}
+	userref, err := order.UserReferenceId.Int64()
+	return userref, err == nil
+}
+
+// Return true if the status is one of the values known by the SDK.
+func (s OrderStatusEnum) IsKnown() bool {
+	switch s {
+	case Pending, Open, Closed, Canceled, Expired:
+		return true
+	default:
+		return false
+	}
+}
+
+/*****************************************************************************/
+/* FILTERS                                                                   */
+/*****************************************************************************/
+
+// Predicate used to select orders, for example in the options of GetOpenOrders and
+// GetClosedOrders. A filter must return false for nil orders.
+type OrderInfoFilter func(order *OrderInfo) bool
+
+// # Description
+//
+// Build a filter which selects the orders placed on one of the provided pairs. Orders describe
+// their pair with its alternative name (ex: XBTUSD): provide the pair aliases to select orders with
+// any name of the pair (ex: XXBTZUSD or XBT/USD).
+//
+// # Inputs
+//
+//   - aliases: Mapping between the names of the pairs. If nil, pair names must be equal.
+//   - pairs: Names of the pairs to select.
+//
+// # Return
+//
+// The filter.
+func OnlyOrderPairs(aliases *market.PairAliases, pairs ...string) OrderInfoFilter {
+	return func(order *OrderInfo) bool {
+		if order == nil {
+			return false
+		}
+		for _, pair := range pairs {
+			if aliases.Same(pair, order.Description.Pair) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Build a filter which selects the orders whose user reference is between min and max
+// (inclusive). Orders without user reference are not selected.
+func OnlyUserReferenceRange(min int64, max int64) OrderInfoFilter {
+	return func(order *OrderInfo) bool {
+		if order == nil {
+			return false
+		}
+		userref, found := order.GetUserReference()
+		return found && userref >= min && userref <= max
+	}
+}
+
+// Build a filter which selects the orders with one of the provided order types.
+func OnlyOrderTypes(types ...OrderTypeEnum) OrderInfoFilter {
+	return func(order *OrderInfo) bool {
+		if order == nil {
+			return false
+		}
+		t := order.GetOrderType()
+		for _, candidate := range types {
+			if t == candidate {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Build a filter which selects the orders with one of the provided statuses.
+func OnlyOrderStatuses(statuses ...OrderStatusEnum) OrderInfoFilter {
+	return func(order *OrderInfo) bool {
+		if order == nil {
+			return false
+		}
+		s := order.GetStatus()
+		for _, candidate := range statuses {
+			if s == candidate {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Build a filter which selects the orders placed on the provided side.
+func OnlyOrderSide(side SideEnum) OrderInfoFilter {
+	return func(order *OrderInfo) bool {
+		return order != nil && order.GetSide() == side
+	}
+}
+
+// Return true if the order is selected by all the provided filters. Any non-nil order is selected
+// when no filter is provided.
+func MatchOrderInfo(order *OrderInfo, filters ...OrderInfoFilter) bool {
+	if order == nil {
+		return false
+	}
+	for _, filter := range filters {
+		if !filter(order) {
+			return false
+		}
+	}
+	return true
+}
+
+// # Description
+//
+// Select the orders which match all the provided filters (Cf. MatchOrderInfo).
+//
+// # Inputs
+//
+//   - orders: Orders by transaction ID, as returned by GetOpenOrders, GetClosedOrders and QueryOrdersInfo.
+//   - filters: Filters to apply.
+//
+// # Return
+//
+// A new map with the selected orders by transaction ID. The provided map is returned as is when no
+// filter is provided.
+func FilterOrderInfos(orders map[string]*OrderInfo, filters ...OrderInfoFilter) map[string]*OrderInfo {
+	if len(filters) == 0 {
+		return orders
+	}
+	selected := make(map[string]*OrderInfo, len(orders))
+	for txid, order := range orders {
+		if MatchOrderInfo(order, filters...) {
+			selected[txid] = order
+		}
+	}
+	return selected
+}
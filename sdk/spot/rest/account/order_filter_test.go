@@ -0,0 +1,91 @@
+package account
+
+import (
+	"testing"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for OrderInfo getters and filters.
+type OrderFilterTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestOrderFilterTestSuite(t *testing.T) {
+	suite.Run(t, new(OrderFilterTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the typed getters of an order.
+//
+// The test will ensure:
+//   - Known values are parsed case insensitively.
+//   - Unknown values are returned as is and are reported as unknown.
+//   - Missing and non integer user references are reported as not found.
+func (suite *OrderFilterTestSuite) TestGetters() {
+	order := &OrderInfo{Status: "Canceled", UserReferenceId: "42", Description: OrderInfoDescription{Type: "SELL", OrderType: "take-profit"}}
+	require.Equal(suite.T(), Canceled, order.GetStatus())
+	require.Equal(suite.T(), Sell, order.GetSide())
+	require.Equal(suite.T(), TakeProfit, order.GetOrderType())
+	userref, found := order.GetUserReference()
+	require.True(suite.T(), found)
+	require.Equal(suite.T(), int64(42), userref)
+	order = &OrderInfo{Status: "archived", UserReferenceId: "abc", Description: OrderInfoDescription{OrderType: "iceberg"}}
+	require.Equal(suite.T(), OrderStatusEnum("archived"), order.GetStatus())
+	require.False(suite.T(), order.GetStatus().IsKnown())
+	require.False(suite.T(), order.GetOrderType().IsKnown())
+	_, found = order.GetUserReference()
+	require.False(suite.T(), found)
+	_, found = (&OrderInfo{}).GetUserReference()
+	require.False(suite.T(), found)
+}
+
+// Test the order filters.
+//
+// The test will ensure:
+//   - Each filter selects the matching orders and never selects nil orders.
+//   - Pairs are matched with any of their names when aliases are provided.
+//   - MatchOrderInfo requires all filters to match.
+//   - FilterOrderInfos keeps the transaction IDs of the selected orders.
+func (suite *OrderFilterTestSuite) TestFilters() {
+	btc := &OrderInfo{Status: "open", UserReferenceId: "10", Description: OrderInfoDescription{Pair: "XBTUSD", Type: "buy", OrderType: "limit"}}
+	eth := &OrderInfo{Status: "closed", UserReferenceId: "200", Description: OrderInfoDescription{Pair: "ETHUSD", Type: "sell", OrderType: "stop-loss"}}
+	noref := &OrderInfo{Status: "canceled", Description: OrderInfoDescription{Pair: "XBTUSD", Type: "sell", OrderType: "market"}}
+	aliases := market.NewPairAliases(map[string]market.AssetPairInfo{"XXBTZUSD": {AlternativeName: "XBTUSD", WebsocketName: "XBT/USD"}})
+	for _, filter := range []OrderInfoFilter{
+		OnlyOrderPairs(nil), OnlyUserReferenceRange(0, 100), OnlyOrderTypes(Limit), OnlyOrderStatuses(Open), OnlyOrderSide(Buy),
+	} {
+		require.False(suite.T(), filter(nil))
+	}
+	require.True(suite.T(), OnlyOrderPairs(nil, "XBTUSD")(btc))
+	require.False(suite.T(), OnlyOrderPairs(nil, "XBT/USD")(btc))
+	require.True(suite.T(), OnlyOrderPairs(aliases, "XBT/USD")(btc))
+	require.True(suite.T(), OnlyOrderPairs(aliases, "XXBTZUSD", "ETHUSD")(eth))
+	require.True(suite.T(), OnlyUserReferenceRange(10, 100)(btc))
+	require.False(suite.T(), OnlyUserReferenceRange(10, 100)(eth))
+	require.False(suite.T(), OnlyUserReferenceRange(0, 100)(noref))
+	require.True(suite.T(), OnlyOrderTypes(Market, StopLoss)(eth))
+	require.False(suite.T(), OnlyOrderTypes(Market, StopLoss)(btc))
+	require.True(suite.T(), OnlyOrderStatuses(Closed, Canceled)(noref))
+	require.False(suite.T(), OnlyOrderStatuses(Closed, Canceled)(btc))
+	require.True(suite.T(), OnlyOrderSide(Sell)(eth))
+	require.False(suite.T(), OnlyOrderSide(Sell)(btc))
+	require.True(suite.T(), MatchOrderInfo(btc))
+	require.False(suite.T(), MatchOrderInfo(nil))
+	require.True(suite.T(), MatchOrderInfo(noref, OnlyOrderPairs(aliases, "XBT/USD"), OnlyOrderSide(Sell)))
+	require.False(suite.T(), MatchOrderInfo(btc, OnlyOrderPairs(aliases, "XBT/USD"), OnlyOrderSide(Sell)))
+	orders := map[string]*OrderInfo{"A": btc, "B": eth, "C": noref}
+	require.Equal(suite.T(), orders, FilterOrderInfos(orders))
+	require.Equal(suite.T(), map[string]*OrderInfo{"C": noref}, FilterOrderInfos(orders, OnlyOrderPairs(aliases, "XBT/USD"), OnlyOrderSide(Sell)))
+	require.Empty(suite.T(), FilterOrderInfos(orders, OnlyOrderStatuses(Expired)))
+}
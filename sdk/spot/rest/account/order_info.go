@@ -104,6 +104,8 @@ type OrderInfo struct {
 	ReferralOrderTransactionId string `json:"refid,omitempty"`
 	// Optional user defined reference ID
 	UserReferenceId json.Number `json:"userref,omitempty"`
+	// Optional client order ID
+	ClientOrderId string `json:"cl_ord_id,omitempty"`
 	// Status of order. Cf. OrderStatusEnum
 	Status string `json:"status"`
 	// Unix timestamp of when order was placed.
@@ -144,4 +146,6 @@ type OrderInfo struct {
 	CloseTimestamp json.Number `json:"closetm,omitempty"`
 	// Additional info on status if any
 	Reason string `json:"reason,omitempty"`
+	// True if the order has been amended. Cf. GetOrderAmends to get the amends history.
+	Amended bool `json:"amended,omitempty"`
 }
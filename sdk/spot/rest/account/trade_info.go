@@ -1,6 +1,9 @@
 package account
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strings"
+)
 
 // TradeInfo contains full trade information
 type TradeInfo struct {
@@ -12,9 +15,9 @@ type TradeInfo struct {
 	Pair string `json:"pair"`
 	// Unix timestamp for the trade
 	Timestamp json.Number `json:"time"`
-	// Trade direction (buy/sell)
+	// Trade direction (buy/sell). Cf. SideEnum for values and GetSide for the typed value.
 	Type string `json:"type"`
-	// Order type. Cf. OrderTypeEnum for values
+	// Order type. Cf. OrderTypeEnum for values and GetOrderType for the typed value.
 	OrderType string `json:"ordertype"`
 	// Average price order was executed at
 	Price json.Number `json:"price"`
@@ -55,4 +58,80 @@ type TradeInfo struct {
 	// List of closing trades for position (if available)
 	// - Only present if trade opened a position
 	ClosingTrades []string `json:"trades,omitempty"`
+	// True if the trade was executed as maker
+	Maker bool `json:"maker,omitempty"`
+	// IDs of the ledger entries related to the trade
+	// - Only present if ledgers info were requested
+	Ledgers []string `json:"ledgers,omitempty"`
+}
+
+// Get the trade direction. Unknown values are returned as is (Cf. SideEnum.IsKnown).
+func (trade *TradeInfo) GetSide() SideEnum {
+	if known := SideEnum(strings.ToLower(strings.TrimSpace(trade.Type))); known.IsKnown() {
+		return known
+	}
+	return SideEnum(trade.Type)
+}
+
+// Get the order type. Unknown values are returned as is (Cf. OrderTypeEnum.IsKnown).
+func (trade *TradeInfo) GetOrderType() OrderTypeEnum {
+	if known := OrderTypeEnum(strings.ToLower(strings.TrimSpace(trade.OrderType))); known.IsKnown() {
+		return known
+	}
+	return OrderTypeEnum(trade.OrderType)
+}
+
+// Return true if the side is one of the values known by the SDK.
+func (s SideEnum) IsKnown() bool {
+	return s == Buy || s == Sell
+}
+
+// Return true if the order type is one of the values known by the SDK.
+func (t OrderTypeEnum) IsKnown() bool {
+	switch t {
+	case Market, Limit, StopLoss, TakeProfit, StopLossLimit, TakeProfitLimit, SettlePosition:
+		return true
+	default:
+		return false
+	}
+}
+
+// Predicate used to select trades, for example in the options of the history streams. A filter
+// must return false for nil trades.
+type TradeInfoFilter func(trade *TradeInfo) bool
+
+// Build a filter which selects the trades made on the provided side.
+func OnlySide(side SideEnum) TradeInfoFilter {
+	return func(trade *TradeInfo) bool {
+		return trade != nil && trade.GetSide() == side
+	}
+}
+
+// Build a filter which selects the trades made on one of the provided pairs.
+func OnlyPairs(pairs ...string) TradeInfoFilter {
+	return func(trade *TradeInfo) bool {
+		if trade == nil {
+			return false
+		}
+		for _, pair := range pairs {
+			if trade.Pair == pair {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Return true if the trade is selected by all the provided filters. Any non-nil trade is selected
+// when no filter is provided.
+func MatchTradeInfo(trade *TradeInfo, filters ...TradeInfoFilter) bool {
+	if trade == nil {
+		return false
+	}
+	for _, filter := range filters {
+		if !filter(trade) {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,61 @@
+package account
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for TradeInfo enums and filters.
+type TradeInfoTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestTradeInfoTestSuite(t *testing.T) {
+	suite.Run(t, new(TradeInfoTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the typed side and order type of a trade.
+//
+// The test will ensure:
+//   - Known values are parsed case insensitively.
+//   - Unknown values are returned as is and are reported as unknown.
+func (suite *TradeInfoTestSuite) TestParse() {
+	trade := &TradeInfo{Type: "Buy", OrderType: "stop-loss-limit"}
+	require.Equal(suite.T(), Buy, trade.GetSide())
+	require.Equal(suite.T(), StopLossLimit, trade.GetOrderType())
+	trade = &TradeInfo{Type: "short", OrderType: "iceberg"}
+	require.Equal(suite.T(), SideEnum("short"), trade.GetSide())
+	require.False(suite.T(), trade.GetSide().IsKnown())
+	require.Equal(suite.T(), OrderTypeEnum("iceberg"), trade.GetOrderType())
+	require.False(suite.T(), trade.GetOrderType().IsKnown())
+}
+
+// Test the trade filters.
+//
+// The test will ensure:
+//   - OnlySide and OnlyPairs select the matching trades.
+//   - MatchTradeInfo requires all filters to match and never selects nil trades.
+func (suite *TradeInfoTestSuite) TestFilters() {
+	buy := &TradeInfo{Type: "buy", Pair: "XXBTZUSD"}
+	sell := &TradeInfo{Type: "sell", Pair: "XETHZUSD"}
+	require.True(suite.T(), OnlySide(Buy)(buy))
+	require.False(suite.T(), OnlySide(Buy)(sell))
+	require.False(suite.T(), OnlySide(Buy)(nil))
+	require.True(suite.T(), OnlyPairs("XETHZUSD", "XXBTZEUR")(sell))
+	require.False(suite.T(), OnlyPairs("XETHZUSD")(buy))
+	require.True(suite.T(), MatchTradeInfo(buy))
+	require.False(suite.T(), MatchTradeInfo(nil))
+	require.True(suite.T(), MatchTradeInfo(sell, OnlySide(Sell), OnlyPairs("XETHZUSD")))
+	require.False(suite.T(), MatchTradeInfo(sell, OnlySide(Sell), OnlyPairs("XXBTZUSD")))
+}
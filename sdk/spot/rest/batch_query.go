@@ -0,0 +1,158 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+)
+
+/*****************************************************************************/
+/* BATCH QUERIES: MODEL                                                      */
+/*****************************************************************************/
+
+// Maximum number of transaction IDs accepted by a single QueryOrdersInfo call.
+const MaxQueryOrdersInfoTxIds = 50
+
+// Maximum number of transaction IDs accepted by a single QueryTradesInfo call.
+const MaxQueryTradesInfoTxIds = 20
+
+/*****************************************************************************/
+/* BATCH QUERIES: FUNCTIONS                                                  */
+/*****************************************************************************/
+
+// # Description
+//
+// Retrieve information about any number of orders. Transaction IDs are split into chunks of
+// MaxQueryOrdersInfoTxIds IDs which are queried one after the other with QueryOrdersInfo and
+// results are merged into a single map. Chunks are not queried concurrently so nonces reach the
+// server in order.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - client: REST client used to query orders. Must not be nil.
+//   - nonceGenerator: Nonce generator used to sign requests. Must not be nil.
+//   - txIds: Transaction IDs of the orders. Duplicates are ignored.
+//   - opts: QueryOrdersInfo request options used for each chunk. A nil value triggers all default behaviors.
+//   - secopts: Optional security options. Can be nil if 2FA is not used.
+//   - limiter: Optional rate limiter waited before each chunk. Can be nil.
+//
+// # Return
+//
+// Orders by transaction ID and an error if a chunk could not be queried. In case of error, the
+// orders of the chunks queried so far are returned and remaining chunks are not queried.
+func QueryOrdersInfoBatch(
+	ctx context.Context,
+	client KrakenSpotRESTClientIface,
+	nonceGenerator noncegen.NonceGenerator,
+	txIds []string,
+	opts *account.QueryOrdersInfoRequestOptions,
+	secopts *common.SecurityOptions,
+	limiter RateLimiter) (map[string]*account.OrderInfo, error) {
+	return queryInBatches(ctx, txIds, MaxQueryOrdersInfoTxIds, limiter, func(ctx context.Context, chunk []string) (map[string]*account.OrderInfo, error) {
+		resp, _, err := client.QueryOrdersInfo(ctx, nonceGenerator.GenerateNonce(), account.QueryOrdersInfoParameters{TxId: chunk}, opts, secopts)
+		if err != nil {
+			return nil, fmt.Errorf("query orders info failed: %w", err)
+		}
+		if len(resp.Error) > 0 {
+			return nil, fmt.Errorf("query orders info failed: %v", resp.Error)
+		}
+		return resp.Result, nil
+	})
+}
+
+// # Description
+//
+// Retrieve information about any number of trades. Transaction IDs are split into chunks of
+// MaxQueryTradesInfoTxIds IDs which are queried one after the other with QueryTradesInfo and
+// results are merged into a single map. Chunks are not queried concurrently so nonces reach the
+// server in order.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - client: REST client used to query trades. Must not be nil.
+//   - nonceGenerator: Nonce generator used to sign requests. Must not be nil.
+//   - txIds: Transaction IDs of the trades. Duplicates are ignored.
+//   - opts: QueryTradesInfo request options used for each chunk. A nil value triggers all default behaviors.
+//   - secopts: Optional security options. Can be nil if 2FA is not used.
+//   - limiter: Optional rate limiter waited before each chunk. Can be nil.
+//
+// # Return
+//
+// Trades by transaction ID and an error if a chunk could not be queried. In case of error, the
+// trades of the chunks queried so far are returned and remaining chunks are not queried.
+func QueryTradesInfoBatch(
+	ctx context.Context,
+	client KrakenSpotRESTClientIface,
+	nonceGenerator noncegen.NonceGenerator,
+	txIds []string,
+	opts *account.QueryTradesRequestOptions,
+	secopts *common.SecurityOptions,
+	limiter RateLimiter) (map[string]*account.TradeInfo, error) {
+	return queryInBatches(ctx, txIds, MaxQueryTradesInfoTxIds, limiter, func(ctx context.Context, chunk []string) (map[string]*account.TradeInfo, error) {
+		resp, _, err := client.QueryTradesInfo(ctx, nonceGenerator.GenerateNonce(), account.QueryTradesRequestParameters{TransactionIds: chunk}, opts, secopts)
+		if err != nil {
+			return nil, fmt.Errorf("query trades info failed: %w", err)
+		}
+		if len(resp.Error) > 0 {
+			return nil, fmt.Errorf("query trades info failed: %v", resp.Error)
+		}
+		return resp.Result, nil
+	})
+}
+
+// # Description
+//
+// Split transaction IDs into chunks, query them one after the other and merge the results.
+//
+// # Inputs
+//
+//   - ctx: Context used for coordination purpose. Remaining chunks are not queried once the context is done.
+//   - txIds: Transaction IDs to query. Duplicates are ignored.
+//   - size: Maximum number of transaction IDs in a chunk.
+//   - limiter: Optional rate limiter waited before each chunk.
+//   - query: Function used to query a chunk.
+//
+// # Return
+//
+// Merged results and an error if a chunk could not be queried.
+func queryInBatches[T any](
+	ctx context.Context,
+	txIds []string,
+	size int,
+	limiter RateLimiter,
+	query func(ctx context.Context, chunk []string) (map[string]*T, error)) (map[string]*T, error) {
+	// Remove duplicates while keeping the order of the transaction IDs
+	unique := make([]string, 0, len(txIds))
+	seen := make(map[string]bool, len(txIds))
+	for _, txId := range txIds {
+		if !seen[txId] {
+			seen[txId] = true
+			unique = append(unique, txId)
+		}
+	}
+	results := make(map[string]*T, len(unique))
+	for start := 0; start < len(unique); start += size {
+		end := min(start+size, len(unique))
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return results, err
+			}
+		}
+		chunk, err := query(ctx, unique[start:end])
+		if err != nil {
+			return results, fmt.Errorf("chunk %d (transaction IDs %d to %d) failed: %w", start/size, start, end-1, err)
+		}
+		for txId, result := range chunk {
+			results[txId] = result
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,99 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for QueryOrdersInfoBatch and QueryTradesInfoBatch
+type BatchQueryTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestBatchQueryTestSuite(t *testing.T) {
+	suite.Run(t, new(BatchQueryTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test QueryOrdersInfoBatch.
+//
+// Test will ensure:
+//   - Transaction IDs are deduplicated and split into chunks of MaxQueryOrdersInfoTxIds IDs.
+//   - Results of all chunks are merged.
+//   - The rate limiter is waited before each chunk.
+func (suite *BatchQueryTestSuite) TestQueryOrdersInfoBatch() {
+	client := NewMockKrakenSpotRESTClient()
+	txIds := newTxIds(120)
+	for _, chunk := range [][]string{txIds[:50], txIds[50:100], txIds[100:]} {
+		result := map[string]*account.OrderInfo{}
+		for _, txId := range chunk {
+			result[txId] = &account.OrderInfo{Status: "closed"}
+		}
+		client.On("QueryOrdersInfo", mock.Anything, mock.Anything, account.QueryOrdersInfoParameters{TxId: chunk}, mock.Anything, mock.Anything).
+			Return(&account.QueryOrdersInfoResponse{Result: result}, nil, nil)
+	}
+	txIds = append(txIds, txIds[0])
+	limiter := &countingRateLimiter{}
+	orders, err := QueryOrdersInfoBatch(context.Background(), client, noncegen.NewHFNonceGenerator(), txIds, &account.QueryOrdersInfoRequestOptions{Trades: true}, nil, limiter)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), orders, 120)
+	require.Equal(suite.T(), "closed", orders["T119"].Status)
+	client.AssertNumberOfCalls(suite.T(), "QueryOrdersInfo", 3)
+	for _, call := range client.Calls {
+		require.True(suite.T(), call.Arguments.Get(3).(*account.QueryOrdersInfoRequestOptions).Trades)
+	}
+	require.Equal(suite.T(), 3, limiter.calls)
+}
+
+// Test QueryTradesInfoBatch.
+//
+// Test will ensure:
+//   - Transaction IDs are split into chunks of MaxQueryTradesInfoTxIds IDs.
+//   - Querying stops at the first failed chunk and results of previous chunks are returned.
+//   - An empty list of transaction IDs does not trigger any call.
+func (suite *BatchQueryTestSuite) TestQueryTradesInfoBatch() {
+	client := NewMockKrakenSpotRESTClient()
+	client.On("QueryTradesInfo", mock.Anything, mock.Anything, account.QueryTradesRequestParameters{TransactionIds: newTxIds(20)}, mock.Anything, mock.Anything).
+		Return(&account.QueryTradesInfoResponse{Result: map[string]*account.TradeInfo{"T0": {Pair: "XXBTZUSD"}}}, nil, nil)
+	client.On("QueryTradesInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&account.QueryTradesInfoResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{"EAPI:Rate limit exceeded"}}}, nil, nil)
+	trades, err := QueryTradesInfoBatch(context.Background(), client, noncegen.NewHFNonceGenerator(), newTxIds(50), nil, nil, nil)
+	require.Error(suite.T(), err)
+	require.Contains(suite.T(), err.Error(), "Rate limit exceeded")
+	require.Equal(suite.T(), "XXBTZUSD", trades["T0"].Pair)
+	client.AssertNumberOfCalls(suite.T(), "QueryTradesInfo", 2)
+	// Empty list
+	trades, err = QueryTradesInfoBatch(context.Background(), client, noncegen.NewHFNonceGenerator(), nil, nil, nil, nil)
+	require.NoError(suite.T(), err)
+	require.Empty(suite.T(), trades)
+	client.AssertNumberOfCalls(suite.T(), "QueryTradesInfo", 2)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build n transaction IDs: T0, T1, ...
+func newTxIds(n int) []string {
+	txIds := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		txIds = append(txIds, fmt.Sprintf("T%d", i))
+	}
+	return txIds
+}
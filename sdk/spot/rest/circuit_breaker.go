@@ -0,0 +1,332 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/clienttag"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+/*****************************************************************************/
+/* CIRCUIT BREAKER: MODEL                                                    */
+/*****************************************************************************/
+
+// Name of the metric which records the state of the REST client circuit breaker: 0 for closed,
+// 1 for half-open and 2 for open. Each client reports its state with the CircuitBreakerIdAttribute
+// attribute and, if set, with the client tag attribute (Cf. clienttag.AttributeKey).
+const CircuitBreakerStateMetricName = "goctopus.sdk.spot.rest.circuit_breaker.state"
+
+// Key of the metric attribute which identifies the circuit breaker of a client in the process.
+const CircuitBreakerIdAttribute = attribute.Key("goctopus.sdk.spot.rest.circuit_breaker.id")
+
+// Last circuit breaker ID
+var circuitBreakerIds atomic.Int64
+
+// Default values for CircuitBreakerConfiguration
+const (
+	DefaultCircuitBreakerFailureThreshold = 5
+	DefaultCircuitBreakerCoolDown         = 30 * time.Second
+	DefaultCircuitBreakerHalfOpenProbes   = 1
+)
+
+// Enum for the states of the circuit breaker.
+type CircuitStateEnum string
+
+// Values for CircuitStateEnum
+const (
+	// Requests are sent.
+	CircuitClosed CircuitStateEnum = "closed"
+	// Requests fail fast with a CircuitOpenError until the cool-down period is over.
+	CircuitOpen CircuitStateEnum = "open"
+	// A limited number of probe requests are sent: the circuit closes if a probe succeeds and
+	// opens again if a probe fails. Other requests fail fast with a CircuitOpenError.
+	CircuitHalfOpen CircuitStateEnum = "half_open"
+)
+
+// Settings for the circuit breaker of KrakenSpotRESTClient.
+//
+// Transport errors, responses with a 5xx status code (including the 52x status codes used by
+// Cloudflare) and responses with an unexpected content type (ex: HTML error pages) are failures.
+// Other responses, including responses with API errors, are successes. Requests aborted because
+// their context is done are ignored.
+type CircuitBreakerConfiguration struct {
+	// Number of consecutive failures which opens the circuit. Defaults to
+	// DefaultCircuitBreakerFailureThreshold if zero or negative.
+	FailureThreshold int
+	// Duration during which requests fail fast once the circuit is open. Defaults to
+	// DefaultCircuitBreakerCoolDown if zero or negative.
+	CoolDown time.Duration
+	// Maximum number of concurrent probe requests once the cool-down period is over. Defaults to
+	// DefaultCircuitBreakerHalfOpenProbes if zero or negative.
+	HalfOpenProbes int
+	// Optional logger used to report errors which occur when the state metric is set up. If nil,
+	// the standard logger is used.
+	Logger *log.Logger
+	// Optional clock used to manage the cool-down period. If nil, the system clock is used.
+	Clock clock.Clock
+}
+
+// Error returned by KrakenSpotRESTClient when a request is not sent because the circuit is open.
+type CircuitOpenError struct {
+	// State of the circuit: open or half-open (all probes in flight)
+	State CircuitStateEnum
+	// Duration before probe requests are allowed. Zero when the circuit is half-open.
+	RetryAfter time.Duration
+}
+
+// Format the error message
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("request not sent: circuit breaker is %s, retry after %s", e.State, e.RetryAfter)
+}
+
+// State and counters of the circuit breaker.
+type CircuitBreakerStats struct {
+	// Current state
+	State CircuitStateEnum
+	// Number of consecutive failures
+	ConsecutiveFailures int
+	// Number of times the circuit has opened
+	Opened uint64
+	// Number of requests which failed fast because the circuit was open
+	Rejected uint64
+}
+
+// Circuit breaker used by KrakenSpotRESTClient.
+type circuitBreaker struct {
+	// Mutex used to protect the circuit breaker state
+	mu sync.Mutex
+	// Settings
+	cfg CircuitBreakerConfiguration
+	// Clock used to manage the cool-down period
+	clock clock.Clock
+	// Current state
+	state CircuitStateEnum
+	// Number of consecutive failures
+	failures int
+	// Time when the circuit has opened
+	openedAt time.Time
+	// Number of probe requests in flight
+	probes int
+	// Counters
+	opened   uint64
+	rejected uint64
+	// Registration of the callback which reports the state metric. Nil if the metric could not be
+	// set up or once the circuit breaker has been closed.
+	registration metric.Registration
+}
+
+/*****************************************************************************/
+/* CIRCUIT BREAKER: FUNCTIONS                                                */
+/*****************************************************************************/
+
+// # Description
+//
+// Build a new circuit breaker with a closed circuit and register a callback which reports its
+// state with the state metric. The callback must be unregistered with close once the circuit
+// breaker is not used anymore.
+//
+// # Inputs
+//
+//   - cfg: Circuit breaker settings. Default values are used for unset settings.
+//   - clientTag: Tag of the client which uses the circuit breaker. Empty if not set.
+//   - meterProvider: Meter provider used to create the state metric. If nil, the global meter
+//     provider is used.
+//
+// # Return
+//
+// A new circuit breaker.
+func newCircuitBreaker(cfg CircuitBreakerConfiguration, clientTag string, meterProvider metric.MeterProvider) *circuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = DefaultCircuitBreakerFailureThreshold
+	}
+	if cfg.CoolDown <= 0 {
+		cfg.CoolDown = DefaultCircuitBreakerCoolDown
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = DefaultCircuitBreakerHalfOpenProbes
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Default()
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = clock.NewSystemClock()
+	}
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+	cb := &circuitBreaker{cfg: cfg, clock: cfg.Clock, state: CircuitClosed}
+	// Metric is best effort: the circuit breaker works without it.
+	meter := meterProvider.Meter(tracing.PackageName, metric.WithInstrumentationVersion(tracing.PackageVersion))
+	gauge, err := meter.Int64ObservableGauge(
+		CircuitBreakerStateMetricName,
+		metric.WithDescription("State of the REST client circuit breaker: 0 for closed, 1 for half-open and 2 for open"))
+	if err != nil {
+		cfg.Logger.Println("failed to create circuit breaker state gauge:", err.Error())
+		return cb
+	}
+	attrs := []attribute.KeyValue{CircuitBreakerIdAttribute.Int64(circuitBreakerIds.Add(1))}
+	if clientTag != "" {
+		attrs = append(attrs, clienttag.AttributeKey.String(clientTag))
+	}
+	opt := metric.WithAttributes(attrs...)
+	registration, err := meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveInt64(gauge, cb.stateValue(), opt)
+		return nil
+	}, gauge)
+	if err != nil {
+		cfg.Logger.Println("failed to register circuit breaker state callback:", err.Error())
+		return cb
+	}
+	cb.registration = registration
+	return cb
+}
+
+// Unregister the callback which reports the state metric. The circuit breaker keeps working.
+func (cb *circuitBreaker) close() error {
+	cb.mu.Lock()
+	registration := cb.registration
+	cb.registration = nil
+	cb.mu.Unlock()
+	if registration == nil {
+		return nil
+	}
+	return registration.Unregister()
+}
+
+// Get the value reported by the state metric: 0 for closed, 1 for half-open and 2 for open.
+func (cb *circuitBreaker) stateValue() int64 {
+	switch cb.stats().State {
+	case CircuitHalfOpen:
+		return 1
+	case CircuitOpen:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// # Description
+//
+// Check whether a request can be sent.
+//
+// # Return
+//
+// True if the request is a probe, and a CircuitOpenError if the request must not be sent.
+func (cb *circuitBreaker) allow() (bool, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.state == CircuitOpen {
+		elapsed := cb.clock.Now().Sub(cb.openedAt)
+		if elapsed < cb.cfg.CoolDown {
+			cb.rejected++
+			return false, &CircuitOpenError{State: CircuitOpen, RetryAfter: cb.cfg.CoolDown - elapsed}
+		}
+		cb.state = CircuitHalfOpen
+	}
+	if cb.state == CircuitHalfOpen {
+		if cb.probes >= cb.cfg.HalfOpenProbes {
+			cb.rejected++
+			return false, &CircuitOpenError{State: CircuitHalfOpen}
+		}
+		cb.probes++
+		return true, nil
+	}
+	return false, nil
+}
+
+// # Description
+//
+// Record the outcome of a request which has been allowed.
+//
+// # Inputs
+//
+//   - probe: True if the request was a probe.
+//   - failed: True if the request failed.
+func (cb *circuitBreaker) record(probe bool, failed bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if probe {
+		cb.probes--
+	}
+	if !failed {
+		cb.failures = 0
+		if probe {
+			cb.state = CircuitClosed
+		}
+		return
+	}
+	cb.failures++
+	if (probe && cb.state == CircuitHalfOpen) || (cb.state == CircuitClosed && cb.failures >= cb.cfg.FailureThreshold) {
+		cb.state = CircuitOpen
+		cb.openedAt = cb.clock.Now()
+		cb.opened++
+	}
+}
+
+// Get the state and counters of the circuit breaker.
+func (cb *circuitBreaker) stats() CircuitBreakerStats {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	state := cb.state
+	if state == CircuitOpen && cb.clock.Now().Sub(cb.openedAt) >= cb.cfg.CoolDown {
+		state = CircuitHalfOpen
+	}
+	return CircuitBreakerStats{
+		State:               state,
+		ConsecutiveFailures: cb.failures,
+		Opened:              cb.opened,
+		Rejected:            cb.rejected,
+	}
+}
+
+// # Description
+//
+// Get the state and counters of the client circuit breaker. A closed circuit without any failure
+// is reported if the circuit breaker is not configured (Cf. KrakenSpotRESTClientConfiguration).
+//
+// # Return
+//
+// The state and counters of the circuit breaker.
+func (client *KrakenSpotRESTClient) GetCircuitBreakerStats() CircuitBreakerStats {
+	if client.breaker == nil {
+		return CircuitBreakerStats{State: CircuitClosed}
+	}
+	return client.breaker.stats()
+}
+
+// # Description
+//
+// Release the resources held by the client: the callback which reports the state of the circuit
+// breaker is unregistered from the meter provider so the client can be garbage collected. The
+// client can still be used but its circuit breaker state is not reported anymore.
+//
+// # Return
+//
+// An error if the callback could not be unregistered.
+func (client *KrakenSpotRESTClient) Close() error {
+	if client.breaker == nil {
+		return nil
+	}
+	return client.breaker.close()
+}
+
+// Tell whether the outcome of a request is a failure for the circuit breaker: transport errors,
+// 5xx status codes and unexpected content types are failures, requests aborted because their
+// context is done are not.
+func isCircuitBreakerFailure(ctx context.Context, resp *http.Response, err error) bool {
+	if err == nil || ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return resp == nil || resp.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,293 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/gosette"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/clienttag"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/embedded"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the REST client circuit breaker
+type CircuitBreakerTestSuite struct {
+	suite.Suite
+	// Mock HTTP server
+	srv *gosette.HTTPTestServer
+}
+
+// Run unit test suite
+func TestCircuitBreakerTestSuite(t *testing.T) {
+	tstsrv := gosette.NewHTTPTestServer(nil)
+	tstsrv.Start()
+	defer tstsrv.Close()
+	suite.Run(t, &CircuitBreakerTestSuite{srv: tstsrv})
+}
+
+// Clean the server predefined responses and records before each test.
+func (suite *CircuitBreakerTestSuite) BeforeTest(suiteName, testName string) {
+	suite.srv.Clear()
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the circuit breaker state machine.
+//
+// Test will ensure:
+//   - The circuit opens after the configured number of consecutive 5xx failures.
+//   - Requests fail fast with a CircuitOpenError during the cool-down period and are not sent.
+//   - A failed probe opens the circuit again.
+//   - A successful probe closes the circuit.
+//   - Responses with API errors are not failures.
+func (suite *CircuitBreakerTestSuite) TestCircuitBreaker() {
+	fake := clock.NewFakeClock(time.Now())
+	client := suite.newClient(&CircuitBreakerConfiguration{FailureThreshold: 2, CoolDown: time.Minute, Clock: fake})
+	// Failures open the circuit
+	suite.pushResponse(http.StatusBadGateway, `<html>Bad gateway</html>`, "text/html")
+	_, _, err := client.GetServerTime(context.Background())
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), CircuitBreakerStats{State: CircuitClosed, ConsecutiveFailures: 1}, client.GetCircuitBreakerStats())
+	_, _, err = client.GetServerTime(context.Background())
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), CircuitOpen, client.GetCircuitBreakerStats().State)
+	// Fail fast
+	fake.Advance(10 * time.Second)
+	_, _, err = client.GetServerTime(context.Background())
+	coerr := new(CircuitOpenError)
+	require.True(suite.T(), errors.As(err, &coerr))
+	require.Equal(suite.T(), CircuitOpen, coerr.State)
+	require.Equal(suite.T(), 50*time.Second, coerr.RetryAfter)
+	require.NotNil(suite.T(), suite.srv.PopServerRecord())
+	require.NotNil(suite.T(), suite.srv.PopServerRecord())
+	require.Nil(suite.T(), suite.srv.PopServerRecord())
+	// Failed probe
+	fake.Advance(time.Minute)
+	require.Equal(suite.T(), CircuitHalfOpen, client.GetCircuitBreakerStats().State)
+	_, _, err = client.GetServerTime(context.Background())
+	require.Error(suite.T(), err)
+	require.False(suite.T(), errors.As(err, &coerr))
+	require.Equal(suite.T(), CircuitOpen, client.GetCircuitBreakerStats().State)
+	// Successful probe
+	fake.Advance(time.Minute)
+	suite.srv.Clear()
+	suite.pushResponse(http.StatusOK, `{"error":["EGeneral:Internal error"]}`, "application/json")
+	_, _, err = client.GetServerTime(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), CircuitBreakerStats{State: CircuitClosed, Opened: 2, Rejected: 1}, client.GetCircuitBreakerStats())
+}
+
+// Test the circuit breaker in half-open state and when it is disabled.
+//
+// Test will ensure:
+//   - Only the configured number of probes are sent while the circuit is half-open.
+//   - Requests aborted because their context is done are not failures.
+//   - The circuit breaker is disabled by default.
+func (suite *CircuitBreakerTestSuite) TestHalfOpenAndDisabled() {
+	fake := clock.NewFakeClock(time.Now())
+	client := suite.newClient(&CircuitBreakerConfiguration{FailureThreshold: 1, CoolDown: time.Minute, Clock: fake})
+	client.breaker.record(false, true)
+	fake.Advance(time.Minute)
+	probe, err := client.breaker.allow()
+	require.NoError(suite.T(), err)
+	require.True(suite.T(), probe)
+	_, err = client.breaker.allow()
+	coerr := new(CircuitOpenError)
+	require.True(suite.T(), errors.As(err, &coerr))
+	require.Equal(suite.T(), CircuitHalfOpen, coerr.State)
+	// Aborted requests
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.False(suite.T(), isCircuitBreakerFailure(ctx, nil, ctx.Err()))
+	require.True(suite.T(), isCircuitBreakerFailure(context.Background(), nil, errors.New("connection refused")))
+	require.False(suite.T(), isCircuitBreakerFailure(context.Background(), &http.Response{StatusCode: http.StatusTooManyRequests}, errors.New("429")))
+	// Disabled
+	client = suite.newClient(nil)
+	suite.pushResponse(http.StatusServiceUnavailable, ``, "text/html")
+	for i := 0; i < DefaultCircuitBreakerFailureThreshold+1; i++ {
+		_, _, err = client.GetServerTime(context.Background())
+		require.Error(suite.T(), err)
+		require.False(suite.T(), errors.As(err, &coerr))
+	}
+	require.Equal(suite.T(), CircuitBreakerStats{State: CircuitClosed}, client.GetCircuitBreakerStats())
+}
+
+// Test the circuit breaker state metric.
+//
+// Test will ensure:
+//   - The state metric is created with the meter provider set in the client configuration.
+//   - Each client registers its own callback with its circuit breaker ID and client tag.
+//   - The callback reports the state of the circuit breaker.
+//   - Close unregisters the callback and can be called several times.
+func (suite *CircuitBreakerTestSuite) TestStateMetric() {
+	meter := &recordingMeter{callbacks: map[*recordingRegistration]metric.Callback{}}
+	provider := &recordingMeterProvider{meter: meter}
+	first := NewKrakenSpotRESTClient(nil, &KrakenSpotRESTClientConfiguration{
+		BaseURL:        suite.srv.GetBaseURL(),
+		Agent:          usrAgent,
+		ClientTag:      "first",
+		CircuitBreaker: &CircuitBreakerConfiguration{FailureThreshold: 1},
+		MeterProvider:  provider,
+	})
+	second := NewKrakenSpotRESTClient(nil, &KrakenSpotRESTClientConfiguration{
+		BaseURL:        suite.srv.GetBaseURL(),
+		Agent:          usrAgent,
+		ClientTag:      "second",
+		CircuitBreaker: &CircuitBreakerConfiguration{FailureThreshold: 1},
+		MeterProvider:  provider,
+	})
+	first.breaker.record(false, true)
+	observations := meter.collect()
+	require.Contains(suite.T(), observations, "first")
+	require.Contains(suite.T(), observations, "second")
+	firstId, _ := observations["first"].Value(CircuitBreakerIdAttribute)
+	secondId, _ := observations["second"].Value(CircuitBreakerIdAttribute)
+	require.NotEqual(suite.T(), firstId.AsInt64(), secondId.AsInt64())
+	require.Equal(suite.T(), int64(2), meter.values["first"])
+	require.Equal(suite.T(), int64(0), meter.values["second"])
+	// Close
+	require.NoError(suite.T(), first.Close())
+	require.NoError(suite.T(), first.Close())
+	observations = meter.collect()
+	require.NotContains(suite.T(), observations, "first")
+	require.Contains(suite.T(), observations, "second")
+	require.NoError(suite.T(), second.Close())
+	require.NotContains(suite.T(), meter.collect(), "second")
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build a client which uses the test server and the provided circuit breaker settings. The
+// client is closed once the test is over.
+func (suite *CircuitBreakerTestSuite) newClient(cfg *CircuitBreakerConfiguration) *KrakenSpotRESTClient {
+	client := NewKrakenSpotRESTClient(nil, &KrakenSpotRESTClientConfiguration{
+		BaseURL:        suite.srv.GetBaseURL(),
+		Agent:          usrAgent,
+		CircuitBreaker: cfg,
+	})
+	suite.T().Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// Push a predefined response to the test server.
+func (suite *CircuitBreakerTestSuite) pushResponse(status int, body string, contentType string) {
+	suite.srv.PushPredefinedServerResponse(&gosette.PredefinedServerResponse{
+		Status:  status,
+		Headers: http.Header{"Content-Type": []string{contentType}},
+		Body:    []byte(body),
+	})
+}
+
+// Meter provider which provides a recordingMeter.
+type recordingMeterProvider struct {
+	noop.MeterProvider
+	meter *recordingMeter
+}
+
+// Return the recording meter.
+func (p *recordingMeterProvider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return p.meter
+}
+
+// Meter which records the registered callbacks so they can be run by the tests.
+type recordingMeter struct {
+	noop.Meter
+	mu        sync.Mutex
+	callbacks map[*recordingRegistration]metric.Callback
+	// Last observed values by client tag
+	values map[string]int64
+	// Sum of the values added to the counters by kind attribute
+	counts map[string]int64
+}
+
+// Return a counter which records the added values in the meter.
+func (m *recordingMeter) Int64Counter(name string, options ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return &recordingCounter{meter: m}, nil
+}
+
+// Record the callback.
+func (m *recordingMeter) RegisterCallback(f metric.Callback, instruments ...metric.Observable) (metric.Registration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	registration := &recordingRegistration{meter: m}
+	m.callbacks[registration] = f
+	return registration, nil
+}
+
+// Run the registered callbacks and return the observed attributes by client tag. The observed
+// values are stored in values.
+func (m *recordingMeter) collect() map[string]*attribute.Set {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	o := &recordingObserver{attrs: map[string]*attribute.Set{}, values: map[string]int64{}}
+	for _, f := range m.callbacks {
+		_ = f(context.Background(), o)
+	}
+	m.values = o.values
+	return o.attrs
+}
+
+// Registration of a callback with a recordingMeter.
+type recordingRegistration struct {
+	embedded.Registration
+	meter *recordingMeter
+}
+
+// Remove the callback from the meter.
+func (r *recordingRegistration) Unregister() error {
+	r.meter.mu.Lock()
+	defer r.meter.mu.Unlock()
+	delete(r.meter.callbacks, r)
+	return nil
+}
+
+// Observer which records the observed values and attributes by client tag.
+type recordingObserver struct {
+	embedded.Observer
+	attrs  map[string]*attribute.Set
+	values map[string]int64
+}
+
+// Ignored.
+func (o *recordingObserver) ObserveFloat64(obsrv metric.Float64Observable, value float64, opts ...metric.ObserveOption) {
+}
+
+// Record the value and the attributes.
+func (o *recordingObserver) ObserveInt64(obsrv metric.Int64Observable, value int64, opts ...metric.ObserveOption) {
+	attrs := metric.NewObserveConfig(opts).Attributes()
+	tag, _ := attrs.Value(clienttag.AttributeKey)
+	o.attrs[tag.AsString()] = &attrs
+	o.values[tag.AsString()] = value
+}
+
+// Counter created by a recordingMeter.
+type recordingCounter struct {
+	noop.Int64Counter
+	meter *recordingMeter
+}
+
+// Record the added value by kind attribute.
+func (c *recordingCounter) Add(ctx context.Context, incr int64, options ...metric.AddOption) {
+	attrs := metric.NewAddConfig(options).Attributes()
+	kind, _ := attrs.Value("kind")
+	c.meter.mu.Lock()
+	defer c.meter.mu.Unlock()
+	c.meter.counts[kind.AsString()] += incr
+}
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -17,6 +18,27 @@ type KrakenSpotRESTResponse struct {
 	Error []string `json:"error"`
 	// Result for the request
 	Result interface{} `json:"result,omitempty"`
+	// Raw JSON values of the fields which are unknown to the response model or which could not be
+	// decoded, by path (ex: "result.XXBTZUSD.new_field"). Only populated by clients which use lax
+	// decoding. Nil if no schema drift has been detected.
+	RawExtra map[string]json.RawMessage `json:"-"`
+}
+
+// Get the errors returned with the response.
+func (resp *KrakenSpotRESTResponse) GetErrors() []string {
+	return resp.Error
+}
+
+// Get the raw JSON values of the fields which are unknown to the response model or which could
+// not be decoded, by path.
+func (resp *KrakenSpotRESTResponse) GetRawExtra() map[string]json.RawMessage {
+	return resp.RawExtra
+}
+
+// Set the raw JSON values of the fields which are unknown to the response model or which could
+// not be decoded, by path.
+func (resp *KrakenSpotRESTResponse) SetRawExtra(extra map[string]json.RawMessage) {
+	resp.RawExtra = extra
 }
 
 // Container for security options to use during the API call (2FA, ...)
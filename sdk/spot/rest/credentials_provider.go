@@ -0,0 +1,224 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Default environment variables used by EnvCredentialsProvider.
+const (
+	// API key
+	DefaultEnvAPIKey = "KRAKEN_API_KEY"
+	// Base64 encoded API secret
+	DefaultEnvAPISecret = "KRAKEN_API_SECRET"
+)
+
+// API credentials used to sign requests to private endpoints.
+type Credentials struct {
+	// API key
+	Key string `json:"key" yaml:"key"`
+	// Base64 encoded API secret (the value displayed when creating the API key)
+	Secret string `json:"secret" yaml:"secret"`
+}
+
+// Interface for a component which provides API credentials.
+//
+// Providers are called each time credentials are (re)loaded: a provider must return the current
+// credentials so a new key/secret can be picked up without restarting the client (Cf.
+// RotatingKrakenSpotRESTClientAuthorizer).
+type CredentialsProviderIface interface {
+	// # Description
+	//
+	// Get the current API credentials.
+	//
+	// # Inputs
+	//
+	//	- ctx: Context used for tracing and coordination purpose.
+	//
+	// # Returns
+	//
+	//	- The current credentials.
+	//	- An error if credentials could not be retrieved.
+	GetCredentials(ctx context.Context) (*Credentials, error)
+}
+
+/*************************************************************************************************/
+/* FUNC PROVIDER                                                                                 */
+/*************************************************************************************************/
+
+// # Description
+//
+// Adapter which allows the use of a function as a CredentialsProviderIface.
+//
+// It can be used to plug a secret manager (AWS Secrets Manager, Vault, ...) without adding its
+// SDK as a dependency of this module:
+//
+//	provider := rest.CredentialsProviderFunc(func(ctx context.Context) (*rest.Credentials, error) {
+//		secret, err := vaultClient.KVv2("secret").Get(ctx, "kraken")
+//		if err != nil {
+//			return nil, err
+//		}
+//		return &rest.Credentials{Key: secret.Data["key"].(string), Secret: secret.Data["secret"].(string)}, nil
+//	})
+type CredentialsProviderFunc func(ctx context.Context) (*Credentials, error)
+
+// Call the function.
+func (f CredentialsProviderFunc) GetCredentials(ctx context.Context) (*Credentials, error) {
+	return f(ctx)
+}
+
+/*************************************************************************************************/
+/* ENV PROVIDER                                                                                  */
+/*************************************************************************************************/
+
+// Provider which reads API credentials from environment variables.
+type EnvCredentialsProvider struct {
+	// Environment variable which contains the API key
+	keyVar string
+	// Environment variable which contains the base64 encoded API secret
+	secretVar string
+	// Function used to read environment variables - Can be replaced for testing purpose.
+	getenv func(string) string
+}
+
+// # Description
+//
+// Build a new EnvCredentialsProvider.
+//
+// # Inputs
+//
+//   - keyVar: Environment variable which contains the API key. If empty, DefaultEnvAPIKey is used.
+//   - secretVar: Environment variable which contains the base64 encoded API secret. If empty,
+//     DefaultEnvAPISecret is used.
+//
+// # Returns
+//
+// The new provider.
+func NewEnvCredentialsProvider(keyVar string, secretVar string) *EnvCredentialsProvider {
+	if keyVar == "" {
+		keyVar = DefaultEnvAPIKey
+	}
+	if secretVar == "" {
+		secretVar = DefaultEnvAPISecret
+	}
+	return &EnvCredentialsProvider{
+		keyVar:    keyVar,
+		secretVar: secretVar,
+		getenv:    os.Getenv,
+	}
+}
+
+// Read the API credentials from the environment variables. An error is returned if one of the
+// variables is not set or empty.
+func (p *EnvCredentialsProvider) GetCredentials(ctx context.Context) (*Credentials, error) {
+	creds := &Credentials{Key: p.getenv(p.keyVar), Secret: p.getenv(p.secretVar)}
+	if creds.Key == "" || creds.Secret == "" {
+		return nil, fmt.Errorf("environment variables %s and %s must be set", p.keyVar, p.secretVar)
+	}
+	return creds, nil
+}
+
+/*************************************************************************************************/
+/* FILE PROVIDER                                                                                 */
+/*************************************************************************************************/
+
+// Provider which reads API credentials from a JSON or YAML file with a key and a secret field.
+//
+// The file is read each time credentials are requested so a new key/secret written to the file
+// (ex: by a secret manager agent or a mounted Kubernetes secret) is picked up.
+type FileCredentialsProvider struct {
+	// Path to the file
+	path string
+}
+
+// # Description
+//
+// Build a new FileCredentialsProvider.
+//
+// # Inputs
+//
+//   - path: Path to the file. Files with a .yaml or .yml extension are parsed as YAML, other
+//     files are parsed as JSON.
+//
+// # Returns
+//
+// The new provider.
+func NewFileCredentialsProvider(path string) *FileCredentialsProvider {
+	return &FileCredentialsProvider{path: path}
+}
+
+// Read the API credentials from the file. An error is returned if the file cannot be read or
+// parsed or if the key or the secret is empty.
+func (p *FileCredentialsProvider) GetCredentials(ctx context.Context) (*Credentials, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read credentials file: %w", err)
+	}
+	creds := new(Credentials)
+	switch strings.ToLower(filepath.Ext(p.path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, creds)
+	default:
+		err = json.Unmarshal(data, creds)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse credentials file %s: %w", p.path, err)
+	}
+	if creds.Key == "" || creds.Secret == "" {
+		return nil, fmt.Errorf("credentials file %s must contain a key and a secret", p.path)
+	}
+	return creds, nil
+}
+
+/*************************************************************************************************/
+/* CHAIN PROVIDER                                                                                */
+/*************************************************************************************************/
+
+// Provider which tries a list of providers in order and returns the credentials of the first
+// provider which succeeds.
+type ChainCredentialsProvider struct {
+	// Providers to try, in order
+	providers []CredentialsProviderIface
+}
+
+// # Description
+//
+// Build a new ChainCredentialsProvider.
+//
+// # Inputs
+//
+//   - providers: Providers to try, in order. Nil providers are ignored.
+//
+// # Returns
+//
+// The new provider.
+func NewChainCredentialsProvider(providers ...CredentialsProviderIface) *ChainCredentialsProvider {
+	chain := &ChainCredentialsProvider{providers: []CredentialsProviderIface{}}
+	for _, provider := range providers {
+		if provider != nil {
+			chain.providers = append(chain.providers, provider)
+		}
+	}
+	return chain
+}
+
+// Return the credentials of the first provider which succeeds. If all providers fail, the
+// returned error wraps the errors of all providers.
+func (p *ChainCredentialsProvider) GetCredentials(ctx context.Context) (*Credentials, error) {
+	errs := []error{}
+	for _, provider := range p.providers {
+		creds, err := provider.GetCredentials(ctx)
+		if err == nil {
+			return creds, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("no credentials provider succeeded: %w", errors.Join(errs...))
+}
@@ -0,0 +1,98 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the credentials providers
+type CredentialsProviderTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestCredentialsProviderTestSuite(t *testing.T) {
+	suite.Run(t, new(CredentialsProviderTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test EnvCredentialsProvider with default and custom variables.
+func (suite *CredentialsProviderTestSuite) TestEnvCredentialsProvider() {
+	env := map[string]string{}
+	provider := NewEnvCredentialsProvider("", "")
+	provider.getenv = func(key string) string { return env[key] }
+	// Missing variables
+	_, err := provider.GetCredentials(context.Background())
+	require.ErrorContains(suite.T(), err, DefaultEnvAPIKey)
+	// Default variables
+	env[DefaultEnvAPIKey] = "key"
+	env[DefaultEnvAPISecret] = "c2VjcmV0"
+	creds, err := provider.GetCredentials(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), &Credentials{Key: "key", Secret: "c2VjcmV0"}, creds)
+	// Custom variables
+	provider = NewEnvCredentialsProvider("MY_KEY", "MY_SECRET")
+	provider.getenv = func(key string) string { return env[key] }
+	_, err = provider.GetCredentials(context.Background())
+	require.ErrorContains(suite.T(), err, "MY_KEY")
+}
+
+// Test FileCredentialsProvider with JSON and YAML files.
+func (suite *CredentialsProviderTestSuite) TestFileCredentialsProvider() {
+	dir := suite.T().TempDir()
+	files := map[string]string{
+		"creds.json": `{"key": "json-key", "secret": "c2VjcmV0"}`,
+		"creds.yaml": "key: yaml-key\nsecret: c2VjcmV0\n",
+		"creds.yml":  "key: yml-key\nsecret: c2VjcmV0\n",
+		"empty.json": `{"key": "json-key"}`,
+		"bad.json":   `not json`,
+	}
+	for name, content := range files {
+		require.NoError(suite.T(), os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	for name, key := range map[string]string{"creds.json": "json-key", "creds.yaml": "yaml-key", "creds.yml": "yml-key"} {
+		creds, err := NewFileCredentialsProvider(filepath.Join(dir, name)).GetCredentials(context.Background())
+		require.NoError(suite.T(), err)
+		require.Equal(suite.T(), &Credentials{Key: key, Secret: "c2VjcmV0"}, creds)
+	}
+	// Invalid files
+	for _, name := range []string{"empty.json", "bad.json", "missing.json"} {
+		_, err := NewFileCredentialsProvider(filepath.Join(dir, name)).GetCredentials(context.Background())
+		require.Error(suite.T(), err)
+	}
+}
+
+// Test ChainCredentialsProvider returns the credentials of the first provider which succeeds.
+func (suite *CredentialsProviderTestSuite) TestChainCredentialsProvider() {
+	failing := CredentialsProviderFunc(func(ctx context.Context) (*Credentials, error) {
+		return nil, fmt.Errorf("failing provider")
+	})
+	first := CredentialsProviderFunc(func(ctx context.Context) (*Credentials, error) {
+		return &Credentials{Key: "first", Secret: "c2VjcmV0"}, nil
+	})
+	second := CredentialsProviderFunc(func(ctx context.Context) (*Credentials, error) {
+		return &Credentials{Key: "second", Secret: "c2VjcmV0"}, nil
+	})
+	creds, err := NewChainCredentialsProvider(failing, nil, first, second).GetCredentials(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "first", creds.Key)
+	// All providers fail
+	_, err = NewChainCredentialsProvider(failing, failing).GetCredentials(context.Background())
+	require.ErrorContains(suite.T(), err, "failing provider")
+	// No provider
+	_, err = NewChainCredentialsProvider().GetCredentials(context.Background())
+	require.Error(suite.T(), err)
+}
@@ -0,0 +1,213 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/funding"
+)
+
+// Default interval between two GetStatusOfRecentDeposits requests made by WaitForDeposit.
+const DefaultDepositPollInterval = 30 * time.Second
+
+// Tolerance used to compare the amount of a deposit with the expected amount.
+const depositAmountTolerance = 1e-10
+
+// Error returned by WaitForDeposit when the expected deposit has failed.
+type DepositFailedError struct {
+	// The failed deposit
+	Deposit funding.Deposit
+}
+
+// Format the error message
+func (e *DepositFailedError) Error() string {
+	return fmt.Sprintf("deposit %s of %s %s failed with status %s", e.Deposit.ReferenceID, e.Deposit.Amount, e.Deposit.Asset, e.Deposit.Status)
+}
+
+// DepositWorkflow chains the requests needed to fund an account: GetDepositMethods to choose a
+// deposit method, GetDepositAddresses to get (or generate) an address and GetStatusOfRecentDeposits
+// polling to wait until the deposit is credited.
+type DepositWorkflow struct {
+	// REST client used to send requests
+	client KrakenSpotRESTClientIface
+	// Nonce generator used to sign requests
+	nonceGenerator noncegen.NonceGenerator
+	// Security options used for requests
+	secopts *common.SecurityOptions
+	// Interval between two polls
+	pollInterval time.Duration
+	// Clock used to schedule polls
+	clock clock.Clock
+}
+
+// # Description
+//
+// Factory which creates a new DepositWorkflow.
+//
+// # Inputs
+//
+//   - client: REST client used to send requests. Must not be nil.
+//   - nonceGenerator: Nonce generator used to sign requests. Must not be nil.
+//   - secopts: Optional security options (like password 2FA) to use for requests. Can be nil if 2FA is not used.
+//
+// # Return
+//
+// The new DepositWorkflow or an error if the client or the nonce generator is nil.
+func NewDepositWorkflow(client KrakenSpotRESTClientIface, nonceGenerator noncegen.NonceGenerator, secopts *common.SecurityOptions) (*DepositWorkflow, error) {
+	if client == nil || nonceGenerator == nil {
+		return nil, fmt.Errorf("rest client and nonce generator cannot be nil")
+	}
+	return &DepositWorkflow{
+		client:         client,
+		nonceGenerator: nonceGenerator,
+		secopts:        secopts,
+		pollInterval:   DefaultDepositPollInterval,
+		clock:          clock.NewSystemClock(),
+	}, nil
+}
+
+// Set the interval between two GetStatusOfRecentDeposits requests made by WaitForDeposit. If
+// interval is not strictly positive, DefaultDepositPollInterval is used.
+func (w *DepositWorkflow) SetPollInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultDepositPollInterval
+	}
+	w.pollInterval = interval
+}
+
+// Set the clock used to schedule polls. This can be used to provide a clock.FakeClock in tests.
+// If nil, the system clock is used.
+func (w *DepositWorkflow) SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.NewSystemClock()
+	}
+	w.clock = c
+}
+
+// # Description
+//
+// Get the deposit methods available for an asset.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - asset: Asset being deposited.
+//
+// # Return
+//
+// The deposit methods or an error if the request failed or the server replied with an error.
+func (w *DepositWorkflow) Methods(ctx context.Context, asset string) ([]funding.DepositMethod, error) {
+	resp, _, err := w.client.GetDepositMethods(ctx, w.nonceGenerator.GenerateNonce(), funding.GetDepositMethodsRequestParameters{Asset: asset}, w.secopts)
+	if err != nil {
+		return nil, fmt.Errorf("get deposit methods failed: %w", err)
+	}
+	if len(resp.Error) > 0 {
+		return nil, fmt.Errorf("get deposit methods failed: %v", resp.Error)
+	}
+	return resp.Result, nil
+}
+
+// # Description
+//
+// Get an address to deposit an asset with the provided method.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - asset: Asset being deposited.
+//   - method: Name of the deposit method (Cf. Methods).
+//   - generateNew: True to generate a new address. The method must support address generation
+//     (Cf. DepositMethod.GenAddress).
+//
+// # Return
+//
+// The deposit address or an error if the request failed, the server replied with an error or no
+// address has been returned.
+func (w *DepositWorkflow) Address(ctx context.Context, asset string, method string, generateNew bool) (*funding.DepositAddress, error) {
+	resp, _, err := w.client.GetDepositAddresses(
+		ctx,
+		w.nonceGenerator.GenerateNonce(),
+		funding.GetDepositAddressesRequestParameters{Asset: asset, Method: method},
+		&funding.GetDepositAddressesRequestOptions{New: generateNew},
+		w.secopts)
+	if err != nil {
+		return nil, fmt.Errorf("get deposit addresses failed: %w", err)
+	}
+	if len(resp.Error) > 0 {
+		return nil, fmt.Errorf("get deposit addresses failed: %v", resp.Error)
+	}
+	if len(resp.Result) == 0 {
+		return nil, fmt.Errorf("get deposit addresses failed: no address returned for %s with method %s", asset, method)
+	}
+	return &resp.Result[0], nil
+}
+
+// # Description
+//
+// Poll GetStatusOfRecentDeposits until a deposit of the expected amount of the asset made since
+// the provided time is credited (status Success). Use a context with a deadline or a timeout to
+// limit the time spent waiting.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. Waiting stops when ctx is done.
+//   - asset: Deposited asset.
+//   - method: Optional deposit method used to filter deposits. Can be empty.
+//   - amount: Expected amount.
+//   - since: Only deposits made at or after this time are considered.
+//
+// # Return
+//
+// The credited deposit or an error if:
+//
+//   - The amount is not a valid number.
+//   - A request failed or the server replied with an error.
+//   - The deposit has failed. The error is a DepositFailedError.
+//   - The context is done before the deposit is credited.
+func (w *DepositWorkflow) WaitForDeposit(ctx context.Context, asset string, method string, amount string, since time.Time) (*funding.Deposit, error) {
+	expected, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deposit amount %s: %w", amount, err)
+	}
+	opts := &funding.GetStatusOfRecentDepositsRequestOptions{
+		Asset:  asset,
+		Method: method,
+		Start:  strconv.FormatInt(since.Unix(), 10),
+	}
+	for {
+		resp, _, err := w.client.GetStatusOfRecentDeposits(ctx, w.nonceGenerator.GenerateNonce(), opts, w.secopts)
+		if err != nil {
+			return nil, fmt.Errorf("get status of recent deposits failed: %w", err)
+		}
+		if len(resp.Error) > 0 {
+			return nil, fmt.Errorf("get status of recent deposits failed: %v", resp.Error)
+		}
+		if resp.Result != nil {
+			for _, deposit := range resp.Result.Deposits {
+				value, err := strconv.ParseFloat(deposit.Amount, 64)
+				if err != nil || math.Abs(value-expected) > depositAmountTolerance || deposit.Time < since.Unix() {
+					continue
+				}
+				switch funding.TransactionStateEnum(deposit.Status) {
+				case funding.TxStateSuccess:
+					return &deposit, nil
+				case funding.TxStateFailure:
+					return nil, &DepositFailedError{Deposit: deposit}
+				}
+			}
+		}
+		timer := w.clock.NewTimer(w.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("wait for deposit of %s %s failed: %w", amount, asset, ctx.Err())
+		case <-timer.C():
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/funding"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for DepositWorkflow
+type DepositWorkflowTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestDepositWorkflowTestSuite(t *testing.T) {
+	suite.Run(t, new(DepositWorkflowTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test DepositWorkflow factory.
+func (suite *DepositWorkflowTestSuite) TestNewDepositWorkflow() {
+	_, err := NewDepositWorkflow(nil, noncegen.NewHFNonceGenerator(), nil)
+	require.Error(suite.T(), err)
+	_, err = NewDepositWorkflow(NewMockKrakenSpotRESTClient(), nil, nil)
+	require.Error(suite.T(), err)
+	workflow, err := NewDepositWorkflow(NewMockKrakenSpotRESTClient(), noncegen.NewHFNonceGenerator(), nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), DefaultDepositPollInterval, workflow.pollInterval)
+}
+
+// Test Methods and Address.
+//
+// Test will ensure:
+//   - Deposit methods are returned.
+//   - The new address generation option and the security options are used.
+//   - API errors and empty address lists are reported as errors.
+func (suite *DepositWorkflowTestSuite) TestMethodsAndAddress() {
+	secopts := &common.SecurityOptions{SecondFactor: "42"}
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetDepositMethods", mock.Anything, mock.Anything, funding.GetDepositMethodsRequestParameters{Asset: "XBT"}, secopts).
+		Return(&funding.GetDepositMethodsResponse{Result: []funding.DepositMethod{{Method: "Bitcoin", GenAddress: true}}}, nil, nil).Once()
+	client.On("GetDepositMethods", mock.Anything, mock.Anything, mock.Anything, secopts).
+		Return(&funding.GetDepositMethodsResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{"EFunding:Unknown asset"}}}, nil, nil).Once()
+	client.On("GetDepositAddresses", mock.Anything, mock.Anything, funding.GetDepositAddressesRequestParameters{Asset: "XBT", Method: "Bitcoin"}, &funding.GetDepositAddressesRequestOptions{New: true}, secopts).
+		Return(&funding.GetDepositAddressesResponse{Result: []funding.DepositAddress{{Address: "bc1q", New: true}}}, nil, nil).Once()
+	client.On("GetDepositAddresses", mock.Anything, mock.Anything, mock.Anything, mock.Anything, secopts).
+		Return(&funding.GetDepositAddressesResponse{Result: []funding.DepositAddress{}}, nil, nil).Once()
+	workflow, err := NewDepositWorkflow(client, noncegen.NewHFNonceGenerator(), secopts)
+	require.NoError(suite.T(), err)
+	methods, err := workflow.Methods(context.Background(), "XBT")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "Bitcoin", methods[0].Method)
+	_, err = workflow.Methods(context.Background(), "FOO")
+	require.ErrorContains(suite.T(), err, "EFunding:Unknown asset")
+	address, err := workflow.Address(context.Background(), "XBT", "Bitcoin", true)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "bc1q", address.Address)
+	_, err = workflow.Address(context.Background(), "XBT", "Bitcoin", false)
+	require.Error(suite.T(), err)
+}
+
+// Test WaitForDeposit.
+//
+// Test will ensure:
+//   - Deposits are polled until a deposit with the expected amount is credited.
+//   - Deposits with another amount or made before the start time are ignored.
+//   - A failed deposit is reported as a DepositFailedError.
+//   - Waiting stops when the context is done.
+func (suite *DepositWorkflowTestSuite) TestWaitForDeposit() {
+	since := time.Unix(1700000000, 0)
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetStatusOfRecentDeposits", mock.Anything, mock.Anything, &funding.GetStatusOfRecentDepositsRequestOptions{Asset: "XBT", Start: "1700000000"}, mock.Anything).
+		Return(suite.deposits(funding.Deposit{Amount: "0.5", Time: 1700000010, Status: string(funding.TxStatePending)}), nil, nil).Once()
+	client.On("GetStatusOfRecentDeposits", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(suite.deposits(
+			funding.Deposit{Amount: "1.0", Time: 1600000000, Status: string(funding.TxStateSuccess)},
+			funding.Deposit{Amount: "0.4", Time: 1700000010, Status: string(funding.TxStateSuccess)},
+			funding.Deposit{ReferenceID: "R1", Amount: "0.50", Time: 1700000010, Status: string(funding.TxStateSuccess)},
+		), nil, nil).Once()
+	fake := clock.NewFakeClock(time.Now())
+	workflow, err := NewDepositWorkflow(client, noncegen.NewHFNonceGenerator(), nil)
+	require.NoError(suite.T(), err)
+	workflow.SetClock(fake)
+	workflow.SetPollInterval(time.Minute)
+	go func() {
+		fake.BlockUntil(1)
+		fake.Advance(time.Minute)
+	}()
+	deposit, err := workflow.WaitForDeposit(context.Background(), "XBT", "", "0.5", since)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "R1", deposit.ReferenceID)
+	client.AssertNumberOfCalls(suite.T(), "GetStatusOfRecentDeposits", 2)
+	// Failed deposit
+	client.On("GetStatusOfRecentDeposits", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(suite.deposits(funding.Deposit{ReferenceID: "R2", Amount: "0.5", Time: 1700000010, Status: string(funding.TxStateFailure)}), nil, nil).Once()
+	_, err = workflow.WaitForDeposit(context.Background(), "XBT", "", "0.5", since)
+	dferr := new(DepositFailedError)
+	require.True(suite.T(), errors.As(err, &dferr))
+	require.Equal(suite.T(), "R2", dferr.Deposit.ReferenceID)
+	// Timeout
+	client.On("GetStatusOfRecentDeposits", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(suite.deposits(), nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		fake.BlockUntil(1)
+		cancel()
+	}()
+	_, err = workflow.WaitForDeposit(ctx, "XBT", "", "0.5", since)
+	require.ErrorIs(suite.T(), err, context.Canceled)
+	// Invalid amount
+	_, err = workflow.WaitForDeposit(context.Background(), "XBT", "", "abc", since)
+	require.Error(suite.T(), err)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build a GetStatusOfRecentDeposits response with the provided deposits.
+func (suite *DepositWorkflowTestSuite) deposits(deposits ...funding.Deposit) *funding.GetStatusOfRecentDepositsResponse {
+	return &funding.GetStatusOfRecentDepositsResponse{
+		KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+		Result:                 &funding.GetStatusOfRecentDepositsResult{Deposits: deposits},
+	}
+}
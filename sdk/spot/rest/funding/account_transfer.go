@@ -0,0 +1,30 @@
+package funding
+
+import "github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+
+// AccountTransfer request parameters
+type AccountTransferRequestParameters struct {
+	// Asset being transfered
+	Asset string `json:"asset"`
+	// Amount to be transfered
+	Amount string `json:"amount"`
+	// IIBAN of the source account
+	From string `json:"from"`
+	// IIBAN of the destination account
+	To string `json:"to"`
+}
+
+// AccountTransfer result
+type AccountTransferResult struct {
+	// Transfer ID
+	TransferId string `json:"transfer_id"`
+	// Transfer status
+	Status string `json:"status"`
+}
+
+// AccountTransfer response
+type AccountTransferResponse struct {
+	common.KrakenSpotRESTResponse
+	// AccountTransfer result
+	Result *AccountTransferResult `json:"result,omitempty"`
+}
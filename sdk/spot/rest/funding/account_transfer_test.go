@@ -0,0 +1,56 @@
+package funding
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for AccountTransfer DTO.
+//
+// The test suite ensures all DTO can be marshalled/unmarshalled to/from JSON payloads used by the
+// Kraken Spot REST API.
+type AccountTransferTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestAccountTransferTestSuite(t *testing.T) {
+	suite.Run(t, new(AccountTransferTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the JSON unmarshaller of AccountTransferResponse.
+//
+// The test will ensure:
+//   - A valid JSON response from the API can be unmarshalled into the corresponding AccountTransferResponse struct.
+func (suite *AccountTransferTestSuite) TestAccountTransferResponseUnmarshalJSON() {
+	// Test settings, expectations, ...
+	payload := `{
+		"error": [],
+		"result": {
+		  "transfer_id": "TOH3AS2-LPCWR8-JDQGEU",
+		  "status": "complete"
+		}
+	}`
+	expectedTransferId := "TOH3AS2-LPCWR8-JDQGEU"
+	expectedStatus := "complete"
+	// Unmarshal payload into struct
+	response := new(AccountTransferResponse)
+	err := json.Unmarshal([]byte(payload), response)
+	require.NoError(suite.T(), err)
+	// Check data
+	require.Empty(suite.T(), response.Error)
+	require.NotNil(suite.T(), response.Result)
+	require.Equal(suite.T(), expectedTransferId, response.Result.TransferId)
+	require.Equal(suite.T(), expectedStatus, response.Result.Status)
+}
@@ -1,6 +1,11 @@
 package funding
 
-import "github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+)
 
 // Enum for wallet destinations
 type WalletTransferDestination string
@@ -41,3 +46,70 @@ type RequestWalletTransferResponse struct {
 	// RequestWalletTransfer result
 	Result *RequestWalletTransferResult `json:"result,omitempty"`
 }
+
+// # Description
+//
+// Build RequestWalletTransfer request parameters from a typed amount. The amount is formatted with
+// the provided number of decimals and is never rounded: an error is returned if the amount has
+// more decimals than allowed.
+//
+// # Inputs
+//
+//   - asset: Asset being transfered.
+//   - from: Source wallet.
+//   - to: Destination wallet.
+//   - amount: Amount to be transfered. Must be strictly positive.
+//   - decimals: Number of decimals used to format the amount (ex: asset decimals returned by
+//     GetAssetInfo).
+//
+// # Return
+//
+// The request parameters or an error if the parameters are not valid (Cf. Validate).
+func NewRequestWalletTransferRequestParameters(asset string, from WalletTransferDestination, to WalletTransferDestination, amount *big.Rat, decimals int) (RequestWalletTransferRequestParameters, error) {
+	if amount == nil {
+		return RequestWalletTransferRequestParameters{}, fmt.Errorf("amount must be provided")
+	}
+	if decimals < 0 {
+		return RequestWalletTransferRequestParameters{}, fmt.Errorf("decimals must be positive. Got %d", decimals)
+	}
+	formatted := amount.FloatString(decimals)
+	if rounded, _ := new(big.Rat).SetString(formatted); rounded.Cmp(amount) != 0 {
+		return RequestWalletTransferRequestParameters{}, fmt.Errorf("amount %s has more than %d decimals", amount.FloatString(decimals+10), decimals)
+	}
+	params := RequestWalletTransferRequestParameters{
+		Asset:  asset,
+		From:   string(from),
+		To:     string(to),
+		Amount: formatted,
+	}
+	return params, params.Validate()
+}
+
+// # Description
+//
+// Check the request parameters before they are sent to the server.
+//
+// # Return
+//
+// An error if the asset is empty, if a wallet is unknown, if both wallets are the same or if the
+// amount is not a strictly positive decimal number.
+func (params RequestWalletTransferRequestParameters) Validate() error {
+	if params.Asset == "" {
+		return fmt.Errorf("asset must be provided")
+	}
+	for _, wallet := range []string{params.From, params.To} {
+		switch WalletTransferDestination(wallet) {
+		case Spot, Futures:
+		default:
+			return fmt.Errorf("unknown wallet %q. Expected %q or %q", wallet, Spot, Futures)
+		}
+	}
+	if params.From == params.To {
+		return fmt.Errorf("source and destination wallets must be different. Got %q", params.From)
+	}
+	amount, ok := new(big.Rat).SetString(params.Amount)
+	if !ok || amount.Sign() <= 0 {
+		return fmt.Errorf("amount must be a strictly positive decimal number. Got %q", params.Amount)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package funding
 
 import (
 	"encoding/json"
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -51,3 +52,28 @@ func (suite *RequestWalletTransferTestSuite) TestRequestWalletTransferResponseUn
 	require.NotNil(suite.T(), response.Result)
 	require.Equal(suite.T(), expectedRefId, response.Result.ReferenceID)
 }
+
+// Test NewRequestWalletTransferRequestParameters and Validate.
+//
+// The test will ensure:
+//   - Typed amounts are formatted with the provided decimals.
+//   - Amounts which would be rounded are rejected.
+//   - Unknown or identical wallets, empty assets and non positive amounts are rejected.
+func (suite *RequestWalletTransferTestSuite) TestNewRequestWalletTransferRequestParameters() {
+	params, err := NewRequestWalletTransferRequestParameters("XXBT", Spot, Futures, big.NewRat(3, 2), 8)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), RequestWalletTransferRequestParameters{Asset: "XXBT", From: "Spot Wallet", To: "Futures Wallet", Amount: "1.50000000"}, params)
+	_, err = NewRequestWalletTransferRequestParameters("XXBT", Spot, Futures, big.NewRat(1, 3), 8)
+	require.ErrorContains(suite.T(), err, "more than 8 decimals")
+	_, err = NewRequestWalletTransferRequestParameters("XXBT", Spot, Futures, nil, 8)
+	require.Error(suite.T(), err)
+	_, err = NewRequestWalletTransferRequestParameters("XXBT", Spot, Futures, big.NewRat(1, 1), -1)
+	require.Error(suite.T(), err)
+	_, err = NewRequestWalletTransferRequestParameters("XXBT", Spot, Spot, big.NewRat(1, 1), 8)
+	require.ErrorContains(suite.T(), err, "must be different")
+	_, err = NewRequestWalletTransferRequestParameters("XXBT", Spot, Futures, big.NewRat(0, 1), 8)
+	require.ErrorContains(suite.T(), err, "strictly positive")
+	require.Error(suite.T(), RequestWalletTransferRequestParameters{From: "Spot Wallet", To: "Futures Wallet", Amount: "1"}.Validate())
+	require.ErrorContains(suite.T(), RequestWalletTransferRequestParameters{Asset: "XXBT", From: "Margin Wallet", To: "Futures Wallet", Amount: "1"}.Validate(), "unknown wallet")
+	require.Error(suite.T(), RequestWalletTransferRequestParameters{Asset: "XXBT", From: "Spot Wallet", To: "Futures Wallet", Amount: "abc"}.Validate())
+}
@@ -0,0 +1,264 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+)
+
+/*****************************************************************************/
+/* HISTORY STREAMS: MODEL                                                    */
+/*****************************************************************************/
+
+// Ledger entry yielded by StreamLedgers.
+type StreamedLedgerEntry struct {
+	// Ledger entry ID
+	Id string
+	// Ledger entry
+	Entry *account.LedgerEntry
+	// Offset to use in the request options to resume the stream after this entry.
+	Offset int64
+}
+
+// Trade yielded by StreamTradesHistory.
+type StreamedTrade struct {
+	// Trade transaction ID
+	Id string
+	// Trade
+	Trade *account.TradeInfo
+	// Offset to use in the request options to resume the stream after this trade.
+	Offset int64
+}
+
+// A page of history entries fetched by a history stream.
+type historyPage[T any] struct {
+	// Entries by ID
+	entries map[string]*T
+	// Amount of available entries matching criteria
+	count int
+}
+
+/*****************************************************************************/
+/* HISTORY STREAMS: FUNCTIONS                                                */
+/*****************************************************************************/
+
+// # Description
+//
+// Stream the ledger entries matching the provided options. Pages are fetched with GetLedgersInfo
+// in a background goroutine and their entries are yielded on the returned channel, newest first.
+// The next page is only fetched once all entries of the current page have been consumed so memory
+// usage is bounded by the page size and the buffer size.
+//
+// Pagination relies on offsets: set End in the options to get a consistent stream if new ledger
+// entries can be created while streaming. To resume an interrupted stream, set Offset in the
+// options to the Offset of the last consumed entry. Entries skipped by the filters are still
+// fetched: filter server side with the Type of the options when possible.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. Streaming stops when the context is done.
+//   - client: REST client used to fetch pages. Must not be nil.
+//   - nonceGenerator: Nonce generator used to sign requests. Must not be nil.
+//   - opts: GetLedgersInfo request options. The Offset of the options is the offset of the first streamed entry. Can be nil.
+//   - secopts: Optional security options. Can be nil if 2FA is not used.
+//   - bufferSize: Capacity of the entries channel. Use 0 for an unbuffered channel.
+//   - filters: Optional filters (ex: account.OnlyStakingRewards). Only the entries selected by all filters are yielded.
+//
+// # Return
+//
+// A channel where entries are yielded and a channel where at most one error is published once
+// streaming has stopped. The entries channel is closed when streaming stops and the error channel
+// is closed right after. No error is published if all entries have been streamed. The context
+// error is published if the context is done before all entries have been streamed.
+func StreamLedgers(
+	ctx context.Context,
+	client KrakenSpotRESTClientIface,
+	nonceGenerator noncegen.NonceGenerator,
+	opts *account.GetLedgersInfoRequestOptions,
+	secopts *common.SecurityOptions,
+	bufferSize int,
+	filters ...account.LedgerEntryFilter) (<-chan StreamedLedgerEntry, <-chan error) {
+	// Copy options so offset can be updated without modifying the user's options
+	reqopts := account.GetLedgersInfoRequestOptions{}
+	if opts != nil {
+		reqopts = *opts
+	}
+	fetch := func(ctx context.Context, offset int64) (*historyPage[account.LedgerEntry], error) {
+		reqopts.Offset = offset
+		resp, _, err := client.GetLedgersInfo(ctx, nonceGenerator.GenerateNonce(), &reqopts, secopts)
+		if err != nil {
+			return nil, fmt.Errorf("get ledgers info failed: %w", err)
+		}
+		if len(resp.Error) > 0 || resp.Result == nil {
+			return nil, fmt.Errorf("get ledgers info failed: %v", resp.Error)
+		}
+		return &historyPage[account.LedgerEntry]{entries: resp.Result.Ledgers, count: resp.Result.Count}, nil
+	}
+	out := make(chan StreamedLedgerEntry, bufferSize)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(out)
+		err := streamHistory(ctx, reqopts.Offset, fetch,
+			func(entry *account.LedgerEntry) json.Number { return entry.Timestamp },
+			func(id string, entry *account.LedgerEntry, offset int64) bool {
+				if len(filters) > 0 && !account.MatchLedgerEntry(entry, filters...) {
+					return true
+				}
+				select {
+				case out <- StreamedLedgerEntry{Id: id, Entry: entry, Offset: offset}:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			})
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return out, errs
+}
+
+// # Description
+//
+// Stream the trades matching the provided options. Pages are fetched with GetTradesHistory in a
+// background goroutine and their trades are yielded on the returned channel, newest first. The
+// next page is only fetched once all trades of the current page have been consumed so memory
+// usage is bounded by the page size and the buffer size.
+//
+// Pagination relies on offsets: set End in the options to get a consistent stream if new trades
+// can be made while streaming. To resume an interrupted stream, set Offset in the options to the
+// Offset of the last consumed trade. Trades skipped by the filters are still fetched.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. Streaming stops when the context is done.
+//   - client: REST client used to fetch pages. Must not be nil.
+//   - nonceGenerator: Nonce generator used to sign requests. Must not be nil.
+//   - opts: GetTradesHistory request options. The Offset of the options is the offset of the first streamed trade. Can be nil.
+//   - secopts: Optional security options. Can be nil if 2FA is not used.
+//   - bufferSize: Capacity of the trades channel. Use 0 for an unbuffered channel.
+//   - filters: Optional filters (ex: account.OnlySide). Only the trades selected by all filters are yielded.
+//
+// # Return
+//
+// A channel where trades are yielded and a channel where at most one error is published once
+// streaming has stopped. The trades channel is closed when streaming stops and the error channel
+// is closed right after. No error is published if all trades have been streamed. The context
+// error is published if the context is done before all trades have been streamed.
+func StreamTradesHistory(
+	ctx context.Context,
+	client KrakenSpotRESTClientIface,
+	nonceGenerator noncegen.NonceGenerator,
+	opts *account.GetTradesHistoryRequestOptions,
+	secopts *common.SecurityOptions,
+	bufferSize int,
+	filters ...account.TradeInfoFilter) (<-chan StreamedTrade, <-chan error) {
+	// Copy options so offset can be updated without modifying the user's options
+	reqopts := account.GetTradesHistoryRequestOptions{}
+	if opts != nil {
+		reqopts = *opts
+	}
+	fetch := func(ctx context.Context, offset int64) (*historyPage[account.TradeInfo], error) {
+		reqopts.Offset = offset
+		resp, _, err := client.GetTradesHistory(ctx, nonceGenerator.GenerateNonce(), &reqopts, secopts)
+		if err != nil {
+			return nil, fmt.Errorf("get trades history failed: %w", err)
+		}
+		if len(resp.Error) > 0 || resp.Result == nil {
+			return nil, fmt.Errorf("get trades history failed: %v", resp.Error)
+		}
+		return &historyPage[account.TradeInfo]{entries: resp.Result.Trades, count: resp.Result.Count}, nil
+	}
+	out := make(chan StreamedTrade, bufferSize)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(out)
+		err := streamHistory(ctx, reqopts.Offset, fetch,
+			func(trade *account.TradeInfo) json.Number { return trade.Timestamp },
+			func(id string, trade *account.TradeInfo, offset int64) bool {
+				if len(filters) > 0 && !account.MatchTradeInfo(trade, filters...) {
+					return true
+				}
+				select {
+				case out <- StreamedTrade{Id: id, Trade: trade, Offset: offset}:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			})
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return out, errs
+}
+
+// # Description
+//
+// Fetch pages from the provided offset until all entries have been yielded.
+//
+// # Inputs
+//
+//   - ctx: Context used for coordination purpose.
+//   - offset: Offset of the first entry.
+//   - fetch: Function used to fetch the page which starts at the provided offset.
+//   - timestamp: Function used to get the timestamp of an entry. Entries of a page are yielded from the newest to the oldest.
+//   - yield: Function used to yield an entry with the offset of the next entry. Must return false if the context is done.
+//
+// # Return
+//
+// An error if a page could not be fetched or if the context is done before all entries have been
+// yielded.
+func streamHistory[T any](
+	ctx context.Context,
+	offset int64,
+	fetch func(ctx context.Context, offset int64) (*historyPage[T], error),
+	timestamp func(entry *T) json.Number,
+	yield func(id string, entry *T, offset int64) bool) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		page, err := fetch(ctx, offset)
+		if err != nil {
+			return err
+		}
+		// Stop when the page is empty: all entries have been yielded
+		if len(page.entries) == 0 {
+			return nil
+		}
+		// Order entries from the newest to the oldest like the API does
+		ids := make([]string, 0, len(page.entries))
+		times := make(map[string]float64, len(page.entries))
+		for id, entry := range page.entries {
+			ids = append(ids, id)
+			if entry != nil {
+				times[id], _ = timestamp(entry).Float64()
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			if times[ids[i]] != times[ids[j]] {
+				return times[ids[i]] > times[ids[j]]
+			}
+			return ids[i] < ids[j]
+		})
+		for _, id := range ids {
+			offset++
+			if !yield(id, page.entries[id], offset) {
+				return ctx.Err()
+			}
+		}
+		// Stop when the last entry matching criteria has been yielded. Count is not provided when
+		// WithoutCount is set: paging continues until an empty page is fetched.
+		if page.count > 0 && offset >= int64(page.count) {
+			return nil
+		}
+	}
+}
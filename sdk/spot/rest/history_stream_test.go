@@ -0,0 +1,164 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for StreamLedgers and StreamTradesHistory
+type HistoryStreamTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestHistoryStreamTestSuite(t *testing.T) {
+	suite.Run(t, new(HistoryStreamTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test StreamLedgers.
+//
+// Test will ensure:
+//   - Pages are fetched until all entries matching criteria have been streamed.
+//   - Entries of a page are streamed from the newest to the oldest with their resume offset.
+//   - The user's options are not modified.
+//   - Both channels are closed and no error is published once all entries have been streamed.
+func (suite *HistoryStreamTestSuite) TestStreamLedgers() {
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetLedgersInfo", mock.Anything, mock.Anything, mock.MatchedBy(ledgersOffset(0)), mock.Anything).
+		Return(newLedgersResponse(3, map[string]string{"L1": "100", "L2": "200"}), nil, nil)
+	client.On("GetLedgersInfo", mock.Anything, mock.Anything, mock.MatchedBy(ledgersOffset(2)), mock.Anything).
+		Return(newLedgersResponse(3, map[string]string{"L0": "50"}), nil, nil)
+	opts := &account.GetLedgersInfoRequestOptions{Assets: []string{"XXBT"}, End: "1000"}
+	entries, errs := StreamLedgers(context.Background(), client, noncegen.NewHFNonceGenerator(), opts, nil, 0)
+	streamed := []StreamedLedgerEntry{}
+	for entry := range entries {
+		streamed = append(streamed, entry)
+	}
+	require.NoError(suite.T(), <-errs)
+	require.Len(suite.T(), streamed, 3)
+	for i, id := range []string{"L2", "L1", "L0"} {
+		require.Equal(suite.T(), id, streamed[i].Id)
+		require.Equal(suite.T(), int64(i+1), streamed[i].Offset)
+	}
+	require.Equal(suite.T(), json.Number("50"), streamed[2].Entry.Timestamp)
+	require.Equal(suite.T(), int64(0), opts.Offset)
+	client.AssertNumberOfCalls(suite.T(), "GetLedgersInfo", 2)
+}
+
+// Test StreamTradesHistory.
+//
+// Test will ensure:
+//   - Streaming starts at the offset of the options.
+//   - An error response from the API stops streaming and is published on the error channel.
+func (suite *HistoryStreamTestSuite) TestStreamTradesHistory() {
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetTradesHistory", mock.Anything, mock.Anything, mock.MatchedBy(func(opts *account.GetTradesHistoryRequestOptions) bool {
+		return opts.Offset == 10
+	}), mock.Anything).Return(&account.GetTradesHistoryResponse{
+		Result: &account.GetTradesHistoryResult{
+			Trades: map[string]*account.TradeInfo{"T1": {Timestamp: "100"}},
+			Count:  20,
+		},
+	}, nil, nil)
+	client.On("GetTradesHistory", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&account.GetTradesHistoryResponse{
+		KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{"EAPI:Rate limit exceeded"}},
+	}, nil, nil)
+	trades, errs := StreamTradesHistory(context.Background(), client, noncegen.NewHFNonceGenerator(), &account.GetTradesHistoryRequestOptions{Offset: 10}, nil, 10)
+	streamed := []StreamedTrade{}
+	for trade := range trades {
+		streamed = append(streamed, trade)
+	}
+	require.Len(suite.T(), streamed, 1)
+	require.Equal(suite.T(), "T1", streamed[0].Id)
+	require.Equal(suite.T(), int64(11), streamed[0].Offset)
+	err := <-errs
+	require.Error(suite.T(), err)
+	require.Contains(suite.T(), err.Error(), "Rate limit exceeded")
+}
+
+// Test StreamLedgers with filters.
+//
+// Test will ensure:
+//   - Only the entries selected by all filters are streamed.
+//   - Skipped entries still advance the resume offset of the streamed entries.
+func (suite *HistoryStreamTestSuite) TestStreamLedgersFilters() {
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetLedgersInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&account.GetLedgersInfoResponse{Result: &account.LedgersInfoResult{
+			Ledgers: map[string]*account.LedgerEntry{
+				"L1": {Timestamp: "100", Type: "staking"},
+				"L2": {Timestamp: "200", Type: "trade"},
+				"L3": {Timestamp: "300", Type: "earn", SubType: "reward"},
+			},
+			Count: 3,
+		}}, nil, nil)
+	entries, errs := StreamLedgers(context.Background(), client, noncegen.NewHFNonceGenerator(), nil, nil, 0, account.OnlyStakingRewards())
+	streamed := []StreamedLedgerEntry{}
+	for entry := range entries {
+		streamed = append(streamed, entry)
+	}
+	require.NoError(suite.T(), <-errs)
+	require.Len(suite.T(), streamed, 2)
+	require.Equal(suite.T(), "L3", streamed[0].Id)
+	require.Equal(suite.T(), int64(1), streamed[0].Offset)
+	require.Equal(suite.T(), "L1", streamed[1].Id)
+	require.Equal(suite.T(), int64(3), streamed[1].Offset)
+}
+
+// Test backpressure and cancellation.
+//
+// Test will ensure:
+//   - The next page is not fetched while entries of the current page are not consumed.
+//   - Streaming stops when the context is canceled and the context error is published.
+func (suite *HistoryStreamTestSuite) TestStreamCancellation() {
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetLedgersInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(newLedgersResponse(100, map[string]string{"L1": "100", "L2": "200"}), nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	entries, errs := StreamLedgers(ctx, client, noncegen.NewHFNonceGenerator(), nil, nil, 0)
+	first := <-entries
+	require.Equal(suite.T(), "L2", first.Id)
+	cancel()
+	for range entries {
+		// Drain entries which may have been sent before the cancellation was noticed
+	}
+	require.ErrorIs(suite.T(), <-errs, context.Canceled)
+	client.AssertNumberOfCalls(suite.T(), "GetLedgersInfo", 1)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build a matcher for GetLedgersInfo options with the provided offset.
+func ledgersOffset(offset int64) func(opts *account.GetLedgersInfoRequestOptions) bool {
+	return func(opts *account.GetLedgersInfoRequestOptions) bool {
+		return opts.Offset == offset
+	}
+}
+
+// Build a GetLedgersInfo response with the provided count and entries timestamps by ID.
+func newLedgersResponse(count int, timestamps map[string]string) *account.GetLedgersInfoResponse {
+	ledgers := map[string]*account.LedgerEntry{}
+	for id, ts := range timestamps {
+		ledgers[id] = &account.LedgerEntry{Timestamp: json.Number(ts)}
+	}
+	return &account.GetLedgersInfoResponse{Result: &account.LedgersInfoResult{Ledgers: ledgers, Count: count}}
+}
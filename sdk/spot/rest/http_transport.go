@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Configuration of the HTTP transport and connection pool used by KrakenSpotRESTClient.
+//
+// All requests of a client target the same host (api.kraken.com): the default http.Transport
+// keeps only 2 idle connections per host, which forces bursts of requests to open new TLS
+// connections. Use NewDefaultHTTPTransportConfiguration to get defaults tuned for this use case.
+type HTTPTransportConfiguration struct {
+	// Maximum number of idle (keep-alive) connections across all hosts. Zero means no limit.
+	MaxIdleConns int
+	// Maximum number of idle (keep-alive) connections to keep per host. Zero means
+	// http.DefaultMaxIdleConnsPerHost (2).
+	MaxIdleConnsPerHost int
+	// Maximum number of connections per host, including connections in the dialing, active and
+	// idle states. Zero means no limit.
+	MaxConnsPerHost int
+	// Maximum amount of time an idle connection remains idle before closing itself. Zero means no
+	// limit.
+	IdleConnTimeout time.Duration
+	// Timeout used to establish TCP connections. Zero means no timeout.
+	DialTimeout time.Duration
+	// Interval between keep-alive probes for active TCP connections. Zero means keep-alives are
+	// enabled with the default interval.
+	KeepAlive time.Duration
+	// Maximum amount of time to wait for a TLS handshake. Zero means no timeout.
+	TLSHandshakeTimeout time.Duration
+	// Maximum amount of time to wait for the response headers once the request has been written.
+	// Zero means no timeout.
+	ResponseHeaderTimeout time.Duration
+	// Timeout for the whole request, including reading the response body. Zero means no timeout:
+	// request contexts can be used instead.
+	Timeout time.Duration
+	// Optional TLS configuration. If nil, the default configuration is used.
+	TLSClientConfig *tls.Config
+	// Function which returns the proxy to use for a request. If nil, no proxy is used. Use
+	// http.ProxyFromEnvironment to use the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+	// variables.
+	Proxy func(*http.Request) (*url.URL, error)
+	// True to disable HTTP/2 and only use HTTP/1.1.
+	DisableHTTP2 bool
+}
+
+// A factory which creates a new HTTPTransportConfiguration with defaults tuned for a client which
+// sends all its requests to the Kraken API: up to 100 idle connections kept for 90 seconds, proxy
+// read from the environment and HTTP/2 enabled.
+func NewDefaultHTTPTransportConfiguration() *HTTPTransportConfiguration {
+	return &HTTPTransportConfiguration{
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   100,
+		MaxConnsPerHost:       0,
+		IdleConnTimeout:       90 * time.Second,
+		DialTimeout:           30 * time.Second,
+		KeepAlive:             30 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 0,
+		Timeout:               0,
+		TLSClientConfig:       nil,
+		Proxy:                 http.ProxyFromEnvironment,
+		DisableHTTP2:          false,
+	}
+}
+
+// # Description
+//
+// Build a new HTTP client with a dedicated transport configured with the provided settings.
+//
+// # Inputs
+//
+//   - cfg: Transport configuration. If nil, NewDefaultHTTPTransportConfiguration is used.
+//
+// # Return
+//
+// A new HTTP client which does not share its connection pool with other clients.
+func NewHTTPClient(cfg *HTTPTransportConfiguration) *http.Client {
+	if cfg == nil {
+		cfg = NewDefaultHTTPTransportConfiguration()
+	}
+	dialer := &net.Dialer{
+		Timeout:   cfg.DialTimeout,
+		KeepAlive: cfg.KeepAlive,
+	}
+	transport := &http.Transport{
+		Proxy:                 cfg.Proxy,
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: cfg.ResponseHeaderTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if cfg.TLSClientConfig != nil {
+		transport.TLSClientConfig = cfg.TLSClientConfig.Clone()
+	}
+	if cfg.DisableHTTP2 {
+		// A non-nil empty map disables HTTP/2
+		transport.TLSNextProto = map[string]func(authority string, c *tls.Conn) http.RoundTripper{}
+	} else {
+		// Required to keep HTTP/2 enabled when a custom dialer or TLS configuration is used
+		transport.ForceAttemptHTTP2 = true
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+	}
+}
@@ -0,0 +1,106 @@
+package rest
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the HTTP transport configuration
+type HTTPTransportTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestHTTPTransportTestSuite(t *testing.T) {
+	suite.Run(t, new(HTTPTransportTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test NewHTTPClient applies the provided settings to a dedicated transport.
+func (suite *HTTPTransportTestSuite) TestNewHTTPClient() {
+	// Defaults
+	client := NewHTTPClient(nil)
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(suite.T(), ok)
+	require.NotSame(suite.T(), http.DefaultTransport, transport)
+	require.Equal(suite.T(), 100, transport.MaxIdleConnsPerHost)
+	require.Equal(suite.T(), 90*time.Second, transport.IdleConnTimeout)
+	require.True(suite.T(), transport.ForceAttemptHTTP2)
+	require.NotNil(suite.T(), transport.Proxy)
+	// Custom settings
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS13}
+	cfg := &HTTPTransportConfiguration{
+		MaxIdleConnsPerHost: 8,
+		MaxConnsPerHost:     16,
+		Timeout:             5 * time.Second,
+		TLSClientConfig:     tlsConfig,
+		DisableHTTP2:        true,
+	}
+	client = NewHTTPClient(cfg)
+	transport = client.Transport.(*http.Transport)
+	require.Equal(suite.T(), 8, transport.MaxIdleConnsPerHost)
+	require.Equal(suite.T(), 16, transport.MaxConnsPerHost)
+	require.Equal(suite.T(), 5*time.Second, client.Timeout)
+	require.Nil(suite.T(), transport.Proxy)
+	require.False(suite.T(), transport.ForceAttemptHTTP2)
+	require.NotNil(suite.T(), transport.TLSNextProto)
+	require.Empty(suite.T(), transport.TLSNextProto)
+	// TLS configuration is copied
+	require.NotSame(suite.T(), tlsConfig, transport.TLSClientConfig)
+	require.Equal(suite.T(), uint16(tls.VersionTLS13), transport.TLSClientConfig.MinVersion)
+}
+
+// Test the HTTP/2 toggle against a HTTP/2 enabled TLS server.
+func (suite *HTTPTransportTestSuite) TestHTTP2Toggle() {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+	serverTLS := server.Client().Transport.(*http.Transport).TLSClientConfig
+	for _, disable := range []bool{false, true} {
+		cfg := NewDefaultHTTPTransportConfiguration()
+		cfg.Proxy = nil
+		cfg.TLSClientConfig = serverTLS
+		cfg.DisableHTTP2 = disable
+		resp, err := NewHTTPClient(cfg).Get(server.URL)
+		require.NoError(suite.T(), err)
+		resp.Body.Close()
+		if disable {
+			require.Equal(suite.T(), 1, resp.ProtoMajor)
+		} else {
+			require.Equal(suite.T(), 2, resp.ProtoMajor)
+		}
+	}
+}
+
+// Test the REST client uses the transport configuration unless a HTTP client is provided.
+func (suite *HTTPTransportTestSuite) TestClientConfiguration() {
+	client := NewKrakenSpotRESTClient(nil, nil)
+	require.Same(suite.T(), http.DefaultClient, client.client)
+	client = NewKrakenSpotRESTClient(nil, &KrakenSpotRESTClientConfiguration{
+		Transport: &HTTPTransportConfiguration{MaxIdleConnsPerHost: 12},
+	})
+	require.NotSame(suite.T(), http.DefaultClient, client.client)
+	require.Equal(suite.T(), 12, client.client.Transport.(*http.Transport).MaxIdleConnsPerHost)
+	custom := &http.Client{}
+	client = NewKrakenSpotRESTClient(nil, &KrakenSpotRESTClientConfiguration{
+		Client:    custom,
+		Transport: &HTTPTransportConfiguration{MaxIdleConnsPerHost: 12},
+	})
+	require.Same(suite.T(), custom, client.client)
+}
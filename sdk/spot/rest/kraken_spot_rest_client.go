@@ -2,7 +2,6 @@ package rest
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"mime"
@@ -10,15 +9,21 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/gbdevw/purple-goctopus/sdk/spot/clienttag"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/codec"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/requesttag"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/earn"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/funding"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/staking"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/websocket"
+	"go.opentelemetry.io/otel/metric"
 )
 
 /*****************************************************************************/
@@ -50,6 +55,7 @@ const (
 	getOpenOrdersPath         = "/private/OpenOrders"
 	getClosedOrdersPath       = "/private/ClosedOrders"
 	queryOrdersInfosPath      = "/private/QueryOrders"
+	getOrderAmendsPath        = "/private/OrderAmends"
 	getTradesHistoryPath      = "/private/TradesHistory"
 	queryTradesInfoPath       = "/private/QueryTrades"
 	getOpenPositionsPath      = "/private/OpenPositions"
@@ -60,12 +66,14 @@ const (
 	getExportReportStatusPath = "/private/ExportStatus"
 	retrieveDataExportPath    = "/private/RetrieveExport"
 	deleteExportReportPath    = "/private/RemoveExport"
+	createSubaccountPath      = "/private/CreateSubaccount"
 
 	// Trading
 
 	addOrderPath              = "/private/AddOrder"
 	addOrderBatchPath         = "/private/AddOrderBatch"
 	editOrderPath             = "/private/EditOrder"
+	amendOrderPath            = "/private/AmendOrder"
 	cancelOrderPath           = "/private/CancelOrder"
 	cancelAllOrdersPath       = "/private/CancelAll"
 	cancelAllOrdersAfterXPath = "/private/CancelAllOrdersAfter"
@@ -83,6 +91,7 @@ const (
 	getStatusOfRecentWithdrawalsPath  = "/private/WithdrawStatus"
 	requestWithdrawalCancellationPath = "/private/WithdrawCancel"
 	requestWalletTransferPath         = "/private/WalletTransfer"
+	accountTransferPath               = "/private/AccountTransfer"
 
 	// Earn
 
@@ -93,6 +102,14 @@ const (
 	listEarnStartegiesPath    = "/private/Earn/Strategies"
 	listEarnAllocationsPath   = "/private/Earn/Allocations"
 
+	// Staking
+
+	stakePath                         = "/private/Stake"
+	unstakePath                       = "/private/Unstake"
+	listStakeableAssetsPath           = "/private/Staking/Assets"
+	getPendingStakingTransactionsPath = "/private/Staking/Pending"
+	listRecentStakingTransactionsPath = "/private/Staking/Transactions"
+
 	// Websocket
 
 	getWebsocketTokenPath = "/private/GetWebSocketsToken"
@@ -106,7 +123,7 @@ const (
 	managedHeaderUserAgent   = "User-Agent"
 
 	// Default value for User-Agent
-	DefaultUserAgent = "Lake42-Goctopus"
+	DefaultUserAgent = clienttag.DefaultUserAgent
 )
 
 /*****************************************************************************/
@@ -120,10 +137,26 @@ type KrakenSpotRESTClient struct {
 	baseURL string
 	// Value for the mandatory User-Agent header.
 	agent string
+	// Client tag added to the User-Agent header. Empty if not set.
+	clientTag string
 	// Authorizer used to authorize requests to Kraken spot REST API.
 	authorizer KrakenSpotRESTClientAuthorizerIface
 	// HTTP client used to perform API calls.
 	client *http.Client
+	// JSON codec used to parse API responses.
+	codec codec.JSONCodec
+	// Settings for the automatic handling of invalid nonce errors. Nil if disabled.
+	nonceResync *NonceResyncConfiguration
+	// Number of invalid nonce errors handled
+	nonceResyncs atomic.Uint64
+	// Number of requests retried after an invalid nonce error
+	nonceRetries atomic.Uint64
+	// Circuit breaker which stops sending requests after repeated failures. Nil if disabled.
+	breaker *circuitBreaker
+	// Decoder used to detect schema drifts in responses. Nil if lax decoding is disabled.
+	lax *laxDecoder
+	// Key of the request tag used as default userref of orders. Empty if disabled.
+	userReferenceTag string
 }
 
 // Configuration for KrakenSpotRESTClient.
@@ -136,10 +169,55 @@ type KrakenSpotRESTClientConfiguration struct {
 	//
 	// If an empty string is used, defaults to "Lake42-Goctopus"
 	Agent string
+	// Optional client tag used to distinguish bots which share the same API keys. The tag is added
+	// to the User-Agent header ("<agent> (<tag>)") and, when the client is instrumented with
+	// InstrumentKrakenSpotRESTClient, to every span (Cf. clienttag package).
+	//
+	// If an empty string is used, no tag is added.
+	ClientTag string
 	// Low level HTTP client to use to perform API calls.
 	//
-	// If nil, defaults to http.DefaultClient.
+	// If nil, a client is built from Transport if set. Otherwise, defaults to http.DefaultClient.
 	Client *http.Client
+	// Settings used to build a HTTP client with a dedicated, tuned connection pool (idle
+	// connections, timeouts, TLS, proxy, HTTP/2). Cf. NewDefaultHTTPTransportConfiguration.
+	//
+	// Ignored if Client is set. If nil, http.DefaultClient is used.
+	Transport *HTTPTransportConfiguration
+	// JSON codec used to parse API responses. Can be used to plug a faster JSON library.
+	//
+	// If nil, defaults to codec.StandardJSONCodec (encoding/json).
+	Codec codec.JSONCodec
+	// Settings for the automatic handling of "EAPI:Invalid nonce" errors: resync of the nonce
+	// generator and optional retry of the rejected request. Cf. NonceResyncConfiguration.
+	//
+	// If nil or if its nonce generator is nil, invalid nonce errors are returned as is.
+	NonceResync *NonceResyncConfiguration
+	// Settings for a circuit breaker which fails fast with a CircuitOpenError after repeated
+	// transport or 5xx failures. Cf. CircuitBreakerConfiguration. The state of the circuit breaker
+	// is reported with a metric until the client is closed (Cf. KrakenSpotRESTClient.Close).
+	//
+	// If nil, the circuit breaker is disabled.
+	CircuitBreaker *CircuitBreakerConfiguration
+	// Settings for the lax decoding of responses: unknown fields and type mismatches are captured
+	// in the RawExtra map of the responses and reported as schema drifts instead of failing the
+	// request. Cf. LaxDecodingConfiguration.
+	//
+	// If nil, responses are decoded strictly: type mismatches fail the request and unknown fields
+	// are ignored.
+	LaxDecoding *LaxDecodingConfiguration
+	// Optional key of the request tag (Cf. requesttag.WithTag) used as userref of the orders
+	// created with AddOrder and AddOrderBatch when no userref is provided. The tag is ignored if
+	// its value is not a 32 bits integer. Can be used to correlate orders and fills with the
+	// strategy decisions which created them.
+	//
+	// If an empty string is used, userref is only set from the order parameters.
+	UserReferenceTag string
+	// Meter provider used to create the client metrics (Cf. CircuitBreakerStateMetricName and
+	// SchemaDriftMetricName).
+	//
+	// If nil, the global meter provider is used.
+	MeterProvider metric.MeterProvider
 }
 
 // A factory which creates a new KrakenSpotRESTClientConfiguration with all its default values set.
@@ -148,6 +226,7 @@ func NewDefaultKrakenSpotRESTClientConfiguration() *KrakenSpotRESTClientConfigur
 		BaseURL: KrakenProductionV0BaseUrl,
 		Agent:   DefaultUserAgent,
 		Client:  http.DefaultClient,
+		Codec:   codec.StandardJSONCodec{},
 	}
 }
 
@@ -195,17 +274,46 @@ func NewKrakenSpotRESTClient(authorizer KrakenSpotRESTClientAuthorizerIface, cfg
 		if cfg.Agent != "" {
 			defCfg.Agent = cfg.Agent
 		}
+		defCfg.ClientTag = cfg.ClientTag
 		if cfg.Client != nil {
 			defCfg.Client = cfg.Client
+		} else if cfg.Transport != nil {
+			defCfg.Client = NewHTTPClient(cfg.Transport)
+		}
+		if cfg.Codec != nil {
+			defCfg.Codec = cfg.Codec
+		}
+		if cfg.NonceResync != nil && cfg.NonceResync.NonceGenerator != nil {
+			defCfg.NonceResync = cfg.NonceResync
 		}
+		defCfg.CircuitBreaker = cfg.CircuitBreaker
+		defCfg.LaxDecoding = cfg.LaxDecoding
+		defCfg.UserReferenceTag = cfg.UserReferenceTag
+		defCfg.MeterProvider = cfg.MeterProvider
 	}
 	// Build and return client
-	return &KrakenSpotRESTClient{
-		baseURL:    defCfg.BaseURL,
-		agent:      defCfg.Agent,
-		authorizer: authorizer,
-		client:     defCfg.Client,
-	}
+	client := &KrakenSpotRESTClient{
+		baseURL:          defCfg.BaseURL,
+		agent:            clienttag.UserAgent(defCfg.Agent, defCfg.ClientTag),
+		clientTag:        defCfg.ClientTag,
+		authorizer:       authorizer,
+		client:           defCfg.Client,
+		codec:            defCfg.Codec,
+		nonceResync:      defCfg.NonceResync,
+		userReferenceTag: defCfg.UserReferenceTag,
+	}
+	if defCfg.CircuitBreaker != nil {
+		client.breaker = newCircuitBreaker(*defCfg.CircuitBreaker, defCfg.ClientTag, defCfg.MeterProvider)
+	}
+	if defCfg.LaxDecoding != nil {
+		client.lax = newLaxDecoder(*defCfg.LaxDecoding, defCfg.Codec, defCfg.MeterProvider)
+	}
+	return client
+}
+
+// Get the client tag set in the client configuration. Empty if not set.
+func (client *KrakenSpotRESTClient) GetClientTag() string {
+	return client.clientTag
 }
 
 /*****************************************************************************/
@@ -282,7 +390,38 @@ func (client *KrakenSpotRESTClient) forgeAndAuthorizeKrakenAPIRequest(
 //   - The parsed JSON response from KRaken API (= receiver)
 //   - A reference to the raw http.Response (with its body closed except if the response contains binary data)
 //   - An error if any has occured (error at HTTP level, error when parsing response, ...)
+//
+// # Invalid nonce
+//
+// If the automatic handling of invalid nonce errors is configured, the nonce generator is resynced
+// when the response contains an "EAPI:Invalid nonce" error and the request is retried once with a
+// new nonce if retries are enabled. Cf. NonceResyncConfiguration.
+//
+// # Circuit breaker
+//
+// If the circuit breaker is configured, the request is not sent and a CircuitOpenError is returned
+// while the circuit is open. The outcome of sent requests is recorded by the circuit breaker.
+// Cf. CircuitBreakerConfiguration.
 func (client *KrakenSpotRESTClient) doKrakenAPIRequest(ctx context.Context, req *http.Request, receiver interface{}) (*http.Response, error) {
+	probe := false
+	if client.breaker != nil {
+		var err error
+		if probe, err = client.breaker.allow(); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := client.sendKrakenAPIRequest(ctx, req, receiver)
+	if err == nil && client.nonceResync != nil && hasInvalidNonceError(receiver) {
+		resp, err = client.handleInvalidNonce(ctx, req, receiver, resp)
+	}
+	if client.breaker != nil {
+		client.breaker.record(probe, isCircuitBreakerFailure(ctx, resp, err))
+	}
+	return resp, err
+}
+
+// Send the provided request to Kraken spot REST API and process the response if any. Cf. doKrakenAPIRequest.
+func (client *KrakenSpotRESTClient) sendKrakenAPIRequest(ctx context.Context, req *http.Request, receiver interface{}) (*http.Response, error) {
 	select {
 	// Abort request processing if context has expired
 	case <-ctx.Done():
@@ -321,7 +460,11 @@ func (client *KrakenSpotRESTClient) doKrakenAPIRequest(ctx context.Context, req
 			if err != nil {
 				return resp, fmt.Errorf("failed to read response body: %w", err)
 			}
-			err = json.Unmarshal(body, receiver)
+			err = client.codec.Unmarshal(body, receiver)
+			if client.lax != nil {
+				// Report schema drifts and tolerate type mismatches
+				err = client.lax.process(req.Context(), req, body, receiver, err)
+			}
 			if err != nil {
 				return resp, fmt.Errorf("failed to parse JSON response: %w", err)
 			}
@@ -1082,6 +1225,9 @@ func (client *KrakenSpotRESTClient) GetOpenOrders(ctx context.Context, nonce int
 		if opts.UserReference != nil {
 			form.Set("userref", strconv.FormatInt(*opts.UserReference, 10))
 		}
+		if opts.ClientOrderId != "" {
+			form.Set("cl_ord_id", opts.ClientOrderId)
+		}
 	}
 	// Forge and authorize the request
 	req, err := client.forgeAndAuthorizeKrakenAPIRequest(ctx, getOpenOrdersPath, http.MethodPost, "application/x-www-form-urlencoded", nil, strings.NewReader(form.Encode()))
@@ -1094,6 +1240,10 @@ func (client *KrakenSpotRESTClient) GetOpenOrders(ctx context.Context, nonce int
 	if err != nil {
 		return nil, resp, fmt.Errorf("request for GetOpenOrders failed: %w", err)
 	}
+	// Apply client side filters
+	if opts != nil && receiver.Result != nil {
+		receiver.Result.Open = account.FilterOrderInfos(receiver.Result.Open, opts.Filters...)
+	}
 	// Return results
 	return receiver, resp, nil
 }
@@ -1160,6 +1310,12 @@ func (client *KrakenSpotRESTClient) GetClosedOrders(ctx context.Context, nonce i
 		if opts.ConsolidateTaker {
 			form.Set("consolidate_taker", strconv.FormatBool(opts.ConsolidateTaker))
 		}
+		if opts.ClientOrderId != "" {
+			form.Set("cl_ord_id", opts.ClientOrderId)
+		}
+		if opts.WithoutCount {
+			form.Set("without_count", strconv.FormatBool(opts.WithoutCount))
+		}
 	}
 	// Forge and authorize the request
 	req, err := client.forgeAndAuthorizeKrakenAPIRequest(ctx, getClosedOrdersPath, http.MethodPost, "application/x-www-form-urlencoded", nil, strings.NewReader(form.Encode()))
@@ -1172,6 +1328,10 @@ func (client *KrakenSpotRESTClient) GetClosedOrders(ctx context.Context, nonce i
 	if err != nil {
 		return nil, resp, fmt.Errorf("request for GetClosedOrders failed: %w", err)
 	}
+	// Apply client side filters
+	if opts != nil && receiver.Result != nil {
+		receiver.Result.Closed = account.FilterOrderInfos(receiver.Result.Closed, opts.Filters...)
+	}
 	// Return results
 	return receiver, resp, nil
 }
@@ -1247,6 +1407,69 @@ func (client *KrakenSpotRESTClient) QueryOrdersInfo(ctx context.Context, nonce i
 	return receiver, resp, nil
 }
 
+// # Description
+//
+// GetOrderAmends - Retrieve the amends history of an order, including the original order values.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - nonce: Nonce used to sign request.
+//   - params: GetOrderAmends request parameters.
+//   - opts: GetOrderAmends request options. A nil value triggers all default behaviors.
+//   - secopts: Security options to use for the API call (2FA, ...)
+//
+// # Returns
+//
+//   - GetOrderAmendsResponse: The parsed response from Kraken API.
+//   - http.Response: A reference to the raw HTTP response received from Kraken API.
+//   - error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+//
+// # Note on error
+//
+// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+// when context has expired.
+//
+// An nil error does not mean everything is OK: You also have to check the response error field for specific
+// errors from Kraken API.
+//
+// # Note on the http.Response
+//
+// A reference to the received http.Response is always returned but it may be nil if no response was received.
+// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+// to extract the metadata (or any other kind of data that are not used by the API client directly).
+//
+// Please note response body will always be closed except for RetrieveDataExport.
+func (client *KrakenSpotRESTClient) GetOrderAmends(ctx context.Context, nonce int64, params account.GetOrderAmendsRequestParameters, opts *account.GetOrderAmendsRequestOptions, secopts *common.SecurityOptions) (*account.GetOrderAmendsResponse, *http.Response, error) {
+	// Prepare form body.
+	form := url.Values{}
+	// Encode nonce and optional common security options
+	EncodeNonceAndSecurityOptions(form, nonce, secopts)
+	// Add parameters
+	form.Set("order_id", params.OrderId)
+	// Add options
+	if opts != nil {
+		if opts.RebaseMultiplier != "" {
+			form.Set("rebase_multiplier", opts.RebaseMultiplier)
+		}
+	}
+	// Forge and authorize the request
+	req, err := client.forgeAndAuthorizeKrakenAPIRequest(ctx, getOrderAmendsPath, http.MethodPost, "application/x-www-form-urlencoded", nil, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to forge and authorize request for GetOrderAmends: %w", err)
+	}
+	// Send the request
+	receiver := new(account.GetOrderAmendsResponse)
+	resp, err := client.doKrakenAPIRequest(ctx, req, receiver)
+	if err != nil {
+		return nil, resp, fmt.Errorf("request for GetOrderAmends failed: %w", err)
+	}
+	// Return results
+	return receiver, resp, nil
+}
+
 // # Description
 //
 // GetTradesHistory - Retrieve information about trades/fills. 50 results are returned at a time, the most recent by default.
@@ -1306,6 +1529,9 @@ func (client *KrakenSpotRESTClient) GetTradesHistory(ctx context.Context, nonce
 		if opts.ConsolidateTaker {
 			form.Set("consolidate_taker", strconv.FormatBool(opts.ConsolidateTaker))
 		}
+		if opts.Ledgers {
+			form.Set("ledgers", strconv.FormatBool(opts.Ledgers))
+		}
 	}
 	// Forge and authorize the request
 	req, err := client.forgeAndAuthorizeKrakenAPIRequest(ctx, getTradesHistoryPath, http.MethodPost, "application/x-www-form-urlencoded", nil, strings.NewReader(form.Encode()))
@@ -1899,6 +2125,64 @@ func (client *KrakenSpotRESTClient) DeleteExportReport(ctx context.Context, nonc
 	return receiver, resp, nil
 }
 
+// # Description
+//
+// CreateSubaccount - Create a trading subaccount. The API key must have been created from the
+// master account.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - nonce: Nonce used to sign request.
+//   - params: CreateSubaccount request parameters.
+//   - secopts: Security options to use for the API call (2FA, ...)
+//
+// # Returns
+//
+//   - CreateSubaccountResponse: The parsed response from Kraken API.
+//   - http.Response: A reference to the raw HTTP response received from Kraken API.
+//   - error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+//
+// # Note on error
+//
+// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+// when context has expired.
+//
+// An nil error does not mean everything is OK: You also have to check the response error field for specific
+// errors from Kraken API.
+//
+// # Note on the http.Response
+//
+// A reference to the received http.Response is always returned but it may be nil if no response was received.
+// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+// to extract the metadata (or any other kind of data that are not used by the API client directly).
+//
+// Please note response body will always be closed except for RetrieveDataExport.
+func (client *KrakenSpotRESTClient) CreateSubaccount(ctx context.Context, nonce int64, params account.CreateSubaccountRequestParameters, secopts *common.SecurityOptions) (*account.CreateSubaccountResponse, *http.Response, error) {
+	// Prepare form body.
+	form := url.Values{}
+	// Encode nonce and optional common security options
+	EncodeNonceAndSecurityOptions(form, nonce, secopts)
+	// Add parameters
+	form.Set("username", params.Username)
+	form.Set("email", params.Email)
+	// Forge and authorize the request
+	req, err := client.forgeAndAuthorizeKrakenAPIRequest(ctx, createSubaccountPath, http.MethodPost, "application/x-www-form-urlencoded", nil, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to forge and authorize request for CreateSubaccount: %w", err)
+	}
+	// Send the request
+	receiver := new(account.CreateSubaccountResponse)
+	resp, err := client.doKrakenAPIRequest(ctx, req, receiver)
+	if err != nil {
+		return nil, resp, fmt.Errorf("request for CreateSubaccount failed: %w", err)
+	}
+	// Return results
+	return receiver, resp, nil
+}
+
 /*****************************************************************************/
 /* KRAKEN API CLIENT: OPERATIONS - TRADING                                   */
 /*****************************************************************************/
@@ -1939,6 +2223,16 @@ func (client *KrakenSpotRESTClient) DeleteExportReport(ctx context.Context, nonc
 //
 // Please note response body will always be closed except for RetrieveDataExport.
 func (client *KrakenSpotRESTClient) AddOrder(ctx context.Context, nonce int64, params trading.AddOrderRequestParameters, opts *trading.AddOrderRequestOptions, secopts *common.SecurityOptions) (*trading.AddOrderResponse, *http.Response, error) {
+	// Validate the self trade prevention flag
+	if err := trading.ValidateSelfTradePreventionFlag(params.Order.StpType); err != nil {
+		return nil, nil, fmt.Errorf("invalid parameters for AddOrder: %w", err)
+	}
+	// Validate the deadline
+	if opts != nil {
+		if err := trading.ValidateDeadline(opts.Deadline, time.Now()); err != nil {
+			return nil, nil, fmt.Errorf("invalid options for AddOrder: %w", err)
+		}
+	}
 	// Prepare form body.
 	form := url.Values{}
 	// Encode nonce and optional common security options
@@ -1946,9 +2240,11 @@ func (client *KrakenSpotRESTClient) AddOrder(ctx context.Context, nonce int64, p
 	// Add parameters
 	// Set targeted asset pair
 	form.Set("pair", params.Pair)
-	// Add user reference if defined
+	// Add user reference if defined or if set by a request tag
 	if params.Order.UserReference != nil {
 		form.Set("userref", strconv.FormatInt(*params.Order.UserReference, 10))
+	} else if userref, ok := requesttag.UserReference(ctx, client.userReferenceTag); ok {
+		form.Set("userref", strconv.FormatInt(userref, 10))
 	}
 	// Set order type
 	form.Set("ordertype", params.Order.OrderType)
@@ -2015,7 +2311,7 @@ func (client *KrakenSpotRESTClient) AddOrder(ctx context.Context, nonce int64, p
 	if opts != nil {
 		// Set deadline if defined
 		if !opts.Deadline.IsZero() {
-			form.Set("deadline", opts.Deadline.Format(time.RFC3339))
+			form.Set("deadline", trading.FormatDeadline(opts.Deadline))
 		}
 		// Set validate
 		form.Set("validate", strconv.FormatBool(opts.Validate))
@@ -2070,6 +2366,18 @@ func (client *KrakenSpotRESTClient) AddOrder(ctx context.Context, nonce int64, p
 //
 // Please note response body will always be closed except for RetrieveDataExport.
 func (client *KrakenSpotRESTClient) AddOrderBatch(ctx context.Context, nonce int64, params trading.AddOrderBatchRequestParameters, opts *trading.AddOrderBatchRequestOptions, secopts *common.SecurityOptions) (*trading.AddOrderBatchResponse, *http.Response, error) {
+	// Validate the self trade prevention flags
+	for index, order := range params.Orders {
+		if err := trading.ValidateSelfTradePreventionFlag(order.StpType); err != nil {
+			return nil, nil, fmt.Errorf("invalid parameters for AddOrderBatch: order %d: %w", index, err)
+		}
+	}
+	// Validate the deadline
+	if opts != nil {
+		if err := trading.ValidateDeadline(opts.Deadline, time.Now()); err != nil {
+			return nil, nil, fmt.Errorf("invalid options for AddOrderBatch: %w", err)
+		}
+	}
 	// Prepare form body.
 	form := url.Values{}
 	// Encode nonce and optional common security options
@@ -2079,9 +2387,11 @@ func (client *KrakenSpotRESTClient) AddOrderBatch(ctx context.Context, nonce int
 	form.Set("pair", params.Pair)
 	// Set orders
 	for index, order := range params.Orders {
-		// Add user reference if defined
+		// Add user reference if defined or if set by a request tag
 		if order.UserReference != nil {
 			form.Set(fmt.Sprintf("orders[%d][%s]", index, "userref"), strconv.FormatInt(*order.UserReference, 10))
+		} else if userref, ok := requesttag.UserReference(ctx, client.userReferenceTag); ok {
+			form.Set(fmt.Sprintf("orders[%d][%s]", index, "userref"), strconv.FormatInt(userref, 10))
 		}
 
 		// Set order type
@@ -2163,7 +2473,7 @@ func (client *KrakenSpotRESTClient) AddOrderBatch(ctx context.Context, nonce int
 	if opts != nil {
 		// Set deadline if defined
 		if !opts.Deadline.IsZero() {
-			form.Set("deadline", opts.Deadline.Format(time.RFC3339))
+			form.Set("deadline", trading.FormatDeadline(opts.Deadline))
 		}
 		// Set validate
 		form.Set("validate", strconv.FormatBool(opts.Validate))
@@ -2223,6 +2533,12 @@ func (client *KrakenSpotRESTClient) AddOrderBatch(ctx context.Context, nonce int
 //
 // Please note response body will always be closed except for RetrieveDataExport.
 func (client *KrakenSpotRESTClient) EditOrder(ctx context.Context, nonce int64, params trading.EditOrderRequestParameters, opts *trading.EditOrderRequestOptions, secopts *common.SecurityOptions) (*trading.EditOrderResponse, *http.Response, error) {
+	// Validate the deadline
+	if opts != nil {
+		if err := trading.ValidateDeadline(opts.Deadline, time.Now()); err != nil {
+			return nil, nil, fmt.Errorf("invalid options for EditOrder: %w", err)
+		}
+	}
 	// Prepare form body.
 	form := url.Values{}
 	// Encode nonce and optional common security options
@@ -2261,7 +2577,7 @@ func (client *KrakenSpotRESTClient) EditOrder(ctx context.Context, nonce int64,
 		}
 		// Set deadline if defined
 		if !opts.Deadline.IsZero() {
-			form.Set("deadline", opts.Deadline.Format(time.RFC3339))
+			form.Set("deadline", trading.FormatDeadline(opts.Deadline))
 		}
 		// Set cancel_response
 		form.Set("cancel_response", strconv.FormatBool(opts.CancelResponse))
@@ -2283,6 +2599,97 @@ func (client *KrakenSpotRESTClient) EditOrder(ctx context.Context, nonce int64,
 	return receiver, resp, nil
 }
 
+// # Description
+//
+// AmendOrder - Change the quantity or the prices of an open order in place. Unlike EditOrder, the
+// order ID and the queue priority are kept when possible.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - nonce: Nonce used to sign request.
+//   - params: AmendOrder request parameters.
+//   - opts: AmendOrder request options. A nil value triggers all default behaviors.
+//   - secopts: Security options to use for the API call (2FA, ...)
+//
+// # Returns
+//
+//   - AmendOrderResponse: The parsed response from Kraken API.
+//   - http.Response: A reference to the raw HTTP response received from Kraken API.
+//   - error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+//
+// # Note on error
+//
+// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+// when context has expired.
+//
+// An nil error does not mean everything is OK: You also have to check the response error field for specific
+// errors from Kraken API.
+//
+// # Note on the http.Response
+//
+// A reference to the received http.Response is always returned but it may be nil if no response was received.
+// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+// to extract the metadata (or any other kind of data that are not used by the API client directly).
+//
+// Please note response body will always be closed except for RetrieveDataExport.
+func (client *KrakenSpotRESTClient) AmendOrder(ctx context.Context, nonce int64, params trading.AmendOrderRequestParameters, opts *trading.AmendOrderRequestOptions, secopts *common.SecurityOptions) (*trading.AmendOrderResponse, *http.Response, error) {
+	// Validate the deadline
+	if opts != nil {
+		if err := trading.ValidateDeadline(opts.Deadline, time.Now()); err != nil {
+			return nil, nil, fmt.Errorf("invalid options for AmendOrder: %w", err)
+		}
+	}
+	// Prepare form body.
+	form := url.Values{}
+	// Encode nonce and optional common security options
+	EncodeNonceAndSecurityOptions(form, nonce, secopts)
+	// Add parameters
+	if params.TxId != "" {
+		form.Set("txid", params.TxId)
+	}
+	if params.ClientOrderId != "" {
+		form.Set("cl_ord_id", params.ClientOrderId)
+	}
+	// Add options
+	if opts != nil {
+		if opts.OrderQuantity != "" {
+			form.Set("order_qty", opts.OrderQuantity)
+		}
+		if opts.DisplayQuantity != "" {
+			form.Set("display_qty", opts.DisplayQuantity)
+		}
+		if opts.LimitPrice != "" {
+			form.Set("limit_price", opts.LimitPrice)
+		}
+		if opts.TriggerPrice != "" {
+			form.Set("trigger_price", opts.TriggerPrice)
+		}
+		if opts.PostOnly {
+			form.Set("post_only", strconv.FormatBool(opts.PostOnly))
+		}
+		// Set deadline if defined
+		if !opts.Deadline.IsZero() {
+			form.Set("deadline", trading.FormatDeadline(opts.Deadline))
+		}
+	}
+	// Forge and authorize the request
+	req, err := client.forgeAndAuthorizeKrakenAPIRequest(ctx, amendOrderPath, http.MethodPost, "application/x-www-form-urlencoded", nil, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to forge and authorize request for AmendOrder: %w", err)
+	}
+	// Send the request
+	receiver := new(trading.AmendOrderResponse)
+	resp, err := client.doKrakenAPIRequest(ctx, req, receiver)
+	if err != nil {
+		return nil, resp, fmt.Errorf("request for AmendOrder failed: %w", err)
+	}
+	// Return results
+	return receiver, resp, nil
+}
+
 // # Description
 //
 // CancelOrder - Cancel a particular open order (or set of open orders) by txid or userref.
@@ -3157,6 +3564,10 @@ func (client *KrakenSpotRESTClient) RequestWithdrawalCancellation(ctx context.Co
 //
 // Please note response body will always be closed except for RetrieveDataExport.
 func (client *KrakenSpotRESTClient) RequestWalletTransfer(ctx context.Context, nonce int64, params funding.RequestWalletTransferRequestParameters, secopts *common.SecurityOptions) (*funding.RequestWalletTransferResponse, *http.Response, error) {
+	// Check parameters before sending the request
+	if err := params.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid parameters for RequestWalletTransfer: %w", err)
+	}
 	// Prepare form body.
 	form := url.Values{}
 	// Encode nonce and optional common security options
@@ -3181,6 +3592,66 @@ func (client *KrakenSpotRESTClient) RequestWalletTransfer(ctx context.Context, n
 	return receiver, resp, nil
 }
 
+// # Description
+//
+// AccountTransfer - Transfer funds between the master account and its subaccounts. The API key
+// must have been created from the master account.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - nonce: Nonce used to sign request.
+//   - params: AccountTransfer request parameters.
+//   - secopts: Security options to use for the API call (2FA, ...)
+//
+// # Returns
+//
+//   - AccountTransferResponse: The parsed response from Kraken API.
+//   - http.Response: A reference to the raw HTTP response received from Kraken API.
+//   - error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+//
+// # Note on error
+//
+// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+// when context has expired.
+//
+// An nil error does not mean everything is OK: You also have to check the response error field for specific
+// errors from Kraken API.
+//
+// # Note on the http.Response
+//
+// A reference to the received http.Response is always returned but it may be nil if no response was received.
+// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+// to extract the metadata (or any other kind of data that are not used by the API client directly).
+//
+// Please note response body will always be closed except for RetrieveDataExport.
+func (client *KrakenSpotRESTClient) AccountTransfer(ctx context.Context, nonce int64, params funding.AccountTransferRequestParameters, secopts *common.SecurityOptions) (*funding.AccountTransferResponse, *http.Response, error) {
+	// Prepare form body.
+	form := url.Values{}
+	// Encode nonce and optional common security options
+	EncodeNonceAndSecurityOptions(form, nonce, secopts)
+	// Add parameters
+	form.Set("asset", params.Asset)
+	form.Set("amount", params.Amount)
+	form.Set("from", params.From)
+	form.Set("to", params.To)
+	// Forge and authorize the request
+	req, err := client.forgeAndAuthorizeKrakenAPIRequest(ctx, accountTransferPath, http.MethodPost, "application/x-www-form-urlencoded", nil, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to forge and authorize request for AccountTransfer: %w", err)
+	}
+	// Send the request
+	receiver := new(funding.AccountTransferResponse)
+	resp, err := client.doKrakenAPIRequest(ctx, req, receiver)
+	if err != nil {
+		return nil, resp, fmt.Errorf("request for AccountTransfer failed: %w", err)
+	}
+	// Return results
+	return receiver, resp, nil
+}
+
 /*****************************************************************************/
 /* KRAKEN API CLIENT: OPERATIONS - EARN                                      */
 /*****************************************************************************/
@@ -3630,6 +4101,301 @@ func (client *KrakenSpotRESTClient) ListEarnAllocations(ctx context.Context, non
 	return receiver, resp, nil
 }
 
+/*****************************************************************************/
+/* KRAKEN API CLIENT: OPERATIONS - STAKING                                   */
+/*****************************************************************************/
+
+// # Description
+//
+// Stake - Stake an asset from your spot wallet. This operation requires an API key with Withdraw
+// funds permission.
+//
+// This is a legacy endpoint. Kraken recommends using the Earn endpoints (Cf. AllocateEarnFunds).
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - nonce: Nonce used to sign request.
+//   - params: Stake request parameters.
+//   - secopts: Security options to use for the API call (2FA, ...)
+//
+// # Returns
+//
+//   - StakeResponse: The parsed response from Kraken API.
+//   - http.Response: A reference to the raw HTTP response received from Kraken API.
+//   - error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+//
+// # Note on error
+//
+// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+// when context has expired.
+//
+// An nil error does not mean everything is OK: You also have to check the response error field for specific
+// errors from Kraken API.
+//
+// # Note on the http.Response
+//
+// A reference to the received http.Response is always returned but it may be nil if no response was received.
+// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+// to extract the metadata (or any other kind of data that are not used by the API client directly).
+//
+// Please note response body will always be closed except for RetrieveDataExport.
+func (client *KrakenSpotRESTClient) Stake(ctx context.Context, nonce int64, params staking.StakeRequestParameters, secopts *common.SecurityOptions) (*staking.StakeResponse, *http.Response, error) {
+	// Prepare form body.
+	form := url.Values{}
+	// Encode nonce and optional common security options
+	EncodeNonceAndSecurityOptions(form, nonce, secopts)
+	// Add parameters
+	form.Set("asset", params.Asset)
+	form.Set("amount", params.Amount)
+	form.Set("method", params.Method)
+	// Forge and authorize the request
+	req, err := client.forgeAndAuthorizeKrakenAPIRequest(ctx, stakePath, http.MethodPost, "application/x-www-form-urlencoded", nil, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to forge and authorize request for Stake: %w", err)
+	}
+	// Send the request
+	receiver := new(staking.StakeResponse)
+	resp, err := client.doKrakenAPIRequest(ctx, req, receiver)
+	if err != nil {
+		return nil, resp, fmt.Errorf("request for Stake failed: %w", err)
+	}
+	// Return results
+	return receiver, resp, nil
+}
+
+// # Description
+//
+// Unstake - Unstake an asset from your staking wallet. This operation requires an API key with
+// Withdraw funds permission.
+//
+// This is a legacy endpoint. Kraken recommends using the Earn endpoints (Cf. DeallocateEarnFunds).
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - nonce: Nonce used to sign request.
+//   - params: Unstake request parameters.
+//   - secopts: Security options to use for the API call (2FA, ...)
+//
+// # Returns
+//
+//   - UnstakeResponse: The parsed response from Kraken API.
+//   - http.Response: A reference to the raw HTTP response received from Kraken API.
+//   - error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+//
+// # Note on error
+//
+// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+// when context has expired.
+//
+// An nil error does not mean everything is OK: You also have to check the response error field for specific
+// errors from Kraken API.
+//
+// # Note on the http.Response
+//
+// A reference to the received http.Response is always returned but it may be nil if no response was received.
+// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+// to extract the metadata (or any other kind of data that are not used by the API client directly).
+//
+// Please note response body will always be closed except for RetrieveDataExport.
+func (client *KrakenSpotRESTClient) Unstake(ctx context.Context, nonce int64, params staking.UnstakeRequestParameters, secopts *common.SecurityOptions) (*staking.UnstakeResponse, *http.Response, error) {
+	// Prepare form body.
+	form := url.Values{}
+	// Encode nonce and optional common security options
+	EncodeNonceAndSecurityOptions(form, nonce, secopts)
+	// Add parameters
+	form.Set("asset", params.Asset)
+	form.Set("amount", params.Amount)
+	// Forge and authorize the request
+	req, err := client.forgeAndAuthorizeKrakenAPIRequest(ctx, unstakePath, http.MethodPost, "application/x-www-form-urlencoded", nil, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to forge and authorize request for Unstake: %w", err)
+	}
+	// Send the request
+	receiver := new(staking.UnstakeResponse)
+	resp, err := client.doKrakenAPIRequest(ctx, req, receiver)
+	if err != nil {
+		return nil, resp, fmt.Errorf("request for Unstake failed: %w", err)
+	}
+	// Return results
+	return receiver, resp, nil
+}
+
+// # Description
+//
+// ListStakeableAssets - Returns the list of assets that the user is able to stake. This operation
+// requires an API key with both Withdraw funds and Query funds permission.
+//
+// This is a legacy endpoint. Kraken recommends using the Earn endpoints (Cf. ListEarnStrategies).
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - nonce: Nonce used to sign request.
+//   - secopts: Security options to use for the API call (2FA, ...)
+//
+// # Returns
+//
+//   - ListStakeableAssetsResponse: The parsed response from Kraken API.
+//   - http.Response: A reference to the raw HTTP response received from Kraken API.
+//   - error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+//
+// # Note on error
+//
+// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+// when context has expired.
+//
+// An nil error does not mean everything is OK: You also have to check the response error field for specific
+// errors from Kraken API.
+//
+// # Note on the http.Response
+//
+// A reference to the received http.Response is always returned but it may be nil if no response was received.
+// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+// to extract the metadata (or any other kind of data that are not used by the API client directly).
+//
+// Please note response body will always be closed except for RetrieveDataExport.
+func (client *KrakenSpotRESTClient) ListStakeableAssets(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*staking.ListStakeableAssetsResponse, *http.Response, error) {
+	// Prepare form body.
+	form := url.Values{}
+	// Encode nonce and optional common security options
+	EncodeNonceAndSecurityOptions(form, nonce, secopts)
+	// Forge and authorize the request
+	req, err := client.forgeAndAuthorizeKrakenAPIRequest(ctx, listStakeableAssetsPath, http.MethodPost, "application/x-www-form-urlencoded", nil, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to forge and authorize request for ListStakeableAssets: %w", err)
+	}
+	// Send the request
+	receiver := new(staking.ListStakeableAssetsResponse)
+	resp, err := client.doKrakenAPIRequest(ctx, req, receiver)
+	if err != nil {
+		return nil, resp, fmt.Errorf("request for ListStakeableAssets failed: %w", err)
+	}
+	// Return results
+	return receiver, resp, nil
+}
+
+// # Description
+//
+// GetPendingStakingTransactions - Returns the list of pending staking transactions. Once resolved,
+// these transactions will appear on the list of recent staking transactions (Cf.
+// ListRecentStakingTransactions). This operation requires an API key with both Query funds and
+// Withdraw funds permissions.
+//
+// This is a legacy endpoint. Kraken recommends using the Earn endpoints.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - nonce: Nonce used to sign request.
+//   - secopts: Security options to use for the API call (2FA, ...)
+//
+// # Returns
+//
+//   - GetPendingStakingTransactionsResponse: The parsed response from Kraken API.
+//   - http.Response: A reference to the raw HTTP response received from Kraken API.
+//   - error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+//
+// # Note on error
+//
+// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+// when context has expired.
+//
+// An nil error does not mean everything is OK: You also have to check the response error field for specific
+// errors from Kraken API.
+//
+// # Note on the http.Response
+//
+// A reference to the received http.Response is always returned but it may be nil if no response was received.
+// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+// to extract the metadata (or any other kind of data that are not used by the API client directly).
+//
+// Please note response body will always be closed except for RetrieveDataExport.
+func (client *KrakenSpotRESTClient) GetPendingStakingTransactions(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*staking.GetPendingStakingTransactionsResponse, *http.Response, error) {
+	// Prepare form body.
+	form := url.Values{}
+	// Encode nonce and optional common security options
+	EncodeNonceAndSecurityOptions(form, nonce, secopts)
+	// Forge and authorize the request
+	req, err := client.forgeAndAuthorizeKrakenAPIRequest(ctx, getPendingStakingTransactionsPath, http.MethodPost, "application/x-www-form-urlencoded", nil, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to forge and authorize request for GetPendingStakingTransactions: %w", err)
+	}
+	// Send the request
+	receiver := new(staking.GetPendingStakingTransactionsResponse)
+	resp, err := client.doKrakenAPIRequest(ctx, req, receiver)
+	if err != nil {
+		return nil, resp, fmt.Errorf("request for GetPendingStakingTransactions failed: %w", err)
+	}
+	// Return results
+	return receiver, resp, nil
+}
+
+// # Description
+//
+// ListRecentStakingTransactions - Returns the list of 1000 recent staking transactions from past 90
+// days. This operation requires an API key with Query funds permissions.
+//
+// This is a legacy endpoint. Kraken recommends using the Earn endpoints.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - nonce: Nonce used to sign request.
+//   - secopts: Security options to use for the API call (2FA, ...)
+//
+// # Returns
+//
+//   - ListRecentStakingTransactionsResponse: The parsed response from Kraken API.
+//   - http.Response: A reference to the raw HTTP response received from Kraken API.
+//   - error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+//
+// # Note on error
+//
+// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+// when context has expired.
+//
+// An nil error does not mean everything is OK: You also have to check the response error field for specific
+// errors from Kraken API.
+//
+// # Note on the http.Response
+//
+// A reference to the received http.Response is always returned but it may be nil if no response was received.
+// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+// to extract the metadata (or any other kind of data that are not used by the API client directly).
+//
+// Please note response body will always be closed except for RetrieveDataExport.
+func (client *KrakenSpotRESTClient) ListRecentStakingTransactions(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*staking.ListRecentStakingTransactionsResponse, *http.Response, error) {
+	// Prepare form body.
+	form := url.Values{}
+	// Encode nonce and optional common security options
+	EncodeNonceAndSecurityOptions(form, nonce, secopts)
+	// Forge and authorize the request
+	req, err := client.forgeAndAuthorizeKrakenAPIRequest(ctx, listRecentStakingTransactionsPath, http.MethodPost, "application/x-www-form-urlencoded", nil, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to forge and authorize request for ListRecentStakingTransactions: %w", err)
+	}
+	// Send the request
+	receiver := new(staking.ListRecentStakingTransactionsResponse)
+	resp, err := client.doKrakenAPIRequest(ctx, req, receiver)
+	if err != nil {
+		return nil, resp, fmt.Errorf("request for ListRecentStakingTransactions failed: %w", err)
+	}
+	// Return results
+	return receiver, resp, nil
+}
+
 /*****************************************************************************/
 /* KRAKEN API CLIENT: OPERATIONS - WEBSOCKET                                 */
 /*****************************************************************************/
@@ -6,11 +6,14 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/gbdevw/purple-goctopus/sdk/spot/clienttag"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/requesttag"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/earn"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/funding"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/staking"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/tracing"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/websocket"
@@ -38,6 +41,10 @@ type KrakenSpotRESTClientInstrumentationDecorator struct {
 //   - decorated: The KrakenSpotRESTClientIface implentation to decorate. Must no be nil.
 //   - tracerProvider: Tracer provider to use to get the tracer used by the decorator to instrument code. If nil, the global tracer provider will be used (can be a NoopTracerProvider).
 //
+// If the decorated has a client tag (Cf. KrakenSpotRESTClientConfiguration.ClientTag), the tag is
+// added to every span started by the decorator. The tags set in the context of each call (Cf.
+// requesttag.WithTag) are added to the span of the call.
+//
 // # Returns
 //
 // The decorator which decorates the provided KrakenSpotRESTClientIface implementation.
@@ -51,10 +58,14 @@ func InstrumentKrakenSpotRESTClient(decorated KrakenSpotRESTClientIface, tracerP
 		// In case the global tracer provider is not configured, its default behavior is to return a NoopTracerProvider.
 		tracerProvider = otel.GetTracerProvider()
 	}
+	if tagged, ok := decorated.(interface{ GetClientTag() string }); ok {
+		// Add the client tag to every span
+		tracerProvider = clienttag.TracerProvider(tracerProvider, tagged.GetClientTag())
+	}
 	// Return decorator
 	return &KrakenSpotRESTClientInstrumentationDecorator{
 		decorated: decorated,
-		tracer:    tracerProvider.Tracer(tracing.PackageName, trace.WithInstrumentationVersion(tracing.PackageVersion)),
+		tracer:    requesttag.Tracer(tracerProvider.Tracer(tracing.PackageName, trace.WithInstrumentationVersion(tracing.PackageVersion))),
 	}
 }
 
@@ -440,6 +451,9 @@ func (dec *KrakenSpotRESTClientInstrumentationDecorator) GetOpenOrders(ctx conte
 		if opts.UserReference != nil {
 			reqAttributes = append(reqAttributes, attribute.Int64("userref", *opts.UserReference))
 		}
+		if opts.ClientOrderId != "" {
+			reqAttributes = append(reqAttributes, attribute.String("cl_ord_id", opts.ClientOrderId))
+		}
 	}
 	// Start a span
 	ctx, span := dec.tracer.Start(
@@ -471,6 +485,7 @@ func (dec *KrakenSpotRESTClientInstrumentationDecorator) GetClosedOrders(ctx con
 	if opts != nil {
 		reqAttributes = append(reqAttributes, attribute.Bool("trades", opts.Trades))
 		reqAttributes = append(reqAttributes, attribute.Bool("consolidate_taker", opts.ConsolidateTaker))
+		reqAttributes = append(reqAttributes, attribute.Bool("without_count", opts.WithoutCount))
 		if opts.UserReference != nil {
 			reqAttributes = append(reqAttributes, attribute.Int64("userref", *opts.UserReference))
 		}
@@ -486,6 +501,9 @@ func (dec *KrakenSpotRESTClientInstrumentationDecorator) GetClosedOrders(ctx con
 		if opts.Offset != 0 {
 			reqAttributes = append(reqAttributes, attribute.Int64("ofs", opts.Offset))
 		}
+		if opts.ClientOrderId != "" {
+			reqAttributes = append(reqAttributes, attribute.String("cl_ord_id", opts.ClientOrderId))
+		}
 	}
 	// Start a span
 	ctx, span := dec.tracer.Start(
@@ -550,6 +568,39 @@ func (dec *KrakenSpotRESTClientInstrumentationDecorator) QueryOrdersInfo(ctx con
 	return resp, httpresp, err
 }
 
+// Trace GetOrderAmends execution.
+func (dec *KrakenSpotRESTClientInstrumentationDecorator) GetOrderAmends(ctx context.Context, nonce int64, params account.GetOrderAmendsRequestParameters, opts *account.GetOrderAmendsRequestOptions, secopts *common.SecurityOptions) (*account.GetOrderAmendsResponse, *http.Response, error) {
+	// Build attributes that will be added to span and that will record request settings
+	reqAttributes := []attribute.KeyValue{
+		attribute.Int64("nonce", nonce),
+		attribute.String("order_id", params.OrderId),
+	}
+	if opts != nil && opts.RebaseMultiplier != "" {
+		reqAttributes = append(reqAttributes, attribute.String("rebase_multiplier", opts.RebaseMultiplier))
+	}
+	// Start a span
+	ctx, span := dec.tracer.Start(
+		ctx,
+		tracing.TracesNamespace+".get_order_amends",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(reqAttributes...))
+	defer span.End()
+	// Call decorated
+	resp, httpresp, err := dec.decorated.GetOrderAmends(ctx, nonce, params, opts, secopts)
+	// Add custom event and interesting values for received API response if any
+	if resp != nil {
+		respAttributes := []attribute.KeyValue{attribute.StringSlice("error", resp.Error)}
+		if resp.Result != nil {
+			respAttributes = append(respAttributes, attribute.Int("count", resp.Result.Count))
+		}
+		span.AddEvent(tracing.TracesNamespace+".get_order_amends.response", trace.WithAttributes(respAttributes...))
+	}
+	// Trace error and set span status
+	tracing.TraceApiOperationAndSetStatus(span, &resp.KrakenSpotRESTResponse, httpresp, err)
+	// Return results
+	return resp, httpresp, err
+}
+
 // Trace GetTradesHistory execution.
 func (dec *KrakenSpotRESTClientInstrumentationDecorator) GetTradesHistory(ctx context.Context, nonce int64, opts *account.GetTradesHistoryRequestOptions, secopts *common.SecurityOptions) (*account.GetTradesHistoryResponse, *http.Response, error) {
 	// Build attributes that will be added to span and that will record request settings
@@ -557,6 +608,7 @@ func (dec *KrakenSpotRESTClientInstrumentationDecorator) GetTradesHistory(ctx co
 	if opts != nil {
 		reqAttributes = append(reqAttributes, attribute.Bool("trades", opts.Trades))
 		reqAttributes = append(reqAttributes, attribute.Bool("consolidate_taker", opts.ConsolidateTaker))
+		reqAttributes = append(reqAttributes, attribute.Bool("ledgers", opts.Ledgers))
 		if opts.Start != "" {
 			reqAttributes = append(reqAttributes, attribute.String("start", opts.Start))
 		}
@@ -900,6 +952,36 @@ func (dec *KrakenSpotRESTClientInstrumentationDecorator) DeleteExportReport(ctx
 	return resp, httpresp, err
 }
 
+// Trace CreateSubaccount execution.
+func (dec *KrakenSpotRESTClientInstrumentationDecorator) CreateSubaccount(ctx context.Context, nonce int64, params account.CreateSubaccountRequestParameters, secopts *common.SecurityOptions) (*account.CreateSubaccountResponse, *http.Response, error) {
+	// Build attributes that will be added to span and that will record request settings
+	reqAttributes := []attribute.KeyValue{
+		attribute.Int64("nonce", nonce),
+		attribute.String("username", params.Username),
+	}
+	// Start a span
+	ctx, span := dec.tracer.Start(
+		ctx,
+		tracing.TracesNamespace+".create_subaccount",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(reqAttributes...))
+	defer span.End()
+	// Call decorated
+	resp, httpresp, err := dec.decorated.CreateSubaccount(ctx, nonce, params, secopts)
+	// Add custom event and interesting values for received API response if any
+	if resp != nil {
+		respAttributes := []attribute.KeyValue{
+			attribute.StringSlice("error", resp.Error),
+			attribute.Bool("result", resp.Result),
+		}
+		span.AddEvent(tracing.TracesNamespace+".create_subaccount.response", trace.WithAttributes(respAttributes...))
+	}
+	// Trace error and set span status
+	tracing.TraceApiOperationAndSetStatus(span, &resp.KrakenSpotRESTResponse, httpresp, err)
+	// Return results
+	return resp, httpresp, err
+}
+
 // Trace AddOrder execution.
 func (dec *KrakenSpotRESTClientInstrumentationDecorator) AddOrder(ctx context.Context, nonce int64, params trading.AddOrderRequestParameters, opts *trading.AddOrderRequestOptions, secopts *common.SecurityOptions) (*trading.AddOrderResponse, *http.Response, error) {
 	// Build attributes that will be added to span and that will record request settings
@@ -955,7 +1037,7 @@ func (dec *KrakenSpotRESTClientInstrumentationDecorator) AddOrder(ctx context.Co
 	if opts != nil {
 		reqAttributes = append(reqAttributes, attribute.Bool("validate", opts.Validate))
 		if !opts.Deadline.IsZero() {
-			reqAttributes = append(reqAttributes, attribute.String("deadline", opts.Deadline.Format(time.RFC3339)))
+			reqAttributes = append(reqAttributes, attribute.String("deadline", trading.FormatDeadline(opts.Deadline)))
 		}
 	}
 	// Start a span
@@ -1043,7 +1125,7 @@ func (dec *KrakenSpotRESTClientInstrumentationDecorator) AddOrderBatch(ctx conte
 	if opts != nil {
 		reqAttributes = append(reqAttributes, attribute.Bool("validate", opts.Validate))
 		if !opts.Deadline.IsZero() {
-			reqAttributes = append(reqAttributes, attribute.String("deadline", opts.Deadline.Format(time.RFC3339)))
+			reqAttributes = append(reqAttributes, attribute.String("deadline", trading.FormatDeadline(opts.Deadline)))
 		}
 	}
 	// Start a span
@@ -1108,7 +1190,7 @@ func (dec *KrakenSpotRESTClientInstrumentationDecorator) EditOrder(ctx context.C
 		reqAttributes = append(reqAttributes, attribute.Bool("validate", opts.Validate))
 		reqAttributes = append(reqAttributes, attribute.Bool("cancel_response", opts.CancelResponse))
 		if !opts.Deadline.IsZero() {
-			reqAttributes = append(reqAttributes, attribute.String("deadline", opts.Deadline.Format(time.RFC3339)))
+			reqAttributes = append(reqAttributes, attribute.String("deadline", trading.FormatDeadline(opts.Deadline)))
 		}
 	}
 	// Start a span
@@ -1169,6 +1251,57 @@ func (dec *KrakenSpotRESTClientInstrumentationDecorator) EditOrder(ctx context.C
 	return resp, httpresp, err
 }
 
+// Trace AmendOrder execution.
+func (dec *KrakenSpotRESTClientInstrumentationDecorator) AmendOrder(ctx context.Context, nonce int64, params trading.AmendOrderRequestParameters, opts *trading.AmendOrderRequestOptions, secopts *common.SecurityOptions) (*trading.AmendOrderResponse, *http.Response, error) {
+	// Build attributes that will be added to span and that will record request settings
+	reqAttributes := []attribute.KeyValue{attribute.Int64("nonce", nonce)}
+	if params.TxId != "" {
+		reqAttributes = append(reqAttributes, attribute.String("txid", params.TxId))
+	}
+	if params.ClientOrderId != "" {
+		reqAttributes = append(reqAttributes, attribute.String("cl_ord_id", params.ClientOrderId))
+	}
+	if opts != nil {
+		if opts.OrderQuantity != "" {
+			reqAttributes = append(reqAttributes, attribute.String("order_qty", opts.OrderQuantity))
+		}
+		if opts.DisplayQuantity != "" {
+			reqAttributes = append(reqAttributes, attribute.String("display_qty", opts.DisplayQuantity))
+		}
+		if opts.LimitPrice != "" {
+			reqAttributes = append(reqAttributes, attribute.String("limit_price", opts.LimitPrice))
+		}
+		if opts.TriggerPrice != "" {
+			reqAttributes = append(reqAttributes, attribute.String("trigger_price", opts.TriggerPrice))
+		}
+		reqAttributes = append(reqAttributes, attribute.Bool("post_only", opts.PostOnly))
+		if !opts.Deadline.IsZero() {
+			reqAttributes = append(reqAttributes, attribute.String("deadline", trading.FormatDeadline(opts.Deadline)))
+		}
+	}
+	// Start a span
+	ctx, span := dec.tracer.Start(
+		ctx,
+		tracing.TracesNamespace+".amend_order",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(reqAttributes...))
+	defer span.End()
+	// Call decorated
+	resp, httpresp, err := dec.decorated.AmendOrder(ctx, nonce, params, opts, secopts)
+	// Add custom event and interesting values for received API response if any
+	if resp != nil {
+		respAttributes := []attribute.KeyValue{attribute.StringSlice("error", resp.Error)}
+		if resp.Result != nil {
+			respAttributes = append(respAttributes, attribute.String("amend_id", resp.Result.AmendId))
+		}
+		span.AddEvent(tracing.TracesNamespace+".amend_order.response", trace.WithAttributes(respAttributes...))
+	}
+	// Trace error and set span status
+	tracing.TraceApiOperationAndSetStatus(span, &resp.KrakenSpotRESTResponse, httpresp, err)
+	// Return results
+	return resp, httpresp, err
+}
+
 // Trace CancelOrder execution.
 func (dec *KrakenSpotRESTClientInstrumentationDecorator) CancelOrder(ctx context.Context, nonce int64, params trading.CancelOrderRequestParameters, secopts *common.SecurityOptions) (*trading.CancelOrderResponse, *http.Response, error) {
 	// Build attributes that will be added to span and that will record request settings
@@ -1655,6 +1788,42 @@ func (dec *KrakenSpotRESTClientInstrumentationDecorator) RequestWalletTransfer(c
 	return resp, httpresp, err
 }
 
+// Trace AccountTransfer execution.
+func (dec *KrakenSpotRESTClientInstrumentationDecorator) AccountTransfer(ctx context.Context, nonce int64, params funding.AccountTransferRequestParameters, secopts *common.SecurityOptions) (*funding.AccountTransferResponse, *http.Response, error) {
+	// Build attributes that will be added to span and that will record request settings
+	reqAttributes := []attribute.KeyValue{
+		attribute.Int64("nonce", nonce),
+		attribute.String("asset", params.Asset),
+		attribute.String("amount", params.Amount),
+		attribute.String("from", params.From),
+		attribute.String("to", params.To),
+	}
+	// Start a span
+	ctx, span := dec.tracer.Start(
+		ctx,
+		tracing.TracesNamespace+".account_transfer",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(reqAttributes...))
+	defer span.End()
+	// Call decorated
+	resp, httpresp, err := dec.decorated.AccountTransfer(ctx, nonce, params, secopts)
+	// Add custom event and interesting values for received API response if any
+	if resp != nil {
+		respAttributes := []attribute.KeyValue{attribute.StringSlice("error", resp.Error)}
+		if resp.Result != nil {
+			respAttributes = append(
+				respAttributes,
+				attribute.String("transfer_id", resp.Result.TransferId),
+				attribute.String("status", resp.Result.Status))
+		}
+		span.AddEvent(tracing.TracesNamespace+".account_transfer.response", trace.WithAttributes(respAttributes...))
+	}
+	// Trace error and set span status
+	tracing.TraceApiOperationAndSetStatus(span, &resp.KrakenSpotRESTResponse, httpresp, err)
+	// Return results
+	return resp, httpresp, err
+}
+
 // Trace AllocateEarnFunds execution.
 func (dec *KrakenSpotRESTClientInstrumentationDecorator) AllocateEarnFunds(ctx context.Context, nonce int64, params earn.AllocateEarnFundsRequestParameters, secopts *common.SecurityOptions) (*earn.AllocateEarnFundsResponse, *http.Response, error) {
 	// Build attributes that will be added to span and that will record request settings
@@ -1861,6 +2030,150 @@ func (dec *KrakenSpotRESTClientInstrumentationDecorator) ListEarnAllocations(ctx
 	return resp, httpresp, err
 }
 
+// Trace Stake execution.
+func (dec *KrakenSpotRESTClientInstrumentationDecorator) Stake(ctx context.Context, nonce int64, params staking.StakeRequestParameters, secopts *common.SecurityOptions) (*staking.StakeResponse, *http.Response, error) {
+	// Build attributes that will be added to span and that will record request settings
+	reqAttributes := []attribute.KeyValue{
+		attribute.Int64("nonce", nonce),
+		attribute.String("asset", params.Asset),
+		attribute.String("amount", params.Amount),
+		attribute.String("method", params.Method),
+	}
+	// Start a span
+	ctx, span := dec.tracer.Start(
+		ctx,
+		tracing.TracesNamespace+".stake",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(reqAttributes...))
+	defer span.End()
+	// Call decorated
+	resp, httpresp, err := dec.decorated.Stake(ctx, nonce, params, secopts)
+	// Add custom event and interesting values for received API response if any
+	if resp != nil {
+		respAttributes := []attribute.KeyValue{attribute.StringSlice("error", resp.Error)}
+		if resp.Result != nil {
+			respAttributes = append(respAttributes, attribute.String("refid", resp.Result.ReferenceId))
+		}
+		span.AddEvent(tracing.TracesNamespace+".stake.response", trace.WithAttributes(respAttributes...))
+	}
+	// Trace error and set span status
+	tracing.TraceApiOperationAndSetStatus(span, &resp.KrakenSpotRESTResponse, httpresp, err)
+	// Return results
+	return resp, httpresp, err
+}
+
+// Trace Unstake execution.
+func (dec *KrakenSpotRESTClientInstrumentationDecorator) Unstake(ctx context.Context, nonce int64, params staking.UnstakeRequestParameters, secopts *common.SecurityOptions) (*staking.UnstakeResponse, *http.Response, error) {
+	// Build attributes that will be added to span and that will record request settings
+	reqAttributes := []attribute.KeyValue{
+		attribute.Int64("nonce", nonce),
+		attribute.String("asset", params.Asset),
+		attribute.String("amount", params.Amount),
+	}
+	// Start a span
+	ctx, span := dec.tracer.Start(
+		ctx,
+		tracing.TracesNamespace+".unstake",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(reqAttributes...))
+	defer span.End()
+	// Call decorated
+	resp, httpresp, err := dec.decorated.Unstake(ctx, nonce, params, secopts)
+	// Add custom event and interesting values for received API response if any
+	if resp != nil {
+		respAttributes := []attribute.KeyValue{attribute.StringSlice("error", resp.Error)}
+		if resp.Result != nil {
+			respAttributes = append(respAttributes, attribute.String("refid", resp.Result.ReferenceId))
+		}
+		span.AddEvent(tracing.TracesNamespace+".unstake.response", trace.WithAttributes(respAttributes...))
+	}
+	// Trace error and set span status
+	tracing.TraceApiOperationAndSetStatus(span, &resp.KrakenSpotRESTResponse, httpresp, err)
+	// Return results
+	return resp, httpresp, err
+}
+
+// Trace ListStakeableAssets execution.
+func (dec *KrakenSpotRESTClientInstrumentationDecorator) ListStakeableAssets(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*staking.ListStakeableAssetsResponse, *http.Response, error) {
+	// Build attributes that will be added to span and that will record request settings
+	reqAttributes := []attribute.KeyValue{attribute.Int64("nonce", nonce)}
+	// Start a span
+	ctx, span := dec.tracer.Start(
+		ctx,
+		tracing.TracesNamespace+".list_stakeable_assets",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(reqAttributes...))
+	defer span.End()
+	// Call decorated
+	resp, httpresp, err := dec.decorated.ListStakeableAssets(ctx, nonce, secopts)
+	// Add custom event and interesting values for received API response if any
+	if resp != nil {
+		respAttributes := []attribute.KeyValue{
+			attribute.StringSlice("error", resp.Error),
+			attribute.Int("count", len(resp.Result)),
+		}
+		span.AddEvent(tracing.TracesNamespace+".list_stakeable_assets.response", trace.WithAttributes(respAttributes...))
+	}
+	// Trace error and set span status
+	tracing.TraceApiOperationAndSetStatus(span, &resp.KrakenSpotRESTResponse, httpresp, err)
+	// Return results
+	return resp, httpresp, err
+}
+
+// Trace GetPendingStakingTransactions execution.
+func (dec *KrakenSpotRESTClientInstrumentationDecorator) GetPendingStakingTransactions(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*staking.GetPendingStakingTransactionsResponse, *http.Response, error) {
+	// Build attributes that will be added to span and that will record request settings
+	reqAttributes := []attribute.KeyValue{attribute.Int64("nonce", nonce)}
+	// Start a span
+	ctx, span := dec.tracer.Start(
+		ctx,
+		tracing.TracesNamespace+".get_pending_staking_transactions",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(reqAttributes...))
+	defer span.End()
+	// Call decorated
+	resp, httpresp, err := dec.decorated.GetPendingStakingTransactions(ctx, nonce, secopts)
+	// Add custom event and interesting values for received API response if any
+	if resp != nil {
+		respAttributes := []attribute.KeyValue{
+			attribute.StringSlice("error", resp.Error),
+			attribute.Int("count", len(resp.Result)),
+		}
+		span.AddEvent(tracing.TracesNamespace+".get_pending_staking_transactions.response", trace.WithAttributes(respAttributes...))
+	}
+	// Trace error and set span status
+	tracing.TraceApiOperationAndSetStatus(span, &resp.KrakenSpotRESTResponse, httpresp, err)
+	// Return results
+	return resp, httpresp, err
+}
+
+// Trace ListRecentStakingTransactions execution.
+func (dec *KrakenSpotRESTClientInstrumentationDecorator) ListRecentStakingTransactions(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*staking.ListRecentStakingTransactionsResponse, *http.Response, error) {
+	// Build attributes that will be added to span and that will record request settings
+	reqAttributes := []attribute.KeyValue{attribute.Int64("nonce", nonce)}
+	// Start a span
+	ctx, span := dec.tracer.Start(
+		ctx,
+		tracing.TracesNamespace+".list_recent_staking_transactions",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(reqAttributes...))
+	defer span.End()
+	// Call decorated
+	resp, httpresp, err := dec.decorated.ListRecentStakingTransactions(ctx, nonce, secopts)
+	// Add custom event and interesting values for received API response if any
+	if resp != nil {
+		respAttributes := []attribute.KeyValue{
+			attribute.StringSlice("error", resp.Error),
+			attribute.Int("count", len(resp.Result)),
+		}
+		span.AddEvent(tracing.TracesNamespace+".list_recent_staking_transactions.response", trace.WithAttributes(respAttributes...))
+	}
+	// Trace error and set span status
+	tracing.TraceApiOperationAndSetStatus(span, &resp.KrakenSpotRESTResponse, httpresp, err)
+	// Return results
+	return resp, httpresp, err
+}
+
 // Trace GetWebsocketToken execution
 func (dec *KrakenSpotRESTClientInstrumentationDecorator) GetWebsocketToken(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*websocket.GetWebsocketTokenResponse, *http.Response, error) {
 	// Build attributes that will be added to span and that will record request settings
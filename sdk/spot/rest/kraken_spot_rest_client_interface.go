@@ -9,6 +9,7 @@ import (
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/earn"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/funding"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/staking"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/websocket"
 )
@@ -535,6 +536,42 @@ type KrakenSpotRESTClientIface interface {
 	QueryOrdersInfo(ctx context.Context, nonce int64, params account.QueryOrdersInfoParameters, opts *account.QueryOrdersInfoRequestOptions, secopts *common.SecurityOptions) (*account.QueryOrdersInfoResponse, *http.Response, error)
 	// # Description
 	//
+	// GetOrderAmends - Retrieve the amends history of an order, including the original order values.
+	//
+	// # Inputs
+	//
+	//	- ctx: Context used for tracing and coordination purpose.
+	//	- nonce: Nonce used to sign request.
+	//	- params: GetOrderAmends request parameters.
+	//	- opts: GetOrderAmends request options. A nil value triggers all default behaviors.
+	//	- secopts: Security options to use for the API call (2FA, ...)
+	//
+	// # Returns
+	//
+	//	- GetOrderAmendsResponse: The parsed response from Kraken API.
+	//	- http.Response: A reference to the raw HTTP response received from Kraken API.
+	//	- error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+	//
+	// # Note on error
+	//
+	// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+	// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+	// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+	// when context has expired.
+	//
+	// An nil error does not mean everything is OK: You also have to check the response error field for specific
+	// errors from Kraken API.
+	//
+	// # Note on the http.Response
+	//
+	// A reference to the received http.Response is always returned but it may be nil if no response was received.
+	// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+	// to extract the metadata (or any other kind of data that are not used by the API client directly).
+	//
+	// Please note response body will always be closed except for RetrieveDataExport.
+	GetOrderAmends(ctx context.Context, nonce int64, params account.GetOrderAmendsRequestParameters, opts *account.GetOrderAmendsRequestOptions, secopts *common.SecurityOptions) (*account.GetOrderAmendsResponse, *http.Response, error)
+	// # Description
+	//
 	// GetTradesHistory - Retrieve information about trades/fills. 50 results are returned at a time, the most recent by default.
 	//
 	// # Inputs
@@ -892,6 +929,42 @@ type KrakenSpotRESTClientIface interface {
 	DeleteExportReport(ctx context.Context, nonce int64, params account.DeleteExportReportRequestParameters, secopts *common.SecurityOptions) (*account.DeleteExportReportResponse, *http.Response, error)
 	// # Description
 	//
+	// CreateSubaccount - Create a trading subaccount. The API key must have been created from the
+	// master account.
+	//
+	// # Inputs
+	//
+	//	- ctx: Context used for tracing and coordination purpose.
+	//	- nonce: Nonce used to sign request.
+	//	- params: CreateSubaccount request parameters.
+	//	- secopts: Security options to use for the API call (2FA, ...)
+	//
+	// # Returns
+	//
+	//	- CreateSubaccountResponse: The parsed response from Kraken API.
+	//	- http.Response: A reference to the raw HTTP response received from Kraken API.
+	//	- error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+	//
+	// # Note on error
+	//
+	// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+	// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+	// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+	// when context has expired.
+	//
+	// An nil error does not mean everything is OK: You also have to check the response error field for specific
+	// errors from Kraken API.
+	//
+	// # Note on the http.Response
+	//
+	// A reference to the received http.Response is always returned but it may be nil if no response was received.
+	// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+	// to extract the metadata (or any other kind of data that are not used by the API client directly).
+	//
+	// Please note response body will always be closed except for RetrieveDataExport.
+	CreateSubaccount(ctx context.Context, nonce int64, params account.CreateSubaccountRequestParameters, secopts *common.SecurityOptions) (*account.CreateSubaccountResponse, *http.Response, error)
+	// # Description
+	//
 	// AddOrder - Place a new order.
 	//
 	// # Inputs
@@ -1003,6 +1076,43 @@ type KrakenSpotRESTClientIface interface {
 	EditOrder(ctx context.Context, nonce int64, params trading.EditOrderRequestParameters, opts *trading.EditOrderRequestOptions, secopts *common.SecurityOptions) (*trading.EditOrderResponse, *http.Response, error)
 	// # Description
 	//
+	// AmendOrder - Change the quantity or the prices of an open order in place. Unlike EditOrder, the
+	// order ID and the queue priority are kept when possible.
+	//
+	// # Inputs
+	//
+	//	- ctx: Context used for tracing and coordination purpose.
+	//	- nonce: Nonce used to sign request.
+	//	- params: AmendOrder request parameters.
+	//	- opts: AmendOrder request options. A nil value triggers all default behaviors.
+	//	- secopts: Security options to use for the API call (2FA, ...)
+	//
+	// # Returns
+	//
+	//	- AmendOrderResponse: The parsed response from Kraken API.
+	//	- http.Response: A reference to the raw HTTP response received from Kraken API.
+	//	- error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+	//
+	// # Note on error
+	//
+	// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+	// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+	// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+	// when context has expired.
+	//
+	// An nil error does not mean everything is OK: You also have to check the response error field for specific
+	// errors from Kraken API.
+	//
+	// # Note on the http.Response
+	//
+	// A reference to the received http.Response is always returned but it may be nil if no response was received.
+	// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+	// to extract the metadata (or any other kind of data that are not used by the API client directly).
+	//
+	// Please note response body will always be closed except for RetrieveDataExport.
+	AmendOrder(ctx context.Context, nonce int64, params trading.AmendOrderRequestParameters, opts *trading.AmendOrderRequestOptions, secopts *common.SecurityOptions) (*trading.AmendOrderResponse, *http.Response, error)
+	// # Description
+	//
 	// CancelOrder - Cancel a particular open order (or set of open orders) by txid or userref.
 	//
 	// # Inputs
@@ -1518,6 +1628,42 @@ type KrakenSpotRESTClientIface interface {
 	RequestWalletTransfer(ctx context.Context, nonce int64, params funding.RequestWalletTransferRequestParameters, secopts *common.SecurityOptions) (*funding.RequestWalletTransferResponse, *http.Response, error)
 	// # Description
 	//
+	// AccountTransfer - Transfer funds between the master account and its subaccounts. The API key
+	// must have been created from the master account.
+	//
+	// # Inputs
+	//
+	//	- ctx: Context used for tracing and coordination purpose.
+	//	- nonce: Nonce used to sign request.
+	//	- params: AccountTransfer request parameters.
+	//	- secopts: Security options to use for the API call (2FA, ...)
+	//
+	// # Returns
+	//
+	//	- AccountTransferResponse: The parsed response from Kraken API.
+	//	- http.Response: A reference to the raw HTTP response received from Kraken API.
+	//	- error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+	//
+	// # Note on error
+	//
+	// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+	// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+	// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+	// when context has expired.
+	//
+	// An nil error does not mean everything is OK: You also have to check the response error field for specific
+	// errors from Kraken API.
+	//
+	// # Note on the http.Response
+	//
+	// A reference to the received http.Response is always returned but it may be nil if no response was received.
+	// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+	// to extract the metadata (or any other kind of data that are not used by the API client directly).
+	//
+	// Please note response body will always be closed except for RetrieveDataExport.
+	AccountTransfer(ctx context.Context, nonce int64, params funding.AccountTransferRequestParameters, secopts *common.SecurityOptions) (*funding.AccountTransferResponse, *http.Response, error)
+	// # Description
+	//
 	// AllocateEarnFunds - Allocate funds to the Strategy.
 	//
 	// # Usage tips
@@ -1807,6 +1953,195 @@ type KrakenSpotRESTClientIface interface {
 	ListEarnAllocations(ctx context.Context, nonce int64, opts *earn.ListEarnAllocationsRequestOptions, secopts *common.SecurityOptions) (*earn.ListEarnAllocationsResponse, *http.Response, error)
 	// # Description
 	//
+	// Stake - Stake an asset from your spot wallet. This operation requires an API key with Withdraw
+	// funds permission.
+	//
+	// This is a legacy endpoint. Kraken recommends using the Earn endpoints (Cf. AllocateEarnFunds).
+	//
+	// # Inputs
+	//
+	//	- ctx: Context used for tracing and coordination purpose.
+	//	- nonce: Nonce used to sign request.
+	//	- params: Stake request parameters.
+	//	- secopts: Security options to use for the API call (2FA, ...)
+	//
+	// # Returns
+	//
+	//	- StakeResponse: The parsed response from Kraken API.
+	//	- http.Response: A reference to the raw HTTP response received from Kraken API.
+	//	- error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+	//
+	// # Note on error
+	//
+	// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+	// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+	// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+	// when context has expired.
+	//
+	// An nil error does not mean everything is OK: You also have to check the response error field for specific
+	// errors from Kraken API.
+	//
+	// # Note on the http.Response
+	//
+	// A reference to the received http.Response is always returned but it may be nil if no response was received.
+	// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+	// to extract the metadata (or any other kind of data that are not used by the API client directly).
+	//
+	// Please note response body will always be closed except for RetrieveDataExport.
+	Stake(ctx context.Context, nonce int64, params staking.StakeRequestParameters, secopts *common.SecurityOptions) (*staking.StakeResponse, *http.Response, error)
+	// # Description
+	//
+	// Unstake - Unstake an asset from your staking wallet. This operation requires an API key with
+	// Withdraw funds permission.
+	//
+	// This is a legacy endpoint. Kraken recommends using the Earn endpoints (Cf. DeallocateEarnFunds).
+	//
+	// # Inputs
+	//
+	//	- ctx: Context used for tracing and coordination purpose.
+	//	- nonce: Nonce used to sign request.
+	//	- params: Unstake request parameters.
+	//	- secopts: Security options to use for the API call (2FA, ...)
+	//
+	// # Returns
+	//
+	//	- UnstakeResponse: The parsed response from Kraken API.
+	//	- http.Response: A reference to the raw HTTP response received from Kraken API.
+	//	- error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+	//
+	// # Note on error
+	//
+	// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+	// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+	// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+	// when context has expired.
+	//
+	// An nil error does not mean everything is OK: You also have to check the response error field for specific
+	// errors from Kraken API.
+	//
+	// # Note on the http.Response
+	//
+	// A reference to the received http.Response is always returned but it may be nil if no response was received.
+	// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+	// to extract the metadata (or any other kind of data that are not used by the API client directly).
+	//
+	// Please note response body will always be closed except for RetrieveDataExport.
+	Unstake(ctx context.Context, nonce int64, params staking.UnstakeRequestParameters, secopts *common.SecurityOptions) (*staking.UnstakeResponse, *http.Response, error)
+	// # Description
+	//
+	// ListStakeableAssets - Returns the list of assets that the user is able to stake. This operation
+	// requires an API key with both Withdraw funds and Query funds permission.
+	//
+	// This is a legacy endpoint. Kraken recommends using the Earn endpoints (Cf. ListEarnStrategies).
+	//
+	// # Inputs
+	//
+	//	- ctx: Context used for tracing and coordination purpose.
+	//	- nonce: Nonce used to sign request.
+	//	- secopts: Security options to use for the API call (2FA, ...)
+	//
+	// # Returns
+	//
+	//	- ListStakeableAssetsResponse: The parsed response from Kraken API.
+	//	- http.Response: A reference to the raw HTTP response received from Kraken API.
+	//	- error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+	//
+	// # Note on error
+	//
+	// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+	// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+	// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+	// when context has expired.
+	//
+	// An nil error does not mean everything is OK: You also have to check the response error field for specific
+	// errors from Kraken API.
+	//
+	// # Note on the http.Response
+	//
+	// A reference to the received http.Response is always returned but it may be nil if no response was received.
+	// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+	// to extract the metadata (or any other kind of data that are not used by the API client directly).
+	//
+	// Please note response body will always be closed except for RetrieveDataExport.
+	ListStakeableAssets(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*staking.ListStakeableAssetsResponse, *http.Response, error)
+	// # Description
+	//
+	// GetPendingStakingTransactions - Returns the list of pending staking transactions. Once resolved,
+	// these transactions will appear on the list of recent staking transactions (Cf.
+	// ListRecentStakingTransactions). This operation requires an API key with both Query funds and
+	// Withdraw funds permissions.
+	//
+	// This is a legacy endpoint. Kraken recommends using the Earn endpoints.
+	//
+	// # Inputs
+	//
+	//	- ctx: Context used for tracing and coordination purpose.
+	//	- nonce: Nonce used to sign request.
+	//	- secopts: Security options to use for the API call (2FA, ...)
+	//
+	// # Returns
+	//
+	//	- GetPendingStakingTransactionsResponse: The parsed response from Kraken API.
+	//	- http.Response: A reference to the raw HTTP response received from Kraken API.
+	//	- error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+	//
+	// # Note on error
+	//
+	// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+	// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+	// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+	// when context has expired.
+	//
+	// An nil error does not mean everything is OK: You also have to check the response error field for specific
+	// errors from Kraken API.
+	//
+	// # Note on the http.Response
+	//
+	// A reference to the received http.Response is always returned but it may be nil if no response was received.
+	// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+	// to extract the metadata (or any other kind of data that are not used by the API client directly).
+	//
+	// Please note response body will always be closed except for RetrieveDataExport.
+	GetPendingStakingTransactions(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*staking.GetPendingStakingTransactionsResponse, *http.Response, error)
+	// # Description
+	//
+	// ListRecentStakingTransactions - Returns the list of 1000 recent staking transactions from past 90
+	// days. This operation requires an API key with Query funds permissions.
+	//
+	// This is a legacy endpoint. Kraken recommends using the Earn endpoints.
+	//
+	// # Inputs
+	//
+	//	- ctx: Context used for tracing and coordination purpose.
+	//	- nonce: Nonce used to sign request.
+	//	- secopts: Security options to use for the API call (2FA, ...)
+	//
+	// # Returns
+	//
+	//	- ListRecentStakingTransactionsResponse: The parsed response from Kraken API.
+	//	- http.Response: A reference to the raw HTTP response received from Kraken API.
+	//	- error: An error in case the HTTP request failed, response JSON payload could not be parsed or context has expired.
+	//
+	// # Note on error
+	//
+	// The error is set only when something wrong has happened either at the HTTP level (while building the request,
+	// when the server is unreachable, when the API replies with a status code different from 200, ...) , when
+	// an error happens while parsing the response JSON payload (in that case, error is json.UnmarshalTypeError) or
+	// when context has expired.
+	//
+	// An nil error does not mean everything is OK: You also have to check the response error field for specific
+	// errors from Kraken API.
+	//
+	// # Note on the http.Response
+	//
+	// A reference to the received http.Response is always returned but it may be nil if no response was received.
+	// Some endpoints of the Kraken API include tracing metadata in the response headers. The reference can be used
+	// to extract the metadata (or any other kind of data that are not used by the API client directly).
+	//
+	// Please note response body will always be closed except for RetrieveDataExport.
+	ListRecentStakingTransactions(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*staking.ListRecentStakingTransactionsResponse, *http.Response, error)
+	// # Description
+	//
 	// GetWebsocketToken - An authentication token must be requested via this REST API endpoint in
 	// order to connect to and authenticate with our Websockets API. The token should be used
 	// within 15 minutes of creation, but it does not expire once a successful Websockets
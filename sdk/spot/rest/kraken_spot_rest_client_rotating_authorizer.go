@@ -0,0 +1,150 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+)
+
+// An authorizer for a KrakenSpotRESTClient which gets its credentials from a
+// CredentialsProviderIface and reloads them periodically or on demand, so a new key/secret is
+// picked up without restarting the client.
+//
+// Requests are signed by a KrakenSpotRESTClientAuthorizer built from the current credentials.
+// Like any other authorizer, it can be decorated with InstrumentKrakenSpotRESTClientAuthorizer.
+type RotatingKrakenSpotRESTClientAuthorizer struct {
+	// Provider used to load credentials
+	provider CredentialsProviderIface
+	// Interval between two credentials reloads. Zero disables periodic reloads.
+	refreshInterval time.Duration
+	// Mutex used to protect the authorizer state: the signing authorizer reuses its hashes and
+	// must not be used concurrently.
+	mu sync.Mutex
+	// Authorizer built from the current credentials
+	current *KrakenSpotRESTClientAuthorizer
+	// Current credentials
+	credentials Credentials
+	// Time of the last reload attempt
+	loadedAt time.Time
+	// Error returned by the last reload attempt. Nil if it has succeeded.
+	lastError error
+	// Clock used to schedule periodic reloads
+	clock clock.Clock
+}
+
+// # Description
+//
+// Factory for RotatingKrakenSpotRESTClientAuthorizer. Credentials are loaded once by the factory
+// so invalid credentials are detected early.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - provider: Provider used to load credentials. Must not be nil.
+//   - refreshInterval: Interval between two credentials reloads. Reloads are done by Authorize
+//     once the interval has elapsed. Zero disables periodic reloads: use Refresh to reload
+//     credentials on demand.
+//
+// # Returns
+//
+// The new authorizer or an error if the inputs are invalid or if credentials could not be loaded.
+func NewRotatingKrakenSpotRESTClientAuthorizer(ctx context.Context, provider CredentialsProviderIface, refreshInterval time.Duration) (*RotatingKrakenSpotRESTClientAuthorizer, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("a credentials provider must be provided")
+	}
+	if refreshInterval < 0 {
+		return nil, fmt.Errorf("refresh interval must be positive. Got %s", refreshInterval)
+	}
+	auth := &RotatingKrakenSpotRESTClientAuthorizer{
+		provider:        provider,
+		refreshInterval: refreshInterval,
+		clock:           clock.NewSystemClock(),
+	}
+	if err := auth.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// # Description
+//
+// Reload the credentials from the provider. If the credentials have changed, the next requests
+// are signed with the new credentials. If credentials cannot be loaded, the current credentials
+// are kept.
+//
+// # Returns
+//
+// An error if credentials could not be loaded or are invalid.
+func (auth *RotatingKrakenSpotRESTClientAuthorizer) Refresh(ctx context.Context) error {
+	auth.mu.Lock()
+	defer auth.mu.Unlock()
+	return auth.refresh(ctx)
+}
+
+// Reload the credentials. Must be called with mu locked.
+func (auth *RotatingKrakenSpotRESTClientAuthorizer) refresh(ctx context.Context) error {
+	auth.loadedAt = auth.clock.Now()
+	auth.lastError = auth.load(ctx)
+	return auth.lastError
+}
+
+// Load the credentials and build the signing authorizer if they have changed. Must be called
+// with mu locked.
+func (auth *RotatingKrakenSpotRESTClientAuthorizer) load(ctx context.Context) error {
+	creds, err := auth.provider.GetCredentials(ctx)
+	if err != nil {
+		return fmt.Errorf("could not load API credentials: %w", err)
+	}
+	if creds == nil {
+		return fmt.Errorf("could not load API credentials: provider returned no credentials")
+	}
+	if auth.current != nil && *creds == auth.credentials {
+		// Unchanged
+		return nil
+	}
+	current, err := NewKrakenSpotRESTClientAuthorizer(creds.Key, creds.Secret)
+	if err != nil {
+		return fmt.Errorf("invalid API credentials: %w", err)
+	}
+	auth.current = current
+	auth.credentials = *creds
+	return nil
+}
+
+// Set the clock used to schedule periodic reloads. The refresh interval restarts from the current
+// time of the provided clock. If nil, the system clock is used (Cf. clock.SystemClock).
+func (auth *RotatingKrakenSpotRESTClientAuthorizer) SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.NewSystemClock()
+	}
+	auth.mu.Lock()
+	defer auth.mu.Unlock()
+	auth.clock = c
+	auth.loadedAt = c.Now()
+}
+
+// Return the error of the last reload attempt. Nil if it has succeeded.
+func (auth *RotatingKrakenSpotRESTClientAuthorizer) LastError() error {
+	auth.mu.Lock()
+	defer auth.mu.Unlock()
+	return auth.lastError
+}
+
+// Authorize the request with the current credentials. Credentials are reloaded first if the
+// refresh interval has elapsed: if they cannot be reloaded, the current credentials are used and
+// the reload is retried once the refresh interval has elapsed again (Cf. LastError).
+//
+// Cf. KrakenSpotRESTClientAuthorizer.Authorize for the requirements on the request.
+func (auth *RotatingKrakenSpotRESTClientAuthorizer) Authorize(ctx context.Context, req *http.Request) (*http.Request, error) {
+	auth.mu.Lock()
+	defer auth.mu.Unlock()
+	if auth.refreshInterval > 0 && auth.clock.Now().Sub(auth.loadedAt) >= auth.refreshInterval {
+		// Error is kept in lastError
+		_ = auth.refresh(ctx)
+	}
+	return auth.current.Authorize(ctx, req)
+}
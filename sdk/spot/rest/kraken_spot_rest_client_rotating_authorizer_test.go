@@ -0,0 +1,126 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for RotatingKrakenSpotRESTClientAuthorizer.
+type RotatingKrakenSpotRESTClientAuthorizerTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestRotatingKrakenSpotRESTClientAuthorizerTestSuite(t *testing.T) {
+	suite.Run(t, new(RotatingKrakenSpotRESTClientAuthorizerTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test interface compliance and factory input validation.
+func (suite *RotatingKrakenSpotRESTClientAuthorizerTestSuite) TestNewRotatingAuthorizer() {
+	var _ KrakenSpotRESTClientAuthorizerIface = &RotatingKrakenSpotRESTClientAuthorizer{}
+	provider := &switchableProvider{creds: &Credentials{Key: "KEY", Secret: "c2VjcmV0"}}
+	_, err := NewRotatingKrakenSpotRESTClientAuthorizer(context.Background(), nil, 0)
+	require.Error(suite.T(), err)
+	_, err = NewRotatingKrakenSpotRESTClientAuthorizer(context.Background(), provider, -time.Second)
+	require.Error(suite.T(), err)
+	// Invalid secret
+	provider.set(&Credentials{Key: "KEY", Secret: "not base64!"}, nil)
+	_, err = NewRotatingKrakenSpotRESTClientAuthorizer(context.Background(), provider, 0)
+	require.Error(suite.T(), err)
+	// Provider failure
+	provider.set(nil, fmt.Errorf("vault is sealed"))
+	_, err = NewRotatingKrakenSpotRESTClientAuthorizer(context.Background(), provider, 0)
+	require.ErrorContains(suite.T(), err, "vault is sealed")
+}
+
+// Test the authorizer picks up new credentials on refresh.
+//
+// Test will ensure:
+//   - Requests are signed with the current credentials.
+//   - Refresh picks up new credentials.
+//   - Current credentials are kept when the provider fails.
+func (suite *RotatingKrakenSpotRESTClientAuthorizerTestSuite) TestRefresh() {
+	provider := &switchableProvider{creds: &Credentials{Key: "KEY1", Secret: "c2VjcmV0"}}
+	auth, err := NewRotatingKrakenSpotRESTClientAuthorizer(context.Background(), provider, 0)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "KEY1", suite.authorize(auth))
+	// Rotation without refresh: old key is still used
+	provider.set(&Credentials{Key: "KEY2", Secret: "c2VjcmV0Mg=="}, nil)
+	require.Equal(suite.T(), "KEY1", suite.authorize(auth))
+	require.NoError(suite.T(), auth.Refresh(context.Background()))
+	require.Equal(suite.T(), "KEY2", suite.authorize(auth))
+	// Provider failure: current key is kept
+	provider.set(nil, fmt.Errorf("vault is sealed"))
+	require.Error(suite.T(), auth.Refresh(context.Background()))
+	require.Error(suite.T(), auth.LastError())
+	require.Equal(suite.T(), "KEY2", suite.authorize(auth))
+}
+
+// Test the authorizer reloads credentials once the refresh interval has elapsed.
+func (suite *RotatingKrakenSpotRESTClientAuthorizerTestSuite) TestPeriodicRefresh() {
+	provider := &switchableProvider{creds: &Credentials{Key: "KEY1", Secret: "c2VjcmV0"}}
+	auth, err := NewRotatingKrakenSpotRESTClientAuthorizer(context.Background(), provider, time.Minute)
+	require.NoError(suite.T(), err)
+	fake := clock.NewFakeClock(time.Now())
+	auth.SetClock(fake)
+	provider.set(&Credentials{Key: "KEY2", Secret: "c2VjcmV0Mg=="}, nil)
+	require.Equal(suite.T(), "KEY1", suite.authorize(auth))
+	fake.Advance(59 * time.Second)
+	require.Equal(suite.T(), "KEY1", suite.authorize(auth))
+	fake.Advance(time.Second)
+	require.Equal(suite.T(), "KEY2", suite.authorize(auth))
+	require.NoError(suite.T(), auth.LastError())
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Credentials provider which returns the configured credentials or error.
+type switchableProvider struct {
+	mu    sync.Mutex
+	creds *Credentials
+	err   error
+}
+
+// Set the credentials or error returned by the provider.
+func (p *switchableProvider) set(creds *Credentials, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.creds, p.err = creds, err
+}
+
+// Return the configured credentials or error.
+func (p *switchableProvider) GetCredentials(ctx context.Context) (*Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.creds, p.err
+}
+
+// Authorize a private request and return the API key set on the request.
+func (suite *RotatingKrakenSpotRESTClientAuthorizerTestSuite) authorize(auth KrakenSpotRESTClientAuthorizerIface) string {
+	req, err := http.NewRequest(http.MethodPost, "http://localhost/0/private/Balance", strings.NewReader("nonce=1"))
+	require.NoError(suite.T(), err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	oreq, err := auth.Authorize(context.Background(), req)
+	require.NoError(suite.T(), err)
+	require.NotEmpty(suite.T(), oreq.Header[managedHeaderAPISign])
+	return oreq.Header[managedHeaderAPIKey][0]
+}
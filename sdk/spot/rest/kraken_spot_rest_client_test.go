@@ -2,6 +2,7 @@ package rest
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -13,11 +14,13 @@ import (
 	"time"
 
 	"github.com/gbdevw/gosette"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/requesttag"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/earn"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/funding"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/staking"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/stretchr/testify/require"
@@ -196,6 +199,17 @@ func (suite *KrakenSpotRESTClientTestSuite) TestForgeAndAuthorizeKrakenAPIReques
 	require.Empty(suite.T(), req.Header.Get(managedHeaderContentType))
 }
 
+// Test forgeAndAuthorizeKrakenAPIRequest method when a client tag is configured.
+//
+// Test will ensure the client tag is added to the User-Agent header.
+func (suite *KrakenSpotRESTClientTestSuite) TestForgeAndAuthorizeKrakenAPIRequestWithClientTag() {
+	client := NewKrakenSpotRESTClient(nil, &KrakenSpotRESTClientConfiguration{Agent: usrAgent, ClientTag: "bot-a"})
+	require.Equal(suite.T(), "bot-a", client.GetClientTag())
+	req, err := client.forgeAndAuthorizeKrakenAPIRequest(context.Background(), "/public/Assets", http.MethodGet, "", nil, nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), usrAgent+" (bot-a)", req.Header.Get(managedHeaderUserAgent))
+}
+
 // Test forgeAndAuthorizeKrakenAPIRequest method when wrong inputs lead to a malformed request.
 //
 // Test will ensure the method returns an error and no request when it fails to create the http.Request.
@@ -1534,6 +1548,51 @@ func (suite *KrakenSpotRESTClientTestSuite) TestGetOpenOrders() {
 	require.Equal(suite.T(), strconv.FormatInt(*options.UserReference, 10), record.Request.Form.Get("userref"))
 }
 
+// Test GetOpenOrders and GetClosedOrders with client side filters.
+//
+// Test will ensure:
+//   - Only the orders which match all filters are returned.
+//   - Filters are not sent to the API.
+//   - The count of closed orders computed by the API is kept.
+func (suite *KrakenSpotRESTClientTestSuite) TestGetOrdersWithFilters() {
+	orders := `{
+		"OQCLML-BW3P3-BUCMWZ": {"userref": 10, "status": "open", "descr": {"pair": "XBTUSD", "type": "buy", "ordertype": "limit"}},
+		"OB5VMB-B4U2U-DK2WRW": {"userref": 45326, "status": "open", "descr": {"pair": "XBTUSD", "type": "buy", "ordertype": "limit"}},
+		"OXHSYN-DB7EC-3XPJW6": {"userref": 12, "status": "open", "descr": {"pair": "ETHUSD", "type": "sell", "ordertype": "stop-loss"}}
+	}`
+	suite.srv.PushPredefinedServerResponse(&gosette.PredefinedServerResponse{
+		Status:  http.StatusOK,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+		Body:    []byte(`{"error": [], "result": {"open": ` + orders + `}}`),
+	})
+	aliases := market.NewPairAliases(map[string]market.AssetPairInfo{"XXBTZUSD": {AlternativeName: "XBTUSD", WebsocketName: "XBT/USD"}})
+	open, _, err := suite.client.GetOpenOrders(context.Background(), 42, &account.GetOpenOrdersRequestOptions{
+		Filters: []account.OrderInfoFilter{account.OnlyOrderPairs(aliases, "XBT/USD"), account.OnlyUserReferenceRange(1, 100)},
+	}, nil)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), open.Result.Open, 1)
+	require.NotNil(suite.T(), open.Result.Open["OQCLML-BW3P3-BUCMWZ"])
+	record := suite.srv.PopServerRecord()
+	require.NotNil(suite.T(), record)
+	require.NoError(suite.T(), record.Request.ParseForm())
+	require.Equal(suite.T(), url.Values{"nonce": []string{"42"}}, record.Request.PostForm)
+	// Closed orders
+	suite.srv.ClearPredefinedServerResponses()
+	suite.srv.PushPredefinedServerResponse(&gosette.PredefinedServerResponse{
+		Status:  http.StatusOK,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+		Body:    []byte(`{"error": [], "result": {"closed": ` + orders + `, "count": 3}}`),
+	})
+	closed, _, err := suite.client.GetClosedOrders(context.Background(), 42, &account.GetClosedOrdersRequestOptions{
+		Filters: []account.OrderInfoFilter{account.OnlyOrderTypes(account.StopLoss)},
+	}, nil)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), closed.Result.Closed, 1)
+	require.NotNil(suite.T(), closed.Result.Closed["OXHSYN-DB7EC-3XPJW6"])
+	require.Equal(suite.T(), 3, closed.Result.Count)
+	require.NotNil(suite.T(), suite.srv.PopServerRecord())
+}
+
 // Test GetClosedOrders when a valid response is received from the test server.
 //
 // Test will ensure:
@@ -1555,6 +1614,8 @@ func (suite *KrakenSpotRESTClientTestSuite) TestGetClosedOrders() {
 		Offset:           10,
 		Closetime:        string(account.UseBoth),
 		ConsolidateTaker: true,
+		ClientOrderId:    "my-order",
+		WithoutCount:     true,
 	}
 
 	// Expected API response from API documentation
@@ -1673,6 +1734,8 @@ func (suite *KrakenSpotRESTClientTestSuite) TestGetClosedOrders() {
 	require.Equal(suite.T(), strconv.FormatInt(options.Offset, 10), record.Request.Form.Get("ofs"))
 	require.Equal(suite.T(), options.Closetime, record.Request.Form.Get("closetime"))
 	require.Equal(suite.T(), strconv.FormatBool(options.ConsolidateTaker), record.Request.Form.Get("consolidate_taker"))
+	require.Equal(suite.T(), options.ClientOrderId, record.Request.Form.Get("cl_ord_id"))
+	require.Equal(suite.T(), strconv.FormatBool(options.WithoutCount), record.Request.Form.Get("without_count"))
 }
 
 // Test QueryOrdersInfo when a valid response is received from the test server.
@@ -1817,6 +1880,91 @@ func (suite *KrakenSpotRESTClientTestSuite) TestQueryOrdersInfo() {
 	require.Equal(suite.T(), strings.Join(params.TxId, ","), record.Request.Form.Get("txid"))
 }
 
+// Test GetOrderAmends when a valid response is received from the test server.
+//
+// Test will ensure:
+//   - The request is well formatted and contains all inputs.
+//   - The returned values contain the expected parsed response data.
+func (suite *KrakenSpotRESTClientTestSuite) TestGetOrderAmends() {
+
+	// Expected nonce and secopts
+	expectedNonce := int64(42)
+	expectedSecOpts := &common.SecurityOptions{
+		SecondFactor: "42",
+	}
+
+	// Expected parameters and options
+	params := account.GetOrderAmendsRequestParameters{OrderId: "OVM3PT-56ACO-53SM2T"}
+	options := &account.GetOrderAmendsRequestOptions{RebaseMultiplier: string(account.RebaseMultiplierBase)}
+
+	// Expected API response from API documentation
+	expectedJSONResponse := `
+	{
+		"error": [],
+		"result": {
+		  "amends": [
+			{
+			  "amend_id": "TN2WNY-VWLQA-SFEXBY",
+			  "amend_type": "original",
+			  "order_qty": "1.00000000",
+			  "remaining_qty": "1.00000000",
+			  "limit_price": "30000.0",
+			  "post_only": false,
+			  "timestamp": 1724922426345
+			},
+			{
+			  "amend_id": "TCTA2Z-OXJLW-YM42RT",
+			  "amend_type": "user",
+			  "order_qty": "0.50000000",
+			  "remaining_qty": "0.50000000",
+			  "limit_price": "30000.0",
+			  "post_only": false,
+			  "timestamp": 1724922537432
+			}
+		  ],
+		  "count": 2
+		}
+	}`
+
+	// Configure test server
+	suite.srv.PushPredefinedServerResponse(&gosette.PredefinedServerResponse{
+		Status:  http.StatusOK,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+		Body:    []byte(expectedJSONResponse),
+	})
+
+	// Make request
+	resp, httpresp, err := suite.instrumentedClient.GetOrderAmends(context.Background(), expectedNonce, params, options, expectedSecOpts)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), httpresp)
+	require.NotNil(suite.T(), resp)
+
+	// Check parsed response
+	require.Equal(suite.T(), 2, resp.Result.Count)
+	require.Len(suite.T(), resp.Result.Amends, 2)
+	require.Equal(suite.T(), string(account.AmendUser), resp.Result.Amends[1].AmendType)
+	require.Equal(suite.T(), json.Number("0.50000000"), resp.Result.Amends[1].OrderQuantity)
+
+	// Get the recorded request
+	record := suite.srv.PopServerRecord()
+	require.NotNil(suite.T(), record)
+
+	// Check the request settings
+	require.Contains(suite.T(), record.Request.URL.Path, getOrderAmendsPath)
+	require.Equal(suite.T(), http.MethodPost, record.Request.Method)
+	require.Equal(suite.T(), suite.client.agent, record.Request.UserAgent())
+	require.Equal(suite.T(), "application/x-www-form-urlencoded", record.Request.Header.Get("Content-Type"))
+	require.NotEmpty(suite.T(), record.Request.Header.Get("Api-Sign"))     // Headers are in canonical form in recorded request
+	require.Equal(suite.T(), apiKey, record.Request.Header.Get("Api-Key")) // Headers are in canonical form in recorded request
+
+	// Check request form body
+	require.NoError(suite.T(), record.Request.ParseForm())
+	require.Equal(suite.T(), strconv.FormatInt(expectedNonce, 10), record.Request.Form.Get("nonce"))
+	require.Equal(suite.T(), expectedSecOpts.SecondFactor, record.Request.Form.Get("otp"))
+	require.Equal(suite.T(), params.OrderId, record.Request.Form.Get("order_id"))
+	require.Equal(suite.T(), options.RebaseMultiplier, record.Request.Form.Get("rebase_multiplier"))
+}
+
 // Test GetTradesHistory when a valid response is received from the test server.
 //
 // Test will ensure:
@@ -2763,6 +2911,68 @@ func (suite *KrakenSpotRESTClientTestSuite) TestDeleteExportReport() {
 	require.Equal(suite.T(), params.Id, record.Request.Form.Get("id"))
 }
 
+// Test CreateSubaccount when a valid response is received from the test server.
+//
+// Test will ensure:
+//   - The request is well formatted and contains all inputs.
+//   - The returned values contain the expected parsed response data.
+func (suite *KrakenSpotRESTClientTestSuite) TestCreateSubaccount() {
+
+	// Expected nonce and secopts
+	expectedNonce := int64(42)
+	expectedSecOpts := &common.SecurityOptions{
+		SecondFactor: "42",
+	}
+
+	// Expected params
+	params := account.CreateSubaccountRequestParameters{
+		Username: "trading-bot",
+		Email:    "bot@example.com",
+	}
+
+	// Expected API response from API documentation
+	expectedJSONResponse := `
+	{
+		"error": [],
+		"result": true
+	}`
+
+	// Configure test server
+	suite.srv.PushPredefinedServerResponse(&gosette.PredefinedServerResponse{
+		Status:  http.StatusOK,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+		Body:    []byte(expectedJSONResponse),
+	})
+
+	// Make request
+	resp, httpresp, err := suite.instrumentedClient.CreateSubaccount(context.Background(), expectedNonce, params, expectedSecOpts)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), httpresp)
+	require.NotNil(suite.T(), resp)
+
+	// Check parsed response
+	require.True(suite.T(), resp.Result)
+
+	// Get the recorded request
+	record := suite.srv.PopServerRecord()
+	require.NotNil(suite.T(), record)
+
+	// Check the request settings
+	require.Contains(suite.T(), record.Request.URL.Path, createSubaccountPath)
+	require.Equal(suite.T(), http.MethodPost, record.Request.Method)
+	require.Equal(suite.T(), suite.client.agent, record.Request.UserAgent())
+	require.Equal(suite.T(), "application/x-www-form-urlencoded", record.Request.Header.Get("Content-Type"))
+	require.NotEmpty(suite.T(), record.Request.Header.Get("Api-Sign"))     // Headers are in canonical form in recorded request
+	require.Equal(suite.T(), apiKey, record.Request.Header.Get("Api-Key")) // Headers are in canonical form in recorded request
+
+	// Check request form body
+	require.NoError(suite.T(), record.Request.ParseForm())
+	require.Equal(suite.T(), strconv.FormatInt(expectedNonce, 10), record.Request.Form.Get("nonce"))
+	require.Equal(suite.T(), expectedSecOpts.SecondFactor, record.Request.Form.Get("otp"))
+	require.Equal(suite.T(), params.Username, record.Request.Form.Get("username"))
+	require.Equal(suite.T(), params.Email, record.Request.Form.Get("email"))
+}
+
 /*************************************************************************************************/
 /* UNIT TESTS - TRADING                                                                          */
 /*************************************************************************************************/
@@ -3026,6 +3236,82 @@ func (suite *KrakenSpotRESTClientTestSuite) TestAddOrderBatch() {
 	}
 }
 
+// Test AddOrder and AddOrderBatch with an invalid self trade prevention flag.
+//
+// Test will ensure:
+//   - An error is returned and no request is sent to the server.
+func (suite *KrakenSpotRESTClientTestSuite) TestAddOrderInvalidSelfTradePreventionFlag() {
+	order := trading.Order{OrderType: string(trading.Limit), Type: string(trading.Buy), Volume: "1", Price: "1", StpType: "cancel-all"}
+	_, _, err := suite.client.AddOrder(context.Background(), 42, trading.AddOrderRequestParameters{Pair: "XXBTZUSD", Order: order}, nil, nil)
+	require.ErrorContains(suite.T(), err, "invalid self trade prevention flag")
+	_, _, err = suite.client.AddOrderBatch(context.Background(), 42, trading.AddOrderBatchRequestParameters{Pair: "XXBTZUSD", Orders: []trading.Order{order}}, nil, nil)
+	require.ErrorContains(suite.T(), err, "order 0")
+	require.Nil(suite.T(), suite.srv.PopServerRecord())
+}
+
+// Test order management methods with a deadline outside the window allowed by Kraken.
+//
+// Test will ensure:
+//   - An error is returned and no request is sent to the server.
+func (suite *KrakenSpotRESTClientTestSuite) TestInvalidDeadline() {
+	order := trading.Order{OrderType: string(trading.Limit), Type: string(trading.Buy), Volume: "1", Price: "1"}
+	deadline := time.Now().Add(2 * time.Minute)
+	_, _, err := suite.client.AddOrder(context.Background(), 42, trading.AddOrderRequestParameters{Pair: "XXBTZUSD", Order: order}, &trading.AddOrderRequestOptions{Deadline: deadline}, nil)
+	require.ErrorContains(suite.T(), err, "invalid deadline")
+	_, _, err = suite.client.AddOrderBatch(context.Background(), 42, trading.AddOrderBatchRequestParameters{Pair: "XXBTZUSD", Orders: []trading.Order{order}}, &trading.AddOrderBatchRequestOptions{Deadline: deadline}, nil)
+	require.ErrorContains(suite.T(), err, "invalid deadline")
+	_, _, err = suite.client.EditOrder(context.Background(), 42, trading.EditOrderRequestParameters{Id: "OHYO67-6LP66-HMQ437", Pair: "XXBTZUSD"}, &trading.EditOrderRequestOptions{Deadline: time.Now()}, nil)
+	require.ErrorContains(suite.T(), err, "invalid deadline")
+	_, _, err = suite.client.AmendOrder(context.Background(), 42, trading.AmendOrderRequestParameters{TxId: "OHYO67-6LP66-HMQ437"}, &trading.AmendOrderRequestOptions{Deadline: deadline}, nil)
+	require.ErrorContains(suite.T(), err, "invalid deadline")
+	require.Nil(suite.T(), suite.srv.PopServerRecord())
+}
+
+// Test AddOrder and AddOrderBatch when a request tag is used as userref.
+//
+// Test will ensure:
+//   - The tag is used as userref of the orders which do not have one.
+//   - The userref of the order parameters takes precedence over the tag.
+//   - The tag is ignored when it is not an integer.
+func (suite *KrakenSpotRESTClientTestSuite) TestAddOrderWithUserReferenceTag() {
+	client := NewKrakenSpotRESTClient(nil, &KrakenSpotRESTClientConfiguration{
+		BaseURL:          suite.srv.GetBaseURL(),
+		Agent:            usrAgent,
+		UserReferenceTag: "decision",
+	})
+	userref := int64(7)
+	order := trading.Order{OrderType: string(trading.Limit), Type: string(trading.Buy), Volume: "1", Price: "1"}
+	ctx := requesttag.WithTag(context.Background(), "decision", "42")
+	for i := 0; i < 3; i++ {
+		suite.srv.PushPredefinedServerResponse(&gosette.PredefinedServerResponse{
+			Status:  http.StatusOK,
+			Headers: http.Header{"Content-Type": []string{"application/json"}},
+			Body:    []byte(`{"error":[],"result":{}}`),
+		})
+	}
+	// AddOrder
+	_, _, err := client.AddOrder(ctx, 42, trading.AddOrderRequestParameters{Pair: "XXBTZUSD", Order: order}, nil, nil)
+	require.NoError(suite.T(), err)
+	record := suite.srv.PopServerRecord()
+	require.NoError(suite.T(), record.Request.ParseForm())
+	require.Equal(suite.T(), "42", record.Request.Form.Get("userref"))
+	// AddOrderBatch
+	withUserref := order
+	withUserref.UserReference = &userref
+	_, _, err = client.AddOrderBatch(ctx, 42, trading.AddOrderBatchRequestParameters{Pair: "XXBTZUSD", Orders: []trading.Order{order, withUserref}}, nil, nil)
+	require.NoError(suite.T(), err)
+	record = suite.srv.PopServerRecord()
+	require.NoError(suite.T(), record.Request.ParseForm())
+	require.Equal(suite.T(), "42", record.Request.Form.Get("orders[0][userref]"))
+	require.Equal(suite.T(), "7", record.Request.Form.Get("orders[1][userref]"))
+	// Tag which is not an integer
+	_, _, err = client.AddOrder(requesttag.WithTag(ctx, "decision", "d-1"), 42, trading.AddOrderRequestParameters{Pair: "XXBTZUSD", Order: order}, nil, nil)
+	require.NoError(suite.T(), err)
+	record = suite.srv.PopServerRecord()
+	require.NoError(suite.T(), record.Request.ParseForm())
+	require.NotContains(suite.T(), record.Request.Form, "userref")
+}
+
 // Test EditOrder when a valid response is received from the test server.
 //
 // Test will ensure:
@@ -3122,6 +3408,81 @@ func (suite *KrakenSpotRESTClientTestSuite) TestEditOrder() {
 	require.Equal(suite.T(), options.Deadline.Format(time.RFC3339), record.Request.Form.Get("deadline"))
 }
 
+// Test AmendOrder when a valid response is received from the test server.
+//
+// Test will ensure:
+//   - The request is well formatted and contains all inputs.
+//   - The returned values contain the expected parsed response data.
+func (suite *KrakenSpotRESTClientTestSuite) TestAmendOrder() {
+
+	// Expected nonce and secopts
+	expectedNonce := int64(42)
+	expectedSecOpts := &common.SecurityOptions{
+		SecondFactor: "42",
+	}
+
+	// Expected parameters and options
+	params := trading.AmendOrderRequestParameters{ClientOrderId: "my-order"}
+	options := &trading.AmendOrderRequestOptions{
+		OrderQuantity:   "1.5",
+		DisplayQuantity: "0.5",
+		LimitPrice:      "30000",
+		TriggerPrice:    "29000",
+		PostOnly:        true,
+		Deadline:        time.Now().Add(5 * time.Second).UTC().Truncate(time.Second),
+	}
+
+	// Predefined response
+	expectedJSONResponse := `
+	{
+		"error": [],
+		"result": {
+		  "amend_id": "TTW6PD-RC36L-ZZSWNU"
+		}
+	}`
+
+	// Configure test server
+	suite.srv.PushPredefinedServerResponse(&gosette.PredefinedServerResponse{
+		Status:  http.StatusOK,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+		Body:    []byte(expectedJSONResponse),
+	})
+
+	// Make request
+	resp, httpresp, err := suite.instrumentedClient.AmendOrder(context.Background(), expectedNonce, params, options, expectedSecOpts)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), httpresp)
+	require.NotNil(suite.T(), resp)
+
+	// Check parsed response
+	require.Equal(suite.T(), "TTW6PD-RC36L-ZZSWNU", resp.Result.AmendId)
+
+	// Get the recorded request
+	record := suite.srv.PopServerRecord()
+	require.NotNil(suite.T(), record)
+
+	// Check the request settings
+	require.Contains(suite.T(), record.Request.URL.Path, amendOrderPath)
+	require.Equal(suite.T(), http.MethodPost, record.Request.Method)
+	require.Equal(suite.T(), suite.client.agent, record.Request.UserAgent())
+	require.Equal(suite.T(), "application/x-www-form-urlencoded", record.Request.Header.Get("Content-Type"))
+	require.NotEmpty(suite.T(), record.Request.Header.Get("Api-Sign"))     // Headers are in canonical form in recorded request
+	require.Equal(suite.T(), apiKey, record.Request.Header.Get("Api-Key")) // Headers are in canonical form in recorded request
+
+	// Check request form body
+	require.NoError(suite.T(), record.Request.ParseForm())
+	require.Equal(suite.T(), strconv.FormatInt(expectedNonce, 10), record.Request.Form.Get("nonce"))
+	require.Equal(suite.T(), expectedSecOpts.SecondFactor, record.Request.Form.Get("otp"))
+	require.Empty(suite.T(), record.Request.Form.Get("txid"))
+	require.Equal(suite.T(), params.ClientOrderId, record.Request.Form.Get("cl_ord_id"))
+	require.Equal(suite.T(), options.OrderQuantity, record.Request.Form.Get("order_qty"))
+	require.Equal(suite.T(), options.DisplayQuantity, record.Request.Form.Get("display_qty"))
+	require.Equal(suite.T(), options.LimitPrice, record.Request.Form.Get("limit_price"))
+	require.Equal(suite.T(), options.TriggerPrice, record.Request.Form.Get("trigger_price"))
+	require.Equal(suite.T(), strconv.FormatBool(options.PostOnly), record.Request.Form.Get("post_only"))
+	require.Equal(suite.T(), options.Deadline.Format(time.RFC3339), record.Request.Form.Get("deadline"))
+}
+
 // Test CancelOrder when a valid response is received from the test server.
 //
 // Test will ensure:
@@ -4197,16 +4558,23 @@ func (suite *KrakenSpotRESTClientTestSuite) TestRequestWalletTransfer() {
 	require.Equal(suite.T(), params.Amount, record.Request.Form.Get("amount"))
 }
 
-/*************************************************************************************************/
-/* UNIT TESTS - EARN                                                                             */
-/*************************************************************************************************/
+// Test RequestWalletTransfer with invalid parameters.
+//
+// Test will ensure:
+//   - An error is returned and no request is sent to the server.
+func (suite *KrakenSpotRESTClientTestSuite) TestRequestWalletTransferInvalidParameters() {
+	params := funding.RequestWalletTransferRequestParameters{Asset: "XXBT", From: string(funding.Spot), To: string(funding.Spot), Amount: "1.2"}
+	_, _, err := suite.client.RequestWalletTransfer(context.Background(), 42, params, nil)
+	require.ErrorContains(suite.T(), err, "invalid parameters for RequestWalletTransfer")
+	require.Nil(suite.T(), suite.srv.PopServerRecord())
+}
 
-// Test AllocateEarnFunds when a valid response is received from the test server.
+// Test AccountTransfer when a valid response is received from the test server.
 //
 // Test will ensure:
 //   - The request is well formatted and contains all inputs.
 //   - The returned values contain the expected parsed response data.
-func (suite *KrakenSpotRESTClientTestSuite) TestAllocateEarnFunds() {
+func (suite *KrakenSpotRESTClientTestSuite) TestAccountTransfer() {
 
 	// Expected nonce and secopts
 	expectedNonce := int64(42)
@@ -4215,17 +4583,24 @@ func (suite *KrakenSpotRESTClientTestSuite) TestAllocateEarnFunds() {
 	}
 
 	// Expected params
-	params := earn.AllocateEarnFundsRequestParameters{
-		Amount:     "1.2",
-		StrategyId: "ESRFUO3-Q62XD-WIOIL7",
+	params := funding.AccountTransferRequestParameters{
+		Asset:  "XXBT",
+		Amount: "1.2",
+		From:   "AA81 N84G 6KJB M3BW",
+		To:     "AA36 H98V QYZA V6JK",
 	}
 
 	// Predefined response
 	expectedJSONResponse := `
 	{
 		"error": [],
-		"result": true
+		"result": {
+		  "transfer_id": "TOH3AS2-LPCWR8-JDQGEU",
+		  "status": "complete"
+		}
 	}`
+	expectedTransferId := "TOH3AS2-LPCWR8-JDQGEU"
+	expectedStatus := "complete"
 
 	// Configure test server
 	suite.srv.PushPredefinedServerResponse(&gosette.PredefinedServerResponse{
@@ -4235,21 +4610,22 @@ func (suite *KrakenSpotRESTClientTestSuite) TestAllocateEarnFunds() {
 	})
 
 	// Make request
-	resp, httpresp, err := suite.instrumentedClient.AllocateEarnFunds(context.Background(), expectedNonce, params, expectedSecOpts)
+	resp, httpresp, err := suite.instrumentedClient.AccountTransfer(context.Background(), expectedNonce, params, expectedSecOpts)
 	require.NoError(suite.T(), err)
 	require.NotNil(suite.T(), httpresp)
 	require.NotNil(suite.T(), resp)
 
 	// Check parsed response
 	require.NotNil(suite.T(), resp.Result)
-	require.True(suite.T(), resp.Result)
+	require.Equal(suite.T(), expectedTransferId, resp.Result.TransferId)
+	require.Equal(suite.T(), expectedStatus, resp.Result.Status)
 
 	// Get the recorded request
 	record := suite.srv.PopServerRecord()
 	require.NotNil(suite.T(), record)
 
 	// Check the request settings
-	require.Contains(suite.T(), record.Request.URL.Path, allocateEarnFundsPath)
+	require.Contains(suite.T(), record.Request.URL.Path, accountTransferPath)
 	require.Equal(suite.T(), http.MethodPost, record.Request.Method)
 	require.Equal(suite.T(), suite.client.agent, record.Request.UserAgent())
 	require.Equal(suite.T(), "application/x-www-form-urlencoded", record.Request.Header.Get("Content-Type"))
@@ -4260,16 +4636,22 @@ func (suite *KrakenSpotRESTClientTestSuite) TestAllocateEarnFunds() {
 	require.NoError(suite.T(), record.Request.ParseForm())
 	require.Equal(suite.T(), strconv.FormatInt(expectedNonce, 10), record.Request.Form.Get("nonce"))
 	require.Equal(suite.T(), expectedSecOpts.SecondFactor, record.Request.Form.Get("otp"))
+	require.Equal(suite.T(), params.Asset, record.Request.Form.Get("asset"))
 	require.Equal(suite.T(), params.Amount, record.Request.Form.Get("amount"))
-	require.Equal(suite.T(), params.StrategyId, record.Request.Form.Get("strategy_id"))
+	require.Equal(suite.T(), params.From, record.Request.Form.Get("from"))
+	require.Equal(suite.T(), params.To, record.Request.Form.Get("to"))
 }
 
-// Test DeallocateEarnFunds when a valid response is received from the test server.
+/*************************************************************************************************/
+/* UNIT TESTS - EARN                                                                             */
+/*************************************************************************************************/
+
+// Test AllocateEarnFunds when a valid response is received from the test server.
 //
 // Test will ensure:
 //   - The request is well formatted and contains all inputs.
 //   - The returned values contain the expected parsed response data.
-func (suite *KrakenSpotRESTClientTestSuite) TestDeallocateEarnFunds() {
+func (suite *KrakenSpotRESTClientTestSuite) TestAllocateEarnFunds() {
 
 	// Expected nonce and secopts
 	expectedNonce := int64(42)
@@ -4278,7 +4660,7 @@ func (suite *KrakenSpotRESTClientTestSuite) TestDeallocateEarnFunds() {
 	}
 
 	// Expected params
-	params := earn.DeallocateEarnFundsRequestParameters{
+	params := earn.AllocateEarnFundsRequestParameters{
 		Amount:     "1.2",
 		StrategyId: "ESRFUO3-Q62XD-WIOIL7",
 	}
@@ -4298,7 +4680,7 @@ func (suite *KrakenSpotRESTClientTestSuite) TestDeallocateEarnFunds() {
 	})
 
 	// Make request
-	resp, httpresp, err := suite.instrumentedClient.DeallocateEarnFunds(context.Background(), expectedNonce, params, expectedSecOpts)
+	resp, httpresp, err := suite.instrumentedClient.AllocateEarnFunds(context.Background(), expectedNonce, params, expectedSecOpts)
 	require.NoError(suite.T(), err)
 	require.NotNil(suite.T(), httpresp)
 	require.NotNil(suite.T(), resp)
@@ -4312,7 +4694,7 @@ func (suite *KrakenSpotRESTClientTestSuite) TestDeallocateEarnFunds() {
 	require.NotNil(suite.T(), record)
 
 	// Check the request settings
-	require.Contains(suite.T(), record.Request.URL.Path, deallocateEarnFundsPath)
+	require.Contains(suite.T(), record.Request.URL.Path, allocateEarnFundsPath)
 	require.Equal(suite.T(), http.MethodPost, record.Request.Method)
 	require.Equal(suite.T(), suite.client.agent, record.Request.UserAgent())
 	require.Equal(suite.T(), "application/x-www-form-urlencoded", record.Request.Header.Get("Content-Type"))
@@ -4327,12 +4709,12 @@ func (suite *KrakenSpotRESTClientTestSuite) TestDeallocateEarnFunds() {
 	require.Equal(suite.T(), params.StrategyId, record.Request.Form.Get("strategy_id"))
 }
 
-// Test GetAllocationStatus when a valid response is received from the test server.
+// Test DeallocateEarnFunds when a valid response is received from the test server.
 //
 // Test will ensure:
 //   - The request is well formatted and contains all inputs.
 //   - The returned values contain the expected parsed response data.
-func (suite *KrakenSpotRESTClientTestSuite) TestGetAllocationStatus() {
+func (suite *KrakenSpotRESTClientTestSuite) TestDeallocateEarnFunds() {
 
 	// Expected nonce and secopts
 	expectedNonce := int64(42)
@@ -4341,7 +4723,8 @@ func (suite *KrakenSpotRESTClientTestSuite) TestGetAllocationStatus() {
 	}
 
 	// Expected params
-	params := earn.GetAllocationStatusRequestParameters{
+	params := earn.DeallocateEarnFundsRequestParameters{
+		Amount:     "1.2",
 		StrategyId: "ESRFUO3-Q62XD-WIOIL7",
 	}
 
@@ -4349,8 +4732,70 @@ func (suite *KrakenSpotRESTClientTestSuite) TestGetAllocationStatus() {
 	expectedJSONResponse := `
 	{
 		"error": [],
-		"result": {
-		  "pending": true
+		"result": true
+	}`
+
+	// Configure test server
+	suite.srv.PushPredefinedServerResponse(&gosette.PredefinedServerResponse{
+		Status:  http.StatusOK,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+		Body:    []byte(expectedJSONResponse),
+	})
+
+	// Make request
+	resp, httpresp, err := suite.instrumentedClient.DeallocateEarnFunds(context.Background(), expectedNonce, params, expectedSecOpts)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), httpresp)
+	require.NotNil(suite.T(), resp)
+
+	// Check parsed response
+	require.NotNil(suite.T(), resp.Result)
+	require.True(suite.T(), resp.Result)
+
+	// Get the recorded request
+	record := suite.srv.PopServerRecord()
+	require.NotNil(suite.T(), record)
+
+	// Check the request settings
+	require.Contains(suite.T(), record.Request.URL.Path, deallocateEarnFundsPath)
+	require.Equal(suite.T(), http.MethodPost, record.Request.Method)
+	require.Equal(suite.T(), suite.client.agent, record.Request.UserAgent())
+	require.Equal(suite.T(), "application/x-www-form-urlencoded", record.Request.Header.Get("Content-Type"))
+	require.NotEmpty(suite.T(), record.Request.Header.Get("Api-Sign"))     // Headers are in canonical form in recorded request
+	require.Equal(suite.T(), apiKey, record.Request.Header.Get("Api-Key")) // Headers are in canonical form in recorded request
+
+	// Check request form body
+	require.NoError(suite.T(), record.Request.ParseForm())
+	require.Equal(suite.T(), strconv.FormatInt(expectedNonce, 10), record.Request.Form.Get("nonce"))
+	require.Equal(suite.T(), expectedSecOpts.SecondFactor, record.Request.Form.Get("otp"))
+	require.Equal(suite.T(), params.Amount, record.Request.Form.Get("amount"))
+	require.Equal(suite.T(), params.StrategyId, record.Request.Form.Get("strategy_id"))
+}
+
+// Test GetAllocationStatus when a valid response is received from the test server.
+//
+// Test will ensure:
+//   - The request is well formatted and contains all inputs.
+//   - The returned values contain the expected parsed response data.
+func (suite *KrakenSpotRESTClientTestSuite) TestGetAllocationStatus() {
+
+	// Expected nonce and secopts
+	expectedNonce := int64(42)
+	expectedSecOpts := &common.SecurityOptions{
+		SecondFactor: "42",
+	}
+
+	// Expected params
+	params := earn.GetAllocationStatusRequestParameters{
+		StrategyId: "ESRFUO3-Q62XD-WIOIL7",
+	}
+
+	// Predefined response
+	expectedJSONResponse := `
+	{
+		"error": [],
+		"result": {
+		  "pending": true
 		}
 	}`
 
@@ -4667,6 +5112,353 @@ func (suite *KrakenSpotRESTClientTestSuite) TestListEarnAllocations() {
 	require.Equal(suite.T(), options.ConvertedAsset, record.Request.Form.Get("converted_asset"))
 }
 
+/*************************************************************************************************/
+/* UNIT TESTS - STAKING                                                                          */
+/*************************************************************************************************/
+
+// Test Stake when a valid response is received from the test server.
+//
+// Test will ensure:
+//   - The request is well formatted and contains all inputs.
+//   - The returned values contain the expected parsed response data.
+func (suite *KrakenSpotRESTClientTestSuite) TestStake() {
+
+	// Expected nonce and secopts
+	expectedNonce := int64(42)
+	expectedSecOpts := &common.SecurityOptions{
+		SecondFactor: "42",
+	}
+
+	// Expected params
+	params := staking.StakeRequestParameters{
+		Asset:  "XBT",
+		Amount: "0.1",
+		Method: "xbt-staked",
+	}
+
+	// Predefined response
+	expectedJSONResponse := `
+	{
+		"error": [],
+		"result": {
+			"refid": "BOG5AE5-KSCNR4-VPNPEV"
+		}
+	}`
+
+	// Configure test server
+	suite.srv.PushPredefinedServerResponse(&gosette.PredefinedServerResponse{
+		Status:  http.StatusOK,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+		Body:    []byte(expectedJSONResponse),
+	})
+
+	// Make request
+	resp, httpresp, err := suite.instrumentedClient.Stake(context.Background(), expectedNonce, params, expectedSecOpts)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), httpresp)
+	require.NotNil(suite.T(), resp)
+
+	// Check parsed response
+	require.NotNil(suite.T(), resp.Result)
+	require.Equal(suite.T(), "BOG5AE5-KSCNR4-VPNPEV", resp.Result.ReferenceId)
+
+	// Get the recorded request
+	record := suite.srv.PopServerRecord()
+	require.NotNil(suite.T(), record)
+
+	// Check the request settings
+	require.Contains(suite.T(), record.Request.URL.Path, stakePath)
+	require.Equal(suite.T(), http.MethodPost, record.Request.Method)
+	require.Equal(suite.T(), suite.client.agent, record.Request.UserAgent())
+	require.Equal(suite.T(), "application/x-www-form-urlencoded", record.Request.Header.Get("Content-Type"))
+	require.NotEmpty(suite.T(), record.Request.Header.Get("Api-Sign"))     // Headers are in canonical form in recorded request
+	require.Equal(suite.T(), apiKey, record.Request.Header.Get("Api-Key")) // Headers are in canonical form in recorded request
+
+	// Check request form body
+	require.NoError(suite.T(), record.Request.ParseForm())
+	require.Equal(suite.T(), strconv.FormatInt(expectedNonce, 10), record.Request.Form.Get("nonce"))
+	require.Equal(suite.T(), expectedSecOpts.SecondFactor, record.Request.Form.Get("otp"))
+	require.Equal(suite.T(), params.Asset, record.Request.Form.Get("asset"))
+	require.Equal(suite.T(), params.Amount, record.Request.Form.Get("amount"))
+	require.Equal(suite.T(), params.Method, record.Request.Form.Get("method"))
+}
+
+// Test Unstake when a valid response is received from the test server.
+//
+// Test will ensure:
+//   - The request is well formatted and contains all inputs.
+//   - The returned values contain the expected parsed response data.
+func (suite *KrakenSpotRESTClientTestSuite) TestUnstake() {
+
+	// Expected nonce and secopts
+	expectedNonce := int64(42)
+	expectedSecOpts := &common.SecurityOptions{
+		SecondFactor: "42",
+	}
+
+	// Expected params
+	params := staking.UnstakeRequestParameters{
+		Asset:  "XBT.M",
+		Amount: "0.1",
+	}
+
+	// Predefined response
+	expectedJSONResponse := `
+	{
+		"error": [],
+		"result": {
+			"refid": "BOG5AE5-KSCNR4-VPNPEV"
+		}
+	}`
+
+	// Configure test server
+	suite.srv.PushPredefinedServerResponse(&gosette.PredefinedServerResponse{
+		Status:  http.StatusOK,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+		Body:    []byte(expectedJSONResponse),
+	})
+
+	// Make request
+	resp, httpresp, err := suite.instrumentedClient.Unstake(context.Background(), expectedNonce, params, expectedSecOpts)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), httpresp)
+	require.NotNil(suite.T(), resp)
+
+	// Check parsed response
+	require.NotNil(suite.T(), resp.Result)
+	require.Equal(suite.T(), "BOG5AE5-KSCNR4-VPNPEV", resp.Result.ReferenceId)
+
+	// Get the recorded request
+	record := suite.srv.PopServerRecord()
+	require.NotNil(suite.T(), record)
+
+	// Check the request settings
+	require.Contains(suite.T(), record.Request.URL.Path, unstakePath)
+	require.Equal(suite.T(), http.MethodPost, record.Request.Method)
+	require.Equal(suite.T(), suite.client.agent, record.Request.UserAgent())
+	require.Equal(suite.T(), "application/x-www-form-urlencoded", record.Request.Header.Get("Content-Type"))
+	require.NotEmpty(suite.T(), record.Request.Header.Get("Api-Sign"))     // Headers are in canonical form in recorded request
+	require.Equal(suite.T(), apiKey, record.Request.Header.Get("Api-Key")) // Headers are in canonical form in recorded request
+
+	// Check request form body
+	require.NoError(suite.T(), record.Request.ParseForm())
+	require.Equal(suite.T(), strconv.FormatInt(expectedNonce, 10), record.Request.Form.Get("nonce"))
+	require.Equal(suite.T(), expectedSecOpts.SecondFactor, record.Request.Form.Get("otp"))
+	require.Equal(suite.T(), params.Asset, record.Request.Form.Get("asset"))
+	require.Equal(suite.T(), params.Amount, record.Request.Form.Get("amount"))
+}
+
+// Test ListStakeableAssets when a valid response is received from the test server.
+//
+// Test will ensure:
+//   - The request is well formatted and contains all inputs.
+//   - The returned values contain the expected parsed response data.
+func (suite *KrakenSpotRESTClientTestSuite) TestListStakeableAssets() {
+
+	// Expected nonce and secopts
+	expectedNonce := int64(42)
+	expectedSecOpts := &common.SecurityOptions{
+		SecondFactor: "42",
+	}
+
+	// Predefined response
+	expectedJSONResponse := `
+	{
+		"error": [],
+		"result": [
+			{
+				"method": "polkadot-staked",
+				"asset": "DOT",
+				"staking_asset": "DOT.S",
+				"rewards": {
+					"reward": "12.00",
+					"type": "percentage"
+				},
+				"on_chain": true,
+				"can_stake": true,
+				"can_unstake": true,
+				"minimum_amount": {
+					"staking": "0.0000000000",
+					"unstaking": "0.0000000000"
+				},
+				"enabled_for_user": true,
+				"disabled": false
+			}
+		]
+	}`
+
+	// Configure test server
+	suite.srv.PushPredefinedServerResponse(&gosette.PredefinedServerResponse{
+		Status:  http.StatusOK,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+		Body:    []byte(expectedJSONResponse),
+	})
+
+	// Make request
+	resp, httpresp, err := suite.instrumentedClient.ListStakeableAssets(context.Background(), expectedNonce, expectedSecOpts)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), httpresp)
+	require.NotNil(suite.T(), resp)
+
+	// Check parsed response
+	require.Len(suite.T(), resp.Result, 1)
+	require.Equal(suite.T(), "polkadot-staked", resp.Result[0].Method)
+	require.Equal(suite.T(), "DOT.S", resp.Result[0].StakingAsset)
+
+	// Get the recorded request
+	record := suite.srv.PopServerRecord()
+	require.NotNil(suite.T(), record)
+
+	// Check the request settings
+	require.Contains(suite.T(), record.Request.URL.Path, listStakeableAssetsPath)
+	require.Equal(suite.T(), http.MethodPost, record.Request.Method)
+	require.Equal(suite.T(), suite.client.agent, record.Request.UserAgent())
+	require.Equal(suite.T(), "application/x-www-form-urlencoded", record.Request.Header.Get("Content-Type"))
+	require.NotEmpty(suite.T(), record.Request.Header.Get("Api-Sign"))     // Headers are in canonical form in recorded request
+	require.Equal(suite.T(), apiKey, record.Request.Header.Get("Api-Key")) // Headers are in canonical form in recorded request
+
+	// Check request form body
+	require.NoError(suite.T(), record.Request.ParseForm())
+	require.Equal(suite.T(), strconv.FormatInt(expectedNonce, 10), record.Request.Form.Get("nonce"))
+	require.Equal(suite.T(), expectedSecOpts.SecondFactor, record.Request.Form.Get("otp"))
+}
+
+// Test GetPendingStakingTransactions when a valid response is received from the test server.
+//
+// Test will ensure:
+//   - The request is well formatted and contains all inputs.
+//   - The returned values contain the expected parsed response data.
+func (suite *KrakenSpotRESTClientTestSuite) TestGetPendingStakingTransactions() {
+
+	// Expected nonce and secopts
+	expectedNonce := int64(42)
+	expectedSecOpts := &common.SecurityOptions{
+		SecondFactor: "42",
+	}
+
+	// Predefined response
+	expectedJSONResponse := `
+	{
+		"error": [],
+		"result": [
+			{
+				"method": "ada-staked",
+				"aclass": "currency",
+				"asset": "ADA.S",
+				"refid": "RUSB7W6-ESIXUX-K6PVTM",
+				"amount": "0.34844300",
+				"fee": "0.00000000",
+				"time": 1688967367,
+				"status": "Initial",
+				"type": "bonding"
+			}
+		]
+	}`
+
+	// Configure test server
+	suite.srv.PushPredefinedServerResponse(&gosette.PredefinedServerResponse{
+		Status:  http.StatusOK,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+		Body:    []byte(expectedJSONResponse),
+	})
+
+	// Make request
+	resp, httpresp, err := suite.instrumentedClient.GetPendingStakingTransactions(context.Background(), expectedNonce, expectedSecOpts)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), httpresp)
+	require.NotNil(suite.T(), resp)
+
+	// Check parsed response
+	require.Len(suite.T(), resp.Result, 1)
+	require.Equal(suite.T(), "RUSB7W6-ESIXUX-K6PVTM", resp.Result[0].ReferenceId)
+	require.Equal(suite.T(), int64(1688967367), resp.Result[0].Timestamp)
+
+	// Get the recorded request
+	record := suite.srv.PopServerRecord()
+	require.NotNil(suite.T(), record)
+
+	// Check the request settings
+	require.Contains(suite.T(), record.Request.URL.Path, getPendingStakingTransactionsPath)
+	require.Equal(suite.T(), http.MethodPost, record.Request.Method)
+	require.Equal(suite.T(), suite.client.agent, record.Request.UserAgent())
+	require.Equal(suite.T(), "application/x-www-form-urlencoded", record.Request.Header.Get("Content-Type"))
+	require.NotEmpty(suite.T(), record.Request.Header.Get("Api-Sign"))     // Headers are in canonical form in recorded request
+	require.Equal(suite.T(), apiKey, record.Request.Header.Get("Api-Key")) // Headers are in canonical form in recorded request
+
+	// Check request form body
+	require.NoError(suite.T(), record.Request.ParseForm())
+	require.Equal(suite.T(), strconv.FormatInt(expectedNonce, 10), record.Request.Form.Get("nonce"))
+	require.Equal(suite.T(), expectedSecOpts.SecondFactor, record.Request.Form.Get("otp"))
+}
+
+// Test ListRecentStakingTransactions when a valid response is received from the test server.
+//
+// Test will ensure:
+//   - The request is well formatted and contains all inputs.
+//   - The returned values contain the expected parsed response data.
+func (suite *KrakenSpotRESTClientTestSuite) TestListRecentStakingTransactions() {
+
+	// Expected nonce and secopts
+	expectedNonce := int64(42)
+	expectedSecOpts := &common.SecurityOptions{
+		SecondFactor: "42",
+	}
+
+	// Predefined response
+	expectedJSONResponse := `
+	{
+		"error": [],
+		"result": [
+			{
+				"method": "ada-staked",
+				"aclass": "currency",
+				"asset": "ADA.S",
+				"refid": "RUSB7W6-ESIXUX-K6PVTM",
+				"amount": "0.34844300",
+				"fee": "0.00000000",
+				"time": 1688967367,
+				"status": "Initial",
+				"type": "bonding"
+			}
+		]
+	}`
+
+	// Configure test server
+	suite.srv.PushPredefinedServerResponse(&gosette.PredefinedServerResponse{
+		Status:  http.StatusOK,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+		Body:    []byte(expectedJSONResponse),
+	})
+
+	// Make request
+	resp, httpresp, err := suite.instrumentedClient.ListRecentStakingTransactions(context.Background(), expectedNonce, expectedSecOpts)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), httpresp)
+	require.NotNil(suite.T(), resp)
+
+	// Check parsed response
+	require.Len(suite.T(), resp.Result, 1)
+	require.Equal(suite.T(), "RUSB7W6-ESIXUX-K6PVTM", resp.Result[0].ReferenceId)
+	require.Equal(suite.T(), int64(1688967367), resp.Result[0].Timestamp)
+
+	// Get the recorded request
+	record := suite.srv.PopServerRecord()
+	require.NotNil(suite.T(), record)
+
+	// Check the request settings
+	require.Contains(suite.T(), record.Request.URL.Path, listRecentStakingTransactionsPath)
+	require.Equal(suite.T(), http.MethodPost, record.Request.Method)
+	require.Equal(suite.T(), suite.client.agent, record.Request.UserAgent())
+	require.Equal(suite.T(), "application/x-www-form-urlencoded", record.Request.Header.Get("Content-Type"))
+	require.NotEmpty(suite.T(), record.Request.Header.Get("Api-Sign"))     // Headers are in canonical form in recorded request
+	require.Equal(suite.T(), apiKey, record.Request.Header.Get("Api-Key")) // Headers are in canonical form in recorded request
+
+	// Check request form body
+	require.NoError(suite.T(), record.Request.ParseForm())
+	require.Equal(suite.T(), strconv.FormatInt(expectedNonce, 10), record.Request.Form.Get("nonce"))
+	require.Equal(suite.T(), expectedSecOpts.SecondFactor, record.Request.Form.Get("otp"))
+}
+
 /*************************************************************************************************/
 /* UNIT TESTS - WEBSOCKET                                                                        */
 /*************************************************************************************************/
@@ -0,0 +1,374 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/codec"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+/*****************************************************************************/
+/* LAX DECODING: MODEL                                                       */
+/*****************************************************************************/
+
+// Name of the metric which counts the schema drifts detected by the REST client when lax
+// decoding is enabled.
+const SchemaDriftMetricName = "goctopus.sdk.spot.rest.schema_drift"
+
+// Enum for the kinds of schema drift.
+type SchemaDriftKindEnum string
+
+// Values for SchemaDriftKindEnum
+const (
+	// The response contains a field which is unknown to the response model.
+	UnknownField SchemaDriftKindEnum = "unknown_field"
+	// The type of a field of the response does not match the type of the response model.
+	TypeMismatch SchemaDriftKindEnum = "type_mismatch"
+)
+
+// Schema drift detected in a response from the API.
+type SchemaDrift struct {
+	// Kind of schema drift
+	Kind SchemaDriftKindEnum
+	// Path of the API operation (ex: /0/public/Ticker)
+	Operation string
+	// Path of the field in the response (ex: result.XXBTZUSD.new_field)
+	Field string
+	// Path of the field where map keys are replaced by * and array indexes by [] (ex:
+	// result.*.new_field). Used as metric attribute.
+	Pattern string
+}
+
+// Settings for the lax decoding of the responses of KrakenSpotRESTClient.
+//
+// When lax decoding is enabled, fields which are unknown to the response models and fields whose
+// type does not match the response models are reported as schema drifts instead of failing the
+// request. Their raw JSON values are captured in the RawExtra map of the response (Cf.
+// common.KrakenSpotRESTResponse). Schema drifts are logged, counted with the
+// SchemaDriftMetricName metric and reported to the optional callback.
+//
+// Type mismatches are detected by comparing the JSON types of the response values with the
+// response model, whatever the error returned by the JSON codec: all the mismatches of a response
+// are reported and the response is decoded again without the mismatched values. Fields of types
+// which implement their own JSON decoding (ex: arrays decoded as structs) are not inspected.
+type LaxDecodingConfiguration struct {
+	// Optional logger used to report schema drifts. If nil, schema drifts are not logged.
+	Logger *log.Logger
+	// Optional callback called with each schema drift. The callback is called from the goroutine
+	// which sends the request: it must not block.
+	OnSchemaDrift func(ctx context.Context, drift SchemaDrift)
+}
+
+// Decoder used to process responses when lax decoding is enabled.
+type laxDecoder struct {
+	// Settings
+	cfg LaxDecodingConfiguration
+	// JSON codec used to decode the responses again without their mismatched values
+	codec codec.JSONCodec
+	// Counter used to record schema drifts
+	counter metric.Int64Counter
+}
+
+// Interface implemented by response models which can hold raw JSON values of unknown fields.
+type rawExtraHolder interface {
+	SetRawExtra(extra map[string]json.RawMessage)
+}
+
+// Type of json.Unmarshaler
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// Type of encoding.TextUnmarshaler
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// Type of json.Number
+var jsonNumberType = reflect.TypeOf(json.Number(""))
+
+/*****************************************************************************/
+/* LAX DECODING: FUNCTIONS                                                   */
+/*****************************************************************************/
+
+// Build a new lax decoder which uses the provided JSON codec and create the schema drift counter
+// with the provided meter provider. If the meter provider is nil, the global meter provider is
+// used.
+func newLaxDecoder(cfg LaxDecodingConfiguration, jsonCodec codec.JSONCodec, meterProvider metric.MeterProvider) *laxDecoder {
+	decoder := &laxDecoder{cfg: cfg, codec: jsonCodec}
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+	// Metric is best effort: lax decoding works without it.
+	decoder.counter, _ = meterProvider.
+		Meter(tracing.PackageName, metric.WithInstrumentationVersion(tracing.PackageVersion)).
+		Int64Counter(
+			SchemaDriftMetricName,
+			metric.WithDescription("Number of schema drifts (unknown fields, type mismatches) detected in REST API responses"))
+	return decoder
+}
+
+// # Description
+//
+// Detect schema drifts in a decoded response, capture their raw values in the response and
+// report them.
+//
+// If the JSON codec has failed to decode the response and type mismatches are found, the
+// response is decoded again without the mismatched values, which are left to their zero value.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing purpose.
+//   - req: Request sent to the API.
+//   - body: Raw JSON response.
+//   - receiver: Response model the body has been decoded into.
+//   - err: Error returned by the JSON codec.
+//
+// # Return
+//
+// Nil if the response has been decoded, either at once or once the mismatched values have been
+// removed. The decoding error otherwise.
+func (decoder *laxDecoder) process(ctx context.Context, req *http.Request, body []byte, receiver interface{}, err error) error {
+	drifts := []SchemaDrift{}
+	extra := map[string]json.RawMessage{}
+	mismatches := 0
+	sanitized, _ := findSchemaDrifts(body, reflect.TypeOf(receiver), "", "", func(kind SchemaDriftKindEnum, field string, pattern string, raw json.RawMessage) {
+		if kind == TypeMismatch {
+			mismatches++
+		}
+		extra[field] = raw
+		drifts = append(drifts, SchemaDrift{Kind: kind, Field: field, Pattern: pattern})
+	})
+	if err != nil {
+		if mismatches == 0 {
+			return err
+		}
+		// Decode again from scratch without the mismatched values
+		target := reflect.ValueOf(receiver)
+		if target.Kind() != reflect.Pointer || target.IsNil() {
+			return err
+		}
+		target.Elem().Set(reflect.Zero(target.Elem().Type()))
+		if decoder.codec.Unmarshal(sanitized, receiver) != nil {
+			return err
+		}
+	}
+	if len(drifts) == 0 {
+		return nil
+	}
+	if holder, ok := receiver.(rawExtraHolder); ok {
+		holder.SetRawExtra(extra)
+	}
+	for _, drift := range drifts {
+		drift.Operation = req.URL.Path
+		if decoder.cfg.Logger != nil {
+			decoder.cfg.Logger.Printf("schema drift (%s) detected on %s for field %s", drift.Kind, drift.Operation, drift.Field)
+		}
+		if decoder.counter != nil {
+			decoder.counter.Add(ctx, 1, metric.WithAttributes(
+				attribute.String("kind", string(drift.Kind)),
+				attribute.String("operation", drift.Operation),
+				attribute.String("field", drift.Pattern)))
+		}
+		if decoder.cfg.OnSchemaDrift != nil {
+			decoder.cfg.OnSchemaDrift(ctx, drift)
+		}
+	}
+	return nil
+}
+
+// # Description
+//
+// Walk the raw JSON data along the provided type and report the object fields which do not
+// match any field of the type and the values whose JSON type does not match the type. Values
+// whose type implements json.Unmarshaler or encoding.TextUnmarshaler or is an interface are not
+// inspected. The type of the root value is not checked.
+//
+// # Inputs
+//
+//   - data: Raw JSON data.
+//   - t: Type the data has been decoded into.
+//   - field: Path of the data.
+//   - pattern: Path of the data where map keys are replaced by * and array indexes by [].
+//   - report: Function called with each schema drift and the raw value of the field.
+//
+// # Return
+//
+// The data where mismatched values are replaced by null and true if a value has been replaced.
+func findSchemaDrifts(data json.RawMessage, t reflect.Type, field string, pattern string, report func(kind SchemaDriftKindEnum, field string, pattern string, raw json.RawMessage)) (json.RawMessage, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface ||
+		reflect.PointerTo(t).Implements(jsonUnmarshalerType) ||
+		reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return data, false
+	}
+	if field != "" && !matchesJSONType(data, t) {
+		report(TypeMismatch, field, pattern, data)
+		return json.RawMessage("null"), true
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		object := map[string]json.RawMessage{}
+		if json.Unmarshal(data, &object) != nil {
+			return data, false
+		}
+		fields := jsonFields(t)
+		changed := false
+		for key, raw := range object {
+			ft, ok := fields[key]
+			if !ok {
+				// encoding/json matches field names case insensitively
+				for name, candidate := range fields {
+					if strings.EqualFold(name, key) {
+						ft, ok = candidate, true
+						break
+					}
+				}
+			}
+			if !ok {
+				report(UnknownField, joinFieldPath(field, key), joinFieldPath(pattern, key), raw)
+				continue
+			}
+			if sanitized, replaced := findSchemaDrifts(raw, ft, joinFieldPath(field, key), joinFieldPath(pattern, key), report); replaced {
+				object[key] = sanitized
+				changed = true
+			}
+		}
+		return marshalIfChanged(data, object, changed)
+	case reflect.Map:
+		object := map[string]json.RawMessage{}
+		if json.Unmarshal(data, &object) != nil {
+			return data, false
+		}
+		changed := false
+		for key, raw := range object {
+			if sanitized, replaced := findSchemaDrifts(raw, t.Elem(), joinFieldPath(field, key), joinFieldPath(pattern, "*"), report); replaced {
+				object[key] = sanitized
+				changed = true
+			}
+		}
+		return marshalIfChanged(data, object, changed)
+	case reflect.Slice, reflect.Array:
+		items := []json.RawMessage{}
+		if json.Unmarshal(data, &items) != nil {
+			return data, false
+		}
+		changed := false
+		for i, raw := range items {
+			if sanitized, replaced := findSchemaDrifts(raw, t.Elem(), fmt.Sprintf("%s[%d]", field, i), pattern+"[]", report); replaced {
+				items[i] = sanitized
+				changed = true
+			}
+		}
+		return marshalIfChanged(data, items, changed)
+	}
+	return data, false
+}
+
+// Check whether the JSON type of the raw value can be decoded into the provided type. Null can be
+// decoded into any type.
+func matchesJSONType(raw json.RawMessage, t reflect.Type) bool {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || raw[0] == 'n' {
+		return true
+	}
+	isString := raw[0] == '"'
+	isNumber := raw[0] == '-' || (raw[0] >= '0' && raw[0] <= '9')
+	switch t.Kind() {
+	case reflect.String:
+		if t == jsonNumberType {
+			return isNumber || isString
+		}
+		return isString
+	case reflect.Bool:
+		return raw[0] == 't' || raw[0] == 'f'
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, err := strconv.ParseInt(string(raw), 10, t.Bits())
+		return isNumber && err == nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		_, err := strconv.ParseUint(string(raw), 10, t.Bits())
+		return isNumber && err == nil
+	case reflect.Float32, reflect.Float64:
+		return isNumber
+	case reflect.Struct, reflect.Map:
+		return raw[0] == '{'
+	case reflect.Slice:
+		// Byte slices are decoded from base64 strings
+		return raw[0] == '[' || (isString && t.Elem().Kind() == reflect.Uint8)
+	case reflect.Array:
+		return raw[0] == '['
+	default:
+		return true
+	}
+}
+
+// Encode the value if it has changed. Otherwise, or if the value cannot be encoded, return the
+// original data.
+func marshalIfChanged(data json.RawMessage, value interface{}, changed bool) (json.RawMessage, bool) {
+	if !changed {
+		return data, false
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return data, false
+	}
+	return encoded, true
+}
+
+// Get the types of the fields of a struct by JSON name. Fields of embedded structs are included
+// unless they are shadowed by a field of the outer struct.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	embedded := []reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	for _, et := range embedded {
+		for name, ft := range jsonFields(et) {
+			if _, ok := fields[name]; !ok {
+				fields[name] = ft
+			}
+		}
+	}
+	return fields
+}
+
+// Append a key to a field path.
+func joinFieldPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,222 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gbdevw/gosette"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the lax decoding of REST responses
+type LaxDecodingTestSuite struct {
+	suite.Suite
+	// Mock HTTP server
+	srv *gosette.HTTPTestServer
+}
+
+// Run unit test suite
+func TestLaxDecodingTestSuite(t *testing.T) {
+	tstsrv := gosette.NewHTTPTestServer(nil)
+	tstsrv.Start()
+	defer tstsrv.Close()
+	suite.Run(t, &LaxDecodingTestSuite{srv: tstsrv})
+}
+
+// Clean the server predefined responses and records before each test.
+func (suite *LaxDecodingTestSuite) BeforeTest(suiteName, testName string) {
+	suite.srv.Clear()
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Response with an unknown field and a type mismatch
+const driftedAssetInfo = `{"error":[],"result":{"XXBT":{"aclass":"currency","altname":"XBT","decimals":"10","display_decimals":5,"collateral_value":1,"status":"enabled","new_field":{"a":1}}}}`
+
+// Test lax decoding.
+//
+// Test will ensure:
+//   - A type mismatch fails the request when lax decoding is disabled.
+//   - With lax decoding, the response is decoded, the raw values of the unknown field and of the
+//     mismatched field are captured and the schema drifts are logged and reported.
+//   - Schema drifts are counted with the meter provider set in the client configuration.
+//   - A response which matches the model does not report any schema drift.
+func (suite *LaxDecodingTestSuite) TestLaxDecoding() {
+	// Strict
+	suite.pushResponse(driftedAssetInfo)
+	_, _, err := suite.newClient(nil).GetAssetInfo(context.Background(), nil)
+	require.Error(suite.T(), err)
+	// Lax
+	buf := new(bytes.Buffer)
+	drifts := []SchemaDrift{}
+	meter := &recordingMeter{counts: map[string]int64{}}
+	client := NewKrakenSpotRESTClient(nil, &KrakenSpotRESTClientConfiguration{
+		BaseURL: suite.srv.GetBaseURL(),
+		Agent:   usrAgent,
+		LaxDecoding: &LaxDecodingConfiguration{
+			Logger: log.New(buf, "", 0),
+			OnSchemaDrift: func(ctx context.Context, drift SchemaDrift) {
+				drifts = append(drifts, drift)
+			},
+		},
+		MeterProvider: &recordingMeterProvider{meter: meter},
+	})
+	suite.pushResponse(driftedAssetInfo)
+	resp, _, err := client.GetAssetInfo(context.Background(), nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "XBT", resp.Result["XXBT"].Altname)
+	require.Equal(suite.T(), 5, resp.Result["XXBT"].DisplayDecimals)
+	require.Equal(suite.T(), map[string]json.RawMessage{
+		"result.XXBT.new_field": json.RawMessage(`{"a":1}`),
+		"result.XXBT.decimals":  json.RawMessage(`"10"`),
+	}, resp.GetRawExtra())
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].Field < drifts[j].Field })
+	require.Equal(suite.T(), []SchemaDrift{
+		{Kind: TypeMismatch, Operation: "/public/Assets", Field: "result.XXBT.decimals", Pattern: "result.*.decimals"},
+		{Kind: UnknownField, Operation: "/public/Assets", Field: "result.XXBT.new_field", Pattern: "result.*.new_field"},
+	}, drifts)
+	require.Contains(suite.T(), buf.String(), "schema drift (unknown_field) detected on /public/Assets for field result.XXBT.new_field")
+	require.Equal(suite.T(), map[string]int64{string(TypeMismatch): 1, string(UnknownField): 1}, meter.counts)
+	// No drift
+	drifts = []SchemaDrift{}
+	suite.pushResponse(`{"error":[],"result":{"XXBT":{"aclass":"currency","altname":"XBT","decimals":10}}}`)
+	resp, _, err = client.GetAssetInfo(context.Background(), nil)
+	require.NoError(suite.T(), err)
+	require.Nil(suite.T(), resp.GetRawExtra())
+	require.Empty(suite.T(), drifts)
+	// Malformed responses still fail
+	suite.pushResponse(`{"error":[],"result":`)
+	_, _, err = client.GetAssetInfo(context.Background(), nil)
+	require.Error(suite.T(), err)
+}
+
+// Test type mismatches are tolerated whatever the error returned by the JSON codec.
+//
+// Test will ensure:
+//   - All the type mismatches of a response are reported and their raw values are captured.
+//   - The other fields are decoded even if the codec stops at the first error.
+func (suite *LaxDecodingTestSuite) TestTypeMismatches() {
+	drifts := []SchemaDrift{}
+	client := NewKrakenSpotRESTClient(nil, &KrakenSpotRESTClientConfiguration{
+		BaseURL: suite.srv.GetBaseURL(),
+		Agent:   usrAgent,
+		Codec:   abortingCodec{},
+		LaxDecoding: &LaxDecodingConfiguration{
+			OnSchemaDrift: func(ctx context.Context, drift SchemaDrift) {
+				drifts = append(drifts, drift)
+			},
+		},
+	})
+	suite.pushResponse(`{"error":[],"result":{"XXBT":{"aclass":"currency","altname":"XBT","decimals":"10","display_decimals":true,"status":"enabled"}}}`)
+	resp, _, err := client.GetAssetInfo(context.Background(), nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "XBT", resp.Result["XXBT"].Altname)
+	require.Equal(suite.T(), "enabled", resp.Result["XXBT"].Status)
+	require.Zero(suite.T(), resp.Result["XXBT"].Decimals)
+	require.Equal(suite.T(), map[string]json.RawMessage{
+		"result.XXBT.decimals":         json.RawMessage(`"10"`),
+		"result.XXBT.display_decimals": json.RawMessage(`true`),
+	}, resp.GetRawExtra())
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].Field < drifts[j].Field })
+	require.Equal(suite.T(), []SchemaDrift{
+		{Kind: TypeMismatch, Operation: "/public/Assets", Field: "result.XXBT.decimals", Pattern: "result.*.decimals"},
+		{Kind: TypeMismatch, Operation: "/public/Assets", Field: "result.XXBT.display_decimals", Pattern: "result.*.display_decimals"},
+	}, drifts)
+	// A root value with an unexpected type still fails
+	suite.pushResponse(`[]`)
+	_, _, err = client.GetAssetInfo(context.Background(), nil)
+	require.Error(suite.T(), err)
+}
+
+// Test the detection of schema drifts.
+//
+// Test will ensure:
+//   - Fields of embedded structs are known and fields are matched case insensitively.
+//   - Unknown fields are reported in nested objects, maps and arrays.
+//   - Type mismatches are reported and replaced by null in the returned data.
+//   - Values whose type implements json.Unmarshaler are not inspected.
+func (suite *LaxDecodingTestSuite) TestFindSchemaDrifts() {
+	body := `{"error":[],"ERROR":[],"result":{"Last":1,"PairId":"XXBTZUSD","extra":true},"trades":[{"a":1},{"b":2},{"a":1.5}],"count":"3"}`
+	receiver := &struct {
+		market.GetRecentSpreadsResponse
+		Trades []struct {
+			A int `json:"a"`
+		} `json:"trades"`
+		Count int `json:"count"`
+	}{}
+	found := map[string]string{}
+	sanitized, replaced := findSchemaDrifts([]byte(body), reflect.TypeOf(receiver), "", "", func(kind SchemaDriftKindEnum, field string, pattern string, raw json.RawMessage) {
+		found[field] = string(kind) + ":" + pattern
+	})
+	require.Equal(suite.T(), map[string]string{
+		"trades[1].b": "unknown_field:trades[].b",
+		"trades[2].a": "type_mismatch:trades[].a",
+		"count":       "type_mismatch:count",
+	}, found)
+	require.True(suite.T(), replaced)
+	values := map[string]interface{}{}
+	require.NoError(suite.T(), json.Unmarshal(sanitized, &values))
+	require.Nil(suite.T(), values["count"])
+	require.Equal(suite.T(), []interface{}{map[string]interface{}{"a": 1.0}, map[string]interface{}{"b": 2.0}, map[string]interface{}{"a": nil}}, values["trades"])
+	require.Equal(suite.T(), map[string]interface{}{"Last": 1.0, "PairId": "XXBTZUSD", "extra": true}, values["result"])
+	// Nothing to replace
+	sanitized, replaced = findSchemaDrifts([]byte(`{"count":3}`), reflect.TypeOf(receiver), "", "", func(SchemaDriftKindEnum, string, string, json.RawMessage) {})
+	require.False(suite.T(), replaced)
+	require.Equal(suite.T(), `{"count":3}`, string(sanitized))
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build a client which uses the test server and the provided lax decoding settings.
+func (suite *LaxDecodingTestSuite) newClient(cfg *LaxDecodingConfiguration) *KrakenSpotRESTClient {
+	return NewKrakenSpotRESTClient(nil, &KrakenSpotRESTClientConfiguration{
+		BaseURL:     suite.srv.GetBaseURL(),
+		Agent:       usrAgent,
+		LaxDecoding: cfg,
+	})
+}
+
+// Replace the predefined responses of the test server by the provided JSON response.
+func (suite *LaxDecodingTestSuite) pushResponse(body string) {
+	suite.srv.Clear()
+	suite.srv.PushPredefinedServerResponse(&gosette.PredefinedServerResponse{
+		Status:  http.StatusOK,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+		Body:    []byte(body),
+	})
+}
+
+// JSON codec which stops at the first error and does not wrap the errors of encoding/json.
+type abortingCodec struct{}
+
+// Encode with encoding/json.
+func (abortingCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Decode with encoding/json and reset the value on error.
+func (abortingCodec) Unmarshal(data []byte, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		target := reflect.ValueOf(v).Elem()
+		target.Set(reflect.Zero(target.Type()))
+		return fmt.Errorf("decoding aborted: %s", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+)
+
+/*****************************************************************************/
+/* LEDGER SYNC: MODEL                                                        */
+/*****************************************************************************/
+
+// Error returned by Kraken API when the rate limit of the API key has been exceeded.
+const RateLimitExceededError = "EAPI:Rate limit exceeded"
+
+// Maximum number of ledger entries returned by a single GetLedgersInfo call.
+const LedgersInfoPageSize = 50
+
+// Default delay waited by SyncLedger before retrying a request rejected because the rate limit
+// has been exceeded.
+const DefaultLedgerSyncRetryDelay = 5 * time.Second
+
+// Default maximum number of retries of a request rejected because the rate limit has been
+// exceeded.
+const DefaultLedgerSyncMaxRetries = 3
+
+// Position of the newest ledger entry synced by SyncLedger.
+type LedgerCursor struct {
+	// ID of the newest synced ledger entry
+	Id string `json:"id"`
+	// Unix timestamp of the newest synced ledger entry
+	Timestamp json.Number `json:"time"`
+}
+
+// Ledger entry synced by SyncLedger.
+type SyncedLedgerEntry struct {
+	// Ledger entry ID
+	Id string
+	// Ledger entry
+	Entry *account.LedgerEntry
+}
+
+// # Description
+//
+// Interface for stores used by SyncLedger to keep a local copy of the account ledger and the
+// position of the newest synced entry.
+//
+// # Implementation and usage guidelines
+//
+//   - GetLedgerCursor must return nil and no error when the ledger has never been synced.
+//
+//   - SaveLedgerEntries should save the entries and the cursor atomically. Otherwise, entries
+//     saved without their cursor are fetched again by the next sync: saving entries must then be
+//     idempotent (entries are identified by their ID).
+//
+//   - Implementations must be safe for concurrent use.
+type LedgerStore interface {
+	// Get the position of the newest synced ledger entry. Nil if the ledger has never been synced.
+	GetLedgerCursor(ctx context.Context) (*LedgerCursor, error)
+	// Save new ledger entries, from the oldest to the newest, and the cursor which points to the
+	// newest one.
+	SaveLedgerEntries(ctx context.Context, entries []SyncedLedgerEntry, cursor LedgerCursor) error
+}
+
+// LedgerSyncer keeps a local copy of the account ledger up to date: each call to SyncLedger only
+// fetches the ledger entries which are newer than the last synced entry.
+type LedgerSyncer struct {
+	// REST client used to send requests
+	client KrakenSpotRESTClientIface
+	// Nonce generator used to sign requests
+	nonceGenerator noncegen.NonceGenerator
+	// Security options used for requests
+	secopts *common.SecurityOptions
+	// Optional rate limiter waited before each request
+	limiter RateLimiter
+	// Delay waited before retrying a rate limited request
+	retryDelay time.Duration
+	// Maximum number of retries of a rate limited request
+	maxRetries int
+	// Clock used to wait before retries
+	clock clock.Clock
+}
+
+/*****************************************************************************/
+/* LEDGER SYNC: FUNCTIONS                                                    */
+/*****************************************************************************/
+
+// # Description
+//
+// Factory which creates a new LedgerSyncer.
+//
+// # Inputs
+//
+//   - client: REST client used to send requests. Must not be nil.
+//   - nonceGenerator: Nonce generator used to sign requests. Must not be nil.
+//   - secopts: Optional security options (like password 2FA) to use for requests. Can be nil if 2FA is not used.
+//
+// # Return
+//
+// The new LedgerSyncer or an error if the client or the nonce generator is nil.
+func NewLedgerSyncer(client KrakenSpotRESTClientIface, nonceGenerator noncegen.NonceGenerator, secopts *common.SecurityOptions) (*LedgerSyncer, error) {
+	if client == nil || nonceGenerator == nil {
+		return nil, fmt.Errorf("rest client and nonce generator cannot be nil")
+	}
+	return &LedgerSyncer{
+		client:         client,
+		nonceGenerator: nonceGenerator,
+		secopts:        secopts,
+		retryDelay:     DefaultLedgerSyncRetryDelay,
+		maxRetries:     DefaultLedgerSyncMaxRetries,
+		clock:          clock.NewSystemClock(),
+	}, nil
+}
+
+// Set the optional rate limiter waited before each GetLedgersInfo request. If nil, requests are
+// not rate limited.
+func (s *LedgerSyncer) SetRateLimiter(limiter RateLimiter) {
+	s.limiter = limiter
+}
+
+// Set how requests rejected because the rate limit has been exceeded are retried. If delay is
+// not strictly positive, DefaultLedgerSyncRetryDelay is used. If maxRetries is negative,
+// DefaultLedgerSyncMaxRetries is used. Use 0 to disable retries.
+func (s *LedgerSyncer) SetRateLimitRetry(delay time.Duration, maxRetries int) {
+	if delay <= 0 {
+		delay = DefaultLedgerSyncRetryDelay
+	}
+	if maxRetries < 0 {
+		maxRetries = DefaultLedgerSyncMaxRetries
+	}
+	s.retryDelay = delay
+	s.maxRetries = maxRetries
+}
+
+// Set the clock used to wait before retries. This can be used to provide a clock.FakeClock in
+// tests. If nil, the system clock is used.
+func (s *LedgerSyncer) SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.NewSystemClock()
+	}
+	s.clock = c
+}
+
+// # Description
+//
+// Fetch the ledger entries which are newer than the cursor of the store and save them in the
+// store. The whole ledger is fetched on the first sync.
+//
+// The first page of GetLedgersInfo gives the number of new entries: the newest entry is then used
+// as the end of the sync so offsets do not shift if new entries are created meanwhile. Pages of
+// LedgersInfoPageSize entries are fetched from the oldest to the newest and each page is saved
+// with its cursor as soon as it is fetched: an interrupted sync resumes from the last saved
+// page. Entries created during the sync are fetched by the next sync.
+//
+// Requests rejected because the rate limit has been exceeded are retried (Cf.
+// SetRateLimitRetry). Use SetRateLimiter to pace requests.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - store: Store which contains the local copy of the ledger. Must not be nil.
+//
+// # Return
+//
+// The number of saved entries and an error if the cursor could not be read, a request failed,
+// the server replied with an error or entries could not be saved. Entries saved before the error
+// are counted.
+func (s *LedgerSyncer) SyncLedger(ctx context.Context, store LedgerStore) (int, error) {
+	cursor, err := store.GetLedgerCursor(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("sync ledger failed: %w", err)
+	}
+	opts := account.GetLedgersInfoRequestOptions{}
+	if cursor != nil {
+		opts.Start = cursor.Id
+	}
+	first, err := s.fetch(ctx, opts)
+	if err != nil {
+		return 0, fmt.Errorf("sync ledger failed: %w", err)
+	}
+	if len(first.Ledgers) == 0 {
+		return 0, nil
+	}
+	newest := sortLedgerEntries(first.Ledgers)
+	opts.End = newest[len(newest)-1].Id
+	total := max(first.Count, len(newest))
+	synced := 0
+	// Older pages first so the cursor only moves forward
+	for offset := ((total - 1) / LedgersInfoPageSize) * LedgersInfoPageSize; offset > 0; offset -= LedgersInfoPageSize {
+		opts.Offset = int64(offset)
+		page, err := s.fetch(ctx, opts)
+		if err != nil {
+			return synced, fmt.Errorf("sync ledger failed at offset %d: %w", offset, err)
+		}
+		n, err := saveLedgerEntries(ctx, store, sortLedgerEntries(page.Ledgers))
+		synced += n
+		if err != nil {
+			return synced, fmt.Errorf("sync ledger failed: %w", err)
+		}
+	}
+	n, err := saveLedgerEntries(ctx, store, newest)
+	synced += n
+	if err != nil {
+		return synced, fmt.Errorf("sync ledger failed: %w", err)
+	}
+	return synced, nil
+}
+
+// Fetch a page of ledger entries. Requests rejected because the rate limit has been exceeded are
+// retried.
+func (s *LedgerSyncer) fetch(ctx context.Context, opts account.GetLedgersInfoRequestOptions) (*account.LedgersInfoResult, error) {
+	for retries := 0; ; retries++ {
+		if s.limiter != nil {
+			if err := s.limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		resp, _, err := s.client.GetLedgersInfo(ctx, s.nonceGenerator.GenerateNonce(), &opts, s.secopts)
+		if err != nil {
+			return nil, fmt.Errorf("get ledgers info failed: %w", err)
+		}
+		if len(resp.Error) == 0 {
+			if resp.Result == nil {
+				return &account.LedgersInfoResult{}, nil
+			}
+			return resp.Result, nil
+		}
+		if !isRateLimitExceeded(resp.Error) || retries >= s.maxRetries {
+			return nil, fmt.Errorf("get ledgers info failed: %v", resp.Error)
+		}
+		timer := s.clock.NewTimer(s.retryDelay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("get ledgers info failed: %w", ctx.Err())
+		case <-timer.C():
+		}
+	}
+}
+
+// Tell whether the errors of a response include RateLimitExceededError.
+func isRateLimitExceeded(errs []string) bool {
+	for _, err := range errs {
+		if err == RateLimitExceededError {
+			return true
+		}
+	}
+	return false
+}
+
+// Sort ledger entries from the oldest to the newest. Entries with the same timestamp are sorted
+// by ID.
+func sortLedgerEntries(ledgers map[string]*account.LedgerEntry) []SyncedLedgerEntry {
+	entries := make([]SyncedLedgerEntry, 0, len(ledgers))
+	times := make(map[string]float64, len(ledgers))
+	for id, entry := range ledgers {
+		entries = append(entries, SyncedLedgerEntry{Id: id, Entry: entry})
+		if entry != nil {
+			times[id], _ = entry.Timestamp.Float64()
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if times[entries[i].Id] != times[entries[j].Id] {
+			return times[entries[i].Id] < times[entries[j].Id]
+		}
+		return entries[i].Id < entries[j].Id
+	})
+	return entries
+}
+
+// Save sorted entries with the cursor which points to the newest one and return the number of
+// saved entries.
+func saveLedgerEntries(ctx context.Context, store LedgerStore, entries []SyncedLedgerEntry) (int, error) {
+	if len(entries) == 0 {
+		return 0, nil
+	}
+	last := entries[len(entries)-1]
+	cursor := LedgerCursor{Id: last.Id}
+	if last.Entry != nil {
+		cursor.Timestamp = last.Entry.Timestamp
+	}
+	if err := store.SaveLedgerEntries(ctx, entries, cursor); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+/*****************************************************************************/
+/* LEDGER SYNC: MEMORY STORE                                                 */
+/*****************************************************************************/
+
+// LedgerStore which keeps ledger entries in memory.
+type MemoryLedgerStore struct {
+	// Mutex used to protect the store
+	mu sync.Mutex
+	// Entries by ID
+	entries map[string]*account.LedgerEntry
+	// Synced entry IDs from the oldest to the newest
+	ids []string
+	// Cursor. Nil until entries are saved.
+	cursor *LedgerCursor
+}
+
+// Build a new empty MemoryLedgerStore.
+func NewMemoryLedgerStore() *MemoryLedgerStore {
+	return &MemoryLedgerStore{entries: map[string]*account.LedgerEntry{}}
+}
+
+// Get the position of the newest saved entry. Nil if no entry has been saved.
+func (s *MemoryLedgerStore) GetLedgerCursor(ctx context.Context) (*LedgerCursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cursor == nil {
+		return nil, nil
+	}
+	cursor := *s.cursor
+	return &cursor, nil
+}
+
+// Save entries and the cursor. Entries which have already been saved are replaced.
+func (s *MemoryLedgerStore) SaveLedgerEntries(ctx context.Context, entries []SyncedLedgerEntry, cursor LedgerCursor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range entries {
+		if _, ok := s.entries[entry.Id]; !ok {
+			s.ids = append(s.ids, entry.Id)
+		}
+		s.entries[entry.Id] = entry.Entry
+	}
+	s.cursor = &cursor
+	return nil
+}
+
+// Get the saved entries from the oldest to the newest.
+func (s *MemoryLedgerStore) Entries() []SyncedLedgerEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]SyncedLedgerEntry, 0, len(s.ids))
+	for _, id := range s.ids {
+		entries = append(entries, SyncedLedgerEntry{Id: id, Entry: s.entries[id]})
+	}
+	return entries
+}
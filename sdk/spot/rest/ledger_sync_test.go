@@ -0,0 +1,157 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for LedgerSyncer
+type LedgerSyncTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestLedgerSyncTestSuite(t *testing.T) {
+	suite.Run(t, new(LedgerSyncTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test LedgerSyncer factory.
+func (suite *LedgerSyncTestSuite) TestNewLedgerSyncer() {
+	_, err := NewLedgerSyncer(nil, noncegen.NewHFNonceGenerator(), nil)
+	require.Error(suite.T(), err)
+	_, err = NewLedgerSyncer(NewMockKrakenSpotRESTClient(), nil, nil)
+	require.Error(suite.T(), err)
+	syncer, err := NewLedgerSyncer(NewMockKrakenSpotRESTClient(), noncegen.NewHFNonceGenerator(), nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), DefaultLedgerSyncRetryDelay, syncer.retryDelay)
+	require.Equal(suite.T(), DefaultLedgerSyncMaxRetries, syncer.maxRetries)
+	syncer.SetRateLimitRetry(0, -1)
+	require.Equal(suite.T(), DefaultLedgerSyncRetryDelay, syncer.retryDelay)
+	require.Equal(suite.T(), DefaultLedgerSyncMaxRetries, syncer.maxRetries)
+}
+
+// Test SyncLedger.
+//
+// Test will ensure:
+//   - The whole ledger is fetched page by page on the first sync and pages after the first one
+//     end at the newest entry of the first page.
+//   - Entries are saved from the oldest to the newest and the cursor points to the newest one.
+//   - The next sync only fetches entries newer than the cursor.
+//   - Nothing is saved when there is no new entry.
+func (suite *LedgerSyncTestSuite) TestSyncLedger() {
+	client := NewMockKrakenSpotRESTClient()
+	// First sync: 120 entries in 3 pages
+	client.On("GetLedgersInfo", mock.Anything, mock.Anything, &account.GetLedgersInfoRequestOptions{}, mock.Anything).
+		Return(ledgersInfoResponse(120, 71, 120), nil, nil).Once()
+	client.On("GetLedgersInfo", mock.Anything, mock.Anything, &account.GetLedgersInfoRequestOptions{End: "L120", Offset: 100}, mock.Anything).
+		Return(ledgersInfoResponse(120, 1, 20), nil, nil).Once()
+	client.On("GetLedgersInfo", mock.Anything, mock.Anything, &account.GetLedgersInfoRequestOptions{End: "L120", Offset: 50}, mock.Anything).
+		Return(ledgersInfoResponse(120, 21, 70), nil, nil).Once()
+	syncer, err := NewLedgerSyncer(client, noncegen.NewHFNonceGenerator(), nil)
+	require.NoError(suite.T(), err)
+	store := NewMemoryLedgerStore()
+	synced, err := syncer.SyncLedger(context.Background(), store)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 120, synced)
+	entries := store.Entries()
+	require.Len(suite.T(), entries, 120)
+	for i, entry := range entries {
+		require.Equal(suite.T(), ledgerId(i+1), entry.Id)
+	}
+	cursor, err := store.GetLedgerCursor(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), &LedgerCursor{Id: "L120", Timestamp: "1120"}, cursor)
+	// Second sync: 2 new entries
+	client.On("GetLedgersInfo", mock.Anything, mock.Anything, &account.GetLedgersInfoRequestOptions{Start: "L120"}, mock.Anything).
+		Return(ledgersInfoResponse(2, 121, 122), nil, nil).Once()
+	synced, err = syncer.SyncLedger(context.Background(), store)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 2, synced)
+	require.Len(suite.T(), store.Entries(), 122)
+	cursor, err = store.GetLedgerCursor(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "L122", cursor.Id)
+	// No new entry
+	client.On("GetLedgersInfo", mock.Anything, mock.Anything, &account.GetLedgersInfoRequestOptions{Start: "L122"}, mock.Anything).
+		Return(&account.GetLedgersInfoResponse{Result: &account.LedgersInfoResult{Ledgers: map[string]*account.LedgerEntry{}}}, nil, nil).Once()
+	synced, err = syncer.SyncLedger(context.Background(), store)
+	require.NoError(suite.T(), err)
+	require.Zero(suite.T(), synced)
+	client.AssertExpectations(suite.T())
+}
+
+// Test SyncLedger when the rate limit is exceeded.
+//
+// Test will ensure:
+//   - Rate limited requests are retried after the retry delay.
+//   - An error is returned once the maximum number of retries is reached.
+//   - Other API errors are not retried.
+func (suite *LedgerSyncTestSuite) TestSyncLedgerRateLimit() {
+	rateLimited := &account.GetLedgersInfoResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{RateLimitExceededError}}}
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetLedgersInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(rateLimited, nil, nil).Once()
+	client.On("GetLedgersInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(ledgersInfoResponse(1, 1, 1), nil, nil).Once()
+	fake := clock.NewFakeClock(time.Now())
+	syncer, err := NewLedgerSyncer(client, noncegen.NewHFNonceGenerator(), nil)
+	require.NoError(suite.T(), err)
+	syncer.SetClock(fake)
+	syncer.SetRateLimitRetry(time.Minute, 1)
+	go func() {
+		fake.BlockUntil(1)
+		fake.Advance(time.Minute)
+	}()
+	store := NewMemoryLedgerStore()
+	synced, err := syncer.SyncLedger(context.Background(), store)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, synced)
+	// Maximum number of retries reached
+	syncer.SetRateLimitRetry(time.Minute, 0)
+	client.On("GetLedgersInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(rateLimited, nil, nil).Once()
+	_, err = syncer.SyncLedger(context.Background(), store)
+	require.ErrorContains(suite.T(), err, RateLimitExceededError)
+	// Other API error
+	syncer.SetRateLimitRetry(time.Minute, 1)
+	client.On("GetLedgersInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&account.GetLedgersInfoResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{"EGeneral:Internal error"}}}, nil, nil).Once()
+	_, err = syncer.SyncLedger(context.Background(), store)
+	require.ErrorContains(suite.T(), err, "EGeneral:Internal error")
+	client.AssertNumberOfCalls(suite.T(), "GetLedgersInfo", 4)
+	require.Len(suite.T(), store.Entries(), 1)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build the ID of the nth ledger entry.
+func ledgerId(n int) string {
+	return fmt.Sprintf("L%03d", n)
+}
+
+// Build a GetLedgersInfo response with entries from..to. The nth entry has the 1000+n timestamp.
+func ledgersInfoResponse(count int, from int, to int) *account.GetLedgersInfoResponse {
+	ledgers := map[string]*account.LedgerEntry{}
+	for n := from; n <= to; n++ {
+		ledgers[ledgerId(n)] = &account.LedgerEntry{Timestamp: json.Number(fmt.Sprint(1000 + n))}
+	}
+	return &account.GetLedgersInfoResponse{Result: &account.LedgersInfoResult{Ledgers: ledgers, Count: count}}
+}
@@ -174,6 +174,27 @@ type OHLCData struct {
 	Data []OHLC
 }
 
+// # Description
+//
+// Get the OHLC data if they are the data of the provided pair. The API returns the data keyed by
+// the canonical pair name (ex: XXBTZUSD) even if another name has been used in the request (ex:
+// XBTUSD): the aliases are used to match both names.
+//
+// # Inputs
+//
+//   - pair: Any name of the pair (ex: the name used in the request).
+//   - aliases: Mapping between the names of the pairs. If nil, the pair name must be the name returned by the API.
+//
+// # Return
+//
+// The OHLC data and true if they are the data of the pair.
+func (ohlc *OHLCData) GetPairData(pair string, aliases *PairAliases) ([]OHLC, bool) {
+	if !aliases.Same(ohlc.PairId, pair) {
+		return nil, false
+	}
+	return ohlc.Data, true
+}
+
 // Marshal OHLC data to produce the same raw data as the API.
 func (ohlc *OHLCData) MarshalJSON() ([]byte, error) {
 	// Put data into a map
@@ -116,6 +116,27 @@ type RecentTrades struct {
 	Trades []Trade
 }
 
+// # Description
+//
+// Get the trades if they are the trades of the provided pair. The API returns the trades keyed
+// by the canonical pair name (ex: XXBTZUSD) even if another name has been used in the request
+// (ex: XBTUSD): the aliases are used to match both names.
+//
+// # Inputs
+//
+//   - pair: Any name of the pair (ex: the name used in the request).
+//   - aliases: Mapping between the names of the pairs. If nil, the pair name must be the name returned by the API.
+//
+// # Return
+//
+// The trades and true if they are the trades of the pair.
+func (trades *RecentTrades) GetPairTrades(pair string, aliases *PairAliases) ([]Trade, bool) {
+	if !aliases.Same(trades.PairId, pair) {
+		return nil, false
+	}
+	return trades.Trades, true
+}
+
 // Marshal trades data to produce the same raw data as the API.
 func (trades *RecentTrades) MarshalJSON() ([]byte, error) {
 	// Put data into a map
@@ -43,6 +43,12 @@ type GetTickerInformationResponse struct {
 /* HELPER METHODS                                                                                */
 /*************************************************************************************************/
 
+// Get the ticker of a pair whatever the name used to request the pair. Cf. LookupPair.
+func (resp *GetTickerInformationResponse) GetPairTicker(pair string, aliases *PairAliases) (*AssetTickerInfo, bool) {
+	ticker, _, found := LookupPair(resp.Result, pair, aliases)
+	return ticker, found
+}
+
 // Get the price of the best ask out of this AssetTickerInfo
 func (ati *AssetTickerInfo) GetAskPrice() string {
 	return ati.Ask[0]
@@ -0,0 +1,105 @@
+package market
+
+// Mapping between the names of the pairs: the REST API accepts several names for the same pair
+// (ex: XBTUSD) but keys its responses with the canonical pair name (ex: XXBTZUSD) and the
+// websocket API uses another name (ex: XBT/USD).
+//
+// A nil PairAliases is valid: pair names then only match when they are equal.
+type PairAliases struct {
+	// Canonical pair name by pair name (canonical name, alternative name and websocket name)
+	canonical map[string]string
+	// Names of each pair by canonical pair name
+	names map[string][]string
+}
+
+// # Description
+//
+// Build the mapping between the names of the provided pairs, as returned by
+// GetTradableAssetPairs or by metadata.Refresher.Pairs. Each pair is known by its canonical name,
+// its alternative name and its websocket name.
+//
+// # Inputs
+//
+//   - pairs: Pair metadata by canonical pair name.
+//
+// # Return
+//
+// The mapping between the names of the pairs.
+func NewPairAliases(pairs map[string]AssetPairInfo) *PairAliases {
+	aliases := &PairAliases{
+		canonical: make(map[string]string, 3*len(pairs)),
+		names:     make(map[string][]string, len(pairs)),
+	}
+	// Register canonical names first so they cannot be shadowed by the aliases of other pairs
+	for name := range pairs {
+		aliases.canonical[name] = name
+		aliases.names[name] = []string{name}
+	}
+	for name, info := range pairs {
+		for _, alias := range []string{info.AlternativeName, info.WebsocketName} {
+			if _, found := aliases.canonical[alias]; alias != "" && !found {
+				aliases.canonical[alias] = name
+				aliases.names[name] = append(aliases.names[name], alias)
+			}
+		}
+	}
+	return aliases
+}
+
+// Get the canonical name of a pair from any of its names. Returns false if the pair is unknown.
+func (aliases *PairAliases) Canonical(pair string) (string, bool) {
+	if aliases == nil {
+		return "", false
+	}
+	canonical, found := aliases.canonical[pair]
+	return canonical, found
+}
+
+// Get all the names of a pair from any of its names: canonical name first. Nil if the pair is unknown.
+func (aliases *PairAliases) Names(pair string) []string {
+	canonical, found := aliases.Canonical(pair)
+	if !found {
+		return nil
+	}
+	return append([]string{}, aliases.names[canonical]...)
+}
+
+// Return true if both names designate the same pair.
+func (aliases *PairAliases) Same(pair string, other string) bool {
+	if pair == other {
+		return true
+	}
+	canonical, found := aliases.Canonical(pair)
+	if !found {
+		return false
+	}
+	otherCanonical, found := aliases.Canonical(other)
+	return found && canonical == otherCanonical
+}
+
+// # Description
+//
+// Get the data of a pair from a response keyed by pair name (ex: the result of
+// GetTickerInformation) whatever the name used to request the pair.
+//
+// # Inputs
+//
+//   - data: Data keyed by pair name.
+//   - pair: Any name of the pair (ex: the name used in the request).
+//   - aliases: Mapping between the names of the pairs. If nil, only the data keyed by the provided name is returned.
+//
+// # Return
+//
+// The data of the pair, the key of the data and true if data have been found for the pair.
+func LookupPair[T any](data map[string]T, pair string, aliases *PairAliases) (T, string, bool) {
+	if value, found := data[pair]; found {
+		return value, pair, true
+	}
+	for _, name := range aliases.Names(pair) {
+		if value, found := data[name]; found {
+			return value, name, true
+		}
+	}
+	var zero T
+	return zero, "", false
+}
@@ -0,0 +1,99 @@
+package market
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for PairAliases and the pair accessors of the responses.
+type PairAliasesTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestPairAliasesTestSuite(t *testing.T) {
+	suite.Run(t, new(PairAliasesTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the mapping between the names of the pairs.
+//
+// The test will ensure:
+//   - Any name of a pair is mapped to its canonical name.
+//   - All the names of a pair can be listed from any of its names, canonical name first.
+//   - Unknown pairs only match themselves, including with nil aliases.
+func (suite *PairAliasesTestSuite) TestPairAliases() {
+	aliases := newTestPairAliases()
+	canonical, found := aliases.Canonical("XBT/USD")
+	require.True(suite.T(), found)
+	require.Equal(suite.T(), "XXBTZUSD", canonical)
+	require.Equal(suite.T(), []string{"XXBTZUSD", "XBTUSD", "XBT/USD"}, aliases.Names("XBTUSD"))
+	require.True(suite.T(), aliases.Same("XBTUSD", "XBT/USD"))
+	require.False(suite.T(), aliases.Same("XBTUSD", "ETHUSD"))
+	require.False(suite.T(), aliases.Same("XBTUSD", "UNKNOWN"))
+	require.True(suite.T(), aliases.Same("UNKNOWN", "UNKNOWN"))
+	require.Nil(suite.T(), aliases.Names("UNKNOWN"))
+	// Nil aliases
+	var none *PairAliases
+	_, found = none.Canonical("XXBTZUSD")
+	require.False(suite.T(), found)
+	require.True(suite.T(), none.Same("XXBTZUSD", "XXBTZUSD"))
+	require.False(suite.T(), none.Same("XBTUSD", "XXBTZUSD"))
+}
+
+// Test the pair accessors of the responses.
+//
+// The test will ensure:
+//   - OHLC data, recent trades and tickers are returned for any name of the returned pair.
+//   - No data are returned for another pair.
+//   - Only the returned pair name matches when no aliases are provided.
+func (suite *PairAliasesTestSuite) TestPairAccessors() {
+	aliases := newTestPairAliases()
+	ohlc := &OHLCData{PairId: "XXBTZUSD", Data: []OHLC{{Timestamp: 1}}}
+	data, found := ohlc.GetPairData("XBTUSD", aliases)
+	require.True(suite.T(), found)
+	require.Equal(suite.T(), ohlc.Data, data)
+	_, found = ohlc.GetPairData("ETHUSD", aliases)
+	require.False(suite.T(), found)
+	_, found = ohlc.GetPairData("XBTUSD", nil)
+	require.False(suite.T(), found)
+	_, found = ohlc.GetPairData("XXBTZUSD", nil)
+	require.True(suite.T(), found)
+	trades := &RecentTrades{PairId: "XETHZUSD", Trades: []Trade{{Id: 1}}}
+	tdata, found := trades.GetPairTrades("ETH/USD", aliases)
+	require.True(suite.T(), found)
+	require.Equal(suite.T(), trades.Trades, tdata)
+	_, found = trades.GetPairTrades("XBT/USD", aliases)
+	require.False(suite.T(), found)
+	ticker := &AssetTickerInfo{OpeningPrice: "30000"}
+	resp := &GetTickerInformationResponse{Result: map[string]*AssetTickerInfo{"XXBTZUSD": ticker}}
+	tinfo, found := resp.GetPairTicker("XBTUSD", aliases)
+	require.True(suite.T(), found)
+	require.Same(suite.T(), ticker, tinfo)
+	_, found = resp.GetPairTicker("XBTUSD", nil)
+	require.False(suite.T(), found)
+	_, key, found := LookupPair(resp.Result, "XBT/USD", aliases)
+	require.True(suite.T(), found)
+	require.Equal(suite.T(), "XXBTZUSD", key)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build the aliases of XXBTZUSD and XETHZUSD.
+func newTestPairAliases() *PairAliases {
+	return NewPairAliases(map[string]AssetPairInfo{
+		"XXBTZUSD": {AlternativeName: "XBTUSD", WebsocketName: "XBT/USD"},
+		"XETHZUSD": {AlternativeName: "ETHUSD", WebsocketName: "ETH/USD"},
+	})
+}
@@ -0,0 +1,183 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+)
+
+/*****************************************************************************/
+/* MARKET SNAPSHOT: MODEL                                                    */
+/*****************************************************************************/
+
+// Default maximum number of concurrent requests used by GetMarketSnapshot.
+const DefaultMarketSnapshotParallelism = 4
+
+// Rate limiter waited before each request sent by helpers like GetMarketSnapshot or
+// QueryOrdersInfoBatch. Compatible with golang.org/x/time/rate.Limiter.
+type RateLimiter interface {
+	// Block until a request can be sent or until the context is done. An error is returned if the
+	// request cannot be sent.
+	Wait(ctx context.Context) error
+}
+
+// GetMarketSnapshot options.
+type MarketSnapshotOptions struct {
+	// Maximum number of bid/ask entries of the order books : [1,500].
+	//
+	// Defaults to 100. A zero value (= 0) triggers default behavior.
+	BookDepth int
+	// Number of recent trades to fetch, up to 1000.
+	//
+	// 1000 by default. A zero value triggers default behavior.
+	TradesCount int
+	// Maximum number of concurrent requests.
+	//
+	// Defaults to DefaultMarketSnapshotParallelism. A zero or negative value triggers default behavior.
+	MaxParallelism int
+	// Optional rate limiter waited before each request. If nil, requests are not rate limited.
+	RateLimiter RateLimiter
+}
+
+// Market data of a single pair fetched by GetMarketSnapshot.
+type PairSnapshot struct {
+	// Asset pair as provided to GetMarketSnapshot
+	Pair string
+	// Ticker data. Nil if ticker could not be fetched.
+	Ticker *market.AssetTickerInfo
+	// Order book. Nil if order book could not be fetched.
+	OrderBook *market.OrderBook
+	// Recent trades. Nil if recent trades could not be fetched.
+	Trades *market.RecentTrades
+}
+
+// Consolidated market data of several pairs fetched by GetMarketSnapshot.
+type MarketSnapshot struct {
+	// Market data by pair as provided to GetMarketSnapshot
+	Pairs map[string]*PairSnapshot
+	// Time when the snapshot has been started
+	StartedAt time.Time
+	// Time when all requests have completed
+	CompletedAt time.Time
+}
+
+/*****************************************************************************/
+/* MARKET SNAPSHOT: FUNCTIONS                                                */
+/*****************************************************************************/
+
+// # Description
+//
+// Concurrently fetch the ticker, the order book and the recent trades of each provided pair and
+// consolidate them in a single snapshot. The number of concurrent requests is bounded by
+// MaxParallelism and the rate limiter provided in the options, if any, is waited before each
+// request.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. Pending requests are interrupted when the context is done.
+//   - client: REST client used to fetch market data. Must not be nil.
+//   - pairs: Asset pairs to get data for. Duplicates are ignored.
+//   - opts: GetMarketSnapshot options. A nil value triggers all default behaviors.
+//
+// # Return
+//
+// The market snapshot and an error if some market data could not be fetched. The snapshot is
+// always returned and contains all market data which have been fetched: fields of the pair
+// snapshots are nil for failed requests. Errors of all failed requests are joined.
+func GetMarketSnapshot(
+	ctx context.Context,
+	client KrakenSpotRESTClientIface,
+	pairs []string,
+	opts *MarketSnapshotOptions) (*MarketSnapshot, error) {
+	if opts == nil {
+		opts = &MarketSnapshotOptions{}
+	}
+	parallelism := opts.MaxParallelism
+	if parallelism <= 0 {
+		parallelism = DefaultMarketSnapshotParallelism
+	}
+	snapshot := &MarketSnapshot{Pairs: map[string]*PairSnapshot{}, StartedAt: time.Now()}
+	// Mutex used to protect the snapshot and errs
+	mu := sync.Mutex{}
+	errs := []error{}
+	// Semaphore used to bound the number of concurrent requests
+	sem := make(chan struct{}, parallelism)
+	wg := sync.WaitGroup{}
+	// Run a request in a separate goroutine once a slot and the rate limiter allow it. Provided
+	// function must return a function which sets the fetched data in the pair snapshot.
+	run := func(pair string, request string, fetch func() (func(ps *PairSnapshot), error)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			set, err := func() (func(ps *PairSnapshot), error) {
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+				if opts.RateLimiter != nil {
+					if err := opts.RateLimiter.Wait(ctx); err != nil {
+						return nil, err
+					}
+				}
+				return fetch()
+			}()
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s for %s failed: %w", request, pair, err))
+				return
+			}
+			set(snapshot.Pairs[pair])
+		}()
+	}
+	for _, pair := range pairs {
+		if _, found := snapshot.Pairs[pair]; found {
+			continue
+		}
+		pair := pair
+		snapshot.Pairs[pair] = &PairSnapshot{Pair: pair}
+		run(pair, "get ticker information", func() (func(ps *PairSnapshot), error) {
+			resp, _, err := client.GetTickerInformation(ctx, &market.GetTickerInformationRequestOptions{Pairs: []string{pair}})
+			if err != nil {
+				return nil, err
+			}
+			if len(resp.Error) > 0 || len(resp.Result) == 0 {
+				return nil, fmt.Errorf("%v", resp.Error)
+			}
+			// Result is keyed by the pair name used by the API which can differ from the provided one
+			for _, ticker := range resp.Result {
+				return func(ps *PairSnapshot) { ps.Ticker = ticker }, nil
+			}
+			return nil, nil
+		})
+		run(pair, "get order book", func() (func(ps *PairSnapshot), error) {
+			resp, _, err := client.GetOrderBook(ctx, market.GetOrderBookRequestParameters{Pair: pair}, &market.GetOrderBookRequestOptions{Count: opts.BookDepth})
+			if err != nil {
+				return nil, err
+			}
+			if len(resp.Error) > 0 || resp.Result == nil {
+				return nil, fmt.Errorf("%v", resp.Error)
+			}
+			return func(ps *PairSnapshot) { ps.OrderBook = resp.Result }, nil
+		})
+		run(pair, "get recent trades", func() (func(ps *PairSnapshot), error) {
+			resp, _, err := client.GetRecentTrades(ctx, market.GetRecentTradesRequestParameters{Pair: pair}, &market.GetRecentTradesRequestOptions{Count: opts.TradesCount})
+			if err != nil {
+				return nil, err
+			}
+			if len(resp.Error) > 0 || resp.Result == nil {
+				return nil, fmt.Errorf("%v", resp.Error)
+			}
+			return func(ps *PairSnapshot) { ps.Trades = resp.Result }, nil
+		})
+	}
+	wg.Wait()
+	snapshot.CompletedAt = time.Now()
+	return snapshot, errors.Join(errs...)
+}
@@ -0,0 +1,140 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for GetMarketSnapshot
+type MarketSnapshotTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestMarketSnapshotTestSuite(t *testing.T) {
+	suite.Run(t, new(MarketSnapshotTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test GetMarketSnapshot.
+//
+// Test will ensure:
+//   - Ticker, order book and recent trades are fetched for each pair and consolidated by pair.
+//   - Book depth and trades count are used in requests.
+//   - The number of concurrent requests does not exceed MaxParallelism.
+//   - The rate limiter is waited before each request.
+func (suite *MarketSnapshotTestSuite) TestGetMarketSnapshot() {
+	client := NewMockKrakenSpotRESTClient()
+	// Track concurrent requests
+	active := atomic.Int32{}
+	peak := atomic.Int32{}
+	track := func(args mock.Arguments) {
+		current := active.Add(1)
+		for {
+			max := peak.Load()
+			if current <= max || peak.CompareAndSwap(max, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		active.Add(-1)
+	}
+	for _, pair := range []string{"XBTUSD", "ETHUSD"} {
+		pair := pair
+		client.On("GetTickerInformation", mock.Anything, &market.GetTickerInformationRequestOptions{Pairs: []string{pair}}).Run(track).
+			Return(&market.GetTickerInformationResponse{Result: map[string]*market.AssetTickerInfo{"X" + pair: {OpeningPrice: pair}}}, nil, nil)
+		client.On("GetOrderBook", mock.Anything, market.GetOrderBookRequestParameters{Pair: pair}, &market.GetOrderBookRequestOptions{Count: 10}).Run(track).
+			Return(&market.GetOrderBookResponse{Result: &market.OrderBook{PairId: "X" + pair}}, nil, nil)
+		client.On("GetRecentTrades", mock.Anything, market.GetRecentTradesRequestParameters{Pair: pair}, &market.GetRecentTradesRequestOptions{Count: 50}).Run(track).
+			Return(&market.GetRecentTradesResponse{Result: &market.RecentTrades{PairId: "X" + pair}}, nil, nil)
+	}
+	limiter := &countingRateLimiter{}
+	snapshot, err := GetMarketSnapshot(context.Background(), client, []string{"XBTUSD", "ETHUSD", "XBTUSD"}, &MarketSnapshotOptions{
+		BookDepth:      10,
+		TradesCount:    50,
+		MaxParallelism: 2,
+		RateLimiter:    limiter,
+	})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), snapshot.Pairs, 2)
+	for _, pair := range []string{"XBTUSD", "ETHUSD"} {
+		ps := snapshot.Pairs[pair]
+		require.Equal(suite.T(), pair, ps.Pair)
+		require.Equal(suite.T(), pair, ps.Ticker.OpeningPrice)
+		require.Equal(suite.T(), "X"+pair, ps.OrderBook.PairId)
+		require.Equal(suite.T(), "X"+pair, ps.Trades.PairId)
+	}
+	require.LessOrEqual(suite.T(), peak.Load(), int32(2))
+	require.Equal(suite.T(), 6, limiter.calls)
+	require.False(suite.T(), snapshot.CompletedAt.Before(snapshot.StartedAt))
+	client.AssertExpectations(suite.T())
+}
+
+// Test GetMarketSnapshot with failures.
+//
+// Test will ensure:
+//   - Market data which have been fetched are returned alongside the errors of failed requests.
+//   - API errors and client errors are reported.
+//   - A rate limiter error aborts the request.
+func (suite *MarketSnapshotTestSuite) TestGetMarketSnapshotFailures() {
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetTickerInformation", mock.Anything, mock.Anything).
+		Return(&market.GetTickerInformationResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{"EQuery:Unknown asset pair"}}}, nil, nil)
+	client.On("GetOrderBook", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, nil, fmt.Errorf("connection refused"))
+	client.On("GetRecentTrades", mock.Anything, mock.Anything, mock.Anything).
+		Return(&market.GetRecentTradesResponse{Result: &market.RecentTrades{PairId: "XXBTZUSD"}}, nil, nil)
+	snapshot, err := GetMarketSnapshot(context.Background(), client, []string{"XBTUSD"}, nil)
+	require.Error(suite.T(), err)
+	require.Contains(suite.T(), err.Error(), "Unknown asset pair")
+	require.Contains(suite.T(), err.Error(), "connection refused")
+	ps := snapshot.Pairs["XBTUSD"]
+	require.Nil(suite.T(), ps.Ticker)
+	require.Nil(suite.T(), ps.OrderBook)
+	require.Equal(suite.T(), "XXBTZUSD", ps.Trades.PairId)
+	// Rate limiter error
+	limiter := &countingRateLimiter{err: context.DeadlineExceeded}
+	snapshot, err = GetMarketSnapshot(context.Background(), client, []string{"ETHUSD"}, &MarketSnapshotOptions{RateLimiter: limiter})
+	require.ErrorIs(suite.T(), err, context.DeadlineExceeded)
+	require.Nil(suite.T(), snapshot.Pairs["ETHUSD"].Trades)
+	client.AssertNumberOfCalls(suite.T(), "GetRecentTrades", 1)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Rate limiter which counts calls and returns the configured error.
+type countingRateLimiter struct {
+	// Mutex used to protect calls
+	mu sync.Mutex
+	// Number of calls to Wait
+	calls int
+	// Error returned by Wait
+	err error
+}
+
+// Count the call and return the configured error.
+func (l *countingRateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls++
+	return l.err
+}
@@ -0,0 +1,461 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/earn"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/funding"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/staking"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/websocket"
+	"github.com/stretchr/testify/mock"
+)
+
+/*************************************************************************************************/
+/* MOCK                                                                                          */
+/*************************************************************************************************/
+
+// A mock for KrakenSpotRESTClientIface.
+//
+// Each mocked method expects three return values to be set with Return: the typed response (or
+// nil), the *http.Response (or nil) and the error (or nil).
+type MockKrakenSpotRESTClient struct {
+	mock.Mock
+}
+
+// Factory which creates a new MockKrakenSpotRESTClient without any expectations set.
+func NewMockKrakenSpotRESTClient() *MockKrakenSpotRESTClient {
+	return &MockKrakenSpotRESTClient{mock.Mock{}}
+}
+
+// Extract the typed response, the http.Response and the error from the arguments returned by a
+// mocked call. Nil values can be used for the response and the http.Response.
+func mockedResponse[R any](args mock.Arguments) (*R, *http.Response, error) {
+	var resp *R
+	if args.Get(0) != nil {
+		resp = args.Get(0).(*R)
+	}
+	var httpresp *http.Response
+	if args.Get(1) != nil {
+		httpresp = args.Get(1).(*http.Response)
+	}
+	return resp, httpresp, args.Error(2)
+}
+
+// Mocked GetServerTime method
+func (m *MockKrakenSpotRESTClient) GetServerTime(ctx context.Context) (*market.GetServerTimeResponse, *http.Response, error) {
+	args := m.Called(ctx)
+	return mockedResponse[market.GetServerTimeResponse](args)
+}
+
+// Mocked GetSystemStatus method
+func (m *MockKrakenSpotRESTClient) GetSystemStatus(ctx context.Context) (*market.GetSystemStatusResponse, *http.Response, error) {
+	args := m.Called(ctx)
+	return mockedResponse[market.GetSystemStatusResponse](args)
+}
+
+// Mocked GetAssetInfo method
+func (m *MockKrakenSpotRESTClient) GetAssetInfo(ctx context.Context, opts *market.GetAssetInfoRequestOptions) (*market.GetAssetInfoResponse, *http.Response, error) {
+	args := m.Called(ctx, opts)
+	return mockedResponse[market.GetAssetInfoResponse](args)
+}
+
+// Mocked GetTradableAssetPairs method
+func (m *MockKrakenSpotRESTClient) GetTradableAssetPairs(ctx context.Context, opts *market.GetTradableAssetPairsRequestOptions) (*market.GetTradableAssetPairsResponse, *http.Response, error) {
+	args := m.Called(ctx, opts)
+	return mockedResponse[market.GetTradableAssetPairsResponse](args)
+}
+
+// Mocked GetTickerInformation method
+func (m *MockKrakenSpotRESTClient) GetTickerInformation(ctx context.Context, opts *market.GetTickerInformationRequestOptions) (*market.GetTickerInformationResponse, *http.Response, error) {
+	args := m.Called(ctx, opts)
+	return mockedResponse[market.GetTickerInformationResponse](args)
+}
+
+// Mocked GetOHLCData method
+func (m *MockKrakenSpotRESTClient) GetOHLCData(ctx context.Context, params market.GetOHLCDataRequestParameters, opts *market.GetOHLCDataRequestOptions) (*market.GetOHLCDataResponse, *http.Response, error) {
+	args := m.Called(ctx, params, opts)
+	return mockedResponse[market.GetOHLCDataResponse](args)
+}
+
+// Mocked GetOrderBook method
+func (m *MockKrakenSpotRESTClient) GetOrderBook(ctx context.Context, params market.GetOrderBookRequestParameters, opts *market.GetOrderBookRequestOptions) (*market.GetOrderBookResponse, *http.Response, error) {
+	args := m.Called(ctx, params, opts)
+	return mockedResponse[market.GetOrderBookResponse](args)
+}
+
+// Mocked GetRecentTrades method
+func (m *MockKrakenSpotRESTClient) GetRecentTrades(ctx context.Context, params market.GetRecentTradesRequestParameters, opts *market.GetRecentTradesRequestOptions) (*market.GetRecentTradesResponse, *http.Response, error) {
+	args := m.Called(ctx, params, opts)
+	return mockedResponse[market.GetRecentTradesResponse](args)
+}
+
+// Mocked GetRecentSpreads method
+func (m *MockKrakenSpotRESTClient) GetRecentSpreads(ctx context.Context, params market.GetRecentSpreadsRequestParameters, opts *market.GetRecentSpreadsRequestOptions) (*market.GetRecentSpreadsResponse, *http.Response, error) {
+	args := m.Called(ctx, params, opts)
+	return mockedResponse[market.GetRecentSpreadsResponse](args)
+}
+
+// Mocked GetAccountBalance method
+func (m *MockKrakenSpotRESTClient) GetAccountBalance(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*account.GetAccountBalanceResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, secopts)
+	return mockedResponse[account.GetAccountBalanceResponse](args)
+}
+
+// Mocked GetExtendedBalance method
+func (m *MockKrakenSpotRESTClient) GetExtendedBalance(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*account.GetExtendedBalanceResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, secopts)
+	return mockedResponse[account.GetExtendedBalanceResponse](args)
+}
+
+// Mocked GetTradeBalance method
+func (m *MockKrakenSpotRESTClient) GetTradeBalance(ctx context.Context, nonce int64, opts *account.GetTradeBalanceRequestOptions, secopts *common.SecurityOptions) (*account.GetTradeBalanceResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, opts, secopts)
+	return mockedResponse[account.GetTradeBalanceResponse](args)
+}
+
+// Mocked GetOpenOrders method
+func (m *MockKrakenSpotRESTClient) GetOpenOrders(ctx context.Context, nonce int64, opts *account.GetOpenOrdersRequestOptions, secopts *common.SecurityOptions) (*account.GetOpenOrdersResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, opts, secopts)
+	return mockedResponse[account.GetOpenOrdersResponse](args)
+}
+
+// Mocked GetClosedOrders method
+func (m *MockKrakenSpotRESTClient) GetClosedOrders(ctx context.Context, nonce int64, opts *account.GetClosedOrdersRequestOptions, secopts *common.SecurityOptions) (*account.GetClosedOrdersResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, opts, secopts)
+	return mockedResponse[account.GetClosedOrdersResponse](args)
+}
+
+// Mocked QueryOrdersInfo method
+func (m *MockKrakenSpotRESTClient) QueryOrdersInfo(ctx context.Context, nonce int64, params account.QueryOrdersInfoParameters, opts *account.QueryOrdersInfoRequestOptions, secopts *common.SecurityOptions) (*account.QueryOrdersInfoResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, opts, secopts)
+	return mockedResponse[account.QueryOrdersInfoResponse](args)
+}
+
+// Mocked GetOrderAmends method
+func (m *MockKrakenSpotRESTClient) GetOrderAmends(ctx context.Context, nonce int64, params account.GetOrderAmendsRequestParameters, opts *account.GetOrderAmendsRequestOptions, secopts *common.SecurityOptions) (*account.GetOrderAmendsResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, opts, secopts)
+	return mockedResponse[account.GetOrderAmendsResponse](args)
+}
+
+// Mocked GetTradesHistory method
+func (m *MockKrakenSpotRESTClient) GetTradesHistory(ctx context.Context, nonce int64, opts *account.GetTradesHistoryRequestOptions, secopts *common.SecurityOptions) (*account.GetTradesHistoryResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, opts, secopts)
+	return mockedResponse[account.GetTradesHistoryResponse](args)
+}
+
+// Mocked QueryTradesInfo method
+func (m *MockKrakenSpotRESTClient) QueryTradesInfo(ctx context.Context, nonce int64, params account.QueryTradesRequestParameters, opts *account.QueryTradesRequestOptions, secopts *common.SecurityOptions) (*account.QueryTradesInfoResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, opts, secopts)
+	return mockedResponse[account.QueryTradesInfoResponse](args)
+}
+
+// Mocked GetOpenPositions method
+func (m *MockKrakenSpotRESTClient) GetOpenPositions(ctx context.Context, nonce int64, opts *account.GetOpenPositionsRequestOptions, secopts *common.SecurityOptions) (*account.GetOpenPositionsResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, opts, secopts)
+	return mockedResponse[account.GetOpenPositionsResponse](args)
+}
+
+// Mocked GetLedgersInfo method
+func (m *MockKrakenSpotRESTClient) GetLedgersInfo(ctx context.Context, nonce int64, opts *account.GetLedgersInfoRequestOptions, secopts *common.SecurityOptions) (*account.GetLedgersInfoResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, opts, secopts)
+	return mockedResponse[account.GetLedgersInfoResponse](args)
+}
+
+// Mocked QueryLedgers method
+func (m *MockKrakenSpotRESTClient) QueryLedgers(ctx context.Context, nonce int64, params account.QueryLedgersRequestParameters, opts *account.QueryLedgersRequestOptions, secopts *common.SecurityOptions) (*account.QueryLedgersResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, opts, secopts)
+	return mockedResponse[account.QueryLedgersResponse](args)
+}
+
+// Mocked GetTradeVolume method
+func (m *MockKrakenSpotRESTClient) GetTradeVolume(ctx context.Context, nonce int64, opts *account.GetTradeVolumeRequestOptions, secopts *common.SecurityOptions) (*account.GetTradeVolumeResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, opts, secopts)
+	return mockedResponse[account.GetTradeVolumeResponse](args)
+}
+
+// Mocked RequestExportReport method
+func (m *MockKrakenSpotRESTClient) RequestExportReport(ctx context.Context, nonce int64, params account.RequestExportReportRequestParameters, opts *account.RequestExportReportRequestOptions, secopts *common.SecurityOptions) (*account.RequestExportReportResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, opts, secopts)
+	return mockedResponse[account.RequestExportReportResponse](args)
+}
+
+// Mocked GetExportReportStatus method
+func (m *MockKrakenSpotRESTClient) GetExportReportStatus(ctx context.Context, nonce int64, params account.GetExportReportStatusRequestParameters, secopts *common.SecurityOptions) (*account.GetExportReportStatusResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[account.GetExportReportStatusResponse](args)
+}
+
+// Mocked RetrieveDataExport method
+func (m *MockKrakenSpotRESTClient) RetrieveDataExport(ctx context.Context, nonce int64, params account.RetrieveDataExportParameters, secopts *common.SecurityOptions) (*account.RetrieveDataExportResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[account.RetrieveDataExportResponse](args)
+}
+
+// Mocked DeleteExportReport method
+func (m *MockKrakenSpotRESTClient) DeleteExportReport(ctx context.Context, nonce int64, params account.DeleteExportReportRequestParameters, secopts *common.SecurityOptions) (*account.DeleteExportReportResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[account.DeleteExportReportResponse](args)
+}
+
+// Mocked CreateSubaccount method
+func (m *MockKrakenSpotRESTClient) CreateSubaccount(ctx context.Context, nonce int64, params account.CreateSubaccountRequestParameters, secopts *common.SecurityOptions) (*account.CreateSubaccountResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[account.CreateSubaccountResponse](args)
+}
+
+// Mocked AddOrder method
+func (m *MockKrakenSpotRESTClient) AddOrder(ctx context.Context, nonce int64, params trading.AddOrderRequestParameters, opts *trading.AddOrderRequestOptions, secopts *common.SecurityOptions) (*trading.AddOrderResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, opts, secopts)
+	return mockedResponse[trading.AddOrderResponse](args)
+}
+
+// Mocked AddOrderBatch method
+func (m *MockKrakenSpotRESTClient) AddOrderBatch(ctx context.Context, nonce int64, params trading.AddOrderBatchRequestParameters, opts *trading.AddOrderBatchRequestOptions, secopts *common.SecurityOptions) (*trading.AddOrderBatchResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, opts, secopts)
+	return mockedResponse[trading.AddOrderBatchResponse](args)
+}
+
+// Mocked EditOrder method
+func (m *MockKrakenSpotRESTClient) EditOrder(ctx context.Context, nonce int64, params trading.EditOrderRequestParameters, opts *trading.EditOrderRequestOptions, secopts *common.SecurityOptions) (*trading.EditOrderResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, opts, secopts)
+	return mockedResponse[trading.EditOrderResponse](args)
+}
+
+// Mocked AmendOrder method
+func (m *MockKrakenSpotRESTClient) AmendOrder(ctx context.Context, nonce int64, params trading.AmendOrderRequestParameters, opts *trading.AmendOrderRequestOptions, secopts *common.SecurityOptions) (*trading.AmendOrderResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, opts, secopts)
+	return mockedResponse[trading.AmendOrderResponse](args)
+}
+
+// Mocked CancelOrder method
+func (m *MockKrakenSpotRESTClient) CancelOrder(ctx context.Context, nonce int64, params trading.CancelOrderRequestParameters, secopts *common.SecurityOptions) (*trading.CancelOrderResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[trading.CancelOrderResponse](args)
+}
+
+// Mocked CancelAllOrders method
+func (m *MockKrakenSpotRESTClient) CancelAllOrders(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*trading.CancelAllOrdersResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, secopts)
+	return mockedResponse[trading.CancelAllOrdersResponse](args)
+}
+
+// Mocked CancelAllOrdersAfterX method
+func (m *MockKrakenSpotRESTClient) CancelAllOrdersAfterX(ctx context.Context, nonce int64, params trading.CancelAllOrdersAfterXRequestParameters, secopts *common.SecurityOptions) (*trading.CancelAllOrdersAfterXResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[trading.CancelAllOrdersAfterXResponse](args)
+}
+
+// Mocked CancelOrderBatch method
+func (m *MockKrakenSpotRESTClient) CancelOrderBatch(ctx context.Context, nonce int64, params trading.CancelOrderBatchRequestParameters, secopts *common.SecurityOptions) (*trading.CancelOrderBatchResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[trading.CancelOrderBatchResponse](args)
+}
+
+// Mocked GetDepositMethods method
+func (m *MockKrakenSpotRESTClient) GetDepositMethods(ctx context.Context, nonce int64, params funding.GetDepositMethodsRequestParameters, secopts *common.SecurityOptions) (*funding.GetDepositMethodsResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[funding.GetDepositMethodsResponse](args)
+}
+
+// Mocked GetDepositAddresses method
+func (m *MockKrakenSpotRESTClient) GetDepositAddresses(ctx context.Context, nonce int64, params funding.GetDepositAddressesRequestParameters, opts *funding.GetDepositAddressesRequestOptions, secopts *common.SecurityOptions) (*funding.GetDepositAddressesResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, opts, secopts)
+	return mockedResponse[funding.GetDepositAddressesResponse](args)
+}
+
+// Mocked GetStatusOfRecentDeposits method
+func (m *MockKrakenSpotRESTClient) GetStatusOfRecentDeposits(ctx context.Context, nonce int64, opts *funding.GetStatusOfRecentDepositsRequestOptions, secopts *common.SecurityOptions) (*funding.GetStatusOfRecentDepositsResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, opts, secopts)
+	return mockedResponse[funding.GetStatusOfRecentDepositsResponse](args)
+}
+
+// Mocked GetWithdrawalMethods method
+func (m *MockKrakenSpotRESTClient) GetWithdrawalMethods(ctx context.Context, nonce int64, opts *funding.GetWithdrawalMethodsRequestOptions, secopts *common.SecurityOptions) (*funding.GetWithdrawalMethodsResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, opts, secopts)
+	return mockedResponse[funding.GetWithdrawalMethodsResponse](args)
+}
+
+// Mocked GetWithdrawalAddresses method
+func (m *MockKrakenSpotRESTClient) GetWithdrawalAddresses(ctx context.Context, nonce int64, opts *funding.GetWithdrawalAddressesRequestOptions, secopts *common.SecurityOptions) (*funding.GetWithdrawalAddressesResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, opts, secopts)
+	return mockedResponse[funding.GetWithdrawalAddressesResponse](args)
+}
+
+// Mocked GetWithdrawalInformation method
+func (m *MockKrakenSpotRESTClient) GetWithdrawalInformation(ctx context.Context, nonce int64, params funding.GetWithdrawalInformationRequestParameters, secopts *common.SecurityOptions) (*funding.GetWithdrawalInformationResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[funding.GetWithdrawalInformationResponse](args)
+}
+
+// Mocked WithdrawFunds method
+func (m *MockKrakenSpotRESTClient) WithdrawFunds(ctx context.Context, nonce int64, params funding.WithdrawFundsRequestParameters, opts *funding.WithdrawFundsRequestOptions, secopts *common.SecurityOptions) (*funding.WithdrawFundsResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, opts, secopts)
+	return mockedResponse[funding.WithdrawFundsResponse](args)
+}
+
+// Mocked GetStatusOfRecentWithdrawals method
+func (m *MockKrakenSpotRESTClient) GetStatusOfRecentWithdrawals(ctx context.Context, nonce int64, opts *funding.GetStatusOfRecentWithdrawalsRequestOptions, secopts *common.SecurityOptions) (*funding.GetStatusOfRecentWithdrawalsResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, opts, secopts)
+	return mockedResponse[funding.GetStatusOfRecentWithdrawalsResponse](args)
+}
+
+// Mocked RequestWithdrawalCancellation method
+func (m *MockKrakenSpotRESTClient) RequestWithdrawalCancellation(ctx context.Context, nonce int64, params funding.RequestWithdrawalCancellationRequestParameters, secopts *common.SecurityOptions) (*funding.RequestWithdrawalCancellationResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[funding.RequestWithdrawalCancellationResponse](args)
+}
+
+// Mocked RequestWalletTransfer method
+func (m *MockKrakenSpotRESTClient) RequestWalletTransfer(ctx context.Context, nonce int64, params funding.RequestWalletTransferRequestParameters, secopts *common.SecurityOptions) (*funding.RequestWalletTransferResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[funding.RequestWalletTransferResponse](args)
+}
+
+// Mocked AccountTransfer method
+func (m *MockKrakenSpotRESTClient) AccountTransfer(ctx context.Context, nonce int64, params funding.AccountTransferRequestParameters, secopts *common.SecurityOptions) (*funding.AccountTransferResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[funding.AccountTransferResponse](args)
+}
+
+// Mocked AllocateEarnFunds method
+func (m *MockKrakenSpotRESTClient) AllocateEarnFunds(ctx context.Context, nonce int64, params earn.AllocateEarnFundsRequestParameters, secopts *common.SecurityOptions) (*earn.AllocateEarnFundsResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[earn.AllocateEarnFundsResponse](args)
+}
+
+// Mocked DeallocateEarnFunds method
+func (m *MockKrakenSpotRESTClient) DeallocateEarnFunds(ctx context.Context, nonce int64, params earn.DeallocateEarnFundsRequestParameters, secopts *common.SecurityOptions) (*earn.DeallocateEarnFundsResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[earn.DeallocateEarnFundsResponse](args)
+}
+
+// Mocked GetAllocationStatus method
+func (m *MockKrakenSpotRESTClient) GetAllocationStatus(ctx context.Context, nonce int64, params earn.GetAllocationStatusRequestParameters, secopts *common.SecurityOptions) (*earn.GetAllocationStatusResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[earn.GetAllocationStatusResponse](args)
+}
+
+// Mocked GetDeallocationStatus method
+func (m *MockKrakenSpotRESTClient) GetDeallocationStatus(ctx context.Context, nonce int64, params earn.GetDeallocationStatusRequestParameters, secopts *common.SecurityOptions) (*earn.GetDeallocationStatusResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[earn.GetDeallocationStatusResponse](args)
+}
+
+// Mocked ListEarnStrategies method
+func (m *MockKrakenSpotRESTClient) ListEarnStrategies(ctx context.Context, nonce int64, opts *earn.ListEarnStrategiesRequestOptions, secopts *common.SecurityOptions) (*earn.ListEarnStrategiesResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, opts, secopts)
+	return mockedResponse[earn.ListEarnStrategiesResponse](args)
+}
+
+// Mocked ListEarnAllocations method
+func (m *MockKrakenSpotRESTClient) ListEarnAllocations(ctx context.Context, nonce int64, opts *earn.ListEarnAllocationsRequestOptions, secopts *common.SecurityOptions) (*earn.ListEarnAllocationsResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, opts, secopts)
+	return mockedResponse[earn.ListEarnAllocationsResponse](args)
+}
+
+// Mocked Stake method
+func (m *MockKrakenSpotRESTClient) Stake(ctx context.Context, nonce int64, params staking.StakeRequestParameters, secopts *common.SecurityOptions) (*staking.StakeResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[staking.StakeResponse](args)
+}
+
+// Mocked Unstake method
+func (m *MockKrakenSpotRESTClient) Unstake(ctx context.Context, nonce int64, params staking.UnstakeRequestParameters, secopts *common.SecurityOptions) (*staking.UnstakeResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, params, secopts)
+	return mockedResponse[staking.UnstakeResponse](args)
+}
+
+// Mocked ListStakeableAssets method
+func (m *MockKrakenSpotRESTClient) ListStakeableAssets(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*staking.ListStakeableAssetsResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, secopts)
+	return mockedResponse[staking.ListStakeableAssetsResponse](args)
+}
+
+// Mocked GetPendingStakingTransactions method
+func (m *MockKrakenSpotRESTClient) GetPendingStakingTransactions(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*staking.GetPendingStakingTransactionsResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, secopts)
+	return mockedResponse[staking.GetPendingStakingTransactionsResponse](args)
+}
+
+// Mocked ListRecentStakingTransactions method
+func (m *MockKrakenSpotRESTClient) ListRecentStakingTransactions(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*staking.ListRecentStakingTransactionsResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, secopts)
+	return mockedResponse[staking.ListRecentStakingTransactionsResponse](args)
+}
+
+// Mocked GetWebsocketToken method
+func (m *MockKrakenSpotRESTClient) GetWebsocketToken(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*websocket.GetWebsocketTokenResponse, *http.Response, error) {
+	args := m.Called(ctx, nonce, secopts)
+	return mockedResponse[websocket.GetWebsocketTokenResponse](args)
+}
+
+/*************************************************************************************************/
+/* RESPONSE BUILDERS                                                                             */
+/*************************************************************************************************/
+
+// Build a GetAccountBalanceResponse which contains the provided balances and no errors.
+//
+// Balances are provided as a map where keys are assets and values are the balances formatted as
+// strings (ex: "XXBT": "1.2500").
+func NewMockGetAccountBalanceResponse(balances map[string]string) *account.GetAccountBalanceResponse {
+	result := make(map[string]json.Number, len(balances))
+	for asset, balance := range balances {
+		result[asset] = json.Number(balance)
+	}
+	return &account.GetAccountBalanceResponse{
+		KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+		Result:                 result,
+	}
+}
+
+// Build a GetOpenOrdersResponse which contains the provided open orders (keys are transaction IDs)
+// and no errors. A nil map produces a response with no open orders.
+func NewMockGetOpenOrdersResponse(orders map[string]*account.OrderInfo) *account.GetOpenOrdersResponse {
+	if orders == nil {
+		orders = map[string]*account.OrderInfo{}
+	}
+	return &account.GetOpenOrdersResponse{
+		KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+		Result:                 &account.GetOpenOrdersResult{Open: orders},
+	}
+}
+
+// Build a GetTickerInformationResponse which contains the provided ticker data (keys are pairs)
+// and no errors.
+func NewMockGetTickerInformationResponse(tickers map[string]*market.AssetTickerInfo) *market.GetTickerInformationResponse {
+	if tickers == nil {
+		tickers = map[string]*market.AssetTickerInfo{}
+	}
+	return &market.GetTickerInformationResponse{
+		KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+		Result:                 tickers,
+	}
+}
+
+// Build a GetWebsocketTokenResponse which contains the provided token and expiration delay (in
+// seconds) and no errors.
+func NewMockGetWebsocketTokenResponse(token string, expires int64) *websocket.GetWebsocketTokenResponse {
+	return &websocket.GetWebsocketTokenResponse{
+		KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+		Result: &websocket.GetWebsocketTokenResult{
+			Token:   token,
+			Expires: expires,
+		},
+	}
+}
+
+// Build a KrakenSpotRESTResponse which only contains the provided errors. The returned value can
+// be embedded in any typed response to mock an error reply from Kraken API:
+//
+//	resp := &account.GetAccountBalanceResponse{
+//		KrakenSpotRESTResponse: *NewMockKrakenSpotRESTErrorResponse("EAPI:Invalid nonce"),
+//	}
+func NewMockKrakenSpotRESTErrorResponse(errs ...string) *common.KrakenSpotRESTResponse {
+	return &common.KrakenSpotRESTResponse{Error: errs}
+}
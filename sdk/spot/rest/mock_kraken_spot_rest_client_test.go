@@ -0,0 +1,105 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for MockKrakenSpotRESTClient.
+type MockKrakenSpotRESTClientTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestMockKrakenSpotRESTClientTestSuite(t *testing.T) {
+	suite.Run(t, new(MockKrakenSpotRESTClientTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test interface compliance.
+func (suite *MockKrakenSpotRESTClientTestSuite) TestIFaceCompliance() {
+	// Configure mock and assign it to interface{}
+	var instance interface{} = NewMockKrakenSpotRESTClient()
+	// Cast interface{} to the interface type and ensure it is OK
+	_, ok := instance.(KrakenSpotRESTClientIface)
+	require.True(suite.T(), ok)
+}
+
+// Test the GetAccountBalance method with a response built by NewMockGetAccountBalanceResponse.
+//
+// Test will ensure mock works as expected and returns the configured typed response.
+func (suite *MockKrakenSpotRESTClientTestSuite) TestGetAccountBalance() {
+	// Configure mock
+	m := NewMockKrakenSpotRESTClient()
+	expected := NewMockGetAccountBalanceResponse(map[string]string{"XXBT": "1.25", "ZUSD": "100.0"})
+	expectedHttp := &http.Response{StatusCode: http.StatusOK}
+	m.On("GetAccountBalance", mock.Anything, int64(42), mock.Anything).Return(expected, expectedHttp, nil)
+	// Call mocked method
+	resp, httpresp, err := m.GetAccountBalance(context.Background(), 42, nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), expected, resp)
+	require.Equal(suite.T(), expectedHttp, httpresp)
+	require.Empty(suite.T(), resp.Error)
+	require.Equal(suite.T(), "1.25", resp.Result["XXBT"].String())
+	m.AssertNumberOfCalls(suite.T(), "GetAccountBalance", 1)
+}
+
+// Test the AddOrder method when configured to return only an error.
+//
+// Test will ensure nil values can be used for the typed response and the http.Response.
+func (suite *MockKrakenSpotRESTClientTestSuite) TestAddOrderWithError() {
+	// Configure mock
+	m := NewMockKrakenSpotRESTClient()
+	m.On("AddOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("fail"))
+	// Call mocked method
+	resp, httpresp, err := m.AddOrder(context.Background(), 1, trading.AddOrderRequestParameters{Pair: "XXBTZUSD"}, nil, nil)
+	require.Error(suite.T(), err)
+	require.Nil(suite.T(), resp)
+	require.Nil(suite.T(), httpresp)
+	m.AssertNumberOfCalls(suite.T(), "AddOrder", 1)
+}
+
+// Test the response builders.
+//
+// Test will ensure builders produce responses without errors which contain the provided data and
+// that nil inputs produce empty, non-nil results.
+func (suite *MockKrakenSpotRESTClientTestSuite) TestResponseBuilders() {
+	// Open orders
+	orders := NewMockGetOpenOrdersResponse(map[string]*account.OrderInfo{
+		"OQCLML-BW3P3-BUCMWZ": {Status: string(account.Open)},
+	})
+	require.Empty(suite.T(), orders.Error)
+	require.Len(suite.T(), orders.Result.Open, 1)
+	require.Empty(suite.T(), NewMockGetOpenOrdersResponse(nil).Result.Open)
+	// Ticker
+	tickers := NewMockGetTickerInformationResponse(map[string]*market.AssetTickerInfo{
+		"XXBTZUSD": {Close: []string{"30000.0", "0.1"}},
+	})
+	require.Empty(suite.T(), tickers.Error)
+	require.Equal(suite.T(), "30000.0", tickers.Result["XXBTZUSD"].GetLastTradePrice())
+	require.NotNil(suite.T(), NewMockGetTickerInformationResponse(nil).Result)
+	// Websocket token
+	token := NewMockGetWebsocketTokenResponse("token", 900)
+	require.Empty(suite.T(), token.Error)
+	require.Equal(suite.T(), "token", token.Result.Token)
+	require.Equal(suite.T(), int64(900), token.Result.Expires)
+	// Error response
+	errResp := NewMockKrakenSpotRESTErrorResponse("EAPI:Invalid nonce")
+	require.Equal(suite.T(), []string{"EAPI:Invalid nonce"}, errResp.Error)
+}
@@ -0,0 +1,134 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+)
+
+/*****************************************************************************/
+/* NONCE RESYNC: MODEL                                                       */
+/*****************************************************************************/
+
+// Error returned by Kraken API when a request nonce is not greater than the last nonce used with
+// the API key (outside of the nonce window).
+const InvalidNonceError = "EAPI:Invalid nonce"
+
+// Settings for the automatic handling of "EAPI:Invalid nonce" errors by KrakenSpotRESTClient.
+type NonceResyncConfiguration struct {
+	// Nonce generator used by the application. When it implements
+	// noncegen.ResyncableNonceGenerator, its baseline is moved forward each time an invalid nonce
+	// error is received. It is also used to generate the nonce of retried requests.
+	//
+	// Must not be nil.
+	NonceGenerator noncegen.NonceGenerator
+	// If true, a request rejected because of an invalid nonce is retried once with a new nonce.
+	// Otherwise, the response with the invalid nonce error is returned.
+	Retry bool
+}
+
+// Counters of the invalid nonce errors handled by KrakenSpotRESTClient.
+type NonceResyncStats struct {
+	// Number of invalid nonce errors received: each triggered a resync of the nonce generator.
+	Resyncs uint64
+	// Number of requests retried with a new nonce.
+	Retries uint64
+}
+
+/*****************************************************************************/
+/* NONCE RESYNC: FUNCTIONS                                                   */
+/*****************************************************************************/
+
+// # Description
+//
+// Get the counters of the invalid nonce errors handled by the client. Counters remain at zero if
+// the automatic handling of invalid nonce errors is not configured (Cf. KrakenSpotRESTClientConfiguration).
+//
+// # Return
+//
+// The counters of the invalid nonce errors handled by the client.
+func (client *KrakenSpotRESTClient) GetNonceResyncStats() NonceResyncStats {
+	return NonceResyncStats{
+		Resyncs: client.nonceResyncs.Load(),
+		Retries: client.nonceRetries.Load(),
+	}
+}
+
+// # Description
+//
+// Handle a response which contains an invalid nonce error: resync the nonce generator and, if
+// configured, retry the request once with a new nonce.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - req: Request which has been rejected.
+//   - receiver: Receiver which contains the response. It is reset before the request is retried.
+//   - resp: HTTP response received for the rejected request.
+//
+// # Return
+//
+// The HTTP response of the retried request or the provided response if the request is not
+// retried and an error if the request could not be retried.
+func (client *KrakenSpotRESTClient) handleInvalidNonce(ctx context.Context, req *http.Request, receiver interface{}, resp *http.Response) (*http.Response, error) {
+	client.nonceResyncs.Add(1)
+	if gen, ok := client.nonceResync.NonceGenerator.(noncegen.ResyncableNonceGenerator); ok {
+		gen.Resync()
+	}
+	if !client.nonceResync.Retry || req.GetBody == nil {
+		return resp, nil
+	}
+	// Renew the nonce in the form body and authorize the new request
+	body, err := req.GetBody()
+	if err != nil {
+		return resp, fmt.Errorf("failed to renew request nonce: %w", err)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return resp, fmt.Errorf("failed to renew request nonce: %w", err)
+	}
+	form, err := url.ParseQuery(string(data))
+	if err != nil {
+		return resp, fmt.Errorf("failed to renew request nonce: %w", err)
+	}
+	form.Set("nonce", strconv.FormatInt(client.nonceResync.NonceGenerator.GenerateNonce(), 10))
+	retry, err := http.NewRequestWithContext(ctx, req.Method, req.URL.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return resp, fmt.Errorf("failed to renew request nonce: %w", err)
+	}
+	retry.Header = req.Header.Clone()
+	if client.authorizer != nil {
+		retry, err = client.authorizer.Authorize(ctx, retry)
+		if err != nil {
+			return resp, fmt.Errorf("failed to renew request nonce: %w", err)
+		}
+	}
+	// Reset the receiver and send the request
+	value := reflect.ValueOf(receiver)
+	if value.Kind() == reflect.Pointer && !value.IsNil() {
+		value.Elem().Set(reflect.Zero(value.Elem().Type()))
+	}
+	client.nonceRetries.Add(1)
+	return client.sendKrakenAPIRequest(ctx, retry, receiver)
+}
+
+// Tell whether the provided parsed response contains an invalid nonce error.
+func hasInvalidNonceError(receiver interface{}) bool {
+	withErrors, ok := receiver.(interface{ GetErrors() []string })
+	if !ok {
+		return false
+	}
+	for _, err := range withErrors.GetErrors() {
+		if strings.HasPrefix(err, InvalidNonceError) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gbdevw/gosette"
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/fixtures"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the automatic handling of invalid nonce errors
+type NonceResyncTestSuite struct {
+	suite.Suite
+	// Mock HTTP server
+	srv *gosette.HTTPTestServer
+}
+
+// Run unit test suite
+func TestNonceResyncTestSuite(t *testing.T) {
+	tstsrv := gosette.NewHTTPTestServer(nil)
+	tstsrv.Start()
+	defer tstsrv.Close()
+	suite.Run(t, &NonceResyncTestSuite{srv: tstsrv})
+}
+
+// Clean the server predefined responses and records before each test.
+func (suite *NonceResyncTestSuite) BeforeTest(suiteName, testName string) {
+	suite.srv.Clear()
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test a request rejected because of an invalid nonce is retried with a new nonce.
+//
+// Test will ensure:
+//   - The nonce generator is resynced.
+//   - The request is retried once with a new nonce and a new signature.
+//   - The response of the retried request is returned.
+//   - Counters are updated.
+func (suite *NonceResyncTestSuite) TestRetry() {
+	gen := noncegen.NewHFNonceGenerator()
+	client := suite.newClient(&NonceResyncConfiguration{NonceGenerator: gen, Retry: true})
+	suite.pushResponse(fixtures.RESTErrorInvalidNonce)
+	suite.pushResponse(`{"error":[],"result":{"ZUSD":"171288.6158"}}`)
+	before := gen.GenerateNonce()
+	resp, _, err := client.GetAccountBalance(context.Background(), 42, nil)
+	require.NoError(suite.T(), err)
+	require.Empty(suite.T(), resp.Error)
+	require.Equal(suite.T(), "171288.6158", resp.Result["ZUSD"].String())
+	// Check requests
+	first := suite.srv.PopServerRecord()
+	require.NoError(suite.T(), first.Request.ParseForm())
+	require.Equal(suite.T(), "42", first.Request.Form.Get("nonce"))
+	second := suite.srv.PopServerRecord()
+	require.NoError(suite.T(), second.Request.ParseForm())
+	nonce, err := strconv.ParseInt(second.Request.Form.Get("nonce"), 10, 64)
+	require.NoError(suite.T(), err)
+	require.Greater(suite.T(), nonce, before+noncegen.HFNonceResyncStep)
+	require.NotEqual(suite.T(), first.Request.Header.Get("Api-Sign"), second.Request.Header.Get("Api-Sign"))
+	require.Equal(suite.T(), NonceResyncStats{Resyncs: 1, Retries: 1}, client.GetNonceResyncStats())
+	// The request is retried only once - the last predefined response is served for all requests
+	suite.srv.Clear()
+	suite.pushResponse(fixtures.RESTErrorInvalidNonce)
+	resp, _, err = client.GetAccountBalance(context.Background(), gen.GenerateNonce(), nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), []string{InvalidNonceError}, resp.Error)
+	require.Equal(suite.T(), NonceResyncStats{Resyncs: 2, Retries: 2}, client.GetNonceResyncStats())
+	require.NotNil(suite.T(), suite.srv.PopServerRecord())
+	require.NotNil(suite.T(), suite.srv.PopServerRecord())
+	require.Nil(suite.T(), suite.srv.PopServerRecord())
+}
+
+// Test invalid nonce errors when retries are disabled or when the feature is not configured.
+//
+// Test will ensure:
+//   - The nonce generator is resynced and the response is returned as is when retries are disabled.
+//   - Invalid nonce errors are returned as is and not counted when the feature is not configured.
+func (suite *NonceResyncTestSuite) TestNoRetry() {
+	gen := noncegen.NewHFNonceGenerator()
+	client := suite.newClient(&NonceResyncConfiguration{NonceGenerator: gen})
+	suite.pushResponse(fixtures.RESTErrorInvalidNonce)
+	before := gen.GenerateNonce()
+	resp, _, err := client.GetAccountBalance(context.Background(), 42, nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), []string{InvalidNonceError}, resp.Error)
+	require.Greater(suite.T(), gen.GenerateNonce(), before+noncegen.HFNonceResyncStep)
+	require.Equal(suite.T(), NonceResyncStats{Resyncs: 1}, client.GetNonceResyncStats())
+	require.NotNil(suite.T(), suite.srv.PopServerRecord())
+	require.Nil(suite.T(), suite.srv.PopServerRecord())
+	// Not configured
+	client = suite.newClient(nil)
+	resp, _, err = client.GetAccountBalance(context.Background(), 42, nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), []string{InvalidNonceError}, resp.Error)
+	require.Equal(suite.T(), NonceResyncStats{}, client.GetNonceResyncStats())
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build a client which uses the test server and the provided nonce resync settings.
+func (suite *NonceResyncTestSuite) newClient(cfg *NonceResyncConfiguration) *KrakenSpotRESTClient {
+	auth, err := NewKrakenSpotRESTClientAuthorizer(apiKey, secretB64)
+	require.NoError(suite.T(), err)
+	return NewKrakenSpotRESTClient(auth, &KrakenSpotRESTClientConfiguration{
+		BaseURL:     suite.srv.GetBaseURL(),
+		Agent:       usrAgent,
+		NonceResync: cfg,
+	})
+}
+
+// Push a predefined JSON response to the test server.
+func (suite *NonceResyncTestSuite) pushResponse(body string) {
+	suite.srv.PushPredefinedServerResponse(&gosette.PredefinedServerResponse{
+		Status:  http.StatusOK,
+		Headers: http.Header{"Content-Type": []string{"application/json"}},
+		Body:    []byte(body),
+	})
+}
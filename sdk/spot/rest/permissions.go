@@ -0,0 +1,203 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+)
+
+/*****************************************************************************/
+/* PERMISSIONS: MODEL                                                        */
+/*****************************************************************************/
+
+// Error returned by Kraken API when the API key does not have the permission required by the
+// endpoint.
+const PermissionDeniedError = "EGeneral:Permission denied"
+
+// Enum for the permission scopes reported by ProbePermissions.
+type PermissionScopeEnum string
+
+// Values for PermissionScopeEnum
+const (
+	// Funds permissions - Query. Probed with GetAccountBalance.
+	PermissionQueryFunds PermissionScopeEnum = "query_funds"
+	// Orders and trades - Create & modify orders. Probed with a validate-only AddOrder: no order
+	// is submitted.
+	PermissionTrade PermissionScopeEnum = "trade"
+	// Funds permissions - Withdraw. Probed with GetWithdrawalAddresses.
+	PermissionWithdraw PermissionScopeEnum = "withdraw"
+	// Data - Export data. Probed with GetExportReportStatus.
+	PermissionExport PermissionScopeEnum = "export"
+)
+
+// Pair used by the validate-only AddOrder sent to probe the trade permission.
+const permissionProbePair = "XXBTZUSD"
+
+// Permission scopes granted to an API key.
+type APIKeyPermissions struct {
+	// Granted scopes. A scope which is not in the map has not been probed.
+	Scopes map[PermissionScopeEnum]bool
+}
+
+// # Description
+//
+// Tell whether the scope has been granted to the API key.
+//
+// # Return
+//
+// True if the scope has been granted. False if it has been denied or not probed.
+func (p *APIKeyPermissions) Has(scope PermissionScopeEnum) bool {
+	return p.Scopes[scope]
+}
+
+// # Description
+//
+// Check the API key has all the required scopes. Use it at startup to fail fast with an actionable
+// error instead of getting EGeneral:Permission denied errors later.
+//
+// # Inputs
+//
+//   - scopes: Required scopes.
+//
+// # Return
+//
+// Nil if all scopes have been granted, a MissingPermissionsError otherwise.
+func (p *APIKeyPermissions) Require(scopes ...PermissionScopeEnum) error {
+	missing := []PermissionScopeEnum{}
+	for _, scope := range scopes {
+		if !p.Has(scope) {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingPermissionsError{Missing: missing}
+	}
+	return nil
+}
+
+// Error returned by APIKeyPermissions.Require when the API key misses required scopes.
+type MissingPermissionsError struct {
+	// Missing scopes
+	Missing []PermissionScopeEnum
+}
+
+// Format the error message
+func (e *MissingPermissionsError) Error() string {
+	scopes := make([]string, len(e.Missing))
+	for i, scope := range e.Missing {
+		scopes[i] = string(scope)
+	}
+	return fmt.Sprintf("API key is missing required permissions: %s. Update the key permissions in the Kraken API key settings", strings.Join(scopes, ", "))
+}
+
+/*****************************************************************************/
+/* PERMISSIONS: PROBE                                                        */
+/*****************************************************************************/
+
+// # Description
+//
+// Find out which permission scopes are granted to the API key used by the client by sending one
+// harmless request per scope (Cf. PermissionScopeEnum) and by looking for EGeneral:Permission
+// denied errors in the responses. Other errors returned by Kraken (invalid arguments, unknown
+// asset, ...) mean the request passed the permission check: the scope is considered as granted.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - client: REST client to use. Must not be nil.
+//   - nonceGenerator: Nonce generator used to sign requests. Must not be nil.
+//   - secopts: Optional security options (like password 2FA). Can be nil if 2FA is not used.
+//
+// # Return
+//
+// The granted permissions or an error if a request failed or if Kraken rejected the API key
+// itself (invalid key, signature, nonce, ...): in that case permissions cannot be determined.
+func ProbePermissions(ctx context.Context, client KrakenSpotRESTClientIface, nonceGenerator noncegen.NonceGenerator, secopts *common.SecurityOptions) (*APIKeyPermissions, error) {
+	if client == nil || nonceGenerator == nil {
+		return nil, fmt.Errorf("rest client and nonce generator cannot be nil")
+	}
+	probes := []struct {
+		scope PermissionScopeEnum
+		probe func() ([]string, error)
+	}{
+		{PermissionQueryFunds, func() ([]string, error) {
+			resp, _, err := client.GetAccountBalance(ctx, nonceGenerator.GenerateNonce(), secopts)
+			if err != nil {
+				return nil, err
+			}
+			return resp.Error, nil
+		}},
+		{PermissionTrade, func() ([]string, error) {
+			resp, _, err := client.AddOrder(ctx, nonceGenerator.GenerateNonce(), trading.AddOrderRequestParameters{
+				Pair: permissionProbePair,
+				Order: trading.Order{
+					OrderType: string(trading.Market),
+					Type:      string(trading.Buy),
+					Volume:    "0.0001",
+				},
+			}, &trading.AddOrderRequestOptions{Validate: true}, secopts)
+			if err != nil {
+				return nil, err
+			}
+			return resp.Error, nil
+		}},
+		{PermissionWithdraw, func() ([]string, error) {
+			resp, _, err := client.GetWithdrawalAddresses(ctx, nonceGenerator.GenerateNonce(), nil, secopts)
+			if err != nil {
+				return nil, err
+			}
+			return resp.Error, nil
+		}},
+		{PermissionExport, func() ([]string, error) {
+			resp, _, err := client.GetExportReportStatus(ctx, nonceGenerator.GenerateNonce(), account.GetExportReportStatusRequestParameters{
+				Report: string(account.ReportTrades),
+			}, secopts)
+			if err != nil {
+				return nil, err
+			}
+			return resp.Error, nil
+		}},
+	}
+	permissions := &APIKeyPermissions{Scopes: map[PermissionScopeEnum]bool{}}
+	for _, p := range probes {
+		errs, err := p.probe()
+		if err != nil {
+			return nil, fmt.Errorf("failed to probe %s permission: %w", p.scope, err)
+		}
+		granted := true
+		for _, e := range errs {
+			if strings.HasPrefix(e, PermissionDeniedError) {
+				granted = false
+				break
+			}
+			if strings.HasPrefix(e, "EAPI:") {
+				return nil, fmt.Errorf("failed to probe %s permission: %s", p.scope, e)
+			}
+		}
+		permissions.Scopes[p.scope] = granted
+	}
+	return permissions, nil
+}
+
+// # Description
+//
+// Find out which permission scopes are granted to the API key used by the client. Cf.
+// ProbePermissions.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - nonceGenerator: Nonce generator used to sign requests. Must not be nil.
+//   - secopts: Optional security options (like password 2FA). Can be nil if 2FA is not used.
+//
+// # Return
+//
+// The granted permissions or an error if permissions could not be determined.
+func (client *KrakenSpotRESTClient) Permissions(ctx context.Context, nonceGenerator noncegen.NonceGenerator, secopts *common.SecurityOptions) (*APIKeyPermissions, error) {
+	return ProbePermissions(ctx, client, nonceGenerator, secopts)
+}
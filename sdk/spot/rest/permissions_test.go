@@ -0,0 +1,88 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/funding"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for ProbePermissions
+type PermissionsTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestPermissionsTestSuite(t *testing.T) {
+	suite.Run(t, new(PermissionsTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test ProbePermissions.
+//
+// Test will ensure:
+//   - Scopes are denied only when EGeneral:Permission denied is returned.
+//   - Other Kraken errors mean the scope is granted.
+//   - The trade permission is probed with a validate-only order.
+//   - Require reports missing scopes.
+func (suite *PermissionsTestSuite) TestProbePermissions() {
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetAccountBalance", mock.Anything, mock.Anything, mock.Anything).
+		Return(NewMockGetAccountBalanceResponse(map[string]string{"ZUSD": "1.0"}), nil, nil)
+	client.On("AddOrder", mock.Anything, mock.Anything, mock.Anything, &trading.AddOrderRequestOptions{Validate: true}, mock.Anything).
+		Return(&trading.AddOrderResponse{KrakenSpotRESTResponse: *NewMockKrakenSpotRESTErrorResponse("EOrder:Insufficient funds")}, nil, nil)
+	client.On("GetWithdrawalAddresses", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&funding.GetWithdrawalAddressesResponse{KrakenSpotRESTResponse: *NewMockKrakenSpotRESTErrorResponse(PermissionDeniedError)}, nil, nil)
+	client.On("GetExportReportStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&account.GetExportReportStatusResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}}}, nil, nil)
+	permissions, err := ProbePermissions(context.Background(), client, noncegen.NewHFNonceGenerator(), nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), map[PermissionScopeEnum]bool{
+		PermissionQueryFunds: true,
+		PermissionTrade:      true,
+		PermissionWithdraw:   false,
+		PermissionExport:     true,
+	}, permissions.Scopes)
+	require.NoError(suite.T(), permissions.Require(PermissionQueryFunds, PermissionTrade))
+	err = permissions.Require(PermissionTrade, PermissionWithdraw)
+	missing := new(MissingPermissionsError)
+	require.ErrorAs(suite.T(), err, &missing)
+	require.Equal(suite.T(), []PermissionScopeEnum{PermissionWithdraw}, missing.Missing)
+}
+
+// Test ProbePermissions fails when permissions cannot be determined.
+//
+// Test will ensure:
+//   - An error is returned when the API key is rejected.
+//   - An error is returned when a request fails.
+//   - An error is returned when the client or the nonce generator is nil.
+func (suite *PermissionsTestSuite) TestProbePermissionsErrors() {
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetAccountBalance", mock.Anything, mock.Anything, mock.Anything).
+		Return(&account.GetAccountBalanceResponse{KrakenSpotRESTResponse: *NewMockKrakenSpotRESTErrorResponse("EAPI:Invalid key")}, nil, nil).Once()
+	client.On("GetAccountBalance", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, nil, fmt.Errorf("connection refused")).Once()
+	_, err := ProbePermissions(context.Background(), client, noncegen.NewHFNonceGenerator(), nil)
+	require.ErrorContains(suite.T(), err, "EAPI:Invalid key")
+	_, err = ProbePermissions(context.Background(), client, noncegen.NewHFNonceGenerator(), nil)
+	require.ErrorContains(suite.T(), err, "connection refused")
+	_, err = ProbePermissions(context.Background(), nil, noncegen.NewHFNonceGenerator(), nil)
+	require.Error(suite.T(), err)
+	_, err = ProbePermissions(context.Background(), client, nil, nil)
+	require.Error(suite.T(), err)
+}
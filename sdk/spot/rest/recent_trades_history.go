@@ -0,0 +1,271 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+/*****************************************************************************/
+/* RECENT TRADES HISTORY: MODEL                                              */
+/*****************************************************************************/
+
+// Error returned by Kraken API when too many requests have been sent to the public endpoints.
+const TooManyRequestsError = "EGeneral:Too many requests"
+
+// Default delay waited by StreamRecentTrades before retrying a request rejected because the rate
+// limit has been exceeded.
+const DefaultRecentTradesRetryDelay = 5 * time.Second
+
+// Default maximum number of retries of a request rejected because the rate limit has been
+// exceeded.
+const DefaultRecentTradesMaxRetries = 3
+
+// StreamRecentTrades options.
+type RecentTradesStreamOptions struct {
+	// Start of the time range: trades made at or after Start are streamed.
+	//
+	// By default, streaming starts with the most recent trades. A zero value triggers default behavior.
+	Start time.Time
+	// End of the time range: streaming stops once a trade made after End is reached.
+	//
+	// By default, streaming stops once the most recent trade has been streamed. A zero value
+	// triggers default behavior.
+	End time.Time
+	// Trades whose ID is lower or equal to AfterId are skipped. Set it to the ID of the last
+	// consumed trade, along with Start, to resume an interrupted stream.
+	AfterId int64
+	// Number of trades fetched per page, up to 1000.
+	//
+	// 1000 by default. A zero value triggers default behavior.
+	PageSize int
+	// Optional rate limiter waited before each request. If nil, requests are not rate limited.
+	RateLimiter RateLimiter
+	// Delay waited before retrying a request rejected because the rate limit has been exceeded.
+	//
+	// Defaults to DefaultRecentTradesRetryDelay. A zero or negative value triggers default behavior.
+	RetryDelay time.Duration
+	// Maximum number of retries of a request rejected because the rate limit has been exceeded.
+	//
+	// Defaults to DefaultRecentTradesMaxRetries. A zero value triggers default behavior. Use a
+	// negative value to disable retries.
+	MaxRetries int
+}
+
+// Trade yielded by StreamRecentTrades.
+type StreamedRecentTrade struct {
+	// Trade ID
+	Id int64
+	// Trade, with the same type as the trades published by the websocket trade channel
+	Entry messages.TradeEntry
+	// Trade as returned by GetRecentTrades
+	Trade market.Trade
+}
+
+/*****************************************************************************/
+/* RECENT TRADES HISTORY: FUNCTIONS                                          */
+/*****************************************************************************/
+
+// # Description
+//
+// Stream the public trades of a pair over a time range. Pages are fetched with GetRecentTrades
+// in a background goroutine and their trades are yielded on the returned channel, oldest first.
+// Each page is fetched with the Last value of the previous page as since cursor: trades which
+// overlap between two pages are yielded only once (trades are identified by their ID). Streaming
+// stops once a trade made after the end of the time range is reached or once a page does not
+// contain any new trade.
+//
+// The rate limiter provided in the options, if any, is waited before each request and requests
+// rejected because the rate limit has been exceeded are retried after a delay.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. Streaming stops when the context is done.
+//   - client: REST client used to fetch pages. Must not be nil.
+//   - params: GetRecentTrades request parameters.
+//   - opts: Stream options. Can be nil to stream the trades from the most recent ones without rate limiter.
+//   - bufferSize: Capacity of the trades channel. Use 0 for an unbuffered channel.
+//
+// # Return
+//
+// A channel where trades are yielded and a channel where at most one error is published once
+// streaming has stopped. The trades channel is closed when streaming stops and the error channel
+// is closed right after. No error is published if all trades have been streamed. The context
+// error is published if the context is done before all trades have been streamed.
+func StreamRecentTrades(
+	ctx context.Context,
+	client KrakenSpotRESTClientIface,
+	params market.GetRecentTradesRequestParameters,
+	opts *RecentTradesStreamOptions,
+	bufferSize int) (<-chan StreamedRecentTrade, <-chan error) {
+	// Copy options so defaults can be set without modifying the user's options
+	stropts := RecentTradesStreamOptions{}
+	if opts != nil {
+		stropts = *opts
+	}
+	if stropts.RetryDelay <= 0 {
+		stropts.RetryDelay = DefaultRecentTradesRetryDelay
+	}
+	if stropts.MaxRetries == 0 {
+		stropts.MaxRetries = DefaultRecentTradesMaxRetries
+	} else if stropts.MaxRetries < 0 {
+		stropts.MaxRetries = 0
+	}
+	out := make(chan StreamedRecentTrade, bufferSize)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(out)
+		err := streamRecentTrades(ctx, client, clock.NewSystemClock(), params, stropts, func(trade StreamedRecentTrade) bool {
+			select {
+			case out <- trade:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return out, errs
+}
+
+// # Description
+//
+// Convert a trade returned by GetRecentTrades into the typed trade published by the websocket
+// trade channel.
+//
+// # Inputs
+//
+//   - trade: Trade returned by GetRecentTrades.
+//
+// # Return
+//
+// The typed trade or an error if the price or the volume cannot be parsed.
+func NewWebsocketTradeEntry(trade market.Trade) (messages.TradeEntry, error) {
+	data := messages.TradeData{
+		Price:         json.Number(trade.Price),
+		Volume:        json.Number(trade.Volume),
+		Timestamp:     json.Number(fmt.Sprintf("%d.%09d", trade.Timestamp.Unix(), trade.Timestamp.Nanosecond())),
+		Side:          trade.Side,
+		OrderType:     trade.Type,
+		Miscellaneous: trade.Miscellaneous,
+	}
+	entry, err := data.Entry()
+	if err != nil {
+		return messages.TradeEntry{}, fmt.Errorf("failed to convert trade %d: %w", trade.Id, err)
+	}
+	return entry, nil
+}
+
+// # Description
+//
+// Fetch pages with the since cursor until the end of the time range is reached or until a page
+// does not contain any new trade.
+//
+// # Inputs
+//
+//   - ctx: Context used for coordination purpose.
+//   - client: REST client used to fetch pages.
+//   - clk: Clock used to wait before retries.
+//   - params: GetRecentTrades request parameters.
+//   - opts: Stream options with defaults set.
+//   - yield: Function used to yield a new trade. Must return false if the context is done.
+//
+// # Return
+//
+// An error if a page could not be fetched, if a trade could not be converted or if the context
+// is done before all trades have been yielded.
+func streamRecentTrades(
+	ctx context.Context,
+	client KrakenSpotRESTClientIface,
+	clk clock.Clock,
+	params market.GetRecentTradesRequestParameters,
+	opts RecentTradesStreamOptions,
+	yield func(trade StreamedRecentTrade) bool) error {
+	reqopts := market.GetRecentTradesRequestOptions{Count: opts.PageSize}
+	if !opts.Start.IsZero() {
+		reqopts.Since = opts.Start.UnixNano()
+	}
+	// ID of the newest yielded trade
+	newest := opts.AfterId
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		page, err := fetchRecentTrades(ctx, client, clk, params, reqopts, opts)
+		if err != nil {
+			return err
+		}
+		yielded := 0
+		for _, trade := range page.Trades {
+			// Skip trades which have already been yielded with the previous pages or which are
+			// before the time range
+			if trade.Id <= newest || (!opts.Start.IsZero() && trade.Timestamp.Before(opts.Start)) {
+				continue
+			}
+			if !opts.End.IsZero() && trade.Timestamp.After(opts.End) {
+				return nil
+			}
+			entry, err := NewWebsocketTradeEntry(trade)
+			if err != nil {
+				return err
+			}
+			if !yield(StreamedRecentTrade{Id: trade.Id, Entry: entry, Trade: trade}) {
+				return ctx.Err()
+			}
+			newest = trade.Id
+			yielded++
+		}
+		// Stop when the page does not contain new trades or when the cursor does not move
+		if yielded == 0 || page.Last == reqopts.Since {
+			return nil
+		}
+		reqopts.Since = page.Last
+	}
+}
+
+// Fetch a page of recent trades. The rate limiter is waited before each request and requests
+// rejected because the rate limit has been exceeded are retried.
+func fetchRecentTrades(
+	ctx context.Context,
+	client KrakenSpotRESTClientIface,
+	clk clock.Clock,
+	params market.GetRecentTradesRequestParameters,
+	reqopts market.GetRecentTradesRequestOptions,
+	opts RecentTradesStreamOptions) (*market.RecentTrades, error) {
+	for retries := 0; ; retries++ {
+		if opts.RateLimiter != nil {
+			if err := opts.RateLimiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		resp, _, err := client.GetRecentTrades(ctx, params, &reqopts)
+		if err != nil {
+			return nil, fmt.Errorf("get recent trades failed: %w", err)
+		}
+		if len(resp.Error) == 0 && resp.Result != nil {
+			return resp.Result, nil
+		}
+		limited := isRateLimitExceeded(resp.Error)
+		for _, e := range resp.Error {
+			limited = limited || e == TooManyRequestsError
+		}
+		if !limited || retries >= opts.MaxRetries {
+			return nil, fmt.Errorf("get recent trades failed: %v", resp.Error)
+		}
+		timer := clk.NewTimer(opts.RetryDelay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("get recent trades failed: %w", ctx.Err())
+		case <-timer.C():
+		}
+	}
+}
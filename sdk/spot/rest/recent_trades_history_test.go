@@ -0,0 +1,169 @@
+package rest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for StreamRecentTrades
+type RecentTradesHistoryTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestRecentTradesHistoryTestSuite(t *testing.T) {
+	suite.Run(t, new(RecentTradesHistoryTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test StreamRecentTrades.
+//
+// Test will ensure:
+//   - The first page is fetched with the start of the time range as since cursor and the next
+//     pages with the Last value of the previous page.
+//   - Trades which overlap between pages, trades before the start of the time range and trades
+//     already consumed (AfterId) are skipped.
+//   - Streaming stops once a trade made after the end of the time range is reached.
+//   - The rate limiter is waited before each request.
+func (suite *RecentTradesHistoryTestSuite) TestStreamRecentTrades() {
+	params := market.GetRecentTradesRequestParameters{Pair: "XXBTZUSD"}
+	start := time.Unix(100, 0)
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetRecentTrades", mock.Anything, params, mock.MatchedBy(tradesSince(start.UnixNano()))).
+		Return(newTradesResponse(102, newTrade(1, 99), newTrade(2, 100), newTrade(3, 101), newTrade(4, 102)), nil, nil).Once()
+	client.On("GetRecentTrades", mock.Anything, params, mock.MatchedBy(tradesSince(102))).
+		Return(newTradesResponse(104, newTrade(4, 102), newTrade(5, 103), newTrade(6, 104), newTrade(7, 105)), nil, nil).Once()
+	limiter := &countingRateLimiter{}
+	trades, errs := StreamRecentTrades(context.Background(), client, params, &RecentTradesStreamOptions{
+		Start:       start,
+		End:         time.Unix(104, 0),
+		AfterId:     2,
+		RateLimiter: limiter,
+	}, 0)
+	ids := []int64{}
+	for trade := range trades {
+		ids = append(ids, trade.Id)
+		require.Equal(suite.T(), trade.Trade.Timestamp.UTC(), trade.Entry.Time)
+	}
+	require.NoError(suite.T(), <-errs)
+	require.Equal(suite.T(), []int64{3, 4, 5, 6}, ids)
+	require.Equal(suite.T(), 2, limiter.calls)
+	client.AssertExpectations(suite.T())
+	// Without end, streaming stops once a page does not contain new trades
+	client = NewMockKrakenSpotRESTClient()
+	client.On("GetRecentTrades", mock.Anything, params, mock.MatchedBy(tradesSince(0))).
+		Return(newTradesResponse(101, newTrade(1, 100), newTrade(2, 101)), nil, nil).Once()
+	client.On("GetRecentTrades", mock.Anything, params, mock.MatchedBy(tradesSince(101))).
+		Return(newTradesResponse(101, newTrade(2, 101)), nil, nil).Once()
+	trades, errs = StreamRecentTrades(context.Background(), client, params, nil, 0)
+	ids = []int64{}
+	for trade := range trades {
+		ids = append(ids, trade.Id)
+	}
+	require.NoError(suite.T(), <-errs)
+	require.Equal(suite.T(), []int64{1, 2}, ids)
+	client.AssertExpectations(suite.T())
+}
+
+// Test StreamRecentTrades errors and cancellation.
+//
+// Test will ensure:
+//   - Requests rejected because the rate limit has been exceeded are retried.
+//   - Other API errors and exhausted retries stop streaming and are published on the error channel.
+//   - Streaming stops when the context is canceled and the context error is published.
+func (suite *RecentTradesHistoryTestSuite) TestStreamRecentTradesErrors() {
+	params := market.GetRecentTradesRequestParameters{Pair: "XXBTZUSD"}
+	limited := &market.GetRecentTradesResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{TooManyRequestsError}}}
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetRecentTrades", mock.Anything, params, mock.Anything).Return(limited, nil, nil).Once()
+	client.On("GetRecentTrades", mock.Anything, params, mock.Anything).
+		Return(newTradesResponse(101, newTrade(1, 100), newTrade(2, 101)), nil, nil).Once()
+	trades, errs := StreamRecentTrades(context.Background(), client, params, &RecentTradesStreamOptions{RetryDelay: time.Millisecond, End: time.Unix(100, 0)}, 1)
+	require.Equal(suite.T(), int64(1), (<-trades).Id)
+	require.NoError(suite.T(), <-errs)
+	client.AssertExpectations(suite.T())
+	// Retries disabled
+	client = NewMockKrakenSpotRESTClient()
+	client.On("GetRecentTrades", mock.Anything, params, mock.Anything).Return(limited, nil, nil).Once()
+	trades, errs = StreamRecentTrades(context.Background(), client, params, &RecentTradesStreamOptions{MaxRetries: -1}, 0)
+	for range trades {
+		suite.FailNow("no trade must be streamed")
+	}
+	require.ErrorContains(suite.T(), <-errs, "get recent trades failed")
+	client.AssertExpectations(suite.T())
+	// Cancellation
+	client = NewMockKrakenSpotRESTClient()
+	client.On("GetRecentTrades", mock.Anything, params, mock.Anything).
+		Return(newTradesResponse(101, newTrade(1, 100), newTrade(2, 101)), nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	trades, errs = StreamRecentTrades(ctx, client, params, nil, 0)
+	require.Equal(suite.T(), int64(1), (<-trades).Id)
+	cancel()
+	for range trades {
+		// Drain trades which may have been sent before the cancellation was noticed
+	}
+	require.ErrorIs(suite.T(), <-errs, context.Canceled)
+}
+
+// Test NewWebsocketTradeEntry.
+//
+// Test will ensure:
+//   - The trade is converted into the typed trade published by the websocket trade channel.
+//   - Invalid prices are rejected.
+func (suite *RecentTradesHistoryTestSuite) TestNewWebsocketTradeEntry() {
+	entry, err := NewWebsocketTradeEntry(market.Trade{
+		Price:     "30000.1",
+		Volume:    "0.5",
+		Timestamp: time.Unix(1700000000, 123456789),
+		Side:      "b",
+		Type:      "l",
+		Id:        42,
+	})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), messages.TradeEntry{
+		Price:     30000.1,
+		Volume:    0.5,
+		Time:      time.Unix(1700000000, 123456789).UTC(),
+		Side:      messages.Buy,
+		OrderType: messages.Limit,
+	}, entry)
+	_, err = NewWebsocketTradeEntry(market.Trade{Price: "bad", Volume: "0.5", Timestamp: time.Unix(1700000000, 0)})
+	require.Error(suite.T(), err)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Match GetRecentTrades options with the provided since cursor.
+func tradesSince(since int64) func(opts *market.GetRecentTradesRequestOptions) bool {
+	return func(opts *market.GetRecentTradesRequestOptions) bool {
+		return opts.Since == since
+	}
+}
+
+// Build a trade with the provided ID and unix timestamp.
+func newTrade(id int64, ts int64) market.Trade {
+	return market.Trade{Price: "30000.0", Volume: "0.1", Timestamp: time.Unix(ts, 0), Side: "s", Type: "m", Id: id}
+}
+
+// Build a GetRecentTrades response for XXBTZUSD.
+func newTradesResponse(last int64, trades ...market.Trade) *market.GetRecentTradesResponse {
+	return &market.GetRecentTradesResponse{Result: &market.RecentTrades{Last: last, PairId: "XXBTZUSD", Trades: trades}}
+}
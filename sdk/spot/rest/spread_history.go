@@ -0,0 +1,172 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+/*****************************************************************************/
+/* SPREAD HISTORY: FUNCTIONS                                                 */
+/*****************************************************************************/
+
+// # Description
+//
+// Stream the recent spreads of a pair. Pages are fetched with GetRecentSpreads in a background
+// goroutine and their spreads are yielded on the returned channel, oldest first. Each page is
+// fetched with the Last value of the previous page as since cursor: spreads which overlap
+// between two pages are yielded only once. Streaming stops once a page does not contain any new
+// spread.
+//
+// Spreads are deduplicated by value: two identical spreads (same timestamp, bid and ask) which
+// are returned for the same second are yielded only once.
+//
+// Use NewWebsocketSpread to convert the yielded spreads into the messages published by the
+// websocket spread channel, for example to seed spread analytics before the live feed starts.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. Streaming stops when the context is done.
+//   - client: REST client used to fetch pages. Must not be nil.
+//   - params: GetRecentSpreads request parameters.
+//   - opts: GetRecentSpreads request options. The Since of the options is the cursor used to fetch the first page. Can be nil.
+//   - bufferSize: Capacity of the spreads channel. Use 0 for an unbuffered channel.
+//
+// # Return
+//
+// A channel where spreads are yielded and a channel where at most one error is published once
+// streaming has stopped. The spreads channel is closed when streaming stops and the error channel
+// is closed right after. No error is published if all spreads have been streamed. The context
+// error is published if the context is done before all spreads have been streamed.
+func StreamRecentSpreads(
+	ctx context.Context,
+	client KrakenSpotRESTClientIface,
+	params market.GetRecentSpreadsRequestParameters,
+	opts *market.GetRecentSpreadsRequestOptions,
+	bufferSize int) (<-chan market.Spread, <-chan error) {
+	// Copy options so since can be updated without modifying the user's options
+	reqopts := market.GetRecentSpreadsRequestOptions{}
+	if opts != nil {
+		reqopts = *opts
+	}
+	out := make(chan market.Spread, bufferSize)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(out)
+		err := streamRecentSpreads(ctx, client, params, reqopts, func(spread market.Spread) bool {
+			select {
+			case out <- spread:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return out, errs
+}
+
+// # Description
+//
+// Convert a spread returned by GetRecentSpreads into the message published by the websocket
+// spread channel. The typed entry of the message is populated.
+//
+// The REST API does not provide the volumes of the best bid and ask: they are set to 0.
+//
+// # Inputs
+//
+//   - pair: Websocket pair name (ex: XBT/USD).
+//   - spread: Spread returned by GetRecentSpreads.
+//
+// # Return
+//
+// The spread message or an error if the prices cannot be parsed.
+func NewWebsocketSpread(pair string, spread market.Spread) (messages.Spread, error) {
+	msg := messages.Spread{
+		Name: string(messages.ChannelSpread),
+		Pair: pair,
+		Data: messages.SpreadData{
+			BestBidPrice:  json.Number(spread.BestBid),
+			BestAskPrice:  json.Number(spread.BestAsk),
+			Timestamp:     json.Number(strconv.FormatInt(spread.Timestamp, 10)),
+			BestBidVolume: "0",
+			BestAskVolume: "0",
+		},
+	}
+	entry, err := msg.Data.Entry()
+	if err != nil {
+		return messages.Spread{}, fmt.Errorf("failed to convert spread of %s: %w", pair, err)
+	}
+	msg.Entry = entry
+	return msg, nil
+}
+
+// # Description
+//
+// Fetch pages with the since cursor until a page does not contain any new spread.
+//
+// # Inputs
+//
+//   - ctx: Context used for coordination purpose.
+//   - client: REST client used to fetch pages.
+//   - params: GetRecentSpreads request parameters.
+//   - opts: GetRecentSpreads request options used to fetch the first page.
+//   - yield: Function used to yield a new spread. Must return false if the context is done.
+//
+// # Return
+//
+// An error if a page could not be fetched or if the context is done before all spreads have been
+// yielded.
+func streamRecentSpreads(
+	ctx context.Context,
+	client KrakenSpotRESTClientIface,
+	params market.GetRecentSpreadsRequestParameters,
+	opts market.GetRecentSpreadsRequestOptions,
+	yield func(spread market.Spread) bool) error {
+	// Timestamp of the newest yielded spread and spreads yielded for this timestamp
+	var newest int64
+	seen := map[market.Spread]struct{}{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		resp, _, err := client.GetRecentSpreads(ctx, params, &opts)
+		if err != nil {
+			return fmt.Errorf("get recent spreads failed: %w", err)
+		}
+		if len(resp.Error) > 0 || resp.Result == nil {
+			return fmt.Errorf("get recent spreads failed: %v", resp.Error)
+		}
+		yielded := 0
+		for _, spread := range resp.Result.Spreads {
+			// Skip spreads which have already been yielded with the previous pages
+			if spread.Timestamp < newest {
+				continue
+			}
+			if spread.Timestamp > newest {
+				newest = spread.Timestamp
+				seen = map[market.Spread]struct{}{}
+			}
+			if _, ok := seen[spread]; ok {
+				continue
+			}
+			seen[spread] = struct{}{}
+			if !yield(spread) {
+				return ctx.Err()
+			}
+			yielded++
+		}
+		// Stop when the page does not contain new spreads or when the cursor does not move
+		if yielded == 0 || resp.Result.Last == opts.Since {
+			return nil
+		}
+		opts.Since = resp.Result.Last
+	}
+}
@@ -0,0 +1,127 @@
+package rest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for StreamRecentSpreads
+type SpreadHistoryTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestSpreadHistoryTestSuite(t *testing.T) {
+	suite.Run(t, new(SpreadHistoryTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test StreamRecentSpreads.
+//
+// Test will ensure:
+//   - Pages are fetched with the Last value of the previous page as since cursor.
+//   - Spreads which overlap between pages are yielded only once, oldest first.
+//   - Streaming stops once a page does not contain new spreads.
+//   - The user's options are not modified.
+func (suite *SpreadHistoryTestSuite) TestStreamRecentSpreads() {
+	params := market.GetRecentSpreadsRequestParameters{Pair: "XXBTZUSD"}
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetRecentSpreads", mock.Anything, params, mock.MatchedBy(spreadsSince(50))).
+		Return(newSpreadsResponse(102, newSpread(100, "1"), newSpread(101, "2"), newSpread(101, "3"), newSpread(102, "4")), nil, nil).Once()
+	client.On("GetRecentSpreads", mock.Anything, params, mock.MatchedBy(spreadsSince(102))).
+		Return(newSpreadsResponse(103, newSpread(102, "4"), newSpread(102, "5"), newSpread(103, "6")), nil, nil).Once()
+	client.On("GetRecentSpreads", mock.Anything, params, mock.MatchedBy(spreadsSince(103))).
+		Return(newSpreadsResponse(103, newSpread(103, "6")), nil, nil).Once()
+	opts := &market.GetRecentSpreadsRequestOptions{Since: 50}
+	spreads, errs := StreamRecentSpreads(context.Background(), client, params, opts, 0)
+	bids := []string{}
+	for s := range spreads {
+		bids = append(bids, s.BestBid)
+	}
+	require.NoError(suite.T(), <-errs)
+	require.Equal(suite.T(), []string{"1", "2", "3", "4", "5", "6"}, bids)
+	require.Equal(suite.T(), int64(50), opts.Since)
+	client.AssertExpectations(suite.T())
+}
+
+// Test StreamRecentSpreads errors and cancellation.
+//
+// Test will ensure:
+//   - An error response from the API stops streaming and is published on the error channel.
+//   - Streaming stops when the context is canceled and the context error is published.
+func (suite *SpreadHistoryTestSuite) TestStreamRecentSpreadsErrors() {
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetRecentSpreads", mock.Anything, mock.Anything, mock.Anything).
+		Return(&market.GetRecentSpreadsResponse{}, nil, nil).Once()
+	spreads, errs := StreamRecentSpreads(context.Background(), client, market.GetRecentSpreadsRequestParameters{Pair: "XXBTZUSD"}, nil, 0)
+	for range spreads {
+		suite.FailNow("no spread must be streamed")
+	}
+	require.ErrorContains(suite.T(), <-errs, "get recent spreads failed")
+	// Cancellation
+	client.On("GetRecentSpreads", mock.Anything, mock.Anything, mock.Anything).
+		Return(newSpreadsResponse(101, newSpread(100, "1"), newSpread(101, "2")), nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	spreads, errs = StreamRecentSpreads(ctx, client, market.GetRecentSpreadsRequestParameters{Pair: "XXBTZUSD"}, nil, 0)
+	require.Equal(suite.T(), "1", (<-spreads).BestBid)
+	cancel()
+	for range spreads {
+		// Drain spreads which may have been sent before the cancellation was noticed
+	}
+	require.ErrorIs(suite.T(), <-errs, context.Canceled)
+}
+
+// Test NewWebsocketSpread.
+//
+// Test will ensure:
+//   - The spread is converted into a websocket spread message with a typed entry.
+//   - Invalid prices are rejected.
+func (suite *SpreadHistoryTestSuite) TestNewWebsocketSpread() {
+	msg, err := NewWebsocketSpread("XBT/USD", market.Spread{Timestamp: 1700000000, BestBid: "30000.1", BestAsk: "30000.2"})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), string(messages.ChannelSpread), msg.Name)
+	require.Equal(suite.T(), "XBT/USD", msg.Pair)
+	require.Equal(suite.T(), messages.SpreadEntry{
+		BestBidPrice: 30000.1,
+		BestAskPrice: 30000.2,
+		Time:         time.Unix(1700000000, 0).UTC(),
+	}, msg.Entry)
+	_, err = NewWebsocketSpread("XBT/USD", market.Spread{Timestamp: 1700000000, BestBid: "bad", BestAsk: "30000.2"})
+	require.Error(suite.T(), err)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Match GetRecentSpreads options with the provided since cursor.
+func spreadsSince(since int64) func(opts *market.GetRecentSpreadsRequestOptions) bool {
+	return func(opts *market.GetRecentSpreadsRequestOptions) bool {
+		return opts.Since == since
+	}
+}
+
+// Build a spread with the provided timestamp and best bid.
+func newSpread(ts int64, bid string) market.Spread {
+	return market.Spread{Timestamp: ts, BestBid: bid, BestAsk: "30000.0"}
+}
+
+// Build a GetRecentSpreads response for XXBTZUSD.
+func newSpreadsResponse(last int64, spreads ...market.Spread) *market.GetRecentSpreadsResponse {
+	return &market.GetRecentSpreadsResponse{Result: &market.SpreadData{Last: last, PairId: "XXBTZUSD", Spreads: spreads}}
+}
@@ -0,0 +1,9 @@
+package staking
+
+import "github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+
+// Response for ListRecentStakingTransactions
+type ListRecentStakingTransactionsResponse struct {
+	common.KrakenSpotRESTResponse
+	Result []StakingTransaction `json:"result,omitempty"`
+}
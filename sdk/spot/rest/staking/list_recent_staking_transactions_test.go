@@ -0,0 +1,87 @@
+package staking
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for ListRecentStakingTransactions DTO.
+//
+// The test suite ensures all DTO can be marshalled/unmarshalled to/from JSON payloads used by the
+// Kraken Spot REST API.
+type ListRecentStakingTransactionsTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestListRecentStakingTransactionsTestSuite(t *testing.T) {
+	suite.Run(t, new(ListRecentStakingTransactionsTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the JSON unmarshaller of ListRecentStakingTransactionsResponse.
+//
+// The test will ensure:
+//   - A valid JSON response from the API can be unmarshalled into the corresponding ListRecentStakingTransactionsResponse struct.
+func (suite *ListRecentStakingTransactionsTestSuite) TestListRecentStakingTransactionsResponseUnmarshalJSON() {
+	// Test settings, expectations, ...
+	payload := `{
+		"error": [],
+		"result": [
+			{
+				"method": "ada-staked",
+				"aclass": "currency",
+				"asset": "ADA.S",
+				"refid": "RUSB7W6-ESIXUX-K6PVTM",
+				"amount": "0.34844300",
+				"fee": "0.00000000",
+				"time": 1688967367,
+				"status": "Initial",
+				"type": "bonding"
+			},
+			{
+				"method": "xtz-staked",
+				"aclass": "currency",
+				"asset": "XTZ.S",
+				"refid": "RUCXX7O-6MWQBO-CQPGAX",
+				"amount": "0.00746900",
+				"fee": "0.00000000",
+				"time": 1688967367,
+				"status": "Initial",
+				"type": "bonding",
+				"bond_start": 1688967367,
+				"bond_end": 1689572167
+			}
+		]
+	}`
+	// Unmarshal payload into struct
+	response := new(ListRecentStakingTransactionsResponse)
+	err := json.Unmarshal([]byte(payload), response)
+	require.NoError(suite.T(), err)
+	// Check data
+	require.Empty(suite.T(), response.Error)
+	require.NotNil(suite.T(), response.Result)
+	require.Len(suite.T(), response.Result, 2)
+	require.Equal(suite.T(), "ada-staked", response.Result[0].Method)
+	require.Equal(suite.T(), "currency", response.Result[0].AssetClass)
+	require.Equal(suite.T(), "ADA.S", response.Result[0].Asset)
+	require.Equal(suite.T(), "RUSB7W6-ESIXUX-K6PVTM", response.Result[0].ReferenceId)
+	require.Equal(suite.T(), "0.34844300", response.Result[0].Amount)
+	require.Equal(suite.T(), "0.00000000", response.Result[0].Fee)
+	require.Equal(suite.T(), int64(1688967367), response.Result[0].Timestamp)
+	require.Equal(suite.T(), string(StatusInitial), response.Result[0].Status)
+	require.Equal(suite.T(), string(TransactionBonding), response.Result[0].Type)
+	require.Zero(suite.T(), response.Result[0].BondStart)
+	require.Equal(suite.T(), int64(1688967367), response.Result[1].BondStart)
+	require.Equal(suite.T(), int64(1689572167), response.Result[1].BondEnd)
+}
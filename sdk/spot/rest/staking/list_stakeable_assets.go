@@ -0,0 +1,69 @@
+package staking
+
+import "github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+
+// Reward earned while staking.
+type StakingReward struct {
+	// Reward earned while staking.
+	Reward string `json:"reward"`
+	// Reward type. Value: percentage
+	Type string `json:"type"`
+}
+
+// Minimum amounts for staking and unstaking.
+type StakingMinimumAmount struct {
+	// Minimum amount for staking.
+	Staking string `json:"staking"`
+	// Minimum amount for unstaking.
+	Unstaking string `json:"unstaking"`
+}
+
+// Lock period and percentage.
+type StakingLockPeriod struct {
+	// Days the funds are locked.
+	Days float64 `json:"days"`
+	// Percentage of the funds that are locked (0 - 100).
+	Percentage float64 `json:"percentage"`
+}
+
+// Lock periods applied to staked funds.
+type StakingLock struct {
+	// Optional lock periods applied when unstaking.
+	Unstaking []StakingLockPeriod `json:"unstaking,omitempty"`
+	// Optional lock periods applied when staking.
+	Staking []StakingLockPeriod `json:"staking,omitempty"`
+	// Optional lockup periods.
+	Lockup []StakingLockPeriod `json:"lockup,omitempty"`
+}
+
+// Stakeable asset.
+type StakeableAsset struct {
+	// Unique ID of the staking option (used in Stake operations).
+	Method string `json:"method"`
+	// Asset code/name.
+	Asset string `json:"asset"`
+	// Staking asset code/name.
+	StakingAsset string `json:"staking_asset"`
+	// Describes the rewards earned while staking.
+	Rewards StakingReward `json:"rewards"`
+	// Whether the staking operation is on-chain or not.
+	OnChain bool `json:"on_chain"`
+	// Whether the user will be able to stake this asset.
+	CanStake bool `json:"can_stake"`
+	// Whether the user will be able to unstake this asset.
+	CanUnstake bool `json:"can_unstake"`
+	// Minimum amounts for staking/unstaking.
+	MinimumAmount *StakingMinimumAmount `json:"minimum_amount,omitempty"`
+	// Optional lock periods applied to staked funds.
+	Lock *StakingLock `json:"lock,omitempty"`
+	// Whether the staking option is enabled for the user.
+	EnabledForUser bool `json:"enabled_for_user"`
+	// Whether the staking option is disabled.
+	Disabled bool `json:"disabled"`
+}
+
+// Response for ListStakeableAssets
+type ListStakeableAssetsResponse struct {
+	common.KrakenSpotRESTResponse
+	Result []StakeableAsset `json:"result,omitempty"`
+}
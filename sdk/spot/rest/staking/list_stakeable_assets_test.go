@@ -0,0 +1,119 @@
+package staking
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for ListStakeableAssets DTO.
+//
+// The test suite ensures all DTO can be marshalled/unmarshalled to/from JSON payloads used by the
+// Kraken Spot REST API.
+type ListStakeableAssetsTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestListStakeableAssetsTestSuite(t *testing.T) {
+	suite.Run(t, new(ListStakeableAssetsTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the JSON unmarshaller of ListStakeableAssetsResponse.
+//
+// The test will ensure:
+//   - A valid JSON response from the API can be unmarshalled into the corresponding ListStakeableAssetsResponse struct.
+func (suite *ListStakeableAssetsTestSuite) TestListStakeableAssetsResponseUnmarshalJSON() {
+	// Test settings, expectations, ...
+	payload := `{
+		"error": [],
+		"result": [
+			{
+				"method": "polkadot-staked",
+				"asset": "DOT",
+				"staking_asset": "DOT.S",
+				"rewards": {
+					"reward": "12.00",
+					"type": "percentage"
+				},
+				"on_chain": true,
+				"can_stake": true,
+				"can_unstake": true,
+				"minimum_amount": {
+					"staking": "0.0000000000",
+					"unstaking": "0.0000000000"
+				},
+				"lock": {
+					"unstaking": [
+						{
+							"days": 28,
+							"percentage": 100
+						}
+					]
+				},
+				"enabled_for_user": true,
+				"disabled": false
+			},
+			{
+				"method": "kusama-staked",
+				"asset": "KSM",
+				"staking_asset": "KSM.S",
+				"rewards": {
+					"reward": "12.00",
+					"type": "percentage"
+				},
+				"on_chain": true,
+				"can_stake": true,
+				"can_unstake": true,
+				"minimum_amount": {
+					"staking": "0.0000000000",
+					"unstaking": "0.0000000000"
+				},
+				"lock": {
+					"unstaking": [
+						{
+							"days": 7,
+							"percentage": 100
+						}
+					]
+				},
+				"enabled_for_user": true,
+				"disabled": false
+			}
+		]
+	}`
+	// Unmarshal payload into struct
+	response := new(ListStakeableAssetsResponse)
+	err := json.Unmarshal([]byte(payload), response)
+	require.NoError(suite.T(), err)
+	// Check data
+	require.Empty(suite.T(), response.Error)
+	require.NotNil(suite.T(), response.Result)
+	require.Len(suite.T(), response.Result, 2)
+	require.Equal(suite.T(), "polkadot-staked", response.Result[0].Method)
+	require.Equal(suite.T(), "DOT", response.Result[0].Asset)
+	require.Equal(suite.T(), "DOT.S", response.Result[0].StakingAsset)
+	require.Equal(suite.T(), "12.00", response.Result[0].Rewards.Reward)
+	require.Equal(suite.T(), "percentage", response.Result[0].Rewards.Type)
+	require.True(suite.T(), response.Result[0].OnChain)
+	require.True(suite.T(), response.Result[0].CanStake)
+	require.True(suite.T(), response.Result[0].CanUnstake)
+	require.NotNil(suite.T(), response.Result[0].MinimumAmount)
+	require.Equal(suite.T(), "0.0000000000", response.Result[0].MinimumAmount.Staking)
+	require.NotNil(suite.T(), response.Result[0].Lock)
+	require.Len(suite.T(), response.Result[0].Lock.Unstaking, 1)
+	require.Equal(suite.T(), float64(28), response.Result[0].Lock.Unstaking[0].Days)
+	require.Equal(suite.T(), float64(100), response.Result[0].Lock.Unstaking[0].Percentage)
+	require.True(suite.T(), response.Result[0].EnabledForUser)
+	require.False(suite.T(), response.Result[0].Disabled)
+}
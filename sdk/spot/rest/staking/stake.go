@@ -0,0 +1,26 @@
+package staking
+
+import "github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+
+// Request parameters for Stake
+type StakeRequestParameters struct {
+	// Asset to stake (asset ID or altname).
+	Asset string `json:"asset"`
+	// Amount of the asset to stake.
+	Amount string `json:"amount"`
+	// Name of the staking option to use (refer to ListStakeableAssets for the correct method names
+	// for each asset).
+	Method string `json:"method"`
+}
+
+// Result for Stake
+type StakeResult struct {
+	// Reference ID of the staking transaction.
+	ReferenceId string `json:"refid"`
+}
+
+// Response for Stake
+type StakeResponse struct {
+	common.KrakenSpotRESTResponse
+	Result *StakeResult `json:"result,omitempty"`
+}
@@ -0,0 +1,52 @@
+package staking
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for Stake DTO.
+//
+// The test suite ensures all DTO can be marshalled/unmarshalled to/from JSON payloads used by the
+// Kraken Spot REST API.
+type StakeTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestStakeTestSuite(t *testing.T) {
+	suite.Run(t, new(StakeTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the JSON unmarshaller of StakeResponse.
+//
+// The test will ensure:
+//   - A valid JSON response from the API can be unmarshalled into the corresponding StakeResponse struct.
+func (suite *StakeTestSuite) TestStakeResponseUnmarshalJSON() {
+	// Test settings, expectations, ...
+	payload := `{
+		"error": [],
+		"result": {
+			"refid": "BOG5AE5-KSCNR4-VPNPEV"
+		}
+	}`
+	// Unmarshal payload into struct
+	response := new(StakeResponse)
+	err := json.Unmarshal([]byte(payload), response)
+	require.NoError(suite.T(), err)
+	// Check data
+	require.Empty(suite.T(), response.Error)
+	require.NotNil(suite.T(), response.Result)
+	require.Equal(suite.T(), "BOG5AE5-KSCNR4-VPNPEV", response.Result.ReferenceId)
+}
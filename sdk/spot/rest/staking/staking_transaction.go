@@ -0,0 +1,49 @@
+package staking
+
+// Enum for staking transaction types
+type StakingTransactionTypeEnum string
+
+// Values for StakingTransactionTypeEnum
+const (
+	TransactionBonding   StakingTransactionTypeEnum = "bonding"
+	TransactionReward    StakingTransactionTypeEnum = "reward"
+	TransactionUnbonding StakingTransactionTypeEnum = "unbonding"
+)
+
+// Enum for staking transaction status
+type StakingTransactionStatusEnum string
+
+// Values for StakingTransactionStatusEnum
+const (
+	StatusInitial StakingTransactionStatusEnum = "Initial"
+	StatusPending StakingTransactionStatusEnum = "Pending"
+	StatusSettled StakingTransactionStatusEnum = "Settled"
+	StatusSuccess StakingTransactionStatusEnum = "Success"
+	StatusFailure StakingTransactionStatusEnum = "Failure"
+)
+
+// Staking transaction
+type StakingTransaction struct {
+	// Staking method as described by ListStakeableAssets.
+	Method string `json:"method"`
+	// Asset class.
+	AssetClass string `json:"aclass"`
+	// Asset code/name.
+	Asset string `json:"asset"`
+	// Staking transaction reference ID.
+	ReferenceId string `json:"refid"`
+	// Transaction amount.
+	Amount string `json:"amount"`
+	// Transaction fee.
+	Fee string `json:"fee"`
+	// Unix timestamp when the transaction was initiated.
+	Timestamp int64 `json:"time"`
+	// Transaction status. Cf. StakingTransactionStatusEnum.
+	Status string `json:"status"`
+	// Transaction type. Cf. StakingTransactionTypeEnum.
+	Type string `json:"type"`
+	// Unix timestamp from the start of bond period (applicable only to bonding transactions).
+	BondStart int64 `json:"bond_start,omitempty"`
+	// Unix timestamp of the end of bond period (applicable only to bonding transactions).
+	BondEnd int64 `json:"bond_end,omitempty"`
+}
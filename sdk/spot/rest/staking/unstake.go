@@ -0,0 +1,23 @@
+package staking
+
+import "github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+
+// Request parameters for Unstake
+type UnstakeRequestParameters struct {
+	// Asset to unstake (asset ID or altname). Must be a valid staking asset (e.g. XBT.M, XTZ.S, ADA.S).
+	Asset string `json:"asset"`
+	// Amount of the asset to unstake.
+	Amount string `json:"amount"`
+}
+
+// Result for Unstake
+type UnstakeResult struct {
+	// Reference ID of the unstaking transaction.
+	ReferenceId string `json:"refid"`
+}
+
+// Response for Unstake
+type UnstakeResponse struct {
+	common.KrakenSpotRESTResponse
+	Result *UnstakeResult `json:"result,omitempty"`
+}
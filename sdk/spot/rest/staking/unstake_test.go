@@ -0,0 +1,52 @@
+package staking
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for Unstake DTO.
+//
+// The test suite ensures all DTO can be marshalled/unmarshalled to/from JSON payloads used by the
+// Kraken Spot REST API.
+type UnstakeTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestUnstakeTestSuite(t *testing.T) {
+	suite.Run(t, new(UnstakeTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the JSON unmarshaller of UnstakeResponse.
+//
+// The test will ensure:
+//   - A valid JSON response from the API can be unmarshalled into the corresponding UnstakeResponse struct.
+func (suite *UnstakeTestSuite) TestUnstakeResponseUnmarshalJSON() {
+	// Test settings, expectations, ...
+	payload := `{
+		"error": [],
+		"result": {
+			"refid": "BOG5AE5-KSCNR4-VPNPEV"
+		}
+	}`
+	// Unmarshal payload into struct
+	response := new(UnstakeResponse)
+	err := json.Unmarshal([]byte(payload), response)
+	require.NoError(suite.T(), err)
+	// Check data
+	require.Empty(suite.T(), response.Error)
+	require.NotNil(suite.T(), response.Result)
+	require.Equal(suite.T(), "BOG5AE5-KSCNR4-VPNPEV", response.Result.ReferenceId)
+}
@@ -0,0 +1,63 @@
+package trading
+
+import (
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+)
+
+// AmendOrder request parameters.
+//
+// Unlike EditOrder, AmendOrder changes the order in place: the order ID and the queue priority
+// are kept when possible. Only the data set in the request are changed.
+type AmendOrderRequestParameters struct {
+	// Kraken order ID. Either the order ID or the client order ID must be set.
+	TxId string `json:"txid,omitempty"`
+	// Client order ID. Either the order ID or the client order ID must be set.
+	ClientOrderId string `json:"cl_ord_id,omitempty"`
+}
+
+// AmendOrder request options.
+type AmendOrderRequestOptions struct {
+	// New order quantity in terms of the base asset.
+	//
+	// An empty value means data must not be changed.
+	OrderQuantity string `json:"order_qty,omitempty"`
+	// New visible quantity for iceberg orders.
+	//
+	// An empty value means data must not be changed.
+	DisplayQuantity string `json:"display_qty,omitempty"`
+	// New limit price. Can be a relative price (Cf. RelativePrice).
+	//
+	// An empty value means data must not be changed.
+	LimitPrice string `json:"limit_price,omitempty"`
+	// New trigger price for triggered orders. Can be a relative price (Cf. RelativePrice).
+	//
+	// An empty value means data must not be changed.
+	TriggerPrice string `json:"trigger_price,omitempty"`
+	// Reject the amend if the new limit price would make the order take liquidity.
+	//
+	// Defaults to false.
+	PostOnly bool `json:"post_only,omitempty"`
+	// RFC3339 timestamp (e.g. 2021-04-01T00:18:45Z) after which the matching
+	// engine should reject the amend request, in presence of latency or
+	// order queueing. min now() + 2 seconds, max now() + 60 seconds.
+	//
+	// The deadline is sent in UTC and validated before the request is sent.
+	// Use DeadlineIn to get a deadline from a delay.
+	//
+	// A zero value means no deadline.
+	Deadline time.Time `json:"deadline,omitempty"`
+}
+
+// AmendOrder result.
+type AmendOrderResult struct {
+	// Amend ID. Cf. GetOrderAmends.
+	AmendId string `json:"amend_id"`
+}
+
+// AmendOrder response.
+type AmendOrderResponse struct {
+	common.KrakenSpotRESTResponse
+	Result *AmendOrderResult `json:"result,omitempty"`
+}
@@ -0,0 +1,52 @@
+package trading
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for AmendOrder DTO.
+//
+// The test suite ensures all DTO can be marshalled/unmarshalled to/from JSON payloads used by the
+// Kraken Spot REST API.
+type AmendOrderTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestAmendOrderTestSuite(t *testing.T) {
+	suite.Run(t, new(AmendOrderTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the JSON unmarshaller of AmendOrderResponse.
+//
+// The test will ensure:
+//   - A valid JSON response from the API can be unmarshalled into the corresponding AmendOrderResponse struct.
+func (suite *AmendOrderTestSuite) TestAmendOrderUnmarshalJSON() {
+	// Test settings, expectations, ...
+	payload := `{
+		"error": [],
+		"result": {
+		  "amend_id": "TTW6PD-RC36L-ZZSWNU"
+		}
+	}`
+	// Unmarshal payload into struct
+	response := new(AmendOrderResponse)
+	err := json.Unmarshal([]byte(payload), response)
+	require.NoError(suite.T(), err)
+	// Check data
+	require.Empty(suite.T(), response.Error)
+	require.NotNil(suite.T(), response.Result)
+	require.Equal(suite.T(), "TTW6PD-RC36L-ZZSWNU", response.Result.AmendId)
+}
@@ -0,0 +1,144 @@
+package trading
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Static regex used to match absolute prices (ex: 27500.5).
+var matchAbsolutePriceRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// Static regex used to match relative prices (ex: +10, -5%, #2.5).
+//
+// A relative price is prefixed by +, - or # and can be suffixed by % to express the offset as a
+// percentage of the last traded price.
+var matchRelativePriceRegex = regexp.MustCompile(`^[+\-#][0-9]+(\.[0-9]+)?%?$`)
+
+// Conditional close order types which are accepted by Kraken API.
+var allowedCloseOrderTypes = map[OrderTypeEnum]bool{
+	Limit:             true,
+	StopLoss:          true,
+	TakeProfit:        true,
+	StopLossLimit:     true,
+	TakeProfitLimit:   true,
+	TrailingStop:      true,
+	TrailingStopLimit: true,
+}
+
+// Conditional close order types which require a secondary price.
+var closeOrderTypesWithPrice2 = map[OrderTypeEnum]bool{
+	StopLossLimit:     true,
+	TakeProfitLimit:   true,
+	TrailingStopLimit: true,
+}
+
+// Builder for conditional close orders.
+//
+// The builder can be used to set the close order of both REST and websocket AddOrder requests
+// (cf. Order.SetConditionalClose). The builder validates the close order type and price(s) when
+// Build or Validate is called:
+//
+//   - The order type must be one of: limit, stop-loss, take-profit, stop-loss-limit,
+//     take-profit-limit, trailing-stop or trailing-stop-limit.
+//   - A price is always required. Price can be absolute (ex: "27500") or relative (ex: "+5%",
+//     "-10", "#2").
+//   - A secondary price is required for stop-loss-limit, take-profit-limit and
+//     trailing-stop-limit orders and must not be set for other order types.
+//   - For trailing-stop and trailing-stop-limit orders, price must be a relative price with a
+//     plus prefix (ex: "+5%") and price2 must be a relative price with a plus or minus prefix.
+type ConditionalClose struct {
+	// Close order type
+	orderType OrderTypeEnum
+	// Close order price
+	price string
+	// Close order secondary price
+	price2 string
+}
+
+// Create a new ConditionalClose builder for the provided close order type.
+func NewConditionalClose(orderType OrderTypeEnum) *ConditionalClose {
+	return &ConditionalClose{orderType: orderType}
+}
+
+// Set the close order price and return the builder.
+func (cc *ConditionalClose) WithPrice(price string) *ConditionalClose {
+	cc.price = price
+	return cc
+}
+
+// Set the close order secondary price and return the builder.
+func (cc *ConditionalClose) WithPrice2(price2 string) *ConditionalClose {
+	cc.price2 = price2
+	return cc
+}
+
+// Validate the conditional close order. An error which describes the issue is returned if the
+// close order is invalid.
+func (cc *ConditionalClose) Validate() error {
+	// Check order type
+	if !allowedCloseOrderTypes[cc.orderType] {
+		return fmt.Errorf("order type %q cannot be used for a conditional close order", cc.orderType)
+	}
+	// Check price
+	if cc.price == "" {
+		return fmt.Errorf("a price is required for a %s conditional close order", cc.orderType)
+	}
+	trailing := cc.orderType == TrailingStop || cc.orderType == TrailingStopLimit
+	if trailing {
+		if !matchRelativePriceRegex.MatchString(cc.price) || cc.price[0] != '+' {
+			return fmt.Errorf("price for a %s conditional close order must be a relative price with a + prefix. Got %q", cc.orderType, cc.price)
+		}
+	} else if !isValidPrice(cc.price) {
+		return fmt.Errorf("invalid price for conditional close order: %q", cc.price)
+	}
+	// Check price2
+	if closeOrderTypesWithPrice2[cc.orderType] {
+		if cc.price2 == "" {
+			return fmt.Errorf("a secondary price is required for a %s conditional close order", cc.orderType)
+		}
+		if trailing {
+			if !matchRelativePriceRegex.MatchString(cc.price2) || cc.price2[0] == '#' {
+				return fmt.Errorf("secondary price for a %s conditional close order must be a relative price with a + or - prefix. Got %q", cc.orderType, cc.price2)
+			}
+		} else if !isValidPrice(cc.price2) {
+			return fmt.Errorf("invalid secondary price for conditional close order: %q", cc.price2)
+		}
+	} else if cc.price2 != "" {
+		return fmt.Errorf("a secondary price cannot be used for a %s conditional close order", cc.orderType)
+	}
+	return nil
+}
+
+// Validate the conditional close order and build the corresponding CloseOrder.
+func (cc *ConditionalClose) Build() (*CloseOrder, error) {
+	if err := cc.Validate(); err != nil {
+		return nil, err
+	}
+	return &CloseOrder{
+		OrderType: string(cc.orderType),
+		Price:     cc.price,
+		Price2:    cc.price2,
+	}, nil
+}
+
+// Validate and set the provided conditional close as the order close order.
+//
+// The order close order is left unchanged if the provided conditional close is invalid. A nil
+// value removes the close order.
+func (o *Order) SetConditionalClose(cc *ConditionalClose) error {
+	if cc == nil {
+		o.Close = nil
+		return nil
+	}
+	close, err := cc.Build()
+	if err != nil {
+		return err
+	}
+	o.Close = close
+	return nil
+}
+
+// Returns true if the provided price is either a valid absolute price or a valid relative price.
+func isValidPrice(price string) bool {
+	return matchAbsolutePriceRegex.MatchString(price) || matchRelativePriceRegex.MatchString(price)
+}
@@ -0,0 +1,86 @@
+package trading
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for ConditionalClose builder.
+type ConditionalCloseTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestConditionalCloseTestSuite(t *testing.T) {
+	suite.Run(t, new(ConditionalCloseTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test Validate with valid conditional close orders.
+//
+// The test will ensure no error is returned for valid combinations of order type and prices.
+func (suite *ConditionalCloseTestSuite) TestValidateValid() {
+	valids := []*ConditionalClose{
+		NewConditionalClose(Limit).WithPrice("27500.5"),
+		NewConditionalClose(Limit).WithPrice("+5%"),
+		NewConditionalClose(StopLoss).WithPrice("#10"),
+		NewConditionalClose(TakeProfit).WithPrice("-2.5"),
+		NewConditionalClose(StopLossLimit).WithPrice("-5%").WithPrice2("#1"),
+		NewConditionalClose(TakeProfitLimit).WithPrice("30000").WithPrice2("29990"),
+		NewConditionalClose(TrailingStop).WithPrice("+1%"),
+		NewConditionalClose(TrailingStopLimit).WithPrice("+100").WithPrice2("-10"),
+	}
+	for _, cc := range valids {
+		require.NoError(suite.T(), cc.Validate(), cc)
+	}
+}
+
+// Test Validate with invalid conditional close orders.
+//
+// The test will ensure an error is returned for invalid order types, missing/unexpected prices
+// and malformed prices.
+func (suite *ConditionalCloseTestSuite) TestValidateInvalid() {
+	invalids := []*ConditionalClose{
+		NewConditionalClose(Market).WithPrice("1"),
+		NewConditionalClose(SettlePosition).WithPrice("1"),
+		NewConditionalClose(Limit),
+		NewConditionalClose(Limit).WithPrice("abc"),
+		NewConditionalClose(Limit).WithPrice("10%"),
+		NewConditionalClose(Limit).WithPrice("10").WithPrice2("11"),
+		NewConditionalClose(StopLossLimit).WithPrice("10"),
+		NewConditionalClose(StopLossLimit).WithPrice("10").WithPrice2("*5"),
+		NewConditionalClose(TrailingStop).WithPrice("100"),
+		NewConditionalClose(TrailingStop).WithPrice("-1%"),
+		NewConditionalClose(TrailingStopLimit).WithPrice("+1%").WithPrice2("#5"),
+	}
+	for _, cc := range invalids {
+		require.Error(suite.T(), cc.Validate(), cc)
+	}
+}
+
+// Test Build and Order.SetConditionalClose.
+//
+// The test will ensure the built CloseOrder contains the provided data, that an invalid
+// conditional close leaves the order unchanged and that nil removes the close order.
+func (suite *ConditionalCloseTestSuite) TestSetConditionalClose() {
+	order := &Order{}
+	err := order.SetConditionalClose(NewConditionalClose(StopLossLimit).WithPrice("#5%").WithPrice2("#4%"))
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), &CloseOrder{OrderType: string(StopLossLimit), Price: "#5%", Price2: "#4%"}, order.Close)
+	// Invalid conditional close must leave order unchanged
+	err = order.SetConditionalClose(NewConditionalClose(Market))
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), string(StopLossLimit), order.Close.OrderType)
+	// Nil removes close order
+	require.NoError(suite.T(), order.SetConditionalClose(nil))
+	require.Nil(suite.T(), order.Close)
+}
@@ -0,0 +1,116 @@
+package trading
+
+import (
+	"fmt"
+	"time"
+)
+
+// Bounds of the window allowed by Kraken for the deadline of AddOrder, AddOrderBatch, EditOrder
+// and AmendOrder requests: the deadline must be between now + MinDeadlineDelay and now +
+// MaxDeadlineDelay.
+const (
+	// Minimum delay between now and the deadline.
+	MinDeadlineDelay = 2 * time.Second
+	// Maximum delay between now and the deadline.
+	MaxDeadlineDelay = 60 * time.Second
+)
+
+// # Description
+//
+// Get a deadline which expires after the provided delay, to use as the deadline of order
+// requests (ex: Deadline: DeadlineIn(5*time.Second)).
+//
+// Deadlines are sent with a second precision (Cf. FormatDeadline): the returned deadline is
+// aligned on a whole second so that the sent deadline does not expire before the provided delay,
+// unless this would make the deadline exceed the allowed window.
+//
+// # Inputs
+//
+//   - d: Delay after which the deadline expires. Must be between MinDeadlineDelay and
+//     MaxDeadlineDelay to be accepted by Kraken (Cf. ValidateDeadline).
+//
+// # Return
+//
+// The deadline.
+func DeadlineIn(d time.Duration) time.Time {
+	return deadlineAfter(time.Now(), d)
+}
+
+// Get a deadline which expires the provided delay after now, aligned on a whole second.
+func deadlineAfter(now time.Time, d time.Duration) time.Time {
+	deadline := now.Add(d)
+	truncated := deadline.Truncate(time.Second)
+	if truncated.Equal(deadline) {
+		return deadline
+	}
+	if ceiled := truncated.Add(time.Second); ceiled.Sub(now) <= MaxDeadlineDelay {
+		return ceiled
+	}
+	return truncated
+}
+
+// # Description
+//
+// Format a deadline as expected by the API: a RFC3339 timestamp in UTC (ex:
+// 2021-04-01T00:18:45Z). Sub-second precision is discarded.
+//
+// # Inputs
+//
+//   - deadline: Deadline to format.
+//
+// # Return
+//
+// The formatted deadline or an empty string if the deadline is the zero value.
+func FormatDeadline(deadline time.Time) string {
+	if deadline.IsZero() {
+		return ""
+	}
+	return deadline.UTC().Format(time.RFC3339)
+}
+
+// # Description
+//
+// Validate a deadline against the window allowed by Kraken: once formatted (Cf. FormatDeadline),
+// the deadline must be between now + MinDeadlineDelay and now + MaxDeadlineDelay.
+//
+// # Inputs
+//
+//   - deadline: Deadline to validate. A zero value means no deadline and is valid.
+//   - now: Current time.
+//
+// # Return
+//
+// An error if the deadline is outside the allowed window.
+func ValidateDeadline(deadline time.Time, now time.Time) error {
+	if deadline.IsZero() {
+		return nil
+	}
+	delay := deadline.Truncate(time.Second).Sub(now)
+	if delay < MinDeadlineDelay || delay > MaxDeadlineDelay {
+		return fmt.Errorf("invalid deadline %s: must be between now + %s and now + %s (got now + %s)", FormatDeadline(deadline), MinDeadlineDelay, MaxDeadlineDelay, delay.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// # Description
+//
+// Parse and validate a formatted deadline (Cf. FormatDeadline and ValidateDeadline).
+//
+// # Inputs
+//
+//   - deadline: RFC3339 deadline. An empty string means no deadline and is valid.
+//   - now: Current time.
+//
+// # Return
+//
+// An error if the deadline is not a RFC3339 timestamp or if it is outside the allowed window.
+func ValidateFormattedDeadline(deadline string, now time.Time) error {
+	if deadline == "" {
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, deadline)
+	if err != nil {
+		return fmt.Errorf("invalid deadline %q: %w", deadline, err)
+	}
+	return ValidateDeadline(parsed, now)
+}
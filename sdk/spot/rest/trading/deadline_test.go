@@ -0,0 +1,82 @@
+package trading
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for deadline helpers.
+type DeadlineTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestDeadlineTestSuite(t *testing.T) {
+	suite.Run(t, new(DeadlineTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test deadlines are formatted as RFC3339 timestamps in UTC.
+//
+// Test will ensure:
+//   - Deadlines in other time zones are converted to UTC.
+//   - Sub-second precision is discarded.
+//   - A zero deadline is formatted as an empty string.
+func (suite *DeadlineTestSuite) TestFormatDeadline() {
+	paris := time.FixedZone("CEST", 2*60*60)
+	deadline := time.Date(2021, 4, 1, 2, 18, 45, 500000000, paris)
+	require.Equal(suite.T(), "2021-04-01T00:18:45Z", FormatDeadline(deadline))
+	require.Empty(suite.T(), FormatDeadline(time.Time{}))
+}
+
+// Test deadlines created from a delay.
+//
+// Test will ensure:
+//   - Deadlines are aligned on a whole second without expiring before the delay.
+//   - Deadlines are rounded down when rounding up would exceed the allowed window.
+//   - Deadlines created with a delay within the allowed window are valid.
+func (suite *DeadlineTestSuite) TestDeadlineIn() {
+	now := time.Date(2021, 4, 1, 0, 18, 45, 300000000, time.UTC)
+	require.Equal(suite.T(), time.Date(2021, 4, 1, 0, 18, 48, 0, time.UTC), deadlineAfter(now, 2*time.Second))
+	require.Equal(suite.T(), time.Date(2021, 4, 1, 0, 19, 45, 0, time.UTC), deadlineAfter(now, MaxDeadlineDelay))
+	aligned := now.Truncate(time.Second)
+	require.Equal(suite.T(), aligned.Add(5*time.Second), deadlineAfter(aligned, 5*time.Second))
+	for _, d := range []time.Duration{MinDeadlineDelay, 10 * time.Second, MaxDeadlineDelay} {
+		require.NoError(suite.T(), ValidateDeadline(deadlineAfter(now, d), now))
+	}
+	require.WithinDuration(suite.T(), time.Now().Add(10*time.Second), DeadlineIn(10*time.Second), time.Second)
+}
+
+// Test deadline validation.
+//
+// Test will ensure:
+//   - Deadlines within the allowed window are valid.
+//   - Deadlines too close or too far in the future are invalid.
+//   - Deadlines are validated once formatted (sub-second precision is discarded).
+//   - Zero deadlines and empty formatted deadlines are valid.
+//   - Formatted deadlines which are not RFC3339 timestamps are invalid.
+func (suite *DeadlineTestSuite) TestValidateDeadline() {
+	now := time.Date(2021, 4, 1, 0, 18, 45, 0, time.UTC)
+	require.NoError(suite.T(), ValidateDeadline(time.Time{}, now))
+	require.NoError(suite.T(), ValidateDeadline(now.Add(MinDeadlineDelay), now))
+	require.NoError(suite.T(), ValidateDeadline(now.Add(MaxDeadlineDelay), now))
+	require.Error(suite.T(), ValidateDeadline(now.Add(time.Second), now))
+	require.Error(suite.T(), ValidateDeadline(now.Add(-10*time.Second), now))
+	require.Error(suite.T(), ValidateDeadline(now.Add(MaxDeadlineDelay+time.Second), now))
+	require.Error(suite.T(), ValidateDeadline(now.Add(MinDeadlineDelay+900*time.Millisecond), now.Add(100*time.Millisecond)))
+	require.NoError(suite.T(), ValidateFormattedDeadline("", now))
+	require.NoError(suite.T(), ValidateFormattedDeadline("2021-04-01T00:18:55Z", now))
+	require.NoError(suite.T(), ValidateFormattedDeadline("2021-04-01T02:18:55+02:00", now))
+	require.Error(suite.T(), ValidateFormattedDeadline("2021-04-01T00:20:00Z", now))
+	require.Error(suite.T(), ValidateFormattedDeadline("+10", now))
+}
@@ -93,6 +93,9 @@ type EditOrderRequestOptions struct {
 	// engine should reject  the new order request, in presence of latency or
 	// order queueing. min now() + 2 seconds, max now() + 60 seconds.
 	//
+	// The deadline is sent in UTC and validated before the request is sent.
+	// Use DeadlineIn to get a deadline from a delay.
+	//
 	// A zero value means no deadline.
 	Deadline time.Time `json:"deadline,omitempty"`
 }
@@ -1,5 +1,7 @@
 package trading
 
+import "fmt"
+
 // Enum for sides
 type SideEnum string
 
@@ -44,6 +46,26 @@ const (
 	STPCancelBoth   SelfTradePreventionFlagEnum = "cancel-both"
 )
 
+// Validate a self trade prevention flag. An empty value is valid: it triggers the default
+// behavior (cancel-newest).
+func ValidateSelfTradePreventionFlag(flag string) error {
+	switch SelfTradePreventionFlagEnum(flag) {
+	case "", STPCancelNewest, STPCancelOldest, STPCancelBoth:
+		return nil
+	default:
+		return fmt.Errorf("invalid self trade prevention flag %q: expected one of %s, %s or %s", flag, STPCancelNewest, STPCancelOldest, STPCancelBoth)
+	}
+}
+
+// Get the self trade prevention flag applied by Kraken for the provided flag: an empty value
+// means the default behavior (cancel-newest) applies.
+func AppliedSelfTradePreventionFlag(flag string) SelfTradePreventionFlagEnum {
+	if flag == "" {
+		return STPCancelNewest
+	}
+	return SelfTradePreventionFlagEnum(flag)
+}
+
 // Enum for order flags
 type OrderFlagEnum string
 
@@ -158,7 +180,7 @@ type Order struct {
 	Leverage string `json:"leverage,omitempty"`
 	// If true, order will only reduce a currently open position, not increase it or open a new position.
 	ReduceOnly bool `json:"reduce_only"`
-	// Self trade prevention flag.
+	// Self trade prevention flag. Cf. SelfTradePreventionFlagEnum for values.
 	//
 	// By default cancel-newest behavior is used. An empty value triggers default behavior.
 	StpType string `json:"stp_type,omitempty"`
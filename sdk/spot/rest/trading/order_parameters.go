@@ -0,0 +1,211 @@
+package trading
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Interface for the typed parameters of advanced orders (Cf. TrailingStopParameters,
+// TrailingStopLimitParameters and IcebergParameters).
+//
+// The parameters can be used to set the order of both REST and websocket AddOrder requests (cf.
+// AddOrderRequestParameters.SetOrderParameters).
+type OrderParameters interface {
+	// Validate the parameters. An error which describes the issue is returned if the parameters
+	// are invalid.
+	Validate() error
+	// Validate the parameters and build the corresponding Order. Only the order type, direction,
+	// volume, displayed volume, prices and trigger are set.
+	Build() (*Order, error)
+}
+
+// Parameters of a trailing-stop order: a market order is triggered once the price moves against
+// the position by the trailing offset from the best price reached since the order was placed.
+type TrailingStopParameters struct {
+	// Order direction. Cf. SideEnum
+	Side SideEnum
+	// Order quantity in terms of the base asset. Must be strictly positive.
+	Volume string
+	// Trailing offset. Required: must be a relative price with a + prefix (ex: "+50", "+2%").
+	// Cf. Trailing to build the offset.
+	Offset string
+	// Price signal used to trigger the order. An empty value means "last".
+	Trigger TriggerEnum
+}
+
+// Validate the trailing-stop parameters.
+func (p *TrailingStopParameters) Validate() error {
+	if err := validateSideAndVolume(TrailingStop, p.Side, p.Volume); err != nil {
+		return err
+	}
+	if err := validateTrailingOffset(TrailingStop, p.Offset); err != nil {
+		return err
+	}
+	return validateTrigger(TrailingStop, p.Trigger)
+}
+
+// Validate the trailing-stop parameters and build the corresponding Order.
+func (p *TrailingStopParameters) Build() (*Order, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &Order{
+		OrderType: string(TrailingStop),
+		Type:      string(p.Side),
+		Volume:    p.Volume,
+		Price:     p.Offset,
+		Trigger:   string(p.Trigger),
+	}, nil
+}
+
+// Parameters of a trailing-stop-limit order: a limit order is triggered once the price moves
+// against the position by the trailing offset from the best price reached since the order was
+// placed. The limit price is expressed as an offset from the trigger price.
+type TrailingStopLimitParameters struct {
+	// Order direction. Cf. SideEnum
+	Side SideEnum
+	// Order quantity in terms of the base asset. Must be strictly positive.
+	Volume string
+	// Trailing offset. Required: must be a relative price with a + prefix (ex: "+50", "+2%").
+	// Cf. Trailing to build the offset.
+	Offset string
+	// Offset of the limit price from the trigger price. Required: must be a relative price with
+	// a + or - prefix (ex: "+0" to use the trigger price as limit price, "-1%").
+	LimitOffset string
+	// Price signal used to trigger the order. An empty value means "last".
+	Trigger TriggerEnum
+}
+
+// Validate the trailing-stop-limit parameters.
+func (p *TrailingStopLimitParameters) Validate() error {
+	if err := validateSideAndVolume(TrailingStopLimit, p.Side, p.Volume); err != nil {
+		return err
+	}
+	if err := validateTrailingOffset(TrailingStopLimit, p.Offset); err != nil {
+		return err
+	}
+	if p.LimitOffset == "" {
+		return fmt.Errorf("a limit offset is required for a %s order", TrailingStopLimit)
+	}
+	if !matchRelativePriceRegex.MatchString(p.LimitOffset) || p.LimitOffset[0] == '#' {
+		return fmt.Errorf("limit offset for a %s order must be a relative price with a + or - prefix. Got %q", TrailingStopLimit, p.LimitOffset)
+	}
+	return validateTrigger(TrailingStopLimit, p.Trigger)
+}
+
+// Validate the trailing-stop-limit parameters and build the corresponding Order.
+func (p *TrailingStopLimitParameters) Build() (*Order, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &Order{
+		OrderType: string(TrailingStopLimit),
+		Type:      string(p.Side),
+		Volume:    p.Volume,
+		Price:     p.Offset,
+		Price2:    p.LimitOffset,
+		Trigger:   string(p.Trigger),
+	}, nil
+}
+
+// Parameters of an iceberg order: a limit order of which only the displayed volume is visible in
+// the order book. The rest of the order is hidden, although the full volume can be filled at any
+// time by an order of that size or larger.
+type IcebergParameters struct {
+	// Order direction. Cf. SideEnum
+	Side SideEnum
+	// Total order quantity in terms of the base asset. Must be strictly positive.
+	Volume string
+	// Visible order quantity in terms of the base asset. Required: must be strictly positive and
+	// lower than the volume.
+	DisplayVolume string
+	// Limit price. Required: can be absolute (ex: "27500") or relative (ex: "-1%").
+	Price string
+}
+
+// Validate the iceberg parameters.
+func (p *IcebergParameters) Validate() error {
+	if err := validateSideAndVolume(Limit, p.Side, p.Volume); err != nil {
+		return err
+	}
+	volume, _ := strconv.ParseFloat(p.Volume, 64)
+	display, err := strconv.ParseFloat(p.DisplayVolume, 64)
+	if err != nil || display <= 0 {
+		return fmt.Errorf("display volume for an iceberg order must be strictly positive. Got %q", p.DisplayVolume)
+	}
+	if display >= volume {
+		return fmt.Errorf("display volume for an iceberg order must be lower than volume. Got %q for volume %q", p.DisplayVolume, p.Volume)
+	}
+	if p.Price == "" {
+		return fmt.Errorf("a limit price is required for an iceberg order")
+	}
+	if !isValidPrice(p.Price) {
+		return fmt.Errorf("invalid limit price for iceberg order: %q", p.Price)
+	}
+	return nil
+}
+
+// Validate the iceberg parameters and build the corresponding limit Order.
+func (p *IcebergParameters) Build() (*Order, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &Order{
+		OrderType:       string(Limit),
+		Type:            string(p.Side),
+		Volume:          p.Volume,
+		DisplayedVolume: p.DisplayVolume,
+		Price:           p.Price,
+	}, nil
+}
+
+// Validate and apply the provided order parameters to the order of the request. The order type,
+// direction, volume, displayed volume, prices and trigger are replaced: other order options (user
+// reference, flags, close order, ...) are left unchanged.
+//
+// The order is left unchanged if the provided parameters are invalid.
+func (params *AddOrderRequestParameters) SetOrderParameters(p OrderParameters) error {
+	order, err := p.Build()
+	if err != nil {
+		return err
+	}
+	params.Order.OrderType = order.OrderType
+	params.Order.Type = order.Type
+	params.Order.Volume = order.Volume
+	params.Order.DisplayedVolume = order.DisplayedVolume
+	params.Order.Price = order.Price
+	params.Order.Price2 = order.Price2
+	params.Order.Trigger = order.Trigger
+	return nil
+}
+
+// Check the side and the volume of an order.
+func validateSideAndVolume(orderType OrderTypeEnum, side SideEnum, volume string) error {
+	if side != Buy && side != Sell {
+		return fmt.Errorf("invalid side for a %s order: %q", orderType, side)
+	}
+	v, err := strconv.ParseFloat(volume, 64)
+	if err != nil || v <= 0 {
+		return fmt.Errorf("volume for a %s order must be strictly positive. Got %q", orderType, volume)
+	}
+	return nil
+}
+
+// Check the trailing offset of a trailing-stop or trailing-stop-limit order.
+func validateTrailingOffset(orderType OrderTypeEnum, offset string) error {
+	if offset == "" {
+		return fmt.Errorf("a trailing offset is required for a %s order", orderType)
+	}
+	if !matchRelativePriceRegex.MatchString(offset) || offset[0] != '+' {
+		return fmt.Errorf("trailing offset for a %s order must be a relative price with a + prefix. Got %q", orderType, offset)
+	}
+	return nil
+}
+
+// Check the trigger of an order.
+func validateTrigger(orderType OrderTypeEnum, trigger TriggerEnum) error {
+	if trigger != "" && trigger != Last && trigger != Index {
+		return fmt.Errorf("invalid trigger for a %s order: %q", orderType, trigger)
+	}
+	return nil
+}
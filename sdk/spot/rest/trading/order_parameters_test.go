@@ -0,0 +1,109 @@
+package trading
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for the advanced order parameters.
+type OrderParametersTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestOrderParametersTestSuite(t *testing.T) {
+	suite.Run(t, new(OrderParametersTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test Validate with valid and invalid order parameters.
+//
+// The test will ensure no error is returned for valid parameters and that an error is returned
+// for invalid sides, volumes, offsets, display volumes, prices and triggers.
+func (suite *OrderParametersTestSuite) TestValidate() {
+	valids := []OrderParameters{
+		&TrailingStopParameters{Side: Sell, Volume: "1.5", Offset: "+50"},
+		&TrailingStopParameters{Side: Buy, Volume: "1", Offset: "+2%", Trigger: Index},
+		&TrailingStopLimitParameters{Side: Sell, Volume: "1", Offset: "+1%", LimitOffset: "+0"},
+		&TrailingStopLimitParameters{Side: Buy, Volume: "1", Offset: "+100", LimitOffset: "-0.5%", Trigger: Last},
+		&IcebergParameters{Side: Buy, Volume: "10", DisplayVolume: "1", Price: "27500"},
+		&IcebergParameters{Side: Sell, Volume: "10", DisplayVolume: "9.99", Price: "+1%"},
+	}
+	for _, p := range valids {
+		require.NoError(suite.T(), p.Validate(), p)
+	}
+	invalids := []OrderParameters{
+		&TrailingStopParameters{Side: "hold", Volume: "1", Offset: "+50"},
+		&TrailingStopParameters{Side: Sell, Volume: "0", Offset: "+50"},
+		&TrailingStopParameters{Side: Sell, Volume: "abc", Offset: "+50"},
+		&TrailingStopParameters{Side: Sell, Volume: "1"},
+		&TrailingStopParameters{Side: Sell, Volume: "1", Offset: "-50"},
+		&TrailingStopParameters{Side: Sell, Volume: "1", Offset: "27500"},
+		&TrailingStopParameters{Side: Sell, Volume: "1", Offset: "+50", Trigger: "mark"},
+		&TrailingStopLimitParameters{Side: Sell, Volume: "1", Offset: "+1%"},
+		&TrailingStopLimitParameters{Side: Sell, Volume: "1", Offset: "+1%", LimitOffset: "#1"},
+		&TrailingStopLimitParameters{Side: Sell, Volume: "1", Offset: "#1%", LimitOffset: "+1"},
+		&IcebergParameters{Side: Buy, Volume: "10", Price: "27500"},
+		&IcebergParameters{Side: Buy, Volume: "10", DisplayVolume: "0", Price: "27500"},
+		&IcebergParameters{Side: Buy, Volume: "10", DisplayVolume: "10", Price: "27500"},
+		&IcebergParameters{Side: Buy, Volume: "10", DisplayVolume: "1"},
+		&IcebergParameters{Side: Buy, Volume: "10", DisplayVolume: "1", Price: "abc"},
+	}
+	for _, p := range invalids {
+		require.Error(suite.T(), p.Validate(), p)
+	}
+}
+
+// Test Build and AddOrderRequestParameters.SetOrderParameters.
+//
+// The test will ensure the built orders contain the provided data, that other order options are
+// kept and that invalid parameters leave the order unchanged.
+func (suite *OrderParametersTestSuite) TestSetOrderParameters() {
+	userref := int64(42)
+	params := &AddOrderRequestParameters{Pair: "XXBTZUSD", Order: Order{UserReference: &userref, OrderFlags: string(OFlagFeeInQuote)}}
+	trailing, err := Trailing(2, true)
+	require.NoError(suite.T(), err)
+	limitOffset, err := FromPercent(-0.5)
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), params.SetOrderParameters(&TrailingStopLimitParameters{
+		Side:        Sell,
+		Volume:      "1",
+		Offset:      trailing.String(),
+		LimitOffset: limitOffset.String(),
+		Trigger:     Index,
+	}))
+	require.Equal(suite.T(), Order{
+		UserReference: &userref,
+		OrderType:     string(TrailingStopLimit),
+		Type:          string(Sell),
+		Volume:        "1",
+		Price:         "+2%",
+		Price2:        "-0.5%",
+		Trigger:       string(Index),
+		OrderFlags:    string(OFlagFeeInQuote),
+	}, params.Order)
+	// Iceberg order replaces prices and trigger
+	require.NoError(suite.T(), params.SetOrderParameters(&IcebergParameters{Side: Buy, Volume: "10", DisplayVolume: "2", Price: "27500"}))
+	require.Equal(suite.T(), Order{
+		UserReference:   &userref,
+		OrderType:       string(Limit),
+		Type:            string(Buy),
+		Volume:          "10",
+		DisplayedVolume: "2",
+		Price:           "27500",
+		OrderFlags:      string(OFlagFeeInQuote),
+	}, params.Order)
+	// Invalid parameters must leave the order unchanged
+	expected := params.Order
+	require.Error(suite.T(), params.SetOrderParameters(&TrailingStopParameters{Side: Sell, Volume: "1"}))
+	require.Equal(suite.T(), expected, params.Order)
+}
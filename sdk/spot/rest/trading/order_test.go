@@ -0,0 +1,40 @@
+package trading
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for order helpers.
+type OrderTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestOrderTestSuite(t *testing.T) {
+	suite.Run(t, new(OrderTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test self trade prevention flag helpers.
+//
+// The test will ensure:
+//   - Empty and known flags are valid and unknown flags are rejected.
+//   - The applied flag is cancel-newest when no flag is provided.
+func (suite *OrderTestSuite) TestSelfTradePreventionFlag() {
+	for _, flag := range []string{"", string(STPCancelNewest), string(STPCancelOldest), string(STPCancelBoth)} {
+		require.NoError(suite.T(), ValidateSelfTradePreventionFlag(flag))
+	}
+	require.ErrorContains(suite.T(), ValidateSelfTradePreventionFlag("cancel-all"), "cancel-all")
+	require.Equal(suite.T(), STPCancelNewest, AppliedSelfTradePreventionFlag(""))
+	require.Equal(suite.T(), STPCancelBoth, AppliedSelfTradePreventionFlag(string(STPCancelBoth)))
+}
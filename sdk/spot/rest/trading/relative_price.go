@@ -0,0 +1,177 @@
+package trading
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Enum for relative price prefixes.
+type RelativePricePrefixEnum string
+
+// Values for RelativePricePrefixEnum
+const (
+	// Add the offset to the last traded price.
+	Plus RelativePricePrefixEnum = "+"
+	// Subtract the offset from the last traded price.
+	Minus RelativePricePrefixEnum = "-"
+	// Add or subtract the offset to/from the last traded price depending on the direction and
+	// order type used.
+	Directional RelativePricePrefixEnum = "#"
+)
+
+// A price expressed as an offset relative to the last traded price (ex: "+10", "-5%", "#2").
+//
+// Use FromDelta, FromPercent, FromDirectionalOffset or Trailing to create a RelativePrice and
+// String to get the value expected by the API for the price and price2 fields of AddOrder and
+// EditOrder requests.
+type RelativePrice struct {
+	// Relative price prefix.
+	prefix RelativePricePrefixEnum
+	// Absolute value of the offset.
+	offset string
+	// True if the offset is a percentage of the last traded price.
+	percent bool
+}
+
+// Create a RelativePrice which adds (positive delta) or subtracts (negative delta) the provided
+// amount to/from the last traded price. An error is returned if the delta is zero, NaN or infinite.
+func FromDelta(delta float64) (RelativePrice, error) {
+	return newSignedRelativePrice(delta, false)
+}
+
+// Create a RelativePrice which adds (positive percent) or subtracts (negative percent) the
+// provided percentage of the last traded price to/from the last traded price. An error is
+// returned if the percentage is zero, NaN or infinite.
+//
+// Example: FromPercent(-5) produces "-5%".
+func FromPercent(percent float64) (RelativePrice, error) {
+	return newSignedRelativePrice(percent, true)
+}
+
+// Create a RelativePrice using the # prefix: Kraken will either add or subtract the offset
+// to/from the last traded price depending on the direction and order type used. The sign of the
+// provided offset is ignored. An error is returned if the offset is zero, NaN or infinite.
+func FromDirectionalOffset(offset float64, percent bool) (RelativePrice, error) {
+	formatted, err := formatOffset(offset)
+	if err != nil {
+		return RelativePrice{}, err
+	}
+	return RelativePrice{prefix: Directional, offset: formatted, percent: percent}, nil
+}
+
+// Create a RelativePrice suitable for the price of trailing-stop and trailing-stop-limit orders.
+// Kraken requires these prices to be relative offsets with a + prefix. The sign of the provided
+// offset is ignored. An error is returned if the offset is zero, NaN or infinite.
+func Trailing(offset float64, percent bool) (RelativePrice, error) {
+	formatted, err := formatOffset(offset)
+	if err != nil {
+		return RelativePrice{}, err
+	}
+	return RelativePrice{prefix: Plus, offset: formatted, percent: percent}, nil
+}
+
+// Parse a RelativePrice from its string representation (ex: "+10", "-5%", "#2.5").
+func ParseRelativePrice(price string) (RelativePrice, error) {
+	if !matchRelativePriceRegex.MatchString(price) {
+		return RelativePrice{}, fmt.Errorf("invalid relative price: %q", price)
+	}
+	if value, _ := strconv.ParseFloat(strings.TrimSuffix(price[1:], "%"), 64); value == 0 {
+		return RelativePrice{}, fmt.Errorf("invalid relative price: %q: offset cannot be zero", price)
+	}
+	rp := RelativePrice{prefix: RelativePricePrefixEnum(price[:1]), offset: price[1:]}
+	if price[len(price)-1] == '%' {
+		rp.percent = true
+		rp.offset = rp.offset[:len(rp.offset)-1]
+	}
+	return rp, nil
+}
+
+// Get the relative price prefix.
+func (rp RelativePrice) Prefix() RelativePricePrefixEnum {
+	return rp.prefix
+}
+
+// Get the absolute value of the offset.
+func (rp RelativePrice) Offset() string {
+	return rp.offset
+}
+
+// Returns true if the offset is a percentage of the last traded price.
+func (rp RelativePrice) IsPercent() bool {
+	return rp.percent
+}
+
+// Format the relative price as expected by the API (ex: "+5%").
+func (rp RelativePrice) String() string {
+	if rp.percent {
+		return string(rp.prefix) + rp.offset + "%"
+	}
+	return string(rp.prefix) + rp.offset
+}
+
+// Create a relative price using the + or - prefix depending on the sign of the provided value.
+func newSignedRelativePrice(value float64, percent bool) (RelativePrice, error) {
+	formatted, err := formatOffset(value)
+	if err != nil {
+		return RelativePrice{}, err
+	}
+	prefix := Plus
+	if value < 0 {
+		prefix = Minus
+	}
+	return RelativePrice{prefix: prefix, offset: formatted, percent: percent}, nil
+}
+
+// Format the absolute value of the provided offset without exponent and trailing zeros. An error
+// is returned if the offset is zero, NaN or infinite as Kraken rejects such relative prices.
+func formatOffset(offset float64) (string, error) {
+	if math.IsNaN(offset) || math.IsInf(offset, 0) {
+		return "", fmt.Errorf("invalid relative price offset: %v: offset must be a finite number", offset)
+	}
+	if offset == 0 {
+		return "", fmt.Errorf("invalid relative price offset: offset cannot be zero")
+	}
+	return strconv.FormatFloat(math.Abs(offset), 'f', -1, 64), nil
+}
+
+// Set the provided relative price as the order price.
+func (o *Order) SetRelativePrice(price RelativePrice) {
+	o.Price = price.String()
+}
+
+// Set the provided relative price as the order secondary price.
+func (o *Order) SetRelativePrice2(price2 RelativePrice) {
+	o.Price2 = price2.String()
+}
+
+// Set the provided relative price as the new order price.
+func (opts *EditOrderRequestOptions) SetRelativePrice(price RelativePrice) {
+	opts.Price = price.String()
+}
+
+// Set the provided relative price as the new order secondary price.
+func (opts *EditOrderRequestOptions) SetRelativePrice2(price2 RelativePrice) {
+	opts.Price2 = price2.String()
+}
+
+// Set the provided relative price as the new limit price of an amended order.
+func (opts *AmendOrderRequestOptions) SetRelativeLimitPrice(price RelativePrice) {
+	opts.LimitPrice = price.String()
+}
+
+// Set the provided relative price as the new trigger price of an amended order.
+func (opts *AmendOrderRequestOptions) SetRelativeTriggerPrice(price RelativePrice) {
+	opts.TriggerPrice = price.String()
+}
+
+// Set the provided relative price as the close order price and return the builder.
+func (cc *ConditionalClose) WithRelativePrice(price RelativePrice) *ConditionalClose {
+	return cc.WithPrice(price.String())
+}
+
+// Set the provided relative price as the close order secondary price and return the builder.
+func (cc *ConditionalClose) WithRelativePrice2(price2 RelativePrice) *ConditionalClose {
+	return cc.WithPrice2(price2.String())
+}
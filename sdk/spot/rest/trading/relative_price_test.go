@@ -0,0 +1,129 @@
+package trading
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for RelativePrice.
+type RelativePriceTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestRelativePriceTestSuite(t *testing.T) {
+	suite.Run(t, new(RelativePriceTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test RelativePrice constructors and String.
+//
+// The test will ensure:
+//   - Constructors produce the string representation expected by the API.
+//   - Constructors reject zero, NaN and infinite offsets.
+func (suite *RelativePriceTestSuite) TestConstructors() {
+	testCases := []struct {
+		name     string
+		build    func() (RelativePrice, error)
+		expected string
+	}{
+		{name: "positive delta", build: func() (RelativePrice, error) { return FromDelta(10) }, expected: "+10"},
+		{name: "negative delta", build: func() (RelativePrice, error) { return FromDelta(-2.5) }, expected: "-2.5"},
+		{name: "small delta", build: func() (RelativePrice, error) { return FromDelta(0.00001) }, expected: "+0.00001"},
+		{name: "positive percent", build: func() (RelativePrice, error) { return FromPercent(5) }, expected: "+5%"},
+		{name: "negative percent", build: func() (RelativePrice, error) { return FromPercent(-0.75) }, expected: "-0.75%"},
+		{name: "directional", build: func() (RelativePrice, error) { return FromDirectionalOffset(-2, false) }, expected: "#2"},
+		{name: "directional percent", build: func() (RelativePrice, error) { return FromDirectionalOffset(1.5, true) }, expected: "#1.5%"},
+		{name: "trailing", build: func() (RelativePrice, error) { return Trailing(-100, false) }, expected: "+100"},
+		{name: "trailing percent", build: func() (RelativePrice, error) { return Trailing(1, true) }, expected: "+1%"},
+		{name: "zero delta", build: func() (RelativePrice, error) { return FromDelta(0) }},
+		{name: "negative zero delta", build: func() (RelativePrice, error) { return FromDelta(math.Copysign(0, -1)) }},
+		{name: "NaN delta", build: func() (RelativePrice, error) { return FromDelta(math.NaN()) }},
+		{name: "infinite delta", build: func() (RelativePrice, error) { return FromDelta(math.Inf(1)) }},
+		{name: "zero percent", build: func() (RelativePrice, error) { return FromPercent(0) }},
+		{name: "NaN percent", build: func() (RelativePrice, error) { return FromPercent(math.NaN()) }},
+		{name: "negative infinite percent", build: func() (RelativePrice, error) { return FromPercent(math.Inf(-1)) }},
+		{name: "zero directional", build: func() (RelativePrice, error) { return FromDirectionalOffset(0, true) }},
+		{name: "infinite directional", build: func() (RelativePrice, error) { return FromDirectionalOffset(math.Inf(1), false) }},
+		{name: "zero trailing", build: func() (RelativePrice, error) { return Trailing(0, false) }},
+		{name: "NaN trailing", build: func() (RelativePrice, error) { return Trailing(math.NaN(), true) }},
+	}
+	for _, tc := range testCases {
+		rp, err := tc.build()
+		if tc.expected == "" {
+			require.Error(suite.T(), err, tc.name)
+			require.Equal(suite.T(), RelativePrice{}, rp, tc.name)
+			continue
+		}
+		require.NoError(suite.T(), err, tc.name)
+		require.Equal(suite.T(), tc.expected, rp.String(), tc.name)
+	}
+}
+
+// Test ParseRelativePrice.
+//
+// The test will ensure valid relative prices are parsed and invalid ones are rejected.
+func (suite *RelativePriceTestSuite) TestParseRelativePrice() {
+	rp, err := ParseRelativePrice("-5.5%")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), Minus, rp.Prefix())
+	require.Equal(suite.T(), "5.5", rp.Offset())
+	require.True(suite.T(), rp.IsPercent())
+	require.Equal(suite.T(), "-5.5%", rp.String())
+	rp, err = ParseRelativePrice("#2")
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), Directional, rp.Prefix())
+	require.False(suite.T(), rp.IsPercent())
+	for _, invalid := range []string{"", "10", "10%", "+", "+%", "*5", "+1.2.3", "+0", "-0.0%", "#0"} {
+		_, err = ParseRelativePrice(invalid)
+		require.Error(suite.T(), err, invalid)
+	}
+}
+
+// Test relative price setters.
+//
+// The test will ensure setters and the ConditionalClose builder accept RelativePrice values.
+func (suite *RelativePriceTestSuite) TestSetters() {
+	order := &Order{}
+	order.SetRelativePrice(suite.mustRelativePrice(FromPercent(-5)))
+	order.SetRelativePrice2(suite.mustRelativePrice(FromDelta(10)))
+	require.Equal(suite.T(), "-5%", order.Price)
+	require.Equal(suite.T(), "+10", order.Price2)
+	opts := &EditOrderRequestOptions{}
+	opts.SetRelativePrice(suite.mustRelativePrice(FromDirectionalOffset(3, true)))
+	opts.SetRelativePrice2(suite.mustRelativePrice(FromDelta(-1)))
+	require.Equal(suite.T(), "#3%", opts.Price)
+	require.Equal(suite.T(), "-1", opts.Price2)
+	amend := &AmendOrderRequestOptions{}
+	amend.SetRelativeLimitPrice(suite.mustRelativePrice(FromPercent(2)))
+	amend.SetRelativeTriggerPrice(suite.mustRelativePrice(FromDelta(-50)))
+	require.Equal(suite.T(), "+2%", amend.LimitPrice)
+	require.Equal(suite.T(), "-50", amend.TriggerPrice)
+	close, err := NewConditionalClose(TrailingStopLimit).
+		WithRelativePrice(suite.mustRelativePrice(Trailing(1, true))).
+		WithRelativePrice2(suite.mustRelativePrice(FromDelta(-5))).
+		Build()
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "+1%", close.Price)
+	require.Equal(suite.T(), "-5", close.Price2)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Fail the test if the relative price could not be created.
+func (suite *RelativePriceTestSuite) mustRelativePrice(rp RelativePrice, err error) RelativePrice {
+	require.NoError(suite.T(), err)
+	return rp
+}
@@ -0,0 +1,208 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/funding"
+)
+
+// Default interval between two GetStatusOfRecentWithdrawals requests made by WaitForTransfer.
+const DefaultWalletTransferPollInterval = 10 * time.Second
+
+// Error returned by WaitForTransfer when the wallet transfer has failed or has been canceled.
+type WalletTransferFailedError struct {
+	// The failed transfer as reported by GetStatusOfRecentWithdrawals
+	Transfer funding.Withdrawal
+}
+
+// Format the error message
+func (e *WalletTransferFailedError) Error() string {
+	return fmt.Sprintf("wallet transfer %s of %s %s failed with status %s (%s)", e.Transfer.ReferenceID, e.Transfer.Amount, e.Transfer.Asset, e.Transfer.Status, e.Transfer.StatusProperty)
+}
+
+// WalletTransferWorkflow chains the requests needed to move funds from the Kraken spot wallet to
+// the Kraken Futures wallet: RequestWalletTransfer to request the transfer and
+// GetStatusOfRecentWithdrawals polling to wait until the transfer is completed.
+type WalletTransferWorkflow struct {
+	// REST client used to send requests
+	client KrakenSpotRESTClientIface
+	// Nonce generator used to sign requests
+	nonceGenerator noncegen.NonceGenerator
+	// Security options used for requests
+	secopts *common.SecurityOptions
+	// Interval between two polls
+	pollInterval time.Duration
+	// Clock used to schedule polls
+	clock clock.Clock
+}
+
+// # Description
+//
+// Factory which creates a new WalletTransferWorkflow.
+//
+// # Inputs
+//
+//   - client: REST client used to send requests. Must not be nil.
+//   - nonceGenerator: Nonce generator used to sign requests. Must not be nil.
+//   - secopts: Optional security options (like password 2FA) to use for requests. Can be nil if 2FA is not used.
+//
+// # Return
+//
+// The new WalletTransferWorkflow or an error if the client or the nonce generator is nil.
+func NewWalletTransferWorkflow(client KrakenSpotRESTClientIface, nonceGenerator noncegen.NonceGenerator, secopts *common.SecurityOptions) (*WalletTransferWorkflow, error) {
+	if client == nil || nonceGenerator == nil {
+		return nil, fmt.Errorf("rest client and nonce generator cannot be nil")
+	}
+	return &WalletTransferWorkflow{
+		client:         client,
+		nonceGenerator: nonceGenerator,
+		secopts:        secopts,
+		pollInterval:   DefaultWalletTransferPollInterval,
+		clock:          clock.NewSystemClock(),
+	}, nil
+}
+
+// Set the interval between two GetStatusOfRecentWithdrawals requests made by WaitForTransfer. If
+// interval is not strictly positive, DefaultWalletTransferPollInterval is used.
+func (w *WalletTransferWorkflow) SetPollInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultWalletTransferPollInterval
+	}
+	w.pollInterval = interval
+}
+
+// Set the clock used to schedule polls. This can be used to provide a clock.FakeClock in tests.
+// If nil, the system clock is used.
+func (w *WalletTransferWorkflow) SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.NewSystemClock()
+	}
+	w.clock = c
+}
+
+// # Description
+//
+// Request a wallet transfer. Use funding.NewRequestWalletTransferRequestParameters to build the
+// parameters from a typed amount.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - params: RequestWalletTransfer request parameters.
+//
+// # Return
+//
+// The reference ID of the transfer or an error if the parameters are invalid, the request failed
+// or the server replied with an error.
+func (w *WalletTransferWorkflow) Transfer(ctx context.Context, params funding.RequestWalletTransferRequestParameters) (string, error) {
+	resp, _, err := w.client.RequestWalletTransfer(ctx, w.nonceGenerator.GenerateNonce(), params, w.secopts)
+	if err != nil {
+		return "", fmt.Errorf("request wallet transfer failed: %w", err)
+	}
+	if len(resp.Error) > 0 {
+		return "", fmt.Errorf("request wallet transfer failed: %v", resp.Error)
+	}
+	if resp.Result == nil || resp.Result.ReferenceID == "" {
+		return "", fmt.Errorf("request wallet transfer failed: no reference ID returned")
+	}
+	return resp.Result.ReferenceID, nil
+}
+
+// # Description
+//
+// Get the status of a wallet transfer with GetStatusOfRecentWithdrawals.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - asset: Transfered asset.
+//   - refid: Reference ID of the transfer (Cf. Transfer).
+//
+// # Return
+//
+// The transfer or nil if it is not listed yet. An error is returned if the request failed or the
+// server replied with an error.
+func (w *WalletTransferWorkflow) Status(ctx context.Context, asset string, refid string) (*funding.Withdrawal, error) {
+	resp, _, err := w.client.GetStatusOfRecentWithdrawals(ctx, w.nonceGenerator.GenerateNonce(), &funding.GetStatusOfRecentWithdrawalsRequestOptions{Asset: asset}, w.secopts)
+	if err != nil {
+		return nil, fmt.Errorf("get status of recent withdrawals failed: %w", err)
+	}
+	if len(resp.Error) > 0 {
+		return nil, fmt.Errorf("get status of recent withdrawals failed: %v", resp.Error)
+	}
+	for _, withdrawal := range resp.Result {
+		if withdrawal.ReferenceID == refid {
+			return &withdrawal, nil
+		}
+	}
+	return nil, nil
+}
+
+// # Description
+//
+// Poll the status of a wallet transfer until it is completed (status Success). Use a context with
+// a deadline or a timeout to limit the time spent waiting.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. Waiting stops when ctx is done.
+//   - asset: Transfered asset.
+//   - refid: Reference ID of the transfer (Cf. Transfer).
+//
+// # Return
+//
+// The completed transfer or an error if:
+//
+//   - A request failed or the server replied with an error.
+//   - The transfer has failed or has been canceled. The error is a WalletTransferFailedError.
+//   - The context is done before the transfer is completed.
+func (w *WalletTransferWorkflow) WaitForTransfer(ctx context.Context, asset string, refid string) (*funding.Withdrawal, error) {
+	for {
+		transfer, err := w.Status(ctx, asset, refid)
+		if err != nil {
+			return nil, err
+		}
+		if transfer != nil {
+			if funding.TransactionStateEnum(transfer.Status) == funding.TxStateFailure || funding.TransactionStatus(transfer.StatusProperty) == funding.TxCanceled {
+				return nil, &WalletTransferFailedError{Transfer: *transfer}
+			}
+			if funding.TransactionStateEnum(transfer.Status) == funding.TxStateSuccess {
+				return transfer, nil
+			}
+		}
+		timer := w.clock.NewTimer(w.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("wait for wallet transfer %s failed: %w", refid, ctx.Err())
+		case <-timer.C():
+		}
+	}
+}
+
+// # Description
+//
+// Request a wallet transfer and wait until it is completed (Cf. Transfer and WaitForTransfer).
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. Waiting stops when ctx is done.
+//   - params: RequestWalletTransfer request parameters.
+//
+// # Return
+//
+// The completed transfer or an error if the transfer could not be requested or if waiting failed
+// (Cf. WaitForTransfer). The reference ID of the transfer is included in the error once the
+// transfer has been requested.
+func (w *WalletTransferWorkflow) TransferAndWait(ctx context.Context, params funding.RequestWalletTransferRequestParameters) (*funding.Withdrawal, error) {
+	refid, err := w.Transfer(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return w.WaitForTransfer(ctx, params.Asset, refid)
+}
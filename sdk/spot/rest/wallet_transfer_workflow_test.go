@@ -0,0 +1,126 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/funding"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for WalletTransferWorkflow
+type WalletTransferWorkflowTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestWalletTransferWorkflowTestSuite(t *testing.T) {
+	suite.Run(t, new(WalletTransferWorkflowTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test WalletTransferWorkflow factory.
+func (suite *WalletTransferWorkflowTestSuite) TestNewWalletTransferWorkflow() {
+	_, err := NewWalletTransferWorkflow(nil, noncegen.NewHFNonceGenerator(), nil)
+	require.Error(suite.T(), err)
+	_, err = NewWalletTransferWorkflow(NewMockKrakenSpotRESTClient(), nil, nil)
+	require.Error(suite.T(), err)
+	workflow, err := NewWalletTransferWorkflow(NewMockKrakenSpotRESTClient(), noncegen.NewHFNonceGenerator(), nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), DefaultWalletTransferPollInterval, workflow.pollInterval)
+}
+
+// Test TransferAndWait.
+//
+// Test will ensure:
+//   - The transfer is requested with the security options and its reference ID is returned.
+//   - The transfer status is polled until the transfer is completed.
+//   - Other withdrawals are ignored.
+func (suite *WalletTransferWorkflowTestSuite) TestTransferAndWait() {
+	secopts := &common.SecurityOptions{SecondFactor: "42"}
+	params := funding.RequestWalletTransferRequestParameters{Asset: "XXBT", From: string(funding.Spot), To: string(funding.Futures), Amount: "0.5"}
+	client := NewMockKrakenSpotRESTClient()
+	client.On("RequestWalletTransfer", mock.Anything, mock.Anything, params, secopts).
+		Return(&funding.RequestWalletTransferResponse{Result: &funding.RequestWalletTransferResult{ReferenceID: "R1"}}, nil, nil).Once()
+	client.On("GetStatusOfRecentWithdrawals", mock.Anything, mock.Anything, &funding.GetStatusOfRecentWithdrawalsRequestOptions{Asset: "XXBT"}, secopts).
+		Return(suite.withdrawals(funding.Withdrawal{ReferenceID: "R0", Status: string(funding.TxStateSuccess)}), nil, nil).Once()
+	client.On("GetStatusOfRecentWithdrawals", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(suite.withdrawals(funding.Withdrawal{ReferenceID: "R1", Status: string(funding.TxStateSuccess)}), nil, nil).Once()
+	fake := clock.NewFakeClock(time.Now())
+	workflow, err := NewWalletTransferWorkflow(client, noncegen.NewHFNonceGenerator(), secopts)
+	require.NoError(suite.T(), err)
+	workflow.SetClock(fake)
+	workflow.SetPollInterval(time.Minute)
+	go func() {
+		fake.BlockUntil(1)
+		fake.Advance(time.Minute)
+	}()
+	transfer, err := workflow.TransferAndWait(context.Background(), params)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "R1", transfer.ReferenceID)
+	client.AssertNumberOfCalls(suite.T(), "GetStatusOfRecentWithdrawals", 2)
+	// API error
+	client.On("RequestWalletTransfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&funding.RequestWalletTransferResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{"EFunding:Insufficient funds"}}}, nil, nil).Once()
+	_, err = workflow.TransferAndWait(context.Background(), params)
+	require.ErrorContains(suite.T(), err, "EFunding:Insufficient funds")
+}
+
+// Test WaitForTransfer.
+//
+// Test will ensure:
+//   - Failed and canceled transfers are reported as WalletTransferFailedError.
+//   - Waiting stops when the context is done.
+func (suite *WalletTransferWorkflowTestSuite) TestWaitForTransfer() {
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetStatusOfRecentWithdrawals", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(suite.withdrawals(funding.Withdrawal{ReferenceID: "R1", Status: string(funding.TxStateFailure)}), nil, nil).Once()
+	client.On("GetStatusOfRecentWithdrawals", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(suite.withdrawals(funding.Withdrawal{ReferenceID: "R1", Status: string(funding.TxStatePending), StatusProperty: string(funding.TxCanceled)}), nil, nil).Once()
+	fake := clock.NewFakeClock(time.Now())
+	workflow, err := NewWalletTransferWorkflow(client, noncegen.NewHFNonceGenerator(), nil)
+	require.NoError(suite.T(), err)
+	workflow.SetClock(fake)
+	for i := 0; i < 2; i++ {
+		_, err = workflow.WaitForTransfer(context.Background(), "XXBT", "R1")
+		wterr := new(WalletTransferFailedError)
+		require.True(suite.T(), errors.As(err, &wterr))
+		require.Equal(suite.T(), "R1", wterr.Transfer.ReferenceID)
+	}
+	// Timeout
+	client.On("GetStatusOfRecentWithdrawals", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(suite.withdrawals(funding.Withdrawal{ReferenceID: "R1", Status: string(funding.TxStatePending)}), nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		fake.BlockUntil(1)
+		cancel()
+	}()
+	_, err = workflow.WaitForTransfer(ctx, "XXBT", "R1")
+	require.ErrorIs(suite.T(), err, context.Canceled)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build a GetStatusOfRecentWithdrawals response with the provided withdrawals.
+func (suite *WalletTransferWorkflowTestSuite) withdrawals(withdrawals ...funding.Withdrawal) *funding.GetStatusOfRecentWithdrawalsResponse {
+	return &funding.GetStatusOfRecentWithdrawalsResponse{
+		KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+		Result:                 withdrawals,
+	}
+}
@@ -0,0 +1,162 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+)
+
+/*****************************************************************************/
+/* WEBSOCKET TOKEN PROVIDER: INTERFACE                                       */
+/*****************************************************************************/
+
+// Interface for components which provide websocket tokens to private websocket clients.
+type WebsocketTokenProviderIface interface {
+	// # Description
+	//
+	// Get a websocket token which remains valid for at least the provided duration. A cached token
+	// is returned if it is still valid long enough, otherwise a new token is fetched.
+	//
+	// # Inputs
+	//
+	//	- ctx: Context used for tracing and coordination purpose.
+	//	- minValidity: Minimum remaining validity of the returned token. Use 0 to get any valid token.
+	//
+	// # Return
+	//
+	// The token, its expiration time and an error if a new token could not be fetched.
+	GetWebsocketToken(ctx context.Context, minValidity time.Duration) (string, time.Time, error)
+}
+
+/*****************************************************************************/
+/* WEBSOCKET TOKEN PROVIDER: MODEL & FACTORY                                 */
+/*****************************************************************************/
+
+// Delay substracted from the token expiration delay to be sure the token is refreshed before it
+// really expires.
+const websocketTokenExpirationMargin = 5 * time.Second
+
+// WebsocketTokenProvider fetches websocket tokens with the REST client and caches them until
+// they expire. The provider is safe for concurrent use and can be shared by multiple private
+// websocket clients: concurrent callers wait for a single GetWebsocketToken request when the
+// cached token must be refreshed.
+type WebsocketTokenProvider struct {
+	// REST client used to get websocket tokens
+	client KrakenSpotRESTClientIface
+	// Nonce generator used to sign GetWebsocketToken requests
+	nonceGenerator noncegen.NonceGenerator
+	// Security options used for GetWebsocketToken requests
+	secopts *common.SecurityOptions
+	// Mutex used to protect the cached token
+	mu sync.Mutex
+	// Cached token
+	token string
+	// Cached token expiration time
+	expiresAt time.Time
+	// Clock used to check the cached token expiration
+	clock clock.Clock
+}
+
+// # Description
+//
+// Factory which creates a new WebsocketTokenProvider.
+//
+// # Inputs
+//
+//   - client: REST client used to get websocket tokens. Must not be nil.
+//   - nonceGenerator: Nonce generator used to sign GetWebsocketToken requests. Must not be nil.
+//   - secopts: Optional security options (like password 2FA) to use when requesting tokens. Can be nil if 2FA is not used.
+//
+// # Return
+//
+// The new WebsocketTokenProvider or an error if the client or the nonce generator is nil.
+func NewWebsocketTokenProvider(client KrakenSpotRESTClientIface, nonceGenerator noncegen.NonceGenerator, secopts *common.SecurityOptions) (*WebsocketTokenProvider, error) {
+	if client == nil || nonceGenerator == nil {
+		return nil, fmt.Errorf("rest client and nonce generator cannot be nil")
+	}
+	return &WebsocketTokenProvider{
+		client:         client,
+		nonceGenerator: nonceGenerator,
+		secopts:        secopts,
+		mu:             sync.Mutex{},
+		token:          "",
+		expiresAt:      time.Time{},
+		clock:          clock.NewSystemClock(),
+	}, nil
+}
+
+/*****************************************************************************/
+/* WEBSOCKET TOKEN PROVIDER: METHODS                                         */
+/*****************************************************************************/
+
+// # Description
+//
+// Get a websocket token which remains valid for at least the provided duration. A cached token
+// is returned if it is still valid long enough, otherwise a new token is fetched with the REST
+// client and cached.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - minValidity: Minimum remaining validity of the returned token. Use 0 to get any valid token.
+//
+// # Return
+//
+// The token, its expiration time and an error if a new token could not be fetched. An error
+// is returned when the request failed or when the server replied with an error.
+func (p *WebsocketTokenProvider) GetWebsocketToken(ctx context.Context, minValidity time.Duration) (string, time.Time, error) {
+	// Acquire mutex
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Return cached token if still valid long enough
+	now := p.clock.Now()
+	if p.token != "" && now.Add(minValidity).Before(p.expiresAt) {
+		return p.token, p.expiresAt, nil
+	}
+	// Fetch a new token
+	resp, _, err := p.client.GetWebsocketToken(ctx, p.nonceGenerator.GenerateNonce(), p.secopts)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("get websocket token failed: %w", err)
+	}
+	if len(resp.Error) > 0 || resp.Result == nil {
+		return "", time.Time{}, fmt.Errorf("get websocket token failed: %v", resp.Error)
+	}
+	// Cache token
+	p.token = resp.Result.Token
+	p.expiresAt = now.Add(time.Duration(resp.Result.Expires)*time.Second - websocketTokenExpirationMargin)
+	return p.token, p.expiresAt, nil
+}
+
+// # Description
+//
+// Set the clock used to check the cached token expiration. This can be used to provide a
+// clock.FakeClock in tests.
+//
+// # Inputs
+//
+//   - c: Clock to use. If nil, the system clock is used (Cf. clock.SystemClock).
+func (p *WebsocketTokenProvider) SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.NewSystemClock()
+	}
+	// Acquire mutex
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clock = c
+}
+
+// # Description
+//
+// Discard the cached token. The next call to GetWebsocketToken will fetch a new token.
+func (p *WebsocketTokenProvider) Invalidate() {
+	// Acquire mutex
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = ""
+	p.expiresAt = time.Time{}
+}
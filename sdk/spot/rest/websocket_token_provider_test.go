@@ -0,0 +1,129 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/websocket"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for WebsocketTokenProvider
+type WebsocketTokenProviderTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestWebsocketTokenProviderTestSuite(t *testing.T) {
+	suite.Run(t, new(WebsocketTokenProviderTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test WebsocketTokenProvider factory.
+func (suite *WebsocketTokenProviderTestSuite) TestNewWebsocketTokenProvider() {
+	_, err := NewWebsocketTokenProvider(nil, noncegen.NewHFNonceGenerator(), nil)
+	require.Error(suite.T(), err)
+	_, err = NewWebsocketTokenProvider(NewMockKrakenSpotRESTClient(), nil, nil)
+	require.Error(suite.T(), err)
+	provider, err := NewWebsocketTokenProvider(NewMockKrakenSpotRESTClient(), noncegen.NewHFNonceGenerator(), nil)
+	require.NoError(suite.T(), err)
+	require.Implements(suite.T(), (*WebsocketTokenProviderIface)(nil), provider)
+}
+
+// Test token caching.
+//
+// Test will ensure:
+//   - The token is fetched once and shared by concurrent callers.
+//   - The provided security options are used.
+//   - A new token is fetched when the cached token is not valid long enough.
+//   - A new token is fetched after the provider has been invalidated.
+func (suite *WebsocketTokenProviderTestSuite) TestGetWebsocketToken() {
+	secopts := &common.SecurityOptions{SecondFactor: "42"}
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetWebsocketToken", mock.Anything, mock.Anything, secopts).
+		Return(NewMockGetWebsocketTokenResponse("token", 900), nil, nil)
+	provider, err := NewWebsocketTokenProvider(client, noncegen.NewHFNonceGenerator(), secopts)
+	require.NoError(suite.T(), err)
+	// Concurrent callers share a single request
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, expiresAt, err := provider.GetWebsocketToken(context.Background(), 0)
+			require.NoError(suite.T(), err)
+			require.Equal(suite.T(), "token", token)
+			require.WithinDuration(suite.T(), time.Now().Add(895*time.Second), expiresAt, 5*time.Second)
+		}()
+	}
+	wg.Wait()
+	client.AssertNumberOfCalls(suite.T(), "GetWebsocketToken", 1)
+	// Cached token is valid for less than 20 minutes
+	_, _, err = provider.GetWebsocketToken(context.Background(), 10*time.Minute)
+	require.NoError(suite.T(), err)
+	client.AssertNumberOfCalls(suite.T(), "GetWebsocketToken", 1)
+	_, _, err = provider.GetWebsocketToken(context.Background(), 20*time.Minute)
+	require.NoError(suite.T(), err)
+	client.AssertNumberOfCalls(suite.T(), "GetWebsocketToken", 2)
+	// Invalidate
+	provider.Invalidate()
+	_, _, err = provider.GetWebsocketToken(context.Background(), 0)
+	require.NoError(suite.T(), err)
+	client.AssertNumberOfCalls(suite.T(), "GetWebsocketToken", 3)
+}
+
+// Test a new token is fetched once the cached token has expired according to the provider clock.
+func (suite *WebsocketTokenProviderTestSuite) TestGetWebsocketTokenExpiry() {
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetWebsocketToken", mock.Anything, mock.Anything, mock.Anything).
+		Return(NewMockGetWebsocketTokenResponse("token", 900), nil, nil)
+	provider, err := NewWebsocketTokenProvider(client, noncegen.NewHFNonceGenerator(), nil)
+	require.NoError(suite.T(), err)
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFakeClock(start)
+	provider.SetClock(fake)
+	_, expiresAt, err := provider.GetWebsocketToken(context.Background(), 0)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), start.Add(895*time.Second), expiresAt)
+	fake.Advance(894 * time.Second)
+	_, _, err = provider.GetWebsocketToken(context.Background(), 0)
+	require.NoError(suite.T(), err)
+	client.AssertNumberOfCalls(suite.T(), "GetWebsocketToken", 1)
+	fake.Advance(time.Second)
+	_, _, err = provider.GetWebsocketToken(context.Background(), 0)
+	require.NoError(suite.T(), err)
+	client.AssertNumberOfCalls(suite.T(), "GetWebsocketToken", 2)
+}
+
+// Test errors are returned and nothing is cached when a token cannot be fetched.
+func (suite *WebsocketTokenProviderTestSuite) TestGetWebsocketTokenErrors() {
+	client := NewMockKrakenSpotRESTClient()
+	client.On("GetWebsocketToken", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, nil, fmt.Errorf("connection refused")).Once()
+	client.On("GetWebsocketToken", mock.Anything, mock.Anything, mock.Anything).
+		Return(&websocket.GetWebsocketTokenResponse{
+			KrakenSpotRESTResponse: *NewMockKrakenSpotRESTErrorResponse("EGeneral:Permission denied"),
+		}, nil, nil).Once()
+	provider, err := NewWebsocketTokenProvider(client, noncegen.NewHFNonceGenerator(), nil)
+	require.NoError(suite.T(), err)
+	_, _, err = provider.GetWebsocketToken(context.Background(), 0)
+	require.Error(suite.T(), err)
+	_, _, err = provider.GetWebsocketToken(context.Background(), 0)
+	require.ErrorContains(suite.T(), err, "EGeneral:Permission denied")
+	client.AssertNumberOfCalls(suite.T(), "GetWebsocketToken", 2)
+}
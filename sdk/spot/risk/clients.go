@@ -0,0 +1,274 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+/*************************************************************************************************/
+/* WEBSOCKET                                                                                     */
+/*************************************************************************************************/
+
+// Private websocket client which checks orders with a Checker before they are sent to Kraken.
+// All other methods are forwarded to the wrapped client.
+type WebsocketClient struct {
+	// Wrapped client
+	websocket.KrakenSpotPrivateWebsocketClientInterface
+	// Checker used to validate orders
+	checker *Checker
+}
+
+// # Description
+//
+// Wrap a private websocket client so orders are checked with the provided checker before being
+// sent with AddOrder and EditOrder.
+//
+// # Inputs
+//
+//   - client: Private websocket client to wrap.
+//   - checker: Checker used to validate orders.
+//
+// # Return
+//
+// The risk-checked client.
+func NewWebsocketClient(client websocket.KrakenSpotPrivateWebsocketClientInterface, checker *Checker) *WebsocketClient {
+	return &WebsocketClient{
+		KrakenSpotPrivateWebsocketClientInterface: client,
+		checker: checker,
+	}
+}
+
+// # Description
+//
+// Check the order with the checker and send it with the wrapped client if it passes all checks.
+// Cf. KrakenSpotPrivateWebsocketClientInterface.AddOrder.
+//
+// # Return
+//
+// A RiskCheckError if the order violates a risk limit. The order is not sent in that case.
+// Otherwise, the response and error returned by the wrapped client.
+func (client *WebsocketClient) AddOrder(ctx context.Context, params websocket.AddOrderRequestParameters) (*messages.AddOrderResponse, error) {
+	order := openOrder{pair: params.Pair, volume: params.Volume, price: params.Price}
+	if err := client.checker.reserve(params.Pair, order); err != nil {
+		return nil, err
+	}
+	resp, err := client.KrakenSpotPrivateWebsocketClientInterface.AddOrder(ctx, params)
+	accepted := map[string]openOrder{}
+	if err == nil && resp != nil && !params.Validate {
+		accepted[resp.TxId] = order
+	}
+	client.checker.release(1, accepted)
+	return resp, err
+}
+
+// # Description
+//
+// Check the edited order with the checker and send the edition with the wrapped client if it
+// passes all checks. The original order is replaced by the new order in the tracked open orders.
+// Cf. KrakenSpotPrivateWebsocketClientInterface.EditOrder.
+//
+// # Return
+//
+// A RiskCheckError if the edited order violates a risk limit. The edition is not sent in that
+// case. Otherwise, the response and error returned by the wrapped client.
+func (client *WebsocketClient) EditOrder(ctx context.Context, params websocket.EditOrderRequestParameters) (*messages.EditOrderResponse, error) {
+	if err := client.checker.CheckEdit(params.Id, params.Pair, params.Volume, params.Price); err != nil {
+		return nil, err
+	}
+	resp, err := client.KrakenSpotPrivateWebsocketClientInterface.EditOrder(ctx, params)
+	if err == nil && resp != nil && !params.Validate && resp.TxId != "" {
+		client.checker.replaceOpenOrder(params.Id, resp.TxId, openOrder{pair: params.Pair, volume: params.Volume, price: params.Price})
+	}
+	return resp, err
+}
+
+// Runtime configuration update of a risk-checked websocket client. Nil fields are left unchanged.
+type ConfigUpdate struct {
+	// Risk limits
+	Limits *Limits
+	// Update of the runtime-tunable parameters of the wrapped client (Cf. websocket.ConfigUpdate)
+	Client websocket.ConfigUpdate
+}
+
+// # Description
+//
+// Update the risk limits and the runtime-tunable parameters of the wrapped client while it runs.
+// The wrapped client is updated first (Cf. websocket.ConfigUpdater): risk limits are not updated
+// if the update of the wrapped client fails.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing purpose.
+//   - update: Parameters to update. Nil fields are left unchanged.
+//
+// # Return
+//
+// An error if the wrapped client cannot be updated at runtime or if its update is invalid.
+func (client *WebsocketClient) UpdateConfig(ctx context.Context, update ConfigUpdate) error {
+	if update.Client != (websocket.ConfigUpdate{}) {
+		updater, ok := client.KrakenSpotPrivateWebsocketClientInterface.(websocket.ConfigUpdater)
+		if !ok {
+			return fmt.Errorf("update config failed: the wrapped client does not support runtime configuration updates")
+		}
+		if err := updater.UpdateConfig(ctx, update.Client); err != nil {
+			return err
+		}
+	}
+	if update.Limits != nil {
+		client.checker.SetLimits(*update.Limits)
+	}
+	return nil
+}
+
+/*************************************************************************************************/
+/* REST                                                                                          */
+/*************************************************************************************************/
+
+// REST client which checks orders with a Checker before they are sent to Kraken. All other
+// methods are forwarded to the wrapped client.
+type RESTClient struct {
+	// Wrapped client
+	rest.KrakenSpotRESTClientIface
+	// Checker used to validate orders
+	checker *Checker
+}
+
+// # Description
+//
+// Wrap a REST client so orders are checked with the provided checker before being sent with
+// AddOrder, AddOrderBatch and EditOrder and amendments are checked before being sent with
+// AmendOrder.
+//
+// # Inputs
+//
+//   - client: REST client to wrap.
+//   - checker: Checker used to validate orders.
+//
+// # Return
+//
+// The risk-checked client.
+func NewRESTClient(client rest.KrakenSpotRESTClientIface, checker *Checker) *RESTClient {
+	return &RESTClient{
+		KrakenSpotRESTClientIface: client,
+		checker:                   checker,
+	}
+}
+
+// # Description
+//
+// Check the order with the checker and send it with the wrapped client if it passes all checks.
+// Cf. KrakenSpotRESTClientIface.AddOrder.
+//
+// # Return
+//
+// A RiskCheckError if the order violates a risk limit. The order is not sent in that case.
+// Otherwise, the values returned by the wrapped client.
+func (client *RESTClient) AddOrder(ctx context.Context, nonce int64, params trading.AddOrderRequestParameters, opts *trading.AddOrderRequestOptions, secopts *common.SecurityOptions) (*trading.AddOrderResponse, *http.Response, error) {
+	order := openOrder{pair: params.Pair, volume: params.Order.Volume, price: params.Order.Price}
+	if err := client.checker.reserve(params.Pair, order); err != nil {
+		return nil, nil, err
+	}
+	resp, httpresp, err := client.KrakenSpotRESTClientIface.AddOrder(ctx, nonce, params, opts, secopts)
+	accepted := map[string]openOrder{}
+	if err == nil && resp != nil && resp.Result != nil && (opts == nil || !opts.Validate) {
+		for _, id := range resp.Result.TransactionIDs {
+			accepted[id] = order
+		}
+	}
+	client.checker.release(1, accepted)
+	return resp, httpresp, err
+}
+
+// # Description
+//
+// Check each order of the batch with the checker and send the batch with the wrapped client if
+// all orders pass all checks. The whole batch is checked against the maximum number of open
+// orders. Cf. KrakenSpotRESTClientIface.AddOrderBatch.
+//
+// # Return
+//
+// A RiskCheckError for the first order which violates a risk limit. The batch is not sent in that
+// case. Otherwise, the values returned by the wrapped client.
+func (client *RESTClient) AddOrderBatch(ctx context.Context, nonce int64, params trading.AddOrderBatchRequestParameters, opts *trading.AddOrderBatchRequestOptions, secopts *common.SecurityOptions) (*trading.AddOrderBatchResponse, *http.Response, error) {
+	orders := make([]openOrder, 0, len(params.Orders))
+	for _, order := range params.Orders {
+		orders = append(orders, openOrder{pair: params.Pair, volume: order.Volume, price: order.Price})
+	}
+	if err := client.checker.reserve(params.Pair, orders...); err != nil {
+		return nil, nil, err
+	}
+	resp, httpresp, err := client.KrakenSpotRESTClientIface.AddOrderBatch(ctx, nonce, params, opts, secopts)
+	accepted := map[string]openOrder{}
+	if err == nil && resp != nil && resp.Result != nil && (opts == nil || !opts.Validate) {
+		for i, entry := range resp.Result.Orders {
+			order := openOrder{pair: params.Pair}
+			if i < len(orders) {
+				order = orders[i]
+			}
+			accepted[entry.Id] = order
+		}
+	}
+	client.checker.release(len(orders), accepted)
+	return resp, httpresp, err
+}
+
+// # Description
+//
+// Check the edited order with the checker and send the edition with the wrapped client if it
+// passes all checks. The original order is replaced by the new order in the tracked open orders.
+// Cf. KrakenSpotRESTClientIface.EditOrder.
+//
+// # Return
+//
+// A RiskCheckError if the edited order violates a risk limit. The edition is not sent in that
+// case. Otherwise, the values returned by the wrapped client.
+func (client *RESTClient) EditOrder(ctx context.Context, nonce int64, params trading.EditOrderRequestParameters, opts *trading.EditOrderRequestOptions, secopts *common.SecurityOptions) (*trading.EditOrderResponse, *http.Response, error) {
+	edited := openOrder{pair: params.Pair}
+	if opts != nil {
+		edited.volume = opts.NewVolume
+		edited.price = opts.Price
+	}
+	if err := client.checker.CheckEdit(params.Id, edited.pair, edited.volume, edited.price); err != nil {
+		return nil, nil, err
+	}
+	resp, httpresp, err := client.KrakenSpotRESTClientIface.EditOrder(ctx, nonce, params, opts, secopts)
+	if err == nil && resp != nil && resp.Result != nil && resp.Result.TransactionID != "" && (opts == nil || !opts.Validate) {
+		client.checker.replaceOpenOrder(params.Id, resp.Result.TransactionID, edited)
+	}
+	return resp, httpresp, err
+}
+
+// # Description
+//
+// Check the new quantity and the new price of the order with the checker and send the amendment
+// with the wrapped client if the amended order passes all checks. Cf.
+// KrakenSpotRESTClientIface.AmendOrder.
+//
+// Orders must be tracked by the checker and identified with their order ID: amendments of
+// unknown orders and amendments which only use a client order ID are rejected.
+//
+// # Return
+//
+// A RiskCheckError if the amended order violates a risk limit. The amendment is not sent in that
+// case. Otherwise, the values returned by the wrapped client.
+func (client *RESTClient) AmendOrder(ctx context.Context, nonce int64, params trading.AmendOrderRequestParameters, opts *trading.AmendOrderRequestOptions, secopts *common.SecurityOptions) (*trading.AmendOrderResponse, *http.Response, error) {
+	amended := openOrder{}
+	if opts != nil {
+		amended = openOrder{volume: opts.OrderQuantity, price: opts.LimitPrice}
+	}
+	if err := client.checker.CheckAmend(params.TxId, amended.volume, amended.price); err != nil {
+		return nil, nil, err
+	}
+	resp, httpresp, err := client.KrakenSpotRESTClientIface.AmendOrder(ctx, nonce, params, opts, secopts)
+	if err == nil && resp != nil && resp.Result != nil {
+		client.checker.addOpenOrders(amended, params.TxId)
+	}
+	return resp, httpresp, err
+}
@@ -0,0 +1,17 @@
+//go:build !goctopus_publiconly
+
+package risk
+
+import (
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the private websocket client can be updated by the risk-checked websocket client.
+func (suite *ClientsUnitTestSuite) TestPrivateWebsocketClientIsConfigUpdater() {
+	require.Implements(suite.T(), (*websocket.ConfigUpdater)(nil), new(websocket.KrakenSpotPrivateWebsocketClient))
+}
@@ -0,0 +1,228 @@
+package risk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/papertrading"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for risk-checked clients
+type ClientsUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestClientsUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(ClientsUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the risk-checked websocket client.
+//
+// Test will ensure:
+//   - Violating orders are rejected and not sent.
+//   - Accepted orders are sent and tracked as open orders.
+//   - Validate-only orders are not tracked.
+//   - Violating editions are rejected and accepted editions replace the tracked order.
+func (suite *ClientsUnitTestSuite) TestWebsocketClient() {
+	checker := NewChecker(Limits{Default: PairLimits{MaxQuantity: 1}, MaxOpenOrders: 1})
+	paper := papertrading.NewKrakenSpotPaperTradingClient(nil, 0, nil)
+	client := NewWebsocketClient(paper, checker)
+	require.Implements(suite.T(), (*websocket.KrakenSpotPrivateWebsocketClientInterface)(nil), client)
+	params := websocket.AddOrderRequestParameters{OrderType: "limit", Type: "buy", Pair: "XBT/USD", Price: "100", Volume: "2"}
+	_, err := client.AddOrder(context.Background(), params)
+	require.ErrorAs(suite.T(), err, new(*RiskCheckError))
+	params.Volume = "0.5"
+	params.Validate = true
+	_, err = client.AddOrder(context.Background(), params)
+	require.NoError(suite.T(), err)
+	require.Zero(suite.T(), checker.OpenOrdersCount())
+	params.Validate = false
+	resp, err := client.AddOrder(context.Background(), params)
+	require.NoError(suite.T(), err)
+	require.NotEmpty(suite.T(), resp.TxId)
+	require.Equal(suite.T(), 1, checker.OpenOrdersCount())
+	_, err = client.AddOrder(context.Background(), params)
+	require.ErrorAs(suite.T(), err, new(*RiskCheckError))
+	// Editions
+	edit := websocket.EditOrderRequestParameters{Id: resp.TxId, Pair: "XBT/USD", Volume: "2"}
+	_, err = client.EditOrder(context.Background(), edit)
+	require.ErrorAs(suite.T(), err, new(*RiskCheckError))
+	edit.Volume = "0.8"
+	edited, err := client.EditOrder(context.Background(), edit)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, checker.OpenOrdersCount())
+	checker.SetLimits(Limits{Default: PairLimits{MaxNotional: 100}})
+	require.Error(suite.T(), checker.CheckEdit(edited.TxId, "XBT/USD", "", "200"))
+	require.NoError(suite.T(), checker.CheckEdit(edited.TxId, "XBT/USD", "", "110"))
+	require.Error(suite.T(), checker.CheckEdit(resp.TxId, "XBT/USD", "", "110"))
+}
+
+// Test UpdateConfig on the risk-checked websocket client.
+//
+// Test will ensure:
+//   - Risk limits are updated.
+//   - Updating the parameters of a wrapped client which cannot be updated fails and leaves the
+//     limits unchanged.
+func (suite *ClientsUnitTestSuite) TestWebsocketClientUpdateConfig() {
+	checker := NewChecker(Limits{Default: PairLimits{MaxQuantity: 1}})
+	client := NewWebsocketClient(papertrading.NewKrakenSpotPaperTradingClient(nil, 0, nil), checker)
+	params := websocket.AddOrderRequestParameters{OrderType: "limit", Type: "buy", Pair: "XBT/USD", Price: "100", Volume: "2", Validate: true}
+	_, err := client.AddOrder(context.Background(), params)
+	require.ErrorAs(suite.T(), err, new(*RiskCheckError))
+	err = client.UpdateConfig(context.Background(), ConfigUpdate{Limits: &Limits{Default: PairLimits{MaxQuantity: 5}}})
+	require.NoError(suite.T(), err)
+	_, err = client.AddOrder(context.Background(), params)
+	require.NoError(suite.T(), err)
+	silent := websocket.LogLevelSilent
+	err = client.UpdateConfig(context.Background(), ConfigUpdate{
+		Limits: &Limits{Default: PairLimits{MaxQuantity: 1}},
+		Client: websocket.ConfigUpdate{LogLevel: &silent},
+	})
+	require.Error(suite.T(), err)
+	_, err = client.AddOrder(context.Background(), params)
+	require.NoError(suite.T(), err)
+}
+
+// Test the risk-checked REST client.
+//
+// Test will ensure:
+//   - Violating orders and batches are rejected and not sent.
+//   - Accepted orders are sent and tracked as open orders.
+//   - Violating amendments and amendments of unknown orders are rejected and not sent.
+//   - Accepted amendments are sent and update the tracked order.
+//   - Batches which would exceed the maximum number of open orders are rejected.
+//   - Violating editions are rejected and accepted editions replace the tracked order.
+func (suite *ClientsUnitTestSuite) TestRESTClient() {
+	checker := NewChecker(Limits{Default: PairLimits{MaxQuantity: 1}, MaxOpenOrders: 5})
+	mockClient := rest.NewMockKrakenSpotRESTClient()
+	mockClient.On("AddOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&trading.AddOrderResponse{
+			KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+			Result:                 &trading.AddOrderResult{TransactionIDs: []string{"O1"}},
+		}, nil, nil)
+	mockClient.On("AddOrderBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&trading.AddOrderBatchResponse{
+			KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+			Result:                 &trading.AddOrderBatchResult{Orders: []trading.AddOrderBatchEntry{{Id: "O2"}, {Id: "O3"}}},
+		}, nil, nil)
+	client := NewRESTClient(mockClient, checker)
+	require.Implements(suite.T(), (*rest.KrakenSpotRESTClientIface)(nil), client)
+	_, _, err := client.AddOrder(context.Background(), 1, trading.AddOrderRequestParameters{
+		Pair:  "XXBTZUSD",
+		Order: trading.Order{OrderType: "market", Type: "buy", Volume: "2"},
+	}, nil, nil)
+	require.ErrorAs(suite.T(), err, new(*RiskCheckError))
+	mockClient.AssertNotCalled(suite.T(), "AddOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	_, _, err = client.AddOrder(context.Background(), 1, trading.AddOrderRequestParameters{
+		Pair:  "XXBTZUSD",
+		Order: trading.Order{OrderType: "market", Type: "buy", Volume: "0.5"},
+	}, nil, nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, checker.OpenOrdersCount())
+	batch := trading.AddOrderBatchRequestParameters{
+		Pair: "XXBTZUSD",
+		Orders: []trading.Order{
+			{OrderType: "market", Type: "buy", Volume: "0.5"},
+			{OrderType: "market", Type: "buy", Volume: "1.5"},
+		},
+	}
+	_, _, err = client.AddOrderBatch(context.Background(), 2, batch, nil, nil)
+	require.ErrorAs(suite.T(), err, new(*RiskCheckError))
+	mockClient.AssertNotCalled(suite.T(), "AddOrderBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	batch.Orders[1].Volume = "1"
+	_, _, err = client.AddOrderBatch(context.Background(), 2, batch, nil, nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 3, checker.OpenOrdersCount())
+	// Amendments
+	mockClient.On("AmendOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&trading.AmendOrderResponse{
+			KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+			Result:                 &trading.AmendOrderResult{AmendId: "A1"},
+		}, nil, nil)
+	_, _, err = client.AmendOrder(context.Background(), 3, trading.AmendOrderRequestParameters{TxId: "O1"}, &trading.AmendOrderRequestOptions{OrderQuantity: "2"}, nil)
+	require.ErrorAs(suite.T(), err, new(*RiskCheckError))
+	_, _, err = client.AmendOrder(context.Background(), 3, trading.AmendOrderRequestParameters{ClientOrderId: "C1"}, &trading.AmendOrderRequestOptions{OrderQuantity: "0.1"}, nil)
+	require.ErrorAs(suite.T(), err, new(*RiskCheckError))
+	mockClient.AssertNotCalled(suite.T(), "AmendOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	_, _, err = client.AmendOrder(context.Background(), 3, trading.AmendOrderRequestParameters{TxId: "O1"}, &trading.AmendOrderRequestOptions{OrderQuantity: "0.8"}, nil)
+	require.NoError(suite.T(), err)
+	mockClient.AssertNumberOfCalls(suite.T(), "AmendOrder", 1)
+	checker.SetLimits(Limits{Default: PairLimits{MaxNotional: 100}})
+	checker.SetLastPrice("XXBTZUSD", 100)
+	require.Error(suite.T(), checker.CheckAmend("O1", "", "200"))
+	require.NoError(suite.T(), checker.CheckAmend("O1", "", "110"))
+	// Batch exceeding the maximum number of open orders
+	checker.SetLimits(Limits{Default: PairLimits{MaxQuantity: 1}, MaxOpenOrders: 4})
+	_, _, err = client.AddOrderBatch(context.Background(), 4, batch, nil, nil)
+	riskErr := new(RiskCheckError)
+	require.ErrorAs(suite.T(), err, &riskErr)
+	require.Equal(suite.T(), CheckMaxOpenOrders, riskErr.Check)
+	mockClient.AssertNumberOfCalls(suite.T(), "AddOrderBatch", 1)
+	// Editions
+	mockClient.On("EditOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&trading.EditOrderResponse{
+			KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+			Result:                 &trading.EditOrderResult{TransactionID: "O4", OriginalTransactionID: "O2"},
+		}, nil, nil)
+	edit := trading.EditOrderRequestParameters{Id: "O2", Pair: "XXBTZUSD"}
+	_, _, err = client.EditOrder(context.Background(), 5, edit, &trading.EditOrderRequestOptions{NewVolume: "2"}, nil)
+	require.ErrorAs(suite.T(), err, new(*RiskCheckError))
+	mockClient.AssertNotCalled(suite.T(), "EditOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	_, _, err = client.EditOrder(context.Background(), 5, edit, &trading.EditOrderRequestOptions{NewVolume: "0.2"}, nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 3, checker.OpenOrdersCount())
+	require.NoError(suite.T(), checker.CheckAmend("O4", "", ""))
+	require.Error(suite.T(), checker.CheckAmend("O2", "", ""))
+}
+
+// Test concurrent orders cannot exceed the maximum number of open orders.
+//
+// Test will ensure:
+//   - Open order slots are reserved while orders are being sent.
+//   - Slots of rejected or failed orders are released.
+func (suite *ClientsUnitTestSuite) TestConcurrentOrders() {
+	checker := NewChecker(Limits{MaxOpenOrders: 2})
+	mockClient := rest.NewMockKrakenSpotRESTClient()
+	unblock := make(chan struct{})
+	mockClient.On("AddOrder", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { <-unblock }).
+		Return(&trading.AddOrderResponse{
+			KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+			Result:                 &trading.AddOrderResult{TransactionIDs: []string{"O1"}},
+		}, nil, nil)
+	client := NewRESTClient(mockClient, checker)
+	params := trading.AddOrderRequestParameters{Pair: "XXBTZUSD", Order: trading.Order{OrderType: "market", Type: "buy", Volume: "1"}}
+	results := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, _, err := client.AddOrder(context.Background(), 1, params, nil, nil)
+			results <- err
+		}()
+	}
+	// Third order is rejected while the first two are being sent
+	riskErr := new(RiskCheckError)
+	require.ErrorAs(suite.T(), <-results, &riskErr)
+	require.Equal(suite.T(), CheckMaxOpenOrders, riskErr.Check)
+	close(unblock)
+	require.NoError(suite.T(), <-results)
+	require.NoError(suite.T(), <-results)
+	// Same transaction ID returned twice
+	require.Equal(suite.T(), 1, checker.OpenOrdersCount())
+	require.NoError(suite.T(), checker.Check("XXBTZUSD", "1", ""))
+}
@@ -0,0 +1,397 @@
+// Package risk provides a client-side pre-trade risk layer which checks orders against
+// configurable limits (order size, notional, price collar, open orders) before they are sent to
+// Kraken with the websocket or REST AddOrder and EditOrder APIs. Amendments of open orders sent
+// with the REST AmendOrder API are checked against the same limits.
+package risk
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// Enum for the risk checks performed by the Checker.
+type RiskCheckEnum string
+
+// Values for RiskCheckEnum
+const (
+	// The order quantity exceeds the maximum quantity per order.
+	CheckMaxQuantity RiskCheckEnum = "max_quantity"
+	// The order notional (quantity * price) exceeds the maximum notional per order.
+	CheckMaxNotional RiskCheckEnum = "max_notional"
+	// The order price deviates too much from the last price.
+	CheckPriceCollar RiskCheckEnum = "price_collar"
+	// The maximum number of open orders has been reached.
+	CheckMaxOpenOrders RiskCheckEnum = "max_open_orders"
+	// The order could not be checked (unparsable volume, no reference price, ...).
+	CheckInvalidOrder RiskCheckEnum = "invalid_order"
+)
+
+// Error returned when an order violates a risk limit. The order has not been sent to Kraken.
+type RiskCheckError struct {
+	// Failed check
+	Check RiskCheckEnum
+	// Pair of the rejected order
+	Pair string
+	// Human readable reason
+	Reason string
+}
+
+// Format the error message
+func (e *RiskCheckError) Error() string {
+	return fmt.Sprintf("order for %s rejected by risk check %s: %s", e.Pair, e.Check, e.Reason)
+}
+
+// Limits which apply to the orders of a pair. Zero values disable the related check.
+type PairLimits struct {
+	// Maximum order quantity, in base currency.
+	MaxQuantity float64
+	// Maximum order notional (quantity * price), in quote currency. The last price is used for
+	// orders without an absolute price (ex: market orders).
+	MaxNotional float64
+	// Maximum relative deviation (ex: 0.05 for 5%) of the order price from the last price. Only
+	// orders with an absolute price are checked.
+	MaxPriceDeviation float64
+}
+
+// Configuration of a Checker.
+type Limits struct {
+	// Limits by pair. Pairs must be named like in the orders: the websocket API (ex: XBT/USD) and
+	// the REST API (ex: XXBTZUSD) do not use the same pair names.
+	Pairs map[string]PairLimits
+	// Limits used for pairs which are not in Pairs.
+	Default PairLimits
+	// Maximum number of open orders. Zero disables the check.
+	MaxOpenOrders int
+}
+
+// # Description
+//
+// Checker which validates orders against risk limits. Checks which need the last price (notional
+// of orders without an absolute price, price collar) reject the order when no price has been
+// provided for the pair: the checker fails closed.
+//
+// Last prices are provided with SetLastPrice or ProcessTicker. Open orders are tracked from the
+// orders accepted by the risk-checked clients (Cf. NewWebsocketClient and NewRESTClient) and from
+// the openOrders websocket feed (ProcessOpenOrders) or the REST GetOpenOrders result
+// (ProcessRESTOpenOrders).
+//
+// The checker is safe for concurrent use.
+type Checker struct {
+	// Mutex used to protect checker state
+	mu sync.Mutex
+	// Limits
+	limits Limits
+	// Last prices by pair
+	lastPrices map[string]float64
+	// Open orders by ID
+	openOrders map[string]openOrder
+	// Number of open order slots reserved by the orders being sent (Cf. reserve)
+	reserved int
+}
+
+// Open order tracked by a Checker. Fields are empty when they are unknown.
+type openOrder struct {
+	// Order pair
+	pair string
+	// Order volume, in base currency
+	volume string
+	// Order price
+	price string
+}
+
+// Update the order with the non-empty values of the provided order.
+func (o *openOrder) merge(update openOrder) {
+	if update.pair != "" {
+		o.pair = update.pair
+	}
+	if update.volume != "" {
+		o.volume = update.volume
+	}
+	if update.price != "" {
+		o.price = update.price
+	}
+}
+
+// # Description
+//
+// Build a new Checker.
+//
+// # Inputs
+//
+//   - limits: Risk limits.
+//
+// # Return
+//
+// A new Checker
+func NewChecker(limits Limits) *Checker {
+	return &Checker{
+		limits:     limits,
+		lastPrices: map[string]float64{},
+		openOrders: map[string]openOrder{},
+	}
+}
+
+// Replace the risk limits.
+func (c *Checker) SetLimits(limits Limits) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limits = limits
+}
+
+// Set the last price of the pair used for notional and price collar checks.
+func (c *Checker) SetLastPrice(pair string, price float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastPrices[pair] = price
+}
+
+// Process a ticker message from the websocket API: the last trade price is used as last price.
+func (c *Checker) ProcessTicker(msg messages.Ticker) error {
+	if len(msg.Data.Close) == 0 {
+		return fmt.Errorf("ticker for %s has no last trade price", msg.Pair)
+	}
+	price, err := msg.Data.GetLastTradePrice().Float64()
+	if err != nil {
+		return fmt.Errorf("failed to parse ticker last trade price: %w", err)
+	}
+	c.SetLastPrice(msg.Pair, price)
+	return nil
+}
+
+// Process an openOrders message from the websocket API: orders are tracked until they are closed,
+// canceled or expired.
+func (c *Checker) ProcessOpenOrders(msg messages.OpenOrders) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, orders := range msg.Orders {
+		for id, order := range orders {
+			switch messages.OrderStatusEnum(order.Status) {
+			case messages.Closed, messages.Canceled, messages.Expired:
+				delete(c.openOrders, id)
+			case messages.Pending, messages.Open:
+				tracked := c.openOrders[id]
+				update := openOrder{volume: order.Volume}
+				if order.Description != nil {
+					update.pair = order.Description.Pair
+					update.price = order.Description.Price
+				}
+				tracked.merge(update)
+				c.openOrders[id] = tracked
+			}
+		}
+	}
+}
+
+// Replace the tracked open orders by the open orders returned by the REST GetOpenOrders endpoint.
+func (c *Checker) ProcessRESTOpenOrders(result *account.GetOpenOrdersResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.openOrders = map[string]openOrder{}
+	if result == nil {
+		return
+	}
+	for id, order := range result.Open {
+		tracked := openOrder{}
+		if order != nil {
+			tracked = openOrder{pair: order.Description.Pair, volume: order.Volume.String(), price: order.Description.Price.String()}
+		}
+		c.openOrders[id] = tracked
+	}
+}
+
+// Get the number of tracked open orders.
+func (c *Checker) OpenOrdersCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.openOrders)
+}
+
+// # Description
+//
+// Check an order against the risk limits.
+//
+// # Inputs
+//
+//   - pair: Order pair.
+//   - volume: Order volume, in base currency.
+//   - price: Order price. Empty or relative prices (+, -, # prefixes or % suffix) are considered
+//     as no absolute price: the last price is used for the notional check.
+//
+// # Return
+//
+// Nil if the order passes all checks, a RiskCheckError otherwise.
+func (c *Checker) Check(pair string, volume string, price string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.check(pair, volume, price, 1)
+}
+
+// # Description
+//
+// Check the amendment of a tracked open order against the risk limits: the new quantity and the
+// new price are checked with the limits of the order pair. The maximum number of open orders is
+// not checked as no order is added.
+//
+// # Inputs
+//
+//   - id: ID of the amended order.
+//   - volume: New order volume, in base currency. Empty if the volume is not amended.
+//   - price: New order price. Empty if the price is not amended.
+//
+// # Return
+//
+// Nil if the amended order passes all checks, a RiskCheckError otherwise. The amendment is
+// rejected when the order is not tracked or when its pair, volume or price is unknown: the
+// checker fails closed.
+func (c *Checker) CheckAmend(id string, volume string, price string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	order, ok := c.openOrders[id]
+	if !ok || order.pair == "" {
+		return &RiskCheckError{Check: CheckInvalidOrder, Pair: order.pair, Reason: fmt.Sprintf("unknown open order %q", id)}
+	}
+	order.merge(openOrder{volume: volume, price: price})
+	return c.check(order.pair, order.volume, order.price, 0)
+}
+
+// # Description
+//
+// Check the edition of an open order against the risk limits: the edited order, which replaces
+// the original order, is checked with its new quantity and its new price. The maximum number of
+// open orders is not checked as the original order is canceled.
+//
+// # Inputs
+//
+//   - id: ID of the edited order.
+//   - pair: Order pair.
+//   - volume: New order volume, in base currency. Empty if the volume is not edited.
+//   - price: New order price. Empty if the price is not edited.
+//
+// # Return
+//
+// Nil if the edited order passes all checks, a RiskCheckError otherwise. The volume and the price
+// of the order must be tracked by the checker when they are not edited: the checker fails closed.
+func (c *Checker) CheckEdit(id string, pair string, volume string, price string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	order := c.openOrders[id]
+	order.merge(openOrder{pair: pair, volume: volume, price: price})
+	return c.check(order.pair, order.volume, order.price, 0)
+}
+
+// Check an order against the risk limits while the mutex is held. count is the number of orders
+// which would be added to the open orders.
+func (c *Checker) check(pair string, volume string, price string, count int) error {
+	limits, ok := c.limits.Pairs[pair]
+	if !ok {
+		limits = c.limits.Default
+	}
+	if open := len(c.openOrders) + c.reserved; count > 0 && c.limits.MaxOpenOrders > 0 && open+count > c.limits.MaxOpenOrders {
+		return &RiskCheckError{Check: CheckMaxOpenOrders, Pair: pair, Reason: fmt.Sprintf("%d open or pending orders, %d new orders, maximum is %d", open, count, c.limits.MaxOpenOrders)}
+	}
+	quantity, err := strconv.ParseFloat(volume, 64)
+	if err != nil {
+		return &RiskCheckError{Check: CheckInvalidOrder, Pair: pair, Reason: fmt.Sprintf("failed to parse volume %q", volume)}
+	}
+	if limits.MaxQuantity > 0 && quantity > limits.MaxQuantity {
+		return &RiskCheckError{Check: CheckMaxQuantity, Pair: pair, Reason: fmt.Sprintf("quantity %g exceeds %g", quantity, limits.MaxQuantity)}
+	}
+	orderPrice, hasPrice := parseAbsolutePrice(price)
+	lastPrice, hasLastPrice := c.lastPrices[pair]
+	if limits.MaxPriceDeviation > 0 && hasPrice {
+		if !hasLastPrice {
+			return &RiskCheckError{Check: CheckInvalidOrder, Pair: pair, Reason: "no last price to check the price collar"}
+		}
+		deviation := math.Abs(orderPrice-lastPrice) / lastPrice
+		if deviation > limits.MaxPriceDeviation {
+			return &RiskCheckError{Check: CheckPriceCollar, Pair: pair, Reason: fmt.Sprintf("price %g deviates by %.2f%% from last price %g", orderPrice, deviation*100, lastPrice)}
+		}
+	}
+	if limits.MaxNotional > 0 {
+		if !hasPrice {
+			if !hasLastPrice {
+				return &RiskCheckError{Check: CheckInvalidOrder, Pair: pair, Reason: "no last price to compute the notional"}
+			}
+			orderPrice = lastPrice
+		}
+		if notional := quantity * orderPrice; notional > limits.MaxNotional {
+			return &RiskCheckError{Check: CheckMaxNotional, Pair: pair, Reason: fmt.Sprintf("notional %g exceeds %g", notional, limits.MaxNotional)}
+		}
+	}
+	return nil
+}
+
+// Check orders against the risk limits and reserve an open order slot for each of them, so
+// concurrent orders cannot exceed the maximum number of open orders. Slots must be released with
+// release once the orders have been sent. No slot is reserved if an order fails the checks.
+func (c *Checker) reserve(pair string, orders ...openOrder) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, order := range orders {
+		if err := c.check(pair, order.volume, order.price, len(orders)); err != nil {
+			return err
+		}
+	}
+	c.reserved += len(orders)
+	return nil
+}
+
+// Release the open order slots reserved with reserve and track the orders accepted by Kraken as
+// open orders.
+func (c *Checker) release(count int, accepted map[string]openOrder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reserved -= count
+	for id, order := range accepted {
+		c.track(id, order)
+	}
+}
+
+// Track orders accepted by Kraken as open orders. The pair, volume and price of the order are
+// merged with the tracked values.
+func (c *Checker) addOpenOrders(order openOrder, ids ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range ids {
+		c.track(id, order)
+	}
+}
+
+// Replace an edited order by the new order. The values of the edited order are merged with the
+// values of the original order.
+func (c *Checker) replaceOpenOrder(id string, newId string, order openOrder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tracked := c.openOrders[id]
+	delete(c.openOrders, id)
+	tracked.merge(order)
+	c.track(newId, tracked)
+}
+
+// Merge the order with the tracked values of the order. Mutex must be held.
+func (c *Checker) track(id string, order openOrder) {
+	if id == "" {
+		return
+	}
+	tracked := c.openOrders[id]
+	tracked.merge(order)
+	c.openOrders[id] = tracked
+}
+
+// Parse an absolute price. False is returned for empty and relative prices.
+func parseAbsolutePrice(price string) (float64, bool) {
+	if price == "" || strings.ContainsAny(price[:1], "+-#") || strings.HasSuffix(price, "%") {
+		return 0, false
+	}
+	p, err := strconv.ParseFloat(price, 64)
+	if err != nil {
+		return 0, false
+	}
+	return p, true
+}
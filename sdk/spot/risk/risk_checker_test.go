@@ -0,0 +1,104 @@
+package risk
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for Checker
+type CheckerUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestCheckerUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(CheckerUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test order checks.
+//
+// Test will ensure:
+//   - Pair limits are used, default limits otherwise.
+//   - Quantity, notional and price collar violations are rejected with a RiskCheckError.
+//   - The last price is used for the notional of orders without an absolute price.
+//   - Orders are rejected when no last price is known and a check needs it.
+func (suite *CheckerUnitTestSuite) TestCheck() {
+	checker := NewChecker(Limits{
+		Pairs: map[string]PairLimits{
+			"XBT/USD": {MaxQuantity: 1, MaxNotional: 50000, MaxPriceDeviation: 0.05},
+		},
+		Default: PairLimits{MaxQuantity: 10},
+	})
+	requireCheck := func(err error, check RiskCheckEnum) {
+		riskErr := new(RiskCheckError)
+		require.ErrorAs(suite.T(), err, &riskErr)
+		require.Equal(suite.T(), check, riskErr.Check)
+	}
+	// No last price
+	requireCheck(checker.Check("XBT/USD", "0.5", ""), CheckInvalidOrder)
+	requireCheck(checker.Check("XBT/USD", "0.5", "40000"), CheckInvalidOrder)
+	checker.SetLastPrice("XBT/USD", 40000)
+	require.NoError(suite.T(), checker.Check("XBT/USD", "0.5", ""))
+	require.NoError(suite.T(), checker.Check("XBT/USD", "0.5", "41000"))
+	require.NoError(suite.T(), checker.Check("XBT/USD", "0.5", "+100"))
+	requireCheck(checker.Check("XBT/USD", "1.5", "40000"), CheckMaxQuantity)
+	requireCheck(checker.Check("XBT/USD", "1", "45000"), CheckPriceCollar)
+	checker.SetLastPrice("XBT/USD", 60000)
+	requireCheck(checker.Check("XBT/USD", "1", "-5%"), CheckMaxNotional)
+	requireCheck(checker.Check("XBT/USD", "abc", "40000"), CheckInvalidOrder)
+	// Default limits
+	require.NoError(suite.T(), checker.Check("ETH/USD", "5", ""))
+	requireCheck(checker.Check("ETH/USD", "11", ""), CheckMaxQuantity)
+}
+
+// Test open orders tracking and the max open orders check.
+//
+// Test will ensure:
+//   - Orders are tracked from openOrders messages until they are closed.
+//   - Orders are replaced by the REST GetOpenOrders result.
+//   - Orders are rejected once the maximum number of open orders is reached.
+func (suite *CheckerUnitTestSuite) TestMaxOpenOrders() {
+	checker := NewChecker(Limits{MaxOpenOrders: 2})
+	checker.ProcessOpenOrders(messages.OpenOrders{Orders: []map[string]messages.OrderInfo{
+		{"O1": {Status: string(messages.Open)}},
+		{"O2": {Status: string(messages.Pending)}},
+	}})
+	require.Equal(suite.T(), 2, checker.OpenOrdersCount())
+	err := checker.Check("XBT/USD", "1", "")
+	riskErr := new(RiskCheckError)
+	require.ErrorAs(suite.T(), err, &riskErr)
+	require.Equal(suite.T(), CheckMaxOpenOrders, riskErr.Check)
+	checker.ProcessOpenOrders(messages.OpenOrders{Orders: []map[string]messages.OrderInfo{
+		{"O1": {Status: string(messages.Canceled)}},
+	}})
+	require.NoError(suite.T(), checker.Check("XBT/USD", "1", ""))
+	checker.ProcessRESTOpenOrders(&account.GetOpenOrdersResult{Open: map[string]*account.OrderInfo{"A": {}, "B": {}}})
+	require.Equal(suite.T(), 2, checker.OpenOrdersCount())
+	checker.ProcessRESTOpenOrders(nil)
+	require.Zero(suite.T(), checker.OpenOrdersCount())
+}
+
+// Test ProcessTicker uses the last trade price.
+func (suite *CheckerUnitTestSuite) TestProcessTicker() {
+	checker := NewChecker(Limits{Default: PairLimits{MaxNotional: 100}})
+	require.NoError(suite.T(), checker.ProcessTicker(messages.Ticker{
+		Pair: "XBT/USD",
+		Data: messages.TickerData{Close: []json.Number{"50", "1"}},
+	}))
+	require.NoError(suite.T(), checker.Check("XBT/USD", "2", ""))
+	require.Error(suite.T(), checker.Check("XBT/USD", "3", ""))
+	require.Error(suite.T(), checker.ProcessTicker(messages.Ticker{Pair: "XBT/USD"}))
+}
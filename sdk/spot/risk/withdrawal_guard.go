@@ -0,0 +1,310 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/funding"
+)
+
+// Type of the events published by the withdrawal guard for each attempted withdrawal.
+const WithdrawalAuditEventType = "withdrawal_audit"
+
+// Type of the events published by the withdrawal guard when the balance of an asset falls below
+// its alert threshold.
+const BalanceAlertEventType = "balance_alert"
+
+// Source of the events published by the withdrawal guard.
+const WithdrawalGuardEventSource = "goctopus.sdk.spot.risk"
+
+// Period over which withdrawals are accounted against the withdrawal budget.
+const WithdrawalBudgetPeriod = 24 * time.Hour
+
+// Configuration of a WithdrawalGuard. Zero values disable the related check.
+type WithdrawalLimits struct {
+	// Maximum fraction (ex: 0.25 for 25%) of the balance of an asset a single withdrawal can
+	// represent. Withdrawals of assets with an unknown balance are blocked when set.
+	MaxHoldingsFraction float64
+	// Maximum amount which can be withdrawn by asset over the last 24 hours
+	// (Cf. WithdrawalBudgetPeriod). Assets which are not in the map have no budget.
+	DailyBudgets map[string]float64
+	// Balance alert thresholds by asset. A balance_alert event is published when the balance of an
+	// asset falls below its threshold.
+	BalanceThresholds map[string]float64
+}
+
+// Error returned when a withdrawal is blocked by the withdrawal guard. The withdrawal has not been
+// sent to Kraken.
+type WithdrawalBlockedError struct {
+	// Asset
+	Asset string
+	// Requested amount
+	Amount string
+	// Human readable reason
+	Reason string
+}
+
+// Format the error message
+func (e *WithdrawalBlockedError) Error() string {
+	return fmt.Sprintf("withdrawal of %s %s blocked: %s", e.Amount, e.Asset, e.Reason)
+}
+
+// Data of a withdrawal_audit event.
+type WithdrawalAudit struct {
+	// Asset
+	Asset string `json:"asset"`
+	// Withdrawal key
+	Key string `json:"key"`
+	// Requested amount
+	Amount string `json:"amount"`
+	// True if the withdrawal has been sent to Kraken.
+	Allowed bool `json:"allowed"`
+	// Reason why the withdrawal has been blocked. Empty if allowed.
+	Reason string `json:"reason,omitempty"`
+	// Reference ID of the withdrawal returned by Kraken. Empty if blocked or failed.
+	ReferenceId string `json:"refid,omitempty"`
+	// Error returned by Kraken or by the request. Empty if successful.
+	Error string `json:"error,omitempty"`
+}
+
+// Data of a balance_alert event.
+type BalanceAlert struct {
+	// Asset
+	Asset string `json:"asset"`
+	// New balance
+	Balance float64 `json:"balance"`
+	// Threshold
+	Threshold float64 `json:"threshold"`
+}
+
+// A withdrawal accounted against the withdrawal budget.
+type withdrawal struct {
+	// Time of the withdrawal
+	at time.Time
+	// Amount
+	amount float64
+}
+
+// # Description
+//
+// REST client which blocks WithdrawFunds calls which exceed the configured limits (fraction of
+// holdings, daily budget) and which publishes an audit event for every attempted withdrawal. All
+// other methods are forwarded to the wrapped client.
+//
+// Balances are tracked from the responses of GetAccountBalance calls made with the guard and can
+// also be provided with SetBalance. Balances are decreased by successful withdrawals.
+//
+// The guard is safe for concurrent use.
+type WithdrawalGuard struct {
+	// Wrapped client
+	rest.KrakenSpotRESTClientIface
+	// Mutex used to protect guard state
+	mu sync.Mutex
+	// Mutex used to serialize event publication
+	pubMu sync.Mutex
+	// Limits
+	limits WithdrawalLimits
+	// Balances by asset
+	balances map[string]float64
+	// Withdrawals made during the budget period by asset
+	withdrawals map[string][]withdrawal
+	// Optional channel used to publish audit and alert events
+	pub chan event.Event
+	// Function used to get the current time
+	now func() time.Time
+}
+
+// # Description
+//
+// Wrap a REST client with a withdrawal guard.
+//
+// # Inputs
+//
+//   - client: REST client to wrap.
+//   - limits: Withdrawal limits.
+//   - pub: Optional channel used to publish withdrawal_audit (Cf. WithdrawalAudit) and
+//     balance_alert (Cf. BalanceAlert) events. Blocking writes are used. Can be nil.
+//
+// # Return
+//
+// The guarded client.
+func NewWithdrawalGuard(client rest.KrakenSpotRESTClientIface, limits WithdrawalLimits, pub chan event.Event) *WithdrawalGuard {
+	return &WithdrawalGuard{
+		KrakenSpotRESTClientIface: client,
+		limits:                    limits,
+		balances:                  map[string]float64{},
+		withdrawals:               map[string][]withdrawal{},
+		pub:                       pub,
+		now:                       time.Now,
+	}
+}
+
+// Set the balance of an asset. A balance_alert event is published if the balance falls below the
+// alert threshold of the asset.
+func (g *WithdrawalGuard) SetBalance(asset string, balance float64) {
+	g.pubMu.Lock()
+	defer g.pubMu.Unlock()
+	g.mu.Lock()
+	alert := g.setBalanceLocked(asset, balance)
+	g.mu.Unlock()
+	if alert != nil {
+		g.publish(BalanceAlertEventType, asset, alert)
+	}
+}
+
+// Get the tracked balance of an asset. False is returned if the balance is unknown.
+func (g *WithdrawalGuard) GetBalance(asset string) (float64, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	balance, ok := g.balances[asset]
+	return balance, ok
+}
+
+// Get the amount which can still be withdrawn for the asset during the budget period. False is
+// returned if the asset has no budget.
+func (g *WithdrawalGuard) RemainingBudget(asset string) (float64, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	budget, ok := g.limits.DailyBudgets[asset]
+	if !ok {
+		return 0, false
+	}
+	return budget - g.withdrawnLocked(asset), true
+}
+
+// # Description
+//
+// Get the account balances with the wrapped client and track the returned balances. Cf.
+// KrakenSpotRESTClientIface.GetAccountBalance.
+func (g *WithdrawalGuard) GetAccountBalance(ctx context.Context, nonce int64, secopts *common.SecurityOptions) (*account.GetAccountBalanceResponse, *http.Response, error) {
+	resp, httpresp, err := g.KrakenSpotRESTClientIface.GetAccountBalance(ctx, nonce, secopts)
+	if err == nil && resp != nil && len(resp.Error) == 0 {
+		for asset, balance := range resp.Result {
+			if value, perr := balance.Float64(); perr == nil {
+				g.SetBalance(asset, value)
+			}
+		}
+	}
+	return resp, httpresp, err
+}
+
+// # Description
+//
+// Check the withdrawal against the limits and send it with the wrapped client if allowed. A
+// withdrawal_audit event is published in all cases. Cf. KrakenSpotRESTClientIface.WithdrawFunds.
+//
+// # Return
+//
+// A WithdrawalBlockedError if the withdrawal exceeds a limit. The withdrawal is not sent in that
+// case. Otherwise, the values returned by the wrapped client.
+func (g *WithdrawalGuard) WithdrawFunds(ctx context.Context, nonce int64, params funding.WithdrawFundsRequestParameters, opts *funding.WithdrawFundsRequestOptions, secopts *common.SecurityOptions) (*funding.WithdrawFundsResponse, *http.Response, error) {
+	audit := WithdrawalAudit{Asset: params.Asset, Key: params.Key, Amount: params.Amount}
+	// Hold the lock during the request so concurrent withdrawals cannot exceed the budget
+	g.pubMu.Lock()
+	defer g.pubMu.Unlock()
+	g.mu.Lock()
+	amount, reason := g.checkLocked(params.Asset, params.Amount)
+	g.mu.Unlock()
+	if reason != "" {
+		audit.Reason = reason
+		g.publish(WithdrawalAuditEventType, params.Asset, audit)
+		return nil, nil, &WithdrawalBlockedError{Asset: params.Asset, Amount: params.Amount, Reason: reason}
+	}
+	audit.Allowed = true
+	resp, httpresp, err := g.KrakenSpotRESTClientIface.WithdrawFunds(ctx, nonce, params, opts, secopts)
+	switch {
+	case err != nil:
+		audit.Error = err.Error()
+	case resp == nil:
+		audit.Error = "empty response"
+	case len(resp.Error) > 0:
+		audit.Error = fmt.Sprintf("%v", resp.Error)
+	default:
+		if resp.Result != nil {
+			audit.ReferenceId = resp.Result.ReferenceID
+		}
+		g.mu.Lock()
+		g.withdrawals[params.Asset] = append(g.withdrawals[params.Asset], withdrawal{at: g.now(), amount: amount})
+		var alert *BalanceAlert
+		if balance, ok := g.balances[params.Asset]; ok {
+			alert = g.setBalanceLocked(params.Asset, balance-amount)
+		}
+		g.mu.Unlock()
+		if alert != nil {
+			g.publish(BalanceAlertEventType, params.Asset, alert)
+		}
+	}
+	g.publish(WithdrawalAuditEventType, params.Asset, audit)
+	return resp, httpresp, err
+}
+
+// Check the withdrawal against the limits. The parsed amount and the reason why the withdrawal
+// must be blocked (empty if allowed) are returned.
+func (g *WithdrawalGuard) checkLocked(asset string, rawAmount string) (float64, string) {
+	amount, err := strconv.ParseFloat(rawAmount, 64)
+	if err != nil || amount <= 0 {
+		return 0, fmt.Sprintf("invalid amount %q", rawAmount)
+	}
+	if g.limits.MaxHoldingsFraction > 0 {
+		balance, ok := g.balances[asset]
+		if !ok {
+			return amount, "balance is unknown"
+		}
+		if amount > balance*g.limits.MaxHoldingsFraction {
+			return amount, fmt.Sprintf("amount exceeds %.2f%% of holdings (%g)", g.limits.MaxHoldingsFraction*100, balance)
+		}
+	}
+	if budget, ok := g.limits.DailyBudgets[asset]; ok {
+		if withdrawn := g.withdrawnLocked(asset); withdrawn+amount > budget {
+			return amount, fmt.Sprintf("daily withdrawal budget exhausted (%g of %g already withdrawn)", withdrawn, budget)
+		}
+	}
+	return amount, ""
+}
+
+// Get the amount withdrawn for the asset during the budget period and discard older withdrawals.
+func (g *WithdrawalGuard) withdrawnLocked(asset string) float64 {
+	since := g.now().Add(-WithdrawalBudgetPeriod)
+	kept := g.withdrawals[asset][:0]
+	total := 0.0
+	for _, w := range g.withdrawals[asset] {
+		if w.at.After(since) {
+			kept = append(kept, w)
+			total += w.amount
+		}
+	}
+	g.withdrawals[asset] = kept
+	return total
+}
+
+// Set the balance and return an alert if the balance has fallen below the threshold.
+func (g *WithdrawalGuard) setBalanceLocked(asset string, balance float64) *BalanceAlert {
+	previous, known := g.balances[asset]
+	g.balances[asset] = balance
+	threshold, ok := g.limits.BalanceThresholds[asset]
+	if !ok || balance >= threshold || (known && previous < threshold) {
+		return nil
+	}
+	return &BalanceAlert{Asset: asset, Balance: balance, Threshold: threshold}
+}
+
+// Publish an event if a channel has been provided.
+func (g *WithdrawalGuard) publish(eventType string, subject string, data interface{}) {
+	if g.pub == nil {
+		return
+	}
+	e := event.New()
+	e.SetType(eventType)
+	e.SetSource(WithdrawalGuardEventSource)
+	e.SetSubject(subject)
+	e.SetData("application/json", data)
+	g.pub <- e
+}
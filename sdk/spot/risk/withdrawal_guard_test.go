@@ -0,0 +1,105 @@
+package risk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/funding"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for WithdrawalGuard
+type WithdrawalGuardUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestWithdrawalGuardUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(WithdrawalGuardUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test withdrawals are checked against the limits and audited.
+//
+// Test will ensure:
+//   - Balances are tracked from GetAccountBalance responses.
+//   - Withdrawals exceeding the fraction of holdings or the daily budget are blocked and not sent.
+//   - Allowed withdrawals are sent, decrease the balance and consume the budget.
+//   - The budget is restored after the budget period.
+//   - An audit event is published for every attempt and alerts when balances cross thresholds.
+func (suite *WithdrawalGuardUnitTestSuite) TestWithdrawFunds() {
+	mockClient := rest.NewMockKrakenSpotRESTClient()
+	mockClient.On("GetAccountBalance", mock.Anything, mock.Anything, mock.Anything).
+		Return(rest.NewMockGetAccountBalanceResponse(map[string]string{"XXBT": "10"}), nil, nil)
+	mockClient.On("WithdrawFunds", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&funding.WithdrawFundsResponse{
+			KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+			Result:                 &funding.WithdrawFundsResult{ReferenceID: "REF"},
+		}, nil, nil)
+	pub := make(chan event.Event, 10)
+	guard := NewWithdrawalGuard(mockClient, WithdrawalLimits{
+		MaxHoldingsFraction: 0.5,
+		DailyBudgets:        map[string]float64{"XXBT": 4},
+		BalanceThresholds:   map[string]float64{"XXBT": 8},
+	}, pub)
+	now := time.Now()
+	guard.now = func() time.Time { return now }
+	withdraw := func(amount string) error {
+		_, _, err := guard.WithdrawFunds(context.Background(), 1, funding.WithdrawFundsRequestParameters{Asset: "XXBT", Key: "cold", Amount: amount}, nil, nil)
+		return err
+	}
+	audit := func() WithdrawalAudit {
+		e := <-pub
+		require.Equal(suite.T(), WithdrawalAuditEventType, e.Type())
+		data := WithdrawalAudit{}
+		require.NoError(suite.T(), e.DataAs(&data))
+		return data
+	}
+	// Unknown balance
+	require.ErrorAs(suite.T(), withdraw("1"), new(*WithdrawalBlockedError))
+	require.Equal(suite.T(), "balance is unknown", audit().Reason)
+	_, _, err := guard.GetAccountBalance(context.Background(), 1, nil)
+	require.NoError(suite.T(), err)
+	balance, ok := guard.GetBalance("XXBT")
+	require.True(suite.T(), ok)
+	require.Equal(suite.T(), 10.0, balance)
+	// Exceeds fraction of holdings
+	require.ErrorAs(suite.T(), withdraw("6"), new(*WithdrawalBlockedError))
+	require.False(suite.T(), audit().Allowed)
+	mockClient.AssertNotCalled(suite.T(), "WithdrawFunds", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	// Allowed - balance falls below threshold
+	require.NoError(suite.T(), withdraw("3"))
+	alert := <-pub
+	require.Equal(suite.T(), BalanceAlertEventType, alert.Type())
+	allowed := audit()
+	require.True(suite.T(), allowed.Allowed)
+	require.Equal(suite.T(), "REF", allowed.ReferenceId)
+	balance, _ = guard.GetBalance("XXBT")
+	require.Equal(suite.T(), 7.0, balance)
+	remaining, ok := guard.RemainingBudget("XXBT")
+	require.True(suite.T(), ok)
+	require.Equal(suite.T(), 1.0, remaining)
+	// Budget exhausted
+	require.ErrorAs(suite.T(), withdraw("2"), new(*WithdrawalBlockedError))
+	require.Contains(suite.T(), audit().Reason, "budget")
+	// Budget restored after the budget period - no new alert as balance was already below threshold
+	now = now.Add(WithdrawalBudgetPeriod + time.Second)
+	require.NoError(suite.T(), withdraw("2"))
+	require.True(suite.T(), audit().Allowed)
+	require.Empty(suite.T(), pub)
+	_, ok = guard.RemainingBudget("XETH")
+	require.False(suite.T(), ok)
+}
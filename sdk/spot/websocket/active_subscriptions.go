@@ -15,8 +15,8 @@ type activeSubscriptions struct {
 	trade *tradeSubscription
 	// spread subscription. Will be nil if not subscribed.
 	spread *spreadSubscription
-	// book subscriptions per depth. Will be nil if book topic has never been subscribed to.
-	book *bookSubscription
+	// book subscriptions by depth. Will be nil if book topic has never been subscribed to.
+	books map[messages.DepthEnum]*bookSubscription
 	// ownTrades subscription. Will be nil if not subscribed.
 	ownTrades *ownTradesSubscription
 	// openOrders subscription. Will be nil if not subscribed.
@@ -25,6 +25,10 @@ type activeSubscriptions struct {
 	heartbeat chan event.Event
 	// SystemStatus channel
 	systemStatus chan event.Event
+	// Channel for error messages without request ID
+	generalErrors chan event.Event
+	// Channel for messages which violate the protocol
+	unknownMessages chan event.Event
 }
 
 // Data of a ticker subscription
@@ -43,6 +47,9 @@ type ohlcSubscription struct {
 	interval messages.IntervalEnum
 	// Channel used to publish subscription's messages
 	pub chan event.Event
+	// True if pub has been created by the client (ex: SubscribeOHLCMulti). In this case, pub is
+	// always closed on unsubscribe.
+	internal bool
 }
 
 // Data of a trade subscription
@@ -51,6 +58,9 @@ type tradeSubscription struct {
 	pairs []string
 	// Channel used to publish subscription's messages
 	pub chan event.Event
+	// True if pub has been created by the client (ex: SubscribeTradeBatched). In this case, pub is
+	// always closed on unsubscribe.
+	internal bool
 }
 
 // Data of a spread subscription
@@ -69,6 +79,9 @@ type bookSubscription struct {
 	pub chan event.Event
 	// Desired depth
 	depth messages.DepthEnum
+	// True if pub has been created by the client (ex: SubscribeBookTop). In this case, pub is
+	// always closed on unsubscribe.
+	internal bool
 }
 
 // Data of a ownTrades subscription
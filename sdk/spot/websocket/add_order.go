@@ -1,5 +1,7 @@
 package websocket
 
+import "github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+
 // AddOrder request parameters
 type AddOrderRequestParameters struct {
 	// Order type. Cf. OrderTypeEnum for values.
@@ -15,12 +17,26 @@ type AddOrderRequestParameters struct {
 	// Order secondary price
 	// Order volume in base currency
 	Volume string `json:"volume"`
+	// Optional - visible order quantity of an iceberg order in base currency. Can only be used
+	// with limit orders: must be greater than 0 and less than volume.
+	//
+	// An empty string means the feature is not used.
+	DisplayVolume string `json:"displayvol,omitempty"`
+	// Optional - price signal used to trigger stop, take-profit and trailing orders. Cf.
+	// TriggerEnum for values.
+	//
+	// An empty string triggers the default behavior (last).
+	Trigger string `json:"trigger,omitempty"`
 	// Amount of leverage desired.
 	//
 	// A zero value means no leverage.
 	Leverage int `json:"leverage,omitempty"`
 	// If true, order will only reduce a currently open position, not increase it or open a new position.
 	ReduceOnly bool `json:"reduce_only,omitempty"`
+	// Optional - self trade prevention flag. The flag is validated before the order is sent.
+	//
+	// An empty value triggers the default behavior (cancel-newest).
+	StpType trading.SelfTradePreventionFlagEnum `json:"stptype,omitempty"`
 	// Optional comma delimited list of order flags. Cf. OrderFlagEnum for values.
 	//
 	// viqc = volume in quote currency (not currently available), fcib = prefer fee in base currency, fciq = prefer fee in quote currency,
@@ -49,7 +65,9 @@ type AddOrderRequestParameters struct {
 	// Optional - RFC3339 timestamp (e.g. 2021-04-01T00:18:45Z) after which matching engine should reject new order request,4 in presence of latency
 	// or order queueing. min now + 2 seconds, max now + 60 seconds. Defaults to now + 60 seconds if not specified.
 	//
-	// An empty string triggers the default behavior.
+	// An empty string triggers the default behavior. Use SetDeadline to set the deadline from a
+	// time.Time (ex: trading.DeadlineIn(5*time.Second)). The deadline is validated before the
+	// order is sent.
 	Deadline string `json:"deadline,omitempty"`
 	// Optional - user reference ID (should be an integer in quotes)
 	UserReference string `json:"userref,omitempty"`
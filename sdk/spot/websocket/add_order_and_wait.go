@@ -0,0 +1,92 @@
+//go:build !goctopus_publiconly
+
+package websocket
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// # Description
+//
+// Add a new order and wait until the order reaches the expected status. AddOrder returns as soon
+// as the server has acknowledged the order while the order may still be pending: this method
+// uses the updates received from the openOrders channel to wait until the order is actually
+// open, filled or canceled.
+//
+// The wait ends when the order reaches the expected status or a later one: an order which is
+// closed, canceled or expired cannot change anymore, so the wait ends when one of these statuses
+// is reached whatever the expected status is. Check the status of the returned order.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. Use a context with a timeout or a
+//     deadline to limit the wait.
+//   - params: AddOrder request parameters. Validate-only orders are rejected.
+//   - until: Expected order status (pending, open, closed, canceled or expired).
+//
+// # Return
+//
+// The order ID and the final state of the order merged from all updates received from the
+// openOrders channel. The StpType of the returned state is set to the self trade prevention flag
+// applied to the order. An error is returned if:
+//
+//   - The client has no active subscription to the openOrders channel.
+//   - AddOrder fails. In that case, the order ID and state are empty.
+//   - ctx is done before the order reaches the expected status. In that case, an
+//     OperationInterruptedError is returned with the order ID and the last known state of the
+//     order (nil if no update has been received).
+func (client *krakenSpotWebsocketClient) AddOrderAndWait(ctx context.Context, params AddOrderRequestParameters, until messages.OrderStatusEnum) (string, *messages.OrderInfo, error) {
+	// Tracing: Start span
+	ctx, span := client.tracer.Start(ctx, "add_order_and_wait", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("pair", params.Pair),
+		attribute.String("until", string(until)),
+	))
+	defer span.End()
+	if params.Validate {
+		return "", nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("add order and wait failed: validate-only orders are not supported"))
+	}
+	client.openOrdersSubMu.Lock()
+	subscribed := client.subscriptions.openOrders != nil
+	client.openOrdersSubMu.Unlock()
+	if !subscribed {
+		return "", nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("add order and wait failed: an active subscription to the open orders channel is required"))
+	}
+	// Register the watcher before sending the order: updates can be received before the response
+	w := newOrderWatcher()
+	client.addOrderWatcher(w)
+	defer client.removeOrderWatcher(w)
+	resp, err := client.AddOrder(ctx, params)
+	if err != nil {
+		return "", nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("add order and wait failed: %w", err))
+	}
+	span.SetAttributes(attribute.String("txid", resp.TxId))
+	stpType := string(trading.AppliedSelfTradePreventionFlag(string(params.StpType)))
+	for {
+		info, found := w.get(resp.TxId)
+		if found && info.StpType == "" {
+			info.StpType = stpType
+		}
+		if found && orderStatusReached(messages.OrderStatusEnum(info.Status), until) {
+			span.SetAttributes(attribute.String("status", info.Status))
+			span.SetStatus(codes.Ok, codes.Ok.String())
+			return resp.TxId, &info, nil
+		}
+		select {
+		case <-ctx.Done():
+			var last *messages.OrderInfo
+			if found {
+				last = &info
+			}
+			return resp.TxId, last, tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "add_order_and_wait", Root: fmt.Errorf("order %s did not reach status %s: %w", resp.TxId, until, ctx.Err())})
+		case <-w.notify:
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// Default maximum number of events in a batch.
+const DefaultBatchMaxSize = 100
+
+// Default maximum time an event waits in a batch before the batch is delivered.
+const DefaultBatchMaxWait = 10 * time.Millisecond
+
+// Capacity of the channels used to receive events from the underlying subscriptions.
+const batchChannelCapacity = 1000
+
+// Options of the batch delivery mode.
+type BatchOptions struct {
+	// Maximum number of events in a batch. DefaultBatchMaxSize is used if not strictly positive.
+	MaxSize int
+	// Maximum time the first event of a batch waits before the batch is delivered.
+	// DefaultBatchMaxWait is used if not strictly positive.
+	MaxWait time.Duration
+}
+
+// Batcher which coalesces the events of an input channel into batches.
+type eventBatcher struct {
+	// Maximum number of events in a batch
+	maxSize int
+	// Maximum time the first event of a batch waits before the batch is delivered
+	maxWait time.Duration
+	// Clock used to create timers
+	clock clock.Clock
+	// Optional function which tells whether the output channel must be left open when the input
+	// channel is closed. If nil, the output channel is closed.
+	keepOutOpen func() bool
+}
+
+// Create a new batcher. Default values are used for missing options. If clk is nil, a system clock
+// is used.
+func newEventBatcher(opts BatchOptions, clk clock.Clock) *eventBatcher {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultBatchMaxSize
+	}
+	if opts.MaxWait <= 0 {
+		opts.MaxWait = DefaultBatchMaxWait
+	}
+	if clk == nil {
+		clk = clock.NewSystemClock()
+	}
+	return &eventBatcher{maxSize: opts.MaxSize, maxWait: opts.MaxWait, clock: clk}
+}
+
+// # Description
+//
+// Coalesce the events of the input channel into batches published on the output channel until
+// the input channel is closed. A batch is delivered when:
+//
+//   - MaxWait has elapsed since the first event of the batch has been received.
+//   - The batch has reached MaxSize events.
+//   - A connection_interrupted event has been received. The event is the last one of the batch.
+//
+// While the consumer is busy, received events keep being added to the pending batch up to
+// MaxSize: the slower the consumer, the bigger the batches. Once the pending batch is full, events
+// are not read from the input channel anymore until the batch is delivered.
+//
+// The pending batch is delivered and the output channel is closed when the input channel is
+// closed unless keepOutOpen returns true.
+func (b *eventBatcher) run(in chan event.Event, out chan []event.Event) {
+	defer func() {
+		if b.keepOutOpen == nil || !b.keepOutOpen() {
+			close(out)
+		}
+	}()
+	var batch []event.Event
+	var timer clock.Timer
+	var timeout <-chan time.Time
+	ready := false
+	for {
+		// Only read events while the batch is not full and only deliver ready batches
+		input := in
+		if len(batch) >= b.maxSize {
+			input = nil
+			ready = true
+		}
+		var output chan []event.Event
+		if ready && len(batch) > 0 {
+			output = out
+		}
+		select {
+		case e, ok := <-input:
+			if !ok {
+				if timer != nil {
+					timer.Stop()
+				}
+				if len(batch) > 0 {
+					out <- batch
+				}
+				return
+			}
+			batch = append(batch, e)
+			if e.Type() == string(events.ConnectionInterrupted) {
+				ready = true
+			}
+			if timer == nil {
+				timer = b.clock.NewTimer(b.maxWait)
+				timeout = timer.C()
+			}
+		case <-timeout:
+			timer, timeout = nil, nil
+			ready = true
+		case output <- batch:
+			batch = nil
+			ready = false
+			if timer != nil {
+				timer.Stop()
+				timer, timeout = nil, nil
+			}
+		}
+	}
+}
+
+// # Description
+//
+// Subscribe to the trade channel and deliver trade events in batches coalesced over a small time
+// window or up to a maximum batch size instead of one event per channel send. This reduces the
+// channel overhead during bursts for consumers which process messages in batches.
+//
+// Cf. BatchOptions for the batch settings. A batch is delivered early when a
+// connection_interrupted event is received: the event is the last one of the batch.
+//
+// Use UnsubscribeTrade to unsubscribe: the provided channel will be closed once the pending batch
+// has been delivered unless the client is configured to keep channels open on unsubscribe (Cf.
+// SetKeepChannelsOpenOnUnsubscribe). The subscription is not persisted.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - pairs: Pairs to subscribe to.
+//   - opts: Batch options.
+//   - rcv: Channel used to publish batches of trade and connection_interrupted events. Blocking
+//     writes are used.
+//
+// # Return
+//
+// An error if SubscribeTrade fails.
+func (client *KrakenSpotPublicWebsocketClient) SubscribeTradeBatched(ctx context.Context, pairs []string, opts BatchOptions, rcv chan []event.Event) error {
+	in := make(chan event.Event, batchChannelCapacity)
+	err := client.subscribeTrade(ctx, pairs, in, nil, true)
+	if err != nil {
+		return fmt.Errorf("subscribe trade batched failed: %w", err)
+	}
+	batcher := newEventBatcher(opts, client.clock)
+	batcher.keepOutOpen = client.keepChannelsOpenOnUnsubscribe.Load
+	go batcher.run(in, rcv)
+	return nil
+}
+
+// # Description
+//
+// Subscribe to the book channel and deliver book snapshots and updates in batches coalesced over
+// a small time window or up to a maximum batch size instead of one event per channel send. This
+// reduces the channel overhead during bursts for consumers which process messages in batches.
+// Events are delivered in the order they have been received.
+//
+// Cf. BatchOptions for the batch settings. A batch is delivered early when a
+// connection_interrupted event is received: the event is the last one of the batch.
+//
+// Use UnsubscribeBook to unsubscribe: the provided channel will be closed once the pending batch
+// has been delivered unless the client is configured to keep channels open on unsubscribe (Cf.
+// SetKeepChannelsOpenOnUnsubscribe). The subscription is not persisted.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - pairs: Pairs to subscribe to.
+//   - depth: Depth of the book subscription.
+//   - opts: Batch options.
+//   - rcv: Channel used to publish batches of book and connection_interrupted events. Blocking
+//     writes are used.
+//
+// # Return
+//
+// An error if SubscribeBook fails.
+func (client *KrakenSpotPublicWebsocketClient) SubscribeBookBatched(ctx context.Context, pairs []string, depth messages.DepthEnum, opts BatchOptions, rcv chan []event.Event) error {
+	in := make(chan event.Event, batchChannelCapacity)
+	err := client.subscribeBook(ctx, pairs, depth, in, nil, true)
+	if err != nil {
+		return fmt.Errorf("subscribe book batched failed: %w", err)
+	}
+	batcher := newEventBatcher(opts, client.clock)
+	batcher.keepOutOpen = client.keepChannelsOpenOnUnsubscribe.Load
+	go batcher.run(in, rcv)
+	return nil
+}
@@ -0,0 +1,194 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the batch delivery mode
+type BatchUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestBatchUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(BatchUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the event batcher.
+//
+// Test will ensure:
+//   - A batch is delivered once MaxWait has elapsed since its first event.
+//   - A batch is delivered once it has reached MaxSize events.
+//   - A batch is delivered early when a connection_interrupted event is received.
+//   - The pending batch is delivered and the output channel closed when the input is closed.
+func (suite *BatchUnitTestSuite) TestBatcher() {
+	fake := clock.NewFakeClock(time.Now())
+	batcher := newEventBatcher(BatchOptions{MaxSize: 3, MaxWait: time.Second}, fake)
+	in := make(chan event.Event, 10)
+	out := make(chan []event.Event)
+	go batcher.run(in, out)
+	// Time window
+	in <- suite.newEvent(events.Trade)
+	in <- suite.newEvent(events.Trade)
+	fake.BlockUntil(1)
+	select {
+	case <-out:
+		suite.FailNow("batch delivered before the end of the time window")
+	case <-time.After(20 * time.Millisecond):
+	}
+	fake.Advance(time.Second)
+	require.Len(suite.T(), <-out, 2)
+	// Max size
+	for i := 0; i < 4; i++ {
+		in <- suite.newEvent(events.Trade)
+	}
+	require.Len(suite.T(), <-out, 3)
+	// Connection interrupted
+	in <- suite.newEvent(events.ConnectionInterrupted)
+	batch := <-out
+	require.Len(suite.T(), batch, 2)
+	require.Equal(suite.T(), string(events.ConnectionInterrupted), batch[1].Type())
+	// Close
+	in <- suite.newEvent(events.Trade)
+	close(in)
+	require.Len(suite.T(), <-out, 1)
+	_, ok := <-out
+	require.False(suite.T(), ok)
+}
+
+// Test the batcher defaults and backpressure.
+//
+// Test will ensure:
+//   - Default options are used when options are not set.
+//   - Events keep being added to the pending batch while the consumer is busy.
+//   - The output channel is left open if required.
+func (suite *BatchUnitTestSuite) TestBatcherBackpressure() {
+	batcher := newEventBatcher(BatchOptions{}, nil)
+	require.Equal(suite.T(), DefaultBatchMaxSize, batcher.maxSize)
+	require.Equal(suite.T(), DefaultBatchMaxWait, batcher.maxWait)
+	batcher.keepOutOpen = func() bool { return true }
+	in := make(chan event.Event, 10)
+	out := make(chan []event.Event)
+	done := make(chan struct{})
+	go func() {
+		batcher.run(in, out)
+		close(done)
+	}()
+	in <- suite.newEvent(events.Trade)
+	// Consumer is busy while the time window elapses
+	time.Sleep(3 * DefaultBatchMaxWait)
+	in <- suite.newEvent(events.Trade)
+	require.Eventually(suite.T(), func() bool { return len(in) == 0 }, time.Second, time.Millisecond)
+	require.Len(suite.T(), <-out, 2)
+	close(in)
+	<-done
+	require.Empty(suite.T(), out)
+}
+
+// Test SubscribeTradeBatched and SubscribeBookBatched.
+//
+// Test will ensure:
+//   - Trade and book events are delivered in batches.
+//   - The subscriptions are never persisted, even transiently.
+//   - The output channel is closed on unsubscribe.
+func (suite *BatchUnitTestSuite) TestSubscribeBatched() {
+	client := newSubscribingClient(suite.T())
+	store := &recordingStore{}
+	client.SetSubscriptionStore(store)
+	trades := make(chan []event.Event, 1)
+	books := make(chan []event.Event, 1)
+	opts := BatchOptions{MaxSize: 2, MaxWait: time.Hour}
+	require.NoError(suite.T(), client.SubscribeTradeBatched(context.Background(), []string{"XBT/USD"}, opts, trades))
+	require.NoError(suite.T(), client.SubscribeBookBatched(context.Background(), []string{"XBT/USD"}, messages.D10, opts, books))
+	require.Empty(suite.T(), client.GetSubscriptionStates())
+	require.Len(suite.T(), store.saved, 2)
+	for _, states := range store.saved {
+		require.Empty(suite.T(), states)
+	}
+	for i := 0; i < 2; i++ {
+		require.NoError(suite.T(), client.handleTrade(context.Background(), nil, nil, nil, nil, "s1", 0, "XBT/USD", []byte(`[]`)))
+		require.NoError(suite.T(), client.handleBookSnapshot(context.Background(), nil, nil, nil, nil, "s1", 0, "XBT/USD", []byte(`[]`), messages.D10))
+	}
+	batch := <-trades
+	require.Len(suite.T(), batch, 2)
+	require.Equal(suite.T(), string(events.Trade), batch[0].Type())
+	require.Len(suite.T(), <-books, 2)
+	// Unsubscribe
+	require.NoError(suite.T(), client.UnsubscribeTrade(context.Background()))
+	require.NoError(suite.T(), client.UnsubscribeBook(context.Background()))
+	_, ok := <-trades
+	require.False(suite.T(), ok)
+	_, ok = <-books
+	require.False(suite.T(), ok)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build an event of the provided type.
+func (suite *BatchUnitTestSuite) newEvent(eventType events.WebsocketClientEventTypeEnum) event.Event {
+	e := event.New()
+	e.SetType(string(eventType))
+	return e
+}
+
+// Subscription store which records the saved states.
+type recordingStore struct {
+	mu    sync.Mutex
+	saved [][]SubscriptionState
+}
+
+func (s *recordingStore) Save(ctx context.Context, states []SubscriptionState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved = append(s.saved, states)
+	return nil
+}
+
+func (s *recordingStore) Load(ctx context.Context) ([]SubscriptionState, error) {
+	return nil, nil
+}
+
+// Build a client with a mocked connection which answers subscribe and unsubscribe requests.
+func newSubscribingClient(t *testing.T) *KrakenSpotPublicWebsocketClient {
+	client := &KrakenSpotPublicWebsocketClient{newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)}
+	conn := newConnectionMock()
+	conn.On("Write", mock.Anything, wsadapters.Text, mock.Anything).Run(func(args mock.Arguments) {
+		req := new(messages.Subscribe)
+		require.NoError(t, json.Unmarshal(args.Get(2).([]byte), req))
+		status := "subscribed"
+		if req.Event == string(messages.EventTypeUnsubscribe) {
+			status = "unsubscribed"
+		}
+		for _, pair := range req.Pairs {
+			resp := fmt.Sprintf(`{"channelName":"%s","event":"subscriptionStatus","pair":"%s","reqid":%d,"status":"%s","subscription":{"name":"%s"}}`,
+				req.Subscription.Name, pair, req.ReqId, status, req.Subscription.Name)
+			go client.handleSubscriptionStatus(context.Background(), nil, nil, nil, nil, "s1", 0, []byte(resp))
+		}
+	}).Return(nil)
+	client.conn = conn
+	return client
+}
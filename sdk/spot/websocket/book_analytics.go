@@ -0,0 +1,289 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	otelObs "github.com/cloudevents/sdk-go/observability/opentelemetry/v2/client"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/codec"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
+)
+
+// Default number of top levels of each side of the book used to compute book analytics.
+const DefaultBookAnalyticsLevels = 10
+
+// Options of a SubscribeBookAnalytics subscription.
+type BookAnalyticsOptions struct {
+	// Number of top levels of each side of the book used to compute the imbalance and the weighted
+	// mid. DefaultBookAnalyticsLevels is used if not strictly positive. Must not exceed the depth.
+	Levels int
+	// Interval between two publications of the analytics of a pair. Analytics are published each
+	// time the book of a pair changes if zero. Must not be negative.
+	Interval time.Duration
+}
+
+// Data of a book_analytics event: analytics computed from the book of a pair.
+type BookAnalytics struct {
+	// Pair
+	Pair string `json:"pair"`
+	// Number of top levels of each side of the book used to compute the imbalance and the
+	// weighted mid.
+	Levels int `json:"levels"`
+	// Best bid price
+	BestBid float64 `json:"best_bid"`
+	// Best ask price
+	BestAsk float64 `json:"best_ask"`
+	// Difference between the best ask and the best bid
+	Spread float64 `json:"spread"`
+	// Average of the best bid and the best ask
+	Mid float64 `json:"mid"`
+	// Average of the best bid and the best ask weighted by the volume of the opposite side:
+	// (best bid * best ask volume + best ask * best bid volume) / (best bid volume + best ask volume)
+	Microprice float64 `json:"microprice"`
+	// Average of the volume weighted average prices of the top bid levels and of the top ask levels
+	WeightedMid float64 `json:"weighted_mid"`
+	// Total volume of the top bid levels
+	BidVolume float64 `json:"bid_volume"`
+	// Total volume of the top ask levels
+	AskVolume float64 `json:"ask_volume"`
+	// Bid/ask imbalance over the top levels, between -1 (asks only) and 1 (bids only):
+	// (bid volume - ask volume) / (bid volume + ask volume)
+	Imbalance float64 `json:"imbalance"`
+	// Time at which the analytics have been computed
+	Time time.Time `json:"time"`
+}
+
+// Analyzer which maintains the books of the subscribed pairs from validated book snapshots and
+// updates and which publishes analytics computed from the books.
+type bookAnalyzer struct {
+	// Book depth
+	depth int
+	// Number of top levels used to compute analytics
+	levels int
+	// Interval between two publications. Zero to publish on each change.
+	interval time.Duration
+	// Books by pair
+	books map[string]*localBook
+	// Last book event by pair for the pairs whose book has changed since the last publication
+	pending map[string]event.Event
+	// Clock used to schedule publications and timestamp analytics
+	clock clock.Clock
+	// JSON codec used to parse book messages
+	codec codec.JSONCodec
+	// Optional function which tells whether the output channel must be left open when the input
+	// channel is closed. If nil, the output channel is closed.
+	keepOutOpen func() bool
+}
+
+// # Description
+//
+// Subscribe to the book channel in managed mode (Cf. SubscribeManagedBook) and publish analytics
+// computed from the books of the subscribed pairs: bid/ask imbalance over the top levels,
+// microprice and weighted mid (Cf. BookAnalytics).
+//
+// book_analytics events are published for a pair each time its book changes or, if an interval
+// is provided, at most once per interval with the latest analytics of the pairs whose book has
+// changed. Analytics are not published while one side of the book is empty.
+//
+// connection_interrupted, resubscribe_failed and book_resynced events are forwarded. Books are
+// reset on connection_interrupted and analytics are published again once the new snapshots are
+// received.
+//
+// Use UnsubscribeBook to unsubscribe: the provided channel will be closed once the underlying
+// book subscription is closed unless the client is configured to keep channels open on
+// unsubscribe (Cf. SetKeepChannelsOpenOnUnsubscribe).
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - pairs: Pairs to subscribe to.
+//   - depth: Depth of the book subscription.
+//   - opts: Analytics options.
+//   - rcv: Channel used to publish book_analytics and forwarded events. Blocking writes are used.
+//
+// # Return
+//
+// An error if the options are invalid or if SubscribeBook fails.
+func (client *KrakenSpotPublicWebsocketClient) SubscribeBookAnalytics(ctx context.Context, pairs []string, depth messages.DepthEnum, opts BookAnalyticsOptions, rcv chan event.Event) error {
+	if opts.Levels <= 0 {
+		opts.Levels = DefaultBookAnalyticsLevels
+	}
+	if opts.Levels > int(depth) {
+		return fmt.Errorf("subscribe book analytics failed: levels must be between 1 and %d. Got %d", depth, opts.Levels)
+	}
+	if opts.Interval < 0 {
+		return fmt.Errorf("subscribe book analytics failed: interval must be positive. Got %s", opts.Interval)
+	}
+	validated := make(chan event.Event, managedBookChannelCapacity)
+	err := client.subscribeManagedBook(ctx, pairs, depth, validated, nil)
+	if err != nil {
+		return fmt.Errorf("subscribe book analytics failed: %w", err)
+	}
+	analyzer := newBookAnalyzer(int(depth), opts, client.clock, client.codec)
+	analyzer.keepOutOpen = client.keepChannelsOpenOnUnsubscribe.Load
+	go analyzer.run(validated, rcv)
+	return nil
+}
+
+// Create a new book analyzer. opts must have been validated. If clk is nil, a system clock is used.
+// If jsonCodec is nil, codec.StandardJSONCodec is used.
+func newBookAnalyzer(depth int, opts BookAnalyticsOptions, clk clock.Clock, jsonCodec codec.JSONCodec) *bookAnalyzer {
+	if clk == nil {
+		clk = clock.NewSystemClock()
+	}
+	if jsonCodec == nil {
+		jsonCodec = codec.StandardJSONCodec{}
+	}
+	return &bookAnalyzer{
+		depth:    depth,
+		levels:   opts.Levels,
+		interval: opts.Interval,
+		books:    map[string]*localBook{},
+		pending:  map[string]event.Event{},
+		clock:    clk,
+		codec:    jsonCodec,
+	}
+}
+
+// Process events from the input channel until it is closed and publish book_analytics and
+// forwarded events on the output channel. The output channel is closed when the input channel is
+// closed unless keepOutOpen returns true.
+func (a *bookAnalyzer) run(in chan event.Event, out chan event.Event) {
+	defer func() {
+		if a.keepOutOpen == nil || !a.keepOutOpen() {
+			close(out)
+		}
+	}()
+	var tick <-chan time.Time
+	var timer clock.Timer
+	if a.interval > 0 {
+		timer = a.clock.NewTimer(a.interval)
+		defer timer.Stop()
+		tick = timer.C()
+	}
+	for {
+		select {
+		case e, ok := <-in:
+			if !ok {
+				return
+			}
+			switch e.Type() {
+			case string(events.ConnectionInterrupted):
+				// Reset books and forward event
+				a.books = map[string]*localBook{}
+				a.pending = map[string]event.Event{}
+				out <- e
+			case string(events.BookSnapshot), string(events.BookUpdate):
+				pair, err := a.process(e)
+				if err != nil {
+					continue
+				}
+				if a.interval > 0 {
+					a.pending[pair] = e
+				} else if analytics := a.compute(pair); analytics != nil {
+					out <- a.newEvent(e, analytics)
+				}
+			default:
+				out <- e
+			}
+		case <-tick:
+			// Publish the latest analytics of the pairs whose book has changed
+			pairs := make([]string, 0, len(a.pending))
+			for pair := range a.pending {
+				pairs = append(pairs, pair)
+			}
+			sort.Strings(pairs)
+			for _, pair := range pairs {
+				if analytics := a.compute(pair); analytics != nil {
+					out <- a.newEvent(a.pending[pair], analytics)
+				}
+			}
+			a.pending = map[string]event.Event{}
+			timer.Reset(a.interval)
+		}
+	}
+}
+
+// Apply the book snapshot or update carried by the event and return the pair.
+func (a *bookAnalyzer) process(e event.Event) (string, error) {
+	if e.Type() == string(events.BookSnapshot) {
+		snapshot := new(messages.BookSnapshot)
+		if err := a.codec.Unmarshal(e.Data(), snapshot); err != nil {
+			return "", err
+		}
+		book := &localBook{}
+		book.asks = applyBookEntries(book.asks, snapshot.Data.Asks, true, a.depth)
+		book.bids = applyBookEntries(book.bids, snapshot.Data.Bids, false, a.depth)
+		a.books[snapshot.Pair] = book
+		return snapshot.Pair, nil
+	}
+	update := new(messages.BookUpdate)
+	if err := a.codec.Unmarshal(e.Data(), update); err != nil {
+		return "", err
+	}
+	book, ok := a.books[update.Pair]
+	if !ok {
+		return "", fmt.Errorf("book update received before snapshot for %s", update.Pair)
+	}
+	book.asks = applyBookEntries(book.asks, update.Data.Asks, true, a.depth)
+	book.bids = applyBookEntries(book.bids, update.Data.Bids, false, a.depth)
+	return update.Pair, nil
+}
+
+// Compute the analytics of the book of a pair. Nil is returned if the book is unknown or if one
+// side of the book is empty.
+func (a *bookAnalyzer) compute(pair string) *BookAnalytics {
+	book, ok := a.books[pair]
+	if !ok || len(book.bids) == 0 || len(book.asks) == 0 {
+		return nil
+	}
+	bestBid, bestAsk := book.bids[0], book.asks[0]
+	analytics := &BookAnalytics{
+		Pair:    pair,
+		Levels:  a.levels,
+		BestBid: bestBid.price,
+		BestAsk: bestAsk.price,
+		Spread:  bestAsk.price - bestBid.price,
+		Mid:     (bestBid.price + bestAsk.price) / 2,
+		Time:    a.clock.Now(),
+	}
+	analytics.Microprice = (bestBid.price*bestAsk.volume + bestAsk.price*bestBid.volume) / (bestBid.volume + bestAsk.volume)
+	bidVWAP, bidVolume := volumeWeightedPrice(book.bids, a.levels)
+	askVWAP, askVolume := volumeWeightedPrice(book.asks, a.levels)
+	analytics.BidVolume = bidVolume
+	analytics.AskVolume = askVolume
+	analytics.WeightedMid = (bidVWAP + askVWAP) / 2
+	analytics.Imbalance = (bidVolume - askVolume) / (bidVolume + askVolume)
+	return analytics
+}
+
+// Build a book_analytics event from the source event and the analytics.
+func (a *bookAnalyzer) newEvent(source event.Event, analytics *BookAnalytics) event.Event {
+	e := event.New()
+	e.Context.SetType(string(events.BookAnalytics))
+	e.Context.SetSource(tracing.PackageName)
+	e.SetSubject(analytics.Pair)
+	e.SetData("application/json", analytics)
+	// Propagate tracing context from the source event
+	otelObs.InjectDistributedTracingExtension(otelObs.ExtractDistributedTracingExtension(context.Background(), source), e)
+	return e
+}
+
+// Compute the volume weighted average price and the total volume of the n top levels.
+func volumeWeightedPrice(levels []bookLevel, n int) (float64, float64) {
+	if len(levels) < n {
+		n = len(levels)
+	}
+	notional, volume := 0.0, 0.0
+	for i := 0; i < n; i++ {
+		notional += levels[i].price * levels[i].volume
+		volume += levels[i].volume
+	}
+	return notional / volume, volume
+}
@@ -0,0 +1,141 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the book analyzer used by SubscribeBookAnalytics
+type BookAnalyticsUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestBookAnalyticsUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(BookAnalyticsUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the book analyzer when analytics are published on each book change.
+//
+// Test will ensure:
+//   - Imbalance, microprice and weighted mid are computed over the configured top levels.
+//   - Analytics are updated when the book changes and are not published while a side is empty.
+//   - connection_interrupted events are forwarded and output channel is closed with input.
+func (suite *BookAnalyticsUnitTestSuite) TestBookAnalyzer() {
+	in := make(chan event.Event, 10)
+	out := make(chan event.Event, 10)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	go newBookAnalyzer(10, BookAnalyticsOptions{Levels: 2}, clock.NewFakeClock(now), nil).run(in, out)
+	// Snapshot -> analytics over 2 top levels
+	in <- newBookEvent(events.BookSnapshot, `[0,{"as":[["101.0","1.0","1"],["102.0","3.0","1"],["103.0","5.0","1"]],"bs":[["99.0","3.0","1"],["98.0","1.0","1"],["97.0","5.0","1"]]},"book-10","XBT/USD"]`)
+	analytics := suite.readBookAnalytics(out)
+	require.Equal(suite.T(), "XBT/USD", analytics.Pair)
+	require.Equal(suite.T(), 2, analytics.Levels)
+	require.Equal(suite.T(), 99.0, analytics.BestBid)
+	require.Equal(suite.T(), 101.0, analytics.BestAsk)
+	require.Equal(suite.T(), 2.0, analytics.Spread)
+	require.Equal(suite.T(), 100.0, analytics.Mid)
+	// (99 * 1 + 101 * 3) / 4
+	require.InDelta(suite.T(), 100.5, analytics.Microprice, 1e-9)
+	require.Equal(suite.T(), 4.0, analytics.BidVolume)
+	require.Equal(suite.T(), 4.0, analytics.AskVolume)
+	require.Zero(suite.T(), analytics.Imbalance)
+	// ((99 * 3 + 98) / 4 + (101 + 102 * 3) / 4) / 2
+	require.InDelta(suite.T(), 100.25, analytics.WeightedMid, 1e-9)
+	require.True(suite.T(), now.Equal(analytics.Time))
+	// Update of the best bid volume
+	in <- newBookEvent(events.BookUpdate, `[0,{"b":[["99.0","7.0","2"]],"c":"0"},"book-10","XBT/USD"]`)
+	analytics = suite.readBookAnalytics(out)
+	require.Equal(suite.T(), 8.0, analytics.BidVolume)
+	require.InDelta(suite.T(), 1.0/3, analytics.Imbalance, 1e-9)
+	// Empty ask side -> no analytics
+	in <- newBookEvent(events.BookSnapshot, `[0,{"as":[],"bs":[["99.0","1.0","3"]]},"book-10","XBT/USD"]`)
+	// Connection interrupted -> forwarded, update before new snapshot is discarded
+	interrupted := event.New()
+	interrupted.SetType(string(events.ConnectionInterrupted))
+	in <- interrupted
+	in <- newBookEvent(events.BookUpdate, `[0,{"b":[["98.0","8.0","4"]],"c":"0"},"book-10","XBT/USD"]`)
+	in <- newBookEvent(events.BookSnapshot, `[0,{"as":[["100.0","1.0","5"]],"bs":[["99.0","3.0","5"]]},"book-10","XBT/USD"]`)
+	close(in)
+	e := <-out
+	require.Equal(suite.T(), string(events.ConnectionInterrupted), e.Type())
+	analytics = suite.readBookAnalytics(out)
+	require.Equal(suite.T(), 0.5, analytics.Imbalance)
+	// Output is closed
+	_, ok := <-out
+	require.False(suite.T(), ok)
+}
+
+// Test the book analyzer when analytics are published at a fixed interval.
+//
+// Test will ensure:
+//   - No analytics are published before the interval has elapsed.
+//   - Only the latest analytics of the pairs whose book has changed are published, sorted by pair.
+func (suite *BookAnalyticsUnitTestSuite) TestBookAnalyzerInterval() {
+	in := make(chan event.Event)
+	out := make(chan event.Event, 10)
+	fake := clock.NewFakeClock(time.Now())
+	go newBookAnalyzer(10, BookAnalyticsOptions{Levels: 1, Interval: time.Second}, fake, nil).run(in, out)
+	fake.BlockUntil(1)
+	in <- newBookEvent(events.BookSnapshot, `[0,{"as":[["101.0","1.0","1"]],"bs":[["99.0","1.0","1"]]},"book-10","XBT/USD"]`)
+	in <- newBookEvent(events.BookSnapshot, `[0,{"as":[["11.0","1.0","1"]],"bs":[["9.0","1.0","1"]]},"book-10","ETH/USD"]`)
+	in <- newBookEvent(events.BookUpdate, `[0,{"a":[["101.0","3.0","2"]],"c":"0"},"book-10","XBT/USD"]`)
+	require.Empty(suite.T(), out)
+	fake.Advance(time.Second)
+	require.Equal(suite.T(), "ETH/USD", suite.readBookAnalytics(out).Pair)
+	analytics := suite.readBookAnalytics(out)
+	require.Equal(suite.T(), "XBT/USD", analytics.Pair)
+	require.Equal(suite.T(), -0.5, analytics.Imbalance)
+	// Nothing changed -> nothing published on next tick
+	fake.BlockUntil(1)
+	fake.Advance(time.Second)
+	fake.BlockUntil(1)
+	in <- newBookEvent(events.BookUpdate, `[0,{"b":[["9.0","3.0","3"]],"c":"0"},"book-10","ETH/USD"]`)
+	require.Empty(suite.T(), out)
+	fake.Advance(time.Second)
+	analytics = suite.readBookAnalytics(out)
+	require.Equal(suite.T(), "ETH/USD", analytics.Pair)
+	require.Equal(suite.T(), 0.5, analytics.Imbalance)
+	close(in)
+	_, ok := <-out
+	require.False(suite.T(), ok)
+}
+
+// Test SubscribeBookAnalytics options are validated before subscribing.
+func (suite *BookAnalyticsUnitTestSuite) TestSubscribeBookAnalyticsOptions() {
+	client := &KrakenSpotPublicWebsocketClient{newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)}
+	err := client.SubscribeBookAnalytics(context.Background(), []string{"XBT/USD"}, messages.D10, BookAnalyticsOptions{Levels: 25}, make(chan event.Event))
+	require.ErrorContains(suite.T(), err, "levels")
+	err = client.SubscribeBookAnalytics(context.Background(), []string{"XBT/USD"}, messages.D10, BookAnalyticsOptions{Interval: -time.Second}, make(chan event.Event))
+	require.ErrorContains(suite.T(), err, "interval")
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Read and parse the next event as a book_analytics event.
+func (suite *BookAnalyticsUnitTestSuite) readBookAnalytics(out chan event.Event) *BookAnalytics {
+	e := <-out
+	require.Equal(suite.T(), string(events.BookAnalytics), e.Type())
+	analytics := new(BookAnalytics)
+	require.NoError(suite.T(), json.Unmarshal(e.Data(), analytics))
+	return analytics
+}
@@ -0,0 +1,301 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	otelObs "github.com/cloudevents/sdk-go/observability/opentelemetry/v2/client"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/codec"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
+)
+
+// Capacity of the channel used to receive book messages from the underlying book subscription.
+const bookTopChannelCapacity = 100
+
+// Data of a book_top event: top levels of the book for a pair.
+type BookTop struct {
+	// Pair
+	Pair string `json:"pair"`
+	// Top ask levels, ordered by ascending price.
+	Asks []messages.BookMessageEntry `json:"asks"`
+	// Top bid levels, ordered by descending price.
+	Bids []messages.BookMessageEntry `json:"bids"`
+}
+
+// A price level of a book maintained by a book top filter.
+type bookLevel struct {
+	// Parsed price
+	price float64
+	// Parsed volume
+	volume float64
+	// Entry as received from the server
+	entry messages.BookMessageEntry
+}
+
+// Book maintained by a book top filter for a single pair.
+type localBook struct {
+	// Asks, ordered by ascending price
+	asks []bookLevel
+	// Bids, ordered by descending price
+	bids []bookLevel
+}
+
+// Filter which maintains the books of the subscribed pairs from book snapshots and updates and
+// which publishes the top levels of a book only when they have changed significantly.
+type bookTopFilter struct {
+	// Book depth
+	depth int
+	// Number of top levels to watch
+	levels int
+	// Minimum relative change of price or volume of a top level which triggers an event
+	minChange float64
+	// Books by pair
+	books map[string]*localBook
+	// Last published top levels by pair
+	published map[string]*BookTop
+	// JSON codec used to parse book messages
+	codec codec.JSONCodec
+	// Optional function which tells whether the output channel must be left open when the input
+	// channel is closed. If nil, the output channel is closed.
+	keepOutOpen func() bool
+}
+
+// # Description
+//
+// Subscribe to the book channel and only publish an event when the top levels of the book of a
+// pair change significantly. This reduces the load on consumers which only care about the top of
+// the book (UI, alerting, ...).
+//
+// Books are maintained internally from the book snapshots and updates. A book_top event (Cf.
+// BookTop) is published for a pair when:
+//
+//   - The first snapshot for the pair is received.
+//   - A level appears in or disappears from the top levels.
+//   - The relative change of price or volume of one of the top levels is greater than minChange.
+//
+// connection_interrupted events are forwarded and the books are reset: a new book_top event is
+// published when the snapshot is received after the client has resubscribed.
+//
+// Use UnsubscribeBook to unsubscribe: the provided channel will be closed once the underlying
+// book subscription is closed unless the client is configured to keep channels open on
+// unsubscribe (Cf. SetKeepChannelsOpenOnUnsubscribe).
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - pairs: Pairs to subscribe to.
+//   - depth: Depth of the book subscription.
+//   - levels: Number of top levels to watch. Must be between 1 and depth.
+//   - minChange: Minimum relative change (ex: 0.01 for 1%) of price or volume of a top level which
+//     triggers an event. 0 means any change triggers an event.
+//   - rcv: Channel used to publish book_top and connection_interrupted events. Blocking writes are
+//     used.
+//
+// # Return
+//
+// An error if levels or minChange are invalid or if SubscribeBook fails.
+func (client *KrakenSpotPublicWebsocketClient) SubscribeBookTop(ctx context.Context, pairs []string, depth messages.DepthEnum, levels int, minChange float64, rcv chan event.Event) error {
+	if levels < 1 || levels > int(depth) {
+		return fmt.Errorf("subscribe book top failed: levels must be between 1 and %d. Got %d", depth, levels)
+	}
+	if minChange < 0 {
+		return fmt.Errorf("subscribe book top failed: minChange must be positive. Got %f", minChange)
+	}
+	in := make(chan event.Event, bookTopChannelCapacity)
+	err := client.subscribeBook(ctx, pairs, depth, in, nil, true)
+	if err != nil {
+		return fmt.Errorf("subscribe book top failed: %w", err)
+	}
+	filter := newBookTopFilter(int(depth), levels, minChange, client.codec)
+	filter.keepOutOpen = client.keepChannelsOpenOnUnsubscribe.Load
+	go filter.run(in, rcv)
+	return nil
+}
+
+// Create a new book top filter. If jsonCodec is nil, codec.StandardJSONCodec is used.
+func newBookTopFilter(depth int, levels int, minChange float64, jsonCodec codec.JSONCodec) *bookTopFilter {
+	if jsonCodec == nil {
+		jsonCodec = codec.StandardJSONCodec{}
+	}
+	return &bookTopFilter{
+		depth:     depth,
+		levels:    levels,
+		minChange: minChange,
+		books:     map[string]*localBook{},
+		published: map[string]*BookTop{},
+		codec:     jsonCodec,
+	}
+}
+
+// Process events from the input channel until it is closed and publish book_top and
+// connection_interrupted events on the output channel. The output channel is closed when the
+// input channel is closed unless keepOutOpen returns true.
+func (f *bookTopFilter) run(in chan event.Event, out chan event.Event) {
+	defer func() {
+		if f.keepOutOpen == nil || !f.keepOutOpen() {
+			close(out)
+		}
+	}()
+	for e := range in {
+		switch e.Type() {
+		case string(events.ConnectionInterrupted):
+			// Reset books and forward event
+			f.books = map[string]*localBook{}
+			f.published = map[string]*BookTop{}
+			out <- e
+		case string(events.BookSnapshot), string(events.BookUpdate):
+			top, err := f.process(e)
+			if err != nil {
+				continue
+			}
+			if top != nil {
+				out <- f.newEvent(e, top)
+			}
+		}
+	}
+}
+
+// Apply the book snapshot or update carried by the event and return the top levels if they have
+// changed significantly since the last published top levels. Nil is returned otherwise.
+func (f *bookTopFilter) process(e event.Event) (*BookTop, error) {
+	var pair string
+	switch e.Type() {
+	case string(events.BookSnapshot):
+		snapshot := new(messages.BookSnapshot)
+		if err := f.codec.Unmarshal(e.Data(), snapshot); err != nil {
+			return nil, err
+		}
+		pair = snapshot.Pair
+		book := &localBook{}
+		f.books[pair] = book
+		book.asks = applyBookEntries(book.asks, snapshot.Data.Asks, true, f.depth)
+		book.bids = applyBookEntries(book.bids, snapshot.Data.Bids, false, f.depth)
+	default:
+		update := new(messages.BookUpdate)
+		if err := f.codec.Unmarshal(e.Data(), update); err != nil {
+			return nil, err
+		}
+		pair = update.Pair
+		book, ok := f.books[pair]
+		if !ok {
+			// Discard updates received before the snapshot
+			return nil, fmt.Errorf("book update received before snapshot for %s", pair)
+		}
+		book.asks = applyBookEntries(book.asks, update.Data.Asks, true, f.depth)
+		book.bids = applyBookEntries(book.bids, update.Data.Bids, false, f.depth)
+	}
+	book := f.books[pair]
+	top := &BookTop{
+		Pair: pair,
+		Asks: topEntries(book.asks, f.levels),
+		Bids: topEntries(book.bids, f.levels),
+	}
+	last, ok := f.published[pair]
+	if ok && !f.hasChanged(last.Asks, top.Asks) && !f.hasChanged(last.Bids, top.Bids) {
+		return nil, nil
+	}
+	f.published[pair] = top
+	return top, nil
+}
+
+// Returns true if the top levels have changed significantly.
+func (f *bookTopFilter) hasChanged(previous []messages.BookMessageEntry, current []messages.BookMessageEntry) bool {
+	if len(previous) != len(current) {
+		return true
+	}
+	for i := range previous {
+		if relativeChange(previous[i].Price, current[i].Price) > f.minChange ||
+			relativeChange(previous[i].Volume, current[i].Volume) > f.minChange {
+			return true
+		}
+	}
+	return false
+}
+
+// Build a book_top event from the source event and the top levels.
+func (f *bookTopFilter) newEvent(source event.Event, top *BookTop) event.Event {
+	e := event.New()
+	e.Context.SetType(string(events.BookTop))
+	e.Context.SetSource(tracing.PackageName)
+	e.SetSubject(top.Pair)
+	e.SetData("application/json", top)
+	// Propagate tracing context from the source event
+	otelObs.InjectDistributedTracingExtension(otelObs.ExtractDistributedTracingExtension(context.Background(), source), e)
+	return e
+}
+
+// Apply the entries to the provided sorted levels and return the updated levels truncated to the
+// book depth. A zero volume removes the level.
+func applyBookEntries(levels []bookLevel, entries []messages.BookMessageEntry, asks bool, depth int) []bookLevel {
+	for _, entry := range entries {
+		price, err := entry.Price.Float64()
+		if err != nil {
+			continue
+		}
+		volume, err := entry.Volume.Float64()
+		if err != nil {
+			continue
+		}
+		// Remove existing level for the price if any
+		for i := range levels {
+			if levels[i].price == price {
+				levels = append(levels[:i], levels[i+1:]...)
+				break
+			}
+		}
+		if volume > 0 {
+			levels = append(levels, bookLevel{price: price, volume: volume, entry: messages.BookMessageEntry{
+				Price:     entry.Price,
+				Volume:    entry.Volume,
+				Timestamp: entry.Timestamp,
+			}})
+		}
+	}
+	sort.Slice(levels, func(i, j int) bool {
+		if asks {
+			return levels[i].price < levels[j].price
+		}
+		return levels[i].price > levels[j].price
+	})
+	if len(levels) > depth {
+		levels = levels[:depth]
+	}
+	return levels
+}
+
+// Get the entries of the n top levels.
+func topEntries(levels []bookLevel, n int) []messages.BookMessageEntry {
+	if len(levels) < n {
+		n = len(levels)
+	}
+	entries := make([]messages.BookMessageEntry, n)
+	for i := 0; i < n; i++ {
+		entries[i] = levels[i].entry
+	}
+	return entries
+}
+
+// Compute the relative change between two numbers. A change from zero is considered infinite.
+func relativeChange(previous json.Number, current json.Number) float64 {
+	p, err := previous.Float64()
+	if err != nil {
+		return math.Inf(1)
+	}
+	c, err := current.Float64()
+	if err != nil {
+		return math.Inf(1)
+	}
+	if p == c {
+		return 0
+	}
+	if p == 0 {
+		return math.Inf(1)
+	}
+	return math.Abs(c-p) / math.Abs(p)
+}
@@ -0,0 +1,128 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the book top filter used by SubscribeBookTop
+type BookTopUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestBookTopUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(BookTopUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the book top filter with a sequence of snapshot, updates and connection interruption.
+//
+// Test will ensure:
+//   - An event is published for the first snapshot.
+//   - Updates outside of the top levels or below the threshold do not produce events.
+//   - Updates which change the top levels significantly produce events.
+//   - connection_interrupted events are forwarded and output channel is closed with input.
+func (suite *BookTopUnitTestSuite) TestBookTopFilter() {
+	in := make(chan event.Event, 10)
+	out := make(chan event.Event, 10)
+	// Watch 2 top levels with a 1% threshold
+	go newBookTopFilter(10, 2, 0.01, nil).run(in, out)
+	// Snapshot -> event
+	in <- newBookEvent(events.BookSnapshot, `[0,{"as":[["100.0","1.0","1"],["101.0","2.0","1"],["102.0","3.0","1"]],"bs":[["99.0","1.0","1"],["98.0","2.0","1"],["97.0","3.0","1"]]},"book-10","XBT/USD"]`)
+	top := suite.readBookTop(out)
+	require.Equal(suite.T(), "XBT/USD", top.Pair)
+	require.Len(suite.T(), top.Asks, 2)
+	require.Equal(suite.T(), "100.0", top.Asks[0].Price.String())
+	require.Equal(suite.T(), "99.0", top.Bids[0].Price.String())
+	// Update of the third level -> no event
+	in <- newBookEvent(events.BookUpdate, `[0,{"a":[["102.0","5.0","2"]],"c":"0"},"book-10","XBT/USD"]`)
+	// Small volume change on the best bid (0.5%) -> no event
+	in <- newBookEvent(events.BookUpdate, `[0,{"b":[["99.0","1.005","2"]],"c":"0"},"book-10","XBT/USD"]`)
+	// Best ask is removed -> event
+	in <- newBookEvent(events.BookUpdate, `[0,{"a":[["100.0","0.0","3"]],"c":"0"},"book-10","XBT/USD"]`)
+	top = suite.readBookTop(out)
+	require.Equal(suite.T(), "101.0", top.Asks[0].Price.String())
+	require.Equal(suite.T(), "102.0", top.Asks[1].Price.String())
+	require.Equal(suite.T(), "5.0", top.Asks[1].Volume.String())
+	require.Equal(suite.T(), "1.005", top.Bids[0].Volume.String())
+	// Large volume change on second bid -> event
+	in <- newBookEvent(events.BookUpdate, `[0,{"b":[["98.0","4.0","4"]],"c":"0"},"book-10","XBT/USD"]`)
+	top = suite.readBookTop(out)
+	require.Equal(suite.T(), "4.0", top.Bids[1].Volume.String())
+	// Connection interrupted -> forwarded, update before new snapshot is discarded
+	interrupted := event.New()
+	interrupted.SetType(string(events.ConnectionInterrupted))
+	in <- interrupted
+	in <- newBookEvent(events.BookUpdate, `[0,{"b":[["98.0","8.0","5"]],"c":"0"},"book-10","XBT/USD"]`)
+	in <- newBookEvent(events.BookSnapshot, `[0,{"as":[["100.0","1.0","6"]],"bs":[["99.0","1.0","6"]]},"book-10","XBT/USD"]`)
+	close(in)
+	e := <-out
+	require.Equal(suite.T(), string(events.ConnectionInterrupted), e.Type())
+	top = suite.readBookTop(out)
+	require.Len(suite.T(), top.Asks, 1)
+	require.Len(suite.T(), top.Bids, 1)
+	// Output is closed
+	_, ok := <-out
+	require.False(suite.T(), ok)
+}
+
+// Test the book top filter leaves the output channel open when keepOutOpen returns true.
+func (suite *BookTopUnitTestSuite) TestBookTopFilterKeepOutOpen() {
+	in := make(chan event.Event)
+	out := make(chan event.Event, 1)
+	filter := newBookTopFilter(10, 2, 0, nil)
+	filter.keepOutOpen = func() bool { return true }
+	done := make(chan struct{})
+	go func() {
+		filter.run(in, out)
+		close(done)
+	}()
+	close(in)
+	<-done
+	// Output channel is still open
+	out <- event.New()
+	require.Len(suite.T(), out, 1)
+}
+
+// Test SubscribeBookTop input validation.
+func (suite *BookTopUnitTestSuite) TestSubscribeBookTopInvalidInputs() {
+	client := NewKrakenSpotPublicWebsocketClient(nil, nil, nil, nil, nil)
+	require.Error(suite.T(), client.SubscribeBookTop(context.Background(), []string{"XBT/USD"}, 10, 0, 0, make(chan event.Event)))
+	require.Error(suite.T(), client.SubscribeBookTop(context.Background(), []string{"XBT/USD"}, 10, 11, 0, make(chan event.Event)))
+	require.Error(suite.T(), client.SubscribeBookTop(context.Background(), []string{"XBT/USD"}, 10, 5, -1, make(chan event.Event)))
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build a book event of the provided type with the provided payload.
+func newBookEvent(etype events.WebsocketClientEventTypeEnum, payload string) event.Event {
+	e := event.New()
+	e.SetType(string(etype))
+	e.SetData("application/json", []byte(payload))
+	return e
+}
+
+// Read and parse the next event as a book_top event.
+func (suite *BookTopUnitTestSuite) readBookTop(out chan event.Event) *BookTop {
+	e := <-out
+	require.Equal(suite.T(), string(events.BookTop), e.Type())
+	top := new(BookTop)
+	require.NoError(suite.T(), json.Unmarshal(e.Data(), top))
+	return top
+}
@@ -0,0 +1,113 @@
+//go:build !goctopus_publiconly
+
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Default interval between two GetOpenOrders requests made by CancelAllAndVerify.
+const DefaultCancelAllVerifyInterval = time.Second
+
+// # Description
+//
+// Set the interval between two GetOpenOrders requests made by CancelAllAndVerify.
+//
+// # Inputs
+//
+//   - interval: Interval between two requests. A zero or negative value resets the default
+//     interval (DefaultCancelAllVerifyInterval).
+func (client *KrakenSpotPrivateWebsocketClient) SetCancelAllVerifyInterval(interval time.Duration) {
+	if interval < 0 {
+		interval = 0
+	}
+	client.cancelAllVerifyInterval.Store(int64(interval))
+}
+
+// # Description
+//
+// Cancel all orders and verify that no open order remains. CancellAllOrders returns the number
+// of canceled orders but orders sent concurrently can still be open once the command has been
+// processed. This method cancels all orders and then polls the REST GetOpenOrders endpoint,
+// which provides a consistent snapshot of the open orders, until no open order remains.
+//
+// Surviving orders are not canceled again: use the returned orders to decide what to do with
+// them.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. Use a context with a timeout or a
+//     deadline to limit the verification.
+//
+// # Return
+//
+// The orders which were still open when the verification stopped, by order ID. The map is empty
+// if no open order remains. An error is returned if:
+//
+//   - CancellAllOrders fails. In that case, no order is returned.
+//   - A GetOpenOrders request fails or the server replies with an error. In that case, the orders
+//     returned by the last successful request (if any) are returned.
+//   - ctx is done before all orders are canceled. In that case, an OperationInterruptedError is
+//     returned with the orders returned by the last successful request.
+func (client *KrakenSpotPrivateWebsocketClient) CancelAllAndVerify(ctx context.Context) (map[string]*account.OrderInfo, error) {
+	// Tracing: Start span
+	ctx, span := client.tracer.Start(ctx, "cancel_all_and_verify", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	resp, err := client.CancellAllOrders(ctx)
+	if err != nil {
+		return nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all and verify failed: %w", err))
+	}
+	span.SetAttributes(attribute.Int("count", resp.Count))
+	interval := time.Duration(client.cancelAllVerifyInterval.Load())
+	if interval <= 0 {
+		interval = DefaultCancelAllVerifyInterval
+	}
+	var survivors map[string]*account.OrderInfo
+	for polls := 1; ; polls++ {
+		open, err := client.getOpenOrders(ctx)
+		if err != nil {
+			return survivors, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all and verify failed: %w", err))
+		}
+		survivors = open
+		span.SetAttributes(attribute.Int("polls", polls), attribute.Int("survivors", len(survivors)))
+		if len(survivors) == 0 {
+			span.SetStatus(codes.Ok, codes.Ok.String())
+			return survivors, nil
+		}
+		client.logger.Printf("%d orders are still open after cancel all orders\n", len(survivors))
+		timer := client.clock.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return survivors, tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "cancel_all_and_verify", Root: fmt.Errorf("%d orders are still open: %w", len(survivors), ctx.Err())})
+		case <-timer.C():
+		}
+	}
+}
+
+// Get the open orders with the REST client used to get websocket tokens.
+func (client *KrakenSpotPrivateWebsocketClient) getOpenOrders(ctx context.Context) (map[string]*account.OrderInfo, error) {
+	source := client.tokenSource
+	resp, _, err := source.restClient.GetOpenOrders(ctx, source.cgen.GenerateNonce(), nil, source.secopts)
+	if err != nil {
+		return nil, fmt.Errorf("get open orders failed: %w", err)
+	}
+	if len(resp.Error) > 0 {
+		return nil, &OperationError{Operation: "get_open_orders", Root: fmt.Errorf("get open orders failed: %v", resp.Error)}
+	}
+	open := map[string]*account.OrderInfo{}
+	if resp.Result != nil {
+		for id, order := range resp.Result.Open {
+			open[id] = order
+		}
+	}
+	return open, nil
+}
@@ -0,0 +1,148 @@
+//go:build !goctopus_publiconly
+
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/account"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for CancelAllAndVerify
+type CancelAllAndVerifyTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestCancelAllAndVerifyTestSuite(t *testing.T) {
+	suite.Run(t, new(CancelAllAndVerifyTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test CancelAllAndVerify when surviving orders are eventually canceled.
+//
+// Test will ensure:
+//   - All orders are canceled with a cancelAll request.
+//   - Open orders are polled until no open order remains.
+//   - An empty map is returned.
+func (suite *CancelAllAndVerifyTestSuite) TestCancelAllAndVerify() {
+	client, restClient, fake := suite.newTestClient()
+	client.SetCancelAllVerifyInterval(time.Minute)
+	// Signal the first poll: the write timer used to send the cancelAll request is stopped by then
+	polled := make(chan struct{})
+	restClient.On("GetOpenOrders", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) { close(polled) }).
+		Return(suite.openOrders("OABC"), nil, nil).Once()
+	restClient.On("GetOpenOrders", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(suite.openOrders(), nil, nil).Once()
+	go func() {
+		<-polled
+		fake.BlockUntil(1)
+		fake.Advance(time.Minute)
+	}()
+	survivors, err := client.CancelAllAndVerify(context.Background())
+	require.NoError(suite.T(), err)
+	require.Empty(suite.T(), survivors)
+	restClient.AssertNumberOfCalls(suite.T(), "GetOpenOrders", 2)
+}
+
+// Test CancelAllAndVerify when orders survive until the context is done.
+//
+// Test will ensure:
+//   - An OperationInterruptedError which wraps the context error is returned.
+//   - The surviving orders are returned.
+func (suite *CancelAllAndVerifyTestSuite) TestCancelAllAndVerifyTimeout() {
+	client, restClient, fake := suite.newTestClient()
+	polled := make(chan struct{})
+	restClient.On("GetOpenOrders", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(mock.Arguments) { close(polled) }).
+		Return(suite.openOrders("OABC", "ODEF"), nil, nil).Once()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-polled
+		fake.BlockUntil(1)
+		cancel()
+	}()
+	survivors, err := client.CancelAllAndVerify(ctx)
+	operr := new(OperationInterruptedError)
+	require.True(suite.T(), errors.As(err, &operr))
+	require.ErrorIs(suite.T(), err, context.Canceled)
+	require.Len(suite.T(), survivors, 2)
+	require.Contains(suite.T(), survivors, "OABC")
+	require.Contains(suite.T(), survivors, "ODEF")
+}
+
+// Test CancelAllAndVerify when GetOpenOrders fails.
+//
+// Test will ensure:
+//   - An OperationError which contains the server errors is returned.
+func (suite *CancelAllAndVerifyTestSuite) TestCancelAllAndVerifyRESTError() {
+	client, restClient, _ := suite.newTestClient()
+	restClient.On("GetOpenOrders", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&account.GetOpenOrdersResponse{KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{"EAPI:Invalid nonce"}}}, nil, nil).Once()
+	survivors, err := client.CancelAllAndVerify(context.Background())
+	operr := new(OperationError)
+	require.True(suite.T(), errors.As(err, &operr))
+	require.ErrorContains(suite.T(), err, "EAPI:Invalid nonce")
+	require.Nil(suite.T(), survivors)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build a private client with a mocked REST client, a fake clock and a mocked connection which
+// answers cancelAll requests.
+func (suite *CancelAllAndVerifyTestSuite) newTestClient() (*KrakenSpotPrivateWebsocketClient, *rest.MockKrakenSpotRESTClient, *clock.FakeClock) {
+	restClient := rest.NewMockKrakenSpotRESTClient()
+	restClient.On("GetWebsocketToken", mock.Anything, mock.Anything, mock.Anything).
+		Return(rest.NewMockGetWebsocketTokenResponse("token", 900), nil, nil)
+	client := &KrakenSpotPrivateWebsocketClient{
+		krakenSpotWebsocketClient: newKrakenSpotWebsocketClient(newWebsocketTokenSource(restClient, noncegen.NewHFNonceGenerator(), nil), nil, nil, nil, nil, nil),
+	}
+	fake := clock.NewFakeClock(time.Now())
+	client.SetClock(fake)
+	conn := newConnectionMock()
+	conn.On("Write", mock.Anything, wsadapters.Text, mock.Anything).Run(func(args mock.Arguments) {
+		req := new(messages.CancelAllOrdersRequest)
+		if err := json.Unmarshal(args.Get(2).([]byte), req); err != nil {
+			panic(err)
+		}
+		go client.handleCancelAllOrdersStatus(context.Background(), nil, nil, nil, nil, "", 0, []byte(fmt.Sprintf(`{"event":"cancelAllStatus","reqid":%d,"status":"ok","count":2}`, req.RequestId)))
+	}).Return(nil)
+	client.conn = conn
+	return client, restClient, fake
+}
+
+// Build a GetOpenOrders response with the provided open order IDs.
+func (suite *CancelAllAndVerifyTestSuite) openOrders(ids ...string) *account.GetOpenOrdersResponse {
+	open := map[string]*account.OrderInfo{}
+	for _, id := range ids {
+		open[id] = &account.OrderInfo{Status: "open"}
+	}
+	return &account.GetOpenOrdersResponse{
+		KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+		Result:                 &account.GetOpenOrdersResult{Open: open},
+	}
+}
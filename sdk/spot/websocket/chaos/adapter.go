@@ -0,0 +1,409 @@
+// Package chaos provides a websocket connection adapter decorator which injects faults (dropped
+// and corrupted messages, delayed writes, failed dials and forced closes) in the connection used
+// by a websocket engine. It is meant to verify the resilience of the subscription recovery logic
+// of applications, not to be used in production.
+//
+// The decorated adapter is provided to the engine in place of the original adapter:
+//
+//	adapter, err := chaos.NewConnectionAdapter(websocket.NewWebsocketConnectionAdapter(nil), chaos.Config{
+//		DropReadProbability: 0.01,
+//		CloseAfter:          5 * time.Minute,
+//	})
+//	engine, err := wscengine.NewWebsocketEngine(target, adapter, client, opts, tracerProvider)
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+)
+
+// Error wrapped by the errors returned because of an injected fault.
+var ErrInjectedFault = errors.New("chaos: injected fault")
+
+// Reason of the close error returned by Read when the connection has been closed by the adapter.
+const ForcedCloseReason = "chaos: forced close"
+
+// Faults injected by a ConnectionAdapter. Probabilities are between 0 (never) and 1 (always):
+// the zero value does not inject any fault.
+type Config struct {
+	// Probability that a connection attempt fails without contacting the server.
+	DialFailureProbability float64
+	// Probability that a message received from the server is dropped.
+	DropReadProbability float64
+	// Probability that the payload of a message received from the server is corrupted: the
+	// payload is truncated at a random position.
+	CorruptReadProbability float64
+	// Probability that a message is dropped instead of being sent to the server. Write returns
+	// nil as if the message has been sent.
+	DropWriteProbability float64
+	// Probability that a message is sent to the server after WriteDelay.
+	DelayWriteProbability float64
+	// Delay applied to delayed writes.
+	WriteDelay time.Duration
+	// Probability that the connection is closed when a message is received from the server. The
+	// message is lost.
+	CloseProbability float64
+	// Close each connection once it has been open for this duration. Zero means never.
+	CloseAfter time.Duration
+	// Close each connection when this number of messages has been received from the server. The
+	// last message is lost. Zero means never.
+	CloseAfterMessages int
+	// Seed of the random number generator used to decide which faults are injected, so a run can
+	// be reproduced. Zero means a random seed. Only used by NewConnectionAdapter.
+	Seed int64
+}
+
+// Validate the configuration.
+func (config Config) Validate() error {
+	for name, p := range map[string]float64{
+		"DialFailureProbability": config.DialFailureProbability,
+		"DropReadProbability":    config.DropReadProbability,
+		"CorruptReadProbability": config.CorruptReadProbability,
+		"DropWriteProbability":   config.DropWriteProbability,
+		"DelayWriteProbability":  config.DelayWriteProbability,
+		"CloseProbability":       config.CloseProbability,
+	} {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("%s must be between 0 and 1: %v", name, p)
+		}
+	}
+	if config.WriteDelay < 0 || config.CloseAfter < 0 || config.CloseAfterMessages < 0 {
+		return fmt.Errorf("WriteDelay, CloseAfter and CloseAfterMessages cannot be negative")
+	}
+	return nil
+}
+
+// Counters of the faults injected by a ConnectionAdapter.
+type Stats struct {
+	// Number of failed connection attempts.
+	FailedDials uint64
+	// Number of dropped messages received from the server.
+	DroppedReads uint64
+	// Number of corrupted messages received from the server.
+	CorruptedReads uint64
+	// Number of messages which have not been sent to the server.
+	DroppedWrites uint64
+	// Number of delayed writes.
+	DelayedWrites uint64
+	// Number of connections closed by the adapter.
+	ForcedCloses uint64
+}
+
+// Websocket connection adapter decorator which injects faults in the decorated connection.
+type ConnectionAdapter struct {
+	wsadapters.WebsocketConnectionAdapterInterface
+	// Mutex held while a connection is opened or forcibly closed so a new connection cannot be
+	// opened before the decorated connection has been closed
+	dialMu sync.Mutex
+	// Mutex used to protect the fields below
+	mu sync.Mutex
+	// Injected faults
+	config Config
+	// Random number generator used to decide which faults are injected
+	rand *rand.Rand
+	// Clock used for delayed writes and time-based closes
+	clock clock.Clock
+	// True if a connection is open
+	connected bool
+	// True if the current connection has been closed by the adapter
+	forced bool
+	// True if the network connection has been closed by the adapter but the decorated adapter has
+	// not been closed yet: the decorated adapter must be closed before a new connection is opened
+	pendingDrop bool
+	// Number of messages received on the current connection
+	received int
+	// Channel closed when the current connection is closed. Used to stop the time-based close.
+	stop chan struct{}
+	// Injected faults counters
+	stats Stats
+}
+
+// # Description
+//
+// Build a connection adapter which injects faults in the provided adapter.
+//
+// # Inputs
+//
+//   - decorated: Connection adapter to inject faults in. Must not be nil.
+//   - config: Faults to inject.
+//
+// # Return
+//
+// The adapter or an error if the decorated adapter is nil or if the configuration is invalid.
+func NewConnectionAdapter(decorated wsadapters.WebsocketConnectionAdapterInterface, config Config) (*ConnectionAdapter, error) {
+	if decorated == nil {
+		return nil, fmt.Errorf("decorated connection adapter cannot be nil")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid chaos configuration: %w", err)
+	}
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &ConnectionAdapter{
+		WebsocketConnectionAdapterInterface: decorated,
+		config:                              config,
+		rand:                                rand.New(rand.NewSource(seed)),
+		clock:                               clock.NewSystemClock(),
+	}, nil
+}
+
+// Set the clock used for delayed writes and time-based closes. If nil, the system clock is used.
+//
+// The clock must be set before the first connection is opened.
+func (adapter *ConnectionAdapter) SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.NewSystemClock()
+	}
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	adapter.clock = c
+}
+
+// # Description
+//
+// Replace the injected faults (ex: to start injecting faults once subscriptions are set up or to
+// stop injecting them). CloseAfter applies to the connections opened after the call. The random
+// number generator is not seeded again.
+//
+// # Return
+//
+// An error if the configuration is invalid. In that case, the injected faults are not modified.
+func (adapter *ConnectionAdapter) SetConfig(config Config) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid chaos configuration: %w", err)
+	}
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	adapter.config = config
+	return nil
+}
+
+// Get a snapshot of the injected faults counters.
+func (adapter *ConnectionAdapter) Stats() Stats {
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	return adapter.stats
+}
+
+// # Description
+//
+// Open a connection with the decorated adapter unless a dial failure is injected.
+//
+// # Return
+//
+// The server response to the websocket handshake or an error. Injected failures wrap
+// ErrInjectedFault.
+func (adapter *ConnectionAdapter) Dial(ctx context.Context, target url.URL) (*http.Response, error) {
+	adapter.dialMu.Lock()
+	defer adapter.dialMu.Unlock()
+	adapter.mu.Lock()
+	if adapter.roll(adapter.config.DialFailureProbability) {
+		adapter.stats.FailedDials++
+		adapter.mu.Unlock()
+		return nil, fmt.Errorf("failed to connect to %s: %w", target.String(), ErrInjectedFault)
+	}
+	adapter.mu.Unlock()
+	adapter.dropForcedConnectionLocked(ctx)
+	resp, err := adapter.WebsocketConnectionAdapterInterface.Dial(ctx, target)
+	if err != nil {
+		return resp, err
+	}
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	adapter.connected = true
+	adapter.forced = false
+	adapter.received = 0
+	adapter.stop = make(chan struct{})
+	if adapter.config.CloseAfter > 0 {
+		go adapter.closeAfter(adapter.clock.NewTimer(adapter.config.CloseAfter), adapter.stop)
+	}
+	return resp, nil
+}
+
+// # Description
+//
+// Read a message with the decorated adapter and inject the configured faults: dropped messages
+// are skipped and the next message is read.
+//
+// # Return
+//
+// The message or an error. When the connection has been closed by the adapter, a
+// wsadapters.WebsocketCloseError with the 1006 status code (abnormal closure) and
+// ForcedCloseReason is returned, as if the connection had been dropped.
+func (adapter *ConnectionAdapter) Read(ctx context.Context) (wsadapters.MessageType, []byte, error) {
+	for {
+		msgType, msg, err := adapter.WebsocketConnectionAdapterInterface.Read(ctx)
+		adapter.mu.Lock()
+		if adapter.forced {
+			adapter.mu.Unlock()
+			adapter.dialMu.Lock()
+			adapter.dropForcedConnectionLocked(ctx)
+			adapter.dialMu.Unlock()
+			return msgType, nil, forcedCloseError()
+		}
+		if err != nil {
+			adapter.mu.Unlock()
+			return msgType, msg, err
+		}
+		adapter.received++
+		limit := adapter.config.CloseAfterMessages
+		if (limit > 0 && adapter.received >= limit) || adapter.roll(adapter.config.CloseProbability) {
+			adapter.mu.Unlock()
+			adapter.ForceClose(ctx)
+			adapter.dialMu.Lock()
+			adapter.dropForcedConnectionLocked(ctx)
+			adapter.dialMu.Unlock()
+			return msgType, nil, forcedCloseError()
+		}
+		if adapter.roll(adapter.config.DropReadProbability) {
+			adapter.stats.DroppedReads++
+			adapter.mu.Unlock()
+			continue
+		}
+		if len(msg) > 0 && adapter.roll(adapter.config.CorruptReadProbability) {
+			adapter.stats.CorruptedReads++
+			msg = msg[:adapter.rand.Intn(len(msg))]
+		}
+		adapter.mu.Unlock()
+		return msgType, msg, nil
+	}
+}
+
+// # Description
+//
+// Write a message with the decorated adapter and inject the configured faults.
+//
+// # Return
+//
+// Nil if the message has been sent or dropped. An error if the decorated adapter fails or if the
+// context is done while the write is delayed.
+func (adapter *ConnectionAdapter) Write(ctx context.Context, msgType wsadapters.MessageType, msg []byte) error {
+	adapter.mu.Lock()
+	delayed := adapter.roll(adapter.config.DelayWriteProbability)
+	delay := adapter.config.WriteDelay
+	dropped := adapter.roll(adapter.config.DropWriteProbability)
+	clk := adapter.clock
+	if delayed {
+		adapter.stats.DelayedWrites++
+	}
+	if dropped {
+		adapter.stats.DroppedWrites++
+	}
+	adapter.mu.Unlock()
+	if delayed && delay > 0 {
+		timer := clk.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C():
+		}
+	}
+	if dropped {
+		return nil
+	}
+	return adapter.WebsocketConnectionAdapterInterface.Write(ctx, msgType, msg)
+}
+
+// Close the connection with the decorated adapter.
+func (adapter *ConnectionAdapter) Close(ctx context.Context, code wsadapters.StatusCode, reason string) error {
+	adapter.mu.Lock()
+	adapter.disconnectLocked()
+	adapter.mu.Unlock()
+	return adapter.WebsocketConnectionAdapterInterface.Close(ctx, code, reason)
+}
+
+// # Description
+//
+// Close the current connection as if it had been dropped: the pending and next Read calls return
+// a wsadapters.WebsocketCloseError with the 1006 status code (abnormal closure) so the engine
+// reconnects.
+//
+// If the underlying websocket connection can be closed (io.Closer, ex: gorilla connections), the
+// network connection is closed without close message. Otherwise, the decorated connection is
+// closed with the 1001 status code (going away).
+//
+// # Return
+//
+// True if a connection was open and has been closed.
+func (adapter *ConnectionAdapter) ForceClose(ctx context.Context) bool {
+	adapter.dialMu.Lock()
+	defer adapter.dialMu.Unlock()
+	adapter.mu.Lock()
+	if !adapter.connected {
+		adapter.mu.Unlock()
+		return false
+	}
+	adapter.disconnectLocked()
+	adapter.forced = true
+	adapter.stats.ForcedCloses++
+	// Errors are ignored: the decorated connection may have already been dropped
+	if closer, ok := adapter.WebsocketConnectionAdapterInterface.GetUnderlyingWebsocketConnection().(io.Closer); ok {
+		adapter.pendingDrop = true
+		adapter.mu.Unlock()
+		closer.Close()
+		return true
+	}
+	adapter.mu.Unlock()
+	adapter.WebsocketConnectionAdapterInterface.Close(ctx, wsadapters.GoingAway, ForcedCloseReason)
+	return true
+}
+
+// Close the decorated adapter if its network connection has been closed by ForceClose, so a new
+// connection can be opened. Dial mutex must be held.
+func (adapter *ConnectionAdapter) dropForcedConnectionLocked(ctx context.Context) {
+	adapter.mu.Lock()
+	pending := adapter.pendingDrop
+	adapter.pendingDrop = false
+	adapter.mu.Unlock()
+	if pending {
+		// Errors are ignored: the close message cannot be sent on the closed network connection
+		adapter.WebsocketConnectionAdapterInterface.Close(ctx, wsadapters.GoingAway, ForcedCloseReason)
+	}
+}
+
+// Mark the current connection as closed and stop its time-based close. Mutex must be held.
+func (adapter *ConnectionAdapter) disconnectLocked() {
+	if adapter.connected {
+		close(adapter.stop)
+	}
+	adapter.connected = false
+}
+
+// Close the connection when the timer fires unless the stop channel is closed first.
+func (adapter *ConnectionAdapter) closeAfter(timer clock.Timer, stop chan struct{}) {
+	select {
+	case <-stop:
+		timer.Stop()
+	case <-timer.C():
+		adapter.mu.Lock()
+		current := adapter.stop == stop && adapter.connected
+		adapter.mu.Unlock()
+		if current {
+			adapter.ForceClose(context.Background())
+		}
+	}
+}
+
+// Draw a random number and return true if it is below the provided probability. No number is
+// drawn when the probability is 0. Mutex must be held.
+func (adapter *ConnectionAdapter) roll(p float64) bool {
+	return p > 0 && adapter.rand.Float64() < p
+}
+
+// Build the error returned by Read when the connection has been closed by the adapter.
+func forcedCloseError() error {
+	return wsadapters.WebsocketCloseError{Code: wsadapters.AbnormalClosure, Reason: ForcedCloseReason, Err: ErrInjectedFault}
+}
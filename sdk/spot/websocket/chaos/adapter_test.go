@@ -0,0 +1,214 @@
+package chaos
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	gorillaws "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for ConnectionAdapter
+type ConnectionAdapterUnitTestSuite struct {
+	suite.Suite
+	// Websocket server which echoes received messages
+	server *httptest.Server
+}
+
+// Run unit test suite
+func TestConnectionAdapterUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(ConnectionAdapterUnitTestSuite))
+}
+
+// Start an echo websocket server before each test.
+func (suite *ConnectionAdapterUnitTestSuite) SetupTest() {
+	upgrader := gorillaws.Upgrader{}
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			msgType, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(msgType, msg); err != nil {
+				return
+			}
+		}
+	}))
+	suite.server.Config.ErrorLog = log.New(io.Discard, "", 0)
+}
+
+// Stop the websocket server after each test.
+func (suite *ConnectionAdapterUnitTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test invalid configurations are rejected.
+func (suite *ConnectionAdapterUnitTestSuite) TestInvalidConfig() {
+	_, err := NewConnectionAdapter(nil, Config{})
+	require.Error(suite.T(), err)
+	_, err = NewConnectionAdapter(websocket.NewWebsocketConnectionAdapter(nil), Config{DropReadProbability: 1.5})
+	require.Error(suite.T(), err)
+	adapter, err := NewConnectionAdapter(websocket.NewWebsocketConnectionAdapter(nil), Config{})
+	require.NoError(suite.T(), err)
+	require.Error(suite.T(), adapter.SetConfig(Config{CloseAfter: -time.Second}))
+	require.Error(suite.T(), adapter.SetConfig(Config{CloseProbability: -0.1}))
+	require.False(suite.T(), adapter.ForceClose(context.Background()))
+}
+
+// Test faults injected when messages are received.
+//
+// Test will ensure:
+//   - Dropped messages are skipped.
+//   - The connection is closed after the configured number of messages with a 1006 close error.
+//   - Corrupted messages are truncated.
+func (suite *ConnectionAdapterUnitTestSuite) TestReadFaults() {
+	ctx := context.Background()
+	adapter := suite.dial(Config{DropReadProbability: 1, CloseAfterMessages: 3, Seed: 42})
+	for _, msg := range []string{"a", "b", "c"} {
+		require.NoError(suite.T(), adapter.Write(ctx, wsadapters.Text, []byte(msg)))
+	}
+	_, _, err := adapter.Read(ctx)
+	suite.requireForcedClose(err)
+	stats := adapter.Stats()
+	require.Equal(suite.T(), uint64(2), stats.DroppedReads)
+	require.Equal(suite.T(), uint64(1), stats.ForcedCloses)
+	// Corrupt messages on a new connection
+	require.NoError(suite.T(), adapter.SetConfig(Config{CorruptReadProbability: 1}))
+	_, err = adapter.Dial(ctx, suite.serverURL())
+	require.NoError(suite.T(), err)
+	defer adapter.Close(ctx, wsadapters.NormalClosure, "")
+	payload := `{"event":"heartbeat"}`
+	require.NoError(suite.T(), adapter.Write(ctx, wsadapters.Text, []byte(payload)))
+	_, msg, err := adapter.Read(ctx)
+	require.NoError(suite.T(), err)
+	require.Less(suite.T(), len(msg), len(payload))
+	require.Equal(suite.T(), payload[:len(msg)], string(msg))
+	require.Equal(suite.T(), uint64(1), adapter.Stats().CorruptedReads)
+}
+
+// Test faults injected when messages are sent.
+//
+// Test will ensure:
+//   - Dropped messages are not sent to the server.
+//   - Delayed messages are sent once the delay has elapsed.
+//   - A delayed write is interrupted when its context is done.
+func (suite *ConnectionAdapterUnitTestSuite) TestWriteFaults() {
+	ctx := context.Background()
+	adapter := suite.dial(Config{DropWriteProbability: 1})
+	defer adapter.Close(ctx, wsadapters.NormalClosure, "")
+	clk := clock.NewFakeClock(time.Now())
+	adapter.SetClock(clk)
+	require.NoError(suite.T(), adapter.Write(ctx, wsadapters.Text, []byte("dropped")))
+	require.NoError(suite.T(), adapter.SetConfig(Config{DelayWriteProbability: 1, WriteDelay: time.Second}))
+	done := make(chan error, 1)
+	go func() { done <- adapter.Write(ctx, wsadapters.Text, []byte("delayed")) }()
+	clk.BlockUntil(1)
+	require.Empty(suite.T(), done)
+	clk.Advance(time.Second)
+	require.NoError(suite.T(), <-done)
+	_, msg, err := adapter.Read(ctx)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "delayed", string(msg))
+	// Interrupted delayed write
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(suite.T(), adapter.Write(canceled, wsadapters.Text, []byte("canceled")), context.Canceled)
+	stats := adapter.Stats()
+	require.Equal(suite.T(), uint64(1), stats.DroppedWrites)
+	require.Equal(suite.T(), uint64(2), stats.DelayedWrites)
+}
+
+// Test injected dial failures and forced closes.
+//
+// Test will ensure:
+//   - Injected dial failures wrap ErrInjectedFault.
+//   - The connection is closed once it has been open for CloseAfter, with a 1006 close error.
+//   - A new connection can be opened once the connection has been forcibly closed.
+//   - ForceClose closes the current connection and returns false when no connection is open.
+func (suite *ConnectionAdapterUnitTestSuite) TestDialFailureAndForcedClose() {
+	ctx := context.Background()
+	adapter, err := NewConnectionAdapter(websocket.NewWebsocketConnectionAdapter(nil), Config{DialFailureProbability: 1})
+	require.NoError(suite.T(), err)
+	clk := clock.NewFakeClock(time.Now())
+	adapter.SetClock(clk)
+	_, err = adapter.Dial(ctx, suite.serverURL())
+	require.ErrorIs(suite.T(), err, ErrInjectedFault)
+	require.Equal(suite.T(), uint64(1), adapter.Stats().FailedDials)
+	// Time based close
+	require.NoError(suite.T(), adapter.SetConfig(Config{CloseAfter: time.Minute}))
+	_, err = adapter.Dial(ctx, suite.serverURL())
+	require.NoError(suite.T(), err)
+	read := make(chan error, 1)
+	go func() {
+		_, _, err := adapter.Read(ctx)
+		read <- err
+	}()
+	clk.BlockUntil(1)
+	clk.Advance(time.Minute)
+	suite.requireForcedClose(<-read)
+	// Reconnect
+	_, err = adapter.Dial(ctx, suite.serverURL())
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), adapter.Write(ctx, wsadapters.Text, []byte("ping")))
+	_, msg, err := adapter.Read(ctx)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "ping", string(msg))
+	// Manual close
+	require.True(suite.T(), adapter.ForceClose(ctx))
+	_, _, err = adapter.Read(ctx)
+	suite.requireForcedClose(err)
+	require.False(suite.T(), adapter.ForceClose(ctx))
+	require.Equal(suite.T(), uint64(2), adapter.Stats().ForcedCloses)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Get the URL of the websocket server.
+func (suite *ConnectionAdapterUnitTestSuite) serverURL() url.URL {
+	target, err := url.Parse("ws" + suite.server.URL[len("http"):])
+	require.NoError(suite.T(), err)
+	return *target
+}
+
+// Build a chaos adapter with the provided configuration and connect it to the websocket server.
+func (suite *ConnectionAdapterUnitTestSuite) dial(config Config) *ConnectionAdapter {
+	adapter, err := NewConnectionAdapter(websocket.NewWebsocketConnectionAdapter(nil), config)
+	require.NoError(suite.T(), err)
+	_, err = adapter.Dial(context.Background(), suite.serverURL())
+	require.NoError(suite.T(), err)
+	return adapter
+}
+
+// Check the provided error is the close error returned when the connection is forcibly closed.
+func (suite *ConnectionAdapterUnitTestSuite) requireForcedClose(err error) {
+	closeErr, ok := err.(wsadapters.WebsocketCloseError)
+	require.True(suite.T(), ok, "unexpected error: %v", err)
+	require.Equal(suite.T(), wsadapters.AbnormalClosure, closeErr.Code)
+	require.Equal(suite.T(), ForcedCloseReason, closeErr.Reason)
+	require.ErrorIs(suite.T(), err, ErrInjectedFault)
+}
@@ -0,0 +1,232 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Enum for the account verification tiers which determine the trading rate limits.
+type VerificationTierEnum string
+
+// Values for VerificationTierEnum
+const (
+	TierStarter      VerificationTierEnum = "starter"
+	TierIntermediate VerificationTierEnum = "intermediate"
+	TierPro          VerificationTierEnum = "pro"
+)
+
+// Enum for the behaviors of the client when an order management command exceeds the rate limit.
+type CommandRateLimitModeEnum string
+
+// Values for CommandRateLimitModeEnum
+const (
+	// Wait until the command can be sent or until the context is done.
+	CommandRateLimitQueue CommandRateLimitModeEnum = "queue"
+	// Reject the command immediately with a RateLimitedError.
+	CommandRateLimitReject CommandRateLimitModeEnum = "reject"
+)
+
+// Token bucket configuration used to rate limit order management commands. Each command consumes
+// one token.
+type CommandRateLimit struct {
+	// Maximum number of tokens in the bucket: maximum burst of commands. Must be at least 1.
+	Capacity float64
+	// Number of tokens added to the bucket each second. Must be strictly positive.
+	RefillRate float64
+}
+
+// Rate limits of order management commands by verification tier. Values match the maximum
+// counter and the decay rate of the Kraken trading rate limits.
+var CommandRateLimits = map[VerificationTierEnum]CommandRateLimit{
+	TierStarter:      {Capacity: 60, RefillRate: 1},
+	TierIntermediate: {Capacity: 125, RefillRate: 2.34},
+	TierPro:          {Capacity: 180, RefillRate: 3.75},
+}
+
+// Counters of the order management commands processed by the client side rate limiter.
+type CommandRateLimitStats struct {
+	// Number of commands which have been sent without waiting.
+	Allowed uint64
+	// Number of commands which have waited for the rate limiter before being sent.
+	Delayed uint64
+	// Number of commands which have not been sent because of the rate limiter.
+	Rejected uint64
+}
+
+// Token bucket used to rate limit order management commands.
+type commandRateLimiter struct {
+	// Bucket configuration
+	limit CommandRateLimit
+	// Behavior when the bucket is empty
+	mode CommandRateLimitModeEnum
+	// Available tokens
+	tokens float64
+	// Last time tokens have been added to the bucket
+	last time.Time
+	// Verification tier whose rate limit is applied. Empty if a custom rate limit is applied.
+	tier VerificationTierEnum
+}
+
+// # Description
+//
+// Enable client side rate limiting of the order management commands: addOrder, editOrder,
+// cancelOrder, cancelAll and cancelAllOrdersAfter. Commands exceeding the rate limit are either
+// queued until they can be sent or rejected with a RateLimitedError. Rate limiting is disabled by
+// default.
+//
+// The bucket starts full. Setting a new rate limit resets the bucket.
+//
+// # Inputs
+//
+//   - limit: Rate limit to apply. Cf. CommandRateLimits for the rate limits of each verification
+//     tier. A nil value disables rate limiting.
+//   - mode: Behavior when a command exceeds the rate limit. An empty value defaults to CommandRateLimitQueue.
+//
+// # Return
+//
+// An error if the capacity is less than 1 or if the refill rate is not strictly positive. The
+// current rate limit is kept in that case.
+func (client *krakenSpotWebsocketClient) SetCommandRateLimit(limit *CommandRateLimit, mode CommandRateLimitModeEnum) error {
+	if limit != nil {
+		if limit.Capacity < 1 {
+			return fmt.Errorf("capacity must be at least 1. Got %v", limit.Capacity)
+		}
+		if limit.RefillRate <= 0 {
+			return fmt.Errorf("refill rate must be strictly positive. Got %v", limit.RefillRate)
+		}
+	}
+	client.commandRateLimiterMu.Lock()
+	defer client.commandRateLimiterMu.Unlock()
+	if limit == nil {
+		client.commandRateLimiter = nil
+		return nil
+	}
+	if mode == "" {
+		mode = CommandRateLimitQueue
+	}
+	client.commandRateLimiter = &commandRateLimiter{
+		limit:  *limit,
+		mode:   mode,
+		tokens: limit.Capacity,
+		last:   client.clock.Now(),
+	}
+	return nil
+}
+
+// Apply the rate limit of a verification tier without resetting the bucket: available tokens are
+// kept, up to the capacity of the new rate limit. If rate limiting is disabled, it is enabled in
+// CommandRateLimitQueue mode with a full bucket. The tier must exist in CommandRateLimits.
+func (client *krakenSpotWebsocketClient) setCommandRateLimitTier(tier VerificationTierEnum) {
+	client.commandRateLimiterMu.Lock()
+	defer client.commandRateLimiterMu.Unlock()
+	limit := CommandRateLimits[tier]
+	limiter := client.commandRateLimiter
+	if limiter == nil {
+		client.commandRateLimiter = &commandRateLimiter{
+			limit:  limit,
+			mode:   CommandRateLimitQueue,
+			tokens: limit.Capacity,
+			last:   client.clock.Now(),
+			tier:   tier,
+		}
+		return
+	}
+	// Refill the bucket with the previous rate limit before applying the new one
+	now := client.clock.Now()
+	limiter.tokens = min(limit.Capacity, limiter.tokens+now.Sub(limiter.last).Seconds()*limiter.limit.RefillRate)
+	limiter.last = now
+	limiter.limit = limit
+	limiter.tier = tier
+}
+
+// Get the verification tier whose rate limit is applied. Empty if rate limiting is disabled or if
+// a custom rate limit is applied.
+func (client *krakenSpotWebsocketClient) getCommandRateLimitTier() VerificationTierEnum {
+	client.commandRateLimiterMu.Lock()
+	defer client.commandRateLimiterMu.Unlock()
+	if client.commandRateLimiter == nil {
+		return ""
+	}
+	return client.commandRateLimiter.tier
+}
+
+// # Description
+//
+// Get the counters of the order management commands processed by the client side rate limiter.
+// Commands sent while rate limiting is disabled are not counted.
+//
+// # Return
+//
+// The counters of the client side rate limiter.
+func (client *krakenSpotWebsocketClient) GetCommandRateLimitStats() CommandRateLimitStats {
+	return CommandRateLimitStats{
+		Allowed:  client.commandsAllowed.Load(),
+		Delayed:  client.commandsDelayed.Load(),
+		Rejected: client.commandsRejected.Load(),
+	}
+}
+
+// # Description
+//
+// Take a token from the rate limiter before sending an order management command. Depending on
+// the rate limiter mode, the call either waits until a token is available or fails immediately.
+//
+// # Inputs
+//
+//   - ctx: Context used for coordination purpose. Waiting stops once the context is done.
+//   - span: Span of the command. An event is added when the command is delayed or rejected.
+//   - operation: Name of the command.
+//
+// # Return
+//
+// A RateLimitedError if the command must not be sent.
+func (client *krakenSpotWebsocketClient) waitCommandRateLimit(ctx context.Context, span trace.Span, operation string) error {
+	delayed := false
+	for {
+		client.commandRateLimiterMu.Lock()
+		limiter := client.commandRateLimiter
+		if limiter == nil {
+			client.commandRateLimiterMu.Unlock()
+			return nil
+		}
+		// Refill the bucket and take a token if one is available
+		now := client.clock.Now()
+		limiter.tokens = min(limiter.limit.Capacity, limiter.tokens+now.Sub(limiter.last).Seconds()*limiter.limit.RefillRate)
+		limiter.last = now
+		if limiter.tokens >= 1 {
+			limiter.tokens--
+			client.commandRateLimiterMu.Unlock()
+			if delayed {
+				client.commandsDelayed.Add(1)
+			} else {
+				client.commandsAllowed.Add(1)
+			}
+			return nil
+		}
+		wait := time.Duration((1 - limiter.tokens) / limiter.limit.RefillRate * float64(time.Second))
+		mode := limiter.mode
+		client.commandRateLimiterMu.Unlock()
+		if mode == CommandRateLimitReject {
+			client.commandsRejected.Add(1)
+			span.AddEvent("rate_limited", trace.WithAttributes(attribute.String("retry_after", wait.String())))
+			return &RateLimitedError{Operation: operation, RetryAfter: wait}
+		}
+		if !delayed {
+			delayed = true
+			span.AddEvent("rate_limit_wait", trace.WithAttributes(attribute.String("wait", wait.String())))
+		}
+		// Wait until a token should be available, then retry as concurrent commands can take it
+		timer := client.clock.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			client.commandsRejected.Add(1)
+			return &RateLimitedError{Operation: operation, RetryAfter: wait, Root: ctx.Err()}
+		case <-timer.C():
+		}
+	}
+}
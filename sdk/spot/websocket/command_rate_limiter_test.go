@@ -0,0 +1,120 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/trace"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the rate limiter of order management commands
+type CommandRateLimiterUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestCommandRateLimiterUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(CommandRateLimiterUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the rate limiter in reject mode.
+//
+// Test will ensure:
+//   - Commands are not rate limited by default.
+//   - Commands up to the bucket capacity are allowed, then rejected with a RateLimitedError.
+//   - Tokens are added back to the bucket over time.
+//   - AddOrder is rejected before the request is sent.
+func (suite *CommandRateLimiterUnitTestSuite) TestReject() {
+	fclock := clock.NewFakeClock(time.Now())
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	client.SetClock(fclock)
+	span := trace.SpanFromContext(context.Background())
+	// Disabled by default
+	for i := 0; i < 10; i++ {
+		require.NoError(suite.T(), client.waitCommandRateLimit(context.Background(), span, "add_order"))
+	}
+	require.Equal(suite.T(), CommandRateLimitStats{}, client.GetCommandRateLimitStats())
+	// Burst of 2 commands, then 1 command every 2 seconds
+	require.NoError(suite.T(), client.SetCommandRateLimit(&CommandRateLimit{Capacity: 2, RefillRate: 0.5}, CommandRateLimitReject))
+	require.NoError(suite.T(), client.waitCommandRateLimit(context.Background(), span, "add_order"))
+	require.NoError(suite.T(), client.waitCommandRateLimit(context.Background(), span, "add_order"))
+	err := client.waitCommandRateLimit(context.Background(), span, "add_order")
+	rlerr := new(RateLimitedError)
+	require.True(suite.T(), errors.As(err, &rlerr))
+	require.Equal(suite.T(), "add_order", rlerr.Operation)
+	require.Equal(suite.T(), 2*time.Second, rlerr.RetryAfter)
+	// Refill
+	fclock.Advance(2 * time.Second)
+	require.NoError(suite.T(), client.waitCommandRateLimit(context.Background(), span, "add_order"))
+	// AddOrder
+	_, err = client.AddOrder(context.Background(), AddOrderRequestParameters{Pair: "XBT/USD"})
+	require.True(suite.T(), errors.As(err, &rlerr))
+	require.Equal(suite.T(), CommandRateLimitStats{Allowed: 3, Rejected: 2}, client.GetCommandRateLimitStats())
+}
+
+// Test invalid rate limits are rejected.
+//
+// Test will ensure:
+//   - A capacity less than 1 or a refill rate which is not strictly positive is rejected.
+//   - The current rate limit is kept when the new rate limit is rejected.
+func (suite *CommandRateLimiterUnitTestSuite) TestInvalidRateLimit() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	require.ErrorContains(suite.T(), client.SetCommandRateLimit(&CommandRateLimit{Capacity: 1, RefillRate: 0}, ""), "refill rate")
+	require.ErrorContains(suite.T(), client.SetCommandRateLimit(&CommandRateLimit{Capacity: 1, RefillRate: -1}, ""), "refill rate")
+	require.ErrorContains(suite.T(), client.SetCommandRateLimit(&CommandRateLimit{Capacity: 0.5, RefillRate: 1}, ""), "capacity")
+	require.Nil(suite.T(), client.commandRateLimiter)
+	limit := CommandRateLimit{Capacity: 2, RefillRate: 1}
+	require.NoError(suite.T(), client.SetCommandRateLimit(&limit, ""))
+	require.Error(suite.T(), client.SetCommandRateLimit(&CommandRateLimit{Capacity: 2, RefillRate: 0}, ""))
+	require.Equal(suite.T(), limit, client.commandRateLimiter.limit)
+}
+
+// Test the rate limiter in queue mode.
+//
+// Test will ensure:
+//   - Commands exceeding the rate limit wait until a token is available and are counted as delayed.
+//   - Waiting commands are rejected with a RateLimitedError wrapping the context error once the
+//     context is done.
+func (suite *CommandRateLimiterUnitTestSuite) TestQueue() {
+	fclock := clock.NewFakeClock(time.Now())
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	client.SetClock(fclock)
+	span := trace.SpanFromContext(context.Background())
+	limit := CommandRateLimits[TierStarter]
+	limit.Capacity = 1
+	require.NoError(suite.T(), client.SetCommandRateLimit(&limit, ""))
+	require.NoError(suite.T(), client.waitCommandRateLimit(context.Background(), span, "cancel_order"))
+	// Next command waits for one second
+	result := make(chan error, 1)
+	go func() { result <- client.waitCommandRateLimit(context.Background(), span, "cancel_order") }()
+	fclock.BlockUntil(1)
+	require.Empty(suite.T(), result)
+	fclock.Advance(time.Second)
+	require.NoError(suite.T(), <-result)
+	// Context is done while waiting
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { result <- client.waitCommandRateLimit(ctx, span, "cancel_order") }()
+	fclock.BlockUntil(1)
+	cancel()
+	err := <-result
+	require.ErrorIs(suite.T(), err, context.Canceled)
+	rlerr := new(RateLimitedError)
+	require.True(suite.T(), errors.As(err, &rlerr))
+	require.Equal(suite.T(), CommandRateLimitStats{Allowed: 1, Delayed: 1, Rejected: 1}, client.GetCommandRateLimitStats())
+	// Disable
+	require.NoError(suite.T(), client.SetCommandRateLimit(nil, ""))
+	require.NoError(suite.T(), client.waitCommandRateLimit(context.Background(), span, "cancel_order"))
+}
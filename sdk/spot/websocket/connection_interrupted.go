@@ -0,0 +1,122 @@
+package websocket
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+)
+
+// # Description
+//
+// Set the maximum amount of time OnClose waits for consumers to receive the connection_interrupted
+// events published on the subscription channels. The deadline is shared by all channels: once it
+// is reached, events are only delivered to channels which can receive them immediately.
+//
+// By default, OnClose uses blocking writes and waits until each event has been delivered, which
+// can block the engine (shutdown and reconnect) if a consumer is stuck. Events which cannot be
+// delivered are discarded, counted (Cf. GetDroppedMessagesCount with connection_interrupted) and
+// reported to the OnDroppedMessage callback.
+//
+// # Inputs
+//
+//   - timeout: Delivery timeout. Zero (default) waits until delivery. A negative value never
+//     waits: events are discarded if consumers are not ready to receive them.
+func (client *krakenSpotWebsocketClient) SetConnectionInterruptedDeliveryTimeout(timeout time.Duration) {
+	client.connectionInterruptedTimeout.Store(int64(timeout))
+}
+
+// Deadline shared by the deliveries of connection_interrupted events during OnClose.
+type deliveryDeadline struct {
+	// True if deliveries must wait until the event has been received.
+	blocking bool
+	// Timer which fires when the deadline is reached. Nil if the deadline has no timer.
+	timer clock.Timer
+	// True once the deadline has been reached: remaining deliveries do not wait.
+	expired bool
+}
+
+// Build the deadline used to deliver connection_interrupted events from the configured timeout.
+// The deadline must be stopped once all events have been delivered.
+func (client *krakenSpotWebsocketClient) newConnectionInterruptedDeadline() *deliveryDeadline {
+	timeout := time.Duration(client.connectionInterruptedTimeout.Load())
+	switch {
+	case timeout == 0:
+		return &deliveryDeadline{blocking: true}
+	case timeout < 0:
+		return &deliveryDeadline{expired: true}
+	default:
+		return &deliveryDeadline{timer: client.clock.NewTimer(timeout)}
+	}
+}
+
+// Release the timer of the deadline, if any.
+func (d *deliveryDeadline) stop() {
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+}
+
+// Publish an event on the provided channel before the deadline. Return false if the event could
+// not be delivered.
+func (d *deliveryDeadline) deliver(pub chan event.Event, e event.Event) bool {
+	if d.blocking {
+		pub <- e
+		return true
+	}
+	if d.expired {
+		select {
+		case pub <- e:
+			return true
+		default:
+			return false
+		}
+	}
+	select {
+	case pub <- e:
+		return true
+	case <-d.timer.C():
+		d.expired = true
+		return false
+	}
+}
+
+// Wait until the provided channel is closed or the deadline is reached. Return false if the
+// deadline has been reached first.
+func (d *deliveryDeadline) wait(done <-chan struct{}) bool {
+	if d.blocking {
+		<-done
+		return true
+	}
+	if d.expired {
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}
+	select {
+	case <-done:
+		return true
+	case <-d.timer.C():
+		d.expired = true
+		return false
+	}
+}
+
+// Publish a connection_interrupted event on the channel of a subscription. Events which cannot
+// be delivered before the deadline are recorded as dropped messages.
+func (client *krakenSpotWebsocketClient) publishConnectionInterrupted(ctx context.Context, deadline *deliveryDeadline, pub chan event.Event, e event.Event, channel string) {
+	client.logger.Println("sending a connection_interrupted event to warn about connection interruption on", channel)
+	delivered := false
+	client.withDispatchTarget(pub, func(target chan event.Event) {
+		delivered = deadline.deliver(target, e)
+	})
+	if !delivered {
+		client.logger.Println("connection_interrupted event could not be delivered before the deadline on", channel)
+		client.recordDroppedMessage(ctx, events.ConnectionInterrupted)
+	}
+}
@@ -0,0 +1,71 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the delivery of connection_interrupted events
+type ConnectionInterruptedUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestConnectionInterruptedUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(ConnectionInterruptedUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the delivery of connection_interrupted events by OnClose.
+//
+// Test will ensure:
+//   - Events are delivered with blocking writes by default.
+//   - Events are not awaited when a negative timeout is set.
+//   - Once the deadline is reached, events are still delivered to ready consumers.
+//   - Undelivered events are counted and reported to the OnDroppedMessage callback.
+func (suite *ConnectionInterruptedUnitTestSuite) TestDeliveryTimeout() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	drops := []uint64{}
+	client.SetOnDroppedMessageCallback(func(eventType events.WebsocketClientEventTypeEnum, count uint64) {
+		require.Equal(suite.T(), events.ConnectionInterrupted, eventType)
+		drops = append(drops, count)
+	})
+	ticker := make(chan event.Event)
+	trade := make(chan event.Event, 1)
+	client.subscriptions.ticker = &tickerSubscription{pairs: []string{"XBT/USD"}, pub: ticker}
+	client.subscriptions.trade = &tradeSubscription{pairs: []string{"XBT/USD"}, pub: trade}
+	// Default: blocking writes
+	received := make(chan event.Event, 1)
+	go func() { received <- <-ticker }()
+	client.OnClose(context.Background(), nil, nil, nil)
+	require.Equal(suite.T(), string(events.ConnectionInterrupted), (<-received).Type())
+	require.Equal(suite.T(), string(events.ConnectionInterrupted), (<-trade).Type())
+	require.Zero(suite.T(), client.GetDroppedMessagesCount(events.ConnectionInterrupted))
+	// Never wait
+	client.SetConnectionInterruptedDeliveryTimeout(-1)
+	client.OnClose(context.Background(), nil, nil, nil)
+	require.Len(suite.T(), trade, 1)
+	<-trade
+	require.Equal(suite.T(), uint64(1), client.GetDroppedMessagesCount(events.ConnectionInterrupted))
+	// Timeout: ticker consumer is stuck but trade consumer is ready
+	client.SetConnectionInterruptedDeliveryTimeout(50 * time.Millisecond)
+	start := time.Now()
+	client.OnClose(context.Background(), nil, nil, nil)
+	require.GreaterOrEqual(suite.T(), time.Since(start), 50*time.Millisecond)
+	require.Len(suite.T(), trade, 1)
+	require.Equal(suite.T(), uint64(2), client.GetDroppedMessagesCount(events.ConnectionInterrupted))
+	require.Equal(suite.T(), []uint64{1, 2}, drops)
+}
@@ -0,0 +1,77 @@
+package websocket
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Name of the websocket extension used to compress messages.
+const PerMessageDeflateExtension = "permessage-deflate"
+
+// Snapshot of the state of the websocket connection.
+type ConnectionState struct {
+	// True if a connection with the server is opened.
+	Connected bool
+	// Websocket extensions negotiated during the last handshake, with their parameters (ex:
+	// "permessage-deflate; server_no_context_takeover; client_no_context_takeover").
+	Extensions []string
+	// True if compression (permessage-deflate) has been negotiated during the last handshake.
+	// Compressed frames are decompressed transparently by the connection adapter.
+	Compressed bool
+}
+
+// # Description
+//
+// Get a snapshot of the state of the websocket connection. Use DialOptions.EnableCompression to
+// request compression when the connection is opened.
+//
+// # Return
+//
+// A snapshot of the state of the websocket connection.
+func (client *krakenSpotWebsocketClient) GetConnectionState() ConnectionState {
+	state := client.connectionState.Load()
+	if state == nil {
+		return ConnectionState{Extensions: []string{}}
+	}
+	// Copy extensions so the snapshot cannot be modified by the caller
+	snapshot := *state
+	snapshot.Extensions = append([]string{}, state.Extensions...)
+	return snapshot
+}
+
+// Record the extensions negotiated during the handshake once the connection has been opened.
+func (client *krakenSpotWebsocketClient) recordConnectionOpened(resp *http.Response) {
+	state := &ConnectionState{Connected: true, Extensions: []string{}}
+	if resp != nil {
+		state.Extensions = parseWebsocketExtensions(resp.Header)
+	}
+	for _, extension := range state.Extensions {
+		name, _, _ := strings.Cut(extension, ";")
+		if strings.TrimSpace(name) == PerMessageDeflateExtension {
+			state.Compressed = true
+		}
+	}
+	client.connectionState.Store(state)
+}
+
+// Record the connection has been closed. Extensions negotiated during the last handshake are kept.
+func (client *krakenSpotWebsocketClient) recordConnectionClosed() {
+	state := client.GetConnectionState()
+	state.Connected = false
+	client.connectionState.Store(&state)
+}
+
+// Extract the websocket extensions from the Sec-WebSocket-Extensions headers of a handshake
+// response. Extensions can be listed in several headers and several extensions can be listed in
+// a single header, separated by commas.
+func parseWebsocketExtensions(header http.Header) []string {
+	extensions := []string{}
+	for _, value := range header.Values("Sec-WebSocket-Extensions") {
+		for _, extension := range strings.Split(value, ",") {
+			if extension = strings.TrimSpace(extension); extension != "" {
+				extensions = append(extensions, extension)
+			}
+		}
+	}
+	return extensions
+}
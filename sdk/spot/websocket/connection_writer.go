@@ -0,0 +1,222 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+)
+
+// Default maximum amount of time a message can wait in the outbound queue and be written.
+const DefaultWriteTimeout = 10 * time.Second
+
+// Default capacity of the outbound queue of a connection.
+const DefaultOutboundQueueSize = 64
+
+// A message waiting in the outbound queue of a connection.
+type outboundMessage struct {
+	// Context of the caller. The message is not written if the context is done.
+	ctx context.Context
+	// Time after which the message must not be written anymore.
+	deadline time.Time
+	// Message to write
+	payload []byte
+	// Channel used to publish the write result. Must have a capacity of 1.
+	done chan error
+}
+
+// # Description
+//
+// Writer which owns the writes on a websocket connection. Messages are queued in a bounded
+// outbound queue and written one after the other by a dedicated goroutine so concurrent callers
+// (RPCs, resubscribes, latency monitor, ...) are serialized and a stalled connection cannot block
+// them: callers give up once the write deadline is reached and new messages are rejected with a
+// BackpressureError once the queue is full.
+type connectionWriter struct {
+	// Connection used to write messages
+	conn wsadapters.WebsocketConnectionAdapterInterface
+	// Bounded outbound queue
+	queue chan *outboundMessage
+	// Channel closed to stop the writer goroutine
+	stop chan struct{}
+}
+
+// Build a new connection writer and start its goroutine.
+func (client *krakenSpotWebsocketClient) startConnectionWriter(conn wsadapters.WebsocketConnectionAdapterInterface, size int) *connectionWriter {
+	writer := &connectionWriter{
+		conn:  conn,
+		queue: make(chan *outboundMessage, size),
+		stop:  make(chan struct{}),
+	}
+	go writer.run(client)
+	return writer
+}
+
+// Write queued messages until the writer is stopped. Messages which are expired or whose caller
+// has given up are discarded. Messages still queued when the writer is stopped are failed.
+func (writer *connectionWriter) run(client *krakenSpotWebsocketClient) {
+	for {
+		select {
+		case <-writer.stop:
+			for {
+				select {
+				case msg := <-writer.queue:
+					msg.done <- fmt.Errorf("connection has been closed")
+				default:
+					return
+				}
+			}
+		case msg := <-writer.queue:
+			select {
+			case <-writer.stop:
+				msg.done <- fmt.Errorf("connection has been closed")
+				continue
+			default:
+			}
+			if err := msg.ctx.Err(); err != nil {
+				msg.done <- err
+				continue
+			}
+			if !client.clock.Now().Before(msg.deadline) {
+				msg.done <- fmt.Errorf("message expired in the outbound queue: %w", context.DeadlineExceeded)
+				continue
+			}
+			client.hookRawFrame(FrameOutbound, "", msg.payload)
+			msg.done <- writer.write(msg, msg.deadline.Sub(client.clock.Now()))
+		}
+	}
+}
+
+// Connection which supports write deadlines (ex: gorilla *websocket.Conn).
+type writeDeadliner interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+// # Description
+//
+// Write the message to the connection within the provided remaining time. The deadline is set on
+// the context provided to the adapter and on the underlying connection when it supports write
+// deadlines: some adapters ignore the context once the write has started (ex: gorilla), so a
+// stalled connection would otherwise block the writer goroutine forever.
+//
+// # Inputs
+//
+//   - msg: Message to write.
+//   - remaining: Remaining time before the message deadline. The client clock can be a fake
+//     clock: the deadline is converted to a wall clock deadline.
+//
+// # Return
+//
+// The write result.
+func (writer *connectionWriter) write(msg *outboundMessage, remaining time.Duration) error {
+	deadline := time.Now().Add(remaining)
+	ctx, cancel := context.WithDeadline(msg.ctx, deadline)
+	defer cancel()
+	if conn, ok := writer.conn.GetUnderlyingWebsocketConnection().(writeDeadliner); ok {
+		if err := conn.SetWriteDeadline(deadline); err != nil {
+			return fmt.Errorf("failed to set write deadline: %w", err)
+		}
+		defer conn.SetWriteDeadline(time.Time{})
+	}
+	return writer.conn.Write(ctx, wsadapters.Text, msg.payload)
+}
+
+// # Description
+//
+// Set the maximum amount of time a message sent by the client can wait in the outbound queue and
+// be written to the connection. Callers get an error once the deadline is reached and expired
+// messages are never written.
+//
+// # Inputs
+//
+//   - timeout: Write timeout. A zero or negative value resets the default timeout (DefaultWriteTimeout).
+func (client *krakenSpotWebsocketClient) SetWriteTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultWriteTimeout
+	}
+	client.writerMu.Lock()
+	defer client.writerMu.Unlock()
+	client.writeTimeout = timeout
+}
+
+// # Description
+//
+// Set the capacity of the outbound queue. Messages are rejected with a BackpressureError when
+// the queue is full. The capacity is used for the connections opened after the call.
+//
+// # Inputs
+//
+//   - size: Capacity of the outbound queue. A zero or negative value resets the default capacity (DefaultOutboundQueueSize).
+func (client *krakenSpotWebsocketClient) SetOutboundQueueSize(size int) {
+	if size <= 0 {
+		size = DefaultOutboundQueueSize
+	}
+	client.writerMu.Lock()
+	defer client.writerMu.Unlock()
+	client.outboundQueueSize = size
+}
+
+// # Description
+//
+// Queue a text message for the current connection and wait until it has been written.
+//
+// # Inputs
+//
+//   - ctx: Context used for coordination purpose. The message is not written if the context is
+//     done before the message is dequeued.
+//   - payload: Message to write.
+//
+// # Return
+//
+// An error if there is no connection, if the outbound queue is full (BackpressureError), if the
+// context is done, if the write deadline is reached or if the write has failed.
+func (client *krakenSpotWebsocketClient) write(ctx context.Context, payload []byte) error {
+	client.writerMu.Lock()
+	conn := client.conn
+	if conn == nil {
+		client.writerMu.Unlock()
+		return fmt.Errorf("write failed because there is no active connection")
+	}
+	// Start a writer for the connection if it is new
+	if client.writer == nil || client.writer.conn != conn {
+		if client.writer != nil {
+			close(client.writer.stop)
+		}
+		client.writer = client.startConnectionWriter(conn, client.outboundQueueSize)
+	}
+	writer := client.writer
+	timeout := client.writeTimeout
+	client.writerMu.Unlock()
+	msg := &outboundMessage{
+		ctx:      ctx,
+		deadline: client.clock.Now().Add(timeout),
+		payload:  payload,
+		done:     make(chan error, 1),
+	}
+	select {
+	case writer.queue <- msg:
+	default:
+		return &BackpressureError{Capacity: cap(writer.queue)}
+	}
+	timer := client.clock.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-msg.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return fmt.Errorf("write deadline exceeded after %s: %w", timeout, context.DeadlineExceeded)
+	}
+}
+
+// Stop the writer of the current connection, if any. Queued messages are failed.
+func (client *krakenSpotWebsocketClient) stopConnectionWriter() {
+	client.writerMu.Lock()
+	defer client.writerMu.Unlock()
+	if client.writer != nil {
+		close(client.writer.stop)
+		client.writer = nil
+	}
+}
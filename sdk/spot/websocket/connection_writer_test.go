@@ -0,0 +1,195 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the connection writer
+type ConnectionWriterUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestConnectionWriterUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(ConnectionWriterUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test writes are serialized.
+//
+// Test will ensure:
+//   - Concurrent callers never write to the connection at the same time.
+//   - All messages are written and callers get the write result.
+func (suite *ConnectionWriterUnitTestSuite) TestSerializedWrites() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	conn := newConnectionMock()
+	inflight := atomic.Int32{}
+	overlaps := atomic.Int32{}
+	conn.On("Write", mock.Anything, wsadapters.Text, mock.Anything).Run(func(args mock.Arguments) {
+		if inflight.Add(1) > 1 {
+			overlaps.Add(1)
+		}
+		time.Sleep(time.Millisecond)
+		inflight.Add(-1)
+	}).Return(nil)
+	client.conn = conn
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(suite.T(), client.write(context.Background(), []byte("{}")))
+		}()
+	}
+	wg.Wait()
+	require.Zero(suite.T(), overlaps.Load())
+	conn.AssertNumberOfCalls(suite.T(), "Write", 20)
+}
+
+// Test a stalled connection does not block callers.
+//
+// Test will ensure:
+//   - Callers get a deadline error once the write timeout is reached.
+//   - Messages are rejected with a BackpressureError once the outbound queue is full.
+//   - Expired messages are not written once the connection is unblocked.
+//   - Writes fail when there is no connection.
+func (suite *ConnectionWriterUnitTestSuite) TestStalledConnection() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	client.SetWriteTimeout(50 * time.Millisecond)
+	client.SetOutboundQueueSize(1)
+	conn := newConnectionMock()
+	unblock := make(chan struct{})
+	conn.On("Write", mock.Anything, wsadapters.Text, mock.Anything).Run(func(args mock.Arguments) {
+		<-unblock
+	}).Return(nil)
+	client.conn = conn
+	// First message is stuck in Write
+	err := client.write(context.Background(), []byte("first"))
+	require.ErrorIs(suite.T(), err, context.DeadlineExceeded)
+	// Second message fills the queue, third one is rejected
+	result := make(chan error, 1)
+	go func() { result <- client.write(context.Background(), []byte("second")) }()
+	require.Eventually(suite.T(), func() bool { return queuedMessages(client) == 1 }, time.Second, time.Millisecond)
+	err = client.write(context.Background(), []byte("third"))
+	bperr := new(BackpressureError)
+	require.True(suite.T(), errors.As(err, &bperr))
+	require.Equal(suite.T(), 1, bperr.Capacity)
+	require.ErrorIs(suite.T(), <-result, context.DeadlineExceeded)
+	// Unblock the connection: the second message has expired and is not written
+	close(unblock)
+	require.Eventually(suite.T(), func() bool { return queuedMessages(client) == 0 }, time.Second, time.Millisecond)
+	conn.AssertNumberOfCalls(suite.T(), "Write", 1)
+	// No connection
+	client.OnClose(context.Background(), nil, nil, nil)
+	require.Nil(suite.T(), client.writer)
+	require.Error(suite.T(), client.write(context.Background(), []byte("{}")))
+}
+
+// Test the write deadline is applied to the connection.
+//
+// Test will ensure:
+//   - The context provided to the adapter has the message deadline.
+//   - The deadline is set on the underlying connection during the write and reset afterwards.
+func (suite *ConnectionWriterUnitTestSuite) TestWriteDeadline() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	client.SetWriteTimeout(time.Minute)
+	underlying := &deadlineRecorder{}
+	conn := wsadapters.NewWebsocketConnectionAdapterInterfaceMock()
+	conn.On("GetUnderlyingWebsocketConnection").Return(underlying)
+	var ctxDeadline time.Time
+	var connDeadline time.Time
+	conn.On("Write", mock.Anything, wsadapters.Text, mock.Anything).Run(func(args mock.Arguments) {
+		ctxDeadline, _ = args.Get(0).(context.Context).Deadline()
+		connDeadline = underlying.get()
+	}).Return(nil)
+	client.conn = conn
+	start := time.Now()
+	require.NoError(suite.T(), client.write(context.Background(), []byte("{}")))
+	require.WithinDuration(suite.T(), start.Add(time.Minute), ctxDeadline, time.Second)
+	require.Equal(suite.T(), ctxDeadline, connDeadline)
+	require.True(suite.T(), underlying.get().IsZero())
+}
+
+// Test messages queued when the connection is closed are failed.
+//
+// Test will ensure:
+//   - Queued messages are not written and their callers get an error.
+func (suite *ConnectionWriterUnitTestSuite) TestCloseFailsQueuedMessages() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	conn := newConnectionMock()
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	conn.On("Write", mock.Anything, wsadapters.Text, mock.Anything).Run(func(args mock.Arguments) {
+		close(started)
+		<-unblock
+	}).Return(nil).Once()
+	client.conn = conn
+	first := make(chan error, 1)
+	go func() { first <- client.write(context.Background(), []byte("first")) }()
+	<-started
+	second := make(chan error, 1)
+	go func() { second <- client.write(context.Background(), []byte("second")) }()
+	require.Eventually(suite.T(), func() bool { return queuedMessages(client) == 1 }, time.Second, time.Millisecond)
+	client.stopConnectionWriter()
+	close(unblock)
+	require.NoError(suite.T(), <-first)
+	require.ErrorContains(suite.T(), <-second, "connection has been closed")
+	conn.AssertNumberOfCalls(suite.T(), "Write", 1)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Get the number of messages in the outbound queue of the client connection writer.
+func queuedMessages(client *krakenSpotWebsocketClient) int {
+	client.writerMu.Lock()
+	defer client.writerMu.Unlock()
+	if client.writer == nil {
+		return 0
+	}
+	return len(client.writer.queue)
+}
+
+// Build a connection adapter mock without underlying connection.
+func newConnectionMock() *wsadapters.WebsocketConnectionAdapterInterfaceMock {
+	conn := wsadapters.NewWebsocketConnectionAdapterInterfaceMock()
+	conn.On("GetUnderlyingWebsocketConnection").Return(nil).Maybe()
+	return conn
+}
+
+// Underlying connection which records the write deadline.
+type deadlineRecorder struct {
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+func (r *deadlineRecorder) SetWriteDeadline(t time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deadline = t
+	return nil
+}
+
+func (r *deadlineRecorder) get() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.deadline
+}
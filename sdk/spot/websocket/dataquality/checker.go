@@ -0,0 +1,418 @@
+// Package dataquality provides a Checker which wraps a trade or OHLC stream and flags anomalies
+// (non-monotonic timestamps, out-of-order candles, duplicate trades, suspicious gaps) as
+// structured findings which can be fed to monitoring pipelines.
+package dataquality
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	otelObs "github.com/cloudevents/sdk-go/observability/opentelemetry/v2/client"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/codec"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
+)
+
+// Default number of recent trades remembered for each pair to detect duplicate trades.
+const DefaultDuplicateWindow = 1000
+
+// Enum for the kinds of anomalies reported by a Checker.
+type FindingKindEnum string
+
+// Values for FindingKindEnum
+const (
+	// A trade is older than the previous trade of the pair or a candle update is older than the
+	// previous update of the same candle.
+	NonMonotonicTimestamp FindingKindEnum = "non_monotonic_timestamp"
+	// A candle ends before the last candle received for the pair.
+	OutOfOrderCandle FindingKindEnum = "out_of_order_candle"
+	// A trade is identical to one of the recent trades of the pair.
+	DuplicateTrade FindingKindEnum = "duplicate_trade"
+	// No data has been received for a pair for longer than the gap threshold while heartbeats
+	// show the connection is alive.
+	Gap FindingKindEnum = "gap"
+)
+
+// Data of a data_quality_finding event.
+type Finding struct {
+	// Kind of anomaly
+	Kind FindingKindEnum `json:"kind"`
+	// Pair
+	Pair string `json:"pair"`
+	// Channel name of the stream: trade or ohlc-<interval>
+	Channel string `json:"channel"`
+	// Time at which the anomaly has been detected
+	Time time.Time `json:"time"`
+	// Timestamp of the previous trade or candle. For a gap, time at which the last data has been
+	// received.
+	Previous time.Time `json:"previous"`
+	// Timestamp of the trade or candle which triggered the finding. Zero for a gap.
+	Current time.Time `json:"current"`
+	// Number of heartbeats received since the last data. Only set for a gap.
+	Heartbeats int `json:"heartbeats,omitempty"`
+	// Human readable description of the anomaly
+	Detail string `json:"detail"`
+}
+
+// Options of a Checker.
+type Options struct {
+	// A gap is reported for a pair when no data has been received for longer than this duration
+	// and at least one heartbeat has been received meanwhile. Gap detection is disabled if zero.
+	// Must not be negative.
+	GapThreshold time.Duration
+	// Number of recent trades remembered for each pair to detect duplicate trades.
+	// DefaultDuplicateWindow is used if zero. Must not be negative.
+	DuplicateWindow int
+	// JSON codec used to parse the trade and ohlc events. Use the codec set on the websocket
+	// client with SetJSONCodec. If nil, codec.StandardJSONCodec is used.
+	Codec codec.JSONCodec
+}
+
+// Identity of a trade. The websocket API does not provide trade IDs: trades are identified by
+// their content.
+type tradeKey struct {
+	price     float64
+	volume    float64
+	time      int64
+	side      messages.SideEnum
+	orderType messages.OrderTypeEnum
+	misc      string
+}
+
+// Identity of a stream: a pair on a channel. Candles of different intervals are tracked
+// separately.
+type streamKey struct {
+	// Channel name: trade or ohlc-<interval>
+	channel string
+	// Pair
+	pair string
+}
+
+// Stream tracking of a single pair on a channel.
+type pairState struct {
+	// Channel name of the stream
+	channel string
+	// Time of the last trade or last update time of the last candle
+	last time.Time
+	// End time of the last candle
+	lastEnd time.Time
+	// Recent trades - Ring buffer
+	recent []tradeKey
+	// Index of the next trade in recent
+	next int
+	// Number of occurences of the trades in recent
+	seen map[tradeKey]int
+	// Time at which the last data has been received. Zero if no data has been received since
+	// the connection has been (re)opened.
+	received time.Time
+	// Number of heartbeats received since the last data
+	heartbeats int
+	// True when a gap has been reported since the last data
+	gapReported bool
+}
+
+// # Description
+//
+// Checker which wraps a trade or OHLC stream and publishes data_quality_finding events (Cf.
+// Finding) when anomalies are detected:
+//
+// Streams are tracked by channel name and pair: the candles of different intervals of a pair are
+// checked separately.
+//
+//   - Trades older than the previous trade of the pair (NonMonotonicTimestamp).
+//   - Candle updates older than the previous update of the same candle (NonMonotonicTimestamp).
+//   - Candles which end before the last candle of the pair (OutOfOrderCandle).
+//   - Trades identical to one of the recent trades of the pair (DuplicateTrade). The websocket
+//     API does not provide trade IDs: trades are compared by price, volume, time, side, order
+//     type and miscellaneous info.
+//   - Pairs for which no data has been received for longer than the gap threshold while
+//     heartbeats show the connection is alive (Gap). Gaps are checked each time a heartbeat
+//     event is received: heartbeat events must be merged in the input channel to enable gap
+//     detection. A single gap is reported until data is received again for the pair.
+//
+// Gap tracking is reset when a connection_interrupted event is received because no data is
+// expected until the subscription is restored.
+type Checker struct {
+	// Gap threshold. Zero to disable gap detection.
+	gapThreshold time.Duration
+	// Number of recent trades remembered for each pair
+	duplicateWindow int
+	// State by channel name and pair
+	pairs map[streamKey]*pairState
+	// JSON codec used to parse events
+	codec codec.JSONCodec
+	// Clock used to timestamp findings and measure gaps
+	clock clock.Clock
+	// Number of findings by kind
+	counts map[FindingKindEnum]int
+	// Mutex used to protect counts: Counts can be called while the checker runs.
+	mu sync.Mutex
+}
+
+// # Description
+//
+// Build a new Checker.
+//
+// # Inputs
+//
+//   - opts: Checker options.
+//
+// # Return
+//
+// The new Checker or an error if the options are invalid.
+func NewChecker(opts Options) (*Checker, error) {
+	if opts.GapThreshold < 0 {
+		return nil, fmt.Errorf("gap threshold must not be negative. Got %s", opts.GapThreshold)
+	}
+	if opts.DuplicateWindow < 0 {
+		return nil, fmt.Errorf("duplicate window must not be negative. Got %d", opts.DuplicateWindow)
+	}
+	if opts.DuplicateWindow == 0 {
+		opts.DuplicateWindow = DefaultDuplicateWindow
+	}
+	if opts.Codec == nil {
+		opts.Codec = codec.StandardJSONCodec{}
+	}
+	return &Checker{
+		gapThreshold:    opts.GapThreshold,
+		duplicateWindow: opts.DuplicateWindow,
+		pairs:           map[streamKey]*pairState{},
+		codec:           opts.Codec,
+		clock:           clock.NewSystemClock(),
+		counts:          map[FindingKindEnum]int{},
+	}, nil
+}
+
+// Set the clock used to timestamp findings and measure gaps. This can be used to provide a
+// clock.FakeClock in tests. If nil, the system clock is used. Must be called before Run.
+func (c *Checker) SetClock(clk clock.Clock) {
+	if clk == nil {
+		clk = clock.NewSystemClock()
+	}
+	c.clock = clk
+}
+
+// Get the number of findings reported by kind since the checker has been created.
+func (c *Checker) Counts() map[FindingKindEnum]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := make(map[FindingKindEnum]int, len(c.counts))
+	for kind, count := range c.counts {
+		counts[kind] = count
+	}
+	return counts
+}
+
+// # Description
+//
+// Process events from the input channel (the channel used by a trade or an OHLC subscription,
+// optionally merged with heartbeat events) until it is closed. All input events are forwarded to
+// the output channel and each event which triggers findings is followed by one
+// data_quality_finding event per finding. The output channel is closed when the input channel is
+// closed.
+//
+// # Inputs
+//
+//   - in: Channel used by the trade or OHLC subscription.
+//   - out: Channel used to publish the input events and the data_quality_finding events.
+//     Blocking writes are used.
+func (c *Checker) Run(in chan event.Event, out chan event.Event) {
+	defer close(out)
+	for e := range in {
+		out <- e
+		var findings []*Finding
+		var err error
+		switch e.Type() {
+		case string(events.Trade):
+			findings, err = c.processTrade(e)
+		case string(events.OHLC):
+			findings, err = c.processOHLC(e)
+		case string(events.Heartbeat):
+			findings = c.processHeartbeat()
+		case string(events.ConnectionInterrupted):
+			for _, state := range c.pairs {
+				state.received = time.Time{}
+				state.heartbeats = 0
+				state.gapReported = false
+			}
+		}
+		if err != nil {
+			continue
+		}
+		c.count(findings)
+		for _, finding := range findings {
+			out <- newFindingEvent(e, finding)
+		}
+	}
+}
+
+// Process a trade event and return the findings.
+func (c *Checker) processTrade(e event.Event) ([]*Finding, error) {
+	msg := new(messages.Trade)
+	if err := codec.UnmarshalEventData(c.codec, e, msg); err != nil {
+		return nil, err
+	}
+	now := c.clock.Now()
+	state := c.received(msg.Pair, channelName(msg.Name, messages.ChannelTrade), now)
+	findings := []*Finding{}
+	for _, trade := range msg.Entries {
+		if trade.Time.Before(state.last) {
+			findings = append(findings, c.newFinding(NonMonotonicTimestamp, msg.Pair, state, now, trade.Time,
+				fmt.Sprintf("trade at %s is older than the previous trade at %s", trade.Time.Format(time.RFC3339Nano), state.last.Format(time.RFC3339Nano))))
+		} else {
+			state.last = trade.Time
+		}
+		key := tradeKey{
+			price:     trade.Price,
+			volume:    trade.Volume,
+			time:      trade.Time.UnixNano(),
+			side:      trade.Side,
+			orderType: trade.OrderType,
+			misc:      trade.Miscellaneous,
+		}
+		if state.seen[key] > 0 {
+			findings = append(findings, c.newFinding(DuplicateTrade, msg.Pair, state, now, trade.Time,
+				fmt.Sprintf("%s trade of %g @ %g at %s has already been received", trade.Side, trade.Volume, trade.Price, trade.Time.Format(time.RFC3339Nano))))
+		}
+		state.remember(key, c.duplicateWindow)
+	}
+	return findings, nil
+}
+
+// Process an OHLC event and return the findings.
+func (c *Checker) processOHLC(e event.Event) ([]*Finding, error) {
+	msg := new(messages.OHLC)
+	if err := codec.UnmarshalEventData(c.codec, e, msg); err != nil {
+		return nil, err
+	}
+	candle := msg.Entry
+	now := c.clock.Now()
+	state := c.received(msg.Pair, channelName(msg.Name, messages.ChannelOHLC), now)
+	findings := []*Finding{}
+	switch {
+	case candle.End.Before(state.lastEnd):
+		findings = append(findings, c.newFinding(OutOfOrderCandle, msg.Pair, state, now, candle.End,
+			fmt.Sprintf("candle ending at %s has been received after the candle ending at %s", candle.End.Format(time.RFC3339), state.lastEnd.Format(time.RFC3339))))
+	case candle.End.Equal(state.lastEnd) && candle.Time.Before(state.last):
+		findings = append(findings, c.newFinding(NonMonotonicTimestamp, msg.Pair, state, now, candle.Time,
+			fmt.Sprintf("update at %s of the candle ending at %s is older than the previous update at %s", candle.Time.Format(time.RFC3339Nano), candle.End.Format(time.RFC3339), state.last.Format(time.RFC3339Nano))))
+	default:
+		state.lastEnd = candle.End
+		state.last = candle.Time
+	}
+	return findings, nil
+}
+
+// Check all pairs for gaps when a heartbeat is received and return the findings.
+func (c *Checker) processHeartbeat() []*Finding {
+	if c.gapThreshold == 0 {
+		return nil
+	}
+	now := c.clock.Now()
+	findings := []*Finding{}
+	keys := make([]streamKey, 0, len(c.pairs))
+	for key := range c.pairs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].channel != keys[j].channel {
+			return keys[i].channel < keys[j].channel
+		}
+		return keys[i].pair < keys[j].pair
+	})
+	for _, key := range keys {
+		pair, state := key.pair, c.pairs[key]
+		if state.received.IsZero() {
+			continue
+		}
+		state.heartbeats++
+		silence := now.Sub(state.received)
+		if !state.gapReported && silence > c.gapThreshold {
+			state.gapReported = true
+			finding := c.newFinding(Gap, pair, state, now, time.Time{},
+				fmt.Sprintf("no data received for %s while %d heartbeats have been received", silence, state.heartbeats))
+			finding.Previous = state.received
+			finding.Heartbeats = state.heartbeats
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// Return the state of a pair on a channel, creating it if needed, and record data has been
+// received.
+func (c *Checker) received(pair string, channel string, now time.Time) *pairState {
+	key := streamKey{channel: channel, pair: pair}
+	state, ok := c.pairs[key]
+	if !ok {
+		state = &pairState{channel: channel, seen: map[tradeKey]int{}}
+		c.pairs[key] = state
+	}
+	state.received = now
+	state.heartbeats = 0
+	state.gapReported = false
+	return state
+}
+
+// Get the channel name of a message or the provided channel if the message has no name.
+func channelName(name string, channel messages.ChannelEnum) string {
+	if name == "" {
+		return string(channel)
+	}
+	return name
+}
+
+// Build a finding for a pair.
+func (c *Checker) newFinding(kind FindingKindEnum, pair string, state *pairState, now time.Time, current time.Time, detail string) *Finding {
+	return &Finding{
+		Kind:     kind,
+		Pair:     pair,
+		Channel:  state.channel,
+		Time:     now,
+		Previous: state.last,
+		Current:  current,
+		Detail:   detail,
+	}
+}
+
+// Update counts with the provided findings.
+func (c *Checker) count(findings []*Finding) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, finding := range findings {
+		c.counts[finding.Kind]++
+	}
+}
+
+// Remember a trade, forgetting the oldest trade once window trades are remembered.
+func (state *pairState) remember(key tradeKey, window int) {
+	if len(state.recent) < window {
+		state.recent = append(state.recent, key)
+	} else {
+		old := state.recent[state.next]
+		if state.seen[old]--; state.seen[old] == 0 {
+			delete(state.seen, old)
+		}
+		state.recent[state.next] = key
+		state.next = (state.next + 1) % window
+	}
+	state.seen[key]++
+}
+
+// Build a data_quality_finding event from the source event and the finding.
+func newFindingEvent(source event.Event, finding *Finding) event.Event {
+	e := event.New()
+	e.Context.SetType(string(events.DataQualityFinding))
+	e.Context.SetSource(tracing.PackageName)
+	e.SetSubject(finding.Pair)
+	e.SetData("application/json", finding)
+	// Propagate tracing context from the source event
+	otelObs.InjectDistributedTracingExtension(otelObs.ExtractDistributedTracingExtension(context.Background(), source), e)
+	return e
+}
@@ -0,0 +1,252 @@
+package dataquality
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/codec"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for Checker
+type CheckerUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestCheckerUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(CheckerUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test NewChecker input validation.
+func (suite *CheckerUnitTestSuite) TestNewCheckerValidation() {
+	_, err := NewChecker(Options{GapThreshold: -time.Second})
+	require.Error(suite.T(), err)
+	_, err = NewChecker(Options{DuplicateWindow: -1})
+	require.Error(suite.T(), err)
+	checker, err := NewChecker(Options{})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), DefaultDuplicateWindow, checker.duplicateWindow)
+}
+
+// Test a checker attached to a trade subscription.
+//
+// Test will ensure:
+//   - Input events are forwarded.
+//   - Trades older than the previous trade are reported.
+//   - Duplicate trades are reported, including duplicates in the same message.
+//   - Trades which left the duplicate window are not reported.
+//   - Output channel is closed with input.
+func (suite *CheckerUnitTestSuite) TestTradeChecker() {
+	checker, err := NewChecker(Options{DuplicateWindow: 3})
+	require.NoError(suite.T(), err)
+	in := make(chan event.Event, 10)
+	out := make(chan event.Event, 10)
+	go checker.Run(in, out)
+	// Valid trades -> forwarded only
+	in <- newTradeEvent("1000.1", "1001.1")
+	require.Equal(suite.T(), string(events.Trade), (<-out).Type())
+	// Older trade
+	in <- newTradeEvent("1000.5")
+	require.Equal(suite.T(), string(events.Trade), (<-out).Type())
+	finding := suite.readFinding(out)
+	require.Equal(suite.T(), NonMonotonicTimestamp, finding.Kind)
+	require.Equal(suite.T(), "XBT/USD", finding.Pair)
+	require.Equal(suite.T(), "trade", finding.Channel)
+	require.Equal(suite.T(), time.Unix(1001, 1e8).UTC(), finding.Previous.UTC())
+	require.Equal(suite.T(), time.Unix(1000, 5e8).UTC(), finding.Current.UTC())
+	// Duplicate in the same message
+	in <- newTradeEvent("1002.1", "1002.1")
+	require.Equal(suite.T(), string(events.Trade), (<-out).Type())
+	finding = suite.readFinding(out)
+	require.Equal(suite.T(), DuplicateTrade, finding.Kind)
+	require.Equal(suite.T(), time.Unix(1002, 1e8).UTC(), finding.Current.UTC())
+	// Window is full: 1000.1, 1001.1 and 1000.5 have been forgotten
+	in <- newTradeEvent("1003.1")
+	require.Equal(suite.T(), string(events.Trade), (<-out).Type())
+	in <- newTradeEvent("1001.1")
+	require.Equal(suite.T(), string(events.Trade), (<-out).Type())
+	require.Equal(suite.T(), NonMonotonicTimestamp, suite.readFinding(out).Kind)
+	require.Equal(suite.T(), map[FindingKindEnum]int{NonMonotonicTimestamp: 2, DuplicateTrade: 1}, checker.Counts())
+	close(in)
+	_, ok := <-out
+	require.False(suite.T(), ok)
+}
+
+// Test a checker attached to an OHLC subscription.
+//
+// Test will ensure:
+//   - Updates of the candle in progress and new candles are not reported.
+//   - Updates older than the previous update of the same candle are reported.
+//   - Candles which end before the last candle are reported.
+//   - Candles of different intervals of a pair are checked separately.
+//   - The provided codec is used to parse events.
+func (suite *CheckerUnitTestSuite) TestOHLCChecker() {
+	jsonCodec := &countingCodec{}
+	checker, err := NewChecker(Options{Codec: jsonCodec})
+	require.NoError(suite.T(), err)
+	in := make(chan event.Event, 10)
+	out := make(chan event.Event, 10)
+	go checker.Run(in, out)
+	// Candle in progress, its update and the next candle -> forwarded only
+	for _, candle := range [][2]int64{{110, 120}, {115, 120}, {125, 180}} {
+		in <- newOHLCEvent(candle[0], candle[1])
+		require.Equal(suite.T(), string(events.OHLC), (<-out).Type())
+	}
+	// Candle of another interval which ends before the last 1 minute candle -> forwarded only
+	in <- newIntervalOHLCEvent(5, 100, 120)
+	require.Equal(suite.T(), string(events.OHLC), (<-out).Type())
+	require.Equal(suite.T(), 4, jsonCodec.count())
+	// Older update of the candle in progress
+	in <- newOHLCEvent(121, 180)
+	require.Equal(suite.T(), string(events.OHLC), (<-out).Type())
+	finding := suite.readFinding(out)
+	require.Equal(suite.T(), NonMonotonicTimestamp, finding.Kind)
+	require.Equal(suite.T(), "ohlc-1", finding.Channel)
+	require.Equal(suite.T(), time.Unix(125, 0).UTC(), finding.Previous.UTC())
+	// Previous candle
+	in <- newOHLCEvent(119, 120)
+	require.Equal(suite.T(), string(events.OHLC), (<-out).Type())
+	finding = suite.readFinding(out)
+	require.Equal(suite.T(), OutOfOrderCandle, finding.Kind)
+	require.Equal(suite.T(), time.Unix(120, 0).UTC(), finding.Current.UTC())
+	close(in)
+	_, ok := <-out
+	require.False(suite.T(), ok)
+}
+
+// Test gap detection.
+//
+// Test will ensure:
+//   - No gap is reported while data is received within the threshold.
+//   - A single gap is reported with the number of heartbeats once the threshold is exceeded.
+//   - Gap tracking is reset when data is received or when the connection is interrupted.
+func (suite *CheckerUnitTestSuite) TestGapDetection() {
+	checker, err := NewChecker(Options{GapThreshold: 10 * time.Second})
+	require.NoError(suite.T(), err)
+	fake := clock.NewFakeClock(time.Unix(0, 0))
+	checker.SetClock(fake)
+	in := make(chan event.Event, 10)
+	out := make(chan event.Event, 10)
+	go checker.Run(in, out)
+	// Heartbeat before any data -> forwarded only
+	suite.send(in, out, newEvent(events.Heartbeat))
+	suite.send(in, out, newTradeEvent("1000.1"))
+	fake.Advance(5 * time.Second)
+	suite.send(in, out, newEvent(events.Heartbeat))
+	fake.Advance(6 * time.Second)
+	suite.send(in, out, newEvent(events.Heartbeat))
+	finding := suite.readFinding(out)
+	require.Equal(suite.T(), Gap, finding.Kind)
+	require.Equal(suite.T(), 2, finding.Heartbeats)
+	require.Equal(suite.T(), time.Unix(0, 0).UTC(), finding.Previous.UTC())
+	require.Equal(suite.T(), time.Unix(11, 0).UTC(), finding.Time.UTC())
+	// Gap already reported
+	fake.Advance(time.Second)
+	suite.send(in, out, newEvent(events.Heartbeat))
+	// Data resets gap tracking
+	suite.send(in, out, newTradeEvent("1001.1"))
+	fake.Advance(11 * time.Second)
+	suite.send(in, out, newEvent(events.Heartbeat))
+	require.Equal(suite.T(), Gap, suite.readFinding(out).Kind)
+	// Connection interrupted: no gap until data is received again
+	suite.send(in, out, newEvent(events.ConnectionInterrupted))
+	fake.Advance(time.Minute)
+	suite.send(in, out, newEvent(events.Heartbeat))
+	require.Equal(suite.T(), map[FindingKindEnum]int{Gap: 2}, checker.Counts())
+	close(in)
+	_, ok := <-out
+	require.False(suite.T(), ok)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Send an event to the checker and ensure it is forwarded.
+func (suite *CheckerUnitTestSuite) send(in chan event.Event, out chan event.Event, e event.Event) {
+	in <- e
+	require.Equal(suite.T(), e.Type(), (<-out).Type())
+}
+
+// Build an event with no data.
+func newEvent(etype events.WebsocketClientEventTypeEnum) event.Event {
+	e := event.New()
+	e.SetType(string(etype))
+	return e
+}
+
+// Build a 1 minute ohlc event for XBT/USD.
+func newOHLCEvent(updated int64, end int64) event.Event {
+	return newIntervalOHLCEvent(1, updated, end)
+}
+
+// Build an ohlc event for XBT/USD with the provided interval.
+func newIntervalOHLCEvent(interval int, updated int64, end int64) event.Event {
+	e := event.New()
+	e.SetType(string(events.OHLC))
+	e.SetData("application/json", []byte(fmt.Sprintf(
+		`[42,["%d.000000","%d.000000","1.0","1.0","1.0","1.0","1.0","1.0",1],"ohlc-%d","XBT/USD"]`,
+		updated, end, interval)))
+	return e
+}
+
+// JSON codec which counts the calls to Unmarshal.
+type countingCodec struct {
+	codec.StandardJSONCodec
+	// Number of calls to Unmarshal
+	calls atomic.Int64
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	c.calls.Add(1)
+	return c.StandardJSONCodec.Unmarshal(data, v)
+}
+
+// Get the number of calls to Unmarshal.
+func (c *countingCodec) count() int {
+	return int(c.calls.Load())
+}
+
+// Build a trade event for XBT/USD with one trade per timestamp.
+func newTradeEvent(timestamps ...string) event.Event {
+	trades := []messages.TradeData{}
+	for _, ts := range timestamps {
+		trades = append(trades, messages.TradeData{
+			Price:     "30000.0",
+			Volume:    "1.0",
+			Timestamp: json.Number(ts),
+			Side:      "b",
+			OrderType: "l",
+		})
+	}
+	e := event.New()
+	e.SetType(string(events.Trade))
+	e.SetData("application/json", messages.Trade{Name: "trade", Pair: "XBT/USD", Data: trades})
+	return e
+}
+
+// Read and parse the next event as a data_quality_finding event.
+func (suite *CheckerUnitTestSuite) readFinding(out chan event.Event) *Finding {
+	e := <-out
+	require.Equal(suite.T(), string(events.DataQualityFinding), e.Type())
+	finding := new(Finding)
+	require.NoError(suite.T(), json.Unmarshal(e.Data(), finding))
+	return finding
+}
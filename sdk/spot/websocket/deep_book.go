@@ -0,0 +1,320 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/codec"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// Maximum number of levels by side which can be fetched with the REST GetOrderBook endpoint.
+const MaxRESTBookDepth = 500
+
+// A price level of a DeepBook.
+type DeepBookLevel struct {
+	// Price level
+	Price json.Number
+	// Price level volume
+	Volume json.Number
+	// Price level last updated, seconds since epoch
+	Timestamp json.Number
+	// True if the level is kept up to date by the websocket book feed. False if the level comes
+	// from the REST snapshot and may be stale.
+	Live bool
+}
+
+// A price level of a DeepBook side with its parsed price.
+type deepLevel struct {
+	// Parsed price
+	price float64
+	// Level
+	level DeepBookLevel
+}
+
+// # Description
+//
+// Book of a single pair which combines a deep REST GetOrderBook snapshot (up to 500 levels) with
+// the websocket book feed (limited to the depth of the websocket subscription). Levels within
+// the range covered by the websocket feed are kept up to date by the feed while deeper levels
+// come from the last REST snapshot.
+//
+// Reconciliation rules:
+//
+//   - The websocket feed is authoritative within its range: REST levels which are better than
+//     the worst live level of a side are removed.
+//   - Live levels which are pushed out of the websocket window by better levels are kept but
+//     are not live anymore.
+//   - Stale levels which cross the live levels of the other side are removed.
+//   - When the connection is interrupted, all levels become stale until the next websocket
+//     snapshot is received.
+//
+// Feed the book with ProcessEvent (events from SubscribeBook or SubscribeManagedBook) or with
+// ProcessSnapshot/ProcessUpdate and refresh the deep levels with Bootstrap. The book is safe for
+// concurrent use.
+type DeepBook struct {
+	// Mutex used to protect the book
+	mu sync.Mutex
+	// Websocket pair name (ex: XBT/USD)
+	pair string
+	// Depth of the websocket book subscription
+	wsDepth int
+	// Asks, ordered by ascending price
+	asks []deepLevel
+	// Bids, ordered by descending price
+	bids []deepLevel
+	// JSON codec used to parse events
+	codec codec.JSONCodec
+}
+
+// # Description
+//
+// Build an empty DeepBook.
+//
+// # Inputs
+//
+//   - pair: Websocket pair name (ex: XBT/USD). Messages for other pairs are ignored.
+//   - wsDepth: Depth of the websocket book subscription used to feed the book.
+//
+// # Return
+//
+// An empty DeepBook.
+func NewDeepBook(pair string, wsDepth messages.DepthEnum) *DeepBook {
+	return &DeepBook{
+		pair:    pair,
+		wsDepth: int(wsDepth),
+		asks:    []deepLevel{},
+		bids:    []deepLevel{},
+		codec:   codec.StandardJSONCodec{},
+	}
+}
+
+// # Description
+//
+// Process a book event: book_snapshot and book_update events for the pair are applied,
+// connection_interrupted events make all levels stale. Other events are ignored.
+//
+// # Return
+//
+// An error if the event data could not be parsed.
+func (b *DeepBook) ProcessEvent(e event.Event) error {
+	switch e.Type() {
+	case string(events.BookSnapshot):
+		snapshot := new(messages.BookSnapshot)
+		if err := b.codec.Unmarshal(e.Data(), snapshot); err != nil {
+			return fmt.Errorf("failed to parse book snapshot: %w", err)
+		}
+		return b.ProcessSnapshot(*snapshot)
+	case string(events.BookUpdate):
+		update := new(messages.BookUpdate)
+		if err := b.codec.Unmarshal(e.Data(), update); err != nil {
+			return fmt.Errorf("failed to parse book update: %w", err)
+		}
+		return b.ProcessUpdate(*update)
+	case string(events.ConnectionInterrupted):
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for _, side := range [][]deepLevel{b.asks, b.bids} {
+			for i := range side {
+				side[i].level.Live = false
+			}
+		}
+	}
+	return nil
+}
+
+// Apply a websocket book snapshot: live levels are replaced by the levels of the snapshot.
+func (b *DeepBook) ProcessSnapshot(msg messages.BookSnapshot) error {
+	if msg.Pair != b.pair {
+		return nil
+	}
+	asks, err := liveLevels(msg.Data.Asks)
+	if err != nil {
+		return err
+	}
+	bids, err := liveLevels(msg.Data.Bids)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.asks = applySnapshotLevels(b.asks, asks, true)
+	b.bids = applySnapshotLevels(b.bids, bids, false)
+	b.reconcile()
+	return nil
+}
+
+// Apply a websocket book update: levels with a zero volume are removed, others are inserted or
+// updated as live levels.
+func (b *DeepBook) ProcessUpdate(msg messages.BookUpdate) error {
+	if msg.Pair != b.pair {
+		return nil
+	}
+	asks, err := liveLevels(msg.Data.Asks)
+	if err != nil {
+		return err
+	}
+	bids, err := liveLevels(msg.Data.Bids)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.asks = applyUpdateLevels(b.asks, asks, true)
+	b.bids = applyUpdateLevels(b.bids, bids, false)
+	b.reconcile()
+	return nil
+}
+
+// Get the n best asks, ordered by ascending price. All asks are returned if n <= 0.
+func (b *DeepBook) Asks(n int) []DeepBookLevel {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return topDeepLevels(b.asks, n)
+}
+
+// Get the n best bids, ordered by descending price. All bids are returned if n <= 0.
+func (b *DeepBook) Bids(n int) []DeepBookLevel {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return topDeepLevels(b.bids, n)
+}
+
+// Apply the reconciliation rules. Mutex must be held by the caller.
+func (b *DeepBook) reconcile() {
+	b.asks = reconcileSide(b.asks, b.wsDepth, true)
+	b.bids = reconcileSide(b.bids, b.wsDepth, false)
+	// Remove stale levels which cross the live levels of the other side
+	if bestBid, ok := bestLivePrice(b.bids); ok {
+		b.asks = removeLevels(b.asks, func(l deepLevel) bool { return !l.level.Live && l.price <= bestBid })
+	}
+	if bestAsk, ok := bestLivePrice(b.asks); ok {
+		b.bids = removeLevels(b.bids, func(l deepLevel) bool { return !l.level.Live && l.price >= bestAsk })
+	}
+}
+
+// Demote live levels pushed out of the websocket window and remove stale levels within the range
+// covered by the websocket feed.
+func reconcileSide(levels []deepLevel, wsDepth int, asks bool) []deepLevel {
+	live := 0
+	worst := 0.0
+	for i := range levels {
+		if !levels[i].level.Live {
+			continue
+		}
+		if live >= wsDepth {
+			levels[i].level.Live = false
+			continue
+		}
+		live++
+		worst = levels[i].price
+	}
+	if live == 0 {
+		return levels
+	}
+	return removeLevels(levels, func(l deepLevel) bool {
+		return !l.level.Live && (l.price == worst || isBetter(l.price, worst, asks))
+	})
+}
+
+// Replace live levels by the levels of a snapshot.
+func applySnapshotLevels(levels []deepLevel, snapshot []deepLevel, asks bool) []deepLevel {
+	// Stale levels within the range of the snapshot are removed by reconcile
+	levels = removeLevels(levels, func(l deepLevel) bool { return l.level.Live })
+	return sortDeepLevels(append(levels, snapshot...), asks)
+}
+
+// Insert, update or remove levels from a websocket update.
+func applyUpdateLevels(levels []deepLevel, updates []deepLevel, asks bool) []deepLevel {
+	for _, u := range updates {
+		levels = removeLevels(levels, func(l deepLevel) bool { return l.price == u.price })
+		if volume, err := u.level.Volume.Float64(); err == nil && volume > 0 {
+			levels = append(levels, u)
+		}
+	}
+	return sortDeepLevels(levels, asks)
+}
+
+// Replace stale levels by REST levels. REST levels at the price of a live level are ignored.
+func mergeRESTLevels(levels []deepLevel, rest []deepLevel, asks bool) []deepLevel {
+	levels = removeLevels(levels, func(l deepLevel) bool { return !l.level.Live })
+	live := map[float64]bool{}
+	for _, l := range levels {
+		live[l.price] = true
+	}
+	for _, r := range rest {
+		if !live[r.price] {
+			levels = append(levels, r)
+		}
+	}
+	return sortDeepLevels(levels, asks)
+}
+
+// Convert websocket book entries into live levels.
+func liveLevels(entries []messages.BookMessageEntry) ([]deepLevel, error) {
+	levels := make([]deepLevel, 0, len(entries))
+	for _, entry := range entries {
+		price, err := entry.Price.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse book price %q: %w", entry.Price, err)
+		}
+		levels = append(levels, deepLevel{price: price, level: DeepBookLevel{
+			Price:     entry.Price,
+			Volume:    entry.Volume,
+			Timestamp: entry.Timestamp,
+			Live:      true,
+		}})
+	}
+	return levels, nil
+}
+
+// Get the best live price of a side.
+func bestLivePrice(levels []deepLevel) (float64, bool) {
+	for _, l := range levels {
+		if l.level.Live {
+			return l.price, true
+		}
+	}
+	return 0, false
+}
+
+// Tell whether price a is better than price b: lower for asks, higher for bids.
+func isBetter(a float64, b float64, asks bool) bool {
+	if asks {
+		return a < b
+	}
+	return a > b
+}
+
+// Remove the levels which match the predicate. The order of the levels is preserved.
+func removeLevels(levels []deepLevel, remove func(deepLevel) bool) []deepLevel {
+	kept := levels[:0]
+	for _, l := range levels {
+		if !remove(l) {
+			kept = append(kept, l)
+		}
+	}
+	return kept
+}
+
+// Sort levels from the best to the worst price.
+func sortDeepLevels(levels []deepLevel, asks bool) []deepLevel {
+	sort.Slice(levels, func(i, j int) bool { return isBetter(levels[i].price, levels[j].price, asks) })
+	return levels
+}
+
+// Copy the n best levels. All levels are copied if n <= 0.
+func topDeepLevels(levels []deepLevel, n int) []DeepBookLevel {
+	if n <= 0 || n > len(levels) {
+		n = len(levels)
+	}
+	result := make([]DeepBookLevel, n)
+	for i := 0; i < n; i++ {
+		result[i] = levels[i].level
+	}
+	return result
+}
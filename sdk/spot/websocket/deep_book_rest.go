@@ -0,0 +1,78 @@
+//go:build !goctopus_publiconly
+
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+)
+
+// # Description
+//
+// Fetch the book of the pair with the REST GetOrderBook endpoint and use it as the deep levels
+// of the book. Live levels are not modified.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - client: REST client used to fetch the book.
+//   - restPair: REST pair name (ex: XXBTZUSD).
+//   - count: Number of levels to fetch by side. Cf. MaxRESTBookDepth.
+//
+// # Return
+//
+// An error if the book could not be fetched.
+func (b *DeepBook) Bootstrap(ctx context.Context, client rest.KrakenSpotRESTClientIface, restPair string, count int) error {
+	resp, _, err := client.GetOrderBook(ctx, market.GetOrderBookRequestParameters{Pair: restPair}, &market.GetOrderBookRequestOptions{Count: count})
+	if err != nil {
+		return fmt.Errorf("failed to fetch order book for %s: %w", restPair, err)
+	}
+	if len(resp.Error) > 0 {
+		return fmt.Errorf("failed to fetch order book for %s: %v", restPair, resp.Error)
+	}
+	if resp.Result == nil {
+		return fmt.Errorf("failed to fetch order book for %s: empty result", restPair)
+	}
+	return b.ProcessRESTSnapshot(resp.Result)
+}
+
+// Replace the deep levels of the book by the levels of a REST order book. Live levels are not
+// modified.
+func (b *DeepBook) ProcessRESTSnapshot(book *market.OrderBook) error {
+	asks, err := restLevels(book.Asks)
+	if err != nil {
+		return err
+	}
+	bids, err := restLevels(book.Bids)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.asks = mergeRESTLevels(b.asks, asks, true)
+	b.bids = mergeRESTLevels(b.bids, bids, false)
+	b.reconcile()
+	return nil
+}
+
+// Convert REST book entries into stale levels.
+func restLevels(entries []market.OrderBookEntry) ([]deepLevel, error) {
+	levels := make([]deepLevel, 0, len(entries))
+	for _, entry := range entries {
+		price, err := strconv.ParseFloat(entry.Price, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse book price %q: %w", entry.Price, err)
+		}
+		levels = append(levels, deepLevel{price: price, level: DeepBookLevel{
+			Price:     json.Number(entry.Price),
+			Volume:    json.Number(entry.Volume),
+			Timestamp: json.Number(strconv.FormatInt(entry.Timestamp, 10)),
+		}})
+	}
+	return levels, nil
+}
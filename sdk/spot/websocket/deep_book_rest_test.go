@@ -0,0 +1,111 @@
+//go:build !goctopus_publiconly
+
+package websocket
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test DeepBook.Bootstrap fetches the REST book with the requested depth.
+func (suite *DeepBookUnitTestSuite) TestDeepBookBootstrap() {
+	book := NewDeepBook("XBT/USD", messages.D10)
+	client := rest.NewMockKrakenSpotRESTClient()
+	client.On("GetOrderBook", mock.Anything, market.GetOrderBookRequestParameters{Pair: "XXBTZUSD"}, &market.GetOrderBookRequestOptions{Count: 500}).
+		Return(&market.GetOrderBookResponse{Result: &market.OrderBook{
+			PairId: "XXBTZUSD",
+			Asks:   []market.OrderBookEntry{{Price: "100.0", Volume: "1", Timestamp: 1}},
+			Bids:   []market.OrderBookEntry{{Price: "99.0", Volume: "1", Timestamp: 1}},
+		}}, nil, nil)
+	require.NoError(suite.T(), book.Bootstrap(context.Background(), client, "XXBTZUSD", MaxRESTBookDepth))
+	require.Equal(suite.T(), []string{"100.0"}, deepBookPrices(book.Asks(0)))
+	require.Equal(suite.T(), []string{"99.0"}, deepBookPrices(book.Bids(0)))
+}
+
+// Test DeepBook.Bootstrap failures.
+func (suite *DeepBookUnitTestSuite) TestDeepBookBootstrapErrors() {
+	book := NewDeepBook("XBT/USD", messages.D10)
+	client := rest.NewMockKrakenSpotRESTClient()
+	client.On("GetOrderBook", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, nil, fmt.Errorf("connection refused")).Once()
+	client.On("GetOrderBook", mock.Anything, mock.Anything, mock.Anything).
+		Return(&market.GetOrderBookResponse{KrakenSpotRESTResponse: *rest.NewMockKrakenSpotRESTErrorResponse("EQuery:Unknown asset pair")}, nil, nil).Once()
+	require.ErrorContains(suite.T(), book.Bootstrap(context.Background(), client, "XXBTZUSD", 100), "connection refused")
+	require.ErrorContains(suite.T(), book.Bootstrap(context.Background(), client, "XXBTZUSD", 100), "EQuery:Unknown asset pair")
+}
+
+// Test DeepBook reconciliation of REST and websocket levels.
+//
+// Test will ensure:
+//   - The REST snapshot provides the deep levels.
+//   - The websocket snapshot replaces REST levels within its range.
+//   - Live levels pushed out of the websocket window become stale and are kept.
+//   - Levels with a zero volume are removed.
+//   - Messages for other pairs are ignored.
+//   - All levels become stale when the connection is interrupted.
+func (suite *DeepBookUnitTestSuite) TestDeepBook() {
+	book := NewDeepBook("XBT/USD", messages.D10)
+	book.wsDepth = 2
+	require.NoError(suite.T(), book.ProcessRESTSnapshot(&market.OrderBook{
+		PairId: "XXBTZUSD",
+		Asks:   []market.OrderBookEntry{{Price: "100.0", Volume: "1", Timestamp: 1}, {Price: "101.0", Volume: "1", Timestamp: 1}, {Price: "102.0", Volume: "1", Timestamp: 1}, {Price: "103.0", Volume: "1", Timestamp: 1}},
+		Bids:   []market.OrderBookEntry{{Price: "99.0", Volume: "1", Timestamp: 1}, {Price: "98.0", Volume: "1", Timestamp: 1}, {Price: "97.0", Volume: "1", Timestamp: 1}},
+	}))
+	require.Equal(suite.T(), []string{"100.0", "101.0", "102.0", "103.0"}, deepBookPrices(book.Asks(0)))
+	require.False(suite.T(), book.Asks(1)[0].Live)
+	require.Equal(suite.T(), "1", book.Asks(1)[0].Timestamp.String())
+	// Websocket snapshot
+	require.NoError(suite.T(), book.ProcessEvent(newBookEvent(events.BookSnapshot, `[0,{"as":[["100.5","2.0","2"],["101.0","2.0","2"]],"bs":[["99.0","2.0","2"],["98.5","2.0","2"]]},"book-10","XBT/USD"]`)))
+	asks := book.Asks(0)
+	require.Equal(suite.T(), []string{"100.5", "101.0", "102.0", "103.0"}, deepBookPrices(asks))
+	require.Equal(suite.T(), []bool{true, true, false, false}, deepBookLiveness(asks))
+	require.Equal(suite.T(), "2.0", asks[1].Volume.String())
+	bids := book.Bids(0)
+	require.Equal(suite.T(), []string{"99.0", "98.5", "98.0", "97.0"}, deepBookPrices(bids))
+	require.Equal(suite.T(), []bool{true, true, false, false}, deepBookLiveness(bids))
+	// A better ask pushes 101.0 out of the websocket window, best bid is removed
+	require.NoError(suite.T(), book.ProcessEvent(newBookEvent(events.BookUpdate, `[0,{"a":[["100.2","1.0","3"]]},{"b":[["99.0","0.0","3"],["98.2","1.0","3","r"]],"c":"0"},"book-10","XBT/USD"]`)))
+	asks = book.Asks(0)
+	require.Equal(suite.T(), []string{"100.2", "100.5", "101.0", "102.0", "103.0"}, deepBookPrices(asks))
+	require.Equal(suite.T(), []bool{true, true, false, false, false}, deepBookLiveness(asks))
+	bids = book.Bids(0)
+	require.Equal(suite.T(), []string{"98.5", "98.2", "98.0", "97.0"}, deepBookPrices(bids))
+	require.Equal(suite.T(), []bool{true, true, false, false}, deepBookLiveness(bids))
+	require.Len(suite.T(), book.Bids(2), 2)
+	// Other pairs are ignored
+	require.NoError(suite.T(), book.ProcessEvent(newBookEvent(events.BookSnapshot, `[0,{"as":[["1.0","2.0","2"]],"bs":[["0.5","2.0","2"]]},"book-10","ETH/USD"]`)))
+	require.Len(suite.T(), book.Asks(0), 5)
+	// Connection interrupted
+	interrupted := event.New()
+	interrupted.SetType(string(events.ConnectionInterrupted))
+	require.NoError(suite.T(), book.ProcessEvent(interrupted))
+	require.Equal(suite.T(), []bool{false, false, false, false, false}, deepBookLiveness(book.Asks(0)))
+	require.Equal(suite.T(), []bool{false, false, false, false}, deepBookLiveness(book.Bids(0)))
+}
+
+// Test DeepBook removes stale levels which cross the live levels of the other side.
+func (suite *DeepBookUnitTestSuite) TestDeepBookCrossedLevels() {
+	book := NewDeepBook("XBT/USD", messages.D10)
+	require.NoError(suite.T(), book.ProcessRESTSnapshot(&market.OrderBook{
+		Asks: []market.OrderBookEntry{{Price: "100.0", Volume: "1"}, {Price: "101.0", Volume: "1"}},
+		Bids: []market.OrderBookEntry{{Price: "99.0", Volume: "1"}},
+	}))
+	// Market moved up: only bids are live
+	require.NoError(suite.T(), book.ProcessSnapshot(messages.BookSnapshot{Pair: "XBT/USD", Data: messages.BookSnapshotData{
+		Bids: []messages.BookMessageEntry{{Price: "100.5", Volume: "1", Timestamp: "2"}},
+	}}))
+	require.Equal(suite.T(), []string{"101.0"}, deepBookPrices(book.Asks(0)))
+	require.Equal(suite.T(), []string{"100.5", "99.0"}, deepBookPrices(book.Bids(0)))
+}
@@ -0,0 +1,69 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for DeepBook
+type DeepBookUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestDeepBookUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(DeepBookUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test DeepBook without REST levels.
+//
+// Test will ensure:
+//   - Websocket snapshots and updates are applied as live levels.
+//   - All levels become stale when the connection is interrupted.
+func (suite *DeepBookUnitTestSuite) TestDeepBookWebsocketLevels() {
+	book := NewDeepBook("XBT/USD", messages.D10)
+	require.NoError(suite.T(), book.ProcessEvent(newBookEvent(events.BookSnapshot, `[0,{"as":[["100.5","2.0","2"],["101.0","2.0","2"]],"bs":[["99.0","2.0","2"]]},"book-10","XBT/USD"]`)))
+	require.NoError(suite.T(), book.ProcessEvent(newBookEvent(events.BookUpdate, `[0,{"a":[["100.2","1.0","3"]]},{"b":[["99.0","0.0","3"],["98.2","1.0","3","r"]],"c":"0"},"book-10","XBT/USD"]`)))
+	require.Equal(suite.T(), []string{"100.2", "100.5", "101.0"}, deepBookPrices(book.Asks(0)))
+	require.Equal(suite.T(), []bool{true, true, true}, deepBookLiveness(book.Asks(0)))
+	require.Equal(suite.T(), []string{"98.2"}, deepBookPrices(book.Bids(0)))
+	interrupted := event.New()
+	interrupted.SetType(string(events.ConnectionInterrupted))
+	require.NoError(suite.T(), book.ProcessEvent(interrupted))
+	require.Equal(suite.T(), []bool{false, false, false}, deepBookLiveness(book.Asks(0)))
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Get the prices of the levels.
+func deepBookPrices(levels []DeepBookLevel) []string {
+	prices := make([]string, len(levels))
+	for i, l := range levels {
+		prices[i] = l.Price.String()
+	}
+	return prices
+}
+
+// Get the liveness of the levels.
+func deepBookLiveness(levels []DeepBookLevel) []bool {
+	live := make([]bool, len(levels))
+	for i, l := range levels {
+		live[i] = l.Live
+	}
+	return live
+}
@@ -0,0 +1,153 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+)
+
+// # Description
+//
+// Hook which orders the endpoints to try each time a connection is opened (Cf.
+// DialOptions.Endpoints). The next endpoint is tried when the connection to an endpoint fails.
+//
+// # Inputs
+//
+//   - endpoints: Endpoints set in the dial options, in the order they have been provided.
+//   - last: Endpoint used by the last successful connection. Nil if no connection has been opened yet.
+//
+// # Return
+//
+// The endpoints to try, in order. Endpoints can be omitted to exclude them.
+type EndpointSelector func(endpoints []url.URL, last *url.URL) []url.URL
+
+// EndpointSelector which always tries the endpoints in the order they have been provided: the
+// connection is pinned to the first endpoint and the other endpoints are only used as fallbacks.
+func OrderedEndpoints(endpoints []url.URL, last *url.URL) []url.URL {
+	return endpoints
+}
+
+// EndpointSelector which tries the endpoint used by the last successful connection first, then
+// the other endpoints in the order they have been provided: the connection stays on the fallback
+// endpoint once the primary endpoint has failed.
+func StickyEndpoints(endpoints []url.URL, last *url.URL) []url.URL {
+	if last == nil {
+		return endpoints
+	}
+	ordered := []url.URL{*last}
+	for _, endpoint := range endpoints {
+		if endpoint.String() != last.String() {
+			ordered = append(ordered, endpoint)
+		}
+	}
+	return ordered
+}
+
+// Connection adapter decorator which connects to the endpoints set in the dial options instead of
+// the URL provided by the engine.
+type failoverConnectionAdapter struct {
+	wsadapters.WebsocketConnectionAdapterInterface
+	// Endpoints set in the dial options
+	endpoints []url.URL
+	// Hook used to order endpoints
+	selector EndpointSelector
+	// Mutex used to protect last
+	mu sync.Mutex
+	// Endpoint used by the last successful connection. Nil until a connection has been opened.
+	last *url.URL
+}
+
+// Build a connection adapter which connects to the provided endpoints with the decorated adapter.
+// If selector is nil, OrderedEndpoints is used.
+func newFailoverConnectionAdapter(decorated wsadapters.WebsocketConnectionAdapterInterface, endpoints []url.URL, selector EndpointSelector) *failoverConnectionAdapter {
+	if selector == nil {
+		selector = OrderedEndpoints
+	}
+	return &failoverConnectionAdapter{
+		WebsocketConnectionAdapterInterface: decorated,
+		endpoints:                           append([]url.URL{}, endpoints...),
+		selector:                            selector,
+	}
+}
+
+// # Description
+//
+// Connect to the first endpoint which accepts the connection. Endpoints are tried in the order
+// returned by the endpoint selector. The target provided by the engine is ignored.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing/timeout purpose. No other endpoint is tried once the context is done.
+//   - target: Ignored.
+//
+// # Return
+//
+// The server response to the websocket handshake or an error which wraps the errors returned by
+// each endpoint.
+func (adapter *failoverConnectionAdapter) Dial(ctx context.Context, target url.URL) (*http.Response, error) {
+	adapter.mu.Lock()
+	last := adapter.last
+	adapter.mu.Unlock()
+	endpoints := adapter.selector(append([]url.URL{}, adapter.endpoints...), last)
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no endpoint to connect to")
+	}
+	errs := []error{}
+	var resp *http.Response
+	for _, endpoint := range endpoints {
+		var err error
+		resp, err = adapter.WebsocketConnectionAdapterInterface.Dial(ctx, endpoint)
+		if err == nil {
+			connected := endpoint
+			adapter.mu.Lock()
+			adapter.last = &connected
+			adapter.mu.Unlock()
+			return resp, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", endpoint.String(), err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return resp, fmt.Errorf("failed to connect to any endpoint: %w", errors.Join(errs...))
+}
+
+// Build the function used to open TCP connections from the dial options: the user provided
+// function or a net.Dialer configured with the resolver and the fast fallback setting. Pinned
+// addresses are applied to the address to dial. Nil if none of these options is set.
+func (opts *DialOptions) netDialContext() func(ctx context.Context, network string, addr string) (net.Conn, error) {
+	dial := opts.NetDialContext
+	if dial == nil && (opts.Resolver != nil || opts.DisableFastFallback) {
+		dialer := &net.Dialer{Resolver: opts.Resolver}
+		if opts.DisableFastFallback {
+			// A negative value disables fast fallback
+			dialer.FallbackDelay = -1
+		}
+		dial = dialer.DialContext
+	}
+	if len(opts.PinnedAddresses) == 0 {
+		return dial
+	}
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	// Copy pinned addresses so they cannot be modified once the adapter has been built
+	pinned := make(map[string]string, len(opts.PinnedAddresses))
+	for host, ip := range opts.PinnedAddresses {
+		pinned[host] = ip
+	}
+	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			if ip, ok := pinned[host]; ok {
+				addr = net.JoinHostPort(ip, port)
+			}
+		}
+		return dial(ctx, network, addr)
+	}
+}
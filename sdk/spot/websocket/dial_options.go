@@ -0,0 +1,152 @@
+package websocket
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+	"github.com/gbdevw/gowse/wscengine/wsadapters/gorilla"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/clienttag"
+	gorillaws "github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Options used to open the websocket connection with the server.
+type DialOptions struct {
+	// Function which returns the proxy to use to connect to the server. HTTP (http://) and SOCKS5
+	// (socks5://) proxies are supported. Use http.ProxyURL to use a fixed proxy and
+	// http.ProxyFromEnvironment to use the HTTPS_PROXY and NO_PROXY environment variables. If nil,
+	// no proxy is used.
+	Proxy func(*http.Request) (*url.URL, error)
+	// Optional TLS configuration. If nil, the default configuration is used.
+	TLSClientConfig *tls.Config
+	// Maximum amount of time to wait for the websocket handshake to complete. Zero means no
+	// timeout.
+	HandshakeTimeout time.Duration
+	// Optional function used to open TCP connections (ex: to bind a local address). If nil,
+	// net.Dialer is used.
+	NetDialContext func(ctx context.Context, network string, addr string) (net.Conn, error)
+	// Optional headers sent with the handshake request.
+	Header http.Header
+	// User-Agent sent with the handshake request. If empty, the User-Agent set in Header is used
+	// or, if none, the default user agent of the SDK (Cf. clienttag.DefaultUserAgent).
+	UserAgent string
+	// Optional client tag used to distinguish bots which share the same API keys. The tag is added
+	// to the User-Agent header of the handshake request ("<agent> (<tag>)"). When the options are
+	// provided to NewEngineWithPublicWebsocketClient or NewEngineWithPrivateWebsocketClient, the tag
+	// is also added to every span and to the User-Agent of the REST requests (Cf. clienttag package).
+	ClientTag string
+	// Request compression (permessage-deflate) during the handshake to reduce the bandwidth used by
+	// high-volume subscriptions (book, trade). Compressed frames are decompressed transparently.
+	// Use GetConnectionState to know whether the server has accepted compression.
+	EnableCompression bool
+	// Optional websocket URLs to connect to instead of the URL provided to the engine (ex: URLs of
+	// the Kraken edges closest to a colocated deployment). Each time a connection is opened, the
+	// endpoints are tried in the order returned by EndpointSelector until a connection succeeds.
+	Endpoints []url.URL
+	// Optional hook which orders the endpoints to try each time a connection is opened. If nil,
+	// endpoints are tried in the order they are provided (Cf. OrderedEndpoints).
+	EndpointSelector EndpointSelector
+	// Optional resolver used to look up host names (ex: custom DNS server). Ignored when
+	// NetDialContext is set. If nil, the default resolver is used.
+	Resolver *net.Resolver
+	// Optional IP addresses used instead of resolving host names, by host name (ex:
+	// "ws-auth.kraken.com" -> "104.16.0.1"). TLS server name verification still uses the host name.
+	PinnedAddresses map[string]string
+	// Disable Happy Eyeballs (RFC 6555): IPv6 and IPv4 addresses are not raced and the first
+	// resolved address is tried first. Ignored when NetDialContext is set.
+	DisableFastFallback bool
+	// Optional governor which coordinates the connection attempts of the clients it is shared
+	// with: a shared backoff with jitter is applied after failed attempts and the number of
+	// concurrent attempts is capped (Cf. ReconnectGovernor). Use DefaultReconnectGovernor to share
+	// a governor between all the clients of the process. If nil, connection attempts are not
+	// coordinated.
+	ReconnectGovernor *ReconnectGovernor
+}
+
+// A factory which creates DialOptions with the same settings as the default gorilla dialer: proxy
+// read from the environment and a 45 seconds handshake timeout.
+func NewDefaultDialOptions() *DialOptions {
+	return &DialOptions{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     nil,
+		HandshakeTimeout:    45 * time.Second,
+		NetDialContext:      nil,
+		Header:              nil,
+		UserAgent:           "",
+		ClientTag:           "",
+		EnableCompression:   false,
+		Endpoints:           nil,
+		EndpointSelector:    nil,
+		Resolver:            nil,
+		PinnedAddresses:     nil,
+		DisableFastFallback: false,
+		ReconnectGovernor:   nil,
+	}
+}
+
+// # Description
+//
+// Build a websocket connection adapter which can be provided to a websocket engine and which opens
+// connections with the provided options. The adapter uses the gorilla websocket library.
+//
+// # Inputs
+//
+//   - opts: Dial options. If nil, NewDefaultDialOptions is used.
+//
+// # Return
+//
+// The websocket connection adapter. If endpoints are set in the options, the adapter connects to
+// the endpoints instead of the URL provided by the engine (Cf. DialOptions.Endpoints). If a
+// reconnect governor is set in the options, each connection attempt waits for the governor first
+// (Cf. DialOptions.ReconnectGovernor).
+func NewWebsocketConnectionAdapter(opts *DialOptions) wsadapters.WebsocketConnectionAdapterInterface {
+	if opts == nil {
+		opts = NewDefaultDialOptions()
+	}
+	dialer := &gorillaws.Dialer{
+		Proxy:             opts.Proxy,
+		HandshakeTimeout:  opts.HandshakeTimeout,
+		NetDialContext:    opts.netDialContext(),
+		EnableCompression: opts.EnableCompression,
+	}
+	if opts.TLSClientConfig != nil {
+		dialer.TLSClientConfig = opts.TLSClientConfig.Clone()
+	}
+	// Set the User-Agent header with the client tag
+	header := opts.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	agent := opts.UserAgent
+	if agent == "" {
+		agent = header.Get("User-Agent")
+	}
+	header.Set("User-Agent", clienttag.UserAgent(agent, opts.ClientTag))
+	var adapter wsadapters.WebsocketConnectionAdapterInterface = gorilla.NewGorillaWebsocketConnectionAdapter(dialer, header)
+	if len(opts.Endpoints) > 0 {
+		adapter = newFailoverConnectionAdapter(adapter, opts.Endpoints, opts.EndpointSelector)
+	}
+	if opts.ReconnectGovernor != nil {
+		// The governor applies to the whole attempt, fallback endpoints included
+		adapter = &governedConnectionAdapter{WebsocketConnectionAdapterInterface: adapter, governor: opts.ReconnectGovernor}
+	}
+	return adapter
+}
+
+// Add the client tag set in the dial options (if any) to the provided tracer provider. If the
+// tracer provider is nil, the global tracer provider is used.
+func tagTracerProvider(tracerProvider trace.TracerProvider, opts *DialOptions) trace.TracerProvider {
+	if opts == nil || opts.ClientTag == "" {
+		return tracerProvider
+	}
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+	return clienttag.TracerProvider(tracerProvider, opts.ClientTag)
+}
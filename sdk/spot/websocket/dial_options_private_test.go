@@ -0,0 +1,21 @@
+//go:build !goctopus_publiconly
+
+package websocket
+
+import (
+	"github.com/stretchr/testify/require"
+)
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the private engine factory which accepts dial options.
+func (suite *DialOptionsUnitTestSuite) TestPrivateEngineFactory() {
+	engine, client, err := NewEngineWithPrivateWebsocketClient(nil, "key", "c2VjcmV0", nil, nil, nil, nil, nil, nil)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), engine)
+	require.NotNil(suite.T(), client)
+	_, _, err = NewEngineWithPrivateWebsocketClient(nil, "key", "not base64!", nil, nil, nil, nil, nil, nil)
+	require.Error(suite.T(), err)
+}
@@ -0,0 +1,305 @@
+package websocket
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/clienttag"
+	gorillaws "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for DialOptions
+type DialOptionsUnitTestSuite struct {
+	suite.Suite
+	// TLS websocket server
+	server *httptest.Server
+	// Value of the X-Test header received by the server
+	header atomic.Value
+	// Value of the User-Agent header received by the server
+	userAgent atomic.Value
+}
+
+// Run unit test suite
+func TestDialOptionsUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(DialOptionsUnitTestSuite))
+}
+
+// Start a TLS websocket server before each test.
+func (suite *DialOptionsUnitTestSuite) SetupTest() {
+	suite.header = atomic.Value{}
+	suite.userAgent = atomic.Value{}
+	upgrader := gorillaws.Upgrader{EnableCompression: true}
+	suite.server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.header.Store(r.Header.Get("X-Test"))
+		suite.userAgent.Store(r.UserAgent())
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// Echo received messages
+		for {
+			msgType, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(msgType, msg); err != nil {
+				return
+			}
+		}
+	}))
+	// Discard TLS handshake errors
+	suite.server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	suite.server.StartTLS()
+}
+
+// Stop the websocket server after each test.
+func (suite *DialOptionsUnitTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the connection adapter uses the provided TLS configuration, headers and proxy function.
+func (suite *DialOptionsUnitTestSuite) TestDialWithOptions() {
+	proxied := atomic.Bool{}
+	opts := &DialOptions{
+		Proxy: func(r *http.Request) (*url.URL, error) {
+			proxied.Store(true)
+			return nil, nil
+		},
+		TLSClientConfig:  suite.server.Client().Transport.(*http.Transport).TLSClientConfig,
+		HandshakeTimeout: 5 * time.Second,
+		Header:           http.Header{"X-Test": []string{"42"}},
+	}
+	adapter := NewWebsocketConnectionAdapter(opts)
+	ctx := context.Background()
+	_, err := adapter.Dial(ctx, suite.serverURL())
+	require.NoError(suite.T(), err)
+	defer adapter.Close(ctx, wsadapters.NormalClosure, "")
+	require.Equal(suite.T(), "42", suite.header.Load())
+	require.Equal(suite.T(), clienttag.DefaultUserAgent, suite.userAgent.Load())
+	require.True(suite.T(), proxied.Load())
+}
+
+// Test the User-Agent header sent with the handshake request.
+//
+// Test will ensure:
+//   - The client tag is added to the User-Agent set in the dial options or in the headers.
+//   - UserAgent takes precedence over the User-Agent set in the headers.
+func (suite *DialOptionsUnitTestSuite) TestDialWithClientTag() {
+	opts := NewDefaultDialOptions()
+	opts.TLSClientConfig = suite.server.Client().Transport.(*http.Transport).TLSClientConfig
+	opts.Header = http.Header{"User-Agent": []string{"header-agent"}}
+	opts.ClientTag = "bot-a"
+	ctx := context.Background()
+	for _, tc := range []struct {
+		agent    string
+		expected string
+	}{
+		{agent: "", expected: "header-agent (bot-a)"},
+		{agent: "my-bot/1.0", expected: "my-bot/1.0 (bot-a)"},
+	} {
+		opts.UserAgent = tc.agent
+		adapter := NewWebsocketConnectionAdapter(opts)
+		_, err := adapter.Dial(ctx, suite.serverURL())
+		require.NoError(suite.T(), err)
+		adapter.Close(ctx, wsadapters.NormalClosure, "")
+		require.Equal(suite.T(), tc.expected, suite.userAgent.Load())
+	}
+	// Headers provided by user are not modified
+	require.Equal(suite.T(), "header-agent", opts.Header.Get("User-Agent"))
+}
+
+// Test compression negotiation.
+//
+// Test will ensure:
+//   - Compression is not requested by default.
+//   - Compression is negotiated when EnableCompression is set and compressed frames are handled
+//     transparently.
+//   - Negotiated extensions are exposed through GetConnectionState and are kept once the
+//     connection has been closed.
+func (suite *DialOptionsUnitTestSuite) TestDialWithCompression() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	require.Equal(suite.T(), ConnectionState{Extensions: []string{}}, client.GetConnectionState())
+	opts := NewDefaultDialOptions()
+	opts.TLSClientConfig = suite.server.Client().Transport.(*http.Transport).TLSClientConfig
+	ctx := context.Background()
+	// Default: no compression
+	adapter := NewWebsocketConnectionAdapter(opts)
+	resp, err := adapter.Dial(ctx, suite.serverURL())
+	require.NoError(suite.T(), err)
+	client.recordConnectionOpened(resp)
+	require.Equal(suite.T(), ConnectionState{Connected: true, Extensions: []string{}}, client.GetConnectionState())
+	adapter.Close(ctx, wsadapters.NormalClosure, "")
+	// Compression
+	opts.EnableCompression = true
+	adapter = NewWebsocketConnectionAdapter(opts)
+	resp, err = adapter.Dial(ctx, suite.serverURL())
+	require.NoError(suite.T(), err)
+	defer adapter.Close(ctx, wsadapters.NormalClosure, "")
+	msg := []byte(strings.Repeat(`{"event":"heartbeat"}`, 100))
+	require.NoError(suite.T(), adapter.Write(ctx, wsadapters.Text, msg))
+	_, echo, err := adapter.Read(ctx)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), msg, echo)
+	client.recordConnectionOpened(resp)
+	state := client.GetConnectionState()
+	require.True(suite.T(), state.Connected)
+	require.True(suite.T(), state.Compressed)
+	require.Len(suite.T(), state.Extensions, 1)
+	require.True(suite.T(), strings.HasPrefix(state.Extensions[0], PerMessageDeflateExtension))
+	client.recordConnectionClosed()
+	state = client.GetConnectionState()
+	require.False(suite.T(), state.Connected)
+	require.True(suite.T(), state.Compressed)
+}
+
+// Test the connection adapter connects to the endpoints set in the dial options.
+//
+// Test will ensure:
+//   - The target provided by the engine is ignored.
+//   - Endpoints are tried in the order returned by the selector until a connection succeeds.
+//   - The selector receives the endpoint used by the last successful connection.
+//   - An error which reports each endpoint is returned when all endpoints fail.
+func (suite *DialOptionsUnitTestSuite) TestDialWithEndpoints() {
+	unreachable := url.URL{Scheme: "wss", Host: "127.0.0.1:1"}
+	lasts := []*url.URL{}
+	opts := NewDefaultDialOptions()
+	opts.TLSClientConfig = suite.server.Client().Transport.(*http.Transport).TLSClientConfig
+	opts.Endpoints = []url.URL{unreachable, suite.serverURL()}
+	opts.EndpointSelector = func(endpoints []url.URL, last *url.URL) []url.URL {
+		lasts = append(lasts, last)
+		return OrderedEndpoints(endpoints, last)
+	}
+	adapter := NewWebsocketConnectionAdapter(opts)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err := adapter.Dial(ctx, unreachable)
+		require.NoError(suite.T(), err)
+		require.NoError(suite.T(), adapter.Close(ctx, wsadapters.NormalClosure, ""))
+	}
+	require.Len(suite.T(), lasts, 2)
+	require.Nil(suite.T(), lasts[0])
+	require.Equal(suite.T(), suite.serverURL(), *lasts[1])
+	// All endpoints fail
+	opts.Endpoints = []url.URL{unreachable, {Scheme: "wss", Host: "127.0.0.1:2"}}
+	opts.EndpointSelector = nil
+	_, err := NewWebsocketConnectionAdapter(opts).Dial(ctx, suite.serverURL())
+	require.ErrorContains(suite.T(), err, "127.0.0.1:1")
+	require.ErrorContains(suite.T(), err, "127.0.0.1:2")
+}
+
+// Test the built-in endpoint selectors.
+func (suite *DialOptionsUnitTestSuite) TestEndpointSelectors() {
+	primary := url.URL{Scheme: "wss", Host: "primary.kraken.test"}
+	fallback := url.URL{Scheme: "wss", Host: "fallback.kraken.test"}
+	other := url.URL{Scheme: "wss", Host: "other.kraken.test"}
+	endpoints := []url.URL{primary, fallback, other}
+	require.Equal(suite.T(), endpoints, OrderedEndpoints(endpoints, &fallback))
+	require.Equal(suite.T(), endpoints, StickyEndpoints(endpoints, nil))
+	require.Equal(suite.T(), []url.URL{fallback, primary, other}, StickyEndpoints(endpoints, &fallback))
+}
+
+// Test the dial parameters used to open TCP connections.
+//
+// Test will ensure:
+//   - The default dialer is used when no dial parameter is set.
+//   - Pinned addresses are dialed instead of resolving the host name and the TLS server name is
+//     still verified.
+func (suite *DialOptionsUnitTestSuite) TestDialWithPinnedAddresses() {
+	require.Nil(suite.T(), NewDefaultDialOptions().netDialContext())
+	require.NotNil(suite.T(), (&DialOptions{DisableFastFallback: true}).netDialContext())
+	require.NotNil(suite.T(), (&DialOptions{Resolver: &net.Resolver{}}).netDialContext())
+	server := suite.serverURL()
+	_, port, err := net.SplitHostPort(server.Host)
+	require.NoError(suite.T(), err)
+	opts := NewDefaultDialOptions()
+	// The certificate of the test server is valid for example.com
+	opts.TLSClientConfig = suite.server.Client().Transport.(*http.Transport).TLSClientConfig
+	opts.PinnedAddresses = map[string]string{"example.com": "127.0.0.1"}
+	opts.DisableFastFallback = true
+	adapter := NewWebsocketConnectionAdapter(opts)
+	ctx := context.Background()
+	_, err = adapter.Dial(ctx, url.URL{Scheme: "wss", Host: net.JoinHostPort("example.com", port)})
+	require.NoError(suite.T(), err)
+	adapter.Close(ctx, wsadapters.NormalClosure, "")
+	// Server name is verified
+	opts.PinnedAddresses = map[string]string{"kraken.test": "127.0.0.1"}
+	_, err = NewWebsocketConnectionAdapter(opts).Dial(ctx, url.URL{Scheme: "wss", Host: net.JoinHostPort("kraken.test", port)})
+	require.Error(suite.T(), err)
+}
+
+// Test connection attempts wait for the reconnect governor set in the dial options.
+//
+// Test will ensure:
+//   - Failed and successful attempts are reported to the governor.
+//   - An attempt is not made when the context is done while waiting for the governor.
+func (suite *DialOptionsUnitTestSuite) TestDialWithReconnectGovernor() {
+	governor, err := NewReconnectGovernor(&ReconnectGovernorOptions{MaxConcurrentDials: 1, BaseDelay: time.Millisecond, MaxDelay: time.Second})
+	require.NoError(suite.T(), err)
+	opts := NewDefaultDialOptions()
+	opts.TLSClientConfig = suite.server.Client().Transport.(*http.Transport).TLSClientConfig
+	opts.ReconnectGovernor = governor
+	adapter := NewWebsocketConnectionAdapter(opts)
+	ctx := context.Background()
+	_, err = adapter.Dial(ctx, url.URL{Scheme: "wss", Host: "127.0.0.1:1"})
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), 1, governor.GetState().ConsecutiveFailures)
+	_, err = adapter.Dial(ctx, suite.serverURL())
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), adapter.Close(ctx, wsadapters.NormalClosure, ""))
+	require.Equal(suite.T(), ReconnectGovernorState{}, governor.GetState())
+	// Done context
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = adapter.Dial(canceled, suite.serverURL())
+	require.ErrorIs(suite.T(), err, context.Canceled)
+}
+
+// Test the connection adapter fails to connect to a server with a self signed certificate when
+// the default options are used.
+func (suite *DialOptionsUnitTestSuite) TestDialWithDefaultOptions() {
+	opts := NewDefaultDialOptions()
+	require.NotNil(suite.T(), opts.Proxy)
+	require.Equal(suite.T(), 45*time.Second, opts.HandshakeTimeout)
+	_, err := NewWebsocketConnectionAdapter(nil).Dial(context.Background(), suite.serverURL())
+	require.Error(suite.T(), err)
+}
+
+// Test the public engine factory which accepts dial options.
+func (suite *DialOptionsUnitTestSuite) TestEngineFactories() {
+	engine, client, err := NewEngineWithPublicWebsocketClient(NewDefaultDialOptions(), nil, nil, nil, nil, nil)
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), engine)
+	require.NotNil(suite.T(), client)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Get the websocket URL of the test server.
+func (suite *DialOptionsUnitTestSuite) serverURL() url.URL {
+	target, err := url.Parse(strings.Replace(suite.server.URL, "https://", "wss://", 1))
+	require.NoError(suite.T(), err)
+	return *target
+}
@@ -0,0 +1,268 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Queue used to deliver the events of a subscription channel from a dedicated goroutine.
+type dispatchQueue struct {
+	// Mutex used to protect queue, closed and prev
+	mu sync.Mutex
+	// Events waiting to be delivered
+	queue chan event.Event
+	// True once the queue has been stopped: no event can be queued anymore
+	closed bool
+	// True if the subscription channel must be closed once all queued events have been delivered
+	closePub bool
+	// Stopped queue of the same channel whose goroutine was still running when this queue was
+	// created. Events are delivered once it is done. Nil once it is done.
+	prev *dispatchQueue
+	// Closed to discard the events which have not been delivered yet
+	cancel chan struct{}
+	// Used to close cancel only once
+	cancelOnce sync.Once
+	// Closed once all queued events have been delivered or discarded
+	done chan struct{}
+}
+
+// Deliver the queued events on the subscription channel until the queue is stopped. Events are
+// delivered only once the previous queue of the channel is done so events are not interleaved.
+func (q *dispatchQueue) run(pub chan event.Event) {
+	defer close(q.done)
+	q.mu.Lock()
+	prev := q.prev
+	q.mu.Unlock()
+	if prev != nil {
+		// Cancelling q also cancels prev: this cannot block once q has been cancelled
+		<-prev.done
+		q.mu.Lock()
+		q.prev = nil
+		q.mu.Unlock()
+	}
+	for e := range q.queue {
+		select {
+		case <-q.cancel:
+			// Discard remaining events
+			continue
+		default:
+		}
+		select {
+		case pub <- e:
+		case <-q.cancel:
+		}
+	}
+	if q.closePub {
+		close(pub)
+	}
+}
+
+// Get a channel which is closed once all queued events have been delivered or discarded. The
+// channel of a nil queue is already closed.
+func (q *dispatchQueue) flushed() <-chan struct{} {
+	if q == nil {
+		done := make(chan struct{})
+		close(done)
+		return done
+	}
+	return q.done
+}
+
+// Discard the events which have not been delivered yet, including the events of the previous
+// queue of the channel. Can be called several times and on a nil queue.
+func (q *dispatchQueue) discard() {
+	if q == nil {
+		return
+	}
+	q.cancelOnce.Do(func() { close(q.cancel) })
+	q.mu.Lock()
+	prev := q.prev
+	q.mu.Unlock()
+	if prev != nil {
+		prev.discard()
+	}
+}
+
+// # Description
+//
+// Configure the dispatch queues used to deliver the messages received from the server on the
+// channels provided on subscribe.
+//
+// By default, messages are published with blocking writes from the goroutine which reads
+// messages from the server: a slow consumer on one channel stalls the processing of all inbound
+// messages. When dispatch queues are enabled, each subscription channel gets a queue and a
+// dedicated goroutine which delivers its events: a slow book consumer does not delay the delivery
+// of ownTrades or openOrders messages anymore. Events are delivered in the order they were
+// received on each channel.
+//
+// The queue of a channel holds at most size events: once it is full, new events for that channel
+// are discarded and counted (Cf. GetDroppedDispatchEventsCount) so the reading goroutine never
+// waits for a slow consumer. connection_interrupted events are the exception: they wait for room
+// in the queue until the connection_interrupted delivery deadline is reached (Cf.
+// SetConnectionInterruptedDeliveryTimeout).
+//
+// Subscriptions which share a channel share its queue. The queue of a channel is stopped once
+// the client unsubscribes: remaining events are delivered before the channel is closed. All
+// queues are stopped when the connection is closed: OnClose waits until the queued events have
+// been delivered or until the connection_interrupted delivery deadline is reached (Cf.
+// SetConnectionInterruptedDeliveryTimeout), in which case the remaining events are discarded.
+//
+// # Inputs
+//
+//   - size: Capacity of the queue of each channel. Zero or a negative value disables dispatch
+//     queues (default). The capacity is used for the queues created after the call.
+func (client *krakenSpotWebsocketClient) SetDispatchQueueSize(size int) {
+	if size < 0 {
+		size = 0
+	}
+	client.dispatchQueueSize.Store(int64(size))
+}
+
+// Publish an event on a subscription channel. The event is queued if dispatch queues are enabled
+// (Cf. SetDispatchQueueSize) and discarded if the queue is full. Otherwise, a blocking write is
+// used.
+func (client *krakenSpotWebsocketClient) publish(pub chan event.Event, e event.Event) {
+	dropped := false
+	client.withDispatchTarget(pub, func(target chan event.Event) {
+		if target == pub {
+			target <- e
+			return
+		}
+		select {
+		case target <- e:
+		default:
+			dropped = true
+		}
+	})
+	if dropped {
+		count := client.droppedDispatchEvents.Add(1)
+		client.droppedMessagesCounter.Add(context.Background(), 1, metric.WithAttributes(attribute.String("event_type", e.Type())))
+		client.logger.Println("event discarded because the dispatch queue is full", e.Type(), count)
+	}
+}
+
+// # Description
+//
+// Get the total number of events discarded because the dispatch queue of their channel was full
+// (Cf. SetDispatchQueueSize).
+//
+// # Return
+//
+// The total number of discarded events.
+func (client *krakenSpotWebsocketClient) GetDroppedDispatchEventsCount() uint64 {
+	return client.droppedDispatchEvents.Load()
+}
+
+// Call the provided function with the channel events of the provided subscription channel must be
+// written to: the channel of its dispatch queue if dispatch queues are enabled or the
+// subscription channel otherwise. The dispatch queue cannot be stopped while the function runs.
+func (client *krakenSpotWebsocketClient) withDispatchTarget(pub chan event.Event, write func(target chan event.Event)) {
+	for {
+		q := client.getDispatchQueue(pub)
+		if q == nil {
+			write(pub)
+			return
+		}
+		q.mu.Lock()
+		if !q.closed {
+			write(q.queue)
+			q.mu.Unlock()
+			return
+		}
+		// The queue has been stopped meanwhile: a new one is created
+		q.mu.Unlock()
+	}
+}
+
+// Get the dispatch queue of a subscription channel. The queue is created and its goroutine is
+// started if needed. Nil if dispatch queues are disabled and the channel has no queue.
+func (client *krakenSpotWebsocketClient) getDispatchQueue(pub chan event.Event) *dispatchQueue {
+	client.dispatchMu.Lock()
+	defer client.dispatchMu.Unlock()
+	if q, ok := client.dispatchQueues[pub]; ok {
+		return q
+	}
+	size := client.dispatchQueueSize.Load()
+	if size <= 0 {
+		return nil
+	}
+	if client.dispatchQueues == nil {
+		client.dispatchQueues = map[chan event.Event]*dispatchQueue{}
+	}
+	q := &dispatchQueue{
+		queue:  make(chan event.Event, size),
+		prev:   client.stoppedDispatchQueues[pub],
+		cancel: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	client.dispatchQueues[pub] = q
+	go func() {
+		q.run(pub)
+		// Forget the queue once stopped so it is not waited for by the next queue of the channel
+		client.dispatchMu.Lock()
+		defer client.dispatchMu.Unlock()
+		if client.stoppedDispatchQueues[pub] == q {
+			delete(client.stoppedDispatchQueues, pub)
+		}
+	}()
+	return q
+}
+
+// Stop the dispatch queue of a subscription channel. Queued events are delivered by the queue
+// goroutine which then closes the channel if closePub is true. Without dispatch queue, the
+// channel is closed right away if closePub is true.
+//
+// Return the stopped queue. Nil if the channel has no queue.
+func (client *krakenSpotWebsocketClient) stopDispatchQueue(pub chan event.Event, closePub bool) *dispatchQueue {
+	client.dispatchMu.Lock()
+	q, ok := client.dispatchQueues[pub]
+	if ok {
+		delete(client.dispatchQueues, pub)
+		// Keep track of the queue until its goroutine is done so a new queue for the same
+		// channel waits for it (Cf. dispatchQueue.run)
+		if client.stoppedDispatchQueues == nil {
+			client.stoppedDispatchQueues = map[chan event.Event]*dispatchQueue{}
+		}
+		client.stoppedDispatchQueues[pub] = q
+	}
+	client.dispatchMu.Unlock()
+	if !ok {
+		if closePub {
+			close(pub)
+		}
+		return nil
+	}
+	q.mu.Lock()
+	q.closed = true
+	q.closePub = closePub
+	close(q.queue)
+	q.mu.Unlock()
+	return q
+}
+
+// Stop the dispatch queues of all subscription channels without closing the channels and wait
+// until their events have been delivered or the deadline is reached. Events which have not been
+// delivered before the deadline are discarded so no dispatch goroutine outlives the connection.
+func (client *krakenSpotWebsocketClient) stopDispatchQueues(deadline *deliveryDeadline) {
+	client.dispatchMu.Lock()
+	pubs := make([]chan event.Event, 0, len(client.dispatchQueues))
+	for pub := range client.dispatchQueues {
+		pubs = append(pubs, pub)
+	}
+	client.dispatchMu.Unlock()
+	for _, pub := range pubs {
+		q := client.stopDispatchQueue(pub, false)
+		if q == nil {
+			continue
+		}
+		if !deadline.wait(q.done) {
+			client.logger.Println("queued events could not be delivered before the deadline: they are discarded")
+			q.discard()
+			<-q.done
+		}
+	}
+}
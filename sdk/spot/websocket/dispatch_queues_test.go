@@ -0,0 +1,211 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for dispatch queues
+type DispatchQueuesTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestDispatchQueuesTestSuite(t *testing.T) {
+	suite.Run(t, new(DispatchQueuesTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test a slow consumer does not delay the delivery of the messages of other channels.
+//
+// Test will ensure:
+//   - Book messages are queued while the book consumer does not read them.
+//   - Own trades messages are delivered while the book consumer is stalled.
+//   - Book messages are delivered in the order they were received.
+//   - A connection_interrupted event is delivered after the queued messages.
+func (suite *DispatchQueuesTestSuite) TestSlowConsumer() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	client.SetDispatchQueueSize(10)
+	book := make(chan event.Event)
+	ownTrades := make(chan event.Event)
+	client.subscriptions.books[messages.D10] = &bookSubscription{pairs: []string{"XBT/USD"}, depth: messages.D10, pub: book}
+	client.subscriptions.ownTrades = &ownTradesSubscription{pub: ownTrades}
+	for i := 0; i < 5; i++ {
+		require.NoError(suite.T(), client.handleBookSnapshot(context.Background(), nil, nil, nil, nil, "", 0, "XBT/USD", []byte(fmt.Sprintf(`{"as":[],"bs":[],"i":%d}`, i)), messages.D10))
+	}
+	handled := make(chan error, 1)
+	go func() {
+		handled <- client.handleOwnTrades(context.Background(), nil, nil, nil, nil, "", 0, []byte(`{"ownTrades":true}`))
+	}()
+	select {
+	case e := <-ownTrades:
+		require.Equal(suite.T(), string(events.OwnTrades), e.Type())
+	case <-time.After(time.Second):
+		suite.FailNow("own trades message must be delivered while the book consumer is stalled")
+	}
+	require.NoError(suite.T(), <-handled)
+	// Connection interrupted: OnClose waits for the queued events to be delivered
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		client.OnClose(context.Background(), nil, nil, nil)
+	}()
+	for i := 0; i < 5; i++ {
+		e := <-book
+		require.Equal(suite.T(), string(events.BookSnapshot), e.Type())
+		require.JSONEq(suite.T(), fmt.Sprintf(`{"as":[],"bs":[],"i":%d}`, i), string(e.Data()))
+	}
+	require.Equal(suite.T(), string(events.ConnectionInterrupted), (<-book).Type())
+	require.Equal(suite.T(), string(events.ConnectionInterrupted), (<-ownTrades).Type())
+	<-closed
+	require.Empty(suite.T(), client.dispatchQueues)
+}
+
+// Test publishing on a full dispatch queue.
+//
+// Test will ensure:
+//   - Publishing does not block once the queue of a channel is full.
+//   - Events which do not fit in the queue are discarded and counted.
+//   - Queued events are delivered in the order they were received.
+func (suite *DispatchQueuesTestSuite) TestQueueFull() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	client.SetDispatchQueueSize(2)
+	pub := make(chan event.Event)
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		// One event may be taken from the queue by the dispatch goroutine
+		for i := 0; i < 6; i++ {
+			e := newTypedEvent(events.Trade)
+			e.SetID(fmt.Sprint(i))
+			client.publish(pub, e)
+		}
+	}()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		suite.FailNow("publish must not block once the dispatch queue is full")
+	}
+	dropped := client.GetDroppedDispatchEventsCount()
+	require.GreaterOrEqual(suite.T(), dropped, uint64(3))
+	require.LessOrEqual(suite.T(), dropped, uint64(4))
+	for i := 0; i < int(6-dropped); i++ {
+		require.Equal(suite.T(), fmt.Sprint(i), (<-pub).ID())
+	}
+}
+
+// Test dispatch queues are stopped when the connection is closed.
+//
+// Test will ensure:
+//   - OnClose does not wait for a stuck consumer once the delivery deadline is reached.
+//   - Events which have not been delivered before the deadline are discarded and the dispatch
+//     goroutine exits.
+//   - The events of a new queue for a channel are delivered after the events of its previous
+//     queue.
+func (suite *DispatchQueuesTestSuite) TestStopOnClose() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	client.SetDispatchQueueSize(10)
+	client.SetConnectionInterruptedDeliveryTimeout(-1)
+	pub := make(chan event.Event)
+	client.publish(pub, newTypedEvent(events.Ticker))
+	q := client.getDispatchQueue(pub)
+	client.OnClose(context.Background(), nil, nil, nil)
+	<-q.done
+	require.Empty(suite.T(), client.dispatchQueues)
+	require.Eventually(suite.T(), func() bool {
+		client.dispatchMu.Lock()
+		defer client.dispatchMu.Unlock()
+		return len(client.stoppedDispatchQueues) == 0
+	}, time.Second, time.Millisecond)
+	select {
+	case <-pub:
+		suite.FailNow("events must be discarded once the deadline is reached")
+	default:
+	}
+	// Keep the order of the events when a channel gets a new queue while the previous one is
+	// being flushed
+	client.publish(pub, newTypedEvent(events.Ticker))
+	client.stopDispatchQueue(pub, false)
+	client.publish(pub, newTypedEvent(events.Trade))
+	require.Equal(suite.T(), string(events.Ticker), (<-pub).Type())
+	require.Equal(suite.T(), string(events.Trade), (<-pub).Type())
+}
+
+// Test dispatch queues are stopped on unsubscribe.
+//
+// Test will ensure:
+//   - Queued events are delivered before the channel is closed.
+//   - A channel kept open on unsubscribe is not closed and gets a new queue when reused.
+//   - The end_of_stream event of a drained channel is delivered after the queued events.
+//   - Without dispatch queue, events are published on the channel and it is closed right away.
+func (suite *DispatchQueuesTestSuite) TestUnsubscribe() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	client.SetDispatchQueueSize(10)
+	pub := make(chan event.Event)
+	client.publish(pub, newTypedEvent(events.Ticker))
+	client.publish(pub, newTypedEvent(events.Ticker))
+	client.closeOnUnsubscribe(context.Background(), pub, false, "ticker")
+	count := 0
+	for range pub {
+		count++
+	}
+	require.Equal(suite.T(), 2, count)
+	require.Empty(suite.T(), client.dispatchQueues)
+	// Kept open
+	client.SetKeepChannelsOpenOnUnsubscribe(true)
+	pub = make(chan event.Event, 1)
+	client.publish(pub, newTypedEvent(events.Ticker))
+	client.closeOnUnsubscribe(context.Background(), pub, false, "ticker")
+	require.Equal(suite.T(), string(events.Ticker), (<-pub).Type())
+	client.publish(pub, newTypedEvent(events.Trade))
+	require.Equal(suite.T(), string(events.Trade), (<-pub).Type())
+	require.Len(suite.T(), client.dispatchQueues, 1)
+	client.SetKeepChannelsOpenOnUnsubscribe(false)
+	// Drained
+	client.SetClock(clock.NewFakeClock(time.Now()))
+	client.SetDrainOnUnsubscribe(time.Minute)
+	client.publish(pub, newTypedEvent(events.Trade))
+	client.closeOnUnsubscribe(context.Background(), pub, false, "trade")
+	require.Equal(suite.T(), string(events.Trade), (<-pub).Type())
+	require.Equal(suite.T(), string(events.EndOfStream), (<-pub).Type())
+	client.GetEndOfStreamDone(pub)()
+	_, ok := <-pub
+	require.False(suite.T(), ok)
+	client.SetDrainOnUnsubscribe(0)
+	// Disabled
+	client.SetDispatchQueueSize(0)
+	pub = make(chan event.Event, 1)
+	client.publish(pub, newTypedEvent(events.Ticker))
+	require.Empty(suite.T(), client.dispatchQueues)
+	client.closeOnUnsubscribe(context.Background(), pub, false, "ticker")
+	require.Equal(suite.T(), string(events.Ticker), (<-pub).Type())
+	_, ok = <-pub
+	require.False(suite.T(), ok)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build an event with the provided type.
+func newTypedEvent(typ events.WebsocketClientEventTypeEnum) event.Event {
+	e := event.New()
+	e.SetType(string(typ))
+	return e
+}
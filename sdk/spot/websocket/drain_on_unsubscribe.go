@@ -0,0 +1,139 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	otelObs "github.com/cloudevents/sdk-go/observability/opentelemetry/v2/client"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
+	"github.com/google/uuid"
+)
+
+// Data of the end_of_stream event published as the last event of a channel drained on
+// unsubscribe.
+type EndOfStream struct {
+	// Name of the unsubscribed channel (ex: ticker, ohlc-5, book-10, ownTrades)
+	Channel string `json:"channel"`
+}
+
+// Channel drained before being closed on unsubscribe.
+type drainingChannel struct {
+	// Closed once the consumer has acknowledged the end of the stream
+	done chan struct{}
+	// Used to close done only once
+	once sync.Once
+}
+
+// Acknowledge the end of the stream. Can be called several times.
+func (d *drainingChannel) ack() {
+	d.once.Do(func() { close(d.done) })
+}
+
+// # Description
+//
+// Configure whether the channels provided on subscribe are drained before being closed when the
+// client unsubscribes.
+//
+// By default, the channel is closed as soon as a call to an Unsubscribe method succeeds. When
+// draining is enabled, the channel is marked as draining and an end_of_stream event (Cf.
+// EndOfStream) is published after the remaining events: the channel is closed only once the
+// consumer has acknowledged the end of the stream by calling the Done func returned by
+// GetEndOfStreamDone, or once the timeout has elapsed. Queued events (Cf. SetDispatchQueueSize)
+// and the end_of_stream event which have not been delivered when the timeout elapses are
+// discarded so the channel can be closed even if the consumer is stuck.
+//
+// The channel must not be provided to another subscription until it is closed. Draining does not
+// apply to channels which are kept open on unsubscribe (Cf. SetKeepChannelsOpenOnUnsubscribe) and
+// to channels created by the client.
+//
+// # Inputs
+//
+//   - timeout: Maximum amount of time to wait for the end_of_stream event to be received and
+//     acknowledged. Zero or a negative value disables draining (default).
+func (client *krakenSpotWebsocketClient) SetDrainOnUnsubscribe(timeout time.Duration) {
+	if timeout < 0 {
+		timeout = 0
+	}
+	client.drainTimeout.Store(int64(timeout))
+}
+
+// # Description
+//
+// Get the func used to acknowledge the end_of_stream event received on a channel which is drained
+// after an unsubscribe (Cf. SetDrainOnUnsubscribe). Calling the func closes the channel. The func
+// can be called several times.
+//
+// # Inputs
+//
+//   - pub: Channel provided on subscribe.
+//
+// # Return
+//
+// The Done func of the channel. A no-op func is returned if the channel is not draining.
+func (client *krakenSpotWebsocketClient) GetEndOfStreamDone(pub chan event.Event) func() {
+	client.drainingMu.Lock()
+	defer client.drainingMu.Unlock()
+	if d, ok := client.draining[pub]; ok {
+		return d.ack
+	}
+	return func() {}
+}
+
+// Mark the channel as draining, publish an end_of_stream event and close the channel once the
+// consumer has acknowledged the event or once the drain timeout has elapsed. Events which have
+// not been delivered when the timeout elapses are discarded. The caller does not wait for the
+// consumer.
+func (client *krakenSpotWebsocketClient) drainAndClose(ctx context.Context, pub chan event.Event, channel string) {
+	d := &drainingChannel{done: make(chan struct{})}
+	client.drainingMu.Lock()
+	if client.draining == nil {
+		client.draining = map[chan event.Event]*drainingChannel{}
+	}
+	client.draining[pub] = d
+	client.drainingMu.Unlock()
+	e := event.New()
+	e.Context.SetType(string(events.EndOfStream))
+	e.Context.SetID(uuid.NewString())
+	e.Context.SetSource(tracing.PackageName)
+	e.SetData("application/json", &EndOfStream{Channel: channel})
+	otelObs.InjectDistributedTracingExtension(ctx, e)
+	// Events queued before the end_of_stream event are delivered first (Cf. SetDispatchQueueSize)
+	queue := client.stopDispatchQueue(pub, false)
+	flushed := queue.flushed()
+	timer := client.clock.NewTimer(time.Duration(client.drainTimeout.Load()))
+	client.logger.Println("draining", channel, "channel before closing it")
+	go func() {
+		defer func() {
+			timer.Stop()
+			// Discard the queued events which have not been delivered yet: the queue goroutine
+			// exits without waiting for the consumer and the channel can be closed.
+			queue.discard()
+			client.drainingMu.Lock()
+			delete(client.draining, pub)
+			client.drainingMu.Unlock()
+			<-flushed
+			close(pub)
+			client.logger.Println(channel, "channel has been closed")
+		}()
+		select {
+		case <-flushed:
+		case <-timer.C():
+			client.logger.Println("queued events could not be delivered before the timeout on", channel, "channel")
+			return
+		}
+		select {
+		case pub <- e:
+		case <-timer.C():
+			client.logger.Println("end_of_stream event could not be delivered before the timeout on", channel, "channel")
+			return
+		}
+		select {
+		case <-d.done:
+		case <-timer.C():
+			client.logger.Println("end_of_stream event has not been acknowledged before the timeout on", channel, "channel")
+		}
+	}()
+}
@@ -0,0 +1,144 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for channel draining on unsubscribe
+type DrainOnUnsubscribeTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestDrainOnUnsubscribeTestSuite(t *testing.T) {
+	suite.Run(t, new(DrainOnUnsubscribeTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test a drained channel is closed once the consumer acknowledges the end of the stream.
+//
+// Test will ensure:
+//   - Remaining events are received before the end_of_stream event.
+//   - The channel is not closed until the Done func is called.
+//   - The Done func can be called several times.
+func (suite *DrainOnUnsubscribeTestSuite) TestDrainAck() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	client.SetClock(clock.NewFakeClock(time.Now()))
+	client.SetDrainOnUnsubscribe(time.Minute)
+	pub := make(chan event.Event, 2)
+	pending := event.New()
+	pending.SetType(string(events.Ticker))
+	pub <- pending
+	client.closeOnUnsubscribe(context.Background(), pub, false, "ohlc-5")
+	require.Equal(suite.T(), string(events.Ticker), (<-pub).Type())
+	e := <-pub
+	require.Equal(suite.T(), string(events.EndOfStream), e.Type())
+	eos := new(EndOfStream)
+	require.NoError(suite.T(), json.Unmarshal(e.Data(), eos))
+	require.Equal(suite.T(), "ohlc-5", eos.Channel)
+	// Not closed until acknowledged
+	select {
+	case <-pub:
+		suite.FailNow("channel must not be closed before the end of the stream is acknowledged")
+	case <-time.After(10 * time.Millisecond):
+	}
+	done := client.GetEndOfStreamDone(pub)
+	done()
+	done()
+	_, ok := <-pub
+	require.False(suite.T(), ok)
+	// Channel is no longer draining
+	require.Eventually(suite.T(), func() bool {
+		client.drainingMu.Lock()
+		defer client.drainingMu.Unlock()
+		return len(client.draining) == 0
+	}, time.Second, time.Millisecond)
+}
+
+// Test a drained channel is closed once the timeout has elapsed.
+//
+// Test will ensure:
+//   - The channel is closed if the end of the stream is not acknowledged before the timeout.
+//   - The channel is closed if the end_of_stream event cannot be delivered before the timeout.
+//   - The channel is closed if the queued events cannot be delivered before the timeout: they
+//     are discarded.
+func (suite *DrainOnUnsubscribeTestSuite) TestDrainTimeout() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	fake := clock.NewFakeClock(time.Now())
+	client.SetClock(fake)
+	client.SetDrainOnUnsubscribe(time.Minute)
+	// Not acknowledged
+	pub := make(chan event.Event, 1)
+	client.closeOnUnsubscribe(context.Background(), pub, false, "ticker")
+	require.Equal(suite.T(), string(events.EndOfStream), (<-pub).Type())
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+	_, ok := <-pub
+	require.False(suite.T(), ok)
+	// Not delivered: the event is discarded
+	pub = make(chan event.Event)
+	client.closeOnUnsubscribe(context.Background(), pub, false, "ticker")
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+	require.Eventually(suite.T(), func() bool {
+		client.drainingMu.Lock()
+		defer client.drainingMu.Unlock()
+		return len(client.draining) == 0
+	}, time.Second, time.Millisecond)
+	_, ok = <-pub
+	require.False(suite.T(), ok)
+	// Queued events not delivered: they are discarded
+	client.SetDispatchQueueSize(10)
+	pub = make(chan event.Event)
+	client.publish(pub, newTypedEvent(events.Ticker))
+	client.publish(pub, newTypedEvent(events.Ticker))
+	client.closeOnUnsubscribe(context.Background(), pub, false, "ticker")
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+	require.Eventually(suite.T(), func() bool {
+		client.drainingMu.Lock()
+		defer client.drainingMu.Unlock()
+		return len(client.draining) == 0
+	}, time.Second, time.Millisecond)
+	_, ok = <-pub
+	require.False(suite.T(), ok)
+}
+
+// Test channels which are not drained.
+//
+// Test will ensure:
+//   - Channels are closed immediately when draining is disabled.
+//   - Internal channels are closed immediately even if draining is enabled.
+//   - The Done func of a channel which is not draining is a no-op.
+func (suite *DrainOnUnsubscribeTestSuite) TestNoDrain() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	pub := make(chan event.Event, 1)
+	client.closeOnUnsubscribe(context.Background(), pub, false, "ticker")
+	_, ok := <-pub
+	require.False(suite.T(), ok)
+	client.SetDrainOnUnsubscribe(time.Minute)
+	internal := make(chan event.Event, 1)
+	client.closeOnUnsubscribe(context.Background(), internal, true, "trade")
+	_, ok = <-internal
+	require.False(suite.T(), ok)
+	client.GetEndOfStreamDone(make(chan event.Event))()
+	// Disable draining
+	client.SetDrainOnUnsubscribe(-time.Second)
+	require.Zero(suite.T(), client.drainTimeout.Load())
+}
@@ -1,6 +1,11 @@
 package websocket
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
 
 // This error is used when the reply from the server to a request contains an error message.
 //
@@ -51,3 +56,59 @@ func (e *SubscriptionError) Error() string {
 }
 
 func (e *SubscriptionError) Unwrap() error { return nil }
+
+// This error is used when a message cannot be sent because the outbound queue of the connection
+// is full. This usually means the connection is stalled: messages are not sent and the caller
+// should retry later or restart the connection.
+type BackpressureError struct {
+	// Capacity of the outbound queue
+	Capacity int
+}
+
+func (e *BackpressureError) Error() string {
+	return fmt.Sprintf("outbound queue is full (capacity: %d)", e.Capacity)
+}
+
+func (e *BackpressureError) Unwrap() error { return nil }
+
+// This error is used when an order management command (addOrder, editOrder, cancelOrder, ...)
+// is not sent because it exceeds the client side rate limit (Cf. SetCommandRateLimit). The
+// command has not been sent to the server and can be retried once RetryAfter has elapsed.
+type RateLimitedError struct {
+	// Operation which has been rate limited
+	Operation string
+	// Estimated time to wait before the command can be sent
+	RetryAfter time.Duration
+	// Optional root error: the context error if the context is done while waiting for the limiter
+	Root error
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.Root != nil {
+		return fmt.Sprintf("%s has been rate limited (retry after: %s): %s", e.Operation, e.RetryAfter, e.Root.Error())
+	}
+	return fmt.Sprintf("%s has been rate limited (retry after: %s)", e.Operation, e.RetryAfter)
+}
+
+func (e *RateLimitedError) Unwrap() error { return e.Root }
+
+// This error is used when an order management command is not allowed by the current status of
+// the trading engine (maintenance, cancel_only, post_only, limit_only). Cf. ModeGate.
+type TradingModeError struct {
+	// Operation which has been denied
+	Operation string
+	// Status of the trading engine which denies the operation
+	Status messages.EngineStatusEnum
+	// Optional root error: the context error if the context is done while waiting for the
+	// trading engine to allow the operation.
+	Root error
+}
+
+func (e *TradingModeError) Error() string {
+	if e.Root != nil {
+		return fmt.Sprintf("%s is not allowed while trading engine status is %s: %s", e.Operation, e.Status, e.Root.Error())
+	}
+	return fmt.Sprintf("%s is not allowed while trading engine status is %s", e.Operation, e.Status)
+}
+
+func (e *TradingModeError) Unwrap() error { return e.Root }
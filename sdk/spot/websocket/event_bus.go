@@ -0,0 +1,279 @@
+package websocket
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// Separator between the segments of a topic (ex: market.ticker.XBT/USD).
+const TopicSeparator = "."
+
+// Wildcard which matches exactly one segment of a topic (ex: market.*.XBT/USD).
+const TopicWildcardSegment = "*"
+
+// Wildcard which matches one or more trailing segments of a topic. Must be the last segment of
+// the pattern (ex: market.>).
+const TopicWildcardTail = ">"
+
+// Name of the cloud event extension set with the topic of the events delivered by the event bus.
+const TopicExtension = "topic"
+
+// Default capacity of the channels of the topic subscriptions.
+const DefaultTopicSubscriptionCapacity = 100
+
+// Topics of the events published by the websocket client which are not related to a pair.
+const (
+	// Own trades events
+	TopicOwnTrades = "private.own_trades"
+	// Open orders events
+	TopicOpenOrders = "private.open_orders"
+	// connection_interrupted events. Published once each time the connection is closed.
+	TopicConnectionInterrupted = "connection.interrupted"
+	// Heartbeat events
+	TopicHeartbeat = "system.heartbeat"
+	// System status events
+	TopicSystemStatus = "system.status"
+	// General error events
+	TopicGeneralError = "error.general"
+	// Unknown message events
+	TopicUnknownMessage = "error.unknown_message"
+	// config_updated events. Published each time the runtime configuration is updated.
+	TopicConfigUpdated = "client.config_updated"
+)
+
+// Topic of ticker events for a pair (ex: market.ticker.XBT/USD).
+func TickerTopic(pair string) string {
+	return "market.ticker" + TopicSeparator + pair
+}
+
+// Topic of ohlc events for an interval and a pair (ex: market.ohlc.5.XBT/USD).
+func OHLCTopic(interval messages.IntervalEnum, pair string) string {
+	return "market.ohlc" + TopicSeparator + strconv.Itoa(int(interval)) + TopicSeparator + pair
+}
+
+// Topic of trade events for a pair (ex: market.trade.XBT/USD).
+func TradeTopic(pair string) string {
+	return "market.trade" + TopicSeparator + pair
+}
+
+// Topic of spread events for a pair (ex: market.spread.XBT/USD).
+func SpreadTopic(pair string) string {
+	return "market.spread" + TopicSeparator + pair
+}
+
+// Topic of book snapshot and update events for a depth and a pair (ex: market.book.10.XBT/USD).
+func BookTopic(depth messages.DepthEnum, pair string) string {
+	return "market.book" + TopicSeparator + strconv.Itoa(int(depth)) + TopicSeparator + pair
+}
+
+// Topic of resubscribe_failed events for a channel (ex: subscription.resubscribe_failed.ticker).
+func ResubscribeFailedTopic(channel string) string {
+	return "subscription.resubscribe_failed" + TopicSeparator + channel
+}
+
+// # Description
+//
+// Tell whether a topic matches a topic pattern. Patterns are made of segments separated by
+// TopicSeparator: TopicWildcardSegment matches exactly one segment and TopicWildcardTail, which
+// must be the last segment of the pattern, matches one or more trailing segments.
+//
+// Pairs contain a '/' but no '.', so they always make a single segment.
+//
+// # Inputs
+//
+//   - pattern: Topic pattern (ex: market.*.XBT/USD, market.>).
+//   - topic: Topic to match (ex: market.ticker.XBT/USD).
+//
+// # Return
+//
+// True if the topic matches the pattern.
+func MatchTopic(pattern string, topic string) bool {
+	psegs := strings.Split(pattern, TopicSeparator)
+	tsegs := strings.Split(topic, TopicSeparator)
+	for i, pseg := range psegs {
+		if pseg == TopicWildcardTail {
+			return i == len(psegs)-1 && len(tsegs) > i
+		}
+		if i >= len(tsegs) {
+			return false
+		}
+		if pseg != TopicWildcardSegment && pseg != tsegs[i] {
+			return false
+		}
+	}
+	return len(psegs) == len(tsegs)
+}
+
+// Check a topic pattern is valid: segments must not be empty and TopicWildcardTail can only be
+// used as the last segment.
+func validateTopicPattern(pattern string) error {
+	segs := strings.Split(pattern, TopicSeparator)
+	for i, seg := range segs {
+		if seg == "" {
+			return fmt.Errorf("invalid topic pattern '%s': empty segment", pattern)
+		}
+		if seg == TopicWildcardTail && i != len(segs)-1 {
+			return fmt.Errorf("invalid topic pattern '%s': '%s' must be the last segment", pattern, TopicWildcardTail)
+		}
+	}
+	return nil
+}
+
+// # Description
+//
+// Subscription to the event bus returned by EventBus.Subscribe.
+//
+// Events whose topic matches the pattern of the subscription are delivered on C. Events are
+// discarded if C is full so a slow subscriber never blocks the websocket client nor the other
+// subscribers: Dropped returns the number of discarded events.
+type TopicSubscription struct {
+	// Channel used to deliver events. Closed by Unsubscribe.
+	C <-chan event.Event
+	// Topic pattern of the subscription
+	pattern string
+	// Writable side of C
+	ch chan event.Event
+	// Number of events discarded because of congestion
+	dropped atomic.Uint64
+	// Bus the subscription belongs to
+	bus *EventBus
+	// Ensure the subscription is removed only once
+	once sync.Once
+}
+
+// Get the topic pattern of the subscription.
+func (sub *TopicSubscription) Pattern() string {
+	return sub.pattern
+}
+
+// Get the number of events discarded because the channel of the subscription was full.
+func (sub *TopicSubscription) Dropped() uint64 {
+	return sub.dropped.Load()
+}
+
+// Remove the subscription from the event bus and close its channel. Safe to call several times.
+func (sub *TopicSubscription) Unsubscribe() {
+	sub.once.Do(func() {
+		sub.bus.mu.Lock()
+		defer sub.bus.mu.Unlock()
+		delete(sub.bus.subs, sub)
+		close(sub.ch)
+	})
+}
+
+// # Description
+//
+// Internal event bus of the websocket client. The client publishes every event (market data,
+// private data, connection lifecycle and errors) on the bus under a hierarchical topic (Cf.
+// TickerTopic, TopicOwnTrades, ...) in addition to the channels provided when subscribing, so
+// consumers can be decoupled from the subscription methods.
+//
+// Publication never blocks: events are discarded for subscribers whose channel is full.
+//
+// The bus is safe for concurrent use.
+type EventBus struct {
+	// Mutex used to protect subscriptions
+	mu sync.RWMutex
+	// Active subscriptions
+	subs map[*TopicSubscription]struct{}
+}
+
+// Build a new event bus without subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{subs: map[*TopicSubscription]struct{}{}}
+}
+
+// # Description
+//
+// Subscribe to the events whose topic matches the provided pattern.
+//
+// # Inputs
+//
+//   - pattern: Topic pattern. Cf. MatchTopic.
+//   - capacity: Capacity of the channel of the subscription. DefaultTopicSubscriptionCapacity is
+//     used if capacity is not strictly positive.
+//
+// # Return
+//
+// The subscription or an error if the pattern is invalid.
+func (bus *EventBus) Subscribe(pattern string, capacity int) (*TopicSubscription, error) {
+	if err := validateTopicPattern(pattern); err != nil {
+		return nil, err
+	}
+	if capacity <= 0 {
+		capacity = DefaultTopicSubscriptionCapacity
+	}
+	ch := make(chan event.Event, capacity)
+	sub := &TopicSubscription{C: ch, pattern: pattern, ch: ch, bus: bus}
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	bus.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// # Description
+//
+// Publish an event under the provided topic. Each matching subscriber receives its own copy of
+// the event with the TopicExtension extension set to the topic. The event is discarded for the
+// subscribers whose channel is full.
+//
+// # Inputs
+//
+//   - topic: Topic of the event.
+//   - e: Event to publish.
+func (bus *EventBus) Publish(topic string, e event.Event) {
+	if bus == nil {
+		return
+	}
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+	for sub := range bus.subs {
+		if !MatchTopic(sub.pattern, topic) {
+			continue
+		}
+		cpy := e.Clone()
+		cpy.SetExtension(TopicExtension, topic)
+		select {
+		case sub.ch <- cpy:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// # Description
+//
+// Subscribe to the events published by the websocket client whose topic matches the provided
+// pattern. The events published on the channels provided when subscribing are also published on
+// the event bus under the following topics:
+//
+//   - market.ticker.<pair>, market.ohlc.<interval>.<pair>, market.trade.<pair>,
+//     market.spread.<pair> and market.book.<depth>.<pair> for market data.
+//   - private.own_trades and private.open_orders for private data.
+//   - connection.interrupted and subscription.resubscribe_failed.<channel> for the connection
+//     lifecycle.
+//   - system.heartbeat, system.status and error.general for the built-in channels.
+//   - client.config_updated for runtime configuration updates (Cf. UpdateConfig).
+//
+// Events are only published on the bus for active subscriptions: the bus does not subscribe to
+// channels on its own. Market data subscribed in raw mode are not published on the bus.
+//
+// # Inputs
+//
+//   - pattern: Topic pattern (ex: market.*.XBT/USD, market.>, private.>). Cf. MatchTopic.
+//   - capacity: Capacity of the channel of the subscription. Events are discarded when the channel
+//     is full. DefaultTopicSubscriptionCapacity is used if capacity is not strictly positive.
+//
+// # Return
+//
+// The subscription or an error if the pattern is invalid. Use Unsubscribe to stop receiving
+// events and close the channel.
+func (client *krakenSpotWebsocketClient) SubscribeTopic(pattern string, capacity int) (*TopicSubscription, error) {
+	return client.bus.Subscribe(pattern, capacity)
+}
@@ -0,0 +1,116 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the event bus
+type EventBusUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestEventBusUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(EventBusUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test topic pattern matching.
+//
+// Test will ensure:
+//   - Exact patterns only match the same topic.
+//   - '*' matches exactly one segment.
+//   - '>' matches one or more trailing segments.
+//   - Invalid patterns are rejected.
+func (suite *EventBusUnitTestSuite) TestMatchTopic() {
+	require.True(suite.T(), MatchTopic("market.ticker.XBT/USD", TickerTopic("XBT/USD")))
+	require.False(suite.T(), MatchTopic("market.ticker.XBT/USD", TickerTopic("ETH/USD")))
+	require.True(suite.T(), MatchTopic("market.*.XBT/USD", TradeTopic("XBT/USD")))
+	require.False(suite.T(), MatchTopic("market.*.XBT/USD", OHLCTopic(messages.M5, "XBT/USD")))
+	require.True(suite.T(), MatchTopic("market.ohlc.*.XBT/USD", OHLCTopic(messages.M5, "XBT/USD")))
+	require.True(suite.T(), MatchTopic("market.>", OHLCTopic(messages.M5, "XBT/USD")))
+	require.True(suite.T(), MatchTopic("market.book.10.XBT/USD", BookTopic(messages.D10, "XBT/USD")))
+	require.False(suite.T(), MatchTopic("market.book.25.XBT/USD", BookTopic(messages.D10, "XBT/USD")))
+	require.False(suite.T(), MatchTopic("market.ticker.>", "market.ticker"))
+	require.False(suite.T(), MatchTopic("market.ticker", TickerTopic("XBT/USD")))
+	require.True(suite.T(), MatchTopic(">", TopicHeartbeat))
+	bus := NewEventBus()
+	for _, pattern := range []string{"", "market..XBT/USD", "market.>.XBT/USD"} {
+		_, err := bus.Subscribe(pattern, 1)
+		require.Error(suite.T(), err, pattern)
+	}
+}
+
+// Test publication on the event bus.
+//
+// Test will ensure:
+//   - Events are delivered to matching subscribers only, with the topic extension set.
+//   - Events are discarded and counted when the channel of a subscriber is full.
+//   - The channel is closed on unsubscribe and no more events are delivered.
+func (suite *EventBusUnitTestSuite) TestPublish() {
+	bus := NewEventBus()
+	all, err := bus.Subscribe(">", 1)
+	require.NoError(suite.T(), err)
+	ticker, err := bus.Subscribe("market.ticker.*", 0)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), DefaultTopicSubscriptionCapacity, cap(ticker.C))
+	e := event.New()
+	e.SetType(string(events.Ticker))
+	bus.Publish(TickerTopic("XBT/USD"), e)
+	bus.Publish(TopicHeartbeat, e)
+	received := <-ticker.C
+	require.Equal(suite.T(), TickerTopic("XBT/USD"), received.Extensions()[TopicExtension])
+	require.Empty(suite.T(), ticker.C)
+	require.Equal(suite.T(), TickerTopic("XBT/USD"), (<-all.C).Extensions()[TopicExtension])
+	require.Equal(suite.T(), uint64(1), all.Dropped())
+	require.Nil(suite.T(), e.Extensions()[TopicExtension])
+	// Unsubscribe
+	all.Unsubscribe()
+	all.Unsubscribe()
+	_, ok := <-all.C
+	require.False(suite.T(), ok)
+	bus.Publish(TickerTopic("XBT/USD"), e)
+	require.Len(suite.T(), ticker.C, 1)
+}
+
+// Test the websocket client publishes events on the event bus.
+//
+// Test will ensure:
+//   - Market data and private data are published under their topics in addition to the
+//     subscription channels.
+//   - Heartbeats are published on the bus.
+func (suite *EventBusUnitTestSuite) TestClientPublication() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	sub, err := client.SubscribeTopic("market.ohlc.>", 10)
+	require.NoError(suite.T(), err)
+	private, err := client.SubscribeTopic("private.*", 10)
+	require.NoError(suite.T(), err)
+	system, err := client.SubscribeTopic(TopicHeartbeat, 10)
+	require.NoError(suite.T(), err)
+	rcv := make(chan event.Event, 10)
+	client.subscriptions.ohlcs[messages.M5] = &ohlcSubscription{pairs: []string{"XBT/USD"}, interval: messages.M5, pub: rcv}
+	client.subscriptions.ownTrades = &ownTradesSubscription{pub: rcv}
+	require.NoError(suite.T(), client.handleOHLC(context.Background(), nil, nil, nil, nil, "s1", 0, "XBT/USD", []byte(`[]`), messages.M5))
+	require.NoError(suite.T(), client.handleOwnTrades(context.Background(), nil, nil, nil, nil, "s1", 0, []byte(`[]`)))
+	require.NoError(suite.T(), client.handleHeartbeat(context.Background(), nil, nil, nil, nil, "s1", 0, []byte(`{"event":"heartbeat"}`)))
+	require.Len(suite.T(), rcv, 2)
+	e := <-sub.C
+	require.Equal(suite.T(), string(events.OHLC), e.Type())
+	require.Equal(suite.T(), OHLCTopic(messages.M5, "XBT/USD"), e.Extensions()[TopicExtension])
+	require.Equal(suite.T(), string(events.OwnTrades), (<-private.C).Type())
+	require.Equal(suite.T(), string(events.Heartbeat), (<-system.C).Type())
+}
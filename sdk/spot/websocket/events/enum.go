@@ -12,6 +12,9 @@ const (
 	Heartbeat WebsocketClientEventTypeEnum = "heartbeat"
 	// Event type used when a new system status is received from the server.
 	SystemStatus WebsocketClientEventTypeEnum = "system_status"
+	// Event type used when an error message which is not related to a request (no request ID) is
+	// received from the server.
+	GeneralError WebsocketClientEventTypeEnum = "general_error"
 	// Event type when new message is received on the own trades channel.
 	OwnTrades WebsocketClientEventTypeEnum = "own_trades"
 	// Event type used when a new message is received on the open orders channel.
@@ -28,4 +31,30 @@ const (
 	BookSnapshot WebsocketClientEventTypeEnum = "book_snapshot"
 	// Event type used when a new message is received on the book channel (update).
 	BookUpdate WebsocketClientEventTypeEnum = "book_update"
+	// Event type used when the top levels of a book maintained by a SubscribeBookTop subscription
+	// have changed.
+	BookTop WebsocketClientEventTypeEnum = "book_top"
+	// Event type used when the client gives up restoring a subscription after the connection with
+	// the server has been reopened. No more data will be published for the subscription.
+	ResubscribeFailed WebsocketClientEventTypeEnum = "resubscribe_failed"
+	// Event type used when the book of a pair maintained by a SubscribeManagedBook subscription has
+	// been resynchronized after a gap has been detected in the stream of book updates.
+	BookResynced WebsocketClientEventTypeEnum = "book_resynced"
+	// Event type used when the values of an indicator maintained by an indicators pipeline have
+	// been updated.
+	IndicatorUpdate WebsocketClientEventTypeEnum = "indicator_update"
+	// Event type used when the analytics computed from a book maintained by a
+	// SubscribeBookAnalytics subscription are published.
+	BookAnalytics WebsocketClientEventTypeEnum = "book_analytics"
+	// Event type used when an anomaly is detected in a trade or OHLC stream by a data quality
+	// checker.
+	DataQualityFinding WebsocketClientEventTypeEnum = "data_quality_finding"
+	// Event type used when the runtime configuration of the client has been updated.
+	ConfigUpdated WebsocketClientEventTypeEnum = "config_updated"
+	// Event type used as the last event published on a channel which is drained before being
+	// closed on unsubscribe.
+	EndOfStream WebsocketClientEventTypeEnum = "end_of_stream"
+	// Event type used when a message which violates the protocol (unexpected binary message,
+	// unknown message type) is received from the server.
+	UnknownMessage WebsocketClientEventTypeEnum = "unknown_message"
 )
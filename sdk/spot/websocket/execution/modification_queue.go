@@ -0,0 +1,557 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// Desired state of an order managed by a ModificationQueue. Orders are identified by their user
+// reference: submitting a new desired state for the same user reference replaces the previous one.
+type DesiredOrder struct {
+	// User reference of the order. Must be strictly positive.
+	UserReference int64
+	// Currency pair (ex: XBT/USD).
+	Pair string
+	// Side: buy or sell.
+	Side messages.SideEnum
+	// Limit price.
+	Price string
+	// Order volume in base currency. An empty value or a zero volume means the order must be
+	// cancelled.
+	Volume string
+	// Optional comma delimited list of order flags (ex: post).
+	OFlags string
+}
+
+// Configuration of a ModificationQueue.
+type ModificationQueueConfig struct {
+	// Rate limit of the commands sent by the queue. Each command (addOrder, editOrder or
+	// cancelOrder) consumes one token. Desired states submitted while the queue waits for a token
+	// are coalesced: only the latest desired state of each order is used once a token is available.
+	//
+	// A nil value disables rate limiting by the queue. Commands are then sent as fast as the
+	// client accepts them (Cf. SetCommandRateLimit on the websocket client).
+	RateLimit *websocket.CommandRateLimit
+	// If true, orders are modified by cancelling them and adding a new order instead of editing
+	// them. This consumes two tokens per modification but preserves the order flags and the time
+	// in force which are not supported by editOrder.
+	CancelReplace bool
+}
+
+// Counters of a ModificationQueue.
+type ModificationQueueStats struct {
+	// Number of desired states submitted.
+	Submitted uint64
+	// Number of desired states replaced by a more recent one before being processed.
+	Coalesced uint64
+	// Number of desired states which did not require any command: the order already matches.
+	Skipped uint64
+	// Number of addOrder commands sent successfully.
+	Added uint64
+	// Number of editOrder commands sent successfully.
+	Edited uint64
+	// Number of cancelOrder commands sent successfully.
+	Cancelled uint64
+	// Number of commands which have failed.
+	Failed uint64
+	// Last error which occured when sending a command, if any.
+	LastError error
+}
+
+// An order placed by the queue.
+type managedOrder struct {
+	// Transaction ID of the order
+	txid string
+	// Last state applied to the order
+	state DesiredOrder
+}
+
+// # Description
+//
+// Queue which converges the orders of a strategy (ex: quotes of a market maker) to a stream of
+// desired states, one per user reference: orders are added, edited or cancelled as needed.
+//
+// Desired states are processed one at a time, in submission order of the user references. When
+// several desired states are submitted for the same order before it is processed, only the latest
+// one is used: rapid successive updates result in a single command. Desired states which match the
+// current order do not result in any command.
+//
+// Orders can be filled or cancelled by the exchange: openOrders messages must be forwarded to
+// ProcessOpenOrders so the queue knows which orders are still open, as the websocket client
+// supports only one openOrders subscription which usually belongs to the application.
+//
+// Failed commands are not retried: the next desired state of the order is applied from the last
+// known state. Commands rejected by the client side rate limiter (RateLimitedError) are retried
+// once the suggested delay has elapsed.
+type ModificationQueue struct {
+	// Websocket client used to send commands
+	client websocket.KrakenSpotPrivateWebsocketClientInterface
+	// Queue configuration
+	config ModificationQueueConfig
+	// Logger used to publish debug/verbose logs
+	logger *log.Logger
+	// Mutex used to protect queue state
+	mu sync.Mutex
+	// Clock used for rate limiting
+	clock clock.Clock
+	// Latest desired states not processed yet by user reference
+	pending map[int64]DesiredOrder
+	// User references with a pending desired state, in submission order
+	ready []int64
+	// Orders placed by the queue by user reference
+	orders map[int64]*managedOrder
+	// Available rate limit tokens
+	tokens float64
+	// Last time tokens have been added
+	last time.Time
+	// Queue counters
+	stats ModificationQueueStats
+	// Channel used to wake up the worker when a desired state is submitted
+	wake chan struct{}
+	// Cancel function of the worker. Nil if not started.
+	stop context.CancelFunc
+	// Channel closed when the worker exits
+	done chan struct{}
+}
+
+// # Description
+//
+// Build a new ModificationQueue. Call Start to start processing desired states.
+//
+// # Inputs
+//
+//   - client: Websocket client used to send commands.
+//   - config: Queue configuration.
+//   - logger: Optional logger used to publish debug/verbose logs. Nil to disable logging.
+//
+// # Return
+//
+// The queue or an error if the configuration is invalid.
+func NewModificationQueue(client websocket.KrakenSpotPrivateWebsocketClientInterface, config ModificationQueueConfig, logger *log.Logger) (*ModificationQueue, error) {
+	if client == nil {
+		return nil, fmt.Errorf("a websocket client must be provided")
+	}
+	if config.RateLimit != nil && (config.RateLimit.Capacity < 1 || config.RateLimit.RefillRate <= 0) {
+		return nil, fmt.Errorf("rate limit capacity must be at least 1 and refill rate must be greater than 0")
+	}
+	if logger == nil {
+		logger = log.New(io.Discard, "", log.Flags())
+	}
+	q := &ModificationQueue{
+		client:  client,
+		config:  config,
+		logger:  logger,
+		clock:   clock.NewSystemClock(),
+		pending: map[int64]DesiredOrder{},
+		ready:   []int64{},
+		orders:  map[int64]*managedOrder{},
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	if config.RateLimit != nil {
+		q.tokens = config.RateLimit.Capacity
+	}
+	q.last = q.clock.Now()
+	return q, nil
+}
+
+// Set the clock used for rate limiting. This can be used to provide a clock.FakeClock in tests.
+// Must be called before Start. If nil, the system clock is used.
+func (q *ModificationQueue) SetClock(clk clock.Clock) {
+	if clk == nil {
+		clk = clock.NewSystemClock()
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.clock = clk
+	q.last = clk.Now()
+}
+
+// # Description
+//
+// Start processing desired states until Stop is called or the provided context is cancelled.
+//
+// # Return
+//
+// An error if the queue has already been started or has been stopped.
+func (q *ModificationQueue) Start(ctx context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.stop != nil {
+		return fmt.Errorf("queue has already been started or has been stopped")
+	}
+	ctx, q.stop = context.WithCancel(ctx)
+	go q.run(ctx)
+	return nil
+}
+
+// Stop processing desired states. Pending desired states are discarded and open orders are left
+// untouched: submit desired states with a zero volume before stopping to cancel them.
+func (q *ModificationQueue) Stop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.stop != nil {
+		q.stop()
+		return
+	}
+	// Queue has not been started: prevent any start and close done channel
+	q.stop = func() {}
+	close(q.done)
+}
+
+// Get a channel which is closed once the queue has stopped.
+func (q *ModificationQueue) Done() <-chan struct{} {
+	return q.done
+}
+
+// # Description
+//
+// Submit the desired state of an order. The desired state replaces any desired state of the same
+// order which has not been processed yet.
+//
+// # Return
+//
+// An error if the desired state is invalid.
+func (q *ModificationQueue) Submit(desired DesiredOrder) error {
+	if desired.UserReference <= 0 {
+		return fmt.Errorf("user reference must be greater than 0. Got %d", desired.UserReference)
+	}
+	if !isZeroVolume(desired.Volume) {
+		if desired.Pair == "" {
+			return fmt.Errorf("a pair must be provided")
+		}
+		if desired.Side != messages.Buy && desired.Side != messages.Sell {
+			return fmt.Errorf("invalid side: %q", desired.Side)
+		}
+		if desired.Price == "" {
+			return fmt.Errorf("a price must be provided")
+		}
+		if _, err := strconv.ParseFloat(desired.Volume, 64); err != nil {
+			return fmt.Errorf("invalid volume %q: %w", desired.Volume, err)
+		}
+	}
+	q.mu.Lock()
+	q.stats.Submitted++
+	if _, found := q.pending[desired.UserReference]; found {
+		q.stats.Coalesced++
+	} else {
+		q.ready = append(q.ready, desired.UserReference)
+	}
+	q.pending[desired.UserReference] = desired
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Get the number of orders with a desired state which has not been processed yet.
+func (q *ModificationQueue) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Get the transaction IDs of the open orders placed by the queue by user reference.
+func (q *ModificationQueue) Orders() map[int64]string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	orders := make(map[int64]string, len(q.orders))
+	for userref, order := range q.orders {
+		orders[userref] = order.txid
+	}
+	return orders
+}
+
+// Get the queue counters.
+func (q *ModificationQueue) Stats() ModificationQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.stats
+}
+
+// # Description
+//
+// Process an openOrders message: orders placed by the queue which have been closed, cancelled or
+// which have expired are forgotten. The next desired state of these orders will add a new order.
+func (q *ModificationQueue) ProcessOpenOrders(msg messages.OpenOrders) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, orders := range msg.Orders {
+		for txid, info := range orders {
+			switch messages.OrderStatusEnum(info.Status) {
+			case messages.Closed, messages.Canceled, messages.Expired:
+				for userref, order := range q.orders {
+					if order.txid == txid {
+						delete(q.orders, userref)
+					}
+				}
+			}
+		}
+	}
+}
+
+// Process desired states until the context is done.
+func (q *ModificationQueue) run(ctx context.Context) {
+	defer close(q.done)
+	for {
+		q.mu.Lock()
+		empty := len(q.ready) == 0
+		q.mu.Unlock()
+		if empty {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.wake:
+				continue
+			}
+		}
+		if err := q.processNext(ctx); err != nil {
+			return
+		}
+	}
+}
+
+// Process the desired state of the next user reference. The desired state is only taken once
+// enough rate limit tokens are available so that states submitted in the meantime are coalesced.
+// Returns an error only if the context is done.
+func (q *ModificationQueue) processNext(ctx context.Context) error {
+	q.mu.Lock()
+	userref := q.ready[0]
+	// Tokens required by the command computed from the desired state known so far
+	cost := q.commandCost(q.pending[userref])
+	q.mu.Unlock()
+	if err := q.waitTokens(ctx, cost); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	q.ready = q.ready[1:]
+	desired := q.pending[userref]
+	delete(q.pending, userref)
+	order := q.orders[userref]
+	var current *managedOrder
+	if order != nil {
+		copied := *order
+		current = &copied
+	}
+	// Give back the tokens which are not needed by the latest desired state
+	q.refund(cost - q.commandCost(desired))
+	q.mu.Unlock()
+	err := q.apply(ctx, desired, current)
+	var rateLimited *websocket.RateLimitedError
+	if errors.As(err, &rateLimited) && ctx.Err() == nil {
+		// Submit the desired state again unless a newer one has been submitted and wait
+		q.mu.Lock()
+		if _, found := q.pending[userref]; !found {
+			q.pending[userref] = desired
+			q.ready = append(q.ready, userref)
+		}
+		clk := q.clock
+		q.mu.Unlock()
+		timer := clk.NewTimer(rateLimited.RetryAfter)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C():
+		}
+	}
+	return nil
+}
+
+// Get the number of commands required to converge the order to the desired state. Must be called
+// with the mutex held.
+func (q *ModificationQueue) commandCost(desired DesiredOrder) int {
+	order := q.orders[desired.UserReference]
+	switch {
+	case isZeroVolume(desired.Volume):
+		if order == nil {
+			return 0
+		}
+		return 1
+	case order == nil:
+		return 1
+	case order.state == desired:
+		return 0
+	case q.config.CancelReplace || order.state.Pair != desired.Pair || order.state.Side != desired.Side || order.state.OFlags != desired.OFlags:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// Wait until the provided number of rate limit tokens are available and consume them.
+func (q *ModificationQueue) waitTokens(ctx context.Context, tokens int) error {
+	if q.config.RateLimit == nil || tokens == 0 {
+		return ctx.Err()
+	}
+	for {
+		q.mu.Lock()
+		now := q.clock.Now()
+		q.tokens = min(q.config.RateLimit.Capacity, q.tokens+now.Sub(q.last).Seconds()*q.config.RateLimit.RefillRate)
+		q.last = now
+		// A command cannot wait for more tokens than the bucket capacity
+		needed := min(float64(tokens), q.config.RateLimit.Capacity)
+		missing := needed - q.tokens
+		if missing <= 0 {
+			q.tokens -= needed
+			q.mu.Unlock()
+			return nil
+		}
+		clk := q.clock
+		q.mu.Unlock()
+		timer := clk.NewTimer(time.Duration(missing / q.config.RateLimit.RefillRate * float64(time.Second)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C():
+		}
+	}
+}
+
+// Give back unused rate limit tokens. Must be called with the mutex held.
+func (q *ModificationQueue) refund(tokens int) {
+	if q.config.RateLimit == nil || tokens <= 0 {
+		return
+	}
+	q.tokens = min(q.config.RateLimit.Capacity, q.tokens+float64(tokens))
+}
+
+// Send the commands required to converge the order to the desired state.
+func (q *ModificationQueue) apply(ctx context.Context, desired DesiredOrder, current *managedOrder) error {
+	userref := desired.UserReference
+	switch {
+	case isZeroVolume(desired.Volume):
+		if current == nil {
+			q.record(func(stats *ModificationQueueStats) { stats.Skipped++ })
+			return nil
+		}
+		return q.cancel(ctx, userref, current)
+	case current == nil:
+		return q.add(ctx, desired)
+	case current.state == desired:
+		q.record(func(stats *ModificationQueueStats) { stats.Skipped++ })
+		return nil
+	case q.config.CancelReplace || current.state.Pair != desired.Pair || current.state.Side != desired.Side || current.state.OFlags != desired.OFlags:
+		// Side, pair and order flags cannot be edited
+		if err := q.cancel(ctx, userref, current); err != nil {
+			return err
+		}
+		return q.add(ctx, desired)
+	default:
+		return q.edit(ctx, desired, current)
+	}
+}
+
+// Add a new order for the desired state.
+func (q *ModificationQueue) add(ctx context.Context, desired DesiredOrder) error {
+	resp, err := q.client.AddOrder(ctx, websocket.AddOrderRequestParameters{
+		OrderType:     string(messages.Limit),
+		Type:          string(desired.Side),
+		Pair:          desired.Pair,
+		Price:         desired.Price,
+		Volume:        desired.Volume,
+		OFlags:        desired.OFlags,
+		UserReference: strconv.FormatInt(desired.UserReference, 10),
+	})
+	if err != nil {
+		return q.fail("add", desired.UserReference, err)
+	}
+	q.logger.Println("order added", desired.UserReference, resp.TxId)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.orders[desired.UserReference] = &managedOrder{txid: resp.TxId, state: desired}
+	q.stats.Added++
+	return nil
+}
+
+// Edit the order to match the desired state. The edited order gets a new transaction ID.
+func (q *ModificationQueue) edit(ctx context.Context, desired DesiredOrder, current *managedOrder) error {
+	userref := strconv.FormatInt(desired.UserReference, 10)
+	resp, err := q.client.EditOrder(ctx, websocket.EditOrderRequestParameters{
+		Id:               current.txid,
+		Pair:             desired.Pair,
+		Price:            desired.Price,
+		Volume:           desired.Volume,
+		NewUserReference: userref,
+	})
+	if err != nil {
+		var rateLimited *websocket.RateLimitedError
+		if !errors.As(err, &rateLimited) {
+			// The order may have been filled or cancelled: forget it so the next desired state
+			// adds a new order.
+			q.forget(desired.UserReference, current.txid)
+		}
+		return q.fail("edit", desired.UserReference, err)
+	}
+	q.logger.Println("order edited", desired.UserReference, current.txid, resp.TxId)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.orders[desired.UserReference] = &managedOrder{txid: resp.TxId, state: desired}
+	q.stats.Edited++
+	return nil
+}
+
+// Cancel the order.
+func (q *ModificationQueue) cancel(ctx context.Context, userref int64, current *managedOrder) error {
+	_, err := q.client.CancelOrder(ctx, websocket.CancelOrderRequestParameters{TxId: []string{current.txid}})
+	if err != nil {
+		var rateLimited *websocket.RateLimitedError
+		if !errors.As(err, &rateLimited) {
+			// The order may have been filled or cancelled already
+			q.forget(userref, current.txid)
+		}
+		return q.fail("cancel", userref, err)
+	}
+	q.logger.Println("order cancelled", userref, current.txid)
+	q.forget(userref, current.txid)
+	q.record(func(stats *ModificationQueueStats) { stats.Cancelled++ })
+	return nil
+}
+
+// Forget an order unless it has been replaced in the meantime.
+func (q *ModificationQueue) forget(userref int64, txid string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if order := q.orders[userref]; order != nil && order.txid == txid {
+		delete(q.orders, userref)
+	}
+}
+
+// Record a failed command and return the error.
+func (q *ModificationQueue) fail(command string, userref int64, err error) error {
+	q.logger.Println("failed to", command, "order", userref, ":", err.Error())
+	q.record(func(stats *ModificationQueueStats) {
+		stats.Failed++
+		stats.LastError = err
+	})
+	return err
+}
+
+// Update the queue counters.
+func (q *ModificationQueue) record(update func(stats *ModificationQueueStats)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	update(&q.stats)
+}
+
+// Return true if the volume means the order must be cancelled.
+func isZeroVolume(volume string) bool {
+	if volume == "" {
+		return true
+	}
+	value, err := strconv.ParseFloat(volume, 64)
+	return err == nil && value == 0
+}
@@ -0,0 +1,247 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/papertrading"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for ModificationQueue.
+//
+// The paper trading engine is used to simulate the websocket client.
+type ModificationQueueTestSuite struct {
+	suite.Suite
+	// Paper trading engine used to place orders
+	engine *papertrading.KrakenSpotPaperTradingClient
+	// Channel which receives open orders events from the paper trading engine
+	openOrders chan event.Event
+}
+
+// Run unit test suite
+func TestModificationQueueTestSuite(t *testing.T) {
+	suite.Run(t, new(ModificationQueueTestSuite))
+}
+
+// Build a new paper trading engine and subscribe to open orders before each test.
+func (suite *ModificationQueueTestSuite) SetupTest() {
+	suite.engine = papertrading.NewKrakenSpotPaperTradingClient(nil, -1, nil)
+	suite.openOrders = make(chan event.Event, 100)
+	require.NoError(suite.T(), suite.engine.SubscribeOpenOrders(context.Background(), false, suite.openOrders))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test configuration and desired states validation.
+func (suite *ModificationQueueTestSuite) TestInvalidInputs() {
+	_, err := NewModificationQueue(nil, ModificationQueueConfig{}, nil)
+	require.Error(suite.T(), err)
+	_, err = NewModificationQueue(suite.engine, ModificationQueueConfig{RateLimit: &websocket.CommandRateLimit{Capacity: 0, RefillRate: 1}}, nil)
+	require.Error(suite.T(), err)
+	q, err := NewModificationQueue(suite.engine, ModificationQueueConfig{}, nil)
+	require.NoError(suite.T(), err)
+	valid := DesiredOrder{UserReference: 1, Pair: "XBT/USD", Side: messages.Buy, Price: "100", Volume: "1"}
+	require.NoError(suite.T(), q.Submit(valid))
+	invalids := []func(d *DesiredOrder){
+		func(d *DesiredOrder) { d.UserReference = 0 },
+		func(d *DesiredOrder) { d.Pair = "" },
+		func(d *DesiredOrder) { d.Side = "hold" },
+		func(d *DesiredOrder) { d.Price = "" },
+		func(d *DesiredOrder) { d.Volume = "one" },
+	}
+	for _, invalidate := range invalids {
+		desired := valid
+		invalidate(&desired)
+		require.Error(suite.T(), q.Submit(desired))
+	}
+	// Cancellation only requires a user reference
+	require.NoError(suite.T(), q.Submit(DesiredOrder{UserReference: 2}))
+	// Stop before start
+	q.Stop()
+	<-q.Done()
+	require.Error(suite.T(), q.Start(context.Background()))
+}
+
+// Test orders converge to the desired states.
+//
+// Test will ensure:
+//   - A new order is added for an unknown user reference.
+//   - A desired state which matches the order does not result in any command.
+//   - Price and volume changes are applied with editOrder.
+//   - Side changes are applied by cancelling the order and adding a new one.
+//   - A zero volume cancels the order.
+func (suite *ModificationQueueTestSuite) TestConverge() {
+	q, err := NewModificationQueue(suite.engine, ModificationQueueConfig{}, nil)
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), q.Start(context.Background()))
+	defer q.Stop()
+	desired := DesiredOrder{UserReference: 1, Pair: "XBT/USD", Side: messages.Buy, Price: "100", Volume: "1"}
+	suite.submitAndWait(q, desired)
+	first := q.Orders()[1]
+	require.NotEmpty(suite.T(), first)
+	require.Equal(suite.T(), uint64(1), q.Stats().Added)
+	// Same state
+	suite.submitAndWait(q, desired)
+	require.Equal(suite.T(), uint64(1), q.Stats().Skipped)
+	require.Equal(suite.T(), first, q.Orders()[1])
+	// Price change: the edited order gets a new transaction ID
+	desired.Price = "101"
+	suite.submitAndWait(q, desired)
+	require.Equal(suite.T(), uint64(1), q.Stats().Edited)
+	require.NotEqual(suite.T(), first, q.Orders()[1])
+	// Side change
+	desired.Side = messages.Sell
+	desired.Price = "200"
+	require.NoError(suite.T(), q.Submit(desired))
+	require.Eventually(suite.T(), func() bool { return q.Stats().Added == 2 }, time.Second, time.Millisecond)
+	require.Equal(suite.T(), uint64(1), q.Stats().Cancelled)
+	// Cancel
+	suite.submitAndWait(q, DesiredOrder{UserReference: 1, Volume: "0"})
+	require.Empty(suite.T(), q.Orders())
+	stats := q.Stats()
+	require.Equal(suite.T(), uint64(2), stats.Cancelled)
+	require.Zero(suite.T(), stats.Failed)
+	all, err := suite.engine.CancellAllOrders(context.Background())
+	require.NoError(suite.T(), err)
+	require.Zero(suite.T(), all.Count)
+}
+
+// Test rapid successive updates are coalesced while waiting for the rate limiter.
+//
+// Test will ensure:
+//   - Commands wait for rate limit tokens.
+//   - Only the latest desired state submitted while waiting is applied, with a single command.
+func (suite *ModificationQueueTestSuite) TestCoalescing() {
+	q, err := NewModificationQueue(suite.engine, ModificationQueueConfig{RateLimit: &websocket.CommandRateLimit{Capacity: 1, RefillRate: 1}}, nil)
+	require.NoError(suite.T(), err)
+	clk := clock.NewFakeClock(time.Now())
+	q.SetClock(clk)
+	require.NoError(suite.T(), q.Start(context.Background()))
+	defer q.Stop()
+	desired := DesiredOrder{UserReference: 1, Pair: "XBT/USD", Side: messages.Buy, Price: "100", Volume: "1"}
+	suite.submitAndWait(q, desired)
+	// Bucket is empty: updates wait for the next token
+	for _, price := range []string{"101", "102", "103"} {
+		desired.Price = price
+		require.NoError(suite.T(), q.Submit(desired))
+	}
+	clk.BlockUntil(1)
+	require.Equal(suite.T(), 1, q.Pending())
+	clk.Advance(time.Second)
+	require.Eventually(suite.T(), func() bool { return q.Stats().Edited == 1 }, time.Second, time.Millisecond)
+	stats := q.Stats()
+	require.Equal(suite.T(), uint64(4), stats.Submitted)
+	require.Equal(suite.T(), uint64(2), stats.Coalesced)
+	q.mu.Lock()
+	require.Equal(suite.T(), "103", q.orders[1].state.Price)
+	q.mu.Unlock()
+	// A desired state which does not require a command does not wait for a token
+	suite.submitAndWait(q, desired)
+	require.Equal(suite.T(), uint64(1), q.Stats().Skipped)
+}
+
+// Test orders closed by the exchange are forgotten.
+//
+// Test will ensure:
+//   - Filled orders are forgotten when openOrders messages are processed.
+//   - The next desired state adds a new order.
+func (suite *ModificationQueueTestSuite) TestProcessOpenOrders() {
+	q, err := NewModificationQueue(suite.engine, ModificationQueueConfig{}, nil)
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), q.Start(context.Background()))
+	defer q.Stop()
+	desired := DesiredOrder{UserReference: 7, Pair: "XBT/USD", Side: messages.Buy, Price: "100", Volume: "1"}
+	suite.submitAndWait(q, desired)
+	require.Len(suite.T(), q.Orders(), 1)
+	// Fill the order and forward open orders messages
+	suite.engine.ProcessSpread("XBT/USD", messages.SpreadData{
+		BestBidPrice:  "99",
+		BestAskPrice:  "100",
+		Timestamp:     "0",
+		BestBidVolume: "10",
+		BestAskVolume: "10",
+	})
+	suite.forwardOpenOrders(q)
+	require.Empty(suite.T(), q.Orders())
+	suite.submitAndWait(q, desired)
+	require.Equal(suite.T(), uint64(2), q.Stats().Added)
+}
+
+// Test commands rejected by the client side rate limiter are retried.
+//
+// Test will ensure:
+//   - A rate limited command is retried after the suggested delay.
+//   - The order is not forgotten when a command is rate limited.
+func (suite *ModificationQueueTestSuite) TestRateLimitedRetry() {
+	client := &rateLimitedEditClient{KrakenSpotPaperTradingClient: suite.engine, rejects: 1}
+	q, err := NewModificationQueue(client, ModificationQueueConfig{}, nil)
+	require.NoError(suite.T(), err)
+	clk := clock.NewFakeClock(time.Now())
+	q.SetClock(clk)
+	require.NoError(suite.T(), q.Start(context.Background()))
+	defer q.Stop()
+	desired := DesiredOrder{UserReference: 1, Pair: "XBT/USD", Side: messages.Buy, Price: "100", Volume: "1"}
+	suite.submitAndWait(q, desired)
+	desired.Price = "101"
+	require.NoError(suite.T(), q.Submit(desired))
+	clk.BlockUntil(1)
+	require.Equal(suite.T(), uint64(1), q.Stats().Failed)
+	require.Len(suite.T(), q.Orders(), 1)
+	clk.Advance(time.Second)
+	require.Eventually(suite.T(), func() bool { return q.Stats().Edited == 1 }, time.Second, time.Millisecond)
+	require.Equal(suite.T(), uint64(1), q.Stats().Added)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Paper trading client which rejects the first editOrder commands with a RateLimitedError.
+type rateLimitedEditClient struct {
+	*papertrading.KrakenSpotPaperTradingClient
+	// Number of editOrder commands to reject
+	rejects int
+}
+
+// Reject the command with a RateLimitedError or edit the paper order.
+func (client *rateLimitedEditClient) EditOrder(ctx context.Context, params websocket.EditOrderRequestParameters) (*messages.EditOrderResponse, error) {
+	if client.rejects > 0 {
+		client.rejects--
+		return nil, &websocket.RateLimitedError{Operation: "edit_order", RetryAfter: time.Second}
+	}
+	return client.KrakenSpotPaperTradingClient.EditOrder(ctx, params)
+}
+
+// Submit the desired state and wait until it has been processed.
+func (suite *ModificationQueueTestSuite) submitAndWait(q *ModificationQueue, desired DesiredOrder) {
+	before := q.Stats()
+	require.NoError(suite.T(), q.Submit(desired))
+	require.Eventually(suite.T(), func() bool {
+		stats := q.Stats()
+		return q.Pending() == 0 && stats.Added+stats.Edited+stats.Cancelled+stats.Skipped+stats.Failed > before.Added+before.Edited+before.Cancelled+before.Skipped+before.Failed
+	}, time.Second, time.Millisecond)
+}
+
+// Forward all the open orders events received so far to the queue.
+func (suite *ModificationQueueTestSuite) forwardOpenOrders(q *ModificationQueue) {
+	for len(suite.openOrders) > 0 {
+		e := <-suite.openOrders
+		msg := new(messages.OpenOrders)
+		require.NoError(suite.T(), json.Unmarshal(e.Data(), msg))
+		q.ProcessOpenOrders(*msg)
+	}
+}
@@ -0,0 +1,418 @@
+// Package execution provides execution algorithms (TWAP, VWAP) which slice a parent order into
+// child orders placed over time with the websocket AddOrder API, and a modification queue which
+// converges orders to desired states with coalesced and rate limited commands.
+package execution
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// Default number of decimals used to round child order volumes.
+const DefaultLotDecimals = 8
+
+// Configuration of an order slicer.
+type SlicerConfig struct {
+	// Currency pair (ex: XBT/USD).
+	Pair string
+	// Side: buy or sell.
+	Side messages.SideEnum
+	// Total quantity to execute, in base currency.
+	Quantity float64
+	// Duration over which child orders are placed.
+	Duration time.Duration
+	// Number of child orders (slices). One slice is placed at the start of each interval of
+	// Duration / Slices.
+	Slices int
+	// Optional participation limit: maximum fraction (ex: 0.1 for 10%) of the market volume
+	// observed during the previous interval a child order can represent. Market volume must be
+	// provided with ProcessMarketTrades: the limit applies once market volume has been observed
+	// and the first slice is not limited if no market volume has been observed before the start.
+	// Quantities which cannot be placed because of the limit are carried over the next slices.
+	//
+	// A zero value disables the participation limit.
+	MaxParticipation float64
+	// Child orders type: market or limit. Defaults to market if empty.
+	OrderType messages.OrderTypeEnum
+	// Limit price of child orders. Required for limit orders, ignored for market orders.
+	LimitPrice string
+	// Number of decimals used to round down child order volumes. Zero means DefaultLotDecimals.
+	LotDecimals int
+	// Optional user reference set on all child orders.
+	UserReference string
+}
+
+// Progress of an order slicer.
+type Progress struct {
+	// Total quantity to execute.
+	Quantity float64
+	// Quantity placed with child orders.
+	Placed float64
+	// Quantity filled.
+	Filled float64
+	// Total cost of filled quantity.
+	Cost float64
+	// Total fees paid.
+	Fee float64
+	// Average fill price. Zero if nothing has been filled.
+	AveragePrice float64
+	// Number of processed slices.
+	SlicesDone int
+	// Total number of slices.
+	Slices int
+	// Transaction IDs of placed child orders.
+	ChildOrders []string
+	// True once all slices have been processed or the slicer has been cancelled.
+	Completed bool
+	// True if the slicer has been cancelled.
+	Cancelled bool
+	// Last error which occured when placing a child order, if any.
+	LastError error
+}
+
+// A child order placed by a slicer.
+type childOrder struct {
+	// Order volume
+	volume float64
+	// Filled volume
+	filled float64
+}
+
+// # Description
+//
+// Order slicer which places child orders via the websocket AddOrder API following a schedule
+// (quantity per slice) and which tracks fills through ownTrades messages.
+//
+// Use NewTWAP or NewVWAP to build a slicer. Fills must be provided by forwarding ownTrades
+// messages to ProcessOwnTrades, as the websocket client supports only one ownTrades
+// subscription which usually belongs to the application.
+type Slicer struct {
+	// Websocket client used to place and cancel child orders
+	client websocket.KrakenSpotPrivateWebsocketClientInterface
+	// Slicer configuration
+	config SlicerConfig
+	// Quantity to place for each slice
+	schedule []float64
+	// Logger used to publish debug/verbose logs
+	logger *log.Logger
+	// Clock used to schedule slices
+	clock clock.Clock
+	// Mutex used to protect slicer state
+	mu sync.Mutex
+	// Mutex held while a child order is placed. Used by Cancel to wait for the child order being
+	// placed, if any, so it is cancelled as well.
+	placeMu sync.Mutex
+	// Slicer progress
+	progress Progress
+	// Child orders by transaction ID
+	children map[string]*childOrder
+	// IDs of processed trades used to ignore duplicates (ex: snapshots)
+	seenTrades map[string]bool
+	// Quantity carried over from previous slices
+	carry float64
+	// Market volume observed since the last slice
+	marketVolume float64
+	// Whether market volume has been observed (Cf. ProcessMarketTrades)
+	marketObserved bool
+	// Cancel function of the scheduling goroutine. Nil if not started.
+	stop context.CancelFunc
+	// Channel closed when scheduling completes
+	done chan struct{}
+}
+
+// Build a new slicer with the provided schedule after validating the configuration.
+func newSlicer(client websocket.KrakenSpotPrivateWebsocketClientInterface, config SlicerConfig, schedule []float64, logger *log.Logger) (*Slicer, error) {
+	if client == nil {
+		return nil, fmt.Errorf("a websocket client must be provided")
+	}
+	if config.Pair == "" {
+		return nil, fmt.Errorf("a pair must be provided")
+	}
+	if config.Side != messages.Buy && config.Side != messages.Sell {
+		return nil, fmt.Errorf("invalid side: %q", config.Side)
+	}
+	if config.Quantity <= 0 {
+		return nil, fmt.Errorf("quantity must be greater than 0. Got %f", config.Quantity)
+	}
+	if config.Duration <= 0 {
+		return nil, fmt.Errorf("duration must be greater than 0. Got %s", config.Duration)
+	}
+	if config.Slices < 1 {
+		return nil, fmt.Errorf("slices must be greater than 0. Got %d", config.Slices)
+	}
+	if config.MaxParticipation < 0 || config.MaxParticipation > 1 {
+		return nil, fmt.Errorf("max participation must be between 0 and 1. Got %f", config.MaxParticipation)
+	}
+	if config.OrderType == "" {
+		config.OrderType = messages.Market
+	}
+	if config.OrderType != messages.Market && config.OrderType != messages.Limit {
+		return nil, fmt.Errorf("child orders can only be market or limit orders. Got %q", config.OrderType)
+	}
+	if config.OrderType == messages.Limit && config.LimitPrice == "" {
+		return nil, fmt.Errorf("a limit price must be provided for limit child orders")
+	}
+	if config.LotDecimals == 0 {
+		config.LotDecimals = DefaultLotDecimals
+	}
+	if logger == nil {
+		logger = log.New(io.Discard, "", log.Flags())
+	}
+	return &Slicer{
+		client:     client,
+		config:     config,
+		schedule:   schedule,
+		logger:     logger,
+		clock:      clock.NewSystemClock(),
+		progress:   Progress{Quantity: config.Quantity, Slices: len(schedule), ChildOrders: []string{}},
+		children:   map[string]*childOrder{},
+		seenTrades: map[string]bool{},
+		done:       make(chan struct{}),
+	}, nil
+}
+
+// Set the clock used to schedule slices. This can be used to provide a clock.FakeClock in tests.
+// Must be called before Start. If nil, the system clock is used.
+func (s *Slicer) SetClock(clk clock.Clock) {
+	if clk == nil {
+		clk = clock.NewSystemClock()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clk
+}
+
+// # Description
+//
+// Start placing child orders. The first slice is placed immediately, then one slice is placed at
+// the start of each interval until all slices have been processed, the slicer is cancelled or the
+// provided context is cancelled.
+//
+// # Return
+//
+// An error if the slicer has already been started or has been cancelled.
+func (s *Slicer) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return fmt.Errorf("slicer has already been started or has been cancelled")
+	}
+	ctx, s.stop = context.WithCancel(ctx)
+	go s.run(ctx)
+	return nil
+}
+
+// # Description
+//
+// Stop placing child orders and cancel open child orders. A child order which is being placed
+// when Cancel is called is waited for and cancelled as well.
+//
+// # Return
+//
+// An error if open child orders could not be cancelled.
+func (s *Slicer) Cancel(ctx context.Context) error {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.stop()
+	} else {
+		// Slicer has not been started: prevent any start and close done channel
+		s.stop = func() {}
+		close(s.done)
+	}
+	s.progress.Cancelled = true
+	s.progress.Completed = true
+	s.mu.Unlock()
+	// Wait for the child order being placed, if any. No child order is placed afterwards.
+	s.placeMu.Lock()
+	s.placeMu.Unlock()
+	s.mu.Lock()
+	open := []string{}
+	for _, txid := range s.progress.ChildOrders {
+		if child := s.children[txid]; child.filled < child.volume {
+			open = append(open, txid)
+		}
+	}
+	s.mu.Unlock()
+	if len(open) == 0 {
+		return nil
+	}
+	_, err := s.client.CancelOrder(ctx, websocket.CancelOrderRequestParameters{TxId: open})
+	if err != nil {
+		return fmt.Errorf("failed to cancel child orders: %w", err)
+	}
+	return nil
+}
+
+// Get a channel which is closed once all slices have been processed or the slicer is cancelled.
+func (s *Slicer) Done() <-chan struct{} {
+	return s.done
+}
+
+// Get the slicer progress.
+func (s *Slicer) Progress() Progress {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	progress := s.progress
+	progress.ChildOrders = append([]string{}, s.progress.ChildOrders...)
+	return progress
+}
+
+// # Description
+//
+// Process an ownTrades message: trades which belong to child orders are recorded as fills.
+// Duplicated trades (ex: trades from a snapshot) are ignored.
+func (s *Slicer) ProcessOwnTrades(msg messages.OwnTrades) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, trades := range msg.Data {
+		for id, trade := range trades {
+			child, ok := s.children[trade.OrderTransactionId]
+			if !ok || s.seenTrades[id] {
+				continue
+			}
+			s.seenTrades[id] = true
+			volume, _ := strconv.ParseFloat(trade.Volume, 64)
+			price, _ := strconv.ParseFloat(trade.Price, 64)
+			fee, _ := strconv.ParseFloat(trade.Fee, 64)
+			cost, err := strconv.ParseFloat(trade.Cost, 64)
+			if err != nil {
+				cost = price * volume
+			}
+			child.filled += volume
+			s.progress.Filled += volume
+			s.progress.Cost += cost
+			s.progress.Fee += fee
+			if s.progress.Filled > 0 {
+				s.progress.AveragePrice = s.progress.Cost / s.progress.Filled
+			}
+		}
+	}
+}
+
+// # Description
+//
+// Process a trade message from the public trade channel: the volume of trades for the slicer
+// pair is recorded and used to enforce the participation limit.
+func (s *Slicer) ProcessMarketTrades(msg messages.Trade) {
+	if msg.Pair != s.config.Pair {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marketObserved = true
+	for _, trade := range msg.Data {
+		volume, err := trade.Volume.Float64()
+		if err == nil {
+			s.marketVolume += volume
+		}
+	}
+}
+
+// Place slices at regular intervals until done. Slice i is placed at start + i * interval.
+func (s *Slicer) run(ctx context.Context) {
+	defer close(s.done)
+	s.mu.Lock()
+	clk := s.clock
+	s.mu.Unlock()
+	interval := s.config.Duration / time.Duration(len(s.schedule))
+	start := clk.Now()
+	var timer clock.Timer
+	for i := range s.schedule {
+		if i > 0 {
+			wait := start.Add(time.Duration(i) * interval).Sub(clk.Now())
+			if timer == nil {
+				timer = clk.NewTimer(wait)
+				defer timer.Stop()
+			} else {
+				timer.Reset(wait)
+			}
+			select {
+			case <-ctx.Done():
+				s.complete()
+				return
+			case <-timer.C():
+			}
+		}
+		s.placeSlice(ctx, i)
+	}
+	s.complete()
+}
+
+// Mark the slicer as completed.
+func (s *Slicer) complete() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress.Completed = true
+}
+
+// Place the child order for the provided slice. Nothing is placed once the slicer is cancelled.
+func (s *Slicer) placeSlice(ctx context.Context, index int) {
+	s.placeMu.Lock()
+	defer s.placeMu.Unlock()
+	s.mu.Lock()
+	if s.progress.Cancelled {
+		s.mu.Unlock()
+		return
+	}
+	quantity := s.schedule[index] + s.carry
+	// Last slice: place everything which has not been placed yet
+	if index == len(s.schedule)-1 {
+		quantity = s.config.Quantity - s.progress.Placed
+	}
+	if s.config.MaxParticipation > 0 && s.marketObserved {
+		quantity = math.Min(quantity, s.marketVolume*s.config.MaxParticipation)
+	}
+	quantity = roundDown(quantity, s.config.LotDecimals)
+	s.marketVolume = 0
+	s.progress.SlicesDone = index + 1
+	if quantity <= 0 {
+		s.carry = s.schedule[index] + s.carry
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+	price := ""
+	if s.config.OrderType == messages.Limit {
+		price = s.config.LimitPrice
+	}
+	// The request is not interrupted when the slicer is stopped: the child order could be placed
+	// without the slicer knowing its transaction ID.
+	resp, err := s.client.AddOrder(context.WithoutCancel(ctx), websocket.AddOrderRequestParameters{
+		OrderType:     string(s.config.OrderType),
+		Type:          string(s.config.Side),
+		Pair:          s.config.Pair,
+		Price:         price,
+		Volume:        strconv.FormatFloat(quantity, 'f', -1, 64),
+		UserReference: s.config.UserReference,
+	})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.logger.Println("failed to place child order:", err.Error())
+		s.progress.LastError = err
+		s.carry = s.schedule[index] + s.carry
+		return
+	}
+	s.logger.Println("child order placed", resp.TxId, quantity)
+	s.carry = math.Max(0, s.schedule[index]+s.carry-quantity)
+	s.progress.Placed += quantity
+	s.progress.ChildOrders = append(s.progress.ChildOrders, resp.TxId)
+	s.children[resp.TxId] = &childOrder{volume: quantity}
+}
+
+// Round down the value to the provided number of decimals.
+func roundDown(value float64, decimals int) float64 {
+	factor := math.Pow10(decimals)
+	// Add a small epsilon to avoid rounding down values like 0.29999999999999999
+	return math.Floor(value*factor+1e-6) / factor
+}
@@ -0,0 +1,286 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/papertrading"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for TWAP and VWAP slicers.
+//
+// The paper trading engine is used to simulate the websocket client.
+type SlicerTestSuite struct {
+	suite.Suite
+	// Paper trading engine used to place child orders
+	engine *papertrading.KrakenSpotPaperTradingClient
+	// Channel which receives own trades events from the paper trading engine
+	ownTrades chan event.Event
+}
+
+// Run unit test suite
+func TestSlicerTestSuite(t *testing.T) {
+	suite.Run(t, new(SlicerTestSuite))
+}
+
+// Build a new paper trading engine and subscribe to own trades before each test.
+func (suite *SlicerTestSuite) SetupTest() {
+	suite.engine = papertrading.NewKrakenSpotPaperTradingClient(nil, -1, nil)
+	suite.ownTrades = make(chan event.Event, 100)
+	require.NoError(suite.T(), suite.engine.SubscribeOwnTrades(context.Background(), false, false, suite.ownTrades))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test configuration validation.
+func (suite *SlicerTestSuite) TestInvalidConfig() {
+	valid := SlicerConfig{Pair: "XBT/USD", Side: messages.Buy, Quantity: 1, Duration: time.Second, Slices: 2}
+	_, err := NewTWAP(suite.engine, valid, nil)
+	require.NoError(suite.T(), err)
+	_, err = NewTWAP(nil, valid, nil)
+	require.Error(suite.T(), err)
+	invalids := []func(c *SlicerConfig){
+		func(c *SlicerConfig) { c.Pair = "" },
+		func(c *SlicerConfig) { c.Side = "hold" },
+		func(c *SlicerConfig) { c.Quantity = 0 },
+		func(c *SlicerConfig) { c.Duration = 0 },
+		func(c *SlicerConfig) { c.Slices = 0 },
+		func(c *SlicerConfig) { c.MaxParticipation = 1.5 },
+		func(c *SlicerConfig) { c.OrderType = messages.StopLoss },
+		func(c *SlicerConfig) { c.OrderType = messages.Limit },
+	}
+	for _, invalidate := range invalids {
+		config := valid
+		invalidate(&config)
+		_, err = NewTWAP(suite.engine, config, nil)
+		require.Error(suite.T(), err)
+	}
+	_, err = NewVWAP(suite.engine, valid, []float64{0, 0}, nil)
+	require.Error(suite.T(), err)
+	_, err = NewVWAP(suite.engine, valid, []float64{1, -1}, nil)
+	require.Error(suite.T(), err)
+}
+
+// Test a TWAP slicer which places all slices and tracks fills.
+//
+// Test will ensure:
+//   - Equal child orders are placed at regular intervals.
+//   - Fills are tracked through ownTrades and duplicated trades are ignored.
+func (suite *SlicerTestSuite) TestTWAP() {
+	slicer, err := NewTWAP(suite.engine, SlicerConfig{
+		Pair:     "XBT/USD",
+		Side:     messages.Buy,
+		Quantity: 0.3,
+		Duration: 150 * time.Millisecond,
+		Slices:   3,
+	}, nil)
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), slicer.Start(context.Background()))
+	require.Error(suite.T(), slicer.Start(context.Background()))
+	<-slicer.Done()
+	progress := slicer.Progress()
+	require.True(suite.T(), progress.Completed)
+	require.Equal(suite.T(), 3, progress.SlicesDone)
+	require.Len(suite.T(), progress.ChildOrders, 3)
+	require.InDelta(suite.T(), 0.3, progress.Placed, 1e-9)
+	require.Zero(suite.T(), progress.Filled)
+	// Fill child orders and forward own trades to the slicer
+	suite.engine.ProcessSpread("XBT/USD", messages.SpreadData{
+		BestBidPrice:  "99",
+		BestAskPrice:  "100",
+		Timestamp:     "0",
+		BestBidVolume: "10",
+		BestAskVolume: "10",
+	})
+	trades := suite.readOwnTrades()
+	slicer.ProcessOwnTrades(*trades)
+	slicer.ProcessOwnTrades(*trades)
+	progress = slicer.Progress()
+	require.InDelta(suite.T(), 0.3, progress.Filled, 1e-9)
+	require.InDelta(suite.T(), 30, progress.Cost, 1e-9)
+	require.InDelta(suite.T(), 100, progress.AveragePrice, 1e-9)
+	require.Greater(suite.T(), progress.Fee, 0.0)
+	// Nothing to cancel
+	require.NoError(suite.T(), slicer.Cancel(context.Background()))
+}
+
+// Test a VWAP slicer with a participation limit which gets cancelled.
+//
+// Test will ensure:
+//   - Slices follow the volume profile.
+//   - The participation limit caps child orders and remaining quantity is carried over.
+//   - Cancel stops the slicer and cancels open child orders.
+func (suite *SlicerTestSuite) TestVWAPWithParticipationAndCancel() {
+	slicer, err := NewVWAP(suite.engine, SlicerConfig{
+		Pair:             "XBT/USD",
+		Side:             messages.Sell,
+		Quantity:         1,
+		Duration:         time.Hour,
+		MaxParticipation: 0.1,
+		OrderType:        messages.Limit,
+		LimitPrice:       "1000",
+	}, []float64{1, 3}, nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), []float64{0.25, 0.75}, slicer.schedule)
+	// Market volume observed before the first slice: 1.5 -> participation limit is 0.15
+	slicer.ProcessMarketTrades(messages.Trade{Pair: "XBT/USD", Data: []messages.TradeData{{Volume: "1"}, {Volume: "0.5"}}})
+	slicer.ProcessMarketTrades(messages.Trade{Pair: "ETH/USD", Data: []messages.TradeData{{Volume: "100"}}})
+	require.NoError(suite.T(), slicer.Start(context.Background()))
+	require.Eventually(suite.T(), func() bool { return slicer.Progress().SlicesDone == 1 }, time.Second, time.Millisecond)
+	progress := slicer.Progress()
+	require.InDelta(suite.T(), 0.15, progress.Placed, 1e-9)
+	require.InDelta(suite.T(), 0.1, slicer.carry, 1e-9)
+	require.False(suite.T(), progress.Completed)
+	// Cancel: the open limit order is cancelled
+	require.NoError(suite.T(), slicer.Cancel(context.Background()))
+	<-slicer.Done()
+	progress = slicer.Progress()
+	require.True(suite.T(), progress.Cancelled)
+	require.True(suite.T(), progress.Completed)
+	all, err := suite.engine.CancellAllOrders(context.Background())
+	require.NoError(suite.T(), err)
+	require.Zero(suite.T(), all.Count)
+}
+
+// Test slices are scheduled with the slicer clock.
+//
+// Test will ensure:
+//   - Slices are placed at the start of each interval of the clock.
+//   - The first slice is not limited when no market volume has been observed.
+//   - The participation limit applies once market volume has been observed.
+//   - No price is sent with market child orders.
+func (suite *SlicerTestSuite) TestScheduleWithFakeClock() {
+	client := &recordingClient{KrakenSpotPrivateWebsocketClientInterface: suite.engine}
+	slicer, err := NewTWAP(client, SlicerConfig{
+		Pair:             "XBT/USD",
+		Side:             messages.Buy,
+		Quantity:         0.3,
+		Duration:         3 * time.Minute,
+		Slices:           3,
+		MaxParticipation: 0.5,
+		LimitPrice:       "1000",
+	}, nil)
+	require.NoError(suite.T(), err)
+	clk := clock.NewFakeClock(time.Now())
+	slicer.SetClock(clk)
+	require.NoError(suite.T(), slicer.Start(context.Background()))
+	require.Eventually(suite.T(), func() bool { return slicer.Progress().SlicesDone == 1 }, time.Second, time.Millisecond)
+	require.InDelta(suite.T(), 0.1, slicer.Progress().Placed, 1e-9)
+	// Market volume observed during the first interval: 0.1 -> participation limit is 0.05
+	slicer.ProcessMarketTrades(messages.Trade{Pair: "XBT/USD", Data: []messages.TradeData{{Volume: "0.1"}}})
+	clk.BlockUntil(1)
+	clk.Advance(time.Minute - time.Second)
+	require.Never(suite.T(), func() bool { return slicer.Progress().SlicesDone > 1 }, 20*time.Millisecond, time.Millisecond)
+	clk.Advance(time.Second)
+	require.Eventually(suite.T(), func() bool { return slicer.Progress().SlicesDone == 2 }, time.Second, time.Millisecond)
+	require.InDelta(suite.T(), 0.15, slicer.Progress().Placed, 1e-9)
+	// No market volume during the second interval: nothing is placed
+	clk.BlockUntil(1)
+	clk.Advance(time.Minute)
+	<-slicer.Done()
+	require.InDelta(suite.T(), 0.15, slicer.Progress().Placed, 1e-9)
+	for _, params := range client.get() {
+		require.Equal(suite.T(), string(messages.Market), params.OrderType)
+		require.Empty(suite.T(), params.Price)
+	}
+}
+
+// Test cancelling a slicer while a child order is being placed.
+//
+// Test will ensure:
+//   - Cancel waits for the child order being placed.
+//   - The child order placed concurrently with Cancel is cancelled.
+func (suite *SlicerTestSuite) TestCancelWhilePlacing() {
+	client := &recordingClient{KrakenSpotPrivateWebsocketClientInterface: suite.engine, entered: make(chan struct{}, 1), unblock: make(chan struct{})}
+	slicer, err := NewTWAP(client, SlicerConfig{
+		Pair:       "XBT/USD",
+		Side:       messages.Buy,
+		Quantity:   1,
+		Duration:   time.Hour,
+		Slices:     2,
+		OrderType:  messages.Limit,
+		LimitPrice: "10",
+	}, nil)
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), slicer.Start(context.Background()))
+	<-client.entered
+	cancelled := make(chan error, 1)
+	go func() { cancelled <- slicer.Cancel(context.Background()) }()
+	require.Never(suite.T(), func() bool { return len(cancelled) > 0 }, 20*time.Millisecond, time.Millisecond)
+	close(client.unblock)
+	require.NoError(suite.T(), <-cancelled)
+	<-slicer.Done()
+	require.Len(suite.T(), slicer.Progress().ChildOrders, 1)
+	all, err := suite.engine.CancellAllOrders(context.Background())
+	require.NoError(suite.T(), err)
+	require.Zero(suite.T(), all.Count)
+}
+
+// Test cancelling a slicer which has not been started.
+func (suite *SlicerTestSuite) TestCancelBeforeStart() {
+	slicer, err := NewTWAP(suite.engine, SlicerConfig{Pair: "XBT/USD", Side: messages.Buy, Quantity: 1, Duration: time.Second, Slices: 1}, nil)
+	require.NoError(suite.T(), err)
+	require.NoError(suite.T(), slicer.Cancel(context.Background()))
+	<-slicer.Done()
+	require.Error(suite.T(), slicer.Start(context.Background()))
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Read and parse the next own trades event.
+func (suite *SlicerTestSuite) readOwnTrades() *messages.OwnTrades {
+	require.NotEmpty(suite.T(), suite.ownTrades)
+	e := <-suite.ownTrades
+	msg := new(messages.OwnTrades)
+	require.NoError(suite.T(), json.Unmarshal(e.Data(), msg))
+	return msg
+}
+
+// Private websocket client which records AddOrder requests and which can block them.
+type recordingClient struct {
+	websocket.KrakenSpotPrivateWebsocketClientInterface
+	mu sync.Mutex
+	// Recorded requests
+	requests []websocket.AddOrderRequestParameters
+	// Optional channel notified when AddOrder is called
+	entered chan struct{}
+	// Optional channel which blocks AddOrder until it is closed
+	unblock chan struct{}
+}
+
+func (c *recordingClient) AddOrder(ctx context.Context, params websocket.AddOrderRequestParameters) (*messages.AddOrderResponse, error) {
+	c.mu.Lock()
+	c.requests = append(c.requests, params)
+	c.mu.Unlock()
+	if c.entered != nil {
+		c.entered <- struct{}{}
+	}
+	if c.unblock != nil {
+		<-c.unblock
+	}
+	return c.KrakenSpotPrivateWebsocketClientInterface.AddOrder(ctx, params)
+}
+
+// Get the recorded requests.
+func (c *recordingClient) get() []websocket.AddOrderRequestParameters {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]websocket.AddOrderRequestParameters{}, c.requests...)
+}
@@ -0,0 +1,33 @@
+package execution
+
+import (
+	"log"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+)
+
+// # Description
+//
+// Build a TWAP (time weighted average price) slicer: the total quantity is split into equal
+// slices placed at regular intervals over the configured duration.
+//
+// # Inputs
+//
+//   - client: Websocket client used to place and cancel child orders.
+//   - config: Slicer configuration.
+//   - logger: Optional logger used to log debug/vebrose messages. If nil, a logger with a discard
+//     writer (noop) will be used.
+//
+// # Return
+//
+// A new Slicer which must be started with Start, or an error if the configuration is invalid.
+func NewTWAP(client websocket.KrakenSpotPrivateWebsocketClientInterface, config SlicerConfig, logger *log.Logger) (*Slicer, error) {
+	schedule := []float64{}
+	if config.Slices > 0 {
+		schedule = make([]float64, config.Slices)
+		for i := range schedule {
+			schedule[i] = config.Quantity / float64(config.Slices)
+		}
+	}
+	return newSlicer(client, config, schedule, logger)
+}
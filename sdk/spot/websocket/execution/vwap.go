@@ -0,0 +1,49 @@
+package execution
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+)
+
+// # Description
+//
+// Build a VWAP (volume weighted average price) slicer: the total quantity is split into slices
+// proportional to the provided volume profile and placed at regular intervals over the
+// configured duration.
+//
+// The volume profile is usually built from the historical volume of the pair for each interval
+// (ex: from OHLC data). The number of slices is the length of the volume profile: the Slices
+// field of the configuration is overriden.
+//
+// # Inputs
+//
+//   - client: Websocket client used to place and cancel child orders.
+//   - config: Slicer configuration.
+//   - volumeProfile: Expected market volume (or relative weight) for each interval.
+//   - logger: Optional logger used to log debug/vebrose messages. If nil, a logger with a discard
+//     writer (noop) will be used.
+//
+// # Return
+//
+// A new Slicer which must be started with Start, or an error if the configuration or the volume
+// profile is invalid.
+func NewVWAP(client websocket.KrakenSpotPrivateWebsocketClientInterface, config SlicerConfig, volumeProfile []float64, logger *log.Logger) (*Slicer, error) {
+	total := 0.0
+	for _, volume := range volumeProfile {
+		if volume < 0 {
+			return nil, fmt.Errorf("volume profile cannot contain negative values. Got %f", volume)
+		}
+		total += volume
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("volume profile must contain at least one positive value")
+	}
+	config.Slices = len(volumeProfile)
+	schedule := make([]float64, len(volumeProfile))
+	for i, volume := range volumeProfile {
+		schedule[i] = config.Quantity * volume / total
+	}
+	return newSlicer(client, config, schedule, logger)
+}
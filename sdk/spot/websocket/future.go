@@ -0,0 +1,284 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Result of an asynchronous operation which will be available in the future. A future is
+// resolved only once: either completed with a value or failed with an error. Subsequent attempts
+// to resolve the future are ignored.
+//
+// Futures are used by the client to fulfil pending websocket requests and are returned by the
+// asynchronous variants of the websocket requests (Cf. AddOrderAsync). Their results can be
+// awaited (Cf. Await), polled (Cf. Poll) or selected on (Cf. Done).
+type Future[T any] struct {
+	// Channel closed once the future has been resolved
+	done chan struct{}
+	// Mutex used to protect the results and the callbacks
+	mu sync.Mutex
+	// Value of the future. Set before done is closed.
+	value T
+	// Error of the future. Set before done is closed.
+	err error
+	// Functions called once the future has been resolved
+	callbacks []func(value T, err error)
+}
+
+// Build a new unresolved future.
+func NewFuture[T any]() *Future[T] {
+	return &Future[T]{done: make(chan struct{})}
+}
+
+// # Description
+//
+// Complete the future with the provided value.
+//
+// # Inputs
+//
+//   - value: Value of the future.
+//
+// # Return
+//
+// True if the future has been completed, false if it was already resolved.
+func (f *Future[T]) Complete(value T) bool {
+	return f.resolve(value, nil)
+}
+
+// # Description
+//
+// Fail the future with the provided error.
+//
+// # Inputs
+//
+//   - err: Error of the future. Must not be nil.
+//
+// # Return
+//
+// True if the future has been failed, false if it was already resolved.
+func (f *Future[T]) Fail(err error) bool {
+	var zero T
+	return f.resolve(zero, err)
+}
+
+// # Description
+//
+// Wait until the future is resolved or until the provided context is done.
+//
+// # Inputs
+//
+//   - ctx: Context used for coordination purpose. The wait stops when the context is done.
+//
+// # Return
+//
+// The value and the error of the future once it has been resolved. Both a value and an error can
+// be returned if the operation has received a response which reports an error. The context
+// error is returned if the context is done before the future is resolved.
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	// Give precedence to the result when both the future and the context are done
+	select {
+	case <-f.done:
+		return f.get()
+	default:
+	}
+	select {
+	case <-f.done:
+		return f.get()
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// # Description
+//
+// Get the results of the future without waiting.
+//
+// # Return
+//
+// The value of the future, true and the error of the future if the future has been resolved. A
+// zero value, false and a nil error otherwise.
+func (f *Future[T]) Poll() (T, bool, error) {
+	select {
+	case <-f.done:
+		value, err := f.get()
+		return value, true, err
+	default:
+		var zero T
+		return zero, false, nil
+	}
+}
+
+// Get a channel which is closed once the future has been resolved.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Resolve the future with the provided value and error and call the registered callbacks. True
+// if the future has been resolved by this call.
+func (f *Future[T]) resolve(value T, err error) bool {
+	f.mu.Lock()
+	select {
+	case <-f.done:
+		f.mu.Unlock()
+		return false
+	default:
+	}
+	f.value, f.err = value, err
+	callbacks := f.callbacks
+	f.callbacks = nil
+	close(f.done)
+	f.mu.Unlock()
+	// Callbacks are called without holding the lock so they can use the future
+	for _, callback := range callbacks {
+		callback(value, err)
+	}
+	return true
+}
+
+// Register a function called with the results once the future has been resolved. The function is
+// called by the goroutine which resolves the future or right away if the future is already
+// resolved. It must not block.
+func (f *Future[T]) onResolve(callback func(value T, err error)) {
+	f.mu.Lock()
+	select {
+	case <-f.done:
+		f.mu.Unlock()
+		callback(f.get())
+	default:
+		f.callbacks = append(f.callbacks, callback)
+		f.mu.Unlock()
+	}
+}
+
+// Get the value and the error of a resolved future. Must only be called once Done is closed.
+func (f *Future[T]) get() (T, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.value, f.err
+}
+
+// Get a future resolved with the results of the future returned by send. send is enqueued in the
+// provided sender so the caller does not wait for the request to be sent and requests are sent
+// in the order of the calls.
+func sendAsync[T any](sender *asyncSender, send func() *Future[T]) *Future[T] {
+	future := NewFuture[T]()
+	sender.enqueue(func() {
+		send().onResolve(func(value T, err error) {
+			future.resolve(value, err)
+		})
+	})
+	return future
+}
+
+// FIFO queue of requests sent by a single goroutine, in the order they have been enqueued. The
+// goroutine is started when a request is enqueued in an empty queue and exits once the queue is
+// empty. The zero value is ready to use.
+type asyncSender struct {
+	// Mutex used to protect the queue
+	mu sync.Mutex
+	// Requests waiting to be sent
+	queue []func()
+	// Tells whether the goroutine which sends the requests is running
+	running bool
+}
+
+// Enqueue a request. The call does not block.
+func (s *asyncSender) enqueue(send func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, send)
+	if !s.running {
+		s.running = true
+		go s.run()
+	}
+}
+
+// Send the enqueued requests one after the other until the queue is empty.
+func (s *asyncSender) run() {
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.running = false
+			s.mu.Unlock()
+			return
+		}
+		send := s.queue[0]
+		s.queue[0] = nil
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+		send()
+	}
+}
+
+// End the span of a request which could not be sent and get a future failed with the provided
+// error. The error must have been traced.
+func failedRequest[T any](span trace.Span, err error) *Future[T] {
+	span.End()
+	future := NewFuture[T]()
+	future.Fail(err)
+	return future
+}
+
+// # Description
+//
+// Get a future resolved with the results of a pending request which has been sent. No goroutine
+// is used to wait for the response: the future is resolved by the goroutine which fulfils the
+// pending request.
+//
+// The pending request is failed with an OperationInterruptedError and discarded when the
+// context is done before it has been fulfilled. Errors published on the pending request are
+// wrapped in an OperationError. The span of the request is ended once the future is resolved.
+//
+// # Inputs
+//
+//   - ctx: Context of the request.
+//   - client: Client which has sent the request. Used for tracing purpose.
+//   - span: Span of the request.
+//   - operation: Name of the operation (ex: add_order).
+//   - pending: Future of the pending request.
+//   - discard: Function used to remove the pending request from the pending requests.
+//   - process: Function used to process the response received from the server.
+//
+// # Return
+//
+// A future resolved with the results of the processing of the response or with an error.
+func awaitPendingRequest[T any](
+	ctx context.Context,
+	client *krakenSpotWebsocketClient,
+	span trace.Span,
+	operation string,
+	pending *Future[T],
+	discard func(),
+	process func(resp T) (T, error)) *Future[T] {
+	failure := strings.ReplaceAll(operation, "_", " ") + " failed"
+	future := NewFuture[T]()
+	stop := context.AfterFunc(ctx, func() {
+		if pending.Fail(&OperationInterruptedError{Operation: operation, Root: fmt.Errorf("%s: %w", failure, ctx.Err())}) {
+			discard()
+		}
+	})
+	pending.onResolve(func(resp T, err error) {
+		stop()
+		if err != nil {
+			interrupted := new(OperationInterruptedError)
+			if !errors.As(err, &interrupted) {
+				err = &OperationError{Operation: operation, Root: fmt.Errorf("%s: %w", failure, err)}
+			}
+			var zero T
+			resp = zero
+			tracing.HandleAndTraLogError(span, client.logger, err)
+		} else {
+			resp, err = process(resp)
+		}
+		span.End()
+		future.resolve(resp, err)
+	})
+	return future
+}
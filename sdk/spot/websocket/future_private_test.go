@@ -0,0 +1,20 @@
+//go:build !goctopus_publiconly
+
+package websocket
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/require"
+)
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test invalid deadlines are rejected by AddOrder before the order is sent.
+func (suite *FutureUnitTestSuite) TestAddOrderAsyncInvalidDeadline() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	_, err := client.AddOrderAsync(context.Background(), AddOrderRequestParameters{Deadline: "tomorrow"}).Await(context.Background())
+	require.ErrorContains(suite.T(), err, "invalid deadline")
+}
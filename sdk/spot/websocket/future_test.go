@@ -0,0 +1,278 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/trace"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for Future
+type FutureUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestFutureUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(FutureUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test Complete, Fail and Await.
+//
+// Test will ensure:
+//   - Await returns the context error when the context is done before the future is resolved.
+//   - A future is resolved only once: subsequent Complete and Fail calls are ignored.
+//   - Await returns the result of a resolved future even if the context is done.
+//   - Concurrent resolutions resolve the future only once.
+func (suite *FutureUnitTestSuite) TestFuture() {
+	future := NewFuture[int]()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err := future.Await(ctx)
+	require.ErrorIs(suite.T(), err, context.DeadlineExceeded)
+	require.False(suite.T(), isResolved(future))
+	require.True(suite.T(), future.Complete(42))
+	require.False(suite.T(), future.Complete(43))
+	require.False(suite.T(), future.Fail(fmt.Errorf("boom")))
+	value, err := future.Await(ctx)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 42, value)
+	// Failed future
+	failed := NewFuture[int]()
+	require.True(suite.T(), failed.Fail(fmt.Errorf("boom")))
+	_, err = failed.Await(context.Background())
+	require.EqualError(suite.T(), err, "boom")
+	// Concurrent resolutions
+	concurrent := NewFuture[int]()
+	resolved := make(chan bool, 10)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resolved <- concurrent.Complete(i)
+		}(i)
+	}
+	wg.Wait()
+	close(resolved)
+	count := 0
+	for ok := range resolved {
+		if ok {
+			count++
+		}
+	}
+	require.Equal(suite.T(), 1, count)
+}
+
+// Test Poll.
+//
+// Test will ensure:
+//   - Poll reports an unresolved future without blocking.
+//   - Poll returns the value and the error of a resolved future.
+func (suite *FutureUnitTestSuite) TestPoll() {
+	future := NewFuture[int]()
+	value, ok, err := future.Poll()
+	require.False(suite.T(), ok)
+	require.NoError(suite.T(), err)
+	require.Zero(suite.T(), value)
+	future.Fail(fmt.Errorf("boom"))
+	_, ok, err = future.Poll()
+	require.True(suite.T(), ok)
+	require.EqualError(suite.T(), err, "boom")
+}
+
+// Test PingAsync.
+//
+// Test will ensure:
+//   - PingAsync returns before the pong is received.
+//   - The future is resolved with the pong when the client handles it.
+//   - The future is failed with an OperationInterruptedError when the context is done before
+//     the pong is received and the pending request is discarded.
+//   - The future is failed right away when the ping cannot be sent.
+func (suite *FutureUnitTestSuite) TestPingAsync() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	defer client.stopConnectionWriter()
+	conn := newConnectionMock()
+	sent := make(chan int64, 2)
+	conn.On("Write", mock.Anything, wsadapters.Text, mock.Anything).Run(func(args mock.Arguments) {
+		ping := new(messages.Ping)
+		require.NoError(suite.T(), json.Unmarshal(args.Get(2).([]byte), ping))
+		sent <- ping.ReqId
+	}).Return(nil)
+	client.conn = conn
+	// Pong received
+	future := client.PingAsync(context.Background())
+	reqid := <-sent
+	_, ok, _ := future.Poll()
+	require.False(suite.T(), ok)
+	require.NoError(suite.T(), client.handlePong(context.Background(), nil, nil, nil, nil, "", 0, []byte(fmt.Sprintf(`{"event":"pong","reqid":%d}`, reqid))))
+	pong, ok, err := future.Poll()
+	require.True(suite.T(), ok)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), reqid, *pong.ReqId)
+	require.Empty(suite.T(), client.requests.pendingPing)
+	// Interrupted
+	ctx, cancel := context.WithCancel(context.Background())
+	future = client.PingAsync(ctx)
+	<-sent
+	cancel()
+	_, err = future.Await(context.Background())
+	interrupted := new(OperationInterruptedError)
+	require.ErrorAs(suite.T(), err, &interrupted)
+	require.Equal(suite.T(), "ping", interrupted.Operation)
+	client.pendingPingMu.Lock()
+	require.Empty(suite.T(), client.requests.pendingPing)
+	client.pendingPingMu.Unlock()
+	// No connection
+	client.conn = nil
+	_, ok, err = client.PingAsync(context.Background()).Poll()
+	require.True(suite.T(), ok)
+	require.ErrorContains(suite.T(), err, "failed to send ping request")
+}
+
+// Test the asynchronous variants of the order management methods.
+//
+// Test will ensure:
+//   - The future of AddOrderAsync is resolved with the error returned by AddOrder.
+//   - The future is failed when the request cannot be sent because its context is done.
+//   - AddOrderAsync, EditOrderAsync and CancelOrderAsync return without waiting for the rate
+//     limiter.
+func (suite *FutureUnitTestSuite) TestAddOrderAsync() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	// Invalid parameters are rejected by AddOrder
+	future := client.AddOrderAsync(context.Background(), AddOrderRequestParameters{StpType: "cancel-all"})
+	resp, err := future.Await(context.Background())
+	require.Nil(suite.T(), resp)
+	require.ErrorContains(suite.T(), err, "add order failed")
+	// Canceled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.CancellAllOrdersAsync(ctx).Await(context.Background())
+	require.Error(suite.T(), err)
+	// Rate limited: the futures are returned before the requests are sent
+	client.SetClock(clock.NewFakeClock(time.Now()))
+	require.NoError(suite.T(), client.SetCommandRateLimit(&CommandRateLimit{Capacity: 1, RefillRate: 0.001}, ""))
+	span := trace.SpanFromContext(context.Background())
+	require.NoError(suite.T(), client.waitCommandRateLimit(context.Background(), span, "add_order"))
+	ctx, cancel = context.WithCancel(context.Background())
+	add := client.AddOrderAsync(ctx, AddOrderRequestParameters{OrderType: "limit", Type: "buy", Pair: "XBT/USD", Price: "100", Volume: "1"})
+	edit := client.EditOrderAsync(ctx, EditOrderRequestParameters{Id: "OABC", Pair: "XBT/USD"})
+	cancelOrder := client.CancelOrderAsync(ctx, CancelOrderRequestParameters{TxId: []string{"OABC"}})
+	for _, done := range []<-chan struct{}{add.Done(), edit.Done(), cancelOrder.Done()} {
+		select {
+		case <-done:
+			suite.FailNow("future must not be resolved while the request waits for the rate limiter")
+		default:
+		}
+	}
+	cancel()
+	rlerr := new(RateLimitedError)
+	_, err = add.Await(context.Background())
+	require.ErrorAs(suite.T(), err, &rlerr)
+	_, err = edit.Await(context.Background())
+	require.ErrorAs(suite.T(), err, &rlerr)
+	_, err = cancelOrder.Await(context.Background())
+	require.ErrorAs(suite.T(), err, &rlerr)
+}
+
+// Test sendAsync.
+//
+// Test will ensure:
+//   - The call does not wait for the request to be sent.
+//   - Requests are sent in the order of the calls, one after the other.
+//   - Futures are resolved with the results of the sent requests.
+func (suite *FutureUnitTestSuite) TestSendAsyncOrder() {
+	sender := &asyncSender{}
+	release := make(chan struct{})
+	mu := sync.Mutex{}
+	sent := []int{}
+	futures := []*Future[int]{}
+	for i := 0; i < 100; i++ {
+		i := i
+		futures = append(futures, sendAsync(sender, func() *Future[int] {
+			if i == 0 {
+				// Block the first request until all requests are enqueued
+				<-release
+			}
+			mu.Lock()
+			sent = append(sent, i)
+			mu.Unlock()
+			future := NewFuture[int]()
+			future.Complete(i)
+			return future
+		}))
+	}
+	close(release)
+	for i, future := range futures {
+		value, err := future.Await(context.Background())
+		require.NoError(suite.T(), err)
+		require.Equal(suite.T(), i, value)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(suite.T(), sent, 100)
+	for i := range sent {
+		require.Equal(suite.T(), i, sent[i])
+	}
+}
+
+// Test pending requests are fulfilled through their futures.
+//
+// Test will ensure:
+//   - The future of a pending request is completed with the response from the server.
+//   - The future of a pending request is failed when the server replies with an error message.
+//   - The futures of pending requests are failed when the connection is closed.
+func (suite *FutureUnitTestSuite) TestPendingRequestFutures() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	ok := &pendingAddOrderRequest{result: NewFuture[*messages.AddOrderResponse]()}
+	client.requests.pendingAddOrderRequests[1] = ok
+	require.NoError(suite.T(), client.handleAddOrderStatus(context.Background(), nil, nil, nil, nil, "", 0, []byte(`{"event":"addOrderStatus","reqid":1,"status":"ok","txid":"OXYZ"}`)))
+	resp, err := ok.result.Await(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "OXYZ", resp.TxId)
+	// Error message
+	ko := &pendingEditOrderRequest{result: NewFuture[*messages.EditOrderResponse]()}
+	client.requests.pendingEditOrderRequests[2] = ko
+	require.NoError(suite.T(), client.handleErrorMessage(context.Background(), nil, nil, nil, nil, "", 0, []byte(`{"event":"error","errorMessage":"Unsupported event","reqid":2}`)))
+	_, err = ko.result.Await(context.Background())
+	require.ErrorContains(suite.T(), err, "Unsupported event")
+	// Connection closed
+	sub := &pendingSubscribe{result: NewFuture[struct{}](), served: map[string]bool{}, errPerPair: map[string]error{}}
+	client.requests.pendingSubscribe[3] = sub
+	client.OnClose(context.Background(), nil, nil, nil)
+	_, err = sub.result.Await(context.Background())
+	require.EqualError(suite.T(), err, "connection has been closed")
+	require.Empty(suite.T(), client.requests.pendingSubscribe)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Check whether the provided future has been resolved.
+func isResolved[T any](future *Future[T]) bool {
+	select {
+	case <-future.Done():
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,439 @@
+// Package indicators provides streaming implementations of common technical indicators (SMA, EMA,
+// RSI, MACD, Bollinger Bands) and a Pipeline which feeds them from an OHLC or trade subscription
+// and publishes their values as events.
+package indicators
+
+import (
+	"fmt"
+	"math"
+)
+
+// Key used for the value of single value indicators (SMA, EMA, RSI).
+const ValueKey = "value"
+
+// Keys used for the values of a MACD indicator.
+const (
+	MACDKey      = "macd"
+	SignalKey    = "signal"
+	HistogramKey = "histogram"
+)
+
+// Keys used for the values of a Bollinger Bands indicator.
+const (
+	UpperKey  = "upper"
+	MiddleKey = "middle"
+	LowerKey  = "lower"
+)
+
+// # Description
+//
+// Interface for streaming indicators. Indicators are updated one sample at a time (ex: the close
+// price of a committed candle) and keep only the state they need to compute their next values.
+//
+// Indicators are not safe for concurrent use.
+type Indicator interface {
+	// Name which identifies the indicator and its parameters (ex: ema_20).
+	Name() string
+	// Add a new sample.
+	Update(value float64)
+	// True once enough samples have been received to produce values.
+	Ready() bool
+	// Current values of the indicator by key (Cf. ValueKey, MACDKey, UpperKey, ...). Nil if the
+	// indicator is not ready.
+	Values() map[string]float64
+}
+
+/*************************************************************************************************/
+/* SMA                                                                                           */
+/*************************************************************************************************/
+
+// Simple moving average over the last samples.
+type SMA struct {
+	// Number of samples to average
+	period int
+	// Last samples - Ring buffer
+	window []float64
+	// Index of the next sample in window
+	next int
+	// Number of samples received, capped to period
+	count int
+	// Sum of the samples in window
+	sum float64
+}
+
+// # Description
+//
+// Build a new simple moving average.
+//
+// # Inputs
+//
+//   - period: Number of samples to average. Must be greater than 0.
+//
+// # Return
+//
+// The new SMA or an error if period is invalid.
+func NewSMA(period int) (*SMA, error) {
+	if period < 1 {
+		return nil, fmt.Errorf("sma period must be greater than 0. Got %d", period)
+	}
+	return &SMA{period: period, window: make([]float64, period)}, nil
+}
+
+// Name of the indicator: sma_<period>
+func (sma *SMA) Name() string {
+	return fmt.Sprintf("sma_%d", sma.period)
+}
+
+// Add a new sample.
+func (sma *SMA) Update(value float64) {
+	sma.sum = sma.sum - sma.window[sma.next] + value
+	sma.window[sma.next] = value
+	sma.next = (sma.next + 1) % sma.period
+	if sma.count < sma.period {
+		sma.count++
+	}
+}
+
+// True once period samples have been received.
+func (sma *SMA) Ready() bool {
+	return sma.count == sma.period
+}
+
+// Current average (ValueKey). Nil if not ready.
+func (sma *SMA) Values() map[string]float64 {
+	if !sma.Ready() {
+		return nil
+	}
+	return map[string]float64{ValueKey: sma.Value()}
+}
+
+// Current average. Zero if not ready.
+func (sma *SMA) Value() float64 {
+	if !sma.Ready() {
+		return 0
+	}
+	return sma.sum / float64(sma.period)
+}
+
+/*************************************************************************************************/
+/* EMA                                                                                           */
+/*************************************************************************************************/
+
+// Exponential moving average. The average is seeded with the simple average of the first period
+// samples and then uses a smoothing factor of 2 / (period + 1).
+type EMA struct {
+	// Number of samples used to seed the average and compute the smoothing factor
+	period int
+	// Smoothing factor
+	alpha float64
+	// Number of samples received, capped to period
+	count int
+	// Sum of the samples received while seeding
+	seed float64
+	// Current average
+	value float64
+}
+
+// # Description
+//
+// Build a new exponential moving average.
+//
+// # Inputs
+//
+//   - period: Period of the average. Must be greater than 0.
+//
+// # Return
+//
+// The new EMA or an error if period is invalid.
+func NewEMA(period int) (*EMA, error) {
+	if period < 1 {
+		return nil, fmt.Errorf("ema period must be greater than 0. Got %d", period)
+	}
+	return &EMA{period: period, alpha: 2 / float64(period+1)}, nil
+}
+
+// Name of the indicator: ema_<period>
+func (ema *EMA) Name() string {
+	return fmt.Sprintf("ema_%d", ema.period)
+}
+
+// Add a new sample.
+func (ema *EMA) Update(value float64) {
+	if ema.count < ema.period {
+		ema.count++
+		ema.seed += value
+		if ema.count == ema.period {
+			ema.value = ema.seed / float64(ema.period)
+		}
+		return
+	}
+	ema.value = ema.alpha*value + (1-ema.alpha)*ema.value
+}
+
+// True once period samples have been received.
+func (ema *EMA) Ready() bool {
+	return ema.count == ema.period
+}
+
+// Current average (ValueKey). Nil if not ready.
+func (ema *EMA) Values() map[string]float64 {
+	if !ema.Ready() {
+		return nil
+	}
+	return map[string]float64{ValueKey: ema.value}
+}
+
+// Current average. Zero if not ready.
+func (ema *EMA) Value() float64 {
+	return ema.value
+}
+
+/*************************************************************************************************/
+/* RSI                                                                                           */
+/*************************************************************************************************/
+
+// Relative strength index using Wilder's smoothing. The average gain and loss are seeded with the
+// simple average of the first period changes.
+type RSI struct {
+	// Number of changes used by the averages
+	period int
+	// Previous sample
+	previous float64
+	// True once the first sample has been received
+	started bool
+	// Number of changes received, capped to period
+	count int
+	// Average gain
+	gain float64
+	// Average loss
+	loss float64
+}
+
+// # Description
+//
+// Build a new relative strength index.
+//
+// # Inputs
+//
+//   - period: Number of changes used by the averages. Must be greater than 0.
+//
+// # Return
+//
+// The new RSI or an error if period is invalid.
+func NewRSI(period int) (*RSI, error) {
+	if period < 1 {
+		return nil, fmt.Errorf("rsi period must be greater than 0. Got %d", period)
+	}
+	return &RSI{period: period}, nil
+}
+
+// Name of the indicator: rsi_<period>
+func (rsi *RSI) Name() string {
+	return fmt.Sprintf("rsi_%d", rsi.period)
+}
+
+// Add a new sample.
+func (rsi *RSI) Update(value float64) {
+	if !rsi.started {
+		rsi.previous = value
+		rsi.started = true
+		return
+	}
+	change := value - rsi.previous
+	rsi.previous = value
+	gain, loss := math.Max(change, 0), math.Max(-change, 0)
+	if rsi.count < rsi.period {
+		// Seed: accumulate then average
+		rsi.count++
+		rsi.gain += gain
+		rsi.loss += loss
+		if rsi.count == rsi.period {
+			rsi.gain = rsi.gain / float64(rsi.period)
+			rsi.loss = rsi.loss / float64(rsi.period)
+		}
+		return
+	}
+	p := float64(rsi.period)
+	rsi.gain = (rsi.gain*(p-1) + gain) / p
+	rsi.loss = (rsi.loss*(p-1) + loss) / p
+}
+
+// True once period + 1 samples have been received.
+func (rsi *RSI) Ready() bool {
+	return rsi.count == rsi.period
+}
+
+// Current index (ValueKey), between 0 and 100. Nil if not ready.
+func (rsi *RSI) Values() map[string]float64 {
+	if !rsi.Ready() {
+		return nil
+	}
+	return map[string]float64{ValueKey: rsi.Value()}
+}
+
+// Current index, between 0 and 100. Zero if not ready.
+func (rsi *RSI) Value() float64 {
+	if !rsi.Ready() {
+		return 0
+	}
+	if rsi.loss == 0 {
+		if rsi.gain == 0 {
+			// Flat market
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+rsi.gain/rsi.loss)
+}
+
+/*************************************************************************************************/
+/* MACD                                                                                          */
+/*************************************************************************************************/
+
+// Moving average convergence divergence: difference between a fast and a slow EMA, its signal
+// line (EMA of the difference) and the histogram (difference minus signal).
+type MACD struct {
+	// Fast EMA
+	fast *EMA
+	// Slow EMA
+	slow *EMA
+	// EMA of the MACD line
+	signal *EMA
+	// Last MACD line value
+	macd float64
+}
+
+// # Description
+//
+// Build a new MACD indicator. Common parameters are 12, 26 and 9.
+//
+// # Inputs
+//
+//   - fast: Period of the fast EMA. Must be greater than 0.
+//   - slow: Period of the slow EMA. Must be greater than fast.
+//   - signal: Period of the signal line EMA. Must be greater than 0.
+//
+// # Return
+//
+// The new MACD or an error if the periods are invalid.
+func NewMACD(fast int, slow int, signal int) (*MACD, error) {
+	if fast >= slow {
+		return nil, fmt.Errorf("macd fast period must be less than slow period. Got %d and %d", fast, slow)
+	}
+	fastEMA, err := NewEMA(fast)
+	if err != nil {
+		return nil, fmt.Errorf("invalid macd fast period: %w", err)
+	}
+	slowEMA, err := NewEMA(slow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid macd slow period: %w", err)
+	}
+	signalEMA, err := NewEMA(signal)
+	if err != nil {
+		return nil, fmt.Errorf("invalid macd signal period: %w", err)
+	}
+	return &MACD{fast: fastEMA, slow: slowEMA, signal: signalEMA}, nil
+}
+
+// Name of the indicator: macd_<fast>_<slow>_<signal>
+func (macd *MACD) Name() string {
+	return fmt.Sprintf("macd_%d_%d_%d", macd.fast.period, macd.slow.period, macd.signal.period)
+}
+
+// Add a new sample.
+func (macd *MACD) Update(value float64) {
+	macd.fast.Update(value)
+	macd.slow.Update(value)
+	if macd.slow.Ready() {
+		macd.macd = macd.fast.Value() - macd.slow.Value()
+		macd.signal.Update(macd.macd)
+	}
+}
+
+// True once the signal line is ready: slow + signal - 1 samples have been received.
+func (macd *MACD) Ready() bool {
+	return macd.signal.Ready()
+}
+
+// Current MACD line (MACDKey), signal line (SignalKey) and histogram (HistogramKey). Nil if not
+// ready.
+func (macd *MACD) Values() map[string]float64 {
+	if !macd.Ready() {
+		return nil
+	}
+	return map[string]float64{
+		MACDKey:      macd.macd,
+		SignalKey:    macd.signal.Value(),
+		HistogramKey: macd.macd - macd.signal.Value(),
+	}
+}
+
+/*************************************************************************************************/
+/* BOLLINGER BANDS                                                                               */
+/*************************************************************************************************/
+
+// Bollinger Bands: simple moving average (middle band) and bands placed a number of standard
+// deviations above and below it. The population standard deviation of the window is used.
+type BollingerBands struct {
+	// Moving average of the samples
+	sma *SMA
+	// Number of standard deviations between the middle band and the upper/lower bands
+	k float64
+}
+
+// # Description
+//
+// Build new Bollinger Bands. Common parameters are 20 and 2.
+//
+// # Inputs
+//
+//   - period: Number of samples used by the moving average. Must be greater than 0.
+//   - k: Number of standard deviations between the middle band and the upper/lower bands. Must
+//     be greater than 0.
+//
+// # Return
+//
+// The new BollingerBands or an error if the parameters are invalid.
+func NewBollingerBands(period int, k float64) (*BollingerBands, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("bollinger bands k must be greater than 0. Got %f", k)
+	}
+	sma, err := NewSMA(period)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bollinger bands period: %w", err)
+	}
+	return &BollingerBands{sma: sma, k: k}, nil
+}
+
+// Name of the indicator: bollinger_<period>_<k>
+func (bb *BollingerBands) Name() string {
+	return fmt.Sprintf("bollinger_%d_%g", bb.sma.period, bb.k)
+}
+
+// Add a new sample.
+func (bb *BollingerBands) Update(value float64) {
+	bb.sma.Update(value)
+}
+
+// True once period samples have been received.
+func (bb *BollingerBands) Ready() bool {
+	return bb.sma.Ready()
+}
+
+// Current upper (UpperKey), middle (MiddleKey) and lower (LowerKey) bands. Nil if not ready.
+func (bb *BollingerBands) Values() map[string]float64 {
+	if !bb.Ready() {
+		return nil
+	}
+	mean := bb.sma.Value()
+	variance := 0.0
+	for _, v := range bb.sma.window {
+		variance += (v - mean) * (v - mean)
+	}
+	stddev := math.Sqrt(variance / float64(bb.sma.period))
+	return map[string]float64{
+		UpperKey:  mean + bb.k*stddev,
+		MiddleKey: mean,
+		LowerKey:  mean - bb.k*stddev,
+	}
+}
@@ -0,0 +1,149 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for indicators
+type IndicatorsUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestIndicatorsUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(IndicatorsUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test SMA values and readiness.
+func (suite *IndicatorsUnitTestSuite) TestSMA() {
+	_, err := NewSMA(0)
+	require.Error(suite.T(), err)
+	sma, err := NewSMA(3)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "sma_3", sma.Name())
+	feed(sma, 1, 2)
+	require.False(suite.T(), sma.Ready())
+	require.Nil(suite.T(), sma.Values())
+	feed(sma, 3)
+	require.True(suite.T(), sma.Ready())
+	require.InDelta(suite.T(), 2.0, sma.Values()[ValueKey], 1e-9)
+	feed(sma, 4, 5)
+	require.InDelta(suite.T(), 4.0, sma.Values()[ValueKey], 1e-9)
+}
+
+// Test EMA is seeded with a SMA and then smoothed.
+func (suite *IndicatorsUnitTestSuite) TestEMA() {
+	_, err := NewEMA(-1)
+	require.Error(suite.T(), err)
+	ema, err := NewEMA(3)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "ema_3", ema.Name())
+	feed(ema, 1, 2)
+	require.False(suite.T(), ema.Ready())
+	require.Nil(suite.T(), ema.Values())
+	feed(ema, 3)
+	require.InDelta(suite.T(), 2.0, ema.Values()[ValueKey], 1e-9)
+	// alpha = 0.5
+	feed(ema, 4)
+	require.InDelta(suite.T(), 3.0, ema.Values()[ValueKey], 1e-9)
+	feed(ema, 8)
+	require.InDelta(suite.T(), 5.5, ema.Values()[ValueKey], 1e-9)
+}
+
+// Test RSI uses Wilder's smoothing.
+func (suite *IndicatorsUnitTestSuite) TestRSI() {
+	_, err := NewRSI(0)
+	require.Error(suite.T(), err)
+	rsi, err := NewRSI(2)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "rsi_2", rsi.Name())
+	feed(rsi, 1, 2)
+	require.False(suite.T(), rsi.Ready())
+	// Only gains
+	feed(rsi, 3)
+	require.True(suite.T(), rsi.Ready())
+	require.InDelta(suite.T(), 100.0, rsi.Values()[ValueKey], 1e-9)
+	// avg gain = 0.5, avg loss = 0.5
+	feed(rsi, 2)
+	require.InDelta(suite.T(), 50.0, rsi.Values()[ValueKey], 1e-9)
+	// avg gain = 0.25, avg loss = 1.25
+	feed(rsi, 0)
+	require.InDelta(suite.T(), 100-100/(1+0.2), rsi.Values()[ValueKey], 1e-9)
+	// Flat market
+	flat, _ := NewRSI(2)
+	feed(flat, 1, 1, 1)
+	require.InDelta(suite.T(), 50.0, flat.Values()[ValueKey], 1e-9)
+}
+
+// Test MACD line, signal and histogram.
+func (suite *IndicatorsUnitTestSuite) TestMACD() {
+	_, err := NewMACD(26, 12, 9)
+	require.Error(suite.T(), err)
+	_, err = NewMACD(12, 26, 0)
+	require.Error(suite.T(), err)
+	macd, err := NewMACD(2, 3, 2)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "macd_2_3_2", macd.Name())
+	// Slow EMA ready after 3 samples, signal after 4
+	feed(macd, 1, 2, 3)
+	require.False(suite.T(), macd.Ready())
+	require.Nil(suite.T(), macd.Values())
+	feed(macd, 4)
+	require.True(suite.T(), macd.Ready())
+	// fast = 3.5, slow = 3 -> macd = 0.5, signal = avg(0.5, 0.5)
+	values := macd.Values()
+	require.InDelta(suite.T(), 0.5, values[MACDKey], 1e-9)
+	require.InDelta(suite.T(), 0.5, values[SignalKey], 1e-9)
+	require.InDelta(suite.T(), 0.0, values[HistogramKey], 1e-9)
+	// fast = 2/3*10 + 1/3*3.5 = 7.8333, slow = 6.5 -> macd = 1.3333, signal = 2/3*1.3333 + 1/3*0.5
+	feed(macd, 10)
+	values = macd.Values()
+	require.InDelta(suite.T(), 4.0/3, values[MACDKey], 1e-9)
+	require.InDelta(suite.T(), 2.0/3*4.0/3+0.5/3, values[SignalKey], 1e-9)
+	require.InDelta(suite.T(), values[MACDKey]-values[SignalKey], values[HistogramKey], 1e-9)
+}
+
+// Test Bollinger Bands use the population standard deviation of the window.
+func (suite *IndicatorsUnitTestSuite) TestBollingerBands() {
+	_, err := NewBollingerBands(20, 0)
+	require.Error(suite.T(), err)
+	_, err = NewBollingerBands(0, 2)
+	require.Error(suite.T(), err)
+	bb, err := NewBollingerBands(3, 2)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "bollinger_3_2", bb.Name())
+	feed(bb, 1, 2)
+	require.Nil(suite.T(), bb.Values())
+	feed(bb, 3)
+	stddev := math.Sqrt(2.0 / 3)
+	values := bb.Values()
+	require.InDelta(suite.T(), 2.0, values[MiddleKey], 1e-9)
+	require.InDelta(suite.T(), 2+2*stddev, values[UpperKey], 1e-9)
+	require.InDelta(suite.T(), 2-2*stddev, values[LowerKey], 1e-9)
+	// Window slides: 2, 3, 4
+	feed(bb, 4)
+	require.InDelta(suite.T(), 3.0, bb.Values()[MiddleKey], 1e-9)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Update the indicator with the provided samples.
+func feed(indicator Indicator, values ...float64) {
+	for _, v := range values {
+		indicator.Update(v)
+	}
+}
@@ -0,0 +1,316 @@
+package indicators
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	otelObs "github.com/cloudevents/sdk-go/observability/opentelemetry/v2/client"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
+)
+
+// Enum for the subscriptions a Pipeline can be attached to.
+type SourceEnum string
+
+// Values for SourceEnum
+const (
+	// Indicators are updated with the close price of each committed candle of an OHLC subscription.
+	SourceOHLC SourceEnum = "ohlc"
+	// Indicators are updated with the price of each trade of a trade subscription.
+	SourceTrade SourceEnum = "trade"
+)
+
+// Data of an indicator_update event.
+type IndicatorUpdate struct {
+	// Pair
+	Pair string `json:"pair"`
+	// Indicator name (ex: ema_20)
+	Indicator string `json:"indicator"`
+	// End time of the candle or time of the trade used to compute the values.
+	Time time.Time `json:"time"`
+	// Values of the indicator by key (Cf. ValueKey, MACDKey, UpperKey, ...)
+	Values map[string]float64 `json:"values"`
+}
+
+// Factory which creates the indicators maintained for a pair. It is called once for each pair.
+type IndicatorsFactory func() ([]Indicator, error)
+
+// Indicators and candle tracking of a single pair.
+type pairIndicators struct {
+	// Indicators maintained for the pair
+	indicators []Indicator
+	// Last received version of the candle in progress. Nil if none.
+	pending *messages.OHLCData
+	// End time (unix seconds) of the candle in progress
+	pendingEnd float64
+	// End time (unix seconds) of the last candle used to update the indicators
+	lastEnd float64
+}
+
+// # Description
+//
+// Pipeline which maintains indicators from the events of an OHLC or a trade subscription and
+// publishes indicator_update events (Cf. IndicatorUpdate) when their values change.
+//
+// With an OHLC source, indicators are updated with the close price of committed candles only: the
+// server publishes several updates for the candle in progress, the candle is committed when the
+// first update for the next candle is received. With a trade source, indicators are updated with
+// the price of each trade.
+//
+// Indicators can be warmed up with historical data from the REST API (Cf. WarmUp) so they are
+// ready as soon as the subscription starts. Candles which have been used for warm-up are skipped
+// when they are received from the subscription.
+//
+// Candles missed while the connection is interrupted are not recovered: call WarmUp again before
+// the subscription resumes if indicators must not have gaps.
+type Pipeline struct {
+	// Source subscription
+	source SourceEnum
+	// Factory used to create the indicators of a pair
+	factory IndicatorsFactory
+	// Indicators by pair
+	pairs map[string]*pairIndicators
+	// Mutex used to protect pairs: WarmUp can be called while the pipeline runs.
+	mu sync.Mutex
+}
+
+// # Description
+//
+// Build a new Pipeline.
+//
+// # Inputs
+//
+//   - source: Subscription the pipeline will be attached to.
+//   - factory: Factory which creates the indicators maintained for each pair. It is called once
+//     to validate it.
+//
+// # Return
+//
+// The new Pipeline or an error if the inputs are invalid.
+func NewPipeline(source SourceEnum, factory IndicatorsFactory) (*Pipeline, error) {
+	if source != SourceOHLC && source != SourceTrade {
+		return nil, fmt.Errorf("unknown pipeline source %s", source)
+	}
+	if factory == nil {
+		return nil, fmt.Errorf("an indicators factory must be provided")
+	}
+	if _, err := factory(); err != nil {
+		return nil, fmt.Errorf("indicators factory failed: %w", err)
+	}
+	return &Pipeline{
+		source:  source,
+		factory: factory,
+		pairs:   map[string]*pairIndicators{},
+	}, nil
+}
+
+// # Description
+//
+// Warm up the indicators of a pair with the committed candles returned by the REST API (up to
+// 720 candles). The candle in progress, which is always the last entry returned by the API, is
+// not used. Candles which have already been used are skipped.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - client: REST client used to get OHLC data.
+//   - pair: Pair as named by the websocket API (ex: XBT/USD).
+//   - restPair: Pair as named by the REST API (ex: XXBTZUSD). If empty, pair is used.
+//   - interval: Interval of the candles. Must match the interval of the OHLC subscription.
+//
+// # Return
+//
+// An error if OHLC data could not be fetched or if the indicators could not be created.
+func (p *Pipeline) WarmUp(ctx context.Context, client rest.KrakenSpotRESTClientIface, pair string, restPair string, interval messages.IntervalEnum) error {
+	if restPair == "" {
+		restPair = pair
+	}
+	resp, _, err := client.GetOHLCData(ctx, market.GetOHLCDataRequestParameters{Pair: restPair}, &market.GetOHLCDataRequestOptions{Interval: int64(interval)})
+	if err != nil {
+		return fmt.Errorf("warm up failed for %s: %w", pair, err)
+	}
+	if len(resp.Error) > 0 || resp.Result == nil {
+		return fmt.Errorf("warm up failed for %s: %v", pair, resp.Error)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	state, err := p.getPair(pair)
+	if err != nil {
+		return fmt.Errorf("warm up failed for %s: %w", pair, err)
+	}
+	duration := float64(interval) * 60
+	for i := 0; i < len(resp.Result.Data)-1; i++ {
+		candle := resp.Result.Data[i]
+		end := float64(candle.Timestamp) + duration
+		if end <= state.lastEnd {
+			continue
+		}
+		price, err := strconv.ParseFloat(candle.Close, 64)
+		if err != nil {
+			return fmt.Errorf("warm up failed for %s: invalid close price %s: %w", pair, candle.Close, err)
+		}
+		for _, indicator := range state.indicators {
+			indicator.Update(price)
+		}
+		state.lastEnd = end
+	}
+	// Discard the candle in progress if it has been committed by the warm up
+	if state.pending != nil && state.pendingEnd <= state.lastEnd {
+		state.pending = nil
+	}
+	return nil
+}
+
+// # Description
+//
+// Process events from the input channel (the channel used by the OHLC or trade subscription)
+// until it is closed. All input events are forwarded to the output channel and each event which
+// updates indicators is followed by one indicator_update event per ready indicator. The output
+// channel is closed when the input channel is closed.
+//
+// # Inputs
+//
+//   - in: Channel used by the OHLC or trade subscription.
+//   - out: Channel used to publish the input events and the indicator_update events. Blocking
+//     writes are used.
+func (p *Pipeline) Run(in chan event.Event, out chan event.Event) {
+	defer close(out)
+	for e := range in {
+		out <- e
+		var updates []*IndicatorUpdate
+		var err error
+		switch {
+		case p.source == SourceOHLC && e.Type() == string(events.OHLC):
+			updates, err = p.processOHLC(e)
+		case p.source == SourceTrade && e.Type() == string(events.Trade):
+			updates, err = p.processTrade(e)
+		}
+		if err != nil {
+			continue
+		}
+		for _, update := range updates {
+			out <- newIndicatorEvent(e, update)
+		}
+	}
+}
+
+// Process an OHLC event and return the indicator updates if a candle has been committed.
+func (p *Pipeline) processOHLC(e event.Event) ([]*IndicatorUpdate, error) {
+	msg := new(messages.OHLC)
+	if err := json.Unmarshal(e.Data(), msg); err != nil {
+		return nil, err
+	}
+	end, err := msg.Data.End.Float64()
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	state, err := p.getPair(msg.Pair)
+	if err != nil {
+		return nil, err
+	}
+	if end <= state.lastEnd {
+		// Candle already used
+		return nil, nil
+	}
+	var updates []*IndicatorUpdate
+	if state.pending != nil && state.pendingEnd != end {
+		// A new candle has started: commit the candle in progress
+		price, err := state.pending.Close.Float64()
+		if err == nil {
+			updates = state.update(msg.Pair, price, unixTime(state.pendingEnd))
+			state.lastEnd = state.pendingEnd
+		}
+	}
+	data := msg.Data
+	state.pending = &data
+	state.pendingEnd = end
+	return updates, nil
+}
+
+// Process a trade event and return the indicator updates.
+func (p *Pipeline) processTrade(e event.Event) ([]*IndicatorUpdate, error) {
+	msg := new(messages.Trade)
+	if err := json.Unmarshal(e.Data(), msg); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	state, err := p.getPair(msg.Pair)
+	if err != nil {
+		return nil, err
+	}
+	var updates []*IndicatorUpdate
+	for _, trade := range msg.Data {
+		price, err := trade.Price.Float64()
+		if err != nil {
+			continue
+		}
+		ts, err := trade.Timestamp.Float64()
+		if err != nil {
+			continue
+		}
+		updates = append(updates, state.update(msg.Pair, price, unixTime(ts))...)
+	}
+	return updates, nil
+}
+
+// Return the indicators of a pair, creating them if needed. Must be called with mu locked.
+func (p *Pipeline) getPair(pair string) (*pairIndicators, error) {
+	state, ok := p.pairs[pair]
+	if ok {
+		return state, nil
+	}
+	indicators, err := p.factory()
+	if err != nil {
+		return nil, fmt.Errorf("indicators factory failed: %w", err)
+	}
+	state = &pairIndicators{indicators: indicators}
+	p.pairs[pair] = state
+	return state, nil
+}
+
+// Update the indicators with the provided sample and return the values of the ready indicators.
+func (state *pairIndicators) update(pair string, price float64, t time.Time) []*IndicatorUpdate {
+	updates := []*IndicatorUpdate{}
+	for _, indicator := range state.indicators {
+		indicator.Update(price)
+		if indicator.Ready() {
+			updates = append(updates, &IndicatorUpdate{
+				Pair:      pair,
+				Indicator: indicator.Name(),
+				Time:      t,
+				Values:    indicator.Values(),
+			})
+		}
+	}
+	return updates
+}
+
+// Build an indicator_update event from the source event and the indicator values.
+func newIndicatorEvent(source event.Event, update *IndicatorUpdate) event.Event {
+	e := event.New()
+	e.Context.SetType(string(events.IndicatorUpdate))
+	e.Context.SetSource(tracing.PackageName)
+	e.SetSubject(update.Pair)
+	e.SetData("application/json", update)
+	// Propagate tracing context from the source event
+	otelObs.InjectDistributedTracingExtension(otelObs.ExtractDistributedTracingExtension(context.Background(), source), e)
+	return e
+}
+
+// Convert a unix timestamp in seconds with decimals to a time.
+func unixTime(ts float64) time.Time {
+	sec, frac := math.Modf(ts)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC()
+}
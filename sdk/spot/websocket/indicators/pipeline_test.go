@@ -0,0 +1,189 @@
+package indicators
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/market"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for Pipeline
+type PipelineUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestPipelineUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(PipelineUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test NewPipeline input validation.
+func (suite *PipelineUnitTestSuite) TestNewPipelineValidation() {
+	_, err := NewPipeline("book", smaFactory(2))
+	require.Error(suite.T(), err)
+	_, err = NewPipeline(SourceOHLC, nil)
+	require.Error(suite.T(), err)
+	_, err = NewPipeline(SourceOHLC, func() ([]Indicator, error) {
+		sma, err := NewSMA(0)
+		return []Indicator{sma}, err
+	})
+	require.Error(suite.T(), err)
+}
+
+// Test a pipeline attached to an OHLC subscription and warmed up with REST OHLC data.
+//
+// Test will ensure:
+//   - Warm up uses committed candles only.
+//   - Input events are forwarded.
+//   - Candles already used for warm up are skipped.
+//   - Indicators are updated with the last version of a candle when the next candle starts.
+//   - Output channel is closed with input.
+func (suite *PipelineUnitTestSuite) TestOHLCPipeline() {
+	pipeline, err := NewPipeline(SourceOHLC, smaFactory(2))
+	require.NoError(suite.T(), err)
+	// Warm up with 2 committed candles (closes 1 and 2) and the candle in progress
+	client := rest.NewMockKrakenSpotRESTClient()
+	client.On("GetOHLCData", mock.Anything, market.GetOHLCDataRequestParameters{Pair: "XXBTZUSD"}, &market.GetOHLCDataRequestOptions{Interval: 1}).Return(
+		&market.GetOHLCDataResponse{
+			KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{}},
+			Result: &market.OHLCData{
+				Last:   120,
+				PairId: "XXBTZUSD",
+				Data: []market.OHLC{
+					{Timestamp: 0, Close: "1"},
+					{Timestamp: 60, Close: "2"},
+					{Timestamp: 120, Close: "3"},
+				},
+			},
+		}, nil, nil)
+	require.NoError(suite.T(), pipeline.WarmUp(context.Background(), client, "XBT/USD", "XXBTZUSD", messages.M1))
+	in := make(chan event.Event, 10)
+	out := make(chan event.Event, 10)
+	go pipeline.Run(in, out)
+	// Candle used for warm up -> forwarded only
+	in <- newOHLCEvent(120, "2")
+	require.Equal(suite.T(), string(events.OHLC), (<-out).Type())
+	// Candle in progress and its updates -> forwarded only
+	in <- newOHLCEvent(180, "3")
+	require.Equal(suite.T(), string(events.OHLC), (<-out).Type())
+	in <- newOHLCEvent(180, "4")
+	require.Equal(suite.T(), string(events.OHLC), (<-out).Type())
+	// Next candle -> forwarded then candle ending at 180 is committed with close 4
+	in <- newOHLCEvent(240, "5")
+	require.Equal(suite.T(), string(events.OHLC), (<-out).Type())
+	update := suite.readUpdate(out)
+	require.Equal(suite.T(), "XBT/USD", update.Pair)
+	require.Equal(suite.T(), "sma_2", update.Indicator)
+	require.Equal(suite.T(), time.Unix(180, 0).UTC(), update.Time)
+	require.InDelta(suite.T(), 3.0, update.Values[ValueKey], 1e-9)
+	// Other events are forwarded
+	interrupted := event.New()
+	interrupted.SetType(string(events.ConnectionInterrupted))
+	in <- interrupted
+	require.Equal(suite.T(), string(events.ConnectionInterrupted), (<-out).Type())
+	close(in)
+	_, ok := <-out
+	require.False(suite.T(), ok)
+	client.AssertExpectations(suite.T())
+}
+
+// Test a pipeline attached to a trade subscription.
+func (suite *PipelineUnitTestSuite) TestTradePipeline() {
+	pipeline, err := NewPipeline(SourceTrade, smaFactory(2))
+	require.NoError(suite.T(), err)
+	in := make(chan event.Event, 10)
+	out := make(chan event.Event, 10)
+	go pipeline.Run(in, out)
+	// First trade: indicator not ready -> forwarded only
+	in <- newTradeEvent("10.0", "20.0", "30.0")
+	require.Equal(suite.T(), string(events.Trade), (<-out).Type())
+	// Second and third trades -> one update each
+	update := suite.readUpdate(out)
+	require.InDelta(suite.T(), 15.0, update.Values[ValueKey], 1e-9)
+	update = suite.readUpdate(out)
+	require.InDelta(suite.T(), 25.0, update.Values[ValueKey], 1e-9)
+	close(in)
+	_, ok := <-out
+	require.False(suite.T(), ok)
+}
+
+// Test WarmUp returns an error when the API returns an error.
+func (suite *PipelineUnitTestSuite) TestWarmUpError() {
+	pipeline, err := NewPipeline(SourceOHLC, smaFactory(2))
+	require.NoError(suite.T(), err)
+	client := rest.NewMockKrakenSpotRESTClient()
+	client.On("GetOHLCData", mock.Anything, market.GetOHLCDataRequestParameters{Pair: "XBT/USD"}, mock.Anything).Return(
+		&market.GetOHLCDataResponse{
+			KrakenSpotRESTResponse: common.KrakenSpotRESTResponse{Error: []string{"EQuery:Unknown asset pair"}},
+		}, nil, nil)
+	err = pipeline.WarmUp(context.Background(), client, "XBT/USD", "", messages.M5)
+	require.ErrorContains(suite.T(), err, "EQuery:Unknown asset pair")
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Return a factory which creates a single SMA.
+func smaFactory(period int) IndicatorsFactory {
+	return func() ([]Indicator, error) {
+		sma, err := NewSMA(period)
+		return []Indicator{sma}, err
+	}
+}
+
+// Build an ohlc event for XBT/USD.
+func newOHLCEvent(end int64, close string) event.Event {
+	e := event.New()
+	e.SetType(string(events.OHLC))
+	e.SetData("application/json", []byte(fmt.Sprintf(
+		`[42,["%[1]d.000000","%[1]d.000000","%[2]s","%[2]s","%[2]s","%[2]s","%[2]s","1.0",1],"ohlc-1","XBT/USD"]`,
+		end, close)))
+	return e
+}
+
+// Build a trade event for XBT/USD with one trade per price.
+func newTradeEvent(prices ...string) event.Event {
+	trades := []messages.TradeData{}
+	for i, price := range prices {
+		trades = append(trades, messages.TradeData{
+			Price:     json.Number(price),
+			Volume:    "1.0",
+			Timestamp: json.Number(fmt.Sprintf("%d.5", 1000+i)),
+			Side:      "b",
+			OrderType: "l",
+		})
+	}
+	e := event.New()
+	e.SetType(string(events.Trade))
+	e.SetData("application/json", messages.Trade{Name: "trade", Pair: "XBT/USD", Data: trades})
+	return e
+}
+
+// Read and parse the next event as an indicator_update event.
+func (suite *PipelineUnitTestSuite) readUpdate(out chan event.Event) *IndicatorUpdate {
+	e := <-out
+	require.Equal(suite.T(), string(events.IndicatorUpdate), e.Type())
+	update := new(IndicatorUpdate)
+	require.NoError(suite.T(), json.Unmarshal(e.Data(), update))
+	return update
+}
@@ -1,14 +1,16 @@
+//go:build !goctopus_publiconly
+
 package websocket
 
 import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"net/url"
 	"time"
 
 	"github.com/gbdevw/gowse/wscengine"
-	"github.com/gbdevw/gowse/wscengine/wsadapters/gorilla"
 	"github.com/gbdevw/gowse/wscengine/wsclient"
 	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
@@ -58,9 +60,7 @@ func NewKrakenSpotPrivateWebsocketClient(
 	// Build & return public websocket client
 	return &KrakenSpotPrivateWebsocketClient{
 		krakenSpotWebsocketClient: newKrakenSpotWebsocketClient(
-			restClient,
-			clientNonceGenerator,
-			secopts,
+			newWebsocketTokenSource(restClient, clientNonceGenerator, secopts),
 			onCloseCallback,
 			onReadErrorCallback,
 			onRestartError,
@@ -122,11 +122,54 @@ func NewDefaultEngineWithPrivateWebsocketClient(
 	logger *log.Logger,
 	tracerProvider trace.TracerProvider,
 ) (*wscengine.WebsocketEngine, KrakenSpotPrivateWebsocketClientInterface, error) {
+	return NewEngineWithPrivateWebsocketClient(nil, key, b64secret, secopts, onCloseCallback, onReadErrorCallback, onRestartError, logger, tracerProvider)
+}
+
+// # Description
+//
+// Same as NewDefaultEngineWithPrivateWebsocketClient but the websocket connection is opened with
+// the provided dial options (proxy, TLS configuration, headers, ...). The proxy, the TLS
+// configuration, the user agent and the client tag are also used by the REST client used to get
+// websocket tokens. If a client tag is set in the dial options, the tag is added to every span
+// started by the client, the engine and the REST client. Set Endpoints in the dial options to pin
+// the websocket connection to specific endpoints with failover (Cf. DialOptions.Endpoints).
+//
+// # Inputs
+//
+//   - dialOptions: Options used to open the websocket connection. If nil, NewDefaultDialOptions is used.
+//   - key: API key used to authorize requests to the REST API (Get Websocket Token)
+//   - b64secret: API secret provided as a base64 encoded bytestring.
+//   - secopts: Optional security options to use when sending Get Websocket Token requests.
+//   - onCloseCallback: Optional callback called when connection is lost/stopped.
+//   - onReadErrorCallback: Optional callback called when engine fails to read a message.
+//   - onRestartError: Optional callback called when engine fails to reconnect to the server.
+//   - logger: Optional logger used to log debug/vebrose messages. If nil, a logger with a discard writer (noop) will be used
+//   - tracerProvider: Tracer provider to use to get a tracer to instrument websocket client code. If nil, global tracer provider will be used.
+//
+// # Returns
+//
+// In case of success, a ready to start websocket engine is returned along with the private websocket
+// bound to the engine.
+func NewEngineWithPrivateWebsocketClient(
+	dialOptions *DialOptions,
+	key string,
+	b64secret string,
+	secopts *restcommon.SecurityOptions,
+	onCloseCallback func(ctx context.Context, closeMessage *wsclient.CloseMessageDetails),
+	onReadErrorCallback func(ctx context.Context, restart context.CancelFunc, exit context.CancelFunc, err error),
+	onRestartError func(ctx context.Context, exit context.CancelFunc, err error, retryCount int),
+	logger *log.Logger,
+	tracerProvider trace.TracerProvider,
+) (*wscengine.WebsocketEngine, KrakenSpotPrivateWebsocketClientInterface, error) {
+	if dialOptions == nil {
+		dialOptions = NewDefaultDialOptions()
+	}
 	// Build websocket server URL
 	url, err := url.Parse(KrakenSpotWebsocketPrivateProductionURL)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to parse %s as a URL: %w", KrakenSpotWebsocketPrivateProductionURL, err)
 	}
+	tracerProvider = tagTracerProvider(tracerProvider, dialOptions)
 	// Create instrumented authorizer
 	auth, err := rest.NewKrakenSpotRESTClientAuthorizer(key, b64secret)
 	if err != nil {
@@ -139,6 +182,13 @@ func NewDefaultEngineWithPrivateWebsocketClient(
 	httpclient.RetryWaitMin = 1 * time.Second
 	httpclient.RetryMax = 3
 	httpclient.Logger = logger
+	// Use the same proxy and TLS configuration as the websocket connection
+	if transport, ok := httpclient.HTTPClient.Transport.(*http.Transport); ok {
+		transport.Proxy = dialOptions.Proxy
+		if dialOptions.TLSClientConfig != nil {
+			transport.TLSClientConfig = dialOptions.TLSClientConfig.Clone()
+		}
+	}
 	// Create an instrumented Kraken spot REST API.
 	//	- REST client will target production environment.
 	//	- REST client will use the retryable http client as underlying HTTP client.
@@ -147,8 +197,10 @@ func NewDefaultEngineWithPrivateWebsocketClient(
 		rest.NewKrakenSpotRESTClient(
 			authorizer,
 			&rest.KrakenSpotRESTClientConfiguration{
-				BaseURL: rest.KrakenProductionV0BaseUrl,
-				Client:  httpclient.StandardClient(),
+				BaseURL:   rest.KrakenProductionV0BaseUrl,
+				Agent:     dialOptions.UserAgent,
+				ClientTag: dialOptions.ClientTag,
+				Client:    httpclient.StandardClient(),
 			}),
 		tracerProvider)
 	// Create a HFNonceGenerator
@@ -168,7 +220,7 @@ func NewDefaultEngineWithPrivateWebsocketClient(
 		StopTimeoutMs:                      300000,
 	}
 	// Build the engine that will power the wesocket client - Use default options and a gorilla based connection
-	engine, err := wscengine.NewWebsocketEngine(url, gorilla.NewGorillaWebsocketConnectionAdapter(nil, nil), wsclient, defopts, tracerProvider)
+	engine, err := wscengine.NewWebsocketEngine(url, NewWebsocketConnectionAdapter(dialOptions), wsclient, defopts, tracerProvider)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to build the websocket engine: %w", err)
 	}
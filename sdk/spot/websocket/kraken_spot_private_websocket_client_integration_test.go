@@ -1,3 +1,5 @@
+//go:build !goctopus_publiconly
+
 package websocket
 
 import (
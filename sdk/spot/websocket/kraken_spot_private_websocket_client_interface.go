@@ -230,7 +230,8 @@ type KrakenSpotPrivateWebsocketClientInterface interface {
 	//	- A connection_interrupted event MUST be published on the channel each time the websocket
 	//    connection is closed.
 	//
-	//	- The provided channel MUST be closed upon unsubscribe or when the websocket client stops.
+	//	- The provided channel MUST be closed upon unsubscribe (Cf.
+	//	  SetKeepChannelsOpenOnUnsubscribe) or when the websocket client stops.
 	//
 	//	- The websocket client implementation CAN either use blocking writes or discard messages in
 	//    case the provided channel is full. It is up to the client implementation to be clear about
@@ -315,7 +316,8 @@ type KrakenSpotPrivateWebsocketClientInterface interface {
 	//	- A connection_interrupted event MUST be published on the channel each time the websocket
 	//    connection is closed.
 	//
-	//	- The provided channel MUST be closed upon unsubscribe or when the websocket client stops.
+	//	- The provided channel MUST be closed upon unsubscribe (Cf.
+	//	  SetKeepChannelsOpenOnUnsubscribe) or when the websocket client stops.
 	//
 	//	- The websocket client implementation CAN either use blocking writes or discard messages in
 	//    case the provided channel is full. It is up to the client implementation to be clear about
@@ -327,8 +329,8 @@ type KrakenSpotPrivateWebsocketClientInterface interface {
 	SubscribeOpenOrders(ctx context.Context, rateCounter bool, rcv chan event.Event) error
 	// # Description
 	//
-	// Unsubscribe from the ownTrades channel. The channel provided on subscribe will bbe closed by
-	// the websocket client.
+	// Unsubscribe from the ownTrades channel. The channel provided on subscribe will be closed by
+	// the websocket client (Cf. SetKeepChannelsOpenOnUnsubscribe).
 	//
 	// # Inputs
 	//
@@ -345,14 +347,15 @@ type KrakenSpotPrivateWebsocketClientInterface interface {
 	//
 	// # Implementation and usage guidelines
 	//
-	//	- In case of success, the client MUST close the channel used to publish events.
+	//	- In case of success, the client MUST close the channel used to publish events (Cf.
+	//	  SetKeepChannelsOpenOnUnsubscribe).
 	//
 	//	- The client MUST use the right error type as described in the "Return" section.
 	UnsubscribeOwnTrades(ctx context.Context) error
 	// # Description
 	//
-	// Unsubscribe from the openOrders channel. The channel provided on subscribe will bbe closed by
-	// the websocket client.
+	// Unsubscribe from the openOrders channel. The channel provided on subscribe will be closed by
+	// the websocket client (Cf. SetKeepChannelsOpenOnUnsubscribe).
 	//
 	// # Inputs
 	//
@@ -369,7 +372,8 @@ type KrakenSpotPrivateWebsocketClientInterface interface {
 	//
 	// # Implementation and usage guidelines
 	//
-	//	- In case of success, the client MUST close the channel used to publish events.
+	//	- In case of success, the client MUST close the channel used to publish events (Cf.
+	//	  SetKeepChannelsOpenOnUnsubscribe).
 	//
 	//	- The client MUST use the right error type as described in the "Return" section.
 	UnsubscribeOpenOrders(ctx context.Context) error
@@ -423,4 +427,27 @@ type KrakenSpotPrivateWebsocketClientInterface interface {
 	//
 	// The client's built-in channel used to publish received heartbeats.
 	GetHeartbeatChannel() chan event.Event
+	// # Description
+	//
+	// Get the client's built-in channel used to publish error messages which are not related to a
+	// request (error messages without request ID, ex: global service messages).
+	//
+	// # Event types
+	//
+	// Only these types of events will be published on the channel (Cf. WebsocketClientEventTypeEnum):
+	//	- general_error
+	//
+	// # Implemetation and usage guidelines
+	//
+	//	- The client MUST provide the channel it will use to publish general errors even though the
+	//    client has not been started yet and is not connected to the server.
+	//
+	//	- As the channel is automatically subscribed to, the client implementation must deal with
+	//    possible channel congestion by discarding messages in a FIFO or LIFO fashion. The client
+	//    must indicate how congestion is handled.
+	//
+	// # Return
+	//
+	// The client's built-in channel used to publish general errors.
+	GetGeneralErrorChannel() chan event.Event
 }
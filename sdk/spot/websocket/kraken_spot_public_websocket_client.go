@@ -1,3 +1,9 @@
+// Package websocket provides public and private clients for Kraken spot websocket API.
+//
+// Binaries which only use public market data can be built with the goctopus_publiconly build tag:
+// the private client, the websocket token management and the REST client (including the request
+// signing code) are left out of the package. Private operations of the public client then fail
+// with an OperationError.
 package websocket
 
 import (
@@ -7,7 +13,6 @@ import (
 	"net/url"
 
 	"github.com/gbdevw/gowse/wscengine"
-	"github.com/gbdevw/gowse/wscengine/wsadapters/gorilla"
 	"github.com/gbdevw/gowse/wscengine/wsclient"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -23,6 +28,9 @@ type KrakenSpotPublicWebsocketClient struct {
 // Factory which creates a KrakenSpotPublicWebsocketClient that can be provided to a websocket
 // engine (wscengine.WebsocketEngine - Cf. https://github.com/gbdevw/gowse).
 //
+// The public client needs neither a REST client nor a nonce generator: it can be used in builds
+// which use the goctopus_publiconly build tag.
+//
 // # Inputs
 //
 //   - onCloseCallback: optional user defined callback which will be called when connection is closed/interrupted.
@@ -43,8 +51,6 @@ func NewKrakenSpotPublicWebsocketClient(
 	// Build & return public websocket client
 	return &KrakenSpotPublicWebsocketClient{
 		krakenSpotWebsocketClient: newKrakenSpotWebsocketClient(
-			nil,
-			nil,
 			nil,
 			onCloseCallback,
 			onReadErrorCallback,
@@ -92,12 +98,43 @@ func NewDefaultEngineWithPublicWebsocketClient(
 	onRestartError func(ctx context.Context, exit context.CancelFunc, err error, retryCount int),
 	logger *log.Logger,
 	tracerProvider trace.TracerProvider,
+) (*wscengine.WebsocketEngine, KrakenSpotPublicWebsocketClientInterface, error) {
+	return NewEngineWithPublicWebsocketClient(nil, onCloseCallback, onReadErrorCallback, onRestartError, logger, tracerProvider)
+}
+
+// # Description
+//
+// Same as NewDefaultEngineWithPublicWebsocketClient but the websocket connection is opened with
+// the provided dial options (proxy, TLS configuration, headers, ...). If a client tag is set in
+// the dial options, the tag is added to every span started by the client and the engine.
+//
+// # Inputs
+//
+//   - dialOptions: Options used to open the websocket connection. If nil, NewDefaultDialOptions is used.
+//   - onCloseCallback: Optional callback called when connection is lost/stopped.
+//   - onReadErrorCallback: Optional callback called when engine fails to read a message.
+//   - onRestartError: Optional callback called when engine fails to reconnect to the server.
+//   - logger: Optional logger used to log debug/vebrose messages. If nil, a logger with a discard writer (noop) will be used
+//   - tracerProvider: Tracer provider to use to get a tracer to instrument websocket client code. If nil, global tracer provider will be used.
+//
+// # Returns
+//
+// In case of success, a ready to start websocket engine is returned along with the public websocket
+// client bound to the engine.
+func NewEngineWithPublicWebsocketClient(
+	dialOptions *DialOptions,
+	onCloseCallback func(ctx context.Context, closeMessage *wsclient.CloseMessageDetails),
+	onReadErrorCallback func(ctx context.Context, restart context.CancelFunc, exit context.CancelFunc, err error),
+	onRestartError func(ctx context.Context, exit context.CancelFunc, err error, retryCount int),
+	logger *log.Logger,
+	tracerProvider trace.TracerProvider,
 ) (*wscengine.WebsocketEngine, KrakenSpotPublicWebsocketClientInterface, error) {
 	// Build websocket server URL
 	url, err := url.Parse(KrakenSpotWebsocketPublicProductionURL)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to parse %s as a URL: %w", KrakenSpotWebsocketPublicProductionURL, err)
 	}
+	tracerProvider = tagTracerProvider(tracerProvider, dialOptions)
 	// Build websocket client
 	wsclient := NewKrakenSpotPublicWebsocketClient(onCloseCallback, onReadErrorCallback, onRestartError, logger, tracerProvider)
 	// Build engine options
@@ -110,7 +147,7 @@ func NewDefaultEngineWithPublicWebsocketClient(
 		StopTimeoutMs:                      300000,
 	}
 	// Build the engine that will power the wesocket client - Use default options and a gorilla based connection
-	engine, err := wscengine.NewWebsocketEngine(url, gorilla.NewGorillaWebsocketConnectionAdapter(nil, nil), wsclient, defopts, tracerProvider)
+	engine, err := wscengine.NewWebsocketEngine(url, NewWebsocketConnectionAdapter(dialOptions), wsclient, defopts, tracerProvider)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to build the websocket engine: %w", err)
 	}
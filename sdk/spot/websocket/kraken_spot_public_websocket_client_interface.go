@@ -111,7 +111,8 @@ type KrakenSpotPublicWebsocketClientInterface interface {
 	//	- A connection_interrupted event MUST be published on the channel each time the websocket
 	//    connection is closed.
 	//
-	//	- The provided channel MUST be closed upon unsubscribe or when the websocket client stops.
+	//	- The provided channel MUST be closed upon unsubscribe (Cf.
+	//	  SetKeepChannelsOpenOnUnsubscribe) or when the websocket client stops.
 	//
 	//	- The websocket client implementation CAN either use blocking writes or discard messages in
 	//    case the provided channel is full. It is up to the client implementation to be clear about
@@ -199,7 +200,8 @@ type KrakenSpotPublicWebsocketClientInterface interface {
 	//	- A connection_interrupted event MUST be published on the channel each time the websocket
 	//    connection is closed.
 	//
-	//	- The provided channel MUST be closed upon unsubscribe or when the websocket client stops.
+	//	- The provided channel MUST be closed upon unsubscribe (Cf.
+	//	  SetKeepChannelsOpenOnUnsubscribe) or when the websocket client stops.
 	//
 	//	- The websocket client implementation CAN either use blocking writes or discard messages in
 	//    case the provided channel is full. It is up to the client implementation to be clear about
@@ -283,7 +285,8 @@ type KrakenSpotPublicWebsocketClientInterface interface {
 	//	- A connection_interrupted event MUST be published on the channel each time the websocket
 	//    connection is closed.
 	//
-	//	- The provided channel MUST be closed upon unsubscribe or when the websocket client stops.
+	//	- The provided channel MUST be closed upon unsubscribe (Cf.
+	//	  SetKeepChannelsOpenOnUnsubscribe) or when the websocket client stops.
 	//
 	//	- The websocket client implementation CAN either use blocking writes or discard messages in
 	//    case the provided channel is full. It is up to the client implementation to be clear about
@@ -367,7 +370,8 @@ type KrakenSpotPublicWebsocketClientInterface interface {
 	//	- A connection_interrupted event MUST be published on the channel each time the websocket
 	//    connection is closed.
 	//
-	//	- The provided channel MUST be closed upon unsubscribe or when the websocket client stops.
+	//	- The provided channel MUST be closed upon unsubscribe (Cf.
+	//	  SetKeepChannelsOpenOnUnsubscribe) or when the websocket client stops.
 	//
 	//	- The websocket client implementation CAN either use blocking writes or discard messages in
 	//    case the provided channel is full. It is up to the client implementation to be clear about
@@ -443,6 +447,8 @@ type KrakenSpotPublicWebsocketClientInterface interface {
 	//
 	//	- ctx: Context used for tracing and coordination purpose.
 	//	- pair: Pairs to subscribe to.
+	//	- depth: Desired book depth. Subscriptions for different depths can be active at the
+	//	  same time.
 	//	- rcv: Channel used to publish book_snapshot & book+update messages and
 	//         connection_interrupted events.
 	//
@@ -450,7 +456,7 @@ type KrakenSpotPublicWebsocketClientInterface interface {
 	//
 	// An error is returned when:
 	//
-	//	- There is already an active subscription.
+	//	- There is already an active subscription for that depth.
 	//	- An error occurs when sending the subscription message.
 	//	- The provided context expires before subscription is completed (OperationInterruptedError).
 	//	- An error message is received from the server (OperationError).
@@ -464,7 +470,8 @@ type KrakenSpotPublicWebsocketClientInterface interface {
 	//	- A connection_interrupted event MUST be published on the channel each time the websocket
 	//    connection is closed.
 	//
-	//	- The provided channel MUST be closed upon unsubscribe or when the websocket client stops.
+	//	- The provided channel MUST be closed upon unsubscribe (Cf.
+	//	  SetKeepChannelsOpenOnUnsubscribe) or when the websocket client stops.
 	//
 	//	- The websocket client implementation CAN either use blocking writes or discard messages in
 	//    case the provided channel is full. It is up to the client implementation to be clear about
@@ -477,7 +484,7 @@ type KrakenSpotPublicWebsocketClientInterface interface {
 	// # Description
 	//
 	// Unsubscribe from the ticker channel. The channel provided on subscribe will be closed by
-	// the websocket client.
+	// the websocket client (Cf. SetKeepChannelsOpenOnUnsubscribe).
 	//
 	// # Inputs
 	//
@@ -494,14 +501,15 @@ type KrakenSpotPublicWebsocketClientInterface interface {
 	//
 	// # Implementation and usage guidelines
 	//
-	//	- In case of success, the client MUST close the channel used to publish events.
+	//	- In case of success, the client MUST close the channel used to publish events (Cf.
+	//	  SetKeepChannelsOpenOnUnsubscribe).
 	//
 	//	- The client MUST use the right error type as described in the "Return" section.
 	UnsubscribeTicker(ctx context.Context) error
 	// # Description
 	//
 	// Unsubscribe from the ohlc channel with the given interva. The channel provided on subscribe
-	// will be closed by the websocket client.
+	// will be closed by the websocket client (Cf. SetKeepChannelsOpenOnUnsubscribe).
 	//
 	// # Inputs
 	//
@@ -519,14 +527,15 @@ type KrakenSpotPublicWebsocketClientInterface interface {
 	//
 	// # Implementation and usage guidelines
 	//
-	//	- In case of success, the client MUST close the channel used to publish events.
+	//	- In case of success, the client MUST close the channel used to publish events (Cf.
+	//	  SetKeepChannelsOpenOnUnsubscribe).
 	//
 	//	- The client MUST use the right error type as described in the "Return" section.
 	UnsubscribeOHLC(ctx context.Context, interval messages.IntervalEnum) error
 	// # Description
 	//
 	// Unsubscribe from the trade channel. The channel provided on subscribe will be closed by
-	// the websocket client.
+	// the websocket client (Cf. SetKeepChannelsOpenOnUnsubscribe).
 	//
 	// # Inputs
 	//
@@ -543,14 +552,15 @@ type KrakenSpotPublicWebsocketClientInterface interface {
 	//
 	// # Implementation and usage guidelines
 	//
-	//	- In case of success, the client MUST close the channel used to publish events.
+	//	- In case of success, the client MUST close the channel used to publish events (Cf.
+	//	  SetKeepChannelsOpenOnUnsubscribe).
 	//
 	//	- The client MUST use the right error type as described in the "Return" section.
 	UnsubscribeTrade(ctx context.Context) error
 	// # Description
 	//
 	// Unsubscribe from the spread channel. The channel provided on subscribe will be closed by
-	// the websocket client.
+	// the websocket client (Cf. SetKeepChannelsOpenOnUnsubscribe).
 	//
 	// # Inputs
 	//
@@ -567,14 +577,16 @@ type KrakenSpotPublicWebsocketClientInterface interface {
 	//
 	// # Implementation and usage guidelines
 	//
-	//	- In case of success, the client MUST close the channel used to publish events.
+	//	- In case of success, the client MUST close the channel used to publish events (Cf.
+	//	  SetKeepChannelsOpenOnUnsubscribe).
 	//
 	//	- The client MUST use the right error type as described in the "Return" section.
 	UnsubscribeSpread(ctx context.Context) error
 	// # Description
 	//
-	// Unsubscribe from the book channel. The channel provided on subscribe will be closed by
-	// the websocket client.
+	// Unsubscribe from the book channel. All active book subscriptions are cancelled, whatever
+	// their depth. The channels provided on subscribe will be closed by the websocket client
+	// (Cf. SetKeepChannelsOpenOnUnsubscribe).
 	//
 	// # Inputs
 	//
@@ -591,12 +603,40 @@ type KrakenSpotPublicWebsocketClientInterface interface {
 	//
 	// # Implementation and usage guidelines
 	//
-	//	- In case of success, the client MUST close the channel used to publish events.
+	//	- In case of success, the client MUST close the channel used to publish events (Cf.
+	//	  SetKeepChannelsOpenOnUnsubscribe).
 	//
 	//	- The client MUST use the right error type as described in the "Return" section.
 	UnsubscribeBook(ctx context.Context) error
 	// # Description
 	//
+	// Unsubscribe from the book channel for the provided depth. The channel provided on subscribe
+	// will be closed by the websocket client (Cf. SetKeepChannelsOpenOnUnsubscribe). Book
+	// subscriptions for other depths are left untouched.
+	//
+	// # Inputs
+	//
+	//	- ctx: Context used for tracing and coordination purpose.
+	//	- depth: Used to determine which subscription must be cancelled.
+	//
+	// # Return
+	//
+	// An error is returned when:
+	//
+	//	- The channel has not been subscribed to for that depth.
+	//	- An error occurs when sending the unsubscribe message.
+	//	- The provided context expires before subscription is completed (OperationInterruptedError).
+	//	- An error message is received from the server (OperationError).
+	//
+	// # Implementation and usage guidelines
+	//
+	//	- In case of success, the client MUST close the channel used to publish events (Cf.
+	//	  SetKeepChannelsOpenOnUnsubscribe).
+	//
+	//	- The client MUST use the right error type as described in the "Return" section.
+	UnsubscribeBookDepth(ctx context.Context, depth messages.DepthEnum) error
+	// # Description
+	//
 	// Get the client's built-in channel used to publish received system status updates.
 	//
 	// # Event types
@@ -645,4 +685,27 @@ type KrakenSpotPublicWebsocketClientInterface interface {
 	//
 	// The client's built-in channel used to publish received heartbeats.
 	GetHeartbeatChannel() chan event.Event
+	// # Description
+	//
+	// Get the client's built-in channel used to publish error messages which are not related to a
+	// request (error messages without request ID, ex: global service messages).
+	//
+	// # Event types
+	//
+	// Only these types of events will be published on the channel (Cf. WebsocketClientEventTypeEnum):
+	//	- general_error
+	//
+	// # Implemetation and usage guidelines
+	//
+	//	- The client MUST provide the channel it will use to publish general errors even though the
+	//    client has not been started yet and is not connected to the server.
+	//
+	//	- As the channel is automatically subscribed to, the client implementation must deal with
+	//    possible channel congestion by discarding messages in a FIFO or LIFO fashion. The client
+	//    must indicate how congestion is handled.
+	//
+	// # Return
+	//
+	// The client's built-in channel used to publish general errors.
+	GetGeneralErrorChannel() chan event.Event
 }
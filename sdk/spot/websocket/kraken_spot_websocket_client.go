@@ -2,24 +2,25 @@ package websocket
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
-	"math"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	otelObs "github.com/cloudevents/sdk-go/observability/opentelemetry/v2/client"
 	"github.com/cloudevents/sdk-go/v2/event"
 	"github.com/gbdevw/gowse/wscengine/wsadapters"
 	"github.com/gbdevw/gowse/wscengine/wsclient"
-	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
-	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
-	restcommon "github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/clienttag"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/codec"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/requesttag"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
 	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
@@ -27,6 +28,8 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -42,19 +45,27 @@ const (
 	KrakenSpotWebsocketPrivateBetaURL = "wss://beta-ws-auth.kraken.com"
 )
 
+// Name of the counter used to record messages discarded because of congestion on the client's
+// built-in heartbeat, systemStatus and general error channels.
+const DroppedMessagesMetricName = "goctopus.sdk.spot.websocket.dropped_messages"
+
 // This is the base Kraken websocket client implementation: The logic is the same for both public
 // and private clients but separate clients must be built because public and private clients do
 // not use the same servers and connection.
 //
 // Principles:
-//   - Blocking writes are used to publish received messages from the websocket server
-//   - For heartbeats and system status updates, overflowing messages are discarded in FIFO order.
+//   - Blocking writes are used to publish received messages from the websocket server, either
+//     from the reading goroutine or from the dispatch queue of the channel (Cf. SetDispatchQueueSize)
+//   - For heartbeats, system status updates and general errors, overflowing messages are discarded
+//     in FIFO order.
+//     Discarded messages are counted, recorded with the dropped messages metric and reported to
+//     the optional OnDroppedMessage callback.
 type krakenSpotWebsocketClient struct {
 	// Websocket connection adapter to use to interact with the chosen
 	// underlying low-level websocket framework.
 	conn wsadapters.WebsocketConnectionAdapterInterface
 	// Internal nonce generator used to generate unique request IDs
-	ngen noncegen.NonceGenerator
+	ngen *requestIdGenerator
 	// Subscriptions which must be maintained by the websocket client.
 	subscriptions activeSubscriptions
 	// Pending requests that must be served by the client.
@@ -70,6 +81,8 @@ type krakenSpotWebsocketClient struct {
 	onRestartError func(ctx context.Context, exit context.CancelFunc, err error, retryCount int)
 	// Tracer used to instrument code
 	tracer trace.Tracer
+	// Key of the request tag used as default userref of orders. Empty if disabled.
+	userReferenceTag string
 	// Logger used to publish debug/verbose logs
 	logger *log.Logger
 	// Mutex used to protect ticker subscribe/unsubscribe methods
@@ -102,18 +115,151 @@ type krakenSpotWebsocketClient struct {
 	pendingCancelAllOrdersMu sync.Mutex
 	// Mutex used to protect pending cancelAllOrdersAfterX request map from concurrent writes
 	pendingCancelAllOrdersAfterXOrderMu sync.Mutex
-	// Kraken websocket client used to get websocket token
-	restClient rest.KrakenSpotRESTClientIface
-	// User provided nonce generator used to generate nonces used when GetWebsocketToken is called
-	cgen noncegen.NonceGenerator
-	// User provided security options used when
-	secopts *restcommon.SecurityOptions
+	// Source used to get websocket tokens. Nil in case only public endpoints are used.
+	tokenSource *websocketTokenSource
 	// Mutex used to protect cached websocket token
 	tokenMu sync.Mutex
 	// Cached websocket token
 	token string
 	// Cached websocket token epiration time
 	tokenExpiresAt time.Time
+	// Time at which the websocket token was last successfully refreshed
+	tokenLastRefreshAt time.Time
+	// Error returned by the last websocket token refresh attempt (nil if it succeeded)
+	tokenLastError error
+	// Number of times the websocket token has been successfully refreshed
+	tokenRefreshCount int64
+	// True when a background token refresher is running
+	tokenRefresherRunning bool
+	// Number of heartbeats discarded because of congestion
+	droppedHeartbeats atomic.Uint64
+	// Event bus on which all events are also published under hierarchical topics
+	bus *EventBus
+	// Number of system status updates discarded because of congestion
+	droppedSystemStatuses atomic.Uint64
+	// Number of general errors discarded because of congestion
+	droppedGeneralErrors atomic.Uint64
+	// Number of unknown_message events discarded because of congestion
+	droppedUnknownMessages atomic.Uint64
+	// Number of messages received from the server which violate the protocol
+	unknownMessages atomic.Uint64
+	// True if messages which violate the protocol are dropped instead of being read errors (Cf. SetStrictMode)
+	lenientProtocol atomic.Bool
+	// Behavior of the client when a binary message is received. Nil if the default policy is used.
+	binaryMessagePolicy atomic.Pointer[BinaryMessagePolicyEnum]
+	// Number of connection_interrupted events which could not be delivered before the deadline
+	droppedConnectionInterruptions atomic.Uint64
+	// Maximum time OnClose waits for connection_interrupted events delivery (Cf. SetConnectionInterruptedDeliveryTimeout)
+	connectionInterruptedTimeout atomic.Int64
+	// Counter used to record discarded messages with the metrics provider
+	droppedMessagesCounter metric.Int64Counter
+	// Mutex used to protect the OnDroppedMessage callback
+	onDroppedMessageMu sync.Mutex
+	// Optional user provided callback which is called each time a message is discarded because of
+	// congestion on the client's built-in channels.
+	onDroppedMessageCallback func(eventType events.WebsocketClientEventTypeEnum, count uint64)
+	// JSON codec used to encode requests and decode responses from the server
+	codec codec.JSONCodec
+	// Raw trade subscription. Nil if trade channel is not subscribed in raw mode.
+	rawTrade atomic.Pointer[rawSubscription]
+	// Raw spread subscription. Nil if spread channel is not subscribed in raw mode.
+	rawSpread atomic.Pointer[rawSubscription]
+	// Raw book subscriptions by depth. Nil if book channel has never been subscribed in raw mode.
+	// The map is replaced on each change and must not be modified once stored.
+	rawBooks atomic.Pointer[map[messages.DepthEnum]*rawSubscription]
+	// Policy used to restore subscriptions after a reconnect. Nil if the default policy is used.
+	resubscribePolicy atomic.Pointer[ResubscribePolicy]
+	// True if channels provided on subscribe must be left open on unsubscribe.
+	keepChannelsOpenOnUnsubscribe atomic.Bool
+	// Maximum amount of time a channel is drained before being closed on unsubscribe. Zero if
+	// channels are closed immediately.
+	drainTimeout atomic.Int64
+	// Mutex used to protect the draining channels
+	drainingMu sync.Mutex
+	// Channels which are drained before being closed (Cf. SetDrainOnUnsubscribe)
+	draining map[chan event.Event]*drainingChannel
+	// Capacity of the dispatch queues. Zero if dispatch queues are disabled (Cf. SetDispatchQueueSize)
+	dispatchQueueSize atomic.Int64
+	// Number of events discarded because the dispatch queue of their channel was full
+	droppedDispatchEvents atomic.Uint64
+	// Mutex used to protect the dispatch queues
+	dispatchMu sync.Mutex
+	// Dispatch queues by subscription channel
+	dispatchQueues map[chan event.Event]*dispatchQueue
+	// Stopped dispatch queues whose goroutine is still running by subscription channel
+	stoppedDispatchQueues map[chan event.Event]*dispatchQueue
+	// Typed channels used instead of the CloudEvents envelope. Nil if the envelope is used for all
+	// message types (Cf. SetTypedChannels).
+	typedChannels atomic.Pointer[TypedChannels]
+	// Latency monitor. Nil if the latency monitor is not running.
+	latency atomic.Pointer[latencyMonitor]
+	// Histogram used to record latencies measured by the latency monitor
+	latencyHistogram metric.Float64Histogram
+	// Mutex used to protect the order watchers
+	orderWatchersMu sync.Mutex
+	// Watchers notified of the updates received from the openOrders channel (Cf. AddOrderAndWait)
+	orderWatchers map[*orderWatcher]struct{}
+	// Interval between two GetOpenOrders requests made by CancelAllAndVerify. Zero if the default
+	// interval is used.
+	cancelAllVerifyInterval atomic.Int64
+	// Mutex used to serialize runtime configuration updates (Cf. UpdateConfig) and to protect
+	// logOutput
+	configMu sync.Mutex
+	// Writer of the logger saved while the log level is LogLevelSilent. Nil otherwise.
+	logOutput io.Writer
+	// Behavior when a built-in channel is full (CongestionPolicyEnum). Nil if the default
+	// policy is used.
+	congestionPolicy atomic.Pointer[CongestionPolicyEnum]
+	// Clock used by time-based logic (token expiry, resubscribe backoff, latency measurement)
+	clock clock.Clock
+	// Mutex used to protect the subscription store and to serialize saves
+	subscriptionStoreMu sync.Mutex
+	// Optional store where active subscriptions are saved after each subscribe/unsubscribe
+	subscriptionStore SubscriptionStore
+	// True while ResumeSubscriptions is restoring subscriptions: saves are suspended
+	resumingSubscriptions atomic.Bool
+	// Tracker used to relate responses to the session during which requests have been sent
+	sessions *sessionTracker
+	// Number of responses ignored because they have been received on a previous connection
+	staleResponses atomic.Uint64
+	// Hook called with every inbound and outbound frame. Nil if no hook is set.
+	rawMessageHook atomic.Pointer[rawMessageHook]
+	// Number of frames discarded by the previous raw message hooks because their queue was full
+	droppedRawFrames atomic.Uint64
+	// State of the websocket connection. Nil until a connection has been opened.
+	connectionState atomic.Pointer[ConnectionState]
+	// Mutex used to protect the unsubscribed data policy and callback
+	onUnsubscribedDataMu sync.Mutex
+	// Behavior of the client when data are received for a channel without active subscription
+	unsubscribedDataPolicy UnsubscribedDataPolicyEnum
+	// Optional callback called when data are received for a channel without active subscription
+	onUnsubscribedDataCallback func(channel messages.ChannelEnum, pair string, msg []byte)
+	// Number of messages discarded because they have been received for a channel without active subscription
+	unsubscribedData atomic.Uint64
+	// Mutex used to protect the connection writer and its settings
+	writerMu sync.Mutex
+	// Writer of the current connection. Nil until a message is sent on the connection.
+	writer *connectionWriter
+	// Maximum amount of time a message can wait in the outbound queue and be written
+	writeTimeout time.Duration
+	// Capacity of the outbound queue of the connection writers
+	outboundQueueSize int
+	// Mutex used to protect the rate limiter of order management commands
+	commandRateLimiterMu sync.Mutex
+	// Rate limiter of order management commands. Nil if rate limiting is disabled.
+	commandRateLimiter *commandRateLimiter
+	// Number of order management commands sent without waiting for the rate limiter
+	commandsAllowed atomic.Uint64
+	// Number of order management commands which have waited for the rate limiter
+	commandsDelayed atomic.Uint64
+	// Number of order management commands rejected by the rate limiter
+	commandsRejected atomic.Uint64
+	// Gate which tracks the status of the trading engine
+	modeGate *ModeGate
+	// Policy used to consult the mode gate before sending order management commands (ModeGatePolicyEnum)
+	modeGatePolicy atomic.Value
+	// Queue of the requests sent by AddOrderAsync, EditOrderAsync and CancelOrderAsync
+	asyncSender asyncSender
 }
 
 // # Description
@@ -124,9 +270,7 @@ type krakenSpotWebsocketClient struct {
 //
 // # Inputs
 //
-//   - restClient: Optional Kraken spot rest client to use to get a websocket token. Can be nil in case only public endpoints are used.
-//   - clientNonceGenerator: Optional nonce generator used to get nonces used to sign requests sent with the REST Client. Can be nil in case only public endpoints are used.
-//   - secopts: Optional security options (like password 2FA) to use when sending requests with the REST client.
+//   - tokenSource: Optional source used to get websocket tokens. Can be nil in case only public endpoints are used.
 //   - onCloseCallback: optional user defined callback which will be called when connection is closed/interrupted.
 //   - onReadErrorCallback: optional user defined callback which will be called when an error occurs while reading messages from the websocket server
 //   - onRestartError: optional user defined callback which will be called when the websocket engine fails to reconnect to the server.
@@ -137,9 +281,7 @@ type krakenSpotWebsocketClient struct {
 //
 // A new krakenSpotWebsocketClient which can then be used by a wscengine.WebsocketEngine.
 func newKrakenSpotWebsocketClient(
-	restClient rest.KrakenSpotRESTClientIface,
-	clientNonceGenerator noncegen.NonceGenerator,
-	secopts *restcommon.SecurityOptions,
+	tokenSource *websocketTokenSource,
 	onCloseCallback func(ctx context.Context, closeMessage *wsclient.CloseMessageDetails),
 	onReadErrorCallback func(ctx context.Context, restart context.CancelFunc, exit context.CancelFunc, err error),
 	onRestartError func(ctx context.Context, exit context.CancelFunc, err error, retryCount int),
@@ -154,13 +296,16 @@ func newKrakenSpotWebsocketClient(
 	if tracerProvider == nil {
 		tracerProvider = otel.GetTracerProvider()
 	}
-	return &krakenSpotWebsocketClient{
+	client := &krakenSpotWebsocketClient{
 		conn: nil,
-		ngen: noncegen.NewHFNonceGenerator(),
+		ngen: newRequestIdGenerator(),
 		subscriptions: activeSubscriptions{
-			heartbeat:    make(chan event.Event, 10),
-			systemStatus: make(chan event.Event, 10),
-			ohlcs:        make(map[messages.IntervalEnum]*ohlcSubscription),
+			heartbeat:       make(chan event.Event, 10),
+			systemStatus:    make(chan event.Event, 10),
+			generalErrors:   make(chan event.Event, 10),
+			unknownMessages: make(chan event.Event, 10),
+			ohlcs:           make(map[messages.IntervalEnum]*ohlcSubscription),
+			books:           make(map[messages.DepthEnum]*bookSubscription),
 		},
 		requests: pendingRequests{
 			pendingPing:                          map[int64]*pendingPing{},
@@ -171,10 +316,11 @@ func newKrakenSpotWebsocketClient(
 			pendingCancelOrderRequests:           map[int64]*pendingCancelOrderRequest{},
 			pendingCancelAllOrdersRequests:       map[int64]*pendingCancelAllOrdersRequest{},
 			pendingCancelAllOrdersAfterXRequests: map[int64]*pendingCancelAllOrdersAfterXRequest{}},
+		bus:                                 NewEventBus(),
 		onCloseCallback:                     onCloseCallback,
 		onReadErrorCallback:                 onReadErrorCallback,
 		onRestartError:                      onRestartError,
-		tracer:                              tracerProvider.Tracer(tracing.PackageName, trace.WithInstrumentationVersion(tracing.PackageVersion)),
+		tracer:                              requesttag.Tracer(tracerProvider.Tracer(tracing.PackageName, trace.WithInstrumentationVersion(tracing.PackageVersion))),
 		tickerSubMu:                         sync.Mutex{},
 		ohlcSubMu:                           sync.Mutex{},
 		tradeSubMu:                          sync.Mutex{},
@@ -191,13 +337,24 @@ func newKrakenSpotWebsocketClient(
 		pendingCancelAllOrdersMu:            sync.Mutex{},
 		pendingCancelAllOrdersAfterXOrderMu: sync.Mutex{},
 		logger:                              logger,
-		restClient:                          restClient,
-		cgen:                                clientNonceGenerator,
-		secopts:                             secopts,
+		tokenSource:                         tokenSource,
+		sessions:                            newSessionTracker(),
+		unsubscribedDataPolicy:              UnsubscribedDataLog,
+		writeTimeout:                        DefaultWriteTimeout,
+		outboundQueueSize:                   DefaultOutboundQueueSize,
+		modeGate:                            NewModeGate(),
 		tokenMu:                             sync.Mutex{},
 		token:                               "", // Just to make it clear ;)
 		tokenExpiresAt:                      time.Time{},
-	}
+		tokenLastRefreshAt:                  time.Time{},
+		tokenLastError:                      nil,
+		tokenRefreshCount:                   0,
+		tokenRefresherRunning:               false,
+		codec:                               codec.StandardJSONCodec{},
+		clock:                               clock.NewSystemClock(),
+	}
+	client.SetMeterProvider(nil)
+	return client
 }
 
 /*************************************************************************************************/
@@ -222,62 +379,73 @@ func newKrakenSpotWebsocketClient(
 //   - The provided context expires before pong is received (OperationInterruptedError).
 //   - An error message is received from the server (OperationError).
 func (client *krakenSpotWebsocketClient) Ping(ctx context.Context) error {
+	_, err := client.PingAsync(ctx).Await(context.Background())
+	return err
+}
+
+// # Description
+//
+// Asynchronous variant of Ping: the ping is sent and the method returns a future which is
+// resolved once the pong has been received from the server, without waiting for it. No
+// goroutine is used to wait for the response: the future is resolved by the client when it
+// processes the pong. Use Await, Poll or Done to get the results.
+//
+// The future is resolved with the same errors as Ping. It is failed right away if the ping
+// cannot be sent.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. The future is failed with a
+//     OperationInterruptedError if the context is done before the pong is received.
+//
+// # Return
+//
+// A future resolved with the Pong message from the server.
+func (client *krakenSpotWebsocketClient) PingAsync(ctx context.Context) *Future[*messages.Pong] {
 	// Tracing: Start span
 	ctx, span := client.tracer.Start(ctx, "ping", trace.WithSpanKind(trace.SpanKindClient))
-	defer span.End()
 	client.logger.Println("sending ping to the server")
-	// Create response channels
-	errChan := make(chan error, 1)
-	respChan := make(chan *messages.Pong, 1)
+	// Create the future used to get the response
+	result := NewFuture[*messages.Pong]()
 	// Send ping message to server
 	req := &messages.Ping{
 		Event: string(messages.EventTypePing),
 		ReqId: client.ngen.GenerateNonce(),
 	}
-	// Lock pending ping request map and add request to the stack.
-	client.pendingPingMu.Lock()
-	client.requests.pendingPing[req.ReqId] = &pendingPing{
-		resp: respChan,
-		err:  errChan,
-	}
-	// Defer pending request map cleanup to remove it in case of failure or ensure it has been
-	// removed in case of success. This is safe because pending requests ids are unique and
-	// internally managed.
-	defer delete(client.requests.pendingSubscribe, req.ReqId)
-	// Defer unlocking pending request map.
-	unlock := sync.OnceFunc(client.pendingPingMu.Unlock)
-	defer unlock()
 	// Marshal to JSON
-	payload, err := json.Marshal(req)
+	payload, err := client.codec.Marshal(req)
 	if err != nil {
 		// Trace and return error -> failed to format request
-		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("failed to format ping request: %w", err))
+		return failedRequest[*messages.Pong](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("failed to format ping request: %w", err)))
 	}
-	// Send message to websocket server
-	err = client.conn.Write(ctx, wsadapters.Text, payload)
+	// Add pending request and write the message to the server. The pending requests are locked
+	// until the message has been written so the pong cannot be processed before.
+	client.pendingPingMu.Lock()
+	client.requests.pendingPing[req.ReqId] = &pendingPing{
+		session: client.sessions.current(),
+		span:    trace.SpanContextFromContext(ctx),
+		tags:    requesttag.Attributes(ctx),
+		result:  result,
+	}
+	err = client.write(ctx, payload)
 	if err != nil {
-		// Trace and return error -> failed to send request
-		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("failed to send ping request: %w", err))
-	}
-	// Unlock pending ping requests map so another goroutine can process the pong message and
-	// fulfill the pending request. As the call is encapsulaated in a sync.Once, the deferred
-	// unlock will be a noop.
-	unlock()
-	// Wait for response to be published on channels or timeout
+		// Discard the pending request, trace and return error -> failed to send request
+		delete(client.requests.pendingPing, req.ReqId)
+		client.pendingPingMu.Unlock()
+		return failedRequest[*messages.Pong](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("failed to send ping request: %w", err)))
+	}
+	client.pendingPingMu.Unlock()
 	client.logger.Println("waiting for pong from the server")
-	select {
-	case <-ctx.Done():
-		// Trace and return error -> operation interrupted before completion.
-		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "ping", Root: fmt.Errorf("ping failed: %w", ctx.Err())})
-	case err := <-errChan:
-		// Trace and return error -> operation failed with an error from the server.
-		return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "ping", Root: fmt.Errorf("ping failed: %w", err)})
-	case <-respChan:
+	return awaitPendingRequest(ctx, client, span, "ping", result, func() {
+		client.pendingPingMu.Lock()
+		delete(client.requests.pendingPing, req.ReqId)
+		client.pendingPingMu.Unlock()
+	}, func(resp *messages.Pong) (*messages.Pong, error) {
 		// Set span status and exit
 		client.logger.Println("pong received")
 		span.SetStatus(codes.Ok, codes.Ok.String())
-		return nil
-	}
+		return resp, nil
+	})
 }
 
 // # Description
@@ -356,7 +524,8 @@ func (client *krakenSpotWebsocketClient) Ping(ctx context.Context) error {
 //   - A connection_interrupted event MUST be published on the channel each time the websocket
 //     connection is closed.
 //
-//   - The provided channel MUST be closed upon unsubscribe or when the websocket client stops.
+//   - The provided channel MUST be closed upon unsubscribe (Cf.
+//     SetKeepChannelsOpenOnUnsubscribe) or when the websocket client stops.
 //
 //   - The websocket client implementation CAN either use blocking writes or discard messages in
 //     case the provided channel is full. It is up to the client implementation to be clear about
@@ -375,14 +544,15 @@ func (client *krakenSpotWebsocketClient) SubscribeTicker(ctx context.Context, pa
 	defer span.End()
 	client.logger.Println("subscribing to ticker channel", pairs)
 	// Check if there is already an active subscription
-	client.tickerSubMu.Lock() // Lock mutex till subscribe completes - this will block Unsubscribe
+	defer client.persistSubscriptions(ctx) // Runs once the subscription mutex is released
+	client.tickerSubMu.Lock()              // Lock mutex till subscribe completes - this will block Unsubscribe
 	defer client.tickerSubMu.Unlock()
 	if client.subscriptions.ticker != nil {
 		// Trae and log error: already subscribed
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe ticker failed because there is already an active subscription"))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send subscribe message to server
 	err := client.sendSubscribeRequest(
 		ctx,
@@ -394,19 +564,19 @@ func (client *krakenSpotWebsocketClient) SubscribeTicker(ctx context.Context, pa
 				Name: string(messages.ChannelTicker),
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe ticker failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	client.logger.Println("waiting for subscribe response from server")
 	select {
 	case <-ctx.Done():
 		// Trace and return error: operation interrupted before completion
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "suscribe_ticker", Root: fmt.Errorf("subscribe ticker failed: %w", ctx.Err())})
-	case err := <-errChan:
-		if err != nil {
+	case <-result.Done():
+		if _, err := result.get(); err != nil {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "suscribe_ticker", Root: fmt.Errorf("subscribe ticker failed: %w", err)})
 		}
@@ -500,7 +670,8 @@ func (client *krakenSpotWebsocketClient) SubscribeTicker(ctx context.Context, pa
 //   - A connection_interrupted event MUST be published on the channel each time the websocket
 //     connection is closed.
 //
-//   - The provided channel MUST be closed upon unsubscribe or when the websocket client stops.
+//   - The provided channel MUST be closed upon unsubscribe (Cf.
+//     SetKeepChannelsOpenOnUnsubscribe) or when the websocket client stops.
 //
 //   - The websocket client implementation CAN either use blocking writes or discard messages in
 //     case the provided channel is full. It is up to the client implementation to be clear about
@@ -510,6 +681,13 @@ func (client *krakenSpotWebsocketClient) SubscribeTicker(ctx context.Context, pa
 //     then the websocket client MUST resubscribe to previously subscribed channels and reuse
 //     the channel that has been provided when the user subscribed to the channel.
 func (client *krakenSpotWebsocketClient) SubscribeOHLC(ctx context.Context, pairs []string, interval messages.IntervalEnum, rcv chan event.Event) error {
+	return client.subscribeOHLC(ctx, pairs, interval, rcv, false)
+}
+
+// Subscribe to ohlc channel. internal tells whether rcv has been created by the client: the
+// subscription is then flagged before being persisted and rcv is always closed on unsubscribe
+// (Cf. closeOnUnsubscribe).
+func (client *krakenSpotWebsocketClient) subscribeOHLC(ctx context.Context, pairs []string, interval messages.IntervalEnum, rcv chan event.Event, internal bool) error {
 	// Tracing: Start span
 	ctx, span := client.tracer.Start(ctx, "subscribe_ohlc",
 		trace.WithSpanKind(trace.SpanKindClient),
@@ -520,13 +698,14 @@ func (client *krakenSpotWebsocketClient) SubscribeOHLC(ctx context.Context, pair
 	defer span.End()
 	client.logger.Println("subscribing to ohlc channel", pairs, int(interval))
 	// Check if there is already an active subscription
-	client.ohlcSubMu.Lock() // Lock mutex till subscribe completes - this will block Unsubscribe
+	defer client.persistSubscriptions(ctx) // Runs once the subscription mutex is released
+	client.ohlcSubMu.Lock()                // Lock mutex till subscribe completes - this will block Unsubscribe
 	defer client.ohlcSubMu.Unlock()
 	if client.subscriptions.ohlcs[interval] != nil {
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe ohlc-%d failed because there is already an active subscription", int(interval)))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send subscribe message to server
 	err := client.sendSubscribeRequest(
 		ctx,
@@ -539,19 +718,19 @@ func (client *krakenSpotWebsocketClient) SubscribeOHLC(ctx context.Context, pair
 				Interval: int(interval),
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe ohlc-%d failed: %w", int(interval), err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	client.logger.Println("waiting for subscribe response from server")
 	select {
 	case <-ctx.Done():
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "subscribe_ohlc", Root: fmt.Errorf("subscribe ohlc failed: %w", ctx.Err())})
-	case err := <-errChan:
-		if err != nil {
+	case <-result.Done():
+		if _, err := result.get(); err != nil {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "subscribe_ohlc", Root: fmt.Errorf("subscribe ohlc failed: %w", err)})
 		}
@@ -560,6 +739,7 @@ func (client *krakenSpotWebsocketClient) SubscribeOHLC(ctx context.Context, pair
 			pairs:    pairs,
 			pub:      rcv,
 			interval: interval,
+			internal: internal,
 		}
 		// Return publish channel
 		client.logger.Println("ohlc channel subscribed")
@@ -642,7 +822,8 @@ func (client *krakenSpotWebsocketClient) SubscribeOHLC(ctx context.Context, pair
 //   - A connection_interrupted event MUST be published on the channel each time the websocket
 //     connection is closed.
 //
-//   - The provided channel MUST be closed upon unsubscribe or when the websocket client stops.
+//   - The provided channel MUST be closed upon unsubscribe (Cf.
+//     SetKeepChannelsOpenOnUnsubscribe) or when the websocket client stops.
 //
 //   - The websocket client implementation CAN either use blocking writes or discard messages in
 //     case the provided channel is full. It is up to the client implementation to be clear about
@@ -652,6 +833,13 @@ func (client *krakenSpotWebsocketClient) SubscribeOHLC(ctx context.Context, pair
 //     then the websocket client MUST resubscribe to previously subscribed channels and reuse
 //     the channel that has been provided when the user subscribed to the channel.
 func (client *krakenSpotWebsocketClient) SubscribeTrade(ctx context.Context, pairs []string, rcv chan event.Event) error {
+	return client.subscribeTrade(ctx, pairs, rcv, nil, false)
+}
+
+// Subscribe to trade channel. Messages are either published on rcv or, if raw is not nil,
+// dispatched through the raw callback (Cf. SubscribeTradeRaw). internal tells whether rcv has
+// been created by the client (Cf. subscribeOHLC).
+func (client *krakenSpotWebsocketClient) subscribeTrade(ctx context.Context, pairs []string, rcv chan event.Event, raw RawMessageCallback, internal bool) error {
 	// Tracing: Start span
 	ctx, span := client.tracer.Start(ctx, "subscribe_trade",
 		trace.WithSpanKind(trace.SpanKindClient),
@@ -661,13 +849,14 @@ func (client *krakenSpotWebsocketClient) SubscribeTrade(ctx context.Context, pai
 	defer span.End()
 	client.logger.Println("subscribing to trade channel", pairs)
 	// Check if there is already an active subscription
-	client.tradeSubMu.Lock() // Lock mutex till subscribe completes - this will block Unsubscribe
+	defer client.persistSubscriptions(ctx) // Runs once the subscription mutex is released
+	client.tradeSubMu.Lock()               // Lock mutex till subscribe completes - this will block Unsubscribe
 	defer client.tradeSubMu.Unlock()
 	if client.subscriptions.trade != nil {
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe trade failed because there is already an active subscription"))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send subscribe message to server
 	err := client.sendSubscribeRequest(
 		ctx,
@@ -679,26 +868,30 @@ func (client *krakenSpotWebsocketClient) SubscribeTrade(ctx context.Context, pai
 				Name: string(messages.ChannelTrade),
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe trade failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	client.logger.Println("waiting for subscribe response from server")
 	select {
 	case <-ctx.Done():
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "subscribe_trade", Root: fmt.Errorf("subscribe trade failed: %w", ctx.Err())})
-	case err := <-errChan:
-		if err != nil {
+	case <-result.Done():
+		if _, err := result.get(); err != nil {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "subscribe_trade", Root: fmt.Errorf("subscribe trade failed: %w", err)})
 		}
 		// Register the subscription
 		client.subscriptions.trade = &tradeSubscription{
-			pairs: pairs,
-			pub:   rcv,
+			pairs:    pairs,
+			pub:      rcv,
+			internal: internal,
+		}
+		if raw != nil {
+			client.rawTrade.Store(newRawSubscription(pairs, raw))
 		}
 		// Return publish channel
 		client.logger.Println("trade channel subscribed")
@@ -781,7 +974,8 @@ func (client *krakenSpotWebsocketClient) SubscribeTrade(ctx context.Context, pai
 //   - A connection_interrupted event MUST be published on the channel each time the websocket
 //     connection is closed.
 //
-//   - The provided channel MUST be closed upon unsubscribe or when the websocket client stops.
+//   - The provided channel MUST be closed upon unsubscribe (Cf.
+//     SetKeepChannelsOpenOnUnsubscribe) or when the websocket client stops.
 //
 //   - The websocket client implementation CAN either use blocking writes or discard messages in
 //     case the provided channel is full. It is up to the client implementation to be clear about
@@ -791,6 +985,12 @@ func (client *krakenSpotWebsocketClient) SubscribeTrade(ctx context.Context, pai
 //     then the websocket client MUST resubscribe to previously subscribed channels and reuse
 //     the channel that has been provided when the user subscribed to the channel.
 func (client *krakenSpotWebsocketClient) SubscribeSpread(ctx context.Context, pairs []string, rcv chan event.Event) error {
+	return client.subscribeSpread(ctx, pairs, rcv, nil)
+}
+
+// Subscribe to spread channel. Messages are either published on rcv or, if raw is not nil,
+// dispatched through the raw callback (Cf. SubscribeSpreadRaw).
+func (client *krakenSpotWebsocketClient) subscribeSpread(ctx context.Context, pairs []string, rcv chan event.Event, raw RawMessageCallback) error {
 	// Tracing: Start span
 	ctx, span := client.tracer.Start(ctx, "subscribe_spread",
 		trace.WithSpanKind(trace.SpanKindClient),
@@ -800,13 +1000,14 @@ func (client *krakenSpotWebsocketClient) SubscribeSpread(ctx context.Context, pa
 	defer span.End()
 	client.logger.Println("subscribing to spread channel", pairs)
 	// Check if there is already an active subscription
-	client.spreadSubMu.Lock() // Lock mutex till subscribe completes - this will block Unsubscribe
+	defer client.persistSubscriptions(ctx) // Runs once the subscription mutex is released
+	client.spreadSubMu.Lock()              // Lock mutex till subscribe completes - this will block Unsubscribe
 	defer client.spreadSubMu.Unlock()
 	if client.subscriptions.spread != nil {
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe spread failed because there is already an active subscription"))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send subscribe message to server
 	err := client.sendSubscribeRequest(
 		ctx,
@@ -818,19 +1019,19 @@ func (client *krakenSpotWebsocketClient) SubscribeSpread(ctx context.Context, pa
 				Name: string(messages.ChannelSpread),
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe spread failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	client.logger.Println("waiting for subscribe response from server")
 	select {
 	case <-ctx.Done():
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "subscribe_spread", Root: fmt.Errorf("subscribe spread failed: %w", ctx.Err())})
-	case err := <-errChan:
-		if err != nil {
+	case <-result.Done():
+		if _, err := result.get(); err != nil {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "subscribe_spread", Root: fmt.Errorf("subscribe spread failed: %w", err)})
 		}
@@ -839,6 +1040,9 @@ func (client *krakenSpotWebsocketClient) SubscribeSpread(ctx context.Context, pa
 			pairs: pairs,
 			pub:   rcv,
 		}
+		if raw != nil {
+			client.rawSpread.Store(newRawSubscription(pairs, raw))
+		}
 		// Return publish channel
 		client.logger.Println("spread channel subscribed")
 		span.SetStatus(codes.Ok, codes.Ok.String())
@@ -912,6 +1116,8 @@ func (client *krakenSpotWebsocketClient) SubscribeSpread(ctx context.Context, pa
 //
 //   - ctx: Context used for tracing and coordination purpose.
 //   - pair: Pairs to subscribe to.
+//   - depth: Desired book depth. Subscriptions for different depths can be active at the same
+//     time.
 //   - rcv: Channel used to publish book_snapshot & book+update messages and
 //     connection_interrupted events.
 //
@@ -919,7 +1125,7 @@ func (client *krakenSpotWebsocketClient) SubscribeSpread(ctx context.Context, pa
 //
 // An error is returned when:
 //
-//   - There is already an active subscription.
+//   - There is already an active subscription for that depth.
 //   - An error occurs when sending the subscription message.
 //   - The provided context expires before subscription is completed (OperationInterruptedError).
 //   - An error message is received from the server (OperationError).
@@ -933,7 +1139,8 @@ func (client *krakenSpotWebsocketClient) SubscribeSpread(ctx context.Context, pa
 //   - A connection_interrupted event MUST be published on the channel each time the websocket
 //     connection is closed.
 //
-//   - The provided channel MUST be closed upon unsubscribe or when the websocket client stops.
+//   - The provided channel MUST be closed upon unsubscribe (Cf.
+//     SetKeepChannelsOpenOnUnsubscribe) or when the websocket client stops.
 //
 //   - The websocket client implementation CAN either use blocking writes or discard messages in
 //     case the provided channel is full. It is up to the client implementation to be clear about
@@ -943,6 +1150,13 @@ func (client *krakenSpotWebsocketClient) SubscribeSpread(ctx context.Context, pa
 //     then the websocket client MUST resubscribe to previously subscribed channels and reuse
 //     the channel that has been provided when the user subscribed to the channel.
 func (client *krakenSpotWebsocketClient) SubscribeBook(ctx context.Context, pairs []string, depth messages.DepthEnum, rcv chan event.Event) error {
+	return client.subscribeBook(ctx, pairs, depth, rcv, nil, false)
+}
+
+// Subscribe to book channel. Messages are either published on rcv or, if raw is not nil,
+// dispatched through the raw callback (Cf. SubscribeBookRaw). internal tells whether rcv has
+// been created by the client (Cf. subscribeOHLC).
+func (client *krakenSpotWebsocketClient) subscribeBook(ctx context.Context, pairs []string, depth messages.DepthEnum, rcv chan event.Event, raw RawMessageCallback, internal bool) error {
 	// Tracing: Start span
 	ctx, span := client.tracer.Start(ctx, "subscribe_book",
 		trace.WithSpanKind(trace.SpanKindClient),
@@ -953,13 +1167,14 @@ func (client *krakenSpotWebsocketClient) SubscribeBook(ctx context.Context, pair
 	defer span.End()
 	client.logger.Println("subscribing to book channel")
 	// Check if there is already an active subscription
-	client.bookSubMu.Lock() // Lock mutex till subscribe completes - this will block Unsubscribe
+	defer client.persistSubscriptions(ctx) // Runs once the subscription mutex is released
+	client.bookSubMu.Lock()                // Lock mutex till subscribe completes - this will block Unsubscribe
 	defer client.bookSubMu.Unlock()
-	if client.subscriptions.book != nil {
-		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe book failed because there is already an active subscription"))
+	if client.subscriptions.books[depth] != nil {
+		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe book-%d failed because there is already an active subscription", int(depth)))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send subscribe message to server
 	err := client.sendSubscribeRequest(
 		ctx,
@@ -972,27 +1187,31 @@ func (client *krakenSpotWebsocketClient) SubscribeBook(ctx context.Context, pair
 				Depth: int(depth),
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe book failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	client.logger.Println("waiting for subscribe response from server")
 	select {
 	case <-ctx.Done():
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "subscribe_book", Root: fmt.Errorf("subscribe book failed: %w", ctx.Err())})
-	case err := <-errChan:
-		if err != nil {
+	case <-result.Done():
+		if _, err := result.get(); err != nil {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "subscribe_book", Root: fmt.Errorf("subscribe book failed: %w", err)})
 		}
 		// Register the subscription
-		client.subscriptions.book = &bookSubscription{
-			pairs: pairs,
-			pub:   rcv,
-			depth: depth,
+		client.subscriptions.books[depth] = &bookSubscription{
+			pairs:    pairs,
+			pub:      rcv,
+			depth:    depth,
+			internal: internal,
+		}
+		if raw != nil {
+			client.storeRawBook(depth, newRawSubscription(pairs, raw))
 		}
 		// Return publish channel
 		client.logger.Println("book channel subscribed")
@@ -1004,7 +1223,7 @@ func (client *krakenSpotWebsocketClient) SubscribeBook(ctx context.Context, pair
 // # Description
 //
 // Unsubscribe from the ticker channel. The channel provided on subscribe will be closed by
-// the websocket client.
+// the websocket client (Cf. SetKeepChannelsOpenOnUnsubscribe).
 //
 // # Inputs
 //
@@ -1021,7 +1240,8 @@ func (client *krakenSpotWebsocketClient) SubscribeBook(ctx context.Context, pair
 //
 // # Implementation and usage guidelines
 //
-//   - In case of success, the client MUST close the channel used to publish events.
+//   - In case of success, the client MUST close the channel used to publish events (Cf.
+//     SetKeepChannelsOpenOnUnsubscribe).
 //
 //   - The client MUST use the right error type as described in the "Return" section.
 func (client *krakenSpotWebsocketClient) UnsubscribeTicker(ctx context.Context) error {
@@ -1030,13 +1250,14 @@ func (client *krakenSpotWebsocketClient) UnsubscribeTicker(ctx context.Context)
 	defer span.End()
 	client.logger.Println("unsubscribing from ticker channel")
 	// Check if there is already an active subscription
-	client.tickerSubMu.Lock() // Lock mutex till subscribe completes - this will block Subscribe
+	defer client.persistSubscriptions(ctx) // Runs once the subscription mutex is released
+	client.tickerSubMu.Lock()              // Lock mutex till subscribe completes - this will block Subscribe
 	defer client.tickerSubMu.Unlock()
 	if client.subscriptions.ticker == nil {
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe ticker failed because there is no active subscription"))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send unsubscribe message to server
 	err := client.sendUnsubscribeRequest(
 		ctx,
@@ -1048,24 +1269,24 @@ func (client *krakenSpotWebsocketClient) UnsubscribeTicker(ctx context.Context)
 				Name: string(messages.ChannelTicker),
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe ticker failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	client.logger.Println("waiting for unsubscribe response from server")
 	select {
 	case <-ctx.Done():
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "unsubscribe_ticker", Root: fmt.Errorf("unsubscribe ticker failed: %w", ctx.Err())})
-	case err := <-errChan:
-		if err != nil {
+	case <-result.Done():
+		if _, err := result.get(); err != nil {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "unsubscribe_ticker", Root: fmt.Errorf("unsubscribe ticker failed: %w", err)})
 		}
 		// Close the publication channel, discard the subscription and exit
-		close(client.subscriptions.ticker.pub)
+		client.closeOnUnsubscribe(ctx, client.subscriptions.ticker.pub, false, string(messages.ChannelTicker))
 		client.subscriptions.ticker = nil
 		client.logger.Println("unsubscribed from ticker channel")
 		span.SetStatus(codes.Ok, codes.Ok.String())
@@ -1076,7 +1297,7 @@ func (client *krakenSpotWebsocketClient) UnsubscribeTicker(ctx context.Context)
 // # Description
 //
 // Unsubscribe from the ohlc channel with the given interva. The channel provided on subscribe
-// will be closed by the websocket client.
+// will be closed by the websocket client (Cf. SetKeepChannelsOpenOnUnsubscribe).
 //
 // # Inputs
 //
@@ -1094,7 +1315,8 @@ func (client *krakenSpotWebsocketClient) UnsubscribeTicker(ctx context.Context)
 //
 // # Implementation and usage guidelines
 //
-//   - In case of success, the client MUST close the channel used to publish events.
+//   - In case of success, the client MUST close the channel used to publish events (Cf.
+//     SetKeepChannelsOpenOnUnsubscribe).
 //
 //   - The client MUST use the right error type as described in the "Return" section.
 func (client *krakenSpotWebsocketClient) UnsubscribeOHLC(ctx context.Context, interval messages.IntervalEnum) error {
@@ -1105,13 +1327,14 @@ func (client *krakenSpotWebsocketClient) UnsubscribeOHLC(ctx context.Context, in
 	defer span.End()
 	client.logger.Println("unsubscribing from ohlc channel", interval)
 	// Check if there is already an active subscription
-	client.ohlcSubMu.Lock() // Lock mutex till unsubscribe completes - this will block Subscribe
+	defer client.persistSubscriptions(ctx) // Runs once the subscription mutex is released
+	client.ohlcSubMu.Lock()                // Lock mutex till unsubscribe completes - this will block Subscribe
 	defer client.ohlcSubMu.Unlock()
 	if client.subscriptions.ohlcs[interval] == nil {
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe ohlc failed because there is no active subscription"))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send unsubscribe message to server
 	err := client.sendUnsubscribeRequest(
 		ctx,
@@ -1124,24 +1347,24 @@ func (client *krakenSpotWebsocketClient) UnsubscribeOHLC(ctx context.Context, in
 				Interval: int(interval),
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe ohlc failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	client.logger.Println("waiting for unsubscribe response from server")
 	select {
 	case <-ctx.Done():
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "unsubscribe_ohlc", Root: fmt.Errorf("unsubscribe ohlc failed: %w", ctx.Err())})
-	case err := <-errChan:
-		if err != nil {
+	case <-result.Done():
+		if _, err := result.get(); err != nil {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "unsubscribe_ohlc", Root: fmt.Errorf("unsubscribe ohlc failed: %w", err)})
 		}
 		// Close the publication channel, discard the subscription and exit
-		close(client.subscriptions.ohlcs[interval].pub)
+		client.closeOnUnsubscribe(ctx, client.subscriptions.ohlcs[interval].pub, client.subscriptions.ohlcs[interval].internal, fmt.Sprintf("%s-%d", messages.ChannelOHLC, int(interval)))
 		delete(client.subscriptions.ohlcs, interval)
 		client.logger.Println("unsubscribed from ohlc channel", interval)
 		span.SetStatus(codes.Ok, codes.Ok.String())
@@ -1152,7 +1375,7 @@ func (client *krakenSpotWebsocketClient) UnsubscribeOHLC(ctx context.Context, in
 // # Description
 //
 // Unsubscribe from the trade channel. The channel provided on subscribe will be closed by
-// the websocket client.
+// the websocket client (Cf. SetKeepChannelsOpenOnUnsubscribe).
 //
 // # Inputs
 //
@@ -1169,7 +1392,8 @@ func (client *krakenSpotWebsocketClient) UnsubscribeOHLC(ctx context.Context, in
 //
 // # Implementation and usage guidelines
 //
-//   - In case of success, the client MUST close the channel used to publish events.
+//   - In case of success, the client MUST close the channel used to publish events (Cf.
+//     SetKeepChannelsOpenOnUnsubscribe).
 //
 //   - The client MUST use the right error type as described in the "Return" section.
 func (client *krakenSpotWebsocketClient) UnsubscribeTrade(ctx context.Context) error {
@@ -1178,13 +1402,14 @@ func (client *krakenSpotWebsocketClient) UnsubscribeTrade(ctx context.Context) e
 	defer span.End()
 	client.logger.Println("unsubscribing from trade channel")
 	// Check if there is already an active subscription
-	client.tradeSubMu.Lock() // Lock mutex till subscribe completes - this will block Subscribe
+	defer client.persistSubscriptions(ctx) // Runs once the subscription mutex is released
+	client.tradeSubMu.Lock()               // Lock mutex till subscribe completes - this will block Subscribe
 	defer client.tradeSubMu.Unlock()
 	if client.subscriptions.trade == nil {
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe trade failed because there is no active subscription"))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send unsubscribe message to server
 	err := client.sendUnsubscribeRequest(
 		ctx,
@@ -1196,24 +1421,25 @@ func (client *krakenSpotWebsocketClient) UnsubscribeTrade(ctx context.Context) e
 				Name: string(messages.ChannelTrade),
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe trade failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	client.logger.Println("waiting for unsubscribe response from server")
 	select {
 	case <-ctx.Done():
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "unsubscribe_trade", Root: fmt.Errorf("unsubscribe trade failed: %w", ctx.Err())})
-	case err := <-errChan:
-		if err != nil {
+	case <-result.Done():
+		if _, err := result.get(); err != nil {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "unsubscribe_trade", Root: fmt.Errorf("unsubscribe trade failed: %w", err)})
 		}
 		// Close the publication channel, discard the subscription and exit
-		close(client.subscriptions.trade.pub)
+		client.closeOnUnsubscribe(ctx, client.subscriptions.trade.pub, client.subscriptions.trade.internal, string(messages.ChannelTrade))
+		client.rawTrade.Store(nil)
 		client.subscriptions.trade = nil
 		client.logger.Println("unsubscribed from trade channel")
 		span.SetStatus(codes.Ok, codes.Ok.String())
@@ -1224,7 +1450,7 @@ func (client *krakenSpotWebsocketClient) UnsubscribeTrade(ctx context.Context) e
 // # Description
 //
 // Unsubscribe from the spread channel. The channel provided on subscribe will be closed by
-// the websocket client.
+// the websocket client (Cf. SetKeepChannelsOpenOnUnsubscribe).
 //
 // # Inputs
 //
@@ -1241,7 +1467,8 @@ func (client *krakenSpotWebsocketClient) UnsubscribeTrade(ctx context.Context) e
 //
 // # Implementation and usage guidelines
 //
-//   - In case of success, the client MUST close the channel used to publish events.
+//   - In case of success, the client MUST close the channel used to publish events (Cf.
+//     SetKeepChannelsOpenOnUnsubscribe).
 //
 //   - The client MUST use the right error type as described in the "Return" section.
 func (client *krakenSpotWebsocketClient) UnsubscribeSpread(ctx context.Context) error {
@@ -1250,13 +1477,14 @@ func (client *krakenSpotWebsocketClient) UnsubscribeSpread(ctx context.Context)
 	defer span.End()
 	client.logger.Println("unsubscribing from spread channel")
 	// Check if there is already an active subscription
-	client.spreadSubMu.Lock() // Lock mutex till subscribe completes - this will block Subscribe
+	defer client.persistSubscriptions(ctx) // Runs once the subscription mutex is released
+	client.spreadSubMu.Lock()              // Lock mutex till subscribe completes - this will block Subscribe
 	defer client.spreadSubMu.Unlock()
 	if client.subscriptions.spread == nil {
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe spread failed because there is no active subscription"))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send unsubscribe message to server
 	err := client.sendUnsubscribeRequest(
 		ctx,
@@ -1268,24 +1496,25 @@ func (client *krakenSpotWebsocketClient) UnsubscribeSpread(ctx context.Context)
 				Name: string(messages.ChannelSpread),
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe spread failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	client.logger.Println("waiting for unsubscribe response from server")
 	select {
 	case <-ctx.Done():
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "unsubscribe_spread", Root: fmt.Errorf("unsubscribe spread failed: %w", ctx.Err())})
-	case err := <-errChan:
-		if err != nil {
+	case <-result.Done():
+		if _, err := result.get(); err != nil {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "unsubscribe_spread", Root: fmt.Errorf("unsubscribe spread failed: %w", err)})
 		}
 		// close the publication channel, discard the subscription and exit
-		close(client.subscriptions.spread.pub)
+		client.closeOnUnsubscribe(ctx, client.subscriptions.spread.pub, false, string(messages.ChannelSpread))
+		client.rawSpread.Store(nil)
 		client.subscriptions.spread = nil
 		span.SetStatus(codes.Ok, codes.Ok.String())
 		client.logger.Println("unsubscribed from spread channel")
@@ -1295,8 +1524,9 @@ func (client *krakenSpotWebsocketClient) UnsubscribeSpread(ctx context.Context)
 
 // # Description
 //
-// Unsubscribe from the book channel. The channel provided on subscribe will be closed by
-// the websocket client.
+// Unsubscribe from the book channel. All active book subscriptions are cancelled, whatever their
+// depth, in increasing depth order. The channels provided on subscribe will be closed by the
+// websocket client (Cf. SetKeepChannelsOpenOnUnsubscribe).
 //
 // # Inputs
 //
@@ -1311,55 +1541,113 @@ func (client *krakenSpotWebsocketClient) UnsubscribeSpread(ctx context.Context)
 //   - The provided context expires before subscription is completed (OperationInterruptedError).
 //   - An error message is received from the server (OperationError).
 //
+// The first error stops the process: subscriptions for greater depths are left untouched.
+//
 // # Implementation and usage guidelines
 //
-//   - In case of success, the client MUST close the channel used to publish events.
+//   - In case of success, the client MUST close the channel used to publish events (Cf.
+//     SetKeepChannelsOpenOnUnsubscribe).
 //
 //   - The client MUST use the right error type as described in the "Return" section.
 func (client *krakenSpotWebsocketClient) UnsubscribeBook(ctx context.Context) error {
 	// Tracing: Start span
 	ctx, span := client.tracer.Start(ctx, "unsubscribe_book", trace.WithSpanKind(trace.SpanKindClient))
 	defer span.End()
-	client.logger.Println("unsubscribing from book channel")
+	// Get the depths of the active subscriptions
+	client.bookSubMu.Lock()
+	depths := make([]messages.DepthEnum, 0, len(client.subscriptions.books))
+	for depth := range client.subscriptions.books {
+		depths = append(depths, depth)
+	}
+	client.bookSubMu.Unlock()
+	if len(depths) == 0 {
+		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe book failed because there is no active subscription"))
+	}
+	sort.Slice(depths, func(i, j int) bool { return depths[i] < depths[j] })
+	for _, depth := range depths {
+		if err := client.UnsubscribeBookDepth(ctx, depth); err != nil {
+			return tracing.HandleAndTraLogError(span, client.logger, err)
+		}
+	}
+	span.SetStatus(codes.Ok, codes.Ok.String())
+	return nil
+}
+
+// # Description
+//
+// Unsubscribe from the book channel for the provided depth. The channel provided on subscribe
+// will be closed by the websocket client (Cf. SetKeepChannelsOpenOnUnsubscribe). Book
+// subscriptions for other depths are left untouched.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - depth: Depth of the book subscription to unsubscribe from.
+//
+// # Return
+//
+// An error is returned when:
+//
+//   - The channel has not been subscribed to for that depth.
+//   - An error occurs when sending the unsubscribe message.
+//   - The provided context expires before subscription is completed (OperationInterruptedError).
+//   - An error message is received from the server (OperationError).
+//
+// # Implementation and usage guidelines
+//
+//   - In case of success, the client MUST close the channel used to publish events (Cf.
+//     SetKeepChannelsOpenOnUnsubscribe).
+//
+//   - The client MUST use the right error type as described in the "Return" section.
+func (client *krakenSpotWebsocketClient) UnsubscribeBookDepth(ctx context.Context, depth messages.DepthEnum) error {
+	// Tracing: Start span
+	ctx, span := client.tracer.Start(ctx, "unsubscribe_book_depth",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("depth", int(depth))))
+	defer span.End()
+	client.logger.Println("unsubscribing from book channel", depth)
 	// Check if there is already an active subscription
-	client.bookSubMu.Lock() // Lock mutex till subscribe completes - this will block Subscribe
+	defer client.persistSubscriptions(ctx) // Runs once the subscription mutex is released
+	client.bookSubMu.Lock()                // Lock mutex till subscribe completes - this will block Subscribe
 	defer client.bookSubMu.Unlock()
-	if client.subscriptions.book == nil {
-		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe book failed because there is no active subscription"))
+	sub := client.subscriptions.books[depth]
+	if sub == nil {
+		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe book-%d failed because there is no active subscription", int(depth)))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send unsubscribe message to server
 	err := client.sendUnsubscribeRequest(
 		ctx,
 		&messages.Unsubscribe{
 			Event: string(messages.EventTypeUnsubscribe),
 			ReqId: client.ngen.GenerateNonce(),
-			Pairs: client.subscriptions.book.pairs,
+			Pairs: sub.pairs,
 			Subscription: messages.UnsuscribeDetails{
 				Name:  string(messages.ChannelBook),
-				Depth: int(client.subscriptions.book.depth),
+				Depth: int(depth),
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe book failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	client.logger.Println("waiting for unsubscribe response from server")
 	select {
 	case <-ctx.Done():
 		// Trace and return error - OperationInterruptedError
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "unsubscribe_book", Root: fmt.Errorf("unsubscribe book failed: %w", ctx.Err())})
-	case err := <-errChan:
-		if err != nil {
+	case <-result.Done():
+		if _, err := result.get(); err != nil {
 			// Trace and return error - OperationError
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "unsubscribe_book", Root: fmt.Errorf("unsubscribe book failed: %w", err)})
 		}
 		// Close the publication channel, discard the subscription and exit
-		close(client.subscriptions.book.pub)
-		client.subscriptions.book = nil
+		client.closeOnUnsubscribe(ctx, sub.pub, sub.internal, fmt.Sprintf("%s-%d", messages.ChannelBook, int(depth)))
+		client.storeRawBook(depth, nil)
+		delete(client.subscriptions.books, depth)
 		span.SetStatus(codes.Ok, codes.Ok.String())
 		client.logger.Println("unsubscribed from book channel")
 		return nil
@@ -1424,34 +1712,284 @@ func (client *krakenSpotWebsocketClient) GetHeartbeatChannel() chan event.Event
 	return client.subscriptions.heartbeat
 }
 
+// # Description
+//
+// Get the client's built-in channel used to publish error messages which are not related to a
+// request (error messages without request ID, ex: global service messages).
+//
+// # Event types
+//
+// Only these types of events will be published on the channel (Cf. WebsocketClientEventTypeEnum):
+//
+//   - general_error: Data is the received messages.ErrorMessage.
+//
+// # Implemetation and usage guidelines
+//
+//   - The channel is provided even though the client has not been started yet.
+//
+//   - As the channel is automatically subscribed to, overflowing messages are discarded in FIFO
+//     order unless another congestion policy is set (Cf. SetCongestionPolicy).
+//
+// # Return
+//
+// The client's built-in channel used to publish general errors.
+func (client *krakenSpotWebsocketClient) GetGeneralErrorChannel() chan event.Event {
+	return client.subscriptions.generalErrors
+}
+
+// # Description
+//
+// Set the optional callback which is called each time a heartbeat, a system status update or a
+// general error is discarded because the client's built-in channel is full, or each time a
+// connection_interrupted event could not be delivered before the deadline (Cf.
+// SetConnectionInterruptedDeliveryTimeout). This can be used to detect slow consumers. A nil
+// value removes the callback.
+//
+// The callback is called from the goroutine which processes messages from the server: it must
+// not block.
+//
+// # Inputs
+//
+//   - callback: Callback which receives the type of the discarded message (heartbeat,
+//     system_status, general_error or connection_interrupted) and the total number of messages
+//     of this type discarded so far.
+func (client *krakenSpotWebsocketClient) SetOnDroppedMessageCallback(callback func(eventType events.WebsocketClientEventTypeEnum, count uint64)) {
+	client.onDroppedMessageMu.Lock()
+	defer client.onDroppedMessageMu.Unlock()
+	client.onDroppedMessageCallback = callback
+}
+
+// # Description
+//
+// Configure whether the channels provided on subscribe are closed when the client unsubscribes.
+//
+// By default, the client closes the channel provided on subscribe once a call to an Unsubscribe
+// method succeeds so consumers ranging over the channel can exit. When keep is true, the channels
+// are left open: the user becomes responsible for closing them and can provide the same channel
+// again to a later subscription (ex: to move to other pairs without restarting consumers).
+//
+// Channels created internally by the client (ex: by SubscribeBookTop) are always closed: only
+// their output channel follows this setting.
+//
+// # Inputs
+//
+//   - keep: True to keep channels open on unsubscribe, false to close them (default).
+func (client *krakenSpotWebsocketClient) SetKeepChannelsOpenOnUnsubscribe(keep bool) {
+	client.keepChannelsOpenOnUnsubscribe.Store(keep)
+}
+
+// Close the provided publication channel after a successful unsubscribe unless the client is
+// configured to keep channels open. Channels created by the client (internal) are always closed
+// immediately. Other channels are drained first if draining is enabled (Cf.
+// SetDrainOnUnsubscribe). Nil channels (raw subscriptions) are ignored.
+func (client *krakenSpotWebsocketClient) closeOnUnsubscribe(ctx context.Context, pub chan event.Event, internal bool, channel string) {
+	if pub == nil {
+		return
+	}
+	switch {
+	case internal:
+		client.stopDispatchQueue(pub, true)
+	case client.keepChannelsOpenOnUnsubscribe.Load():
+		client.stopDispatchQueue(pub, false)
+	case client.drainTimeout.Load() > 0:
+		client.drainAndClose(ctx, pub, channel)
+	default:
+		client.stopDispatchQueue(pub, true)
+	}
+}
+
+// # Description
+//
+// Set the JSON codec used to encode requests and to decode responses received from the server
+// (pong, subscription status, order status, ...). This can be used to plug a faster JSON library.
+//
+// The codec does not speed up the decoding of the messages received on data channels (book,
+// trade, ...). They are published as raw JSON data and, when they are decoded (typed channels,
+// SubscribeBookTop, managed books, ...), their message types are JSON arrays which are decoded
+// by their own UnmarshalJSON methods with encoding/json whatever the codec.
+//
+// The codec must be set before the client is started.
+//
+// # Inputs
+//
+//   - jsonCodec: JSON codec to use. If nil, codec.StandardJSONCodec (encoding/json) is used.
+func (client *krakenSpotWebsocketClient) SetJSONCodec(jsonCodec codec.JSONCodec) {
+	if jsonCodec == nil {
+		jsonCodec = codec.StandardJSONCodec{}
+	}
+	client.codec = jsonCodec
+}
+
+// # Description
+//
+// Set the clock used by the client time-based logic: websocket token expiry and background
+// refresh, resubscribe backoff and latency measurement. This can be used to provide a
+// clock.FakeClock and make that logic deterministic in tests.
+//
+// The clock must be set before the client is started.
+//
+// # Inputs
+//
+//   - c: Clock to use. If nil, the system clock is used (Cf. clock.SystemClock).
+func (client *krakenSpotWebsocketClient) SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.NewSystemClock()
+	}
+	client.clock = c
+}
+
+// # Description
+//
+// Set the meter provider used to create the client metrics (Cf. DroppedMessagesMetricName and
+// LatencyMetricName). If a metric cannot be created, a noop metric is used.
+//
+// The meter provider must be set before the client is started.
+//
+// # Inputs
+//
+//   - meterProvider: Meter provider to use. If nil, the global meter provider is used.
+func (client *krakenSpotWebsocketClient) SetMeterProvider(meterProvider metric.MeterProvider) {
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+	meter := meterProvider.Meter(tracing.PackageName, metric.WithInstrumentationVersion(tracing.PackageVersion))
+	droppedMessagesCounter, err := meter.Int64Counter(
+		DroppedMessagesMetricName,
+		metric.WithDescription("Number of messages discarded because of congestion on the client's built-in channels"))
+	if err != nil {
+		client.logger.Println("failed to create dropped messages counter:", err.Error())
+		droppedMessagesCounter = noop.Int64Counter{}
+	}
+	latencyHistogram, err := meter.Float64Histogram(
+		LatencyMetricName,
+		metric.WithDescription("Latencies measured by the latency monitor"),
+		metric.WithUnit("ms"))
+	if err != nil {
+		client.logger.Println("failed to create latency histogram:", err.Error())
+		latencyHistogram = noop.Float64Histogram{}
+	}
+	client.droppedMessagesCounter = droppedMessagesCounter
+	client.latencyHistogram = latencyHistogram
+}
+
+// # Description
+//
+// Set the client tag added as an attribute to every span started by the client (Cf. clienttag
+// package). Use DialOptions.ClientTag to also add the tag to the User-Agent of the websocket
+// handshake.
+//
+// The client tag must be set before the client is started.
+//
+// # Inputs
+//
+//   - tag: Client tag. If empty, the client tag is removed.
+func (client *krakenSpotWebsocketClient) SetClientTag(tag string) {
+	client.tracer = clienttag.Tracer(client.tracer, tag)
+}
+
+// # Description
+//
+// Set the key of the request tag (Cf. requesttag.WithTag) used as userref of the orders created
+// with AddOrder when no userref is provided. The tag is ignored if its value is not a 32 bits
+// integer. Can be used to correlate orders, openOrders and ownTrades updates with the strategy
+// decisions which created them.
+//
+// The key must be set before the client is started.
+//
+// # Inputs
+//
+//   - key: Key of the request tag. If empty, userref is only set from the order parameters.
+func (client *krakenSpotWebsocketClient) SetUserReferenceTag(key string) {
+	client.userReferenceTag = key
+}
+
+// # Description
+//
+// Get the total number of messages of the provided type discarded because of congestion on the
+// client's built-in channels, or, for connection_interrupted events, because they could not be
+// delivered before the deadline (Cf. SetConnectionInterruptedDeliveryTimeout).
+//
+// # Inputs
+//
+//   - eventType: Type of messages: heartbeat, system_status, general_error, unknown_message or
+//     connection_interrupted.
+//
+// # Return
+//
+// The total number of discarded messages of the provided type. Zero is returned for other types.
+func (client *krakenSpotWebsocketClient) GetDroppedMessagesCount(eventType events.WebsocketClientEventTypeEnum) uint64 {
+	switch eventType {
+	case events.Heartbeat:
+		return client.droppedHeartbeats.Load()
+	case events.SystemStatus:
+		return client.droppedSystemStatuses.Load()
+	case events.GeneralError:
+		return client.droppedGeneralErrors.Load()
+	case events.UnknownMessage:
+		return client.droppedUnknownMessages.Load()
+	case events.ConnectionInterrupted:
+		return client.droppedConnectionInterruptions.Load()
+	default:
+		return 0
+	}
+}
+
 /*************************************************************************************************/
 /* KRAKEN PRIVATE WEBSOCKET IMPL.                                                                */
 /*************************************************************************************************/
 
 // # Description
 //
-// Add a new order and wait until a AddOrderResponse response is received from the server or
-// until an error or a timeout occurs.
+// Asynchronous variant of AddOrder: the method returns a future right away, without blocking.
+// The request is enqueued: the trading engine status is consulted, the rate limiter is waited for
+// and the request is sent by a single goroutine shared by the asynchronous order management
+// methods (AddOrderAsync, EditOrderAsync and CancelOrderAsync). The future is resolved once a response has been received from the server.
+// Use Await, Poll or Done to get the results.
+//
+// The future is resolved with the same results and errors as AddOrder. Requests enqueued by
+// successive calls are sent in the order of the calls.
 //
 // # Inputs
 //
-//   - ctx: Context used for tracing and coordination purpose. The provided context Done channel
-//     will be watched for timeout/cancel signal.
-//   - params: AddOrder request parameters.
+//   - ctx: Context used for tracing and coordination purpose. The future is failed with a
+//     OperationInterruptedError if the context is done before a response is received.
+//   - params: AddOrder request parameters. If no userref is provided, the request tag set with
+//     SetUserReferenceTag (if any) is used as userref.
 //
 // # Return
 //
-// The AddOrderResponse message from the server if any has been received. In case the response
-// has its error message set, an error with the error message will also be returned.
+// A future resolved with the AddOrderResponse message from the server.
+func (client *krakenSpotWebsocketClient) AddOrderAsync(ctx context.Context, params AddOrderRequestParameters) *Future[*messages.AddOrderResponse] {
+	return sendAsync(&client.asyncSender, func() *Future[*messages.AddOrderResponse] {
+		return client.sendAddOrder(ctx, params)
+	})
+}
+
+// # Description
 //
-// An error is returned when:
+// Send an AddOrder request and get a future resolved once a response has been received from the
+// server. The call blocks until the request has been sent: the trading engine status is
+// consulted, the rate limiter is waited for, the websocket token is fetched and the request is
+// written. No goroutine is used to wait for the response: the future is resolved by the client
+// when it processes the response. The future is failed right away if the request cannot be sent.
 //
-//   - The client failed to send the request (no specific error type).
-//   - A timeout has occured before the request could be sent (no specific error type)
-//   - An error message is received from the server (OperationError).
-//   - A timeout or network failure occurs after sending the request to the server, while
-//     waiting for the server response. In this case, a OperationInterruptedError is returned.
-func (client *krakenSpotWebsocketClient) AddOrder(ctx context.Context, params AddOrderRequestParameters) (*messages.AddOrderResponse, error) {
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. The future is failed with a
+//     OperationInterruptedError if the context is done before a response is received.
+//   - params: AddOrder request parameters. If no userref is provided, the request tag set with
+//     SetUserReferenceTag (if any) is used as userref.
+//
+// # Return
+//
+// A future resolved with the AddOrderResponse message from the server.
+func (client *krakenSpotWebsocketClient) sendAddOrder(ctx context.Context, params AddOrderRequestParameters) *Future[*messages.AddOrderResponse] {
+	// Use the request tag as userref if none is provided
+	if params.UserReference == "" {
+		if userref, ok := requesttag.UserReference(ctx, client.userReferenceTag); ok {
+			params.UserReference = strconv.FormatInt(userref, 10)
+		}
+	}
 	// Tracing: Start span
 	ctx, span := client.tracer.Start(ctx, "add_order", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
 		attribute.String("order_type", params.OrderType),
@@ -1460,8 +1998,11 @@ func (client *krakenSpotWebsocketClient) AddOrder(ctx context.Context, params Ad
 		attribute.String("price", params.Price),
 		attribute.String("price2", params.Price2),
 		attribute.String("volume", params.Volume),
+		attribute.String("displayvol", params.DisplayVolume),
+		attribute.String("trigger", params.Trigger),
 		attribute.Int("leverage", params.Leverage),
 		attribute.Bool("reduce_only", params.ReduceOnly),
+		attribute.String("stptype", string(params.StpType)),
 		attribute.String("oflags", params.OFlags),
 		attribute.String("starttm", params.StartTimestamp),
 		attribute.String("expiretm", params.ExpireTimestamp),
@@ -1473,17 +2014,32 @@ func (client *krakenSpotWebsocketClient) AddOrder(ctx context.Context, params Ad
 		attribute.String("close_price2", params.ClosePrice2),
 		attribute.String("time_in_force", params.TimeInForce),
 	))
-	defer span.End()
+	// Validate the self trade prevention flag and the deadline
+	if err := validateAddOrderRequestParameters(params, client.clock.Now()); err != nil {
+		// Trace and return error
+		return failedRequest[*messages.AddOrderResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("add order failed: %w", err)))
+	}
+	// Consult the trading engine status
+	err := client.consultModeGate(ctx, span, messages.EventTypeAddOrder, params.OrderType, params.OFlags)
+	if err != nil {
+		// Trace and return error
+		return failedRequest[*messages.AddOrderResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("add order failed: %w", err)))
+	}
+	// Wait for the order management commands rate limiter
+	err = client.waitCommandRateLimit(ctx, span, "add_order")
+	if err != nil {
+		// Trace and return error
+		return failedRequest[*messages.AddOrderResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("add order failed: %w", err)))
+	}
 	client.logger.Println("sending add order request to the server", params.Pair, params.OrderType, params.Type)
 	// Get websocket token
 	token, err := client.getWebsocketToken(ctx)
 	if err != nil {
 		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("add order failed: %w", err))
+		return failedRequest[*messages.AddOrderResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("add order failed: %w", err)))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
-	respChan := make(chan *messages.AddOrderResponse, 1)
+	// Create the future used to get the response
+	result := NewFuture[*messages.AddOrderResponse]()
 	// Format request
 	req := &messages.AddOrderRequest{
 		Event:           string(messages.EventTypeAddOrder),
@@ -1495,52 +2051,50 @@ func (client *krakenSpotWebsocketClient) AddOrder(ctx context.Context, params Ad
 		Price:           params.Price,
 		Price2:          params.Price2,
 		Volume:          params.Volume,
+		DisplayVolume:   params.DisplayVolume,
+		Trigger:         params.Trigger,
 		Leverage:        strconv.FormatInt(int64(params.Leverage), 10),
 		ReduceOnly:      params.ReduceOnly,
+		StpType:         string(params.StpType),
 		OFlags:          params.OFlags,
 		StartTimestamp:  params.StartTimestamp,
 		ExpireTimestamp: params.ExpireTimestamp,
 		Deadline:        params.Deadline,
 		UserReference:   params.UserReference,
-		Validate:        strconv.FormatBool(params.Validate),
+		ValidateOnly:    strconv.FormatBool(params.Validate),
 		CloseOrderType:  params.CloseOrderType,
 		ClosePrice:      params.ClosePrice,
 		ClosePrice2:     params.ClosePrice2,
 		TimeInForce:     params.TimeInForce,
 	}
-	payload, err := json.Marshal(req)
+	payload, err := client.codec.Marshal(req)
 	if err != nil {
 		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("add order failed: %w", err))
+		return failedRequest[*messages.AddOrderResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("add order failed: %w", err)))
 	}
-	// Add pending addOrder request
+	// Add pending request and write the message to the server. The pending requests are locked
+	// until the message has been written so the response cannot be processed before.
 	client.pendingAddOrderMu.Lock()
 	client.requests.pendingAddOrderRequests[req.RequestId] = &pendingAddOrderRequest{
-		resp: respChan,
-		err:  errChan,
-	}
-	// Defer pending request cleanup
-	defer delete(client.requests.pendingAddOrderRequests, req.RequestId)
-	// Defer pending request map unlock in a sync.Once
-	unlock := sync.OnceFunc(client.pendingAddOrderMu.Unlock)
-	defer unlock()
-	// Write message to the server
-	err = client.conn.Write(ctx, wsadapters.Text, payload)
+		session: client.sessions.current(),
+		span:    trace.SpanContextFromContext(ctx),
+		tags:    requesttag.Attributes(ctx),
+		result:  result,
+	}
+	err = client.write(ctx, payload)
 	if err != nil {
-		// Trace error and exit
-		return nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("add order failed: %w", err))
+		// Discard the pending request, trace and return error
+		delete(client.requests.pendingAddOrderRequests, req.RequestId)
+		client.pendingAddOrderMu.Unlock()
+		return failedRequest[*messages.AddOrderResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("add order failed: %w", err)))
 	}
-	// Wait for response to be published on channels or timeout
-	unlock() // Unlock pending request map so another go routine can fulfil it
+	client.pendingAddOrderMu.Unlock()
 	client.logger.Println("waiting for a response (addOrderStatus) from the server")
-	select {
-	case <-ctx.Done():
-		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "add_order", Root: fmt.Errorf("add order failed: %w", ctx.Err())})
-	case err := <-errChan:
-		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "add_order", Root: fmt.Errorf("add order failed: %w", err)})
-	case resp := <-respChan:
+	return awaitPendingRequest(ctx, client, span, "add_order", result, func() {
+		client.pendingAddOrderMu.Lock()
+		delete(client.requests.pendingAddOrderRequests, req.RequestId)
+		client.pendingAddOrderMu.Unlock()
+	}, func(resp *messages.AddOrderResponse) (*messages.AddOrderResponse, error) {
 		// Tracing: Add an event for the response
 		span.AddEvent("add_order_response", trace.WithAttributes(
 			attribute.String("status", resp.Status),
@@ -1556,33 +2110,85 @@ func (client *krakenSpotWebsocketClient) AddOrder(ctx context.Context, params Ad
 		span.SetStatus(codes.Ok, codes.Ok.String())
 		client.logger.Println("addOrder has succeeded", resp.TxId)
 		return resp, nil
-	}
+	})
 }
 
 // # Description
 //
-// Edit an existing order and wait until a EditOrderResponse response is received from the
-// server or until an error or a timeout occurs.
+// Add a new order and wait until a AddOrderResponse response is received from the server or
+// until an error or a timeout occurs.
 //
 // # Inputs
 //
 //   - ctx: Context used for tracing and coordination purpose. The provided context Done channel
 //     will be watched for timeout/cancel signal.
-//   - params: EditOrder request parameters.
+//   - params: AddOrder request parameters. If no userref is provided, the request tag set with
+//     SetUserReferenceTag (if any) is used as userref.
 //
 // # Return
 //
-// The EditOrderResponse message from the server if any has been received. In case the response
+// The AddOrderResponse message from the server if any has been received. In case the response
 // has its error message set, an error with the error message will also be returned.
 //
 // An error is returned when:
 //
+//   - The command is not allowed by the trading engine status (TradingModeError). Cf. SetModeGatePolicy.
+//   - The command exceeds the client side rate limit (RateLimitedError).
 //   - The client failed to send the request (no specific error type).
 //   - A timeout has occured before the request could be sent (no specific error type)
 //   - An error message is received from the server (OperationError).
 //   - A timeout or network failure occurs after sending the request to the server, while
 //     waiting for the server response. In this case, a OperationInterruptedError is returned.
-func (client *krakenSpotWebsocketClient) EditOrder(ctx context.Context, params EditOrderRequestParameters) (*messages.EditOrderResponse, error) {
+func (client *krakenSpotWebsocketClient) AddOrder(ctx context.Context, params AddOrderRequestParameters) (*messages.AddOrderResponse, error) {
+	future := client.sendAddOrder(ctx, params)
+	<-future.Done()
+	return future.get()
+}
+
+// # Description
+//
+// Asynchronous variant of EditOrder: the method returns a future right away, without blocking.
+// The request is enqueued: the trading engine status is consulted, the rate limiter is waited for
+// and the request is sent by a single goroutine shared by the asynchronous order management
+// methods (AddOrderAsync, EditOrderAsync and CancelOrderAsync). The future is resolved once a response has been received from the server.
+// Use Await, Poll or Done to get the results.
+//
+// The future is resolved with the same results and errors as EditOrder. Requests enqueued by
+// successive calls are sent in the order of the calls.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. The future is failed with a
+//     OperationInterruptedError if the context is done before a response is received.
+//   - params: EditOrder request parameters.
+//
+// # Return
+//
+// A future resolved with the EditOrderResponse message from the server.
+func (client *krakenSpotWebsocketClient) EditOrderAsync(ctx context.Context, params EditOrderRequestParameters) *Future[*messages.EditOrderResponse] {
+	return sendAsync(&client.asyncSender, func() *Future[*messages.EditOrderResponse] {
+		return client.sendEditOrder(ctx, params)
+	})
+}
+
+// # Description
+//
+// Send an EditOrder request and get a future resolved once a response has been received from the
+// server. The call blocks until the request has been sent: the trading engine status is
+// consulted, the rate limiter is waited for, the websocket token is fetched and the request is
+// written. No goroutine is used to wait for the response: the future is resolved by the client
+// when it processes the response. The future is failed right away if the request cannot be sent.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. The future is failed with a
+//     OperationInterruptedError if the context is done before a response is received.
+//   - params: EditOrder request parameters.
+//
+// # Return
+//
+// A future resolved with the EditOrderResponse message from the server.
+func (client *krakenSpotWebsocketClient) sendEditOrder(ctx context.Context, params EditOrderRequestParameters) *Future[*messages.EditOrderResponse] {
 	// Tracing: Start span
 	ctx, span := client.tracer.Start(ctx, "edit_order", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
 		attribute.String("id", params.Id),
@@ -1594,17 +2200,27 @@ func (client *krakenSpotWebsocketClient) EditOrder(ctx context.Context, params E
 		attribute.String("new_userref", params.NewUserReference),
 		attribute.Bool("validate", params.Validate),
 	))
-	defer span.End()
+	// Consult the trading engine status
+	err := client.consultModeGate(ctx, span, messages.EventTypeEditOrder, "", params.OFlags)
+	if err != nil {
+		// Trace and return error
+		return failedRequest[*messages.EditOrderResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("edit order failed: %w", err)))
+	}
+	// Wait for the order management commands rate limiter
+	err = client.waitCommandRateLimit(ctx, span, "edit_order")
+	if err != nil {
+		// Trace and return error
+		return failedRequest[*messages.EditOrderResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("edit order failed: %w", err)))
+	}
 	client.logger.Println("sending edit order request to the server", params.Id)
 	// Get websocket token
 	token, err := client.getWebsocketToken(ctx)
 	if err != nil {
 		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("edit order failed: %w", err))
+		return failedRequest[*messages.EditOrderResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("edit order failed: %w", err)))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
-	respChan := make(chan *messages.EditOrderResponse, 1)
+	// Create the future used to get the response
+	result := NewFuture[*messages.EditOrderResponse]()
 	// Format request
 	req := &messages.EditOrderRequest{
 		Event:            string(messages.EventTypeEditOrder),
@@ -1615,42 +2231,37 @@ func (client *krakenSpotWebsocketClient) EditOrder(ctx context.Context, params E
 		Price2:           params.Price2,
 		Volume:           params.Volume,
 		OFlags:           params.OFlags,
-		Validate:         strconv.FormatBool(params.Validate),
+		ValidateOnly:     strconv.FormatBool(params.Validate),
 		NewUserReference: params.NewUserReference,
 	}
-	payload, err := json.Marshal(req)
+	payload, err := client.codec.Marshal(req)
 	if err != nil {
 		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("edit order failed: %w", err))
+		return failedRequest[*messages.EditOrderResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("edit order failed: %w", err)))
 	}
-	// Add pending editOrder request
+	// Add pending request and write the message to the server. The pending requests are locked
+	// until the message has been written so the response cannot be processed before.
 	client.pendingEditOrderMu.Lock()
 	client.requests.pendingEditOrderRequests[req.RequestId] = &pendingEditOrderRequest{
-		resp: respChan,
-		err:  errChan,
-	}
-	// Defer map clean
-	defer delete(client.requests.pendingEditOrderRequests, req.RequestId)
-	// Defer unlock in a sync.Once
-	unlock := sync.OnceFunc(client.pendingEditOrderMu.Unlock)
-	defer unlock()
-	// Write message to the server
-	err = client.conn.Write(ctx, wsadapters.Text, payload)
+		session: client.sessions.current(),
+		span:    trace.SpanContextFromContext(ctx),
+		tags:    requesttag.Attributes(ctx),
+		result:  result,
+	}
+	err = client.write(ctx, payload)
 	if err != nil {
-		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("edit order failed: %w", err))
+		// Discard the pending request, trace and return error
+		delete(client.requests.pendingEditOrderRequests, req.RequestId)
+		client.pendingEditOrderMu.Unlock()
+		return failedRequest[*messages.EditOrderResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("edit order failed: %w", err)))
 	}
-	// Wait for response to be published on channels or timeout
-	unlock() // Unlock so another goroutine can complete the request
+	client.pendingEditOrderMu.Unlock()
 	client.logger.Println("waiting for a response (editOrderStatus) from the server")
-	select {
-	case <-ctx.Done():
-		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "edit_order", Root: fmt.Errorf("edit order failed: %w", ctx.Err())})
-	case err := <-errChan:
-		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "edit_order", Root: fmt.Errorf("edit order failed: %w", err)})
-	case resp := <-respChan:
+	return awaitPendingRequest(ctx, client, span, "edit_order", result, func() {
+		client.pendingEditOrderMu.Lock()
+		delete(client.requests.pendingEditOrderRequests, req.RequestId)
+		client.pendingEditOrderMu.Unlock()
+	}, func(resp *messages.EditOrderResponse) (*messages.EditOrderResponse, error) {
 		// Tracing: Add an event for the response
 		span.AddEvent("edit_order_response", trace.WithAttributes(
 			attribute.String("status", resp.Status),
@@ -1668,48 +2279,109 @@ func (client *krakenSpotWebsocketClient) EditOrder(ctx context.Context, params E
 		span.SetStatus(codes.Ok, codes.Ok.String())
 		client.logger.Println("editOrder has succeeded", resp.TxId)
 		return resp, nil
-	}
+	})
 }
 
 // # Description
 //
-// Cancel one or several existing orders and wait until a CancelOrderResponse response is
-// received from the server or until an error or a timeout occurs.
+// Edit an existing order and wait until a EditOrderResponse response is received from the
+// server or until an error or a timeout occurs.
 //
 // # Inputs
 //
 //   - ctx: Context used for tracing and coordination purpose. The provided context Done channel
 //     will be watched for timeout/cancel signal.
-//   - params: CancelOrder request parameters.
+//   - params: EditOrder request parameters.
 //
 // # Return
 //
-// The CancelOrderResponse message from the server if any has been received. In case the response
+// The EditOrderResponse message from the server if any has been received. In case the response
 // has its error message set, an error with the error message will also be returned.
 //
-// An error is returned when:
+// An error is returned when:
+//
+//   - The command is not allowed by the trading engine status (TradingModeError). Cf. SetModeGatePolicy.
+//   - The command exceeds the client side rate limit (RateLimitedError).
+//   - The client failed to send the request (no specific error type).
+//   - A timeout has occured before the request could be sent (no specific error type)
+//   - An error message is received from the server (OperationError).
+//   - A timeout or network failure occurs after sending the request to the server, while
+//     waiting for the server response. In this case, a OperationInterruptedError is returned.
+func (client *krakenSpotWebsocketClient) EditOrder(ctx context.Context, params EditOrderRequestParameters) (*messages.EditOrderResponse, error) {
+	future := client.sendEditOrder(ctx, params)
+	<-future.Done()
+	return future.get()
+}
+
+// # Description
+//
+// Asynchronous variant of CancelOrder: the method returns a future right away, without blocking.
+// The request is enqueued: the trading engine status is consulted, the rate limiter is waited for
+// and the request is sent by a single goroutine shared by the asynchronous order management
+// methods (AddOrderAsync, EditOrderAsync and CancelOrderAsync). The future is resolved once a response has been received from the server.
+// Use Await, Poll or Done to get the results.
+//
+// The future is resolved with the same results and errors as CancelOrder. Requests enqueued by
+// successive calls are sent in the order of the calls.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. The future is failed with a
+//     OperationInterruptedError if the context is done before a response is received.
+//   - params: CancelOrder request parameters.
+//
+// # Return
+//
+// A future resolved with the CancelOrderResponse message from the server.
+func (client *krakenSpotWebsocketClient) CancelOrderAsync(ctx context.Context, params CancelOrderRequestParameters) *Future[*messages.CancelOrderResponse] {
+	return sendAsync(&client.asyncSender, func() *Future[*messages.CancelOrderResponse] {
+		return client.sendCancelOrder(ctx, params)
+	})
+}
+
+// # Description
+//
+// Send a CancelOrder request and get a future resolved once a response has been received from the
+// server. The call blocks until the request has been sent: the trading engine status is
+// consulted, the rate limiter is waited for, the websocket token is fetched and the request is
+// written. No goroutine is used to wait for the response: the future is resolved by the client
+// when it processes the response. The future is failed right away if the request cannot be sent.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. The future is failed with a
+//     OperationInterruptedError if the context is done before a response is received.
+//   - params: CancelOrder request parameters.
+//
+// # Return
 //
-//   - The client failed to send the request (no specific error type).
-//   - A timeout has occured before the request could be sent (no specific error type)
-//   - An error message is received from the server (OperationError).
-//   - A timeout or network failure occurs after sending the request to the server, while
-//     waiting for the server response. In this case, a OperationInterruptedError is returned.
-func (client *krakenSpotWebsocketClient) CancelOrder(ctx context.Context, params CancelOrderRequestParameters) (*messages.CancelOrderResponse, error) {
+// A future resolved with the CancelOrderResponse message from the server.
+func (client *krakenSpotWebsocketClient) sendCancelOrder(ctx context.Context, params CancelOrderRequestParameters) *Future[*messages.CancelOrderResponse] {
 	// Tracing: Start span
 	ctx, span := client.tracer.Start(ctx, "cancel_order", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
 		attribute.StringSlice("id", params.TxId),
 	))
-	defer span.End()
+	// Consult the trading engine status
+	err := client.consultModeGate(ctx, span, messages.EventTypeCancelOrder, "", "")
+	if err != nil {
+		// Trace and return error
+		return failedRequest[*messages.CancelOrderResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel order failed: %w", err)))
+	}
+	// Wait for the order management commands rate limiter
+	err = client.waitCommandRateLimit(ctx, span, "cancel_order")
+	if err != nil {
+		// Trace and return error
+		return failedRequest[*messages.CancelOrderResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel order failed: %w", err)))
+	}
 	client.logger.Println("sending cancel order request to the server", params.TxId)
 	// Get websocket token
 	token, err := client.getWebsocketToken(ctx)
 	if err != nil {
 		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel order failed: %w", err))
+		return failedRequest[*messages.CancelOrderResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel order failed: %w", err)))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
-	respChan := make(chan *messages.CancelOrderResponse, 1)
+	// Create the future used to get the response
+	result := NewFuture[*messages.CancelOrderResponse]()
 	// Format request
 	req := &messages.CancelOrderRequest{
 		Event:     string(messages.EventTypeCancelOrder),
@@ -1717,39 +2389,34 @@ func (client *krakenSpotWebsocketClient) CancelOrder(ctx context.Context, params
 		RequestId: client.ngen.GenerateNonce(),
 		TxId:      params.TxId,
 	}
-	payload, err := json.Marshal(req)
+	payload, err := client.codec.Marshal(req)
 	if err != nil {
 		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel order failed: %w", err))
+		return failedRequest[*messages.CancelOrderResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel order failed: %w", err)))
 	}
-	// Add pending cancelOrder request
+	// Add pending request and write the message to the server. The pending requests are locked
+	// until the message has been written so the response cannot be processed before.
 	client.pendingCancelOrderMu.Lock()
 	client.requests.pendingCancelOrderRequests[req.RequestId] = &pendingCancelOrderRequest{
-		resp: respChan,
-		err:  errChan,
-	}
-	// Defer map clean
-	defer delete(client.requests.pendingCancelOrderRequests, req.RequestId)
-	// Defer unlock in a sync.Once
-	unlock := sync.OnceFunc(client.pendingCancelOrderMu.Unlock)
-	defer unlock()
-	// Write message to the server
-	err = client.conn.Write(ctx, wsadapters.Text, payload)
+		session: client.sessions.current(),
+		span:    trace.SpanContextFromContext(ctx),
+		tags:    requesttag.Attributes(ctx),
+		result:  result,
+	}
+	err = client.write(ctx, payload)
 	if err != nil {
-		// Discard pending request, trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel order failed: %w", err))
+		// Discard the pending request, trace and return error
+		delete(client.requests.pendingCancelOrderRequests, req.RequestId)
+		client.pendingCancelOrderMu.Unlock()
+		return failedRequest[*messages.CancelOrderResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel order failed: %w", err)))
 	}
-	// Wait for response to be published on channels or timeout
-	unlock() // Unlock so another goroutine can compelte the request
+	client.pendingCancelOrderMu.Unlock()
 	client.logger.Println("waiting for a response (cancelOrderStatus) from the server")
-	select {
-	case <-ctx.Done():
-		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "cancel_order", Root: fmt.Errorf("cancel order failed: %w", ctx.Err())})
-	case err := <-errChan:
-		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "cancel_order", Root: fmt.Errorf("cancel order failed: %w", err)})
-	case resp := <-respChan:
+	return awaitPendingRequest(ctx, client, span, "cancel_order", result, func() {
+		client.pendingCancelOrderMu.Lock()
+		delete(client.requests.pendingCancelOrderRequests, req.RequestId)
+		client.pendingCancelOrderMu.Unlock()
+	}, func(resp *messages.CancelOrderResponse) (*messages.CancelOrderResponse, error) {
 		// Tracing: Add an event for the response
 		span.AddEvent("cancel_order_response", trace.WithAttributes(
 			attribute.String("status", resp.Status),
@@ -1764,84 +2431,116 @@ func (client *krakenSpotWebsocketClient) CancelOrder(ctx context.Context, params
 		span.SetStatus(codes.Ok, codes.Ok.String())
 		client.logger.Println("cancelOrder has succeeded")
 		return resp, nil
-	}
+	})
 }
 
 // # Description
 //
-// Cancel all orders and wait until a CancelAllOrdersResponse response is received from the
-// server or until an error or a timeout occurs.
+// Cancel one or several existing orders and wait until a CancelOrderResponse response is
+// received from the server or until an error or a timeout occurs.
 //
 // # Inputs
 //
 //   - ctx: Context used for tracing and coordination purpose. The provided context Done channel
 //     will be watched for timeout/cancel signal.
+//   - params: CancelOrder request parameters.
 //
 // # Return
 //
-// The CancelAllOrdersResponse message from the server if any has been received. In case the response
+// The CancelOrderResponse message from the server if any has been received. In case the response
 // has its error message set, an error with the error message will also be returned.
 //
 // An error is returned when:
 //
+//   - The command is not allowed by the trading engine status (TradingModeError). Cf. SetModeGatePolicy.
+//   - The command exceeds the client side rate limit (RateLimitedError).
 //   - The client failed to send the request (no specific error type).
 //   - A timeout has occured before the request could be sent (no specific error type)
 //   - An error message is received from the server (OperationError).
 //   - A timeout or network failure occurs after sending the request to the server, while
 //     waiting for the server response. In this case, a OperationInterruptedError is returned.
-func (client *krakenSpotWebsocketClient) CancellAllOrders(ctx context.Context) (*messages.CancelAllOrdersResponse, error) {
+func (client *krakenSpotWebsocketClient) CancelOrder(ctx context.Context, params CancelOrderRequestParameters) (*messages.CancelOrderResponse, error) {
+	future := client.sendCancelOrder(ctx, params)
+	<-future.Done()
+	return future.get()
+}
+
+// # Description
+//
+// Asynchronous variant of CancellAllOrders: the request is sent and the method returns a future which
+// is resolved once a response has been received from the server, without waiting for it. No
+// goroutine is used to wait for the response: the future is resolved by the client when it
+// processes the response. Use Await, Poll or Done to get the results.
+//
+// The future is resolved with the same results and errors as CancellAllOrders. It is failed right away
+// if the request cannot be sent.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. The future is failed with a
+//     OperationInterruptedError if the context is done before a response is received.
+//
+// # Return
+//
+// A future resolved with the CancelAllOrdersResponse message from the server.
+func (client *krakenSpotWebsocketClient) CancellAllOrdersAsync(ctx context.Context) *Future[*messages.CancelAllOrdersResponse] {
 	// Tracing: Start span
 	ctx, span := client.tracer.Start(ctx, "cancel_all_orders", trace.WithSpanKind(trace.SpanKindClient))
-	defer span.End()
+	// Consult the trading engine status
+	err := client.consultModeGate(ctx, span, messages.EventTypeCancelAllOrders, "", "")
+	if err != nil {
+		// Trace and return error
+		return failedRequest[*messages.CancelAllOrdersResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all orders failed: %w", err)))
+	}
+	// Wait for the order management commands rate limiter
+	err = client.waitCommandRateLimit(ctx, span, "cancel_all_orders")
+	if err != nil {
+		// Trace and return error
+		return failedRequest[*messages.CancelAllOrdersResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all orders failed: %w", err)))
+	}
 	client.logger.Println("sending cancel all orders request to the server")
 	// Get websocket token
 	token, err := client.getWebsocketToken(ctx)
 	if err != nil {
 		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all orders failed: %w", err))
+		return failedRequest[*messages.CancelAllOrdersResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all orders failed: %w", err)))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
-	respChan := make(chan *messages.CancelAllOrdersResponse, 1)
+	// Create the future used to get the response
+	result := NewFuture[*messages.CancelAllOrdersResponse]()
 	// Format request
 	req := &messages.CancelAllOrdersRequest{
 		Event:     string(messages.EventTypeCancelAllOrders),
 		Token:     token,
 		RequestId: client.ngen.GenerateNonce(),
 	}
-	payload, err := json.Marshal(req)
+	payload, err := client.codec.Marshal(req)
 	if err != nil {
 		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all orders failed: %w", err))
+		return failedRequest[*messages.CancelAllOrdersResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all orders failed: %w", err)))
 	}
-	// Add pending cancelAllOrders request
+	// Add pending request and write the message to the server. The pending requests are locked
+	// until the message has been written so the response cannot be processed before.
 	client.pendingCancelAllOrdersMu.Lock()
 	client.requests.pendingCancelAllOrdersRequests[req.RequestId] = &pendingCancelAllOrdersRequest{
-		resp: respChan,
-		err:  errChan,
-	}
-	// Defer map clean
-	defer delete(client.requests.pendingCancelAllOrdersRequests, req.RequestId)
-	// Defer unlock in a sync.Once
-	unlock := sync.OnceFunc(client.pendingCancelAllOrdersMu.Unlock)
-	defer unlock()
-	// Write message to the server
-	err = client.conn.Write(ctx, wsadapters.Text, payload)
+		session: client.sessions.current(),
+		span:    trace.SpanContextFromContext(ctx),
+		tags:    requesttag.Attributes(ctx),
+		result:  result,
+	}
+	err = client.write(ctx, payload)
 	if err != nil {
-		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all orders failed: %w", err))
+		// Discard the pending request, trace and return error
+		delete(client.requests.pendingCancelAllOrdersRequests, req.RequestId)
+		client.pendingCancelAllOrdersMu.Unlock()
+		return failedRequest[*messages.CancelAllOrdersResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all orders failed: %w", err)))
 	}
-	// Wait for response to be published on channels or timeout
-	unlock()
+	client.pendingCancelAllOrdersMu.Unlock()
 	client.logger.Println("waiting for a response (cancelAllOrdersStatus) from the server")
-	select {
-	case <-ctx.Done():
-		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "cancel_all_orders", Root: fmt.Errorf("cancel all orders failed: %w", ctx.Err())})
-	case err := <-errChan:
-		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "cancel_all_orders", Root: fmt.Errorf("cancel all orders failed: %w", err)})
-	case resp := <-respChan:
+	return awaitPendingRequest(ctx, client, span, "cancel_all_orders", result, func() {
+		client.pendingCancelAllOrdersMu.Lock()
+		delete(client.requests.pendingCancelAllOrdersRequests, req.RequestId)
+		client.pendingCancelAllOrdersMu.Unlock()
+	}, func(resp *messages.CancelAllOrdersResponse) (*messages.CancelAllOrdersResponse, error) {
 		// Tracing: Add an event for the response
 		span.AddEvent("cancel_all_orders_response", trace.WithAttributes(
 			attribute.String("status", resp.Status),
@@ -1856,48 +2555,84 @@ func (client *krakenSpotWebsocketClient) CancellAllOrders(ctx context.Context) (
 		client.logger.Println("cancel all orders has succeeded")
 		span.SetStatus(codes.Ok, codes.Ok.String())
 		return resp, nil
-	}
+	})
 }
 
 // # Description
 //
-// Set, extend or unset a timer which cancels all orders when expiring and wait until a
-// response is received from the server or until an error or a timeout occurs.
+// Cancel all orders and wait until a CancelAllOrdersResponse response is received from the
+// server or until an error or a timeout occurs.
 //
 // # Inputs
 //
 //   - ctx: Context used for tracing and coordination purpose. The provided context Done channel
 //     will be watched for timeout/cancel signal.
-//   - params: CancellAllOrdersAfterX request parameters.
 //
 // # Return
 //
-// The CancelAllOrdersAfterXResponse message from the server if any has been received. In case
-// the response has its error message set, an error with the error message is also be returned.
+// The CancelAllOrdersResponse message from the server if any has been received. In case the response
+// has its error message set, an error with the error message will also be returned.
 //
 // An error is returned when:
 //
+//   - The command is not allowed by the trading engine status (TradingModeError). Cf. SetModeGatePolicy.
+//   - The command exceeds the client side rate limit (RateLimitedError).
 //   - The client failed to send the request (no specific error type).
 //   - A timeout has occured before the request could be sent (no specific error type)
 //   - An error message is received from the server (OperationError).
 //   - A timeout or network failure occurs after sending the request to the server, while
 //     waiting for the server response. In this case, a OperationInterruptedError is returned.
-func (client *krakenSpotWebsocketClient) CancellAllOrdersAfterX(ctx context.Context, params CancelAllOrdersAfterXRequestParameters) (*messages.CancelAllOrdersAfterXResponse, error) {
+func (client *krakenSpotWebsocketClient) CancellAllOrders(ctx context.Context) (*messages.CancelAllOrdersResponse, error) {
+	future := client.CancellAllOrdersAsync(ctx)
+	<-future.Done()
+	return future.get()
+}
+
+// # Description
+//
+// Asynchronous variant of CancellAllOrdersAfterX: the request is sent and the method returns a future which
+// is resolved once a response has been received from the server, without waiting for it. No
+// goroutine is used to wait for the response: the future is resolved by the client when it
+// processes the response. Use Await, Poll or Done to get the results.
+//
+// The future is resolved with the same results and errors as CancellAllOrdersAfterX. It is failed right away
+// if the request cannot be sent.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. The future is failed with a
+//     OperationInterruptedError if the context is done before a response is received.
+//   - params: CancelAllOrdersAfterX request parameters.
+//
+// # Return
+//
+// A future resolved with the CancelAllOrdersAfterXResponse message from the server.
+func (client *krakenSpotWebsocketClient) CancellAllOrdersAfterXAsync(ctx context.Context, params CancelAllOrdersAfterXRequestParameters) *Future[*messages.CancelAllOrdersAfterXResponse] {
 	// Tracing: Start span
 	ctx, span := client.tracer.Start(ctx, "cancel_all_orders_after_x", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
 		attribute.Int("timeout", params.Timeout),
 	))
-	defer span.End()
+	// Consult the trading engine status
+	err := client.consultModeGate(ctx, span, messages.EventTypeCancelAllOrdersAfterX, "", "")
+	if err != nil {
+		// Trace and return error
+		return failedRequest[*messages.CancelAllOrdersAfterXResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all orders after x failed: %w", err)))
+	}
+	// Wait for the order management commands rate limiter
+	err = client.waitCommandRateLimit(ctx, span, "cancel_all_orders_after_x")
+	if err != nil {
+		// Trace and return error
+		return failedRequest[*messages.CancelAllOrdersAfterXResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all orders after x failed: %w", err)))
+	}
 	client.logger.Println("sending cancel all orders after x request to the server", params.Timeout)
 	// Get websocket token
 	token, err := client.getWebsocketToken(ctx)
 	if err != nil {
 		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all orders after x failed: %w", err))
+		return failedRequest[*messages.CancelAllOrdersAfterXResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all orders after x failed: %w", err)))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
-	respChan := make(chan *messages.CancelAllOrdersAfterXResponse, 1)
+	// Create the future used to get the response
+	result := NewFuture[*messages.CancelAllOrdersAfterXResponse]()
 	// Format request
 	req := &messages.CancelAllOrdersAfterXRequest{
 		Event:     string(messages.EventTypeCancelAllOrdersAfterX),
@@ -1905,39 +2640,34 @@ func (client *krakenSpotWebsocketClient) CancellAllOrdersAfterX(ctx context.Cont
 		RequestId: client.ngen.GenerateNonce(),
 		Timeout:   params.Timeout,
 	}
-	payload, err := json.Marshal(req)
+	payload, err := client.codec.Marshal(req)
 	if err != nil {
 		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all orders after x failed: %w", err))
+		return failedRequest[*messages.CancelAllOrdersAfterXResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all orders after x failed: %w", err)))
 	}
-	// Add pending cancelAllOrders request
+	// Add pending request and write the message to the server. The pending requests are locked
+	// until the message has been written so the response cannot be processed before.
 	client.pendingCancelAllOrdersAfterXOrderMu.Lock()
 	client.requests.pendingCancelAllOrdersAfterXRequests[req.RequestId] = &pendingCancelAllOrdersAfterXRequest{
-		resp: respChan,
-		err:  errChan,
-	}
-	// Defer map clean
-	defer delete(client.requests.pendingCancelAllOrdersAfterXRequests, req.RequestId)
-	// Defer unlock in a sync.Once
-	unlock := sync.OnceFunc(client.pendingCancelAllOrdersAfterXOrderMu.Unlock)
-	defer unlock()
-	// Write message to the server
-	err = client.conn.Write(ctx, wsadapters.Text, payload)
+		session: client.sessions.current(),
+		span:    trace.SpanContextFromContext(ctx),
+		tags:    requesttag.Attributes(ctx),
+		result:  result,
+	}
+	err = client.write(ctx, payload)
 	if err != nil {
-		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all orders after x failed: %w", err))
+		// Discard the pending request, trace and return error
+		delete(client.requests.pendingCancelAllOrdersAfterXRequests, req.RequestId)
+		client.pendingCancelAllOrdersAfterXOrderMu.Unlock()
+		return failedRequest[*messages.CancelAllOrdersAfterXResponse](span, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("cancel all orders after x failed: %w", err)))
 	}
-	// Wait for response to be published on channels or timeout
-	unlock()
+	client.pendingCancelAllOrdersAfterXOrderMu.Unlock()
 	client.logger.Println("waiting for a response (cancelAllOrdersAfterXStatus) from the server")
-	select {
-	case <-ctx.Done():
-		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "cancel_all_orders_after_x", Root: fmt.Errorf("cancel all orders after x failed: %w", ctx.Err())})
-	case err := <-errChan:
-		// Trace and return error
-		return nil, tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "cancel_all_orders_after_x", Root: fmt.Errorf("cancel all orders after x failed: %w", err)})
-	case resp := <-respChan:
+	return awaitPendingRequest(ctx, client, span, "cancel_all_orders_after_x", result, func() {
+		client.pendingCancelAllOrdersAfterXOrderMu.Lock()
+		delete(client.requests.pendingCancelAllOrdersAfterXRequests, req.RequestId)
+		client.pendingCancelAllOrdersAfterXOrderMu.Unlock()
+	}, func(resp *messages.CancelAllOrdersAfterXResponse) (*messages.CancelAllOrdersAfterXResponse, error) {
 		// Tracing: Add an event for the response
 		span.AddEvent("cancel_all_orders_after_x", trace.WithAttributes(
 			attribute.String("status", resp.Status),
@@ -1954,7 +2684,38 @@ func (client *krakenSpotWebsocketClient) CancellAllOrdersAfterX(ctx context.Cont
 		client.logger.Println("cancel all orders has succeeded")
 		span.SetStatus(codes.Ok, codes.Ok.String())
 		return resp, nil
-	}
+	})
+}
+
+// # Description
+//
+// Set, extend or unset a timer which cancels all orders when expiring and wait until a
+// response is received from the server or until an error or a timeout occurs.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. The provided context Done channel
+//     will be watched for timeout/cancel signal.
+//   - params: CancellAllOrdersAfterX request parameters.
+//
+// # Return
+//
+// The CancelAllOrdersAfterXResponse message from the server if any has been received. In case
+// the response has its error message set, an error with the error message is also be returned.
+//
+// An error is returned when:
+//
+//   - The command is not allowed by the trading engine status (TradingModeError). Cf. SetModeGatePolicy.
+//   - The command exceeds the client side rate limit (RateLimitedError).
+//   - The client failed to send the request (no specific error type).
+//   - A timeout has occured before the request could be sent (no specific error type)
+//   - An error message is received from the server (OperationError).
+//   - A timeout or network failure occurs after sending the request to the server, while
+//     waiting for the server response. In this case, a OperationInterruptedError is returned.
+func (client *krakenSpotWebsocketClient) CancellAllOrdersAfterX(ctx context.Context, params CancelAllOrdersAfterXRequestParameters) (*messages.CancelAllOrdersAfterXResponse, error) {
+	future := client.CancellAllOrdersAfterXAsync(ctx, params)
+	<-future.Done()
+	return future.get()
 }
 
 // # Description
@@ -2032,7 +2793,8 @@ func (client *krakenSpotWebsocketClient) CancellAllOrdersAfterX(ctx context.Cont
 //   - A connection_interrupted event MUST be published on the channel each time the websocket
 //     connection is closed.
 //
-//   - The provided channel MUST be closed upon unsubscribe or when the websocket client stops.
+//   - The provided channel MUST be closed upon unsubscribe (Cf.
+//     SetKeepChannelsOpenOnUnsubscribe) or when the websocket client stops.
 //
 //   - The websocket client implementation CAN either use blocking writes or discard messages in
 //     case the provided channel is full. It is up to the client implementation to be clear about
@@ -2052,7 +2814,8 @@ func (client *krakenSpotWebsocketClient) SubscribeOwnTrades(ctx context.Context,
 	defer span.End()
 	client.logger.Println("subscribing to own trades channel")
 	// Check if there is already an active subscription
-	client.ownTradesSubMu.Lock() // Lock mutex till subscribe completes - this will block Unsubscribe
+	defer client.persistSubscriptions(ctx) // Runs once the subscription mutex is released
+	client.ownTradesSubMu.Lock()           // Lock mutex till subscribe completes - this will block Unsubscribe
 	defer client.ownTradesSubMu.Unlock()
 	if client.subscriptions.ownTrades != nil {
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe own trades failed because there is already an active subscription"))
@@ -2063,8 +2826,8 @@ func (client *krakenSpotWebsocketClient) SubscribeOwnTrades(ctx context.Context,
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe own trades failed: %w", err))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send subscribe message to server
 	err = client.sendSubscribeRequest(
 		ctx,
@@ -2078,19 +2841,19 @@ func (client *krakenSpotWebsocketClient) SubscribeOwnTrades(ctx context.Context,
 				Token:            token,
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe own trades failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	client.logger.Println("waiting for a subscribe response from the server")
 	select {
 	case <-ctx.Done():
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "subscribe_own_trades", Root: fmt.Errorf("subscribe own trades failed: %w", ctx.Err())})
-	case err := <-errChan:
-		if err != nil {
+	case <-result.Done():
+		if _, err := result.get(); err != nil {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "subscribe_own_trades", Root: fmt.Errorf("subscribe own trades failed: %w", err)})
 		}
@@ -2181,7 +2944,8 @@ func (client *krakenSpotWebsocketClient) SubscribeOwnTrades(ctx context.Context,
 //   - A connection_interrupted event MUST be published on the channel each time the websocket
 //     connection is closed.
 //
-//   - The provided channel MUST be closed upon unsubscribe or when the websocket client stops.
+//   - The provided channel MUST be closed upon unsubscribe (Cf.
+//     SetKeepChannelsOpenOnUnsubscribe) or when the websocket client stops.
 //
 //   - The websocket client implementation CAN either use blocking writes or discard messages in
 //     case the provided channel is full. It is up to the client implementation to be clear about
@@ -2200,7 +2964,8 @@ func (client *krakenSpotWebsocketClient) SubscribeOpenOrders(ctx context.Context
 	defer span.End()
 	client.logger.Println("subscribing to open orders channel")
 	// Check if there is already an active subscription
-	client.openOrdersSubMu.Lock() // Lock mutex till subscribe completes - this will block Unsubscribe
+	defer client.persistSubscriptions(ctx) // Runs once the subscription mutex is released
+	client.openOrdersSubMu.Lock()          // Lock mutex till subscribe completes - this will block Unsubscribe
 	defer client.openOrdersSubMu.Unlock()
 	if client.subscriptions.openOrders != nil {
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe open orders failed because there is already an active subscription"))
@@ -2211,8 +2976,8 @@ func (client *krakenSpotWebsocketClient) SubscribeOpenOrders(ctx context.Context
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe open orders failed: %w", err))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send subscribe message to server
 	err = client.sendSubscribeRequest(
 		ctx,
@@ -2225,19 +2990,19 @@ func (client *krakenSpotWebsocketClient) SubscribeOpenOrders(ctx context.Context
 				Token:       token,
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("subscribe open orders failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	client.logger.Println("waiting for a subscribe response from the server")
 	select {
 	case <-ctx.Done():
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "subscribe_open_orders", Root: fmt.Errorf("subscribe open orders failed: %w", ctx.Err())})
-	case err := <-errChan:
-		if err != nil {
+	case <-result.Done():
+		if _, err := result.get(); err != nil {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "subscribe_open_orders", Root: fmt.Errorf("subscribe open orders failed: %w", err)})
 		}
@@ -2255,8 +3020,8 @@ func (client *krakenSpotWebsocketClient) SubscribeOpenOrders(ctx context.Context
 
 // # Description
 //
-// Unsubscribe from the ownTrades channel. The channel provided on subscribe will bbe closed by
-// the websocket client.
+// Unsubscribe from the ownTrades channel. The channel provided on subscribe will be closed by
+// the websocket client (Cf. SetKeepChannelsOpenOnUnsubscribe).
 //
 // # Inputs
 //
@@ -2273,7 +3038,8 @@ func (client *krakenSpotWebsocketClient) SubscribeOpenOrders(ctx context.Context
 //
 // # Implementation and usage guidelines
 //
-//   - In case of success, the client MUST close the channel used to publish events.
+//   - In case of success, the client MUST close the channel used to publish events (Cf.
+//     SetKeepChannelsOpenOnUnsubscribe).
 //
 //   - The client MUST use the right error type as described in the "Return" section.
 func (client *krakenSpotWebsocketClient) UnsubscribeOwnTrades(ctx context.Context) error {
@@ -2282,7 +3048,8 @@ func (client *krakenSpotWebsocketClient) UnsubscribeOwnTrades(ctx context.Contex
 	defer span.End()
 	client.logger.Println("unsubscribing from own trades channel")
 	// Check if there is already an active subscription
-	client.ownTradesSubMu.Lock() // Lock mutex till subscribe completes - this will block Subscribe
+	defer client.persistSubscriptions(ctx) // Runs once the subscription mutex is released
+	client.ownTradesSubMu.Lock()           // Lock mutex till subscribe completes - this will block Subscribe
 	defer client.ownTradesSubMu.Unlock()
 	if client.subscriptions.ownTrades == nil {
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe own trades failed because there is no active subscription"))
@@ -2293,8 +3060,8 @@ func (client *krakenSpotWebsocketClient) UnsubscribeOwnTrades(ctx context.Contex
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe own trades failed: %w", err))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send unsubscribe message to server
 	err = client.sendUnsubscribeRequest(
 		ctx,
@@ -2306,23 +3073,24 @@ func (client *krakenSpotWebsocketClient) UnsubscribeOwnTrades(ctx context.Contex
 				Token: token,
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe own trades failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	client.logger.Println("waiting for a unsubscribe response from the server")
 	select {
 	case <-ctx.Done():
 		// Trace and return error - OperationInterruptedError
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "unsubscribe_own_trades", Root: fmt.Errorf("unsubscribe own trades failed: %w", ctx.Err())})
-	case err := <-errChan:
-		if err != nil {
+	case <-result.Done():
+		if _, err := result.get(); err != nil {
 			// Trace and return error - OperationError
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "unsubscribe_own_trades", Root: fmt.Errorf("unsubscribe own trades failed: %w", err)})
 		}
-		// Discard the subscription and exit
+		// Close the publication channel, discard the subscription and exit
+		client.closeOnUnsubscribe(ctx, client.subscriptions.ownTrades.pub, false, string(messages.ChannelOwnTrades))
 		client.logger.Println("unsubscribed from own trades channel")
 		span.SetStatus(codes.Ok, codes.Ok.String())
 		client.subscriptions.ownTrades = nil
@@ -2332,8 +3100,8 @@ func (client *krakenSpotWebsocketClient) UnsubscribeOwnTrades(ctx context.Contex
 
 // # Description
 //
-// Unsubscribe from the openOrders channel. The channel provided on subscribe will bbe closed by
-// the websocket client.
+// Unsubscribe from the openOrders channel. The channel provided on subscribe will be closed by
+// the websocket client (Cf. SetKeepChannelsOpenOnUnsubscribe).
 //
 // # Inputs
 //
@@ -2350,7 +3118,8 @@ func (client *krakenSpotWebsocketClient) UnsubscribeOwnTrades(ctx context.Contex
 //
 // # Implementation and usage guidelines
 //
-//   - In case of success, the client MUST close the channel used to publish events.
+//   - In case of success, the client MUST close the channel used to publish events (Cf.
+//     SetKeepChannelsOpenOnUnsubscribe).
 //
 //   - The client MUST use the right error type as described in the "Return" section.
 func (client *krakenSpotWebsocketClient) UnsubscribeOpenOrders(ctx context.Context) error {
@@ -2359,7 +3128,8 @@ func (client *krakenSpotWebsocketClient) UnsubscribeOpenOrders(ctx context.Conte
 	defer span.End()
 	client.logger.Println("unsubscribing from open orders channel")
 	// Check if there is already an active subscription
-	client.openOrdersSubMu.Lock() // Lock mutex till subscribe completes - this will block Subscribe
+	defer client.persistSubscriptions(ctx) // Runs once the subscription mutex is released
+	client.openOrdersSubMu.Lock()          // Lock mutex till subscribe completes - this will block Subscribe
 	defer client.openOrdersSubMu.Unlock()
 	if client.subscriptions.openOrders == nil {
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe open orders failed because there is no active subscription"))
@@ -2370,8 +3140,8 @@ func (client *krakenSpotWebsocketClient) UnsubscribeOpenOrders(ctx context.Conte
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe open orders failed: %w", err))
 	}
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send unsubscribe message to server
 	err = client.sendUnsubscribeRequest(
 		ctx,
@@ -2383,23 +3153,24 @@ func (client *krakenSpotWebsocketClient) UnsubscribeOpenOrders(ctx context.Conte
 				Token: token,
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("unsubscribe open orders failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	client.logger.Println("waiting for a unsubscribe response from the server")
 	select {
 	case <-ctx.Done():
 		// Trace and return error - OperationInterruptedError
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "unsubscribe_open_orders", Root: fmt.Errorf("unsubscribe open orders failed: %w", ctx.Err())})
-	case err := <-errChan:
-		if err != nil {
+	case <-result.Done():
+		if _, err := result.get(); err != nil {
 			// Trace and return error - OperationError
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "unsubscribe_open_orders", Root: fmt.Errorf("unsubscribe open orders failed: %w", err)})
 		}
-		// Discard the subscription and exit
+		// Close the publication channel, discard the subscription and exit
+		client.closeOnUnsubscribe(ctx, client.subscriptions.openOrders.pub, false, string(messages.ChannelOpenOrders))
 		client.logger.Println("unsubscribed from open orders channel")
 		span.SetStatus(codes.Ok, codes.Ok.String())
 		client.subscriptions.openOrders = nil
@@ -2474,6 +3245,11 @@ func (client *krakenSpotWebsocketClient) OnOpen(
 	client.logger.Println("connection opened with the server - restarting:", restarting)
 	// Store new connection
 	client.conn = conn
+	// Start a new session so responses from the previous connection are ignored
+	client.sessions.open()
+	// Record the extensions negotiated during the handshake (ex: compression)
+	client.recordConnectionOpened(resp)
+	span.SetAttributes(attribute.StringSlice("extensions", client.GetConnectionState().Extensions))
 	// Restore all active subscriptions if restarting
 	if restarting {
 		// Provided context is canceled by the engine after OnOpen exits. Hence, a separate context
@@ -2483,184 +3259,117 @@ func (client *krakenSpotWebsocketClient) OnOpen(
 		carrier := propagation.MapCarrier{}
 		propgator.Inject(ctx, carrier)
 		rootctx := propgator.Extract(context.Background(), carrier)
-		// Retry limit & base wait time
-		base := 2.0
-		limit := 3
 		// Resubscribe to ticker if an active subscription is set
 		client.tickerSubMu.Lock()
 		defer client.tickerSubMu.Unlock()
 		if client.subscriptions.ticker != nil {
-			// Start a goroutine that will perform the resubscribe.
-			// Goroutine will make 3 attempts then exit.
-			client.logger.Println("starting process to resubscribe to ticker channel", client.subscriptions.ticker.pairs)
-			go func(client *krakenSpotWebsocketClient) {
-				ctx, cancel := context.WithTimeout(rootctx, 30*time.Second)
-				defer cancel()
-				for retry := 0; retry < limit; retry++ {
-					err := client.resubscribeTicker(ctx, client.subscriptions.ticker.pairs)
-					if err != nil {
-						// Wait an exponential amount of time before retrying (1, 2 & 4 seconds)
-						eerr := fmt.Errorf("resubscribe ticker attempt number %d failed: %w", retry+1, err)
-						client.logger.Println(eerr.Error())
-						time.Sleep(time.Second * time.Duration(int64(math.Pow(base, float64(retry)))))
-					} else {
-						// Break
-						break
-					}
+			// Start a goroutine that will perform the resubscribe according to the resubscribe policy.
+			tsub := client.subscriptions.ticker
+			client.logger.Println("starting process to resubscribe to ticker channel", tsub.pairs)
+			go client.resubscribeWithPolicy(rootctx, string(messages.ChannelTicker), tsub.pairs, func(ctx context.Context) error {
+				return client.resubscribeTicker(ctx, tsub.pairs)
+			}, &client.tickerSubMu, func() chan event.Event {
+				if client.subscriptions.ticker != nil && client.subscriptions.ticker.pub == tsub.pub {
+					return tsub.pub
 				}
-				client.logger.Println("resubscribe ticker definitly failed")
-			}(client)
+				return nil
+			})
 		}
 		// Resubscribe to ohlcs if an active subscription is set
 		client.ohlcSubMu.Lock()
 		defer client.ohlcSubMu.Unlock()
 		for interval := range client.subscriptions.ohlcs {
 			osub := client.subscriptions.ohlcs[interval]
-			// Start a goroutine that will perform the resubscribe.
-			// Goroutine will make 3 attempts then exit.
+			// Start a goroutine that will perform the resubscribe according to the resubscribe policy.
 			client.logger.Println("starting process to resubscribe to ohlc channel", osub.pairs, osub.interval)
-			go func(client *krakenSpotWebsocketClient) {
-				ctx, cancel := context.WithTimeout(rootctx, 30*time.Second)
-				defer cancel()
-				for retry := 0; retry < limit; retry++ {
-					err := client.resubscribeOHLC(ctx, osub.pairs, osub.interval)
-					if err != nil {
-						// Wait an exponential amount of time before retrying (1, 2 & 4 seconds)
-						eerr := fmt.Errorf("resubscribe ohlc attempt number %d failed: %w", retry+1, err)
-						client.logger.Println(eerr.Error())
-						time.Sleep(time.Second * time.Duration(int64(math.Pow(base, float64(retry)))))
-					} else {
-						// Break
-						break
-					}
+			go client.resubscribeWithPolicy(rootctx, string(messages.ChannelOHLC), osub.pairs, func(ctx context.Context) error {
+				return client.resubscribeOHLC(ctx, osub.pairs, osub.interval)
+			}, &client.ohlcSubMu, func() chan event.Event {
+				if current, ok := client.subscriptions.ohlcs[osub.interval]; ok && current.pub == osub.pub {
+					return osub.pub
 				}
-				client.logger.Println("resubscribe ohlc definitly failed")
-			}(client)
+				return nil
+			})
 		}
 		// Resubscribe to trade if an active subscription is set
 		client.tradeSubMu.Lock()
 		defer client.tradeSubMu.Unlock()
 		if client.subscriptions.trade != nil {
-			// Start a goroutine that will perform the resubscribe.
-			// Goroutine will make 3 attempts then exit.
-			client.logger.Println("starting process to resubscribe to trade channel", client.subscriptions.trade.pairs)
-			go func(client *krakenSpotWebsocketClient) {
-				for retry := 0; retry < limit; retry++ {
-					ctx, cancel := context.WithTimeout(rootctx, 30*time.Second)
-					defer cancel()
-					err := client.resubscribeTrade(ctx, client.subscriptions.trade.pairs)
-					if err != nil {
-						// Wait an exponential amount of time before retrying (1, 2 & 4 seconds)
-						eerr := fmt.Errorf("resubscribe trade attempt number %d failed: %w", retry+1, err)
-						client.logger.Println(eerr.Error())
-						time.Sleep(time.Second * time.Duration(int64(math.Pow(base, float64(retry)))))
-					} else {
-						// Break
-						break
-					}
+			// Start a goroutine that will perform the resubscribe according to the resubscribe policy.
+			tsub := client.subscriptions.trade
+			client.logger.Println("starting process to resubscribe to trade channel", tsub.pairs)
+			go client.resubscribeWithPolicy(rootctx, string(messages.ChannelTrade), tsub.pairs, func(ctx context.Context) error {
+				return client.resubscribeTrade(ctx, tsub.pairs)
+			}, &client.tradeSubMu, func() chan event.Event {
+				if client.subscriptions.trade != nil && client.subscriptions.trade.pub == tsub.pub {
+					return tsub.pub
 				}
-				client.logger.Println("resubscribe trade definitly failed")
-			}(client)
+				return nil
+			})
 		}
 		// Resubscribe to spread if an active subscription is set
 		client.spreadSubMu.Lock()
 		defer client.spreadSubMu.Unlock()
 		if client.subscriptions.spread != nil {
-			// Start a goroutine that will perform the resubscribe.
-			// Goroutine will make 3 attempts then exit.
-			client.logger.Println("starting process to resubscribe to spread channel", client.subscriptions.spread.pairs)
-			go func(client *krakenSpotWebsocketClient) {
-				ctx, cancel := context.WithTimeout(rootctx, 30*time.Second)
-				defer cancel()
-				for retry := 0; retry < limit; retry++ {
-					err := client.resubscribeSpread(ctx, client.subscriptions.spread.pairs)
-					if err != nil {
-						// Wait an exponential amount of time before retrying (1, 2 & 4 seconds)
-						eerr := fmt.Errorf("resubscribe spread attempt number %d failed: %w", retry+1, err)
-						client.logger.Println(eerr.Error())
-						time.Sleep(time.Second * time.Duration(int64(math.Pow(base, float64(retry)))))
-					} else {
-						// Break
-						break
-					}
+			// Start a goroutine that will perform the resubscribe according to the resubscribe policy.
+			ssub := client.subscriptions.spread
+			client.logger.Println("starting process to resubscribe to spread channel", ssub.pairs)
+			go client.resubscribeWithPolicy(rootctx, string(messages.ChannelSpread), ssub.pairs, func(ctx context.Context) error {
+				return client.resubscribeSpread(ctx, ssub.pairs)
+			}, &client.spreadSubMu, func() chan event.Event {
+				if client.subscriptions.spread != nil && client.subscriptions.spread.pub == ssub.pub {
+					return ssub.pub
 				}
-				client.logger.Println("resubscribe spread definitly failed")
-			}(client)
+				return nil
+			})
 		}
-		// Resubscribe to book if an active subscription is set
+		// Resubscribe to books if active subscriptions are set
 		client.bookSubMu.Lock()
 		defer client.bookSubMu.Unlock()
-		if client.subscriptions.book != nil {
-			// Start a goroutine that will perform the resubscribe.
-			// Goroutine will make 3 attempts then exit.
-			client.logger.Println("starting process to resubscribe to book channel", client.subscriptions.book.pairs, client.subscriptions.book.depth)
-			go func(client *krakenSpotWebsocketClient) {
-				for retry := 0; retry < limit; retry++ {
-					ctx, cancel := context.WithTimeout(rootctx, 30*time.Second)
-					defer cancel()
-					err := client.resubscribeBook(ctx, client.subscriptions.book.pairs, client.subscriptions.book.depth)
-					if err != nil {
-						// Wait an exponential amount of time before retrying (1, 2 & 4 seconds)
-						eerr := fmt.Errorf("resubscribe book attempt number %d failed: %w", retry+1, err)
-						client.logger.Println(eerr.Error())
-						time.Sleep(time.Second * time.Duration(int64(math.Pow(base, float64(retry)))))
-					} else {
-						// Break
-						break
-					}
+		for depth := range client.subscriptions.books {
+			bsub := client.subscriptions.books[depth]
+			// Start a goroutine that will perform the resubscribe according to the resubscribe policy.
+			client.logger.Println("starting process to resubscribe to book channel", bsub.pairs, bsub.depth)
+			go client.resubscribeWithPolicy(rootctx, string(messages.ChannelBook), bsub.pairs, func(ctx context.Context) error {
+				return client.resubscribeBook(ctx, bsub.pairs, bsub.depth)
+			}, &client.bookSubMu, func() chan event.Event {
+				if current, ok := client.subscriptions.books[bsub.depth]; ok && current.pub == bsub.pub {
+					return bsub.pub
 				}
-				client.logger.Println("resubscribe book definitly failed")
-			}(client)
+				return nil
+			})
 		}
 		// Resubscribe to own trades if an active subscription is set
 		client.ownTradesSubMu.Lock()
 		defer client.ownTradesSubMu.Unlock()
 		if client.subscriptions.ownTrades != nil {
-			// Start a goroutine that will perform the resubscribe.
-			// Goroutine will make 3 attempts then exit.
+			// Start a goroutine that will perform the resubscribe according to the resubscribe policy.
+			otsub := client.subscriptions.ownTrades
 			client.logger.Println("starting process to resubscribe to own trades channel")
-			go func(client *krakenSpotWebsocketClient) {
-				for retry := 0; retry < limit; retry++ {
-					ctx, cancel := context.WithTimeout(rootctx, 30*time.Second)
-					defer cancel()
-					err := client.resubscribeOwnTrades(ctx, client.subscriptions.ownTrades.snapshot, client.subscriptions.ownTrades.consolidateTaker)
-					if err != nil {
-						// Wait an exponential amount of time before retrying (1, 2 & 4 seconds)
-						eerr := fmt.Errorf("resubscribe own trades attempt number %d failed: %w", retry+1, err)
-						client.logger.Println(eerr.Error())
-						time.Sleep(time.Second * time.Duration(int64(math.Pow(base, float64(retry)))))
-					} else {
-						// Break
-						break
-					}
+			go client.resubscribeWithPolicy(rootctx, string(messages.ChannelOwnTrades), nil, func(ctx context.Context) error {
+				return client.resubscribeOwnTrades(ctx, otsub.snapshot, otsub.consolidateTaker)
+			}, &client.ownTradesSubMu, func() chan event.Event {
+				if client.subscriptions.ownTrades != nil && client.subscriptions.ownTrades.pub == otsub.pub {
+					return otsub.pub
 				}
-				client.logger.Println("resubscribe own trades definitly failed")
-			}(client)
+				return nil
+			})
 		}
 		// Resubscribe to open orders if an active subscription is set
 		client.openOrdersSubMu.Lock()
 		defer client.openOrdersSubMu.Unlock()
 		if client.subscriptions.openOrders != nil {
-			// Start a goroutine that will perform the resubscribe.
-			// Goroutine will make 3 attempts then exit.
+			// Start a goroutine that will perform the resubscribe according to the resubscribe policy.
+			oosub := client.subscriptions.openOrders
 			client.logger.Println("starting process to resubscribe to open orders channel")
-			go func(client *krakenSpotWebsocketClient) {
-				for retry := 0; retry < limit; retry++ {
-					ctx, cancel := context.WithTimeout(rootctx, 30*time.Second)
-					defer cancel()
-					err := client.resubscribeOpenOrders(ctx, client.subscriptions.openOrders.rateCounter)
-					if err != nil {
-						// Wait an exponential amount of time before retrying (1, 2 & 4 seconds)
-						eerr := fmt.Errorf("resubscribe open orders attempt number %d failed: %w", retry+1, err)
-						client.logger.Println(eerr.Error())
-						time.Sleep(time.Second * time.Duration(int64(math.Pow(base, float64(retry)))))
-					} else {
-						// Break
-						break
-					}
+			go client.resubscribeWithPolicy(rootctx, string(messages.ChannelOpenOrders), nil, func(ctx context.Context) error {
+				return client.resubscribeOpenOrders(ctx, oosub.rateCounter)
+			}, &client.openOrdersSubMu, func() chan event.Event {
+				if client.subscriptions.openOrders != nil && client.subscriptions.openOrders.pub == oosub.pub {
+					return oosub.pub
 				}
-				client.logger.Println("resubscribe open orders definitly failed")
-			}(client)
+				return nil
+			})
 		}
 		// Do not wait for goroutines: Engine will start reading messages only after OnOpen completes
 	}
@@ -2707,6 +3416,14 @@ func (client *krakenSpotWebsocketClient) OnMessage(
 	sessionId string,
 	msgType wsadapters.MessageType,
 	msg []byte) {
+	// Pass the frame to the raw message hook before any processing
+	client.hookRawFrame(FrameInbound, sessionId, msg)
+	// Binary messages are processed as text unless configured otherwise
+	binaryViolation := msgType == wsadapters.Binary && client.binaryMessagesAreViolations()
+	// Fast path: dispatch public market data subscribed in raw mode
+	if !binaryViolation && client.dispatchRawMessage(sessionId, msg) {
+		return
+	}
 	// Tracing: Start span
 	ctx, span := client.tracer.Start(ctx, "on_message",
 		trace.WithSpanKind(trace.SpanKindInternal),
@@ -2715,55 +3432,65 @@ func (client *krakenSpotWebsocketClient) OnMessage(
 		))
 	defer span.End()
 	client.logger.Println("message received from the server")
-	// Match the message type - 5 matches are expected
-	matches := messages.MatchMessageTypeRegex.FindStringSubmatch(string(msg))
-	if len(matches) != 5 {
-		// Call OnReadError - Not the expected number of matches
-		err := fmt.Errorf("failed to extract the message type from '%s' - not the expected number of matches %d", string(msg), len(matches))
-		tracing.HandleAndTraLogError(span, client.logger, err)
-		client.OnReadError(ctx, conn, readMutex, restart, exit, err)
+	if binaryViolation {
+		client.handleProtocolViolation(ctx, span, conn, readMutex, restart, exit, sessionId, msgType, msg, fmt.Errorf("unexpected binary message received from the server"))
 		return
 	}
-	// Extract the message type from the matches. The regex will try to find the event type and the pair in case of a public
-	// market event (ticker, spread, ...).
-	//
-	// Index 0 will contain the original message
-	// Index 1 will contain the event type in case the message is a JSON object (usually request/responses)
-	// Index 2 will contain the event type in case the message is a JSON Array (openOrders or ownTrades)
-	// Index 3 will contain the event type in case the message is a JSON Array (public market data)
-	// Index 4 will contain the pair in case the message is a public market data event like a spread.
-	mType := matches[1]
-	if mType == "" {
-		mType = matches[2]
-		if mType == "" {
-			mType = matches[3]
-		}
+	// Extract the message type and the pair (for public market data) from the message
+	mType, pair, err := messages.SniffMessageType(msg)
+	if err != nil {
+		// Protocol violation - Message type could not be extracted
+		err := fmt.Errorf("failed to extract the message type from '%s': %w", string(msg), err)
+		client.handleProtocolViolation(ctx, span, conn, readMutex, restart, exit, sessionId, msgType, msg, err)
+		return
 	}
 	// Depending on the message type.
 	splits := strings.Split(mType, "-")
 	client.logger.Println("received message type: ", splits[0])
+	// Ignore responses received on a previous connection: the related pending requests have been
+	// discarded when the connection was closed and their IDs may collide with new requests.
+	if isResponseMessageType(splits[0]) && !client.sessions.isCurrent(sessionId) {
+		client.flagStaleResponse(span, sessionId, 0)
+		span.SetStatus(codes.Ok, codes.Ok.String())
+		return
+	}
 	switch splits[0] {
 	// General error has been received
 	case string(messages.EventTypeError):
 		client.handleErrorMessage(ctx, conn, readMutex, restart, exit, sessionId, msgType, msg)
 	// Trade
 	case string(messages.ChannelTrade):
-		client.handleTrade(ctx, conn, readMutex, restart, exit, sessionId, msgType, matches[4], msg)
+		client.handleTrade(ctx, conn, readMutex, restart, exit, sessionId, msgType, pair, msg)
 	// Book
 	case string(messages.ChannelBook):
-		client.handleBook(ctx, conn, readMutex, restart, exit, sessionId, msgType, matches[4], msg)
+		// Extract depth
+		if len(splits) > 1 {
+			if depth, err := strconv.ParseInt(splits[1], 10, 64); err == nil {
+				client.handleBook(ctx, conn, readMutex, restart, exit, sessionId, msgType, pair, msg, messages.DepthEnum(depth))
+			} else {
+				err := fmt.Errorf("failed to parse depth for book from '%s'", string(mType))
+				tracing.HandleAndTraLogError(span, client.logger, err)
+				client.OnReadError(ctx, conn, readMutex, restart, exit, err)
+				return
+			}
+		} else {
+			err := fmt.Errorf("failed to parse depth for book from '%s'", string(mType))
+			tracing.HandleAndTraLogError(span, client.logger, err)
+			client.OnReadError(ctx, conn, readMutex, restart, exit, err)
+			return
+		}
 	// Spread
 	case string(messages.ChannelSpread):
-		client.handleSpread(ctx, conn, readMutex, restart, exit, sessionId, msgType, matches[4], msg)
+		client.handleSpread(ctx, conn, readMutex, restart, exit, sessionId, msgType, pair, msg)
 	// Ticker
 	case string(messages.ChannelTicker):
-		client.handleTicker(ctx, conn, readMutex, restart, exit, sessionId, msgType, matches[4], msg)
+		client.handleTicker(ctx, conn, readMutex, restart, exit, sessionId, msgType, pair, msg)
 	// OHLC
 	case string(messages.ChannelOHLC):
 		// Extract interval
-		if len(splits) > 0 {
+		if len(splits) > 1 {
 			if interval, err := strconv.ParseInt(splits[1], 10, 64); err == nil {
-				client.handleOHLC(ctx, conn, readMutex, restart, exit, sessionId, msgType, matches[4], msg, messages.IntervalEnum(interval))
+				client.handleOHLC(ctx, conn, readMutex, restart, exit, sessionId, msgType, pair, msg, messages.IntervalEnum(interval))
 			} else {
 				err := fmt.Errorf("failed to parse interval for ohlc from '%s'", string(mType))
 				tracing.HandleAndTraLogError(span, client.logger, err)
@@ -2810,10 +3537,9 @@ func (client *krakenSpotWebsocketClient) OnMessage(
 	case string(messages.EventTypeHeartbeat):
 		client.handleHeartbeat(ctx, conn, readMutex, restart, exit, sessionId, msgType, msg)
 	default:
-		// Call OnReadError - Unknown message type
+		// Protocol violation - Unknown message type
 		eerr := fmt.Errorf("unkown or unexpected message type (%s) extracted from '%s'", mType, string(msg))
-		tracing.HandleAndTraLogError(span, client.logger, eerr)
-		client.OnReadError(ctx, conn, readMutex, restart, exit, eerr)
+		client.handleProtocolViolation(ctx, span, conn, readMutex, restart, exit, sessionId, msgType, msg, eerr)
 		return
 	}
 	// Set span status to OK and exit
@@ -2918,13 +3644,13 @@ func (client *krakenSpotWebsocketClient) OnClose(
 	defer span.End()
 	defer span.SetStatus(codes.Ok, codes.Ok.String())
 	client.logger.Println("handling on close")
+	client.recordConnectionClosed()
 	// Discard pending ping requests to unlock all blocked thread waiting for a response.
 	client.logger.Println("discarding pending ping requests")
 	client.pendingPingMu.Lock()
 	defer client.pendingPingMu.Unlock()
 	for reqid, req := range client.requests.pendingPing {
-		// blocking write can be used as channels are managed internally and must have a capacity of 1
-		req.err <- fmt.Errorf("connection has been closed")
+		req.result.Fail(fmt.Errorf("connection has been closed"))
 		// Remove pending request
 		delete(client.requests.pendingPing, reqid)
 		// Log
@@ -2935,8 +3661,7 @@ func (client *krakenSpotWebsocketClient) OnClose(
 	client.pendingSubscribeMu.Lock()
 	defer client.pendingSubscribeMu.Unlock()
 	for reqid, req := range client.requests.pendingSubscribe {
-		// blocking write can be used as channels are managed internally and must have a capacity of 1
-		req.err <- fmt.Errorf("connection has been closed")
+		req.result.Fail(fmt.Errorf("connection has been closed"))
 		// Remove pending request
 		delete(client.requests.pendingSubscribe, reqid)
 		// Log
@@ -2947,8 +3672,7 @@ func (client *krakenSpotWebsocketClient) OnClose(
 	client.pendingUnsubscribeMu.Lock()
 	defer client.pendingUnsubscribeMu.Unlock()
 	for reqid, req := range client.requests.pendingUnsubscribe {
-		// blocking write can be used as channels are managed internally and must have a capacity of 1
-		req.err <- fmt.Errorf("connection has been closed")
+		req.result.Fail(fmt.Errorf("connection has been closed"))
 		// Remove pending request
 		delete(client.requests.pendingUnsubscribe, reqid)
 		// Log
@@ -2959,8 +3683,7 @@ func (client *krakenSpotWebsocketClient) OnClose(
 	client.pendingAddOrderMu.Lock()
 	defer client.pendingAddOrderMu.Unlock()
 	for reqid, req := range client.requests.pendingAddOrderRequests {
-		// blocking write can be used as channels are managed internally and must have a capacity of 1
-		req.err <- fmt.Errorf("connection has been closed")
+		req.result.Fail(fmt.Errorf("connection has been closed"))
 		// Remove pending request
 		delete(client.requests.pendingAddOrderRequests, reqid)
 		// Log
@@ -2971,8 +3694,7 @@ func (client *krakenSpotWebsocketClient) OnClose(
 	client.pendingEditOrderMu.Lock()
 	defer client.pendingEditOrderMu.Unlock()
 	for reqid, req := range client.requests.pendingEditOrderRequests {
-		// blocking write can be used as channels are managed internally and must have a capacity of 1
-		req.err <- fmt.Errorf("connection has been closed")
+		req.result.Fail(fmt.Errorf("connection has been closed"))
 		// Remove pending request
 		delete(client.requests.pendingEditOrderRequests, reqid)
 		// Log
@@ -2983,8 +3705,7 @@ func (client *krakenSpotWebsocketClient) OnClose(
 	client.pendingCancelOrderMu.Lock()
 	defer client.pendingCancelOrderMu.Unlock()
 	for reqid, req := range client.requests.pendingCancelOrderRequests {
-		// blocking write can be used as channels are managed internally and must have a capacity of 1
-		req.err <- fmt.Errorf("connection has been closed")
+		req.result.Fail(fmt.Errorf("connection has been closed"))
 		// Remove pending request
 		delete(client.requests.pendingCancelOrderRequests, reqid)
 		// Log
@@ -2995,8 +3716,7 @@ func (client *krakenSpotWebsocketClient) OnClose(
 	client.pendingCancelAllOrdersMu.Lock()
 	defer client.pendingCancelAllOrdersMu.Unlock()
 	for reqid, req := range client.requests.pendingCancelAllOrdersRequests {
-		// blocking write can be used as channels are managed internally and must have a capacity of 1
-		req.err <- fmt.Errorf("connection has been closed")
+		req.result.Fail(fmt.Errorf("connection has been closed"))
 		// Remove pending request
 		delete(client.requests.pendingCancelAllOrdersRequests, reqid)
 		// Log
@@ -3007,8 +3727,7 @@ func (client *krakenSpotWebsocketClient) OnClose(
 	client.pendingCancelAllOrdersAfterXOrderMu.Lock()
 	defer client.pendingCancelAllOrdersAfterXOrderMu.Unlock()
 	for reqid, req := range client.requests.pendingCancelAllOrdersAfterXRequests {
-		// blocking write can be used as channels are managed internally and must have a capacity of 1
-		req.err <- fmt.Errorf("connection has been closed")
+		req.result.Fail(fmt.Errorf("connection has been closed"))
 		// Remove pending request
 		delete(client.requests.pendingCancelAllOrdersAfterXRequests, reqid)
 		// Log
@@ -3019,54 +3738,56 @@ func (client *krakenSpotWebsocketClient) OnClose(
 	e.Context.SetType(string(events.ConnectionInterrupted))
 	e.Context.SetID(uuid.NewString())
 	e.Context.SetSource(tracing.PackageName)
-	// Use blocking writes (design principle: wait 'till delivery)
+	// Use blocking writes (design principle: wait 'till delivery) unless a delivery timeout is set
+	// (Cf. SetConnectionInterruptedDeliveryTimeout)
+	deadline := client.newConnectionInterruptedDeadline()
+	defer deadline.stop()
+	client.bus.Publish(TopicConnectionInterrupted, e)
 	client.tickerSubMu.Lock()
 	defer client.tickerSubMu.Unlock()
 	if client.subscriptions.ticker != nil {
-		client.logger.Println("sending a connection_interrupted event on ticker channel to warn about connection interruption")
-		client.subscriptions.ticker.pub <- e
+		client.publishConnectionInterrupted(ctx, deadline, client.subscriptions.ticker.pub, e, "ticker channel")
 	}
 	client.ohlcSubMu.Lock()
 	defer client.ohlcSubMu.Unlock()
 	for _, osub := range client.subscriptions.ohlcs {
-		client.logger.Println("sending a connection_interrupted event on ohlc channel to warn about connection interruption", int(osub.interval))
-		osub.pub <- e
+		client.publishConnectionInterrupted(ctx, deadline, osub.pub, e, fmt.Sprintf("ohlc channel (%d)", int(osub.interval)))
 	}
 	client.tradeSubMu.Lock()
 	defer client.tradeSubMu.Unlock()
-	if client.subscriptions.trade != nil {
-		client.logger.Println("sending a connection_interrupted event on trade channel to warn about connection interruption")
-		client.subscriptions.trade.pub <- e
+	if client.subscriptions.trade != nil && client.subscriptions.trade.pub != nil {
+		client.publishConnectionInterrupted(ctx, deadline, client.subscriptions.trade.pub, e, "trade channel")
 	}
 	client.spreadSubMu.Lock()
 	defer client.spreadSubMu.Unlock()
-	if client.subscriptions.spread != nil {
-		client.logger.Println("sending a connection_interrupted event on spread channel to warn about connection interruption")
-		client.subscriptions.spread.pub <- e
+	if client.subscriptions.spread != nil && client.subscriptions.spread.pub != nil {
+		client.publishConnectionInterrupted(ctx, deadline, client.subscriptions.spread.pub, e, "spread channel")
 	}
 	client.bookSubMu.Lock()
 	defer client.bookSubMu.Unlock()
-	if client.subscriptions.book != nil {
-		client.logger.Println("sending a connection_interrupted event on book channels to warn about connection interruption")
-		client.subscriptions.book.pub <- e
+	for _, bsub := range client.subscriptions.books {
+		if bsub.pub != nil {
+			client.publishConnectionInterrupted(ctx, deadline, bsub.pub, e, fmt.Sprintf("book channel (%d)", int(bsub.depth)))
+		}
 	}
 	client.ownTradesSubMu.Lock()
 	defer client.ownTradesSubMu.Unlock()
 	if client.subscriptions.ownTrades != nil {
-		client.logger.Println("sending a connection_interrupted event on own trades channel to warn about connection interruption")
-		client.subscriptions.ownTrades.pub <- e
+		client.publishConnectionInterrupted(ctx, deadline, client.subscriptions.ownTrades.pub, e, "own trades channel")
 	}
 	client.openOrdersSubMu.Lock()
 	defer client.openOrdersSubMu.Unlock()
 	if client.subscriptions.openOrders != nil {
-		client.logger.Println("sending a connection_interrupted event on open orders channel to warn about connection interruption")
-		client.subscriptions.openOrders.pub <- e
+		client.publishConnectionInterrupted(ctx, deadline, client.subscriptions.openOrders.pub, e, "open orders channel")
 	}
+	// Wait for the queued events, including connection_interrupted events, to be delivered
+	client.stopDispatchQueues(deadline)
 	// Call user callback if set
 	if client.onCloseCallback != nil {
 		client.onCloseCallback(ctx, closeMessage)
 	}
-	// Remove conn & return
+	// Stop the connection writer, remove conn & return
+	client.stopConnectionWriter()
 	client.conn = nil
 	return closeMessage
 }
@@ -3146,7 +3867,7 @@ func (client *krakenSpotWebsocketClient) handleErrorMessage(
 	client.logger.Println("handing error message from server")
 	// Parse message as error
 	errMsg := new(messages.ErrorMessage)
-	err := json.Unmarshal(msg, errMsg)
+	err := client.codec.Unmarshal(msg, errMsg)
 	if err != nil {
 		// Call OnReadError - failed to parse message as error
 		eerr := fmt.Errorf("failed to parse message '%s' as error message: %w", string(msg), err)
@@ -3161,14 +3882,18 @@ func (client *krakenSpotWebsocketClient) handleErrorMessage(
 		attr = append(attr, attribute.Int64("request_id", *errMsg.ReqId))
 	}
 	span.AddEvent("error_message", trace.WithAttributes(attr...))
-	// If there is a joined request ID, check pending requests
+	// If there is a joined request ID, check pending requests. Pending requests sent during another
+	// session than the response are skipped (Cf. isStaleResponse).
 	if errMsg.ReqId != nil {
 		// Check pending subscribe
 		client.pendingSubscribeMu.Lock()
 		prSub := client.requests.pendingSubscribe[*errMsg.ReqId]
-		if prSub != nil {
-			// Fulfil request by publishing an error on the request error channel
-			prSub.err <- fmt.Errorf("server replied with an error message: %s", errMsg.Err)
+		if prSub != nil && !client.isStaleResponse(span, sessionId, prSub.session, *errMsg.ReqId) {
+			// Fulfil request by failing the request future
+			rspan := client.startResponseSpan(ctx, "subscribe_response", prSub.span, *errMsg.ReqId, prSub.tags)
+			prSub.result.Fail(fmt.Errorf("server replied with an error message: %s", errMsg.Err))
+			rspan.SetStatus(codes.Error, errMsg.Err)
+			rspan.End()
 			// Discard the request
 			delete(client.requests.pendingSubscribe, *errMsg.ReqId)
 			// Unlock pending subscribe requests map & Exit
@@ -3180,9 +3905,12 @@ func (client *krakenSpotWebsocketClient) handleErrorMessage(
 		// Check pending addOrder
 		client.pendingAddOrderMu.Lock()
 		prAddOrder := client.requests.pendingAddOrderRequests[*errMsg.ReqId]
-		if prAddOrder != nil {
-			// Fulfil request by publishing an error on the request error channel
-			prAddOrder.err <- fmt.Errorf("server replied with an error message: %s", errMsg.Err)
+		if prAddOrder != nil && !client.isStaleResponse(span, sessionId, prAddOrder.session, *errMsg.ReqId) {
+			// Fulfil request by failing the request future
+			rspan := client.startResponseSpan(ctx, "add_order_response", prAddOrder.span, *errMsg.ReqId, prAddOrder.tags)
+			prAddOrder.result.Fail(fmt.Errorf("server replied with an error message: %s", errMsg.Err))
+			rspan.SetStatus(codes.Error, errMsg.Err)
+			rspan.End()
 			// Discard the request
 			delete(client.requests.pendingAddOrderRequests, *errMsg.ReqId)
 			// Unlock pending add order requests map & Exit
@@ -3194,9 +3922,12 @@ func (client *krakenSpotWebsocketClient) handleErrorMessage(
 		// Check pending editOrder
 		client.pendingEditOrderMu.Lock()
 		prEditOrder := client.requests.pendingEditOrderRequests[*errMsg.ReqId]
-		if prEditOrder != nil {
-			// Fulfil request by publishing an error on the request error channel
-			prEditOrder.err <- fmt.Errorf("server replied with an error message: %s", errMsg.Err)
+		if prEditOrder != nil && !client.isStaleResponse(span, sessionId, prEditOrder.session, *errMsg.ReqId) {
+			// Fulfil request by failing the request future
+			rspan := client.startResponseSpan(ctx, "edit_order_response", prEditOrder.span, *errMsg.ReqId, prEditOrder.tags)
+			prEditOrder.result.Fail(fmt.Errorf("server replied with an error message: %s", errMsg.Err))
+			rspan.SetStatus(codes.Error, errMsg.Err)
+			rspan.End()
 			// Discard the request
 			delete(client.requests.pendingEditOrderRequests, *errMsg.ReqId)
 			// Unlock pending edit order requests map & Exit
@@ -3208,9 +3939,12 @@ func (client *krakenSpotWebsocketClient) handleErrorMessage(
 		// Check pending cancelOrder
 		client.pendingCancelOrderMu.Lock()
 		prCancelOrder := client.requests.pendingCancelOrderRequests[*errMsg.ReqId]
-		if prCancelOrder != nil {
-			// Fulfil request by publishing an error on the request error channel
-			prCancelOrder.err <- fmt.Errorf("server replied with an error message: %s", errMsg.Err)
+		if prCancelOrder != nil && !client.isStaleResponse(span, sessionId, prCancelOrder.session, *errMsg.ReqId) {
+			// Fulfil request by failing the request future
+			rspan := client.startResponseSpan(ctx, "cancel_order_response", prCancelOrder.span, *errMsg.ReqId, prCancelOrder.tags)
+			prCancelOrder.result.Fail(fmt.Errorf("server replied with an error message: %s", errMsg.Err))
+			rspan.SetStatus(codes.Error, errMsg.Err)
+			rspan.End()
 			// Discard the request
 			delete(client.requests.pendingCancelOrderRequests, *errMsg.ReqId)
 			// Unlock pending edit order requests map & Exit
@@ -3222,9 +3956,12 @@ func (client *krakenSpotWebsocketClient) handleErrorMessage(
 		// Check pending cancelAllOrders
 		client.pendingCancelAllOrdersMu.Lock()
 		prCancelAllOrders := client.requests.pendingCancelAllOrdersRequests[*errMsg.ReqId]
-		if prCancelAllOrders != nil {
-			// Fulfil request by publishing an error on the request error channel
-			prCancelAllOrders.err <- fmt.Errorf("server replied with an error message: %s", errMsg.Err)
+		if prCancelAllOrders != nil && !client.isStaleResponse(span, sessionId, prCancelAllOrders.session, *errMsg.ReqId) {
+			// Fulfil request by failing the request future
+			rspan := client.startResponseSpan(ctx, "cancel_all_orders_response", prCancelAllOrders.span, *errMsg.ReqId, prCancelAllOrders.tags)
+			prCancelAllOrders.result.Fail(fmt.Errorf("server replied with an error message: %s", errMsg.Err))
+			rspan.SetStatus(codes.Error, errMsg.Err)
+			rspan.End()
 			// Discard the request
 			delete(client.requests.pendingCancelAllOrdersRequests, *errMsg.ReqId)
 			// Unlock pending edit order requests map & Exit
@@ -3236,9 +3973,12 @@ func (client *krakenSpotWebsocketClient) handleErrorMessage(
 		// Check pending cancelALlOrdersAfterX
 		client.pendingCancelAllOrdersAfterXOrderMu.Lock()
 		prCancelAllOrdersAfterX := client.requests.pendingCancelAllOrdersAfterXRequests[*errMsg.ReqId]
-		if prCancelAllOrdersAfterX != nil {
-			// Fulfil request by publishing an error on the request error channel
-			prCancelAllOrdersAfterX.err <- fmt.Errorf("server replied with an error message: %s", errMsg.Err)
+		if prCancelAllOrdersAfterX != nil && !client.isStaleResponse(span, sessionId, prCancelAllOrdersAfterX.session, *errMsg.ReqId) {
+			// Fulfil request by failing the request future
+			rspan := client.startResponseSpan(ctx, "cancel_all_orders_after_x_response", prCancelAllOrdersAfterX.span, *errMsg.ReqId, prCancelAllOrdersAfterX.tags)
+			prCancelAllOrdersAfterX.result.Fail(fmt.Errorf("server replied with an error message: %s", errMsg.Err))
+			rspan.SetStatus(codes.Error, errMsg.Err)
+			rspan.End()
 			// Discard the request
 			delete(client.requests.pendingCancelAllOrdersAfterXRequests, *errMsg.ReqId)
 			// Unlock pending edit order requests map & Exit
@@ -3250,9 +3990,12 @@ func (client *krakenSpotWebsocketClient) handleErrorMessage(
 		// Check pending unsubscribe
 		client.pendingUnsubscribeMu.Lock()
 		prUnsub := client.requests.pendingUnsubscribe[*errMsg.ReqId]
-		if prUnsub != nil {
-			// Fulfil request by publishing an error on the request error channel
-			prUnsub.err <- fmt.Errorf("server replied with an error message: %s", errMsg.Err)
+		if prUnsub != nil && !client.isStaleResponse(span, sessionId, prUnsub.session, *errMsg.ReqId) {
+			// Fulfil request by failing the request future
+			rspan := client.startResponseSpan(ctx, "unsubscribe_response", prUnsub.span, *errMsg.ReqId, prUnsub.tags)
+			prUnsub.result.Fail(fmt.Errorf("server replied with an error message: %s", errMsg.Err))
+			rspan.SetStatus(codes.Error, errMsg.Err)
+			rspan.End()
 			// Discard the request
 			delete(client.requests.pendingUnsubscribe, *errMsg.ReqId)
 			// Unlock and exit
@@ -3264,11 +4007,14 @@ func (client *krakenSpotWebsocketClient) handleErrorMessage(
 		client.pendingUnsubscribeMu.Unlock()
 		//  Check pending ping
 		client.pendingPingMu.Lock()
-		defer client.pendingPingMu.Lock()
+		defer client.pendingPingMu.Unlock()
 		prPing := client.requests.pendingPing[*errMsg.ReqId]
-		if prPing != nil {
-			// Fulfil request by publish an error on the request error channel
-			prPing.err <- fmt.Errorf("server replied with an error message: %s", errMsg.Err)
+		if prPing != nil && !client.isStaleResponse(span, sessionId, prPing.session, *errMsg.ReqId) {
+			// Fulfil request by failing the request future
+			rspan := client.startResponseSpan(ctx, "ping_response", prPing.span, *errMsg.ReqId, prPing.tags)
+			prPing.result.Fail(fmt.Errorf("server replied with an error message: %s", errMsg.Err))
+			rspan.SetStatus(codes.Error, errMsg.Err)
+			rspan.End()
 			// Discard the request
 			delete(client.requests.pendingPing, *errMsg.ReqId)
 			// Exit
@@ -3279,10 +4025,19 @@ func (client *krakenSpotWebsocketClient) handleErrorMessage(
 		eerr := fmt.Errorf("no corresponding pending request has been found for the request id %d to relay the following error: %s", *errMsg.ReqId, errMsg.Err)
 		return tracing.HandleAndTraLogError(span, client.logger, eerr)
 	}
-	// Error no request ID -> As the cient force the usage of request IDs, not having one is
-	// considered as an error.
-	eerr := fmt.Errorf("no requests id for the following error message: %s", errMsg.Err)
-	return tracing.HandleAndTraLogError(span, client.logger, eerr)
+	// No request ID -> The error is not related to a request (ex: global service message). Publish
+	// it on the general error channel - as user might not actively listen to general errors, discard
+	// messages according to the congestion policy in case of congestion
+	client.logger.Println("received an error message without request id:", errMsg.Err)
+	event := event.New()
+	event.Context.SetType(string(events.GeneralError))
+	event.Context.SetSource(tracing.PackageName)
+	event.SetData("application/json", msg)
+	otelObs.InjectDistributedTracingExtension(ctx, event)
+	client.bus.Publish(TopicGeneralError, event)
+	client.publishBuiltin(ctx, client.subscriptions.generalErrors, event, events.GeneralError)
+	span.SetStatus(codes.Ok, codes.Ok.String())
+	return nil
 }
 
 // This method contains the logic to handle a received heartbeat message.
@@ -3301,20 +4056,15 @@ func (client *krakenSpotWebsocketClient) handleHeartbeat(
 		trace.WithAttributes(attribute.String("session_id", sessionId)))
 	defer span.End()
 	client.logger.Println("handling heartbeat from server")
-	// Publish heartbeat - as user might not actively listen to heartbeats, manage the channel in FIFO
-	// fashion by discarding oldest messages in case of congestion
+	// Publish heartbeat - as user might not actively listen to heartbeats, discard messages
+	// according to the congestion policy in case of congestion
 	event := event.New()
 	event.Context.SetType(string(events.Heartbeat))
 	event.Context.SetSource(tracing.PackageName)
 	event.SetData("application/json", msg)
 	otelObs.InjectDistributedTracingExtension(ctx, event)
-	select {
-	case client.subscriptions.heartbeat <- event:
-	default:
-		// Discard oldest heartbeat & push new one
-		<-client.subscriptions.heartbeat
-		client.subscriptions.heartbeat <- event
-	}
+	client.bus.Publish(TopicHeartbeat, event)
+	client.publishBuiltin(ctx, client.subscriptions.heartbeat, event, events.Heartbeat)
 	span.SetStatus(codes.Ok, codes.Ok.String())
 	return nil
 }
@@ -3330,26 +4080,82 @@ func (client *krakenSpotWebsocketClient) handleSystemStatus(
 	msgType wsadapters.MessageType,
 	msg []byte) error {
 	// Tracing: Start span
-	_, span := client.tracer.Start(ctx, "handle_system_status",
+	ctx, span := client.tracer.Start(ctx, "handle_system_status",
 		trace.WithSpanKind(trace.SpanKindInternal),
 		trace.WithAttributes(attribute.String("session_id", sessionId)))
 	defer span.End()
 	client.logger.Println("handling system status from server")
-	// Publish heartbeat - as user might not actively listen to system statuses, manage the channel
-	// in FIFO fashion by discarding oldest messages in case of congestion
+	// Parse message as system status and update the mode gate
+	status := new(messages.SystemStatus)
+	err := client.codec.Unmarshal(msg, status)
+	if err != nil {
+		// Call OnReadError - failed to parse message as system status
+		eerr := fmt.Errorf("failed to parse message '%s' as system status: %w", string(msg), err)
+		client.logger.Println(eerr.Error())
+		client.OnReadError(ctx, conn, readMutex, restart, exit, eerr)
+		return tracing.HandleAndTraLogError(span, client.logger, eerr)
+	}
+	span.SetAttributes(attribute.String("status", status.Status))
+	client.modeGate.Update(messages.EngineStatusEnum(status.Status))
+	// Publish system status - as user might not actively listen to system statuses, discard
+	// messages according to the congestion policy in case of congestion
 	event := event.New()
 	event.Context.SetType(string(events.SystemStatus))
 	event.Context.SetSource(tracing.PackageName)
 	event.SetData("application/json", msg)
+	client.bus.Publish(TopicSystemStatus, event)
+	client.publishBuiltin(ctx, client.subscriptions.systemStatus, event, events.SystemStatus)
+	span.SetStatus(codes.Ok, codes.Ok.String())
+	return nil
+}
+
+// This method publishes an event on one of the client's built-in channels without blocking. When
+// the channel is full, either the oldest event or the new event is discarded according to the
+// congestion policy (Cf. SetCongestionPolicy).
+func (client *krakenSpotWebsocketClient) publishBuiltin(ctx context.Context, ch chan event.Event, e event.Event, eventType events.WebsocketClientEventTypeEnum) {
 	select {
-	case client.subscriptions.systemStatus <- event:
+	case ch <- e:
+		return
 	default:
-		// Discard oldest heartbeat & push new one
-		<-client.subscriptions.systemStatus
-		client.subscriptions.systemStatus <- event
 	}
-	span.SetStatus(codes.Ok, codes.Ok.String())
-	return nil
+	if client.getCongestionPolicy() == CongestionDropOldest {
+		// Discard oldest event & push new one - Consumer might have read events meanwhile
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+	client.recordDroppedMessage(ctx, eventType)
+}
+
+// This method records a message discarded because of congestion: counters are updated, the
+// dropped messages metric is recorded and the optional OnDroppedMessage callback is called.
+func (client *krakenSpotWebsocketClient) recordDroppedMessage(ctx context.Context, eventType events.WebsocketClientEventTypeEnum) {
+	var count uint64
+	switch eventType {
+	case events.Heartbeat:
+		count = client.droppedHeartbeats.Add(1)
+	case events.GeneralError:
+		count = client.droppedGeneralErrors.Add(1)
+	case events.UnknownMessage:
+		count = client.droppedUnknownMessages.Add(1)
+	case events.ConnectionInterrupted:
+		count = client.droppedConnectionInterruptions.Add(1)
+	default:
+		count = client.droppedSystemStatuses.Add(1)
+	}
+	client.droppedMessagesCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("event_type", string(eventType))))
+	client.logger.Println("message discarded because of congestion", eventType, count)
+	client.onDroppedMessageMu.Lock()
+	callback := client.onDroppedMessageCallback
+	client.onDroppedMessageMu.Unlock()
+	if callback != nil {
+		callback(eventType, count)
+	}
 }
 
 // This method contains the logic to handle a received pong message.
@@ -3370,7 +4176,7 @@ func (client *krakenSpotWebsocketClient) handlePong(
 	client.logger.Println("handling pong from server")
 	// Parse message as pong
 	pong := new(messages.Pong)
-	err := json.Unmarshal(msg, pong)
+	err := client.codec.Unmarshal(msg, pong)
 	if err != nil {
 		// Call OnReadError - failed to parse message as pong
 		eerr := fmt.Errorf("failed to parse message '%s' as pong: %w", string(msg), err)
@@ -3404,9 +4210,15 @@ func (client *krakenSpotWebsocketClient) handlePong(
 		client.OnReadError(ctx, conn, readMutex, restart, exit, err)
 		return tracing.HandleAndTraLogError(span, client.logger, err)
 	}
-	// Fulfil pending request
-	// Blocking write can be used as channel must always have a capacity of one and be internally managed
-	pr.resp <- pong
+	// Ignore responses which cannot be related to the request because of a reconnect
+	if client.isStaleResponse(span, sessionId, pr.session, *pong.ReqId) {
+		span.SetStatus(codes.Ok, codes.Ok.String())
+		return nil
+	}
+	// Fulfil pending request - Tracing: relate the response to the request span
+	rspan := client.startResponseSpan(ctx, "ping_response", pr.span, *pong.ReqId, pr.tags)
+	pr.result.Complete(pong)
+	rspan.End()
 	// Discard pending request now that it has been served and exit
 	client.logger.Println("pong handled")
 	delete(client.requests.pendingPing, *pong.ReqId)
@@ -3433,7 +4245,7 @@ func (client *krakenSpotWebsocketClient) handleSubscriptionStatus(
 	client.logger.Println("handling subscription status from server")
 	// Parse message as SubscriptionStatus
 	subs := new(messages.SubscriptionStatus)
-	err := json.Unmarshal(msg, subs)
+	err := client.codec.Unmarshal(msg, subs)
 	if err != nil {
 		// Call OnReadError - failed to parse message as SubscriptionStatus
 		eerr := fmt.Errorf("failed to parse message '%s' as subscriptionStatus: %w", string(msg), err)
@@ -3491,6 +4303,11 @@ func (client *krakenSpotWebsocketClient) handleSubscriptionStatus(
 			client.OnReadError(ctx, conn, readMutex, restart, exit, err)
 			return tracing.HandleAndTraLogError(span, client.logger, err)
 		}
+		// Ignore responses which cannot be related to the request because of a reconnect
+		if client.isStaleResponse(span, sessionId, unsubreq.session, *subs.ReqId) {
+			span.SetStatus(codes.Ok, codes.Ok.String())
+			return nil
+		}
 		// Check if the message has an error message and record it if that is the case
 		if subs.Status == string(messages.Err) {
 			unsubreq.errPerPair[subs.Pair] = fmt.Errorf("unsubscribe for %s failed: %s", subs.Pair, subs.Err)
@@ -3506,8 +4323,8 @@ func (client *krakenSpotWebsocketClient) handleSubscriptionStatus(
 			fully = fully && unsubreq.served[v]
 		}
 		if fully {
-			// Fulfil pending unsubscribe: send nil in case of success or an error with the error message if
-			// unsubscribe has failed.
+			// Fulfil pending unsubscribe: complete the future in case of success or fail it with the error
+			// message if unsubscribe has failed.
 			err = nil
 			if len(unsubreq.errPerPair) > 0 {
 				// Trace error
@@ -3517,12 +4334,23 @@ func (client *krakenSpotWebsocketClient) handleSubscriptionStatus(
 				client.logger.Println(err.Error())
 				tracing.HandleAndTraLogError(span, client.logger, err)
 			}
-			// Blocking write can be used as channel must always have a capacity of one and be internally managed
-			unsubreq.err <- err
+			// Tracing: relate the response to the request span
+			rspan := client.startResponseSpan(ctx, "unsubscribe_response", unsubreq.span, *subs.ReqId, unsubreq.tags)
+			if err != nil {
+				unsubreq.result.Fail(err)
+			} else {
+				unsubreq.result.Complete(struct{}{})
+			}
+			rspan.End()
 			// Discard pending request
 			delete(client.requests.pendingUnsubscribe, *subs.ReqId)
 		}
 	} else {
+		// Ignore responses which cannot be related to the request because of a reconnect
+		if client.isStaleResponse(span, sessionId, subreq.session, *subs.ReqId) {
+			span.SetStatus(codes.Ok, codes.Ok.String())
+			return nil
+		}
 		// Check if the message has an error message and record it if that is the case
 		if subs.Status == string(messages.Err) {
 			subreq.errPerPair[subs.Pair] = fmt.Errorf("subscribe for %s failed: %s", subs.Pair, subs.Err)
@@ -3538,8 +4366,8 @@ func (client *krakenSpotWebsocketClient) handleSubscriptionStatus(
 			fully = fully && subreq.served[v]
 		}
 		if fully {
-			// Fulfil pending subscribe: send nil in case of success or an error with the error message if
-			// subscribe has failed
+			// Fulfil pending subscribe: complete the future in case of success or fail it with the error
+			// message if subscribe has failed
 			err = nil
 			if len(subreq.errPerPair) > 0 {
 				err = &SubscriptionError{
@@ -3548,8 +4376,14 @@ func (client *krakenSpotWebsocketClient) handleSubscriptionStatus(
 				client.logger.Println(err.Error())
 				tracing.HandleAndTraLogError(span, client.logger, err)
 			}
-			// Blocking write can be used as channel must always have a capacity of one and be internally managed
-			subreq.err <- err
+			// Tracing: relate the response to the request span
+			rspan := client.startResponseSpan(ctx, "subscribe_response", subreq.span, *subs.ReqId, subreq.tags)
+			if err != nil {
+				subreq.result.Fail(err)
+			} else {
+				subreq.result.Complete(struct{}{})
+			}
+			rspan.End()
 			// Discard pending request
 			delete(client.requests.pendingSubscribe, *subs.ReqId)
 		}
@@ -3580,9 +4414,10 @@ func (client *krakenSpotWebsocketClient) handleTicker(
 	client.tickerSubMu.Lock()
 	defer client.tickerSubMu.Unlock()
 	if client.subscriptions.ticker == nil {
-		err := fmt.Errorf("a ticker message has been received while there is no active subscription to ticker channel")
-		client.logger.Println(err.Error())
-		return tracing.HandleAndTraLogError(span, client.logger, err)
+		return client.discardUnsubscribedData(span, messages.ChannelTicker, pair, msg)
+	}
+	if delivered, err := deliverTyped(client, span, client.getTypedChannels().Ticker, false, msg); delivered {
+		return err
 	}
 	// Publish ticker - use blocking write (block until delivery)
 	event := event.New()
@@ -3591,7 +4426,8 @@ func (client *krakenSpotWebsocketClient) handleTicker(
 	event.SetSubject(pair)
 	event.SetData("application/json", msg)
 	otelObs.InjectDistributedTracingExtension(ctx, event)
-	client.subscriptions.ticker.pub <- event
+	client.bus.Publish(TickerTopic(pair), event)
+	client.publish(client.subscriptions.ticker.pub, event)
 	span.SetStatus(codes.Ok, codes.Ok.String())
 	return nil
 }
@@ -3617,10 +4453,11 @@ func (client *krakenSpotWebsocketClient) handleOHLC(
 	// Check if there is an active subscription, discard otherwise
 	client.ohlcSubMu.Lock()
 	defer client.ohlcSubMu.Unlock()
-	if client.subscriptions.ohlcs == nil {
-		err := fmt.Errorf("a ohlc message has been received while there is no active subscription to ohlc channel")
-		client.logger.Println(err.Error())
-		return tracing.HandleAndTraLogError(span, client.logger, err)
+	if client.subscriptions.ohlcs[interval] == nil {
+		return client.discardUnsubscribedData(span, messages.ChannelOHLC, pair, msg)
+	}
+	if delivered, err := deliverTyped(client, span, client.getTypedChannels().OHLC, client.subscriptions.ohlcs[interval].internal, msg); delivered {
+		return err
 	}
 	// Publish ohlc - use blocking write (block until delivery)
 	event := event.New()
@@ -3629,7 +4466,8 @@ func (client *krakenSpotWebsocketClient) handleOHLC(
 	event.SetSubject(pair)
 	event.SetData("application/json", msg)
 	otelObs.InjectDistributedTracingExtension(ctx, event)
-	client.subscriptions.ohlcs[messages.IntervalEnum(interval)].pub <- event
+	client.bus.Publish(OHLCTopic(interval, pair), event)
+	client.publish(client.subscriptions.ohlcs[messages.IntervalEnum(interval)].pub, event)
 	span.SetStatus(codes.Ok, codes.Ok.String())
 	return nil
 }
@@ -3651,14 +4489,22 @@ func (client *krakenSpotWebsocketClient) handleTrade(
 		trace.WithAttributes(attribute.String("session_id", sessionId)))
 	defer span.End()
 	client.logger.Println("handling trade message from server")
+	// Measure latency if the latency monitor is running
+	client.measureTradeLatency(ctx, msg, client.clock.Now())
 	// Check if there is an active subscription, discard otherwise
 	client.tradeSubMu.Lock()
 	defer client.tradeSubMu.Unlock()
 	if client.subscriptions.trade == nil {
-		err := fmt.Errorf("a trade message has been received while there is no active subscription to trade channel")
+		return client.discardUnsubscribedData(span, messages.ChannelTrade, pair, msg)
+	}
+	if client.subscriptions.trade.pub == nil {
+		err := fmt.Errorf("a trade message could not be dispatched to the raw callback of the active subscription to trade channel")
 		client.logger.Println(err.Error())
 		return tracing.HandleAndTraLogError(span, client.logger, err)
 	}
+	if delivered, err := deliverTyped(client, span, client.getTypedChannels().Trade, client.subscriptions.trade.internal, msg); delivered {
+		return err
+	}
 	// Publish trade - use blocking write (block until delivery)
 	event := event.New()
 	event.Context.SetType(string(events.Trade))
@@ -3666,7 +4512,8 @@ func (client *krakenSpotWebsocketClient) handleTrade(
 	event.SetSubject(pair)
 	event.SetData("application/json", msg)
 	otelObs.InjectDistributedTracingExtension(ctx, event)
-	client.subscriptions.trade.pub <- event
+	client.bus.Publish(TradeTopic(pair), event)
+	client.publish(client.subscriptions.trade.pub, event)
 	span.SetStatus(codes.Ok, codes.Ok.String())
 	return nil
 }
@@ -3688,14 +4535,22 @@ func (client *krakenSpotWebsocketClient) handleSpread(
 		trace.WithAttributes(attribute.String("session_id", sessionId)))
 	defer span.End()
 	client.logger.Println("handling spread message from server")
+	// Measure latency if the latency monitor is running
+	client.measureSpreadLatency(ctx, msg, client.clock.Now())
 	// Check if there is an active subscription, discard otherwise
 	client.spreadSubMu.Lock()
 	defer client.spreadSubMu.Unlock()
 	if client.subscriptions.spread == nil {
-		err := fmt.Errorf("a spread message has been received while there is no active subscription to spread channel")
+		return client.discardUnsubscribedData(span, messages.ChannelSpread, pair, msg)
+	}
+	if client.subscriptions.spread.pub == nil {
+		err := fmt.Errorf("a spread message could not be dispatched to the raw callback of the active subscription to spread channel")
 		client.logger.Println(err.Error())
 		return tracing.HandleAndTraLogError(span, client.logger, err)
 	}
+	if delivered, err := deliverTyped(client, span, client.getTypedChannels().Spread, false, msg); delivered {
+		return err
+	}
 	// Publish trade - use blocking write
 	event := event.New()
 	event.Context.SetType(string(events.Spread))
@@ -3703,7 +4558,8 @@ func (client *krakenSpotWebsocketClient) handleSpread(
 	event.SetSubject(pair)
 	event.SetData("application/json", msg)
 	otelObs.InjectDistributedTracingExtension(ctx, event)
-	client.subscriptions.spread.pub <- event
+	client.bus.Publish(SpreadTopic(pair), event)
+	client.publish(client.subscriptions.spread.pub, event)
 	span.SetStatus(codes.Ok, codes.Ok.String())
 	return nil
 }
@@ -3718,7 +4574,8 @@ func (client *krakenSpotWebsocketClient) handleBook(
 	sessionId string,
 	msgType wsadapters.MessageType,
 	pair string,
-	msg []byte) error {
+	msg []byte,
+	depth messages.DepthEnum) error {
 	// Tracing: Start span
 	ctx, span := client.tracer.Start(ctx, "handle_book",
 		trace.WithSpanKind(trace.SpanKindInternal),
@@ -3729,10 +4586,10 @@ func (client *krakenSpotWebsocketClient) handleBook(
 	// Check if it is a snapshot or an update -> an update will have a "c" field
 	if strings.Contains(string(msg), `"c"`) {
 		// Handle update
-		return client.handleBookUpdate(ctx, conn, readMutex, restart, exit, sessionId, msgType, pair, msg)
+		return client.handleBookUpdate(ctx, conn, readMutex, restart, exit, sessionId, msgType, pair, msg, depth)
 	}
 	// Hanlde snapshot
-	return client.handleBookSnapshot(ctx, conn, readMutex, restart, exit, sessionId, msgType, pair, msg)
+	return client.handleBookSnapshot(ctx, conn, readMutex, restart, exit, sessionId, msgType, pair, msg, depth)
 }
 
 // This method contains the logic to handle a received book update message.
@@ -3745,7 +4602,8 @@ func (client *krakenSpotWebsocketClient) handleBookUpdate(
 	sessionId string,
 	msgType wsadapters.MessageType,
 	pair string,
-	msg []byte) error {
+	msg []byte,
+	depth messages.DepthEnum) error {
 	// Tracing: Start span
 	ctx, span := client.tracer.Start(ctx, "handle_book_update",
 		trace.WithSpanKind(trace.SpanKindInternal),
@@ -3755,11 +4613,18 @@ func (client *krakenSpotWebsocketClient) handleBookUpdate(
 	// Check if there is an active subscription, discard otherwise
 	client.bookSubMu.Lock()
 	defer client.bookSubMu.Unlock()
-	if client.subscriptions.book == nil {
-		err := fmt.Errorf("a book update message has been received while there is no active subscription to book channel")
+	bsub := client.subscriptions.books[depth]
+	if bsub == nil {
+		return client.discardUnsubscribedData(span, messages.ChannelBook, pair, msg)
+	}
+	if bsub.pub == nil {
+		err := fmt.Errorf("a book message could not be dispatched to the raw callback of the active subscription to book channel")
 		client.logger.Println(err.Error())
 		return tracing.HandleAndTraLogError(span, client.logger, err)
 	}
+	if delivered, err := deliverTyped(client, span, client.getTypedChannels().BookUpdate, bsub.internal, msg); delivered {
+		return err
+	}
 	// Publish book update - use blocking write
 	event := event.New()
 	event.Context.SetType(string(events.BookUpdate))
@@ -3767,7 +4632,8 @@ func (client *krakenSpotWebsocketClient) handleBookUpdate(
 	event.SetSubject(pair)
 	event.SetData("application/json", msg)
 	otelObs.InjectDistributedTracingExtension(ctx, event)
-	client.subscriptions.book.pub <- event
+	client.bus.Publish(BookTopic(depth, pair), event)
+	client.publish(bsub.pub, event)
 	span.SetStatus(codes.Ok, codes.Ok.String())
 	return nil
 }
@@ -3782,7 +4648,8 @@ func (client *krakenSpotWebsocketClient) handleBookSnapshot(
 	sessionId string,
 	msgType wsadapters.MessageType,
 	pair string,
-	msg []byte) error {
+	msg []byte,
+	depth messages.DepthEnum) error {
 	// Tracing: Start span
 	ctx, span := client.tracer.Start(ctx, "handle_book_snapshot",
 		trace.WithSpanKind(trace.SpanKindInternal),
@@ -3792,11 +4659,18 @@ func (client *krakenSpotWebsocketClient) handleBookSnapshot(
 	// Check if there is an active subscription, discard otherwise
 	client.bookSubMu.Lock()
 	defer client.bookSubMu.Unlock()
-	if client.subscriptions.book == nil {
-		err := fmt.Errorf("a book snapshot message has been received while there is no active subscription to book channel")
+	bsub := client.subscriptions.books[depth]
+	if bsub == nil {
+		return client.discardUnsubscribedData(span, messages.ChannelBook, pair, msg)
+	}
+	if bsub.pub == nil {
+		err := fmt.Errorf("a book message could not be dispatched to the raw callback of the active subscription to book channel")
 		client.logger.Println(err.Error())
 		return tracing.HandleAndTraLogError(span, client.logger, err)
 	}
+	if delivered, err := deliverTyped(client, span, client.getTypedChannels().BookSnapshot, bsub.internal, msg); delivered {
+		return err
+	}
 	// Publish book snapshot - use blocking write (wait till delivery)
 	event := event.New()
 	event.Context.SetType(string(events.BookSnapshot))
@@ -3804,7 +4678,8 @@ func (client *krakenSpotWebsocketClient) handleBookSnapshot(
 	event.SetSubject(pair)
 	event.SetData("application/json", msg)
 	otelObs.InjectDistributedTracingExtension(ctx, event)
-	client.subscriptions.book.pub <- event
+	client.bus.Publish(BookTopic(depth, pair), event)
+	client.publish(bsub.pub, event)
 	span.SetStatus(codes.Ok, codes.Ok.String())
 	return nil
 }
@@ -3829,9 +4704,10 @@ func (client *krakenSpotWebsocketClient) handleOwnTrades(
 	client.ownTradesSubMu.Lock()
 	defer client.ownTradesSubMu.Unlock()
 	if client.subscriptions.ownTrades == nil {
-		err := fmt.Errorf("a own trades message has been received while there is no active subscription to own trades channel")
-		client.logger.Println(err.Error())
-		return tracing.HandleAndTraLogError(span, client.logger, err)
+		return client.discardUnsubscribedData(span, messages.ChannelOwnTrades, "", msg)
+	}
+	if delivered, err := deliverTyped(client, span, client.getTypedChannels().OwnTrades, false, msg); delivered {
+		return err
 	}
 	// Publish own trades - use blocking write (wait till delivery)
 	event := event.New()
@@ -3839,7 +4715,8 @@ func (client *krakenSpotWebsocketClient) handleOwnTrades(
 	event.Context.SetSource(tracing.PackageName)
 	event.SetData("application/json", msg)
 	otelObs.InjectDistributedTracingExtension(ctx, event)
-	client.subscriptions.ownTrades.pub <- event
+	client.bus.Publish(TopicOwnTrades, event)
+	client.publish(client.subscriptions.ownTrades.pub, event)
 	span.SetStatus(codes.Ok, codes.Ok.String())
 	return nil
 }
@@ -3864,9 +4741,12 @@ func (client *krakenSpotWebsocketClient) handleOpenOrders(
 	client.openOrdersSubMu.Lock()
 	defer client.openOrdersSubMu.Unlock()
 	if client.subscriptions.openOrders == nil {
-		err := fmt.Errorf("a open orders message has been received while there is no active subscription to open orders channel")
-		client.logger.Println(err.Error())
-		return tracing.HandleAndTraLogError(span, client.logger, err)
+		return client.discardUnsubscribedData(span, messages.ChannelOpenOrders, "", msg)
+	}
+	// Notify order watchers
+	client.notifyOrderWatchers(msg)
+	if delivered, err := deliverTyped(client, span, client.getTypedChannels().OpenOrders, false, msg); delivered {
+		return err
 	}
 	// Publish own trades - use blocking write (wait till delivery)
 	event := event.New()
@@ -3874,7 +4754,8 @@ func (client *krakenSpotWebsocketClient) handleOpenOrders(
 	event.Context.SetSource(tracing.PackageName)
 	event.SetData("application/json", msg)
 	otelObs.InjectDistributedTracingExtension(ctx, event)
-	client.subscriptions.openOrders.pub <- event
+	client.bus.Publish(TopicOpenOrders, event)
+	client.publish(client.subscriptions.openOrders.pub, event)
 	span.SetStatus(codes.Ok, codes.Ok.String())
 	return nil
 }
@@ -3897,7 +4778,7 @@ func (client *krakenSpotWebsocketClient) handleAddOrderStatus(
 	client.logger.Println("handling add order status message from server")
 	// Parse message as AddOrderResponse
 	aos := new(messages.AddOrderResponse)
-	err := json.Unmarshal(msg, aos)
+	err := client.codec.Unmarshal(msg, aos)
 	if err != nil {
 		// Call OnReadError - failed to parse message as addOrderResponse
 		eerr := fmt.Errorf("failed to parse message '%s' as add order response : %w", string(msg), err)
@@ -3932,9 +4813,15 @@ func (client *krakenSpotWebsocketClient) handleAddOrderStatus(
 		client.OnReadError(ctx, conn, readMutex, restart, exit, err)
 		return tracing.HandleAndTraLogError(span, client.logger, err)
 	}
-	// Fulfil pending request
-	// Blocking write can be used as channel must always have a capacity of one and be internally managed
-	pr.resp <- aos
+	// Ignore responses which cannot be related to the request because of a reconnect
+	if client.isStaleResponse(span, sessionId, pr.session, *aos.RequestId) {
+		span.SetStatus(codes.Ok, codes.Ok.String())
+		return nil
+	}
+	// Fulfil pending request - Tracing: relate the response to the request span
+	rspan := client.startResponseSpan(ctx, "add_order_response", pr.span, *aos.RequestId, pr.tags)
+	pr.result.Complete(aos)
+	rspan.End()
 	// Discard pending request now that it has been served and exit
 	delete(client.requests.pendingAddOrderRequests, *aos.RequestId)
 	span.SetStatus(codes.Ok, codes.Ok.String())
@@ -3959,7 +4846,7 @@ func (client *krakenSpotWebsocketClient) handleEditOrderStatus(
 	client.logger.Println("handling edit order status message from server")
 	// Parse message as EditORderResponse
 	eo := new(messages.EditOrderResponse)
-	err := json.Unmarshal(msg, eo)
+	err := client.codec.Unmarshal(msg, eo)
 	if err != nil {
 		// Call OnReadError - failed to parse message as editOrderResponse
 		eerr := fmt.Errorf("failed to parse message '%s' as edit order response : %w", string(msg), err)
@@ -3995,9 +4882,15 @@ func (client *krakenSpotWebsocketClient) handleEditOrderStatus(
 		client.OnReadError(ctx, conn, readMutex, restart, exit, err)
 		return tracing.HandleAndTraLogError(span, client.logger, err)
 	}
-	// Fulfil pending request
-	// Blocking write can be used as channel must always have a capacity of one and be internally managed
-	pr.resp <- eo
+	// Ignore responses which cannot be related to the request because of a reconnect
+	if client.isStaleResponse(span, sessionId, pr.session, *eo.RequestId) {
+		span.SetStatus(codes.Ok, codes.Ok.String())
+		return nil
+	}
+	// Fulfil pending request - Tracing: relate the response to the request span
+	rspan := client.startResponseSpan(ctx, "edit_order_response", pr.span, *eo.RequestId, pr.tags)
+	pr.result.Complete(eo)
+	rspan.End()
 	// Discard pending request now that it has been served and exit
 	delete(client.requests.pendingEditOrderRequests, *eo.RequestId)
 	span.SetStatus(codes.Ok, codes.Ok.String())
@@ -4022,7 +4915,7 @@ func (client *krakenSpotWebsocketClient) handleCancelOrderStatus(
 	client.logger.Println("handling cancel order status message from server")
 	// Parse message as CancelOrderResponse
 	co := new(messages.CancelOrderResponse)
-	err := json.Unmarshal(msg, co)
+	err := client.codec.Unmarshal(msg, co)
 	if err != nil {
 		// Call OnReadError - failed to parse message as cancelOrderResponse
 		eerr := fmt.Errorf("failed to parse message '%s' as cancel order response : %w", string(msg), err)
@@ -4055,9 +4948,15 @@ func (client *krakenSpotWebsocketClient) handleCancelOrderStatus(
 		client.OnReadError(ctx, conn, readMutex, restart, exit, err)
 		return tracing.HandleAndTraLogError(span, client.logger, err)
 	}
-	// Fulfil pending request
-	// Blocking write can be used as channel must always have a capacity of one and be internally managed
-	pr.resp <- co
+	// Ignore responses which cannot be related to the request because of a reconnect
+	if client.isStaleResponse(span, sessionId, pr.session, *co.RequestId) {
+		span.SetStatus(codes.Ok, codes.Ok.String())
+		return nil
+	}
+	// Fulfil pending request - Tracing: relate the response to the request span
+	rspan := client.startResponseSpan(ctx, "cancel_order_response", pr.span, *co.RequestId, pr.tags)
+	pr.result.Complete(co)
+	rspan.End()
 	// Discard pending request now that it has been served and exit
 	delete(client.requests.pendingCancelOrderRequests, *co.RequestId)
 	span.SetStatus(codes.Ok, codes.Ok.String())
@@ -4082,7 +4981,7 @@ func (client *krakenSpotWebsocketClient) handleCancelAllOrdersStatus(
 	client.logger.Println("handling cancel all orders status message from server")
 	// Parse message as CancelAllOrdersResponse
 	co := new(messages.CancelAllOrdersResponse)
-	err := json.Unmarshal(msg, co)
+	err := client.codec.Unmarshal(msg, co)
 	if err != nil {
 		// Call OnReadError - failed to parse message as cancelAllOrdersResponse
 		eerr := fmt.Errorf("failed to parse message '%s' as cancel all orders response : %w", string(msg), err)
@@ -4115,9 +5014,15 @@ func (client *krakenSpotWebsocketClient) handleCancelAllOrdersStatus(
 		client.OnReadError(ctx, conn, readMutex, restart, exit, err)
 		return tracing.HandleAndTraLogError(span, client.logger, err)
 	}
-	// Fulfil pending request
-	// Blocking write can be used as channel must always have a capacity of one and be internally managed
-	pr.resp <- co
+	// Ignore responses which cannot be related to the request because of a reconnect
+	if client.isStaleResponse(span, sessionId, pr.session, *co.RequestId) {
+		span.SetStatus(codes.Ok, codes.Ok.String())
+		return nil
+	}
+	// Fulfil pending request - Tracing: relate the response to the request span
+	rspan := client.startResponseSpan(ctx, "cancel_all_orders_response", pr.span, *co.RequestId, pr.tags)
+	pr.result.Complete(co)
+	rspan.End()
 	// Discard pending request now that it has been served and exit
 	delete(client.requests.pendingCancelAllOrdersRequests, *co.RequestId)
 	span.SetStatus(codes.Ok, codes.Ok.String())
@@ -4142,7 +5047,7 @@ func (client *krakenSpotWebsocketClient) handleCancelAllOrdersAfterXStatus(
 	client.logger.Println("handling cancel all orders after x status message from server")
 	// Parse message as CancelAllOrdersAfterXResponse
 	co := new(messages.CancelAllOrdersAfterXResponse)
-	err := json.Unmarshal(msg, co)
+	err := client.codec.Unmarshal(msg, co)
 	if err != nil {
 		// Call OnReadError - failed to parse message as CancelAllOrdersAfterXResponse
 		eerr := fmt.Errorf("failed to parse message '%s' as cancel all orders after x response : %w", string(msg), err)
@@ -4177,9 +5082,15 @@ func (client *krakenSpotWebsocketClient) handleCancelAllOrdersAfterXStatus(
 		client.OnReadError(ctx, conn, readMutex, restart, exit, err)
 		return tracing.HandleAndTraLogError(span, client.logger, err)
 	}
-	// Fulfil pending request
-	// Blocking write can be used as channel must always have a capacity of one and be internally managed
-	pr.resp <- co
+	// Ignore responses which cannot be related to the request because of a reconnect
+	if client.isStaleResponse(span, sessionId, pr.session, *co.RequestId) {
+		span.SetStatus(codes.Ok, codes.Ok.String())
+		return nil
+	}
+	// Fulfil pending request - Tracing: relate the response to the request span
+	rspan := client.startResponseSpan(ctx, "cancel_all_orders_after_x_response", pr.span, *co.RequestId, pr.tags)
+	pr.result.Complete(co)
+	rspan.End()
 	// Discard pending request now that it has been served and exit
 	delete(client.requests.pendingCancelAllOrdersAfterXRequests, *co.RequestId)
 	span.SetStatus(codes.Ok, codes.Ok.String())
@@ -4201,12 +5112,12 @@ func (client *krakenSpotWebsocketClient) handleCancelAllOrdersAfterXStatus(
 //
 //   - ctx: Context used for tracing and coordination purpose. Will be provided to the pending request.
 //   - req: Subscribe request to send. Must not be nil
-//   - errChan: Channel provided to the pending request. Will be used to publish the results.
+//   - result: Future provided to the pending request. Will be used to publish the results.
 //
 // # Return
 //
 // An error if the request cannot be sent.
-func (client *krakenSpotWebsocketClient) sendSubscribeRequest(ctx context.Context, req *messages.Subscribe, errChan chan error) error {
+func (client *krakenSpotWebsocketClient) sendSubscribeRequest(ctx context.Context, req *messages.Subscribe, result *Future[struct{}]) error {
 	// Tracing: Prepare span attributes
 	reqAttr := []attribute.KeyValue{
 		attribute.String("type", req.Subscription.Name),
@@ -4240,20 +5151,23 @@ func (client *krakenSpotWebsocketClient) sendSubscribeRequest(ctx context.Contex
 	client.pendingSubscribeMu.Lock() // Lock to not add requests while engine is discarding pending requests
 	defer client.pendingSubscribeMu.Unlock()
 	client.requests.pendingSubscribe[req.ReqId] = &pendingSubscribe{
+		session:    client.sessions.current(),
+		span:       trace.SpanContextFromContext(ctx),
+		tags:       requesttag.Attributes(ctx),
 		pairs:      req.Pairs,
 		served:     map[string]bool{},
 		errPerPair: map[string]error{},
-		err:        errChan,
+		result:     result,
 	}
 	// Marshal to JSON
-	payload, err := json.Marshal(req)
+	payload, err := client.codec.Marshal(req)
 	if err != nil {
 		// Remove pending request as it has failed before it even starts
 		delete(client.requests.pendingSubscribe, req.ReqId)
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("failed to format subscribe request: %w", err))
 	}
 	// Send message to websocket server
-	err = client.conn.Write(ctx, wsadapters.Text, payload)
+	err = client.write(ctx, payload)
 	if err != nil {
 		// Remove pending request as it has failed before it even starts
 		delete(client.requests.pendingSubscribe, req.ReqId)
@@ -4276,12 +5190,12 @@ func (client *krakenSpotWebsocketClient) sendSubscribeRequest(ctx context.Contex
 //
 //   - ctx: Context used for tracing and coordination purpose. Will be provided to the pending request.
 //   - req: Unsubscribe request to send. Must not be nil
-//   - errChan: Channel provided to the pending request. Will be used to publish the results.
+//   - result: Future provided to the pending request. Will be used to publish the results.
 //
 // # Return
 //
 // An error if the request cannot be sent.
-func (client *krakenSpotWebsocketClient) sendUnsubscribeRequest(ctx context.Context, req *messages.Unsubscribe, errChan chan error) error {
+func (client *krakenSpotWebsocketClient) sendUnsubscribeRequest(ctx context.Context, req *messages.Unsubscribe, result *Future[struct{}]) error {
 	// Tracing: Prepare span attributes
 	reqAttr := []attribute.KeyValue{
 		attribute.String("type", req.Subscription.Name),
@@ -4304,21 +5218,24 @@ func (client *krakenSpotWebsocketClient) sendUnsubscribeRequest(ctx context.Cont
 	client.pendingUnsubscribeMu.Lock() // Lock to not add requests while engine is discarding pending requests
 	defer client.pendingUnsubscribeMu.Unlock()
 	client.requests.pendingUnsubscribe[req.ReqId] = &pendingUnsubscribe{
+		session:    client.sessions.current(),
+		span:       trace.SpanContextFromContext(ctx),
+		tags:       requesttag.Attributes(ctx),
 		pairs:      req.Pairs,
 		served:     map[string]bool{},
 		errPerPair: map[string]error{},
-		err:        errChan,
+		result:     result,
 	}
 	client.logger.Println("send unsubscribe request for: ", req.Subscription.Name)
 	// Marshal to JSON
-	payload, err := json.Marshal(req)
+	payload, err := client.codec.Marshal(req)
 	if err != nil {
 		// Remove pending request as it has failed before it even starts
 		delete(client.requests.pendingUnsubscribe, req.ReqId)
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("failed to format unsubscribe request: %w", err))
 	}
 	// Send message to websocket server
-	err = client.conn.Write(ctx, wsadapters.Text, payload)
+	err = client.write(ctx, payload)
 	if err != nil {
 		// Remove pending request as it has failed before it even starts
 		delete(client.requests.pendingUnsubscribe, req.ReqId)
@@ -4355,8 +5272,8 @@ func (client *krakenSpotWebsocketClient) resubscribeTicker(ctx context.Context,
 			attribute.StringSlice("pairs", pairs),
 		))
 	defer span.End()
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send subscribe message to server
 	err := client.sendSubscribeRequest(
 		ctx,
@@ -4368,20 +5285,20 @@ func (client *krakenSpotWebsocketClient) resubscribeTicker(ctx context.Context,
 				Name: string(messages.ChannelTicker),
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		fmt.Println("resubscribe failed", err.Error())
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("resubscribe ticker failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	select {
 	case <-ctx.Done():
 		// Trace and return error - Use an operation itnerrupted error as request has been sent to the server
 		fmt.Println("resubscribe failed", err.Error())
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "resubscribe_ticker", Root: fmt.Errorf("subscribe ticker failed: %w", err)})
-	case err := <-errChan:
-		if err != nil && !strings.Contains(strings.ToLower(err.Error()), "already subscribed") {
+	case <-result.Done():
+		if _, err := result.get(); err != nil && !strings.Contains(strings.ToLower(err.Error()), "already subscribed") {
 			fmt.Println("resubscribe failed", err.Error())
 			// Trace and return error - Use an operation error as the error was caused by an error emssage from the server.
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "resubscribe_ticker", Root: fmt.Errorf("subscribe ticker failed: %w", err)})
@@ -4420,8 +5337,8 @@ func (client *krakenSpotWebsocketClient) resubscribeOHLC(ctx context.Context, pa
 			attribute.Int("interval", int(interval)),
 		))
 	defer span.End()
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send subscribe message to server
 	err := client.sendSubscribeRequest(
 		ctx,
@@ -4434,18 +5351,18 @@ func (client *krakenSpotWebsocketClient) resubscribeOHLC(ctx context.Context, pa
 				Interval: int(interval),
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("resubscribe ohlc failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	select {
 	case <-ctx.Done():
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "resubscribe_ohlc", Root: fmt.Errorf("resubscribe ohlc failed: %w", err)})
-	case err := <-errChan:
-		if err != nil && !strings.Contains(strings.ToLower(err.Error()), "already subscribed") {
+	case <-result.Done():
+		if _, err := result.get(); err != nil && !strings.Contains(strings.ToLower(err.Error()), "already subscribed") {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "resubscribe_ohlc", Root: fmt.Errorf("resubscribe ohlc failed: %w", err)})
 		}
@@ -4479,8 +5396,8 @@ func (client *krakenSpotWebsocketClient) resubscribeTrade(ctx context.Context, p
 			attribute.StringSlice("pairs", pairs),
 		))
 	defer span.End()
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send subscribe message to server
 	err := client.sendSubscribeRequest(
 		ctx,
@@ -4492,18 +5409,18 @@ func (client *krakenSpotWebsocketClient) resubscribeTrade(ctx context.Context, p
 				Name: string(messages.ChannelTrade),
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("resubscribe trade failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	select {
 	case <-ctx.Done():
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "resubscribe_trade", Root: fmt.Errorf("resubscribe trade failed: %w", err)})
-	case err := <-errChan:
-		if err != nil && !strings.Contains(strings.ToLower(err.Error()), "already subscribed") {
+	case <-result.Done():
+		if _, err := result.get(); err != nil && !strings.Contains(strings.ToLower(err.Error()), "already subscribed") {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "resubscribe_trade", Root: fmt.Errorf("resubscribe trade failed: %w", err)})
 		}
@@ -4537,8 +5454,8 @@ func (client *krakenSpotWebsocketClient) resubscribeSpread(ctx context.Context,
 			attribute.StringSlice("pairs", pairs),
 		))
 	defer span.End()
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send subscribe message to server
 	err := client.sendSubscribeRequest(
 		ctx,
@@ -4550,18 +5467,18 @@ func (client *krakenSpotWebsocketClient) resubscribeSpread(ctx context.Context,
 				Name: string(messages.ChannelSpread),
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("resubscribe spread failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	select {
 	case <-ctx.Done():
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "resubscribe_spread", Root: fmt.Errorf("resubscribe spread failed: %w", err)})
-	case err := <-errChan:
-		if err != nil && !strings.Contains(strings.ToLower(err.Error()), "already subscribed") {
+	case <-result.Done():
+		if _, err := result.get(); err != nil && !strings.Contains(strings.ToLower(err.Error()), "already subscribed") {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "resubscribe_spread", Root: fmt.Errorf("resubscribe spread failed: %w", err)})
 		}
@@ -4597,8 +5514,8 @@ func (client *krakenSpotWebsocketClient) resubscribeBook(ctx context.Context, pa
 			attribute.Int("depth", int(depth)),
 		))
 	defer span.End()
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Send subscribe message to server
 	err := client.sendSubscribeRequest(
 		ctx,
@@ -4611,18 +5528,18 @@ func (client *krakenSpotWebsocketClient) resubscribeBook(ctx context.Context, pa
 				Depth: int(depth),
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("resubscribe book failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	select {
 	case <-ctx.Done():
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "resubscribe_book", Root: fmt.Errorf("resubscribe book failed: %w", err)})
-	case err := <-errChan:
-		if err != nil && !strings.Contains(strings.ToLower(err.Error()), "already subscribed") {
+	case <-result.Done():
+		if _, err := result.get(); err != nil && !strings.Contains(strings.ToLower(err.Error()), "already subscribed") {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "resubscribe_book", Root: fmt.Errorf("resubscribe book failed: %w", err)})
 		}
@@ -4658,8 +5575,8 @@ func (client *krakenSpotWebsocketClient) resubscribeOwnTrades(ctx context.Contex
 			attribute.Bool("consolidate_taker", consolidateTaker),
 		))
 	defer span.End()
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Get websocket token
 	token, err := client.getWebsocketToken(ctx)
 	if err != nil {
@@ -4679,18 +5596,18 @@ func (client *krakenSpotWebsocketClient) resubscribeOwnTrades(ctx context.Contex
 				Token:            token,
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("resubscribe own trades failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	select {
 	case <-ctx.Done():
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "resubscribe_own_trades", Root: fmt.Errorf("resubscribe own trades failed: %w", err)})
-	case err := <-errChan:
-		if err != nil && !strings.Contains(strings.ToLower(err.Error()), "already subscribed") {
+	case <-result.Done():
+		if _, err := result.get(); err != nil && !strings.Contains(strings.ToLower(err.Error()), "already subscribed") {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "resubscribe_own_trades", Root: fmt.Errorf("resubscribe own trades failed: %w", err)})
 		}
@@ -4724,8 +5641,8 @@ func (client *krakenSpotWebsocketClient) resubscribeOpenOrders(ctx context.Conte
 			attribute.Bool("rate_counter", rateCounter),
 		))
 	defer span.End()
-	// Create response channels
-	errChan := make(chan error, 1)
+	// Create the future used to get the response
+	result := NewFuture[struct{}]()
 	// Get websocket token
 	token, err := client.getWebsocketToken(ctx)
 	if err != nil {
@@ -4744,18 +5661,18 @@ func (client *krakenSpotWebsocketClient) resubscribeOpenOrders(ctx context.Conte
 				Token:       token,
 			},
 		},
-		errChan)
+		result)
 	if err != nil {
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("resubscribe open orders failed: %w", err))
 	}
-	// Wait for response to be published on channels or timeout
+	// Wait for the future to be resolved or timeout
 	select {
 	case <-ctx.Done():
 		// Trace and return error
 		return tracing.HandleAndTraLogError(span, client.logger, &OperationInterruptedError{Operation: "resubscribe_open_orders", Root: fmt.Errorf("resubscribe open orders failed: %w", err)})
-	case err := <-errChan:
-		if err != nil && !strings.Contains(strings.ToLower(err.Error()), "already subscribed") {
+	case <-result.Done():
+		if _, err := result.get(); err != nil && !strings.Contains(strings.ToLower(err.Error()), "already subscribed") {
 			// Trace and return error
 			return tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "resubscribe_open_orders", Root: fmt.Errorf("resubscribe open orders failed: %w", err)})
 		}
@@ -4790,25 +5707,54 @@ func (client *krakenSpotWebsocketClient) getWebsocketToken(ctx context.Context)
 	client.tokenMu.Lock()
 	defer client.tokenMu.Unlock()
 	// Check if a token is cached and is still valid
-	now := time.Now()
-	if client.token == "" || client.tokenExpiresAt.Compare(now) >= 0 {
+	if client.token == "" || !client.clock.Now().Before(client.tokenExpiresAt) {
 		// Acquire a new token
-		client.logger.Println("requesting new websocket token")
-		resp, _, err := client.restClient.GetWebsocketToken(ctx, client.cgen.GenerateNonce(), client.secopts)
-		if err != nil {
-			// Trace and return error
-			return "", tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("get websocket token failed: %w", err))
-		}
-		if len(resp.Error) > 0 || resp.Result == nil {
+		if err := client.refreshWebsocketTokenLocked(ctx, 0); err != nil {
 			// Trace and return error
-			return "", tracing.HandleAndTraLogError(span, client.logger, &OperationError{Operation: "get_websocket_token", Root: fmt.Errorf("get websocket token failed: %v", resp.Error)})
+			return "", tracing.HandleAndTraLogError(span, client.logger, err)
 		}
-		// Cache token & set expire (substract 5 seconds to be sure to refresh the token before it really expire)
-		client.token = resp.Result.Token
-		client.tokenExpiresAt = now.Add(time.Duration(resp.Result.Expires-5) * time.Second)
-		client.logger.Println("websocket token refreshed")
 	}
 	// Return cached token
 	span.SetStatus(codes.Ok, codes.Ok.String())
 	return client.token, nil
 }
+
+// # Description
+//
+// Request a new websocket token, cache it and update the token state. Token mutex must be held by
+// the caller.
+//
+// If a token provider has been set, the token is requested from the provider which may return a
+// shared cached token. Otherwise, a new token is requested with the REST client.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing/coordination purpose
+//   - minValidity: Minimum remaining validity of the token returned by the token provider. Not used without token provider.
+//
+// # Return
+//
+// An error if the token could not be refreshed. An error will be returned when:
+//
+//   - The provided context has expired
+//   - The request could not be sent (formatting or connection issue)
+//   - The server replied with an error (OperationError)
+func (client *krakenSpotWebsocketClient) refreshWebsocketTokenLocked(ctx context.Context, minValidity time.Duration) error {
+	now := client.clock.Now()
+	if client.tokenSource == nil {
+		client.tokenLastError = &OperationError{Operation: "get_websocket_token", Root: fmt.Errorf("no websocket token source: client can only use public endpoints")}
+		return client.tokenLastError
+	}
+	token, expiresAt, err := client.tokenSource.getWebsocketToken(ctx, client.logger, now, minValidity)
+	if err != nil {
+		client.tokenLastError = err
+		return client.tokenLastError
+	}
+	client.token = token
+	client.tokenExpiresAt = expiresAt
+	client.tokenLastRefreshAt = now
+	client.tokenLastError = nil
+	client.tokenRefreshCount++
+	client.logger.Println("websocket token refreshed")
+	return nil
+}
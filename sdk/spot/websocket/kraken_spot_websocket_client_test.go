@@ -0,0 +1,497 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/clienttag"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/codec"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/fixtures"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/requesttag"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for krakenSpotWebsocketClient
+type KrakenSpotWebsocketClientUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestKrakenSpotWebsocketClientUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(KrakenSpotWebsocketClientUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test a client without websocket token source (public client) cannot use private endpoints.
+func (suite *KrakenSpotWebsocketClientUnitTestSuite) TestNoWebsocketTokenSource() {
+	client := NewKrakenSpotPublicWebsocketClient(nil, nil, nil, nil, nil)
+	_, err := client.getWebsocketToken(context.Background())
+	operr := new(OperationError)
+	require.ErrorAs(suite.T(), err, &operr)
+	require.Equal(suite.T(), "get_websocket_token", operr.Operation)
+}
+
+// Test dropped messages counters and callback.
+//
+// Test will ensure:
+//   - Oldest heartbeats and system statuses are discarded when built-in channels are full.
+//   - Discarded messages are counted by type.
+//   - The OnDroppedMessage callback is called with the type and total count of dropped messages.
+//   - Discarded messages are recorded with the meter provider set with SetMeterProvider.
+func (suite *KrakenSpotWebsocketClientUnitTestSuite) TestDroppedMessages() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	meter := &recordingMeter{counts: map[string]int64{}}
+	client.SetMeterProvider(&recordingMeterProvider{meter: meter})
+	type drop struct {
+		eventType events.WebsocketClientEventTypeEnum
+		count     uint64
+	}
+	drops := []drop{}
+	client.SetOnDroppedMessageCallback(func(eventType events.WebsocketClientEventTypeEnum, count uint64) {
+		drops = append(drops, drop{eventType, count})
+	})
+	heartbeat := []byte(fixtures.WebsocketHeartbeat)
+	status := []byte(`{"connectionID":8628615390848610000,"event":"systemStatus","status":"online","version":"1.0.0"}`)
+	// Fill channels - no drop
+	for i := 0; i < cap(client.GetHeartbeatChannel()); i++ {
+		require.NoError(suite.T(), client.handleHeartbeat(context.Background(), nil, nil, nil, nil, "", 0, heartbeat))
+		require.NoError(suite.T(), client.handleSystemStatus(context.Background(), nil, nil, nil, nil, "", 0, status))
+	}
+	require.Empty(suite.T(), drops)
+	require.Zero(suite.T(), client.GetDroppedMessagesCount(events.Heartbeat))
+	// Overflow channels
+	require.NoError(suite.T(), client.handleHeartbeat(context.Background(), nil, nil, nil, nil, "", 0, heartbeat))
+	require.NoError(suite.T(), client.handleHeartbeat(context.Background(), nil, nil, nil, nil, "", 0, heartbeat))
+	require.NoError(suite.T(), client.handleSystemStatus(context.Background(), nil, nil, nil, nil, "", 0, status))
+	require.Equal(suite.T(), uint64(2), client.GetDroppedMessagesCount(events.Heartbeat))
+	require.Equal(suite.T(), uint64(1), client.GetDroppedMessagesCount(events.SystemStatus))
+	require.Zero(suite.T(), client.GetDroppedMessagesCount(events.Trade))
+	require.Equal(suite.T(), []drop{
+		{events.Heartbeat, 1},
+		{events.Heartbeat, 2},
+		{events.SystemStatus, 1},
+	}, drops)
+	require.Len(suite.T(), client.GetHeartbeatChannel(), cap(client.GetHeartbeatChannel()))
+	require.Equal(suite.T(), map[string]int64{string(events.Heartbeat): 2, string(events.SystemStatus): 1}, meter.counts)
+	// Remove callback
+	client.SetOnDroppedMessageCallback(nil)
+	require.NoError(suite.T(), client.handleHeartbeat(context.Background(), nil, nil, nil, nil, "", 0, heartbeat))
+	require.Len(suite.T(), drops, 3)
+	require.Equal(suite.T(), uint64(3), client.GetDroppedMessagesCount(events.Heartbeat))
+}
+
+// Test error messages without request ID.
+//
+// Test will ensure:
+//   - Error messages without request ID are published on the general error channel and are not
+//     escalated to OnReadError.
+//   - Malformed error messages are escalated to OnReadError.
+//   - Oldest general errors are discarded when the channel is full.
+func (suite *KrakenSpotWebsocketClientUnitTestSuite) TestGeneralErrors() {
+	readErrors := []error{}
+	client := newKrakenSpotWebsocketClient(nil, nil, func(ctx context.Context, restart, exit context.CancelFunc, err error) {
+		readErrors = append(readErrors, err)
+	}, nil, nil, nil)
+	msg := []byte(`{"event":"error","errorMessage":"Service unavailable"}`)
+	require.NoError(suite.T(), client.handleErrorMessage(context.Background(), nil, nil, nil, nil, "", 0, msg))
+	require.Empty(suite.T(), readErrors)
+	require.Len(suite.T(), client.GetGeneralErrorChannel(), 1)
+	e := <-client.GetGeneralErrorChannel()
+	require.Equal(suite.T(), string(events.GeneralError), e.Type())
+	errMsg := new(messages.ErrorMessage)
+	require.NoError(suite.T(), e.DataAs(errMsg))
+	require.Equal(suite.T(), "Service unavailable", errMsg.Err)
+	require.Nil(suite.T(), errMsg.ReqId)
+	// Malformed payload
+	require.Error(suite.T(), client.handleErrorMessage(context.Background(), nil, nil, nil, nil, "", 0, []byte(`{"event":"error","errorMessage":42}`)))
+	require.Len(suite.T(), readErrors, 1)
+	require.Empty(suite.T(), client.GetGeneralErrorChannel())
+	// Congestion
+	for i := 0; i <= cap(client.GetGeneralErrorChannel()); i++ {
+		require.NoError(suite.T(), client.handleErrorMessage(context.Background(), nil, nil, nil, nil, "", 0, msg))
+	}
+	require.Len(suite.T(), client.GetGeneralErrorChannel(), cap(client.GetGeneralErrorChannel()))
+	require.Equal(suite.T(), uint64(1), client.GetDroppedMessagesCount(events.GeneralError))
+}
+
+// Test the client uses the JSON codec set with SetJSONCodec to parse server responses.
+func (suite *KrakenSpotWebsocketClientUnitTestSuite) TestSetJSONCodec() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	require.Equal(suite.T(), codec.StandardJSONCodec{}, client.codec)
+	counting := &countingJSONCodec{}
+	client.SetJSONCodec(counting)
+	// Register a pending ping and handle the pong
+	pr := &pendingPing{result: NewFuture[*messages.Pong]()}
+	client.requests.pendingPing[42] = pr
+	require.NoError(suite.T(), client.handlePong(context.Background(), nil, nil, nil, nil, "", 0, []byte(`{"event":"pong","reqid":42}`)))
+	require.Equal(suite.T(), 1, counting.unmarshals)
+	pong, err := pr.result.Await(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), int64(42), *pong.ReqId)
+	// Nil resets the default codec
+	client.SetJSONCodec(nil)
+	require.Equal(suite.T(), codec.StandardJSONCodec{}, client.codec)
+}
+
+// Test the handling of a response is related to the span of the request.
+//
+// Test will ensure:
+//   - A response span is started as a child of the request span when a pending request is fulfilled.
+//   - The response span is linked to the span of the response handler.
+//   - Error responses are related to the request span as well.
+func (suite *KrakenSpotWebsocketClientUnitTestSuite) TestResponseSpanLinkage() {
+	tp := newRecordingTracerProvider()
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, tp)
+	// Register a pending add order request from a request span
+	ctx, reqSpan := client.tracer.Start(context.Background(), "add_order")
+	client.requests.pendingAddOrderRequests[1] = &pendingAddOrderRequest{
+		span:   trace.SpanContextFromContext(ctx),
+		result: NewFuture[*messages.AddOrderResponse](),
+	}
+	reqSpan.End()
+	require.NoError(suite.T(), client.handleAddOrderStatus(context.Background(), nil, nil, nil, nil, "", 0, []byte(`{"event":"addOrderStatus","reqid":1,"status":"ok","txid":"OXYZ"}`)))
+	handler := tp.find("handle_add_order_status")
+	response := tp.find("add_order_response")
+	require.NotNil(suite.T(), handler)
+	require.NotNil(suite.T(), response)
+	require.Equal(suite.T(), reqSpan.SpanContext(), response.parent)
+	require.Len(suite.T(), response.links, 1)
+	require.Equal(suite.T(), handler.SpanContext(), response.links[0].SpanContext)
+	// Error response
+	ctx, reqSpan = client.tracer.Start(context.Background(), "ping")
+	client.requests.pendingPing[2] = &pendingPing{
+		span:   trace.SpanContextFromContext(ctx),
+		result: NewFuture[*messages.Pong](),
+	}
+	reqSpan.End()
+	require.NoError(suite.T(), client.handleErrorMessage(context.Background(), nil, nil, nil, nil, "", 0, []byte(`{"event":"error","errorMessage":"Unsupported event","reqid":2}`)))
+	response = tp.find("ping_response")
+	require.NotNil(suite.T(), response)
+	require.Equal(suite.T(), reqSpan.SpanContext(), response.parent)
+}
+
+// Test the client tag is added to every span started by the client.
+//
+// Test will ensure:
+//   - Spans have the client tag attribute once SetClientTag has been used.
+//   - Setting a new tag replaces the previous one and an empty tag removes it.
+func (suite *KrakenSpotWebsocketClientUnitTestSuite) TestClientTag() {
+	tp := newRecordingTracerProvider()
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, tp)
+	client.SetClientTag("bot-a")
+	client.SetClientTag("bot-b")
+	client.handleHeartbeat(context.Background(), nil, nil, nil, nil, "", 0, []byte(fixtures.WebsocketHeartbeat))
+	span := tp.find("handle_heartbeat")
+	require.NotNil(suite.T(), span)
+	require.Contains(suite.T(), span.attributes, clienttag.AttributeKey.String("bot-b"))
+	require.NotContains(suite.T(), span.attributes, clienttag.AttributeKey.String("bot-a"))
+	// Remove tag
+	client.SetClientTag("")
+	client.handleHeartbeat(context.Background(), nil, nil, nil, nil, "", 0, []byte(fixtures.WebsocketHeartbeat))
+	for _, attr := range tp.find("handle_heartbeat").attributes {
+		require.NotEqual(suite.T(), clienttag.AttributeKey, attr.Key)
+	}
+}
+
+// Test request tags are propagated to spans and pending requests.
+//
+// Test will ensure:
+//   - Spans started by the client have the tags of the request context.
+//   - The response span of a pending request has the tags of the request even if the response
+//     handler context has no tags.
+func (suite *KrakenSpotWebsocketClientUnitTestSuite) TestRequestTags() {
+	tp := newRecordingTracerProvider()
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, tp)
+	client.SetClientTag("bot-a")
+	ctx := requesttag.WithTag(context.Background(), "strategy", "mm")
+	ctx, reqSpan := client.tracer.Start(ctx, "add_order")
+	reqSpan.End()
+	require.Contains(suite.T(), tp.find("add_order").attributes, attribute.String("goctopus.tag.strategy", "mm"))
+	require.Contains(suite.T(), tp.find("add_order").attributes, clienttag.AttributeKey.String("bot-a"))
+	client.requests.pendingAddOrderRequests[1] = &pendingAddOrderRequest{
+		span:   trace.SpanContextFromContext(ctx),
+		tags:   requesttag.Attributes(ctx),
+		result: NewFuture[*messages.AddOrderResponse](),
+	}
+	require.NoError(suite.T(), client.handleAddOrderStatus(context.Background(), nil, nil, nil, nil, "", 0, []byte(`{"event":"addOrderStatus","reqid":1,"status":"ok","txid":"OXYZ"}`)))
+	require.Contains(suite.T(), tp.find("add_order_response").attributes, attribute.String("goctopus.tag.strategy", "mm"))
+	require.NotContains(suite.T(), tp.find("handle_add_order_status").attributes, attribute.String("goctopus.tag.strategy", "mm"))
+}
+
+// Test channels are closed on unsubscribe unless the client is configured to keep them open.
+//
+// Test will ensure:
+//   - Channels are closed by default.
+//   - Channels are left open when SetKeepChannelsOpenOnUnsubscribe(true) has been used.
+//   - Internal channels are always closed.
+//   - Nil channels (raw subscriptions) are ignored.
+func (suite *KrakenSpotWebsocketClientUnitTestSuite) TestCloseOnUnsubscribe() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	pub := make(chan event.Event)
+	client.closeOnUnsubscribe(context.Background(), pub, false, "ticker")
+	_, ok := <-pub
+	require.False(suite.T(), ok)
+	client.closeOnUnsubscribe(context.Background(), nil, false, "ticker")
+	// Keep channels open
+	client.SetKeepChannelsOpenOnUnsubscribe(true)
+	pub = make(chan event.Event, 1)
+	client.closeOnUnsubscribe(context.Background(), pub, false, "ticker")
+	pub <- event.New()
+	require.Len(suite.T(), pub, 1)
+	// Internal channels are always closed
+	internal := make(chan event.Event)
+	client.closeOnUnsubscribe(context.Background(), internal, true, "trade")
+	_, ok = <-internal
+	require.False(suite.T(), ok)
+	client.closeOnUnsubscribe(context.Background(), nil, true, "trade")
+}
+
+// Test book subscriptions for different depths can be active at the same time.
+//
+// Test will ensure:
+//   - A second subscription for the same depth is rejected.
+//   - Book messages are routed to the subscription matching the depth of the channel name.
+//   - Raw book messages are routed by depth.
+//   - Book events are published on the event bus under a topic which includes the depth.
+//   - Unsubscribing from a depth leaves the other depths untouched.
+//   - UnsubscribeBook unsubscribes from all depths.
+func (suite *KrakenSpotWebsocketClientUnitTestSuite) TestMultiDepthBook() {
+	client := &KrakenSpotPublicWebsocketClient{newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)}
+	conn := newConnectionMock()
+	conn.On("Write", mock.Anything, wsadapters.Text, mock.Anything).Run(func(args mock.Arguments) {
+		req := new(messages.Subscribe)
+		require.NoError(suite.T(), json.Unmarshal(args.Get(2).([]byte), req))
+		resp := fmt.Sprintf(`{"channelName":"book-%d","event":"%sStatus","pair":"XBT/USD","reqid":%d,"status":"%sd","subscription":{"depth":%d,"name":"book"}}`,
+			req.Subscription.Depth, req.Event, req.ReqId, req.Event, req.Subscription.Depth)
+		go client.handleSubscriptionStatus(context.Background(), nil, nil, nil, nil, "s1", 0, []byte(resp))
+	}).Return(nil)
+	client.conn = conn
+	d10 := make(chan event.Event, 1)
+	d100 := make(chan event.Event, 1)
+	require.NoError(suite.T(), client.SubscribeBook(context.Background(), []string{"XBT/USD"}, messages.D10, d10))
+	require.NoError(suite.T(), client.SubscribeBook(context.Background(), []string{"XBT/USD"}, messages.D100, d100))
+	require.Error(suite.T(), client.SubscribeBook(context.Background(), []string{"XBT/USD"}, messages.D10, d10))
+	topic, err := client.SubscribeTopic(BookTopic(messages.D100, "XBT/USD"), 10)
+	require.NoError(suite.T(), err)
+	defer topic.Unsubscribe()
+	// Route messages by depth
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "s1", wsadapters.Text, []byte(`[0,{"as":[],"bs":[]},"book-100","XBT/USD"]`))
+	require.Len(suite.T(), d100, 1)
+	require.Empty(suite.T(), d10)
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "s1", wsadapters.Text, []byte(`[0,{"as":[],"bs":[]},"book-10","XBT/USD"]`))
+	require.Len(suite.T(), d10, 1)
+	require.Equal(suite.T(), string(events.BookSnapshot), (<-d10).Type())
+	require.Equal(suite.T(), string(events.BookSnapshot), (<-d100).Type())
+	require.Len(suite.T(), topic.C, 1)
+	<-topic.C
+	// Raw book messages are routed by depth
+	received := []string{}
+	client.bookSubMu.Lock()
+	client.storeRawBook(messages.D25, newRawSubscription([]string{"XBT/USD"}, func(pair string, payload []byte) {
+		received = append(received, pair)
+	}))
+	client.bookSubMu.Unlock()
+	require.True(suite.T(), client.dispatchRawMessage("s1", []byte(`[0,{"as":[],"bs":[]},"book-25","XBT/USD"]`)))
+	require.False(suite.T(), client.dispatchRawMessage("s1", []byte(`[0,{"as":[],"bs":[]},"book-10","XBT/USD"]`)))
+	require.False(suite.T(), client.dispatchRawMessage("s1", []byte(`[0,{"as":[],"bs":[]},"book-x","XBT/USD"]`)))
+	require.Equal(suite.T(), []string{"XBT/USD"}, received)
+	// Unsubscribe from one depth
+	require.NoError(suite.T(), client.UnsubscribeBookDepth(context.Background(), messages.D10))
+	_, ok := <-d10
+	require.False(suite.T(), ok)
+	require.Error(suite.T(), client.UnsubscribeBookDepth(context.Background(), messages.D10))
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "s1", wsadapters.Text, []byte(`[0,{"as":[],"bs":[]},"book-100","XBT/USD"]`))
+	require.Len(suite.T(), d100, 1)
+	require.Equal(suite.T(), []SubscriptionState{{Channel: messages.ChannelBook, Pairs: []string{"XBT/USD"}, Depth: messages.D100}}, client.GetSubscriptionStates())
+	// Unsubscribe from all depths
+	require.NoError(suite.T(), client.SubscribeBook(context.Background(), []string{"XBT/USD"}, messages.D10, make(chan event.Event, 1)))
+	require.NoError(suite.T(), client.UnsubscribeBook(context.Background()))
+	require.Empty(suite.T(), client.GetSubscriptionStates())
+	require.Error(suite.T(), client.UnsubscribeBook(context.Background()))
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// JSON codec which counts calls and delegates to encoding/json.
+type countingJSONCodec struct {
+	codec.StandardJSONCodec
+	// Number of calls to Unmarshal
+	unmarshals int
+}
+
+// Count the call and unmarshal with encoding/json.
+func (c *countingJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	c.unmarshals++
+	return c.StandardJSONCodec.Unmarshal(data, v)
+}
+
+// Tracer provider which records the spans started by its tracers. Spans do not record any data
+// besides their name, parent and links.
+type recordingTracerProvider struct {
+	trace.TracerProvider
+	// Mutex used to protect spans
+	mu sync.Mutex
+	// Started spans
+	spans []*recordedSpan
+	// Counter used to generate span IDs
+	next byte
+}
+
+// Build a new recording tracer provider.
+func newRecordingTracerProvider() *recordingTracerProvider {
+	return &recordingTracerProvider{TracerProvider: trace.NewNoopTracerProvider()}
+}
+
+// Get a tracer which records spans in the provider.
+func (tp *recordingTracerProvider) Tracer(name string, options ...trace.TracerOption) trace.Tracer {
+	return &recordingTracer{Tracer: tp.TracerProvider.Tracer(name, options...), tp: tp}
+}
+
+// Get the last started span with the provided name. Nil if not found.
+func (tp *recordingTracerProvider) find(name string) *recordedSpan {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	for i := len(tp.spans) - 1; i >= 0; i-- {
+		if tp.spans[i].name == name {
+			return tp.spans[i]
+		}
+	}
+	return nil
+}
+
+// Tracer which records started spans in its provider.
+type recordingTracer struct {
+	trace.Tracer
+	// Provider where spans are recorded
+	tp *recordingTracerProvider
+}
+
+// Start and record a span. The span belongs to the trace of its parent if any.
+func (t *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	cfg := trace.NewSpanStartConfig(opts...)
+	parent := trace.SpanContextFromContext(ctx)
+	t.tp.mu.Lock()
+	defer t.tp.mu.Unlock()
+	t.tp.next++
+	traceId := parent.TraceID()
+	if !parent.IsValid() {
+		traceId = trace.TraceID{t.tp.next}
+	}
+	span := &recordedSpan{
+		Span:       trace.SpanFromContext(context.Background()),
+		name:       name,
+		parent:     parent,
+		links:      cfg.Links(),
+		attributes: cfg.Attributes(),
+		sc: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceId,
+			SpanID:     trace.SpanID{t.tp.next},
+			TraceFlags: trace.FlagsSampled,
+		}),
+	}
+	t.tp.spans = append(t.tp.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+// Span recorded by a recordingTracer.
+type recordedSpan struct {
+	trace.Span
+	// Span name
+	name string
+	// Span context
+	sc trace.SpanContext
+	// Span context of the parent span
+	parent trace.SpanContext
+	// Span links
+	links []trace.Link
+	// Span attributes
+	attributes []attribute.KeyValue
+}
+
+// Get the span context.
+func (s *recordedSpan) SpanContext() trace.SpanContext {
+	return s.sc
+}
+
+// Meter provider which provides a recordingMeter.
+type recordingMeterProvider struct {
+	noop.MeterProvider
+	meter *recordingMeter
+}
+
+// Return the recording meter.
+func (p *recordingMeterProvider) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return p.meter
+}
+
+// Meter which provides instruments recording their measurements.
+type recordingMeter struct {
+	noop.Meter
+	mu sync.Mutex
+	// Sum of the values added to the counters by event_type attribute
+	counts map[string]int64
+	// Values recorded by the histograms by source attribute
+	samples map[string][]float64
+}
+
+// Return a counter which records the added values in the meter.
+func (m *recordingMeter) Int64Counter(name string, options ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return &recordingCounter{meter: m}, nil
+}
+
+// Return a histogram which records the values in the meter.
+func (m *recordingMeter) Float64Histogram(name string, options ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return &recordingHistogram{meter: m}, nil
+}
+
+// Counter created by a recordingMeter.
+type recordingCounter struct {
+	noop.Int64Counter
+	meter *recordingMeter
+}
+
+// Record the added value by event_type attribute.
+func (c *recordingCounter) Add(ctx context.Context, incr int64, options ...metric.AddOption) {
+	attrs := metric.NewAddConfig(options).Attributes()
+	eventType, _ := attrs.Value("event_type")
+	c.meter.mu.Lock()
+	defer c.meter.mu.Unlock()
+	c.meter.counts[eventType.AsString()] += incr
+}
+
+// Histogram created by a recordingMeter.
+type recordingHistogram struct {
+	noop.Float64Histogram
+	meter *recordingMeter
+}
+
+// Record the value by source attribute.
+func (h *recordingHistogram) Record(ctx context.Context, value float64, options ...metric.RecordOption) {
+	attrs := metric.NewRecordConfig(options).Attributes()
+	source, _ := attrs.Value("source")
+	h.meter.mu.Lock()
+	defer h.meter.mu.Unlock()
+	h.meter.samples[source.AsString()] = append(h.meter.samples[source.AsString()], value)
+}
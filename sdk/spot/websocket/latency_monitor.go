@@ -0,0 +1,286 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name of the histogram used to record latencies measured by the latency monitor (milliseconds).
+const LatencyMetricName = "goctopus.sdk.spot.websocket.latency"
+
+// Default interval between two pings sent by the latency monitor.
+const DefaultLatencyPingInterval = 10 * time.Second
+
+// Default number of samples kept by the latency monitor to compute rolling statistics.
+const DefaultLatencyWindowSize = 100
+
+// Enum for the sources of the latencies measured by the latency monitor.
+type LatencySourceEnum string
+
+// Values for LatencySourceEnum
+const (
+	// Round-trip time of a ping/pong exchange with the server.
+	LatencyPingRTT LatencySourceEnum = "ping_rtt"
+	// Delay between the time of the most recent trade of a trade message and the time the message
+	// was received.
+	LatencyTrade LatencySourceEnum = "trade"
+	// Delay between the time of a spread message and the time the message was received.
+	LatencySpread LatencySourceEnum = "spread"
+)
+
+// Rolling latency statistics computed from the most recent samples of a source.
+type LatencyStats struct {
+	// Number of samples used to compute the statistics.
+	Count int
+	// Most recent sample.
+	Last time.Duration
+	// Median of the samples.
+	P50 time.Duration
+	// 95th percentile of the samples.
+	P95 time.Duration
+	// Maximum of the samples.
+	Max time.Duration
+}
+
+// Snapshot of the state of the latency monitor.
+type LatencyState struct {
+	// True if the latency monitor is running.
+	Running bool
+	// Error returned by the last ping. Nil if the last ping has succeeded.
+	LastPingError error
+	// Rolling statistics by source. Sources without samples are omitted.
+	Stats map[LatencySourceEnum]LatencyStats
+}
+
+// Latency monitor state shared by the monitor loop and the message handlers.
+type latencyMonitor struct {
+	// Mutex used to protect the monitor state
+	mu sync.Mutex
+	// Maximum number of samples kept by source
+	windowSize int
+	// Ring buffers of samples by source
+	samples map[LatencySourceEnum][]time.Duration
+	// Index of the next sample to overwrite by source once the ring buffer is full
+	next map[LatencySourceEnum]int
+	// Error returned by the last ping
+	lastPingError error
+}
+
+// Create a new latency monitor which keeps windowSize samples by source.
+func newLatencyMonitor(windowSize int) *latencyMonitor {
+	return &latencyMonitor{
+		mu:         sync.Mutex{},
+		windowSize: windowSize,
+		samples:    map[LatencySourceEnum][]time.Duration{},
+		next:       map[LatencySourceEnum]int{},
+	}
+}
+
+// Add a sample for the source. The oldest sample is overwritten once the window is full.
+func (m *latencyMonitor) record(source LatencySourceEnum, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	samples := m.samples[source]
+	if len(samples) < m.windowSize {
+		m.samples[source] = append(samples, latency)
+		return
+	}
+	samples[m.next[source]] = latency
+	m.next[source] = (m.next[source] + 1) % m.windowSize
+}
+
+// Compute the statistics for the source from the samples in the window.
+func (m *latencyMonitor) stats(source LatencySourceEnum) LatencyStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	samples := m.samples[source]
+	if len(samples) == 0 {
+		return LatencyStats{}
+	}
+	// Most recent sample is right before the next sample to overwrite
+	last := samples[len(samples)-1]
+	if len(samples) == m.windowSize {
+		last = samples[(m.next[source]+m.windowSize-1)%m.windowSize]
+	}
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return LatencyStats{
+		Count: len(sorted),
+		Last:  last,
+		P50:   percentile(sorted, 0.50),
+		P95:   percentile(sorted, 0.95),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// Get the percentile of sorted samples using the nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// # Description
+//
+// Start a latency monitor which periodically pings the server to measure the round-trip time and
+// which compares the timestamps of received trade and spread messages against their local receive
+// time. Ticker messages do not carry a timestamp and are not measured. Messages of raw
+// subscriptions (Cf. SubscribeTradeRaw) are not measured either to keep that path lean.
+//
+// Rolling statistics over the most recent samples can be read with GetLatencyState. Each sample is
+// also recorded in milliseconds with the client meter provider (Cf. LatencyMetricName and
+// SetMeterProvider) with a "source" attribute.
+//
+// Event timestamps are set by Kraken's servers: the measured delays include the clock offset
+// between the servers and the local host.
+//
+// Pings are skipped while the client is not connected. The monitor runs until the provided context
+// is cancelled.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing/coordination purpose. The monitor stops when the context is cancelled.
+//   - interval: Interval between two pings. DefaultLatencyPingInterval is used if interval is not strictly positive.
+//   - windowSize: Number of samples kept by source. DefaultLatencyWindowSize is used if windowSize is not strictly positive.
+//
+// # Return
+//
+// An error if the latency monitor is already running.
+func (client *krakenSpotWebsocketClient) StartLatencyMonitor(ctx context.Context, interval time.Duration, windowSize int) error {
+	// Tracing: Start span
+	ctx, span := client.tracer.Start(ctx, "start_latency_monitor", trace.WithSpanKind(trace.SpanKindInternal))
+	defer span.End()
+	if interval <= 0 {
+		interval = DefaultLatencyPingInterval
+	}
+	if windowSize <= 0 {
+		windowSize = DefaultLatencyWindowSize
+	}
+	monitor := newLatencyMonitor(windowSize)
+	if !client.latency.CompareAndSwap(nil, monitor) {
+		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("latency monitor is already running"))
+	}
+	go client.runLatencyMonitor(ctx, monitor, interval, client.pingIfConnected)
+	span.SetStatus(codes.Ok, codes.Ok.String())
+	return nil
+}
+
+// # Description
+//
+// Get a snapshot of the state of the latency monitor.
+//
+// # Return
+//
+// The current latency state. Running is false and Stats is empty if the monitor has not been
+// started or has stopped.
+func (client *krakenSpotWebsocketClient) GetLatencyState() LatencyState {
+	monitor := client.latency.Load()
+	if monitor == nil {
+		return LatencyState{Running: false, Stats: map[LatencySourceEnum]LatencyStats{}}
+	}
+	state := LatencyState{Running: true, Stats: map[LatencySourceEnum]LatencyStats{}}
+	for _, source := range []LatencySourceEnum{LatencyPingRTT, LatencyTrade, LatencySpread} {
+		if stats := monitor.stats(source); stats.Count > 0 {
+			state.Stats[source] = stats
+		}
+	}
+	monitor.mu.Lock()
+	state.LastPingError = monitor.lastPingError
+	monitor.mu.Unlock()
+	return state
+}
+
+// Background loop which pings the server until the provided context is cancelled.
+func (client *krakenSpotWebsocketClient) runLatencyMonitor(ctx context.Context, monitor *latencyMonitor, interval time.Duration, ping func(ctx context.Context) error) {
+	defer func() {
+		client.latency.CompareAndSwap(monitor, nil)
+		client.logger.Println("latency monitor stopped")
+	}()
+	timer := client.clock.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C():
+			timer.Reset(interval)
+			pingCtx, cancel := context.WithTimeout(ctx, interval)
+			start := client.clock.Now()
+			err := ping(pingCtx)
+			rtt := client.clock.Now().Sub(start)
+			cancel()
+			monitor.mu.Lock()
+			monitor.lastPingError = err
+			monitor.mu.Unlock()
+			if err != nil {
+				client.logger.Printf("latency monitor ping failed: %s\n", err.Error())
+				continue
+			}
+			client.recordLatency(ctx, monitor, LatencyPingRTT, rtt)
+		}
+	}
+}
+
+// Send a ping to the server or return an error if the client is not connected.
+func (client *krakenSpotWebsocketClient) pingIfConnected(ctx context.Context) error {
+	if client.conn == nil {
+		return fmt.Errorf("client is not connected")
+	}
+	return client.Ping(ctx)
+}
+
+// Record a latency sample with the monitor and the latency histogram.
+func (client *krakenSpotWebsocketClient) recordLatency(ctx context.Context, monitor *latencyMonitor, source LatencySourceEnum, latency time.Duration) {
+	monitor.record(source, latency)
+	client.latencyHistogram.Record(ctx, float64(latency)/float64(time.Millisecond), metric.WithAttributes(attribute.String("source", string(source))))
+}
+
+// Measure the delay between the time of the most recent trade of the message and the receive
+// time. Noop if the latency monitor is not running.
+func (client *krakenSpotWebsocketClient) measureTradeLatency(ctx context.Context, msg []byte, receivedAt time.Time) {
+	monitor := client.latency.Load()
+	if monitor == nil {
+		return
+	}
+	trade := new(messages.Trade)
+	if err := client.codec.Unmarshal(msg, trade); err != nil || len(trade.Entries) == 0 {
+		return
+	}
+	latest := trade.Entries[0].Time
+	for _, entry := range trade.Entries[1:] {
+		if entry.Time.After(latest) {
+			latest = entry.Time
+		}
+	}
+	client.recordLatency(ctx, monitor, LatencyTrade, receivedAt.Sub(latest))
+}
+
+// Measure the delay between the time of the spread message and the receive time. Noop if the
+// latency monitor is not running.
+func (client *krakenSpotWebsocketClient) measureSpreadLatency(ctx context.Context, msg []byte, receivedAt time.Time) {
+	monitor := client.latency.Load()
+	if monitor == nil {
+		return
+	}
+	spread := new(messages.Spread)
+	if err := client.codec.Unmarshal(msg, spread); err != nil {
+		return
+	}
+	client.recordLatency(ctx, monitor, LatencySpread, receivedAt.Sub(spread.Entry.Time))
+}
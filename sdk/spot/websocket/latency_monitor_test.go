@@ -0,0 +1,114 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the latency monitor
+type LatencyMonitorUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestLatencyMonitorUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(LatencyMonitorUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test rolling statistics computed by the latency monitor.
+//
+// Test will ensure:
+//   - Empty sources have zero statistics.
+//   - p50, p95, max and last are computed from the samples.
+//   - Oldest samples are overwritten once the window is full.
+func (suite *LatencyMonitorUnitTestSuite) TestLatencyStats() {
+	monitor := newLatencyMonitor(20)
+	require.Equal(suite.T(), LatencyStats{}, monitor.stats(LatencyPingRTT))
+	for i := 20; i >= 1; i-- {
+		monitor.record(LatencyPingRTT, time.Duration(i)*time.Millisecond)
+	}
+	require.Equal(suite.T(), LatencyStats{
+		Count: 20,
+		Last:  1 * time.Millisecond,
+		P50:   10 * time.Millisecond,
+		P95:   19 * time.Millisecond,
+		Max:   20 * time.Millisecond,
+	}, monitor.stats(LatencyPingRTT))
+	// Overwrite the 20ms and 19ms samples
+	monitor.record(LatencyPingRTT, 5*time.Millisecond)
+	monitor.record(LatencyPingRTT, 7*time.Millisecond)
+	stats := monitor.stats(LatencyPingRTT)
+	require.Equal(suite.T(), 20, stats.Count)
+	require.Equal(suite.T(), 7*time.Millisecond, stats.Last)
+	require.Equal(suite.T(), 18*time.Millisecond, stats.Max)
+	require.Zero(suite.T(), monitor.stats(LatencyTrade).Count)
+}
+
+// Test the latency monitor loop and the measure of event timestamps.
+//
+// Test will ensure:
+//   - Ping round-trip times are recorded.
+//   - Ping errors are reported in the state and not recorded.
+//   - Trade and spread latencies are measured from the message timestamps.
+//   - Latencies are recorded with the meter provider set with SetMeterProvider.
+//   - The monitor cannot be started twice and stops when its context is cancelled.
+func (suite *LatencyMonitorUnitTestSuite) TestLatencyMonitor() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	meter := &recordingMeter{samples: map[string][]float64{}}
+	client.SetMeterProvider(&recordingMeterProvider{meter: meter})
+	require.False(suite.T(), client.GetLatencyState().Running)
+	// No measure when the monitor is not running
+	client.measureTradeLatency(context.Background(), []byte(`[0,[["5541.20000","0.15850568","1534614057.321597","s","l",""]],"trade","XBT/USD"]`), time.Now())
+	require.Empty(suite.T(), client.GetLatencyState().Stats)
+	// Start the monitor with a fake ping which fails after the first ping
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	monitor := newLatencyMonitor(DefaultLatencyWindowSize)
+	client.latency.Store(monitor)
+	pings := 0
+	go client.runLatencyMonitor(ctx, monitor, 10*time.Millisecond, func(ctx context.Context) error {
+		pings++
+		if pings > 1 {
+			return fmt.Errorf("ping failed")
+		}
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	})
+	require.Error(suite.T(), client.StartLatencyMonitor(ctx, 0, 0))
+	require.Eventually(suite.T(), func() bool {
+		return client.GetLatencyState().LastPingError != nil
+	}, time.Second, 5*time.Millisecond)
+	stats := client.GetLatencyState().Stats[LatencyPingRTT]
+	require.Equal(suite.T(), 1, stats.Count)
+	require.GreaterOrEqual(suite.T(), stats.Last, 2*time.Millisecond)
+	// Trade latency uses the most recent trade
+	receivedAt := time.Unix(1534614060, 0)
+	client.measureTradeLatency(context.Background(), []byte(`[0,[["5541.20000","0.15850568","1534614057.000000","s","l",""],["6060.00000","0.02455000","1534614058.000000","b","l",""]],"trade","XBT/USD"]`), receivedAt)
+	require.Equal(suite.T(), 2*time.Second, client.GetLatencyState().Stats[LatencyTrade].Last)
+	// Spread latency
+	client.measureSpreadLatency(context.Background(), []byte(`[0,["5698.40000","5700.00000","1534614059.500000","1.01234567","0.98765432"],"spread","XBT/USD"]`), receivedAt)
+	require.Equal(suite.T(), 500*time.Millisecond, client.GetLatencyState().Stats[LatencySpread].Last)
+	meter.mu.Lock()
+	require.Equal(suite.T(), []float64{2000}, meter.samples[string(LatencyTrade)])
+	require.Equal(suite.T(), []float64{500}, meter.samples[string(LatencySpread)])
+	require.Len(suite.T(), meter.samples[string(LatencyPingRTT)], 1)
+	meter.mu.Unlock()
+	// Stop the monitor
+	cancel()
+	require.Eventually(suite.T(), func() bool {
+		return !client.GetLatencyState().Running
+	}, time.Second, 5*time.Millisecond)
+}
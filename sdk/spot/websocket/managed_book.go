@@ -0,0 +1,281 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+
+	otelObs "github.com/cloudevents/sdk-go/observability/opentelemetry/v2/client"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/codec"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
+	"github.com/google/uuid"
+)
+
+// Capacity of the channel used to receive book messages from the underlying book subscription.
+const managedBookChannelCapacity = 100
+
+// Number of levels of each side of the book used to compute the book checksum.
+const bookChecksumLevels = 10
+
+// Data of a book_resynced event: the book of a pair has been resynchronized with a new snapshot
+// after a gap has been detected in the stream of book updates.
+type BookResynced struct {
+	// Pair
+	Pair string `json:"pair"`
+	// Reason why the book has been resynchronized
+	Reason string `json:"reason"`
+}
+
+// Manager which maintains the books of the subscribed pairs, validates each book update with the
+// checksum provided by the server and triggers a resynchronization when a gap is detected.
+type managedBook struct {
+	// Book depth
+	depth int
+	// Books by pair
+	books map[string]*localBook
+	// Reasons of the pending resynchronizations by pair
+	resyncing map[string]string
+	// Function called to resynchronize the book of a pair. Must not block.
+	resync func(pair string)
+	// JSON codec used to parse book messages
+	codec codec.JSONCodec
+	// Optional function which tells whether the output channel must be left open when the input
+	// channel is closed. If nil, the output channel is closed.
+	keepOutOpen func() bool
+}
+
+// # Description
+//
+// Subscribe to the book channel in managed mode: the client maintains the books of the subscribed
+// pairs and checks each book update against the checksum provided by the server. Kraken book
+// messages have no sequence number: a missed update (ex: brief network hiccup without the
+// connection being dropped) is detected because the checksum of the local book does not match
+// the checksum of the update anymore.
+//
+// When a gap is detected for a pair, the corrupted update is discarded and the client recovers
+// automatically by unsubscribing from and resubscribing to the book channel for this pair only.
+// The resubscribe policy (Cf. SetResubscribePolicy) is used. Updates received for the pair are
+// discarded until the new snapshot is received. Then, a book_resynced event (Cf. BookResynced) is
+// published right before the new book_snapshot event.
+//
+// book_snapshot & book_update events which pass validation are forwarded unchanged.
+// connection_interrupted and resubscribe_failed events are forwarded as well.
+//
+// Use UnsubscribeBook to unsubscribe: the provided channel will be closed once the underlying
+// book subscription is closed unless the client is configured to keep channels open on
+// unsubscribe (Cf. SetKeepChannelsOpenOnUnsubscribe).
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - pairs: Pairs to subscribe to.
+//   - depth: Depth of the book subscription.
+//   - rcv: Channel used to publish book events. Blocking writes are used.
+//
+// # Return
+//
+// An error if SubscribeBook fails.
+func (client *KrakenSpotPublicWebsocketClient) SubscribeManagedBook(ctx context.Context, pairs []string, depth messages.DepthEnum, rcv chan event.Event) error {
+	err := client.subscribeManagedBook(ctx, pairs, depth, rcv, client.keepChannelsOpenOnUnsubscribe.Load)
+	if err != nil {
+		return fmt.Errorf("subscribe managed book failed: %w", err)
+	}
+	return nil
+}
+
+// Subscribe to the book channel with an internal channel and start a book manager which forwards
+// validated events to out. keepOutOpen tells whether out must be left open when the book
+// subscription is closed (Cf. managedBook).
+func (client *KrakenSpotPublicWebsocketClient) subscribeManagedBook(ctx context.Context, pairs []string, depth messages.DepthEnum, out chan event.Event, keepOutOpen func() bool) error {
+	in := make(chan event.Event, managedBookChannelCapacity)
+	err := client.subscribeBook(ctx, pairs, depth, in, nil, true)
+	if err != nil {
+		return err
+	}
+	manager := newManagedBook(int(depth), client.codec, func(pair string) {
+		go client.resyncBook(pair, depth, in)
+	})
+	manager.keepOutOpen = keepOutOpen
+	go manager.run(in, out)
+	return nil
+}
+
+// # Description
+//
+// Resynchronize the book of a pair by unsubscribing from and resubscribing to the book channel
+// for this pair. The method blocks until the client has resubscribed or has given up according
+// to the resubscribe policy.
+//
+// # Inputs
+//
+//   - pair: Pair to resynchronize.
+//   - depth: Depth of the book subscription.
+//   - pub: Channel of the book subscription. Used to publish a resubscribe_failed event if the
+//     client gives up and the subscription is still active.
+func (client *krakenSpotWebsocketClient) resyncBook(pair string, depth messages.DepthEnum, pub chan event.Event) {
+	client.logger.Println("resynchronizing book", pair, depth)
+	client.resubscribeWithPolicy(context.Background(), string(messages.ChannelBook), []string{pair}, func(ctx context.Context) error {
+		err := client.unsubscribeBookPair(ctx, pair, depth)
+		if err != nil {
+			return err
+		}
+		return client.resubscribeBook(ctx, []string{pair}, depth)
+	}, &client.bookSubMu, func() chan event.Event {
+		if sub := client.subscriptions.books[depth]; sub != nil && sub.pub == pub {
+			return pub
+		}
+		return nil
+	})
+}
+
+// # Description
+//
+// Unsubscribe from the book channel for a single pair without discarding the client's book
+// subscription. An error from the server which tells the pair is not subscribed is ignored.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - pair: Pair to unsubscribe from.
+//   - depth: Depth of the book subscription.
+//
+// # Return
+//
+// An error if the unsubscribe message could not be sent or if the server has returned an error.
+func (client *krakenSpotWebsocketClient) unsubscribeBookPair(ctx context.Context, pair string, depth messages.DepthEnum) error {
+	result := NewFuture[struct{}]()
+	err := client.sendUnsubscribeRequest(
+		ctx,
+		&messages.Unsubscribe{
+			Event: string(messages.EventTypeUnsubscribe),
+			ReqId: client.ngen.GenerateNonce(),
+			Pairs: []string{pair},
+			Subscription: messages.UnsuscribeDetails{
+				Name:  string(messages.ChannelBook),
+				Depth: int(depth),
+			},
+		},
+		result)
+	if err != nil {
+		return fmt.Errorf("unsubscribe book %s failed: %w", pair, err)
+	}
+	select {
+	case <-ctx.Done():
+		return &OperationInterruptedError{Operation: "unsubscribe_book", Root: fmt.Errorf("unsubscribe book %s failed: %w", pair, ctx.Err())}
+	case <-result.Done():
+		if _, err := result.get(); err != nil && !strings.Contains(strings.ToLower(err.Error()), "not found") {
+			return &OperationError{Operation: "unsubscribe_book", Root: fmt.Errorf("unsubscribe book %s failed: %w", pair, err)}
+		}
+		return nil
+	}
+}
+
+// Create a new book manager. If jsonCodec is nil, codec.StandardJSONCodec is used.
+func newManagedBook(depth int, jsonCodec codec.JSONCodec, resync func(pair string)) *managedBook {
+	if jsonCodec == nil {
+		jsonCodec = codec.StandardJSONCodec{}
+	}
+	return &managedBook{
+		depth:     depth,
+		books:     map[string]*localBook{},
+		resyncing: map[string]string{},
+		resync:    resync,
+		codec:     jsonCodec,
+	}
+}
+
+// Process events from the input channel until it is closed and forward valid events to the output
+// channel. The output channel is closed when the input channel is closed unless keepOutOpen
+// returns true.
+func (m *managedBook) run(in chan event.Event, out chan event.Event) {
+	defer func() {
+		if m.keepOutOpen == nil || !m.keepOutOpen() {
+			close(out)
+		}
+	}()
+	for e := range in {
+		switch e.Type() {
+		case string(events.ConnectionInterrupted):
+			// Reset books: the client resubscribes and new snapshots will be received
+			m.books = map[string]*localBook{}
+			m.resyncing = map[string]string{}
+			out <- e
+		case string(events.BookSnapshot):
+			snapshot := new(messages.BookSnapshot)
+			if err := m.codec.Unmarshal(e.Data(), snapshot); err != nil {
+				continue
+			}
+			book := &localBook{}
+			book.asks = applyBookEntries(book.asks, snapshot.Data.Asks, true, m.depth)
+			book.bids = applyBookEntries(book.bids, snapshot.Data.Bids, false, m.depth)
+			m.books[snapshot.Pair] = book
+			if reason, ok := m.resyncing[snapshot.Pair]; ok {
+				delete(m.resyncing, snapshot.Pair)
+				out <- m.newResyncedEvent(e, snapshot.Pair, reason)
+			}
+			out <- e
+		case string(events.BookUpdate):
+			update := new(messages.BookUpdate)
+			if err := m.codec.Unmarshal(e.Data(), update); err != nil {
+				continue
+			}
+			book, ok := m.books[update.Pair]
+			if !ok {
+				// Discard updates received before the snapshot or while resynchronizing
+				continue
+			}
+			book.asks = applyBookEntries(book.asks, update.Data.Asks, true, m.depth)
+			book.bids = applyBookEntries(book.bids, update.Data.Bids, false, m.depth)
+			if update.Data.Checksum != "" {
+				if checksum := bookChecksum(book); checksum != update.Data.Checksum {
+					// Gap detected: discard the book and resynchronize
+					delete(m.books, update.Pair)
+					m.resyncing[update.Pair] = fmt.Sprintf("checksum mismatch: expected %s, got %s", update.Data.Checksum, checksum)
+					m.resync(update.Pair)
+					continue
+				}
+			}
+			out <- e
+		default:
+			out <- e
+		}
+	}
+}
+
+// Build a book_resynced event from the source snapshot event.
+func (m *managedBook) newResyncedEvent(source event.Event, pair string, reason string) event.Event {
+	e := event.New()
+	e.Context.SetType(string(events.BookResynced))
+	e.Context.SetID(uuid.NewString())
+	e.Context.SetSource(tracing.PackageName)
+	e.SetSubject(pair)
+	e.SetData("application/json", &BookResynced{Pair: pair, Reason: reason})
+	// Propagate tracing context from the source event
+	otelObs.InjectDistributedTracingExtension(otelObs.ExtractDistributedTracingExtension(context.Background(), source), e)
+	return e
+}
+
+// Compute the checksum of a book as described by the Kraken API: CRC32 of the concatenation of
+// price and volume of the 10 best asks (ascending) then of the 10 best bids (descending), where
+// the decimal point and leading zeros have been removed.
+func bookChecksum(book *localBook) string {
+	var sb strings.Builder
+	for _, levels := range [][]bookLevel{book.asks, book.bids} {
+		for i := 0; i < len(levels) && i < bookChecksumLevels; i++ {
+			sb.WriteString(checksumValue(levels[i].entry.Price))
+			sb.WriteString(checksumValue(levels[i].entry.Volume))
+		}
+	}
+	return strconv.FormatUint(uint64(crc32.ChecksumIEEE([]byte(sb.String()))), 10)
+}
+
+// Format a price or a volume for the book checksum.
+func checksumValue(n json.Number) string {
+	return strings.TrimLeft(strings.Replace(string(n), ".", "", 1), "0")
+}
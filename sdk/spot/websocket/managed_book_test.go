@@ -0,0 +1,95 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the book manager used by SubscribeManagedBook
+type ManagedBookUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestManagedBookUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(ManagedBookUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the book checksum computation.
+func (suite *ManagedBookUnitTestSuite) TestBookChecksum() {
+	book := &localBook{}
+	book.asks = applyBookEntries(book.asks, []messages.BookMessageEntry{
+		{Price: "5541.30000", Volume: "2.50700000"},
+		{Price: "5541.80000", Volume: "0.33000000"},
+	}, true, 10)
+	book.bids = applyBookEntries(book.bids, []messages.BookMessageEntry{
+		{Price: "5539.90000", Volume: "0.30000000"},
+		{Price: "5541.20000", Volume: "1.52900000"},
+	}, false, 10)
+	require.Equal(suite.T(), "600453227", bookChecksum(book))
+	require.Equal(suite.T(), "5005", checksumValue("0.05005"))
+}
+
+// Test the book manager with a sequence of snapshot and updates which contains a gap.
+//
+// Test will ensure:
+//   - Snapshot and valid updates are forwarded.
+//   - An update with a checksum mismatch is discarded and triggers a resync for the pair.
+//   - Updates received while resynchronizing are discarded.
+//   - A book_resynced event is published before the new snapshot.
+//   - Output channel is closed with input.
+func (suite *ManagedBookUnitTestSuite) TestManagedBookGapRecovery() {
+	in := make(chan event.Event, 10)
+	out := make(chan event.Event, 10)
+	resyncs := make(chan string, 10)
+	manager := newManagedBook(10, nil, func(pair string) { resyncs <- pair })
+	go manager.run(in, out)
+	// Snapshot -> forwarded
+	snapshot := `[0,{"as":[["100.0","1.0","1"],["101.0","2.0","1"]],"bs":[["99.0","1.0","1"],["98.0","2.0","1"]]},"book-10","XBT/USD"]`
+	in <- newBookEvent(events.BookSnapshot, snapshot)
+	require.Equal(suite.T(), string(events.BookSnapshot), (<-out).Type())
+	// Valid update -> forwarded
+	expected := &localBook{}
+	expected.asks = applyBookEntries(expected.asks, []messages.BookMessageEntry{{Price: "100.0", Volume: "3.0"}, {Price: "101.0", Volume: "2.0"}}, true, 10)
+	expected.bids = applyBookEntries(expected.bids, []messages.BookMessageEntry{{Price: "99.0", Volume: "1.0"}, {Price: "98.0", Volume: "2.0"}}, false, 10)
+	in <- newBookEvent(events.BookUpdate, fmt.Sprintf(`[0,{"a":[["100.0","3.0","2"]],"c":"%s"},"book-10","XBT/USD"]`, bookChecksum(expected)))
+	require.Equal(suite.T(), string(events.BookUpdate), (<-out).Type())
+	// Update with a bad checksum -> discarded, resync triggered
+	in <- newBookEvent(events.BookUpdate, `[0,{"b":[["99.0","5.0","3"]],"c":"12345"},"book-10","XBT/USD"]`)
+	require.Equal(suite.T(), "XBT/USD", <-resyncs)
+	// Update while resynchronizing -> discarded
+	in <- newBookEvent(events.BookUpdate, `[0,{"b":[["99.0","6.0","4"]],"c":"12345"},"book-10","XBT/USD"]`)
+	// New snapshot -> book_resynced then snapshot
+	in <- newBookEvent(events.BookSnapshot, snapshot)
+	e := <-out
+	require.Equal(suite.T(), string(events.BookResynced), e.Type())
+	resynced := new(BookResynced)
+	require.NoError(suite.T(), json.Unmarshal(e.Data(), resynced))
+	require.Equal(suite.T(), "XBT/USD", resynced.Pair)
+	require.Contains(suite.T(), resynced.Reason, "checksum mismatch")
+	require.Equal(suite.T(), string(events.BookSnapshot), (<-out).Type())
+	// Other events are forwarded
+	interrupted := event.New()
+	interrupted.SetType(string(events.ConnectionInterrupted))
+	in <- interrupted
+	require.Equal(suite.T(), string(events.ConnectionInterrupted), (<-out).Type())
+	close(in)
+	_, ok := <-out
+	require.False(suite.T(), ok)
+	require.Empty(suite.T(), resyncs)
+}
@@ -26,12 +26,23 @@ type AddOrderRequest struct {
 	Price2 string `json:"price2,omitempty"`
 	// Order volume in base currency
 	Volume string `json:"volume"`
+	// Optional - visible order quantity of an iceberg order in base currency.
+	DisplayVolume string `json:"displayvol,omitempty"`
+	// Optional - price signal used to trigger stop, take-profit and trailing orders. Cf.
+	// TriggerEnum for values.
+	//
+	// An empty string triggers the default behavior (last).
+	Trigger string `json:"trigger,omitempty"`
 	// Amount of leverage desired.
 	//
 	// An empty value means no leverage.
 	Leverage string `json:"leverage,omitempty"`
 	// If true, order will only reduce a currently open position, not increase it or open a new position.
 	ReduceOnly bool `json:"reduce_only,omitempty"`
+	// Optional - self trade prevention flag. Cf. SelfTradePreventionFlagEnum for values.
+	//
+	// An empty string triggers the default behavior (cancel-newest).
+	StpType string `json:"stptype,omitempty"`
 	// Optional comma delimited list of order flags. Cf. OrderFlagEnum for values.
 	//
 	// viqc = volume in quote currency (not currently available), fcib = prefer fee in base currency, fciq = prefer fee in quote currency,
@@ -65,7 +76,9 @@ type AddOrderRequest struct {
 	// Optional - user reference ID (should be an integer in quotes)
 	UserReference string `json:"userref,omitempty"`
 	// Optional - if true, validate inputs only; do not submit order.
-	Validate string `json:"validate,omitempty"`
+	//
+	// Named ValidateOnly as Validate is the method used to validate the message.
+	ValidateOnly string `json:"validate,omitempty"`
 	// Optional close order type. Cf. OrderTypeEnum
 	CloseOrderType string `json:"close[ordertype],omitempty"`
 	// Optional - close order price.
@@ -20,6 +20,8 @@ type BookMessageEntry struct {
 	Timestamp json.Number
 	// Optional - "r" in case update is a republished update
 	UpdateType string
+	// Number of trailing items received with the array and ignored (Cf. Validate)
+	extraItems int
 }
 
 // Unmarshal a single book entry.
@@ -32,11 +34,16 @@ func (b *BookMessageEntry) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return fmt.Errorf("cannot parse data as a book entry: %w. Got %s", err, string(data))
 	}
+	extra, err := checkArrayLength("book entry", len(tmp), 3, 4)
+	if err != nil {
+		return err
+	}
+	b.extraItems = extra
 	// Encode struct
 	b.Price = json.Number(tmp[0])
 	b.Volume = json.Number(tmp[1])
 	b.Timestamp = json.Number(tmp[2])
-	if len(tmp) == 4 {
+	if len(tmp) >= 4 {
 		b.UpdateType = tmp[3]
 	}
 	return nil
@@ -82,6 +89,8 @@ type BookSnapshot struct {
 	Pair string
 	// Book snapshot data
 	Data BookSnapshotData
+	// Number of trailing items received with the array and ignored (Cf. Validate)
+	extraItems int
 }
 
 // Custom JSON marshaller for BookSnapshot
@@ -108,6 +117,11 @@ func (bs *BookSnapshot) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	extra, err := checkArrayLength("book snapshot message", len(tmp), 4, 4)
+	if err != nil {
+		return err
+	}
+	bs.extraItems = extra
 	// 3. Extract data
 	// Extract channel ID: index 0
 	cid, ok := tmp[0].(float64) // Yes, it is understood like that by the parser
@@ -128,7 +142,11 @@ func (bs *BookSnapshot) UnmarshalJSON(data []byte) error {
 	bs.ChannelId = int(cid)
 	bs.Name = cname
 	bs.Pair = pair
-	bs.Data = *tmp[1].(*BookSnapshotData)
+	bsdata, ok := tmp[1].(*BookSnapshotData)
+	if !ok {
+		return fmt.Errorf("failed to extract book snapshot data from parsed data: %s", string(data))
+	}
+	bs.Data = *bsdata
 	return nil
 }
 
@@ -187,6 +205,8 @@ type BookUpdate struct {
 	Pair string
 	// Book update data
 	Data BookUpdateData
+	// Number of trailing items received with the array and ignored (Cf. Validate)
+	extraItems int
 }
 
 // Custom JSON marshaller for BookUpdate
@@ -260,10 +280,16 @@ func (bu *BookUpdate) UnmarshalJSON(data []byte) error {
 		typ = BidsOnly
 	}
 	// 2. Unmarshal data into the array of objects
+	expected := len(tmp)
 	err := json.Unmarshal(data, &tmp)
 	if err != nil {
 		return err
 	}
+	extra, err := checkArrayLength("book update message", len(tmp), expected, expected)
+	if err != nil {
+		return err
+	}
+	bu.extraItems = extra
 	// 3. Extract common data depending on the content type
 	cid, ok := tmp[0].(float64) // Yes, it is understood like that by the parser
 	if !ok {
@@ -2,7 +2,14 @@
 // with Kraken spot websocket API.
 package messages
 
-import "regexp"
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
 
 /*************************************************************************************************/
 /* COMMON ENUMS                                                                                  */
@@ -185,4 +192,54 @@ var matchesWhitespacesRegex = regexp.MustCompile(`\s`)
 //   - A JSON array which contains an string like ownTrades, openOrders, ticker, trade, spread,
 //     ohlc* or book*
 //   - For events related to public market data, the regex will also extract the pair name.
+//
+// Deprecated: use SniffMessageType which is faster and supports all message shapes.
 var MatchMessageTypeRegex = regexp.MustCompile(`^{.*\"event\":\ *\"(pong|heartbeat|systemStatus|subscriptionStatus|addOrderStatus|editOrderStatus|cancelOrderStatus|cancelAllStatus|cancelAllOrdersAfterStatus)\".*}$|^\[.*\"(ownTrades|openOrders)\".*\]$|^\[.*\"(ticker|trade|spread|ohlc[-0-9]*|book[-0-9]*)\".*\"(.*\/.*)\".*\]$`)
+
+/*************************************************************************************************/
+/* PARSING HELPERS                                                                               */
+/*************************************************************************************************/
+
+// Check a JSON array received from the server has at least min items and get the number of
+// trailing items beyond the max known items. Arrays are parsed item by item: this prevents out of
+// range accesses on payloads which do not have the expected shape. Trailing items are ignored so
+// fields appended by the server do not break parsing: Validate reports them.
+func checkArrayLength(name string, items int, min int, max int) (int, error) {
+	if items < min {
+		return 0, fmt.Errorf("unexpected number of items for %s: %d. Expected at least %d", name, items, min)
+	}
+	if items > max {
+		return items - max, nil
+	}
+	return 0, nil
+}
+
+// Parse a decimal value received from the server as a float64.
+func parseDecimal(name string, value json.Number) (float64, error) {
+	f, err := strconv.ParseFloat(value.String(), 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse %s %q: %w", name, value.String(), err)
+	}
+	return f, nil
+}
+
+// Parse a time received from the server as seconds since epoch with decimal nanoseconds (ex:
+// 1534614057.321597). The fractional part is parsed without float rounding.
+func parseTimestamp(name string, value json.Number) (time.Time, error) {
+	sec, frac, _ := strings.Cut(value.String(), ".")
+	s, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse %s %q: %w", name, value.String(), err)
+	}
+	var ns int64
+	if frac != "" {
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		ns, err = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse %s %q: %w", name, value.String(), err)
+		}
+	}
+	return time.Unix(s, ns).UTC(), nil
+}
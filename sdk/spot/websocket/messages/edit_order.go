@@ -35,7 +35,9 @@ type EditOrderRequest struct {
 	// An empty string means no new user reference will be defined.
 	NewUserReference string `json:"newuserref,omitempty"`
 	// Optional - if true, validate inputs only; do not submit order.
-	Validate string `json:"validate,omitempty"`
+	//
+	// Named ValidateOnly as Validate is the method used to validate the message.
+	ValidateOnly string `json:"validate,omitempty"`
 }
 
 // Response message for EditOrder
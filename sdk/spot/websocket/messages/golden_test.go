@@ -0,0 +1,227 @@
+package messages
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the golden files of the websocket messages.
+//
+// Golden files are stored in testdata/golden/<message type>/<case>.json and contain payloads in
+// the exact format sent or expected by the API. Add a golden file each time a new message shape
+// is met: the suite will make sure it is decoded, validated and encoded back without loss.
+type GoldenUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run the unit test suite
+func TestGoldenUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(GoldenUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test all golden files round-trip.
+//
+// Test will ensure:
+//   - Each message type has at least one golden file and each golden file matches a message type.
+//   - Golden files are decoded and pass validation.
+//   - Decoded messages are encoded to the same payloads as the golden files.
+func (suite *GoldenUnitTestSuite) TestGoldenFilesRoundTrip() {
+	dirs, err := os.ReadDir(goldenDir)
+	require.NoError(suite.T(), err)
+	found := map[string]bool{}
+	for _, dir := range dirs {
+		newMessage, ok := goldenMessageTypes[dir.Name()]
+		require.True(suite.T(), ok, "golden files for unknown message type %s", dir.Name())
+		files, err := filepath.Glob(filepath.Join(goldenDir, dir.Name(), "*.json"))
+		require.NoError(suite.T(), err)
+		for _, file := range files {
+			payload, err := os.ReadFile(file)
+			require.NoError(suite.T(), err)
+			msg := newMessage()
+			require.NoError(suite.T(), json.Unmarshal(payload, msg), file)
+			require.NoError(suite.T(), msg.Validate(), file)
+			actual, err := json.Marshal(msg)
+			require.NoError(suite.T(), err, file)
+			require.JSONEq(suite.T(), string(payload), string(actual), file)
+			found[dir.Name()] = true
+		}
+	}
+	for name := range goldenMessageTypes {
+		require.True(suite.T(), found[name], "no golden file for message type %s", name)
+	}
+}
+
+// Test invalid messages do not pass validation.
+//
+// Test will ensure:
+//   - Messages with an unexpected event type, channel name or missing required field are rejected.
+//   - Messages with values which cannot be parsed are rejected.
+//   - Arrays with trailing items are decoded but rejected.
+func (suite *GoldenUnitTestSuite) TestValidateInvalidMessages() {
+	for _, tc := range []struct {
+		name    string
+		typ     string
+		payload string
+	}{
+		{"wrong event", "heartbeat", `{"event":"pong"}`},
+		{"unknown engine status", "system_status", `{"event":"systemStatus","status":"sleeping","version":"1.9.0"}`},
+		{"subscription error without message", "subscription_status", `{"event":"subscriptionStatus","status":"error"}`},
+		{"subscription without channel name", "subscription_status", `{"event":"subscriptionStatus","status":"subscribed","subscription":{"name":"ticker"}}`},
+		{"error without message", "error", `{"event":"error"}`},
+		{"subscribe to unknown channel", "subscribe", `{"event":"subscribe","subscription":{"name":"candles"}}`},
+		{"subscribe with unknown depth", "subscribe", `{"event":"subscribe","subscription":{"name":"book","depth":42}}`},
+		{"private subscribe without token", "subscribe", `{"event":"subscribe","subscription":{"name":"ownTrades"}}`},
+		{"ticker with missing ask items", "ticker", `[340,{"a":["5525.40000",1],"b":["5525.10000",1,"1.000"],"c":["5525.10000","0.00398963"],"v":["2634.11501494","3591.17907851"],"p":["5631.44067","5653.78939"],"t":[11493,16267],"l":["5505.00000","5505.00000"],"h":["5783.00000","5783.00000"],"o":["5760.70000","5763.40000"]},"ticker","XBT/USD"]`},
+		{"ohlc with unknown interval", "ohlc", `[42,["1542057314.748456","1542057360.435743","3586.70000","3586.70000","3586.60000","3586.60000","3586.68894","0.03373000",2],"ohlc-7","XBT/USD"]`},
+		{"trade without pair", "trade", `[0,[["5541.20000","0.15850568","1534614057.321597","s","l",""]],"trade",""]`},
+		{"trade without data", "trade", `[0,[],"trade","XBT/USD"]`},
+		{"trade with trailing items", "trade", `[0,[["5541.20000","0.15850568","1534614057.321597","s","l","","42"]],"trade","XBT/USD"]`},
+		{"trade message with trailing items", "trade", `[0,[["5541.20000","0.15850568","1534614057.321597","s","l",""]],"trade","XBT/USD",{"sequence":1}]`},
+		{"spread with trailing items", "spread", `[0,["5698.40000","5700.00000","1542057299.545897","1.01234567","0.98765432","42"],"spread","XBT/USD"]`},
+		{"ohlc with trailing items", "ohlc", `[42,["1542057314.748456","1542057360.435743","3586.70000","3586.70000","3586.60000","3586.60000","3586.68894","0.03373000",2,"42"],"ohlc-5","XBT/USD"]`},
+		{"book entry with trailing items", "book_snapshot", `[0,{"as":[["5541.30000","2.50700000","1534614248.123678","r","42"]],"bs":[]},"book-10","XBT/USD"]`},
+		{"spread with wrong channel", "spread", `[0,["5698.40000","5700.00000","1542057299.545897","1.01234567","0.98765432"],"ticker","XBT/USD"]`},
+		{"book snapshot with invalid price", "book_snapshot", `[0,{"as":[["abc","2.50700000","1534614248.123678"]],"bs":[]},"book-10","XBT/USD"]`},
+		{"book update without checksum", "book_update", `[1234,{"a":[["5541.30000","2.50700000","1534614248.456738"]]},"book-10","XBT/USD"]`},
+		{"open orders without sequence", "open_orders", `[[{"OGTT3Y-C6I3P-XRI6HX":{"status":"closed"}}],"openOrders",{}]`},
+		{"own trade with unknown side", "own_trades", `[[{"TDLH43-DVQXD-2KHVYY":{"ordertxid":"OGTT3Y-C6I3P-XRI6HX","pair":"XBT/EUR","time":"1560516023.070651","type":"short","ordertype":"limit","price":"100000.00000","fee":"1600.00000","vol":"10.00000000"}}],"ownTrades",{"sequence":2948}]`},
+		{"add order without volume", "add_order_request", `{"event":"addOrder","token":"0000","ordertype":"limit","type":"buy","pair":"XBT/USD","price":"9000"}`},
+		{"add order with unknown order type", "add_order_request", `{"event":"addOrder","token":"0000","ordertype":"stop","type":"buy","pair":"XBT/USD","volume":"10"}`},
+		{"edit order without order id", "edit_order_request", `{"event":"editOrder","token":"0000","pair":"XBT/USD"}`},
+		{"cancel order without order id", "cancel_order_request", `{"event":"cancelOrder","token":"0000","txid":[]}`},
+		{"cancel all without token", "cancel_all_orders_request", `{"event":"cancelAll"}`},
+		{"cancel all after with negative timeout", "cancel_all_orders_after_x_request", `{"event":"cancelAllOrdersAfter","token":"0000","timeout":-1}`},
+		{"add order status without order id", "add_order_response", `{"event":"addOrderStatus","status":"ok"}`},
+		{"edit order status with unknown status", "edit_order_response", `{"event":"editOrderStatus","status":"done"}`},
+		{"cancel order status error without message", "cancel_order_response", `{"event":"cancelOrderStatus","status":"error"}`},
+		{"cancel all status with negative count", "cancel_all_orders_response", `{"event":"cancelAllStatus","status":"ok","count":-1}`},
+		{"cancel all after status with invalid time", "cancel_all_orders_after_x_response", `{"event":"cancelAllOrdersAfterStatus","status":"ok","currentTime":"yesterday","triggerTime":"0"}`},
+	} {
+		msg := goldenMessageTypes[tc.typ]()
+		require.NoError(suite.T(), json.Unmarshal([]byte(tc.payload), msg), tc.name)
+		require.Error(suite.T(), msg.Validate(), tc.name)
+	}
+}
+
+// Test payloads which do not have the expected shape are rejected.
+//
+// Test will ensure:
+//   - Arrays with missing items or items of unexpected types are rejected with an error instead
+//     of causing a panic.
+func (suite *GoldenUnitTestSuite) TestUnmarshalUnexpectedShapes() {
+	for _, tc := range []struct {
+		typ     string
+		payload string
+	}{
+		{"ohlc", `[0,["5698.40000","5700.00000","1542057299.545897","1.01234567","0.98765432"],"spread","XBT/USD"]`},
+		{"ohlc", `[42,[1,2,3,4,5,6,7,8,9],"ohlc-5","XBT/USD"]`},
+		{"ticker", `[340,null,"ticker","XBT/USD"]`},
+		{"ticker", `[340]`},
+		{"trade", `[0,[["5541.20000","0.15850568"]],"trade","XBT/USD"]`},
+		{"spread", `[0,["5698.40000"],"spread","XBT/USD"]`},
+		{"book_snapshot", `[0,{"as":[["5541.30000"]],"bs":[]},"book-10","XBT/USD"]`},
+		{"book_update", `[1234,{"a":[["5541.30000","2.50700000","1534614248.456738"]],"c":"974942666"}]`},
+		{"open_orders", `[[],42]`},
+		{"own_trades", `[null,"ownTrades",null]`},
+	} {
+		require.Error(suite.T(), json.Unmarshal([]byte(tc.payload), goldenMessageTypes[tc.typ]()), tc.payload)
+	}
+}
+
+/*************************************************************************************************/
+/* FUZZ TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Fuzz the JSON unmarshallers and marshallers of all message types. The corpus is seeded with the
+// golden files.
+//
+// Test will ensure:
+//   - Decoding arbitrary payloads never panics.
+//   - Validation of decoded messages never panics.
+//   - Decoded messages which pass validation can be encoded and the encoded payload is stable:
+//     decoding and encoding it again produces the same payload.
+func FuzzMessages(f *testing.F) {
+	files, err := filepath.Glob(filepath.Join(goldenDir, "*", "*.json"))
+	require.NoError(f, err)
+	for _, file := range files {
+		payload, err := os.ReadFile(file)
+		require.NoError(f, err)
+		f.Add(payload)
+	}
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		for _, name := range goldenMessageTypeNames() {
+			msg := goldenMessageTypes[name]()
+			if json.Unmarshal(payload, msg) != nil || msg.Validate() != nil {
+				continue
+			}
+			encoded, err := json.Marshal(msg)
+			require.NoError(t, err, name)
+			again := goldenMessageTypes[name]()
+			require.NoError(t, json.Unmarshal(encoded, again), name)
+			reencoded, err := json.Marshal(again)
+			require.NoError(t, err, name)
+			require.Equal(t, string(encoded), string(reencoded), name)
+		}
+	})
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Directory which contains the golden files
+const goldenDir = "testdata/golden"
+
+// Constructors of the message types by golden files directory name.
+var goldenMessageTypes = map[string]func() Validator{
+	"heartbeat":                          func() Validator { return new(Heartbeat) },
+	"ping":                               func() Validator { return new(Ping) },
+	"pong":                               func() Validator { return new(Pong) },
+	"system_status":                      func() Validator { return new(SystemStatus) },
+	"subscription_status":                func() Validator { return new(SubscriptionStatus) },
+	"error":                              func() Validator { return new(ErrorMessage) },
+	"subscribe":                          func() Validator { return new(Subscribe) },
+	"unsubscribe":                        func() Validator { return new(Unsubscribe) },
+	"ticker":                             func() Validator { return new(Ticker) },
+	"ohlc":                               func() Validator { return new(OHLC) },
+	"trade":                              func() Validator { return new(Trade) },
+	"spread":                             func() Validator { return new(Spread) },
+	"book_snapshot":                      func() Validator { return new(BookSnapshot) },
+	"book_update":                        func() Validator { return new(BookUpdate) },
+	"open_orders":                        func() Validator { return new(OpenOrders) },
+	"own_trades":                         func() Validator { return new(OwnTrades) },
+	"add_order_request":                  func() Validator { return new(AddOrderRequest) },
+	"add_order_response":                 func() Validator { return new(AddOrderResponse) },
+	"edit_order_request":                 func() Validator { return new(EditOrderRequest) },
+	"edit_order_response":                func() Validator { return new(EditOrderResponse) },
+	"cancel_order_request":               func() Validator { return new(CancelOrderRequest) },
+	"cancel_order_response":              func() Validator { return new(CancelOrderResponse) },
+	"cancel_all_orders_request":          func() Validator { return new(CancelAllOrdersRequest) },
+	"cancel_all_orders_response":         func() Validator { return new(CancelAllOrdersResponse) },
+	"cancel_all_orders_after_x_request":  func() Validator { return new(CancelAllOrdersAfterXRequest) },
+	"cancel_all_orders_after_x_response": func() Validator { return new(CancelAllOrdersAfterXResponse) },
+}
+
+// Get the names of the message types in a stable order.
+func goldenMessageTypeNames() []string {
+	names := make([]string, 0, len(goldenMessageTypes))
+	for name := range goldenMessageTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,245 @@
+package messages
+
+import (
+	"bytes"
+	"fmt"
+)
+
+/*************************************************************************************************/
+/* MESSAGE TYPE SNIFFING                                                                         */
+/*************************************************************************************************/
+
+// # Description
+//
+// Extract the message type (event type or channel name) and the pair from a message received
+// from the server by scanning the JSON payload once, without fully parsing it.
+//
+// Messages received from the server can have the following shapes:
+//
+//   - A JSON object with an "event" field: the event type is the value of the "event" field
+//     (pong, heartbeat, systemStatus, subscriptionStatus, addOrderStatus, ...).
+//   - A JSON array for public market data: [<channelID>, <data>..., "<channel name>", "<pair>"].
+//     The message type is the channel name (ticker, trade, spread, ohlc-5, book-10, ...) and the
+//     pair is the last element.
+//   - A JSON array for private data: [<data>, "<channel name>", {"sequence": <seq>}]. The message
+//     type is the channel name (ownTrades, openOrders). There is no pair.
+//
+// # Inputs
+//
+//   - msg: Message received from the server.
+//
+// # Return
+//
+// The message type, the pair (empty if the message has no pair) and an error if the message is
+// not valid JSON or if the message type cannot be found.
+func SniffMessageType(msg []byte) (string, string, error) {
+	s := &jsonSniffer{data: msg}
+	s.skipWhitespaces()
+	if s.pos >= len(s.data) {
+		return "", "", fmt.Errorf("empty message")
+	}
+	switch s.data[s.pos] {
+	case '{':
+		return s.sniffObject()
+	case '[':
+		return s.sniffArray()
+	default:
+		return "", "", fmt.Errorf("message is neither a JSON object nor a JSON array")
+	}
+}
+
+// Lightweight scanner used to sniff the type of JSON messages.
+type jsonSniffer struct {
+	// Data to scan
+	data []byte
+	// Current position
+	pos int
+}
+
+// Extract the value of the "event" field of a JSON object.
+func (s *jsonSniffer) sniffObject() (string, string, error) {
+	s.pos++ // Skip '{'
+	for {
+		s.skipWhitespaces()
+		if s.pos < len(s.data) && s.data[s.pos] == '}' {
+			return "", "", fmt.Errorf("message has no event field")
+		}
+		key, err := s.readString()
+		if err != nil {
+			return "", "", err
+		}
+		if err := s.expect(':'); err != nil {
+			return "", "", err
+		}
+		s.skipWhitespaces()
+		if bytes.Equal(key, []byte("event")) {
+			value, err := s.readString()
+			if err != nil {
+				return "", "", fmt.Errorf("event field is not a string: %w", err)
+			}
+			return string(value), "", nil
+		}
+		if err := s.skipValue(); err != nil {
+			return "", "", err
+		}
+		s.skipWhitespaces()
+		if s.pos >= len(s.data) {
+			return "", "", fmt.Errorf("unexpected end of message")
+		}
+		switch s.data[s.pos] {
+		case ',':
+			s.pos++
+		case '}':
+			return "", "", fmt.Errorf("message has no event field")
+		default:
+			return "", "", fmt.Errorf("unexpected character '%c' at position %d", s.data[s.pos], s.pos)
+		}
+	}
+}
+
+// Extract the channel name and the pair of a JSON array.
+func (s *jsonSniffer) sniffArray() (string, string, error) {
+	s.pos++ // Skip '['
+	// Last two top level elements
+	var previous, last arrayElement
+	count := 0
+	for {
+		s.skipWhitespaces()
+		if s.pos >= len(s.data) {
+			return "", "", fmt.Errorf("unexpected end of message")
+		}
+		if s.data[s.pos] == ']' {
+			break
+		}
+		element := arrayElement{}
+		if s.data[s.pos] == '"' {
+			value, err := s.readString()
+			if err != nil {
+				return "", "", err
+			}
+			element = arrayElement{value: value, isString: true}
+		} else if err := s.skipValue(); err != nil {
+			return "", "", err
+		}
+		previous, last = last, element
+		count++
+		s.skipWhitespaces()
+		if s.pos >= len(s.data) {
+			return "", "", fmt.Errorf("unexpected end of message")
+		}
+		switch s.data[s.pos] {
+		case ',':
+			s.pos++
+		case ']':
+		default:
+			return "", "", fmt.Errorf("unexpected character '%c' at position %d", s.data[s.pos], s.pos)
+		}
+	}
+	switch {
+	case count >= 2 && previous.isString && last.isString:
+		// Public market data: channel name and pair
+		return string(previous.value), string(last.value), nil
+	case count >= 2 && previous.isString:
+		// Private data: channel name followed by sequence object
+		return string(previous.value), "", nil
+	default:
+		return "", "", fmt.Errorf("message has no channel name")
+	}
+}
+
+// Top level element of a JSON array.
+type arrayElement struct {
+	// Raw content if the element is a string
+	value []byte
+	// True if the element is a string
+	isString bool
+}
+
+// Skip whitespaces.
+func (s *jsonSniffer) skipWhitespaces() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\r', '\n':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// Skip whitespaces and consume the expected character.
+func (s *jsonSniffer) expect(c byte) error {
+	s.skipWhitespaces()
+	if s.pos >= len(s.data) || s.data[s.pos] != c {
+		return fmt.Errorf("expected '%c' at position %d", c, s.pos)
+	}
+	s.pos++
+	return nil
+}
+
+// Read a string at the current position and return its raw content (escape sequences are not
+// decoded).
+func (s *jsonSniffer) readString() ([]byte, error) {
+	if s.pos >= len(s.data) || s.data[s.pos] != '"' {
+		return nil, fmt.Errorf("expected a string at position %d", s.pos)
+	}
+	start := s.pos + 1
+	for i := start; i < len(s.data); i++ {
+		switch s.data[i] {
+		case '\\':
+			i++ // Skip escaped character
+		case '"':
+			s.pos = i + 1
+			return s.data[start:i], nil
+		}
+	}
+	return nil, fmt.Errorf("unterminated string at position %d", s.pos)
+}
+
+// Skip the value at the current position: string, object, array, number or literal.
+func (s *jsonSniffer) skipValue() error {
+	if s.pos >= len(s.data) {
+		return fmt.Errorf("unexpected end of message")
+	}
+	switch s.data[s.pos] {
+	case '"':
+		_, err := s.readString()
+		return err
+	case '{', '[':
+		// Skip nested objects and arrays by tracking depth
+		depth := 0
+		for s.pos < len(s.data) {
+			switch s.data[s.pos] {
+			case '"':
+				if _, err := s.readString(); err != nil {
+					return err
+				}
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					s.pos++
+					return nil
+				}
+			}
+			s.pos++
+		}
+		return fmt.Errorf("unexpected end of message")
+	default:
+		// Number or literal: skip until the next delimiter
+		start := s.pos
+		for s.pos < len(s.data) {
+			switch s.data[s.pos] {
+			case ',', ']', '}', ' ', '\t', '\r', '\n':
+				if s.pos == start {
+					return fmt.Errorf("expected a value at position %d", start)
+				}
+				return nil
+			}
+			s.pos++
+		}
+		return fmt.Errorf("unexpected end of message")
+	}
+}
@@ -0,0 +1,149 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for SniffMessageType
+type SniffMessageTypeUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run the unit test suite
+func TestSniffMessageTypeUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(SniffMessageTypeUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test sniffing the type of all message shapes.
+func (suite *SniffMessageTypeUnitTestSuite) TestSniffMessageType() {
+	cases := []struct {
+		payload string
+		mType   string
+		pair    string
+	}{
+		{`{"event":"pong","reqid":42}`, "pong", ""},
+		{`{"event":"heartbeat"}`, "heartbeat", ""},
+		{`{"connectionID":8628615390848610000,"event":"systemStatus","status":"online","version":"1.0.0"}`, "systemStatus", ""},
+		{`{
+			"channelName": "ohlc-5",
+			"pair": "XBT/EUR",
+			"reqid": 42,
+			"subscription": {"interval": 5, "name": "ohlc"},
+			"event" : "subscriptionStatus",
+			"status": "subscribed"
+		}`, "subscriptionStatus", ""},
+		{`{"descr":"buy 0.01770000 XBTUSD @ limit 4000","event":"addOrderStatus","status":"ok","txid":"ONPNXH-KMKMU-F4MR5V"}`, "addOrderStatus", ""},
+		{`{"errorMessage":"Event(s) not found","event":"error","reqid":42,"status":"error"}`, "error", ""},
+		{`{"event":"cancelAllOrdersAfterStatus","currentTime":"2020-12-21T09:37:09Z","reqid":1608543428051,"status":"ok","triggerTime":"0"}`, "cancelAllOrdersAfterStatus", ""},
+		{`{"msg":"a \"quoted\" {value}","nested":{"event":"nope","list":[1,{"a":"]"}]},"ok":true,"none":null,"n":-1.5e3,"event":"pong"}`, "pong", ""},
+		{`[340,{"a":["5525.40000",1,"1.000000000"],"b":["5525.10000",1,"1.000000000"],"c":["5525.10000","0.00398963"]},"ticker","XBT/USD"]`, "ticker", "XBT/USD"},
+		{`[42,["1542057314.748456","1542057360.435743","3586.70000","3586.70000","3586.60000","3586.60000","3586.68894","0.03373000",2],"ohlc-5","XBT/USD"]`, "ohlc-5", "XBT/USD"},
+		{`[0,[["5541.20000","0.15850568","1534614057.321597","s","l",""]],"trade","XBT/USD"]`, "trade", "XBT/USD"},
+		{`[0,["5698.40000","5700.00000","1542057299.545897","1.01234567","0.98765432"],"spread","XBT/USD"]`, "spread", "XBT/USD"},
+		{`[0,{"as":[["5541.30000","2.50700000","1534614248.123678"]],"bs":[["5541.20000","1.52900000","1534614248.765567"]]},"book-100","XBT/USD"]`, "book-100", "XBT/USD"},
+		{`[1234,{"a":[["5541.30000","2.50700000","1534614248.456738"]],"c":"974942666"},"book-10","XBT/USD"]`, "book-10", "XBT/USD"},
+		{`[1234,{"a":[["5541.30000","2.50700000","1534614248.456738"]]},{"b":[["5541.30000","0.00000000","1534614335.345903"]],"c":"974942666"},"book-10","XBT/USD"]`, "book-10", "XBT/USD"},
+		{`[
+			[{"TDLH43-DVQXD-2KHVYY":{"cost":"1000000.00000","fee":"1600.00000","pair":"XBT/EUR","type":"sell"}}],
+			"ownTrades",
+			{"sequence":2948}
+		]`, "ownTrades", ""},
+		{`[[{"OGTT3Y-C6I3P-XRI6HX":{"status":"closed"}}],"openOrders",{"sequence":59342}]`, "openOrders", ""},
+	}
+	for _, c := range cases {
+		mType, pair, err := SniffMessageType([]byte(c.payload))
+		require.NoError(suite.T(), err, c.payload)
+		require.Equal(suite.T(), c.mType, mType, c.payload)
+		require.Equal(suite.T(), c.pair, pair, c.payload)
+	}
+}
+
+// Test sniffing invalid messages.
+func (suite *SniffMessageTypeUnitTestSuite) TestSniffInvalidMessages() {
+	for _, payload := range []string{
+		``,
+		`   `,
+		`"pong"`,
+		`{}`,
+		`{"status":"ok"}`,
+		`{"event":42}`,
+		`{"event" "pong"}`,
+		`{"status":"ok"`,
+		`{"status":"ok" "event":"pong"}`,
+		`{"status":"unterminated`,
+		`[]`,
+		`["trade"]`,
+		`[0,[],1]`,
+		`[0,[1,2],"trade","XBT/USD"`,
+		`[0,[1,2] "trade","XBT/USD"]`,
+		`[0,{"a":[1,2]`,
+	} {
+		_, _, err := SniffMessageType([]byte(payload))
+		require.Error(suite.T(), err, payload)
+	}
+}
+
+/*************************************************************************************************/
+/* BENCHMARKS                                                                                    */
+/*************************************************************************************************/
+
+// Book update used by benchmarks
+const benchmarkBookUpdate = `[1234,{"a":[["5541.30000","2.50700000","1534614248.456738"],["5542.50000","0.40100000","1534614248.456738"]],"c":"974942666"},"book-10","XBT/USD"]`
+
+// Add order status used by benchmarks
+const benchmarkAddOrderStatus = `{"descr":"buy 0.01770000 XBTUSD @ limit 4000","event":"addOrderStatus","reqid":42,"status":"ok","txid":"ONPNXH-KMKMU-F4MR5V"}`
+
+// Benchmark extracting the type of a book update with SniffMessageType.
+func BenchmarkSniffMessageTypeBookUpdate(b *testing.B) {
+	msg := []byte(benchmarkBookUpdate)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := SniffMessageType(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Benchmark extracting the type of a book update with MatchMessageTypeRegex.
+func BenchmarkRegexMessageTypeBookUpdate(b *testing.B) {
+	msg := []byte(benchmarkBookUpdate)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if len(MatchMessageTypeRegex.FindStringSubmatch(string(msg))) != 5 {
+			b.Fatal("no match")
+		}
+	}
+}
+
+// Benchmark extracting the type of an add order status with SniffMessageType.
+func BenchmarkSniffMessageTypeAddOrderStatus(b *testing.B) {
+	msg := []byte(benchmarkAddOrderStatus)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := SniffMessageType(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Benchmark extracting the type of an add order status with MatchMessageTypeRegex.
+func BenchmarkRegexMessageTypeAddOrderStatus(b *testing.B) {
+	msg := []byte(benchmarkAddOrderStatus)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if len(MatchMessageTypeRegex.FindStringSubmatch(string(msg))) != 5 {
+			b.Fatal("no match")
+		}
+	}
+}
@@ -3,6 +3,7 @@ package messages
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 /*************************************************************************************************/
@@ -21,6 +22,13 @@ type OHLC struct {
 	Pair string
 	// OHLC data
 	Data OHLCData
+	// Typed view of the OHLC data, populated by UnmarshalJSON. Ignored by MarshalJSON: Data is
+	// used to produce the JSON data. Cf. OHLCData.Entry to build the entry from Data.
+	//
+	// Zero value if the OHLC data cannot be parsed. The parsing error is reported by Validate.
+	Entry OHLCEntry
+	// Number of trailing items received with the array and ignored (Cf. Validate)
+	extraItems int
 }
 
 // Custom JSON marshaller for OHLC
@@ -47,6 +55,11 @@ func (o *OHLC) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	extra, err := checkArrayLength("ohlc message", len(tmp), 4, 4)
+	if err != nil {
+		return err
+	}
+	o.extraItems = extra
 	// 3. Extract data
 	// Extract channel ID: index 0
 	cid, ok := tmp[0].(float64) // Yes, it is understood like that by the parser
@@ -67,7 +80,15 @@ func (o *OHLC) UnmarshalJSON(data []byte) error {
 	o.ChannelId = int(cid)
 	o.Name = cname
 	o.Pair = pair
-	o.Data = *tmp[1].(*OHLCData)
+	odata, ok := tmp[1].(*OHLCData)
+	if !ok {
+		return fmt.Errorf("failed to extract ohlc data from parsed data: %s", string(data))
+	}
+	o.Data = *odata
+	// 4. Build typed entry - leave it empty if the OHLC data cannot be parsed (Cf. Validate)
+	if entry, err := o.Data.Entry(); err == nil {
+		o.Entry = entry
+	}
 	return nil
 }
 
@@ -95,6 +116,8 @@ type OHLCData struct {
 	Volume json.Number
 	// Number of trades used to build the indicator
 	TradesCount int64
+	// Number of trailing items received with the array and ignored (Cf. Validate)
+	extraItems int
 }
 
 // Marshal a single OHLC indicator as an array of strings to produce the same JSON data as the API.
@@ -136,15 +159,96 @@ func (ohlc *OHLCData) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	extra, err := checkArrayLength("ohlc", len(tmp), 9, 9)
+	if err != nil {
+		return err
+	}
+	ohlc.extraItems = extra
+	// Check types: all values are strings except the trades count
+	values := make([]json.Number, 8)
+	for i := range values {
+		value, ok := tmp[i].(string)
+		if !ok {
+			return fmt.Errorf("failed to extract ohlc value #%d from parsed data: %s", i, string(data))
+		}
+		values[i] = json.Number(value)
+	}
+	count, ok := tmp[8].(float64)
+	if !ok {
+		return fmt.Errorf("failed to extract ohlc trades count from parsed data: %s", string(data))
+	}
 	// Encode OHLC and exit
-	ohlc.Start = json.Number(tmp[0].(string))
-	ohlc.End = json.Number(tmp[1].(string))
-	ohlc.Open = json.Number(tmp[2].(string))
-	ohlc.High = json.Number(tmp[3].(string))
-	ohlc.Low = json.Number(tmp[4].(string))
-	ohlc.Close = json.Number(tmp[5].(string))
-	ohlc.VolumeAveragePrice = json.Number(tmp[6].(string))
-	ohlc.Volume = json.Number(tmp[7].(string))
-	ohlc.TradesCount = int64(tmp[8].(float64))
+	ohlc.Start = values[0]
+	ohlc.End = values[1]
+	ohlc.Open = values[2]
+	ohlc.High = values[3]
+	ohlc.Low = values[4]
+	ohlc.Close = values[5]
+	ohlc.VolumeAveragePrice = values[6]
+	ohlc.Volume = values[7]
+	ohlc.TradesCount = int64(count)
 	return nil
 }
+
+/*************************************************************************************************/
+/* OHLC ENTRY                                                                                    */
+/*************************************************************************************************/
+
+// Typed view of a single OHLC indicator.
+type OHLCEntry struct {
+	// Candle last update time
+	Time time.Time
+	// End time of interval
+	End time.Time
+	// Price of the first trade
+	Open float64
+	// Highest trade price
+	High float64
+	// Lowest trade price
+	Low float64
+	// Price of the last trade
+	Close float64
+	// Volume average price
+	VolumeAveragePrice float64
+	// Volume
+	Volume float64
+	// Number of trades used to build the indicator
+	TradesCount int64
+}
+
+// # Description
+//
+// Parse the OHLC indicator into an OHLCEntry.
+//
+// # Return
+//
+// The typed OHLC indicator or an error if one of the values cannot be parsed.
+func (ohlc OHLCData) Entry() (OHLCEntry, error) {
+	var err error
+	entry := OHLCEntry{TradesCount: ohlc.TradesCount}
+	if entry.Time, err = parseTimestamp("time", ohlc.Start); err != nil {
+		return OHLCEntry{}, err
+	}
+	if entry.End, err = parseTimestamp("end time", ohlc.End); err != nil {
+		return OHLCEntry{}, err
+	}
+	if entry.Open, err = parseDecimal("open", ohlc.Open); err != nil {
+		return OHLCEntry{}, err
+	}
+	if entry.High, err = parseDecimal("high", ohlc.High); err != nil {
+		return OHLCEntry{}, err
+	}
+	if entry.Low, err = parseDecimal("low", ohlc.Low); err != nil {
+		return OHLCEntry{}, err
+	}
+	if entry.Close, err = parseDecimal("close", ohlc.Close); err != nil {
+		return OHLCEntry{}, err
+	}
+	if entry.VolumeAveragePrice, err = parseDecimal("volume average price", ohlc.VolumeAveragePrice); err != nil {
+		return OHLCEntry{}, err
+	}
+	if entry.Volume, err = parseDecimal("volume", ohlc.Volume); err != nil {
+		return OHLCEntry{}, err
+	}
+	return entry, nil
+}
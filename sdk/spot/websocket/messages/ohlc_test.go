@@ -3,6 +3,7 @@ package messages
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -98,3 +99,28 @@ func (suite *OHLCUnitTestSuite) TestOHLCMarshalJsonOHLC() {
 	// Compare
 	require.Equal(suite.T(), payload, string(actual))
 }
+
+// Test the typed entry built when unmarshalling an OHLC message.
+func (suite *OHLCUnitTestSuite) TestOHLCUnmarshalJsonEntry() {
+	payload := `[42,["1542057314.748456","1542057360.435743","3586.70000","3586.70000","3586.60000","3586.60000","3586.68894","0.03373000",2],"ohlc-5","XBT/USD"]`
+	target := new(OHLC)
+	err := json.Unmarshal([]byte(payload), target)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), OHLCEntry{
+		Time:               time.Unix(1542057314, 748456000).UTC(),
+		End:                time.Unix(1542057360, 435743000).UTC(),
+		Open:               3586.7,
+		High:               3586.7,
+		Low:                3586.6,
+		Close:              3586.6,
+		VolumeAveragePrice: 3586.68894,
+		Volume:             0.03373,
+		TradesCount:        2,
+	}, target.Entry)
+	// Invalid close - the entry is skipped and reported by Validate
+	payload = `[42,["1542057314.748456","1542057360.435743","3586.70000","3586.70000","3586.60000","n/a","3586.68894","0.03373000",2],"ohlc-5","XBT/USD"]`
+	target = new(OHLC)
+	require.NoError(suite.T(), json.Unmarshal([]byte(payload), target))
+	require.Zero(suite.T(), target.Entry)
+	require.ErrorContains(suite.T(), target.Validate(), "close")
+}
@@ -3,6 +3,8 @@ package messages
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
 )
 
 // Data of a openOrders message from the websocket server
@@ -13,6 +15,8 @@ type OpenOrders struct {
 	Sequence SequenceId
 	// Channel name
 	ChannelName string
+	// Number of trailing items received with the array and ignored (Cf. Validate)
+	extraItems int
 }
 
 // Custom JSON marhsaller for OpenOrders wich produces the same payloads as the API.
@@ -36,10 +40,27 @@ func (oo *OpenOrders) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return fmt.Errorf("failed to parse data as OrderInfo: %w", err)
 	}
+	extra, err := checkArrayLength("openOrders message", len(tmp), 3, 3)
+	if err != nil {
+		return err
+	}
+	oo.extraItems = extra
 	// Encode target
-	oo.ChannelName = tmp[1].(string)
-	oo.Orders = *tmp[0].(*[]map[string]OrderInfo)
-	oo.Sequence = *tmp[2].(*SequenceId)
+	orders, ok := tmp[0].(*[]map[string]OrderInfo)
+	if !ok {
+		return fmt.Errorf("failed to extract orders from parsed data: %s", string(data))
+	}
+	cname, ok := tmp[1].(string)
+	if !ok {
+		return fmt.Errorf("failed to extract channel name from parsed data: %s", string(data))
+	}
+	sequence, ok := tmp[2].(*SequenceId)
+	if !ok {
+		return fmt.Errorf("failed to extract sequence from parsed data: %s", string(data))
+	}
+	oo.ChannelName = cname
+	oo.Orders = *orders
+	oo.Sequence = *sequence
 	return nil
 }
 
@@ -131,8 +152,170 @@ type OrderInfo struct {
 	OrderFlags string `json:"oflags,omitempty"`
 	// Optional - time in force.
 	TimeInForce string `json:"timeinforce,omitempty"`
+	// Optional - self trade prevention flag applied to the order. The server does not provide the
+	// flag: it is set from the order request by AddOrderAndWait and by the paper trading client.
+	StpType string `json:"stptype,omitempty"`
 	// Optional - cancel reason, present for all cancellation updates (status="canceled") and for some close updates (status="closed")
 	CancelReason string `json:"cancel_reason,omitempty"`
 	// Optional - rate-limit counter, present if requested in subscription request.
 	RateCount int `json:"ratecount,omitempty"`
 }
+
+/*************************************************************************************************/
+/* ORDER UPDATES                                                                                 */
+/*************************************************************************************************/
+
+// Enum for the kinds of order updates found in openOrders messages.
+type OrderUpdateKindEnum string
+
+// Values for OrderUpdateKindEnum
+const (
+	// Full order description: the initial snapshot and new orders. The order description is set.
+	FullOrder OrderUpdateKindEnum = "full"
+	// Status-only update: only the status and the related data (last update time, cancel reason)
+	// are set.
+	StatusUpdate OrderUpdateKindEnum = "status"
+	// Partial update: some order data (ex: executed volume, cost, fee) have changed. Only the
+	// changed data are set.
+	PartialUpdate OrderUpdateKindEnum = "partial"
+)
+
+// A single order update from an openOrders message.
+type OrderUpdate struct {
+	// Order ID
+	TxId string
+	// Kind of update
+	Kind OrderUpdateKindEnum
+	// Order data. Only the changed data are set for status-only and partial updates.
+	Order OrderInfo
+}
+
+// # Description
+//
+// Parse the orders of the message into a list of order updates, in the order they have been sent
+// by the server. Updates grouped in the same object are sorted by order ID.
+//
+// Updates with an order description are full order descriptions. Updates which only set the
+// status, the last update time, the cancel reason and the rate counter are status-only updates.
+// All other updates are partial updates.
+//
+// # Return
+//
+// The order updates.
+func (oo OpenOrders) Updates() []OrderUpdate {
+	updates := []OrderUpdate{}
+	for _, orders := range oo.Orders {
+		txids := make([]string, 0, len(orders))
+		for txid := range orders {
+			txids = append(txids, txid)
+		}
+		sort.Strings(txids)
+		for _, txid := range txids {
+			order := orders[txid]
+			updates = append(updates, OrderUpdate{TxId: txid, Kind: order.updateKind(), Order: order})
+		}
+	}
+	return updates
+}
+
+// Local view of orders by order ID, maintained by applying openOrders messages with MergeInto.
+type OpenOrdersState map[string]OrderInfo
+
+// # Description
+//
+// Apply the order updates of the message to the provided local view of open orders:
+//   - Full order descriptions replace the order.
+//   - Status-only and partial updates are merged into the known order: only the data set in the
+//     update are changed. Updates for unknown orders are stored as is.
+//   - Orders which reach a final status (closed, canceled, expired) are removed from the state.
+//
+// # Inputs
+//
+//   - state: Local view of open orders to update. Must not be nil.
+//
+// # Return
+//
+// The orders which have been removed from the state because they reached a final status, with
+// the update merged into their last known data.
+func (oo OpenOrders) MergeInto(state OpenOrdersState) map[string]OrderInfo {
+	done := map[string]OrderInfo{}
+	for _, update := range oo.Updates() {
+		order := update.Order
+		if known, ok := state[update.TxId]; ok && update.Kind != FullOrder {
+			known.Merge(update.Order)
+			order = known
+		}
+		switch OrderStatusEnum(order.Status) {
+		case Closed, Canceled, Expired:
+			delete(state, update.TxId)
+			done[update.TxId] = order
+		default:
+			state[update.TxId] = order
+		}
+	}
+	return done
+}
+
+// # Description
+//
+// Merge an update into the order: the data set in the update replace the data of the order, the
+// other data are left untouched.
+//
+// # Inputs
+//
+//   - update: Status-only or partial update for the order.
+func (info *OrderInfo) Merge(update OrderInfo) {
+	setString := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
+		}
+	}
+	setString(&info.ReferralOrderTransactionId, update.ReferralOrderTransactionId)
+	setString(&info.Status, update.Status)
+	setString(&info.OpenTimestamp, update.OpenTimestamp)
+	setString(&info.StartTimestamp, update.StartTimestamp)
+	setString(&info.DisplayVolume, update.DisplayVolume)
+	setString(&info.DisplayVolumeRemain, update.DisplayVolumeRemain)
+	setString(&info.ExpireTimestamp, update.ExpireTimestamp)
+	setString(&info.LastUpdated, update.LastUpdated)
+	setString(&info.Volume, update.Volume)
+	setString(&info.VolumeExecuted, update.VolumeExecuted)
+	setString(&info.Cost, update.Cost)
+	setString(&info.Fee, update.Fee)
+	setString(&info.AvgPrice, update.AvgPrice)
+	setString(&info.StopPrice, update.StopPrice)
+	setString(&info.LimitPrice, update.LimitPrice)
+	setString(&info.Miscellaneous, update.Miscellaneous)
+	setString(&info.OrderFlags, update.OrderFlags)
+	setString(&info.TimeInForce, update.TimeInForce)
+	setString(&info.CancelReason, update.CancelReason)
+	if update.UserReferenceId != nil {
+		info.UserReferenceId = update.UserReferenceId
+	}
+	if update.Contingent != nil {
+		info.Contingent = update.Contingent
+	}
+	if update.Description != nil {
+		info.Description = update.Description
+	}
+	if update.RateCount != 0 {
+		info.RateCount = update.RateCount
+	}
+}
+
+// Classify the order data as a full order description, a status-only or a partial update.
+func (info OrderInfo) updateKind() OrderUpdateKindEnum {
+	if info.Description != nil {
+		return FullOrder
+	}
+	statusOnly := OrderInfo{
+		Status:       info.Status,
+		LastUpdated:  info.LastUpdated,
+		CancelReason: info.CancelReason,
+		RateCount:    info.RateCount,
+	}
+	if info.Status != "" && reflect.DeepEqual(info, statusOnly) {
+		return StatusUpdate
+	}
+	return PartialUpdate
+}
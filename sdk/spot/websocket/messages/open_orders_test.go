@@ -293,3 +293,50 @@ func (suite *OpenOrdersUnitTestSuite) TestOpenOrdersMarshalJson() {
 	// Check data
 	require.Equal(suite.T(), payload, string(actual))
 }
+
+// Test parsing order updates and applying them to a local view of open orders.
+//
+// Test will ensure:
+//   - Full order descriptions, status-only and partial updates are distinguished.
+//   - Full order descriptions are added to the state.
+//   - Partial and status-only updates are merged into the known orders.
+//   - Orders which reach a final status are removed from the state and returned.
+func (suite *OpenOrdersUnitTestSuite) TestOpenOrdersMergeInto() {
+	state := OpenOrdersState{}
+	// Snapshot
+	snapshot := new(OpenOrders)
+	require.NoError(suite.T(), json.Unmarshal([]byte(`[[
+		{"O1":{"status":"open","vol":"2","vol_exec":"0","descr":{"pair":"XBT/USD","type":"buy","ordertype":"limit","price":"100"}}},
+		{"O2":{"status":"pending","vol":"1","vol_exec":"0","descr":{"pair":"XBT/USD","type":"sell","ordertype":"limit","price":"200"}}}
+	],"openOrders",{"sequence":1}]`), snapshot))
+	updates := snapshot.Updates()
+	require.Len(suite.T(), updates, 2)
+	require.Equal(suite.T(), "O1", updates[0].TxId)
+	require.Equal(suite.T(), FullOrder, updates[0].Kind)
+	require.Empty(suite.T(), snapshot.MergeInto(state))
+	require.Len(suite.T(), state, 2)
+	// Updates
+	update := new(OpenOrders)
+	require.NoError(suite.T(), json.Unmarshal([]byte(`[[
+		{"O1":{"vol_exec":"1","cost":"100","fee":"0.1","avg_price":"100","lastupdated":"1688666559.8974"}},
+		{"O2":{"status":"open"}},
+		{"O2":{"status":"canceled","cancel_reason":"User requested","lastupdated":"1688666560.1"}},
+		{"O3":{"vol_exec":"1"}}
+	],"openOrders",{"sequence":2}]`), update))
+	updates = update.Updates()
+	require.Equal(suite.T(), []OrderUpdateKindEnum{PartialUpdate, StatusUpdate, StatusUpdate, PartialUpdate}, []OrderUpdateKindEnum{
+		updates[0].Kind, updates[1].Kind, updates[2].Kind, updates[3].Kind,
+	})
+	done := update.MergeInto(state)
+	require.Len(suite.T(), done, 1)
+	require.Equal(suite.T(), string(Canceled), done["O2"].Status)
+	require.Equal(suite.T(), "User requested", done["O2"].CancelReason)
+	require.Equal(suite.T(), "200", done["O2"].Description.Price)
+	require.NotContains(suite.T(), state, "O2")
+	o1 := state["O1"]
+	require.Equal(suite.T(), string(Open), o1.Status)
+	require.Equal(suite.T(), "2", o1.Volume)
+	require.Equal(suite.T(), "1", o1.VolumeExecuted)
+	require.Equal(suite.T(), "XBT/USD", o1.Description.Pair)
+	require.Equal(suite.T(), "1", state["O3"].VolumeExecuted)
+}
@@ -13,6 +13,8 @@ type OwnTrades struct {
 	SequenceId SequenceId
 	// Message data which contains a collection of maps where keys are trades IDs and values the related trades.
 	Data []map[string]OwnTradeData
+	// Number of trailing items received with the array and ignored (Cf. Validate)
+	extraItems int
 }
 
 // Custom JSON marshaller which produces the same JSON payloads as the API.
@@ -36,10 +38,27 @@ func (owt *OwnTrades) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return fmt.Errorf("failed to parse data as OwnTrades: %w", err)
 	}
+	extra, err := checkArrayLength("ownTrades message", len(tmp), 3, 3)
+	if err != nil {
+		return err
+	}
+	owt.extraItems = extra
 	// Encode target
-	owt.ChannelName = tmp[1].(string)
-	owt.Data = *tmp[0].(*[]map[string]OwnTradeData)
-	owt.SequenceId = *tmp[2].(*SequenceId)
+	trades, ok := tmp[0].(*[]map[string]OwnTradeData)
+	if !ok {
+		return fmt.Errorf("failed to extract trades from parsed data: %s", string(data))
+	}
+	cname, ok := tmp[1].(string)
+	if !ok {
+		return fmt.Errorf("failed to extract channel name from parsed data: %s", string(data))
+	}
+	sequence, ok := tmp[2].(*SequenceId)
+	if !ok {
+		return fmt.Errorf("failed to extract sequence from parsed data: %s", string(data))
+	}
+	owt.ChannelName = cname
+	owt.Data = *trades
+	owt.SequenceId = *sequence
 	return nil
 }
 
@@ -3,6 +3,7 @@ package messages
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 /*************************************************************************************************/
@@ -21,6 +22,13 @@ type Spread struct {
 	Pair string
 	// Spread
 	Data SpreadData
+	// Typed view of the spread, populated by UnmarshalJSON. Ignored by MarshalJSON: Data is used
+	// to produce the JSON data. Cf. SpreadData.Entry to build the entry from Data.
+	//
+	// Zero value if the spread cannot be parsed. The parsing error is reported by Validate.
+	Entry SpreadEntry
+	// Number of trailing items received with the array and ignored (Cf. Validate)
+	extraItems int
 }
 
 // Custom JSON marshaller for Spread
@@ -47,6 +55,11 @@ func (s *Spread) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	extra, err := checkArrayLength("spread message", len(tmp), 4, 4)
+	if err != nil {
+		return err
+	}
+	s.extraItems = extra
 	// 3. Extract data
 	// Extract channel ID: index 0
 	cid, ok := tmp[0].(float64) // Yes, it is understood like that by the parser
@@ -67,7 +80,15 @@ func (s *Spread) UnmarshalJSON(data []byte) error {
 	s.ChannelId = int(cid)
 	s.Name = cname
 	s.Pair = pair
-	s.Data = *tmp[1].(*SpreadData)
+	sdata, ok := tmp[1].(*SpreadData)
+	if !ok {
+		return fmt.Errorf("failed to extract spread data from parsed data: %s", string(data))
+	}
+	s.Data = *sdata
+	// 4. Build typed entry - leave it empty if the spread cannot be parsed (Cf. Validate)
+	if entry, err := s.Data.Entry(); err == nil {
+		s.Entry = entry
+	}
 	return nil
 }
 
@@ -87,6 +108,8 @@ type SpreadData struct {
 	BestBidVolume json.Number
 	// Best ask volume
 	BestAskVolume json.Number
+	// Number of trailing items received with the array and ignored (Cf. Validate)
+	extraItems int
 }
 
 // Marshal a spread as an array of strings to produce the same JSON data as the API.
@@ -113,6 +136,11 @@ func (spread *SpreadData) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	extra, err := checkArrayLength("spread", len(tmp), 5, 5)
+	if err != nil {
+		return err
+	}
+	spread.extraItems = extra
 	// Encode spread and exit
 	spread.BestBidPrice = json.Number(tmp[0])
 	spread.BestAskPrice = json.Number(tmp[1])
@@ -121,3 +149,49 @@ func (spread *SpreadData) UnmarshalJSON(data []byte) error {
 	spread.BestAskVolume = json.Number(tmp[4])
 	return nil
 }
+
+/*************************************************************************************************/
+/* SPREAD ENTRY                                                                                  */
+/*************************************************************************************************/
+
+// Typed view of a spread.
+type SpreadEntry struct {
+	// Best bid price
+	BestBidPrice float64
+	// Best ask price
+	BestAskPrice float64
+	// Time of the spread
+	Time time.Time
+	// Best bid volume
+	BestBidVolume float64
+	// Best ask volume
+	BestAskVolume float64
+}
+
+// # Description
+//
+// Parse the spread into a SpreadEntry.
+//
+// # Return
+//
+// The typed spread or an error if one of the values cannot be parsed.
+func (spread SpreadData) Entry() (SpreadEntry, error) {
+	var err error
+	entry := SpreadEntry{}
+	if entry.BestBidPrice, err = parseDecimal("best bid price", spread.BestBidPrice); err != nil {
+		return SpreadEntry{}, err
+	}
+	if entry.BestAskPrice, err = parseDecimal("best ask price", spread.BestAskPrice); err != nil {
+		return SpreadEntry{}, err
+	}
+	if entry.Time, err = parseTimestamp("time", spread.Timestamp); err != nil {
+		return SpreadEntry{}, err
+	}
+	if entry.BestBidVolume, err = parseDecimal("best bid volume", spread.BestBidVolume); err != nil {
+		return SpreadEntry{}, err
+	}
+	if entry.BestAskVolume, err = parseDecimal("best ask volume", spread.BestAskVolume); err != nil {
+		return SpreadEntry{}, err
+	}
+	return entry, nil
+}
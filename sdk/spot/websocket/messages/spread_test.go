@@ -3,6 +3,7 @@ package messages
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -88,3 +89,24 @@ func (suite *SpreadUnitTestSuite) TestSpreadMarshalJsonSpread() {
 	// Compare
 	require.Equal(suite.T(), payload, string(actual))
 }
+
+// Test the typed entry built when unmarshalling a Spread message.
+func (suite *SpreadUnitTestSuite) TestSpreadUnmarshalJsonEntry() {
+	payload := `[0,["5698.40000","5700.00000","1542057299.545897","1.01234567","0.98765432"],"spread","XBT/USD"]`
+	target := new(Spread)
+	err := json.Unmarshal([]byte(payload), target)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), SpreadEntry{
+		BestBidPrice:  5698.4,
+		BestAskPrice:  5700,
+		Time:          time.Unix(1542057299, 545897000).UTC(),
+		BestBidVolume: 1.01234567,
+		BestAskVolume: 0.98765432,
+	}, target.Entry)
+	// Invalid volume - the entry is skipped and reported by Validate
+	payload = `[0,["5698.40000","5700.00000","1542057299.545897","","0.98765432"],"spread","XBT/USD"]`
+	target = new(Spread)
+	require.NoError(suite.T(), json.Unmarshal([]byte(payload), target))
+	require.Zero(suite.T(), target.Entry)
+	require.ErrorContains(suite.T(), target.Validate(), "best bid volume")
+}
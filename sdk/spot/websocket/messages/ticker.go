@@ -21,6 +21,8 @@ type Ticker struct {
 	Pair string
 	// Ticker data
 	Data TickerData
+	// Number of trailing items received with the array and ignored (Cf. Validate)
+	extraItems int
 }
 
 // Custom JSON marshaller for Ticker
@@ -47,6 +49,11 @@ func (t *Ticker) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	extra, err := checkArrayLength("ticker message", len(tmp), 4, 4)
+	if err != nil {
+		return err
+	}
+	t.extraItems = extra
 	// 3. Extract data
 	// Extract channel ID: index 0
 	cid, ok := tmp[0].(float64) // Yes, it is understood like that by the parser
@@ -67,7 +74,11 @@ func (t *Ticker) UnmarshalJSON(data []byte) error {
 	t.ChannelId = int(cid)
 	t.Name = cname
 	t.Pair = pair
-	t.Data = *tmp[1].(*TickerData)
+	tdata, ok := tmp[1].(*TickerData)
+	if !ok {
+		return fmt.Errorf("failed to extract ticker data from parsed data: %s", string(data))
+	}
+	t.Data = *tdata
 	return nil
 }
 
@@ -3,6 +3,7 @@ package messages
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 /*************************************************************************************************/
@@ -21,6 +22,14 @@ type Trade struct {
 	Pair string
 	// Trades
 	Data []TradeData
+	// Typed view of the trades, populated by UnmarshalJSON. Ignored by MarshalJSON: Data is used
+	// to produce the JSON data. Cf. TradeData.Entry to build entries from Data.
+	//
+	// Trades which cannot be parsed are skipped and reported by Validate: Entries can have less
+	// items than Data.
+	Entries []TradeEntry
+	// Number of trailing items received with the array and ignored (Cf. Validate)
+	extraItems int
 }
 
 // Custom JSON marshaller for Trade
@@ -47,6 +56,11 @@ func (t *Trade) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	extra, err := checkArrayLength("trade message", len(tmp), 4, 4)
+	if err != nil {
+		return err
+	}
+	t.extraItems = extra
 	// 3. Extract data
 	// Extract channel ID: index 0
 	cid, ok := tmp[0].(float64) // Yes, it is understood like that by the parser
@@ -67,7 +81,18 @@ func (t *Trade) UnmarshalJSON(data []byte) error {
 	t.ChannelId = int(cid)
 	t.Name = cname
 	t.Pair = pair
-	t.Data = *tmp[1].(*[]TradeData)
+	tdata, ok := tmp[1].(*[]TradeData)
+	if !ok {
+		return fmt.Errorf("failed to extract trades from parsed data: %s", string(data))
+	}
+	t.Data = *tdata
+	// 4. Build typed entries - skip trades which cannot be parsed (Cf. Validate)
+	t.Entries = make([]TradeEntry, 0, len(t.Data))
+	for _, trade := range t.Data {
+		if entry, err := trade.Entry(); err == nil {
+			t.Entries = append(t.Entries, entry)
+		}
+	}
 	return nil
 }
 
@@ -89,6 +114,8 @@ type TradeData struct {
 	OrderType string
 	// Miscellaneous
 	Miscellaneous string
+	// Number of trailing items received with the array and ignored (Cf. Validate)
+	extraItems int
 }
 
 // Marshal a single trade as an array of strings to produce the same JSON data as the API.
@@ -116,6 +143,11 @@ func (trade *TradeData) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	extra, err := checkArrayLength("trade", len(tmp), 6, 6)
+	if err != nil {
+		return err
+	}
+	trade.extraItems = extra
 	// Encode trade and exit
 	trade.Price = json.Number(tmp[0])
 	trade.Volume = json.Number(tmp[1])
@@ -125,3 +157,68 @@ func (trade *TradeData) UnmarshalJSON(data []byte) error {
 	trade.Miscellaneous = tmp[5]
 	return nil
 }
+
+/*************************************************************************************************/
+/* TRADE ENTRY                                                                                   */
+/*************************************************************************************************/
+
+// Typed view of a single trade.
+type TradeEntry struct {
+	// Price
+	Price float64
+	// Volume
+	Volume float64
+	// Time of the trade
+	Time time.Time
+	// Triggering order side: Buy or Sell
+	Side SideEnum
+	// Triggering order type: Market or Limit
+	OrderType OrderTypeEnum
+	// Miscellaneous
+	Miscellaneous string
+}
+
+// # Description
+//
+// Parse the trade into a TradeEntry. Sides (b/s) and order types (m/l) are converted to SideEnum
+// and OrderTypeEnum values. Unknown sides and order types are kept as is.
+//
+// # Return
+//
+// The typed trade or an error if price, volume or time cannot be parsed.
+func (trade TradeData) Entry() (TradeEntry, error) {
+	price, err := parseDecimal("price", trade.Price)
+	if err != nil {
+		return TradeEntry{}, err
+	}
+	volume, err := parseDecimal("volume", trade.Volume)
+	if err != nil {
+		return TradeEntry{}, err
+	}
+	ts, err := parseTimestamp("time", trade.Timestamp)
+	if err != nil {
+		return TradeEntry{}, err
+	}
+	side := SideEnum(trade.Side)
+	switch trade.Side {
+	case "b":
+		side = Buy
+	case "s":
+		side = Sell
+	}
+	orderType := OrderTypeEnum(trade.OrderType)
+	switch trade.OrderType {
+	case "m":
+		orderType = Market
+	case "l":
+		orderType = Limit
+	}
+	return TradeEntry{
+		Price:         price,
+		Volume:        volume,
+		Time:          ts,
+		Side:          side,
+		OrderType:     orderType,
+		Miscellaneous: trade.Miscellaneous,
+	}, nil
+}
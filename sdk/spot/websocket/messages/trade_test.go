@@ -3,6 +3,7 @@ package messages
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -112,3 +113,44 @@ func (suite *TradeUnitTestSuite) TestTradeMarshalJsonTrade() {
 	// Compare
 	require.Equal(suite.T(), payload, string(actual))
 }
+
+// Test the typed entries built when unmarshalling a Trade message.
+//
+// Test will ensure:
+//   - Prices, volumes and times are parsed, including nanoseconds.
+//   - Sides and order types are converted to SideEnum and OrderTypeEnum values.
+//   - Trades which cannot be parsed are skipped and reported by Validate.
+//   - An error is returned when a value cannot be parsed.
+func (suite *TradeUnitTestSuite) TestTradeUnmarshalJsonEntries() {
+	payload := `[0,[["5541.20000","0.15850568","1534614057.321597","s","l",""],["6060.00000","0.02455000","1534614057","b","m","x"]],"trade","XBT/USD"]`
+	target := new(Trade)
+	err := json.Unmarshal([]byte(payload), target)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), target.Entries, 2)
+	require.Equal(suite.T(), TradeEntry{
+		Price:     5541.2,
+		Volume:    0.15850568,
+		Time:      time.Unix(1534614057, 321597000).UTC(),
+		Side:      Sell,
+		OrderType: Limit,
+	}, target.Entries[0])
+	require.Equal(suite.T(), TradeEntry{
+		Price:         6060,
+		Volume:        0.02455,
+		Time:          time.Unix(1534614057, 0).UTC(),
+		Side:          Buy,
+		OrderType:     Market,
+		Miscellaneous: "x",
+	}, target.Entries[1])
+	// Invalid price
+	payload = `[0,[["abc","0.15850568","1534614057.321597","s","l",""],["6060.00000","0.02455000","1534614057","b","m","x"]],"trade","XBT/USD"]`
+	target = new(Trade)
+	require.NoError(suite.T(), json.Unmarshal([]byte(payload), target))
+	require.Len(suite.T(), target.Data, 2)
+	require.Len(suite.T(), target.Entries, 1)
+	require.Equal(suite.T(), 6060.0, target.Entries[0].Price)
+	require.ErrorContains(suite.T(), target.Validate(), "price")
+	// Invalid time
+	_, err = TradeData{Price: "1", Volume: "1", Timestamp: "now"}.Entry()
+	require.ErrorContains(suite.T(), err, "time")
+}
@@ -0,0 +1,650 @@
+package messages
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*************************************************************************************************/
+/* VALIDATION HELPERS                                                                            */
+/*************************************************************************************************/
+
+// Interface implemented by all messages exchanged with the websocket API.
+type Validator interface {
+	// Validate the message: an error which describes the issue is returned if the message does not
+	// match the schema of the API (missing required field, unexpected event type or channel,
+	// value which cannot be parsed, ...).
+	Validate() error
+}
+
+// Check the event type of a message.
+func validateEvent(event string, expected EventTypeEnum) error {
+	if event != string(expected) {
+		return fmt.Errorf("event must be %q. Got %q", expected, event)
+	}
+	return nil
+}
+
+// Check a field required by the API is not empty.
+func validateRequired(name string, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s is required", name)
+	}
+	return nil
+}
+
+// Check the status of a response to a request. An error message is required if the status is error.
+func validateResponseStatus(status string, errorMessage string) error {
+	switch AddOrderStatusEnum(status) {
+	case Ok:
+		return nil
+	case Err:
+		return validateRequired("error message", errorMessage)
+	default:
+		return fmt.Errorf("status must be %q or %q. Got %q", Ok, Err, status)
+	}
+}
+
+// Check the name of a channel without suffix (ticker, trade, spread, ownTrades, openOrders).
+func validateChannelName(name string, expected ChannelEnum) error {
+	if name != string(expected) {
+		return fmt.Errorf("channel name must be %q. Got %q", expected, name)
+	}
+	return nil
+}
+
+// Check the name of a channel with a numeric suffix: ohlc-<interval> and book-<depth>. The suffix
+// must be one of the provided values.
+func validateChannelNameWithSuffix(name string, expected ChannelEnum, values ...int) error {
+	prefix, suffix, found := strings.Cut(name, "-")
+	if !found || prefix != string(expected) {
+		return fmt.Errorf("channel name must be %q followed by a suffix. Got %q", expected, name)
+	}
+	value, err := strconv.Atoi(suffix)
+	if err != nil || !containsInt(values, value) {
+		return fmt.Errorf("unexpected suffix for channel name %q", name)
+	}
+	return nil
+}
+
+// Check a value is one of the allowed values.
+func validateOneOf[T ~string](name string, value string, allowed ...T) error {
+	for _, candidate := range allowed {
+		if value == string(candidate) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unexpected %s %q", name, value)
+}
+
+// Check a decimal value can be parsed.
+func validateDecimal(name string, value json.Number) error {
+	_, err := parseDecimal(name, value)
+	return err
+}
+
+// Check a timestamp (seconds since epoch with decimal nanoseconds) can be parsed.
+func validateTimestamp(name string, value json.Number) error {
+	_, err := parseTimestamp(name, value)
+	return err
+}
+
+// Check the items of an array received from the server: expected length and parsable decimals.
+func validateDecimalArray(name string, values []json.Number, length int) error {
+	if len(values) != length {
+		return fmt.Errorf("%s must have %d items. Got %d", name, length, len(values))
+	}
+	for _, value := range values {
+		if err := validateDecimal(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check no trailing item has been received with an array (Cf. checkArrayLength).
+func validateExtraItems(name string, extra int) error {
+	if extra > 0 {
+		return fmt.Errorf("unexpected number of items for %s: %d trailing items", name, extra)
+	}
+	return nil
+}
+
+// Check a sequence number of a private message.
+func validateSequence(sequence SequenceId) error {
+	if sequence.Sequence <= 0 {
+		return fmt.Errorf("sequence must be strictly positive. Got %d", sequence.Sequence)
+	}
+	return nil
+}
+
+// Return true if values contains value.
+func containsInt(values []int, value int) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed values for the interval of ohlc channels.
+var validIntervals = []int{int(M1), int(M5), int(M15), int(M30), int(M60), int(M240), int(M1440), int(M10080), int(M21600)}
+
+// Allowed values for the depth of book channels.
+var validDepths = []int{int(D10), int(D25), int(D100), int(D500), int(D1000)}
+
+// Channels which can be subscribed to.
+var validChannels = []ChannelEnum{ChannelAll, ChannelBook, ChannelOHLC, ChannelOpenOrders, ChannelOwnTrades, ChannelSpread, ChannelTicker, ChannelTrade}
+
+// Check the details of a subscribe or unsubscribe request.
+func validateSubscriptionDetails(name string, depth int, interval int, token string) error {
+	if err := validateOneOf("channel", name, validChannels...); err != nil {
+		return err
+	}
+	if depth != 0 && !containsInt(validDepths, depth) {
+		return fmt.Errorf("unexpected depth %d", depth)
+	}
+	if interval != 0 && !containsInt(validIntervals, interval) {
+		return fmt.Errorf("unexpected interval %d", interval)
+	}
+	if (name == string(ChannelOwnTrades) || name == string(ChannelOpenOrders)) && token == "" {
+		return fmt.Errorf("a token is required to subscribe to the %s channel", name)
+	}
+	return nil
+}
+
+// Check the entries of a book message.
+func validateBookEntries(name string, entries []BookMessageEntry) error {
+	for _, entry := range entries {
+		if err := validateExtraItems(name+" entry", entry.extraItems); err != nil {
+			return err
+		}
+		if err := validateDecimal(name+" price", entry.Price); err != nil {
+			return err
+		}
+		if err := validateDecimal(name+" volume", entry.Volume); err != nil {
+			return err
+		}
+		if err := validateTimestamp(name+" timestamp", entry.Timestamp); err != nil {
+			return err
+		}
+		if entry.UpdateType != "" && entry.UpdateType != "r" {
+			return fmt.Errorf("unexpected %s update type %q", name, entry.UpdateType)
+		}
+	}
+	return nil
+}
+
+/*************************************************************************************************/
+/* GENERAL MESSAGES                                                                              */
+/*************************************************************************************************/
+
+// Validate the heartbeat message.
+func (msg Heartbeat) Validate() error {
+	return validateEvent(msg.Event, EventTypeHeartbeat)
+}
+
+// Validate the ping request.
+func (msg Ping) Validate() error {
+	return validateEvent(msg.Event, EventTypePing)
+}
+
+// Validate the pong message.
+func (msg Pong) Validate() error {
+	return validateEvent(msg.Event, EventTypePong)
+}
+
+// Validate the systemStatus message.
+func (msg SystemStatus) Validate() error {
+	if err := validateEvent(msg.Event, EventTypeSystemStatus); err != nil {
+		return err
+	}
+	if err := validateOneOf("status", msg.Status, StatusOnline, StatusMaintenance, StatusCancelOnly, StatusLimitOnly, StatusPostOnly); err != nil {
+		return err
+	}
+	return validateRequired("version", msg.Version)
+}
+
+// Validate the subscriptionStatus message. Channel name and subscription details are required
+// unless the status is error.
+func (msg SubscriptionStatus) Validate() error {
+	if err := validateEvent(msg.Event, EventTypeSubscriptionStatus); err != nil {
+		return err
+	}
+	if err := validateOneOf("status", msg.Status, Subscribed, Unsubscribed, Error); err != nil {
+		return err
+	}
+	if msg.Status == string(Error) {
+		return validateRequired("error message", msg.Err)
+	}
+	if err := validateRequired("channel name", msg.ChannelName); err != nil {
+		return err
+	}
+	if msg.Subscription == nil {
+		return fmt.Errorf("subscription is required")
+	}
+	return validateOneOf("channel", msg.Subscription.Name, validChannels...)
+}
+
+// Validate the error message.
+func (msg ErrorMessage) Validate() error {
+	if err := validateEvent(msg.Event, EventTypeError); err != nil {
+		return err
+	}
+	return validateRequired("error message", msg.Err)
+}
+
+// Validate the subscribe request.
+func (msg Subscribe) Validate() error {
+	if err := validateEvent(msg.Event, EventTypeSubscribe); err != nil {
+		return err
+	}
+	return validateSubscriptionDetails(msg.Subscription.Name, msg.Subscription.Depth, msg.Subscription.Interval, msg.Subscription.Token)
+}
+
+// Validate the unsubscribe request.
+func (msg Unsubscribe) Validate() error {
+	if err := validateEvent(msg.Event, EventTypeUnsubscribe); err != nil {
+		return err
+	}
+	return validateSubscriptionDetails(msg.Subscription.Name, msg.Subscription.Depth, msg.Subscription.Interval, msg.Subscription.Token)
+}
+
+/*************************************************************************************************/
+/* PUBLIC MESSAGES                                                                               */
+/*************************************************************************************************/
+
+// Validate the ticker message: all the arrays must have the expected length and their values
+// must be parsable.
+func (msg Ticker) Validate() error {
+	if err := validateExtraItems("ticker message", msg.extraItems); err != nil {
+		return err
+	}
+	if err := validateChannelName(msg.Name, ChannelTicker); err != nil {
+		return err
+	}
+	if err := validateRequired("pair", msg.Pair); err != nil {
+		return err
+	}
+	for _, array := range []struct {
+		name   string
+		values []json.Number
+		length int
+	}{
+		{"ask", msg.Data.Ask, 3},
+		{"bid", msg.Data.Bid, 3},
+		{"close", msg.Data.Close, 2},
+		{"volume", msg.Data.Volume, 2},
+		{"volume average price", msg.Data.VolumeAveragePrice, 2},
+		{"trades", msg.Data.Trades, 2},
+		{"low", msg.Data.Low, 2},
+		{"high", msg.Data.High, 2},
+		{"open", msg.Data.Open, 2},
+	} {
+		if err := validateDecimalArray(array.name, array.values, array.length); err != nil {
+			return err
+		}
+	}
+	// Whole lot volumes and trade counts are integers
+	for name, value := range map[string]json.Number{
+		"ask whole lot volume": msg.Data.Ask[1],
+		"bid whole lot volume": msg.Data.Bid[1],
+		"today trade count":    msg.Data.Trades[0],
+		"past 24h trade count": msg.Data.Trades[1],
+	} {
+		if _, err := value.Int64(); err != nil {
+			return fmt.Errorf("%s must be an integer. Got %q", name, value.String())
+		}
+	}
+	return nil
+}
+
+// Validate the ohlc message.
+func (msg OHLC) Validate() error {
+	if err := validateExtraItems("ohlc message", msg.extraItems); err != nil {
+		return err
+	}
+	if err := validateExtraItems("ohlc", msg.Data.extraItems); err != nil {
+		return err
+	}
+	if err := validateChannelNameWithSuffix(msg.Name, ChannelOHLC, validIntervals...); err != nil {
+		return err
+	}
+	if err := validateRequired("pair", msg.Pair); err != nil {
+		return err
+	}
+	_, err := msg.Data.Entry()
+	return err
+}
+
+// Validate the trade message: at least one trade is required.
+func (msg Trade) Validate() error {
+	if err := validateExtraItems("trade message", msg.extraItems); err != nil {
+		return err
+	}
+	if err := validateChannelName(msg.Name, ChannelTrade); err != nil {
+		return err
+	}
+	if err := validateRequired("pair", msg.Pair); err != nil {
+		return err
+	}
+	if len(msg.Data) == 0 {
+		return fmt.Errorf("at least one trade is required")
+	}
+	for _, trade := range msg.Data {
+		if err := validateExtraItems("trade", trade.extraItems); err != nil {
+			return err
+		}
+		if _, err := trade.Entry(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate the spread message.
+func (msg Spread) Validate() error {
+	if err := validateExtraItems("spread message", msg.extraItems); err != nil {
+		return err
+	}
+	if err := validateExtraItems("spread", msg.Data.extraItems); err != nil {
+		return err
+	}
+	if err := validateChannelName(msg.Name, ChannelSpread); err != nil {
+		return err
+	}
+	if err := validateRequired("pair", msg.Pair); err != nil {
+		return err
+	}
+	_, err := msg.Data.Entry()
+	return err
+}
+
+// Validate the book snapshot message.
+func (msg BookSnapshot) Validate() error {
+	if err := validateExtraItems("book snapshot message", msg.extraItems); err != nil {
+		return err
+	}
+	if err := validateChannelNameWithSuffix(msg.Name, ChannelBook, validDepths...); err != nil {
+		return err
+	}
+	if err := validateRequired("pair", msg.Pair); err != nil {
+		return err
+	}
+	if err := validateBookEntries("ask", msg.Data.Asks); err != nil {
+		return err
+	}
+	return validateBookEntries("bid", msg.Data.Bids)
+}
+
+// Validate the book update message: at least one ask or bid update and a checksum are required.
+func (msg BookUpdate) Validate() error {
+	if err := validateExtraItems("book update message", msg.extraItems); err != nil {
+		return err
+	}
+	if err := validateChannelNameWithSuffix(msg.Name, ChannelBook, validDepths...); err != nil {
+		return err
+	}
+	if err := validateRequired("pair", msg.Pair); err != nil {
+		return err
+	}
+	if len(msg.Data.Asks) == 0 && len(msg.Data.Bids) == 0 {
+		return fmt.Errorf("at least one ask or bid update is required")
+	}
+	if err := validateBookEntries("ask", msg.Data.Asks); err != nil {
+		return err
+	}
+	if err := validateBookEntries("bid", msg.Data.Bids); err != nil {
+		return err
+	}
+	if _, err := strconv.ParseUint(msg.Data.Checksum, 10, 32); err != nil {
+		return fmt.Errorf("checksum must be an unsigned 32-bit integer. Got %q", msg.Data.Checksum)
+	}
+	return nil
+}
+
+/*************************************************************************************************/
+/* PRIVATE MESSAGES                                                                              */
+/*************************************************************************************************/
+
+// Validate the openOrders message. Orders are not checked further as updates only contain the
+// fields which have changed.
+func (msg OpenOrders) Validate() error {
+	if err := validateExtraItems("openOrders message", msg.extraItems); err != nil {
+		return err
+	}
+	if err := validateChannelName(msg.ChannelName, ChannelOpenOrders); err != nil {
+		return err
+	}
+	if err := validateSequence(msg.Sequence); err != nil {
+		return err
+	}
+	for _, orders := range msg.Orders {
+		for id := range orders {
+			if id == "" {
+				return fmt.Errorf("order id is required")
+			}
+		}
+	}
+	return nil
+}
+
+// Validate the ownTrades message.
+func (msg OwnTrades) Validate() error {
+	if err := validateExtraItems("ownTrades message", msg.extraItems); err != nil {
+		return err
+	}
+	if err := validateChannelName(msg.ChannelName, ChannelOwnTrades); err != nil {
+		return err
+	}
+	if err := validateSequence(msg.SequenceId); err != nil {
+		return err
+	}
+	for _, trades := range msg.Data {
+		for id, trade := range trades {
+			if id == "" {
+				return fmt.Errorf("trade id is required")
+			}
+			if err := trade.validate(); err != nil {
+				return fmt.Errorf("invalid trade %s: %w", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate the data of a single trade.
+func (trade OwnTradeData) validate() error {
+	if err := validateRequired("order id", trade.OrderTransactionId); err != nil {
+		return err
+	}
+	if err := validateRequired("pair", trade.Pair); err != nil {
+		return err
+	}
+	if err := validateTimestamp("time", json.Number(trade.Timestamp)); err != nil {
+		return err
+	}
+	if err := validateOneOf("side", trade.Type, Buy, Sell); err != nil {
+		return err
+	}
+	if err := validateDecimal("price", json.Number(trade.Price)); err != nil {
+		return err
+	}
+	if err := validateDecimal("fee", json.Number(trade.Fee)); err != nil {
+		return err
+	}
+	return validateDecimal("volume", json.Number(trade.Volume))
+}
+
+/*************************************************************************************************/
+/* TRADING REQUESTS                                                                              */
+/*************************************************************************************************/
+
+// Validate the addOrder request. Prices are not checked as they can be relative prices.
+func (msg AddOrderRequest) Validate() error {
+	if err := validateEvent(msg.Event, EventTypeAddOrder); err != nil {
+		return err
+	}
+	if err := validateRequired("token", msg.Token); err != nil {
+		return err
+	}
+	if err := validateOneOf("order type", msg.OrderType, Market, Limit, StopLoss, TakeProfit, StopLossLimit, TakeProfitLimit, SettlePosition, Iceberg, TrailingStop, TrailingStopLimit); err != nil {
+		return err
+	}
+	if err := validateOneOf("side", msg.Type, Buy, Sell); err != nil {
+		return err
+	}
+	if err := validateRequired("pair", msg.Pair); err != nil {
+		return err
+	}
+	if err := validateDecimal("volume", json.Number(msg.Volume)); err != nil {
+		return err
+	}
+	if msg.Trigger != "" {
+		if err := validateOneOf("trigger", msg.Trigger, Last, Index); err != nil {
+			return err
+		}
+	}
+	if msg.StpType != "" {
+		if err := validateOneOf("self trade prevention flag", msg.StpType, STPCancelNewest, STPCancelOldest, STPCancelBoth); err != nil {
+			return err
+		}
+	}
+	if msg.TimeInForce != "" {
+		if err := validateOneOf("time in force", msg.TimeInForce, GoodTilCanceled, ImmediateOrCancel, GoodTilDate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate the editOrder request.
+func (msg EditOrderRequest) Validate() error {
+	if err := validateEvent(msg.Event, EventTypeEditOrder); err != nil {
+		return err
+	}
+	if err := validateRequired("token", msg.Token); err != nil {
+		return err
+	}
+	if err := validateRequired("order id", msg.Id); err != nil {
+		return err
+	}
+	return validateRequired("pair", msg.Pair)
+}
+
+// Validate the cancelOrder request: at least one order id is required.
+func (msg CancelOrderRequest) Validate() error {
+	if err := validateEvent(msg.Event, EventTypeCancelOrder); err != nil {
+		return err
+	}
+	if err := validateRequired("token", msg.Token); err != nil {
+		return err
+	}
+	if len(msg.TxId) == 0 {
+		return fmt.Errorf("at least one order id is required")
+	}
+	return nil
+}
+
+// Validate the cancelAll request.
+func (msg CancelAllOrdersRequest) Validate() error {
+	if err := validateEvent(msg.Event, EventTypeCancelAllOrders); err != nil {
+		return err
+	}
+	return validateRequired("token", msg.Token)
+}
+
+// Validate the cancelAllOrdersAfter request.
+func (msg CancelAllOrdersAfterXRequest) Validate() error {
+	if err := validateEvent(msg.Event, EventTypeCancelAllOrdersAfterX); err != nil {
+		return err
+	}
+	if err := validateRequired("token", msg.Token); err != nil {
+		return err
+	}
+	if msg.Timeout < 0 {
+		return fmt.Errorf("timeout must be positive. Got %d", msg.Timeout)
+	}
+	return nil
+}
+
+/*************************************************************************************************/
+/* TRADING RESPONSES                                                                             */
+/*************************************************************************************************/
+
+// Validate the addOrderStatus message: an order id is required if the order has been added.
+func (msg AddOrderResponse) Validate() error {
+	if err := validateEvent(msg.Event, EventTypeAddOrderStatus); err != nil {
+		return err
+	}
+	if err := validateResponseStatus(msg.Status, msg.Err); err != nil {
+		return err
+	}
+	if msg.Status == string(Ok) {
+		return validateRequired("order id", msg.TxId)
+	}
+	return nil
+}
+
+// Validate the editOrderStatus message: the new order id is required if the order has been edited.
+func (msg EditOrderResponse) Validate() error {
+	if err := validateEvent(msg.Event, EventTypeEditOrderStatus); err != nil {
+		return err
+	}
+	if err := validateResponseStatus(msg.Status, msg.Err); err != nil {
+		return err
+	}
+	if msg.Status == string(Ok) {
+		return validateRequired("order id", msg.TxId)
+	}
+	return nil
+}
+
+// Validate the cancelOrderStatus message.
+func (msg CancelOrderResponse) Validate() error {
+	if err := validateEvent(msg.Event, EventTypeCancelOrderStatus); err != nil {
+		return err
+	}
+	return validateResponseStatus(msg.Status, msg.Err)
+}
+
+// Validate the cancelAllStatus message.
+func (msg CancelAllOrdersResponse) Validate() error {
+	if err := validateEvent(msg.Event, EventTypeCancelAllOrderStatus); err != nil {
+		return err
+	}
+	if err := validateResponseStatus(msg.Status, msg.Err); err != nil {
+		return err
+	}
+	if msg.Count < 0 {
+		return fmt.Errorf("count must be positive. Got %d", msg.Count)
+	}
+	return nil
+}
+
+// Validate the cancelAllOrdersAfterStatus message: the current time is required if the request
+// has succeeded. The trigger time is "0" when the timer is disabled.
+func (msg CancelAllOrdersAfterXResponse) Validate() error {
+	if err := validateEvent(msg.Event, EventTypeCancelAllOrderAfterXStatus); err != nil {
+		return err
+	}
+	if err := validateResponseStatus(msg.Status, msg.Err); err != nil {
+		return err
+	}
+	if msg.Status != string(Ok) {
+		return nil
+	}
+	if _, err := time.Parse(time.RFC3339, msg.CurrentTime); err != nil {
+		return fmt.Errorf("current time must be a RFC3339 timestamp. Got %q", msg.CurrentTime)
+	}
+	if msg.TriggerTime != "0" {
+		if _, err := time.Parse(time.RFC3339, msg.TriggerTime); err != nil {
+			return fmt.Errorf("trigger time must be a RFC3339 timestamp or \"0\". Got %q", msg.TriggerTime)
+		}
+	}
+	return nil
+}
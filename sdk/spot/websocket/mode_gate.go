@@ -0,0 +1,230 @@
+package websocket
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Enum for the behaviors of the client when an order management command is not allowed by the
+// current status of the trading engine.
+type ModeGatePolicyEnum string
+
+// Values for ModeGatePolicyEnum
+const (
+	// Send commands whatever the status of the trading engine. This is the default behavior.
+	ModeGateDisabled ModeGatePolicyEnum = "disabled"
+	// Reject commands which are not allowed with a TradingModeError.
+	ModeGateDeny ModeGatePolicyEnum = "deny"
+	// Wait until the command is allowed or until the context is done.
+	ModeGateDefer ModeGatePolicyEnum = "defer"
+)
+
+// # Description
+//
+// ModeGate tracks the status of the trading engine published in systemStatus messages and tells
+// whether order management commands are allowed:
+//
+//   - online or unknown status: all commands are allowed.
+//   - maintenance: no command is allowed.
+//   - cancel_only: only cancelOrder, cancelAll and cancelAllOrdersAfter are allowed.
+//   - post_only: cancels are allowed, new and edited orders must be post-only limit orders.
+//   - limit_only: cancels and edits are allowed, new orders must be limit orders.
+//
+// ModeGate is safe for concurrent use.
+type ModeGate struct {
+	// Mutex used to protect the gate state
+	mu sync.Mutex
+	// Current status of the trading engine. Empty if unknown.
+	status messages.EngineStatusEnum
+	// Channel closed and replaced each time the status changes
+	changed chan struct{}
+}
+
+// Factory which returns a new ModeGate with an unknown status.
+func NewModeGate() *ModeGate {
+	return &ModeGate{changed: make(chan struct{})}
+}
+
+// Get the current status of the trading engine. An empty value is returned if no status has been
+// received yet.
+func (gate *ModeGate) Status() messages.EngineStatusEnum {
+	gate.mu.Lock()
+	defer gate.mu.Unlock()
+	return gate.status
+}
+
+// # Description
+//
+// Update the status of the trading engine and wake up the commands waiting for the gate.
+//
+// # Inputs
+//
+//   - status: New status of the trading engine.
+func (gate *ModeGate) Update(status messages.EngineStatusEnum) {
+	gate.mu.Lock()
+	defer gate.mu.Unlock()
+	if gate.status == status {
+		return
+	}
+	gate.status = status
+	close(gate.changed)
+	gate.changed = make(chan struct{})
+}
+
+// # Description
+//
+// Check whether an order management command is allowed by the current status of the trading
+// engine.
+//
+// # Inputs
+//
+//   - command: Command type: addOrder, editOrder, cancelOrder, cancelAll or cancelAllOrdersAfter.
+//   - orderType: Order type for addOrder commands. Ignored for other commands.
+//   - oflags: Comma delimited order flags for addOrder and editOrder commands. Ignored for other commands.
+//
+// # Return
+//
+// A TradingModeError if the command is not allowed, nil otherwise.
+func (gate *ModeGate) Check(command messages.EventTypeEnum, orderType string, oflags string) error {
+	gate.mu.Lock()
+	status := gate.status
+	gate.mu.Unlock()
+	if allowedByStatus(status, command, orderType, oflags) {
+		return nil
+	}
+	return &TradingModeError{Operation: string(command), Status: status}
+}
+
+// # Description
+//
+// Wait until an order management command is allowed by the status of the trading engine.
+//
+// # Inputs
+//
+//   - ctx: Context used for coordination purpose. Waiting stops once the context is done.
+//   - command: Command type: addOrder, editOrder, cancelOrder, cancelAll or cancelAllOrdersAfter.
+//   - orderType: Order type for addOrder commands. Ignored for other commands.
+//   - oflags: Comma delimited order flags for addOrder and editOrder commands. Ignored for other commands.
+//
+// # Return
+//
+// Nil once the command is allowed. A TradingModeError which wraps the context error if the
+// context is done before.
+func (gate *ModeGate) Wait(ctx context.Context, command messages.EventTypeEnum, orderType string, oflags string) error {
+	for {
+		gate.mu.Lock()
+		status := gate.status
+		changed := gate.changed
+		gate.mu.Unlock()
+		if allowedByStatus(status, command, orderType, oflags) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return &TradingModeError{Operation: string(command), Status: status, Root: ctx.Err()}
+		case <-changed:
+		}
+	}
+}
+
+// Tell whether a command is allowed by the provided trading engine status.
+func allowedByStatus(status messages.EngineStatusEnum, command messages.EventTypeEnum, orderType string, oflags string) bool {
+	cancel := command == messages.EventTypeCancelOrder ||
+		command == messages.EventTypeCancelAllOrders ||
+		command == messages.EventTypeCancelAllOrdersAfterX
+	switch status {
+	case messages.StatusMaintenance:
+		return false
+	case messages.StatusCancelOnly:
+		return cancel
+	case messages.StatusPostOnly:
+		if cancel {
+			return true
+		}
+		post := false
+		for _, flag := range strings.Split(oflags, ",") {
+			post = post || strings.TrimSpace(flag) == string(messages.OFlagPost)
+		}
+		if command == messages.EventTypeAddOrder {
+			return post && orderType == string(messages.Limit)
+		}
+		return post
+	case messages.StatusLimitOnly:
+		if command == messages.EventTypeAddOrder {
+			return orderType == string(messages.Limit)
+		}
+		return true
+	default:
+		// online or unknown status
+		return true
+	}
+}
+
+// # Description
+//
+// Get the ModeGate which tracks the status of the trading engine from the systemStatus messages
+// received by the client. The gate can be consulted or waited before sending orders through
+// another client.
+//
+// # Return
+//
+// The ModeGate of the client.
+func (client *krakenSpotWebsocketClient) GetModeGate() *ModeGate {
+	return client.modeGate
+}
+
+// # Description
+//
+// Set whether and how order management commands sent by the client consult the ModeGate. By
+// default, commands are sent whatever the status of the trading engine.
+//
+// # Inputs
+//
+//   - policy: Behavior of the client. An empty value resets the default behavior (ModeGateDisabled).
+func (client *krakenSpotWebsocketClient) SetModeGatePolicy(policy ModeGatePolicyEnum) {
+	if policy == "" {
+		policy = ModeGateDisabled
+	}
+	client.modeGatePolicy.Store(policy)
+}
+
+// # Description
+//
+// Consult the ModeGate before sending an order management command, according to the mode gate
+// policy of the client.
+//
+// # Inputs
+//
+//   - ctx: Context used for coordination purpose. Waiting stops once the context is done.
+//   - span: Span of the command. An event is added when the command is deferred or denied.
+//   - command: Command type.
+//   - orderType: Order type for addOrder commands.
+//   - oflags: Order flags for addOrder and editOrder commands.
+//
+// # Return
+//
+// A TradingModeError if the command must not be sent.
+func (client *krakenSpotWebsocketClient) consultModeGate(ctx context.Context, span trace.Span, command messages.EventTypeEnum, orderType string, oflags string) error {
+	policy, _ := client.modeGatePolicy.Load().(ModeGatePolicyEnum)
+	switch policy {
+	case ModeGateDeny:
+		err := client.modeGate.Check(command, orderType, oflags)
+		if err != nil {
+			span.AddEvent("trading_mode_denied", trace.WithAttributes(attribute.String("status", string(client.modeGate.Status()))))
+		}
+		return err
+	case ModeGateDefer:
+		if client.modeGate.Check(command, orderType, oflags) == nil {
+			return nil
+		}
+		span.AddEvent("trading_mode_deferred", trace.WithAttributes(attribute.String("status", string(client.modeGate.Status()))))
+		return client.modeGate.Wait(ctx, command, orderType, oflags)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,131 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/trace"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for ModeGate
+type ModeGateUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestModeGateUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(ModeGateUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test ModeGate.Check.
+//
+// Test will ensure:
+//   - All commands are allowed while the status is unknown or online.
+//   - No command is allowed in maintenance.
+//   - Only cancels are allowed in cancel_only.
+//   - Only post-only limit orders can be added in post_only.
+//   - Only limit orders can be added in limit_only.
+func (suite *ModeGateUnitTestSuite) TestCheck() {
+	gate := NewModeGate()
+	require.Empty(suite.T(), gate.Status())
+	require.NoError(suite.T(), gate.Check(messages.EventTypeAddOrder, "market", ""))
+	gate.Update(messages.StatusOnline)
+	require.NoError(suite.T(), gate.Check(messages.EventTypeAddOrder, "market", ""))
+	// Maintenance
+	gate.Update(messages.StatusMaintenance)
+	err := gate.Check(messages.EventTypeCancelOrder, "", "")
+	tmerr := new(TradingModeError)
+	require.True(suite.T(), errors.As(err, &tmerr))
+	require.Equal(suite.T(), messages.StatusMaintenance, tmerr.Status)
+	require.Equal(suite.T(), string(messages.EventTypeCancelOrder), tmerr.Operation)
+	// Cancel only
+	gate.Update(messages.StatusCancelOnly)
+	require.Error(suite.T(), gate.Check(messages.EventTypeAddOrder, "limit", "post"))
+	require.Error(suite.T(), gate.Check(messages.EventTypeEditOrder, "", ""))
+	require.NoError(suite.T(), gate.Check(messages.EventTypeCancelOrder, "", ""))
+	require.NoError(suite.T(), gate.Check(messages.EventTypeCancelAllOrders, "", ""))
+	require.NoError(suite.T(), gate.Check(messages.EventTypeCancelAllOrdersAfterX, "", ""))
+	// Post only
+	gate.Update(messages.StatusPostOnly)
+	require.NoError(suite.T(), gate.Check(messages.EventTypeAddOrder, "limit", "fcib, post"))
+	require.Error(suite.T(), gate.Check(messages.EventTypeAddOrder, "limit", "fcib"))
+	require.Error(suite.T(), gate.Check(messages.EventTypeAddOrder, "market", "post"))
+	require.NoError(suite.T(), gate.Check(messages.EventTypeEditOrder, "", "post"))
+	require.NoError(suite.T(), gate.Check(messages.EventTypeCancelOrder, "", ""))
+	// Limit only
+	gate.Update(messages.StatusLimitOnly)
+	require.NoError(suite.T(), gate.Check(messages.EventTypeAddOrder, "limit", ""))
+	require.Error(suite.T(), gate.Check(messages.EventTypeAddOrder, "market", ""))
+	require.NoError(suite.T(), gate.Check(messages.EventTypeEditOrder, "", ""))
+}
+
+// Test ModeGate.Wait.
+//
+// Test will ensure:
+//   - Wait returns once the status allows the command.
+//   - Wait returns a TradingModeError which wraps the context error if the context is done before.
+func (suite *ModeGateUnitTestSuite) TestWait() {
+	gate := NewModeGate()
+	gate.Update(messages.StatusMaintenance)
+	result := make(chan error, 1)
+	go func() { result <- gate.Wait(context.Background(), messages.EventTypeAddOrder, "limit", "") }()
+	gate.Update(messages.StatusCancelOnly)
+	require.Never(suite.T(), func() bool { return len(result) > 0 }, 50*time.Millisecond, time.Millisecond)
+	gate.Update(messages.StatusOnline)
+	require.NoError(suite.T(), <-result)
+	// Context done
+	gate.Update(messages.StatusMaintenance)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := gate.Wait(ctx, messages.EventTypeCancelOrder, "", "")
+	require.ErrorIs(suite.T(), err, context.DeadlineExceeded)
+	tmerr := new(TradingModeError)
+	require.True(suite.T(), errors.As(err, &tmerr))
+}
+
+// Test the client mode gate integration.
+//
+// Test will ensure:
+//   - Received system statuses update the client mode gate.
+//   - Commands are sent whatever the status by default.
+//   - Commands are denied with a TradingModeError by the deny policy.
+//   - Commands are deferred until the status allows them by the defer policy.
+func (suite *ModeGateUnitTestSuite) TestClientModeGate() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	status := []byte(`{"connectionID":8628615390848610000,"event":"systemStatus","status":"cancel_only","version":"1.0.0"}`)
+	require.NoError(suite.T(), client.handleSystemStatus(context.Background(), nil, nil, nil, nil, "", 0, status))
+	require.Equal(suite.T(), messages.StatusCancelOnly, client.GetModeGate().Status())
+	require.Len(suite.T(), client.GetSystemStatusChannel(), 1)
+	span := trace.SpanFromContext(context.Background())
+	// Disabled by default
+	require.NoError(suite.T(), client.consultModeGate(context.Background(), span, messages.EventTypeAddOrder, "limit", ""))
+	// Deny
+	client.SetModeGatePolicy(ModeGateDeny)
+	_, err := client.AddOrder(context.Background(), AddOrderRequestParameters{OrderType: "limit", Pair: "XBT/USD"})
+	tmerr := new(TradingModeError)
+	require.True(suite.T(), errors.As(err, &tmerr))
+	require.NoError(suite.T(), client.consultModeGate(context.Background(), span, messages.EventTypeCancelOrder, "", ""))
+	// Defer
+	client.SetModeGatePolicy(ModeGateDefer)
+	result := make(chan error, 1)
+	go func() {
+		result <- client.consultModeGate(context.Background(), span, messages.EventTypeAddOrder, "limit", "")
+	}()
+	require.Never(suite.T(), func() bool { return len(result) > 0 }, 50*time.Millisecond, time.Millisecond)
+	status = []byte(`{"event":"systemStatus","status":"online","version":"1.0.0"}`)
+	require.NoError(suite.T(), client.handleSystemStatus(context.Background(), nil, nil, nil, nil, "", 0, status))
+	require.NoError(suite.T(), <-result)
+}
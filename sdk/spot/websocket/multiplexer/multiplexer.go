@@ -0,0 +1,443 @@
+// Package multiplexer provides a component which owns the subscriptions to the public channels of
+// the websocket API and which fans events out to any number of consumers.
+package multiplexer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// Capacity of the channels used to receive events from the websocket client.
+const sourceChannelCapacity = 100
+
+// Enum for congestion policies: how events are delivered to a consumer whose channel is full.
+type CongestionPolicyEnum string
+
+// Values for CongestionPolicyEnum
+const (
+	// Wait until the event is delivered. A slow consumer slows down all consumers of the channel
+	// and consumers must keep reading their channel until they are removed.
+	Block CongestionPolicyEnum = "block"
+	// Discard the event which cannot be delivered.
+	DropNewest CongestionPolicyEnum = "drop_newest"
+	// Discard the oldest event in the consumer channel to make room for the new event.
+	DropOldest CongestionPolicyEnum = "drop_oldest"
+)
+
+// Description of a subscription to a public channel.
+type Subscription struct {
+	// Channel: ticker, ohlc, trade, spread or book.
+	Channel messages.ChannelEnum
+	// Pairs to receive events for.
+	Pairs []string
+	// Interval, for ohlc channel only.
+	Interval messages.IntervalEnum
+	// Depth, for book channel only.
+	Depth messages.DepthEnum
+}
+
+// Get the key of the websocket subscription used for the subscription.
+func (s Subscription) topic() string {
+	switch s.Channel {
+	case messages.ChannelOHLC:
+		return fmt.Sprintf("%s-%d", s.Channel, s.Interval)
+	case messages.ChannelBook:
+		return fmt.Sprintf("%s-%d", s.Channel, s.Depth)
+	default:
+		return string(s.Channel)
+	}
+}
+
+// A consumer of a topic.
+type consumer struct {
+	// Consumer ID
+	id int64
+	// Pairs the consumer receives events for
+	pairs map[string]bool
+	// Consumer channel
+	rcv chan event.Event
+	// Congestion policy
+	policy CongestionPolicyEnum
+	// Number of discarded events
+	dropped atomic.Uint64
+	// Channel closed when the consumer is removed. Used to unblock blocking writes.
+	removed chan struct{}
+}
+
+// A websocket subscription shared by consumers.
+type topic struct {
+	// Subscription used to subscribe to the channel (union of consumers pairs)
+	subscription Subscription
+	// Mutex used to protect consumers while events are dispatched
+	mu sync.Mutex
+	// Consumers by ID
+	consumers map[int64]*consumer
+	// Channel closed to stop the fan-out goroutine of the active websocket subscription
+	stop chan struct{}
+}
+
+// # Description
+//
+// Multiplexer which owns a single websocket subscription per public channel (ticker, trade,
+// spread, book per depth, ohlc per interval) and which fans events out to any number of
+// dynamically added and removed consumers.
+//
+// Each consumer receives the events for the pairs it is interested in and all
+// connection_interrupted events. Each consumer has its own congestion policy.
+//
+// When a consumer requests a pair which is not part of the active websocket subscription, the
+// multiplexer unsubscribes and subscribes again with all requested pairs: consumers of the
+// channel can miss events during the resubscription and book consumers will receive new
+// snapshots. The websocket subscription is removed once the last consumer of a channel has been
+// removed.
+type Multiplexer struct {
+	// Websocket client used to subscribe to public channels
+	client websocket.KrakenSpotPublicWebsocketClientInterface
+	// Logger used to publish debug/verbose logs
+	logger *log.Logger
+	// Mutex used to protect topics and consumers
+	mu sync.Mutex
+	// Topics by key
+	topics map[string]*topic
+	// Topic key by consumer ID
+	consumers map[int64]string
+	// Last consumer ID
+	lastId int64
+}
+
+// # Description
+//
+// Build a new Multiplexer.
+//
+// # Inputs
+//
+//   - client: Websocket client used to subscribe to public channels. The client must not be used
+//     to subscribe to public channels by other components.
+//   - logger: Optional logger used to log debug/verbose messages. If nil, a logger with a discard
+//     writer (noop) will be used.
+//
+// # Return
+//
+// A new Multiplexer.
+func NewMultiplexer(client websocket.KrakenSpotPublicWebsocketClientInterface, logger *log.Logger) *Multiplexer {
+	if logger == nil {
+		logger = log.New(io.Discard, "", log.Flags())
+	}
+	return &Multiplexer{
+		client:    client,
+		logger:    logger,
+		topics:    map[string]*topic{},
+		consumers: map[int64]string{},
+	}
+}
+
+// # Description
+//
+// Add a consumer which will receive the events of the subscription on the provided channel.
+//
+// The websocket subscription is created if it does not exist yet, or recreated with the new
+// pairs if they are not part of the active subscription.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - sub: Subscription: channel, pairs and interval or depth.
+//   - rcv: Channel used to publish events. The channel is closed when the consumer is removed.
+//   - policy: Congestion policy used when the consumer channel is full.
+//
+// # Return
+//
+// The consumer ID which can be used to remove the consumer or an error if the subscription is
+// invalid or if the websocket subscription fails.
+func (m *Multiplexer) AddConsumer(ctx context.Context, sub Subscription, rcv chan event.Event, policy CongestionPolicyEnum) (int64, error) {
+	if err := validate(sub, rcv, policy); err != nil {
+		return 0, fmt.Errorf("add consumer failed: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := sub.topic()
+	t := m.topics[key]
+	c := &consumer{id: m.lastId + 1, pairs: map[string]bool{}, rcv: rcv, policy: policy, removed: make(chan struct{})}
+	for _, pair := range sub.Pairs {
+		c.pairs[pair] = true
+	}
+	if t == nil {
+		// Create the topic and subscribe
+		t = &topic{subscription: sub, consumers: map[int64]*consumer{}}
+		t.subscription.Pairs = append([]string{}, sub.Pairs...)
+		if err := m.subscribe(ctx, t); err != nil {
+			return 0, fmt.Errorf("add consumer failed: %w", err)
+		}
+		m.topics[key] = t
+	} else if missing := missingPairs(t.subscription.Pairs, sub.Pairs); len(missing) > 0 {
+		// Resubscribe with all pairs
+		m.logger.Println("resubscribing to", key, "to add pairs", missing)
+		if err := m.unsubscribe(ctx, t); err != nil {
+			return 0, fmt.Errorf("add consumer failed: %w", err)
+		}
+		t.subscription.Pairs = append(t.subscription.Pairs, missing...)
+		if err := m.subscribe(ctx, t); err != nil {
+			// Topic is now inactive: close consumers and discard it
+			m.closeTopic(key, t)
+			return 0, fmt.Errorf("add consumer failed: %w", err)
+		}
+	}
+	m.lastId++
+	t.mu.Lock()
+	t.consumers[c.id] = c
+	t.mu.Unlock()
+	m.consumers[c.id] = key
+	m.logger.Println("consumer added", c.id, key, sub.Pairs)
+	return c.id, nil
+}
+
+// # Description
+//
+// Remove a consumer and close its channel. The websocket subscription is removed if the consumer
+// was the last consumer of the channel.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - id: Consumer ID.
+//
+// # Return
+//
+// An error if the consumer does not exist or if the websocket subscription could not be
+// removed. The consumer is removed in any case.
+func (m *Multiplexer) RemoveConsumer(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, ok := m.consumers[id]
+	if !ok {
+		return fmt.Errorf("remove consumer failed: unknown consumer %d", id)
+	}
+	t := m.topics[key]
+	c := t.consumers[id]
+	delete(m.consumers, id)
+	// Unblock any pending write to the consumer before removing it
+	close(c.removed)
+	t.mu.Lock()
+	delete(t.consumers, id)
+	close(c.rcv)
+	t.mu.Unlock()
+	m.logger.Println("consumer removed", id, key)
+	if len(t.consumers) > 0 {
+		return nil
+	}
+	delete(m.topics, key)
+	if err := m.unsubscribe(ctx, t); err != nil {
+		return fmt.Errorf("remove consumer failed: %w", err)
+	}
+	return nil
+}
+
+// Get the number of events discarded for the consumer because of congestion. Zero is returned
+// for unknown consumers.
+func (m *Multiplexer) GetDroppedCount(id int64) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, ok := m.consumers[id]
+	if !ok {
+		return 0
+	}
+	return m.topics[key].consumers[id].dropped.Load()
+}
+
+// Get the number of consumers of each active websocket subscription (ex: trade, book-10, ohlc-5).
+func (m *Multiplexer) GetTopics() map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	topics := make(map[string]int, len(m.topics))
+	for key, t := range m.topics {
+		topics[key] = len(t.consumers)
+	}
+	return topics
+}
+
+// Subscribe to the topic channel and start the fan-out goroutine. Mutex must be held.
+func (m *Multiplexer) subscribe(ctx context.Context, t *topic) error {
+	source := make(chan event.Event, sourceChannelCapacity)
+	var err error
+	sub := t.subscription
+	switch sub.Channel {
+	case messages.ChannelTicker:
+		err = m.client.SubscribeTicker(ctx, sub.Pairs, source)
+	case messages.ChannelOHLC:
+		err = m.client.SubscribeOHLC(ctx, sub.Pairs, sub.Interval, source)
+	case messages.ChannelTrade:
+		err = m.client.SubscribeTrade(ctx, sub.Pairs, source)
+	case messages.ChannelSpread:
+		err = m.client.SubscribeSpread(ctx, sub.Pairs, source)
+	case messages.ChannelBook:
+		err = m.client.SubscribeBook(ctx, sub.Pairs, sub.Depth, source)
+	}
+	if err != nil {
+		return err
+	}
+	t.stop = make(chan struct{})
+	go m.fanOut(t, source, t.stop)
+	return nil
+}
+
+// Unsubscribe from the topic channel and stop the fan-out goroutine. Mutex must be held.
+//
+// The fan-out goroutine is not waited for: the source channel is not closed by clients which keep
+// their channels open upon unsubscribe and a consumer may call back into the multiplexer before
+// reading its channel. Pending blocking writes are abandoned and the goroutine exits.
+func (m *Multiplexer) unsubscribe(ctx context.Context, t *topic) error {
+	var err error
+	switch t.subscription.Channel {
+	case messages.ChannelTicker:
+		err = m.client.UnsubscribeTicker(ctx)
+	case messages.ChannelOHLC:
+		err = m.client.UnsubscribeOHLC(ctx, t.subscription.Interval)
+	case messages.ChannelTrade:
+		err = m.client.UnsubscribeTrade(ctx)
+	case messages.ChannelSpread:
+		err = m.client.UnsubscribeSpread(ctx)
+	case messages.ChannelBook:
+		err = m.client.UnsubscribeBookDepth(ctx, t.subscription.Depth)
+	}
+	if err != nil {
+		return err
+	}
+	close(t.stop)
+	return nil
+}
+
+// Close the channels of the consumers of the topic and discard it. Mutex must be held.
+func (m *Multiplexer) closeTopic(key string, t *topic) {
+	// Unblock any pending write to the consumers before closing their channel
+	for _, c := range t.consumers {
+		close(c.removed)
+	}
+	t.mu.Lock()
+	for id, c := range t.consumers {
+		close(c.rcv)
+		delete(t.consumers, id)
+		delete(m.consumers, id)
+	}
+	t.mu.Unlock()
+	delete(m.topics, key)
+}
+
+// Read events from the source channel and dispatch them to the consumers until the source channel
+// is closed or the stop channel is closed.
+func (m *Multiplexer) fanOut(t *topic, source chan event.Event, stop chan struct{}) {
+	for {
+		var e event.Event
+		select {
+		case <-stop:
+			return
+		case evt, ok := <-source:
+			if !ok {
+				return
+			}
+			e = evt
+		}
+		interrupted := e.Type() == string(events.ConnectionInterrupted)
+		t.mu.Lock()
+		select {
+		case <-stop:
+			// Unsubscribed while waiting for the lock: events of the new subscription, if any,
+			// must not be preceded by stale events.
+			t.mu.Unlock()
+			return
+		default:
+		}
+		for _, c := range t.consumers {
+			if interrupted || c.pairs[e.Subject()] {
+				c.publish(e, stop)
+			}
+		}
+		t.mu.Unlock()
+	}
+}
+
+// Publish the event on the consumer channel according to the consumer congestion policy. Blocking
+// writes are abandoned when the consumer is removed or when the stop channel is closed.
+func (c *consumer) publish(e event.Event, stop chan struct{}) {
+	switch c.policy {
+	case DropNewest:
+		select {
+		case c.rcv <- e:
+		default:
+			c.dropped.Add(1)
+		}
+	case DropOldest:
+		for {
+			select {
+			case c.rcv <- e:
+				return
+			default:
+				// Discard the oldest event
+				select {
+				case <-c.rcv:
+					c.dropped.Add(1)
+				default:
+				}
+			}
+		}
+	default:
+		select {
+		case c.rcv <- e:
+		case <-c.removed:
+		case <-stop:
+		}
+	}
+}
+
+// Validate the consumer subscription, channel and policy.
+func validate(sub Subscription, rcv chan event.Event, policy CongestionPolicyEnum) error {
+	switch sub.Channel {
+	case messages.ChannelTicker, messages.ChannelTrade, messages.ChannelSpread:
+	case messages.ChannelOHLC:
+		if sub.Interval <= 0 {
+			return fmt.Errorf("an interval must be provided for ohlc channel")
+		}
+	case messages.ChannelBook:
+		if sub.Depth <= 0 {
+			return fmt.Errorf("a depth must be provided for book channel")
+		}
+	default:
+		return fmt.Errorf("unsupported channel: %q", sub.Channel)
+	}
+	if len(sub.Pairs) == 0 {
+		return fmt.Errorf("at least one pair must be provided")
+	}
+	if rcv == nil {
+		return fmt.Errorf("a channel must be provided")
+	}
+	switch policy {
+	case Block, DropNewest, DropOldest:
+	default:
+		return fmt.Errorf("unsupported congestion policy: %q", policy)
+	}
+	return nil
+}
+
+// Get the pairs which are requested but not in the subscribed pairs.
+func missingPairs(subscribed []string, requested []string) []string {
+	known := make(map[string]bool, len(subscribed))
+	for _, pair := range subscribed {
+		known[pair] = true
+	}
+	missing := []string{}
+	for _, pair := range requested {
+		if !known[pair] {
+			known[pair] = true
+			missing = append(missing, pair)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,344 @@
+package multiplexer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for Multiplexer
+type MultiplexerUnitTestSuite struct {
+	suite.Suite
+	// Fake websocket client
+	client *fakePublicClient
+	// Multiplexer under test
+	mux *Multiplexer
+}
+
+// Run unit test suite
+func TestMultiplexerUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(MultiplexerUnitTestSuite))
+}
+
+// Build a new fake client and multiplexer before each test.
+func (suite *MultiplexerUnitTestSuite) SetupTest() {
+	suite.client = newFakePublicClient()
+	suite.mux = NewMultiplexer(suite.client, nil)
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test fan-out to multiple consumers.
+//
+// Test will ensure:
+//   - A single websocket subscription is used for all consumers of a channel.
+//   - Consumers only receive events for their pairs and all connection_interrupted events.
+//   - Adding a consumer with new pairs resubscribes with all pairs.
+//   - Removing the last consumer removes the websocket subscription.
+func (suite *MultiplexerUnitTestSuite) TestFanOut() {
+	ctx := context.Background()
+	xbt := make(chan event.Event, 10)
+	all := make(chan event.Event, 10)
+	xbtId, err := suite.mux.AddConsumer(ctx, Subscription{Channel: messages.ChannelTrade, Pairs: []string{"XBT/USD"}}, xbt, Block)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), []string{"XBT/USD"}, suite.client.pairs("trade"))
+	// Second consumer with a new pair -> resubscribe with union
+	allId, err := suite.mux.AddConsumer(ctx, Subscription{Channel: messages.ChannelTrade, Pairs: []string{"XBT/USD", "ETH/USD"}}, all, DropNewest)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), []string{"XBT/USD", "ETH/USD"}, suite.client.pairs("trade"))
+	require.Equal(suite.T(), 2, suite.client.subscribes)
+	require.Equal(suite.T(), map[string]int{"trade": 2}, suite.mux.GetTopics())
+	// Publish events
+	suite.client.publish("trade", newEvent(events.Trade, "XBT/USD"))
+	suite.client.publish("trade", newEvent(events.Trade, "ETH/USD"))
+	suite.client.publish("trade", newEvent(events.ConnectionInterrupted, ""))
+	require.Equal(suite.T(), "XBT/USD", readEvent(suite.T(), xbt).Subject())
+	require.Equal(suite.T(), string(events.ConnectionInterrupted), readEvent(suite.T(), xbt).Type())
+	require.Equal(suite.T(), "XBT/USD", readEvent(suite.T(), all).Subject())
+	require.Equal(suite.T(), "ETH/USD", readEvent(suite.T(), all).Subject())
+	require.Equal(suite.T(), string(events.ConnectionInterrupted), readEvent(suite.T(), all).Type())
+	// Third consumer with known pairs -> no resubscription
+	_, err = suite.mux.AddConsumer(ctx, Subscription{Channel: messages.ChannelTrade, Pairs: []string{"ETH/USD"}}, make(chan event.Event, 1), DropOldest)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 2, suite.client.subscribes)
+	// Remove consumers
+	require.NoError(suite.T(), suite.mux.RemoveConsumer(ctx, xbtId))
+	_, ok := <-xbt
+	require.False(suite.T(), ok)
+	require.Error(suite.T(), suite.mux.RemoveConsumer(ctx, xbtId))
+	require.NoError(suite.T(), suite.mux.RemoveConsumer(ctx, allId))
+	require.NoError(suite.T(), suite.mux.RemoveConsumer(ctx, 3))
+	require.Empty(suite.T(), suite.mux.GetTopics())
+	require.Nil(suite.T(), suite.client.pairs("trade"))
+}
+
+// Test congestion policies.
+//
+// Test will ensure:
+//   - drop_newest consumers keep the oldest events and count dropped events.
+//   - drop_oldest consumers keep the newest events and count dropped events.
+//   - A blocked consumer can be removed.
+func (suite *MultiplexerUnitTestSuite) TestCongestionPolicies() {
+	ctx := context.Background()
+	sub := Subscription{Channel: messages.ChannelBook, Pairs: []string{"XBT/USD"}, Depth: messages.D10}
+	newest := make(chan event.Event, 2)
+	oldest := make(chan event.Event, 2)
+	blocked := make(chan event.Event)
+	newestId, err := suite.mux.AddConsumer(ctx, sub, newest, DropNewest)
+	require.NoError(suite.T(), err)
+	oldestId, err := suite.mux.AddConsumer(ctx, sub, oldest, DropOldest)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), map[string]int{"book-10": 2}, suite.mux.GetTopics())
+	for i := 0; i < 5; i++ {
+		e := newEvent(events.BookUpdate, "XBT/USD")
+		e.SetID(fmt.Sprint(i))
+		suite.client.publish("book", e)
+	}
+	require.Eventually(suite.T(), func() bool { return suite.mux.GetDroppedCount(oldestId) == 3 }, time.Second, time.Millisecond)
+	require.Equal(suite.T(), uint64(3), suite.mux.GetDroppedCount(newestId))
+	require.Equal(suite.T(), "0", (<-newest).ID())
+	require.Equal(suite.T(), "1", (<-newest).ID())
+	require.Equal(suite.T(), "3", (<-oldest).ID())
+	require.Equal(suite.T(), "4", (<-oldest).ID())
+	// Blocked consumer can be removed while an event is being delivered
+	blockedId, err := suite.mux.AddConsumer(ctx, sub, blocked, Block)
+	require.NoError(suite.T(), err)
+	suite.client.publish("book", newEvent(events.BookUpdate, "XBT/USD"))
+	require.NoError(suite.T(), suite.mux.RemoveConsumer(ctx, blockedId))
+	require.Zero(suite.T(), suite.mux.GetDroppedCount(blockedId))
+}
+
+// Test invalid subscriptions and subscription failures.
+func (suite *MultiplexerUnitTestSuite) TestAddConsumerErrors() {
+	ctx := context.Background()
+	rcv := make(chan event.Event)
+	invalids := []Subscription{
+		{Channel: messages.ChannelOwnTrades, Pairs: []string{"XBT/USD"}},
+		{Channel: messages.ChannelOHLC, Pairs: []string{"XBT/USD"}},
+		{Channel: messages.ChannelBook, Pairs: []string{"XBT/USD"}},
+		{Channel: messages.ChannelTicker},
+	}
+	for _, sub := range invalids {
+		_, err := suite.mux.AddConsumer(ctx, sub, rcv, Block)
+		require.Error(suite.T(), err)
+	}
+	_, err := suite.mux.AddConsumer(ctx, Subscription{Channel: messages.ChannelTicker, Pairs: []string{"XBT/USD"}}, nil, Block)
+	require.Error(suite.T(), err)
+	_, err = suite.mux.AddConsumer(ctx, Subscription{Channel: messages.ChannelTicker, Pairs: []string{"XBT/USD"}}, rcv, "whatever")
+	require.Error(suite.T(), err)
+	// Subscription failure
+	suite.client.err = fmt.Errorf("boom")
+	_, err = suite.mux.AddConsumer(ctx, Subscription{Channel: messages.ChannelOHLC, Pairs: []string{"XBT/USD"}, Interval: messages.M5}, rcv, Block)
+	require.Error(suite.T(), err)
+	require.Empty(suite.T(), suite.mux.GetTopics())
+	// Resubscription failure closes existing consumers
+	suite.client.err = nil
+	spread := make(chan event.Event, 1)
+	_, err = suite.mux.AddConsumer(ctx, Subscription{Channel: messages.ChannelSpread, Pairs: []string{"XBT/USD"}}, spread, Block)
+	require.NoError(suite.T(), err)
+	suite.client.failSubscribe = true
+	_, err = suite.mux.AddConsumer(ctx, Subscription{Channel: messages.ChannelSpread, Pairs: []string{"ETH/USD"}}, rcv, Block)
+	require.Error(suite.T(), err)
+	_, ok := <-spread
+	require.False(suite.T(), ok)
+	require.Empty(suite.T(), suite.mux.GetTopics())
+}
+
+// Test consumers can be added and removed while the fan-out goroutine is blocked.
+//
+// Test will ensure:
+//   - A resubscription does not wait for a blocked consumer to read its channel.
+//   - The multiplexer can be used by the blocked consumer during the resubscription.
+//   - Consumers can be removed when the client keeps channels open upon unsubscribe.
+func (suite *MultiplexerUnitTestSuite) TestNoDeadlock() {
+	ctx := context.Background()
+	blocked := make(chan event.Event)
+	blockedId, err := suite.mux.AddConsumer(ctx, Subscription{Channel: messages.ChannelTrade, Pairs: []string{"XBT/USD"}}, blocked, Block)
+	require.NoError(suite.T(), err)
+	suite.client.publish("trade", newEvent(events.Trade, "XBT/USD"))
+	// Resubscribe while the fan-out goroutine is blocked on the consumer
+	result := make(chan error, 1)
+	go func() {
+		_, err := suite.mux.AddConsumer(ctx, Subscription{Channel: messages.ChannelTrade, Pairs: []string{"ETH/USD"}}, make(chan event.Event, 10), Block)
+		result <- err
+	}()
+	select {
+	case err := <-result:
+		require.NoError(suite.T(), err)
+	case <-time.After(time.Second):
+		require.FailNow(suite.T(), "resubscription is blocked by the consumer")
+	}
+	require.Equal(suite.T(), map[string]int{"trade": 2}, suite.mux.GetTopics())
+	require.NoError(suite.T(), suite.mux.RemoveConsumer(ctx, blockedId))
+	// Client which keeps channels open upon unsubscribe
+	suite.client.keepOpen = true
+	spread := make(chan event.Event, 10)
+	spreadId, err := suite.mux.AddConsumer(ctx, Subscription{Channel: messages.ChannelSpread, Pairs: []string{"XBT/USD"}}, spread, Block)
+	require.NoError(suite.T(), err)
+	go func() { result <- suite.mux.RemoveConsumer(ctx, spreadId) }()
+	select {
+	case err := <-result:
+		require.NoError(suite.T(), err)
+	case <-time.After(time.Second):
+		require.FailNow(suite.T(), "consumer removal is blocked")
+	}
+	_, ok := <-spread
+	require.False(suite.T(), ok)
+	require.Equal(suite.T(), map[string]int{"trade": 1}, suite.mux.GetTopics())
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build an event of the provided type for the provided pair.
+func newEvent(etype events.WebsocketClientEventTypeEnum, pair string) event.Event {
+	e := event.New()
+	e.SetType(string(etype))
+	e.SetSubject(pair)
+	return e
+}
+
+// Read the next event from the channel or fail after a timeout.
+func readEvent(t *testing.T, rcv chan event.Event) event.Event {
+	select {
+	case e := <-rcv:
+		return e
+	case <-time.After(time.Second):
+		require.FailNow(t, "timeout while waiting for event")
+		return event.Event{}
+	}
+}
+
+// Fake public websocket client which records subscriptions by channel.
+type fakePublicClient struct {
+	mu sync.Mutex
+	// Subscribed pairs by channel
+	subscribed map[string][]string
+	// Publication channels by channel
+	channels map[string]chan event.Event
+	// Number of subscribe calls
+	subscribes int
+	// Error returned by all calls if not nil
+	err error
+	// If true, subscribe calls fail
+	failSubscribe bool
+	// If true, channels are not closed upon unsubscribe
+	keepOpen bool
+}
+
+// Build a new fake public websocket client.
+func newFakePublicClient() *fakePublicClient {
+	return &fakePublicClient{subscribed: map[string][]string{}, channels: map[string]chan event.Event{}}
+}
+
+// Get the subscribed pairs for the channel.
+func (c *fakePublicClient) pairs(channel string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.subscribed[channel]
+}
+
+// Publish an event on the channel subscription.
+func (c *fakePublicClient) publish(channel string, e event.Event) {
+	c.mu.Lock()
+	rcv := c.channels[channel]
+	c.mu.Unlock()
+	rcv <- e
+}
+
+// Record a subscription.
+func (c *fakePublicClient) subscribe(channel string, pairs []string, rcv chan event.Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	if c.failSubscribe {
+		return fmt.Errorf("subscribe failed")
+	}
+	c.subscribes++
+	c.subscribed[channel] = pairs
+	c.channels[channel] = rcv
+	return nil
+}
+
+// Remove a subscription and close its channel unless channels are kept open.
+func (c *fakePublicClient) unsubscribe(channel string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	if !c.keepOpen {
+		close(c.channels[channel])
+	}
+	delete(c.channels, channel)
+	delete(c.subscribed, channel)
+	return nil
+}
+
+func (c *fakePublicClient) Ping(ctx context.Context) error { return c.err }
+
+func (c *fakePublicClient) SubscribeTicker(ctx context.Context, pairs []string, rcv chan event.Event) error {
+	return c.subscribe("ticker", pairs, rcv)
+}
+
+func (c *fakePublicClient) SubscribeOHLC(ctx context.Context, pairs []string, interval messages.IntervalEnum, rcv chan event.Event) error {
+	return c.subscribe(fmt.Sprintf("ohlc-%d", interval), pairs, rcv)
+}
+
+func (c *fakePublicClient) SubscribeTrade(ctx context.Context, pairs []string, rcv chan event.Event) error {
+	return c.subscribe("trade", pairs, rcv)
+}
+
+func (c *fakePublicClient) SubscribeSpread(ctx context.Context, pairs []string, rcv chan event.Event) error {
+	return c.subscribe("spread", pairs, rcv)
+}
+
+func (c *fakePublicClient) SubscribeBook(ctx context.Context, pairs []string, depth messages.DepthEnum, rcv chan event.Event) error {
+	return c.subscribe("book", pairs, rcv)
+}
+
+func (c *fakePublicClient) UnsubscribeTicker(ctx context.Context) error {
+	return c.unsubscribe("ticker")
+}
+
+func (c *fakePublicClient) UnsubscribeOHLC(ctx context.Context, interval messages.IntervalEnum) error {
+	return c.unsubscribe(fmt.Sprintf("ohlc-%d", interval))
+}
+
+func (c *fakePublicClient) UnsubscribeTrade(ctx context.Context) error {
+	return c.unsubscribe("trade")
+}
+
+func (c *fakePublicClient) UnsubscribeSpread(ctx context.Context) error {
+	return c.unsubscribe("spread")
+}
+
+func (c *fakePublicClient) UnsubscribeBook(ctx context.Context) error {
+	return c.unsubscribe("book")
+}
+
+func (c *fakePublicClient) UnsubscribeBookDepth(ctx context.Context, depth messages.DepthEnum) error {
+	return c.unsubscribe("book")
+}
+
+func (c *fakePublicClient) GetSystemStatusChannel() chan event.Event { return nil }
+
+func (c *fakePublicClient) GetHeartbeatChannel() chan event.Event    { return nil }
+func (c *fakePublicClient) GetGeneralErrorChannel() chan event.Event { return nil }
@@ -0,0 +1,154 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// Separator between the pair and the interval in the subject of the ohlc events published by
+// SubscribeOHLCMulti (ex: XBT/USD@5).
+const OHLCMultiSubjectSeparator = "@"
+
+// Capacity of the channels used to receive ohlc messages from the underlying ohlc subscriptions.
+const ohlcMultiChannelCapacity = 100
+
+// # Description
+//
+// Subscribe to the ohlc channel for several intervals and publish the candles of all intervals
+// on a single channel. This simplifies multi-timeframe strategies.
+//
+// The subscriptions are made as a group: if a subscription fails, the intervals subscribed so far
+// are unsubscribed and an error is returned.
+//
+// The subject of the published ohlc events is annotated with the interval of the candle
+// (ex: XBT/USD@5, Cf. OHLCMultiSubject and ParseOHLCMultiSubject). connection_interrupted events
+// are forwarded once for each interval.
+//
+// Use UnsubscribeOHLCMulti (or UnsubscribeOHLC for each interval) to unsubscribe: the provided
+// channel will be closed once all the intervals of the group are unsubscribed unless the client
+// is configured to keep channels open on unsubscribe (Cf. SetKeepChannelsOpenOnUnsubscribe).
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - pairs: Pairs to subscribe to.
+//   - intervals: Intervals to subscribe to. Must not be empty nor contain duplicates.
+//   - rcv: Channel used to publish ohlc and connection_interrupted events. Blocking writes are used.
+//
+// # Return
+//
+// An error if intervals are invalid or if a subscription fails. The error also reports the
+// intervals which could not be unsubscribed during the rollback, if any.
+func (client *KrakenSpotPublicWebsocketClient) SubscribeOHLCMulti(ctx context.Context, pairs []string, intervals []messages.IntervalEnum, rcv chan event.Event) error {
+	if len(intervals) == 0 {
+		return fmt.Errorf("subscribe ohlc multi failed: at least one interval must be provided")
+	}
+	seen := map[messages.IntervalEnum]bool{}
+	for _, interval := range intervals {
+		if seen[interval] {
+			return fmt.Errorf("subscribe ohlc multi failed: duplicated interval %d", int(interval))
+		}
+		seen[interval] = true
+	}
+	ins := make(map[messages.IntervalEnum]chan event.Event, len(intervals))
+	for _, interval := range intervals {
+		in := make(chan event.Event, ohlcMultiChannelCapacity)
+		err := client.subscribeOHLC(ctx, pairs, interval, in, true)
+		if err != nil {
+			// Roll back the subscriptions of the group
+			errs := []error{fmt.Errorf("subscribe ohlc multi failed: %w", err)}
+			for subscribed := range ins {
+				if uerr := client.UnsubscribeOHLC(ctx, subscribed); uerr != nil {
+					errs = append(errs, fmt.Errorf("rollback of ohlc-%d subscription failed: %w", int(subscribed), uerr))
+				}
+			}
+			return errors.Join(errs...)
+		}
+		ins[interval] = in
+	}
+	go fanInOHLC(ins, rcv, client.keepChannelsOpenOnUnsubscribe.Load)
+	return nil
+}
+
+// # Description
+//
+// Unsubscribe from the ohlc channel for several intervals, like the intervals subscribed with
+// SubscribeOHLCMulti. All intervals are unsubscribed even if an unsubscribe fails.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - intervals: Intervals to unsubscribe from.
+//
+// # Return
+//
+// The errors of the intervals which could not be unsubscribed, joined together.
+func (client *KrakenSpotPublicWebsocketClient) UnsubscribeOHLCMulti(ctx context.Context, intervals []messages.IntervalEnum) error {
+	errs := []error{}
+	for _, interval := range intervals {
+		if err := client.UnsubscribeOHLC(ctx, interval); err != nil {
+			errs = append(errs, fmt.Errorf("unsubscribe ohlc-%d failed: %w", int(interval), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Build the subject of an ohlc event published by SubscribeOHLCMulti.
+func OHLCMultiSubject(pair string, interval messages.IntervalEnum) string {
+	return pair + OHLCMultiSubjectSeparator + strconv.Itoa(int(interval))
+}
+
+// # Description
+//
+// Extract the pair and the interval from the subject of an ohlc event published by
+// SubscribeOHLCMulti.
+//
+// # Inputs
+//
+//   - subject: Subject of the event (ex: XBT/USD@5).
+//
+// # Return
+//
+// The pair and the interval or an error if the subject is not annotated with an interval.
+func ParseOHLCMultiSubject(subject string) (string, messages.IntervalEnum, error) {
+	idx := strings.LastIndex(subject, OHLCMultiSubjectSeparator)
+	if idx < 0 {
+		return "", 0, fmt.Errorf("subject %s is not annotated with an interval", subject)
+	}
+	interval, err := strconv.Atoi(subject[idx+len(OHLCMultiSubjectSeparator):])
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to parse interval from subject %s: %w", subject, err)
+	}
+	return subject[:idx], messages.IntervalEnum(interval), nil
+}
+
+// Forward the events from the input channels of each interval to the output channel until all
+// input channels are closed. The subject of ohlc events is annotated with the interval. The output
+// channel is closed once all input channels are closed unless keepOutOpen returns true.
+func fanInOHLC(ins map[messages.IntervalEnum]chan event.Event, out chan event.Event, keepOutOpen func() bool) {
+	wg := sync.WaitGroup{}
+	for interval, in := range ins {
+		wg.Add(1)
+		go func(interval messages.IntervalEnum, in chan event.Event) {
+			defer wg.Done()
+			for e := range in {
+				if e.Type() == string(events.OHLC) && e.Subject() != "" {
+					e.SetSubject(OHLCMultiSubject(e.Subject(), interval))
+				}
+				out <- e
+			}
+		}(interval, in)
+	}
+	wg.Wait()
+	if keepOutOpen == nil || !keepOutOpen() {
+		close(out)
+	}
+}
@@ -0,0 +1,149 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for SubscribeOHLCMulti
+type OHLCMultiUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestOHLCMultiUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(OHLCMultiUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test a multi-interval subscription.
+//
+// Test will ensure:
+//   - All intervals are subscribed and candles of all intervals are published on the same channel.
+//   - The subject of ohlc events is annotated with the interval.
+//   - The subscriptions are not persisted.
+//   - The channel is closed once all intervals are unsubscribed.
+func (suite *OHLCMultiUnitTestSuite) TestSubscribeOHLCMulti() {
+	client := suite.newClient(nil)
+	rcv := make(chan event.Event, 10)
+	intervals := []messages.IntervalEnum{messages.M1, messages.M5}
+	require.NoError(suite.T(), client.SubscribeOHLCMulti(context.Background(), []string{"XBT/USD"}, intervals, rcv))
+	require.Empty(suite.T(), client.GetSubscriptionStates())
+	// Publish candles
+	for _, interval := range intervals {
+		require.NoError(suite.T(), client.handleOHLC(context.Background(), nil, nil, nil, nil, "s1", 0, "XBT/USD", []byte(`[]`), interval))
+	}
+	subjects := []string{(<-rcv).Subject(), (<-rcv).Subject()}
+	require.ElementsMatch(suite.T(), []string{"XBT/USD@1", "XBT/USD@5"}, subjects)
+	// Unsubscribe
+	require.NoError(suite.T(), client.UnsubscribeOHLC(context.Background(), messages.M1))
+	require.Never(suite.T(), func() bool {
+		select {
+		case _, ok := <-rcv:
+			return !ok
+		default:
+			return false
+		}
+	}, 20*time.Millisecond, time.Millisecond)
+	require.NoError(suite.T(), client.UnsubscribeOHLCMulti(context.Background(), []messages.IntervalEnum{messages.M5}))
+	_, ok := <-rcv
+	require.False(suite.T(), ok)
+	require.Error(suite.T(), client.UnsubscribeOHLCMulti(context.Background(), intervals))
+}
+
+// Test the subscriptions are rolled back when a subscription fails.
+//
+// Test will ensure:
+//   - Invalid intervals are rejected.
+//   - The intervals subscribed before the failure are unsubscribed.
+//   - The provided channel is not used.
+func (suite *OHLCMultiUnitTestSuite) TestSubscribeOHLCMultiRollback() {
+	client := suite.newClient(map[int]bool{int(messages.M15): true})
+	rcv := make(chan event.Event, 10)
+	require.Error(suite.T(), client.SubscribeOHLCMulti(context.Background(), []string{"XBT/USD"}, nil, rcv))
+	require.Error(suite.T(), client.SubscribeOHLCMulti(context.Background(), []string{"XBT/USD"}, []messages.IntervalEnum{messages.M1, messages.M1}, rcv))
+	err := client.SubscribeOHLCMulti(context.Background(), []string{"XBT/USD"}, []messages.IntervalEnum{messages.M1, messages.M5, messages.M15}, rcv)
+	require.Error(suite.T(), err)
+	client.ohlcSubMu.Lock()
+	require.Empty(suite.T(), client.subscriptions.ohlcs)
+	client.ohlcSubMu.Unlock()
+	require.Empty(suite.T(), rcv)
+}
+
+// Test the subject helpers and the fan in of events.
+//
+// Test will ensure:
+//   - Subjects can be built and parsed.
+//   - Non ohlc events are forwarded as is.
+//   - The output channel is left open if required.
+func (suite *OHLCMultiUnitTestSuite) TestFanIn() {
+	pair, interval, err := ParseOHLCMultiSubject(OHLCMultiSubject("XBT/USD", messages.M1440))
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "XBT/USD", pair)
+	require.Equal(suite.T(), messages.M1440, interval)
+	_, _, err = ParseOHLCMultiSubject("XBT/USD")
+	require.Error(suite.T(), err)
+	_, _, err = ParseOHLCMultiSubject("XBT/USD@abc")
+	require.Error(suite.T(), err)
+	// Fan in
+	in := make(chan event.Event, 1)
+	out := make(chan event.Event, 1)
+	done := make(chan struct{})
+	go func() {
+		fanInOHLC(map[messages.IntervalEnum]chan event.Event{messages.M1: in}, out, func() bool { return true })
+		close(done)
+	}()
+	interrupted := event.New()
+	interrupted.SetType(string(events.ConnectionInterrupted))
+	in <- interrupted
+	require.Equal(suite.T(), "", (<-out).Subject())
+	close(in)
+	<-done
+	out <- event.New()
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build a client with a mocked connection which answers subscribe and unsubscribe requests. OHLC
+// subscriptions with the provided intervals fail.
+func (suite *OHLCMultiUnitTestSuite) newClient(failing map[int]bool) *KrakenSpotPublicWebsocketClient {
+	client := &KrakenSpotPublicWebsocketClient{newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)}
+	conn := newConnectionMock()
+	conn.On("Write", mock.Anything, wsadapters.Text, mock.Anything).Run(func(args mock.Arguments) {
+		req := new(messages.Subscribe)
+		require.NoError(suite.T(), json.Unmarshal(args.Get(2).([]byte), req))
+		status := "subscribed"
+		errMsg := ""
+		if failing[req.Subscription.Interval] {
+			status = "error"
+			errMsg = "Subscription ohlc interval not supported"
+		}
+		for _, pair := range req.Pairs {
+			resp := fmt.Sprintf(`{"channelName":"ohlc-%d","event":"subscriptionStatus","pair":"%s","reqid":%d,"status":"%s","errorMessage":"%s","subscription":{"interval":%d,"name":"ohlc"}}`,
+				req.Subscription.Interval, pair, req.ReqId, status, errMsg, req.Subscription.Interval)
+			go client.handleSubscriptionStatus(context.Background(), nil, nil, nil, nil, "s1", 0, []byte(resp))
+		}
+	}).Return(nil)
+	client.conn = conn
+	return client
+}
@@ -0,0 +1,90 @@
+//go:build !goctopus_publiconly
+
+package websocket
+
+import (
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+)
+
+// Helpers which build AddOrder and EditOrder request parameters from the typed helpers of the
+// REST trading package. They are not included in public-only builds (goctopus_publiconly build
+// tag).
+
+// Validate and set the provided conditional close as the order close order (close[ordertype],
+// close[price] and close[price2]).
+//
+// Close order fields are left unchanged if the provided conditional close is invalid. A nil value
+// removes the close order.
+func (params *AddOrderRequestParameters) SetConditionalClose(cc *trading.ConditionalClose) error {
+	if cc == nil {
+		params.CloseOrderType, params.ClosePrice, params.ClosePrice2 = "", "", ""
+		return nil
+	}
+	close, err := cc.Build()
+	if err != nil {
+		return err
+	}
+	params.CloseOrderType = close.OrderType
+	params.ClosePrice = close.Price
+	params.ClosePrice2 = close.Price2
+	return nil
+}
+
+// Validate and apply the provided order parameters (Cf. trading.TrailingStopParameters,
+// trading.TrailingStopLimitParameters and trading.IcebergParameters). The order type, side,
+// volume, display volume, prices and trigger are replaced: other fields are left unchanged.
+//
+// The parameters are left unchanged if the provided order parameters are invalid.
+func (params *AddOrderRequestParameters) SetOrderParameters(p trading.OrderParameters) error {
+	order, err := p.Build()
+	if err != nil {
+		return err
+	}
+	params.OrderType = order.OrderType
+	params.Type = order.Type
+	params.Volume = order.Volume
+	params.DisplayVolume = order.DisplayedVolume
+	params.Price = order.Price
+	params.Price2 = order.Price2
+	params.Trigger = order.Trigger
+	return nil
+}
+
+// Set the provided relative price as the order price.
+func (params *AddOrderRequestParameters) SetRelativePrice(price trading.RelativePrice) {
+	params.Price = price.String()
+}
+
+// Set the provided relative price as the order secondary price.
+func (params *AddOrderRequestParameters) SetRelativePrice2(price2 trading.RelativePrice) {
+	params.Price2 = price2.String()
+}
+
+// Set the provided time as the order deadline, formatted as a RFC3339 timestamp in UTC (Cf.
+// trading.FormatDeadline and trading.DeadlineIn). A zero value removes the deadline.
+//
+// The deadline is validated against the window allowed by Kraken when the order is sent.
+func (params *AddOrderRequestParameters) SetDeadline(deadline time.Time) {
+	params.Deadline = trading.FormatDeadline(deadline)
+}
+
+// Set the provided relative price as the new order price.
+func (params *EditOrderRequestParameters) SetRelativePrice(price trading.RelativePrice) {
+	params.Price = price.String()
+}
+
+// Set the provided relative price as the new order secondary price.
+func (params *EditOrderRequestParameters) SetRelativePrice2(price2 trading.RelativePrice) {
+	params.Price2 = price2.String()
+}
+
+// Validate the self trade prevention flag and the deadline of an AddOrder request. The deadline
+// is validated against the provided time.
+func validateAddOrderRequestParameters(params AddOrderRequestParameters, now time.Time) error {
+	if err := trading.ValidateSelfTradePreventionFlag(string(params.StpType)); err != nil {
+		return err
+	}
+	return trading.ValidateFormattedDeadline(params.Deadline, now)
+}
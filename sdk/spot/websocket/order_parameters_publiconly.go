@@ -0,0 +1,12 @@
+//go:build goctopus_publiconly
+
+package websocket
+
+import "time"
+
+// Parameters are not validated in public-only builds (goctopus_publiconly build tag): the
+// validation relies on the REST trading package and orders cannot be placed anyway as the client
+// cannot get a websocket token.
+func validateAddOrderRequestParameters(params AddOrderRequestParameters, now time.Time) error {
+	return nil
+}
@@ -0,0 +1,140 @@
+package websocket
+
+import (
+	"sync"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// Watcher which maintains the state of orders from the updates received from the openOrders
+// channel.
+type orderWatcher struct {
+	// Mutex used to protect orders
+	mu sync.Mutex
+	// Merged state of the orders by order ID
+	orders map[string]messages.OrderInfo
+	// Channel used to signal an update has been received
+	notify chan struct{}
+}
+
+// Build a new order watcher.
+func newOrderWatcher() *orderWatcher {
+	return &orderWatcher{
+		orders: map[string]messages.OrderInfo{},
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// Merge the updates with the known state of the orders and signal the update.
+func (w *orderWatcher) update(orders []map[string]messages.OrderInfo) {
+	w.mu.Lock()
+	for _, entries := range orders {
+		for id, info := range entries {
+			current := w.orders[id]
+			mergeOrderInfo(&current, info)
+			w.orders[id] = current
+		}
+	}
+	w.mu.Unlock()
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Get the known state of an order. False is returned if no update has been received for the order.
+func (w *orderWatcher) get(id string) (messages.OrderInfo, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	info, ok := w.orders[id]
+	return info, ok
+}
+
+// Merge an order update into the known state of an order: openOrders updates only contain the
+// fields which have changed.
+func mergeOrderInfo(dst *messages.OrderInfo, src messages.OrderInfo) {
+	set := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
+		}
+	}
+	set(&dst.ReferralOrderTransactionId, src.ReferralOrderTransactionId)
+	set(&dst.Status, src.Status)
+	set(&dst.OpenTimestamp, src.OpenTimestamp)
+	set(&dst.StartTimestamp, src.StartTimestamp)
+	set(&dst.DisplayVolume, src.DisplayVolume)
+	set(&dst.DisplayVolumeRemain, src.DisplayVolumeRemain)
+	set(&dst.ExpireTimestamp, src.ExpireTimestamp)
+	set(&dst.LastUpdated, src.LastUpdated)
+	set(&dst.Volume, src.Volume)
+	set(&dst.VolumeExecuted, src.VolumeExecuted)
+	set(&dst.Cost, src.Cost)
+	set(&dst.Fee, src.Fee)
+	set(&dst.AvgPrice, src.AvgPrice)
+	set(&dst.StopPrice, src.StopPrice)
+	set(&dst.LimitPrice, src.LimitPrice)
+	set(&dst.Miscellaneous, src.Miscellaneous)
+	set(&dst.OrderFlags, src.OrderFlags)
+	set(&dst.TimeInForce, src.TimeInForce)
+	set(&dst.StpType, src.StpType)
+	set(&dst.CancelReason, src.CancelReason)
+	if src.UserReferenceId != nil {
+		dst.UserReferenceId = src.UserReferenceId
+	}
+	if src.Contingent != nil {
+		dst.Contingent = src.Contingent
+	}
+	if src.Description != nil {
+		dst.Description = src.Description
+	}
+	if src.RateCount != 0 {
+		dst.RateCount = src.RateCount
+	}
+}
+
+// Tell whether an order with the provided status has reached the expected status. Terminal
+// statuses (closed, canceled, expired) always end the wait as the order cannot change anymore.
+func orderStatusReached(status messages.OrderStatusEnum, until messages.OrderStatusEnum) bool {
+	switch status {
+	case until, messages.Closed, messages.Canceled, messages.Expired:
+		return true
+	case messages.Open:
+		return until == messages.Pending
+	default:
+		return false
+	}
+}
+
+// Register an order watcher.
+func (client *krakenSpotWebsocketClient) addOrderWatcher(w *orderWatcher) {
+	client.orderWatchersMu.Lock()
+	defer client.orderWatchersMu.Unlock()
+	if client.orderWatchers == nil {
+		client.orderWatchers = map[*orderWatcher]struct{}{}
+	}
+	client.orderWatchers[w] = struct{}{}
+}
+
+// Unregister an order watcher.
+func (client *krakenSpotWebsocketClient) removeOrderWatcher(w *orderWatcher) {
+	client.orderWatchersMu.Lock()
+	defer client.orderWatchersMu.Unlock()
+	delete(client.orderWatchers, w)
+}
+
+// Forward an openOrders message to the registered order watchers, if any.
+func (client *krakenSpotWebsocketClient) notifyOrderWatchers(msg []byte) {
+	client.orderWatchersMu.Lock()
+	defer client.orderWatchersMu.Unlock()
+	if len(client.orderWatchers) == 0 {
+		return
+	}
+	oo := new(messages.OpenOrders)
+	if err := client.codec.Unmarshal(msg, oo); err != nil {
+		client.logger.Println("failed to parse open orders message for order watchers:", err.Error())
+		return
+	}
+	for w := range client.orderWatchers {
+		w.update(oo.Orders)
+	}
+}
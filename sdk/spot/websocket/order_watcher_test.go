@@ -0,0 +1,149 @@
+//go:build !goctopus_publiconly
+
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for AddOrderAndWait and order watchers
+type OrderWatcherUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestOrderWatcherUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(OrderWatcherUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test AddOrderAndWait waits for the expected order status.
+//
+// Test will ensure:
+//   - openOrders updates received before the addOrderStatus response are not lost.
+//   - Partial updates are merged into the returned order state.
+//   - The wait ends when the expected status is reached.
+//   - openOrders messages are still published to the subscriber.
+//   - The self trade prevention flag is sent and surfaced in the returned order state.
+func (suite *OrderWatcherUnitTestSuite) TestAddOrderAndWait() {
+	client, conn, pub := newOrderWatcherTestClient()
+	conn.On("Write", mock.Anything, wsadapters.Text, mock.Anything).Run(func(args mock.Arguments) {
+		req := new(messages.AddOrderRequest)
+		if err := json.Unmarshal(args.Get(2).([]byte), req); err != nil {
+			panic(err)
+		}
+		if req.StpType != string(trading.STPCancelOldest) {
+			panic("unexpected stptype: " + req.StpType)
+		}
+		go func() {
+			// Pending update is received before the response
+			client.handleOpenOrders(context.Background(), nil, nil, nil, nil, "", 0, []byte(`[[{"OXYZ":{"status":"pending","vol":"1.0","descr":{"pair":"XBT/USD","type":"buy"}}}],"openOrders",{"sequence":1}]`))
+			client.handleAddOrderStatus(context.Background(), nil, nil, nil, nil, "", 0, []byte(fmt.Sprintf(`{"event":"addOrderStatus","reqid":%d,"status":"ok","txid":"OXYZ"}`, req.RequestId)))
+			client.handleOpenOrders(context.Background(), nil, nil, nil, nil, "", 0, []byte(`[[{"OXYZ":{"status":"open"}}],"openOrders",{"sequence":2}]`))
+			client.handleOpenOrders(context.Background(), nil, nil, nil, nil, "", 0, []byte(`[[{"OXYZ":{"status":"closed","vol_exec":"1.0","avg_price":"30000.0"}}],"openOrders",{"sequence":3}]`))
+		}()
+	}).Return(nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	txid, info, err := client.AddOrderAndWait(ctx, AddOrderRequestParameters{OrderType: "market", Type: "buy", Pair: "XBT/USD", Volume: "1.0", StpType: trading.STPCancelOldest}, messages.Closed)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "OXYZ", txid)
+	require.NotNil(suite.T(), info)
+	require.Equal(suite.T(), string(messages.Closed), info.Status)
+	require.Equal(suite.T(), "1.0", info.Volume)
+	require.Equal(suite.T(), "1.0", info.VolumeExecuted)
+	require.Equal(suite.T(), "30000.0", info.AvgPrice)
+	require.Equal(suite.T(), "XBT/USD", info.Description.Pair)
+	require.Equal(suite.T(), string(trading.STPCancelOldest), info.StpType)
+	require.Len(suite.T(), pub, 3)
+	require.Empty(suite.T(), client.orderWatchers)
+}
+
+// Test AddOrderAndWait failures.
+//
+// Test will ensure:
+//   - An active openOrders subscription is required.
+//   - Validate-only orders are rejected.
+//   - Orders with an invalid self trade prevention flag are rejected.
+//   - The wait ends with an OperationInterruptedError and the last known state when the context
+//     is done.
+//   - A terminal status ends the wait even if it is not the expected status.
+func (suite *OrderWatcherUnitTestSuite) TestAddOrderAndWaitFailures() {
+	client, conn, _ := newOrderWatcherTestClient()
+	params := AddOrderRequestParameters{OrderType: "limit", Type: "buy", Pair: "XBT/USD", Price: "1.0", Volume: "1.0"}
+	// Validate-only orders
+	_, _, err := client.AddOrderAndWait(context.Background(), AddOrderRequestParameters{Validate: true}, messages.Open)
+	require.Error(suite.T(), err)
+	// Invalid self trade prevention flag
+	_, _, err = client.AddOrderAndWait(context.Background(), AddOrderRequestParameters{OrderType: "market", Type: "buy", Pair: "XBT/USD", Volume: "1.0", StpType: "cancel-all"}, messages.Open)
+	require.ErrorContains(suite.T(), err, "invalid self trade prevention flag")
+	// Timeout
+	conn.On("Write", mock.Anything, wsadapters.Text, mock.Anything).Run(func(args mock.Arguments) {
+		req := new(messages.AddOrderRequest)
+		if err := json.Unmarshal(args.Get(2).([]byte), req); err != nil {
+			panic(err)
+		}
+		go func() {
+			client.handleAddOrderStatus(context.Background(), nil, nil, nil, nil, "", 0, []byte(fmt.Sprintf(`{"event":"addOrderStatus","reqid":%d,"status":"ok","txid":"OABC"}`, req.RequestId)))
+			client.handleOpenOrders(context.Background(), nil, nil, nil, nil, "", 0, []byte(`[[{"OABC":{"status":"open"}}],"openOrders",{"sequence":1}]`))
+		}()
+	}).Return(nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	txid, info, err := client.AddOrderAndWait(ctx, params, messages.Closed)
+	interrupted := new(OperationInterruptedError)
+	require.ErrorAs(suite.T(), err, &interrupted)
+	require.Equal(suite.T(), "OABC", txid)
+	require.NotNil(suite.T(), info)
+	require.Equal(suite.T(), string(messages.Open), info.Status)
+	require.Equal(suite.T(), string(trading.STPCancelNewest), info.StpType)
+	// No subscription
+	client.openOrdersSubMu.Lock()
+	client.subscriptions.openOrders = nil
+	client.openOrdersSubMu.Unlock()
+	_, _, err = client.AddOrderAndWait(context.Background(), params, messages.Open)
+	require.Error(suite.T(), err)
+	// Terminal status
+	require.True(suite.T(), orderStatusReached(messages.Canceled, messages.Closed))
+	require.True(suite.T(), orderStatusReached(messages.Open, messages.Pending))
+	require.False(suite.T(), orderStatusReached(messages.Pending, messages.Open))
+	require.False(suite.T(), orderStatusReached(messages.Open, messages.Closed))
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build a client with a mocked connection and an active openOrders subscription.
+func newOrderWatcherTestClient() (*krakenSpotWebsocketClient, *wsadapters.WebsocketConnectionAdapterInterfaceMock, chan event.Event) {
+	restClient := rest.NewMockKrakenSpotRESTClient()
+	restClient.On("GetWebsocketToken", mock.Anything, mock.Anything, mock.Anything).
+		Return(rest.NewMockGetWebsocketTokenResponse("token", 900), nil, nil)
+	client := newKrakenSpotWebsocketClient(newWebsocketTokenSource(restClient, noncegen.NewHFNonceGenerator(), nil), nil, nil, nil, nil, nil)
+	conn := newConnectionMock()
+	client.conn = conn
+	pub := make(chan event.Event, 10)
+	client.subscriptions.openOrders = &openOrdersSubscription{pub: pub}
+	return client, conn, pub
+}
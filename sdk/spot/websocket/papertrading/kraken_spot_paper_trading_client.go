@@ -0,0 +1,967 @@
+// Package papertrading provides a paper trading engine which implements the same interface as the
+// Kraken spot private websocket client. Orders are never sent to Kraken: they are simulated against
+// live public market data (spread feed) and synthetic ownTrades and openOrders events are
+// published to subscribers, letting users test their strategies without touching real funds.
+package papertrading
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
+)
+
+// Default fee rate applied to simulated trades (Kraken base taker fee).
+const DefaultFeeRate = 0.0026
+
+// Capacity of the channel used to receive spread updates from the market data client.
+const spreadChannelCapacity = 100
+
+// Internal state of a simulated order.
+type paperOrder struct {
+	// Order transaction ID
+	txid string
+	// Order side: buy or sell.
+	side string
+	// Order type: market, limit, stop-loss or take-profit.
+	orderType string
+	// Currency pair.
+	pair string
+	// Resolved order price (absolute). Zero for market orders.
+	price float64
+	// Order price as provided by the user.
+	rawPrice string
+	// Total order volume
+	volume float64
+	// Executed volume
+	executed float64
+	// Total cost of executed volume
+	cost float64
+	// Total fees paid
+	fee float64
+	// Order flags
+	oflags string
+	// Applied self trade prevention flag
+	stpType string
+	// Optional user reference
+	userref *int64
+	// True once a stop-loss/take-profit order has been triggered.
+	triggered bool
+	// Order open timestamp
+	opentm time.Time
+}
+
+// # Description
+//
+// Paper trading engine which implements websocket.KrakenSpotPrivateWebsocketClientInterface.
+//
+// Orders added with the client are simulated against the best bid/ask of the spread feed:
+//
+//   - market orders are filled at the best ask (buy) or the best bid (sell).
+//   - limit orders are filled when the best ask (buy) or the best bid (sell) crosses the limit
+//     price.
+//   - stop-loss and take-profit orders are converted to market orders once triggered.
+//
+// Fills are limited to the volume available at the best price. Fees are computed using the
+// configured fee rate. Relative prices (+, -, # prefixes) are resolved against the mid price of
+// the last known spread.
+//
+// Synthetic ownTrades and openOrders events are published to subscribers using blocking writes,
+// the same way the websocket client does.
+type KrakenSpotPaperTradingClient struct {
+	// Optional public websocket client used to get live market data.
+	marketData websocket.KrakenSpotPublicWebsocketClientInterface
+	// Fee rate applied to simulated trades
+	feeRate float64
+	// Logger used to publish debug/verbose logs
+	logger *log.Logger
+	// Clock used to timestamp orders and trades and to run the dead man's switch
+	clock clock.Clock
+	// Mutex used to protect the engine state
+	mu sync.Mutex
+	// Mutex used to serialize event publication so events are delivered in order
+	pubMu sync.Mutex
+	// Open orders by transaction ID
+	orders map[string]*paperOrder
+	// Trades history used to build ownTrades snapshots
+	trades []map[string]messages.OwnTradeData
+	// Last known spread by pair
+	spreads map[string]messages.SpreadData
+	// Channel used to publish ownTrades events. Nil if there is no active subscription.
+	ownTrades chan event.Event
+	// Channel used to publish openOrders events. Nil if there is no active subscription.
+	openOrders chan event.Event
+	// Sequence ID for ownTrades messages
+	ownTradesSeq int64
+	// Sequence ID for openOrders messages
+	openOrdersSeq int64
+	// Counter used to generate order and trade IDs
+	idCounter int64
+	// Timer used by CancellAllOrdersAfterX
+	cancelAllTimer clock.Timer
+	// Channel closed to stop the goroutine waiting on the CancellAllOrdersAfterX timer
+	cancelAllStop chan struct{}
+	// Built-in channel for heartbeats. Nothing is published by the paper trading engine.
+	heartbeat chan event.Event
+	// Built-in channel for system status. Nothing is published by the paper trading engine.
+	systemStatus chan event.Event
+	// Built-in channel for general errors. Nothing is published by the paper trading engine.
+	generalErrors chan event.Event
+	// True if channels provided on subscribe must be left open on unsubscribe.
+	keepChannelsOpen atomic.Bool
+}
+
+// # Description
+//
+// Build a new KrakenSpotPaperTradingClient.
+//
+// # Inputs
+//
+//   - marketData: Optional public websocket client used by Start to subscribe to the spread feed.
+//     Can be nil in case market data are provided with ProcessSpread.
+//   - feeRate: Fee rate applied to simulated trades. A negative value means DefaultFeeRate.
+//   - logger: Optional logger used to log debug/vebrose messages. If nil, a logger with a discard
+//     writer (noop) will be used.
+//
+// # Return
+//
+// A new KrakenSpotPaperTradingClient.
+func NewKrakenSpotPaperTradingClient(
+	marketData websocket.KrakenSpotPublicWebsocketClientInterface,
+	feeRate float64,
+	logger *log.Logger) *KrakenSpotPaperTradingClient {
+	if feeRate < 0 {
+		feeRate = DefaultFeeRate
+	}
+	if logger == nil {
+		logger = log.New(io.Discard, "", log.Flags())
+	}
+	return &KrakenSpotPaperTradingClient{
+		marketData:    marketData,
+		feeRate:       feeRate,
+		logger:        logger,
+		clock:         clock.NewSystemClock(),
+		orders:        map[string]*paperOrder{},
+		trades:        []map[string]messages.OwnTradeData{},
+		spreads:       map[string]messages.SpreadData{},
+		heartbeat:     make(chan event.Event, 10),
+		systemStatus:  make(chan event.Event, 10),
+		generalErrors: make(chan event.Event, 10),
+	}
+}
+
+/*************************************************************************************************/
+/* MARKET DATA                                                                                   */
+/*************************************************************************************************/
+
+// # Description
+//
+// Subscribe to the spread feed of the provided pairs with the market data client and start a
+// goroutine which feeds the paper trading engine with received spread updates until the provided
+// context is cancelled.
+//
+// # Return
+//
+// An error if no market data client has been provided or if subscribe fails.
+func (client *KrakenSpotPaperTradingClient) Start(ctx context.Context, pairs []string) error {
+	if client.marketData == nil {
+		return fmt.Errorf("start failed because no market data client has been provided")
+	}
+	rcv := make(chan event.Event, spreadChannelCapacity)
+	err := client.marketData.SubscribeSpread(ctx, pairs, rcv)
+	if err != nil {
+		return fmt.Errorf("start failed: %w", err)
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-rcv:
+				if !ok {
+					// Spread subscription has been closed
+					return
+				}
+				if e.Type() != string(events.Spread) {
+					continue
+				}
+				spread := new(messages.Spread)
+				if err := json.Unmarshal(e.Data(), spread); err != nil {
+					client.logger.Println("failed to parse spread event:", err.Error())
+					continue
+				}
+				client.ProcessSpread(spread.Pair, spread.Data)
+			}
+		}
+	}()
+	return nil
+}
+
+// # Description
+//
+// Update the engine with the provided spread for the pair and match open orders for this pair
+// against the new best bid/ask. Synthetic ownTrades and openOrders events are published for
+// resulting fills. Invalid spreads are discarded: the last valid spread is kept.
+//
+// Start uses this method to feed the engine with live market data. It can also be called directly
+// to feed the engine with data from another source.
+func (client *KrakenSpotPaperTradingClient) ProcessSpread(pair string, spread messages.SpreadData) {
+	client.pubMu.Lock()
+	defer client.pubMu.Unlock()
+	bid, ask, bidVol, askVol, err := parseSpread(spread)
+	if err != nil {
+		client.logger.Println("discarding invalid spread:", err.Error())
+		return
+	}
+	client.mu.Lock()
+	client.spreads[pair] = spread
+	trades, updates := client.match(client.sortedOrders(pair), bid, ask, bidVol, askVol)
+	client.mu.Unlock()
+	client.publishOwnTrades(trades)
+	client.publishOpenOrders(updates)
+}
+
+// Match the provided orders, in order, against the provided best bid/ask. Available liquidity is
+// consumed by each fill. Filled orders are removed. Client mutex must be held.
+//
+// Return the trades and the order updates to publish.
+func (client *KrakenSpotPaperTradingClient) match(orders []*paperOrder, bid float64, ask float64, bidVol float64, askVol float64) ([]map[string]messages.OwnTradeData, []map[string]messages.OrderInfo) {
+	trades := []map[string]messages.OwnTradeData{}
+	updates := []map[string]messages.OrderInfo{}
+	for _, order := range orders {
+		var fillPrice, available float64
+		if order.side == string(trading.Buy) {
+			fillPrice, available = ask, askVol
+		} else {
+			fillPrice, available = bid, bidVol
+		}
+		if available <= 0 || !order.isExecutable(bid, ask) {
+			continue
+		}
+		// Consume available liquidity
+		fillVolume := math.Min(order.volume-order.executed, available)
+		if order.side == string(trading.Buy) {
+			askVol -= fillVolume
+		} else {
+			bidVol -= fillVolume
+		}
+		trades = append(trades, client.fill(order, fillPrice, fillVolume))
+		updates = append(updates, map[string]messages.OrderInfo{order.txid: order.fillUpdate()})
+		if order.executed >= order.volume {
+			delete(client.orders, order.txid)
+		}
+	}
+	return trades, updates
+}
+
+/*************************************************************************************************/
+/* KRAKEN PRIVATE WEBSOCKET IMPL.                                                                */
+/*************************************************************************************************/
+
+// Ping always succeeds as no server is involved.
+func (client *KrakenSpotPaperTradingClient) Ping(ctx context.Context) error {
+	return nil
+}
+
+// # Description
+//
+// Add a new simulated order. Supported order types are market, limit, stop-loss and take-profit.
+// Market orders are filled right away against the last known spread of the pair, if any.
+// Otherwise, they are filled on the next spread update.
+//
+// # Return
+//
+// The AddOrderResponse. In case the order is rejected, the response has its status set to error
+// and an OperationError is also returned, like the websocket client does.
+func (client *KrakenSpotPaperTradingClient) AddOrder(ctx context.Context, params websocket.AddOrderRequestParameters) (*messages.AddOrderResponse, error) {
+	client.pubMu.Lock()
+	defer client.pubMu.Unlock()
+	client.mu.Lock()
+	order, err := client.newOrder(params.Type, params.OrderType, params.Pair, params.Price, params.Volume, params.OFlags, params.UserReference)
+	if err == nil {
+		err = trading.ValidateSelfTradePreventionFlag(string(params.StpType))
+	}
+	if err == nil {
+		err = trading.ValidateFormattedDeadline(params.Deadline, client.clock.Now())
+	}
+	if err != nil {
+		client.mu.Unlock()
+		return &messages.AddOrderResponse{
+			Event:  string(messages.EventTypeAddOrderStatus),
+			Status: string(messages.Err),
+			Err:    err.Error(),
+		}, &websocket.OperationError{Operation: "add_order", Root: fmt.Errorf("add order failed: %w", err)}
+	}
+	order.stpType = string(trading.AppliedSelfTradePreventionFlag(string(params.StpType)))
+	resp := &messages.AddOrderResponse{
+		Event:       string(messages.EventTypeAddOrderStatus),
+		Status:      string(messages.Ok),
+		Description: order.description(),
+	}
+	if params.Validate {
+		client.mu.Unlock()
+		return resp, nil
+	}
+	order.txid = client.nextId("O")
+	resp.TxId = order.txid
+	client.orders[order.txid] = order
+	opened := []map[string]messages.OrderInfo{{order.txid: order.info(messages.Open)}}
+	// Fill market orders against the current spread
+	var trades []map[string]messages.OwnTradeData
+	var updates []map[string]messages.OrderInfo
+	if spread, ok := client.spreads[order.pair]; ok && order.orderType == string(trading.Market) {
+		if bid, ask, bidVol, askVol, err := parseSpread(spread); err == nil {
+			trades, updates = client.match([]*paperOrder{order}, bid, ask, bidVol, askVol)
+		}
+	}
+	client.mu.Unlock()
+	client.logger.Println("paper order added", order.txid, order.description())
+	client.publishOpenOrders(opened)
+	client.publishOwnTrades(trades)
+	client.publishOpenOrders(updates)
+	return resp, nil
+}
+
+// # Description
+//
+// Edit a simulated order. Like Kraken, the original order is cancelled and replaced by a new
+// order with a new transaction ID.
+//
+// # Return
+//
+// The EditOrderResponse. In case the edit is rejected, the response has its status set to error
+// and an OperationError is also returned.
+func (client *KrakenSpotPaperTradingClient) EditOrder(ctx context.Context, params websocket.EditOrderRequestParameters) (*messages.EditOrderResponse, error) {
+	client.pubMu.Lock()
+	defer client.pubMu.Unlock()
+	client.mu.Lock()
+	fail := func(err error) (*messages.EditOrderResponse, error) {
+		client.mu.Unlock()
+		return &messages.EditOrderResponse{
+			Event:  string(messages.EventTypeEditOrderStatus),
+			Status: string(messages.Err),
+			Err:    err.Error(),
+		}, &websocket.OperationError{Operation: "edit_order", Root: fmt.Errorf("edit order failed: %w", err)}
+	}
+	original := client.findOrder(params.Id)
+	if original == nil || original.pair != params.Pair {
+		return fail(fmt.Errorf("EOrder:Unknown order"))
+	}
+	price, volume, oflags, userref := original.rawPrice, strconv.FormatFloat(original.volume, 'f', -1, 64), original.oflags, ""
+	if original.userref != nil {
+		userref = strconv.FormatInt(*original.userref, 10)
+	}
+	if params.Price != "" {
+		price = params.Price
+	}
+	if params.Volume != "" {
+		volume = params.Volume
+	}
+	if params.OFlags != "" {
+		oflags = params.OFlags
+	}
+	if params.NewUserReference != "" {
+		userref = params.NewUserReference
+	}
+	order, err := client.newOrder(original.side, original.orderType, original.pair, price, volume, oflags, userref)
+	if err != nil {
+		return fail(err)
+	}
+	if order.volume <= original.executed {
+		return fail(fmt.Errorf("EOrder:Invalid volume"))
+	}
+	resp := &messages.EditOrderResponse{
+		Event:        string(messages.EventTypeEditOrderStatus),
+		Status:       string(messages.Ok),
+		OriginalTxId: original.txid,
+		Description:  order.description(),
+	}
+	if params.Validate {
+		client.mu.Unlock()
+		return resp, nil
+	}
+	// Carry executed volume over the new order and replace the original one
+	order.executed, order.cost, order.fee = original.executed, original.cost, original.fee
+	order.stpType = original.stpType
+	order.txid = client.nextId("O")
+	resp.TxId = order.txid
+	delete(client.orders, original.txid)
+	client.orders[order.txid] = order
+	client.mu.Unlock()
+	client.publishOpenOrders([]map[string]messages.OrderInfo{
+		{original.txid: original.cancelUpdate("Order replaced")},
+		{order.txid: order.info(messages.Open)},
+	})
+	return resp, nil
+}
+
+// # Description
+//
+// Cancel simulated orders by transaction ID or user reference.
+//
+// # Return
+//
+// The CancelOrderResponse. In case one of the orders is unknown, no order is cancelled, the
+// response has its status set to error and an OperationError is also returned.
+func (client *KrakenSpotPaperTradingClient) CancelOrder(ctx context.Context, params websocket.CancelOrderRequestParameters) (*messages.CancelOrderResponse, error) {
+	client.pubMu.Lock()
+	defer client.pubMu.Unlock()
+	client.mu.Lock()
+	toCancel := []*paperOrder{}
+	for _, id := range params.TxId {
+		matches := client.findOrders(id)
+		if len(matches) == 0 {
+			client.mu.Unlock()
+			err := fmt.Errorf("EOrder:Unknown order")
+			return &messages.CancelOrderResponse{
+				Event:  string(messages.EventTypeCancelOrderStatus),
+				Status: string(messages.Err),
+				Err:    err.Error(),
+			}, &websocket.OperationError{Operation: "cancel_order", Root: fmt.Errorf("cancel order failed: %w", err)}
+		}
+		toCancel = append(toCancel, matches...)
+	}
+	updates := client.cancel(toCancel, "User requested")
+	client.mu.Unlock()
+	client.publishOpenOrders(updates)
+	return &messages.CancelOrderResponse{
+		Event:  string(messages.EventTypeCancelOrderStatus),
+		Status: string(messages.Ok),
+	}, nil
+}
+
+// Cancel all simulated open orders.
+func (client *KrakenSpotPaperTradingClient) CancellAllOrders(ctx context.Context) (*messages.CancelAllOrdersResponse, error) {
+	count := client.cancelAll()
+	return &messages.CancelAllOrdersResponse{
+		Event:  string(messages.EventTypeCancelAllOrderStatus),
+		Status: string(messages.Ok),
+		Count:  count,
+	}, nil
+}
+
+// # Description
+//
+// Simulate the dead man's switch: all open orders are cancelled once the timeout expires unless
+// the method is called again before. A timeout of 0 disables the timer.
+func (client *KrakenSpotPaperTradingClient) CancellAllOrdersAfterX(ctx context.Context, params websocket.CancelAllOrdersAfterXRequestParameters) (*messages.CancelAllOrdersAfterXResponse, error) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.cancelAllTimer != nil {
+		client.cancelAllTimer.Stop()
+		close(client.cancelAllStop)
+		client.cancelAllTimer = nil
+	}
+	now := client.clock.Now().UTC()
+	trigger := now
+	if params.Timeout > 0 {
+		trigger = now.Add(time.Duration(params.Timeout) * time.Second)
+		timer := client.clock.NewTimer(time.Duration(params.Timeout) * time.Second)
+		stop := make(chan struct{})
+		client.cancelAllTimer = timer
+		client.cancelAllStop = stop
+		go func() {
+			select {
+			case <-timer.C():
+				client.cancelAll()
+			case <-stop:
+			}
+		}()
+	}
+	return &messages.CancelAllOrdersAfterXResponse{
+		Event:       string(messages.EventTypeCancelAllOrderAfterXStatus),
+		Status:      string(messages.Ok),
+		CurrentTime: now.Format(time.RFC3339),
+		TriggerTime: trigger.Format(time.RFC3339),
+	}, nil
+}
+
+// # Description
+//
+// Subscribe to the simulated ownTrades channel. If snapshot is true, the simulated trades history
+// is published right away. The consolidateTaker parameter has no effect as simulated trades are
+// never split.
+func (client *KrakenSpotPaperTradingClient) SubscribeOwnTrades(ctx context.Context, snapshot bool, consolidateTaker bool, rcv chan event.Event) error {
+	client.pubMu.Lock()
+	defer client.pubMu.Unlock()
+	client.mu.Lock()
+	if client.ownTrades != nil {
+		client.mu.Unlock()
+		return fmt.Errorf("subscribe own trades failed because there is already an active subscription")
+	}
+	client.ownTrades = rcv
+	history := append([]map[string]messages.OwnTradeData{}, client.trades...)
+	client.mu.Unlock()
+	if snapshot {
+		client.publishOwnTrades(history)
+	}
+	return nil
+}
+
+// Subscribe to the simulated openOrders channel. A snapshot of the open orders is published right
+// away. The rateCounter parameter has no effect.
+func (client *KrakenSpotPaperTradingClient) SubscribeOpenOrders(ctx context.Context, rateCounter bool, rcv chan event.Event) error {
+	client.pubMu.Lock()
+	defer client.pubMu.Unlock()
+	client.mu.Lock()
+	if client.openOrders != nil {
+		client.mu.Unlock()
+		return fmt.Errorf("subscribe open orders failed because there is already an active subscription")
+	}
+	client.openOrders = rcv
+	snapshot := []map[string]messages.OrderInfo{}
+	for _, order := range client.sortedOrders("") {
+		snapshot = append(snapshot, map[string]messages.OrderInfo{order.txid: order.info(messages.Open)})
+	}
+	client.mu.Unlock()
+	client.publish(events.OpenOrders, messages.OpenOrders{
+		Orders:      snapshot,
+		ChannelName: string(messages.ChannelOpenOrders),
+		Sequence:    client.nextSequence(&client.openOrdersSeq),
+	})
+	return nil
+}
+
+// # Description
+//
+// Configure whether the channels provided on subscribe are closed on unsubscribe. By default,
+// channels are closed like the private websocket client does (Cf.
+// websocket.KrakenSpotPrivateWebsocketClient.SetKeepChannelsOpenOnUnsubscribe).
+//
+// # Inputs
+//
+//   - keep: True to keep channels open on unsubscribe, false to close them (default).
+func (client *KrakenSpotPaperTradingClient) SetKeepChannelsOpenOnUnsubscribe(keep bool) {
+	client.keepChannelsOpen.Store(keep)
+}
+
+// # Description
+//
+// Set the clock used to timestamp simulated orders and trades and to run the dead man's switch
+// (Cf. CancellAllOrdersAfterX). This can be used to provide a clock.FakeClock in tests.
+//
+// # Inputs
+//
+//   - c: Clock to use. If nil, the system clock is used (Cf. clock.SystemClock).
+func (client *KrakenSpotPaperTradingClient) SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.NewSystemClock()
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.clock = c
+}
+
+// Unsubscribe from the simulated ownTrades channel. The channel provided on subscribe is closed
+// unless the client is configured to keep channels open.
+func (client *KrakenSpotPaperTradingClient) UnsubscribeOwnTrades(ctx context.Context) error {
+	client.pubMu.Lock()
+	defer client.pubMu.Unlock()
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.ownTrades == nil {
+		return fmt.Errorf("unsubscribe own trades failed because there is no active subscription")
+	}
+	if !client.keepChannelsOpen.Load() {
+		close(client.ownTrades)
+	}
+	client.ownTrades = nil
+	return nil
+}
+
+// Unsubscribe from the simulated openOrders channel. The channel provided on subscribe is closed
+// unless the client is configured to keep channels open.
+func (client *KrakenSpotPaperTradingClient) UnsubscribeOpenOrders(ctx context.Context) error {
+	client.pubMu.Lock()
+	defer client.pubMu.Unlock()
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.openOrders == nil {
+		return fmt.Errorf("unsubscribe open orders failed because there is no active subscription")
+	}
+	if !client.keepChannelsOpen.Load() {
+		close(client.openOrders)
+	}
+	client.openOrders = nil
+	return nil
+}
+
+// Get the built-in system status channel. Nothing is published by the paper trading engine.
+func (client *KrakenSpotPaperTradingClient) GetSystemStatusChannel() chan event.Event {
+	return client.systemStatus
+}
+
+// Get the built-in heartbeat channel. Nothing is published by the paper trading engine.
+func (client *KrakenSpotPaperTradingClient) GetHeartbeatChannel() chan event.Event {
+	return client.heartbeat
+}
+
+// Get the built-in general error channel. Nothing is published by the paper trading engine.
+func (client *KrakenSpotPaperTradingClient) GetGeneralErrorChannel() chan event.Event {
+	return client.generalErrors
+}
+
+/*************************************************************************************************/
+/* INTERNALS                                                                                     */
+/*************************************************************************************************/
+
+// Build and validate a new order. Client mutex must be held.
+func (client *KrakenSpotPaperTradingClient) newOrder(side string, orderType string, pair string, price string, volume string, oflags string, userref string) (*paperOrder, error) {
+	if side != string(trading.Buy) && side != string(trading.Sell) {
+		return nil, fmt.Errorf("EGeneral:Invalid arguments:type")
+	}
+	if pair == "" {
+		return nil, fmt.Errorf("EGeneral:Invalid arguments:pair")
+	}
+	order := &paperOrder{side: side, orderType: orderType, pair: pair, oflags: oflags, opentm: client.clock.Now()}
+	var err error
+	order.volume, err = strconv.ParseFloat(volume, 64)
+	if err != nil || order.volume <= 0 {
+		return nil, fmt.Errorf("EGeneral:Invalid arguments:volume")
+	}
+	if userref != "" {
+		ref, err := strconv.ParseInt(userref, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("EGeneral:Invalid arguments:userref")
+		}
+		order.userref = &ref
+	}
+	switch trading.OrderTypeEnum(orderType) {
+	case trading.Market:
+		return order, nil
+	case trading.Limit, trading.StopLoss, trading.TakeProfit:
+		order.rawPrice = price
+		order.price, err = client.resolvePrice(order, price)
+		if err != nil {
+			return nil, err
+		}
+		return order, nil
+	default:
+		return nil, fmt.Errorf("EOrder:Order type %s is not supported by the paper trading engine", orderType)
+	}
+}
+
+// Resolve the provided order price (absolute or relative) into an absolute price using the mid
+// price of the last known spread. Client mutex must be held.
+func (client *KrakenSpotPaperTradingClient) resolvePrice(order *paperOrder, price string) (float64, error) {
+	if abs, err := strconv.ParseFloat(price, 64); err == nil && abs > 0 && !strings.ContainsAny(price[:1], "+-") {
+		return abs, nil
+	}
+	rp, err := trading.ParseRelativePrice(price)
+	if err != nil {
+		return 0, fmt.Errorf("EGeneral:Invalid arguments:price")
+	}
+	spread, ok := client.spreads[order.pair]
+	if !ok {
+		return 0, fmt.Errorf("EOrder:No market data to resolve relative price for %s", order.pair)
+	}
+	bid, ask, _, _, err := parseSpread(spread)
+	if err != nil {
+		return 0, fmt.Errorf("EOrder:No market data to resolve relative price for %s", order.pair)
+	}
+	ref := (bid + ask) / 2
+	offset, _ := strconv.ParseFloat(rp.Offset(), 64)
+	if rp.IsPercent() {
+		offset = ref * offset / 100
+	}
+	switch rp.Prefix() {
+	case trading.Plus:
+		return ref + offset, nil
+	case trading.Minus:
+		return ref - offset, nil
+	default:
+		// # prefix: offset is applied away from the market for limit and take-profit orders
+		// and toward the opposite direction for stop-loss orders.
+		add := order.side == string(trading.Sell)
+		if order.orderType == string(trading.StopLoss) {
+			add = !add
+		}
+		if add {
+			return ref + offset, nil
+		}
+		return ref - offset, nil
+	}
+}
+
+// Returns true if the order can be executed given the provided best bid/ask. Stop-loss and
+// take-profit orders are marked as triggered when their trigger price is reached.
+func (order *paperOrder) isExecutable(bid float64, ask float64) bool {
+	buy := order.side == string(trading.Buy)
+	switch trading.OrderTypeEnum(order.orderType) {
+	case trading.Market:
+		return true
+	case trading.Limit:
+		return (buy && ask <= order.price) || (!buy && bid >= order.price)
+	case trading.StopLoss:
+		if !order.triggered {
+			order.triggered = (buy && ask >= order.price) || (!buy && bid <= order.price)
+		}
+		return order.triggered
+	case trading.TakeProfit:
+		if !order.triggered {
+			order.triggered = (buy && ask <= order.price) || (!buy && bid >= order.price)
+		}
+		return order.triggered
+	default:
+		return false
+	}
+}
+
+// Fill the order and record the simulated trade. Client mutex must be held.
+func (client *KrakenSpotPaperTradingClient) fill(order *paperOrder, price float64, volume float64) map[string]messages.OwnTradeData {
+	cost := price * volume
+	fee := cost * client.feeRate
+	order.executed += volume
+	order.cost += cost
+	order.fee += fee
+	trade := map[string]messages.OwnTradeData{
+		client.nextId("T"): {
+			OrderTransactionId: order.txid,
+			Pair:               order.pair,
+			Timestamp:          formatTimestamp(client.clock.Now()),
+			Type:               order.side,
+			OrderType:          order.orderType,
+			Price:              formatFloat(price),
+			Cost:               formatFloat(cost),
+			Fee:                formatFloat(fee),
+			Volume:             formatFloat(volume),
+			UserReference:      order.userref,
+		},
+	}
+	client.trades = append(client.trades, trade)
+	client.logger.Println("paper order filled", order.txid, formatFloat(volume), "@", formatFloat(price))
+	return trade
+}
+
+// Cancel the provided orders and return the corresponding openOrders updates. Client mutex must
+// be held.
+func (client *KrakenSpotPaperTradingClient) cancel(orders []*paperOrder, reason string) []map[string]messages.OrderInfo {
+	updates := []map[string]messages.OrderInfo{}
+	for _, order := range orders {
+		if _, ok := client.orders[order.txid]; !ok {
+			continue
+		}
+		delete(client.orders, order.txid)
+		updates = append(updates, map[string]messages.OrderInfo{order.txid: order.cancelUpdate(reason)})
+	}
+	return updates
+}
+
+// Cancel all open orders, publish the updates and return the number of cancelled orders.
+func (client *KrakenSpotPaperTradingClient) cancelAll() int {
+	client.pubMu.Lock()
+	defer client.pubMu.Unlock()
+	client.mu.Lock()
+	updates := client.cancel(client.sortedOrders(""), "User requested")
+	client.mu.Unlock()
+	client.publishOpenOrders(updates)
+	return len(updates)
+}
+
+// Find an open order by transaction ID or user reference. Client mutex must be held.
+func (client *KrakenSpotPaperTradingClient) findOrder(id string) *paperOrder {
+	matches := client.findOrders(id)
+	if len(matches) != 1 {
+		return nil
+	}
+	return matches[0]
+}
+
+// Find open orders by transaction ID or user reference. Client mutex must be held.
+func (client *KrakenSpotPaperTradingClient) findOrders(id string) []*paperOrder {
+	if order, ok := client.orders[id]; ok {
+		return []*paperOrder{order}
+	}
+	matches := []*paperOrder{}
+	if ref, err := strconv.ParseInt(id, 10, 64); err == nil {
+		for _, order := range client.sortedOrders("") {
+			if order.userref != nil && *order.userref == ref {
+				matches = append(matches, order)
+			}
+		}
+	}
+	return matches
+}
+
+// Get open orders for the pair (all pairs if empty) ordered by transaction ID, which matches
+// their creation order. Client mutex must be held.
+func (client *KrakenSpotPaperTradingClient) sortedOrders(pair string) []*paperOrder {
+	sorted := make([]*paperOrder, 0, len(client.orders))
+	for _, order := range client.orders {
+		if pair == "" || order.pair == pair {
+			sorted = append(sorted, order)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].txid < sorted[j].txid })
+	return sorted
+}
+
+// Generate a new order (O) or trade (T) ID. Client mutex must be held.
+func (client *KrakenSpotPaperTradingClient) nextId(prefix string) string {
+	client.idCounter++
+	return fmt.Sprintf("%sPAPER-%011d", prefix, client.idCounter)
+}
+
+// Increment and return the provided sequence.
+func (client *KrakenSpotPaperTradingClient) nextSequence(seq *int64) messages.SequenceId {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	*seq++
+	return messages.SequenceId{Sequence: *seq}
+}
+
+// Publish the provided trades on the ownTrades channel if any. Publish mutex must be held.
+func (client *KrakenSpotPaperTradingClient) publishOwnTrades(trades []map[string]messages.OwnTradeData) {
+	if len(trades) == 0 {
+		return
+	}
+	client.publish(events.OwnTrades, messages.OwnTrades{
+		Data:        trades,
+		ChannelName: string(messages.ChannelOwnTrades),
+		SequenceId:  client.nextSequence(&client.ownTradesSeq),
+	})
+}
+
+// Publish the provided order updates on the openOrders channel if any. Publish mutex must be held.
+func (client *KrakenSpotPaperTradingClient) publishOpenOrders(updates []map[string]messages.OrderInfo) {
+	if len(updates) == 0 {
+		return
+	}
+	client.publish(events.OpenOrders, messages.OpenOrders{
+		Orders:      updates,
+		ChannelName: string(messages.ChannelOpenOrders),
+		Sequence:    client.nextSequence(&client.openOrdersSeq),
+	})
+}
+
+// Publish the provided message as an event of the provided type on the matching subscription
+// channel - use blocking write. Message is discarded if there is no active subscription. Publish
+// mutex must be held.
+func (client *KrakenSpotPaperTradingClient) publish(etype events.WebsocketClientEventTypeEnum, msg interface{}) {
+	client.mu.Lock()
+	var pub chan event.Event
+	if etype == events.OwnTrades {
+		pub = client.ownTrades
+	} else {
+		pub = client.openOrders
+	}
+	client.mu.Unlock()
+	if pub == nil {
+		return
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		client.logger.Println("failed to marshal paper trading message:", err.Error())
+		return
+	}
+	e := event.New()
+	e.Context.SetType(string(etype))
+	e.Context.SetSource(tracing.PackageName)
+	e.SetData("application/json", payload)
+	pub <- e
+}
+
+// Get a human readable description of the order (ex: buy 1.5 XBT/USD @ limit 30000).
+func (order *paperOrder) description() string {
+	if order.orderType == string(trading.Market) {
+		return fmt.Sprintf("%s %s %s @ market", order.side, formatFloat(order.volume), order.pair)
+	}
+	return fmt.Sprintf("%s %s %s @ %s %s", order.side, formatFloat(order.volume), order.pair, order.orderType, formatFloat(order.price))
+}
+
+// Build the full order info used for new orders and snapshots.
+func (order *paperOrder) info(status messages.OrderStatusEnum) messages.OrderInfo {
+	info := order.fillUpdate()
+	info.Status = string(status)
+	info.UserReferenceId = order.userref
+	info.OpenTimestamp = formatTimestamp(order.opentm)
+	info.Volume = formatFloat(order.volume)
+	info.OrderFlags = order.oflags
+	info.StpType = order.stpType
+	info.Description = &messages.OrderInfoDescription{
+		Pair:             order.pair,
+		Type:             order.side,
+		OrderType:        order.orderType,
+		Price:            formatFloat(order.price),
+		OrderDescription: order.description(),
+	}
+	return info
+}
+
+// Build the order update published after a fill.
+func (order *paperOrder) fillUpdate() messages.OrderInfo {
+	info := messages.OrderInfo{
+		VolumeExecuted: formatFloat(order.executed),
+		Cost:           formatFloat(order.cost),
+		Fee:            formatFloat(order.fee),
+		AvgPrice:       "0",
+	}
+	if order.executed > 0 {
+		info.AvgPrice = formatFloat(order.cost / order.executed)
+	}
+	if order.executed >= order.volume {
+		info.Status = string(messages.Closed)
+	}
+	return info
+}
+
+// Build the order update published when the order is cancelled.
+func (order *paperOrder) cancelUpdate(reason string) messages.OrderInfo {
+	return messages.OrderInfo{
+		Status:       string(messages.Canceled),
+		CancelReason: reason,
+	}
+}
+
+// Parse best bid/ask prices and volumes from the provided spread. An error is returned if a value
+// cannot be parsed, if a price is not strictly positive, if a volume is negative or if the best
+// bid is above the best ask.
+func parseSpread(spread messages.SpreadData) (bid float64, ask float64, bidVol float64, askVol float64, err error) {
+	if bid, err = spread.BestBidPrice.Float64(); err != nil {
+		return
+	}
+	if ask, err = spread.BestAskPrice.Float64(); err != nil {
+		return
+	}
+	if bidVol, err = spread.BestBidVolume.Float64(); err != nil {
+		return
+	}
+	if askVol, err = spread.BestAskVolume.Float64(); err != nil {
+		return
+	}
+	switch {
+	case bid <= 0 || ask <= 0:
+		err = fmt.Errorf("prices must be strictly positive: bid %s, ask %s", spread.BestBidPrice, spread.BestAskPrice)
+	case bidVol < 0 || askVol < 0:
+		err = fmt.Errorf("volumes must be positive: bid %s, ask %s", spread.BestBidVolume, spread.BestAskVolume)
+	case bid > ask:
+		err = fmt.Errorf("best bid %s is above best ask %s", spread.BestBidPrice, spread.BestAskPrice)
+	}
+	return
+}
+
+// Format a float without exponent and trailing zeros.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// Format a timestamp like Kraken does (unix seconds with microseconds).
+func formatTimestamp(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixMicro())/1e6, 'f', 6, 64)
+}
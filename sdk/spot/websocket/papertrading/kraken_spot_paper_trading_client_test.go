@@ -0,0 +1,347 @@
+package papertrading
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest/trading"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* TEST SUITE                                                                                    */
+/*************************************************************************************************/
+
+// Unit test suite for KrakenSpotPaperTradingClient.
+type KrakenSpotPaperTradingClientTestSuite struct {
+	suite.Suite
+	// Client under test
+	client *KrakenSpotPaperTradingClient
+	// Channel which receives own trades events
+	ownTrades chan event.Event
+	// Channel which receives open orders events
+	openOrders chan event.Event
+}
+
+// Run unit test suite
+func TestKrakenSpotPaperTradingClientTestSuite(t *testing.T) {
+	suite.Run(t, new(KrakenSpotPaperTradingClientTestSuite))
+}
+
+// Build a new client and subscribe to own trades and open orders before each test.
+func (suite *KrakenSpotPaperTradingClientTestSuite) SetupTest() {
+	suite.client = NewKrakenSpotPaperTradingClient(nil, 0.001, nil)
+	suite.ownTrades = make(chan event.Event, 100)
+	suite.openOrders = make(chan event.Event, 100)
+	require.NoError(suite.T(), suite.client.SubscribeOwnTrades(context.Background(), true, false, suite.ownTrades))
+	require.NoError(suite.T(), suite.client.SubscribeOpenOrders(context.Background(), false, suite.openOrders))
+	// Discard open orders snapshot
+	suite.readOpenOrders()
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test interface compliance.
+func (suite *KrakenSpotPaperTradingClientTestSuite) TestIFaceCompliance() {
+	var instance interface{} = suite.client
+	_, ok := instance.(websocket.KrakenSpotPrivateWebsocketClientInterface)
+	require.True(suite.T(), ok)
+}
+
+// Test a limit order which is partially filled and then fully filled by spread updates.
+//
+// The test will ensure:
+//   - Order is not filled while the best ask is above the limit price.
+//   - Fills are limited to the volume available at the best ask.
+//   - ownTrades and openOrders events are published with the expected data.
+func (suite *KrakenSpotPaperTradingClientTestSuite) TestLimitOrderFills() {
+	resp, err := suite.client.AddOrder(context.Background(), websocket.AddOrderRequestParameters{
+		OrderType:     "limit",
+		Type:          "buy",
+		Pair:          "XBT/USD",
+		Price:         "30000",
+		Volume:        "2",
+		UserReference: "42",
+	})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), string(messages.Ok), resp.Status)
+	require.NotEmpty(suite.T(), resp.TxId)
+	require.Equal(suite.T(), "buy 2 XBT/USD @ limit 30000", resp.Description)
+	added := suite.readOpenOrders()
+	require.Equal(suite.T(), string(messages.Open), added.Orders[0][resp.TxId].Status)
+	require.Equal(suite.T(), int64(42), *added.Orders[0][resp.TxId].UserReferenceId)
+	// Ask above limit price - no fill
+	suite.client.ProcessSpread("XBT/USD", spread("30005", "30010", "5", "5"))
+	require.Empty(suite.T(), suite.ownTrades)
+	// Ask below limit price - partial fill limited by ask volume
+	suite.client.ProcessSpread("XBT/USD", spread("29990", "29995", "5", "0.5"))
+	trades := suite.readOwnTrades()
+	require.Len(suite.T(), trades.Data, 1)
+	for _, trade := range trades.Data[0] {
+		require.Equal(suite.T(), resp.TxId, trade.OrderTransactionId)
+		require.Equal(suite.T(), "29995", trade.Price)
+		require.Equal(suite.T(), "0.5", trade.Volume)
+		require.Equal(suite.T(), "14997.5", trade.Cost)
+	}
+	update := suite.readOpenOrders()
+	require.Equal(suite.T(), "0.5", update.Orders[0][resp.TxId].VolumeExecuted)
+	require.Empty(suite.T(), update.Orders[0][resp.TxId].Status)
+	// Fill remaining volume
+	suite.client.ProcessSpread("XBT/USD", spread("29990", "29995", "5", "10"))
+	trades = suite.readOwnTrades()
+	require.Equal(suite.T(), int64(2), trades.SequenceId.Sequence)
+	update = suite.readOpenOrders()
+	require.Equal(suite.T(), "2", update.Orders[0][resp.TxId].VolumeExecuted)
+	require.Equal(suite.T(), string(messages.Closed), update.Orders[0][resp.TxId].Status)
+	// Order is not open anymore
+	_, err = suite.client.CancelOrder(context.Background(), websocket.CancelOrderRequestParameters{TxId: []string{resp.TxId}})
+	require.Error(suite.T(), err)
+}
+
+// Test market, stop-loss and relative price orders.
+//
+// The test will ensure:
+//   - Market orders are filled right away at the best bid of the current spread.
+//   - Invalid spreads are discarded and the last valid spread is kept.
+//   - Relative prices are resolved against the mid price.
+//   - Stop-loss orders are filled once triggered.
+func (suite *KrakenSpotPaperTradingClientTestSuite) TestMarketAndStopLossOrders() {
+	// Relative prices cannot be used without market data
+	_, err := suite.client.AddOrder(context.Background(), websocket.AddOrderRequestParameters{
+		OrderType: "stop-loss", Type: "sell", Pair: "XBT/USD", Price: "-10%", Volume: "1",
+	})
+	require.Error(suite.T(), err)
+	suite.client.ProcessSpread("XBT/USD", spread("99", "101", "10", "10"))
+	// Crossed spread is discarded
+	suite.client.ProcessSpread("XBT/USD", spread("102", "101", "10", "10"))
+	// Market sell is filled right away
+	market, err := suite.client.AddOrder(context.Background(), websocket.AddOrderRequestParameters{
+		OrderType: "market", Type: "sell", Pair: "XBT/USD", Volume: "1",
+	})
+	require.NoError(suite.T(), err)
+	suite.readOpenOrders()
+	trades := suite.readOwnTrades()
+	require.Len(suite.T(), trades.Data, 1)
+	require.Equal(suite.T(), market.TxId, trades.Data[0][firstKey(trades.Data[0])].OrderTransactionId)
+	require.Equal(suite.T(), "99", trades.Data[0][firstKey(trades.Data[0])].Price)
+	suite.readOpenOrders()
+	// Stop loss sell with relative price: 100 - 10% = 90
+	stop, err := suite.client.AddOrder(context.Background(), websocket.AddOrderRequestParameters{
+		OrderType: "stop-loss", Type: "sell", Pair: "XBT/USD", Price: "-10%", Volume: "1",
+	})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "sell 1 XBT/USD @ stop-loss 90", stop.Description)
+	suite.readOpenOrders()
+	// Stop loss is not triggered
+	suite.client.ProcessSpread("XBT/USD", spread("95", "96", "10", "10"))
+	require.Empty(suite.T(), suite.ownTrades)
+	// Stop loss is triggered
+	suite.client.ProcessSpread("XBT/USD", spread("89", "90", "10", "10"))
+	trades = suite.readOwnTrades()
+	trade := trades.Data[0][firstKey(trades.Data[0])]
+	require.Equal(suite.T(), stop.TxId, trade.OrderTransactionId)
+	require.Equal(suite.T(), "89", trade.Price)
+	require.Equal(suite.T(), "0.089", trade.Fee)
+}
+
+// Test order validation, edit and cancel.
+//
+// The test will ensure:
+//   - Invalid orders are rejected with an OperationError and an error status.
+//   - Validate only orders are not registered.
+//   - The applied self trade prevention flag is published and kept by edited orders.
+//   - Edited orders are replaced by a new order.
+//   - Orders can be cancelled by user reference and with CancellAllOrders.
+func (suite *KrakenSpotPaperTradingClientTestSuite) TestValidateEditAndCancel() {
+	resp, err := suite.client.AddOrder(context.Background(), websocket.AddOrderRequestParameters{
+		OrderType: "iceberg", Type: "buy", Pair: "XBT/USD", Price: "1", Volume: "1",
+	})
+	require.Error(suite.T(), err)
+	require.ErrorAs(suite.T(), err, new(*websocket.OperationError))
+	require.Equal(suite.T(), string(messages.Err), resp.Status)
+	_, err = suite.client.AddOrder(context.Background(), websocket.AddOrderRequestParameters{
+		OrderType: "limit", Type: "buy", Pair: "XBT/USD", Price: "1", Volume: "1", StpType: "cancel-all",
+	})
+	require.ErrorAs(suite.T(), err, new(*websocket.OperationError))
+	expired := websocket.AddOrderRequestParameters{OrderType: "limit", Type: "buy", Pair: "XBT/USD", Price: "1", Volume: "1", Deadline: trading.FormatDeadline(time.Now().Add(-time.Minute))}
+	_, err = suite.client.AddOrder(context.Background(), expired)
+	require.ErrorContains(suite.T(), err, "invalid deadline")
+	resp, err = suite.client.AddOrder(context.Background(), websocket.AddOrderRequestParameters{
+		OrderType: "limit", Type: "buy", Pair: "XBT/USD", Price: "1", Volume: "1", Validate: true,
+	})
+	require.NoError(suite.T(), err)
+	require.Empty(suite.T(), resp.TxId)
+	require.Empty(suite.T(), suite.openOrders)
+	// Add two orders
+	first, err := suite.client.AddOrder(context.Background(), websocket.AddOrderRequestParameters{
+		OrderType: "limit", Type: "buy", Pair: "XBT/USD", Price: "1", Volume: "1", UserReference: "7", StpType: "cancel-both",
+	})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "cancel-both", suite.readOpenOrders().Orders[0][first.TxId].StpType)
+	second, err := suite.client.AddOrder(context.Background(), websocket.AddOrderRequestParameters{
+		OrderType: "limit", Type: "sell", Pair: "XBT/USD", Price: "1000", Volume: "1",
+	})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "cancel-newest", suite.readOpenOrders().Orders[0][second.TxId].StpType)
+	// Edit first order by user reference
+	edit, err := suite.client.EditOrder(context.Background(), websocket.EditOrderRequestParameters{
+		Id: "7", Pair: "XBT/USD", Price: "2",
+	})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), first.TxId, edit.OriginalTxId)
+	require.NotEqual(suite.T(), first.TxId, edit.TxId)
+	require.Equal(suite.T(), "buy 1 XBT/USD @ limit 2", edit.Description)
+	update := suite.readOpenOrders()
+	require.Len(suite.T(), update.Orders, 2)
+	require.Equal(suite.T(), string(messages.Canceled), update.Orders[0][first.TxId].Status)
+	require.Equal(suite.T(), string(messages.Open), update.Orders[1][edit.TxId].Status)
+	require.Equal(suite.T(), "cancel-both", update.Orders[1][edit.TxId].StpType)
+	// Cancel by user reference
+	_, err = suite.client.CancelOrder(context.Background(), websocket.CancelOrderRequestParameters{TxId: []string{"7"}})
+	require.NoError(suite.T(), err)
+	update = suite.readOpenOrders()
+	require.Equal(suite.T(), string(messages.Canceled), update.Orders[0][edit.TxId].Status)
+	// Cancel all
+	all, err := suite.client.CancellAllOrders(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, all.Count)
+	// Dead man's switch
+	dms, err := suite.client.CancellAllOrdersAfterX(context.Background(), websocket.CancelAllOrdersAfterXRequestParameters{Timeout: 60})
+	require.NoError(suite.T(), err)
+	require.NotEqual(suite.T(), dms.CurrentTime, dms.TriggerTime)
+	_, err = suite.client.CancellAllOrdersAfterX(context.Background(), websocket.CancelAllOrdersAfterXRequestParameters{Timeout: 0})
+	require.NoError(suite.T(), err)
+}
+
+// Test the dead man's switch cancels open orders once the timeout has expired.
+//
+// The test will ensure orders are not cancelled when the timer is renewed before it expires and are
+// cancelled once the clock reaches the trigger time.
+func (suite *KrakenSpotPaperTradingClientTestSuite) TestCancelAllOrdersAfterXWithClock() {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFakeClock(start)
+	suite.client.SetClock(fake)
+	_, err := suite.client.AddOrder(context.Background(), websocket.AddOrderRequestParameters{
+		OrderType: "limit", Type: "buy", Pair: "XBT/USD", Price: "30000", Volume: "1",
+	})
+	require.NoError(suite.T(), err)
+	suite.readOpenOrders()
+	dms, err := suite.client.CancellAllOrdersAfterX(context.Background(), websocket.CancelAllOrdersAfterXRequestParameters{Timeout: 60})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "2023-01-01T00:00:00Z", dms.CurrentTime)
+	require.Equal(suite.T(), "2023-01-01T00:01:00Z", dms.TriggerTime)
+	// Renew the timer before it expires
+	fake.Advance(59 * time.Second)
+	_, err = suite.client.CancellAllOrdersAfterX(context.Background(), websocket.CancelAllOrdersAfterXRequestParameters{Timeout: 60})
+	require.NoError(suite.T(), err)
+	fake.Advance(59 * time.Second)
+	require.Len(suite.T(), suite.openOrders, 0)
+	// Timer expires
+	fake.Advance(time.Second)
+	require.Eventually(suite.T(), func() bool { return len(suite.openOrders) > 0 }, time.Second, time.Millisecond)
+	cancelled := suite.readOpenOrders()
+	require.Len(suite.T(), cancelled.Orders, 1)
+	for _, order := range cancelled.Orders[0] {
+		require.Equal(suite.T(), string(messages.Canceled), order.Status)
+	}
+}
+
+// Test subscriptions management.
+//
+// The test will ensure subscribing twice fails, unsubscribe works and ownTrades snapshot contains
+// the trade history.
+func (suite *KrakenSpotPaperTradingClientTestSuite) TestSubscriptions() {
+	require.Error(suite.T(), suite.client.SubscribeOwnTrades(context.Background(), true, false, suite.ownTrades))
+	require.Error(suite.T(), suite.client.SubscribeOpenOrders(context.Background(), false, suite.openOrders))
+	suite.client.ProcessSpread("XBT/USD", spread("99", "101", "10", "10"))
+	_, err := suite.client.AddOrder(context.Background(), websocket.AddOrderRequestParameters{
+		OrderType: "market", Type: "buy", Pair: "XBT/USD", Volume: "1",
+	})
+	require.NoError(suite.T(), err)
+	suite.readOwnTrades()
+	require.NoError(suite.T(), suite.client.UnsubscribeOwnTrades(context.Background()))
+	require.Error(suite.T(), suite.client.UnsubscribeOwnTrades(context.Background()))
+	rcv := make(chan event.Event, 10)
+	require.NoError(suite.T(), suite.client.SubscribeOwnTrades(context.Background(), true, false, rcv))
+	snapshot := new(messages.OwnTrades)
+	e := <-rcv
+	require.Equal(suite.T(), string(events.OwnTrades), e.Type())
+	require.NoError(suite.T(), json.Unmarshal(e.Data(), snapshot))
+	require.Len(suite.T(), snapshot.Data, 1)
+	require.NoError(suite.T(), suite.client.UnsubscribeOpenOrders(context.Background()))
+	require.NoError(suite.T(), suite.client.Ping(context.Background()))
+	require.Error(suite.T(), suite.client.Start(context.Background(), []string{"XBT/USD"}))
+}
+
+// Test channels are closed on unsubscribe unless the client is configured to keep them open.
+func (suite *KrakenSpotPaperTradingClientTestSuite) TestUnsubscribeChannelLifecycle() {
+	// Default: channels are closed
+	require.NoError(suite.T(), suite.client.UnsubscribeOwnTrades(context.Background()))
+	for range suite.ownTrades {
+	}
+	// Keep channels open
+	suite.client.SetKeepChannelsOpenOnUnsubscribe(true)
+	require.NoError(suite.T(), suite.client.UnsubscribeOpenOrders(context.Background()))
+	select {
+	case _, ok := <-suite.openOrders:
+		require.True(suite.T(), ok, "channel must not be closed")
+	default:
+	}
+	// Channel can be reused for a new subscription
+	require.NoError(suite.T(), suite.client.SubscribeOpenOrders(context.Background(), false, suite.openOrders))
+	e := <-suite.openOrders
+	require.Equal(suite.T(), string(events.OpenOrders), e.Type())
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Read and parse the next open orders event.
+func (suite *KrakenSpotPaperTradingClientTestSuite) readOpenOrders() *messages.OpenOrders {
+	require.NotEmpty(suite.T(), suite.openOrders)
+	e := <-suite.openOrders
+	require.Equal(suite.T(), string(events.OpenOrders), e.Type())
+	msg := new(messages.OpenOrders)
+	require.NoError(suite.T(), json.Unmarshal(e.Data(), msg))
+	return msg
+}
+
+// Read and parse the next own trades event.
+func (suite *KrakenSpotPaperTradingClientTestSuite) readOwnTrades() *messages.OwnTrades {
+	require.NotEmpty(suite.T(), suite.ownTrades)
+	e := <-suite.ownTrades
+	require.Equal(suite.T(), string(events.OwnTrades), e.Type())
+	msg := new(messages.OwnTrades)
+	require.NoError(suite.T(), json.Unmarshal(e.Data(), msg))
+	return msg
+}
+
+// Build spread data from the provided best bid/ask prices and volumes.
+func spread(bid string, ask string, bidVol string, askVol string) messages.SpreadData {
+	return messages.SpreadData{
+		BestBidPrice:  json.Number(bid),
+		BestAskPrice:  json.Number(ask),
+		Timestamp:     json.Number("1700000000.000000"),
+		BestBidVolume: json.Number(bidVol),
+		BestAskVolume: json.Number(askVol),
+	}
+}
+
+// Get the first key of a map with a single key.
+func firstKey[T any](m map[string]T) string {
+	for k := range m {
+		return k
+	}
+	return ""
+}
@@ -1,7 +1,11 @@
 package websocket
 
 import (
+	"context"
+
 	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Container for pending websocket requests.
@@ -24,82 +28,152 @@ type pendingRequests struct {
 	pendingCancelAllOrdersAfterXRequests map[int64]*pendingCancelAllOrdersAfterXRequest
 }
 
-// Data of a pending Ping request which contains channels whch can be used to provide the
+// Data of a pending Ping request which contains the future used to provide the
 // request results.
 type pendingPing struct {
-	// Channel to use to push the received response to requester.
-	resp chan *messages.Pong
-	// Channel used to push errors to requester.
-	err chan error
+	// Generation of the session during which the request has been sent (Cf. sessionTracker)
+	session uint64
+	// Span context of the request. Used to relate the response handling to the request span.
+	span trace.SpanContext
+	// Request tags (Cf. requesttag package). Added to the span of the response handling.
+	tags []attribute.KeyValue
+	// Future resolved with the received response or with an error.
+	result *Future[*messages.Pong]
 }
 
-// Data of a pending Subscribe request which contains channels whch can be used to provide the
+// Data of a pending Subscribe request which contains the future used to provide the
 // request results.
 type pendingSubscribe struct {
+	// Generation of the session during which the request has been sent (Cf. sessionTracker)
+	session uint64
+	// Span context of the request. Used to relate the response handling to the request span.
+	span trace.SpanContext
+	// Request tags (Cf. requesttag package). Added to the span of the response handling.
+	tags []attribute.KeyValue
 	// Request pairs
 	pairs []string
 	// Map which tracks whether a response has been received for the given pair
 	served map[string]bool
 	// Map which records error messages received when some pairs could not be subscribed to
 	errPerPair map[string]error
-	// Channel used to push errors to requester.
-	err chan error
+	// Future resolved once a response has been received for each requested pair, or with an error.
+	result *Future[struct{}]
 }
 
-// Data of a pending Unsubscribe request which contains channels whch can be used to provide the
+// Data of a pending Unsubscribe request which contains the future used to provide the
 // request results.
 type pendingUnsubscribe struct {
+	// Generation of the session during which the request has been sent (Cf. sessionTracker)
+	session uint64
+	// Span context of the request. Used to relate the response handling to the request span.
+	span trace.SpanContext
+	// Request tags (Cf. requesttag package). Added to the span of the response handling.
+	tags []attribute.KeyValue
 	// Request pairs
 	pairs []string
 	// Map which tracks whether a response has been received for the given pair
 	served map[string]bool
 	// Map which records error messages received when some pairs could not be subscribed to
 	errPerPair map[string]error
-	// Channel used to push errors to requester.
-	err chan error
+	// Future resolved once a response has been received for each requested pair, or with an error.
+	result *Future[struct{}]
 }
 
-// Data of a pending AddOrder request which contains channels whch can be used to provide the
+// Data of a pending AddOrder request which contains the future used to provide the
 // request results.
 type pendingAddOrderRequest struct {
-	// Channel to use to push the received response to requester.
-	resp chan *messages.AddOrderResponse
-	// Channel used to push errors to requester.
-	err chan error
+	// Generation of the session during which the request has been sent (Cf. sessionTracker)
+	session uint64
+	// Span context of the request. Used to relate the response handling to the request span.
+	span trace.SpanContext
+	// Request tags (Cf. requesttag package). Added to the span of the response handling.
+	tags []attribute.KeyValue
+	// Future resolved with the received response or with an error.
+	result *Future[*messages.AddOrderResponse]
 }
 
-// Data of a pending EditOrder request which contains channels whch can be used to provide the
+// Data of a pending EditOrder request which contains the future used to provide the
 // request results.
 type pendingEditOrderRequest struct {
-	// Channel to use to push the received response to requester.
-	resp chan *messages.EditOrderResponse
-	// Channel used to push errors to requester.
-	err chan error
+	// Generation of the session during which the request has been sent (Cf. sessionTracker)
+	session uint64
+	// Span context of the request. Used to relate the response handling to the request span.
+	span trace.SpanContext
+	// Request tags (Cf. requesttag package). Added to the span of the response handling.
+	tags []attribute.KeyValue
+	// Future resolved with the received response or with an error.
+	result *Future[*messages.EditOrderResponse]
 }
 
-// Data of a pending CancelOrder request which contains channels whch can be used to provide the
+// Data of a pending CancelOrder request which contains the future used to provide the
 // request results.
 type pendingCancelOrderRequest struct {
-	// Channel to use to push the received response to requester.
-	resp chan *messages.CancelOrderResponse
-	// Channel used to push errors to requester.
-	err chan error
+	// Generation of the session during which the request has been sent (Cf. sessionTracker)
+	session uint64
+	// Span context of the request. Used to relate the response handling to the request span.
+	span trace.SpanContext
+	// Request tags (Cf. requesttag package). Added to the span of the response handling.
+	tags []attribute.KeyValue
+	// Future resolved with the received response or with an error.
+	result *Future[*messages.CancelOrderResponse]
 }
 
-// Data of a pending CancelAllOrders request which contains channels whch can be used to provide the
+// Data of a pending CancelAllOrders request which contains the future used to provide the
 // request results.
 type pendingCancelAllOrdersRequest struct {
-	// Channel to use to push the received response to requester.
-	resp chan *messages.CancelAllOrdersResponse
-	// Channel used to push errors to requester.
-	err chan error
+	// Generation of the session during which the request has been sent (Cf. sessionTracker)
+	session uint64
+	// Span context of the request. Used to relate the response handling to the request span.
+	span trace.SpanContext
+	// Request tags (Cf. requesttag package). Added to the span of the response handling.
+	tags []attribute.KeyValue
+	// Future resolved with the received response or with an error.
+	result *Future[*messages.CancelAllOrdersResponse]
 }
 
-// Data of a pending CancelAllOrdersAfterX request which contains channels whch can be used to provide the
+// Data of a pending CancelAllOrdersAfterX request which contains the future used to provide the
 // request results.
 type pendingCancelAllOrdersAfterXRequest struct {
-	// Channel to use to push the received response to requester.
-	resp chan *messages.CancelAllOrdersAfterXResponse
-	// Channel used to push errors to requester.
-	err chan error
+	// Generation of the session during which the request has been sent (Cf. sessionTracker)
+	session uint64
+	// Span context of the request. Used to relate the response handling to the request span.
+	span trace.SpanContext
+	// Request tags (Cf. requesttag package). Added to the span of the response handling.
+	tags []attribute.KeyValue
+	// Future resolved with the received response or with an error.
+	result *Future[*messages.CancelAllOrdersAfterXResponse]
+}
+
+// # Description
+//
+// Start a span which relates the handling of a response to the span of the request it answers.
+// The span is a child of the request span so response handling shows up in the request trace
+// and it is linked to the span of the response handler. The span must be ended once the pending
+// request has been fulfilled.
+//
+// # Inputs
+//
+//   - ctx: Context of the response handler.
+//   - name: Name of the span.
+//   - request: Span context of the request. If invalid, the span is a child of the handler span.
+//   - requestId: ID of the request.
+//   - tags: Attributes which record the tags of the request. Added to the span.
+//
+// # Return
+//
+// The started span.
+func (client *krakenSpotWebsocketClient) startResponseSpan(ctx context.Context, name string, request trace.SpanContext, requestId int64, tags []attribute.KeyValue) trace.Span {
+	if !request.IsValid() {
+		_, span := client.tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindInternal),
+			trace.WithAttributes(attribute.Int64("request_id", requestId)),
+			trace.WithAttributes(tags...))
+		return span
+	}
+	_, span := client.tracer.Start(trace.ContextWithSpanContext(ctx, request), name,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithLinks(trace.LinkFromContext(ctx)),
+		trace.WithAttributes(attribute.Int64("request_id", requestId)),
+		trace.WithAttributes(tags...))
+	return span
 }
@@ -0,0 +1,89 @@
+package websocket
+
+import (
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+)
+
+// Key of the WithPerPairChannels map entry used for events of pairs which do not have a dedicated
+// channel and for events which are not related to a pair.
+const OtherPairsChannel = "*"
+
+// Capacity of the channel returned by WithPerPairChannels.
+const perPairChannelCapacity = 100
+
+// # Description
+//
+// Build a channel which can be provided to any Subscribe* method and which routes the published
+// events to a dedicated channel for each pair. This avoids a downstream demux step when different
+// goroutines handle different markets:
+//
+//	xbt, eth := make(chan event.Event, 10), make(chan event.Event, 10)
+//	err := client.SubscribeTicker(ctx, []string{"XBT/USD", "ETH/USD"}, websocket.WithPerPairChannels(
+//		map[string]chan event.Event{"XBT/USD": xbt, "ETH/USD": eth}))
+//
+// Events are routed by their subject, which is set to the pair for market data events:
+//
+//   - Events of a pair are published on the channel of the pair. If the pair has no channel, they
+//     are published on the OtherPairsChannel entry, if any, or discarded.
+//   - Events which are not related to a pair (own trades, open orders, ...) are published on the
+//     OtherPairsChannel entry, if any, or discarded.
+//   - connection_interrupted and resubscribe_failed events are published on all channels.
+//
+// The provided channels are closed when the subscription channel is closed (Cf.
+// SetKeepChannelsOpenOnUnsubscribe). A channel can be used for several pairs: it is closed once.
+//
+// # Inputs
+//
+//   - channels: Channels by pair. The map is copied: later changes are ignored. Blocking writes
+//     are used: a slow consumer blocks the delivery of events to the other pairs.
+//
+// # Return
+//
+// The channel to provide to the Subscribe* method.
+func WithPerPairChannels(channels map[string]chan event.Event) chan event.Event {
+	routes := make(map[string]chan event.Event, len(channels))
+	for pair, ch := range channels {
+		if ch != nil {
+			routes[pair] = ch
+		}
+	}
+	in := make(chan event.Event, perPairChannelCapacity)
+	go routePerPair(in, routes)
+	return in
+}
+
+// Route the events from the input channel to the per pair channels until the input channel is
+// closed. Closes the per pair channels once done.
+func routePerPair(in chan event.Event, routes map[string]chan event.Event) {
+	// Distinct output channels - A channel can be used for several pairs
+	outs := []chan event.Event{}
+	seen := map[chan event.Event]bool{}
+	for _, ch := range routes {
+		if !seen[ch] {
+			seen[ch] = true
+			outs = append(outs, ch)
+		}
+	}
+	defer func() {
+		for _, ch := range outs {
+			close(ch)
+		}
+	}()
+	for e := range in {
+		switch e.Type() {
+		case string(events.ConnectionInterrupted), string(events.ResubscribeFailed):
+			for _, ch := range outs {
+				ch <- e
+			}
+			continue
+		}
+		ch, ok := routes[e.Subject()]
+		if !ok || e.Subject() == "" {
+			ch, ok = routes[OtherPairsChannel]
+		}
+		if ok {
+			ch <- e
+		}
+	}
+}
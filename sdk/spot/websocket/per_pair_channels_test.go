@@ -0,0 +1,91 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for WithPerPairChannels
+type PerPairChannelsUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestPerPairChannelsUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(PerPairChannelsUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test events are routed to the channel of their pair.
+//
+// Test will ensure:
+//   - Events are routed by subject.
+//   - Events of pairs without a channel and events without pair go to OtherPairsChannel.
+//   - connection_interrupted events are published on all channels, once per channel.
+//   - Channels are closed once when the subscription channel is closed.
+func (suite *PerPairChannelsUnitTestSuite) TestRouting() {
+	xbt := make(chan event.Event, 10)
+	eth := make(chan event.Event, 10)
+	other := make(chan event.Event, 10)
+	rcv := WithPerPairChannels(map[string]chan event.Event{
+		"XBT/USD":         xbt,
+		"XBT/EUR":         xbt,
+		"ETH/USD":         eth,
+		OtherPairsChannel: other,
+		"DOT/USD":         nil,
+	})
+	rcv <- newPairEvent(events.Ticker, "XBT/USD")
+	rcv <- newPairEvent(events.Ticker, "ETH/USD")
+	rcv <- newPairEvent(events.Ticker, "XBT/EUR")
+	rcv <- newPairEvent(events.Ticker, "ADA/USD")
+	rcv <- newPairEvent(events.OwnTrades, "")
+	rcv <- newPairEvent(events.ConnectionInterrupted, "")
+	close(rcv)
+	require.Equal(suite.T(), []string{"ticker:XBT/USD", "ticker:XBT/EUR", "connection_interrupted:"}, drain(xbt))
+	require.Equal(suite.T(), []string{"ticker:ETH/USD", "connection_interrupted:"}, drain(eth))
+	require.Equal(suite.T(), []string{"ticker:ADA/USD", "own_trades:", "connection_interrupted:"}, drain(other))
+}
+
+// Test events of pairs without a channel are discarded when there is no OtherPairsChannel entry.
+func (suite *PerPairChannelsUnitTestSuite) TestDiscard() {
+	xbt := make(chan event.Event, 10)
+	rcv := WithPerPairChannels(map[string]chan event.Event{"XBT/USD": xbt})
+	rcv <- newPairEvent(events.Trade, "ETH/USD")
+	rcv <- newPairEvent(events.Trade, "XBT/USD")
+	close(rcv)
+	require.Equal(suite.T(), []string{"trade:XBT/USD"}, drain(xbt))
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build an event with the provided type and subject.
+func newPairEvent(etype events.WebsocketClientEventTypeEnum, pair string) event.Event {
+	e := event.New()
+	e.SetType(string(etype))
+	if pair != "" {
+		e.SetSubject(pair)
+	}
+	return e
+}
+
+// Read the channel until it is closed and return the type:subject of the received events.
+func drain(ch chan event.Event) []string {
+	received := []string{}
+	for e := range ch {
+		received = append(received, e.Type()+":"+e.Subject())
+	}
+	return received
+}
@@ -0,0 +1,157 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+
+	otelObs "github.com/cloudevents/sdk-go/observability/opentelemetry/v2/client"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Enum for the behaviors of the client when a binary message is received from the server.
+type BinaryMessagePolicyEnum string
+
+// Values for BinaryMessagePolicyEnum
+const (
+	// Process binary messages like text messages: the payload is expected to be JSON. This is the
+	// default behavior.
+	BinaryMessageAsText BinaryMessagePolicyEnum = "text"
+	// Handle binary messages as protocol violations (Cf. SetStrictMode).
+	BinaryMessageViolation BinaryMessagePolicyEnum = "violation"
+)
+
+// Data of unknown_message events: a message received from the server which violates the protocol
+// (unexpected binary message, message type which could not be extracted or which is unknown).
+type UnknownMessage struct {
+	// ID of the engine session during which the message has been received.
+	SessionId string `json:"session_id"`
+	// True if the message has been received as a binary message.
+	Binary bool `json:"binary"`
+	// Why the message violates the protocol.
+	Reason string `json:"reason"`
+	// Received message.
+	Payload []byte `json:"payload"`
+}
+
+// # Description
+//
+// Set whether messages which violate the protocol (unexpected binary messages, messages whose type
+// could not be extracted or is unknown) are read errors.
+//
+// In strict mode, which is the default behavior, OnReadError is called for each of these
+// messages. Otherwise, messages are counted (Cf. GetUnknownMessagesCount) and dropped. In both
+// modes, an unknown_message event is published on the channel provided by
+// GetUnknownMessageChannel.
+//
+// # Inputs
+//
+//   - strict: True to call OnReadError for messages which violate the protocol.
+func (client *krakenSpotWebsocketClient) SetStrictMode(strict bool) {
+	client.lenientProtocol.Store(!strict)
+}
+
+// # Description
+//
+// Set the behavior of the client when a binary message is received from the server.
+//
+// # Inputs
+//
+//   - policy: Behavior of the client. An empty value resets the default behavior (BinaryMessageAsText).
+func (client *krakenSpotWebsocketClient) SetBinaryMessagePolicy(policy BinaryMessagePolicyEnum) {
+	if policy == "" {
+		policy = BinaryMessageAsText
+	}
+	client.binaryMessagePolicy.Store(&policy)
+}
+
+// # Description
+//
+// Get the total number of messages received from the server which violate the protocol, whatever
+// the strict mode.
+//
+// # Return
+//
+// The total number of messages which violate the protocol.
+func (client *krakenSpotWebsocketClient) GetUnknownMessagesCount() uint64 {
+	return client.unknownMessages.Load()
+}
+
+// # Description
+//
+// Get the client's built-in channel used to publish messages received from the server which
+// violate the protocol. The channel is meant for diagnostics.
+//
+// # Event types
+//
+// Only these types of events will be published on the channel (Cf. WebsocketClientEventTypeEnum):
+//
+//   - unknown_message: Data is an UnknownMessage.
+//
+// # Implemetation and usage guidelines
+//
+//   - The channel is provided even though the client has not been started yet.
+//
+//   - As the channel is automatically subscribed to, overflowing messages are discarded in FIFO
+//     order unless another congestion policy is set (Cf. SetCongestionPolicy).
+//
+// # Return
+//
+// The client's built-in channel used to publish unknown messages.
+func (client *krakenSpotWebsocketClient) GetUnknownMessageChannel() chan event.Event {
+	return client.subscriptions.unknownMessages
+}
+
+// Tell whether binary messages must be handled as protocol violations.
+func (client *krakenSpotWebsocketClient) binaryMessagesAreViolations() bool {
+	policy := client.binaryMessagePolicy.Load()
+	return policy != nil && *policy == BinaryMessageViolation
+}
+
+// This method contains the logic to handle a message which violates the protocol: the message is
+// counted and published as an unknown_message event. In strict mode, OnReadError is then called
+// with the provided error. Otherwise, the message is dropped.
+func (client *krakenSpotWebsocketClient) handleProtocolViolation(
+	ctx context.Context,
+	span trace.Span,
+	conn wsadapters.WebsocketConnectionAdapterInterface,
+	readMutex *sync.Mutex,
+	restart context.CancelFunc,
+	exit context.CancelFunc,
+	sessionId string,
+	msgType wsadapters.MessageType,
+	msg []byte,
+	err error) {
+	count := client.unknownMessages.Add(1)
+	binary := msgType == wsadapters.Binary
+	span.AddEvent("protocol_violation", trace.WithAttributes(
+		attribute.Bool("binary", binary),
+		attribute.String("reason", err.Error()),
+	))
+	// Publish the message - discard messages according to the congestion policy as for general errors
+	event := event.New()
+	event.Context.SetType(string(events.UnknownMessage))
+	event.Context.SetSource(tracing.PackageName)
+	event.SetData("application/json", UnknownMessage{
+		SessionId: sessionId,
+		Binary:    binary,
+		Reason:    err.Error(),
+		Payload:   append([]byte(nil), msg...),
+	})
+	otelObs.InjectDistributedTracingExtension(ctx, event)
+	client.bus.Publish(TopicUnknownMessage, event)
+	client.publishBuiltin(ctx, client.subscriptions.unknownMessages, event, events.UnknownMessage)
+	if !client.lenientProtocol.Load() {
+		// Call OnReadError - strict mode
+		tracing.HandleAndTraLogError(span, client.logger, err)
+		client.OnReadError(ctx, conn, readMutex, restart, exit, err)
+		return
+	}
+	client.logger.Printf("dropping a message which violates the protocol (total: %d): %s\n", count, err.Error())
+	span.SetStatus(codes.Ok, codes.Ok.String())
+}
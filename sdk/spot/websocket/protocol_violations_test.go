@@ -0,0 +1,117 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/fixtures"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for protocol violations handling
+type ProtocolViolationsUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestProtocolViolationsUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(ProtocolViolationsUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test unknown messages in strict and lenient modes.
+//
+// Test will ensure:
+//   - Unknown messages and messages whose type cannot be extracted are read errors by default.
+//   - Unknown messages are counted and dropped once strict mode is disabled.
+//   - Unknown messages are published as unknown_message events in both modes.
+func (suite *ProtocolViolationsUnitTestSuite) TestStrictMode() {
+	errs := []error{}
+	client := newKrakenSpotWebsocketClient(nil, nil, func(ctx context.Context, restart, exit context.CancelFunc, err error) {
+		errs = append(errs, err)
+	}, nil, nil, nil)
+	unknown := []byte(`{"event":"newEvent"}`)
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "s1", wsadapters.Text, unknown)
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "s1", wsadapters.Text, []byte(`not json`))
+	require.Len(suite.T(), errs, 2)
+	require.Equal(suite.T(), uint64(2), client.GetUnknownMessagesCount())
+	// Check the published event
+	evt := <-client.GetUnknownMessageChannel()
+	require.Equal(suite.T(), string(events.UnknownMessage), evt.Type())
+	data := new(UnknownMessage)
+	require.NoError(suite.T(), evt.DataAs(data))
+	require.Equal(suite.T(), "s1", data.SessionId)
+	require.False(suite.T(), data.Binary)
+	require.Equal(suite.T(), unknown, data.Payload)
+	require.Contains(suite.T(), data.Reason, "newEvent")
+	<-client.GetUnknownMessageChannel()
+	// Lenient mode
+	client.SetStrictMode(false)
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "s1", wsadapters.Text, unknown)
+	require.Len(suite.T(), errs, 2)
+	require.Equal(suite.T(), uint64(3), client.GetUnknownMessagesCount())
+	require.Len(suite.T(), client.GetUnknownMessageChannel(), 1)
+	// Strict mode again
+	client.SetStrictMode(true)
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "s1", wsadapters.Text, unknown)
+	require.Len(suite.T(), errs, 3)
+}
+
+// Test binary messages handling.
+//
+// Test will ensure:
+//   - Binary messages are processed as text by default.
+//   - Binary messages are protocol violations with BinaryMessageViolation, even if they are valid.
+//   - Binary violations bypass raw subscriptions.
+func (suite *ProtocolViolationsUnitTestSuite) TestBinaryMessages() {
+	errs := []error{}
+	client := newKrakenSpotWebsocketClient(nil, nil, func(ctx context.Context, restart, exit context.CancelFunc, err error) {
+		errs = append(errs, err)
+	}, nil, nil, nil)
+	heartbeat := []byte(fixtures.WebsocketHeartbeat)
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "s1", wsadapters.Binary, heartbeat)
+	require.Len(suite.T(), client.GetHeartbeatChannel(), 1)
+	require.Empty(suite.T(), errs)
+	// Binary messages are violations
+	received := 0
+	client.rawTrade.Store(newRawSubscription([]string{"XBT/USD"}, func(pair string, payload []byte) { received++ }))
+	client.SetBinaryMessagePolicy(BinaryMessageViolation)
+	client.SetStrictMode(false)
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "s1", wsadapters.Binary, heartbeat)
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "s1", wsadapters.Binary, []byte(fixtures.WebsocketTrade))
+	require.Len(suite.T(), client.GetHeartbeatChannel(), 1)
+	require.Zero(suite.T(), received)
+	require.Empty(suite.T(), errs)
+	require.Equal(suite.T(), uint64(2), client.GetUnknownMessagesCount())
+	data := new(UnknownMessage)
+	require.NoError(suite.T(), (<-client.GetUnknownMessageChannel()).DataAs(data))
+	require.True(suite.T(), data.Binary)
+	// Text messages are processed
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "s1", wsadapters.Text, []byte(fixtures.WebsocketTrade))
+	require.Equal(suite.T(), 1, received)
+	// Default policy
+	client.SetBinaryMessagePolicy("")
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "s1", wsadapters.Binary, heartbeat)
+	require.Len(suite.T(), client.GetHeartbeatChannel(), 2)
+}
+
+// Test oldest unknown_message events are discarded when the channel is full.
+func (suite *ProtocolViolationsUnitTestSuite) TestUnknownMessageChannelOverflow() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	client.SetStrictMode(false)
+	for i := 0; i <= cap(client.GetUnknownMessageChannel()); i++ {
+		client.OnMessage(context.Background(), nil, nil, nil, nil, "s1", wsadapters.Text, []byte(`{"event":"newEvent"}`))
+	}
+	require.Len(suite.T(), client.GetUnknownMessageChannel(), cap(client.GetUnknownMessageChannel()))
+	require.Equal(suite.T(), uint64(1), client.GetDroppedMessagesCount(events.UnknownMessage))
+}
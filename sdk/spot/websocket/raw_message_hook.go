@@ -0,0 +1,145 @@
+package websocket
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Default capacity of the queue of frames waiting to be passed to the raw message hook.
+const DefaultRawMessageHookQueueSize = 1024
+
+// Enum for the directions of the frames passed to the raw message hook.
+type FrameDirectionEnum string
+
+// Values for FrameDirectionEnum
+const (
+	// Frame received from the server.
+	FrameInbound FrameDirectionEnum = "inbound"
+	// Frame sent to the server.
+	FrameOutbound FrameDirectionEnum = "outbound"
+)
+
+// A websocket frame passed to the raw message hook.
+type RawFrame struct {
+	// Direction of the frame
+	Direction FrameDirectionEnum
+	// ID of the engine session during which the frame has been received or sent. Outbound frames
+	// sent before the first message of a session is received have an empty session ID.
+	SessionId string
+	// Time at which the frame has been received or sent
+	Timestamp time.Time
+	// Payload of the frame. The payload is a copy owned by the hook.
+	Payload []byte
+}
+
+// Hook which passes frames to a user callback from a dedicated goroutine.
+type rawMessageHook struct {
+	// User callback
+	callback func(frame RawFrame)
+	// Bounded queue of frames waiting for the callback
+	queue chan RawFrame
+	// Channel closed to stop the hook goroutine
+	stop chan struct{}
+	// Number of frames discarded because the queue was full
+	dropped atomic.Uint64
+}
+
+// Pass queued frames to the callback until the hook is stopped. Frames still queued when the hook
+// is stopped are passed to the callback before the goroutine exits.
+func (hook *rawMessageHook) run() {
+	for {
+		select {
+		case frame := <-hook.queue:
+			hook.callback(frame)
+		case <-hook.stop:
+			for {
+				select {
+				case frame := <-hook.queue:
+					hook.callback(frame)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// # Description
+//
+// Set a hook which is called with every frame received from or sent to the server, before the
+// frame is processed by the client (inbound) or written to the connection (outbound). The hook
+// can be used for compliance logging or debugging.
+//
+// Frames are queued in a bounded queue and passed to the callback, in order, by a dedicated
+// goroutine: a slow callback can never block the goroutine which reads messages from the server
+// nor the writes. Frames are discarded and counted (Cf. GetDroppedRawFramesCount) when the queue
+// is full.
+//
+// Setting a new hook stops the previous one once its queued frames have been passed to its
+// callback.
+//
+// # Inputs
+//
+//   - callback: Callback called with each frame. A nil value removes the hook.
+//   - queueSize: Capacity of the queue. A zero or negative value uses DefaultRawMessageHookQueueSize.
+func (client *krakenSpotWebsocketClient) SetOnRawMessageCallback(callback func(frame RawFrame), queueSize int) {
+	var hook *rawMessageHook
+	if callback != nil {
+		if queueSize <= 0 {
+			queueSize = DefaultRawMessageHookQueueSize
+		}
+		hook = &rawMessageHook{
+			callback: callback,
+			queue:    make(chan RawFrame, queueSize),
+			stop:     make(chan struct{}),
+		}
+		go hook.run()
+	}
+	if previous := client.rawMessageHook.Swap(hook); previous != nil {
+		client.droppedRawFrames.Add(previous.dropped.Load())
+		close(previous.stop)
+	}
+}
+
+// # Description
+//
+// Get the total number of frames which have not been passed to the raw message hook because its
+// queue was full.
+//
+// # Return
+//
+// The total number of discarded frames.
+func (client *krakenSpotWebsocketClient) GetDroppedRawFramesCount() uint64 {
+	count := client.droppedRawFrames.Load()
+	if hook := client.rawMessageHook.Load(); hook != nil {
+		count += hook.dropped.Load()
+	}
+	return count
+}
+
+// Queue a frame for the raw message hook, if any. The payload is copied. The frame is discarded
+// if the queue is full. The session ID of outbound frames is the engine session ID bound to the
+// current session: the provided session ID is ignored.
+func (client *krakenSpotWebsocketClient) hookRawFrame(direction FrameDirectionEnum, sessionId string, payload []byte) {
+	hook := client.rawMessageHook.Load()
+	if hook == nil {
+		return
+	}
+	if direction == FrameOutbound {
+		sessionId = client.sessions.currentId()
+	} else {
+		// Bind the engine session ID to the current session so outbound frames get it
+		client.sessions.resolve(sessionId)
+	}
+	frame := RawFrame{
+		Direction: direction,
+		SessionId: sessionId,
+		Timestamp: client.clock.Now(),
+		Payload:   append([]byte(nil), payload...),
+	}
+	select {
+	case hook.queue <- frame:
+	default:
+		hook.dropped.Add(1)
+	}
+}
@@ -0,0 +1,107 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the raw message hook
+type RawMessageHookUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestRawMessageHookUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(RawMessageHookUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test inbound and outbound frames are passed to the hook.
+//
+// Test will ensure:
+//   - Inbound frames are passed with the engine session ID before they are processed.
+//   - Outbound frames are passed with the session ID of the current session.
+//   - Payloads are copies.
+//   - No frame is passed once the hook is removed.
+func (suite *RawMessageHookUnitTestSuite) TestFrames() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	conn := newConnectionMock()
+	conn.On("Write", mock.Anything, wsadapters.Text, mock.Anything).Return(nil)
+	client.conn = conn
+	client.sessions.open()
+	frames := make(chan RawFrame, 10)
+	client.SetOnRawMessageCallback(func(frame RawFrame) { frames <- frame }, 0)
+	// Inbound
+	msg := []byte(`{"event":"heartbeat"}`)
+	client.OnMessage(context.Background(), conn, &sync.Mutex{}, func() {}, func() {}, "s1", wsadapters.Text, msg)
+	msg[0] = 'X'
+	frame := <-frames
+	require.Equal(suite.T(), FrameInbound, frame.Direction)
+	require.Equal(suite.T(), "s1", frame.SessionId)
+	require.Equal(suite.T(), `{"event":"heartbeat"}`, string(frame.Payload))
+	require.False(suite.T(), frame.Timestamp.IsZero())
+	// Outbound
+	require.NoError(suite.T(), client.write(context.Background(), []byte(`{"event":"ping"}`)))
+	frame = <-frames
+	require.Equal(suite.T(), FrameOutbound, frame.Direction)
+	require.Equal(suite.T(), "s1", frame.SessionId)
+	require.Equal(suite.T(), `{"event":"ping"}`, string(frame.Payload))
+	// Removed hook
+	client.SetOnRawMessageCallback(nil, 0)
+	require.NoError(suite.T(), client.write(context.Background(), []byte(`{"event":"ping"}`)))
+	require.Never(suite.T(), func() bool { return len(frames) > 0 }, 50*time.Millisecond, time.Millisecond)
+	client.stopConnectionWriter()
+}
+
+// Test a slow hook cannot block the read loop.
+//
+// Test will ensure:
+//   - OnMessage returns while the callback is blocked.
+//   - Frames are discarded and counted once the queue is full.
+//   - Queued frames are passed to the previous callback when a new hook is set.
+func (suite *RawMessageHookUnitTestSuite) TestSlowHook() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	conn := newConnectionMock()
+	unblock := make(chan struct{})
+	received := make(chan RawFrame, 10)
+	client.SetOnRawMessageCallback(func(frame RawFrame) {
+		<-unblock
+		received <- frame
+	}, 2)
+	heartbeat := []byte(`{"event":"heartbeat"}`)
+	client.OnMessage(context.Background(), conn, &sync.Mutex{}, func() {}, func() {}, "s1", wsadapters.Text, heartbeat)
+	hook := client.rawMessageHook.Load()
+	require.Eventually(suite.T(), func() bool { return len(hook.queue) == 0 }, time.Second, time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 4; i++ {
+			client.OnMessage(context.Background(), conn, &sync.Mutex{}, func() {}, func() {}, "s1", wsadapters.Text, heartbeat)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		suite.FailNow("OnMessage has been blocked by the hook")
+	}
+	// One frame is held by the callback and two are queued
+	require.Equal(suite.T(), uint64(2), client.GetDroppedRawFramesCount())
+	client.SetOnRawMessageCallback(func(frame RawFrame) {}, 0)
+	close(unblock)
+	require.Eventually(suite.T(), func() bool { return len(received) == 3 }, time.Second, time.Millisecond)
+	require.Equal(suite.T(), uint64(2), client.GetDroppedRawFramesCount())
+}
@@ -0,0 +1,267 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// # Description
+//
+// Callback used by raw subscriptions to dispatch public market data messages.
+//
+// # Inputs
+//
+//   - pair: Pair of the message (ex: XBT/USD).
+//   - payload: Raw JSON payload of the message as received from the server.
+//
+// # Implementation and usage guidelines
+//
+//   - The callback is called from the goroutine which reads messages from the server: it must
+//     return quickly. A slow callback delays the processing of all messages.
+//
+//   - The payload is the message read from the server. It is not copied: the payload must not be
+//     modified.
+type RawMessageCallback func(pair string, payload []byte)
+
+// Data of a raw subscription.
+type rawSubscription struct {
+	// Callback used to dispatch messages
+	callback RawMessageCallback
+	// Subscribed pairs by pair name. Used to get the pair string without allocating.
+	pairs map[string]string
+}
+
+// Create a new raw subscription.
+func newRawSubscription(pairs []string, callback RawMessageCallback) *rawSubscription {
+	sub := &rawSubscription{
+		callback: callback,
+		pairs:    make(map[string]string, len(pairs)),
+	}
+	for _, pair := range pairs {
+		sub.pairs[pair] = pair
+	}
+	return sub
+}
+
+// # Description
+//
+// Subscribe to the trade channel and dispatch raw trade messages through the provided callback.
+//
+// This is a low-latency alternative to SubscribeTrade: messages bypass the regex based message
+// routing, tracing, CloudEvents and channels. Messages are dispatched synchronously from the
+// goroutine which reads messages from the server. The payload can be parsed into a structure of
+// type messages.Trade.
+//
+// No connection_interrupted event is dispatched: use the onCloseCallback of the client to
+// detect connection interruptions. The client resubscribes to the channel after a reconnection
+// and keeps dispatching messages through the provided callback.
+//
+// Use UnsubscribeTrade to unsubscribe. Only one trade subscription (raw or not) can be active.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - pairs: Pairs to subscribe to.
+//   - callback: Callback used to dispatch raw trade messages. Cf. RawMessageCallback.
+//
+// # Return
+//
+// An error if the callback is nil or if the subscription fails (Cf. SubscribeTrade).
+func (client *KrakenSpotPublicWebsocketClient) SubscribeTradeRaw(ctx context.Context, pairs []string, callback RawMessageCallback) error {
+	if callback == nil {
+		return fmt.Errorf("subscribe trade raw failed: a callback must be provided")
+	}
+	return client.subscribeTrade(ctx, pairs, nil, callback, false)
+}
+
+// # Description
+//
+// Subscribe to the spread channel and dispatch raw spread messages through the provided
+// callback. The payload can be parsed into a structure of type messages.Spread.
+//
+// See SubscribeTradeRaw for details about raw subscriptions.
+//
+// Use UnsubscribeSpread to unsubscribe. Only one spread subscription (raw or not) can be active.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - pairs: Pairs to subscribe to.
+//   - callback: Callback used to dispatch raw spread messages. Cf. RawMessageCallback.
+//
+// # Return
+//
+// An error if the callback is nil or if the subscription fails (Cf. SubscribeSpread).
+func (client *KrakenSpotPublicWebsocketClient) SubscribeSpreadRaw(ctx context.Context, pairs []string, callback RawMessageCallback) error {
+	if callback == nil {
+		return fmt.Errorf("subscribe spread raw failed: a callback must be provided")
+	}
+	return client.subscribeSpread(ctx, pairs, nil, callback)
+}
+
+// # Description
+//
+// Subscribe to the book channel and dispatch raw book snapshots and updates through the
+// provided callback. The payload can be parsed into a structure of type messages.BookSnapshot
+// or messages.BookUpdate: the first message received for a pair after a subscription is a
+// snapshot.
+//
+// See SubscribeTradeRaw for details about raw subscriptions.
+//
+// Use UnsubscribeBookDepth to unsubscribe or UnsubscribeBook to unsubscribe from all depths. Only
+// one book subscription (raw or not) can be active for a given depth.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - pairs: Pairs to subscribe to.
+//   - depth: Desired book depth.
+//   - callback: Callback used to dispatch raw book messages. Cf. RawMessageCallback.
+//
+// # Return
+//
+// An error if the callback is nil or if the subscription fails (Cf. SubscribeBook).
+func (client *KrakenSpotPublicWebsocketClient) SubscribeBookRaw(ctx context.Context, pairs []string, depth messages.DepthEnum, callback RawMessageCallback) error {
+	if callback == nil {
+		return fmt.Errorf("subscribe book raw failed: a callback must be provided")
+	}
+	return client.subscribeBook(ctx, pairs, depth, nil, callback, false)
+}
+
+// # Description
+//
+// Dispatch the message through the callback of a raw subscription if the message is a trade,
+// spread or book message and the corresponding channel is subscribed in raw mode.
+//
+// The channel name and the pair are extracted from the end of the message without allocating.
+// Like responses, messages received on a previous connection are ignored.
+//
+// # Inputs
+//
+//   - sessionId: Engine session ID of the connection which delivered the message.
+//   - msg: Message received from the server.
+//
+// # Return
+//
+// True if the message has been handled (dispatched or ignored because it is stale), false
+// otherwise.
+func (client *krakenSpotWebsocketClient) dispatchRawMessage(sessionId string, msg []byte) bool {
+	trade := client.rawTrade.Load()
+	spread := client.rawSpread.Load()
+	books := client.rawBooks.Load()
+	if trade == nil && spread == nil && (books == nil || len(*books) == 0) {
+		return false
+	}
+	channel, pair, ok := extractChannelAndPair(msg)
+	if !ok {
+		return false
+	}
+	var sub *rawSubscription
+	switch {
+	case bytes.Equal(channel, []byte(messages.ChannelTrade)):
+		sub = trade
+	case bytes.Equal(channel, []byte(messages.ChannelSpread)):
+		sub = spread
+	case books != nil && bytes.HasPrefix(channel, []byte(messages.ChannelBook)):
+		if depth, ok := parseBookDepth(channel); ok {
+			sub = (*books)[depth]
+		}
+	}
+	if sub == nil {
+		return false
+	}
+	// Get the pair string without allocating for subscribed pairs
+	pairStr, found := sub.pairs[string(pair)]
+	if !found {
+		pairStr = string(pair)
+	}
+	// Ignore messages received on a previous connection
+	if !client.sessions.isCurrent(sessionId) {
+		client.logger.Println("ignoring a raw message received from a previous connection")
+		return true
+	}
+	sub.callback(pairStr, msg)
+	return true
+}
+
+// Store the raw book subscription for the provided depth. A nil subscription removes it. Must be
+// called while the book subscription mutex is held.
+func (client *krakenSpotWebsocketClient) storeRawBook(depth messages.DepthEnum, sub *rawSubscription) {
+	books := map[messages.DepthEnum]*rawSubscription{}
+	if current := client.rawBooks.Load(); current != nil {
+		for d, s := range *current {
+			books[d] = s
+		}
+	}
+	if sub != nil {
+		books[depth] = sub
+	} else {
+		delete(books, depth)
+	}
+	client.rawBooks.Store(&books)
+}
+
+// Parse the depth from the name of a book channel (ex: book-10) without allocating. Returns the
+// depth and true if the channel name has the expected format.
+func parseBookDepth(channel []byte) (messages.DepthEnum, bool) {
+	prefix := len(messages.ChannelBook) + 1
+	if len(channel) <= prefix || channel[prefix-1] != '-' {
+		return 0, false
+	}
+	depth := 0
+	for _, c := range channel[prefix:] {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		depth = depth*10 + int(c-'0')
+	}
+	return messages.DepthEnum(depth), true
+}
+
+// # Description
+//
+// Extract the channel name and the pair from a public market data message. These messages are
+// JSON arrays which end with the channel name and the pair:
+//
+//	[<channelID>, <data>, "<channel name>", "<pair>"]
+//
+// # Return
+//
+// The channel name, the pair and true if the message has the expected format. Returned slices
+// share the message memory.
+func extractChannelAndPair(msg []byte) ([]byte, []byte, bool) {
+	msg = bytes.TrimRight(msg, " \t\r\n")
+	if len(msg) < 2 || msg[0] != '[' || msg[len(msg)-1] != ']' {
+		return nil, nil, false
+	}
+	pair, rest, ok := lastJSONString(msg[:len(msg)-1])
+	if !ok {
+		return nil, nil, false
+	}
+	rest = bytes.TrimRight(rest, " \t\r\n")
+	if len(rest) == 0 || rest[len(rest)-1] != ',' {
+		return nil, nil, false
+	}
+	channel, _, ok := lastJSONString(rest[:len(rest)-1])
+	if !ok {
+		return nil, nil, false
+	}
+	return channel, pair, true
+}
+
+// Extract the last JSON string (without escape sequences) of the data. Returns the string
+// content, the data before the string and true if a string has been found.
+func lastJSONString(data []byte) ([]byte, []byte, bool) {
+	data = bytes.TrimRight(data, " \t\r\n")
+	if len(data) < 2 || data[len(data)-1] != '"' {
+		return nil, nil, false
+	}
+	start := bytes.LastIndexByte(data[:len(data)-1], '"')
+	if start < 0 {
+		return nil, nil, false
+	}
+	return data[start+1 : len(data)-1], data[:start], true
+}
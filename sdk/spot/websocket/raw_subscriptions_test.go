@@ -0,0 +1,157 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/fixtures"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for raw subscriptions
+type RawSubscriptionsUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestRawSubscriptionsUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(RawSubscriptionsUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test extraction of channel name and pair from messages.
+func (suite *RawSubscriptionsUnitTestSuite) TestExtractChannelAndPair() {
+	channel, pair, ok := extractChannelAndPair([]byte(fixtures.WebsocketTrade))
+	require.True(suite.T(), ok)
+	require.Equal(suite.T(), "trade", string(channel))
+	require.Equal(suite.T(), "XBT/USD", string(pair))
+	channel, pair, ok = extractChannelAndPair([]byte("[0,{\"a\":[]}, \"book-10\" , \"ETH/USD\" ]\n"))
+	require.True(suite.T(), ok)
+	require.Equal(suite.T(), "book-10", string(channel))
+	require.Equal(suite.T(), "ETH/USD", string(pair))
+	for _, invalid := range []string{
+		`{"event":"heartbeat"}`,
+		`[]`,
+		`[0,[],"trade"]`,
+		`[0,[],"trade" "XBT/USD"]`,
+		`[0,[],1,"XBT/USD"]`,
+		`[0,"XBT/USD"`,
+	} {
+		_, _, ok = extractChannelAndPair([]byte(invalid))
+		require.False(suite.T(), ok, invalid)
+	}
+}
+
+// Test dispatch of raw messages.
+//
+// Test will ensure:
+//   - Messages are not dispatched when there is no raw subscription.
+//   - Trade messages are dispatched through the raw callback with the pair.
+//   - Messages for channels which are not subscribed in raw mode are not dispatched.
+//   - Raw messages are passed to the raw message hook.
+//   - Raw messages received on a previous connection are ignored.
+func (suite *RawSubscriptionsUnitTestSuite) TestDispatchRawMessage() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	require.False(suite.T(), client.dispatchRawMessage("", []byte(fixtures.WebsocketTrade)))
+	received := []string{}
+	client.rawTrade.Store(newRawSubscription([]string{"XBT/USD"}, func(pair string, payload []byte) {
+		received = append(received, pair, string(payload))
+	}))
+	require.True(suite.T(), client.dispatchRawMessage("", []byte(fixtures.WebsocketTrade)))
+	require.Equal(suite.T(), []string{"XBT/USD", fixtures.WebsocketTrade}, received)
+	// Payload can be parsed as a trade
+	trade := new(messages.Trade)
+	require.NoError(suite.T(), client.codec.Unmarshal([]byte(received[1]), trade))
+	require.Len(suite.T(), trade.Data, 2)
+	// Other channels and messages are not dispatched
+	require.False(suite.T(), client.dispatchRawMessage("", []byte(`[0,["5698.40000","5700.00000","1542057299.545897","1.01234567","0.98765432"],"spread","XBT/USD"]`)))
+	require.False(suite.T(), client.dispatchRawMessage("", []byte(`{"event":"heartbeat"}`)))
+	// OnMessage uses the fast path
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "", 0, []byte(fixtures.WebsocketTrade))
+	require.Len(suite.T(), received, 4)
+	// Raw messages are passed to the raw message hook
+	frames := make(chan RawFrame, 1)
+	client.SetOnRawMessageCallback(func(frame RawFrame) { frames <- frame }, 0)
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "", 0, []byte(fixtures.WebsocketTrade))
+	require.Equal(suite.T(), fixtures.WebsocketTrade, string((<-frames).Payload))
+	client.SetOnRawMessageCallback(nil, 0)
+	require.Len(suite.T(), received, 6)
+	// Messages received on a previous connection are ignored
+	client.sessions.open()
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "new", 0, []byte(fixtures.WebsocketTrade))
+	require.Len(suite.T(), received, 8)
+	require.True(suite.T(), client.dispatchRawMessage("", []byte(fixtures.WebsocketTrade)))
+	require.Len(suite.T(), received, 8)
+	// Remove raw subscription
+	client.rawTrade.Store(nil)
+	require.False(suite.T(), client.dispatchRawMessage("", []byte(fixtures.WebsocketTrade)))
+}
+
+// Test raw dispatch does not allocate for subscribed pairs.
+func (suite *RawSubscriptionsUnitTestSuite) TestDispatchRawMessageAllocations() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	client.rawTrade.Store(newRawSubscription([]string{"XBT/USD"}, func(pair string, payload []byte) {}))
+	msg := []byte(fixtures.WebsocketTrade)
+	allocs := testing.AllocsPerRun(100, func() { client.dispatchRawMessage("", msg) })
+	require.Zero(suite.T(), allocs)
+}
+
+// Test raw subscriptions require a callback.
+func (suite *RawSubscriptionsUnitTestSuite) TestSubscribeRawWithoutCallback() {
+	client := NewKrakenSpotPublicWebsocketClient(nil, nil, nil, nil, nil)
+	require.Error(suite.T(), client.SubscribeTradeRaw(context.Background(), []string{"XBT/USD"}, nil))
+	require.Error(suite.T(), client.SubscribeSpreadRaw(context.Background(), []string{"XBT/USD"}, nil))
+	require.Error(suite.T(), client.SubscribeBookRaw(context.Background(), []string{"XBT/USD"}, messages.D10, nil))
+}
+
+/*************************************************************************************************/
+/* BENCHMARKS                                                                                    */
+/*************************************************************************************************/
+
+// Benchmark dispatch of trade messages through a raw subscription.
+func BenchmarkOnMessageTradeRaw(b *testing.B) {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	client.rawTrade.Store(newRawSubscription([]string{"XBT/USD"}, func(pair string, payload []byte) {}))
+	msg := []byte(fixtures.WebsocketTrade)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		client.OnMessage(context.Background(), nil, nil, nil, nil, "", 0, msg)
+	}
+}
+
+// Benchmark dispatch of trade messages through a channel subscription.
+func BenchmarkOnMessageTradeEvent(b *testing.B) {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	pub := make(chan event.Event, 100)
+	client.subscriptions.trade = &tradeSubscription{pairs: []string{"XBT/USD"}, pub: pub}
+	done := make(chan struct{})
+	go func() {
+		for range pub {
+		}
+		close(done)
+	}()
+	msg := []byte(fixtures.WebsocketTrade)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		client.OnMessage(context.Background(), nil, nil, nil, nil, "", 0, msg)
+	}
+	b.StopTimer()
+	close(pub)
+	<-done
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
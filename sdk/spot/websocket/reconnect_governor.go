@@ -0,0 +1,274 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+)
+
+// Options of a ReconnectGovernor.
+type ReconnectGovernorOptions struct {
+	// Maximum number of connection attempts in progress at the same time across all the clients
+	// which share the governor. Must be strictly positive.
+	MaxConcurrentDials int
+	// Delay to wait after the first failed connection attempt. The delay doubles after each
+	// consecutive failure. Must be positive.
+	BaseDelay time.Duration
+	// Maximum delay between two connection attempts. Must be strictly positive.
+	MaxDelay time.Duration
+	// Fraction of the delay which is randomized to spread connection attempts: each client waits a
+	// delay picked in [delay * (1 - Jitter), delay * (1 + Jitter)]. Must be between 0 and 1.
+	Jitter float64
+}
+
+// Snapshot of the state of a ReconnectGovernor.
+type ReconnectGovernorState struct {
+	// Number of consecutive failed connection attempts. Reset once a connection succeeds.
+	ConsecutiveFailures int
+	// Number of connection attempts in progress
+	Dialing int
+	// Number of connection attempts waiting for the backoff delay or for a free dial slot
+	Waiting int
+	// Time of the last failed connection attempt. Zero if the last attempt has succeeded.
+	LastFailure time.Time
+}
+
+// Governor which coordinates the connection attempts of several websocket clients: when the
+// server is unavailable, the clients which share the governor back off together with a shared
+// exponential backoff and jitter instead of reconnecting simultaneously, and the number of
+// connection attempts in progress at the same time is capped.
+//
+// The governor is used through the dial options (Cf. DialOptions.ReconnectGovernor). It applies
+// on top of the retry delay of the websocket engine. Use DefaultReconnectGovernor to share a
+// governor between all the clients of the process.
+//
+// The governor is safe for concurrent use.
+type ReconnectGovernor struct {
+	// Governor options
+	opts ReconnectGovernorOptions
+	// Dial slots: a connection attempt holds a slot until it completes
+	slots chan struct{}
+	// Mutex used to protect the fields below
+	mu sync.Mutex
+	// Clock used to compute and wait the backoff delay
+	clock clock.Clock
+	// Random source used to apply jitter
+	rand *rand.Rand
+	// Number of consecutive failed connection attempts
+	failures int
+	// Time of the last failed connection attempt
+	lastFailure time.Time
+	// Number of connection attempts in progress
+	dialing int
+	// Number of connection attempts waiting for the backoff delay or for a dial slot
+	waiting int
+}
+
+var (
+	// Governor shared by all the clients of the process (Cf. DefaultReconnectGovernor)
+	defaultReconnectGovernor *ReconnectGovernor
+	// Used to build the default governor once
+	defaultReconnectGovernorOnce sync.Once
+)
+
+// # Description
+//
+// Create the default reconnect governor options: at most 2 concurrent connection attempts, an
+// exponential backoff starting at 1 second and capped at 1 minute and a 50% jitter.
+func NewDefaultReconnectGovernorOptions() *ReconnectGovernorOptions {
+	return &ReconnectGovernorOptions{
+		MaxConcurrentDials: 2,
+		BaseDelay:          time.Second,
+		MaxDelay:           time.Minute,
+		Jitter:             0.5,
+	}
+}
+
+// Validate the options.
+func (opts *ReconnectGovernorOptions) validate() error {
+	if opts.MaxConcurrentDials < 1 {
+		return fmt.Errorf("max concurrent dials must be strictly positive. Got %d", opts.MaxConcurrentDials)
+	}
+	if opts.BaseDelay < 0 {
+		return fmt.Errorf("base delay must be positive. Got %s", opts.BaseDelay)
+	}
+	if opts.MaxDelay <= 0 {
+		return fmt.Errorf("max delay must be strictly positive. Got %s", opts.MaxDelay)
+	}
+	if opts.Jitter < 0 || opts.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1. Got %f", opts.Jitter)
+	}
+	return nil
+}
+
+// # Description
+//
+// Build a new ReconnectGovernor. Provide the governor in the dial options of each client which
+// must be coordinated (Cf. DialOptions.ReconnectGovernor).
+//
+// # Inputs
+//
+//   - opts: Governor options. If nil, NewDefaultReconnectGovernorOptions is used.
+//
+// # Return
+//
+// The governor or an error if the options are invalid.
+func NewReconnectGovernor(opts *ReconnectGovernorOptions) (*ReconnectGovernor, error) {
+	if opts == nil {
+		opts = NewDefaultReconnectGovernorOptions()
+	}
+	if err := opts.validate(); err != nil {
+		return nil, fmt.Errorf("invalid reconnect governor options: %w", err)
+	}
+	return &ReconnectGovernor{
+		opts:  *opts,
+		slots: make(chan struct{}, opts.MaxConcurrentDials),
+		clock: clock.NewSystemClock(),
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// Get the governor shared by all the clients of the process. The governor uses the default
+// options (Cf. NewDefaultReconnectGovernorOptions) and is built on first use.
+func DefaultReconnectGovernor() *ReconnectGovernor {
+	defaultReconnectGovernorOnce.Do(func() {
+		// Default options are valid
+		defaultReconnectGovernor, _ = NewReconnectGovernor(nil)
+	})
+	return defaultReconnectGovernor
+}
+
+// Set the clock used to compute and wait the backoff delay. This can be used to provide a
+// clock.FakeClock in tests. If nil, the system clock is used.
+func (g *ReconnectGovernor) SetClock(clk clock.Clock) {
+	if clk == nil {
+		clk = clock.NewSystemClock()
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.clock = clk
+}
+
+// Get a snapshot of the state of the governor.
+func (g *ReconnectGovernor) GetState() ReconnectGovernorState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return ReconnectGovernorState{
+		ConsecutiveFailures: g.failures,
+		Dialing:             g.dialing,
+		Waiting:             g.waiting,
+		LastFailure:         g.lastFailure,
+	}
+}
+
+// # Description
+//
+// Wait until a connection attempt is allowed: the backoff delay computed from the consecutive
+// failures of all the clients which share the governor must have elapsed since the last failure
+// and a dial slot must be free. The caller must call the returned function with the result of the
+// connection attempt once it completes.
+//
+// # Inputs
+//
+//   - ctx: Context used for coordination purpose. Waiting stops when the context is done.
+//
+// # Return
+//
+// A function used to report the result of the connection attempt (nil if it has succeeded) and
+// release the dial slot, or the context error if the context is done before the attempt is allowed.
+func (g *ReconnectGovernor) Acquire(ctx context.Context) (func(err error), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// Pick the jitter of this attempt once so waiting converges
+	g.mu.Lock()
+	g.waiting++
+	jitter := 1 - g.opts.Jitter + 2*g.opts.Jitter*g.rand.Float64()
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		g.waiting--
+		g.mu.Unlock()
+	}()
+	select {
+	case g.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	// Wait the backoff delay. The delay is computed again after waiting as other clients may have
+	// failed in the meantime.
+	for {
+		g.mu.Lock()
+		wait := g.wait(jitter)
+		clk := g.clock
+		if wait <= 0 {
+			g.dialing++
+			g.mu.Unlock()
+			break
+		}
+		g.mu.Unlock()
+		timer := clk.NewTimer(wait)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			<-g.slots
+			return nil, ctx.Err()
+		}
+	}
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			g.mu.Lock()
+			g.dialing--
+			if err == nil {
+				g.failures = 0
+				g.lastFailure = time.Time{}
+			} else {
+				g.failures++
+				g.lastFailure = g.clock.Now()
+			}
+			g.mu.Unlock()
+			<-g.slots
+		})
+	}, nil
+}
+
+// Compute the remaining time to wait before a connection attempt. The backoff delay is multiplied
+// by the provided jitter factor. Must be called with the mutex held.
+func (g *ReconnectGovernor) wait(jitter float64) time.Duration {
+	if g.failures == 0 {
+		return 0
+	}
+	// Delay is capped before being converted: the exponential overflows a time.Duration after a
+	// few dozen consecutive failures.
+	delay := math.Min(float64(g.opts.BaseDelay)*math.Pow(2, float64(g.failures-1)), float64(g.opts.MaxDelay))
+	delay = math.Min(delay*jitter, math.MaxInt64)
+	return g.lastFailure.Add(time.Duration(delay)).Sub(g.clock.Now())
+}
+
+// Connection adapter decorator which waits for the reconnect governor before each connection attempt.
+type governedConnectionAdapter struct {
+	wsadapters.WebsocketConnectionAdapterInterface
+	// Governor shared with the other clients
+	governor *ReconnectGovernor
+}
+
+// Wait for the governor, then connect with the decorated adapter and report the result to the governor.
+func (adapter *governedConnectionAdapter) Dial(ctx context.Context, target url.URL) (*http.Response, error) {
+	done, err := adapter.governor.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("connection attempt not allowed by the reconnect governor: %w", err)
+	}
+	resp, err := adapter.WebsocketConnectionAdapterInterface.Dial(ctx, target)
+	done(err)
+	return resp, err
+}
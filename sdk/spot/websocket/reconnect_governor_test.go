@@ -0,0 +1,169 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for ReconnectGovernor
+type ReconnectGovernorUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestReconnectGovernorUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(ReconnectGovernorUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the number of concurrent connection attempts is capped.
+//
+// Test will ensure:
+//   - A connection attempt waits for a free dial slot.
+//   - The dial slot is released once the result of the attempt is reported.
+//   - A waiting attempt stops waiting when its context is canceled.
+func (suite *ReconnectGovernorUnitTestSuite) TestMaxConcurrentDials() {
+	governor, err := NewReconnectGovernor(&ReconnectGovernorOptions{MaxConcurrentDials: 1, BaseDelay: time.Second, MaxDelay: time.Minute})
+	require.NoError(suite.T(), err)
+	done, err := governor.Acquire(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 1, governor.GetState().Dialing)
+	// Second attempt waits for the first one
+	acquired := make(chan func(error), 1)
+	go func() {
+		done, err := governor.Acquire(context.Background())
+		require.NoError(suite.T(), err)
+		acquired <- done
+	}()
+	require.Eventually(suite.T(), func() bool { return governor.GetState().Waiting == 1 }, time.Second, time.Millisecond)
+	select {
+	case <-acquired:
+		suite.FailNow("attempt must wait for a free dial slot")
+	case <-time.After(20 * time.Millisecond):
+	}
+	done(nil)
+	// Calling the function twice has no effect
+	done(nil)
+	second := <-acquired
+	require.Equal(suite.T(), ReconnectGovernorState{Dialing: 1}, governor.GetState())
+	// Canceled attempt
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = governor.Acquire(ctx)
+	require.ErrorIs(suite.T(), err, context.Canceled)
+	second(nil)
+	require.Equal(suite.T(), ReconnectGovernorState{}, governor.GetState())
+}
+
+// Test the shared backoff.
+//
+// Test will ensure:
+//   - Connection attempts wait for the backoff delay after a failure.
+//   - The delay doubles after each consecutive failure and is capped.
+//   - A successful attempt resets the backoff.
+//   - An attempt waiting for the backoff delay releases its dial slot when its context is canceled.
+func (suite *ReconnectGovernorUnitTestSuite) TestBackoff() {
+	governor, err := NewReconnectGovernor(&ReconnectGovernorOptions{MaxConcurrentDials: 1, BaseDelay: time.Second, MaxDelay: 3 * time.Second})
+	require.NoError(suite.T(), err)
+	clk := clock.NewFakeClock(time.Now())
+	governor.SetClock(clk)
+	ctx := context.Background()
+	done, err := governor.Acquire(ctx)
+	require.NoError(suite.T(), err)
+	done(fmt.Errorf("connection refused"))
+	state := governor.GetState()
+	require.Equal(suite.T(), 1, state.ConsecutiveFailures)
+	require.Equal(suite.T(), clk.Now(), state.LastFailure)
+	// Expected delays after each consecutive failure
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		acquired := make(chan func(error), 1)
+		go func() {
+			done, err := governor.Acquire(ctx)
+			require.NoError(suite.T(), err)
+			acquired <- done
+		}()
+		clk.BlockUntil(1)
+		clk.Advance(delay - time.Millisecond)
+		select {
+		case <-acquired:
+			suite.FailNow("attempt must wait for the backoff delay", delay)
+		case <-time.After(20 * time.Millisecond):
+		}
+		clk.Advance(time.Millisecond)
+		(<-acquired)(fmt.Errorf("connection refused"))
+	}
+	require.Equal(suite.T(), 4, governor.GetState().ConsecutiveFailures)
+	// Canceled while waiting the backoff delay
+	cctx, cancel := context.WithCancel(ctx)
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := governor.Acquire(cctx)
+		canceled <- err
+	}()
+	clk.BlockUntil(1)
+	cancel()
+	require.ErrorIs(suite.T(), <-canceled, context.Canceled)
+	// Success resets the backoff
+	clk.Advance(3 * time.Second)
+	done, err = governor.Acquire(ctx)
+	require.NoError(suite.T(), err)
+	done(nil)
+	require.Equal(suite.T(), ReconnectGovernorState{}, governor.GetState())
+	done, err = governor.Acquire(ctx)
+	require.NoError(suite.T(), err)
+	done(nil)
+}
+
+// Test the backoff delay after many consecutive failures.
+//
+// Test will ensure:
+//   - The delay does not overflow and is capped by the maximum delay.
+//   - The jitter is applied to the capped delay.
+func (suite *ReconnectGovernorUnitTestSuite) TestBackoffManyFailures() {
+	governor, err := NewReconnectGovernor(&ReconnectGovernorOptions{MaxConcurrentDials: 1, BaseDelay: time.Second, MaxDelay: time.Minute})
+	require.NoError(suite.T(), err)
+	clk := clock.NewFakeClock(time.Now())
+	governor.SetClock(clk)
+	governor.lastFailure = clk.Now()
+	for _, failures := range []int{35, 64, 1000, math.MaxInt32} {
+		governor.failures = failures
+		require.Equal(suite.T(), time.Minute, governor.wait(1), failures)
+		require.Equal(suite.T(), 90*time.Second, governor.wait(1.5), failures)
+	}
+}
+
+// Test the governor options.
+//
+// Test will ensure:
+//   - Default options are used when no options are provided.
+//   - Invalid options are rejected.
+//   - The default governor is shared.
+func (suite *ReconnectGovernorUnitTestSuite) TestOptions() {
+	governor, err := NewReconnectGovernor(nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), *NewDefaultReconnectGovernorOptions(), governor.opts)
+	for _, opts := range []*ReconnectGovernorOptions{
+		{MaxConcurrentDials: 0},
+		{MaxConcurrentDials: 1, BaseDelay: -time.Second, MaxDelay: time.Second},
+		{MaxConcurrentDials: 1, BaseDelay: time.Second},
+		{MaxConcurrentDials: 1, MaxDelay: time.Second, Jitter: 1.5},
+	} {
+		_, err := NewReconnectGovernor(opts)
+		require.Error(suite.T(), err)
+	}
+	require.Same(suite.T(), DefaultReconnectGovernor(), DefaultReconnectGovernor())
+}
@@ -0,0 +1,111 @@
+// Package replay provides a player which publishes recorded websocket client events on a channel
+// at the pace of a simulation clock (Cf. clock.SimulationClock), so the subsystems which consume
+// the channels of the websocket client (indicators, candles, strategies, ...) can be fed with
+// recorded data instead of live data.
+//
+// The player waits until the simulation clock reaches the time each event was received: the
+// replay speed (x1, x10, x100, paused) and jumps to a given time are controlled with the clock.
+// Events are published unmodified, except for their time which is set to the time they were
+// received, so the consumers compute the same results as in live mode:
+//
+//	clk := clock.NewSimulationClock(records[0].Time)
+//	clk.SetSpeed(clock.Speed100x)
+//	player, err := replay.NewPlayer(records, clk)
+//	trades := make(chan event.Event)
+//	go player.Run(ctx, trades)
+//	go pipeline.Run(trades, out)
+package replay
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+)
+
+// Event recorded from a channel of the websocket client.
+type Record struct {
+	// Time at which the event was received
+	Time time.Time
+	// Recorded event
+	Event event.Event
+}
+
+// Player which publishes recorded events at the pace of a simulation clock.
+type Player struct {
+	// Recorded events, sorted by time
+	records []Record
+	// Clock used to pace the replay
+	clock *clock.SimulationClock
+	// Number of events which have been published
+	published atomic.Int64
+}
+
+// # Description
+//
+// Build a player for the provided recorded events.
+//
+// # Inputs
+//
+//   - records: Recorded events. They must be sorted by time.
+//   - c: Simulation clock used to pace the replay. It usually starts at the time of the first
+//     event. Must not be nil.
+//
+// # Return
+//
+// The player or an error if the clock is nil or if the events are not sorted by time.
+func NewPlayer(records []Record, c *clock.SimulationClock) (*Player, error) {
+	if c == nil {
+		return nil, fmt.Errorf("simulation clock cannot be nil")
+	}
+	for i := 1; i < len(records); i++ {
+		if records[i].Time.Before(records[i-1].Time) {
+			return nil, fmt.Errorf("records must be sorted by time: record %d is before record %d", i, i-1)
+		}
+	}
+	return &Player{records: records, clock: c}, nil
+}
+
+// # Description
+//
+// Publish the recorded events on the provided channel. Each event is published once the
+// simulation clock has reached the time it was received: events whose time has been skipped by
+// a jump (Cf. clock.SimulationClock.JumpTo) are published right away, in order, so the consumers
+// do not miss any event. Blocking writes are used.
+//
+// The channel is closed once all events have been published or when the context is done.
+//
+// # Inputs
+//
+//   - ctx: Context used to stop the replay.
+//   - out: Channel used to publish the recorded events.
+//
+// # Return
+//
+// Nil once all events have been published or the context error if the replay has been stopped.
+func (p *Player) Run(ctx context.Context, out chan event.Event) error {
+	defer close(out)
+	for _, record := range p.records[p.published.Load():] {
+		if err := p.clock.WaitUntil(ctx, record.Time); err != nil {
+			return err
+		}
+		e := record.Event.Clone()
+		e.SetTime(record.Time)
+		select {
+		case out <- e:
+			p.published.Add(1)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Get the number of events which have been published. A replay stopped with its context can be
+// resumed by calling Run again: the events which have already been published are skipped.
+func (p *Player) Published() int {
+	return int(p.published.Load())
+}
@@ -0,0 +1,112 @@
+package replay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the replay player
+type PlayerUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestPlayerUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(PlayerUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the player publishes the recorded events at the pace of the simulation clock.
+//
+// Test will ensure:
+//   - Events are not published before the simulation clock reaches their time.
+//   - Events skipped by a jump are published right away, in order.
+//   - Published events have the time they were recorded at.
+//   - The channel is closed once all events have been published.
+func (suite *PlayerUnitTestSuite) TestRun() {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := newRecords(start, 0, time.Hour, 2*time.Hour)
+	clk := clock.NewSimulationClock(start)
+	require.NoError(suite.T(), clk.SetSpeed(0))
+	player, err := NewPlayer(records, clk)
+	require.NoError(suite.T(), err)
+	out := make(chan event.Event, len(records))
+	result := make(chan error, 1)
+	go func() { result <- player.Run(context.Background(), out) }()
+	require.Equal(suite.T(), start, (<-out).Time())
+	require.Never(suite.T(), func() bool { return len(out) > 0 }, 20*time.Millisecond, time.Millisecond)
+	clk.JumpTo(start.Add(2 * time.Hour))
+	require.Equal(suite.T(), records[1].Event.ID(), (<-out).ID())
+	e := <-out
+	require.Equal(suite.T(), records[2].Event.ID(), e.ID())
+	require.Equal(suite.T(), start.Add(2*time.Hour), e.Time())
+	require.NoError(suite.T(), <-result)
+	_, ok := <-out
+	require.False(suite.T(), ok)
+	require.Equal(suite.T(), 3, player.Published())
+}
+
+// Test a replay can be stopped and resumed.
+//
+// Test will ensure:
+//   - Run returns the context error once the context is done and closes the channel.
+//   - A new call to Run resumes the replay after the events which have been published.
+//   - Players are not built without clock or with unsorted records.
+func (suite *PlayerUnitTestSuite) TestStopAndResume() {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := newRecords(start, 0, time.Hour)
+	clk := clock.NewSimulationClock(start)
+	require.NoError(suite.T(), clk.SetSpeed(0))
+	player, err := NewPlayer(records, clk)
+	require.NoError(suite.T(), err)
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan event.Event, len(records))
+	result := make(chan error, 1)
+	go func() { result <- player.Run(ctx, out) }()
+	require.Equal(suite.T(), records[0].Event.ID(), (<-out).ID())
+	cancel()
+	require.ErrorIs(suite.T(), <-result, context.Canceled)
+	_, ok := <-out
+	require.False(suite.T(), ok)
+	require.Equal(suite.T(), 1, player.Published())
+	// Resume
+	clk.JumpTo(start.Add(time.Hour))
+	out = make(chan event.Event, len(records))
+	require.NoError(suite.T(), player.Run(context.Background(), out))
+	require.Equal(suite.T(), records[1].Event.ID(), (<-out).ID())
+	// Invalid inputs
+	_, err = NewPlayer(records, nil)
+	require.Error(suite.T(), err)
+	_, err = NewPlayer([]Record{records[1], records[0]}, clk)
+	require.Error(suite.T(), err)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build trade events recorded at the provided offsets from start.
+func newRecords(start time.Time, offsets ...time.Duration) []Record {
+	records := make([]Record, 0, len(offsets))
+	for i, offset := range offsets {
+		e := event.New()
+		e.SetID(string(rune('a' + i)))
+		e.SetType(string(events.Trade))
+		records = append(records, Record{Time: start.Add(offset), Event: e})
+	}
+	return records
+}
@@ -0,0 +1,19 @@
+//go:build !goctopus_publiconly
+
+package websocket
+
+import "github.com/gbdevw/purple-goctopus/sdk/noncegen"
+
+// Generator of the unique request IDs sent by the websocket client: request IDs are generated
+// with a noncegen.HFNonceGenerator.
+//
+// Public-only builds (goctopus_publiconly build tag) use an equivalent generator which does not
+// depend on the noncegen package.
+type requestIdGenerator struct {
+	*noncegen.HFNonceGenerator
+}
+
+// Build a new requestIdGenerator.
+func newRequestIdGenerator() *requestIdGenerator {
+	return &requestIdGenerator{HFNonceGenerator: noncegen.NewHFNonceGenerator()}
+}
@@ -0,0 +1,29 @@
+//go:build goctopus_publiconly
+
+package websocket
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Generator of the unique request IDs sent by the websocket client in public-only builds
+// (goctopus_publiconly build tag). Like noncegen.HFNonceGenerator, request IDs are the sum of
+// the UNIX nanosec timestamp of the moment when the generator has been created and of a counter
+// which increases each time a request ID is generated.
+type requestIdGenerator struct {
+	// UNIX nanosec timestamp of the moment when the generator has been created
+	base int64
+	// Number of generated request IDs
+	inc atomic.Int64
+}
+
+// Build a new requestIdGenerator.
+func newRequestIdGenerator() *requestIdGenerator {
+	return &requestIdGenerator{base: time.Now().UnixNano()}
+}
+
+// Generate a new request ID.
+func (g *requestIdGenerator) GenerateNonce() int64 {
+	return g.base + g.inc.Add(1) - 1
+}
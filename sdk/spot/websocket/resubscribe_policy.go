@@ -0,0 +1,234 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
+	"github.com/google/uuid"
+)
+
+// Enum for backoff strategies used between resubscribe attempts
+type BackoffStrategyEnum string
+
+// Values for BackoffStrategyEnum
+const (
+	// Wait BaseDelay between each attempt
+	ConstantBackoff BackoffStrategyEnum = "constant"
+	// Wait BaseDelay * n after the nth failed attempt
+	LinearBackoff BackoffStrategyEnum = "linear"
+	// Wait BaseDelay * 2^(n-1) after the nth failed attempt
+	ExponentialBackoff BackoffStrategyEnum = "exponential"
+)
+
+// Policy used by the websocket client to restore active subscriptions after the connection with
+// the server has been reopened.
+type ResubscribePolicy struct {
+	// Maximum number of resubscribe attempts for each subscription. Must be strictly positive.
+	MaxAttempts int
+	// Backoff strategy used to compute the delay between two attempts.
+	Backoff BackoffStrategyEnum
+	// Base delay between two attempts. Must be positive.
+	BaseDelay time.Duration
+	// Maximum delay between two attempts. 0 means there is no maximum.
+	MaxDelay time.Duration
+	// Fraction of the delay which is randomized to spread resubscribe attempts: the actual delay
+	// is picked in [delay * (1 - Jitter), delay * (1 + Jitter)]. Must be between 0 and 1.
+	Jitter float64
+	// Timeout for each resubscribe attempt. Must be strictly positive.
+	AttemptTimeout time.Duration
+	// Optional callback called when the client gives up resubscribing to a channel. The callback
+	// receives the channel name, the pairs (empty for private channels) and the last error.
+	OnGiveUp func(channel string, pairs []string, err error)
+}
+
+// Data of a resubscribe_failed event published on the channel of a subscription when the client
+// gives up resubscribing to it: no more data will be received for this subscription.
+type ResubscribeFailed struct {
+	// Channel name
+	Channel string `json:"channel"`
+	// Pairs of the subscription. Empty for private channels.
+	Pairs []string `json:"pairs,omitempty"`
+	// Number of failed attempts
+	Attempts int `json:"attempts"`
+	// Last error
+	Error string `json:"error"`
+}
+
+// # Description
+//
+// Create the default resubscribe policy: 3 attempts with an exponential backoff (1, 2 & 4
+// seconds), no jitter and a 30 seconds timeout for each attempt.
+func NewDefaultResubscribePolicy() *ResubscribePolicy {
+	return &ResubscribePolicy{
+		MaxAttempts:    3,
+		Backoff:        ExponentialBackoff,
+		BaseDelay:      time.Second,
+		MaxDelay:       0,
+		Jitter:         0,
+		AttemptTimeout: 30 * time.Second,
+		OnGiveUp:       nil,
+	}
+}
+
+// Validate the policy.
+func (p *ResubscribePolicy) validate() error {
+	if p.MaxAttempts < 1 {
+		return fmt.Errorf("max attempts must be strictly positive. Got %d", p.MaxAttempts)
+	}
+	switch p.Backoff {
+	case ConstantBackoff, LinearBackoff, ExponentialBackoff:
+	default:
+		return fmt.Errorf("unknown backoff strategy %q", p.Backoff)
+	}
+	if p.BaseDelay < 0 || p.MaxDelay < 0 {
+		return fmt.Errorf("delays must be positive")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1. Got %f", p.Jitter)
+	}
+	if p.AttemptTimeout <= 0 {
+		return fmt.Errorf("attempt timeout must be strictly positive")
+	}
+	return nil
+}
+
+// Compute the delay to wait after the nth failed attempt (starting at 1), jitter excluded.
+func (p *ResubscribePolicy) delay(attempt int) time.Duration {
+	var delay time.Duration
+	switch p.Backoff {
+	case LinearBackoff:
+		delay = p.BaseDelay * time.Duration(attempt)
+	case ExponentialBackoff:
+		delay = time.Duration(float64(p.BaseDelay) * math.Pow(2, float64(attempt-1)))
+	default:
+		delay = p.BaseDelay
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// Apply jitter to the provided delay.
+func (p *ResubscribePolicy) withJitter(delay time.Duration) time.Duration {
+	if p.Jitter == 0 {
+		return delay
+	}
+	return time.Duration(float64(delay) * (1 - p.Jitter + 2*p.Jitter*rand.Float64()))
+}
+
+// # Description
+//
+// Set the policy used to restore active subscriptions when the connection with the server is
+// reopened. The policy can be changed while the client runs (Cf. UpdateConfig): the new policy
+// applies to the next subscriptions to restore.
+//
+// # Inputs
+//
+//   - policy: Resubscribe policy. If nil, the default policy is used (Cf. NewDefaultResubscribePolicy).
+//
+// # Return
+//
+// An error if the policy is invalid. In this case, the current policy is kept.
+func (client *krakenSpotWebsocketClient) SetResubscribePolicy(policy *ResubscribePolicy) error {
+	if policy == nil {
+		client.resubscribePolicy.Store(nil)
+		return nil
+	}
+	if err := policy.validate(); err != nil {
+		return fmt.Errorf("invalid resubscribe policy: %w", err)
+	}
+	// Store a copy so the policy cannot be changed once set
+	copy := *policy
+	client.resubscribePolicy.Store(&copy)
+	return nil
+}
+
+// Get the resubscribe policy in use.
+func (client *krakenSpotWebsocketClient) getResubscribePolicy() *ResubscribePolicy {
+	if policy := client.resubscribePolicy.Load(); policy != nil {
+		return policy
+	}
+	return NewDefaultResubscribePolicy()
+}
+
+// # Description
+//
+// Try to restore a subscription according to the resubscribe policy. The method blocks until the
+// subscription has been restored or until the client gives up.
+//
+// When the client gives up, the OnGiveUp callback of the policy is called and a resubscribe_failed
+// event (Cf. ResubscribeFailed) is published on the subscription channel if the subscription is
+// still active.
+//
+// # Inputs
+//
+//   - ctx: Parent context for resubscribe attempts.
+//   - channel: Channel name.
+//   - pairs: Pairs of the subscription. Nil for private channels.
+//   - resubscribe: Function which performs one resubscribe attempt.
+//   - subMu: Mutex which protects the subscription.
+//   - currentPub: Function which returns the channel of the subscription or nil if the
+//     subscription is not active anymore. Called while subMu is locked.
+//
+// # Return
+//
+// nil if the subscription has been restored or the last error.
+func (client *krakenSpotWebsocketClient) resubscribeWithPolicy(
+	ctx context.Context,
+	channel string,
+	pairs []string,
+	resubscribe func(ctx context.Context) error,
+	subMu *sync.Mutex,
+	currentPub func() chan event.Event) error {
+	policy := client.getResubscribePolicy()
+	var err error
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		actx, cancel := context.WithTimeout(ctx, policy.AttemptTimeout)
+		err = resubscribe(actx)
+		cancel()
+		if err == nil {
+			client.logger.Printf("resubscribe %s succeeded after %d attempt(s)\n", channel, attempt)
+			return nil
+		}
+		client.logger.Println(fmt.Errorf("resubscribe %s attempt number %d failed: %w", channel, attempt, err).Error())
+		if attempt < policy.MaxAttempts {
+			client.clock.Sleep(policy.withJitter(policy.delay(attempt)))
+		}
+	}
+	client.logger.Printf("resubscribe %s definitely failed after %d attempt(s)\n", channel, policy.MaxAttempts)
+	// Call user callback
+	if policy.OnGiveUp != nil {
+		policy.OnGiveUp(channel, pairs, err)
+	}
+	// Publish a resubscribe_failed event if the subscription is still active
+	e := event.New()
+	e.Context.SetType(string(events.ResubscribeFailed))
+	e.Context.SetID(uuid.NewString())
+	e.Context.SetSource(tracing.PackageName)
+	e.Context.SetTime(client.clock.Now())
+	if serr := e.SetData("application/json", &ResubscribeFailed{
+		Channel:  channel,
+		Pairs:    pairs,
+		Attempts: policy.MaxAttempts,
+		Error:    err.Error(),
+	}); serr != nil {
+		client.logger.Println("failed to build resubscribe_failed event:", serr.Error())
+		return err
+	}
+	subMu.Lock()
+	defer subMu.Unlock()
+	if pub := currentPub(); pub != nil {
+		client.bus.Publish(ResubscribeFailedTopic(channel), e)
+		// Use blocking writes (design principle: wait 'till delivery)
+		client.publish(pub, e)
+	}
+	return err
+}
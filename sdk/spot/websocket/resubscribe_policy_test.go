@@ -0,0 +1,143 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the resubscribe policy
+type ResubscribePolicyUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestResubscribePolicyUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(ResubscribePolicyUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test delays computed by the backoff strategies.
+func (suite *ResubscribePolicyUnitTestSuite) TestDelay() {
+	policy := NewDefaultResubscribePolicy()
+	require.Equal(suite.T(), time.Second, policy.delay(1))
+	require.Equal(suite.T(), 2*time.Second, policy.delay(2))
+	require.Equal(suite.T(), 4*time.Second, policy.delay(3))
+	policy.MaxDelay = 3 * time.Second
+	require.Equal(suite.T(), 3*time.Second, policy.delay(3))
+	policy.Backoff = LinearBackoff
+	require.Equal(suite.T(), 2*time.Second, policy.delay(2))
+	policy.Backoff = ConstantBackoff
+	require.Equal(suite.T(), time.Second, policy.delay(5))
+	// Jitter
+	policy.Jitter = 0.5
+	for i := 0; i < 20; i++ {
+		d := policy.withJitter(time.Second)
+		require.GreaterOrEqual(suite.T(), d, 500*time.Millisecond)
+		require.LessOrEqual(suite.T(), d, 1500*time.Millisecond)
+	}
+}
+
+// Test SetResubscribePolicy rejects invalid policies and keeps the current one.
+func (suite *ResubscribePolicyUnitTestSuite) TestSetResubscribePolicy() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	require.Equal(suite.T(), NewDefaultResubscribePolicy().MaxAttempts, client.getResubscribePolicy().MaxAttempts)
+	policy := &ResubscribePolicy{MaxAttempts: 5, Backoff: LinearBackoff, BaseDelay: time.Millisecond, AttemptTimeout: time.Second}
+	require.NoError(suite.T(), client.SetResubscribePolicy(policy))
+	require.Equal(suite.T(), 5, client.getResubscribePolicy().MaxAttempts)
+	invalids := []*ResubscribePolicy{
+		{MaxAttempts: 0, Backoff: LinearBackoff, AttemptTimeout: time.Second},
+		{MaxAttempts: 1, Backoff: "unknown", AttemptTimeout: time.Second},
+		{MaxAttempts: 1, Backoff: LinearBackoff, BaseDelay: -time.Second, AttemptTimeout: time.Second},
+		{MaxAttempts: 1, Backoff: LinearBackoff, Jitter: 2, AttemptTimeout: time.Second},
+		{MaxAttempts: 1, Backoff: LinearBackoff},
+	}
+	for _, invalid := range invalids {
+		require.Error(suite.T(), client.SetResubscribePolicy(invalid))
+	}
+	require.Equal(suite.T(), 5, client.getResubscribePolicy().MaxAttempts)
+	// Reset to default
+	require.NoError(suite.T(), client.SetResubscribePolicy(nil))
+	require.Equal(suite.T(), 3, client.getResubscribePolicy().MaxAttempts)
+}
+
+// Test resubscribeWithPolicy when the client gives up.
+//
+// Test will ensure:
+//   - The configured number of attempts is made.
+//   - The OnGiveUp callback is called with the channel, pairs and last error.
+//   - A resubscribe_failed event is published on the subscription channel.
+func (suite *ResubscribePolicyUnitTestSuite) TestResubscribeGiveUp() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	var gaveUpChannel string
+	var gaveUpPairs []string
+	var gaveUpErr error
+	require.NoError(suite.T(), client.SetResubscribePolicy(&ResubscribePolicy{
+		MaxAttempts:    4,
+		Backoff:        ConstantBackoff,
+		BaseDelay:      time.Millisecond,
+		AttemptTimeout: time.Second,
+		OnGiveUp: func(channel string, pairs []string, err error) {
+			gaveUpChannel, gaveUpPairs, gaveUpErr = channel, pairs, err
+		},
+	}))
+	pub := make(chan event.Event, 1)
+	client.subscriptions.ticker = &tickerSubscription{pairs: []string{"XBT/USD"}, pub: pub}
+	attempts := 0
+	err := client.resubscribeWithPolicy(context.Background(), "ticker", []string{"XBT/USD"}, func(ctx context.Context) error {
+		attempts++
+		return fmt.Errorf("attempt %d failed", attempts)
+	}, &client.tickerSubMu, func() chan event.Event { return client.subscriptions.ticker.pub })
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), 4, attempts)
+	require.Equal(suite.T(), "ticker", gaveUpChannel)
+	require.Equal(suite.T(), []string{"XBT/USD"}, gaveUpPairs)
+	require.ErrorContains(suite.T(), gaveUpErr, "attempt 4 failed")
+	require.Len(suite.T(), pub, 1)
+	e := <-pub
+	require.Equal(suite.T(), string(events.ResubscribeFailed), e.Type())
+	data := new(ResubscribeFailed)
+	require.NoError(suite.T(), e.DataAs(data))
+	require.Equal(suite.T(), "ticker", data.Channel)
+	require.Equal(suite.T(), 4, data.Attempts)
+	require.Equal(suite.T(), "attempt 4 failed", data.Error)
+}
+
+// Test resubscribeWithPolicy stops retrying once the subscription has been restored.
+func (suite *ResubscribePolicyUnitTestSuite) TestResubscribeSuccess() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	require.NoError(suite.T(), client.SetResubscribePolicy(&ResubscribePolicy{
+		MaxAttempts:    5,
+		Backoff:        ExponentialBackoff,
+		BaseDelay:      time.Millisecond,
+		AttemptTimeout: time.Second,
+		OnGiveUp: func(channel string, pairs []string, err error) {
+			suite.T().Error("unexpected give up")
+		},
+	}))
+	pub := make(chan event.Event, 1)
+	attempts := 0
+	err := client.resubscribeWithPolicy(context.Background(), "ownTrades", nil, func(ctx context.Context) error {
+		attempts++
+		if attempts < 2 {
+			return fmt.Errorf("failed")
+		}
+		return nil
+	}, &client.ownTradesSubMu, func() chan event.Event { return pub })
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), 2, attempts)
+	require.Empty(suite.T(), pub)
+}
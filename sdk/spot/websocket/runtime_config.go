@@ -0,0 +1,212 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	otelObs "github.com/cloudevents/sdk-go/observability/opentelemetry/v2/client"
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
+	"github.com/google/uuid"
+)
+
+// Enum for the log levels of the client.
+type LogLevelEnum string
+
+// Values for LogLevelEnum
+const (
+	// Debug messages are written with the logger provided to the client. This is the default level.
+	LogLevelDebug LogLevelEnum = "debug"
+	// No message is written.
+	LogLevelSilent LogLevelEnum = "silent"
+)
+
+// Enum for the behaviors of the client when one of its built-in channels (heartbeat, system
+// status, general error and unknown message channels) is full.
+type CongestionPolicyEnum string
+
+// Values for CongestionPolicyEnum
+const (
+	// Discard the oldest event of the channel to publish the new one. This is the default policy.
+	CongestionDropOldest CongestionPolicyEnum = "drop_oldest"
+	// Discard the new event.
+	CongestionDropNewest CongestionPolicyEnum = "drop_newest"
+)
+
+// Names of the parameters which can be updated with UpdateConfig.
+const (
+	ConfigLogLevel          = "log_level"
+	ConfigCongestionPolicy  = "congestion_policy"
+	ConfigResubscribePolicy = "resubscribe_policy"
+	ConfigRateLimitTier     = "rate_limit_tier"
+)
+
+// Runtime configuration update. Nil fields are left unchanged.
+type ConfigUpdate struct {
+	// Log level of the client.
+	LogLevel *LogLevelEnum
+	// Behavior of the client when one of its built-in channels is full.
+	CongestionPolicy *CongestionPolicyEnum
+	// Policy used to restore active subscriptions when the connection is reopened.
+	ResubscribePolicy *ResubscribePolicy
+	// Verification tier whose rate limit is applied to order management commands (Cf.
+	// CommandRateLimits). Enables client side rate limiting if it is disabled.
+	RateLimitTier *VerificationTierEnum
+}
+
+// Data of a config_updated event.
+type ConfigUpdated struct {
+	// Names of the updated parameters (Cf. ConfigLogLevel, ConfigCongestionPolicy, ...)
+	Parameters []string `json:"parameters"`
+	// Log level in use
+	LogLevel LogLevelEnum `json:"log_level"`
+	// Congestion policy in use
+	CongestionPolicy CongestionPolicyEnum `json:"congestion_policy"`
+	// Verification tier whose rate limit is applied. Empty if rate limiting is disabled or if a
+	// custom rate limit is applied.
+	RateLimitTier VerificationTierEnum `json:"rate_limit_tier,omitempty"`
+}
+
+// Interface implemented by clients whose configuration can be updated at runtime.
+type ConfigUpdater interface {
+	// Update the runtime configuration. Cf. krakenSpotWebsocketClient.UpdateConfig.
+	UpdateConfig(ctx context.Context, update ConfigUpdate) error
+}
+
+// # Description
+//
+// Set the behavior of the client when one of its built-in channels (heartbeat, system status,
+// general error and unknown message channels) is full. Discarded events are counted (Cf.
+// GetDroppedMessagesCount) whatever the policy.
+//
+// # Inputs
+//
+//   - policy: Congestion policy. An empty value resets the default policy (CongestionDropOldest).
+func (client *krakenSpotWebsocketClient) SetCongestionPolicy(policy CongestionPolicyEnum) {
+	if policy == "" {
+		policy = CongestionDropOldest
+	}
+	client.congestionPolicy.Store(&policy)
+}
+
+// Get the congestion policy in use.
+func (client *krakenSpotWebsocketClient) getCongestionPolicy() CongestionPolicyEnum {
+	if policy := client.congestionPolicy.Load(); policy != nil {
+		return *policy
+	}
+	return CongestionDropOldest
+}
+
+// # Description
+//
+// Update the runtime-tunable parameters of the client while it runs, without restarting it. The
+// update is validated before being applied: either all provided parameters are updated or none
+// is. Concurrent updates are serialized.
+//
+// Once the update has been applied, a config_updated event (Cf. ConfigUpdated) is published on
+// the event bus with the TopicConfigUpdated topic.
+//
+// Parameters:
+//
+//   - LogLevel: LogLevelSilent redirects the output of the logger provided to the client to
+//     io.Discard. LogLevelDebug restores its previous output. As the logger is modified, a logger
+//     which is shared with other components should not be provided to the client when this
+//     parameter is used.
+//   - CongestionPolicy: Cf. SetCongestionPolicy.
+//   - ResubscribePolicy: Cf. SetResubscribePolicy. The policy applies to the next subscriptions
+//     to restore.
+//   - RateLimitTier: the rate limit of the tier is applied without resetting the bucket of the
+//     client side rate limiter: available tokens are kept, up to the new capacity. If rate
+//     limiting is disabled, it is enabled in CommandRateLimitQueue mode.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing purpose.
+//   - update: Parameters to update. Nil fields are left unchanged.
+//
+// # Return
+//
+// An error if the update is invalid. In this case, no parameter is updated.
+func (client *krakenSpotWebsocketClient) UpdateConfig(ctx context.Context, update ConfigUpdate) error {
+	// Validate the whole update before applying it
+	parameters := []string{}
+	if update.LogLevel != nil {
+		switch *update.LogLevel {
+		case LogLevelDebug, LogLevelSilent:
+		default:
+			return fmt.Errorf("update config failed: unknown log level %q", *update.LogLevel)
+		}
+		parameters = append(parameters, ConfigLogLevel)
+	}
+	if update.CongestionPolicy != nil {
+		switch *update.CongestionPolicy {
+		case CongestionDropOldest, CongestionDropNewest:
+		default:
+			return fmt.Errorf("update config failed: unknown congestion policy %q", *update.CongestionPolicy)
+		}
+		parameters = append(parameters, ConfigCongestionPolicy)
+	}
+	if update.ResubscribePolicy != nil {
+		if err := update.ResubscribePolicy.validate(); err != nil {
+			return fmt.Errorf("update config failed: invalid resubscribe policy: %w", err)
+		}
+		parameters = append(parameters, ConfigResubscribePolicy)
+	}
+	if update.RateLimitTier != nil {
+		if _, ok := CommandRateLimits[*update.RateLimitTier]; !ok {
+			return fmt.Errorf("update config failed: unknown verification tier %q", *update.RateLimitTier)
+		}
+		parameters = append(parameters, ConfigRateLimitTier)
+	}
+	if len(parameters) == 0 {
+		return nil
+	}
+	// Apply the update
+	client.configMu.Lock()
+	defer client.configMu.Unlock()
+	if update.LogLevel != nil {
+		client.setLogLevel(*update.LogLevel)
+	}
+	if update.CongestionPolicy != nil {
+		client.SetCongestionPolicy(*update.CongestionPolicy)
+	}
+	if update.ResubscribePolicy != nil {
+		// Policy has already been validated
+		_ = client.SetResubscribePolicy(update.ResubscribePolicy)
+	}
+	if update.RateLimitTier != nil {
+		client.setCommandRateLimitTier(*update.RateLimitTier)
+	}
+	// Publish a config_updated event
+	level := LogLevelDebug
+	if client.logOutput != nil {
+		level = LogLevelSilent
+	}
+	e := event.New()
+	e.Context.SetType(string(events.ConfigUpdated))
+	e.Context.SetID(uuid.NewString())
+	e.Context.SetSource(tracing.PackageName)
+	e.SetData("application/json", &ConfigUpdated{
+		Parameters:       parameters,
+		LogLevel:         level,
+		CongestionPolicy: client.getCongestionPolicy(),
+		RateLimitTier:    client.getCommandRateLimitTier(),
+	})
+	otelObs.InjectDistributedTracingExtension(ctx, e)
+	client.bus.Publish(TopicConfigUpdated, e)
+	return nil
+}
+
+// Set the log level by redirecting the output of the logger. Must be called with configMu locked.
+func (client *krakenSpotWebsocketClient) setLogLevel(level LogLevelEnum) {
+	switch {
+	case level == LogLevelSilent && client.logOutput == nil:
+		client.logOutput = client.logger.Writer()
+		client.logger.SetOutput(io.Discard)
+	case level == LogLevelDebug && client.logOutput != nil:
+		client.logger.SetOutput(client.logOutput)
+		client.logOutput = nil
+	}
+}
@@ -0,0 +1,159 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/fixtures"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for runtime configuration updates
+type RuntimeConfigTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestRuntimeConfigTestSuite(t *testing.T) {
+	suite.Run(t, new(RuntimeConfigTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test UpdateConfig.
+//
+// Test will ensure:
+//   - All parameters are updated and a config_updated event is published.
+//   - An invalid update is rejected and no parameter is updated.
+//   - An empty update does not publish any event.
+func (suite *RuntimeConfigTestSuite) TestUpdateConfig() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	sub, err := client.SubscribeTopic(TopicConfigUpdated, 10)
+	require.NoError(suite.T(), err)
+	defer sub.Unsubscribe()
+	silent := LogLevelSilent
+	dropNewest := CongestionDropNewest
+	tier := TierPro
+	policy := &ResubscribePolicy{MaxAttempts: 5, Backoff: ConstantBackoff, AttemptTimeout: time.Second}
+	err = client.UpdateConfig(context.Background(), ConfigUpdate{
+		LogLevel:          &silent,
+		CongestionPolicy:  &dropNewest,
+		ResubscribePolicy: policy,
+		RateLimitTier:     &tier,
+	})
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), CongestionDropNewest, client.getCongestionPolicy())
+	require.Equal(suite.T(), 5, client.getResubscribePolicy().MaxAttempts)
+	require.Equal(suite.T(), TierPro, client.getCommandRateLimitTier())
+	e := <-sub.C
+	require.Equal(suite.T(), string(events.ConfigUpdated), e.Type())
+	updated := new(ConfigUpdated)
+	require.NoError(suite.T(), json.Unmarshal(e.Data(), updated))
+	require.Equal(suite.T(), &ConfigUpdated{
+		Parameters:       []string{ConfigLogLevel, ConfigCongestionPolicy, ConfigResubscribePolicy, ConfigRateLimitTier},
+		LogLevel:         LogLevelSilent,
+		CongestionPolicy: CongestionDropNewest,
+		RateLimitTier:    TierPro,
+	}, updated)
+	// Invalid update: the valid congestion policy is not applied
+	dropOldest := CongestionDropOldest
+	unknown := VerificationTierEnum("unknown")
+	err = client.UpdateConfig(context.Background(), ConfigUpdate{CongestionPolicy: &dropOldest, RateLimitTier: &unknown})
+	require.Error(suite.T(), err)
+	err = client.UpdateConfig(context.Background(), ConfigUpdate{CongestionPolicy: &dropOldest, ResubscribePolicy: &ResubscribePolicy{}})
+	require.Error(suite.T(), err)
+	require.Equal(suite.T(), CongestionDropNewest, client.getCongestionPolicy())
+	// Empty update
+	require.NoError(suite.T(), client.UpdateConfig(context.Background(), ConfigUpdate{}))
+	require.Empty(suite.T(), sub.C)
+}
+
+// Test the log level can be changed at runtime.
+//
+// Test will ensure:
+//   - Nothing is logged while the log level is silent.
+//   - The output of the logger is restored when the log level is debug.
+func (suite *RuntimeConfigTestSuite) TestUpdateLogLevel() {
+	buf := new(bytes.Buffer)
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, log.New(buf, "", 0), nil)
+	silent, debug := LogLevelSilent, LogLevelDebug
+	require.NoError(suite.T(), client.UpdateConfig(context.Background(), ConfigUpdate{LogLevel: &silent}))
+	// Updating twice must not lose the original output
+	require.NoError(suite.T(), client.UpdateConfig(context.Background(), ConfigUpdate{LogLevel: &silent}))
+	require.NoError(suite.T(), client.handleHeartbeat(context.Background(), nil, nil, nil, nil, "", 0, []byte(fixtures.WebsocketHeartbeat)))
+	require.Empty(suite.T(), buf.String())
+	require.NoError(suite.T(), client.UpdateConfig(context.Background(), ConfigUpdate{LogLevel: &debug}))
+	require.NoError(suite.T(), client.handleHeartbeat(context.Background(), nil, nil, nil, nil, "", 0, []byte(fixtures.WebsocketHeartbeat)))
+	require.Contains(suite.T(), buf.String(), "handling heartbeat from server")
+}
+
+// Test the congestion policy of the built-in channels.
+//
+// Test will ensure:
+//   - With CongestionDropNewest, the oldest events are kept and the new ones are counted as dropped.
+//   - With CongestionDropOldest, the newest events are kept.
+func (suite *RuntimeConfigTestSuite) TestCongestionPolicy() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	client.SetCongestionPolicy(CongestionDropNewest)
+	online := []byte(`{"connectionID":1,"event":"systemStatus","status":"online","version":"1.0.0"}`)
+	maintenance := []byte(`{"connectionID":1,"event":"systemStatus","status":"maintenance","version":"1.0.0"}`)
+	for i := 0; i < cap(client.GetSystemStatusChannel()); i++ {
+		require.NoError(suite.T(), client.handleSystemStatus(context.Background(), nil, nil, nil, nil, "", 0, online))
+	}
+	require.NoError(suite.T(), client.handleSystemStatus(context.Background(), nil, nil, nil, nil, "", 0, maintenance))
+	require.Equal(suite.T(), uint64(1), client.GetDroppedMessagesCount(events.SystemStatus))
+	for i := 0; i < cap(client.GetSystemStatusChannel()); i++ {
+		require.JSONEq(suite.T(), string(online), string((<-client.GetSystemStatusChannel()).Data()))
+	}
+	// Default policy
+	client.SetCongestionPolicy("")
+	for i := 0; i < cap(client.GetSystemStatusChannel()); i++ {
+		require.NoError(suite.T(), client.handleSystemStatus(context.Background(), nil, nil, nil, nil, "", 0, online))
+	}
+	require.NoError(suite.T(), client.handleSystemStatus(context.Background(), nil, nil, nil, nil, "", 0, maintenance))
+	require.Equal(suite.T(), uint64(2), client.GetDroppedMessagesCount(events.SystemStatus))
+	var last []byte
+	for len(client.GetSystemStatusChannel()) > 0 {
+		last = (<-client.GetSystemStatusChannel()).Data()
+	}
+	require.JSONEq(suite.T(), string(maintenance), string(last))
+}
+
+// Test the rate limit tier can be changed without resetting the bucket.
+//
+// Test will ensure:
+//   - Rate limiting is enabled in queue mode with a full bucket if it is disabled.
+//   - Available tokens are kept, up to the capacity of the new tier.
+func (suite *RuntimeConfigTestSuite) TestUpdateRateLimitTier() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	fake := clock.NewFakeClock(time.Now())
+	client.SetClock(fake)
+	pro, starter := TierPro, TierStarter
+	require.NoError(suite.T(), client.UpdateConfig(context.Background(), ConfigUpdate{RateLimitTier: &pro}))
+	require.Equal(suite.T(), CommandRateLimitQueue, client.commandRateLimiter.mode)
+	require.Equal(suite.T(), CommandRateLimits[TierPro].Capacity, client.commandRateLimiter.tokens)
+	// Capped to the capacity of the new tier
+	require.NoError(suite.T(), client.UpdateConfig(context.Background(), ConfigUpdate{RateLimitTier: &starter}))
+	require.Equal(suite.T(), CommandRateLimits[TierStarter].Capacity, client.commandRateLimiter.tokens)
+	// Tokens are kept
+	client.commandRateLimiter.tokens = 10
+	require.NoError(suite.T(), client.UpdateConfig(context.Background(), ConfigUpdate{RateLimitTier: &pro}))
+	require.Equal(suite.T(), 10.0, client.commandRateLimiter.tokens)
+	require.Equal(suite.T(), CommandRateLimits[TierPro], client.commandRateLimiter.limit)
+	// Custom rate limit
+	require.NoError(suite.T(), client.SetCommandRateLimit(&CommandRateLimit{Capacity: 1, RefillRate: 1}, CommandRateLimitReject))
+	require.Empty(suite.T(), client.getCommandRateLimitTier())
+}
@@ -0,0 +1,165 @@
+package websocket
+
+import (
+	"sync"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Number of previous sessions remembered by the session tracker. Messages from older sessions are
+// considered as coming from an unknown session, which is also stale.
+const maxTrackedSessions = 8
+
+// # Description
+//
+// Tracker which maps the session IDs provided by the websocket engine for each connection to a
+// generation number incremented each time a connection is opened. Pending requests are tagged
+// with the generation of the session during which they have been sent so responses received on
+// a previous connection cannot fulfil requests sent on the current connection, even if request
+// IDs collide.
+//
+// The engine does not provide the session ID when the connection is opened: the first unknown
+// session ID seen after a connection has been opened is bound to the current generation.
+type sessionTracker struct {
+	// Mutex used to protect the tracker
+	mu sync.Mutex
+	// Generation of the current session
+	generation uint64
+	// True if an engine session ID has been bound to the current generation
+	bound bool
+	// Generations by engine session ID
+	generations map[string]uint64
+}
+
+// Build a new session tracker.
+func newSessionTracker() *sessionTracker {
+	return &sessionTracker{
+		generations: map[string]uint64{},
+	}
+}
+
+// Start a new session. Must be called each time a connection is opened. The generation of the new
+// session is returned.
+func (t *sessionTracker) open() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.generation++
+	t.bound = false
+	// Forget the oldest sessions
+	for id, generation := range t.generations {
+		if generation+maxTrackedSessions < t.generation {
+			delete(t.generations, id)
+		}
+	}
+	return t.generation
+}
+
+// Get the generation of the current session.
+func (t *sessionTracker) current() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.generation
+}
+
+// Get the engine session ID bound to the current session. An empty string is returned if no
+// engine session ID has been bound to the current session yet.
+func (t *sessionTracker) currentId() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.bound {
+		for id, generation := range t.generations {
+			if generation == t.generation {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// Get the generation of the session bound to the provided engine session ID. False is returned if
+// the session is unknown.
+func (t *sessionTracker) resolve(sessionId string) (uint64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if generation, ok := t.generations[sessionId]; ok {
+		return generation, true
+	}
+	if !t.bound {
+		t.generations[sessionId] = t.generation
+		t.bound = true
+		return t.generation, true
+	}
+	return 0, false
+}
+
+// Tell whether the provided engine session ID is bound to the current session.
+func (t *sessionTracker) isCurrent(sessionId string) bool {
+	generation, ok := t.resolve(sessionId)
+	return ok && generation == t.current()
+}
+
+// # Description
+//
+// Tell whether a response received during the provided engine session cannot be related to a
+// pending request sent during the session with the provided generation. Stale responses are
+// counted (Cf. GetStaleResponsesCount), logged and recorded as span events.
+//
+// # Inputs
+//
+//   - span: Span of the response handler.
+//   - sessionId: Engine session ID of the connection which delivered the response.
+//   - requestSession: Generation of the session during which the request has been sent.
+//   - requestId: Request ID of the response.
+//
+// # Return
+//
+// True if the response is stale and must be ignored.
+func (client *krakenSpotWebsocketClient) isStaleResponse(span trace.Span, sessionId string, requestSession uint64, requestId int64) bool {
+	generation, ok := client.sessions.resolve(sessionId)
+	if ok && generation == requestSession {
+		return false
+	}
+	client.flagStaleResponse(span, sessionId, requestId)
+	return true
+}
+
+// Count, log and trace a stale response. Use 0 as request ID if the response has not been parsed.
+func (client *krakenSpotWebsocketClient) flagStaleResponse(span trace.Span, sessionId string, requestId int64) {
+	count := client.staleResponses.Add(1)
+	client.logger.Printf("ignoring a response received from a previous connection (request id: %d, total: %d)\n", requestId, count)
+	span.AddEvent("stale_response", trace.WithAttributes(
+		attribute.String("session_id", sessionId),
+		attribute.Int64("request_id", requestId),
+	))
+}
+
+// # Description
+//
+// Get the total number of responses ignored because they have been received on a previous
+// connection and cannot be related to the pending requests of the current connection.
+//
+// # Return
+//
+// The total number of ignored stale responses.
+func (client *krakenSpotWebsocketClient) GetStaleResponsesCount() uint64 {
+	return client.staleResponses.Load()
+}
+
+// Tell whether messages of the provided type are responses to requests sent by the client. Error
+// messages are not included as they can also be sent without request ID (Cf. GetGeneralErrorChannel).
+func isResponseMessageType(mType string) bool {
+	switch messages.EventTypeEnum(mType) {
+	case messages.EventTypePong,
+		messages.EventTypeSubscriptionStatus,
+		messages.EventTypeAddOrderStatus,
+		messages.EventTypeEditOrderStatus,
+		messages.EventTypeCancelOrderStatus,
+		messages.EventTypeCancelAllOrderStatus,
+		messages.EventTypeCancelAllOrderAfterXStatus:
+		return true
+	default:
+		return false
+	}
+}
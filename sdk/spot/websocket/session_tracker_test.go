@@ -0,0 +1,106 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for sessionTracker and stale responses handling
+type SessionTrackerUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestSessionTrackerUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(SessionTrackerUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test sessionTracker.
+//
+// Test will ensure:
+//   - The first unknown session ID seen after open is bound to the new generation.
+//   - Other unknown session IDs are not bound to the current generation.
+//   - Previous sessions are still resolved after a reconnect but are not current.
+//   - The oldest sessions are forgotten.
+func (suite *SessionTrackerUnitTestSuite) TestSessionTracker() {
+	tracker := newSessionTracker()
+	require.Equal(suite.T(), uint64(1), tracker.open())
+	require.True(suite.T(), tracker.isCurrent("s1"))
+	_, ok := tracker.resolve("s2")
+	require.False(suite.T(), ok)
+	// Reconnect
+	require.Equal(suite.T(), uint64(2), tracker.open())
+	require.Equal(suite.T(), uint64(2), tracker.current())
+	require.True(suite.T(), tracker.isCurrent("s2"))
+	require.False(suite.T(), tracker.isCurrent("s1"))
+	generation, ok := tracker.resolve("s1")
+	require.True(suite.T(), ok)
+	require.Equal(suite.T(), uint64(1), generation)
+	// Pruning
+	for i := 0; i < maxTrackedSessions; i++ {
+		tracker.open()
+	}
+	require.True(suite.T(), tracker.isCurrent("s3"))
+	_, ok = tracker.resolve("s1")
+	require.False(suite.T(), ok)
+	require.Len(suite.T(), tracker.generations, 2)
+}
+
+// Test responses received on a previous connection after a reconnect.
+//
+// Test will ensure:
+//   - A response received on the previous connection does not fulfil a pending request of the
+//     current connection which has the same request ID.
+//   - A response received on the current connection does not fulfil a request sent during a
+//     previous session.
+//   - Stale responses are counted and are not escalated to OnReadError.
+//   - OnMessage ignores responses received on a previous connection.
+func (suite *SessionTrackerUnitTestSuite) TestReconnectRace() {
+	readErrors := []error{}
+	client := newKrakenSpotWebsocketClient(nil, nil, func(ctx context.Context, restart, exit context.CancelFunc, err error) {
+		readErrors = append(readErrors, err)
+	}, nil, nil, nil)
+	// First connection
+	client.sessions.open()
+	pong := []byte(`{"event":"pong","reqid":42}`)
+	pr := &pendingPing{session: client.sessions.current(), result: NewFuture[*messages.Pong]()}
+	client.requests.pendingPing[42] = pr
+	require.NoError(suite.T(), client.handlePong(context.Background(), nil, nil, nil, nil, "s1", 0, pong))
+	require.True(suite.T(), isResolved(pr.result))
+	// Reconnect and send a request with a colliding request ID
+	client.sessions.open()
+	aor := &pendingAddOrderRequest{session: client.sessions.current(), result: NewFuture[*messages.AddOrderResponse]()}
+	client.requests.pendingAddOrderRequests[7] = aor
+	status := []byte(`{"event":"addOrderStatus","reqid":7,"status":"ok","txid":"OXYZ"}`)
+	require.NoError(suite.T(), client.handleAddOrderStatus(context.Background(), nil, nil, nil, nil, "s1", 0, status))
+	require.False(suite.T(), isResolved(aor.result))
+	require.Contains(suite.T(), client.requests.pendingAddOrderRequests, int64(7))
+	require.Equal(suite.T(), uint64(1), client.GetStaleResponsesCount())
+	// OnMessage drops responses from the previous connection
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "s1", 0, status)
+	require.False(suite.T(), isResolved(aor.result))
+	require.Equal(suite.T(), uint64(2), client.GetStaleResponsesCount())
+	// Response on the current connection fulfils the request
+	client.OnMessage(context.Background(), nil, nil, nil, nil, "s2", 0, status)
+	require.True(suite.T(), isResolved(aor.result))
+	require.NotContains(suite.T(), client.requests.pendingAddOrderRequests, int64(7))
+	// Orphaned request sent during the first session is not fulfilled by the current connection
+	orphan := &pendingPing{session: 1, result: NewFuture[*messages.Pong]()}
+	client.requests.pendingPing[42] = orphan
+	require.NoError(suite.T(), client.handlePong(context.Background(), nil, nil, nil, nil, "s2", 0, pong))
+	require.False(suite.T(), isResolved(orphan.result))
+	require.Equal(suite.T(), uint64(3), client.GetStaleResponsesCount())
+	require.Empty(suite.T(), readErrors)
+}
@@ -0,0 +1,122 @@
+// Package storage provides writers which persist trades, spreads and candles received from
+// websocket subscriptions into SQLite or TimescaleDB/PostgreSQL databases.
+//
+// The package only depends on database/sql: the application opens the *sql.DB with the driver of
+// its choice (ex: modernc.org/sqlite, github.com/jackc/pgx/v5/stdlib) and creates the tables with
+// CreateSchema or with the schemas shipped with the package (Cf. Schema).
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Schemas shipped with the package
+//
+//go:embed schema/*.sql
+var schemas embed.FS
+
+// Enum for the supported SQL dialects.
+type DialectEnum string
+
+// Values for DialectEnum
+const (
+	// SQLite
+	SQLite DialectEnum = "sqlite"
+	// PostgreSQL
+	PostgreSQL DialectEnum = "postgres"
+	// TimescaleDB. Same tables as PostgreSQL, converted to hypertables.
+	TimescaleDB DialectEnum = "timescaledb"
+)
+
+// # Description
+//
+// Get the schema shipped with the package for the provided dialect. The schema creates the
+// trades, spreads and candles tables (and the related indexes and hypertables) if they do not
+// exist.
+//
+// # Inputs
+//
+//   - dialect: SQL dialect.
+//
+// # Return
+//
+// The SQL statements of the schema or an error if the dialect is not supported.
+func Schema(dialect DialectEnum) (string, error) {
+	switch dialect {
+	case SQLite, PostgreSQL, TimescaleDB:
+	default:
+		return "", fmt.Errorf("unsupported dialect: %q", dialect)
+	}
+	schema, err := schemas.ReadFile("schema/" + string(dialect) + ".sql")
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s schema: %w", dialect, err)
+	}
+	return string(schema), nil
+}
+
+// # Description
+//
+// Create the tables of the provided dialect if they do not exist. The statements of the schema
+// are executed one by one as some drivers do not support multiple statements per call.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - db: Database handle.
+//   - dialect: SQL dialect.
+//
+// # Return
+//
+// An error if the dialect is not supported or if a statement fails.
+func CreateSchema(ctx context.Context, db *sql.DB, dialect DialectEnum) error {
+	schema, err := Schema(dialect)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range splitStatements(schema) {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create %s schema: %w", dialect, err)
+		}
+	}
+	return nil
+}
+
+// Split a SQL script into statements. Comment lines are removed.
+func splitStatements(script string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(script, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+	stmts := []string{}
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+// Build the insert statement of a table for the provided dialect. If conflict is not empty, it
+// is appended to the statement.
+func insertStatement(dialect DialectEnum, table string, columns []string, conflict string) string {
+	placeholders := make([]string, len(columns))
+	for i := range columns {
+		if dialect == SQLite {
+			placeholders[i] = "?"
+		} else {
+			placeholders[i] = "$" + strconv.Itoa(i+1)
+		}
+	}
+	stmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	if conflict != "" {
+		stmt += " " + conflict
+	}
+	return stmt
+}
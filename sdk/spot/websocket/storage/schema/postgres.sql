@@ -0,0 +1,34 @@
+-- Schema of the market data tables for PostgreSQL.
+CREATE TABLE IF NOT EXISTS trades (
+    pair TEXT NOT NULL,
+    time TIMESTAMPTZ NOT NULL,
+    price NUMERIC NOT NULL,
+    volume NUMERIC NOT NULL,
+    side TEXT NOT NULL,
+    order_type TEXT NOT NULL,
+    misc TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS trades_pair_time_idx ON trades (pair, time DESC);
+CREATE TABLE IF NOT EXISTS spreads (
+    pair TEXT NOT NULL,
+    time TIMESTAMPTZ NOT NULL,
+    bid NUMERIC NOT NULL,
+    ask NUMERIC NOT NULL,
+    bid_volume NUMERIC NOT NULL,
+    ask_volume NUMERIC NOT NULL
+);
+CREATE INDEX IF NOT EXISTS spreads_pair_time_idx ON spreads (pair, time DESC);
+CREATE TABLE IF NOT EXISTS candles (
+    pair TEXT NOT NULL,
+    interval_minutes INTEGER NOT NULL,
+    end_time TIMESTAMPTZ NOT NULL,
+    time TIMESTAMPTZ NOT NULL,
+    open NUMERIC NOT NULL,
+    high NUMERIC NOT NULL,
+    low NUMERIC NOT NULL,
+    close NUMERIC NOT NULL,
+    vwap NUMERIC NOT NULL,
+    volume NUMERIC NOT NULL,
+    count BIGINT NOT NULL,
+    PRIMARY KEY (pair, interval_minutes, end_time)
+);
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the schemas
+type SchemaUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestSchemaUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(SchemaUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the schemas shipped with the package.
+//
+// Test will ensure:
+//   - A schema is shipped for each dialect and unsupported dialects are rejected.
+//   - TimescaleDB tables are converted to hypertables.
+//   - CreateSchema executes the statements one by one, without comments.
+func (suite *SchemaUnitTestSuite) TestSchema() {
+	for _, dialect := range []DialectEnum{SQLite, PostgreSQL, TimescaleDB} {
+		schema, err := Schema(dialect)
+		require.NoError(suite.T(), err)
+		require.Contains(suite.T(), schema, "CREATE TABLE IF NOT EXISTS candles")
+	}
+	_, err := Schema("oracle")
+	require.Error(suite.T(), err)
+	schema, err := Schema(TimescaleDB)
+	require.NoError(suite.T(), err)
+	require.Contains(suite.T(), schema, "create_hypertable('trades'")
+	// Create schema
+	recorder := &recordingDriver{}
+	db := sql.OpenDB(recorder)
+	defer db.Close()
+	require.NoError(suite.T(), CreateSchema(context.Background(), db, SQLite))
+	execs := recorder.execs()
+	require.Len(suite.T(), execs, 5)
+	require.Contains(suite.T(), execs[0].query, "CREATE TABLE IF NOT EXISTS trades (")
+	require.NotContains(suite.T(), execs[0].query, "--")
+	require.Error(suite.T(), CreateSchema(context.Background(), db, "oracle"))
+}
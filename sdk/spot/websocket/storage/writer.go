@@ -0,0 +1,479 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// Default number of pending rows which triggers a flush.
+const DefaultBatchSize = 500
+
+// Default maximum time rows stay pending before being flushed by Run.
+const DefaultFlushInterval = time.Second
+
+// Default number of failed flushes after which a row is inserted on its own and dropped if it
+// still fails.
+const DefaultMaxRetries = 3
+
+// Columns of the trades table
+var tradeColumns = []string{"pair", "time", "price", "volume", "side", "order_type", "misc"}
+
+// Columns of the spreads table
+var spreadColumns = []string{"pair", "time", "bid", "ask", "bid_volume", "ask_volume"}
+
+// Columns of the candles table
+var candleColumns = []string{"pair", "interval_minutes", "end_time", "time", "open", "high", "low", "close", "vwap", "volume", "count"}
+
+// Conflict clause used to update candles which are already stored: ohlc messages are published
+// each time a candle is updated.
+const candleConflict = "ON CONFLICT (pair, interval_minutes, end_time) DO UPDATE SET " +
+	"time = excluded.time, open = excluded.open, high = excluded.high, low = excluded.low, " +
+	"close = excluded.close, vwap = excluded.vwap, volume = excluded.volume, count = excluded.count"
+
+// Options of a Writer.
+type WriterOptions struct {
+	// Number of pending rows which triggers a flush. DefaultBatchSize is used if not strictly
+	// positive.
+	BatchSize int
+	// Maximum time rows stay pending before being flushed by Run. DefaultFlushInterval is used if
+	// not strictly positive.
+	FlushInterval time.Duration
+	// Number of failed flushes after which a row is inserted on its own and dropped if it still
+	// fails. DefaultMaxRetries is used if not strictly positive.
+	MaxRetries int
+	// Optional callback called with each dropped row, the name of its table and the error which
+	// occurred while inserting it. Can be used to dead-letter the rows. It must not block.
+	OnDroppedRow func(table string, row []any, err error)
+}
+
+// Statistics of a Writer.
+type WriterStats struct {
+	// Number of trades written
+	Trades int64
+	// Number of spreads written
+	Spreads int64
+	// Number of candle updates written
+	Candles int64
+	// Number of successful flushes
+	Flushes int64
+	// Number of rows dropped because they could not be inserted after MaxRetries flushes
+	DroppedRows int64
+	// Number of pending rows
+	Pending int
+	// Last error which occurred while writing or flushing. Nil if none.
+	LastError error
+}
+
+// # Description
+//
+// Writer which persists trades, spreads and candles from the trade, spread and ohlc events
+// published by the websocket client. Other events are ignored.
+//
+// Rows are buffered and written in batches: all pending rows are inserted within a single
+// transaction when the batch size is reached, when the flush interval elapses (Cf. Run) or when
+// Flush is called. Candles are upserted as the server publishes each update of a candle.
+//
+// Rows of a failed flush are kept and retried with the next flush. Once a row has been part of
+// MaxRetries failed flushes, it is inserted on its own: if it still fails, it is dropped, counted
+// (Cf. WriterStats.DroppedRows) and handed to the OnDroppedRow callback. A row which cannot be
+// inserted does not block the other rows and the pending rows do not grow without bound while
+// the database is unavailable.
+//
+// The writer is safe for concurrent use.
+type Writer struct {
+	// Database handle
+	db *sql.DB
+	// SQL dialect
+	dialect DialectEnum
+	// Number of pending rows which triggers a flush
+	batchSize int
+	// Maximum time rows stay pending before being flushed by Run
+	flushInterval time.Duration
+	// Number of failed flushes after which a row is inserted on its own
+	maxRetries int
+	// Optional callback called with each dropped row
+	onDroppedRow func(table string, row []any, err error)
+	// Insert statements by table
+	tradeStmt, spreadStmt, candleStmt string
+	// Logger used to publish debug/verbose logs
+	logger *log.Logger
+	// Clock used to schedule flushes
+	clock clock.Clock
+	// Mutex used to protect pending rows and statistics
+	mu sync.Mutex
+	// Mutex used to serialize flushes
+	flushMu sync.Mutex
+	// Pending rows by table
+	trades, spreads, candles []pendingRow
+	// Statistics
+	stats WriterStats
+}
+
+// Row waiting to be inserted.
+type pendingRow struct {
+	// Values of the columns
+	values []any
+	// Number of failed flushes the row has been part of
+	attempts int
+}
+
+// # Description
+//
+// Build a new writer. The tables must have been created beforehand (Cf. CreateSchema).
+//
+// # Inputs
+//
+//   - db: Database handle opened with a driver compatible with the dialect.
+//   - dialect: SQL dialect.
+//   - opts: Writer options. Can be nil to use defaults.
+//   - logger: Optional logger used to publish debug/verbose logs. Can be nil.
+//
+// # Return
+//
+// The new writer or an error if db is nil or the dialect is not supported.
+func NewWriter(db *sql.DB, dialect DialectEnum, opts *WriterOptions, logger *log.Logger) (*Writer, error) {
+	if db == nil {
+		return nil, fmt.Errorf("a database handle must be provided")
+	}
+	if _, err := Schema(dialect); err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &WriterOptions{}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	flushInterval := opts.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = DefaultFlushInterval
+	}
+	maxRetries := opts.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = DefaultMaxRetries
+	}
+	if logger == nil {
+		logger = log.New(io.Discard, "", log.Flags())
+	}
+	return &Writer{
+		db:            db,
+		dialect:       dialect,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		maxRetries:    maxRetries,
+		onDroppedRow:  opts.OnDroppedRow,
+		tradeStmt:     insertStatement(dialect, "trades", tradeColumns, ""),
+		spreadStmt:    insertStatement(dialect, "spreads", spreadColumns, ""),
+		candleStmt:    insertStatement(dialect, "candles", candleColumns, candleConflict),
+		logger:        logger,
+		clock:         clock.NewSystemClock(),
+	}, nil
+}
+
+// Set the clock used to schedule flushes. This can be used to provide a clock.FakeClock in tests.
+// Must be called before Run. If nil, the system clock is used.
+func (w *Writer) SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.NewSystemClock()
+	}
+	w.clock = c
+}
+
+// Get the writer statistics.
+func (w *Writer) Stats() WriterStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stats := w.stats
+	stats.Pending = len(w.trades) + len(w.spreads) + len(w.candles)
+	return stats
+}
+
+// # Description
+//
+// Buffer the rows of a trade, spread or ohlc event. Other events are ignored. Pending rows are
+// flushed if the batch size is reached.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - e: Event published by the websocket client.
+//
+// # Return
+//
+// An error if the event data cannot be parsed or if the triggered flush fails.
+func (w *Writer) Write(ctx context.Context, e event.Event) error {
+	rows, table, err := parseRows(e)
+	if err != nil {
+		w.recordError(err)
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	pending := make([]pendingRow, 0, len(rows))
+	for _, row := range rows {
+		pending = append(pending, pendingRow{values: row})
+	}
+	w.mu.Lock()
+	switch table {
+	case "trades":
+		w.trades = append(w.trades, pending...)
+	case "spreads":
+		w.spreads = append(w.spreads, pending...)
+	default:
+		w.candles = append(w.candles, pending...)
+	}
+	full := len(w.trades)+len(w.spreads)+len(w.candles) >= w.batchSize
+	w.mu.Unlock()
+	if full {
+		return w.Flush(ctx)
+	}
+	return nil
+}
+
+// # Description
+//
+// Insert all pending rows within a single transaction. In case of error, the transaction is
+// rolled back and the rows are kept pending so they are retried with the next flush. Rows which
+// have been part of MaxRetries failed flushes are then inserted on their own and dropped if they
+// still fail (Cf. Writer).
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//
+// # Return
+//
+// An error if the rows could not be inserted.
+func (w *Writer) Flush(ctx context.Context) error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+	w.mu.Lock()
+	trades, spreads, candles := w.trades, w.spreads, w.candles
+	w.trades, w.spreads, w.candles = nil, nil, nil
+	w.mu.Unlock()
+	if len(trades)+len(spreads)+len(candles) == 0 {
+		return nil
+	}
+	err := w.insert(ctx, values(trades), values(spreads), values(candles))
+	if err != nil {
+		// Isolate the rows which have exhausted their retries
+		trades = w.retry(ctx, "trades", trades)
+		spreads = w.retry(ctx, "spreads", spreads)
+		candles = w.retry(ctx, "candles", candles)
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		// Keep rows pending, before the rows buffered during the flush
+		w.trades = append(trades, w.trades...)
+		w.spreads = append(spreads, w.spreads...)
+		w.candles = append(candles, w.candles...)
+		err = fmt.Errorf("flush failed: %w", err)
+		w.stats.LastError = err
+		w.logger.Println(err.Error())
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stats.Trades += int64(len(trades))
+	w.stats.Spreads += int64(len(spreads))
+	w.stats.Candles += int64(len(candles))
+	w.stats.Flushes++
+	return nil
+}
+
+// Record a failed flush for the provided rows of a table and get the rows to keep pending. Rows
+// which have been part of MaxRetries failed flushes are inserted on their own: they are dropped
+// if they still fail.
+func (w *Writer) retry(ctx context.Context, table string, rows []pendingRow) []pendingRow {
+	kept := make([]pendingRow, 0, len(rows))
+	for _, row := range rows {
+		row.attempts++
+		if row.attempts < w.maxRetries {
+			kept = append(kept, row)
+			continue
+		}
+		var err error
+		switch table {
+		case "trades":
+			err = w.insert(ctx, [][]any{row.values}, nil, nil)
+		case "spreads":
+			err = w.insert(ctx, nil, [][]any{row.values}, nil)
+		default:
+			err = w.insert(ctx, nil, nil, [][]any{row.values})
+		}
+		w.mu.Lock()
+		if err == nil {
+			switch table {
+			case "trades":
+				w.stats.Trades++
+			case "spreads":
+				w.stats.Spreads++
+			default:
+				w.stats.Candles++
+			}
+			w.mu.Unlock()
+			continue
+		}
+		w.stats.DroppedRows++
+		w.mu.Unlock()
+		w.logger.Println("row dropped after", row.attempts, "failed flushes:", table, err.Error())
+		if w.onDroppedRow != nil {
+			w.onDroppedRow(table, row.values, err)
+		}
+	}
+	return kept
+}
+
+// Get the values of the provided rows.
+func values(rows []pendingRow) [][]any {
+	values := make([][]any, 0, len(rows))
+	for _, row := range rows {
+		values = append(values, row.values)
+	}
+	return values
+}
+
+// # Description
+//
+// Persist the events received on the provided channel until the channel is closed or the context
+// is cancelled. Pending rows are flushed when the flush interval elapses and a last time before
+// returning, even if the context has been cancelled, so no data is lost on shutdown.
+//
+// Errors which occur while writing events are logged and recorded in the statistics (Cf. Stats):
+// they do not stop the writer.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose. Cancel it to stop the writer.
+//   - in: Channel of events published by the websocket client.
+//
+// # Return
+//
+// An error if the last flush failed.
+func (w *Writer) Run(ctx context.Context, in <-chan event.Event) error {
+	timer := w.clock.NewTimer(w.flushInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return w.Flush(context.WithoutCancel(ctx))
+		case e, ok := <-in:
+			if !ok {
+				return w.Flush(ctx)
+			}
+			if err := w.Write(ctx, e); err != nil {
+				w.logger.Println("failed to write event:", err.Error())
+			}
+		case <-timer.C():
+			w.Flush(ctx)
+			timer.Reset(w.flushInterval)
+		}
+	}
+}
+
+// Insert rows within a single transaction.
+func (w *Writer) insert(ctx context.Context, trades [][]any, spreads [][]any, candles [][]any) error {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	for _, batch := range []struct {
+		stmt string
+		rows [][]any
+	}{{w.tradeStmt, trades}, {w.spreadStmt, spreads}, {w.candleStmt, candles}} {
+		if err := execRows(ctx, tx, batch.stmt, batch.rows); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Execute a prepared statement for each row.
+func execRows(ctx context.Context, tx *sql.Tx, query string, rows [][]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return fmt.Errorf("failed to insert row: %w", err)
+		}
+	}
+	return nil
+}
+
+// Record an error in the statistics.
+func (w *Writer) recordError(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stats.LastError = err
+}
+
+// Parse the rows carried by a trade, spread or ohlc event and the name of the related table. No
+// row is returned for other events.
+func parseRows(e event.Event) ([][]any, string, error) {
+	switch e.Type() {
+	case string(events.Trade):
+		msg := new(messages.Trade)
+		if err := json.Unmarshal(e.Data(), msg); err != nil {
+			return nil, "", fmt.Errorf("failed to parse trade event: %w", err)
+		}
+		rows := make([][]any, 0, len(msg.Data))
+		for _, trade := range msg.Data {
+			entry, err := trade.Entry()
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to parse trade event: %w", err)
+			}
+			rows = append(rows, []any{msg.Pair, entry.Time.UTC(), trade.Price.String(), trade.Volume.String(), string(entry.Side), string(entry.OrderType), entry.Miscellaneous})
+		}
+		return rows, "trades", nil
+	case string(events.Spread):
+		msg := new(messages.Spread)
+		if err := json.Unmarshal(e.Data(), msg); err != nil {
+			return nil, "", fmt.Errorf("failed to parse spread event: %w", err)
+		}
+		entry, err := msg.Data.Entry()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse spread event: %w", err)
+		}
+		return [][]any{{msg.Pair, entry.Time.UTC(), msg.Data.BestBidPrice.String(), msg.Data.BestAskPrice.String(), msg.Data.BestBidVolume.String(), msg.Data.BestAskVolume.String()}}, "spreads", nil
+	case string(events.OHLC):
+		msg := new(messages.OHLC)
+		if err := json.Unmarshal(e.Data(), msg); err != nil {
+			return nil, "", fmt.Errorf("failed to parse ohlc event: %w", err)
+		}
+		interval, err := strconv.Atoi(strings.TrimPrefix(msg.Name, string(messages.ChannelOHLC)+"-"))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse interval of ohlc event from channel name %s: %w", msg.Name, err)
+		}
+		entry, err := msg.Data.Entry()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse ohlc event: %w", err)
+		}
+		return [][]any{{msg.Pair, interval, entry.End.UTC(), entry.Time.UTC(), msg.Data.Open.String(), msg.Data.High.String(), msg.Data.Low.String(), msg.Data.Close.String(), msg.Data.VolumeAveragePrice.String(), msg.Data.Volume.String(), msg.Data.TradesCount}}, "candles", nil
+	default:
+		return nil, "", nil
+	}
+}
@@ -0,0 +1,296 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for Writer
+type WriterUnitTestSuite struct {
+	suite.Suite
+	// Recording driver
+	recorder *recordingDriver
+	// Database handle which uses the recording driver
+	db *sql.DB
+}
+
+// Run unit test suite
+func TestWriterUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(WriterUnitTestSuite))
+}
+
+// Open a new database handle with a fresh recording driver before each test.
+func (suite *WriterUnitTestSuite) BeforeTest(suiteName, testName string) {
+	suite.recorder = &recordingDriver{}
+	suite.db = sql.OpenDB(suite.recorder)
+}
+
+// Close the database handle after each test.
+func (suite *WriterUnitTestSuite) AfterTest(suiteName, testName string) {
+	suite.db.Close()
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the writer batches rows.
+//
+// Test will ensure:
+//   - Trades, spreads and candles are parsed from the events and other events are ignored.
+//   - Rows are inserted within a single transaction once the batch size is reached.
+//   - Candles are upserted and the dialect placeholders are used.
+func (suite *WriterUnitTestSuite) TestWriteBatch() {
+	writer, err := NewWriter(suite.db, PostgreSQL, &WriterOptions{BatchSize: 4}, nil)
+	require.NoError(suite.T(), err)
+	ctx := context.Background()
+	require.NoError(suite.T(), writer.Write(ctx, suite.newEvent(events.Heartbeat, `{"event":"heartbeat"}`)))
+	require.NoError(suite.T(), writer.Write(ctx, suite.newEvent(events.Trade, `[0,[["5541.20000","0.15850568","1534614057.321597","s","l",""],["6060.00000","0.02455000","1534614057","b","m","x"]],"trade","XBT/USD"]`)))
+	require.NoError(suite.T(), writer.Write(ctx, suite.newEvent(events.Spread, `[0,["5698.40000","5700.00000","1542057299.545897","1.01234567","0.98765432"],"spread","XBT/USD"]`)))
+	require.Equal(suite.T(), 3, writer.Stats().Pending)
+	require.Empty(suite.T(), suite.recorder.execs())
+	require.NoError(suite.T(), writer.Write(ctx, suite.newEvent(events.OHLC, `[42,["1542057314.748456","1542057360.435743","3586.70000","3586.70000","3586.60000","3586.60000","3586.68894","0.03373000",2],"ohlc-5","XBT/USD"]`)))
+	execs := suite.recorder.execs()
+	require.Len(suite.T(), execs, 4)
+	require.Equal(suite.T(), "INSERT INTO trades (pair, time, price, volume, side, order_type, misc) VALUES ($1, $2, $3, $4, $5, $6, $7)", execs[0].query)
+	require.Equal(suite.T(), []driver.Value{"XBT/USD", time.Unix(1534614057, 321597000).UTC(), "5541.20000", "0.15850568", "sell", "limit", ""}, execs[0].args)
+	require.Equal(suite.T(), "6060.00000", execs[1].args[2])
+	require.Equal(suite.T(), "5700.00000", execs[2].args[3])
+	require.Contains(suite.T(), execs[3].query, "ON CONFLICT (pair, interval_minutes, end_time) DO UPDATE")
+	require.Equal(suite.T(), int64(5), execs[3].args[1])
+	require.Equal(suite.T(), int64(2), execs[3].args[10])
+	require.Equal(suite.T(), 1, suite.recorder.commits)
+	stats := writer.Stats()
+	require.Equal(suite.T(), WriterStats{Trades: 2, Spreads: 1, Candles: 1, Flushes: 1}, stats)
+	// Invalid event
+	require.Error(suite.T(), writer.Write(ctx, suite.newEvent(events.OHLC, `[42,["1542057314.748456","1542057360.435743","3586.70000","3586.70000","3586.60000","3586.60000","3586.68894","0.03373000",2],"ohlc-x","XBT/USD"]`)))
+	require.Error(suite.T(), writer.Stats().LastError)
+}
+
+// Test failed flushes.
+//
+// Test will ensure:
+//   - The transaction is rolled back and rows are kept pending.
+//   - Rows are written by the next successful flush.
+//   - Flushing without pending rows does nothing.
+func (suite *WriterUnitTestSuite) TestFlushFailure() {
+	writer, err := NewWriter(suite.db, SQLite, nil, nil)
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), DefaultBatchSize, writer.batchSize)
+	require.NoError(suite.T(), writer.Flush(context.Background()))
+	require.NoError(suite.T(), writer.Write(context.Background(), suite.newEvent(events.Spread, `[0,["5698.40000","5700.00000","1542057299.545897","1.01234567","0.98765432"],"spread","XBT/USD"]`)))
+	suite.recorder.setFailure(errors.New("disk full"))
+	require.ErrorContains(suite.T(), writer.Flush(context.Background()), "disk full")
+	require.Equal(suite.T(), 1, suite.recorder.rollbacks)
+	require.Equal(suite.T(), 1, writer.Stats().Pending)
+	suite.recorder.setFailure(nil)
+	require.NoError(suite.T(), writer.Flush(context.Background()))
+	execs := suite.recorder.execs()
+	require.Equal(suite.T(), "INSERT INTO spreads (pair, time, bid, ask, bid_volume, ask_volume) VALUES (?, ?, ?, ?, ?, ?)", execs[len(execs)-1].query)
+	require.Equal(suite.T(), int64(1), writer.Stats().Spreads)
+	require.Zero(suite.T(), writer.Stats().Pending)
+}
+
+// Test rows which cannot be inserted.
+//
+// Test will ensure:
+//   - Rows are retried until they have been part of MaxRetries failed flushes.
+//   - Rows which have exhausted their retries are inserted on their own.
+//   - Rows which still fail are dropped, counted and handed to the OnDroppedRow callback.
+//   - A dropped row does not prevent the other rows from being written.
+func (suite *WriterUnitTestSuite) TestPoisonRow() {
+	dropped := [][]any{}
+	writer, err := NewWriter(suite.db, SQLite, &WriterOptions{
+		MaxRetries: 2,
+		OnDroppedRow: func(table string, row []any, err error) {
+			require.Equal(suite.T(), "spreads", table)
+			require.ErrorContains(suite.T(), err, "constraint violation")
+			dropped = append(dropped, row)
+		},
+	}, nil)
+	require.NoError(suite.T(), err)
+	suite.recorder.setPoison("BAD/USD")
+	require.NoError(suite.T(), writer.Write(context.Background(), suite.newEvent(events.Spread, `[0,["5698.40000","5700.00000","1542057299.545897","1.01234567","0.98765432"],"spread","BAD/USD"]`)))
+	require.NoError(suite.T(), writer.Write(context.Background(), suite.newEvent(events.Spread, `[0,["5698.40000","5700.00000","1542057299.545897","1.01234567","0.98765432"],"spread","XBT/USD"]`)))
+	require.ErrorContains(suite.T(), writer.Flush(context.Background()), "constraint violation")
+	require.Equal(suite.T(), 2, writer.Stats().Pending)
+	require.Empty(suite.T(), dropped)
+	// Retries exhausted: rows are inserted on their own
+	require.ErrorContains(suite.T(), writer.Flush(context.Background()), "constraint violation")
+	stats := writer.Stats()
+	require.Zero(suite.T(), stats.Pending)
+	require.Equal(suite.T(), int64(1), stats.Spreads)
+	require.Equal(suite.T(), int64(1), stats.DroppedRows)
+	require.Len(suite.T(), dropped, 1)
+	require.Equal(suite.T(), "BAD/USD", dropped[0][0])
+	execs := suite.recorder.execs()
+	require.Len(suite.T(), execs, 1)
+	require.Equal(suite.T(), "XBT/USD", execs[0].args[0])
+}
+
+// Test Run.
+//
+// Test will ensure:
+//   - Pending rows are flushed when the flush interval elapses.
+//   - Pending rows are flushed when the context is cancelled.
+//   - Pending rows are flushed when the input channel is closed.
+func (suite *WriterUnitTestSuite) TestRun() {
+	_, err := NewWriter(nil, SQLite, nil, nil)
+	require.Error(suite.T(), err)
+	_, err = NewWriter(suite.db, "oracle", nil, nil)
+	require.Error(suite.T(), err)
+	writer, err := NewWriter(suite.db, SQLite, &WriterOptions{FlushInterval: time.Second}, nil)
+	require.NoError(suite.T(), err)
+	fake := clock.NewFakeClock(time.Now())
+	writer.SetClock(fake)
+	spread := suite.newEvent(events.Spread, `[0,["5698.40000","5700.00000","1542057299.545897","1.01234567","0.98765432"],"spread","XBT/USD"]`)
+	// Flush interval
+	in := make(chan event.Event)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- writer.Run(ctx, in) }()
+	in <- spread
+	fake.BlockUntil(1)
+	fake.Advance(time.Second)
+	require.Eventually(suite.T(), func() bool { return writer.Stats().Spreads == 1 }, time.Second, time.Millisecond)
+	// Cancellation
+	in <- spread
+	cancel()
+	require.NoError(suite.T(), <-done)
+	require.Equal(suite.T(), int64(2), writer.Stats().Spreads)
+	// Closed channel
+	go func() { done <- writer.Run(context.Background(), in) }()
+	in <- spread
+	close(in)
+	require.NoError(suite.T(), <-done)
+	require.Equal(suite.T(), int64(3), writer.Stats().Spreads)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Build an event with the provided type and data.
+func (suite *WriterUnitTestSuite) newEvent(eventType events.WebsocketClientEventTypeEnum, data string) event.Event {
+	e := event.New()
+	e.SetType(string(eventType))
+	require.NoError(suite.T(), e.SetData("application/json", []byte(data)))
+	return e
+}
+
+// A statement executed through the recording driver
+type recordedExec struct {
+	// Query
+	query string
+	// Arguments
+	args []driver.Value
+}
+
+// Minimal database/sql driver which records the executed statements. Statements executed within
+// a rolled back transaction are discarded.
+type recordingDriver struct {
+	// Mutex used to protect records
+	mu sync.Mutex
+	// Committed statements
+	committed []recordedExec
+	// Statements of the current transaction or executed outside a transaction
+	pending []recordedExec
+	// Number of commits
+	commits int
+	// Number of rollbacks
+	rollbacks int
+	// Error returned by Exec. Nil if Exec succeeds.
+	failure error
+	// Exec fails for rows whose first value is poison. Empty if no row fails.
+	poison string
+}
+
+// Get the executed statements which have not been rolled back.
+func (d *recordingDriver) execs() []recordedExec {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append(append([]recordedExec{}, d.committed...), d.pending...)
+}
+
+// Set the first value of the rows for which Exec fails.
+func (d *recordingDriver) setPoison(poison string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.poison = poison
+}
+
+// Set the error returned by Exec.
+func (d *recordingDriver) setFailure(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.failure = err
+}
+
+func (d *recordingDriver) Connect(ctx context.Context) (driver.Conn, error) { return d, nil }
+func (d *recordingDriver) Driver() driver.Driver                            { return d }
+func (d *recordingDriver) Open(name string) (driver.Conn, error)            { return d, nil }
+func (d *recordingDriver) Close() error                                     { return nil }
+func (d *recordingDriver) Begin() (driver.Tx, error)                        { return d, nil }
+func (d *recordingDriver) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{driver: d, query: query}, nil
+}
+
+func (d *recordingDriver) Commit() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.committed = append(d.committed, d.pending...)
+	d.pending = nil
+	d.commits++
+	return nil
+}
+
+func (d *recordingDriver) Rollback() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending = nil
+	d.rollbacks++
+	return nil
+}
+
+// Statement prepared with the recording driver
+type recordingStmt struct {
+	// Driver
+	driver *recordingDriver
+	// Query
+	query string
+}
+
+func (s *recordingStmt) Close() error  { return nil }
+func (s *recordingStmt) NumInput() int { return -1 }
+func (s *recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.driver.mu.Lock()
+	defer s.driver.mu.Unlock()
+	if s.driver.failure != nil {
+		return nil, s.driver.failure
+	}
+	if s.driver.poison != "" && len(args) > 0 && args[0] == s.driver.poison {
+		return nil, errors.New("constraint violation")
+	}
+	s.driver.pending = append(s.driver.pending, recordedExec{query: s.query, args: args})
+	return driver.RowsAffected(1), nil
+}
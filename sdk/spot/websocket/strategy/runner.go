@@ -0,0 +1,436 @@
+// Package strategy provides a Runner which wires up the websocket clients, subscribes to market
+// data and own trades and calls the hooks of a user provided Strategy from a single goroutine.
+package strategy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// Default capacity of the channels used to receive events from the websocket clients.
+const DefaultChannelCapacity = 100
+
+// Timeout used to unsubscribe when the runner stops.
+const unsubscribeTimeout = 10 * time.Second
+
+/*************************************************************************************************/
+/* STRATEGY                                                                                      */
+/*************************************************************************************************/
+
+// A book snapshot or update received for a pair.
+type Book struct {
+	// Pair
+	Pair string
+	// True if the message is a snapshot, false if it is an update.
+	Snapshot bool
+	// Asks snapshot or updates
+	Asks []messages.BookMessageEntry
+	// Bids snapshot or updates
+	Bids []messages.BookMessageEntry
+}
+
+// A trade which has filled one of the user's orders.
+type Fill struct {
+	// Trade ID
+	TradeId string
+	// Trade data
+	Trade messages.OwnTradeData
+}
+
+// # Description
+//
+// Interface for user strategies run by a Runner. All hooks are called from the runner goroutine:
+// they are never called concurrently and the strategy does not need to protect its state.
+//
+// Hooks must not block for long as events are not processed meanwhile. A hook which returns an
+// error stops the runner: Run returns the error.
+//
+// Embed BaseStrategy to only implement the hooks the strategy needs.
+type Strategy interface {
+	// # Description
+	//
+	// Called once when the runner starts, before subscriptions are made.
+	//
+	// # Inputs
+	//
+	//   - ctx: Context bound to the runner lifecycle.
+	//   - trader: Websocket client used to trade. Nil if the runner has no private client.
+	OnStart(ctx context.Context, trader websocket.KrakenSpotPrivateWebsocketClientInterface) error
+	// Called when a ticker message is received.
+	OnTick(ctx context.Context, ticker *messages.Ticker) error
+	// Called when a book snapshot or update is received.
+	OnBook(ctx context.Context, book *Book) error
+	// Called for each trade which fills one of the user's orders.
+	OnFill(ctx context.Context, fill *Fill) error
+	// Called at regular intervals if a timer interval has been configured.
+	OnTimer(ctx context.Context, now time.Time) error
+	// Called once the connection with the server has been interrupted. The websocket clients
+	// resubscribe automatically: market data will resume once the connection is restored.
+	OnConnectionInterrupted(ctx context.Context) error
+	// Called once when the runner stops, after subscriptions have been removed.
+	OnStop(ctx context.Context)
+}
+
+// Strategy with no-op hooks which can be embedded by user strategies.
+type BaseStrategy struct{}
+
+// No-op OnStart hook.
+func (BaseStrategy) OnStart(ctx context.Context, trader websocket.KrakenSpotPrivateWebsocketClientInterface) error {
+	return nil
+}
+
+// No-op OnTick hook.
+func (BaseStrategy) OnTick(ctx context.Context, ticker *messages.Ticker) error { return nil }
+
+// No-op OnBook hook.
+func (BaseStrategy) OnBook(ctx context.Context, book *Book) error { return nil }
+
+// No-op OnFill hook.
+func (BaseStrategy) OnFill(ctx context.Context, fill *Fill) error { return nil }
+
+// No-op OnTimer hook.
+func (BaseStrategy) OnTimer(ctx context.Context, now time.Time) error { return nil }
+
+// No-op OnConnectionInterrupted hook.
+func (BaseStrategy) OnConnectionInterrupted(ctx context.Context) error { return nil }
+
+// No-op OnStop hook.
+func (BaseStrategy) OnStop(ctx context.Context) {}
+
+/*************************************************************************************************/
+/* RUNNER                                                                                        */
+/*************************************************************************************************/
+
+// Configuration of a Runner.
+type RunnerConfig struct {
+	// Pairs to subscribe to (ex: XBT/USD).
+	Pairs []string
+	// True to subscribe to the ticker channel and receive OnTick calls.
+	Ticker bool
+	// Depth of the book subscription. Zero disables the book subscription and OnBook calls.
+	BookDepth messages.DepthEnum
+	// Interval between two OnTimer calls. Zero disables OnTimer calls.
+	TimerInterval time.Duration
+	// Capacity of the channels used to receive events. Zero means DefaultChannelCapacity.
+	ChannelCapacity int
+}
+
+// # Description
+//
+// Runner which subscribes to market data with a public websocket client and to own trades with a
+// private websocket client and which calls the hooks of a Strategy in a single goroutine event
+// loop.
+//
+// Ordering is deterministic: messages of a channel are delivered in the order they are received
+// and, when several events are pending, fills are delivered first, then book messages, then
+// ticker messages and finally timer ticks. This way, a strategy always knows about its fills
+// before it reacts to new market data.
+//
+// Reconnections are handled transparently: the websocket clients resubscribe automatically and
+// OnConnectionInterrupted is called once per interruption. If a client gives up resubscribing
+// (resubscribe_failed event), the runner stops with an error.
+type Runner struct {
+	// Strategy to run
+	strategy Strategy
+	// Public websocket client used for market data. Can be nil if no market data is needed.
+	public websocket.KrakenSpotPublicWebsocketClientInterface
+	// Private websocket client used to trade and receive fills. Can be nil.
+	private websocket.KrakenSpotPrivateWebsocketClientInterface
+	// Runner configuration
+	config RunnerConfig
+	// Logger used to publish debug/verbose logs
+	logger *log.Logger
+	// Clock used to schedule timer ticks
+	clock clock.Clock
+	// Mutex used to protect running flag
+	mu sync.Mutex
+	// True while Run is in progress
+	running bool
+	// True if the connection interruption has already been reported to the strategy
+	interrupted bool
+}
+
+// # Description
+//
+// Build a new Runner.
+//
+// # Inputs
+//
+//   - strategy: Strategy to run.
+//   - public: Public websocket client used for market data. Required if ticker or book is enabled.
+//   - private: Optional private websocket client used to trade and receive fills.
+//   - config: Runner configuration.
+//   - logger: Optional logger used to log debug/vebrose messages. If nil, a logger with a discard
+//     writer (noop) will be used.
+//
+// # Return
+//
+// The new Runner or an error if the inputs are invalid.
+func NewRunner(
+	strategy Strategy,
+	public websocket.KrakenSpotPublicWebsocketClientInterface,
+	private websocket.KrakenSpotPrivateWebsocketClientInterface,
+	config RunnerConfig,
+	logger *log.Logger) (*Runner, error) {
+	if strategy == nil {
+		return nil, fmt.Errorf("a strategy must be provided")
+	}
+	if (config.Ticker || config.BookDepth != 0) && public == nil {
+		return nil, fmt.Errorf("a public websocket client must be provided to receive market data")
+	}
+	if (config.Ticker || config.BookDepth != 0) && len(config.Pairs) == 0 {
+		return nil, fmt.Errorf("pairs must be provided to receive market data")
+	}
+	if config.TimerInterval < 0 {
+		return nil, fmt.Errorf("timer interval must be positive. Got %s", config.TimerInterval)
+	}
+	if config.ChannelCapacity <= 0 {
+		config.ChannelCapacity = DefaultChannelCapacity
+	}
+	if logger == nil {
+		logger = log.New(io.Discard, "", log.Flags())
+	}
+	return &Runner{
+		strategy: strategy,
+		public:   public,
+		private:  private,
+		config:   config,
+		logger:   logger,
+		clock:    clock.NewSystemClock(),
+	}, nil
+}
+
+// Set the clock used to schedule timer ticks. This can be used to provide a clock.FakeClock in
+// tests. Must be called before Run. If nil, the system clock is used.
+func (r *Runner) SetClock(c clock.Clock) {
+	if c == nil {
+		c = clock.NewSystemClock()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clock = c
+}
+
+// # Description
+//
+// Run the strategy until the provided context is cancelled or a hook returns an error. The method
+// blocks: it calls OnStart, subscribes to the configured channels, runs the event loop and, once
+// done, unsubscribes and calls OnStop.
+//
+// # Return
+//
+// nil if the runner has been stopped by cancelling the context. Otherwise, the error which has
+// stopped the runner.
+func (r *Runner) Run(ctx context.Context) error {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return fmt.Errorf("runner is already running")
+	}
+	r.running = true
+	clk := r.clock
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		r.running = false
+		r.mu.Unlock()
+	}()
+	if err := r.strategy.OnStart(ctx, r.private); err != nil {
+		return fmt.Errorf("strategy failed to start: %w", err)
+	}
+	// OnStop must be called even if the context has been cancelled
+	defer r.strategy.OnStop(context.WithoutCancel(ctx))
+	// Subscribe to configured channels
+	subs := &subscriptions{}
+	defer r.unsubscribe(subs)
+	if r.private != nil {
+		subs.fills = make(chan event.Event, r.config.ChannelCapacity)
+		if err := r.private.SubscribeOwnTrades(ctx, false, true, subs.fills); err != nil {
+			return fmt.Errorf("failed to subscribe to own trades: %w", err)
+		}
+		subs.ownTrades = true
+	}
+	if r.config.BookDepth != 0 {
+		subs.books = make(chan event.Event, r.config.ChannelCapacity)
+		if err := r.public.SubscribeBook(ctx, r.config.Pairs, r.config.BookDepth, subs.books); err != nil {
+			return fmt.Errorf("failed to subscribe to book: %w", err)
+		}
+		subs.book = true
+	}
+	if r.config.Ticker {
+		subs.ticks = make(chan event.Event, r.config.ChannelCapacity)
+		if err := r.public.SubscribeTicker(ctx, r.config.Pairs, subs.ticks); err != nil {
+			return fmt.Errorf("failed to subscribe to ticker: %w", err)
+		}
+		subs.ticker = true
+	}
+	var timer clock.Timer
+	var tick <-chan time.Time
+	if r.config.TimerInterval > 0 {
+		timer = clk.NewTimer(r.config.TimerInterval)
+		defer timer.Stop()
+		tick = timer.C()
+	}
+	// Event loop
+	for {
+		// Fills first
+		select {
+		case e, ok := <-subs.fills:
+			if err := r.receive(ctx, e, ok, &subs.fills); err != nil {
+				return err
+			}
+			continue
+		default:
+		}
+		// Then book messages
+		select {
+		case e, ok := <-subs.books:
+			if err := r.receive(ctx, e, ok, &subs.books); err != nil {
+				return err
+			}
+			continue
+		default:
+		}
+		// Wait for the next event
+		var err error
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-subs.fills:
+			err = r.receive(ctx, e, ok, &subs.fills)
+		case e, ok := <-subs.books:
+			err = r.receive(ctx, e, ok, &subs.books)
+		case e, ok := <-subs.ticks:
+			err = r.receive(ctx, e, ok, &subs.ticks)
+		case now := <-tick:
+			timer.Reset(r.config.TimerInterval)
+			if err = r.strategy.OnTimer(ctx, now); err != nil {
+				err = fmt.Errorf("OnTimer failed: %w", err)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Subscriptions made by a runner.
+type subscriptions struct {
+	// Channel used to receive own trades. Nil if not subscribed or closed.
+	fills chan event.Event
+	// Channel used to receive book messages. Nil if not subscribed or closed.
+	books chan event.Event
+	// Channel used to receive ticker messages. Nil if not subscribed or closed.
+	ticks chan event.Event
+	// True if own trades have been subscribed to.
+	ownTrades bool
+	// True if book has been subscribed to.
+	book bool
+	// True if ticker has been subscribed to.
+	ticker bool
+}
+
+// Handle an event received from a channel. If the channel has been closed, it is set to nil so it
+// is not read anymore.
+func (r *Runner) receive(ctx context.Context, e event.Event, ok bool, ch *chan event.Event) error {
+	if !ok {
+		r.logger.Println("subscription channel has been closed")
+		*ch = nil
+		return nil
+	}
+	return r.handle(ctx, e)
+}
+
+// Parse the event and call the matching strategy hook.
+func (r *Runner) handle(ctx context.Context, e event.Event) error {
+	switch e.Type() {
+	case string(events.ConnectionInterrupted):
+		if r.interrupted {
+			// Already reported by another channel
+			return nil
+		}
+		r.interrupted = true
+		if err := r.strategy.OnConnectionInterrupted(ctx); err != nil {
+			return fmt.Errorf("OnConnectionInterrupted failed: %w", err)
+		}
+		return nil
+	case string(events.ResubscribeFailed):
+		return fmt.Errorf("subscription lost: %s", string(e.Data()))
+	}
+	r.interrupted = false
+	switch e.Type() {
+	case string(events.OwnTrades):
+		msg := new(messages.OwnTrades)
+		if err := json.Unmarshal(e.Data(), msg); err != nil {
+			r.logger.Println("failed to parse own trades event:", err.Error())
+			return nil
+		}
+		for _, trades := range msg.Data {
+			for id, trade := range trades {
+				if err := r.strategy.OnFill(ctx, &Fill{TradeId: id, Trade: trade}); err != nil {
+					return fmt.Errorf("OnFill failed: %w", err)
+				}
+			}
+		}
+	case string(events.BookSnapshot):
+		msg := new(messages.BookSnapshot)
+		if err := json.Unmarshal(e.Data(), msg); err != nil {
+			r.logger.Println("failed to parse book snapshot event:", err.Error())
+			return nil
+		}
+		if err := r.strategy.OnBook(ctx, &Book{Pair: msg.Pair, Snapshot: true, Asks: msg.Data.Asks, Bids: msg.Data.Bids}); err != nil {
+			return fmt.Errorf("OnBook failed: %w", err)
+		}
+	case string(events.BookUpdate):
+		msg := new(messages.BookUpdate)
+		if err := json.Unmarshal(e.Data(), msg); err != nil {
+			r.logger.Println("failed to parse book update event:", err.Error())
+			return nil
+		}
+		if err := r.strategy.OnBook(ctx, &Book{Pair: msg.Pair, Asks: msg.Data.Asks, Bids: msg.Data.Bids}); err != nil {
+			return fmt.Errorf("OnBook failed: %w", err)
+		}
+	case string(events.Ticker):
+		msg := new(messages.Ticker)
+		if err := json.Unmarshal(e.Data(), msg); err != nil {
+			r.logger.Println("failed to parse ticker event:", err.Error())
+			return nil
+		}
+		if err := r.strategy.OnTick(ctx, msg); err != nil {
+			return fmt.Errorf("OnTick failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// Remove the subscriptions which have been made. Errors are logged.
+func (r *Runner) unsubscribe(subs *subscriptions) {
+	ctx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
+	defer cancel()
+	if subs.ticker {
+		if err := r.public.UnsubscribeTicker(ctx); err != nil {
+			r.logger.Println("failed to unsubscribe from ticker:", err.Error())
+		}
+	}
+	if subs.book {
+		if err := r.public.UnsubscribeBookDepth(ctx, r.config.BookDepth); err != nil {
+			r.logger.Println("failed to unsubscribe from book:", err.Error())
+		}
+	}
+	if subs.ownTrades {
+		if err := r.private.UnsubscribeOwnTrades(ctx); err != nil {
+			r.logger.Println("failed to unsubscribe from own trades:", err.Error())
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/papertrading"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for Runner
+type RunnerUnitTestSuite struct {
+	suite.Suite
+	// Fake public websocket client
+	public *fakePublicClient
+	// Paper trading engine used as private websocket client
+	engine *papertrading.KrakenSpotPaperTradingClient
+}
+
+// Run unit test suite
+func TestRunnerUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(RunnerUnitTestSuite))
+}
+
+// Build a new fake public client and paper trading engine before each test.
+func (suite *RunnerUnitTestSuite) SetupTest() {
+	suite.public = newFakePublicClient()
+	suite.engine = papertrading.NewKrakenSpotPaperTradingClient(nil, -1, nil)
+	suite.engine.ProcessSpread("XBT/USD", messages.SpreadData{BestBidPrice: "99", BestAskPrice: "101", BestBidVolume: "10", BestAskVolume: "10"})
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test runner inputs validation.
+func (suite *RunnerUnitTestSuite) TestNewRunnerInvalidInputs() {
+	_, err := NewRunner(nil, suite.public, nil, RunnerConfig{}, nil)
+	require.Error(suite.T(), err)
+	_, err = NewRunner(&recordingStrategy{}, nil, nil, RunnerConfig{Pairs: []string{"XBT/USD"}, Ticker: true}, nil)
+	require.Error(suite.T(), err)
+	_, err = NewRunner(&recordingStrategy{}, suite.public, nil, RunnerConfig{BookDepth: messages.D10}, nil)
+	require.Error(suite.T(), err)
+	_, err = NewRunner(&recordingStrategy{}, nil, nil, RunnerConfig{TimerInterval: -time.Second}, nil)
+	require.Error(suite.T(), err)
+	_, err = NewRunner(&recordingStrategy{}, nil, nil, RunnerConfig{TimerInterval: time.Second}, nil)
+	require.NoError(suite.T(), err)
+}
+
+// Test the runner lifecycle.
+//
+// Test will ensure:
+//   - Hooks are called in order: OnStart, then pending book messages before ticker messages.
+//   - The strategy can trade from a hook and receives its fills with OnFill.
+//   - OnConnectionInterrupted is called once per interruption.
+//   - OnTimer is called.
+//   - Subscriptions are removed and OnStop is called when the context is cancelled.
+func (suite *RunnerUnitTestSuite) TestRunnerLifecycle() {
+	suite.public.pending["ticker"] = []event.Event{newTickerEvent("XBT/USD", "100")}
+	suite.public.pending["book"] = []event.Event{newEvent(events.BookSnapshot, `[0,{"as":[["101.0","1.0","1"]],"bs":[["99.0","1.0","1"]]},"book-10","XBT/USD"]`)}
+	strategy := &recordingStrategy{}
+	runner, err := NewRunner(strategy, suite.public, suite.engine, RunnerConfig{
+		Pairs:         []string{"XBT/USD"},
+		Ticker:        true,
+		BookDepth:     messages.D10,
+		TimerInterval: 10 * time.Millisecond,
+	}, nil)
+	require.NoError(suite.T(), err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- runner.Run(ctx) }()
+	// Wait for the order placed on first tick, then fill it with a new spread
+	require.Eventually(suite.T(), func() bool {
+		return strategy.count("tick") == 1
+	}, time.Second, 5*time.Millisecond)
+	suite.engine.ProcessSpread("XBT/USD", messages.SpreadData{BestBidPrice: "99", BestAskPrice: "101", BestBidVolume: "10", BestAskVolume: "10"})
+	require.Eventually(suite.T(), func() bool {
+		return strategy.count("fill") == 1 && strategy.count("timer") > 0
+	}, time.Second, 5*time.Millisecond)
+	// Connection interruption on both channels -> a single call
+	interrupted := event.New()
+	interrupted.SetType(string(events.ConnectionInterrupted))
+	suite.public.publish("book", interrupted)
+	suite.public.publish("ticker", interrupted)
+	suite.public.publish("ticker", newTickerEvent("XBT/USD", "100"))
+	require.Eventually(suite.T(), func() bool {
+		return strategy.count("tick") == 2
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(suite.T(), <-done)
+	calls := strategy.snapshot()
+	require.Equal(suite.T(), []string{"start", "book", "tick", "fill"}, calls[:4])
+	require.Equal(suite.T(), 1, strategy.count("interrupted"))
+	require.Equal(suite.T(), "stop", calls[len(calls)-1])
+	require.Empty(suite.T(), suite.public.subscribed())
+	require.Error(suite.T(), suite.engine.UnsubscribeOwnTrades(context.Background()))
+}
+
+// Test the runner timer is driven by the runner clock.
+//
+// Test will ensure:
+//   - OnTimer is called with the clock time each time the timer interval elapses.
+//   - The timer is rearmed after each call.
+func (suite *RunnerUnitTestSuite) TestRunnerTimer() {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFakeClock(start)
+	strategy := &recordingStrategy{}
+	runner, err := NewRunner(strategy, nil, nil, RunnerConfig{TimerInterval: time.Minute}, nil)
+	require.NoError(suite.T(), err)
+	runner.SetClock(fake)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- runner.Run(ctx) }()
+	for i := 1; i <= 3; i++ {
+		fake.BlockUntil(1)
+		require.Equal(suite.T(), i-1, strategy.count("timer"))
+		fake.Advance(time.Minute)
+		require.Eventually(suite.T(), func() bool {
+			return strategy.count("timer") == i
+		}, time.Second, 5*time.Millisecond)
+	}
+	cancel()
+	require.NoError(suite.T(), <-done)
+}
+
+// Test the runner stops when a hook fails or when a subscription is lost.
+func (suite *RunnerUnitTestSuite) TestRunnerErrors() {
+	// Hook error
+	strategy := &recordingStrategy{failOnTick: true}
+	runner, err := NewRunner(strategy, suite.public, nil, RunnerConfig{Pairs: []string{"XBT/USD"}, Ticker: true}, nil)
+	require.NoError(suite.T(), err)
+	suite.public.pending["ticker"] = []event.Event{newTickerEvent("XBT/USD", "100")}
+	err = runner.Run(context.Background())
+	require.ErrorContains(suite.T(), err, "OnTick failed")
+	require.Empty(suite.T(), suite.public.subscribed())
+	require.Equal(suite.T(), "stop", strategy.snapshot()[len(strategy.snapshot())-1])
+	// Subscription lost
+	failed := event.New()
+	failed.SetType(string(events.ResubscribeFailed))
+	failed.SetData("application/json", []byte(`{"channel":"ticker"}`))
+	suite.public.pending["ticker"] = []event.Event{failed}
+	runner, err = NewRunner(&recordingStrategy{}, suite.public, nil, RunnerConfig{Pairs: []string{"XBT/USD"}, Ticker: true}, nil)
+	require.NoError(suite.T(), err)
+	require.ErrorContains(suite.T(), runner.Run(context.Background()), "subscription lost")
+	// Subscribe error
+	suite.public.err = fmt.Errorf("not connected")
+	runner, err = NewRunner(&recordingStrategy{}, suite.public, nil, RunnerConfig{Pairs: []string{"XBT/USD"}, Ticker: true}, nil)
+	require.NoError(suite.T(), err)
+	require.Error(suite.T(), runner.Run(context.Background()))
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Strategy which records hook calls and places a market order on the first tick.
+type recordingStrategy struct {
+	BaseStrategy
+	// Mutex used to protect calls
+	mu sync.Mutex
+	// Recorded hook calls
+	calls []string
+	// Trading client provided on start
+	trader websocket.KrakenSpotPrivateWebsocketClientInterface
+	// True if OnTick must fail
+	failOnTick bool
+}
+
+func (s *recordingStrategy) record(call string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, call)
+}
+
+func (s *recordingStrategy) count(call string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, c := range s.calls {
+		if c == call {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *recordingStrategy) snapshot() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := []string{}
+	for _, c := range s.calls {
+		// Ignore timer ticks which are not deterministic
+		if c != "timer" {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+func (s *recordingStrategy) OnStart(ctx context.Context, trader websocket.KrakenSpotPrivateWebsocketClientInterface) error {
+	s.record("start")
+	s.trader = trader
+	return nil
+}
+
+func (s *recordingStrategy) OnTick(ctx context.Context, ticker *messages.Ticker) error {
+	// Record the call once the order has been placed
+	defer s.record("tick")
+	if s.failOnTick {
+		return fmt.Errorf("tick failure")
+	}
+	if s.count("tick") == 0 && s.trader != nil {
+		_, err := s.trader.AddOrder(ctx, websocket.AddOrderRequestParameters{
+			OrderType: string(messages.Market), Type: string(messages.Buy), Pair: ticker.Pair, Volume: "1",
+		})
+		return err
+	}
+	return nil
+}
+
+func (s *recordingStrategy) OnBook(ctx context.Context, book *Book) error {
+	s.record("book")
+	return nil
+}
+
+func (s *recordingStrategy) OnFill(ctx context.Context, fill *Fill) error {
+	s.record("fill")
+	return nil
+}
+
+func (s *recordingStrategy) OnTimer(ctx context.Context, now time.Time) error {
+	s.record("timer")
+	return nil
+}
+
+func (s *recordingStrategy) OnConnectionInterrupted(ctx context.Context) error {
+	s.record("interrupted")
+	return nil
+}
+
+func (s *recordingStrategy) OnStop(ctx context.Context) {
+	s.record("stop")
+}
+
+// Build an event with the provided type and raw JSON payload.
+func newEvent(etype events.WebsocketClientEventTypeEnum, payload string) event.Event {
+	e := event.New()
+	e.SetType(string(etype))
+	e.SetData("application/json", []byte(payload))
+	return e
+}
+
+// Build a ticker event.
+func newTickerEvent(pair string, price string) event.Event {
+	payload := fmt.Sprintf(`[0,{"a":["%[2]s",1,"1.000"],"b":["%[2]s",1,"1.000"],"c":["%[2]s","1.0"],"v":["1.0","1.0"],"p":["%[2]s","%[2]s"],"t":[1,1],"l":["%[2]s","%[2]s"],"h":["%[2]s","%[2]s"],"o":["%[2]s","%[2]s"]},"ticker","%[1]s"]`, pair, price)
+	return newEvent(events.Ticker, payload)
+}
+
+// Fake public websocket client which records subscriptions.
+type fakePublicClient struct {
+	websocket.KrakenSpotPublicWebsocketClientInterface
+	// Mutex used to protect the fake client state
+	mu sync.Mutex
+	// Channels by subscription
+	channels map[string]chan event.Event
+	// Events published right after subscribe by subscription
+	pending map[string][]event.Event
+	// Error returned by all methods if set
+	err error
+}
+
+// Build a new fake public client.
+func newFakePublicClient() *fakePublicClient {
+	return &fakePublicClient{channels: map[string]chan event.Event{}, pending: map[string][]event.Event{}}
+}
+
+func (c *fakePublicClient) subscribe(channel string, rcv chan event.Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.channels[channel] = rcv
+	for _, e := range c.pending[channel] {
+		rcv <- e
+	}
+	delete(c.pending, channel)
+	return nil
+}
+
+func (c *fakePublicClient) unsubscribe(channel string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	close(c.channels[channel])
+	delete(c.channels, channel)
+	return nil
+}
+
+func (c *fakePublicClient) publish(channel string, e event.Event) {
+	c.mu.Lock()
+	rcv := c.channels[channel]
+	c.mu.Unlock()
+	rcv <- e
+}
+
+func (c *fakePublicClient) subscribed() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	channels := []string{}
+	for channel := range c.channels {
+		channels = append(channels, channel)
+	}
+	return channels
+}
+
+func (c *fakePublicClient) SubscribeTicker(ctx context.Context, pairs []string, rcv chan event.Event) error {
+	return c.subscribe("ticker", rcv)
+}
+
+func (c *fakePublicClient) SubscribeBook(ctx context.Context, pairs []string, depth messages.DepthEnum, rcv chan event.Event) error {
+	return c.subscribe("book", rcv)
+}
+
+func (c *fakePublicClient) UnsubscribeTicker(ctx context.Context) error {
+	return c.unsubscribe("ticker")
+}
+
+func (c *fakePublicClient) UnsubscribeBook(ctx context.Context) error {
+	return c.unsubscribe("book")
+}
+
+func (c *fakePublicClient) UnsubscribeBookDepth(ctx context.Context, depth messages.DepthEnum) error {
+	return c.unsubscribe("book")
+}
@@ -0,0 +1,364 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+)
+
+// Persisted state of a subscription.
+type SubscriptionState struct {
+	// Subscribed channel
+	Channel messages.ChannelEnum `json:"channel"`
+	// Subscribed pairs. Empty for private channels.
+	Pairs []string `json:"pairs,omitempty"`
+	// Interval of an ohlc subscription
+	Interval messages.IntervalEnum `json:"interval,omitempty"`
+	// Depth of a book subscription
+	Depth messages.DepthEnum `json:"depth,omitempty"`
+	// Snapshot option of an ownTrades subscription
+	Snapshot bool `json:"snapshot,omitempty"`
+	// ConsolidateTaker option of an ownTrades subscription
+	ConsolidateTaker bool `json:"consolidate_taker,omitempty"`
+	// RateCounter option of an openOrders subscription
+	RateCounter bool `json:"rate_counter,omitempty"`
+}
+
+// # Description
+//
+// Interface for stores used to persist the active subscriptions of a websocket client so they can
+// be restored by a freshly started client with ResumeSubscriptions.
+//
+// # Implementation and usage guidelines
+//
+//   - Save must replace the previously saved states.
+//
+//   - Load must return an empty list and no error when no states have been saved.
+//
+//   - Implementations must be safe for concurrent use.
+type SubscriptionStore interface {
+	// Replace the saved subscription states.
+	Save(ctx context.Context, states []SubscriptionState) error
+	// Load the saved subscription states.
+	Load(ctx context.Context) ([]SubscriptionState, error)
+}
+
+/*************************************************************************************************/
+/* FILE STORE                                                                                    */
+/*************************************************************************************************/
+
+// SubscriptionStore which saves subscription states as JSON in a file. The file is replaced
+// atomically on save.
+type FileSubscriptionStore struct {
+	// Path of the file
+	path string
+}
+
+// # Description
+//
+// Build a new FileSubscriptionStore.
+//
+// # Inputs
+//
+//   - path: Path of the file used to save subscription states. The directory must exist.
+//
+// # Return
+//
+// A new FileSubscriptionStore.
+func NewFileSubscriptionStore(path string) *FileSubscriptionStore {
+	return &FileSubscriptionStore{path: path}
+}
+
+// Write the states in a temporary file which then replaces the store file.
+func (s *FileSubscriptionStore) Save(ctx context.Context, states []SubscriptionState) error {
+	payload, err := json.Marshal(states)
+	if err != nil {
+		return fmt.Errorf("failed to encode subscription states: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save subscription states: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(payload); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save subscription states: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save subscription states: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save subscription states: %w", err)
+	}
+	return nil
+}
+
+// Read the states from the store file. An empty list is returned if the file does not exist.
+func (s *FileSubscriptionStore) Load(ctx context.Context) ([]SubscriptionState, error) {
+	payload, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []SubscriptionState{}, nil
+		}
+		return nil, fmt.Errorf("failed to load subscription states: %w", err)
+	}
+	return decodeSubscriptionStates(payload)
+}
+
+/*************************************************************************************************/
+/* REDIS STORE                                                                                   */
+/*************************************************************************************************/
+
+// # Description
+//
+// Minimal Redis client used by RedisSubscriptionStore. Adapt the Redis client of your choice to
+// this interface (ex: wrap go-redis Get and Set commands).
+type RedisClient interface {
+	// Get the value of a key. Found must be false and err nil if the key does not exist.
+	Get(ctx context.Context, key string) (value string, found bool, err error)
+	// Set the value of a key.
+	Set(ctx context.Context, key string, value string) error
+}
+
+// SubscriptionStore which saves subscription states as JSON in a Redis key.
+type RedisSubscriptionStore struct {
+	// Redis client
+	client RedisClient
+	// Key used to save the states
+	key string
+}
+
+// # Description
+//
+// Build a new RedisSubscriptionStore.
+//
+// # Inputs
+//
+//   - client: Redis client.
+//   - key: Redis key used to save subscription states.
+//
+// # Return
+//
+// A new RedisSubscriptionStore.
+func NewRedisSubscriptionStore(client RedisClient, key string) *RedisSubscriptionStore {
+	return &RedisSubscriptionStore{client: client, key: key}
+}
+
+// Set the key with the encoded states.
+func (s *RedisSubscriptionStore) Save(ctx context.Context, states []SubscriptionState) error {
+	payload, err := json.Marshal(states)
+	if err != nil {
+		return fmt.Errorf("failed to encode subscription states: %w", err)
+	}
+	if err := s.client.Set(ctx, s.key, string(payload)); err != nil {
+		return fmt.Errorf("failed to save subscription states: %w", err)
+	}
+	return nil
+}
+
+// Get the states from the key. An empty list is returned if the key does not exist.
+func (s *RedisSubscriptionStore) Load(ctx context.Context) ([]SubscriptionState, error) {
+	value, found, err := s.client.Get(ctx, s.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load subscription states: %w", err)
+	}
+	if !found {
+		return []SubscriptionState{}, nil
+	}
+	return decodeSubscriptionStates([]byte(value))
+}
+
+// Decode JSON encoded subscription states.
+func decodeSubscriptionStates(payload []byte) ([]SubscriptionState, error) {
+	states := []SubscriptionState{}
+	if err := json.Unmarshal(payload, &states); err != nil {
+		return nil, fmt.Errorf("failed to decode subscription states: %w", err)
+	}
+	return states, nil
+}
+
+/*************************************************************************************************/
+/* CLIENT                                                                                        */
+/*************************************************************************************************/
+
+// # Description
+//
+// Set the store used to persist active subscriptions. Once set, the active subscriptions are
+// saved after each subscribe and unsubscribe. Save errors are logged. Subscriptions made with
+// raw callbacks or managed by the client (ex: SubscribeBookTop) are not persisted.
+//
+// # Inputs
+//
+//   - store: Store used to persist subscriptions. Nil disables persistence.
+func (client *krakenSpotWebsocketClient) SetSubscriptionStore(store SubscriptionStore) {
+	client.subscriptionStoreMu.Lock()
+	defer client.subscriptionStoreMu.Unlock()
+	client.subscriptionStore = store
+}
+
+// # Description
+//
+// Get the states of the active subscriptions which can be restored with ResumeSubscriptions.
+// Subscriptions made with raw callbacks or managed by the client (ex: SubscribeBookTop) are not
+// included.
+//
+// # Return
+//
+// The states of the active subscriptions, ordered by channel.
+func (client *krakenSpotWebsocketClient) GetSubscriptionStates() []SubscriptionState {
+	states := []SubscriptionState{}
+	client.tickerSubMu.Lock()
+	if sub := client.subscriptions.ticker; sub != nil && sub.pub != nil {
+		states = append(states, SubscriptionState{Channel: messages.ChannelTicker, Pairs: sub.pairs})
+	}
+	client.tickerSubMu.Unlock()
+	client.ohlcSubMu.Lock()
+	ohlcs := []SubscriptionState{}
+	for interval, sub := range client.subscriptions.ohlcs {
+		if sub != nil && sub.pub != nil && !sub.internal {
+			ohlcs = append(ohlcs, SubscriptionState{Channel: messages.ChannelOHLC, Pairs: sub.pairs, Interval: interval})
+		}
+	}
+	client.ohlcSubMu.Unlock()
+	sort.Slice(ohlcs, func(i, j int) bool { return ohlcs[i].Interval < ohlcs[j].Interval })
+	states = append(states, ohlcs...)
+	client.tradeSubMu.Lock()
+	if sub := client.subscriptions.trade; sub != nil && sub.pub != nil && !sub.internal {
+		states = append(states, SubscriptionState{Channel: messages.ChannelTrade, Pairs: sub.pairs})
+	}
+	client.tradeSubMu.Unlock()
+	client.spreadSubMu.Lock()
+	if sub := client.subscriptions.spread; sub != nil && sub.pub != nil {
+		states = append(states, SubscriptionState{Channel: messages.ChannelSpread, Pairs: sub.pairs})
+	}
+	client.spreadSubMu.Unlock()
+	client.bookSubMu.Lock()
+	books := []SubscriptionState{}
+	for depth, sub := range client.subscriptions.books {
+		if sub.pub != nil && !sub.internal {
+			books = append(books, SubscriptionState{Channel: messages.ChannelBook, Pairs: sub.pairs, Depth: depth})
+		}
+	}
+	sort.Slice(books, func(i, j int) bool { return books[i].Depth < books[j].Depth })
+	states = append(states, books...)
+	client.bookSubMu.Unlock()
+	client.ownTradesSubMu.Lock()
+	if sub := client.subscriptions.ownTrades; sub != nil && sub.pub != nil {
+		states = append(states, SubscriptionState{Channel: messages.ChannelOwnTrades, Snapshot: sub.snapshot, ConsolidateTaker: sub.consolidateTaker})
+	}
+	client.ownTradesSubMu.Unlock()
+	client.openOrdersSubMu.Lock()
+	if sub := client.subscriptions.openOrders; sub != nil && sub.pub != nil {
+		states = append(states, SubscriptionState{Channel: messages.ChannelOpenOrders, RateCounter: sub.rateCounter})
+	}
+	client.openOrdersSubMu.Unlock()
+	return states
+}
+
+// # Description
+//
+// Save the states of the active subscriptions in the provided store.
+//
+// # Inputs
+//
+//   - ctx: Context used for coordination purpose.
+//   - store: Store where subscription states are saved.
+//
+// # Return
+//
+// An error if the states could not be saved.
+func (client *krakenSpotWebsocketClient) SaveSubscriptions(ctx context.Context, store SubscriptionStore) error {
+	return store.Save(ctx, client.GetSubscriptionStates())
+}
+
+// # Description
+//
+// Restore the subscriptions saved in the provided store: the client subscribes to each saved
+// subscription with a channel provided by channelFactory. All saved subscriptions are attempted
+// even if some fail.
+//
+// Automatic saves (Cf. SetSubscriptionStore) are suspended while subscriptions are restored and
+// the active subscriptions are saved once all saved subscriptions have been restored. The store is
+// left untouched if a subscription could not be restored so it can be retried later.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing and coordination purpose.
+//   - store: Store from which subscription states are loaded.
+//   - channelFactory: Function which provides the channel used to publish the events of a
+//     restored subscription. Subscriptions for which nil is returned are not restored.
+//
+// # Return
+//
+// An error if the states could not be loaded or if at least one subscription could not be
+// restored. In the latter case, the error joins the errors of all failed subscriptions.
+func (client *krakenSpotWebsocketClient) ResumeSubscriptions(ctx context.Context, store SubscriptionStore, channelFactory func(state SubscriptionState) chan event.Event) error {
+	states, err := store.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("resume subscriptions failed: %w", err)
+	}
+	client.resumingSubscriptions.Store(true)
+	errs := []error{}
+	for _, state := range states {
+		rcv := channelFactory(state)
+		if rcv == nil {
+			errs = append(errs, fmt.Errorf("no channel provided for %s subscription", state.Channel))
+			continue
+		}
+		if err := client.resumeSubscription(ctx, state, rcv); err != nil {
+			errs = append(errs, fmt.Errorf("resume %s subscription failed: %w", state.Channel, err))
+		}
+	}
+	client.resumingSubscriptions.Store(false)
+	if len(errs) > 0 {
+		return fmt.Errorf("resume subscriptions failed: %w", errors.Join(errs...))
+	}
+	client.persistSubscriptions(ctx)
+	return nil
+}
+
+// Subscribe to the channel of a saved subscription.
+func (client *krakenSpotWebsocketClient) resumeSubscription(ctx context.Context, state SubscriptionState, rcv chan event.Event) error {
+	switch state.Channel {
+	case messages.ChannelTicker:
+		return client.SubscribeTicker(ctx, state.Pairs, rcv)
+	case messages.ChannelOHLC:
+		return client.SubscribeOHLC(ctx, state.Pairs, state.Interval, rcv)
+	case messages.ChannelTrade:
+		return client.SubscribeTrade(ctx, state.Pairs, rcv)
+	case messages.ChannelSpread:
+		return client.SubscribeSpread(ctx, state.Pairs, rcv)
+	case messages.ChannelBook:
+		return client.SubscribeBook(ctx, state.Pairs, state.Depth, rcv)
+	case messages.ChannelOwnTrades:
+		return client.SubscribeOwnTrades(ctx, state.Snapshot, state.ConsolidateTaker, rcv)
+	case messages.ChannelOpenOrders:
+		return client.SubscribeOpenOrders(ctx, state.RateCounter, rcv)
+	default:
+		return fmt.Errorf("unknown channel %q", state.Channel)
+	}
+}
+
+// Save the active subscriptions in the store set with SetSubscriptionStore, if any. Must not be
+// called while a subscription mutex is held.
+func (client *krakenSpotWebsocketClient) persistSubscriptions(ctx context.Context) {
+	if client.resumingSubscriptions.Load() {
+		return
+	}
+	client.subscriptionStoreMu.Lock()
+	defer client.subscriptionStoreMu.Unlock()
+	if client.subscriptionStore == nil {
+		return
+	}
+	// Save even if the context of the subscribe/unsubscribe call has expired
+	if err := client.SaveSubscriptions(context.WithoutCancel(ctx), client.subscriptionStore); err != nil {
+		client.logger.Println("failed to persist subscriptions:", err.Error())
+	}
+}
@@ -0,0 +1,211 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/gowse/wscengine/wsadapters"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for subscription stores and ResumeSubscriptions
+type SubscriptionStoreUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestSubscriptionStoreUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(SubscriptionStoreUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test FileSubscriptionStore.
+//
+// Test will ensure:
+//   - An empty list is loaded when the file does not exist.
+//   - Saved states are loaded back.
+//   - A corrupted file is reported as an error.
+func (suite *SubscriptionStoreUnitTestSuite) TestFileSubscriptionStore() {
+	path := filepath.Join(suite.T().TempDir(), "subscriptions.json")
+	store := NewFileSubscriptionStore(path)
+	states, err := store.Load(context.Background())
+	require.NoError(suite.T(), err)
+	require.Empty(suite.T(), states)
+	expected := []SubscriptionState{
+		{Channel: messages.ChannelOHLC, Pairs: []string{"XBT/USD"}, Interval: messages.M5},
+		{Channel: messages.ChannelOwnTrades, Snapshot: true, ConsolidateTaker: true},
+	}
+	require.NoError(suite.T(), store.Save(context.Background(), expected))
+	states, err = store.Load(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), expected, states)
+	require.NoError(suite.T(), os.WriteFile(path, []byte("{"), 0o600))
+	_, err = store.Load(context.Background())
+	require.Error(suite.T(), err)
+}
+
+// Test RedisSubscriptionStore.
+//
+// Test will ensure:
+//   - An empty list is loaded when the key does not exist.
+//   - States are saved as JSON in the configured key and loaded back.
+//   - Redis errors are returned.
+func (suite *SubscriptionStoreUnitTestSuite) TestRedisSubscriptionStore() {
+	redis := &fakeRedisClient{values: map[string]string{}}
+	store := NewRedisSubscriptionStore(redis, "goctopus:subscriptions")
+	states, err := store.Load(context.Background())
+	require.NoError(suite.T(), err)
+	require.Empty(suite.T(), states)
+	expected := []SubscriptionState{{Channel: messages.ChannelBook, Pairs: []string{"XBT/USD", "ETH/USD"}, Depth: messages.D25}}
+	require.NoError(suite.T(), store.Save(context.Background(), expected))
+	require.JSONEq(suite.T(), `[{"channel":"book","pairs":["XBT/USD","ETH/USD"],"depth":25}]`, redis.values["goctopus:subscriptions"])
+	states, err = store.Load(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), expected, states)
+	redis.err = fmt.Errorf("connection refused")
+	_, err = store.Load(context.Background())
+	require.ErrorIs(suite.T(), err, redis.err)
+	require.ErrorIs(suite.T(), store.Save(context.Background(), expected), redis.err)
+}
+
+// Test GetSubscriptionStates.
+//
+// Test will ensure:
+//   - Active subscriptions are returned with their options, ordered by channel and interval.
+//   - Raw subscriptions and subscriptions managed by the client are not returned.
+func (suite *SubscriptionStoreUnitTestSuite) TestGetSubscriptionStates() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	pub := make(chan event.Event)
+	client.subscriptions.ticker = &tickerSubscription{pairs: []string{"XBT/USD"}, pub: pub}
+	client.subscriptions.ohlcs[messages.M15] = &ohlcSubscription{pairs: []string{"XBT/USD"}, interval: messages.M15, pub: pub}
+	client.subscriptions.ohlcs[messages.M1] = &ohlcSubscription{pairs: []string{"ETH/USD"}, interval: messages.M1, pub: pub}
+	client.subscriptions.trade = &tradeSubscription{pairs: []string{"XBT/USD"}}
+	client.subscriptions.books[messages.D10] = &bookSubscription{pairs: []string{"XBT/USD"}, depth: messages.D10, pub: pub, internal: true}
+	client.subscriptions.books[messages.D100] = &bookSubscription{pairs: []string{"ETH/USD"}, depth: messages.D100, pub: pub}
+	client.subscriptions.openOrders = &openOrdersSubscription{pub: pub, rateCounter: true}
+	require.Equal(suite.T(), []SubscriptionState{
+		{Channel: messages.ChannelTicker, Pairs: []string{"XBT/USD"}},
+		{Channel: messages.ChannelOHLC, Pairs: []string{"ETH/USD"}, Interval: messages.M1},
+		{Channel: messages.ChannelOHLC, Pairs: []string{"XBT/USD"}, Interval: messages.M15},
+		{Channel: messages.ChannelBook, Pairs: []string{"ETH/USD"}, Depth: messages.D100},
+		{Channel: messages.ChannelOpenOrders, RateCounter: true},
+	}, client.GetSubscriptionStates())
+}
+
+// Test ResumeSubscriptions.
+//
+// Test will ensure:
+//   - Saved subscriptions are restored with the channels provided by the factory.
+//   - All saved subscriptions are attempted even if one fails and the failure is reported.
+//   - The store is left untouched when a subscription could not be restored.
+//   - The store is updated after each subscribe once a store has been set.
+func (suite *SubscriptionStoreUnitTestSuite) TestResumeSubscriptions() {
+	client, _ := newSubscriptionStoreTestClient()
+	store := NewFileSubscriptionStore(filepath.Join(suite.T().TempDir(), "subscriptions.json"))
+	saved := []SubscriptionState{
+		{Channel: messages.ChannelTicker, Pairs: []string{"XBT/USD"}},
+		// Fails: no token source to get a websocket token
+		{Channel: messages.ChannelOwnTrades, Snapshot: true},
+		{Channel: messages.ChannelBook, Pairs: []string{"XBT/USD", "ETH/USD"}, Depth: messages.D10},
+	}
+	require.NoError(suite.T(), store.Save(context.Background(), saved))
+	client.SetSubscriptionStore(store)
+	channels := map[messages.ChannelEnum]chan event.Event{}
+	factory := func(state SubscriptionState) chan event.Event {
+		channels[state.Channel] = make(chan event.Event, 10)
+		return channels[state.Channel]
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := client.ResumeSubscriptions(ctx, store, factory)
+	require.Error(suite.T(), err)
+	require.Contains(suite.T(), err.Error(), string(messages.ChannelOwnTrades))
+	require.Len(suite.T(), channels, 3)
+	require.Equal(suite.T(), []SubscriptionState{saved[0], saved[2]}, client.GetSubscriptionStates())
+	require.Equal(suite.T(), channels[messages.ChannelTicker], client.subscriptions.ticker.pub)
+	require.Equal(suite.T(), channels[messages.ChannelBook], client.subscriptions.books[messages.D10].pub)
+	states, err := store.Load(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), saved, states)
+	// Automatic save after subscribe
+	require.NoError(suite.T(), client.SubscribeSpread(ctx, []string{"XBT/USD"}, make(chan event.Event)))
+	states, err = store.Load(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), client.GetSubscriptionStates(), states)
+	require.Len(suite.T(), states, 3)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// In-memory RedisClient used for tests.
+type fakeRedisClient struct {
+	// Mutex used to protect values
+	mu sync.Mutex
+	// Values by key
+	values map[string]string
+	// Error returned by all calls if not nil
+	err error
+}
+
+// Get the value of a key.
+func (c *fakeRedisClient) Get(ctx context.Context, key string) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return "", false, c.err
+	}
+	value, found := c.values[key]
+	return value, found, nil
+}
+
+// Set the value of a key.
+func (c *fakeRedisClient) Set(ctx context.Context, key string, value string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.values[key] = value
+	return nil
+}
+
+// Build a client without token source and with a mocked connection which acknowledges all
+// subscribe requests.
+func newSubscriptionStoreTestClient() (*krakenSpotWebsocketClient, *wsadapters.WebsocketConnectionAdapterInterfaceMock) {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	conn := newConnectionMock()
+	client.conn = conn
+	conn.On("Write", mock.Anything, wsadapters.Text, mock.Anything).Run(func(args mock.Arguments) {
+		req := new(messages.Subscribe)
+		if err := json.Unmarshal(args.Get(2).([]byte), req); err != nil {
+			panic(err)
+		}
+		go func() {
+			for _, pair := range req.Pairs {
+				client.handleSubscriptionStatus(context.Background(), nil, nil, nil, nil, "", 0, []byte(fmt.Sprintf(
+					`{"event":"subscriptionStatus","reqid":%d,"pair":"%s","status":"subscribed","channelName":"%s","subscription":{"name":"%s"}}`,
+					req.ReqId, pair, req.Subscription.Name, req.Subscription.Name)))
+			}
+		}()
+	}).Return(nil)
+	return client, conn
+}
@@ -0,0 +1,226 @@
+//go:build !goctopus_publiconly
+
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Default delay before token expiration at which the background token refresher refreshes the
+// websocket token.
+const DefaultTokenRefreshMargin = 1 * time.Minute
+
+// Delay before the background token refresher retries to refresh the websocket token after a
+// failed attempt.
+const tokenRefreshRetryDelay = 5 * time.Second
+
+// Snapshot of the state of the websocket token managed by the private websocket client.
+type TokenState struct {
+	// True if the client has a cached websocket token which has not expired yet.
+	HasValidToken bool
+	// Time at which the cached websocket token expires. Zero if no token has been fetched yet.
+	ExpiresAt time.Time
+	// Time at which the websocket token was last successfully refreshed. Zero if no token has
+	// been fetched yet.
+	LastRefreshAt time.Time
+	// Error returned by the last refresh attempt. Nil if the last attempt has succeeded.
+	LastError error
+	// Number of times the websocket token has been successfully refreshed.
+	RefreshCount int64
+	// True if the background token refresher is running.
+	RefresherRunning bool
+}
+
+// # Description
+//
+// Start a background token refresher which pre-fetches the websocket token and then refreshes it
+// shortly before it expires. Once started, requests to private endpoints and resubscriptions
+// after a reconnect use the cached token and do not have to wait for a new token to be fetched.
+//
+// The method fetches the first token synchronously so startup errors (bad credentials, ...) are
+// reported to the caller. The refresher then runs until the provided context is cancelled. When a
+// refresh fails, the refresher retries after a short delay and the cached token remains in use
+// until it expires.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing/coordination purpose. The refresher stops when the context is cancelled.
+//   - margin: Delay before token expiration at which the token is refreshed. DefaultTokenRefreshMargin is used if margin is not strictly positive.
+//
+// # Return
+//
+// An error if the refresher is already running or if the first token could not be fetched. In
+// the later case, the refresher is not started.
+func (client *KrakenSpotPrivateWebsocketClient) StartTokenRefresher(ctx context.Context, margin time.Duration) error {
+	// Tracing: Start span
+	ctx, span := client.tracer.Start(ctx, "start_token_refresher", trace.WithSpanKind(trace.SpanKindInternal))
+	defer span.End()
+	if margin <= 0 {
+		margin = DefaultTokenRefreshMargin
+	}
+	// Acquire token mutex
+	client.tokenMu.Lock()
+	defer client.tokenMu.Unlock()
+	if client.tokenRefresherRunning {
+		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("token refresher is already running"))
+	}
+	// Pre-fetch token
+	if err := client.refreshWebsocketTokenLocked(ctx, margin); err != nil {
+		return tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("start token refresher failed: %w", err))
+	}
+	// Start refresher
+	client.tokenRefresherRunning = true
+	go client.runTokenRefresher(ctx, margin)
+	span.SetStatus(codes.Ok, codes.Ok.String())
+	return nil
+}
+
+// # Description
+//
+// Set the provider used to get websocket tokens. A single rest.WebsocketTokenProvider can be
+// shared by several private websocket clients so they reuse the same cached token instead of
+// each requesting their own token with the REST client.
+//
+// The token cached by the client is discarded so the next private request uses the provider.
+//
+// # Inputs
+//
+//   - provider: Provider used to get websocket tokens. If nil, the client fetches its own tokens with the REST client.
+func (client *KrakenSpotPrivateWebsocketClient) SetWebsocketTokenProvider(provider rest.WebsocketTokenProviderIface) {
+	// Acquire token mutex
+	client.tokenMu.Lock()
+	defer client.tokenMu.Unlock()
+	client.tokenSource.provider = provider
+	client.token = ""
+	client.tokenExpiresAt = time.Time{}
+}
+
+// # Description
+//
+// Get a snapshot of the state of the websocket token.
+//
+// # Return
+//
+// The current token state. The token itself is not included.
+func (client *KrakenSpotPrivateWebsocketClient) GetTokenState() TokenState {
+	// Acquire token mutex
+	client.tokenMu.Lock()
+	defer client.tokenMu.Unlock()
+	return TokenState{
+		HasValidToken:    client.token != "" && client.clock.Now().Before(client.tokenExpiresAt),
+		ExpiresAt:        client.tokenExpiresAt,
+		LastRefreshAt:    client.tokenLastRefreshAt,
+		LastError:        client.tokenLastError,
+		RefreshCount:     client.tokenRefreshCount,
+		RefresherRunning: client.tokenRefresherRunning,
+	}
+}
+
+// # Description
+//
+// Get the websocket token used by the client, for example to use it with external tooling. The
+// cached token is returned if it has not expired yet: a new token is fetched otherwise, the same
+// way private requests do, and it is then used by the client.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing/coordination purpose.
+//
+// # Return
+//
+// The token, the time at which it expires or an error if no valid token is cached and a new token
+// could not be fetched.
+func (client *KrakenSpotPrivateWebsocketClient) GetWebsocketToken(ctx context.Context) (string, time.Time, error) {
+	return client.exportWebsocketToken(ctx, "get_websocket_token", false)
+}
+
+// # Description
+//
+// Fetch a new websocket token even if the cached token has not expired yet, cache it so it is
+// used by the client and return it.
+//
+// If a token provider has been set (Cf. SetWebsocketTokenProvider), the provider may return the
+// token it has cached if it has not expired yet.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing/coordination purpose.
+//
+// # Return
+//
+// The new token, the time at which it expires or an error if the token could not be fetched. The
+// cached token remains in use if an error is returned.
+func (client *KrakenSpotPrivateWebsocketClient) RefreshWebsocketToken(ctx context.Context) (string, time.Time, error) {
+	return client.exportWebsocketToken(ctx, "refresh_websocket_token", true)
+}
+
+// Get the cached websocket token or fetch a new one if it has expired or if force is true.
+func (client *KrakenSpotPrivateWebsocketClient) exportWebsocketToken(ctx context.Context, operation string, force bool) (string, time.Time, error) {
+	// Tracing: Start span
+	ctx, span := client.tracer.Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	// Acquire token mutex
+	client.tokenMu.Lock()
+	defer client.tokenMu.Unlock()
+	if force || client.token == "" || !client.clock.Now().Before(client.tokenExpiresAt) {
+		if err := client.refreshWebsocketTokenLocked(ctx, 0); err != nil {
+			return "", time.Time{}, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("%s failed: %w", strings.ReplaceAll(operation, "_", " "), err))
+		}
+	}
+	span.SetStatus(codes.Ok, codes.Ok.String())
+	return client.token, client.tokenExpiresAt, nil
+}
+
+// Background loop which refreshes the websocket token until the provided context is cancelled.
+func (client *KrakenSpotPrivateWebsocketClient) runTokenRefresher(ctx context.Context, margin time.Duration) {
+	defer func() {
+		client.tokenMu.Lock()
+		client.tokenRefresherRunning = false
+		client.tokenMu.Unlock()
+		client.logger.Println("websocket token refresher stopped")
+	}()
+	for {
+		timer := client.clock.NewTimer(client.nextTokenRefreshDelay(margin))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+			client.tokenMu.Lock()
+			err := client.refreshWebsocketTokenLocked(ctx, margin)
+			client.tokenMu.Unlock()
+			if err != nil {
+				client.logger.Printf("background websocket token refresh failed: %s\n", err.Error())
+			}
+		}
+	}
+}
+
+// Compute the delay before the next token refresh.
+func (client *KrakenSpotPrivateWebsocketClient) nextTokenRefreshDelay(margin time.Duration) time.Duration {
+	// Acquire token mutex
+	client.tokenMu.Lock()
+	defer client.tokenMu.Unlock()
+	if client.tokenLastError != nil {
+		// Retry after a short delay if last attempt has failed
+		return tokenRefreshRetryDelay
+	}
+	now := client.clock.Now()
+	delay := client.tokenExpiresAt.Add(-margin).Sub(now)
+	if delay < 0 {
+		// Token lifetime is shorter than the margin: refresh at half the remaining lifetime
+		delay = client.tokenExpiresAt.Sub(now) / 2
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return delay
+}
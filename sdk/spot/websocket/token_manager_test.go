@@ -0,0 +1,260 @@
+//go:build !goctopus_publiconly
+
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/clock"
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the websocket token refresher
+type TokenManagerUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestTokenManagerUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(TokenManagerUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test the token refresher pre-fetches and proactively refreshes the websocket token.
+//
+// Test will ensure:
+//   - The token is fetched when the refresher starts.
+//   - The cached token is used by getWebsocketToken without extra requests.
+//   - The token is refreshed before it expires.
+//   - The refresher cannot be started twice.
+//   - The refresher stops when its context is cancelled.
+func (suite *TokenManagerUnitTestSuite) TestTokenRefresher() {
+	restClient := rest.NewMockKrakenSpotRESTClient()
+	// 6 seconds expiry -> cached for 1 second
+	restClient.On("GetWebsocketToken", mock.Anything, mock.Anything, mock.Anything).
+		Return(rest.NewMockGetWebsocketTokenResponse("first", 6), nil, nil).Once()
+	restClient.On("GetWebsocketToken", mock.Anything, mock.Anything, mock.Anything).
+		Return(rest.NewMockGetWebsocketTokenResponse("second", 900), nil, nil)
+	client, err := NewKrakenSpotPrivateWebsocketClient(restClient, noncegen.NewHFNonceGenerator(), nil, nil, nil, nil, nil, nil)
+	require.NoError(suite.T(), err)
+	// Initial state
+	state := client.GetTokenState()
+	require.False(suite.T(), state.HasValidToken)
+	require.False(suite.T(), state.RefresherRunning)
+	// Start refresher - token is pre-fetched
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(suite.T(), client.StartTokenRefresher(ctx, 500*time.Millisecond))
+	state = client.GetTokenState()
+	require.True(suite.T(), state.HasValidToken)
+	require.True(suite.T(), state.RefresherRunning)
+	require.EqualValues(suite.T(), 1, state.RefreshCount)
+	require.NoError(suite.T(), state.LastError)
+	token, err := client.getWebsocketToken(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "first", token)
+	restClient.AssertNumberOfCalls(suite.T(), "GetWebsocketToken", 1)
+	// Start twice
+	require.Error(suite.T(), client.StartTokenRefresher(ctx, 0))
+	// Token is refreshed in background before it expires
+	require.Eventually(suite.T(), func() bool {
+		return client.GetTokenState().RefreshCount == 2
+	}, 2*time.Second, 10*time.Millisecond)
+	token, err = client.getWebsocketToken(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "second", token)
+	// Stop refresher
+	cancel()
+	require.Eventually(suite.T(), func() bool {
+		return !client.GetTokenState().RefresherRunning
+	}, time.Second, 10*time.Millisecond)
+}
+
+// Test the token refresher schedules refreshes with the clock set with SetClock.
+//
+// Test will ensure:
+//   - The token is not refreshed before the refresh margin is reached.
+//   - The token is refreshed once the clock reaches the refresh margin.
+//   - The token state uses the clock to tell whether the token is valid.
+func (suite *TokenManagerUnitTestSuite) TestTokenRefresherWithClock() {
+	restClient := rest.NewMockKrakenSpotRESTClient()
+	// 65 seconds expiry -> cached for 60 seconds
+	restClient.On("GetWebsocketToken", mock.Anything, mock.Anything, mock.Anything).
+		Return(rest.NewMockGetWebsocketTokenResponse("first", 65), nil, nil).Once()
+	restClient.On("GetWebsocketToken", mock.Anything, mock.Anything, mock.Anything).
+		Return(rest.NewMockGetWebsocketTokenResponse("second", 65), nil, nil)
+	client, err := NewKrakenSpotPrivateWebsocketClient(restClient, noncegen.NewHFNonceGenerator(), nil, nil, nil, nil, nil, nil)
+	require.NoError(suite.T(), err)
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFakeClock(start)
+	client.SetClock(fake)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(suite.T(), client.StartTokenRefresher(ctx, 10*time.Second))
+	require.Equal(suite.T(), start.Add(60*time.Second), client.GetTokenState().ExpiresAt)
+	// Wait for the refresher to wait on its timer
+	fake.BlockUntil(1)
+	fake.Advance(49 * time.Second)
+	require.EqualValues(suite.T(), 1, client.GetTokenState().RefreshCount)
+	fake.Advance(time.Second)
+	require.Eventually(suite.T(), func() bool {
+		return client.GetTokenState().RefreshCount == 2
+	}, time.Second, time.Millisecond)
+	state := client.GetTokenState()
+	require.Equal(suite.T(), start.Add(110*time.Second), state.ExpiresAt)
+	require.True(suite.T(), state.HasValidToken)
+	// Token expires with the clock
+	cancel()
+	require.Eventually(suite.T(), func() bool {
+		return !client.GetTokenState().RefresherRunning
+	}, time.Second, time.Millisecond)
+	fake.Advance(time.Minute)
+	require.False(suite.T(), client.GetTokenState().HasValidToken)
+}
+
+// Test the token refresher does not start when the first token cannot be fetched.
+func (suite *TokenManagerUnitTestSuite) TestTokenRefresherStartError() {
+	restClient := rest.NewMockKrakenSpotRESTClient()
+	restClient.On("GetWebsocketToken", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, nil, fmt.Errorf("connection refused"))
+	client, err := NewKrakenSpotPrivateWebsocketClient(restClient, noncegen.NewHFNonceGenerator(), nil, nil, nil, nil, nil, nil)
+	require.NoError(suite.T(), err)
+	require.Error(suite.T(), client.StartTokenRefresher(context.Background(), 0))
+	state := client.GetTokenState()
+	require.False(suite.T(), state.HasValidToken)
+	require.False(suite.T(), state.RefresherRunning)
+	require.Error(suite.T(), state.LastError)
+	require.Zero(suite.T(), state.RefreshCount)
+}
+
+// Test getWebsocketToken refreshes the token only once it has expired.
+func (suite *TokenManagerUnitTestSuite) TestGetWebsocketTokenCaching() {
+	restClient := rest.NewMockKrakenSpotRESTClient()
+	restClient.On("GetWebsocketToken", mock.Anything, mock.Anything, mock.Anything).
+		Return(rest.NewMockGetWebsocketTokenResponse("token", 900), nil, nil)
+	client, err := NewKrakenSpotPrivateWebsocketClient(restClient, noncegen.NewHFNonceGenerator(), nil, nil, nil, nil, nil, nil)
+	require.NoError(suite.T(), err)
+	for i := 0; i < 3; i++ {
+		token, err := client.getWebsocketToken(context.Background())
+		require.NoError(suite.T(), err)
+		require.Equal(suite.T(), "token", token)
+	}
+	restClient.AssertNumberOfCalls(suite.T(), "GetWebsocketToken", 1)
+	// Expire cached token
+	client.tokenMu.Lock()
+	client.tokenExpiresAt = time.Now().Add(-time.Second)
+	client.tokenMu.Unlock()
+	_, err = client.getWebsocketToken(context.Background())
+	require.NoError(suite.T(), err)
+	restClient.AssertNumberOfCalls(suite.T(), "GetWebsocketToken", 2)
+}
+
+// Test the websocket token can be exported with GetWebsocketToken and RefreshWebsocketToken.
+//
+// Test will ensure:
+//   - GetWebsocketToken returns the cached token and its expiry without extra requests.
+//   - RefreshWebsocketToken fetches a new token which is then used by the client.
+//   - The cached token remains in use when a forced refresh fails.
+func (suite *TokenManagerUnitTestSuite) TestExportWebsocketToken() {
+	restClient := rest.NewMockKrakenSpotRESTClient()
+	restClient.On("GetWebsocketToken", mock.Anything, mock.Anything, mock.Anything).
+		Return(rest.NewMockGetWebsocketTokenResponse("first", 900), nil, nil).Once()
+	restClient.On("GetWebsocketToken", mock.Anything, mock.Anything, mock.Anything).
+		Return(rest.NewMockGetWebsocketTokenResponse("second", 900), nil, nil).Once()
+	restClient.On("GetWebsocketToken", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, nil, fmt.Errorf("connection refused"))
+	client, err := NewKrakenSpotPrivateWebsocketClient(restClient, noncegen.NewHFNonceGenerator(), nil, nil, nil, nil, nil, nil)
+	require.NoError(suite.T(), err)
+	fake := clock.NewFakeClock(time.Now())
+	client.SetClock(fake)
+	// Get token twice: a single request is sent
+	for i := 0; i < 2; i++ {
+		token, expiresAt, err := client.GetWebsocketToken(context.Background())
+		require.NoError(suite.T(), err)
+		require.Equal(suite.T(), "first", token)
+		require.Equal(suite.T(), client.GetTokenState().ExpiresAt, expiresAt)
+		require.True(suite.T(), expiresAt.After(fake.Now()))
+	}
+	restClient.AssertNumberOfCalls(suite.T(), "GetWebsocketToken", 1)
+	// Forced refresh
+	token, _, err := client.RefreshWebsocketToken(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "second", token)
+	token, err = client.getWebsocketToken(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "second", token)
+	// Failed forced refresh
+	_, _, err = client.RefreshWebsocketToken(context.Background())
+	require.ErrorContains(suite.T(), err, "refresh websocket token failed")
+	token, _, err = client.GetWebsocketToken(context.Background())
+	require.NoError(suite.T(), err)
+	require.Equal(suite.T(), "second", token)
+	restClient.AssertNumberOfCalls(suite.T(), "GetWebsocketToken", 3)
+}
+
+// Test private websocket clients can share a websocket token provider.
+//
+// Test will ensure:
+//   - Clients which share a provider reuse the same token with a single REST call.
+//   - The refresher requests a token from the provider with the refresh margin.
+//   - Provider errors are reported as OperationError.
+func (suite *TokenManagerUnitTestSuite) TestSharedTokenProvider() {
+	restClient := rest.NewMockKrakenSpotRESTClient()
+	restClient.On("GetWebsocketToken", mock.Anything, mock.Anything, mock.Anything).
+		Return(rest.NewMockGetWebsocketTokenResponse("shared", 900), nil, nil)
+	provider, err := rest.NewWebsocketTokenProvider(restClient, noncegen.NewHFNonceGenerator(), nil)
+	require.NoError(suite.T(), err)
+	clients := []*KrakenSpotPrivateWebsocketClient{}
+	for i := 0; i < 3; i++ {
+		client, err := NewKrakenSpotPrivateWebsocketClient(restClient, noncegen.NewHFNonceGenerator(), nil, nil, nil, nil, nil, nil)
+		require.NoError(suite.T(), err)
+		client.SetWebsocketTokenProvider(provider)
+		clients = append(clients, client)
+	}
+	for _, client := range clients {
+		token, err := client.getWebsocketToken(context.Background())
+		require.NoError(suite.T(), err)
+		require.Equal(suite.T(), "shared", token)
+	}
+	restClient.AssertNumberOfCalls(suite.T(), "GetWebsocketToken", 1)
+	// Refresher margin longer than token lifetime forces the provider to fetch a new token
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(suite.T(), clients[0].StartTokenRefresher(ctx, time.Hour))
+	restClient.AssertNumberOfCalls(suite.T(), "GetWebsocketToken", 2)
+	cancel()
+	// Provider errors
+	failing := new(failingTokenProvider)
+	clients[1].SetWebsocketTokenProvider(failing)
+	_, err = clients[1].getWebsocketToken(context.Background())
+	require.Error(suite.T(), err)
+	operr := new(OperationError)
+	require.ErrorAs(suite.T(), err, &operr)
+	require.Error(suite.T(), clients[1].GetTokenState().LastError)
+}
+
+/*************************************************************************************************/
+/* UTILITIES                                                                                     */
+/*************************************************************************************************/
+
+// Token provider which always fails.
+type failingTokenProvider struct{}
+
+// Return an error.
+func (p *failingTokenProvider) GetWebsocketToken(ctx context.Context, minValidity time.Duration) (string, time.Time, error) {
+	return "", time.Time{}, fmt.Errorf("provider failure")
+}
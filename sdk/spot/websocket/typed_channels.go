@@ -0,0 +1,120 @@
+package websocket
+
+import (
+	"fmt"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/tracing"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Typed channels used to deliver the messages received from the server as decoded structs,
+// without CloudEvents envelope (Cf. SetTypedChannels). Nil channels keep the default delivery.
+type TypedChannels struct {
+	// Channel used to deliver ticker messages
+	Ticker chan *messages.Ticker
+	// Channel used to deliver ohlc messages of all intervals
+	OHLC chan *messages.OHLC
+	// Channel used to deliver trade messages
+	Trade chan *messages.Trade
+	// Channel used to deliver spread messages
+	Spread chan *messages.Spread
+	// Channel used to deliver book snapshots of all depths
+	BookSnapshot chan *messages.BookSnapshot
+	// Channel used to deliver book updates of all depths
+	BookUpdate chan *messages.BookUpdate
+	// Channel used to deliver ownTrades messages
+	OwnTrades chan *messages.OwnTrades
+	// Channel used to deliver openOrders messages
+	OpenOrders chan *messages.OpenOrders
+}
+
+// # Description
+//
+// Turn off the CloudEvents envelope for in-process consumers: the messages of the subscriptions
+// are decoded (Cf. SetJSONCodec about the codec used) and the structs are written to the
+// provided typed channels instead of being wrapped into events. Message types without typed
+// channel keep the default delivery.
+//
+// The default delivery builds an event for each message, injects the tracing context into it,
+// publishes it on the event bus (Cf. SubscribeTopic) and writes it to the subscription channel.
+// Consumers then decode the event data. Typed channels skip all of this:
+//   - Less work and fewer allocations end to end: no event is built and the payload is decoded
+//     once (Cf. BenchmarkOnMessageTradeTyped and BenchmarkOnMessageTradeEventDecoded). However,
+//     decoding moves from the consumers to the goroutine which reads messages from the server,
+//     which spends more time on each message.
+//   - The tracing context is not propagated to consumers: their processing cannot be related to
+//     the span of the received message.
+//   - Messages are not published on the event bus: topic subscribers (ex: freshness health
+//     checks) do not receive them.
+//   - Dispatch queues, congestion policies and drain on unsubscribe do not apply: messages are
+//     written with blocking writes, like the default delivery does.
+//   - A message which cannot be decoded is discarded and the error is traced and logged.
+//
+// Subscriptions are still made with the subscribe methods: the channels provided on subscribe
+// only receive connection_interrupted events and are closed on unsubscribe like before, so they
+// must still be consumed. Typed channels are shared by all subscriptions of the same message type
+// and are never closed by the client.
+//
+// Subscriptions whose channel is consumed by the client itself (SubscribeManagedBook,
+// SubscribeBookAnalytics, SubscribeBookTop, SubscribeTradeBatched, SubscribeBookBatched and
+// SubscribeOHLCMulti) always keep the default delivery: their messages are not written to the
+// typed channels.
+//
+// Keep the default delivery if the consumers run in another process or need distributed tracing.
+//
+// # Inputs
+//
+//   - channels: Typed channels to use. If nil, the CloudEvents envelope is used for all message
+//     types (default). The channels are used for the messages handled after the call.
+func (client *krakenSpotWebsocketClient) SetTypedChannels(channels *TypedChannels) {
+	if channels == nil {
+		client.typedChannels.Store(nil)
+		return
+	}
+	// Copy channels so they cannot be modified once set
+	copied := *channels
+	client.typedChannels.Store(&copied)
+}
+
+// Typed channels used when none are set: all message types use the CloudEvents envelope.
+var noTypedChannels = &TypedChannels{}
+
+// Get the typed channels set with SetTypedChannels. Never nil.
+func (client *krakenSpotWebsocketClient) getTypedChannels() *TypedChannels {
+	if channels := client.typedChannels.Load(); channels != nil {
+		return channels
+	}
+	return noTypedChannels
+}
+
+// # Description
+//
+// Decode a message and write it to the provided typed channel with a blocking write.
+//
+// # Inputs
+//
+//   - client: Client whose codec is used to decode the message.
+//   - span: Span of the message handler.
+//   - typed: Typed channel. If nil, the message is not handled.
+//   - internal: Whether the subscription channel is consumed by the client (Cf.
+//     closeOnUnsubscribe). Messages of internal subscriptions are not handled.
+//   - msg: Raw message received from the server.
+//
+// # Return
+//
+// True if the message has been handled because a typed channel is set and an error if the message
+// could not be decoded.
+func deliverTyped[T any](client *krakenSpotWebsocketClient, span trace.Span, typed chan *T, internal bool, msg []byte) (bool, error) {
+	if typed == nil || internal {
+		return false, nil
+	}
+	decoded := new(T)
+	if err := client.codec.Unmarshal(msg, decoded); err != nil {
+		return true, tracing.HandleAndTraLogError(span, client.logger, fmt.Errorf("failed to decode message for typed channel: %w", err))
+	}
+	typed <- decoded
+	span.SetStatus(codes.Ok, codes.Ok.String())
+	return true, nil
+}
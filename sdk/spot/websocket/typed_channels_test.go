@@ -0,0 +1,183 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudevents/sdk-go/v2/event"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/fixtures"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/events"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/trace"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for typed channels
+type TypedChannelsUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestTypedChannelsUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(TypedChannelsUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test messages are delivered on typed channels.
+//
+// Test will ensure:
+//   - Messages are decoded and written to the typed channel of their type.
+//   - No event is written to the subscription channel nor published on the event bus.
+//   - Message types without typed channel keep the CloudEvents envelope.
+//   - The CloudEvents envelope is used again once typed channels are removed.
+func (suite *TypedChannelsUnitTestSuite) TestTypedChannels() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	tradePub := make(chan event.Event, 10)
+	spreadPub := make(chan event.Event, 10)
+	bookPub := make(chan event.Event, 10)
+	client.subscriptions.trade = &tradeSubscription{pairs: []string{"XBT/USD"}, pub: tradePub}
+	client.subscriptions.spread = &spreadSubscription{pairs: []string{"XBT/USD"}, pub: spreadPub}
+	client.subscriptions.books = map[messages.DepthEnum]*bookSubscription{
+		messages.D10: {pairs: []string{"XBT/USD"}, depth: messages.D10, pub: bookPub},
+	}
+	topic, err := client.SubscribeTopic(">", 10)
+	require.NoError(suite.T(), err)
+	defer topic.Unsubscribe()
+	typed := &TypedChannels{
+		Trade:        make(chan *messages.Trade, 10),
+		BookSnapshot: make(chan *messages.BookSnapshot, 10),
+		BookUpdate:   make(chan *messages.BookUpdate, 10),
+	}
+	client.SetTypedChannels(typed)
+	// Changes made to the provided struct are ignored
+	typed.Trade = nil
+	ctx := context.Background()
+	for _, msg := range []string{fixtures.WebsocketTrade, fixtures.WebsocketBookSnapshot, fixtures.WebsocketBookUpdate, fixtures.WebsocketSpread} {
+		client.OnMessage(ctx, nil, nil, nil, nil, "", 0, []byte(msg))
+	}
+	trade := <-client.getTypedChannels().Trade
+	require.Equal(suite.T(), "XBT/USD", trade.Pair)
+	require.NotEmpty(suite.T(), trade.Data)
+	require.Equal(suite.T(), "XBT/USD", (<-typed.BookSnapshot).Pair)
+	require.NotNil(suite.T(), <-typed.BookUpdate)
+	require.Empty(suite.T(), tradePub)
+	require.Empty(suite.T(), bookPub)
+	// Spread has no typed channel
+	require.Len(suite.T(), spreadPub, 1)
+	require.Equal(suite.T(), string(events.Spread), (<-spreadPub).Type())
+	require.Equal(suite.T(), string(events.Spread), (<-topic.C).Type())
+	require.Empty(suite.T(), topic.C)
+	// Back to the CloudEvents envelope
+	client.SetTypedChannels(nil)
+	client.OnMessage(ctx, nil, nil, nil, nil, "", 0, []byte(fixtures.WebsocketTrade))
+	require.Equal(suite.T(), string(events.Trade), (<-tradePub).Type())
+}
+
+// Test typed channels are not used for the subscriptions consumed by the client.
+//
+// Test will ensure:
+//   - Book messages of a managed book subscription are delivered to the managed book.
+//   - Typed channels do not receive the messages of internal subscriptions.
+func (suite *TypedChannelsUnitTestSuite) TestManagedBookWithTypedChannels() {
+	client := newSubscribingClient(suite.T())
+	typed := &TypedChannels{
+		BookSnapshot: make(chan *messages.BookSnapshot, 10),
+		BookUpdate:   make(chan *messages.BookUpdate, 10),
+	}
+	client.SetTypedChannels(typed)
+	out := make(chan event.Event, 10)
+	ctx := context.Background()
+	require.NoError(suite.T(), client.SubscribeManagedBook(ctx, []string{"XBT/USD"}, messages.D10, out))
+	require.NoError(suite.T(), client.handleBookSnapshot(ctx, nil, nil, nil, nil, "s1", 0, "XBT/USD", []byte(fixtures.WebsocketBookSnapshot), messages.D10))
+	select {
+	case e := <-out:
+		require.Equal(suite.T(), string(events.BookSnapshot), e.Type())
+	case <-time.After(time.Second):
+		suite.FailNow("book snapshot has not been delivered to the managed book")
+	}
+	require.Empty(suite.T(), typed.BookSnapshot)
+	require.Empty(suite.T(), typed.BookUpdate)
+	require.NoError(suite.T(), client.UnsubscribeBook(ctx))
+}
+
+// Test a message which cannot be decoded is discarded.
+func (suite *TypedChannelsUnitTestSuite) TestDecodeError() {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	typed := make(chan *messages.Trade, 1)
+	handled, err := deliverTyped(client, trace.SpanFromContext(context.Background()), typed, false, []byte(`{"not":"a trade"}`))
+	require.True(suite.T(), handled)
+	require.Error(suite.T(), err)
+	require.Empty(suite.T(), typed)
+	handled, err = deliverTyped[messages.Trade](client, trace.SpanFromContext(context.Background()), nil, false, []byte(fixtures.WebsocketTrade))
+	require.False(suite.T(), handled)
+	require.NoError(suite.T(), err)
+	// Internal subscription
+	handled, err = deliverTyped(client, trace.SpanFromContext(context.Background()), typed, true, []byte(fixtures.WebsocketTrade))
+	require.False(suite.T(), handled)
+	require.NoError(suite.T(), err)
+	require.Empty(suite.T(), typed)
+}
+
+/*************************************************************************************************/
+/* BENCHMARKS                                                                                    */
+/*************************************************************************************************/
+
+// Benchmark dispatch of trade messages through a typed channel. Compare with
+// BenchmarkOnMessageTradeEventDecoded, which also includes decoding by the consumer.
+func BenchmarkOnMessageTradeTyped(b *testing.B) {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	client.subscriptions.trade = &tradeSubscription{pairs: []string{"XBT/USD"}, pub: make(chan event.Event, 1)}
+	typed := make(chan *messages.Trade, 100)
+	client.SetTypedChannels(&TypedChannels{Trade: typed})
+	done := make(chan struct{})
+	go func() {
+		for range typed {
+		}
+		close(done)
+	}()
+	msg := []byte(fixtures.WebsocketTrade)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		client.OnMessage(context.Background(), nil, nil, nil, nil, "", 0, msg)
+	}
+	b.StopTimer()
+	close(typed)
+	<-done
+}
+
+// Benchmark dispatch of trade messages through a channel subscription, the consumer decoding
+// the event data like consumers of the CloudEvents envelope do.
+func BenchmarkOnMessageTradeEventDecoded(b *testing.B) {
+	client := newKrakenSpotWebsocketClient(nil, nil, nil, nil, nil, nil)
+	pub := make(chan event.Event, 100)
+	client.subscriptions.trade = &tradeSubscription{pairs: []string{"XBT/USD"}, pub: pub}
+	done := make(chan struct{})
+	go func() {
+		for e := range pub {
+			trade := new(messages.Trade)
+			if err := e.DataAs(trade); err != nil {
+				panic(err)
+			}
+		}
+		close(done)
+	}()
+	msg := []byte(fixtures.WebsocketTrade)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		client.OnMessage(context.Background(), nil, nil, nil, nil, "", 0, msg)
+	}
+	b.StopTimer()
+	close(pub)
+	<-done
+}
@@ -0,0 +1,96 @@
+package websocket
+
+import (
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Enum for the behaviors of the client when data are received for a channel without active
+// subscription. This is common right after an unsubscribe as the server can still send data which
+// were in flight. Such data are always discarded and are never considered as read errors.
+type UnsubscribedDataPolicyEnum string
+
+// Values for UnsubscribedDataPolicyEnum
+const (
+	// Discard data silently.
+	UnsubscribedDataDrop UnsubscribedDataPolicyEnum = "drop"
+	// Discard data and log a message. This is the default behavior.
+	UnsubscribedDataLog UnsubscribedDataPolicyEnum = "log"
+	// Discard data and call the callback set with SetOnUnsubscribedDataCallback.
+	UnsubscribedDataCallback UnsubscribedDataPolicyEnum = "callback"
+)
+
+// # Description
+//
+// Set the behavior of the client when data are received for a channel without active
+// subscription. Whatever the policy, data are discarded, counted (Cf. GetUnsubscribedDataCount)
+// and recorded as span events.
+//
+// # Inputs
+//
+//   - policy: Behavior of the client. An empty value resets the default behavior (UnsubscribedDataLog).
+func (client *krakenSpotWebsocketClient) SetUnsubscribedDataPolicy(policy UnsubscribedDataPolicyEnum) {
+	if policy == "" {
+		policy = UnsubscribedDataLog
+	}
+	client.onUnsubscribedDataMu.Lock()
+	defer client.onUnsubscribedDataMu.Unlock()
+	client.unsubscribedDataPolicy = policy
+}
+
+// # Description
+//
+// Set the callback which is called each time data are received for a channel without active
+// subscription when the UnsubscribedDataCallback policy is used. A nil value removes the callback.
+//
+// The callback is called from the goroutine which processes messages from the server while the
+// subscription of the channel is locked: it must not block and must not subscribe or unsubscribe.
+//
+// # Inputs
+//
+//   - callback: Callback which receives the channel, the pair (empty for private channels) and
+//     the discarded message.
+func (client *krakenSpotWebsocketClient) SetOnUnsubscribedDataCallback(callback func(channel messages.ChannelEnum, pair string, msg []byte)) {
+	client.onUnsubscribedDataMu.Lock()
+	defer client.onUnsubscribedDataMu.Unlock()
+	client.onUnsubscribedDataCallback = callback
+}
+
+// # Description
+//
+// Get the total number of messages discarded because they have been received for a channel
+// without active subscription.
+//
+// # Return
+//
+// The total number of discarded messages.
+func (client *krakenSpotWebsocketClient) GetUnsubscribedDataCount() uint64 {
+	return client.unsubscribedData.Load()
+}
+
+// Discard data received for a channel without active subscription according to the configured
+// policy. The span of the message handler is marked as successful.
+func (client *krakenSpotWebsocketClient) discardUnsubscribedData(span trace.Span, channel messages.ChannelEnum, pair string, msg []byte) error {
+	count := client.unsubscribedData.Add(1)
+	span.AddEvent("unsubscribed_data", trace.WithAttributes(
+		attribute.String("channel", string(channel)),
+		attribute.String("pair", pair),
+	))
+	span.SetStatus(codes.Ok, codes.Ok.String())
+	client.onUnsubscribedDataMu.Lock()
+	policy := client.unsubscribedDataPolicy
+	callback := client.onUnsubscribedDataCallback
+	client.onUnsubscribedDataMu.Unlock()
+	switch policy {
+	case UnsubscribedDataDrop:
+	case UnsubscribedDataCallback:
+		if callback != nil {
+			callback(channel, pair, msg)
+		}
+	default:
+		client.logger.Printf("discarding a %s message received while there is no active subscription (pair: %s, total: %d)\n", channel, pair, count)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gbdevw/purple-goctopus/sdk/spot/websocket/messages"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+/*************************************************************************************************/
+/* UNIT TEST SUITE                                                                               */
+/*************************************************************************************************/
+
+// Unit test suite for the handling of data received for channels without active subscription
+type UnsubscribedDataUnitTestSuite struct {
+	suite.Suite
+}
+
+// Run unit test suite
+func TestUnsubscribedDataUnitTestSuite(t *testing.T) {
+	suite.Run(t, new(UnsubscribedDataUnitTestSuite))
+}
+
+/*************************************************************************************************/
+/* UNIT TESTS                                                                                    */
+/*************************************************************************************************/
+
+// Test data received for channels without active subscription.
+//
+// Test will ensure:
+//   - Data are discarded without error and without calling OnReadError by default.
+//   - Data for an OHLC interval without active subscription are discarded.
+//   - The callback is only called when the UnsubscribedDataCallback policy is used.
+//   - Discarded messages are counted whatever the policy.
+func (suite *UnsubscribedDataUnitTestSuite) TestUnsubscribedData() {
+	readErrors := []error{}
+	client := newKrakenSpotWebsocketClient(nil, nil, func(ctx context.Context, restart, exit context.CancelFunc, err error) {
+		readErrors = append(readErrors, err)
+	}, nil, nil, nil)
+	type discarded struct {
+		channel messages.ChannelEnum
+		pair    string
+	}
+	calls := []discarded{}
+	client.SetOnUnsubscribedDataCallback(func(channel messages.ChannelEnum, pair string, msg []byte) {
+		calls = append(calls, discarded{channel, pair})
+	})
+	ticker := []byte(`[340,{"a":["5525.40000",1,"1.000"]},"ticker","XBT/USD"]`)
+	// Default policy: log
+	require.NoError(suite.T(), client.handleTicker(context.Background(), nil, nil, nil, nil, "", 0, "XBT/USD", ticker))
+	require.NoError(suite.T(), client.handleOHLC(context.Background(), nil, nil, nil, nil, "", 0, "XBT/USD", []byte(`[42,["1542057314.748456"],"ohlc-5","XBT/USD"]`), messages.M5))
+	require.Empty(suite.T(), calls)
+	// Drop
+	client.SetUnsubscribedDataPolicy(UnsubscribedDataDrop)
+	require.NoError(suite.T(), client.handleOwnTrades(context.Background(), nil, nil, nil, nil, "", 0, []byte(`[[],"ownTrades",{"sequence":1}]`)))
+	require.Empty(suite.T(), calls)
+	// Callback
+	client.SetUnsubscribedDataPolicy(UnsubscribedDataCallback)
+	require.NoError(suite.T(), client.handleTicker(context.Background(), nil, nil, nil, nil, "", 0, "XBT/USD", ticker))
+	require.NoError(suite.T(), client.handleOpenOrders(context.Background(), nil, nil, nil, nil, "", 0, []byte(`[[],"openOrders",{"sequence":1}]`)))
+	require.Equal(suite.T(), []discarded{{messages.ChannelTicker, "XBT/USD"}, {messages.ChannelOpenOrders, ""}}, calls)
+	// Reset the default policy
+	client.SetUnsubscribedDataPolicy("")
+	require.Equal(suite.T(), UnsubscribedDataLog, client.unsubscribedDataPolicy)
+	require.Equal(suite.T(), uint64(5), client.GetUnsubscribedDataCount())
+	require.Empty(suite.T(), readErrors)
+}
@@ -0,0 +1,76 @@
+//go:build !goctopus_publiconly
+
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gbdevw/purple-goctopus/sdk/noncegen"
+	"github.com/gbdevw/purple-goctopus/sdk/spot/rest"
+	restcommon "github.com/gbdevw/purple-goctopus/sdk/spot/rest/common"
+)
+
+// Source of the websocket tokens used by the private websocket client: tokens are requested with
+// the REST client or from a shared token provider if one has been set.
+//
+// The source is only available when the module is built without the goctopus_publiconly build
+// tag: public-only builds do not include the REST client and the request signing code.
+type websocketTokenSource struct {
+	// Kraken REST client used to get websocket tokens
+	restClient rest.KrakenSpotRESTClientIface
+	// User provided nonce generator used to generate nonces used when GetWebsocketToken is called
+	cgen noncegen.NonceGenerator
+	// User provided security options used when GetWebsocketToken is called
+	secopts *restcommon.SecurityOptions
+	// Optional shared provider used to get websocket tokens instead of the REST client
+	provider rest.WebsocketTokenProviderIface
+}
+
+// Build a new websocketTokenSource which uses the provided REST client.
+func newWebsocketTokenSource(restClient rest.KrakenSpotRESTClientIface, cgen noncegen.NonceGenerator, secopts *restcommon.SecurityOptions) *websocketTokenSource {
+	return &websocketTokenSource{
+		restClient: restClient,
+		cgen:       cgen,
+		secopts:    secopts,
+		provider:   nil,
+	}
+}
+
+// # Description
+//
+// Get a new websocket token. The token is requested from the token provider if one has been set,
+// otherwise with the REST client. Token mutex must be held by the caller.
+//
+// # Inputs
+//
+//   - ctx: Context used for tracing/coordination purpose
+//   - logger: Logger used to log debug/verbose messages.
+//   - now: Current time used to compute the token expiration time.
+//   - minValidity: Minimum remaining validity of the token returned by the token provider. Not used without token provider.
+//
+// # Return
+//
+// The token, its expiration time or an error if the token could not be fetched.
+func (s *websocketTokenSource) getWebsocketToken(ctx context.Context, logger *log.Logger, now time.Time, minValidity time.Duration) (string, time.Time, error) {
+	if s.provider != nil {
+		// Get token from provider
+		token, expiresAt, err := s.provider.GetWebsocketToken(ctx, minValidity)
+		if err != nil {
+			return "", time.Time{}, &OperationError{Operation: "get_websocket_token", Root: err}
+		}
+		return token, expiresAt, nil
+	}
+	logger.Println("requesting new websocket token")
+	resp, _, err := s.restClient.GetWebsocketToken(ctx, s.cgen.GenerateNonce(), s.secopts)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("get websocket token failed: %w", err)
+	}
+	if len(resp.Error) > 0 || resp.Result == nil {
+		return "", time.Time{}, &OperationError{Operation: "get_websocket_token", Root: fmt.Errorf("get websocket token failed: %v", resp.Error)}
+	}
+	// Set expire (substract 5 seconds to be sure to refresh the token before it really expire)
+	return resp.Result.Token, now.Add(time.Duration(resp.Result.Expires-5) * time.Second), nil
+}
@@ -0,0 +1,24 @@
+//go:build goctopus_publiconly
+
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Error returned when private endpoints are used in public-only builds (goctopus_publiconly build
+// tag).
+var errPublicOnlyBuild = fmt.Errorf("private endpoints are not available in builds using the goctopus_publiconly build tag")
+
+// Placeholder for the source of websocket tokens in public-only builds (goctopus_publiconly build
+// tag). The REST client and the request signing code are not included in these builds: private
+// endpoints cannot be used.
+type websocketTokenSource struct{}
+
+// Always return an error: websocket tokens cannot be fetched in public-only builds.
+func (s *websocketTokenSource) getWebsocketToken(ctx context.Context, logger *log.Logger, now time.Time, minValidity time.Duration) (string, time.Time, error) {
+	return "", time.Time{}, &OperationError{Operation: "get_websocket_token", Root: errPublicOnlyBuild}
+}